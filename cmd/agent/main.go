@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/conductor/conductor/internal/agent"
+	"github.com/conductor/conductor/pkg/diagnostics"
+	conductorlog "github.com/conductor/conductor/pkg/log"
 	"github.com/conductor/conductor/pkg/metrics"
 	"github.com/conductor/conductor/pkg/tracing"
 	"github.com/rs/zerolog"
@@ -30,7 +32,11 @@ func run() error {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Setup logger for startup
+	// Setup log levels, shared with the agent, and the logger for startup
+	cfg.LogLevels, err = conductorlog.NewLevels(cfg.LogLevel)
+	if err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
 	logger := setupLogger(cfg)
 	logger.Info().
 		Str("agent_id", cfg.AgentID).
@@ -73,13 +79,25 @@ func run() error {
 	if metricsPort == "" {
 		metricsPort = "9092"
 	}
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/", agentMetrics.Handler())
+	metricsMux.Handle("/log-level", cfg.LogLevels.HTTPHandler())
 	metricsServer := &http.Server{
 		Addr:         ":" + metricsPort,
-		Handler:      agentMetrics.Handler(),
+		Handler:      metricsMux,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
 
+	// Serve pprof profiles and runtime diagnostics if enabled
+	var diag *diagnostics.Diagnostics
+	if cfg.DiagnosticsEnabled {
+		diag = diagnostics.New(cfg.DiagnosticsToken)
+		diag.Mount(metricsMux)
+		metricsServer.WriteTimeout = diagnostics.WriteTimeout
+		logger.Info().Msg("diagnostics endpoints enabled on the metrics server")
+	}
+
 	go func() {
 		logger.Info().Str("address", metricsServer.Addr).Msg("starting agent metrics server")
 		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -87,16 +105,15 @@ func run() error {
 		}
 	}()
 
-	// Start resource metrics reporter
-	if agentMetrics.Agent != nil {
-		go reportResourceMetrics(cfg, agentMetrics.Agent, logger)
-	}
-
 	// Create agent
 	agnt, err := agent.New(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create agent: %w", err)
 	}
+	agnt.SetMetrics(agentMetrics.Agent)
+	if diag != nil {
+		diag.Register("work", func() any { return agnt.WorkStats() })
+	}
 
 	// Setup context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
@@ -106,6 +123,9 @@ func run() error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	// Reload log levels on SIGHUP
+	go cfg.LogLevels.ReloadOnSIGHUP(ctx, func() string { return cfg.LogLevel }, cfg.LogLevelFile, logger)
+
 	// Start agent in goroutine
 	errChan := make(chan error, 1)
 	go func() {
@@ -156,46 +176,7 @@ func run() error {
 
 // setupLogger creates a logger based on configuration.
 func setupLogger(cfg *agent.Config) zerolog.Logger {
-	var logger zerolog.Logger
-
-	if cfg.LogFormat == "console" {
-		logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339}).
-			With().Timestamp().Logger()
-	} else {
-		logger = zerolog.New(os.Stderr).With().Timestamp().Logger()
-	}
-
-	switch cfg.LogLevel {
-	case "debug":
-		logger = logger.Level(zerolog.DebugLevel)
-	case "info":
-		logger = logger.Level(zerolog.InfoLevel)
-	case "warn":
-		logger = logger.Level(zerolog.WarnLevel)
-	case "error":
-		logger = logger.Level(zerolog.ErrorLevel)
-	default:
-		logger = logger.Level(zerolog.InfoLevel)
-	}
+	logger := conductorlog.NewCore(conductorlog.Output(os.Stderr, cfg.LogFormat), cfg.LogLevels).Zerolog()
 
 	return logger.With().Str("component", "main").Logger()
 }
-
-// reportResourceMetrics periodically reports resource usage metrics.
-func reportResourceMetrics(cfg *agent.Config, m *metrics.AgentMetrics, logger zerolog.Logger) {
-	ticker := time.NewTicker(15 * time.Second)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		// Get resource usage from the system
-		// Note: In a real implementation, you would use the agent's Monitor
-		// to get actual resource usage. Here we provide a placeholder.
-
-		// These values would come from the agent's resource monitor
-		// For now, we'll leave them as placeholders that can be integrated
-		// with the agent's existing Monitor component
-
-		// The agent's Monitor component already tracks these values
-		// This goroutine is a bridge to expose them via Prometheus metrics
-	}
-}
@@ -51,8 +51,8 @@ Filters:
 			return fmt.Errorf("failed to list agents: %w", err)
 		}
 
-		if outputFormat == "json" {
-			return printJSON(resp)
+		if structuredOutput() {
+			return printStructured(resp)
 		}
 
 		if len(resp.Agents) == 0 {
@@ -121,8 +121,8 @@ Shows agent configuration, capabilities, resource usage, and current runs.`,
 			return fmt.Errorf("failed to get agent: %w", err)
 		}
 
-		if outputFormat == "json" {
-			return printJSON(map[string]interface{}{
+		if structuredOutput() {
+			return printStructured(map[string]interface{}{
 				"agent":        agent,
 				"current_runs": runs,
 			})
@@ -224,8 +224,8 @@ This is useful for graceful maintenance or shutdown.`,
 			return fmt.Errorf("failed to drain agent: %w", err)
 		}
 
-		if outputFormat == "json" {
-			return printJSON(map[string]interface{}{
+		if structuredOutput() {
+			return printStructured(map[string]interface{}{
 				"agent":          agent,
 				"cancelled_runs": cancelled,
 			})
@@ -265,8 +265,8 @@ The agent will resume accepting new work.`,
 			return fmt.Errorf("failed to undrain agent: %w", err)
 		}
 
-		if outputFormat == "json" {
-			return printJSON(agent)
+		if structuredOutput() {
+			return printStructured(agent)
 		}
 
 		fmt.Printf("%s Agent %s is now active\n", Green("✓"), Bold(agent.Name))
@@ -276,6 +276,52 @@ The agent will resume accepting new work.`,
 	},
 }
 
+// agentLogsCmd retrieves the recent logs of an agent
+var agentLogsCmd = &cobra.Command{
+	Use:   "logs <agent-id>",
+	Short: "Show an agent's recent logs",
+	Long: `Show the most recent lines of an agent's log.
+
+The control plane asks the connected agent to upload its in-memory log
+buffer, so agent-side errors can be inspected without host access. The
+agent must be connected.`,
+	Example: `  # Show the last 200 lines
+  conductor-ctl agent logs agent-123
+
+  # Show the last 50 warnings and errors
+  conductor-ctl agent logs agent-123 --level warn --limit 50`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		agentID := args[0]
+		limit, _ := cmd.Flags().GetInt("limit")
+		level, _ := cmd.Flags().GetString("level")
+
+		ShowSpinner("Fetching agent logs...")
+		logs, err := apiClient.GetAgentLogs(ctx, agentID, limit, level)
+		HideSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to get agent logs: %w", err)
+		}
+
+		if structuredOutput() {
+			return printStructured(logs)
+		}
+
+		if logs.Truncated {
+			fmt.Println(Dim("(older lines omitted)"))
+		}
+		for _, line := range logs.Lines {
+			fmt.Println(line.Line)
+		}
+
+		return nil
+	},
+}
+
 func init() {
 	// List command flags
 	agentListCmd.Flags().String("status", "", "Filter by status (idle, busy, draining, offline)")
@@ -289,11 +335,16 @@ func init() {
 	agentDrainCmd.Flags().String("reason", "", "Reason for draining")
 	agentDrainCmd.Flags().Bool("cancel-active", false, "Cancel active runs")
 
+	// Logs command flags
+	agentLogsCmd.Flags().Int("limit", 200, "Number of most recent lines")
+	agentLogsCmd.Flags().String("level", "", "Minimum level (debug, info, warn, error)")
+
 	// Add subcommands
 	agentCmd.AddCommand(agentListCmd)
 	agentCmd.AddCommand(agentGetCmd)
 	agentCmd.AddCommand(agentDrainCmd)
 	agentCmd.AddCommand(agentUndrainCmd)
+	agentCmd.AddCommand(agentLogsCmd)
 }
 
 // formatAgentStatus returns a colored status string
@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/pkg/errcode"
 )
 
 // Client wraps HTTP client for API operations
@@ -69,20 +74,7 @@ func (c *Client) request(ctx context.Context, method, path string, body interfac
 	}
 
 	if resp.StatusCode >= 400 {
-		var errResp struct {
-			Message string `json:"message"`
-			Error   string `json:"error"`
-			Code    int    `json:"code"`
-		}
-		if err := json.Unmarshal(respBody, &errResp); err == nil {
-			if errResp.Message != "" {
-				return fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Message)
-			}
-			if errResp.Error != "" {
-				return fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Error)
-			}
-		}
-		return fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		return parseAPIError(resp, respBody)
 	}
 
 	if result != nil && len(respBody) > 0 {
@@ -94,6 +86,51 @@ func (c *Client) request(ctx context.Context, method, path string, body interfac
 	return nil
 }
 
+// APIError is an error response returned by the Conductor API.
+type APIError struct {
+	StatusCode int
+	Code       errcode.Code
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (%d): %s", e.StatusCode, e.Message)
+}
+
+// ErrorCode returns the Conductor error code carried by err, or an empty
+// code if err is not an API error.
+func ErrorCode(err error) errcode.Code {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return ""
+}
+
+// parseAPIError builds an APIError from an error response, reading the error
+// code from the response header or the problem details in the body.
+func parseAPIError(resp *http.Response, body []byte) error {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Code:       errcode.Code(resp.Header.Get(errcode.HTTPHeader)),
+		Message:    string(body),
+	}
+
+	var problem errcode.Problem
+	if err := json.Unmarshal(body, &problem); err == nil {
+		if problem.Detail != "" {
+			apiErr.Message = problem.Detail
+		} else if problem.Title != "" {
+			apiErr.Message = problem.Title
+		}
+		if problem.Code != "" {
+			apiErr.Code = problem.Code
+		}
+	}
+
+	return apiErr
+}
+
 // Agent represents an agent in the system
 type Agent struct {
 	ID            string            `json:"id"`
@@ -226,6 +263,40 @@ func (c *Client) UndrainAgent(ctx context.Context, agentID string) (*Agent, erro
 	return &resp.Agent, nil
 }
 
+// AgentLogLine is a line of an agent's log
+type AgentLogLine struct {
+	Level string `json:"level"`
+	Line  string `json:"line"`
+}
+
+// AgentLogs holds the recent log lines of an agent
+type AgentLogs struct {
+	Lines       []AgentLogLine `json:"lines"`
+	Truncated   bool           `json:"truncated"`
+	CollectedAt string         `json:"collected_at"`
+}
+
+// GetAgentLogs retrieves the recent log lines of a connected agent
+func (c *Client) GetAgentLogs(ctx context.Context, agentID string, limit int, minLevel string) (*AgentLogs, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s/logs", agentID)
+	params := url.Values{}
+	if limit > 0 {
+		params.Add("limit", fmt.Sprintf("%d", limit))
+	}
+	if minLevel != "" {
+		params.Add("min_level", minLevel)
+	}
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	var resp AgentLogs
+	if err := c.request(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
 // Run represents a test run
 type Run struct {
 	ID            string            `json:"id"`
@@ -246,6 +317,14 @@ type Run struct {
 	Timeout       *Duration         `json:"timeout"`
 	RetryOfRunID  string            `json:"retry_of_run_id"`
 	RetryCount    int               `json:"retry_count"`
+
+	ApprovalReason string `json:"approval_reason"`
+	ApprovedBy     string `json:"approved_by"`
+	ApprovedAt     string `json:"approved_at"`
+
+	Annotations map[string]string `json:"annotations"`
+	Links       map[string]string `json:"links"`
+	Parameters  map[string]string `json:"parameters"`
 }
 
 // GitRef represents a git reference
@@ -289,18 +368,29 @@ type ListRunsResponse struct {
 	Pagination *PaginationResponse `json:"pagination"`
 }
 
+// RunFilter selects the runs to list
+type RunFilter struct {
+	ServiceID string
+	Status    string
+	Branch    string
+	Limit     int
+}
+
 // ListRuns lists test runs with optional filters
-func (c *Client) ListRuns(ctx context.Context, serviceID, status string, limit int) (*ListRunsResponse, error) {
+func (c *Client) ListRuns(ctx context.Context, filter RunFilter) (*ListRunsResponse, error) {
 	path := "/api/v1/runs"
 	params := url.Values{}
-	if serviceID != "" {
-		params.Add("service_id", serviceID)
+	if filter.ServiceID != "" {
+		params.Add("service_id", filter.ServiceID)
 	}
-	if status != "" {
-		params.Add("statuses", status)
+	if filter.Status != "" {
+		params.Add("statuses", runStatusParam(filter.Status))
 	}
-	if limit > 0 {
-		params.Add("pagination.page_size", fmt.Sprintf("%d", limit))
+	if filter.Branch != "" {
+		params.Add("branch", filter.Branch)
+	}
+	if filter.Limit > 0 {
+		params.Add("pagination.page_size", fmt.Sprintf("%d", filter.Limit))
 	}
 	if len(params) > 0 {
 		path += "?" + params.Encode()
@@ -313,6 +403,16 @@ func (c *Client) ListRuns(ctx context.Context, serviceID, status string, limit i
 	return &resp, nil
 }
 
+// runStatusParam converts a short run status such as "failed" to its API
+// enum name
+func runStatusParam(status string) string {
+	status = strings.ToUpper(status)
+	if strings.HasPrefix(status, "RUN_STATUS_") {
+		return status
+	}
+	return "RUN_STATUS_" + status
+}
+
 // GetRun retrieves a specific run
 func (c *Client) GetRun(ctx context.Context, runID string, includeResults, includeArtifacts bool) (*Run, []TestResult, []Artifact, error) {
 	path := fmt.Sprintf("/api/v1/runs/%s", runID)
@@ -383,6 +483,9 @@ type CreateRunRequest struct {
 	Timeout       *Duration         `json:"timeout,omitempty"`
 	Trigger       *RunTrigger       `json:"trigger,omitempty"`
 	Labels        map[string]string `json:"labels,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+	Links         map[string]string `json:"links,omitempty"`
+	Parameters    map[string]string `json:"parameters,omitempty"`
 }
 
 // CreateRun creates a new test run
@@ -412,6 +515,39 @@ func (c *Client) CancelRun(ctx context.Context, runID, reason string) (*Run, err
 	return &resp.Run, nil
 }
 
+// ApproveRun approves a run waiting for approval
+func (c *Client) ApproveRun(ctx context.Context, runID, comment string) (*Run, error) {
+	path := fmt.Sprintf("/api/v1/runs/%s/approve", runID)
+	body := map[string]interface{}{
+		"comment": comment,
+	}
+
+	var resp struct {
+		Run Run `json:"run"`
+	}
+	if err := c.request(ctx, http.MethodPost, path, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Run, nil
+}
+
+// AnnotateRun sets annotations and links of a run; empty values remove them
+func (c *Client) AnnotateRun(ctx context.Context, runID string, annotations, links map[string]string) (*Run, error) {
+	path := fmt.Sprintf("/api/v1/runs/%s/annotations", runID)
+	body := map[string]interface{}{
+		"annotations": annotations,
+		"links":       links,
+	}
+
+	var resp struct {
+		Run Run `json:"run"`
+	}
+	if err := c.request(ctx, http.MethodPost, path, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Run, nil
+}
+
 // RetryRun creates a new run from a previous run
 func (c *Client) RetryRun(ctx context.Context, runID string, failedOnly bool, envOverride map[string]string) (*Run, string, error) {
 	path := fmt.Sprintf("/api/v1/runs/%s/retry", runID)
@@ -516,6 +652,16 @@ type TestDefinition struct {
 	UpdatedAt            string            `json:"updated_at"`
 	EstimatedDuration    *Duration         `json:"estimated_duration"`
 	FlakinessRate        float64           `json:"flakiness_rate"`
+	LabelSelector        map[string]string `json:"label_selector"`
+	Architectures        []string          `json:"architectures"`
+	ArtifactBudget       *ArtifactBudget   `json:"artifact_budget"`
+	Owner                string            `json:"owner"`
+}
+
+// ArtifactBudget caps the artifacts kept per run of a test. Zero is unlimited.
+type ArtifactBudget struct {
+	MaxBytes int64 `json:"max_bytes,omitempty" yaml:"max_bytes"`
+	MaxFiles int   `json:"max_files,omitempty" yaml:"max_files"`
 }
 
 // ListServicesResponse is the response from listing services
@@ -551,6 +697,24 @@ func (c *Client) ListServices(ctx context.Context, owner, zone, query string, li
 	return &resp, nil
 }
 
+// ResolveServiceID returns the ID of a service given by ID or by name
+func (c *Client) ResolveServiceID(ctx context.Context, service string) (string, error) {
+	if _, err := uuid.Parse(service); err == nil {
+		return service, nil
+	}
+
+	resp, err := c.ListServices(ctx, "", "", service, 100)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range resp.Services {
+		if s.Name == service {
+			return s.ID, nil
+		}
+	}
+	return "", fmt.Errorf("service not found: %s", service)
+}
+
 // GetService retrieves a specific service
 func (c *Client) GetService(ctx context.Context, serviceID string, includeTests, includeRuns bool) (*Service, []TestDefinition, []RecentRun, error) {
 	path := fmt.Sprintf("/api/v1/services/%s", serviceID)
@@ -615,6 +779,128 @@ func (c *Client) CreateService(ctx context.Context, req *CreateServiceRequest) (
 	return &resp.Service, nil
 }
 
+// UpdateServiceRequest specifies the service fields to update. Nil fields
+// are left unchanged.
+type UpdateServiceRequest struct {
+	Name                *string  `json:"name,omitempty"`
+	GitURL              *string  `json:"git_url,omitempty"`
+	DefaultBranch       *string  `json:"default_branch,omitempty"`
+	NetworkZones        []string `json:"network_zones,omitempty"`
+	Owner               *string  `json:"owner,omitempty"`
+	Active              *bool    `json:"active,omitempty"`
+	RootPath            *string  `json:"root_path,omitempty"`
+	SyncIntervalSeconds *int     `json:"sync_interval_seconds,omitempty"`
+	AgentPool           *string  `json:"agent_pool,omitempty"`
+}
+
+// UpdateService updates a service
+func (c *Client) UpdateService(ctx context.Context, serviceID string, req *UpdateServiceRequest) (*Service, error) {
+	path := fmt.Sprintf("/api/v1/services/%s", serviceID)
+	var resp struct {
+		Service Service `json:"service"`
+	}
+	if err := c.request(ctx, http.MethodPatch, path, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Service, nil
+}
+
+// DeleteService deletes a service and its test definitions
+func (c *Client) DeleteService(ctx context.Context, serviceID string, deleteHistory bool) error {
+	path := fmt.Sprintf("/api/v1/services/%s", serviceID)
+	if deleteHistory {
+		path += "?delete_history=true"
+	}
+	return c.request(ctx, http.MethodDelete, path, nil, nil)
+}
+
+// ListTestDefinitionsResponse is the response from listing test definitions
+type ListTestDefinitionsResponse struct {
+	Tests      []TestDefinition    `json:"tests"`
+	Pagination *PaginationResponse `json:"pagination"`
+}
+
+// ListTestDefinitions lists the test definitions of a service
+func (c *Client) ListTestDefinitions(ctx context.Context, serviceID string, tags []string, includeDisabled bool, limit int) (*ListTestDefinitionsResponse, error) {
+	path := fmt.Sprintf("/api/v1/services/%s/tests", serviceID)
+	params := url.Values{}
+	for _, tag := range tags {
+		params.Add("tags", tag)
+	}
+	if includeDisabled {
+		params.Add("include_disabled", "true")
+	}
+	if limit > 0 {
+		params.Add("pagination.page_size", fmt.Sprintf("%d", limit))
+	}
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	var resp ListTestDefinitionsResponse
+	if err := c.request(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateTestDefinitionRequest specifies parameters for creating a test definition
+type CreateTestDefinitionRequest struct {
+	Name           string            `json:"name"`
+	Command        string            `json:"command"`
+	ResultFormat   string            `json:"result_format,omitempty"`
+	Timeout        *Duration         `json:"timeout,omitempty"`
+	Tags           []string          `json:"tags,omitempty"`
+	Environment    map[string]string `json:"environment,omitempty"`
+	ArtifactPaths  []string          `json:"artifact_paths,omitempty"`
+	RetryCount     int               `json:"retry_count,omitempty"`
+	LabelSelector  map[string]string `json:"label_selector,omitempty"`
+	Architectures  []string          `json:"architectures,omitempty"`
+	ArtifactBudget *ArtifactBudget   `json:"artifact_budget,omitempty"`
+	Owner          string            `json:"owner,omitempty"`
+}
+
+// CreateTestDefinition adds a test definition to a service
+func (c *Client) CreateTestDefinition(ctx context.Context, serviceID string, req *CreateTestDefinitionRequest) (*TestDefinition, error) {
+	path := fmt.Sprintf("/api/v1/services/%s/tests", serviceID)
+	var resp struct {
+		Test TestDefinition `json:"test"`
+	}
+	if err := c.request(ctx, http.MethodPost, path, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Test, nil
+}
+
+// UpdateTestDefinitionRequest specifies the test definition fields to
+// update. Nil and empty fields are left unchanged; an empty artifact budget
+// removes the budget.
+type UpdateTestDefinitionRequest struct {
+	Command        *string           `json:"command,omitempty"`
+	ResultFormat   *string           `json:"result_format,omitempty"`
+	Timeout        *Duration         `json:"timeout,omitempty"`
+	Tags           []string          `json:"tags,omitempty"`
+	Environment    map[string]string `json:"environment,omitempty"`
+	ArtifactPaths  []string          `json:"artifact_paths,omitempty"`
+	RetryCount     *int              `json:"retry_count,omitempty"`
+	LabelSelector  map[string]string `json:"label_selector,omitempty"`
+	Architectures  []string          `json:"architectures,omitempty"`
+	ArtifactBudget *ArtifactBudget   `json:"artifact_budget,omitempty"`
+	Owner          *string           `json:"owner,omitempty"`
+}
+
+// UpdateTestDefinition updates a test definition
+func (c *Client) UpdateTestDefinition(ctx context.Context, serviceID, testID string, req *UpdateTestDefinitionRequest) (*TestDefinition, error) {
+	path := fmt.Sprintf("/api/v1/services/%s/tests/%s", serviceID, testID)
+	var resp struct {
+		Test TestDefinition `json:"test"`
+	}
+	if err := c.request(ctx, http.MethodPatch, path, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Test, nil
+}
+
 // SyncService triggers test discovery from the repository
 func (c *Client) SyncService(ctx context.Context, serviceID, branch string, deleteMissing bool) (*SyncResult, error) {
 	path := fmt.Sprintf("/api/v1/services/%s/sync", serviceID)
@@ -640,3 +926,212 @@ type SyncResult struct {
 	Errors       []string `json:"errors"`
 	SyncedAt     string   `json:"synced_at"`
 }
+
+// NotificationDelivery is a notification sent to a channel
+type NotificationDelivery struct {
+	ID              string   `json:"id"`
+	ChannelID       string   `json:"channel_id"`
+	ChannelType     string   `json:"channel_type"`
+	RuleID          string   `json:"rule_id"`
+	RuleIDs         []string `json:"rule_ids"`
+	EventType       string   `json:"event_type"`
+	RunID           string   `json:"run_id"`
+	ServiceID       string   `json:"service_id"`
+	Success         bool     `json:"success"`
+	ErrorMessage    string   `json:"error_message"`
+	SentAt          string   `json:"sent_at"`
+	LatencyMs       int64    `json:"latency_ms"`
+	Attempts        int      `json:"attempts"`
+	ResponseExcerpt string   `json:"response_excerpt"`
+	PayloadHash     string   `json:"payload_hash"`
+	RedeliveryOf    string   `json:"redelivery_of"`
+}
+
+// NotificationHistoryFilter specifies filters for notification history
+type NotificationHistoryFilter struct {
+	ServiceID string
+	RunID     string
+	ChannelID string
+	RuleID    string
+	EventType string
+	// Success filters by outcome when set
+	Success   *bool
+	Since     time.Time
+	Limit     int
+	PageToken string
+}
+
+// ListNotificationHistoryResponse is the response from listing notification
+// history
+type ListNotificationHistoryResponse struct {
+	Records    []NotificationDelivery `json:"records"`
+	Pagination *PaginationResponse    `json:"pagination"`
+}
+
+// ListNotificationHistory lists notification deliveries, newest first
+func (c *Client) ListNotificationHistory(ctx context.Context, filter NotificationHistoryFilter) (*ListNotificationHistoryResponse, error) {
+	path := "/api/v1/notifications/history"
+	params := url.Values{}
+	for name, value := range map[string]string{
+		"service_id": filter.ServiceID,
+		"run_id":     filter.RunID,
+		"channel_id": filter.ChannelID,
+		"rule_id":    filter.RuleID,
+		"event_type": filter.EventType,
+	} {
+		if value != "" {
+			params.Add(name, value)
+		}
+	}
+	if filter.Success != nil {
+		params.Add("success", fmt.Sprintf("%t", *filter.Success))
+	}
+	if !filter.Since.IsZero() {
+		params.Add("time_range.start", filter.Since.UTC().Format(time.RFC3339))
+	}
+	if filter.Limit > 0 {
+		params.Add("pagination.page_size", fmt.Sprintf("%d", filter.Limit))
+	}
+	if filter.PageToken != "" {
+		params.Add("pagination.page_token", filter.PageToken)
+	}
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	var resp ListNotificationHistoryResponse
+	if err := c.request(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RedeliverNotification sends the notification of a failed delivery again
+// and returns the new delivery
+func (c *Client) RedeliverNotification(ctx context.Context, deliveryID string) (*NotificationDelivery, error) {
+	path := fmt.Sprintf("/api/v1/notifications/history/%s/redeliver", deliveryID)
+
+	var resp struct {
+		Record NotificationDelivery `json:"record"`
+	}
+	if err := c.request(ctx, http.MethodPost, path, map[string]interface{}{}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Record, nil
+}
+
+// SchedulingPause records that scheduling is paused globally or, if
+// ServiceID is set, for a service
+type SchedulingPause struct {
+	ServiceID string `json:"service_id"`
+	Reason    string `json:"reason"`
+	PausedBy  string `json:"paused_by"`
+	PausedAt  string `json:"paused_at"`
+}
+
+// SchedulingStatus is the paused state of scheduling
+type SchedulingStatus struct {
+	Paused        bool                `json:"paused"`
+	GlobalPause   *SchedulingPause    `json:"global_pause"`
+	ServicePauses []SchedulingPause   `json:"service_pauses"`
+	ActiveWindows []MaintenanceWindow `json:"active_windows"`
+}
+
+// GetSchedulingStatus returns whether scheduling is paused
+func (c *Client) GetSchedulingStatus(ctx context.Context) (*SchedulingStatus, error) {
+	var resp SchedulingStatus
+	if err := c.request(ctx, http.MethodGet, "/api/v1/admin/scheduling", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PauseScheduling pauses scheduling globally, or for a service if serviceID
+// is set
+func (c *Client) PauseScheduling(ctx context.Context, serviceID, reason string) (*SchedulingPause, error) {
+	body := map[string]interface{}{
+		"service_id": serviceID,
+		"reason":     reason,
+	}
+
+	var resp struct {
+		Pause SchedulingPause `json:"pause"`
+	}
+	if err := c.request(ctx, http.MethodPost, "/api/v1/admin/scheduling/pause", body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Pause, nil
+}
+
+// ResumeScheduling resumes scheduling globally, or for a service if
+// serviceID is set
+func (c *Client) ResumeScheduling(ctx context.Context, serviceID string) error {
+	body := map[string]interface{}{
+		"service_id": serviceID,
+	}
+	return c.request(ctx, http.MethodPost, "/api/v1/admin/scheduling/resume", body, nil)
+}
+
+// MaintenanceWindow is a one-off or recurring period during which runs of a
+// service, an agent pool or, with neither set, every service are deferred
+type MaintenanceWindow struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	ServiceID       string `json:"service_id"`
+	AgentPool       string `json:"agent_pool"`
+	CronExpression  string `json:"cron_expression"`
+	Timezone        string `json:"timezone"`
+	DurationSeconds int    `json:"duration_seconds"`
+	StartsAt        string `json:"starts_at"`
+	EndsAt          string `json:"ends_at"`
+	Notifications   string `json:"notifications"`
+	Enabled         bool   `json:"enabled"`
+	CreatedBy       string `json:"created_by"`
+	CreatedAt       string `json:"created_at"`
+	Active          bool   `json:"active"`
+	ActiveUntil     string `json:"active_until"`
+	NextStart       string `json:"next_start"`
+}
+
+// ListMaintenanceWindows lists the maintenance windows
+func (c *Client) ListMaintenanceWindows(ctx context.Context) ([]MaintenanceWindow, error) {
+	var resp struct {
+		Windows []MaintenanceWindow `json:"windows"`
+	}
+	if err := c.request(ctx, http.MethodGet, "/api/v1/admin/maintenance-windows", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Windows, nil
+}
+
+// CreateMaintenanceWindow creates a maintenance window from the request
+// fields in body
+func (c *Client) CreateMaintenanceWindow(ctx context.Context, body map[string]interface{}) (*MaintenanceWindow, error) {
+	var resp struct {
+		Window MaintenanceWindow `json:"window"`
+	}
+	if err := c.request(ctx, http.MethodPost, "/api/v1/admin/maintenance-windows", body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Window, nil
+}
+
+// UpdateMaintenanceWindow updates the fields of a maintenance window set in
+// body
+func (c *Client) UpdateMaintenanceWindow(ctx context.Context, windowID string, body map[string]interface{}) (*MaintenanceWindow, error) {
+	var resp struct {
+		Window MaintenanceWindow `json:"window"`
+	}
+	path := fmt.Sprintf("/api/v1/admin/maintenance-windows/%s", windowID)
+	if err := c.request(ctx, http.MethodPatch, path, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Window, nil
+}
+
+// DeleteMaintenanceWindow deletes a maintenance window
+func (c *Client) DeleteMaintenanceWindow(ctx context.Context, windowID string) error {
+	path := fmt.Sprintf("/api/v1/admin/maintenance-windows/%s", windowID)
+	return c.request(ctx, http.MethodDelete, path, nil, nil)
+}
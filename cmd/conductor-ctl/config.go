@@ -13,9 +13,91 @@ import (
 
 // Config represents the CLI configuration
 type Config struct {
-	Server       string `yaml:"server"`
-	Token        string `yaml:"token"`
-	OutputFormat string `yaml:"output_format"`
+	// Settings are used when no context is selected, and fill in the
+	// settings a context leaves empty.
+	Settings `yaml:",inline"`
+	// CurrentContext is the context used unless --context selects another.
+	CurrentContext string `yaml:"current_context,omitempty"`
+	// Contexts are the named control planes the CLI can talk to.
+	Contexts []Context `yaml:"contexts,omitempty"`
+}
+
+// Settings are the connection and output settings of the CLI
+type Settings struct {
+	Server       string `yaml:"server,omitempty"`
+	Token        string `yaml:"token,omitempty"`
+	OutputFormat string `yaml:"output_format,omitempty"`
+}
+
+// Context is a named control plane endpoint with its own settings
+type Context struct {
+	Name     string `yaml:"name"`
+	Settings `yaml:",inline"`
+}
+
+// findContext returns the named context, or nil if it does not exist
+func (c *Config) findContext(name string) *Context {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == name {
+			return &c.Contexts[i]
+		}
+	}
+	return nil
+}
+
+// Resolve returns the settings of the named context, or of the current
+// context if name is empty, with empty settings taken from the top level.
+// It also returns the name of the context used, empty if none is.
+func (c *Config) Resolve(name string) (Settings, string, error) {
+	if name == "" {
+		name = c.CurrentContext
+	}
+	if name == "" {
+		return c.Settings, "", nil
+	}
+
+	ctx := c.findContext(name)
+	if ctx == nil {
+		return Settings{}, "", fmt.Errorf("context %q not found (see 'conductor-ctl config get-contexts')", name)
+	}
+
+	settings := ctx.Settings
+	if settings.Server == "" {
+		settings.Server = c.Server
+	}
+	if settings.Token == "" {
+		settings.Token = c.Token
+	}
+	if settings.OutputFormat == "" {
+		settings.OutputFormat = c.OutputFormat
+	}
+	return settings, name, nil
+}
+
+// target returns the settings that config changes apply to: those of the
+// selected context if there is one, otherwise the top-level settings.
+func (c *Config) target(name string) (*Settings, string, error) {
+	if name == "" {
+		name = c.CurrentContext
+	}
+	if name == "" {
+		return &c.Settings, "", nil
+	}
+
+	ctx := c.findContext(name)
+	if ctx == nil {
+		return nil, "", fmt.Errorf("context %q not found (see 'conductor-ctl config get-contexts')", name)
+	}
+	return &ctx.Settings, name, nil
+}
+
+// selectedContext returns the context selected with --context or
+// CONDUCTOR_CONTEXT, empty to use the current context.
+func selectedContext() string {
+	if contextName != "" {
+		return contextName
+	}
+	return os.Getenv("CONDUCTOR_CONTEXT")
 }
 
 // DefaultConfigPath returns the default config file path
@@ -98,40 +180,61 @@ Shows settings from the config file and environment variables.`,
 		if err != nil {
 			cfg = &Config{}
 		}
+		settings, context, err := cfg.Resolve(selectedContext())
+		if err != nil {
+			return err
+		}
 
-		if outputFormat == "json" {
-			return printJSON(map[string]interface{}{
+		server := resolveConfigValue(settings.Server, serverAddr, os.Getenv("CONDUCTOR_SERVER"), "localhost:8080")
+		tokenSet := settings.Token != "" || authToken != "" || os.Getenv("CONDUCTOR_TOKEN") != ""
+		output := resolveConfigValue(settings.OutputFormat, outputFormat, os.Getenv("CONDUCTOR_OUTPUT"), "table")
+
+		if structuredOutput() {
+			return printStructured(map[string]interface{}{
 				"file":          path,
-				"server":        resolveConfigValue(cfg.Server, serverAddr, os.Getenv("CONDUCTOR_SERVER"), "localhost:8080"),
-				"token_set":     cfg.Token != "" || authToken != "" || os.Getenv("CONDUCTOR_TOKEN") != "",
-				"output_format": resolveConfigValue(cfg.OutputFormat, outputFormat, os.Getenv("CONDUCTOR_OUTPUT"), "table"),
+				"context":       context,
+				"server":        server,
+				"token_set":     tokenSet,
+				"output_format": output,
 			})
 		}
 
+		// Settings the context leaves empty come from the top level
+		var own Settings
+		if ctx := cfg.findContext(context); ctx != nil {
+			own = ctx.Settings
+		}
+		source := func(configValue, ownValue, flagValue, envValue string) string {
+			src := resolveSource(configValue, flagValue, envValue)
+			if src == "config" && ownValue != "" {
+				return "context " + context
+			}
+			return src
+		}
+
 		fmt.Printf("%s\n", Bold("Configuration"))
 		fmt.Printf("  Config file: %s\n", path)
+		if context != "" {
+			fmt.Printf("  Context:     %s\n", context)
+		} else {
+			fmt.Printf("  Context:     %s\n", Dim("none"))
+		}
 		fmt.Println()
 
 		fmt.Printf("%s\n", Bold("Settings"))
 
 		// Server
-		server := resolveConfigValue(cfg.Server, serverAddr, os.Getenv("CONDUCTOR_SERVER"), "localhost:8080")
-		serverSource := resolveSource(cfg.Server, serverAddr, os.Getenv("CONDUCTOR_SERVER"))
-		fmt.Printf("  Server:        %s %s\n", server, Dim("("+serverSource+")"))
+		fmt.Printf("  Server:        %s %s\n", server, Dim("("+source(settings.Server, own.Server, serverAddr, os.Getenv("CONDUCTOR_SERVER"))+")"))
 
 		// Token
-		tokenSet := cfg.Token != "" || authToken != "" || os.Getenv("CONDUCTOR_TOKEN") != ""
-		tokenSource := resolveSource(cfg.Token, authToken, os.Getenv("CONDUCTOR_TOKEN"))
 		if tokenSet {
-			fmt.Printf("  Token:         %s %s\n", Dim("****"), Dim("("+tokenSource+")"))
+			fmt.Printf("  Token:         %s %s\n", Dim("****"), Dim("("+source(settings.Token, own.Token, authToken, os.Getenv("CONDUCTOR_TOKEN"))+")"))
 		} else {
 			fmt.Printf("  Token:         %s\n", Dim("not set"))
 		}
 
 		// Output format
-		output := resolveConfigValue(cfg.OutputFormat, outputFormat, os.Getenv("CONDUCTOR_OUTPUT"), "table")
-		outputSource := resolveSource(cfg.OutputFormat, outputFormat, os.Getenv("CONDUCTOR_OUTPUT"))
-		fmt.Printf("  Output Format: %s %s\n", output, Dim("("+outputSource+")"))
+		fmt.Printf("  Output Format: %s %s\n", output, Dim("("+source(settings.OutputFormat, own.OutputFormat, outputFormat, os.Getenv("CONDUCTOR_OUTPUT"))+")"))
 
 		return nil
 	},
@@ -143,10 +246,13 @@ var configSetCmd = &cobra.Command{
 	Short: "Set a configuration value",
 	Long: `Set a configuration value in the config file.
 
+Values are set in the current context, or the one selected with --context.
+Without contexts they are set at the top level of the file.
+
 Available keys:
   server        - Conductor server address
   token         - Authentication token
-  output_format - Default output format (json, table)`,
+  output_format - Default output format (table, json, yaml)`,
 	Example: `  # Set server address
   conductor-ctl config set server localhost:8080
 
@@ -171,17 +277,21 @@ Available keys:
 		if err != nil {
 			cfg = &Config{}
 		}
+		settings, context, err := cfg.target(selectedContext())
+		if err != nil {
+			return err
+		}
 
 		switch strings.ToLower(key) {
 		case "server":
-			cfg.Server = value
+			settings.Server = value
 		case "token":
-			cfg.Token = value
+			settings.Token = value
 		case "output_format", "output":
-			if value != "json" && value != "table" {
-				return fmt.Errorf("invalid output format: %s (must be 'json' or 'table')", value)
+			if !validOutputFormat(value) {
+				return fmt.Errorf("invalid output format: %s (must be 'table', 'json' or 'yaml')", value)
 			}
-			cfg.OutputFormat = value
+			settings.OutputFormat = value
 		default:
 			return fmt.Errorf("unknown configuration key: %s", key)
 		}
@@ -190,7 +300,11 @@ Available keys:
 			return fmt.Errorf("failed to save config: %w", err)
 		}
 
-		fmt.Printf("%s Set %s = %s\n", Green("✓"), Bold(key), value)
+		if context != "" {
+			fmt.Printf("%s Set %s = %s in context %s\n", Green("✓"), Bold(key), value, Bold(context))
+		} else {
+			fmt.Printf("%s Set %s = %s\n", Green("✓"), Bold(key), value)
+		}
 
 		return nil
 	},
@@ -202,7 +316,9 @@ var configInitCmd = &cobra.Command{
 	Short: "Initialize configuration interactively",
 	Long: `Initialize conductor-ctl configuration with an interactive setup wizard.
 
-This will guide you through setting up the basic configuration options.`,
+This will guide you through setting up the basic configuration options. With
+contexts, the current context, or the one selected with --context, is set up;
+other contexts are kept.`,
 	Example: `  # Run interactive setup
   conductor-ctl config init`,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -213,10 +329,22 @@ This will guide you through setting up the basic configuration options.`,
 			path = DefaultConfigPath()
 		}
 
-		fmt.Printf("%s\n\n", Bold("Conductor CLI Configuration"))
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			cfg = &Config{}
+		}
+		settings, context, err := cfg.target(selectedContext())
+		if err != nil {
+			return err
+		}
+
+		if context != "" {
+			fmt.Printf("%s %s\n\n", Bold("Conductor CLI Configuration"), Dim("(context "+context+")"))
+		} else {
+			fmt.Printf("%s\n\n", Bold("Conductor CLI Configuration"))
+		}
 
 		reader := bufio.NewReader(os.Stdin)
-		cfg := &Config{}
 
 		// Server
 		fmt.Print("Server address [localhost:8080]: ")
@@ -225,26 +353,26 @@ This will guide you through setting up the basic configuration options.`,
 		if server == "" {
 			server = "localhost:8080"
 		}
-		cfg.Server = server
+		settings.Server = server
 
 		// Token
 		fmt.Print("Authentication token (leave empty to skip): ")
 		token, _ := reader.ReadString('\n')
 		token = strings.TrimSpace(token)
-		cfg.Token = token
+		settings.Token = token
 
 		// Output format
-		fmt.Print("Default output format (table/json) [table]: ")
+		fmt.Print("Default output format (table/json/yaml) [table]: ")
 		output, _ := reader.ReadString('\n')
 		output = strings.TrimSpace(output)
 		if output == "" {
 			output = "table"
 		}
-		if output != "json" && output != "table" {
+		if !validOutputFormat(output) {
 			fmt.Printf("%s Invalid output format, using 'table'\n", Yellow("!"))
 			output = "table"
 		}
-		cfg.OutputFormat = output
+		settings.OutputFormat = output
 
 		// Save
 		if err := SaveConfig(cfg, path); err != nil {
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// ContextInfo is a context as listed by config get-contexts
+type ContextInfo struct {
+	Name         string `json:"name"`
+	Current      bool   `json:"current"`
+	Server       string `json:"server,omitempty"`
+	TokenSet     bool   `json:"token_set"`
+	OutputFormat string `json:"output_format,omitempty"`
+}
+
+// configGetContextsCmd lists the configured contexts
+var configGetContextsCmd = &cobra.Command{
+	Use:     "get-contexts",
+	Aliases: []string{"contexts"},
+	Short:   "List configured contexts",
+	Long: `List the contexts in the config file.
+
+A context stores the server, token and default output format of one control
+plane, e.g. staging or production. The current context is marked with *.`,
+	Example: `  # List contexts
+  conductor-ctl config get-contexts`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		InitColor(!noColor)
+
+		cfg, err := LoadConfig(configFile)
+		if err != nil {
+			cfg = &Config{}
+		}
+
+		contexts := make([]ContextInfo, len(cfg.Contexts))
+		for i, ctx := range cfg.Contexts {
+			contexts[i] = ContextInfo{
+				Name:         ctx.Name,
+				Current:      ctx.Name == cfg.CurrentContext,
+				Server:       ctx.Server,
+				TokenSet:     ctx.Token != "",
+				OutputFormat: ctx.OutputFormat,
+			}
+		}
+
+		if structuredOutput() {
+			return printStructured(contexts)
+		}
+
+		if len(contexts) == 0 {
+			fmt.Println(Dim("No contexts configured. Create one with 'conductor-ctl config set-context'."))
+			return nil
+		}
+
+		headers := []string{"CURRENT", "NAME", "SERVER", "TOKEN", "OUTPUT"}
+		rows := make([][]string, len(contexts))
+		for i, ctx := range contexts {
+			current := ""
+			if ctx.Current {
+				current = Green("*")
+			}
+			token := Dim("-")
+			if ctx.TokenSet {
+				token = "****"
+			}
+			rows[i] = []string{
+				current,
+				ctx.Name,
+				valueOrDash(ctx.Server),
+				token,
+				valueOrDash(ctx.OutputFormat),
+			}
+		}
+		printTable(headers, rows)
+
+		return nil
+	},
+}
+
+// configCurrentContextCmd shows the current context
+var configCurrentContextCmd = &cobra.Command{
+	Use:   "current-context",
+	Short: "Show the current context",
+	Long:  `Display the name of the current context.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := LoadConfig(configFile)
+		if err != nil || cfg.CurrentContext == "" {
+			return fmt.Errorf("current context is not set")
+		}
+		fmt.Println(cfg.CurrentContext)
+		return nil
+	},
+}
+
+// configUseContextCmd switches the current context
+var configUseContextCmd = &cobra.Command{
+	Use:   "use-context <name>",
+	Short: "Switch the current context",
+	Long: `Set the current context. Later commands talk to its server with its token
+and output format, unless --context or CONDUCTOR_CONTEXT selects another.`,
+	Example: `  # Switch to production
+  conductor-ctl config use-context production
+
+  # Run a single command against staging
+  conductor-ctl run list --context staging`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		InitColor(!noColor)
+
+		name := args[0]
+		cfg, err := LoadConfig(configFile)
+		if err != nil {
+			cfg = &Config{}
+		}
+		if cfg.findContext(name) == nil {
+			return fmt.Errorf("context %q not found (see 'conductor-ctl config get-contexts')", name)
+		}
+
+		cfg.CurrentContext = name
+		if err := SaveConfig(cfg, configFile); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		fmt.Printf("%s Switched to context %s\n", Green("✓"), Bold(name))
+		return nil
+	},
+}
+
+// configSetContextCmd creates or updates a context
+var configSetContextCmd = &cobra.Command{
+	Use:   "set-context <name>",
+	Short: "Create or update a context",
+	Long: `Create a context, or update the given settings of an existing one.
+
+Settings a context leaves empty fall back to the top-level settings of the
+config file. The first context created becomes the current context.`,
+	Example: `  # Add a production context
+  conductor-ctl config set-context production --server conductor.example.com:8080 --token $PROD_TOKEN
+
+  # Use JSON output in staging
+  conductor-ctl config set-context staging --output-format json
+
+  # Add a context and switch to it
+  conductor-ctl config set-context local --server localhost:8080 --use`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		InitColor(!noColor)
+
+		name := args[0]
+		server, _ := cmd.Flags().GetString("server")
+		token, _ := cmd.Flags().GetString("token")
+		output, _ := cmd.Flags().GetString("output-format")
+		use, _ := cmd.Flags().GetBool("use")
+
+		if output != "" && !validOutputFormat(output) {
+			return fmt.Errorf("invalid output format: %s (must be 'table', 'json' or 'yaml')", output)
+		}
+
+		cfg, err := LoadConfig(configFile)
+		if err != nil {
+			cfg = &Config{}
+		}
+
+		ctx := cfg.findContext(name)
+		created := ctx == nil
+		if created {
+			cfg.Contexts = append(cfg.Contexts, Context{Name: name})
+			ctx = &cfg.Contexts[len(cfg.Contexts)-1]
+		}
+		if cmd.Flags().Changed("server") {
+			ctx.Server = server
+		}
+		if cmd.Flags().Changed("token") {
+			ctx.Token = token
+		}
+		if cmd.Flags().Changed("output-format") {
+			ctx.OutputFormat = output
+		}
+		if use || cfg.CurrentContext == "" {
+			cfg.CurrentContext = name
+		}
+
+		if err := SaveConfig(cfg, configFile); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		if created {
+			fmt.Printf("%s Created context %s\n", Green("✓"), Bold(name))
+		} else {
+			fmt.Printf("%s Updated context %s\n", Green("✓"), Bold(name))
+		}
+		if cfg.CurrentContext == name {
+			fmt.Printf("  %s\n", Dim("Current context: "+name))
+		}
+		return nil
+	},
+}
+
+// configDeleteContextCmd removes a context
+var configDeleteContextCmd = &cobra.Command{
+	Use:   "delete-context <name>",
+	Short: "Delete a context",
+	Long: `Delete a context from the config file. Deleting the current context unsets
+it, so the top-level settings are used until another context is selected.`,
+	Example: `  # Delete a context
+  conductor-ctl config delete-context staging`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		InitColor(!noColor)
+
+		name := args[0]
+		cfg, err := LoadConfig(configFile)
+		if err != nil || cfg.findContext(name) == nil {
+			return fmt.Errorf("context %q not found (see 'conductor-ctl config get-contexts')", name)
+		}
+
+		contexts := cfg.Contexts[:0]
+		for _, ctx := range cfg.Contexts {
+			if ctx.Name != name {
+				contexts = append(contexts, ctx)
+			}
+		}
+		cfg.Contexts = contexts
+
+		current := cfg.CurrentContext == name
+		if current {
+			cfg.CurrentContext = ""
+		}
+
+		if err := SaveConfig(cfg, configFile); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		fmt.Printf("%s Deleted context %s\n", Green("✓"), Bold(name))
+		if current {
+			Warning("The current context was deleted; select another with 'conductor-ctl config use-context'")
+		}
+		return nil
+	},
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return Dim("-")
+	}
+	return value
+}
+
+func init() {
+	configSetContextCmd.Flags().String("server", "", "Conductor server address")
+	configSetContextCmd.Flags().String("token", "", "Authentication token")
+	configSetContextCmd.Flags().String("output-format", "", "Default output format: table, json, yaml")
+	configSetContextCmd.Flags().Bool("use", false, "Switch to the context")
+
+	configCmd.AddCommand(configGetContextsCmd)
+	configCmd.AddCommand(configCurrentContextCmd)
+	configCmd.AddCommand(configUseContextCmd)
+	configCmd.AddCommand(configSetContextCmd)
+	configCmd.AddCommand(configDeleteContextCmd)
+}
@@ -0,0 +1,496 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/agent/executor"
+	"github.com/conductor/conductor/internal/git"
+	"github.com/conductor/conductor/internal/scheduler"
+)
+
+// LocalTestResult is the outcome of a test run by run local
+type LocalTestResult struct {
+	Name       string `json:"name"`
+	ConfigPath string `json:"config_path"`
+	Executor   string `json:"executor"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	Attempts   int    `json:"attempts"`
+	Error      string `json:"error,omitempty"`
+}
+
+// LocalRunResult summarizes a local run
+type LocalRunResult struct {
+	Dir     string            `json:"dir"`
+	Tests   []LocalTestResult `json:"tests"`
+	Passed  int               `json:"passed"`
+	Failed  int               `json:"failed"`
+	Skipped int               `json:"skipped"`
+}
+
+// runLocalCmd runs the tests of a working copy on this machine
+var runLocalCmd = &cobra.Command{
+	Use:   "local [dir]",
+	Short: "Run tests from a local config file",
+	Long: `Run the tests declared in a working copy's configuration on this machine.
+
+The configuration (.conductor.yaml, conductor.yaml and .conductor/*.yaml) is
+read and validated the same way the control plane syncs it from the
+repository, and tests are executed with the agent's executors. Use it to
+check a pipeline configuration before pushing.
+
+Tests run one at a time in declaration order, in the working copy. Container
+tests need Docker; without it they run as subprocesses, like on an agent
+without Docker. Secrets are not resolved: set them in your environment.
+
+Filters:
+  --tests     Comma-separated test names
+  --tags      Comma-separated tags; tests with any of them run
+  --disabled  Also run tests marked as disabled
+
+Use --list to validate the configuration and list the tests without running
+them. The command fails if the configuration has errors or a test fails.`,
+	Example: `  # Run every test of the current directory
+  conductor-ctl run local
+
+  # Validate the configuration of a service in a monorepo
+  conductor-ctl run local services/api --list
+
+  # Run the smoke tests with an extra variable
+  conductor-ctl run local --tags smoke --env BASE_URL=http://localhost:3000`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		testsStr, _ := cmd.Flags().GetString("tests")
+		tagsStr, _ := cmd.Flags().GetString("tags")
+		includeDisabled, _ := cmd.Flags().GetBool("disabled")
+		list, _ := cmd.Flags().GetBool("list")
+		envFlags, _ := cmd.Flags().GetStringArray("env")
+		failFast, _ := cmd.Flags().GetBool("fail-fast")
+
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+
+		local, err := git.LoadLocalConfig(ctx, dir)
+		if err != nil {
+			return err
+		}
+		for _, msg := range local.Errors {
+			Error(msg)
+		}
+
+		var names, tags []string
+		if testsStr != "" {
+			names = strings.Split(testsStr, ",")
+		}
+		if tagsStr != "" {
+			tags = strings.Split(tagsStr, ",")
+		}
+		tests, err := selectLocalTests(local.Tests, names, tags, includeDisabled)
+		if err != nil {
+			return err
+		}
+
+		if list {
+			if err := printLocalTests(tests); err != nil {
+				return err
+			}
+			if len(local.Errors) > 0 {
+				return fmt.Errorf("configuration has %d error(s)", len(local.Errors))
+			}
+			return nil
+		}
+		if len(local.Errors) > 0 {
+			return fmt.Errorf("configuration has %d error(s); fix them before running tests", len(local.Errors))
+		}
+		if len(tests) == 0 {
+			return fmt.Errorf("no tests selected")
+		}
+
+		env, err := localEnvironment(local, envFlags)
+		if err != nil {
+			return err
+		}
+		for _, name := range unresolvedSecrets(local, tests, env) {
+			Warning(fmt.Sprintf("secret %s is not resolved locally; set it in your environment", name))
+		}
+
+		// Logs go to stderr when the result is printed as JSON or YAML
+		var logs io.Writer = os.Stdout
+		if structuredOutput() {
+			logs = os.Stderr
+		}
+
+		runner := &localRunner{dir: dir, env: env, logs: logs}
+		defer runner.close()
+
+		result := &LocalRunResult{Dir: dir}
+		for _, test := range tests {
+			if !structuredOutput() {
+				fmt.Printf("%s %s %s\n", Blue("→"), Bold(test.Definition.Name), Dim(test.ConfigPath))
+			}
+
+			testResult, err := runner.run(ctx, test)
+			if err != nil {
+				return err
+			}
+			result.Tests = append(result.Tests, *testResult)
+
+			switch testResult.Status {
+			case "pass":
+				result.Passed++
+			case "skip":
+				result.Skipped++
+			default:
+				result.Failed++
+			}
+
+			if !structuredOutput() {
+				printLocalTestResult(testResult)
+			}
+			if failFast && result.Failed > 0 {
+				break
+			}
+		}
+
+		if structuredOutput() {
+			if err := printStructured(result); err != nil {
+				return err
+			}
+		} else {
+			printLocalSummary(result)
+		}
+
+		if result.Failed > 0 {
+			return fmt.Errorf("%d of %d tests failed", result.Failed, len(result.Tests))
+		}
+		return nil
+	},
+}
+
+// selectLocalTests filters tests by name and tag. Disabled tests are only
+// selected by name or with includeDisabled.
+func selectLocalTests(tests []git.LocalTest, names, tags []string, includeDisabled bool) ([]git.LocalTest, error) {
+	byName := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, test := range tests {
+			if test.Definition.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("test not found in configuration: %s", name)
+		}
+		byName[name] = true
+	}
+
+	var selected []git.LocalTest
+	for _, test := range tests {
+		if len(byName) > 0 {
+			if byName[test.Definition.Name] {
+				selected = append(selected, test)
+			}
+			continue
+		}
+		if test.Disabled && !includeDisabled {
+			continue
+		}
+		if len(tags) > 0 && !hasAnyTag(test.Definition.Tags, tags) {
+			continue
+		}
+		selected = append(selected, test)
+	}
+	return selected, nil
+}
+
+func hasAnyTag(testTags, tags []string) bool {
+	for _, tag := range tags {
+		for _, testTag := range testTags {
+			if strings.TrimSpace(tag) == testTag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// localEnvironment returns the service environment of the configuration
+// with the KEY=VALUE overrides of --env applied.
+func localEnvironment(local *git.LocalConfig, overrides []string) (map[string]string, error) {
+	env := make(map[string]string)
+	if local.Environment != nil {
+		for k, v := range local.Environment.Environment {
+			env[k] = v
+		}
+	}
+	for _, kv := range overrides {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid --env %q: must be KEY=VALUE", kv)
+		}
+		env[k] = v
+	}
+	return env, nil
+}
+
+// unresolvedSecrets returns the names of the secrets of the service and the
+// selected tests that are set neither in the environment nor with --env.
+func unresolvedSecrets(local *git.LocalConfig, tests []git.LocalTest, env map[string]string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	check := func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		if _, ok := env[name]; ok {
+			return
+		}
+		if _, ok := os.LookupEnv(name); ok {
+			return
+		}
+		names = append(names, name)
+	}
+
+	if local.Environment != nil {
+		for _, ref := range local.Environment.Secrets {
+			check(ref.Name)
+		}
+	}
+	for _, test := range tests {
+		for _, ref := range test.Definition.Secrets {
+			check(ref.Name)
+		}
+	}
+	return names
+}
+
+// localRunner executes tests with the agent's executors
+type localRunner struct {
+	dir  string
+	env  map[string]string
+	logs io.Writer
+
+	subprocess *executor.SubprocessExecutor
+	container  *executor.ContainerExecutor
+	dockerErr  error
+}
+
+// run executes a test and returns its outcome. An error is only returned if
+// the run was interrupted.
+func (r *localRunner) run(ctx context.Context, test git.LocalTest) (*LocalTestResult, error) {
+	def := test.Definition
+	req := &executor.ExecutionRequest{
+		RunID:       fmt.Sprintf("local-%d", time.Now().Unix()),
+		WorkDir:     r.dir,
+		Tests:       []*conductorv1.TestToRun{scheduler.TestDefinitionToProto(*def)},
+		Environment: r.env,
+	}
+
+	execType := conductorv1.ExecutionType_EXECUTION_TYPE_SUBPROCESS
+	if def.ExecutionType == "container" {
+		execType = conductorv1.ExecutionType_EXECUTION_TYPE_CONTAINER
+		if def.ContainerImage != nil {
+			req.ContainerImage = *def.ContainerImage
+		}
+	}
+	exec := executor.Factory(execType, r.subprocessExecutor(), r.containerExecutor(execType))
+
+	result := &LocalTestResult{
+		Name:       def.Name,
+		ConfigPath: test.ConfigPath,
+		Executor:   exec.Name(),
+		Status:     "error",
+		Attempts:   1,
+	}
+
+	execResult, err := exec.Execute(ctx, req, &localReporter{out: r.logs})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("interrupted while running %s", def.Name)
+		}
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	result.DurationMs = execResult.Duration.Milliseconds()
+	if execResult.Error != "" {
+		result.Error = execResult.Error
+		return result, nil
+	}
+	if len(execResult.TestResults) > 0 {
+		testResult := execResult.TestResults[0]
+		result.Status = strings.ToLower(strings.TrimPrefix(testResult.Status.String(), "TEST_STATUS_"))
+		result.Attempts = testResult.RetryAttempt + 1
+		result.Error = testResult.ErrorMessage
+	}
+	return result, nil
+}
+
+func (r *localRunner) subprocessExecutor() executor.Executor {
+	if r.subprocess == nil {
+		r.subprocess = executor.NewSubprocessExecutor(r.dir, zerolog.Nop())
+	}
+	return r.subprocess
+}
+
+// containerExecutor connects to Docker on the first container test. Like
+// on an agent, container tests run as subprocesses without Docker.
+func (r *localRunner) containerExecutor(execType conductorv1.ExecutionType) executor.Executor {
+	if execType != conductorv1.ExecutionType_EXECUTION_TYPE_CONTAINER {
+		return nil
+	}
+	if r.container == nil && r.dockerErr == nil {
+		r.container, r.dockerErr = executor.NewContainerExecutor(os.Getenv("DOCKER_HOST"), r.dir, zerolog.Nop())
+		if r.dockerErr != nil {
+			Warning(fmt.Sprintf("Docker is not available, running container tests as subprocesses: %v", r.dockerErr))
+		}
+	}
+	if r.container == nil {
+		return nil
+	}
+	return r.container
+}
+
+func (r *localRunner) close() {
+	if r.container != nil {
+		r.container.Close()
+	}
+}
+
+// localReporter writes the output of tests as it is produced
+type localReporter struct {
+	out io.Writer
+}
+
+func (r *localReporter) StreamLogs(ctx context.Context, runID, shardID string, stream conductorv1.LogStream, data []byte) error {
+	_, err := r.out.Write(data)
+	return err
+}
+
+func (r *localReporter) ReportTestResult(ctx context.Context, runID, shardID string, result *conductorv1.TestResultEvent) error {
+	return nil
+}
+
+func (r *localReporter) ReportProgress(ctx context.Context, runID, shardID string, phase string, message string, percent int, completed int, total int) error {
+	return nil
+}
+
+// printLocalTests lists the tests a local run would execute
+func printLocalTests(tests []git.LocalTest) error {
+	if structuredOutput() {
+		type localTest struct {
+			Name          string   `json:"name"`
+			ConfigPath    string   `json:"config_path"`
+			ExecutionType string   `json:"execution_type"`
+			Command       string   `json:"command"`
+			Tags          []string `json:"tags,omitempty"`
+			Disabled      bool     `json:"disabled"`
+		}
+		list := make([]localTest, len(tests))
+		for i, test := range tests {
+			list[i] = localTest{
+				Name:          test.Definition.Name,
+				ConfigPath:    test.ConfigPath,
+				ExecutionType: test.Definition.ExecutionType,
+				Command:       strings.TrimSpace(test.Definition.Command + " " + strings.Join(test.Definition.Args, " ")),
+				Tags:          test.Definition.Tags,
+				Disabled:      test.Disabled,
+			}
+		}
+		return printStructured(list)
+	}
+
+	if len(tests) == 0 {
+		fmt.Println(Dim("No tests selected."))
+		return nil
+	}
+
+	headers := []string{"NAME", "CONFIG", "TYPE", "COMMAND", "TAGS", "TIMEOUT"}
+	rows := make([][]string, len(tests))
+	for i, test := range tests {
+		def := test.Definition
+		name := def.Name
+		if test.Disabled {
+			name += " " + Dim("(disabled)")
+		}
+		rows[i] = []string{
+			name,
+			test.ConfigPath,
+			def.ExecutionType,
+			truncate(strings.TrimSpace(def.Command+" "+strings.Join(def.Args, " ")), 40),
+			strings.Join(def.Tags, ","),
+			formatDuration(&Duration{Seconds: int64(def.TimeoutSeconds)}),
+		}
+	}
+	printTable(headers, rows)
+	return nil
+}
+
+// printLocalTestResult prints the outcome line of a test
+func printLocalTestResult(result *LocalTestResult) {
+	duration := (time.Duration(result.DurationMs) * time.Millisecond).Round(10 * time.Millisecond)
+	line := fmt.Sprintf("%s %s in %s", Bold(result.Name), formatTestStatus(result.Status), duration)
+	if result.Attempts > 1 {
+		line += Dim(fmt.Sprintf(" (%d attempts)", result.Attempts))
+	}
+
+	switch result.Status {
+	case "pass":
+		Success(line)
+	case "skip":
+		Warning(line)
+	default:
+		if result.Error != "" {
+			line += ": " + result.Error
+		}
+		Error(line)
+	}
+	fmt.Println()
+}
+
+// printLocalSummary prints the totals of a local run
+func printLocalSummary(result *LocalRunResult) {
+	summary := fmt.Sprintf("%d passed, %d failed", result.Passed, result.Failed)
+	if result.Skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped", result.Skipped)
+	}
+	if result.Failed > 0 {
+		fmt.Println(Red(summary))
+		return
+	}
+	fmt.Println(Green(summary))
+}
+
+func init() {
+	runLocalCmd.Flags().String("tests", "", "Comma-separated test names to run")
+	runLocalCmd.Flags().String("tags", "", "Comma-separated tags to filter tests")
+	runLocalCmd.Flags().Bool("disabled", false, "Also run tests marked as disabled")
+	runLocalCmd.Flags().Bool("list", false, "Validate the configuration and list tests without running them")
+	runLocalCmd.Flags().StringArray("env", nil, "Environment variable KEY=VALUE (repeatable)")
+	runLocalCmd.Flags().Bool("fail-fast", false, "Stop after the first failing test")
+
+	runCmd.AddCommand(runLocalCmd)
+}
@@ -2,7 +2,9 @@
 package main
 
 import (
+	"errors"
 	"os"
+	"os/exec"
 )
 
 // Build information, set by ldflags during build.
@@ -19,6 +21,11 @@ func main() {
 	BuildTime = buildTime
 
 	if err := Execute(); err != nil {
+		// Plugins exit with their own status
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			os.Exit(exitErr.ExitCode())
+		}
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// maintenanceCmd is the parent command for maintenance window operations
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Manage maintenance windows",
+	Long: `Commands for managing maintenance windows.
+
+A maintenance window is a one-off period between --starts and --ends, or a
+recurring period of --duration starting at each time of a cron expression.
+While a window is active, runs of its service or agent pool (or, with
+neither set, of every service) queue without being assigned, and
+notifications are suppressed or tagged. These commands require the admin
+role.`,
+}
+
+// maintenanceListCmd lists maintenance windows
+var maintenanceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List maintenance windows",
+	Example: `  # List maintenance windows and whether they are active
+  conductor-ctl maintenance list`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		ShowSpinner("Fetching maintenance windows...")
+		windows, err := apiClient.ListMaintenanceWindows(ctx)
+		HideSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to list maintenance windows: %w", err)
+		}
+
+		if structuredOutput() {
+			return printStructured(windows)
+		}
+
+		if len(windows) == 0 {
+			fmt.Println("No maintenance windows found")
+			return nil
+		}
+
+		printMaintenanceWindows(windows)
+		return nil
+	},
+}
+
+// maintenanceCreateCmd creates a maintenance window
+var maintenanceCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a maintenance window",
+	Long: `Create a maintenance window for a service (--service), an agent pool
+(--pool) or every service.
+
+Give --cron and --duration for a recurring window, optionally bounded by
+--starts and --ends, or only --starts and --ends for a one-off window.
+Times are RFC 3339; cron expressions are evaluated in --timezone.`,
+	Example: `  # Defer runs of a service every night from 02:00 to 04:00 Stockholm time
+  conductor-ctl maintenance create nightly --service svc-123 \
+    --cron "0 2 * * *" --timezone Europe/Stockholm --duration 2h
+
+  # Defer runs on an agent pool during an upgrade, tagging notifications
+  conductor-ctl maintenance create gpu-upgrade --pool gpu \
+    --starts 2026-11-01T08:00:00Z --ends 2026-11-01T12:00:00Z --notifications tag`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		body := map[string]interface{}{"name": args[0]}
+		for flag, field := range map[string]string{
+			"description": "description",
+			"service":     "service_id",
+			"pool":        "agent_pool",
+			"cron":        "cron_expression",
+			"timezone":    "timezone",
+			"starts":      "starts_at",
+			"ends":        "ends_at",
+		} {
+			if value, _ := cmd.Flags().GetString(flag); value != "" {
+				body[field] = value
+			}
+		}
+		if err := setMaintenanceFlags(cmd, body); err != nil {
+			return err
+		}
+		if disabled, _ := cmd.Flags().GetBool("disabled"); disabled {
+			body["disabled"] = true
+		}
+
+		ShowSpinner("Creating maintenance window...")
+		window, err := apiClient.CreateMaintenanceWindow(ctx, body)
+		HideSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to create maintenance window: %w", err)
+		}
+
+		if structuredOutput() {
+			return printStructured(window)
+		}
+
+		fmt.Printf("%s Maintenance window %s created (%s)\n", Green("✓"), Bold(window.Name), window.ID)
+		return nil
+	},
+}
+
+// maintenanceUpdateCmd updates a maintenance window
+var maintenanceUpdateCmd = &cobra.Command{
+	Use:   "update <window-id>",
+	Short: "Update a maintenance window",
+	Long: `Update the flags given of a maintenance window. The service or agent
+pool of a window cannot be changed; delete and create it instead.
+
+An empty --cron turns a recurring window into a one-off window.`,
+	Example: `  # Extend a nightly window to three hours
+  conductor-ctl maintenance update 7c9e... --duration 3h
+
+  # Disable a window without deleting it
+  conductor-ctl maintenance update 7c9e... --enabled=false`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		body := map[string]interface{}{}
+		for flag, field := range map[string]string{
+			"name":        "name",
+			"description": "description",
+			"cron":        "cron_expression",
+			"timezone":    "timezone",
+			"starts":      "starts_at",
+			"ends":        "ends_at",
+		} {
+			value, _ := cmd.Flags().GetString(flag)
+			// Empty timestamps are not valid; a cleared cron expression is
+			if cmd.Flags().Changed(flag) && (value != "" || !strings.HasSuffix(field, "_at")) {
+				body[field] = value
+			}
+		}
+		if err := setMaintenanceFlags(cmd, body); err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("enabled") {
+			body["enabled"], _ = cmd.Flags().GetBool("enabled")
+		}
+		if len(body) == 0 {
+			return fmt.Errorf("no changes given")
+		}
+
+		ShowSpinner("Updating maintenance window...")
+		window, err := apiClient.UpdateMaintenanceWindow(ctx, args[0], body)
+		HideSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to update maintenance window: %w", err)
+		}
+
+		if structuredOutput() {
+			return printStructured(window)
+		}
+
+		fmt.Printf("%s Maintenance window %s updated\n", Green("✓"), Bold(window.Name))
+		return nil
+	},
+}
+
+// maintenanceDeleteCmd deletes a maintenance window
+var maintenanceDeleteCmd = &cobra.Command{
+	Use:   "delete <window-id>",
+	Short: "Delete a maintenance window",
+	Example: `  # Delete a maintenance window
+  conductor-ctl maintenance delete 7c9e...`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		ShowSpinner("Deleting maintenance window...")
+		err := apiClient.DeleteMaintenanceWindow(ctx, args[0])
+		HideSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to delete maintenance window: %w", err)
+		}
+
+		if structuredOutput() {
+			return printStructured(map[string]interface{}{
+				"window_id": args[0],
+				"deleted":   true,
+			})
+		}
+
+		fmt.Printf("%s Maintenance window %s deleted\n", Green("✓"), Bold(args[0]))
+		return nil
+	},
+}
+
+// setMaintenanceFlags sets the duration and notification handling given as
+// flags in a maintenance window request
+func setMaintenanceFlags(cmd *cobra.Command, body map[string]interface{}) error {
+	if cmd.Flags().Changed("duration") {
+		duration, _ := cmd.Flags().GetDuration("duration")
+		body["duration_seconds"] = int(duration.Seconds())
+	}
+	if cmd.Flags().Changed("notifications") {
+		notifications, _ := cmd.Flags().GetString("notifications")
+		switch notifications {
+		case "suppress", "tag":
+			body["notifications"] = "MAINTENANCE_NOTIFICATIONS_" + strings.ToUpper(notifications)
+		default:
+			return fmt.Errorf("invalid --notifications %q: must be suppress or tag", notifications)
+		}
+	}
+	return nil
+}
+
+// printMaintenanceWindows prints maintenance windows as a table
+func printMaintenanceWindows(windows []MaintenanceWindow) {
+	headers := []string{"ID", "NAME", "SCOPE", "SCHEDULE", "NOTIFICATIONS", "STATE"}
+	rows := make([][]string, len(windows))
+	for i, w := range windows {
+		scope := "all services"
+		switch {
+		case w.ServiceID != "":
+			scope = "service " + w.ServiceID
+		case w.AgentPool != "":
+			scope = "pool " + w.AgentPool
+		}
+
+		schedule := fmt.Sprintf("%s → %s", formatTimestamp(w.StartsAt), formatTimestamp(w.EndsAt))
+		if w.CronExpression != "" {
+			schedule = fmt.Sprintf("%s %s for %s", w.CronExpression, w.Timezone,
+				(time.Duration(w.DurationSeconds) * time.Second).String())
+		}
+
+		state := Dim("disabled")
+		switch {
+		case w.Active:
+			state = Yellow("active until " + formatTimestamp(w.ActiveUntil))
+		case w.Enabled && w.NextStart != "":
+			state = "next " + formatTimestamp(w.NextStart)
+		case w.Enabled:
+			state = Dim("ended")
+		}
+
+		rows[i] = []string{
+			w.ID,
+			truncate(w.Name, 30),
+			scope,
+			schedule,
+			strings.ToLower(strings.TrimPrefix(w.Notifications, "MAINTENANCE_NOTIFICATIONS_")),
+			state,
+		}
+	}
+	printTable(headers, rows)
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{maintenanceCreateCmd, maintenanceUpdateCmd} {
+		cmd.Flags().String("description", "", "Description of the window")
+		cmd.Flags().String("cron", "", "Cron expression of the starts of a recurring window")
+		cmd.Flags().String("timezone", "", "Timezone of the cron expression (default: UTC)")
+		cmd.Flags().Duration("duration", 0, "Length of each occurrence of a recurring window")
+		cmd.Flags().String("starts", "", "Time the window starts (RFC 3339)")
+		cmd.Flags().String("ends", "", "Time the window ends (RFC 3339)")
+		cmd.Flags().String("notifications", "suppress", "Notifications during the window: suppress or tag")
+	}
+	maintenanceCreateCmd.Flags().String("service", "", "ID of the service in maintenance (default: all services)")
+	maintenanceCreateCmd.Flags().String("pool", "", "Agent pool in maintenance")
+	maintenanceCreateCmd.Flags().Bool("disabled", false, "Create the window disabled")
+	maintenanceUpdateCmd.Flags().String("name", "", "Name of the window")
+	maintenanceUpdateCmd.Flags().Bool("enabled", true, "Whether the window is enabled")
+
+	maintenanceCmd.AddCommand(maintenanceListCmd)
+	maintenanceCmd.AddCommand(maintenanceCreateCmd)
+	maintenanceCmd.AddCommand(maintenanceUpdateCmd)
+	maintenanceCmd.AddCommand(maintenanceDeleteCmd)
+}
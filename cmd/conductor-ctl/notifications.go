@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// notificationCmd is the parent command for notification operations
+var notificationCmd = &cobra.Command{
+	Use:     "notifications",
+	Aliases: []string{"notification", "notify"},
+	Short:   "Inspect notifications",
+	Long:    `Commands for inspecting notifications sent to channels.`,
+}
+
+// notificationHistoryCmd lists notification deliveries
+var notificationHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List notification deliveries",
+	Long: `List notifications sent to channels, newest first.
+
+Every notification sent to a channel is recorded with the event, the rules
+that routed it, the number of attempts, the outcome and the start of the
+channel's response.
+
+Filters:
+  --service   Filter by service name or ID
+  --run       Filter by run ID
+  --channel   Filter by channel ID
+  --rule      Filter by rule ID
+  --event     Filter by event type (run_failed, run_recovered, flaky_detected, ...)
+  --status    Filter by outcome (sent, failed)
+  --since     Only show deliveries newer than a duration, such as 24h
+  --limit     Maximum number of results
+  --page      Page token printed by a previous call`,
+	Example: `  # Did the alert for a run go out?
+  conductor-ctl notifications history --run 3f2a9c1e-...
+
+  # Failed deliveries of a service in the last day
+  conductor-ctl notifications history --service my-service --status failed --since 24h`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		service, _ := cmd.Flags().GetString("service")
+		status, _ := cmd.Flags().GetString("status")
+		since, _ := cmd.Flags().GetDuration("since")
+
+		filter := NotificationHistoryFilter{}
+		filter.RunID, _ = cmd.Flags().GetString("run")
+		filter.ChannelID, _ = cmd.Flags().GetString("channel")
+		filter.RuleID, _ = cmd.Flags().GetString("rule")
+		filter.EventType, _ = cmd.Flags().GetString("event")
+		filter.Limit, _ = cmd.Flags().GetInt("limit")
+		filter.PageToken, _ = cmd.Flags().GetString("page")
+
+		switch strings.ToLower(status) {
+		case "":
+		case "sent":
+			success := true
+			filter.Success = &success
+		case "failed":
+			success := false
+			filter.Success = &success
+		default:
+			return fmt.Errorf("invalid status %q: must be sent or failed", status)
+		}
+		if since > 0 {
+			filter.Since = time.Now().Add(-since)
+		}
+
+		ShowSpinner("Fetching notification history...")
+		if service != "" {
+			serviceID, err := apiClient.ResolveServiceID(ctx, service)
+			if err != nil {
+				HideSpinner()
+				return err
+			}
+			filter.ServiceID = serviceID
+		}
+		resp, err := apiClient.ListNotificationHistory(ctx, filter)
+		HideSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to list notification history: %w", err)
+		}
+
+		if structuredOutput() {
+			return printStructured(resp)
+		}
+
+		if len(resp.Records) == 0 {
+			fmt.Println(Dim("No notifications found."))
+			return nil
+		}
+
+		headers := []string{"SENT", "EVENT", "CHANNEL", "RULES", "RUN", "STATUS", "ATTEMPTS", "LATENCY", "RESPONSE"}
+		rows := make([][]string, len(resp.Records))
+		for i, d := range resp.Records {
+			channel := formatChannelType(d.ChannelType)
+			if d.ChannelID != "" {
+				channel += " " + truncate(d.ChannelID, 12)
+			}
+			run := Dim("-")
+			if d.RunID != "" {
+				run = truncate(d.RunID, 12)
+			}
+			rules := Dim("-")
+			if len(d.RuleIDs) > 0 {
+				rules = truncate(d.RuleIDs[0], 12)
+				if len(d.RuleIDs) > 1 {
+					rules += fmt.Sprintf(" +%d", len(d.RuleIDs)-1)
+				}
+			}
+			outcome := Green("sent")
+			response := d.ResponseExcerpt
+			if !d.Success {
+				outcome = Red("failed")
+				if response == "" {
+					response = d.ErrorMessage
+				}
+			}
+
+			rows[i] = []string{
+				formatTimestamp(d.SentAt),
+				d.EventType,
+				channel,
+				rules,
+				run,
+				outcome,
+				fmt.Sprintf("%d", d.Attempts),
+				fmt.Sprintf("%dms", d.LatencyMs),
+				truncate(strings.Join(strings.Fields(response), " "), 40),
+			}
+		}
+
+		printTable(headers, rows)
+
+		if resp.Pagination != nil && resp.Pagination.HasMore {
+			fmt.Printf("\n%s\n", Dim(fmt.Sprintf("Showing %d of %d. Use --page %s for the next page.",
+				len(resp.Records), resp.Pagination.TotalCount, resp.Pagination.NextPageToken)))
+		}
+
+		return nil
+	},
+}
+
+// notificationRedeliverCmd redelivers a failed notification
+var notificationRedeliverCmd = &cobra.Command{
+	Use:   "redeliver <delivery-id>",
+	Short: "Send a failed notification again",
+	Long: `Send the notification of a failed delivery to its channel again.
+
+The notification is sent as it was originally, bypassing rules, throttling
+and deduplication, and recorded as a new delivery. Only failed deliveries
+whose channel still exists and is enabled can be redelivered.`,
+	Example: `  # Find failed deliveries and send one again
+  conductor-ctl notifications history --status failed
+  conductor-ctl notifications redeliver 7c1d2e3f-...`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		ShowSpinner("Redelivering notification...")
+		delivery, err := apiClient.RedeliverNotification(ctx, args[0])
+		HideSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to redeliver notification: %w", err)
+		}
+
+		if structuredOutput() {
+			return printStructured(delivery)
+		}
+
+		if !delivery.Success {
+			fmt.Printf("%s Redelivery %s failed after %d attempts: %s\n",
+				Red("✗"), Bold(delivery.ID), delivery.Attempts, delivery.ErrorMessage)
+			return nil
+		}
+		fmt.Printf("%s Redelivered as %s in %dms\n", Green("✓"), Bold(delivery.ID), delivery.LatencyMs)
+		return nil
+	},
+}
+
+// formatChannelType converts a channel type enum such as
+// "CHANNEL_TYPE_SLACK" to its short name
+func formatChannelType(channelType string) string {
+	return strings.ToLower(strings.TrimPrefix(channelType, "CHANNEL_TYPE_"))
+}
+
+func init() {
+	notificationHistoryCmd.Flags().String("service", "", "Filter by service name or ID")
+	notificationHistoryCmd.Flags().String("run", "", "Filter by run ID")
+	notificationHistoryCmd.Flags().String("channel", "", "Filter by channel ID")
+	notificationHistoryCmd.Flags().String("rule", "", "Filter by rule ID")
+	notificationHistoryCmd.Flags().String("event", "", "Filter by event type")
+	notificationHistoryCmd.Flags().String("status", "", "Filter by outcome (sent, failed)")
+	notificationHistoryCmd.Flags().Duration("since", 0, "Only show deliveries newer than this duration")
+	notificationHistoryCmd.Flags().Int("limit", 50, "Maximum number of results")
+	notificationHistoryCmd.Flags().String("page", "", "Page token of the next page")
+
+	notificationCmd.AddCommand(notificationHistoryCmd)
+	notificationCmd.AddCommand(notificationRedeliverCmd)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/conductor/conductor/pkg/metrics"
+)
+
+// Files written by the observability export command
+const (
+	dashboardFile  = "conductor-dashboard.json"
+	alertRulesFile = "conductor-alerts.yaml"
+)
+
+// observabilityCmd is the parent command for observability operations
+var observabilityCmd = &cobra.Command{
+	Use:   "observability",
+	Short: "Generate monitoring configuration",
+	Long: `Commands for generating monitoring configuration for Conductor.
+
+The configuration is generated from the metrics this version of the control
+plane and agent export, so it matches them.`,
+}
+
+// observabilityExportCmd writes a Grafana dashboard and Prometheus alerting rules
+var observabilityExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a Grafana dashboard and Prometheus alerting rules",
+	Long: `Write a Grafana dashboard and Prometheus alerting rules for the metrics
+of the control plane and agents to a directory:
+
+  ` + dashboardFile + `   Dashboard with a panel for every metric, to import
+                            into Grafana with a Prometheus data source
+  ` + alertRulesFile + `      Rule file to add to rule_files of Prometheus`,
+	Example: `  # Write the files to the current directory
+  conductor-ctl observability export
+
+  # Write the files to a directory, such as one mounted by Prometheus
+  conductor-ctl observability export --dir /etc/prometheus/conductor`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+
+		families, err := metrics.Families()
+		if err != nil {
+			return err
+		}
+		dashboard, err := metrics.Dashboard(families)
+		if err != nil {
+			return fmt.Errorf("failed to generate dashboard: %w", err)
+		}
+		rules, err := metrics.AlertRules(families)
+		if err != nil {
+			return fmt.Errorf("failed to generate alerting rules: %w", err)
+		}
+
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		for name, data := range map[string][]byte{dashboardFile: dashboard, alertRulesFile: rules} {
+			if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", name, err)
+			}
+		}
+
+		Success(fmt.Sprintf("Wrote %s and %s for %d metrics", filepath.Join(dir, dashboardFile), filepath.Join(dir, alertRulesFile), len(families)))
+		return nil
+	},
+}
+
+func init() {
+	observabilityExportCmd.Flags().String("dir", ".", "Directory to write the files to")
+
+	observabilityCmd.AddCommand(observabilityExportCmd)
+}
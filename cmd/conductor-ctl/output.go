@@ -1,11 +1,14 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Color codes
@@ -120,6 +123,27 @@ func (f *JSONFormatter) Format(data interface{}) (string, error) {
 	return string(out), nil
 }
 
+// YAMLFormatter formats output as YAML, using the JSON field names
+type YAMLFormatter struct{}
+
+// Format formats data as YAML
+func (f *YAMLFormatter) Format(data interface{}) (string, error) {
+	// Round-trip through JSON so fields keep their API names
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(jsonData, &generic); err != nil {
+		return "", err
+	}
+	out, err := yaml.Marshal(generic)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
 // TableFormatter formats output as an ASCII table
 type TableFormatter struct {
 	Headers []string
@@ -142,6 +166,49 @@ func printJSON(data interface{}) error {
 	return nil
 }
 
+// validOutputFormat reports whether format is a supported output format
+func validOutputFormat(format string) bool {
+	switch format {
+	case "table", "json", "yaml":
+		return true
+	}
+	return false
+}
+
+// structuredOutput reports whether the output format is json or yaml
+func structuredOutput() bool {
+	return outputFormat == "json" || outputFormat == "yaml"
+}
+
+// printStructured prints data in the json or yaml output format
+func printStructured(data interface{}) error {
+	if outputFormat != "yaml" {
+		return printJSON(data)
+	}
+	formatter := &YAMLFormatter{}
+	output, err := formatter.Format(data)
+	if err != nil {
+		return err
+	}
+	fmt.Print(output)
+	return nil
+}
+
+// printStreamed prints one item of a stream in the json or yaml output
+// format: JSON as one line per item, YAML as one document per item
+func printStreamed(data interface{}) error {
+	if outputFormat != "yaml" {
+		out, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	fmt.Println("---")
+	return printStructured(data)
+}
+
 // printTable prints an ASCII table
 func printTable(headers []string, rows [][]string) {
 	fmt.Print(formatTable(headers, rows))
@@ -437,3 +504,15 @@ func Warning(msg string) {
 func Info(msg string) {
 	fmt.Printf("%s %s\n", Blue("→"), msg)
 }
+
+// Confirm asks a yes/no question and reports whether it was answered yes
+func Confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
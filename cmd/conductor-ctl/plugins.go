@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// pluginPrefix is the name prefix of executables run as plugins
+const pluginPrefix = "conductor-ctl-"
+
+// Plugin is an executable on PATH that runs as a conductor-ctl command
+type Plugin struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Warning is why the plugin does not run as its name suggests, e.g. a
+	// built-in command or another plugin of the same name takes precedence.
+	Warning string `json:"warning,omitempty"`
+}
+
+// pluginCmd represents the plugin command group
+var pluginCmd = &cobra.Command{
+	Use:     "plugin",
+	Aliases: []string{"plugins"},
+	Short:   "Inspect conductor-ctl plugins",
+	Long: `Plugins add commands to conductor-ctl without changing it.
+
+Any executable on PATH named conductor-ctl-<name> runs as
+'conductor-ctl <name>', receiving the remaining arguments. Dashes nest
+commands: conductor-ctl-team-report runs as 'conductor-ctl team report'.
+Built-in commands always take precedence over plugins.
+
+Global flags given before the plugin name select the settings passed to
+the plugin in these environment variables:
+  CONDUCTOR_SERVER   Resolved server address
+  CONDUCTOR_TOKEN    Resolved authentication token, if any
+  CONDUCTOR_OUTPUT   Resolved output format
+  CONDUCTOR_CONTEXT  Config context in use, empty if none
+  CONDUCTOR_CONFIG   Config file path
+  CONDUCTOR_CTL      Path of the conductor-ctl executable
+  NO_COLOR           Set when --no-color is given`,
+}
+
+// pluginListCmd lists the plugins found on PATH
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List plugins found on PATH",
+	Example: `  # List plugins
+  conductor-ctl plugin list`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		InitColor(!noColor)
+
+		plugins := findPlugins()
+
+		if structuredOutput() {
+			return printStructured(plugins)
+		}
+
+		if len(plugins) == 0 {
+			fmt.Println(Dim("No plugins found. Install an executable named " + pluginPrefix + "<name> on PATH."))
+			return nil
+		}
+
+		headers := []string{"NAME", "PATH", "WARNING"}
+		rows := make([][]string, len(plugins))
+		for i, p := range plugins {
+			warning := Dim("-")
+			if p.Warning != "" {
+				warning = Yellow(p.Warning)
+			}
+			rows[i] = []string{p.Name, p.Path, warning}
+		}
+		printTable(headers, rows)
+
+		return nil
+	},
+}
+
+// findPlugins returns the plugins on PATH in PATH order
+func findPlugins() []Plugin {
+	var plugins []Plugin
+	found := make(map[string]string)
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			file := entry.Name()
+			if entry.IsDir() || !strings.HasPrefix(file, pluginPrefix) {
+				continue
+			}
+			path := filepath.Join(dir, file)
+			if _, err := exec.LookPath(path); err != nil {
+				continue
+			}
+
+			name := strings.TrimPrefix(file, pluginPrefix)
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if name == "" {
+				continue
+			}
+
+			plugin := Plugin{Name: strings.ReplaceAll(name, "-", " "), Path: path}
+			if first, ok := found[name]; ok {
+				plugin.Warning = "shadowed by " + first
+			} else if builtinCommand(strings.SplitN(name, "-", 2)[0]) {
+				plugin.Warning = "overridden by a built-in command"
+				found[name] = path
+			} else {
+				found[name] = path
+			}
+			plugins = append(plugins, plugin)
+		}
+	}
+
+	return plugins
+}
+
+// builtinCommand reports whether name is a built-in command or alias
+func builtinCommand(name string) bool {
+	if name == "help" {
+		return true
+	}
+	for _, cmd := range rootCmd.Commands() {
+		if cmd.Name() == name || cmd.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupPlugin returns the plugin named by the longest run of leading
+// arguments, and how many arguments name it
+func lookupPlugin(args []string) (string, int) {
+	var names []string
+	for _, arg := range args {
+		if arg == "" || strings.HasPrefix(arg, "-") || strings.ContainsAny(arg, `/\`) {
+			break
+		}
+		names = append(names, arg)
+	}
+
+	for n := len(names); n > 0; n-- {
+		if path, err := exec.LookPath(pluginPrefix + strings.Join(names[:n], "-")); err == nil {
+			return path, n
+		}
+	}
+	return "", 0
+}
+
+// runPlugin runs the plugin named by args when no built-in command is, and
+// reports whether it did. Global flags before the plugin name are applied.
+func runPlugin(args []string) (bool, error) {
+	flags := pflag.NewFlagSet(rootCmd.Name(), pflag.ContinueOnError)
+	flags.SetInterspersed(false)
+	flags.SetOutput(io.Discard)
+	flags.AddFlagSet(rootCmd.PersistentFlags())
+	if err := flags.Parse(args); err != nil {
+		// Let the built-in commands report invalid flags and --help.
+		return false, nil
+	}
+
+	rest := flags.Args()
+	if len(rest) == 0 || builtinCommand(rest[0]) {
+		return false, nil
+	}
+	path, n := lookupPlugin(rest)
+	if path == "" {
+		return false, nil
+	}
+
+	env, err := pluginEnv()
+	if err != nil {
+		return true, err
+	}
+
+	plugin := exec.Command(path, rest[n:]...)
+	plugin.Stdin = os.Stdin
+	plugin.Stdout = os.Stdout
+	plugin.Stderr = os.Stderr
+	plugin.Env = append(os.Environ(), env...)
+	return true, plugin.Run()
+}
+
+// pluginEnv returns the environment passing the resolved settings to a
+// plugin
+func pluginEnv() ([]string, error) {
+	settings, err := resolveSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	config := configFile
+	if config == "" {
+		config = DefaultConfigPath()
+	}
+
+	env := []string{
+		"CONDUCTOR_SERVER=" + settings.Server,
+		"CONDUCTOR_OUTPUT=" + settings.OutputFormat,
+		"CONDUCTOR_CONTEXT=" + settings.Context,
+		"CONDUCTOR_CONFIG=" + config,
+	}
+	if settings.Token != "" {
+		env = append(env, "CONDUCTOR_TOKEN="+settings.Token)
+	}
+	if exe, err := os.Executable(); err == nil {
+		env = append(env, "CONDUCTOR_CTL="+exe)
+	}
+	if noColor {
+		env = append(env, "NO_COLOR=1")
+	}
+	return env, nil
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginListCmd)
+}
@@ -22,6 +22,7 @@ var (
 	outputFormat string
 	noColor      bool
 	configFile   string
+	contextName  string
 )
 
 // Global client instance
@@ -38,67 +39,38 @@ It provides commands for managing:
   - Agents: View status, drain/undrain nodes
   - Test runs: Trigger, monitor, cancel, and retry test executions
   - Services: Register and manage services in the test registry
-  - Configuration: Manage CLI settings
+  - Configuration: Manage CLI settings and contexts
+
+Executables named conductor-ctl-<name> on PATH run as plugin commands
+(see 'conductor-ctl plugin --help').
 
 Environment variables:
   CONDUCTOR_SERVER   Server address (default: localhost:8080)
   CONDUCTOR_TOKEN    Authentication token
-  CONDUCTOR_OUTPUT   Output format: json, table (default: table)
-  CONDUCTOR_CONFIG   Config file path (default: ~/.conductor/config.yaml)`,
+  CONDUCTOR_OUTPUT   Output format: table, json, yaml (default: table)
+  CONDUCTOR_CONFIG   Config file path (default: ~/.conductor/config.yaml)
+  CONDUCTOR_CONTEXT  Config context to use instead of the current one`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		// Skip client initialization for completion and config commands
 		if cmd.Name() == "completion" || cmd.Name() == "version" ||
 			(cmd.Parent() != nil && cmd.Parent().Name() == "completion") ||
-			(cmd.Parent() != nil && cmd.Parent().Name() == "config") {
+			(cmd.Parent() != nil && cmd.Parent().Name() == "config") ||
+			(cmd.Parent() != nil && cmd.Parent().Name() == "observability") ||
+			(cmd.Parent() != nil && cmd.Parent().Name() == "plugin") {
 			return nil
 		}
 
 		// Initialize color output
 		InitColor(!noColor)
 
-		// Load configuration
-		cfg, err := LoadConfig(configFile)
+		settings, err := resolveSettings()
 		if err != nil {
-			// Config file not found is OK, we'll use defaults/flags
-			cfg = &Config{}
-		}
-
-		// Resolve server address (flag > env > config > default)
-		server := serverAddr
-		if server == "" {
-			server = os.Getenv("CONDUCTOR_SERVER")
-		}
-		if server == "" && cfg.Server != "" {
-			server = cfg.Server
-		}
-		if server == "" {
-			server = "localhost:8080"
-		}
-
-		// Resolve auth token (flag > env > config)
-		token := authToken
-		if token == "" {
-			token = os.Getenv("CONDUCTOR_TOKEN")
-		}
-		if token == "" && cfg.Token != "" {
-			token = cfg.Token
+			return err
 		}
-
-		// Resolve output format (flag > env > config > default)
-		output := outputFormat
-		if output == "" {
-			output = os.Getenv("CONDUCTOR_OUTPUT")
-		}
-		if output == "" && cfg.OutputFormat != "" {
-			output = cfg.OutputFormat
-		}
-		if output == "" {
-			output = "table"
-		}
-		outputFormat = output
+		outputFormat = settings.OutputFormat
 
 		// Initialize API client
-		apiClient = NewClient(server, token)
+		apiClient = NewClient(settings.Server, settings.Token)
 
 		return nil
 	},
@@ -137,8 +109,76 @@ var versionCmd = &cobra.Command{
 	},
 }
 
-// Execute adds all child commands to the root command and sets flags appropriately.
+// ResolvedSettings are the settings a command runs with
+type ResolvedSettings struct {
+	Settings
+	// Context is the name of the context used, empty if none is.
+	Context string
+}
+
+// resolveSettings resolves the server, token and output format from flags,
+// environment variables, the selected context and defaults, in that order.
+func resolveSettings() (ResolvedSettings, error) {
+	// Load configuration
+	cfg, err := LoadConfig(configFile)
+	if err != nil {
+		// Config file not found is OK, we'll use defaults/flags
+		cfg = &Config{}
+	}
+	settings, context, err := cfg.Resolve(selectedContext())
+	if err != nil {
+		return ResolvedSettings{}, err
+	}
+
+	// Resolve server address (flag > env > config > default)
+	server := serverAddr
+	if server == "" {
+		server = os.Getenv("CONDUCTOR_SERVER")
+	}
+	if server == "" && settings.Server != "" {
+		server = settings.Server
+	}
+	if server == "" {
+		server = "localhost:8080"
+	}
+
+	// Resolve auth token (flag > env > config)
+	token := authToken
+	if token == "" {
+		token = os.Getenv("CONDUCTOR_TOKEN")
+	}
+	if token == "" && settings.Token != "" {
+		token = settings.Token
+	}
+
+	// Resolve output format (flag > env > config > default)
+	output := outputFormat
+	if output == "" {
+		output = os.Getenv("CONDUCTOR_OUTPUT")
+	}
+	if output == "" && settings.OutputFormat != "" {
+		output = settings.OutputFormat
+	}
+	if output == "" {
+		output = "table"
+	}
+	if !validOutputFormat(output) {
+		return ResolvedSettings{}, fmt.Errorf("invalid output format: %s (must be 'table', 'json' or 'yaml')", output)
+	}
+
+	return ResolvedSettings{
+		Settings: Settings{Server: server, Token: token, OutputFormat: output},
+		Context:  context,
+	}, nil
+}
+
+// Execute adds all child commands to the root command and sets flags
+// appropriately. Commands that are not built in run a plugin if one is
+// installed.
 func Execute() error {
+	if ran, err := runPlugin(os.Args[1:]); ran {
+		return err
+	}
 	return rootCmd.Execute()
 }
 
@@ -146,15 +186,23 @@ func init() {
 	// Persistent flags available to all commands
 	rootCmd.PersistentFlags().StringVarP(&serverAddr, "server", "s", "", "Conductor server address (default: localhost:8080)")
 	rootCmd.PersistentFlags().StringVarP(&authToken, "token", "t", "", "Authentication token")
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, table (default: table)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "", "Output format: table, json, yaml (default: table)")
 	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output")
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file (default: ~/.conductor/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&contextName, "context", "", "Config context to use (default: the current context)")
 
 	// Add subcommands
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(agentCmd)
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(serviceCmd)
+	rootCmd.AddCommand(testDefCmd)
+	rootCmd.AddCommand(notificationCmd)
+	rootCmd.AddCommand(observabilityCmd)
+	rootCmd.AddCommand(schedulingCmd)
+	rootCmd.AddCommand(maintenanceCmd)
 	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(secretsCmd)
+	rootCmd.AddCommand(pluginCmd)
 	rootCmd.AddCommand(completionCmd)
 }
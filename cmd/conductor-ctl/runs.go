@@ -5,10 +5,13 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/conductor/conductor/pkg/errcode"
 )
 
 // runCmd is the parent command for run operations
@@ -28,7 +31,10 @@ var runListCmd = &cobra.Command{
 Filters:
   --service   Filter by service ID or name
   --status    Filter by run status (pending, running, passed, failed, error, timeout, cancelled)
-  --limit     Maximum number of results`,
+  --branch    Filter by git branch
+  --limit     Maximum number of results
+
+Use --follow to keep streaming updates of matching runs after the list.`,
 	Example: `  # List recent runs
   conductor-ctl run list
 
@@ -38,70 +44,95 @@ Filters:
   # List only failed runs
   conductor-ctl run list --status failed
 
-  # List runs as JSON
-  conductor-ctl run list -o json`,
+  # List runs of a branch as YAML
+  conductor-ctl run list --branch main -o yaml
+
+  # Stream updates of failing runs
+  conductor-ctl run list --status failed --follow`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
 		service, _ := cmd.Flags().GetString("service")
 		status, _ := cmd.Flags().GetString("status")
+		branch, _ := cmd.Flags().GetString("branch")
 		limit, _ := cmd.Flags().GetInt("limit")
+		follow, _ := cmd.Flags().GetBool("follow")
+
+		filter := RunFilter{Status: status, Branch: branch, Limit: limit}
+		if service != "" {
+			serviceID, err := apiClient.ResolveServiceID(ctx, service)
+			if err != nil {
+				return fmt.Errorf("failed to resolve service: %w", err)
+			}
+			filter.ServiceID = serviceID
+		}
 
 		ShowSpinner("Fetching runs...")
-		resp, err := apiClient.ListRuns(ctx, service, status, limit)
+		resp, err := apiClient.ListRuns(ctx, filter)
 		HideSpinner()
 
 		if err != nil {
 			return fmt.Errorf("failed to list runs: %w", err)
 		}
 
-		if outputFormat == "json" {
-			return printJSON(resp)
+		if err := printRuns(resp); err != nil {
+			return err
 		}
-
-		if len(resp.Runs) == 0 {
-			fmt.Println(Dim("No runs found."))
-			return nil
+		if follow {
+			return followRuns(filter)
 		}
+		return nil
+	},
+}
 
-		headers := []string{"ID", "SERVICE", "STATUS", "BRANCH", "TESTS", "DURATION", "CREATED"}
-		rows := make([][]string, len(resp.Runs))
-		for i, r := range resp.Runs {
-			branch := ""
-			if r.GitRef != nil {
-				branch = r.GitRef.Branch
-			}
+// printRuns prints a page of runs
+func printRuns(resp *ListRunsResponse) error {
+	if structuredOutput() {
+		return printStructured(resp)
+	}
 
-			tests := "-"
-			if r.Summary != nil {
-				tests = fmt.Sprintf("%d/%d", r.Summary.Passed, r.Summary.Total)
-			}
+	if len(resp.Runs) == 0 {
+		fmt.Println(Dim("No runs found."))
+		return nil
+	}
 
-			duration := "-"
-			if r.Summary != nil && r.Summary.Duration != nil {
-				duration = formatDuration(r.Summary.Duration)
-			}
+	headers := []string{"ID", "SERVICE", "STATUS", "BRANCH", "TESTS", "DURATION", "CREATED"}
+	rows := make([][]string, len(resp.Runs))
+	for i, r := range resp.Runs {
+		branch := ""
+		if r.GitRef != nil {
+			branch = r.GitRef.Branch
+		}
 
-			rows[i] = []string{
-				truncate(r.ID, 12),
-				r.ServiceName,
-				formatRunStatus(r.Status),
-				truncate(branch, 20),
-				tests,
-				duration,
-				formatTimestamp(r.CreatedAt),
-			}
+		tests := "-"
+		if r.Summary != nil {
+			tests = fmt.Sprintf("%d/%d", r.Summary.Passed, r.Summary.Total)
 		}
 
-		printTable(headers, rows)
+		duration := "-"
+		if r.Summary != nil && r.Summary.Duration != nil {
+			duration = formatDuration(r.Summary.Duration)
+		}
 
-		if resp.Pagination != nil && resp.Pagination.HasMore {
-			fmt.Printf("\n%s\n", Dim("More results available. Use --limit to see more."))
+		rows[i] = []string{
+			truncate(r.ID, 12),
+			r.ServiceName,
+			formatRunStatus(r.Status),
+			truncate(branch, 20),
+			tests,
+			duration,
+			formatTimestamp(r.CreatedAt),
 		}
+	}
 
-		return nil
-	},
+	printTable(headers, rows)
+
+	if resp.Pagination != nil && resp.Pagination.HasMore {
+		fmt.Printf("\n%s\n", Dim("More results available. Use --limit to see more."))
+	}
+
+	return nil
 }
 
 // runGetCmd gets details for a specific run
@@ -136,8 +167,8 @@ Shows run configuration, test results, and artifacts.`,
 			return fmt.Errorf("failed to get run: %w", err)
 		}
 
-		if outputFormat == "json" {
-			return printJSON(map[string]interface{}{
+		if structuredOutput() {
+			return printStructured(map[string]interface{}{
 				"run":       run,
 				"results":   results,
 				"artifacts": artifacts,
@@ -163,6 +194,12 @@ Shows run configuration, test results, and artifacts.`,
 		if run.ErrorMessage != "" {
 			fmt.Printf("  Error:          %s\n", Red(run.ErrorMessage))
 		}
+		if run.ApprovalReason != "" {
+			fmt.Printf("  Approval:       %s\n", run.ApprovalReason)
+		}
+		if run.ApprovedBy != "" {
+			fmt.Printf("  Approved:       %s by %s\n", formatTimestamp(run.ApprovedAt), run.ApprovedBy)
+		}
 
 		if run.GitRef != nil {
 			fmt.Printf("\n%s\n", Bold("Git Reference"))
@@ -203,6 +240,27 @@ Shows run configuration, test results, and artifacts.`,
 			}
 		}
 
+		if len(run.Parameters) > 0 {
+			fmt.Printf("\n%s\n", Bold("Parameters"))
+			for k, v := range run.Parameters {
+				fmt.Printf("  %s: %s\n", k, v)
+			}
+		}
+
+		if len(run.Annotations) > 0 {
+			fmt.Printf("\n%s\n", Bold("Annotations"))
+			for k, v := range run.Annotations {
+				fmt.Printf("  %s: %s\n", k, v)
+			}
+		}
+
+		if len(run.Links) > 0 {
+			fmt.Printf("\n%s\n", Bold("Links"))
+			for name, link := range run.Links {
+				fmt.Printf("  %s: %s\n", name, link)
+			}
+		}
+
 		if includeResults && len(results) > 0 {
 			fmt.Printf("\n%s\n", Bold("Test Results"))
 			headers := []string{"TEST", "STATUS", "DURATION", "ERROR"}
@@ -247,7 +305,9 @@ var runTriggerCmd = &cobra.Command{
 	Short: "Trigger a new test run",
 	Long: `Trigger a new test run for a service.
 
-By default, runs all tests on the default branch.`,
+By default, runs all tests on the default branch. Parameters set with
+--param are validated against the parameters the service's tests declare
+and passed to them as environment variables.`,
 	Example: `  # Trigger tests for a service
   conductor-ctl run trigger my-service
 
@@ -258,7 +318,13 @@ By default, runs all tests on the default branch.`,
   conductor-ctl run trigger my-service --tests test-1,test-2
 
   # Trigger with higher priority
-  conductor-ctl run trigger my-service --priority 10`,
+  conductor-ctl run trigger my-service --priority 10
+
+  # Link the run to the CI build that triggered it
+  conductor-ctl run trigger my-service --link build=https://ci.example.com/builds/7
+
+  # Run the tests against staging with parameters they declare
+  conductor-ctl run trigger my-service --param TARGET_ENV=staging --param WORKERS=4`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -269,6 +335,9 @@ By default, runs all tests on the default branch.`,
 		testsStr, _ := cmd.Flags().GetString("tests")
 		tagsStr, _ := cmd.Flags().GetString("tags")
 		priority, _ := cmd.Flags().GetInt("priority")
+		annotations, _ := cmd.Flags().GetStringToString("annotation")
+		links, _ := cmd.Flags().GetStringToString("link")
+		params, _ := cmd.Flags().GetStringToString("param")
 
 		req := &CreateRunRequest{
 			ServiceID: serviceID,
@@ -276,6 +345,9 @@ By default, runs all tests on the default branch.`,
 			Trigger: &RunTrigger{
 				Type: "TRIGGER_TYPE_MANUAL",
 			},
+			Annotations: annotations,
+			Links:       links,
+			Parameters:  params,
 		}
 
 		if ref != "" {
@@ -300,8 +372,8 @@ By default, runs all tests on the default branch.`,
 			return fmt.Errorf("failed to trigger run: %w", err)
 		}
 
-		if outputFormat == "json" {
-			return printJSON(run)
+		if structuredOutput() {
+			return printStructured(run)
 		}
 
 		fmt.Printf("%s Run triggered successfully\n", Green("✓"))
@@ -338,11 +410,14 @@ Only runs in pending or running status can be cancelled.`,
 		HideSpinner()
 
 		if err != nil {
+			if ErrorCode(err) == errcode.RunTerminal {
+				return fmt.Errorf("run %s has already finished", runID)
+			}
 			return fmt.Errorf("failed to cancel run: %w", err)
 		}
 
-		if outputFormat == "json" {
-			return printJSON(run)
+		if structuredOutput() {
+			return printStructured(run)
 		}
 
 		fmt.Printf("%s Run cancelled\n", Green("✓"))
@@ -353,6 +428,101 @@ Only runs in pending or running status can be cancelled.`,
 	},
 }
 
+// runApproveCmd approves a run waiting for approval
+var runApproveCmd = &cobra.Command{
+	Use:   "approve <run-id>",
+	Short: "Approve a run waiting for approval",
+	Long: `Approve a run of a protected branch or of a test that requires approval.
+
+The run is scheduled once approved. Approving runs requires the admin or
+approver role, and approvals are recorded in the audit log.`,
+	Example: `  # Approve a run
+  conductor-ctl run approve run-123
+
+  # Approve with a comment for the audit log
+  conductor-ctl run approve run-123 --comment "release 1.4 sign-off"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		runID := args[0]
+		comment, _ := cmd.Flags().GetString("comment")
+
+		ShowSpinner("Approving run...")
+		run, err := apiClient.ApproveRun(ctx, runID, comment)
+		HideSpinner()
+
+		if err != nil {
+			switch ErrorCode(err) {
+			case errcode.PermissionDenied:
+				return fmt.Errorf("approving runs requires the admin or approver role")
+			case errcode.FailedPrecondition:
+				return fmt.Errorf("run %s is not waiting for approval", runID)
+			}
+			return fmt.Errorf("failed to approve run: %w", err)
+		}
+
+		if structuredOutput() {
+			return printStructured(run)
+		}
+
+		fmt.Printf("%s Run approved\n", Green("✓"))
+		fmt.Printf("  Run ID: %s\n", Bold(run.ID))
+		fmt.Printf("  Status: %s\n", formatRunStatus(run.Status))
+
+		return nil
+	},
+}
+
+// runAnnotateCmd attaches annotations and links to a run
+var runAnnotateCmd = &cobra.Command{
+	Use:   "annotate <run-id>",
+	Short: "Attach annotations and links to a run",
+	Long: `Attach key/value annotations and external links to a run, during or
+after its execution, so it can be cross-referenced with CI builds, tickets
+and deployments.
+
+Annotations and links with the same name are replaced; an empty value
+removes them. Links must be absolute http or https URLs.`,
+	Example: `  # Record the deployment a run verified
+  conductor-ctl run annotate run-123 --annotation deployment_id=d-42
+
+  # Link a ticket and remove an annotation
+  conductor-ctl run annotate run-123 --link ticket=https://tracker.example.com/OPS-1 --annotation stale=`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		runID := args[0]
+		annotations, _ := cmd.Flags().GetStringToString("annotation")
+		links, _ := cmd.Flags().GetStringToString("link")
+		if len(annotations) == 0 && len(links) == 0 {
+			return fmt.Errorf("no annotations or links given")
+		}
+
+		ShowSpinner("Annotating run...")
+		run, err := apiClient.AnnotateRun(ctx, runID, annotations, links)
+		HideSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to annotate run: %w", err)
+		}
+
+		if structuredOutput() {
+			return printStructured(run)
+		}
+
+		fmt.Printf("%s Run annotated\n", Green("✓"))
+		fmt.Printf("  Run ID:      %s\n", Bold(run.ID))
+		fmt.Printf("  Annotations: %d\n", len(run.Annotations))
+		fmt.Printf("  Links:       %d\n", len(run.Links))
+
+		return nil
+	},
+}
+
 // runRetryCmd retries a failed run
 var runRetryCmd = &cobra.Command{
 	Use:   "retry <run-id>",
@@ -381,8 +551,8 @@ By default, retries all tests. Use --failed-only to retry only failed tests.`,
 			return fmt.Errorf("failed to retry run: %w", err)
 		}
 
-		if outputFormat == "json" {
-			return printJSON(map[string]interface{}{
+		if structuredOutput() {
+			return printStructured(map[string]interface{}{
 				"run":             run,
 				"original_run_id": originalID,
 			})
@@ -398,6 +568,41 @@ By default, retries all tests. Use --failed-only to retry only failed tests.`,
 	},
 }
 
+// runWatchCmd watches a run until it finishes
+var runWatchCmd = &cobra.Command{
+	Use:   "watch <run-id>",
+	Short: "Watch a run until it finishes",
+	Long: `Stream status updates of a test run until it finishes.
+
+Updates are pushed by the server over WebSocket. If the WebSocket endpoint
+cannot be reached, the run is polled instead.`,
+	Example: `  # Watch a run
+  conductor-ctl run watch run-123
+
+  # Fail unless the run passes, e.g. in CI
+  conductor-ctl run watch run-123 --exit-status
+
+  # Stream updates as JSON lines
+  conductor-ctl run watch run-123 -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		exitStatus, _ := cmd.Flags().GetBool("exit-status")
+
+		run, err := watchRun(args[0])
+		if err != nil {
+			return err
+		}
+
+		if !structuredOutput() {
+			fmt.Printf("\n%s Run finished with status: %s\n", Dim("→"), formatRunStatus(run.Status))
+		}
+		if exitStatus && !isPassedStatus(run.Status) {
+			return fmt.Errorf("run %s did not pass: %s", run.ID, stripAnsi(formatRunStatus(run.Status)))
+		}
+		return nil
+	},
+}
+
 // runLogsCmd shows run logs
 var runLogsCmd = &cobra.Command{
 	Use:   "logs <run-id>",
@@ -436,8 +641,8 @@ For running tests, use --follow to stream logs in real-time.`,
 			return fmt.Errorf("failed to get logs: %w", err)
 		}
 
-		if outputFormat == "json" {
-			return printJSON(entries)
+		if structuredOutput() {
+			return printStructured(entries)
 		}
 
 		if len(entries) == 0 {
@@ -457,6 +662,192 @@ For running tests, use --follow to stream logs in real-time.`,
 	},
 }
 
+// watchRun prints the updates of a run until it finishes and returns its
+// final state
+func watchRun(runID string) (*Run, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	sub, err := apiClient.SubscribeRuns(ctx, runRoom(runID))
+	if err != nil {
+		if !structuredOutput() {
+			Warning(fmt.Sprintf("%v; polling instead", err))
+		}
+		return pollRun(ctx, runID)
+	}
+	defer sub.Close()
+
+	// Fetch the run after subscribing so that no update is missed
+	run, err := fetchRun(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if err := printRunUpdate(run); err != nil {
+		return nil, err
+	}
+
+	for !isTerminalStatus(run.Status) {
+		update, err := sub.Next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("interrupted")
+			}
+			return nil, fmt.Errorf("lost connection to server: %w", err)
+		}
+		applyRunUpdate(run, update)
+		if err := printRunUpdate(run); err != nil {
+			return nil, err
+		}
+	}
+	return run, nil
+}
+
+// pollRun prints the changes of a run, polling it until it finishes
+func pollRun(ctx context.Context, runID string) (*Run, error) {
+	var last string
+	for {
+		run, err := fetchRun(ctx, runID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("interrupted")
+			}
+			return nil, err
+		}
+
+		if state := runState(run); state != last {
+			last = state
+			if err := printRunUpdate(run); err != nil {
+				return nil, err
+			}
+		}
+		if isTerminalStatus(run.Status) {
+			return run, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("interrupted")
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// followRuns prints the updates of runs matching filter until interrupted
+func followRuns(filter RunFilter) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	sub, err := apiClient.SubscribeRuns(ctx, runsRoom(filter.ServiceID))
+	if err != nil {
+		return fmt.Errorf("failed to follow runs: %w", err)
+	}
+	defer sub.Close()
+
+	if !structuredOutput() {
+		fmt.Printf("\n%s Following run updates (press Ctrl+C to stop)\n\n", Dim("→"))
+	}
+
+	for {
+		update, err := sub.Next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("lost connection to server: %w", err)
+		}
+		if filter.Status != "" && runStatusParam(update.Status) != runStatusParam(filter.Status) {
+			continue
+		}
+
+		// Updates carry no service name or git ref; fetch the run for them
+		run, err := fetchRun(ctx, update.RunID.String())
+		if err != nil {
+			run = &Run{ID: update.RunID.String(), ServiceID: update.ServiceID.String()}
+		}
+		applyRunUpdate(run, update)
+		if filter.Branch != "" && (run.GitRef == nil || run.GitRef.Branch != filter.Branch) {
+			continue
+		}
+		if err := printRunUpdate(run); err != nil {
+			return err
+		}
+	}
+}
+
+// fetchRun retrieves a run without results or artifacts
+func fetchRun(ctx context.Context, runID string) (*Run, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	run, _, _, err := apiClient.GetRun(ctx, runID, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run: %w", err)
+	}
+	return run, nil
+}
+
+// applyRunUpdate applies a pushed update to a run
+func applyRunUpdate(run *Run, update *RunUpdate) {
+	run.Status = update.Status
+	run.Summary = &RunSummary{
+		Total:   update.TotalTests,
+		Passed:  update.PassedTests,
+		Failed:  update.FailedTests,
+		Skipped: update.SkippedTests,
+	}
+	if update.DurationMs != nil {
+		ms := *update.DurationMs
+		run.Summary.Duration = &Duration{Seconds: ms / 1000, Nanos: int32(ms%1000) * 1000000}
+	}
+	if update.ErrorMessage != nil {
+		run.ErrorMessage = *update.ErrorMessage
+	}
+	if update.StartedAt != nil {
+		run.StartedAt = update.StartedAt.Format(time.RFC3339)
+	}
+	if update.FinishedAt != nil {
+		run.FinishedAt = update.FinishedAt.Format(time.RFC3339)
+	}
+}
+
+// runState summarizes the status and progress of a run
+func runState(run *Run) string {
+	if run.Summary == nil {
+		return run.Status
+	}
+	return fmt.Sprintf("%s %d/%d/%d", run.Status, run.Summary.Passed, run.Summary.Failed, run.Summary.Total)
+}
+
+// printRunUpdate prints the current state of a run as one line, or as one
+// item of a stream in the json and yaml output formats
+func printRunUpdate(run *Run) error {
+	if structuredOutput() {
+		return printStreamed(run)
+	}
+
+	branch := "-"
+	if run.GitRef != nil && run.GitRef.Branch != "" {
+		branch = run.GitRef.Branch
+	}
+	tests := "-"
+	if run.Summary != nil && run.Summary.Total > 0 {
+		tests = fmt.Sprintf("%d/%d passed", run.Summary.Passed, run.Summary.Total)
+		if run.Summary.Failed > 0 {
+			tests += ", " + Red(fmt.Sprintf("%d failed", run.Summary.Failed))
+		}
+	}
+
+	fmt.Printf("%s  %s  %s  %s  %s  %s\n",
+		Dim(time.Now().Format("15:04:05")),
+		truncate(run.ID, 12),
+		run.ServiceName,
+		truncate(branch, 20),
+		formatRunStatus(run.Status),
+		tests,
+	)
+	return nil
+}
+
 // streamLogs streams logs in real-time (simplified polling implementation)
 func streamLogs(runID, stream, testID string) error {
 	fmt.Printf("%s Streaming logs for run %s (press Ctrl+C to stop)\n\n", Dim("→"), runID)
@@ -505,6 +896,10 @@ func streamLogs(runID, stream, testID string) error {
 	}
 }
 
+func isPassedStatus(status string) bool {
+	return runStatusParam(status) == "RUN_STATUS_PASSED"
+}
+
 func isTerminalStatus(status string) bool {
 	switch strings.ToLower(status) {
 	case "run_status_passed", "passed",
@@ -521,7 +916,9 @@ func init() {
 	// List command flags
 	runListCmd.Flags().String("service", "", "Filter by service")
 	runListCmd.Flags().String("status", "", "Filter by status")
+	runListCmd.Flags().String("branch", "", "Filter by git branch")
 	runListCmd.Flags().Int("limit", 50, "Maximum number of results")
+	runListCmd.Flags().BoolP("follow", "f", false, "Stream updates of matching runs")
 
 	// Get command flags
 	runGetCmd.Flags().Bool("results", false, "Include test results")
@@ -532,13 +929,26 @@ func init() {
 	runTriggerCmd.Flags().String("tests", "", "Comma-separated list of test IDs")
 	runTriggerCmd.Flags().String("tags", "", "Comma-separated list of tags to filter tests")
 	runTriggerCmd.Flags().Int("priority", 0, "Run priority (higher = more urgent)")
+	runTriggerCmd.Flags().StringToString("annotation", nil, "Annotation of the run (KEY=VALUE, repeatable)")
+	runTriggerCmd.Flags().StringToString("link", nil, "External link of the run (NAME=URL, repeatable)")
+	runTriggerCmd.Flags().StringToString("param", nil, "Parameter of the run's tests (NAME=VALUE, repeatable)")
 
 	// Cancel command flags
 	runCancelCmd.Flags().String("reason", "", "Cancellation reason")
 
+	// Approve command flags
+	runApproveCmd.Flags().String("comment", "", "Comment recorded with the approval")
+
+	// Annotate command flags
+	runAnnotateCmd.Flags().StringToString("annotation", nil, "Annotation to set, empty to remove (KEY=VALUE, repeatable)")
+	runAnnotateCmd.Flags().StringToString("link", nil, "External link to set, empty to remove (NAME=URL, repeatable)")
+
 	// Retry command flags
 	runRetryCmd.Flags().Bool("failed-only", false, "Retry only failed tests")
 
+	// Watch command flags
+	runWatchCmd.Flags().Bool("exit-status", false, "Exit with a non-zero status unless the run passed")
+
 	// Logs command flags
 	runLogsCmd.Flags().BoolP("follow", "f", false, "Follow logs in real-time")
 	runLogsCmd.Flags().String("stream", "", "Filter by stream (stdout, stderr)")
@@ -550,7 +960,10 @@ func init() {
 	runCmd.AddCommand(runGetCmd)
 	runCmd.AddCommand(runTriggerCmd)
 	runCmd.AddCommand(runCancelCmd)
+	runCmd.AddCommand(runApproveCmd)
+	runCmd.AddCommand(runAnnotateCmd)
 	runCmd.AddCommand(runRetryCmd)
+	runCmd.AddCommand(runWatchCmd)
 	runCmd.AddCommand(runLogsCmd)
 }
 
@@ -561,6 +974,8 @@ func formatRunStatus(status string) string {
 		return Yellow("pending")
 	case "run_status_running", "running":
 		return Cyan("running")
+	case "run_status_waiting_approval", "waiting_approval":
+		return Yellow("waiting approval")
 	case "run_status_passed", "passed":
 		return Green("passed")
 	case "run_status_failed", "failed":
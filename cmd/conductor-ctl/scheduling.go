@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// schedulingCmd is the parent command for scheduling operations
+var schedulingCmd = &cobra.Command{
+	Use:   "scheduling",
+	Short: "Pause and resume scheduling",
+	Long: `Commands for pausing and resuming the assignment of work to agents.
+
+While scheduling is paused, runs keep queueing but are not assigned to
+agents; work already assigned runs to completion. Pauses survive control
+plane restarts. These commands require the admin role.`,
+}
+
+// schedulingStatusCmd shows whether scheduling is paused
+var schedulingStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether scheduling is paused",
+	Long: `Show whether scheduling is paused globally or per service, and the
+maintenance windows active now.`,
+	Example: `  # Show the global and per-service pauses
+  conductor-ctl scheduling status`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		ShowSpinner("Fetching scheduling status...")
+		status, err := apiClient.GetSchedulingStatus(ctx)
+		HideSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to get scheduling status: %w", err)
+		}
+
+		if structuredOutput() {
+			return printStructured(status)
+		}
+
+		if status.GlobalPause != nil {
+			fmt.Printf("Scheduling: %s\n", Yellow("paused"))
+			printSchedulingPause(status.GlobalPause)
+		} else {
+			fmt.Printf("Scheduling: %s\n", Green("active"))
+		}
+
+		if len(status.ServicePauses) > 0 {
+			fmt.Println()
+			headers := []string{"SERVICE", "PAUSED", "BY", "REASON"}
+			rows := make([][]string, len(status.ServicePauses))
+			for i, p := range status.ServicePauses {
+				rows[i] = []string{
+					p.ServiceID,
+					formatTimestamp(p.PausedAt),
+					p.PausedBy,
+					truncate(p.Reason, 40),
+				}
+			}
+			printTable(headers, rows)
+		}
+
+		if len(status.ActiveWindows) > 0 {
+			fmt.Printf("\nActive maintenance windows:\n")
+			printMaintenanceWindows(status.ActiveWindows)
+		}
+
+		return nil
+	},
+}
+
+// schedulingPauseCmd pauses scheduling
+var schedulingPauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Pause scheduling",
+	Long: `Pause scheduling globally, or for a single service with --service.
+
+Runs keep queueing while scheduling is paused and are assigned once it
+resumes.`,
+	Example: `  # Pause scheduling for every service
+  conductor-ctl scheduling pause --reason "database upgrade"
+
+  # Pause scheduling for one service
+  conductor-ctl scheduling pause --service svc-123`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		serviceID, _ := cmd.Flags().GetString("service")
+		reason, _ := cmd.Flags().GetString("reason")
+
+		ShowSpinner("Pausing scheduling...")
+		pause, err := apiClient.PauseScheduling(ctx, serviceID, reason)
+		HideSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to pause scheduling: %w", err)
+		}
+
+		if structuredOutput() {
+			return printStructured(pause)
+		}
+
+		if serviceID != "" {
+			fmt.Printf("%s Scheduling is paused for service %s\n", Green("✓"), Bold(serviceID))
+		} else {
+			fmt.Printf("%s Scheduling is paused\n", Green("✓"))
+		}
+		printSchedulingPause(pause)
+
+		return nil
+	},
+}
+
+// schedulingResumeCmd resumes scheduling
+var schedulingResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume scheduling",
+	Long: `Resume scheduling globally, or for a single service with --service.
+
+Resuming globally does not resume services that were paused on their own.`,
+	Example: `  # Resume scheduling for every service
+  conductor-ctl scheduling resume
+
+  # Resume scheduling for one service
+  conductor-ctl scheduling resume --service svc-123`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		serviceID, _ := cmd.Flags().GetString("service")
+
+		ShowSpinner("Resuming scheduling...")
+		err := apiClient.ResumeScheduling(ctx, serviceID)
+		HideSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to resume scheduling: %w", err)
+		}
+
+		if structuredOutput() {
+			return printStructured(map[string]interface{}{
+				"service_id": serviceID,
+				"resumed":    true,
+			})
+		}
+
+		if serviceID != "" {
+			fmt.Printf("%s Scheduling resumed for service %s\n", Green("✓"), Bold(serviceID))
+		} else {
+			fmt.Printf("%s Scheduling resumed\n", Green("✓"))
+		}
+
+		return nil
+	},
+}
+
+// printSchedulingPause prints who paused scheduling, when and why
+func printSchedulingPause(p *SchedulingPause) {
+	fmt.Printf("  Paused:  %s by %s\n", formatTimestamp(p.PausedAt), p.PausedBy)
+	if p.Reason != "" {
+		fmt.Printf("  Reason:  %s\n", p.Reason)
+	}
+}
+
+func init() {
+	schedulingPauseCmd.Flags().String("service", "", "ID of the service to pause (default: all services)")
+	schedulingPauseCmd.Flags().String("reason", "", "Reason for pausing")
+	schedulingResumeCmd.Flags().String("service", "", "ID of the service to resume (default: all services)")
+
+	schedulingCmd.AddCommand(schedulingStatusCmd)
+	schedulingCmd.AddCommand(schedulingPauseCmd)
+	schedulingCmd.AddCommand(schedulingResumeCmd)
+}
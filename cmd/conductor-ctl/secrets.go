@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/conductor/conductor/internal/secrets"
+)
+
+var (
+	sealKey    string
+	sealOutput string
+)
+
+// secretsCmd is the parent command for secrets operations
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage sealed secret files",
+	Long:  `Commands for creating sealed env files used by the agent's envfile secrets provider.`,
+}
+
+// secretsKeygenCmd generates a new env file key
+var secretsKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Generate an env file sealing key",
+	Long: `Generate a random base64-encoded 32-byte key.
+
+Configure the same key on agents with CONDUCTOR_AGENT_SECRETS_ENV_FILE_KEY.`,
+	Example: `  conductor-ctl secrets keygen`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := make([]byte, secrets.KeySize)
+		if _, err := rand.Read(key); err != nil {
+			return fmt.Errorf("failed to generate key: %w", err)
+		}
+		fmt.Println(base64.StdEncoding.EncodeToString(key))
+		return nil
+	},
+}
+
+// secretsSealCmd seals a plaintext env file
+var secretsSealCmd = &cobra.Command{
+	Use:   "seal <env-file>",
+	Short: "Seal a plaintext env file",
+	Long: `Encrypt a KEY=VALUE env file so it can be placed in the agent's
+CONDUCTOR_AGENT_SECRETS_ENV_FILE_DIR.
+
+The key is read from --key or the CONDUCTOR_SECRETS_ENV_FILE_KEY environment variable.`,
+	Example: `  # Seal staging.env into staging.env.sealed
+  conductor-ctl secrets seal staging.env -f staging.env.sealed`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		InitColor(!noColor)
+
+		key := sealKey
+		if key == "" {
+			key = os.Getenv("CONDUCTOR_SECRETS_ENV_FILE_KEY")
+		}
+		if key == "" {
+			return errors.New("a sealing key is required (--key or CONDUCTOR_SECRETS_ENV_FILE_KEY)")
+		}
+
+		c, err := secrets.NewCipherFromBase64(key)
+		if err != nil {
+			return fmt.Errorf("invalid key: %w", err)
+		}
+
+		plaintext, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read env file: %w", err)
+		}
+
+		sealed, err := secrets.SealEnvFile(c, plaintext)
+		if err != nil {
+			return fmt.Errorf("failed to seal env file: %w", err)
+		}
+
+		if sealOutput == "" {
+			fmt.Print(string(sealed))
+			return nil
+		}
+		if err := os.WriteFile(sealOutput, sealed, 0600); err != nil {
+			return fmt.Errorf("failed to write sealed file: %w", err)
+		}
+		Success(fmt.Sprintf("Sealed %s to %s", args[0], sealOutput))
+		return nil
+	},
+}
+
+func init() {
+	secretsSealCmd.Flags().StringVar(&sealKey, "key", "", "Base64-encoded 32-byte sealing key")
+	secretsSealCmd.Flags().StringVarP(&sealOutput, "file", "f", "", "Write the sealed file here instead of stdout")
+
+	secretsCmd.AddCommand(secretsKeygenCmd)
+	secretsCmd.AddCommand(secretsSealCmd)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -52,8 +53,8 @@ Filters:
 			return fmt.Errorf("failed to list services: %w", err)
 		}
 
-		if outputFormat == "json" {
-			return printJSON(resp)
+		if structuredOutput() {
+			return printStructured(resp)
 		}
 
 		if len(resp.Services) == 0 {
@@ -97,7 +98,7 @@ Filters:
 
 // serviceGetCmd gets details for a specific service
 var serviceGetCmd = &cobra.Command{
-	Use:   "get <service-id>",
+	Use:   "get <service>",
 	Short: "Get service details",
 	Long: `Display detailed information about a specific service.
 
@@ -115,11 +116,15 @@ Shows service configuration, test definitions, and recent runs.`,
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		serviceID := args[0]
 		includeTests, _ := cmd.Flags().GetBool("tests")
 		includeRuns, _ := cmd.Flags().GetBool("runs")
 
 		ShowSpinner("Fetching service details...")
+		serviceID, err := apiClient.ResolveServiceID(ctx, args[0])
+		if err != nil {
+			HideSpinner()
+			return err
+		}
 		service, tests, runs, err := apiClient.GetService(ctx, serviceID, includeTests, includeRuns)
 		HideSpinner()
 
@@ -127,8 +132,8 @@ Shows service configuration, test definitions, and recent runs.`,
 			return fmt.Errorf("failed to get service: %w", err)
 		}
 
-		if outputFormat == "json" {
-			return printJSON(map[string]interface{}{
+		if structuredOutput() {
+			return printStructured(map[string]interface{}{
 				"service":     service,
 				"tests":       tests,
 				"recent_runs": runs,
@@ -237,7 +242,7 @@ Shows service configuration, test definitions, and recent runs.`,
 
 // serviceSyncCmd syncs test definitions from git
 var serviceSyncCmd = &cobra.Command{
-	Use:   "sync <service-id>",
+	Use:   "sync <service>",
 	Short: "Sync service tests from git",
 	Long: `Trigger discovery of test definitions from the service's git repository.
 
@@ -255,36 +260,46 @@ This will read the Conductor manifest file and update test definitions.`,
 		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 		defer cancel()
 
-		serviceID := args[0]
 		branch, _ := cmd.Flags().GetString("branch")
 		deleteMissing, _ := cmd.Flags().GetBool("delete-missing")
 
-		ShowSpinner("Syncing service...")
-		result, err := apiClient.SyncService(ctx, serviceID, branch, deleteMissing)
+		return syncService(ctx, args[0], branch, deleteMissing)
+	},
+}
+
+// syncService triggers a git sync of a service given by ID or name and
+// prints the result
+func syncService(ctx context.Context, service, branch string, deleteMissing bool) error {
+	ShowSpinner("Syncing service...")
+	serviceID, err := apiClient.ResolveServiceID(ctx, service)
+	if err != nil {
 		HideSpinner()
+		return err
+	}
+	result, err := apiClient.SyncService(ctx, serviceID, branch, deleteMissing)
+	HideSpinner()
 
-		if err != nil {
-			return fmt.Errorf("failed to sync service: %w", err)
-		}
+	if err != nil {
+		return fmt.Errorf("failed to sync service: %w", err)
+	}
 
-		if outputFormat == "json" {
-			return printJSON(result)
-		}
+	if structuredOutput() {
+		return printStructured(result)
+	}
 
-		fmt.Printf("%s Sync completed\n", Green("✓"))
-		fmt.Printf("  Tests Added:   %d\n", result.TestsAdded)
-		fmt.Printf("  Tests Updated: %d\n", result.TestsUpdated)
-		fmt.Printf("  Tests Removed: %d\n", result.TestsRemoved)
+	fmt.Printf("%s Sync completed\n", Green("✓"))
+	fmt.Printf("  Tests Added:   %d\n", result.TestsAdded)
+	fmt.Printf("  Tests Updated: %d\n", result.TestsUpdated)
+	fmt.Printf("  Tests Removed: %d\n", result.TestsRemoved)
 
-		if len(result.Errors) > 0 {
-			fmt.Printf("\n%s\n", Yellow("Warnings:"))
-			for _, e := range result.Errors {
-				fmt.Printf("  - %s\n", e)
-			}
+	if len(result.Errors) > 0 {
+		fmt.Printf("\n%s\n", Yellow("Warnings:"))
+		for _, e := range result.Errors {
+			fmt.Printf("  - %s\n", e)
 		}
+	}
 
-		return nil
-	},
+	return nil
 }
 
 // serviceCreateCmd creates a new service
@@ -342,8 +357,8 @@ Creates a service with the specified git repository URL.`,
 			return fmt.Errorf("failed to create service: %w", err)
 		}
 
-		if outputFormat == "json" {
-			return printJSON(service)
+		if structuredOutput() {
+			return printStructured(service)
 		}
 
 		fmt.Printf("%s Service created\n", Green("✓"))
@@ -356,6 +371,128 @@ Creates a service with the specified git repository URL.`,
 	},
 }
 
+// serviceUpdateCmd updates a service
+var serviceUpdateCmd = &cobra.Command{
+	Use:   "update <service>",
+	Short: "Update a service",
+	Long: `Update the settings of a service given by ID or name.
+
+Only the flags given are changed.`,
+	Example: `  # Change the owner and default branch
+  conductor-ctl service update my-service --owner qa-team --branch develop
+
+  # Deactivate a service
+  conductor-ctl service update my-service --active=false
+
+  # Restore the server's default sync interval
+  conductor-ctl service update my-service --sync-interval -1`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if !slices.ContainsFunc(serviceUpdateFlags, cmd.Flags().Changed) {
+			return fmt.Errorf("no changes given; see --help for the flags")
+		}
+
+		req := &UpdateServiceRequest{
+			Name:          changedString(cmd, "name"),
+			GitURL:        changedString(cmd, "git-url"),
+			DefaultBranch: changedString(cmd, "branch"),
+			Owner:         changedString(cmd, "owner"),
+			RootPath:      changedString(cmd, "root-path"),
+			AgentPool:     changedString(cmd, "agent-pool"),
+		}
+		if zones := changedString(cmd, "zones"); zones != nil {
+			req.NetworkZones = strings.Split(*zones, ",")
+		}
+		if cmd.Flags().Changed("active") {
+			active, _ := cmd.Flags().GetBool("active")
+			req.Active = &active
+		}
+		if cmd.Flags().Changed("sync-interval") {
+			interval, _ := cmd.Flags().GetInt("sync-interval")
+			req.SyncIntervalSeconds = &interval
+		}
+
+		ShowSpinner("Updating service...")
+		serviceID, err := apiClient.ResolveServiceID(ctx, args[0])
+		if err != nil {
+			HideSpinner()
+			return err
+		}
+		service, err := apiClient.UpdateService(ctx, serviceID, req)
+		HideSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to update service: %w", err)
+		}
+
+		if structuredOutput() {
+			return printStructured(service)
+		}
+
+		fmt.Printf("%s Service %s updated\n", Green("✓"), Bold(service.Name))
+		return nil
+	},
+}
+
+// serviceUpdateFlags are the flags of the service fields update can change
+var serviceUpdateFlags = []string{"name", "git-url", "branch", "owner", "zones", "active", "root-path", "sync-interval", "agent-pool"}
+
+// changedString returns the value of a string flag, or nil if it was not given
+func changedString(cmd *cobra.Command, name string) *string {
+	if !cmd.Flags().Changed(name) {
+		return nil
+	}
+	value, _ := cmd.Flags().GetString(name)
+	return &value
+}
+
+// serviceDeleteCmd deletes a service
+var serviceDeleteCmd = &cobra.Command{
+	Use:   "delete <service>",
+	Short: "Delete a service",
+	Long: `Delete a service given by ID or name together with its test definitions.
+
+Asks for confirmation unless --yes is given. To stop triggering a
+decommissioned service but keep its history, archive it instead.`,
+	Example: `  # Delete a service
+  conductor-ctl service delete my-service
+
+  # Delete without confirmation, including run history
+  conductor-ctl service delete my-service --delete-history --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		deleteHistory, _ := cmd.Flags().GetBool("delete-history")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		serviceID, err := apiClient.ResolveServiceID(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		if !yes && !Confirm(fmt.Sprintf("Delete service %s and its test definitions?", args[0])) {
+			fmt.Println(Dim("Aborted."))
+			return nil
+		}
+
+		ShowSpinner("Deleting service...")
+		err = apiClient.DeleteService(ctx, serviceID, deleteHistory)
+		HideSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to delete service: %w", err)
+		}
+
+		fmt.Printf("%s Service %s deleted\n", Green("✓"), args[0])
+		return nil
+	},
+}
+
 func init() {
 	// List command flags
 	serviceListCmd.Flags().String("owner", "", "Filter by owner")
@@ -379,11 +516,28 @@ func init() {
 	serviceCreateCmd.Flags().String("config-path", ".conductor.yaml", "Path to Conductor config file")
 	serviceCreateCmd.Flags().String("zones", "", "Comma-separated network zones")
 
+	// Update command flags
+	serviceUpdateCmd.Flags().String("name", "", "New service name")
+	serviceUpdateCmd.Flags().String("git-url", "", "New git repository URL")
+	serviceUpdateCmd.Flags().String("branch", "", "New default branch")
+	serviceUpdateCmd.Flags().String("owner", "", "New service owner")
+	serviceUpdateCmd.Flags().String("zones", "", "Comma-separated network zones (replaces existing)")
+	serviceUpdateCmd.Flags().Bool("active", true, "Whether the service is active")
+	serviceUpdateCmd.Flags().String("root-path", "", "Service directory in a monorepo; empty for the whole repository")
+	serviceUpdateCmd.Flags().Int("sync-interval", 0, "Periodic sync interval in seconds; 0 disables and -1 restores the default")
+	serviceUpdateCmd.Flags().String("agent-pool", "", "Agent pool to pin the service to; empty unpins it")
+
+	// Delete command flags
+	serviceDeleteCmd.Flags().Bool("delete-history", false, "Also delete run history")
+	serviceDeleteCmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation")
+
 	// Add subcommands
 	serviceCmd.AddCommand(serviceListCmd)
 	serviceCmd.AddCommand(serviceGetCmd)
 	serviceCmd.AddCommand(serviceSyncCmd)
 	serviceCmd.AddCommand(serviceCreateCmd)
+	serviceCmd.AddCommand(serviceUpdateCmd)
+	serviceCmd.AddCommand(serviceDeleteCmd)
 }
 
 // formatTestType returns a human-readable test type
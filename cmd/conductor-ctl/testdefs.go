@@ -0,0 +1,665 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// testDefCmd is the parent command for test definition operations
+var testDefCmd = &cobra.Command{
+	Use:     "test-def",
+	Aliases: []string{"test-defs", "tests"},
+	Short:   "Manage test definitions",
+	Long: `Commands for viewing and managing the test definitions of a service.
+
+Test definitions are usually synced from the service's configuration files
+in git. They can also be created and changed directly, or applied from a
+local YAML file.`,
+}
+
+// testDefListCmd lists the test definitions of a service
+var testDefListCmd = &cobra.Command{
+	Use:   "list <service>",
+	Short: "List test definitions",
+	Long:  `List the test definitions of a service given by ID or name.`,
+	Example: `  # List test definitions
+  conductor-ctl test-def list my-service
+
+  # List definitions tagged unit, including disabled ones
+  conductor-ctl test-def list my-service --tags unit --all`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		tagsStr, _ := cmd.Flags().GetString("tags")
+		all, _ := cmd.Flags().GetBool("all")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		var tags []string
+		if tagsStr != "" {
+			tags = strings.Split(tagsStr, ",")
+		}
+
+		ShowSpinner("Fetching test definitions...")
+		serviceID, err := apiClient.ResolveServiceID(ctx, args[0])
+		if err != nil {
+			HideSpinner()
+			return err
+		}
+		resp, err := apiClient.ListTestDefinitions(ctx, serviceID, tags, all, limit)
+		HideSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to list test definitions: %w", err)
+		}
+
+		if structuredOutput() {
+			return printStructured(resp)
+		}
+
+		if len(resp.Tests) == 0 {
+			fmt.Println(Dim("No test definitions found."))
+			return nil
+		}
+
+		headers := []string{"ID", "NAME", "COMMAND", "FORMAT", "TIMEOUT", "TAGS", "ENABLED"}
+		rows := make([][]string, len(resp.Tests))
+		for i, t := range resp.Tests {
+			enabled := Green("yes")
+			if !t.Enabled {
+				enabled = Red("no")
+			}
+			tags := strings.Join(t.Tags, ", ")
+			if tags == "" {
+				tags = "-"
+			}
+			timeout := "-"
+			if t.Timeout != nil {
+				timeout = formatDuration(t.Timeout)
+			}
+			rows[i] = []string{
+				truncate(t.ID, 12),
+				truncate(t.Name, 30),
+				truncate(t.Command, 30),
+				formatResultFormat(t.ResultFormat),
+				timeout,
+				truncate(tags, 20),
+				enabled,
+			}
+		}
+
+		printTable(headers, rows)
+
+		if resp.Pagination != nil && resp.Pagination.HasMore {
+			fmt.Printf("\n%s\n", Dim("More results available. Use --limit to see more."))
+		}
+
+		return nil
+	},
+}
+
+// testDefCreateCmd creates a test definition
+var testDefCreateCmd = &cobra.Command{
+	Use:   "create <service>",
+	Short: "Create a test definition",
+	Long: `Add a test definition to a service given by ID or name.
+
+A later git sync whose configuration declares a test of the same name
+updates the definition.`,
+	Example: `  # Create a test definition
+  conductor-ctl test-def create my-service --name smoke --command "make smoke"
+
+  # Create with all options
+  conductor-ctl test-def create my-service \
+    --name e2e \
+    --command "npm run e2e" \
+    --result-format junit \
+    --timeout 15m \
+    --tags e2e,slow \
+    --env BASE_URL=http://localhost:3000 \
+    --artifact-paths "screenshots/*.png" \
+    --artifact-max-bytes 104857600`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		name, _ := cmd.Flags().GetString("name")
+		command, _ := cmd.Flags().GetString("command")
+		resultFormat, _ := cmd.Flags().GetString("result-format")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		tagsStr, _ := cmd.Flags().GetString("tags")
+		env, _ := cmd.Flags().GetStringToString("env")
+		artifactsStr, _ := cmd.Flags().GetString("artifact-paths")
+		retries, _ := cmd.Flags().GetInt("retries")
+		labels, _ := cmd.Flags().GetStringToString("labels")
+		archStr, _ := cmd.Flags().GetString("arch")
+		maxBytes, _ := cmd.Flags().GetInt64("artifact-max-bytes")
+		maxFiles, _ := cmd.Flags().GetInt("artifact-max-files")
+
+		if name == "" {
+			return fmt.Errorf("--name is required")
+		}
+		if command == "" {
+			return fmt.Errorf("--command is required")
+		}
+
+		spec := testDefinitionSpec{
+			Name:           name,
+			Command:        command,
+			ResultFormat:   resultFormat,
+			Env:            env,
+			RequiredLabels: labels,
+		}
+		if timeout > 0 {
+			spec.Timeout = timeout.String()
+		}
+		if tagsStr != "" {
+			spec.Tags = strings.Split(tagsStr, ",")
+		}
+		if artifactsStr != "" {
+			spec.ArtifactPaths = strings.Split(artifactsStr, ",")
+		}
+		if archStr != "" {
+			spec.Architectures = strings.Split(archStr, ",")
+		}
+		if cmd.Flags().Changed("retries") {
+			spec.MaxRetries = &retries
+		}
+		if maxBytes > 0 || maxFiles > 0 {
+			spec.ArtifactBudget = &ArtifactBudget{MaxBytes: maxBytes, MaxFiles: maxFiles}
+		}
+
+		ShowSpinner("Creating test definition...")
+		serviceID, err := apiClient.ResolveServiceID(ctx, args[0])
+		if err != nil {
+			HideSpinner()
+			return err
+		}
+		test, err := apiClient.CreateTestDefinition(ctx, serviceID, spec.createRequest())
+		HideSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to create test definition: %w", err)
+		}
+
+		if structuredOutput() {
+			return printStructured(test)
+		}
+
+		fmt.Printf("%s Test definition created\n", Green("✓"))
+		fmt.Printf("  ID:      %s\n", Bold(test.ID))
+		fmt.Printf("  Name:    %s\n", test.Name)
+		fmt.Printf("  Command: %s\n", test.Command)
+
+		return nil
+	},
+}
+
+// testDefSyncCmd syncs test definitions from git or a local file
+var testDefSyncCmd = &cobra.Command{
+	Use:   "sync <service>",
+	Short: "Sync test definitions from git or a local file",
+	Long: `Sync the test definitions of a service given by ID or name.
+
+Without --file, the definitions are synced from the service's git
+repository, like 'service sync'.
+
+With --file, the definitions declared in a local YAML file are applied:
+definitions are matched by name, new ones are created and changed ones are
+updated. The changes are shown first and applied after confirmation.
+Fields left out of the file, and definitions not in the file, are kept as
+they are. The file uses the test fields of the repository configuration:
+
+  tests:
+    - name: unit
+      command: go test ./...
+      result_format: go_test
+      timeout: 10m
+      tags: [unit]
+      env:
+        CGO_ENABLED: "0"
+      required_labels:
+        os: linux
+      architectures: [amd64]
+      max_retries: 1
+      artifact_paths: ["coverage.out"]
+      artifact_budget:
+        max_bytes: 10485760
+        max_files: 5`,
+	Example: `  # Sync from the service's git repository
+  conductor-ctl test-def sync my-service
+
+  # Show what applying a file would change
+  conductor-ctl test-def sync my-service -f tests.yaml --dry-run
+
+  # Apply a file without confirmation
+  conductor-ctl test-def sync my-service -f tests.yaml --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		file, _ := cmd.Flags().GetString("file")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		if file == "" {
+			branch, _ := cmd.Flags().GetString("branch")
+			return syncService(ctx, args[0], branch, false)
+		}
+
+		specs, err := loadTestDefinitionFile(file)
+		if err != nil {
+			return err
+		}
+		if structuredOutput() && !dryRun && !yes {
+			return fmt.Errorf("--yes or --dry-run is required with structured output")
+		}
+
+		ShowSpinner("Fetching test definitions...")
+		serviceID, err := apiClient.ResolveServiceID(ctx, args[0])
+		if err != nil {
+			HideSpinner()
+			return err
+		}
+		existing, err := apiClient.ListTestDefinitions(ctx, serviceID, nil, true, maxAppliedTests)
+		HideSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to list test definitions: %w", err)
+		}
+
+		changes, err := planTestDefinitionChanges(specs, existing.Tests)
+		if err != nil {
+			return err
+		}
+
+		pending := 0
+		for _, c := range changes {
+			if c.Action != testDefUnchanged {
+				pending++
+			}
+		}
+
+		if !structuredOutput() {
+			printTestDefinitionChanges(changes)
+		}
+		if dryRun || pending == 0 {
+			if structuredOutput() {
+				return printStructured(changes)
+			}
+			if pending == 0 {
+				fmt.Printf("\n%s\n", Dim("No changes."))
+			}
+			return nil
+		}
+
+		if !yes && !Confirm(fmt.Sprintf("\nApply %d change(s) to %s?", pending, args[0])) {
+			fmt.Println(Dim("Aborted."))
+			return nil
+		}
+
+		for _, c := range changes {
+			switch c.Action {
+			case testDefCreate:
+				_, err = apiClient.CreateTestDefinition(ctx, serviceID, c.spec.createRequest())
+			case testDefUpdate:
+				_, err = apiClient.UpdateTestDefinition(ctx, serviceID, c.existing.ID, c.spec.updateRequest())
+			default:
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to %s test definition %s: %w", c.Action, c.Name, err)
+			}
+		}
+
+		if structuredOutput() {
+			return printStructured(changes)
+		}
+
+		fmt.Printf("%s Applied %d change(s)\n", Green("✓"), pending)
+		return nil
+	},
+}
+
+// maxAppliedTests is the number of existing test definitions fetched to
+// compare a file against.
+const maxAppliedTests = 1000
+
+// testDefinitionFile is a local file of test definitions to apply
+type testDefinitionFile struct {
+	Tests []testDefinitionSpec `yaml:"tests"`
+}
+
+// testDefinitionSpec is a test definition declared in a file. It uses the
+// test fields of the repository configuration.
+type testDefinitionSpec struct {
+	Name           string            `yaml:"name"`
+	Command        string            `yaml:"command"`
+	ResultFormat   string            `yaml:"result_format"`
+	Timeout        string            `yaml:"timeout"`
+	Tags           []string          `yaml:"tags"`
+	Env            map[string]string `yaml:"env"`
+	RequiredLabels map[string]string `yaml:"required_labels"`
+	Architectures  []string          `yaml:"architectures"`
+	MaxRetries     *int              `yaml:"max_retries"`
+	ArtifactPaths  []string          `yaml:"artifact_paths"`
+	ArtifactBudget *ArtifactBudget   `yaml:"artifact_budget"`
+	Owner          string            `yaml:"owner"`
+}
+
+// loadTestDefinitionFile reads and validates the test definitions of a file
+func loadTestDefinitionFile(path string) ([]testDefinitionSpec, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var file testDefinitionFile
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(file.Tests) == 0 {
+		return nil, fmt.Errorf("%s declares no tests", path)
+	}
+
+	seen := make(map[string]bool, len(file.Tests))
+	for i, spec := range file.Tests {
+		if spec.Name == "" {
+			return nil, fmt.Errorf("%s: tests[%d]: name is required", path, i)
+		}
+		if seen[spec.Name] {
+			return nil, fmt.Errorf("%s: test %s is declared more than once", path, spec.Name)
+		}
+		seen[spec.Name] = true
+		if spec.Timeout != "" {
+			if _, err := time.ParseDuration(spec.Timeout); err != nil {
+				return nil, fmt.Errorf("%s: test %s: invalid timeout: %w", path, spec.Name, err)
+			}
+		}
+		if spec.MaxRetries != nil && *spec.MaxRetries < 0 {
+			return nil, fmt.Errorf("%s: test %s: max_retries must not be negative", path, spec.Name)
+		}
+	}
+	return file.Tests, nil
+}
+
+// timeout returns the timeout of the spec, or nil if it has none
+func (s testDefinitionSpec) timeout() *Duration {
+	d, err := time.ParseDuration(s.Timeout)
+	if err != nil || d <= 0 {
+		return nil
+	}
+	return &Duration{Seconds: int64(d / time.Second)}
+}
+
+// createRequest returns the request creating the spec's test definition
+func (s testDefinitionSpec) createRequest() *CreateTestDefinitionRequest {
+	req := &CreateTestDefinitionRequest{
+		Name:           s.Name,
+		Command:        s.Command,
+		Timeout:        s.timeout(),
+		Tags:           s.Tags,
+		Environment:    s.Env,
+		ArtifactPaths:  s.ArtifactPaths,
+		LabelSelector:  s.RequiredLabels,
+		Architectures:  s.Architectures,
+		ArtifactBudget: s.ArtifactBudget,
+		Owner:          s.Owner,
+	}
+	if s.ResultFormat != "" {
+		req.ResultFormat = resultFormatParam(s.ResultFormat)
+	}
+	if s.MaxRetries != nil {
+		req.RetryCount = *s.MaxRetries
+	}
+	return req
+}
+
+// updateRequest returns the request setting the fields declared by the spec
+func (s testDefinitionSpec) updateRequest() *UpdateTestDefinitionRequest {
+	req := &UpdateTestDefinitionRequest{
+		Timeout:        s.timeout(),
+		Tags:           s.Tags,
+		Environment:    s.Env,
+		ArtifactPaths:  s.ArtifactPaths,
+		RetryCount:     s.MaxRetries,
+		LabelSelector:  s.RequiredLabels,
+		Architectures:  s.Architectures,
+		ArtifactBudget: s.ArtifactBudget,
+	}
+	if s.Command != "" {
+		req.Command = &s.Command
+	}
+	if s.ResultFormat != "" {
+		format := resultFormatParam(s.ResultFormat)
+		req.ResultFormat = &format
+	}
+	if s.Owner != "" {
+		req.Owner = &s.Owner
+	}
+	return req
+}
+
+// Actions applying a test definition spec
+const (
+	testDefCreate    = "create"
+	testDefUpdate    = "update"
+	testDefUnchanged = "unchanged"
+)
+
+// testDefinitionChange is the change applying a spec makes
+type testDefinitionChange struct {
+	Name   string        `json:"name" yaml:"name"`
+	Action string        `json:"action" yaml:"action"`
+	Fields []fieldChange `json:"fields,omitempty" yaml:"fields,omitempty"`
+
+	spec     testDefinitionSpec
+	existing *TestDefinition
+}
+
+// fieldChange is a changed field of a test definition
+type fieldChange struct {
+	Field string `json:"field" yaml:"field"`
+	Old   string `json:"old,omitempty" yaml:"old,omitempty"`
+	New   string `json:"new" yaml:"new"`
+}
+
+// planTestDefinitionChanges matches specs to existing test definitions by
+// name and returns the change each spec makes
+func planTestDefinitionChanges(specs []testDefinitionSpec, existing []TestDefinition) ([]testDefinitionChange, error) {
+	byName := make(map[string]*TestDefinition, len(existing))
+	for i := range existing {
+		byName[existing[i].Name] = &existing[i]
+	}
+
+	changes := make([]testDefinitionChange, len(specs))
+	for i, spec := range specs {
+		test, ok := byName[spec.Name]
+		change := testDefinitionChange{Name: spec.Name, spec: spec, existing: test}
+		switch {
+		case !ok:
+			if spec.Command == "" {
+				return nil, fmt.Errorf("test %s: command is required for new tests", spec.Name)
+			}
+			change.Action = testDefCreate
+			change.Fields = diffTestDefinition(spec, &TestDefinition{})
+		default:
+			change.Fields = diffTestDefinition(spec, test)
+			change.Action = testDefUpdate
+			if len(change.Fields) == 0 {
+				change.Action = testDefUnchanged
+			}
+		}
+		changes[i] = change
+	}
+	return changes, nil
+}
+
+// diffTestDefinition returns the fields declared by spec that differ from
+// the test definition
+func diffTestDefinition(spec testDefinitionSpec, test *TestDefinition) []fieldChange {
+	var fields []fieldChange
+	add := func(field, old, new string) {
+		if old != new {
+			fields = append(fields, fieldChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	if spec.Command != "" {
+		add("command", test.Command, spec.Command)
+	}
+	if spec.ResultFormat != "" {
+		add("result_format", formatResultFormat(test.ResultFormat), formatResultFormat(spec.ResultFormat))
+	}
+	if timeout := spec.timeout(); timeout != nil {
+		add("timeout", formatTimeout(test.Timeout), formatTimeout(timeout))
+	}
+	if len(spec.Tags) > 0 && !slices.Equal(spec.Tags, test.Tags) {
+		add("tags", strings.Join(test.Tags, ", "), strings.Join(spec.Tags, ", "))
+	}
+	if len(spec.Env) > 0 && !maps.Equal(spec.Env, test.Environment) {
+		add("env", formatMap(test.Environment), formatMap(spec.Env))
+	}
+	if len(spec.RequiredLabels) > 0 && !maps.Equal(spec.RequiredLabels, test.LabelSelector) {
+		add("required_labels", formatMap(test.LabelSelector), formatMap(spec.RequiredLabels))
+	}
+	if len(spec.Architectures) > 0 && !slices.Equal(spec.Architectures, test.Architectures) {
+		add("architectures", strings.Join(test.Architectures, ", "), strings.Join(spec.Architectures, ", "))
+	}
+	if spec.MaxRetries != nil {
+		add("max_retries", fmt.Sprintf("%d", test.RetryCount), fmt.Sprintf("%d", *spec.MaxRetries))
+	}
+	if len(spec.ArtifactPaths) > 0 && !slices.Equal(spec.ArtifactPaths, test.ArtifactPaths) {
+		add("artifact_paths", strings.Join(test.ArtifactPaths, ", "), strings.Join(spec.ArtifactPaths, ", "))
+	}
+	if spec.ArtifactBudget != nil {
+		add("artifact_budget", formatArtifactBudget(test.ArtifactBudget), formatArtifactBudget(spec.ArtifactBudget))
+	}
+	if spec.Owner != "" {
+		add("owner", test.Owner, spec.Owner)
+	}
+	return fields
+}
+
+// printTestDefinitionChanges prints the changes applying a file makes
+func printTestDefinitionChanges(changes []testDefinitionChange) {
+	for _, c := range changes {
+		switch c.Action {
+		case testDefCreate:
+			fmt.Printf("%s %s\n", Green("+"), Bold(c.Name))
+			for _, f := range c.Fields {
+				fmt.Printf("    %s: %s\n", f.Field, Green(f.New))
+			}
+		case testDefUpdate:
+			fmt.Printf("%s %s\n", Yellow("~"), Bold(c.Name))
+			for _, f := range c.Fields {
+				old := f.Old
+				if old == "" {
+					old = "(none)"
+				}
+				fmt.Printf("    %s: %s → %s\n", f.Field, Red(old), Green(f.New))
+			}
+		default:
+			fmt.Printf("  %s %s\n", c.Name, Dim("(unchanged)"))
+		}
+	}
+}
+
+// resultFormatParam converts a short result format such as "junit" to its
+// API enum name
+func resultFormatParam(format string) string {
+	format = strings.ToUpper(format)
+	if strings.HasPrefix(format, "RESULT_FORMAT_") {
+		return format
+	}
+	return "RESULT_FORMAT_" + format
+}
+
+// formatResultFormat returns a human-readable result format
+func formatResultFormat(format string) string {
+	format = strings.ToLower(strings.TrimPrefix(strings.ToUpper(format), "RESULT_FORMAT_"))
+	if format == "" || format == "unspecified" {
+		return "-"
+	}
+	return format
+}
+
+// formatTimeout returns a timeout as a duration such as "10m0s"
+func formatTimeout(d *Duration) string {
+	if d == nil || d.Seconds == 0 {
+		return ""
+	}
+	return (time.Duration(d.Seconds) * time.Second).String()
+}
+
+// formatMap returns a map as sorted key=value pairs
+func formatMap(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// formatArtifactBudget returns the limits of an artifact budget
+func formatArtifactBudget(b *ArtifactBudget) string {
+	if b == nil || (b.MaxBytes == 0 && b.MaxFiles == 0) {
+		return "unlimited"
+	}
+	var limits []string
+	if b.MaxBytes > 0 {
+		limits = append(limits, formatBytes(b.MaxBytes))
+	}
+	if b.MaxFiles > 0 {
+		limits = append(limits, fmt.Sprintf("%d files", b.MaxFiles))
+	}
+	return strings.Join(limits, ", ")
+}
+
+func init() {
+	// List command flags
+	testDefListCmd.Flags().String("tags", "", "Comma-separated list of tags to filter by")
+	testDefListCmd.Flags().Bool("all", false, "Include disabled test definitions")
+	testDefListCmd.Flags().Int("limit", 100, "Maximum number of results")
+
+	// Create command flags
+	testDefCreateCmd.Flags().String("name", "", "Test name (required)")
+	testDefCreateCmd.Flags().String("command", "", "Command running the test (required)")
+	testDefCreateCmd.Flags().String("result-format", "", "Result format (junit, jest, playwright, go_test, tap, json)")
+	testDefCreateCmd.Flags().Duration("timeout", 0, "Test timeout (e.g. 10m)")
+	testDefCreateCmd.Flags().String("tags", "", "Comma-separated list of tags")
+	testDefCreateCmd.Flags().StringToString("env", nil, "Environment variables (KEY=VALUE, repeatable)")
+	testDefCreateCmd.Flags().String("artifact-paths", "", "Comma-separated artifact patterns")
+	testDefCreateCmd.Flags().Int("retries", 0, "Number of retry attempts")
+	testDefCreateCmd.Flags().StringToString("labels", nil, "Labels an agent must carry (KEY=VALUE, repeatable)")
+	testDefCreateCmd.Flags().String("arch", "", "Comma-separated CPU architectures to run on")
+	testDefCreateCmd.Flags().Int64("artifact-max-bytes", 0, "Maximum total artifact size per run in bytes")
+	testDefCreateCmd.Flags().Int("artifact-max-files", 0, "Maximum number of artifacts per run")
+
+	// Sync command flags
+	testDefSyncCmd.Flags().StringP("file", "f", "", "Apply test definitions from a local YAML file")
+	testDefSyncCmd.Flags().String("branch", "", "Branch to sync from (without --file)")
+	testDefSyncCmd.Flags().Bool("dry-run", false, "Show the changes without applying them")
+	testDefSyncCmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation")
+
+	// Add subcommands
+	testDefCmd.AddCommand(testDefListCmd)
+	testDefCmd.AddCommand(testDefCreateCmd)
+	testDefCmd.AddCommand(testDefSyncCmd)
+}
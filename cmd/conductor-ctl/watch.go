@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+
+	ws "github.com/conductor/conductor/internal/websocket"
+)
+
+// RunUpdate is a run status update pushed by the control plane
+type RunUpdate = ws.RunUpdatePayload
+
+// RunSubscription receives the run updates of a WebSocket room
+type RunSubscription struct {
+	conn      *websocket.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// runRoom returns the room receiving the updates of one run
+func runRoom(runID string) string {
+	return ws.RoomName(ws.RoomTypeRun, runID)
+}
+
+// runsRoom returns the room receiving the run updates of a service, or of
+// all services if serviceID is empty
+func runsRoom(serviceID string) string {
+	if serviceID != "" {
+		return ws.RoomName(ws.RoomTypeService, serviceID)
+	}
+	return ws.RoomName(ws.RoomTypeGlobal, "runs")
+}
+
+// websocketURL returns the WebSocket endpoint of the server
+func (c *Client) websocketURL() string {
+	switch {
+	case strings.HasPrefix(c.baseURL, "https://"):
+		return "wss://" + strings.TrimPrefix(c.baseURL, "https://") + "/ws"
+	default:
+		return "ws://" + strings.TrimPrefix(c.baseURL, "http://") + "/ws"
+	}
+}
+
+// SubscribeRuns connects to the server's WebSocket endpoint and subscribes
+// to the run updates of a room, such as "run:<id>", "service:<id>" or
+// "global:runs". The subscription is closed when ctx is done.
+func (c *Client) SubscribeRuns(ctx context.Context, room string) (*RunSubscription, error) {
+	header := http.Header{}
+	if c.token != "" {
+		header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, c.websocketURL(), header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", c.websocketURL(), err)
+	}
+
+	msg, err := ws.NewMessage(ws.MessageTypeSubscribe, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	msg.Room = room
+	if err := conn.WriteJSON(msg); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", room, err)
+	}
+
+	sub := &RunSubscription{conn: conn, done: make(chan struct{})}
+	go func() {
+		select {
+		case <-ctx.Done():
+			sub.Close()
+		case <-sub.done:
+		}
+	}()
+	return sub, nil
+}
+
+// Next waits for the next run update. Other messages are skipped.
+func (s *RunSubscription) Next() (*RunUpdate, error) {
+	for {
+		var msg ws.Message
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			return nil, err
+		}
+
+		switch msg.Type {
+		case ws.MessageTypeRunUpdate:
+			var update RunUpdate
+			if err := json.Unmarshal(msg.Payload, &update); err != nil {
+				return nil, fmt.Errorf("invalid run update: %w", err)
+			}
+			return &update, nil
+		case ws.MessageTypeError:
+			var payload ws.ErrorPayload
+			_ = json.Unmarshal(msg.Payload, &payload)
+			return nil, fmt.Errorf("server error: %s", payload.Message)
+		}
+	}
+}
+
+// Close closes the subscription
+func (s *RunSubscription) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		err = s.conn.Close()
+	})
+	return err
+}
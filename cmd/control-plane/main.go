@@ -4,26 +4,42 @@ package main
 import (
 	"context"
 	"fmt"
-	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/analytics"
 	"github.com/conductor/conductor/internal/artifact"
+	"github.com/conductor/conductor/internal/audit"
+	"github.com/conductor/conductor/internal/cache"
 	"github.com/conductor/conductor/internal/config"
 	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/energy"
+	"github.com/conductor/conductor/internal/eventbus"
+	"github.com/conductor/conductor/internal/eventhook"
 	"github.com/conductor/conductor/internal/git"
+	"github.com/conductor/conductor/internal/idempotency"
+	"github.com/conductor/conductor/internal/leader"
 	"github.com/conductor/conductor/internal/notification"
+	"github.com/conductor/conductor/internal/preflight"
+	"github.com/conductor/conductor/internal/result"
+	"github.com/conductor/conductor/internal/schedule"
 	"github.com/conductor/conductor/internal/scheduler"
+	"github.com/conductor/conductor/internal/secrets"
 	"github.com/conductor/conductor/internal/server"
 	"github.com/conductor/conductor/internal/websocket"
 	"github.com/conductor/conductor/internal/wire"
+	"github.com/conductor/conductor/pkg/diagnostics"
+	conductorlog "github.com/conductor/conductor/pkg/log"
 	"github.com/conductor/conductor/pkg/metrics"
 	"github.com/conductor/conductor/pkg/tracing"
 )
@@ -35,6 +51,9 @@ var (
 	buildTime = "unknown"
 )
 
+// configWatchInterval is how often the config file is checked for changes.
+const configWatchInterval = 10 * time.Second
+
 func main() {
 	// Initialize logger
 	logger := setupLogger()
@@ -52,6 +71,15 @@ func main() {
 	if err != nil {
 		logger.Fatal().Err(err).Msg("failed to load configuration")
 	}
+	if cfg.File != "" {
+		// The config file may set the log settings too
+		_ = logCore.Levels().Set(cfg.Log.Level)
+		logger = setLogFormat(logCore.Levels(), cfg.Log.Format)
+		log.Logger = logger
+		logger.Info().Str("file", cfg.File).Msg("configuration file loaded")
+	}
+	var currentConfig atomic.Pointer[config.Config]
+	currentConfig.Store(cfg)
 
 	// Create root context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
@@ -61,6 +89,9 @@ func main() {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
+	// Reload log levels on SIGHUP
+	go logCore.Levels().ReloadOnSIGHUP(ctx, func() string { return currentConfig.Load().Log.Level }, cfg.Log.LevelFile, logger)
+
 	// Initialize metrics
 	appMetrics := metrics.NewControlPlaneMetrics()
 	logger.Info().Msg("metrics initialized")
@@ -99,17 +130,51 @@ func main() {
 		MaxConnLifetime:   cfg.Database.ConnMaxLifetime,
 		MaxConnIdleTime:   cfg.Database.ConnMaxIdleTime,
 		HealthCheckPeriod: time.Minute,
+
+		SlowQueryThreshold: cfg.Database.SlowQueryThreshold,
+		Logger:             logCore.Slog(),
+
+		ReplicaURL:           cfg.Database.ReplicaURL,
+		ReplicaMaxLag:        cfg.Database.ReplicaMaxLag,
+		ReplicaCheckInterval: cfg.Database.ReplicaCheckInterval,
 	})
 	if err != nil {
 		logger.Fatal().Err(err).Msg("failed to connect to database")
 	}
 	defer db.Close()
 
-	logger.Info().Msg("database connection established")
+	logger.Info().Bool("read_replica", cfg.Database.ReplicaURL != "").Msg("database connection established")
 
 	// Create repositories
 	repos := database.NewRepositories(db)
 
+	// Cache hot reads in Redis, if configured
+	hotCache, cacheStore, err := createCache(ctx, cfg, logger)
+	if err != nil {
+		logger.Warn().Err(err).Msg("cache not available - reading from the database")
+	}
+	if hotCache != nil {
+		defer cacheStore.Close()
+		repos.Services = cache.Services(repos.Services, hotCache)
+		repos.Agents = cache.Agents(repos.Agents, hotCache)
+		repos.Runs = cache.Runs(repos.Runs, repos.Services, hotCache)
+	}
+
+	// Forget the agents connected to this replica before it restarted
+	if n, err := repos.Connections.UnregisterReplica(ctx, cfg.Replica.ID); err != nil {
+		logger.Warn().Err(err).Msg("failed to remove stale agent connections")
+	} else if n > 0 {
+		logger.Info().Int64("count", n).Msg("removed stale agent connections")
+	}
+	logger.Info().
+		Str("replica_id", cfg.Replica.ID).
+		Str("replica_address", cfg.Replica.Address).
+		Msg("routing agent messages between replicas")
+
+	// Background jobs that must run on one replica at a time. They are
+	// started on the leader replica, see startLeaderJobs.
+	var leaderJobs []func(ctx context.Context)
+
 	// Create adapted repositories for server interfaces
 	agentRepo := wire.NewAgentRepositoryAdapter(repos.Agents)
 	runRepo := wire.NewRunRepositoryAdapter(repos.Runs)
@@ -124,15 +189,81 @@ func main() {
 		repos.Services,
 		repos.TestDefinitions,
 		repos.RunShards,
-		slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo})),
+		logCore.Slog(),
 	)
 	runScheduler := &wire.NoopScheduler{}
 
+	// Service environment sets are inherited by every test of a service
+	workScheduler.SetEnvironments(repos.Environments)
+
+	// Errored runs are rescheduled according to the service's retry policies
+	workScheduler.SetRetryPolicies(repos.RetryPolicies)
+
+	// Services pinned to an agent pool run only on its agents, within the pool's quotas
+	workScheduler.SetPools(repos.AgentPools)
+
+	// Runs of a concurrency group execute one at a time
+	workScheduler.SetConcurrencyGroups(repos.Concurrency)
+
+	// Runs queue without being assigned while scheduling is paused
+	workScheduler.SetSchedulingPauses(repos.SchedulingPauses)
+
+	// Runs of services and agent pools in maintenance wait for the window to end
+	workScheduler.SetMaintenanceWindows(repos.Maintenance)
+
+	// Enable per-service deploy keys when an encryption key is configured
+	var deployKeyCipher *secrets.Cipher
+	if cfg.Git.DeployKeyEncryptionKey != "" {
+		deployKeyCipher, err = secrets.NewCipherFromBase64(cfg.Git.DeployKeyEncryptionKey)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to initialize deploy key encryption")
+		}
+		workScheduler.SetDeployKeys(repos.DeployKeys, deployKeyCipher)
+		logger.Info().Msg("service deploy keys enabled")
+	}
+
+	// Report pull request run results back to the git provider
+	var statusReporter server.RunStatusReporter
+	gitStatusReporter, err := createStatusReporter(cfg, repos.GitCredentials, repos.Results, deployKeyCipher, logger)
+	if err != nil {
+		logger.Warn().Err(err).Msg("commit status reporting not available")
+	} else if gitStatusReporter != nil {
+		statusReporter = gitStatusReporter
+	}
+
+	// Create WebSocket hub for real-time updates
+	wsHub := websocket.NewHub(logger)
+
+	// Create WebSocket event publisher
+	// The publisher is available for services to broadcast real-time updates.
+	// It can be injected into services that need to publish events.
+	wsPublisher := websocket.NewPublisher(wsHub, logger)
+
+	// Publish domain events to the event bus as well, if configured
+	var events websocket.EventPublisher = wsPublisher
+	eventBus, err := createEventBus(ctx, cfg, logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create event bus publisher")
+	}
+	if eventBus != nil {
+		events = websocket.MultiPublisher{wsPublisher, eventBus}
+	}
+	workScheduler.SetEvents(events)
+
 	// Create git syncer (if configured)
-	gitSyncer, err := createGitSyncer(cfg, repos.TestDefinitions, logger)
+	gitSyncer, err := createGitSyncer(cfg, repos.TestDefinitions, events, logger)
 	if err != nil {
 		logger.Warn().Err(err).Msg("git syncer not available - sync functionality disabled")
 		gitSyncer = &wire.NoopGitSyncer{}
+	} else {
+		periodicSyncLogger := logCore.Slog()
+		periodicSyncer := git.NewPeriodicSyncer(
+			gitSyncer,
+			repos.Syncs,
+			git.PeriodicSyncConfig{Interval: cfg.Git.SyncInterval},
+			periodicSyncLogger,
+		)
+		leaderJobs = append(leaderJobs, periodicSyncer.Start)
 	}
 
 	// Create artifact storage
@@ -141,39 +272,57 @@ func main() {
 		logger.Fatal().Err(err).Msg("failed to create artifact storage")
 	}
 
+	// Serve downloads through the control plane if the object store is not
+	// reachable from user networks
+	var artifactDownloads *server.ArtifactDownloadProxy
+	if cfg.Storage.DownloadProxy {
+		artifactDownloads = server.NewArtifactDownloadProxy(cfg.Storage.DownloadProxyURL, cfg.Auth.JWTSecret, artifactStorage, logger)
+		artifactStorageAdapter = artifactDownloads
+	}
+
+	var cleanupService *artifact.CleanupService
 	if cfg.Storage.CleanupEnabled {
-		cleanupLogger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-			Level: slog.LevelInfo,
-		})).With("component", "artifact_cleanup")
-		cleanupService := artifact.NewCleanupService(
+		cleanupLogger := logCore.Slog().With("component", "artifact_cleanup")
+		cleanupService = artifact.NewCleanupService(
 			repos.Artifacts,
 			artifactStorage,
 			artifact.CleanupConfig{
-				Interval:  cfg.Storage.CleanupInterval,
-				Retention: cfg.Storage.RetentionPeriod,
-				BatchSize: cfg.Storage.CleanupBatchSize,
+				Interval:        cfg.Storage.CleanupInterval,
+				Retention:       cfg.Storage.RetentionPeriod,
+				BatchSize:       cfg.Storage.CleanupBatchSize,
+				BlobGracePeriod: cfg.Storage.BlobGracePeriod,
 			},
 			cleanupLogger,
 		)
-		cleanupService.Start(ctx)
+		cleanupService.SetBlobRepository(repos.ArtifactBlobs)
+		leaderJobs = append(leaderJobs, cleanupService.Start)
+	}
+
+	var artifactRestorer server.ArtifactRestorer
+	if cfg.Storage.ArchiveEnabled {
+		tieringLogger := logCore.Slog().With("component", "artifact_tiering")
+		tieringService := artifact.NewTieringService(
+			repos.Artifacts,
+			artifactStorage,
+			artifact.TieringConfig{
+				Interval:     cfg.Storage.ArchiveInterval,
+				ArchiveAfter: cfg.Storage.ArchiveAfter,
+				StorageClass: cfg.Storage.ArchiveStorageClass,
+				BatchSize:    cfg.Storage.CleanupBatchSize,
+				RestoreDays:  cfg.Storage.RestoreDays,
+				RestoreTier:  cfg.Storage.RestoreTier,
+			},
+			tieringLogger,
+		)
+		leaderJobs = append(leaderJobs, tieringService.Start)
+		artifactRestorer = tieringService
 	}
 
 	// Create JWT validator
 	jwtValidator := server.NewJWTValidator(cfg.Auth.JWTSecret)
 
-	// Create WebSocket hub for real-time updates
-	wsHub := websocket.NewHub(logger)
-
-	// Create WebSocket event publisher
-	// The publisher is available for services to broadcast real-time updates.
-	// It can be injected into services that need to publish events.
-	wsPublisher := websocket.NewPublisher(wsHub, logger)
-	_ = wsPublisher // Used by services for real-time event publishing
-
 	// Create notification service
-	notificationLogger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	})).With("component", "notification_service")
+	notificationLogger := logCore.Slog().With("component", "notification_service")
 
 	notificationConfig := notification.DefaultConfig()
 	// BaseURL can be set via environment or config if needed for notification links
@@ -189,7 +338,146 @@ func main() {
 		SkipVerify:  cfg.Notifications.Email.SkipVerify,
 		ConnTimeout: cfg.Notifications.Email.ConnTimeout,
 	}
+	notificationConfig.DedupWindow = cfg.Notifications.DedupWindow
+	notificationConfig.CollapseRules = cfg.Notifications.CollapseRules
+	notificationConfig.DeadLetterInterval = cfg.Notifications.DeadLetterInterval
+	notificationConfig.DeadLetterMaxAttempts = cfg.Notifications.DeadLetterMaxAttempts
 	notificationService := notification.NewService(notificationConfig, repos.Notifications, notificationLogger)
+	notificationService.SetMetrics(appMetrics.ControlPlane)
+	notificationService.SetMaintenanceWindows(repos.Maintenance)
+	leaderJobs = append(leaderJobs, notificationService.StartDeadLetterDrainer)
+
+	// Flag passed runs that take unusually long or short
+	var durationAnomalies server.DurationAnomalyDetector
+	if cfg.Notifications.DurationAnomaly.Enabled {
+		durationAnomalies = notification.NewDurationAnomalyDetector(repos.Runs, notification.DurationAnomalyConfig{
+			Sigma:    cfg.Notifications.DurationAnomaly.Sigma,
+			Window:   cfg.Notifications.DurationAnomaly.Window,
+			MinRuns:  cfg.Notifications.DurationAnomaly.MinRuns,
+			MinDelta: cfg.Notifications.DurationAnomaly.MinDelta,
+		}, notificationLogger)
+	}
+
+	// Mark agents offline when their heartbeats time out and requeue their work
+	heartbeatLogger := logCore.Slog()
+	heartbeatSweeper := scheduler.NewHeartbeatSweeper(
+		repos.Agents,
+		repos.RunShards,
+		events,
+		notificationService,
+		scheduler.HeartbeatSweepConfig{HeartbeatTimeout: cfg.Agent.HeartbeatTimeout},
+		heartbeatLogger,
+	)
+	leaderJobs = append(leaderJobs, heartbeatSweeper.Start)
+
+	// Export the agent count, running shards and quota of every agent pool
+	poolMetrics := scheduler.NewPoolMetricsReporter(repos.AgentPools, appMetrics.ControlPlane, 0, heartbeatLogger)
+	poolMetrics.Start(ctx)
+
+	// Compute service reports and keep the statistics they are read from fresh
+	reportLogger := logCore.Slog()
+	reporter := analytics.NewReporter(repos.Analytics, reportLogger)
+	if cfg.Reports.RefreshInterval > 0 {
+		leaderJobs = append(leaderJobs, func(ctx context.Context) {
+			reporter.Start(ctx, cfg.Reports.RefreshInterval)
+		})
+	}
+
+	// Delete audit logs past their retention
+	auditLogger := logCore.Slog()
+	leaderJobs = append(leaderJobs, audit.NewPruner(repos.AuditLogs, cfg.Audit.Retention, auditLogger).Start)
+
+	// Maintain the monthly partitions of test results and archive expired months
+	archiverLogger := logCore.Slog()
+	resultArchiver := result.NewArchiver(
+		repos.ResultPartitions,
+		artifactStorage,
+		result.ArchiverConfig{
+			Interval:        cfg.Results.MaintenanceInterval,
+			Retention:       cfg.Results.Retention,
+			PartitionsAhead: cfg.Results.PartitionsAhead,
+			Export:          cfg.Results.ArchiveEnabled,
+		},
+		archiverLogger,
+	)
+	leaderJobs = append(leaderJobs, resultArchiver.Start)
+
+	// Record runs and tests that got slower than the recent runs of their branch
+	var durationRegressions server.DurationRegressionDetector
+	if cfg.Regressions.Enabled {
+		durationRegressions = analytics.NewRegressionDetector(repos.Regressions, analytics.RegressionConfig{
+			Sigma:        cfg.Regressions.Sigma,
+			Window:       cfg.Regressions.Window,
+			MinRuns:      cfg.Regressions.MinRuns,
+			MinDelta:     cfg.Regressions.MinDelta,
+			TestMinDelta: cfg.Regressions.TestMinDelta,
+		}, reportLogger)
+	}
+
+	// Deliver run lifecycle events to the configured event webhooks. The
+	// dispatcher also runs without endpoints, to mark recorded events
+	// dispatched and prune them.
+	eventWebhookEndpoints := make([]eventhook.Endpoint, len(cfg.EventWebhooks.Endpoints))
+	for i, endpoint := range cfg.EventWebhooks.Endpoints {
+		events := make([]database.RunEventType, len(endpoint.Events))
+		for j, event := range endpoint.Events {
+			events[j] = database.RunEventType(event)
+		}
+		eventWebhookEndpoints[i] = eventhook.Endpoint{
+			Name:   endpoint.Name,
+			URL:    endpoint.URL,
+			Secret: endpoint.Secret,
+			Events: events,
+		}
+	}
+	eventDispatcher := eventhook.NewDispatcher(repos.EventWebhooks, eventhook.Config{
+		Endpoints:    eventWebhookEndpoints,
+		Interval:     cfg.EventWebhooks.Interval,
+		Timeout:      cfg.EventWebhooks.Timeout,
+		MaxAttempts:  cfg.EventWebhooks.MaxAttempts,
+		RetryBackoff: cfg.EventWebhooks.RetryBackoff,
+		Retention:    cfg.EventWebhooks.Retention,
+	}, reportLogger)
+	eventDispatcher.Start(ctx)
+
+	// Throttle run triggers per service and branch when configured
+	var triggerThrottle *server.TriggerThrottle
+	if cfg.Trigger.ThrottleWindow > 0 {
+		triggerThrottle = server.NewTriggerThrottle(cfg.Trigger.ThrottleRuns, cfg.Trigger.ThrottleWindow, appMetrics.ControlPlane)
+		logger.Info().
+			Int("runs", cfg.Trigger.ThrottleRuns).
+			Dur("window", cfg.Trigger.ThrottleWindow).
+			Msg("run trigger throttling enabled")
+	}
+
+	// Answer retried run creation requests with the run they created
+	idempotencyLogger := logCore.Slog()
+	idempotencyStore := idempotency.NewStore(repos.IdempotencyKeys, cfg.Trigger.IdempotencyTTL, idempotencyLogger)
+	leaderJobs = append(leaderJobs, idempotency.NewPruner(repos.IdempotencyKeys, idempotencyLogger).Start)
+
+	// Verify the commit, images and secrets of new runs before queueing them
+	var runPreflight server.RunPreflight
+	preflightChecker, err := createPreflightChecker(cfg, repos.GitCredentials, deployKeyCipher, logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create pre-flight checks")
+	}
+	if preflightChecker != nil {
+		runPreflight = preflightChecker
+	}
+
+	// Estimate the energy and carbon emissions of runs from agent resource usage
+	energyModel := energy.Model{
+		WattsPerCore:     cfg.Energy.WattsPerCore,
+		WattsPerMemoryGB: cfg.Energy.WattsPerMemoryGB,
+	}
+	carbonIntensity := energy.CarbonIntensity{
+		Default: cfg.Energy.CarbonIntensity,
+		Zones:   cfg.Energy.ZoneCarbonIntensity,
+	}
+	var energySampleRepo server.AgentEnergyRepository
+	if cfg.Energy.Enabled {
+		energySampleRepo = repos.Energy
+	}
 
 	// Create service dependencies with real repositories
 	services := server.Services{
@@ -197,33 +485,93 @@ func main() {
 			AgentRepo:           agentRepo,
 			RunRepo:             runRepo,
 			ResultRepo:          repos.Results,
+			ResultBatchSize:     cfg.Agent.ResultStreamBufferSize,
+			ResultFlushInterval: cfg.Agent.ResultStreamFlushInterval,
+			ArtifactRepo:        artifactRepo,
+			AnnotationRepo:      repos.Annotations,
+			TestRepo:            testDefRepo,
+			IngestionRepo:       repos.Runs,
+			MaxResultsPerRun:    cfg.Ingestion.MaxResultsPerRun,
+			MaxArtifactsPerRun:  cfg.Ingestion.MaxArtifactsPerRun,
+			MaxArtifactBytes:    cfg.Ingestion.MaxArtifactBytes,
+			Metrics:             appMetrics.ControlPlane,
 			AnalyticsRepo:       repos.Analytics,
 			ServiceRepo:         serviceRepo,
+			PoolRepo:            repos.AgentPools,
 			NotificationService: notificationService,
 			Scheduler:           workScheduler,
+			StatusReporter:      statusReporter,
+			DurationAnomalies:   durationAnomalies,
+			DurationRegressions: durationRegressions,
+			Events:              events,
 			HeartbeatTimeout:    cfg.Agent.HeartbeatTimeout,
 			ServerVersion:       version,
+			EnergyRepo:          energySampleRepo,
+			EnergyModel:         energyModel,
+			CarbonIntensity:     carbonIntensity,
+			Connections:         repos.Connections,
+			ReplicaID:           cfg.Replica.ID,
+			ReplicaAddress:      cfg.Replica.Address,
 		},
 		RunService: server.RunServiceDeps{
-			RunRepo:      runRepo,
-			RunShardRepo: repos.RunShards,
-			ServiceRepo:  serviceRepo,
-			Scheduler:    workScheduler,
+			RunRepo:         runRepo,
+			RunShardRepo:    repos.RunShards,
+			AnnotationRepo:  repos.Annotations,
+			RegressionRepo:  repos.Regressions,
+			ServiceRepo:     serviceRepo,
+			Scheduler:       workScheduler,
+			Throttle:        triggerThrottle,
+			EnergyRepo:      repos.Energy,
+			CarbonIntensity: carbonIntensity,
+			AgentRepo:       agentRepo,
+			TestRepo:        testDefRepo,
+			Preflight:       runPreflight,
+			EnvironmentRepo: repos.Environments,
+			Idempotency:     idempotencyStore,
+
+			SearchRepo:       repos.Runs,
+			ResultSearchRepo: repos.Results,
+			BranchRuns:       repos.Runs,
+			ConcurrencyRepo:  repos.Concurrency,
+			BranchRepo:       repos.Branches,
+			Approvals:        repos.Runs,
+			Metadata:         repos.Runs,
+			QuotaRepo:        repos.Quotas,
 		},
 		ServiceService: server.ServiceRegistryDeps{
-			ServiceRepo: serviceRepo,
-			TestRepo:    testDefRepo,
-			GitSyncer:   gitSyncer,
+			ServiceRepo:     serviceRepo,
+			TestRepo:        testDefRepo,
+			AgentRepo:       agentRepo,
+			GitSyncer:       gitSyncer,
+			DeployKeyRepo:   repos.DeployKeys,
+			DeployKeyCipher: deployKeyCipher,
+
+			GitCredentialRepo: repos.GitCredentials,
+			EnvironmentRepo:   repos.Environments,
+			TriggerRuleRepo:   repos.TriggerRules,
+			QuotaRepo:         repos.Quotas,
+			RetryPolicyRepo:   repos.RetryPolicies,
+			PoolRepo:          repos.AgentPools,
+			BranchRepo:        repos.Branches,
+			RunRepo:           runRepo,
+			SyncRepo:          repos.Syncs,
+			SyncInterval:      cfg.Git.SyncInterval,
+			ScheduleRepo:      repos.Schedules,
 		},
 		ResultService: server.ResultServiceDeps{
 			ResultRepo:      resultRepo,
 			ArtifactRepo:    artifactRepo,
 			RunRepo:         runRepo,
 			ArtifactStorage: artifactStorageAdapter,
+
+			ArtifactRestorer: artifactRestorer,
+			ArtifactReader:   artifactStorage,
+			ServiceRepo:      serviceRepo,
 		},
 		HealthService: server.HealthServiceDeps{
-			DB:        db,
-			StartTime: time.Now(),
+			DB:              db,
+			ArtifactStorage: artifactStorage,
+			StartTime:       time.Now(),
 			Version: server.VersionInfo{
 				Version:   version,
 				Commit:    commit,
@@ -234,7 +582,36 @@ func main() {
 		NotificationService: server.NotificationServiceDeps{
 			Repo:                repos.Notifications,
 			NotificationService: notificationService,
+			TestRepo:            repos.TestDefinitions,
+			RunRepo:             runRepo,
+			ResultRepo:          resultRepo,
+		},
+		ReportService: server.ReportServiceDeps{
+			Reporter: reporter,
+		},
+		AuditService: server.AuditServiceDeps{
+			Repo: repos.AuditLogs,
+		},
+		AdminService: server.AdminServiceDeps{
+			Pauses:      repos.SchedulingPauses,
+			Maintenance: repos.Maintenance,
 		},
+		TenancyService: server.TenancyServiceDeps{
+			OrganizationRepo: repos.Organizations,
+			ProjectRepo:      repos.Projects,
+		},
+		PreferencesService: server.PreferencesServiceDeps{
+			Repo: repos.Preferences,
+		},
+	}
+
+	// Check that the git provider is reachable if enabled
+	if cfg.Git.HealthCheckEnabled {
+		gitHealth, err := git.NewHealthChecker(git.Config{Provider: cfg.Git.Provider, BaseURL: cfg.Git.BaseURL})
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to create git provider health check")
+		}
+		services.HealthService.GitProvider = gitHealth
 	}
 
 	// Parse build time
@@ -244,6 +621,16 @@ func main() {
 		}
 	}
 
+	// Trigger scheduled runs when they are due
+	scheduleLogger := logCore.Slog()
+	scheduleRunner := schedule.NewRunner(
+		repos.Schedules,
+		&scheduledRunTrigger{runs: server.NewRunServiceServer(services.RunService, logger)},
+		0,
+		scheduleLogger,
+	)
+	leaderJobs = append(leaderJobs, scheduleRunner.Start)
+
 	// Create gRPC server
 	grpcConfig := server.GRPCConfig{
 		Port:             cfg.Server.GRPCPort,
@@ -253,6 +640,12 @@ func main() {
 		EnableTracing:    tracer != nil,
 		Metrics:          appMetrics.ControlPlane,
 	}
+	if cfg.Audit.Enabled {
+		grpcConfig.AuditLog = repos.AuditLogs
+	}
+	if cfg.RateLimit.Enabled {
+		grpcConfig.RateLimiter = server.NewRateLimiter(cfg.RateLimit.RequestsPerSecond, cfg.RateLimit.Burst)
+	}
 	grpcServer := server.NewGRPCServer(grpcConfig, services, jwtValidator, logger)
 
 	// Create HTTP server with WebSocket support
@@ -267,6 +660,9 @@ func main() {
 		WebSocketPath:  "/ws",
 		EnableTracing:  tracer != nil,
 		Metrics:        appMetrics.ControlPlane,
+		EnableUI:       cfg.Server.UIEnabled,
+		UIPath:         cfg.Server.UIPath,
+		EnableAPIDocs:  cfg.Server.APIDocsEnabled,
 	}
 
 	// Create WebSocket authenticator that wraps JWT validator
@@ -276,14 +672,35 @@ func main() {
 	if err != nil {
 		logger.Fatal().Err(err).Msg("failed to create HTTP server")
 	}
+	if cfg.Audit.Enabled {
+		httpServer.SetAuditLog(repos.AuditLogs)
+	}
+	if hotCache != nil && cfg.Redis.ResponseCacheTTL > 0 {
+		httpServer.SetResponseCache(hotCache.Responses(cfg.Redis.ResponseCacheTTL))
+	}
+	if artifactDownloads != nil {
+		httpServer.SetArtifactDownloads(artifactDownloads)
+	}
+	if cfg.RateLimit.Enabled {
+		httpServer.SetRateLimiters(
+			server.NewRateLimiter(cfg.RateLimit.RequestsPerSecond, cfg.RateLimit.Burst),
+			server.NewRateLimiter(cfg.RateLimit.WebhookRequestsPerSecond, cfg.RateLimit.WebhookBurst),
+		)
+	}
 
 	// Create and configure webhook handler if enabled
 	if cfg.WebhooksEnabled() {
 		webhookCfg := server.WebhookConfig{
-			GithubSecret:    cfg.Git.WebhookSecret,
-			GitlabSecret:    cfg.Git.GitLabWebhookSecret,
-			BitbucketSecret: cfg.Git.BitbucketWebhookSecret,
-			BaseURL:         cfg.Webhook.BaseURL,
+			GithubSecret:       cfg.Git.WebhookSecret,
+			GitlabSecret:       cfg.Git.GitLabWebhookSecret,
+			BitbucketSecret:    cfg.Git.BitbucketWebhookSecret,
+			GiteaSecret:        cfg.Git.GiteaWebhookSecret,
+			AzureDevOpsSecret:  cfg.Git.AzureDevOpsWebhookSecret,
+			BaseURL:            cfg.Webhook.BaseURL,
+			Strict:             cfg.Webhook.Strict,
+			TimestampTolerance: cfg.Webhook.TimestampTolerance,
+			ReplayWindow:       cfg.Webhook.ReplayWindow,
+			Metrics:            appMetrics.ControlPlane,
 		}
 
 		// Create service repository adapter for webhook handler
@@ -295,12 +712,26 @@ func main() {
 			runScheduler,
 			logger,
 		)
+		webhookHandler.SetTriggerRules(repos.TriggerRules)
+		webhookHandler.SetThrottle(triggerThrottle)
+		webhookHandler.SetBranches(repos.Branches)
+		changeResolver, err := createChangeResolver(cfg, repos.GitCredentials, deployKeyCipher)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to create git change resolver")
+		}
+		if changeResolver != nil {
+			webhookHandler.SetChangedPaths(changeResolver)
+		}
 		httpServer.SetWebhookHandler(webhookHandler)
 
 		logger.Info().
 			Bool("github_secret_set", cfg.Git.WebhookSecret != "").
 			Bool("gitlab_secret_set", cfg.Git.GitLabWebhookSecret != "").
 			Bool("bitbucket_secret_set", cfg.Git.BitbucketWebhookSecret != "").
+			Bool("gitea_secret_set", cfg.Git.GiteaWebhookSecret != "").
+			Bool("azure_devops_secret_set", cfg.Git.AzureDevOpsWebhookSecret != "").
+			Bool("strict", cfg.Webhook.Strict).
+			Dur("replay_window", cfg.Webhook.ReplayWindow).
 			Msg("webhook handler configured")
 	}
 
@@ -312,6 +743,25 @@ func main() {
 		Path:         "/metrics",
 	}
 	metricsServer := server.NewMetricsServer(metricsServerCfg, appMetrics, logger)
+	metricsServer.SetLogLevels(logCore.Levels())
+	if cfg.Observability.DiagnosticsEnabled {
+		diag := diagnostics.New(cfg.Observability.DiagnosticsToken)
+		diag.Register("agent_streams", func() any { return grpcServer.AgentStreams() })
+		diag.Register("websocket", func() any { return wsHub.Stats() })
+		diag.Register("notifications", func() any { return notificationService.QueueStats() })
+		metricsServer.SetDiagnostics(diag)
+		logger.Info().Msg("diagnostics endpoints enabled on the metrics server")
+	}
+
+	// Apply changes to the config file that take effect without a restart
+	go config.Watch(ctx, cfg, configWatchInterval, func(prev, next *config.Config, err error) {
+		if err != nil {
+			logger.Error().Err(err).Str("file", cfg.File).Msg("failed to reload configuration, keeping the previous one")
+			return
+		}
+		currentConfig.Store(next)
+		reloadConfig(logger, prev, next, cleanupService, notificationService)
+	})
 
 	// Channel to collect errors from servers
 	errCh := make(chan error, 5)
@@ -327,6 +777,9 @@ func main() {
 	}
 	logger.Info().Msg("notification service started")
 
+	// Start the background jobs on the leader replica
+	startLeaderJobs(ctx, cfg.Leader, db, leaderJobs, appMetrics.ControlPlane)
+
 	// Start gRPC server
 	go func() {
 		if err := grpcServer.Start(ctx); err != nil {
@@ -367,6 +820,17 @@ func main() {
 
 	// Initiate graceful shutdown
 	logger.Info().Msg("initiating graceful shutdown")
+
+	// Move the agents to the other replicas while the background jobs and
+	// result processing still run
+	if cfg.Server.DrainTimeout > 0 {
+		drainCtx, drainCancel := context.WithTimeout(context.Background(), cfg.Server.DrainTimeout)
+		if err := grpcServer.Drain(drainCtx, cfg.Server.DrainJitter); err != nil {
+			logger.Warn().Err(err).Msg("agent work streams did not drain in time")
+		}
+		drainCancel()
+	}
+
 	cancel()
 
 	// Create shutdown context with timeout
@@ -398,6 +862,13 @@ func main() {
 		shutdownErr = err
 	}
 
+	// Drop triggers still waiting for the throttle window
+	if triggerThrottle != nil {
+		if dropped := triggerThrottle.Stop(); dropped > 0 {
+			logger.Warn().Int("dropped", dropped).Msg("dropped throttled run triggers on shutdown")
+		}
+	}
+
 	// Shutdown notification service
 	if err := notificationService.Stop(shutdownCtx); err != nil {
 		logger.Error().Err(err).Msg("notification service shutdown error")
@@ -410,6 +881,14 @@ func main() {
 		shutdownErr = err
 	}
 
+	// Flush the events still waiting for the event bus
+	if eventBus != nil {
+		if err := eventBus.Close(shutdownCtx); err != nil {
+			logger.Error().Err(err).Msg("event bus shutdown error")
+			shutdownErr = err
+		}
+	}
+
 	if shutdownErr != nil {
 		logger.Error().Msg("shutdown completed with errors")
 		os.Exit(1)
@@ -418,51 +897,72 @@ func main() {
 	logger.Info().Msg("shutdown completed successfully")
 }
 
-// setupLogger initializes the zerolog logger.
+// logCore is the log output of the control plane, from which its zerolog
+// and slog loggers are made. Its levels can be changed at runtime through the
+// metrics server or SIGHUP.
+var logCore *conductorlog.Core
+
+// setupLogger initializes the log output and returns the zerolog logger.
 func setupLogger() zerolog.Logger {
+	// Set log levels; invalid ones are reported by config validation
+	levels, err := conductorlog.NewLevels(os.Getenv("CONDUCTOR_LOG_LEVEL"))
+	if err != nil {
+		levels, _ = conductorlog.NewLevels("info")
+	}
+
 	// Default to JSON logging for production
-	format := os.Getenv("CONDUCTOR_LOG_FORMAT")
-	level := os.Getenv("CONDUCTOR_LOG_LEVEL")
-
-	// Set log level
-	var logLevel zerolog.Level
-	switch level {
-	case "debug":
-		logLevel = zerolog.DebugLevel
-	case "warn":
-		logLevel = zerolog.WarnLevel
-	case "error":
-		logLevel = zerolog.ErrorLevel
-	default:
-		logLevel = zerolog.InfoLevel
+	return setLogFormat(levels, os.Getenv("CONDUCTOR_LOG_FORMAT"))
+}
+
+// setLogFormat sets the log output to a format, filtered by levels, and
+// returns the zerolog logger.
+func setLogFormat(levels *conductorlog.Levels, format string) zerolog.Logger {
+	logCore = conductorlog.NewCore(conductorlog.Output(os.Stdout, format), levels).
+		With("service", "control-plane")
+	return logCore.Zerolog()
+}
+
+// reloadConfig applies the settings of a reloaded configuration that take
+// effect without a restart, and warns about the others that changed.
+// cleanupService is nil when artifact cleanup is disabled.
+func reloadConfig(logger zerolog.Logger, prev, next *config.Config, cleanupService *artifact.CleanupService, notificationService *notification.Service) {
+	changed := next.Changed(prev)
+	if len(changed) == 0 {
+		return
 	}
 
-	zerolog.SetGlobalLevel(logLevel)
+	var applied, restart []string
+	for _, key := range changed {
+		if config.Reloadable(key) {
+			applied = append(applied, key)
+		} else {
+			restart = append(restart, key)
+		}
+	}
 
-	// Set output format
-	var logger zerolog.Logger
-	if format == "console" {
-		logger = zerolog.New(zerolog.ConsoleWriter{
-			Out:        os.Stdout,
-			TimeFormat: time.RFC3339,
-		})
-	} else {
-		logger = zerolog.New(os.Stdout)
+	if next.Log.Level != prev.Log.Level {
+		if err := logCore.Levels().Set(next.Log.Level); err != nil {
+			logger.Error().Err(err).Msg("failed to set log levels")
+		}
+	}
+	if cleanupService != nil {
+		cleanupService.SetSchedule(next.Storage.CleanupInterval, next.Storage.RetentionPeriod)
 	}
+	notificationService.SetDedupWindow(next.Notifications.DedupWindow)
+	notificationService.SetCollapseRules(next.Notifications.CollapseRules)
 
-	return logger.With().
-		Timestamp().
-		Str("service", "control-plane").
-		Logger()
+	if len(applied) > 0 {
+		logger.Info().Strs("settings", applied).Msg("configuration reloaded")
+	}
+	if len(restart) > 0 {
+		logger.Warn().Strs("settings", restart).Msg("changed settings take effect after a restart")
+	}
 }
 
 // createArtifactStorage creates the artifact storage backend.
 func createArtifactStorage(cfg *config.Config, logger zerolog.Logger) (*artifact.Storage, server.ArtifactStorage, error) {
-	// Create slog adapter for artifact storage
-	slogHandler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	})
-	slogLogger := slog.New(slogHandler).With("component", "artifact_storage")
+	// Create slog logger for artifact storage
+	slogLogger := logCore.Slog().With("component", "artifact_storage")
 
 	// Create S3/MinIO storage
 	storageCfg := artifact.StorageConfig{
@@ -501,20 +1001,125 @@ func createArtifactStorage(cfg *config.Config, logger zerolog.Logger) (*artifact
 	return storage, wire.NewArtifactStorageAdapter(storage), nil
 }
 
+// createEventBus creates and starts the event bus publisher, or returns nil
+// if no event bus backend is configured.
+func createEventBus(ctx context.Context, cfg *config.Config, logger zerolog.Logger) (*eventbus.Publisher, error) {
+	if cfg.Events.Backend == "" {
+		return nil, nil
+	}
+
+	connectCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var sink eventbus.Sink
+	var err error
+	switch cfg.Events.Backend {
+	case "nats":
+		sink, err = eventbus.NewNATSSink(connectCtx, eventbus.NATSConfig{
+			URLs:     cfg.Events.URLs,
+			Username: cfg.Events.Username,
+			Password: cfg.Events.Password,
+			TLS:      cfg.Events.TLS,
+			Prefix:   cfg.Events.Prefix,
+			Stream:   cfg.Events.NATSStream,
+		})
+	case "kafka":
+		sink, err = eventbus.NewKafkaSink(eventbus.KafkaConfig{
+			Brokers:  cfg.Events.URLs,
+			Username: cfg.Events.Username,
+			Password: cfg.Events.Password,
+			TLS:      cfg.Events.TLS,
+			Prefix:   cfg.Events.Prefix,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported event bus backend %q", cfg.Events.Backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	slogLogger := logCore.Slog()
+	publisher := eventbus.NewPublisher(sink, eventbus.Config{
+		BufferSize:     cfg.Events.BufferSize,
+		PublishTimeout: cfg.Events.PublishTimeout,
+	}, slogLogger)
+	publisher.Start()
+
+	logger.Info().
+		Str("backend", cfg.Events.Backend).
+		Str("prefix", cfg.Events.Prefix).
+		Msg("event bus publisher initialized")
+
+	return publisher, nil
+}
+
+// createCache connects to Redis and creates the cache of hot reads, or
+// returns nil if Redis is not configured.
+func createCache(ctx context.Context, cfg *config.Config, logger zerolog.Logger) (*cache.Cache, *cache.RedisStore, error) {
+	if !cfg.RedisEnabled() {
+		return nil, nil, nil
+	}
+
+	connectCtx, cancel := context.WithTimeout(ctx, cfg.Redis.DialTimeout)
+	defer cancel()
+
+	store, err := cache.NewRedisStore(connectCtx, cache.RedisConfig{
+		URL:          cfg.Redis.URL,
+		PoolSize:     cfg.Redis.PoolSize,
+		MinIdleConns: cfg.Redis.MinIdleConns,
+		DialTimeout:  cfg.Redis.DialTimeout,
+		ReadTimeout:  cfg.Redis.ReadTimeout,
+		WriteTimeout: cfg.Redis.WriteTimeout,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	slogLogger := logCore.Slog()
+	hotCache := cache.New(store, cache.Config{TTL: cfg.Redis.CacheTTL}, slogLogger)
+
+	logger.Info().
+		Dur("ttl", cfg.Redis.CacheTTL).
+		Dur("response_ttl", cfg.Redis.ResponseCacheTTL).
+		Msg("cache initialized")
+
+	return hotCache, store, nil
+}
+
 // createGitSyncer creates the git syncer if configured.
-func createGitSyncer(cfg *config.Config, testRepo database.TestDefinitionRepository, logger zerolog.Logger) (server.GitSyncer, error) {
+func createGitSyncer(
+	cfg *config.Config,
+	testRepo database.TestDefinitionRepository,
+	events git.SyncEventPublisher,
+	logger zerolog.Logger,
+) (server.GitSyncer, error) {
 	if !cfg.GitEnabled() {
 		logger.Info().Msg("git provider not configured - using noop syncer")
 		return nil, fmt.Errorf("git credentials not configured")
 	}
 
-	// Create slog adapter for git package
-	slogHandler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	})
-	slogLogger := slog.New(slogHandler).With("component", "git_syncer")
+	// Create slog logger for git package
+	slogLogger := logCore.Slog().With("component", "git_syncer")
+
+	provider, err := createGitProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create git provider
+	// Create syncer
+	syncer := git.NewSyncer(provider, testRepo, slogLogger)
+	syncer.EnableEnvironments()
+	syncer.SetEvents(events)
+
+	logger.Info().
+		Str("provider", cfg.Git.Provider).
+		Msg("git syncer initialized")
+
+	return wire.NewGitSyncerAdapter(syncer), nil
+}
+
+// createGitProvider creates the shared git provider from the control plane configuration.
+func createGitProvider(cfg *config.Config) (git.Provider, error) {
 	var appPrivateKey string
 	if cfg.Git.AppPrivateKeyPath != "" {
 		keyBytes, err := os.ReadFile(cfg.Git.AppPrivateKeyPath)
@@ -537,15 +1142,201 @@ func createGitSyncer(cfg *config.Config, testRepo database.TestDefinitionReposit
 	if err != nil {
 		return nil, fmt.Errorf("failed to create git provider: %w", err)
 	}
+	return provider, nil
+}
 
-	// Create syncer
-	syncer := git.NewSyncer(provider, testRepo, slogLogger)
+// createStatusReporter creates the commit status reporter for pull request
+// runs. It returns nil when reporting is disabled or no credentials exist.
+func createStatusReporter(
+	cfg *config.Config,
+	credentialRepo database.GitCredentialRepository,
+	resultRepo database.ResultRepository,
+	cipher *secrets.Cipher,
+	logger zerolog.Logger,
+) (*git.StatusReporter, error) {
+	if !cfg.Git.StatusEnabled {
+		return nil, nil
+	}
+	if !cfg.GitEnabled() && cipher == nil {
+		logger.Info().Msg("no git credentials configured - commit status reporting disabled")
+		return nil, nil
+	}
+
+	// Link statuses to the embedded UI when it is served, otherwise to the API
+	targetBaseURL := strings.TrimSuffix(cfg.Webhook.BaseURL, "/")
+	if targetBaseURL != "" {
+		if cfg.Server.UIEnabled {
+			targetBaseURL += cfg.Server.UIPath + "/#"
+		} else {
+			targetBaseURL += "/api/v1"
+		}
+	}
+
+	reporter := git.NewStatusReporter(git.StatusReporterConfig{
+		BaseURL:       targetBaseURL,
+		Context:       cfg.Git.StatusContext,
+		RetryAttempts: cfg.Git.StatusRetryAttempts,
+		RetryDelay:    cfg.Git.StatusRetryDelay,
+		CheckRuns:     cfg.Git.CheckRunsEnabled,
+		Logger:        logCore.Slog(),
+	})
+
+	if cfg.GitEnabled() {
+		provider, err := createGitProvider(cfg)
+		if err != nil {
+			return nil, err
+		}
+		providerName := strings.ToLower(cfg.Git.Provider)
+		if providerName == "" {
+			providerName = "github"
+		}
+		reporter.RegisterProvider(providerName, provider)
+	}
+
+	// Per-service credentials share the deploy key encryption key
+	if cipher != nil {
+		reporter.SetServiceCredentials(credentialRepo, cipher)
+	}
+	reporter.SetResults(resultRepo)
 
 	logger.Info().
-		Str("provider", cfg.Git.Provider).
-		Msg("git syncer initialized")
+		Str("context", cfg.Git.StatusContext).
+		Bool("service_credentials", cipher != nil).
+		Bool("check_runs", cfg.Git.CheckRunsEnabled).
+		Msg("commit status reporting enabled")
 
-	return wire.NewGitSyncerAdapter(syncer), nil
+	return reporter, nil
+}
+
+// createChangeResolver creates the resolver that lists files changed by
+// webhook events through the git provider API, used to run only the affected
+// services of a monorepo. It returns nil when no git credentials are set.
+func createChangeResolver(
+	cfg *config.Config,
+	credentialRepo database.GitCredentialRepository,
+	cipher *secrets.Cipher,
+) (*git.ChangeResolver, error) {
+	if !cfg.GitEnabled() && cipher == nil {
+		return nil, nil
+	}
+
+	resolver := git.NewChangeResolver()
+	if cfg.GitEnabled() {
+		provider, err := createGitProvider(cfg)
+		if err != nil {
+			return nil, err
+		}
+		providerName := strings.ToLower(cfg.Git.Provider)
+		if providerName == "" {
+			providerName = "github"
+		}
+		resolver.RegisterProvider(providerName, provider)
+	}
+	if cipher != nil {
+		resolver.SetServiceCredentials(credentialRepo, cipher)
+	}
+	return resolver, nil
+}
+
+// createPreflightChecker creates the checks run on new runs before they are
+// queued. Commits are checked when git credentials are set, and secrets for
+// the providers configured for pre-flight checks. It returns nil when
+// pre-flight checks are disabled.
+func createPreflightChecker(
+	cfg *config.Config,
+	credentialRepo database.GitCredentialRepository,
+	cipher *secrets.Cipher,
+	logger zerolog.Logger,
+) (*preflight.Checker, error) {
+	if !cfg.Preflight.Enabled {
+		return nil, nil
+	}
+
+	var commits preflight.CommitResolver
+	if cfg.GitEnabled() || cipher != nil {
+		resolver := git.NewCommitResolver()
+		if cfg.GitEnabled() {
+			provider, err := createGitProvider(cfg)
+			if err != nil {
+				return nil, err
+			}
+			providerName := strings.ToLower(cfg.Git.Provider)
+			if providerName == "" {
+				providerName = "github"
+			}
+			resolver.RegisterProvider(providerName, provider)
+		}
+		if cipher != nil {
+			resolver.SetServiceCredentials(credentialRepo, cipher)
+		}
+		commits = resolver
+	}
+
+	var images preflight.ImageChecker
+	if cfg.Preflight.CheckImages {
+		images = preflight.NewRegistryClient(cfg.Preflight.Timeout)
+	}
+
+	stores := make(map[secrets.Provider]secrets.Store)
+	if cfg.Preflight.VaultAddress != "" {
+		store, err := secrets.NewVaultStore(secrets.VaultConfig{
+			Address:   cfg.Preflight.VaultAddress,
+			Token:     cfg.Preflight.VaultToken,
+			Namespace: cfg.Preflight.VaultNamespace,
+			Mount:     cfg.Preflight.VaultMount,
+			Timeout:   cfg.Preflight.Timeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure vault secrets: %w", err)
+		}
+		stores[secrets.ProviderVault] = store
+	}
+	if cfg.Preflight.AWSRegion != "" {
+		store, err := secrets.NewAWSStore(secrets.AWSConfig{
+			Region:   cfg.Preflight.AWSRegion,
+			Endpoint: cfg.Preflight.AWSEndpoint,
+			Timeout:  cfg.Preflight.Timeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure aws secrets: %w", err)
+		}
+		stores[secrets.ProviderAWS] = store
+	}
+	var resolver preflight.SecretResolver
+	if len(stores) > 0 {
+		resolver = secrets.NewResolver(stores, secrets.Provider(cfg.Preflight.SecretsProvider), 0, logger)
+	}
+
+	logger.Info().
+		Bool("commits", commits != nil).
+		Bool("images", images != nil).
+		Int("secret_providers", len(stores)).
+		Dur("timeout", cfg.Preflight.Timeout).
+		Msg("pre-flight checks enabled")
+
+	return preflight.NewChecker(commits, images, resolver, preflight.Config{Timeout: cfg.Preflight.Timeout}), nil
+}
+
+// startLeaderJobs starts the background jobs. With leader election enabled
+// they run only while this replica holds the leader lock, so that several
+// replicas can serve the API without running the jobs more than once.
+func startLeaderJobs(ctx context.Context, cfg config.LeaderConfig, db *database.DB, jobs []func(ctx context.Context), metrics leader.Metrics) {
+	start := func(ctx context.Context) {
+		for _, job := range jobs {
+			job(ctx)
+		}
+	}
+
+	if !cfg.Enabled {
+		metrics.SetLeader(true)
+		start(ctx)
+		return
+	}
+
+	electionLogger := logCore.Slog()
+	elector := leader.NewElector(leader.NewAdvisoryLock(db, cfg.LockID), cfg.RetryInterval, electionLogger)
+	elector.SetMetrics(metrics)
+	go elector.Run(ctx, start)
 }
 
 // jwtWebSocketAuth adapts the JWT validator to the WebSocket authenticator interface.
@@ -599,3 +1390,18 @@ type webhookServiceRepoAdapter struct {
 func (a *webhookServiceRepoAdapter) List(ctx context.Context, page database.Pagination) ([]database.Service, error) {
 	return a.repo.List(ctx, page)
 }
+
+// scheduledRunTrigger starts the runs of due schedules through the run service.
+type scheduledRunTrigger struct {
+	runs *server.RunServiceServer
+}
+
+func (t *scheduledRunTrigger) TriggerScheduledRun(ctx context.Context, sched *database.ScheduledRun) error {
+	_, err := t.runs.CreateRun(ctx, &conductorv1.CreateRunRequest{
+		ServiceId: sched.ServiceID.String(),
+		GitRef:    &conductorv1.GitRef{Branch: sched.GitRef},
+		Tags:      sched.TestFilter,
+		Trigger:   &conductorv1.RunTrigger{Type: conductorv1.TriggerType_TRIGGER_TYPE_SCHEDULED},
+	})
+	return err
+}
@@ -7,16 +7,23 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/agent/cache"
 	"github.com/conductor/conductor/internal/agent/executor"
 	"github.com/conductor/conductor/internal/agent/repo"
 	"github.com/conductor/conductor/internal/secrets"
+	conductorlog "github.com/conductor/conductor/pkg/log"
+	"github.com/conductor/conductor/pkg/metrics"
+	"github.com/conductor/conductor/pkg/tracing"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Version is the agent software version.
@@ -31,8 +38,11 @@ type Agent struct {
 	state    *State
 	reporter *Reporter
 	repoMgr  *repo.Manager
+	depCache *cache.Manager
 	monitor  *Monitor
 	secrets  secrets.Store
+	metrics  *metrics.AgentMetrics
+	logs     *LogBuffer // nil when log retrieval is disabled
 
 	// Executors for different execution types
 	subprocessExecutor executor.Executor
@@ -53,8 +63,21 @@ type Agent struct {
 	shutdownChan chan struct{}
 	wg           sync.WaitGroup
 
+	// stopReporter stops sending reports once the workers are done;
+	// reporterDone is closed when it stopped.
+	stopReporter context.CancelFunc
+	reporterDone chan struct{}
+
 	// Heartbeat configuration from control plane
 	heartbeatInterval time.Duration
+
+	// clockSkew is the control plane's clock minus the agent's, measured at
+	// registration.
+	clockSkew atomic.Int64
+
+	// maxArtifactBytes is the artifact size limit the control plane returned
+	// at registration; 0 means no limit.
+	maxArtifactBytes atomic.Int64
 }
 
 // activeRun tracks an in-progress test run.
@@ -70,8 +93,19 @@ type activeRun struct {
 
 // New creates a new Agent instance.
 func New(cfg *Config) (*Agent, error) {
-	// Setup logger
-	logger := setupLogger(cfg)
+	// Setup logger, keeping recent lines for retrieval by the control plane
+	var logs *LogBuffer
+	if cfg.LogBufferLines > 0 {
+		logs = NewLogBuffer(cfg.LogBufferLines)
+	}
+	if cfg.LogLevels == nil {
+		levels, err := conductorlog.NewLevels(cfg.LogLevel)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level: %w", err)
+		}
+		cfg.LogLevels = levels
+	}
+	logger := setupLogger(cfg, logs)
 
 	// Create state manager for persistence
 	state, err := NewState(cfg.StateDir)
@@ -85,6 +119,15 @@ func New(cfg *Config) (*Agent, error) {
 		return nil, fmt.Errorf("failed to create repository manager: %w", err)
 	}
 
+	// Create dependency cache manager if configured
+	var depCache *cache.Manager
+	if cfg.DependencyCacheDir != "" {
+		depCache, err = cache.NewManager(cfg.DependencyCacheDir, int64(cfg.DependencyCacheSizeMB)*1024*1024, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create dependency cache: %w", err)
+		}
+	}
+
 	// Create resource monitor
 	monitor := NewMonitor(cfg, logger)
 
@@ -92,12 +135,13 @@ func New(cfg *Config) (*Agent, error) {
 	client := NewClient(cfg, logger)
 
 	// Create reporter
-	reporter := NewReporter(client, logger)
+	reporter := NewReporter(client, state, cfg, logger)
 
-	// Create secrets store if configured
+	// Create secrets stores for every configured provider
 	var secretsStore secrets.Store
-	if cfg.SecretsProvider == "vault" {
-		secretsStore, err = secrets.NewVaultStore(secrets.VaultConfig{
+	stores := make(map[secrets.Provider]secrets.Store)
+	if cfg.VaultAddress != "" {
+		stores[secrets.ProviderVault], err = secrets.NewVaultStore(secrets.VaultConfig{
 			Address:   cfg.VaultAddress,
 			Token:     cfg.VaultToken,
 			Namespace: cfg.VaultNamespace,
@@ -108,6 +152,30 @@ func New(cfg *Config) (*Agent, error) {
 			return nil, fmt.Errorf("failed to configure vault secrets: %w", err)
 		}
 	}
+	if cfg.AWSRegion != "" {
+		stores[secrets.ProviderAWS], err = secrets.NewAWSStore(secrets.AWSConfig{
+			Region:          cfg.AWSRegion,
+			Endpoint:        cfg.AWSEndpoint,
+			AccessKeyID:     cfg.AWSAccessKeyID,
+			SecretAccessKey: cfg.AWSSecretAccessKey,
+			SessionToken:    cfg.AWSSessionToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure aws secrets: %w", err)
+		}
+	}
+	if cfg.EnvFileDir != "" {
+		stores[secrets.ProviderEnvFile], err = secrets.NewEnvFileStore(secrets.EnvFileConfig{
+			Dir: cfg.EnvFileDir,
+			Key: cfg.EnvFileKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure env file secrets: %w", err)
+		}
+	}
+	if len(stores) > 0 {
+		secretsStore = secrets.NewResolver(stores, secrets.Provider(cfg.SecretsProvider), cfg.SecretsCacheTTL, logger)
+	}
 
 	// Create subprocess executor
 	subprocessExec := executor.NewSubprocessExecutor(cfg.WorkspaceDir, logger)
@@ -129,8 +197,10 @@ func New(cfg *Config) (*Agent, error) {
 		state:              state,
 		reporter:           reporter,
 		repoMgr:            repoMgr,
+		depCache:           depCache,
 		monitor:            monitor,
 		secrets:            secretsStore,
+		logs:               logs,
 		subprocessExecutor: subprocessExec,
 		containerExecutor:  containerExec,
 		activeRuns:         make(map[string]*activeRun),
@@ -145,6 +215,41 @@ func New(cfg *Config) (*Agent, error) {
 	return agent, nil
 }
 
+// SetMetrics enables exporting resource usage, active runs and run
+// durations to the given Prometheus metrics. It must be called before Start.
+func (a *Agent) SetMetrics(m *metrics.AgentMetrics) {
+	a.metrics = m
+}
+
+// WorkStats describes the work of an agent, for diagnostics.
+type WorkStats struct {
+	Status     string `json:"status"`
+	Draining   bool   `json:"draining"`
+	ActiveRuns int    `json:"active_runs"`
+	// QueuedWork is the accepted work waiting to be executed.
+	QueuedWork    int `json:"queued_work"`
+	QueueCapacity int `json:"queue_capacity"`
+	// QueuedCancels are the cancellations waiting to be applied.
+	QueuedCancels int `json:"queued_cancels"`
+}
+
+// WorkStats returns statistics of the work of the agent.
+func (a *Agent) WorkStats() WorkStats {
+	a.activeRunsMu.RLock()
+	activeRuns := len(a.activeRuns)
+	a.activeRunsMu.RUnlock()
+
+	status, _ := a.status.Load().(conductorv1.AgentStatus)
+	return WorkStats{
+		Status:        status.String(),
+		Draining:      a.draining.Load(),
+		ActiveRuns:    activeRuns,
+		QueuedWork:    len(a.workChan),
+		QueueCapacity: cap(a.workChan),
+		QueuedCancels: len(a.cancelChan),
+	}
+}
+
 // Start connects to the control plane and begins processing work.
 func (a *Agent) Start(ctx context.Context) error {
 	a.logger.Info().
@@ -162,6 +267,15 @@ func (a *Agent) Start(ctx context.Context) error {
 	a.wg.Add(1)
 	go a.resourceMonitorLoop(ctx)
 
+	// Start sending reports; they are held until the agent registers
+	reporterCtx, stopReporter := context.WithCancel(ctx)
+	a.stopReporter = stopReporter
+	a.reporterDone = make(chan struct{})
+	go func() {
+		defer close(a.reporterDone)
+		a.reporter.Run(reporterCtx)
+	}()
+
 	// Recover any pending runs from previous session
 	if err := a.recoverPendingRuns(ctx); err != nil {
 		a.logger.Warn().Err(err).Msg("Failed to recover pending runs")
@@ -211,6 +325,15 @@ func (a *Agent) Stop(ctx context.Context) error {
 		a.logger.Warn().Msg("Shutdown timeout, forcing exit")
 	}
 
+	// Stop sending reports and spool the unsent ones for the next start
+	if a.stopReporter != nil {
+		a.stopReporter()
+		<-a.reporterDone
+	}
+	if err := a.reporter.Close(); err != nil {
+		a.logger.Warn().Err(err).Msg("Error spooling unsent reports")
+	}
+
 	// Close resources
 	if err := a.state.Close(); err != nil {
 		a.logger.Warn().Err(err).Msg("Error closing state")
@@ -251,16 +374,19 @@ func (a *Agent) connectionLoop(ctx context.Context) error {
 
 		// Register with control plane
 		if err := a.register(stream); err != nil {
+			if a.client.FallBackFromCompression(err) {
+				continue
+			}
 			a.logger.Error().Err(err).Msg("Failed to register with control plane")
 			a.waitForReconnect(ctx)
 			continue
 		}
 
 		// Start message handler
-		if err := a.messageLoop(ctx, stream); err != nil {
-			if !errors.Is(err, context.Canceled) {
-				a.logger.Error().Err(err).Msg("Message loop error, reconnecting")
-			}
+		err = a.messageLoop(ctx, stream)
+		a.reporter.Pause()
+		if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, errReconnect) {
+			a.logger.Error().Err(err).Msg("Message loop error, reconnecting")
 		}
 
 		// Check if we should exit
@@ -268,6 +394,13 @@ func (a *Agent) connectionLoop(ctx context.Context) error {
 			return nil
 		}
 
+		// The control plane asked for a new stream and already spread the
+		// agents' reconnects, so reconnect right away
+		if errors.Is(err, errReconnect) {
+			a.logger.Info().Msg("Reconnecting to control plane")
+			continue
+		}
+
 		a.waitForReconnect(ctx)
 	}
 }
@@ -275,23 +408,27 @@ func (a *Agent) connectionLoop(ctx context.Context) error {
 // register sends the registration message to the control plane.
 func (a *Agent) register(stream *WorkStream) error {
 	capabilities := &conductorv1.Capabilities{
-		NetworkZones:    a.config.NetworkZones,
-		Runtimes:        a.config.Runtimes,
-		MaxParallel:     int32(a.config.MaxParallel),
-		DockerAvailable: a.containerExecutor != nil,
-		Resources:       a.monitor.GetResources(),
-		Os:              runtime.GOOS,
-		Arch:            runtime.GOARCH,
+		NetworkZones:     a.config.NetworkZones,
+		Runtimes:         a.config.Runtimes,
+		MaxParallel:      int32(a.config.MaxParallel),
+		DockerAvailable:  a.containerExecutor != nil,
+		Resources:        a.monitor.GetResources(),
+		Os:               runtime.GOOS,
+		Arch:             runtime.GOARCH,
+		MaxArtifactBytes: a.config.MaxArtifactBytes,
 	}
 
 	msg := &conductorv1.AgentMessage{
 		Message: &conductorv1.AgentMessage_Register{
 			Register: &conductorv1.RegisterRequest{
-				AgentId:      a.config.AgentID,
-				Name:         a.config.AgentName,
-				Version:      Version,
-				Capabilities: capabilities,
-				Labels:       a.config.Labels,
+				AgentId:        a.config.AgentID,
+				Name:           a.config.AgentName,
+				Version:        Version,
+				Capabilities:   capabilities,
+				Labels:         a.config.Labels,
+				Pool:           a.config.Pool,
+				ProjectId:      a.config.Project,
+				ReplaysResults: true,
 			},
 		},
 	}
@@ -315,6 +452,18 @@ func (a *Agent) register(stream *WorkStream) error {
 		return fmt.Errorf("registration failed: %s", registerResp.ErrorMessage)
 	}
 
+	if registerResp.ServerTime != nil {
+		a.clockSkew.Store(int64(time.Until(registerResp.ServerTime.AsTime())))
+	}
+
+	// Control planes that predate artifact limits return none; fall back to
+	// the agent's own limit.
+	maxArtifactBytes := a.config.MaxArtifactBytes
+	if registerResp.ArtifactLimits != nil {
+		maxArtifactBytes = registerResp.ArtifactLimits.MaxArtifactBytes
+	}
+	a.maxArtifactBytes.Store(maxArtifactBytes)
+
 	// Update heartbeat interval if provided
 	if registerResp.HeartbeatIntervalSeconds > 0 {
 		a.heartbeatInterval = time.Duration(registerResp.HeartbeatIntervalSeconds) * time.Second
@@ -323,11 +472,19 @@ func (a *Agent) register(stream *WorkStream) error {
 	a.logger.Info().
 		Str("server_version", registerResp.ServerVersion).
 		Dur("heartbeat_interval", a.heartbeatInterval).
+		Int64("max_artifact_bytes", maxArtifactBytes).
 		Msg("Registered with control plane")
 
+	// Send the held reports, replaying those the control plane missed
+	a.reporter.Resume(registerResp.AcksResults, registerResp.ResultSequence)
+
 	return nil
 }
 
+// errReconnect is returned by messageLoop when the control plane ended the
+// work stream after asking the agent to reconnect.
+var errReconnect = errors.New("control plane requested reconnect")
+
 // messageLoop handles incoming messages from the control plane.
 func (a *Agent) messageLoop(ctx context.Context, stream *WorkStream) error {
 	// Start heartbeat goroutine
@@ -337,6 +494,8 @@ func (a *Agent) messageLoop(ctx context.Context, stream *WorkStream) error {
 	a.wg.Add(1)
 	go a.heartbeatLoop(heartbeatCtx, stream)
 
+	var reconnecting atomic.Bool
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -348,9 +507,19 @@ func (a *Agent) messageLoop(ctx context.Context, stream *WorkStream) error {
 
 		msg, err := stream.Receive()
 		if err != nil {
+			if reconnecting.Load() {
+				return errReconnect
+			}
 			return fmt.Errorf("receive error: %w", err)
 		}
 
+		// Reconnecting closes the stream, which handleControlMessage cannot
+		if reconnect := msg.GetReconnect(); reconnect != nil {
+			reconnecting.Store(true)
+			a.handleReconnect(stream, reconnect)
+			continue
+		}
+
 		if err := a.handleControlMessage(msg); err != nil {
 			a.logger.Error().Err(err).Msg("Error handling control message")
 		}
@@ -366,7 +535,13 @@ func (a *Agent) handleControlMessage(msg *conductorv1.ControlMessage) error {
 		return a.handleCancelWork(m.CancelWork)
 	case *conductorv1.ControlMessage_Drain:
 		return a.handleDrain(m.Drain)
+	case *conductorv1.ControlMessage_LogRequest:
+		return a.handleLogRequest(m.LogRequest)
 	case *conductorv1.ControlMessage_Ack:
+		if m.Ack.ResultSequence > 0 {
+			a.reporter.Ack(m.Ack.ResultSequence)
+			break
+		}
 		a.logger.Debug().Str("id", m.Ack.Id).Bool("success", m.Ack.Success).Msg("Received ack")
 	default:
 		a.logger.Warn().Msg("Received unknown message type")
@@ -374,6 +549,24 @@ func (a *Agent) handleControlMessage(msg *conductorv1.ControlMessage) error {
 	return nil
 }
 
+// handleReconnect closes the work stream after the delay the control plane
+// asked for. Work keeps running; the control plane processes the messages
+// sent before the stream was closed and then ends it, upon which the agent
+// reconnects and is routed to another replica.
+func (a *Agent) handleReconnect(stream *WorkStream, reconnect *conductorv1.Reconnect) {
+	delay := time.Duration(reconnect.GetDelay().GetSeconds())*time.Second + time.Duration(reconnect.GetDelay().GetNanos())
+	a.logger.Info().
+		Str("reason", reconnect.Reason).
+		Dur("delay", delay).
+		Msg("Control plane requested reconnect")
+
+	time.AfterFunc(delay, func() {
+		if err := stream.CloseSend(); err != nil {
+			a.logger.Warn().Err(err).Msg("Failed to close work stream")
+		}
+	})
+}
+
 // handleAssignWork processes a work assignment.
 func (a *Agent) handleAssignWork(work *conductorv1.AssignWork) error {
 	a.logger.Info().
@@ -444,6 +637,46 @@ func (a *Agent) handleDrain(drain *conductorv1.Drain) error {
 	return nil
 }
 
+// handleLogRequest uploads the most recent lines of the log buffer.
+func (a *Agent) handleLogRequest(req *conductorv1.LogRequest) error {
+	a.logger.Debug().
+		Str("request_id", req.RequestId).
+		Int32("limit", req.Limit).
+		Msg("Received log request")
+
+	logs := a.tailLogs(req)
+	logs.RequestId = req.RequestId
+	return a.client.Send(&conductorv1.AgentMessage{
+		Message: &conductorv1.AgentMessage_AgentLogs{AgentLogs: logs},
+	})
+}
+
+// tailLogs reads the lines of the log buffer a request asks for.
+func (a *Agent) tailLogs(req *conductorv1.LogRequest) *conductorv1.AgentLogs {
+	if a.logs == nil {
+		return &conductorv1.AgentLogs{Error: "log buffer is disabled (CONDUCTOR_AGENT_LOG_BUFFER_LINES=0)"}
+	}
+
+	minLevel := zerolog.TraceLevel
+	if req.MinLevel != "" {
+		level, err := zerolog.ParseLevel(req.MinLevel)
+		if err != nil {
+			return &conductorv1.AgentLogs{Error: fmt.Sprintf("invalid log level: %q", req.MinLevel)}
+		}
+		minLevel = level
+	}
+
+	lines, truncated := a.logs.Tail(int(req.Limit), minLevel)
+	logs := &conductorv1.AgentLogs{
+		Lines:     make([]*conductorv1.AgentLogLine, len(lines)),
+		Truncated: truncated,
+	}
+	for i, line := range lines {
+		logs.Lines[i] = &conductorv1.AgentLogLine{Level: line.level.String(), Line: line.text}
+	}
+	return logs
+}
+
 // acceptWork sends a work accepted message.
 func (a *Agent) acceptWork(runID, shardID string) error {
 	msg := &conductorv1.AgentMessage{
@@ -509,6 +742,17 @@ func (a *Agent) processWork(ctx context.Context, work *conductorv1.AssignWork, l
 	logger = logger.With().Str("run_id", runID).Str("shard_id", shardID).Logger()
 	logger.Info().Msg("Starting work execution")
 
+	// Continue the trace of the run, from its creation through scheduling
+	ctx, span := tracing.StartSpan(tracing.ContextWithTraceParent(ctx, work.TraceParent), "agent.Run",
+		tracing.WithSpanKind(trace.SpanKindConsumer),
+		tracing.WithAttributes(
+			tracing.AttrRunID.String(runID),
+			tracing.AttrShardID.String(shardID),
+			tracing.AttrAgentID.String(a.config.AgentID),
+		),
+	)
+	defer span.End()
+
 	// Create cancellable context
 	runCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -545,6 +789,14 @@ func (a *Agent) processWork(ctx context.Context, work *conductorv1.AssignWork, l
 	a.activeRunsMu.Unlock()
 	a.updateStatus()
 
+	// Every early return below reports an error
+	status := conductorv1.RunStatus_RUN_STATUS_ERROR
+	var result *executor.ExecutionResult
+	defer func() {
+		a.recordWorkMetrics(work, status, time.Since(run.startTime), result)
+		endRunSpan(span, status)
+	}()
+
 	// Save state for recovery
 	if err := a.state.SaveRunState(runID, "running", work); err != nil {
 		logger.Warn().Err(err).Msg("Failed to save run state")
@@ -562,14 +814,24 @@ func (a *Agent) processWork(ctx context.Context, work *conductorv1.AssignWork, l
 		}
 	}()
 
+	// Flag environment conditions that may slow down or fail the run
+	a.annotate(ctx, runID, shardID, clockSkewAnnotation(time.Duration(a.clockSkew.Load())), logger)
+	a.annotate(ctx, runID, shardID, diskPressureAnnotation(a.monitor.GetDiskUsagePercent(), a.config.DiskThreshold), logger)
+
 	// Clone repository
-	repoPath, err := a.cloneRepository(runCtx, work, logger)
+	cloneStart := time.Now()
+	cloneCached := work.GitRef != nil && a.repoMgr.GetCached(work.GitRef.RepositoryUrl) != ""
+	cloneCtx, cloneSpan := tracing.StartSpan(runCtx, "agent.Clone")
+	repoPath, err := a.cloneRepository(cloneCtx, work, logger)
+	endSpan(cloneSpan, err)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to clone repository")
 		a.reporter.ReportComplete(ctx, runID, shardID, conductorv1.RunStatus_RUN_STATUS_ERROR, fmt.Sprintf("clone failed: %v", err))
 		return
 	}
 
+	a.annotate(ctx, runID, shardID, slowCloneAnnotation(time.Since(cloneStart), cloneCached), logger)
+
 	// Report progress
 	a.reporter.ReportProgress(ctx, runID, shardID, "setup", "Repository cloned", 10, 0, len(work.Tests))
 
@@ -590,6 +852,15 @@ func (a *Agent) processWork(ctx context.Context, work *conductorv1.AssignWork, l
 		}
 	}
 
+	if err := a.resolveTestSecrets(runCtx, work.Tests); err != nil {
+		logger.Error().Err(err).Msg("Failed to resolve test secrets")
+		a.reporter.ReportComplete(ctx, runID, shardID, conductorv1.RunStatus_RUN_STATUS_ERROR, err.Error())
+		return
+	}
+
+	// Restore dependency caches before setup commands run
+	caches := a.restoreCaches(repoPath, work.Tests, logger)
+
 	// Prepare execution request
 	execReq := &executor.ExecutionRequest{
 		RunID:            runID,
@@ -611,14 +882,18 @@ func (a *Agent) processWork(ctx context.Context, work *conductorv1.AssignWork, l
 	}
 
 	// Execute tests
-	result, err := exec.Execute(runCtx, execReq, a.reporter)
+	execCtx, execSpan := tracing.StartSpan(runCtx, "agent.Execute")
+	result, err = exec.Execute(execCtx, execReq, a.reporter)
+	endSpan(execSpan, err)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
+			status = conductorv1.RunStatus_RUN_STATUS_CANCELLED
 			logger.Info().Msg("Run was cancelled")
 			a.reporter.ReportComplete(ctx, runID, shardID, conductorv1.RunStatus_RUN_STATUS_CANCELLED, "cancelled")
 			return
 		}
 		if errors.Is(err, context.DeadlineExceeded) {
+			status = conductorv1.RunStatus_RUN_STATUS_TIMEOUT
 			logger.Warn().Msg("Run timed out")
 			a.reporter.ReportComplete(ctx, runID, shardID, conductorv1.RunStatus_RUN_STATUS_TIMEOUT, "timeout exceeded")
 			return
@@ -628,17 +903,30 @@ func (a *Agent) processWork(ctx context.Context, work *conductorv1.AssignWork, l
 		return
 	}
 
-	// Upload artifacts
-	for _, artifactPath := range a.collectArtifacts(repoPath, work.Tests) {
-		if err := a.reporter.UploadArtifact(ctx, runID, artifactPath); err != nil {
-			logger.Warn().Err(err).Str("path", artifactPath).Msg("Failed to upload artifact")
+	// Persist dependency caches that missed on restore
+	a.saveCaches(repoPath, caches, logger)
+
+	ctx, reportSpan := tracing.StartSpan(ctx, "agent.Report")
+	defer reportSpan.End()
+
+	// Upload artifacts, staging compressed captures outside the workspace
+	stagingDir, err := os.MkdirTemp("", "conductor-capture-*")
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to create capture staging directory, skipping captures")
+		stagingDir = ""
+	} else {
+		defer os.RemoveAll(stagingDir)
+	}
+	for _, artifact := range a.collectArtifacts(ctx, runID, shardID, repoPath, stagingDir, work.Tests, logger) {
+		if err := a.reporter.UploadArtifact(ctx, runID, artifact.testID, artifact.path); err != nil {
+			logger.Warn().Err(err).Str("path", artifact.path).Msg("Failed to upload artifact")
 		}
 	}
 
 	// Report completion
-	finalStatus := a.determineFinalStatus(result)
+	status = a.determineFinalStatus(result)
 	logger.Info().
-		Str("status", finalStatus.String()).
+		Str("status", status.String()).
 		Int("total", result.Summary.Total).
 		Int("passed", result.Summary.Passed).
 		Int("failed", result.Summary.Failed).
@@ -647,6 +935,57 @@ func (a *Agent) processWork(ctx context.Context, work *conductorv1.AssignWork, l
 	a.reporter.ReportRunComplete(ctx, runID, shardID, result)
 }
 
+// endSpan ends a span of a step of a run, recording the error it failed
+// with, if any.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// endRunSpan records the final status of a run or shard on its span. Runs
+// that could not execute their tests are errors; failed tests are not.
+func endRunSpan(span trace.Span, status conductorv1.RunStatus) {
+	span.SetAttributes(tracing.AttrRunStatus.String(enumLabel(status.String(), "RUN_STATUS_")))
+	switch status {
+	case conductorv1.RunStatus_RUN_STATUS_ERROR, conductorv1.RunStatus_RUN_STATUS_TIMEOUT:
+		span.SetStatus(codes.Error, enumLabel(status.String(), "RUN_STATUS_"))
+	}
+}
+
+// recordWorkMetrics records the duration and outcome of a finished run or
+// shard, and of each test it executed.
+func (a *Agent) recordWorkMetrics(work *conductorv1.AssignWork, status conductorv1.RunStatus, duration time.Duration, result *executor.ExecutionResult) {
+	if a.metrics == nil {
+		return
+	}
+
+	a.metrics.RecordWorkComplete(enumLabel(status.String(), "RUN_STATUS_"), executionTypeLabel(work.ExecutionType), duration.Seconds())
+	if result == nil {
+		return
+	}
+	for _, test := range result.TestResults {
+		a.metrics.RecordTestComplete(enumLabel(test.Status.String(), "TEST_STATUS_"), test.Duration.Seconds())
+	}
+}
+
+// executionTypeLabel returns the metric label of an execution type; work
+// without one runs as a subprocess.
+func executionTypeLabel(execType conductorv1.ExecutionType) string {
+	if execType == conductorv1.ExecutionType_EXECUTION_TYPE_UNSPECIFIED {
+		return "subprocess"
+	}
+	return enumLabel(execType.String(), "EXECUTION_TYPE_")
+}
+
+// enumLabel turns a proto enum name such as RUN_STATUS_PASSED into a metric
+// label such as "passed".
+func enumLabel(name, prefix string) string {
+	return strings.ToLower(strings.TrimPrefix(name, prefix))
+}
+
 // cloneRepository clones the repository for the work assignment.
 func (a *Agent) cloneRepository(ctx context.Context, work *conductorv1.AssignWork, logger zerolog.Logger) (string, error) {
 	if work.GitRef == nil {
@@ -659,10 +998,19 @@ func (a *Agent) cloneRepository(ctx context.Context, work *conductorv1.AssignWor
 
 	// Clone with caching
 	opts := &repo.CloneOptions{
-		URL:       work.GitRef.RepositoryUrl,
-		Branch:    work.GitRef.Branch,
-		CommitSHA: work.GitRef.CommitSha,
-		Depth:     1,
+		URL:         work.GitRef.RepositoryUrl,
+		Branch:      work.GitRef.Branch,
+		CommitSHA:   work.GitRef.CommitSha,
+		Depth:       1,
+		PullRequest: work.GitRef.PullRequestNumber,
+		Submodules:  a.config.GitSubmodules,
+		LFS:         a.config.GitLFS,
+	}
+	if creds := work.GitCredentials; creds != nil && creds.SshPrivateKey != "" {
+		opts.Credentials = &repo.Credentials{
+			SSHKey:     creds.SshPrivateKey,
+			KnownHosts: creds.KnownHosts,
+		}
 	}
 
 	if err := a.repoMgr.Clone(ctx, opts, workspacePath); err != nil {
@@ -697,17 +1045,14 @@ func (a *Agent) resolveSecrets(ctx context.Context, refs []*conductorv1.Secret)
 			continue
 		}
 
-		provider := ref.Provider.String()
-		if ref.Provider == conductorv1.SecretProvider_SECRET_PROVIDER_UNSPECIFIED {
-			provider = "vault"
-		}
-		if provider != "vault" {
-			return nil, fmt.Errorf("unsupported secret provider: %s", provider)
+		provider, err := secretProvider(ref.Provider)
+		if err != nil {
+			return nil, err
 		}
 
 		value, err := a.secrets.Resolve(ctx, secrets.Reference{
 			Name:     ref.Name,
-			Provider: secrets.ProviderVault,
+			Provider: provider,
 			Path:     ref.Path,
 			Key:      ref.Key,
 			Version:  int(ref.Version),
@@ -721,21 +1066,118 @@ func (a *Agent) resolveSecrets(ctx context.Context, refs []*conductorv1.Secret)
 	return values, nil
 }
 
-// collectArtifacts collects artifact paths from the workspace.
-func (a *Agent) collectArtifacts(workspacePath string, tests []*conductorv1.TestToRun) []string {
-	var artifacts []string
+// resolveTestSecrets resolves test-specific secrets into each test's
+// environment, where they override run-level values with the same name.
+func (a *Agent) resolveTestSecrets(ctx context.Context, tests []*conductorv1.TestToRun) error {
 	for _, test := range tests {
-		for _, pattern := range test.ArtifactPaths {
-			// Glob for matching files
-			matches, err := a.repoMgr.Glob(workspacePath, pattern)
-			if err != nil {
-				a.logger.Debug().Err(err).Str("pattern", pattern).Msg("Artifact glob failed")
-				continue
+		if len(test.Secrets) == 0 {
+			continue
+		}
+
+		values, err := a.resolveSecrets(ctx, test.Secrets)
+		if err != nil {
+			return fmt.Errorf("test %s: %w", test.Name, err)
+		}
+
+		if test.Environment == nil {
+			test.Environment = make(map[string]string, len(values))
+		}
+		for key, value := range values {
+			test.Environment[key] = value
+		}
+	}
+	return nil
+}
+
+// secretProvider maps a proto secret provider to a store provider. An
+// unspecified provider resolves through the agent's default provider.
+func secretProvider(p conductorv1.SecretProvider) (secrets.Provider, error) {
+	switch p {
+	case conductorv1.SecretProvider_SECRET_PROVIDER_UNSPECIFIED:
+		return "", nil
+	case conductorv1.SecretProvider_SECRET_PROVIDER_VAULT:
+		return secrets.ProviderVault, nil
+	case conductorv1.SecretProvider_SECRET_PROVIDER_AWS_SECRETS_MANAGER:
+		return secrets.ProviderAWS, nil
+	case conductorv1.SecretProvider_SECRET_PROVIDER_ENV_FILE:
+		return secrets.ProviderEnvFile, nil
+	default:
+		return "", fmt.Errorf("unsupported secret provider: %s", p)
+	}
+}
+
+// dependencyCache is a resolved cache key and the paths stored under it.
+type dependencyCache struct {
+	key   string
+	paths []string
+	hit   bool
+}
+
+// restoreCaches resolves the cache keys of the assigned tests and restores
+// any existing entries into the workspace.
+func (a *Agent) restoreCaches(workspacePath string, tests []*conductorv1.TestToRun, logger zerolog.Logger) []*dependencyCache {
+	if a.depCache == nil {
+		return nil
+	}
+
+	byKey := make(map[string]*dependencyCache)
+	var caches []*dependencyCache
+	for _, test := range tests {
+		if test.CacheKey == "" || len(test.CachePaths) == 0 {
+			continue
+		}
+
+		key, err := cache.ResolveKey(test.CacheKey, workspacePath)
+		if err != nil {
+			logger.Warn().Err(err).Str("test", test.Name).Msg("Failed to resolve cache key")
+			continue
+		}
+
+		entry, ok := byKey[key]
+		if !ok {
+			entry = &dependencyCache{key: key}
+			byKey[key] = entry
+			caches = append(caches, entry)
+		}
+		for _, path := range test.CachePaths {
+			if !containsString(entry.paths, path) {
+				entry.paths = append(entry.paths, path)
 			}
-			artifacts = append(artifacts, matches...)
 		}
 	}
-	return artifacts
+
+	for _, entry := range caches {
+		hit, err := a.depCache.Restore(entry.key, workspacePath, entry.paths)
+		if err != nil {
+			logger.Warn().Err(err).Str("cache_key", entry.key).Msg("Failed to restore dependency cache")
+			continue
+		}
+		entry.hit = hit
+		logger.Info().Str("cache_key", entry.key).Bool("hit", hit).Msg("Dependency cache lookup")
+	}
+
+	return caches
+}
+
+// saveCaches stores dependency caches that were not restored for this run.
+func (a *Agent) saveCaches(workspacePath string, caches []*dependencyCache, logger zerolog.Logger) {
+	for _, entry := range caches {
+		if entry.hit {
+			continue
+		}
+		if err := a.depCache.Save(entry.key, workspacePath, entry.paths); err != nil {
+			logger.Warn().Err(err).Str("cache_key", entry.key).Msg("Failed to save dependency cache")
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
 // determineFinalStatus determines the final run status from execution results.
@@ -825,6 +1267,10 @@ func (a *Agent) sendHeartbeat(stream *WorkStream) error {
 func (a *Agent) resourceMonitorLoop(ctx context.Context) {
 	defer a.wg.Done()
 
+	if a.metrics != nil {
+		a.monitor.ReportMetrics(a.metrics)
+	}
+
 	ticker := time.NewTicker(a.config.ResourceCheckInterval)
 	defer ticker.Stop()
 
@@ -836,6 +1282,9 @@ func (a *Agent) resourceMonitorLoop(ctx context.Context) {
 			return
 		case <-ticker.C:
 			a.monitor.Update()
+			if a.metrics != nil {
+				a.monitor.ReportMetrics(a.metrics)
+			}
 		}
 	}
 }
@@ -855,15 +1304,19 @@ func (a *Agent) canAcceptWork() bool {
 
 // updateStatus updates the agent status based on active runs.
 func (a *Agent) updateStatus() {
+	a.activeRunsMu.RLock()
+	activeCount := len(a.activeRuns)
+	a.activeRunsMu.RUnlock()
+
+	if a.metrics != nil {
+		a.metrics.SetActiveWork(float64(activeCount))
+	}
+
 	if a.draining.Load() {
 		a.status.Store(conductorv1.AgentStatus_AGENT_STATUS_DRAINING)
 		return
 	}
 
-	a.activeRunsMu.RLock()
-	activeCount := len(a.activeRuns)
-	a.activeRunsMu.RUnlock()
-
 	if activeCount > 0 {
 		a.status.Store(conductorv1.AgentStatus_AGENT_STATUS_BUSY)
 	} else {
@@ -881,7 +1334,7 @@ func (a *Agent) recoverPendingRuns(ctx context.Context) error {
 	for _, run := range runs {
 		a.logger.Info().Str("run_id", run.RunID).Msg("Found pending run from previous session")
 		// Mark as error since we can't resume
-		a.reporter.ReportComplete(ctx, run.RunID, "", conductorv1.RunStatus_RUN_STATUS_ERROR, "agent restarted during execution")
+		a.reporter.ReportComplete(ctx, run.RunID, run.Work.GetShardId(), conductorv1.RunStatus_RUN_STATUS_ERROR, "agent restarted during execution")
 		if err := a.state.DeleteRunState(run.RunID); err != nil {
 			a.logger.Warn().Err(err).Str("run_id", run.RunID).Msg("Failed to delete recovered run state")
 		}
@@ -909,6 +1362,9 @@ func (a *Agent) ensureDirectories() error {
 		a.config.CacheDir,
 		a.config.StateDir,
 	}
+	if a.config.DependencyCacheDir != "" {
+		dirs = append(dirs, a.config.DependencyCacheDir)
+	}
 
 	for _, dir := range dirs {
 		if err := os.MkdirAll(dir, 0755); err != nil {
@@ -920,28 +1376,12 @@ func (a *Agent) ensureDirectories() error {
 }
 
 // setupLogger creates the logger based on configuration.
-func setupLogger(cfg *Config) zerolog.Logger {
-	var logger zerolog.Logger
-
-	if cfg.LogFormat == "console" {
-		logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339}).
-			With().Timestamp().Logger()
-	} else {
-		logger = zerolog.New(os.Stderr).With().Timestamp().Logger()
-	}
-
-	switch cfg.LogLevel {
-	case "debug":
-		logger = logger.Level(zerolog.DebugLevel)
-	case "info":
-		logger = logger.Level(zerolog.InfoLevel)
-	case "warn":
-		logger = logger.Level(zerolog.WarnLevel)
-	case "error":
-		logger = logger.Level(zerolog.ErrorLevel)
-	default:
-		logger = logger.Level(zerolog.InfoLevel)
+func setupLogger(cfg *Config, logs *LogBuffer) zerolog.Logger {
+	out := conductorlog.Output(os.Stderr, cfg.LogFormat)
+	if logs != nil {
+		out = zerolog.MultiLevelWriter(out, logs)
 	}
+	logger := conductorlog.NewCore(out, cfg.LogLevels).Zerolog()
 
 	return logger.With().Str("component", "agent").Logger()
 }
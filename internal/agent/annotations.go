@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Kinds of run annotations reported by agents.
+const (
+	AnnotationArtifactBudget = "artifact_budget"
+	AnnotationArtifactSize   = "artifact_size"
+	AnnotationClockSkew      = "clock_skew"
+	AnnotationDiskPressure   = "disk_pressure"
+	AnnotationSlowClone      = "slow_clone"
+)
+
+const (
+	// maxClockSkew is the clock difference to the control plane above which
+	// runs are annotated; timeouts and timestamps drift beyond it.
+	maxClockSkew = 5 * time.Second
+	// diskPressureMargin is how many percentage points below the disk
+	// threshold runs start to be annotated.
+	diskPressureMargin = 10.0
+	// slowCloneThreshold is the clone duration above which runs are
+	// annotated.
+	slowCloneThreshold = time.Minute
+)
+
+// annotate attaches an annotation to a run. Nil annotations are ignored.
+func (a *Agent) annotate(ctx context.Context, runID, shardID string, annotation *conductorv1.RunAnnotation, logger zerolog.Logger) {
+	if annotation == nil {
+		return
+	}
+
+	logger.Warn().Str("kind", annotation.Kind).Msg(annotation.Message)
+	if err := a.reporter.ReportAnnotation(ctx, runID, shardID, annotation); err != nil {
+		logger.Warn().Err(err).Str("kind", annotation.Kind).Msg("Failed to report annotation")
+	}
+}
+
+// clockSkewAnnotation reports a clock differing from the control plane's by
+// more than maxClockSkew.
+func clockSkewAnnotation(skew time.Duration) *conductorv1.RunAnnotation {
+	if skew.Abs() <= maxClockSkew {
+		return nil
+	}
+
+	direction := "behind"
+	if skew < 0 {
+		direction = "ahead of"
+	}
+	return newAnnotation(AnnotationClockSkew,
+		fmt.Sprintf("agent clock is %s %s the control plane", skew.Abs().Round(time.Second), direction),
+		map[string]string{"skew_seconds": strconv.FormatFloat(skew.Seconds(), 'f', 1, 64)})
+}
+
+// diskPressureAnnotation reports a workspace disk within diskPressureMargin
+// of the threshold at which the agent stops accepting work.
+func diskPressureAnnotation(percent, threshold float64) *conductorv1.RunAnnotation {
+	if threshold <= 0 || percent < threshold-diskPressureMargin {
+		return nil
+	}
+
+	return newAnnotation(AnnotationDiskPressure,
+		fmt.Sprintf("workspace disk is %.0f%% full", percent),
+		map[string]string{
+			"usage_percent":     strconv.FormatFloat(percent, 'f', 1, 64),
+			"threshold_percent": strconv.FormatFloat(threshold, 'f', 1, 64),
+		})
+}
+
+// slowCloneAnnotation reports a repository clone that took longer than
+// slowCloneThreshold, and whether the repository cache missed.
+func slowCloneAnnotation(duration time.Duration, cached bool) *conductorv1.RunAnnotation {
+	if duration <= slowCloneThreshold {
+		return nil
+	}
+
+	message := fmt.Sprintf("repository clone took %s", duration.Round(time.Second))
+	if !cached {
+		message += " after a repository cache miss"
+	}
+	return newAnnotation(AnnotationSlowClone, message, map[string]string{
+		"duration_seconds": strconv.FormatFloat(duration.Seconds(), 'f', 1, 64),
+		"cache_hit":        strconv.FormatBool(cached),
+	})
+}
+
+// artifactBudgetAnnotation reports artifacts of a test that were skipped
+// because they exceed the test's artifact budget.
+func artifactBudgetAnnotation(test *conductorv1.TestToRun, skipped []collectedArtifact) *conductorv1.RunAnnotation {
+	if len(skipped) == 0 {
+		return nil
+	}
+
+	var bytes int64
+	for _, artifact := range skipped {
+		bytes += artifact.size
+	}
+	budget := test.GetArtifactBudget()
+	return newAnnotation(AnnotationArtifactBudget,
+		fmt.Sprintf("skipped %d artifacts (%d bytes) of test %s over its artifact budget", len(skipped), bytes, test.Name),
+		map[string]string{
+			"test_id":       test.TestId,
+			"skipped_files": strconv.Itoa(len(skipped)),
+			"skipped_bytes": strconv.FormatInt(bytes, 10),
+			"max_files":     strconv.Itoa(int(budget.GetMaxFiles())),
+			"max_bytes":     strconv.FormatInt(budget.GetMaxBytes(), 10),
+		})
+}
+
+// artifactSizeAnnotation reports artifacts of a test that were skipped
+// because they exceed the artifact size limit of the agent.
+func artifactSizeAnnotation(test *conductorv1.TestToRun, skipped []collectedArtifact, maxBytes int64) *conductorv1.RunAnnotation {
+	if len(skipped) == 0 {
+		return nil
+	}
+
+	var bytes int64
+	for _, artifact := range skipped {
+		bytes += artifact.size
+	}
+	return newAnnotation(AnnotationArtifactSize,
+		fmt.Sprintf("skipped %d artifacts (%d bytes) of test %s over the artifact size limit of %d bytes", len(skipped), bytes, test.Name, maxBytes),
+		map[string]string{
+			"test_id":       test.TestId,
+			"skipped_files": strconv.Itoa(len(skipped)),
+			"skipped_bytes": strconv.FormatInt(bytes, 10),
+			"max_bytes":     strconv.FormatInt(maxBytes, 10),
+		})
+}
+
+// newAnnotation creates a warning annotation detected now.
+func newAnnotation(kind, message string, metadata map[string]string) *conductorv1.RunAnnotation {
+	return &conductorv1.RunAnnotation{
+		Kind:      kind,
+		Severity:  conductorv1.AnnotationSeverity_ANNOTATION_SEVERITY_WARNING,
+		Message:   message,
+		Metadata:  metadata,
+		Timestamp: timestamppb.Now(),
+	}
+}
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockSkewAnnotation(t *testing.T) {
+	if a := clockSkewAnnotation(3 * time.Second); a != nil {
+		t.Errorf("clockSkewAnnotation(3s) = %v, want nil", a)
+	}
+
+	a := clockSkewAnnotation(-90 * time.Second)
+	if a == nil {
+		t.Fatal("clockSkewAnnotation(-90s) = nil, want annotation")
+	}
+	if a.Kind != AnnotationClockSkew {
+		t.Errorf("Kind = %q, want %q", a.Kind, AnnotationClockSkew)
+	}
+	if want := "agent clock is 1m30s ahead of the control plane"; a.Message != want {
+		t.Errorf("Message = %q, want %q", a.Message, want)
+	}
+	if a.Metadata["skew_seconds"] != "-90.0" {
+		t.Errorf("skew_seconds = %q, want -90.0", a.Metadata["skew_seconds"])
+	}
+}
+
+func TestDiskPressureAnnotation(t *testing.T) {
+	if a := diskPressureAnnotation(70, 90); a != nil {
+		t.Errorf("diskPressureAnnotation(70, 90) = %v, want nil", a)
+	}
+	if a := diskPressureAnnotation(95, 0); a != nil {
+		t.Errorf("diskPressureAnnotation without threshold = %v, want nil", a)
+	}
+
+	a := diskPressureAnnotation(85, 90)
+	if a == nil {
+		t.Fatal("diskPressureAnnotation(85, 90) = nil, want annotation")
+	}
+	if want := "workspace disk is 85% full"; a.Message != want {
+		t.Errorf("Message = %q, want %q", a.Message, want)
+	}
+}
+
+func TestSlowCloneAnnotation(t *testing.T) {
+	if a := slowCloneAnnotation(10*time.Second, false); a != nil {
+		t.Errorf("slowCloneAnnotation(10s) = %v, want nil", a)
+	}
+
+	a := slowCloneAnnotation(3*time.Minute, false)
+	if a == nil {
+		t.Fatal("slowCloneAnnotation(3m) = nil, want annotation")
+	}
+	if want := "repository clone took 3m0s after a repository cache miss"; a.Message != want {
+		t.Errorf("Message = %q, want %q", a.Message, want)
+	}
+	if a.Metadata["cache_hit"] != "false" {
+		t.Errorf("cache_hit = %q, want false", a.Metadata["cache_hit"])
+	}
+
+	if a := slowCloneAnnotation(3*time.Minute, true); a == nil || a.Message != "repository clone took 3m0s" {
+		t.Errorf("slowCloneAnnotation cached = %v, want message without cache miss", a)
+	}
+}
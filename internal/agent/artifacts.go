@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"context"
+	"os"
+
+	"github.com/rs/zerolog"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+// collectedArtifact is a workspace file collected as an artifact of a test.
+type collectedArtifact struct {
+	testID string
+	path   string
+	size   int64
+}
+
+// collectArtifacts collects the artifacts of each test from the workspace,
+// with its captured screenshots and videos, whose compressed files are
+// staged in stagingDir. Artifacts over the size limit negotiated at
+// registration or beyond a test's artifact budget are skipped and the run
+// is annotated, so the control plane does not have to reject them.
+func (a *Agent) collectArtifacts(ctx context.Context, runID, shardID, workspacePath, stagingDir string, tests []*conductorv1.TestToRun, logger zerolog.Logger) []collectedArtifact {
+	maxBytes := a.maxArtifactBytes.Load()
+
+	var artifacts []collectedArtifact
+	for _, test := range tests {
+		found := append(a.globArtifacts(workspacePath, test), a.captureArtifacts(workspacePath, stagingDir, test)...)
+		sized, oversized := applyArtifactSizeLimit(found, maxBytes)
+		for _, artifact := range oversized {
+			logger.Debug().Str("test", test.Name).Str("path", artifact.path).Int64("size", artifact.size).Msg("Artifact over size limit, skipping")
+		}
+		a.annotate(ctx, runID, shardID, artifactSizeAnnotation(test, oversized, maxBytes), logger)
+
+		kept, skipped := applyArtifactBudget(sized, test.ArtifactBudget)
+		for _, artifact := range skipped {
+			logger.Debug().Str("test", test.Name).Str("path", artifact.path).Int64("size", artifact.size).Msg("Artifact over budget, skipping")
+		}
+		a.annotate(ctx, runID, shardID, artifactBudgetAnnotation(test, skipped), logger)
+		artifacts = append(artifacts, kept...)
+	}
+	return artifacts
+}
+
+// globArtifacts returns the files matching a test's artifact patterns, in
+// pattern order. Files matched by several patterns are returned once.
+func (a *Agent) globArtifacts(workspacePath string, test *conductorv1.TestToRun) []collectedArtifact {
+	var artifacts []collectedArtifact
+	seen := make(map[string]bool)
+	for _, pattern := range test.ArtifactPaths {
+		// Glob for matching files
+		matches, err := a.repoMgr.Glob(workspacePath, pattern)
+		if err != nil {
+			a.logger.Debug().Err(err).Str("pattern", pattern).Msg("Artifact glob failed")
+			continue
+		}
+		for _, match := range matches {
+			if seen[match] {
+				continue
+			}
+			seen[match] = true
+
+			info, err := os.Stat(match)
+			if err != nil {
+				a.logger.Debug().Err(err).Str("path", match).Msg("Artifact stat failed")
+				continue
+			}
+			artifacts = append(artifacts, collectedArtifact{testID: test.TestId, path: match, size: info.Size()})
+		}
+	}
+	return artifacts
+}
+
+// applyArtifactSizeLimit splits artifacts into those within the size limit
+// and those larger. A limit of 0 keeps all artifacts.
+func applyArtifactSizeLimit(artifacts []collectedArtifact, maxBytes int64) (kept, skipped []collectedArtifact) {
+	if maxBytes <= 0 {
+		return artifacts, nil
+	}
+	for _, artifact := range artifacts {
+		if artifact.size > maxBytes {
+			skipped = append(skipped, artifact)
+			continue
+		}
+		kept = append(kept, artifact)
+	}
+	return kept, skipped
+}
+
+// applyArtifactBudget splits a test's artifacts into those within its
+// budget and those skipped, keeping artifacts in order while they fit. An
+// artifact too large for the remaining bytes is skipped, but smaller ones
+// after it may still be kept. A nil budget keeps all artifacts.
+func applyArtifactBudget(artifacts []collectedArtifact, budget *conductorv1.ArtifactBudget) (kept, skipped []collectedArtifact) {
+	if budget == nil || (budget.MaxBytes <= 0 && budget.MaxFiles <= 0) {
+		return artifacts, nil
+	}
+
+	var bytes int64
+	for _, artifact := range artifacts {
+		if budget.MaxFiles > 0 && len(kept) >= int(budget.MaxFiles) {
+			skipped = append(skipped, artifact)
+			continue
+		}
+		if budget.MaxBytes > 0 && bytes+artifact.size > budget.MaxBytes {
+			skipped = append(skipped, artifact)
+			continue
+		}
+		bytes += artifact.size
+		kept = append(kept, artifact)
+	}
+	return kept, skipped
+}
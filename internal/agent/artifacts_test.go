@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"slices"
+	"testing"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+func TestApplyArtifactBudget(t *testing.T) {
+	artifacts := []collectedArtifact{
+		{path: "trace.zip", size: 60},
+		{path: "video.webm", size: 50},
+		{path: "screenshot.png", size: 30},
+		{path: "log.txt", size: 5},
+	}
+	paths := func(artifacts []collectedArtifact) []string {
+		var paths []string
+		for _, artifact := range artifacts {
+			paths = append(paths, artifact.path)
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name        string
+		budget      *conductorv1.ArtifactBudget
+		wantKept    []string
+		wantSkipped []string
+	}{
+		{
+			name:     "no budget",
+			wantKept: []string{"trace.zip", "video.webm", "screenshot.png", "log.txt"},
+		},
+		{
+			name:        "max bytes skips artifacts that do not fit",
+			budget:      &conductorv1.ArtifactBudget{MaxBytes: 100},
+			wantKept:    []string{"trace.zip", "screenshot.png", "log.txt"},
+			wantSkipped: []string{"video.webm"},
+		},
+		{
+			name:        "max files",
+			budget:      &conductorv1.ArtifactBudget{MaxFiles: 2},
+			wantKept:    []string{"trace.zip", "video.webm"},
+			wantSkipped: []string{"screenshot.png", "log.txt"},
+		},
+		{
+			name:        "both limits",
+			budget:      &conductorv1.ArtifactBudget{MaxBytes: 100, MaxFiles: 2},
+			wantKept:    []string{"trace.zip", "screenshot.png"},
+			wantSkipped: []string{"video.webm", "log.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, skipped := applyArtifactBudget(artifacts, tt.budget)
+			if got := paths(kept); !slices.Equal(got, tt.wantKept) {
+				t.Errorf("kept = %v, want %v", got, tt.wantKept)
+			}
+			if got := paths(skipped); !slices.Equal(got, tt.wantSkipped) {
+				t.Errorf("skipped = %v, want %v", got, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestArtifactBudgetAnnotation(t *testing.T) {
+	test := &conductorv1.TestToRun{TestId: "t1", Name: "e2e", ArtifactBudget: &conductorv1.ArtifactBudget{MaxBytes: 100}}
+	if a := artifactBudgetAnnotation(test, nil); a != nil {
+		t.Errorf("artifactBudgetAnnotation without skipped artifacts = %v, want nil", a)
+	}
+
+	a := artifactBudgetAnnotation(test, []collectedArtifact{{path: "a", size: 60}, {path: "b", size: 50}})
+	if a == nil {
+		t.Fatal("artifactBudgetAnnotation = nil, want annotation")
+	}
+	if a.Kind != AnnotationArtifactBudget {
+		t.Errorf("Kind = %q, want %q", a.Kind, AnnotationArtifactBudget)
+	}
+	if want := "skipped 2 artifacts (110 bytes) of test e2e over its artifact budget"; a.Message != want {
+		t.Errorf("Message = %q, want %q", a.Message, want)
+	}
+	if a.Metadata["max_bytes"] != "100" {
+		t.Errorf("max_bytes = %q, want 100", a.Metadata["max_bytes"])
+	}
+}
+
+func TestApplyArtifactSizeLimit(t *testing.T) {
+	artifacts := []collectedArtifact{
+		{path: "trace.zip", size: 60},
+		{path: "video.webm", size: 150},
+		{path: "log.txt", size: 100},
+	}
+
+	kept, skipped := applyArtifactSizeLimit(artifacts, 100)
+	if len(kept) != 2 || kept[0].path != "trace.zip" || kept[1].path != "log.txt" {
+		t.Errorf("kept = %v, want trace.zip and log.txt", kept)
+	}
+	if len(skipped) != 1 || skipped[0].path != "video.webm" {
+		t.Errorf("skipped = %v, want video.webm", skipped)
+	}
+
+	if kept, skipped := applyArtifactSizeLimit(artifacts, 0); len(kept) != 3 || len(skipped) != 0 {
+		t.Errorf("without limit kept %d and skipped %d artifacts, want 3 and 0", len(kept), len(skipped))
+	}
+}
+
+func TestArtifactSizeAnnotation(t *testing.T) {
+	test := &conductorv1.TestToRun{TestId: "t1", Name: "e2e"}
+	if a := artifactSizeAnnotation(test, nil, 100); a != nil {
+		t.Errorf("artifactSizeAnnotation without skipped artifacts = %v, want nil", a)
+	}
+
+	a := artifactSizeAnnotation(test, []collectedArtifact{{path: "video.webm", size: 150}}, 100)
+	if a == nil {
+		t.Fatal("artifactSizeAnnotation = nil, want annotation")
+	}
+	if a.Kind != AnnotationArtifactSize {
+		t.Errorf("Kind = %q, want %q", a.Kind, AnnotationArtifactSize)
+	}
+	if want := "skipped 1 artifacts (150 bytes) of test e2e over the artifact size limit of 100 bytes"; a.Message != want {
+		t.Errorf("Message = %q, want %q", a.Message, want)
+	}
+	if a.Metadata["max_bytes"] != "100" {
+		t.Errorf("max_bytes = %q, want 100", a.Metadata["max_bytes"])
+	}
+}
@@ -0,0 +1,416 @@
+// Package cache provides keyed dependency caches for the agent.
+//
+// Caches hold directories such as a Go module cache or node_modules that are
+// expensive to rebuild. They are restored into a workspace before execution
+// and saved back afterwards, so repeated runs with the same key skip most of
+// their setup time. Total cache size is bounded and the least recently used
+// entries are evicted first.
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+const (
+	metadataFile = "cache.json"
+	dataDir      = "data"
+	tmpPrefix    = ".tmp-"
+)
+
+// Manager stores and restores keyed dependency caches on local disk.
+type Manager struct {
+	dir      string
+	maxBytes int64
+	logger   zerolog.Logger
+	mu       sync.Mutex
+}
+
+// Entry describes a stored cache entry.
+type Entry struct {
+	Key       string    `json:"key"`
+	Paths     []string  `json:"paths"`
+	SizeBytes int64     `json:"size_bytes"`
+	CreatedAt time.Time `json:"created_at"`
+	LastUsed  time.Time `json:"last_used"`
+
+	dir string
+}
+
+// NewManager creates a cache manager rooted at dir. A maxBytes of zero or
+// less disables size-based eviction.
+func NewManager(dir string, maxBytes int64, logger zerolog.Logger) (*Manager, error) {
+	if dir == "" {
+		return nil, errors.New("cache directory is required")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	return &Manager{
+		dir:      dir,
+		maxBytes: maxBytes,
+		logger:   logger.With().Str("component", "dependency_cache").Logger(),
+	}, nil
+}
+
+// ResolveKey expands a cache key template against a workspace.
+//
+// Templates may use {{ checksum "path" }} to include the SHA-256 of a file
+// relative to the workspace (e.g. a lockfile), and {{ .OS }} / {{ .Arch }}
+// for the agent platform. Keys without template actions are returned as-is.
+func ResolveKey(key, workspace string) (string, error) {
+	if !strings.Contains(key, "{{") {
+		return key, nil
+	}
+
+	funcs := template.FuncMap{
+		"checksum": func(path string) (string, error) {
+			full, err := workspacePath(workspace, path)
+			if err != nil {
+				return "", err
+			}
+			data, err := os.ReadFile(full)
+			if err != nil {
+				return "", fmt.Errorf("checksum %s: %w", path, err)
+			}
+			sum := sha256.Sum256(data)
+			return hex.EncodeToString(sum[:]), nil
+		},
+	}
+
+	tmpl, err := template.New("cache_key").Funcs(funcs).Option("missingkey=error").Parse(key)
+	if err != nil {
+		return "", fmt.Errorf("invalid cache key template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	data := struct{ OS, Arch string }{OS: runtime.GOOS, Arch: runtime.GOARCH}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to resolve cache key: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// Restore copies the cached paths for key into the workspace. It reports
+// whether a cache entry was found.
+func (m *Manager) Restore(key, workspace string, paths []string) (bool, error) {
+	entryDir := m.entryDir(key)
+
+	m.mu.Lock()
+	entry, err := readEntry(entryDir)
+	if err == nil {
+		entry.LastUsed = time.Now().UTC()
+		err = writeEntry(entryDir, entry)
+	}
+	m.mu.Unlock()
+
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	for _, path := range paths {
+		src := filepath.Join(entryDir, dataDir, filepath.FromSlash(path))
+		if _, err := os.Lstat(src); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		dst, err := workspacePath(workspace, path)
+		if err != nil {
+			return false, err
+		}
+		if err := os.RemoveAll(dst); err != nil {
+			return false, fmt.Errorf("failed to clear %s: %w", path, err)
+		}
+		if err := copyTree(src, dst); err != nil {
+			return false, fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+	}
+
+	m.logger.Debug().Str("key", key).Strs("paths", paths).Msg("Restored dependency cache")
+	return true, nil
+}
+
+// Save stores the given workspace paths under key. Existing entries are left
+// untouched, so the first run to populate a key wins. Paths missing from the
+// workspace are skipped.
+func (m *Manager) Save(key, workspace string, paths []string) error {
+	entryDir := m.entryDir(key)
+	if _, err := os.Stat(filepath.Join(entryDir, metadataFile)); err == nil {
+		return nil
+	}
+
+	staging := filepath.Join(m.dir, tmpPrefix+uuid.New().String())
+	defer os.RemoveAll(staging)
+
+	var size int64
+	var saved []string
+	for _, path := range paths {
+		src, err := workspacePath(workspace, path)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Lstat(src); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		dst := filepath.Join(staging, dataDir, filepath.FromSlash(path))
+		if err := copyTree(src, dst); err != nil {
+			return fmt.Errorf("failed to save %s: %w", path, err)
+		}
+		n, err := dirSize(dst)
+		if err != nil {
+			return err
+		}
+		size += n
+		saved = append(saved, path)
+	}
+
+	if len(saved) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	entry := &Entry{Key: key, Paths: saved, SizeBytes: size, CreatedAt: now, LastUsed: now}
+	if err := writeEntry(staging, entry); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := os.Rename(staging, entryDir); err != nil {
+		if _, statErr := os.Stat(filepath.Join(entryDir, metadataFile)); statErr == nil {
+			// Another run saved the same key concurrently.
+			return nil
+		}
+		return fmt.Errorf("failed to commit cache entry: %w", err)
+	}
+
+	m.logger.Info().
+		Str("key", key).
+		Int64("size_bytes", size).
+		Msg("Saved dependency cache")
+
+	return m.evictLocked()
+}
+
+// Entries returns all stored cache entries, most recently used first.
+func (m *Manager) Entries() ([]Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries, err := m.listLocked()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// Evict removes least recently used entries until the cache fits within its
+// size limit.
+func (m *Manager) Evict() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.evictLocked()
+}
+
+func (m *Manager) evictLocked() error {
+	if m.maxBytes <= 0 {
+		return nil
+	}
+
+	entries, err := m.listLocked()
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.SizeBytes
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUsed.Before(entries[j].LastUsed)
+	})
+
+	for _, e := range entries {
+		if total <= m.maxBytes {
+			break
+		}
+		if err := os.RemoveAll(e.dir); err != nil {
+			return fmt.Errorf("failed to evict cache entry %s: %w", e.Key, err)
+		}
+		total -= e.SizeBytes
+		m.logger.Info().
+			Str("key", e.Key).
+			Int64("size_bytes", e.SizeBytes).
+			Msg("Evicted dependency cache")
+	}
+
+	return nil
+}
+
+func (m *Manager) listLocked() ([]Entry, error) {
+	dirEntries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cache directory: %w", err)
+	}
+
+	var entries []Entry
+	for _, de := range dirEntries {
+		if !de.IsDir() || strings.HasPrefix(de.Name(), tmpPrefix) {
+			continue
+		}
+		dir := filepath.Join(m.dir, de.Name())
+		entry, err := readEntry(dir)
+		if err != nil {
+			m.logger.Warn().Err(err).Str("dir", dir).Msg("Skipping unreadable cache entry")
+			continue
+		}
+		entry.dir = dir
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+func (m *Manager) entryDir(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(m.dir, hex.EncodeToString(sum[:16]))
+}
+
+func readEntry(dir string) (*Entry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, metadataFile))
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode cache metadata: %w", err)
+	}
+	return &entry, nil
+}
+
+func writeEntry(dir string, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache metadata: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, metadataFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache metadata: %w", err)
+	}
+	return nil
+}
+
+// workspacePath joins a relative cache path onto the workspace, rejecting
+// paths that would escape it.
+func workspacePath(workspace, path string) (string, error) {
+	if path == "" || filepath.IsAbs(path) {
+		return "", fmt.Errorf("cache path %q must be relative to the workspace", path)
+	}
+	clean := filepath.Clean(filepath.FromSlash(path))
+	if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("cache path %q escapes the workspace", path)
+	}
+	return filepath.Join(workspace, clean), nil
+}
+
+// copyTree copies a file, symlink, or directory tree from src to dst.
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			// Skip sockets, devices, and other special files.
+			return nil
+		}
+	})
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func dirSize(root string) (int64, error) {
+	var size int64
+	err := filepath.Walk(root, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure cache size: %w", err)
+	}
+	return size, nil
+}
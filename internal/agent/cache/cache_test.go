@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+}
+
+func TestSaveAndRestore(t *testing.T) {
+	mgr, err := NewManager(t.TempDir(), 0, zerolog.New(io.Discard))
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "node_modules", "left-pad", "index.js"), "module.exports = 1")
+	if err := os.Symlink("left-pad", filepath.Join(src, "node_modules", "pad")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	if err := mgr.Save("npm-1", src, []string{"node_modules", "missing"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	dst := t.TempDir()
+	hit, err := mgr.Restore("npm-1", dst, []string{"node_modules", "missing"})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if !hit {
+		t.Fatal("expected cache hit")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dst, "node_modules", "left-pad", "index.js"))
+	if err != nil {
+		t.Fatalf("read restored file: %v", err)
+	}
+	if string(data) != "module.exports = 1" {
+		t.Fatalf("unexpected restored content %q", data)
+	}
+	if link, err := os.Readlink(filepath.Join(dst, "node_modules", "pad")); err != nil || link != "left-pad" {
+		t.Fatalf("expected symlink to be restored, got %q (%v)", link, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "missing")); !os.IsNotExist(err) {
+		t.Fatalf("expected missing path to stay absent, got %v", err)
+	}
+}
+
+func TestRestoreMiss(t *testing.T) {
+	mgr, err := NewManager(t.TempDir(), 0, zerolog.New(io.Discard))
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	hit, err := mgr.Restore("unknown", t.TempDir(), []string{"vendor"})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if hit {
+		t.Fatal("expected cache miss")
+	}
+}
+
+func TestSaveKeepsExistingEntry(t *testing.T) {
+	mgr, err := NewManager(t.TempDir(), 0, zerolog.New(io.Discard))
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	first := t.TempDir()
+	writeFile(t, filepath.Join(first, "deps", "a.txt"), "first")
+	if err := mgr.Save("key", first, []string{"deps"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	second := t.TempDir()
+	writeFile(t, filepath.Join(second, "deps", "a.txt"), "second")
+	if err := mgr.Save("key", second, []string{"deps"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	dst := t.TempDir()
+	if _, err := mgr.Restore("key", dst, []string{"deps"}); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dst, "deps", "a.txt"))
+	if string(data) != "first" {
+		t.Fatalf("expected first saved content, got %q", data)
+	}
+}
+
+func TestEvictLeastRecentlyUsed(t *testing.T) {
+	mgr, err := NewManager(t.TempDir(), 15, zerolog.New(io.Discard))
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	for _, key := range []string{"old", "new"} {
+		ws := t.TempDir()
+		writeFile(t, filepath.Join(ws, "deps", "file"), "0123456789")
+		if err := mgr.Save(key, ws, []string{"deps"}); err != nil {
+			t.Fatalf("Save %s: %v", key, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	entries, err := mgr.Entries()
+	if err != nil {
+		t.Fatalf("Entries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Key != "new" {
+		t.Fatalf("expected only the newest entry to survive, got %+v", entries)
+	}
+}
+
+func TestRejectsEscapingPaths(t *testing.T) {
+	mgr, err := NewManager(t.TempDir(), 0, zerolog.New(io.Discard))
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	for _, path := range []string{"../outside", "/etc", ""} {
+		if err := mgr.Save("key", t.TempDir(), []string{path}); err == nil {
+			t.Fatalf("expected error for path %q", path)
+		}
+	}
+}
+
+func TestResolveKey(t *testing.T) {
+	ws := t.TempDir()
+	writeFile(t, filepath.Join(ws, "go.sum"), "deps")
+
+	key, err := ResolveKey("go-{{ .OS }}-{{ checksum \"go.sum\" }}", ws)
+	if err != nil {
+		t.Fatalf("ResolveKey: %v", err)
+	}
+	if !strings.HasPrefix(key, "go-"+runtime.GOOS+"-") || len(key) != len("go-"+runtime.GOOS+"-")+64 {
+		t.Fatalf("unexpected key %q", key)
+	}
+
+	plain, err := ResolveKey("static-key", ws)
+	if err != nil || plain != "static-key" {
+		t.Fatalf("expected static key unchanged, got %q (%v)", plain, err)
+	}
+
+	if _, err := ResolveKey("{{ checksum \"missing.lock\" }}", ws); err == nil {
+		t.Fatal("expected error for missing checksum file")
+	}
+}
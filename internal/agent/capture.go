@@ -0,0 +1,201 @@
+package agent
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+// capturedMediaExtensions are the screenshot and video files captured as
+// they are; their formats are compressed already.
+var capturedMediaExtensions = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".gif":  true,
+	".webp": true,
+	".mp4":  true,
+	".webm": true,
+	".mov":  true,
+}
+
+func isCapturedMedia(path string) bool {
+	return capturedMediaExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// captureArtifacts stages the files matching a test's capture patterns,
+// such as playwright-report/ or cypress/videos/, for upload. Screenshots
+// and videos are kept as they are, so they can be shown in a gallery. Other
+// files are compressed into stagingDir: a captured directory's into one
+// tar.gz archive named after it, a single file into a .gz file.
+func (a *Agent) captureArtifacts(workspacePath, stagingDir string, test *conductorv1.TestToRun) []collectedArtifact {
+	if stagingDir == "" {
+		return nil
+	}
+
+	var artifacts []collectedArtifact
+	seen := make(map[string]bool)
+	for _, pattern := range test.CapturePaths {
+		matches, err := a.repoMgr.Glob(workspacePath, pattern)
+		if err != nil {
+			a.logger.Debug().Err(err).Str("pattern", pattern).Msg("Capture glob failed")
+			continue
+		}
+		for _, match := range matches {
+			if seen[match] {
+				continue
+			}
+			seen[match] = true
+
+			captured, err := stageCapture(workspacePath, filepath.Join(stagingDir, test.TestId), match)
+			if err != nil {
+				a.logger.Debug().Err(err).Str("path", match).Msg("Capture failed")
+				continue
+			}
+			for i := range captured {
+				captured[i].testID = test.TestId
+			}
+			artifacts = append(artifacts, captured...)
+		}
+	}
+	return artifacts
+}
+
+// stageCapture stages a captured file or directory of the workspace for
+// upload. Paths outside the workspace are not captured.
+func stageCapture(workspacePath, stagingDir, path string) ([]collectedArtifact, error) {
+	rel, err := filepath.Rel(workspacePath, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%s is outside the workspace", path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		if isCapturedMedia(path) {
+			return []collectedArtifact{{path: path, size: info.Size()}}, nil
+		}
+		staged, err := gzipFile(path, filepath.Join(stagingDir, rel+".gz"))
+		if err != nil {
+			return nil, err
+		}
+		return []collectedArtifact{staged}, nil
+	}
+
+	var media, rest []string
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		if isCapturedMedia(file) {
+			media = append(media, file)
+		} else {
+			rest = append(rest, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var artifacts []collectedArtifact
+	for _, file := range media {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, collectedArtifact{path: file, size: info.Size()})
+	}
+	if len(rest) > 0 {
+		staged, err := tarGzFiles(path, rest, filepath.Join(stagingDir, rel+".tar.gz"))
+		if err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, staged)
+	}
+	return artifacts, nil
+}
+
+// gzipFile compresses a file to dest.
+func gzipFile(path, dest string) (collectedArtifact, error) {
+	return writeCompressed(dest, func(zw *gzip.Writer) error {
+		zw.Name = filepath.Base(path)
+		return copyFile(zw, path)
+	})
+}
+
+// tarGzFiles archives files of dir, by their paths relative to it, to dest.
+func tarGzFiles(dir string, files []string, dest string) (collectedArtifact, error) {
+	return writeCompressed(dest, func(zw *gzip.Writer) error {
+		tw := tar.NewWriter(zw)
+		for _, file := range files {
+			info, err := os.Stat(file)
+			if err != nil {
+				return err
+			}
+			header, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, file)
+			if err != nil {
+				return err
+			}
+			header.Name = filepath.ToSlash(rel)
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+			if err := copyFile(tw, file); err != nil {
+				return err
+			}
+		}
+		return tw.Close()
+	})
+}
+
+// writeCompressed writes a gzip stream to dest and returns it as an artifact.
+func writeCompressed(dest string, write func(zw *gzip.Writer) error) (collectedArtifact, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return collectedArtifact{}, err
+	}
+	f, err := os.Create(dest)
+	if err != nil {
+		return collectedArtifact{}, err
+	}
+	defer f.Close()
+
+	zw := gzip.NewWriter(f)
+	if err := write(zw); err != nil {
+		return collectedArtifact{}, fmt.Errorf("compress %s: %w", dest, err)
+	}
+	if err := zw.Close(); err != nil {
+		return collectedArtifact{}, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return collectedArtifact{}, err
+	}
+	return collectedArtifact{path: dest, size: info.Size()}, nil
+}
+
+func copyFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
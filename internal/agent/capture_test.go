@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeWorkspaceFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStageCapture(t *testing.T) {
+	workspace := t.TempDir()
+	staging := t.TempDir()
+	report := filepath.Join(workspace, "playwright-report")
+	writeWorkspaceFile(t, filepath.Join(report, "index.html"), "<html></html>")
+	writeWorkspaceFile(t, filepath.Join(report, "data", "trace.json"), "{}")
+	writeWorkspaceFile(t, filepath.Join(report, "data", "failed.png"), "png")
+	writeWorkspaceFile(t, filepath.Join(workspace, "cypress", "videos", "login.mp4"), "mp4")
+	writeWorkspaceFile(t, filepath.Join(workspace, "console.log"), "log")
+
+	t.Run("directories keep media and archive the rest", func(t *testing.T) {
+		artifacts, err := stageCapture(workspace, staging, report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(artifacts) != 2 {
+			t.Fatalf("artifacts = %v, want the screenshot and an archive", artifacts)
+		}
+		if artifacts[0].path != filepath.Join(report, "data", "failed.png") {
+			t.Errorf("media path = %q, want the screenshot as is", artifacts[0].path)
+		}
+
+		archive := artifacts[1].path
+		if archive != filepath.Join(staging, "playwright-report.tar.gz") {
+			t.Fatalf("archive path = %q", archive)
+		}
+		f, err := os.Open(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		tr := tar.NewReader(zr)
+		for {
+			header, err := tr.Next()
+			if err != nil {
+				break
+			}
+			names = append(names, header.Name)
+		}
+		if want := []string{"data/trace.json", "index.html"}; !slices.Equal(names, want) {
+			t.Errorf("archived = %v, want %v", names, want)
+		}
+	})
+
+	t.Run("media files are captured as is", func(t *testing.T) {
+		video := filepath.Join(workspace, "cypress", "videos", "login.mp4")
+		artifacts, err := stageCapture(workspace, staging, video)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(artifacts) != 1 || artifacts[0].path != video || artifacts[0].size != 3 {
+			t.Errorf("artifacts = %v, want the video as is", artifacts)
+		}
+	})
+
+	t.Run("other files are gzipped", func(t *testing.T) {
+		artifacts, err := stageCapture(workspace, staging, filepath.Join(workspace, "console.log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(artifacts) != 1 || artifacts[0].path != filepath.Join(staging, "console.log.gz") {
+			t.Errorf("artifacts = %v, want console.log.gz", artifacts)
+		}
+	})
+
+	t.Run("paths outside the workspace are rejected", func(t *testing.T) {
+		if _, err := stageCapture(workspace, staging, filepath.Dir(workspace)); err == nil {
+			t.Error("stageCapture outside the workspace succeeded")
+		}
+	})
+}
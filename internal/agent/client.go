@@ -8,16 +8,20 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"strings"
 	"sync"
 	"time"
 
 	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/pkg/compression"
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // Client manages the gRPC connection to the control plane.
@@ -33,6 +37,10 @@ type Client struct {
 
 	// Reconnection state
 	reconnectAttempt int
+
+	// compression is the compressor the work stream is opened with; it
+	// falls back to none if the control plane does not support it.
+	compression string
 }
 
 // WorkStream wraps the bidirectional gRPC stream for type-safe operations.
@@ -44,8 +52,9 @@ type WorkStream struct {
 // NewClient creates a new control plane client.
 func NewClient(cfg *Config, logger zerolog.Logger) *Client {
 	return &Client{
-		config: cfg,
-		logger: logger.With().Str("component", "client").Logger(),
+		config:      cfg,
+		logger:      logger.With().Str("component", "client").Logger(),
+		compression: cfg.Compression,
 	}
 }
 
@@ -127,7 +136,13 @@ func (c *Client) WorkStream(ctx context.Context) (*WorkStream, error) {
 	})
 	ctx = metadata.NewOutgoingContext(ctx, md)
 
-	stream, err := c.client.WorkStream(ctx)
+	// The control plane answers in the compression the agent sends with
+	var callOpts []grpc.CallOption
+	if c.compression != "" && c.compression != compression.None {
+		callOpts = append(callOpts, grpc.UseCompressor(c.compression))
+	}
+
+	stream, err := c.client.WorkStream(ctx, callOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open work stream: %w", err)
 	}
@@ -169,6 +184,28 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// FallBackFromCompression turns compression off if err shows that the
+// control plane cannot decompress the agent's messages, as control planes
+// that predate compression cannot. It reports whether it did, so the agent
+// can reconnect uncompressed right away.
+func (c *Client) FallBackFromCompression(err error) bool {
+	if status.Code(err) != codes.Unimplemented || !strings.Contains(err.Error(), "grpc-encoding") {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.compression == "" || c.compression == compression.None {
+		return false
+	}
+	c.logger.Warn().
+		Str("compression", c.compression).
+		Msg("Control plane does not support compression, falling back to none")
+	c.compression = compression.None
+	return true
+}
+
 // NextReconnectInterval returns the next reconnection interval using exponential backoff.
 func (c *Client) NextReconnectInterval() time.Duration {
 	c.mu.Lock()
@@ -0,0 +1,32 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/conductor/conductor/pkg/compression"
+)
+
+func TestClient_FallBackFromCompression(t *testing.T) {
+	unsupported := fmt.Errorf("failed to receive register response: %w",
+		status.Error(codes.Unimplemented, `grpc: Decompressor is not installed for grpc-encoding "zstd"`))
+
+	client := NewClient(&Config{Compression: compression.Zstd}, zerolog.Nop())
+	if client.FallBackFromCompression(errors.New("connection refused")) {
+		t.Error("fell back on an unrelated error")
+	}
+	if !client.FallBackFromCompression(unsupported) {
+		t.Fatal("did not fall back when the control plane lacks the compressor")
+	}
+	if client.compression != compression.None {
+		t.Errorf("compression = %q, want none", client.compression)
+	}
+	if client.FallBackFromCompression(unsupported) {
+		t.Error("fell back twice")
+	}
+}
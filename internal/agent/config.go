@@ -11,6 +11,12 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/internal/secrets"
+	"github.com/conductor/conductor/pkg/compression"
+	conductorlog "github.com/conductor/conductor/pkg/log"
 )
 
 // Config holds all configuration settings for the agent.
@@ -37,9 +43,20 @@ type Config struct {
 	// Labels are key-value pairs for agent selection and filtering.
 	Labels map[string]string
 
+	// Pool is the name of the agent pool to join. Empty joins no pool.
+	Pool string
+
+	// Project is the ID of the project the agent runs work for. Empty uses
+	// the control plane's default project.
+	Project string
+
 	// MaxParallel is the maximum number of parallel test runs (default: 4).
 	MaxParallel int
 
+	// MaxArtifactBytes is the largest artifact the agent uploads, advertised
+	// to the control plane at registration (default: 0, no limit).
+	MaxArtifactBytes int64
+
 	// WorkspaceDir is the base directory for test workspaces (default: /tmp/conductor/workspaces).
 	WorkspaceDir string
 
@@ -49,6 +66,21 @@ type Config struct {
 	// StateDir is the directory for persistent state (default: /var/lib/conductor).
 	StateDir string
 
+	// DependencyCacheDir is the directory for keyed dependency caches such as
+	// module caches and node_modules (default: /tmp/conductor/depcache).
+	DependencyCacheDir string
+
+	// DependencyCacheSizeMB is the total size budget for dependency caches in
+	// megabytes; least recently used entries are evicted beyond it (default: 10240).
+	// Zero disables eviction.
+	DependencyCacheSizeMB int
+
+	// GitSubmodules checks out submodules recursively after cloning (default: false).
+	GitSubmodules bool
+
+	// GitLFS pulls Git LFS objects after cloning; requires git-lfs (default: false).
+	GitLFS bool
+
 	// HeartbeatInterval is the interval for sending heartbeats (default: 30s).
 	HeartbeatInterval time.Duration
 
@@ -58,15 +90,51 @@ type Config struct {
 	// ReconnectMaxInterval is the maximum reconnection interval (default: 60s).
 	ReconnectMaxInterval time.Duration
 
+	// ReportBufferSize is the number of result stream messages kept in
+	// memory; further messages are spooled to the state directory until the
+	// control plane catches up (default: 10000).
+	ReportBufferSize int
+
+	// ReportRateLimit is the number of result stream messages sent per
+	// second; 0 means no limit (default: 1000).
+	ReportRateLimit float64
+
+	// Compression is the compression proposed for the work stream: zstd,
+	// gzip or none. The agent falls back to none if the control plane does
+	// not support it (default: zstd).
+	Compression string
+
 	// DefaultTimeout is the default timeout for test execution (default: 30m).
 	DefaultTimeout time.Duration
 
-	// LogLevel is the log level (debug, info, warn, error) (default: info).
+	// LogLevel is the log level (debug, info, warn, error), optionally
+	// followed by levels of components, such as info,client=debug
+	// (default: info).
 	LogLevel string
 
+	// LogLevelFile is a file the log levels are reloaded from on SIGHUP, in
+	// the format of LogLevel. If empty, SIGHUP restores LogLevel.
+	LogLevelFile string
+
+	// LogLevels holds the log levels, which can be changed at runtime. New
+	// creates them from LogLevel if nil.
+	LogLevels *conductorlog.Levels
+
 	// LogFormat is the log format (json, console) (default: json).
 	LogFormat string
 
+	// LogBufferLines is the number of recent log lines kept in memory for
+	// retrieval by the control plane (default: 1000). 0 disables retrieval.
+	LogBufferLines int
+
+	// DiagnosticsEnabled serves pprof profiles and runtime diagnostics on the
+	// metrics server (default: false).
+	DiagnosticsEnabled bool
+
+	// DiagnosticsToken is the bearer token required by the diagnostics
+	// endpoints.
+	DiagnosticsToken string
+
 	// TLSEnabled enables TLS for the control plane connection.
 	TLSEnabled bool
 
@@ -106,7 +174,8 @@ type Config struct {
 	// StorageUseSSL enables SSL for storage connections (default: true).
 	StorageUseSSL bool
 
-	// SecretsProvider enables secret resolution (vault).
+	// SecretsProvider is the default provider for secrets that do not name
+	// one (vault, aws, envfile).
 	SecretsProvider string
 
 	// VaultAddress is the Vault API address for secret resolution.
@@ -124,6 +193,30 @@ type Config struct {
 	// VaultTimeout is the HTTP timeout for Vault requests (default: 10s).
 	VaultTimeout time.Duration
 
+	// AWSRegion enables AWS Secrets Manager resolution in the given region.
+	AWSRegion string
+
+	// AWSEndpoint overrides the Secrets Manager endpoint (e.g. LocalStack).
+	AWSEndpoint string
+
+	// AWSAccessKeyID is the access key for Secrets Manager; falls back to AWS_ACCESS_KEY_ID.
+	AWSAccessKeyID string
+
+	// AWSSecretAccessKey is the secret key for Secrets Manager; falls back to AWS_SECRET_ACCESS_KEY.
+	AWSSecretAccessKey string
+
+	// AWSSessionToken is the optional session token for temporary credentials.
+	AWSSessionToken string
+
+	// EnvFileDir enables sealed env-file resolution from this directory.
+	EnvFileDir string
+
+	// EnvFileKey is the base64-encoded 32-byte key the env files are sealed with.
+	EnvFileKey string
+
+	// SecretsCacheTTL is how long resolved secrets are cached (default: 5m). Zero disables caching.
+	SecretsCacheTTL time.Duration
+
 	// ResourceCheckInterval is how often to check system resources (default: 10s).
 	ResourceCheckInterval time.Duration
 
@@ -153,16 +246,30 @@ func Load() (*Config, error) {
 		NetworkZones:          getEnvStringSlice("CONDUCTOR_AGENT_NETWORK_ZONES", []string{"default"}),
 		Runtimes:              getEnvStringSlice("CONDUCTOR_AGENT_RUNTIMES", nil),
 		Labels:                getEnvMap("CONDUCTOR_AGENT_LABELS"),
+		Pool:                  getEnv("CONDUCTOR_AGENT_POOL", ""),
+		Project:               getEnv("CONDUCTOR_AGENT_PROJECT", ""),
 		MaxParallel:           getEnvInt("CONDUCTOR_AGENT_MAX_PARALLEL", 4),
+		MaxArtifactBytes:      getEnvInt64("CONDUCTOR_AGENT_MAX_ARTIFACT_BYTES", 0),
 		WorkspaceDir:          getEnv("CONDUCTOR_AGENT_WORKSPACE_DIR", "/tmp/conductor/workspaces"),
 		CacheDir:              getEnv("CONDUCTOR_AGENT_CACHE_DIR", "/tmp/conductor/cache"),
 		StateDir:              getEnv("CONDUCTOR_AGENT_STATE_DIR", "/var/lib/conductor"),
+		DependencyCacheDir:    getEnv("CONDUCTOR_AGENT_DEPENDENCY_CACHE_DIR", "/tmp/conductor/depcache"),
+		DependencyCacheSizeMB: getEnvInt("CONDUCTOR_AGENT_DEPENDENCY_CACHE_SIZE_MB", 10240),
+		GitSubmodules:         getEnvBool("CONDUCTOR_AGENT_GIT_SUBMODULES", false),
+		GitLFS:                getEnvBool("CONDUCTOR_AGENT_GIT_LFS", false),
 		HeartbeatInterval:     getEnvDuration("CONDUCTOR_AGENT_HEARTBEAT_INTERVAL", 30*time.Second),
 		ReconnectMinInterval:  getEnvDuration("CONDUCTOR_AGENT_RECONNECT_MIN_INTERVAL", 1*time.Second),
 		ReconnectMaxInterval:  getEnvDuration("CONDUCTOR_AGENT_RECONNECT_MAX_INTERVAL", 60*time.Second),
+		Compression:           strings.ToLower(getEnv("CONDUCTOR_AGENT_COMPRESSION", compression.Zstd)),
+		ReportBufferSize:      getEnvInt("CONDUCTOR_AGENT_REPORT_BUFFER_SIZE", 10000),
+		ReportRateLimit:       getEnvFloat64("CONDUCTOR_AGENT_REPORT_RATE_LIMIT", 1000),
 		DefaultTimeout:        getEnvDuration("CONDUCTOR_AGENT_DEFAULT_TIMEOUT", 30*time.Minute),
 		LogLevel:              getEnv("CONDUCTOR_AGENT_LOG_LEVEL", "info"),
+		LogLevelFile:          getEnv("CONDUCTOR_AGENT_LOG_LEVEL_FILE", ""),
 		LogFormat:             getEnv("CONDUCTOR_AGENT_LOG_FORMAT", "json"),
+		LogBufferLines:        getEnvInt("CONDUCTOR_AGENT_LOG_BUFFER_LINES", 1000),
+		DiagnosticsEnabled:    getEnvBool("CONDUCTOR_AGENT_DIAGNOSTICS_ENABLED", false),
+		DiagnosticsToken:      getEnv("CONDUCTOR_AGENT_DIAGNOSTICS_TOKEN", ""),
 		TLSEnabled:            getEnvBool("CONDUCTOR_AGENT_TLS_ENABLED", false),
 		TLSCertFile:           getEnv("CONDUCTOR_AGENT_TLS_CERT_FILE", ""),
 		TLSKeyFile:            getEnv("CONDUCTOR_AGENT_TLS_KEY_FILE", ""),
@@ -182,14 +289,29 @@ func Load() (*Config, error) {
 		VaultNamespace:        getEnv("CONDUCTOR_AGENT_SECRETS_VAULT_NAMESPACE", ""),
 		VaultMount:            getEnv("CONDUCTOR_AGENT_SECRETS_VAULT_MOUNT", "secret"),
 		VaultTimeout:          getEnvDuration("CONDUCTOR_AGENT_SECRETS_VAULT_TIMEOUT", 10*time.Second),
+		AWSRegion:             getEnv("CONDUCTOR_AGENT_SECRETS_AWS_REGION", ""),
+		AWSEndpoint:           getEnv("CONDUCTOR_AGENT_SECRETS_AWS_ENDPOINT", ""),
+		AWSAccessKeyID:        getEnv("CONDUCTOR_AGENT_SECRETS_AWS_ACCESS_KEY_ID", ""),
+		AWSSecretAccessKey:    getEnv("CONDUCTOR_AGENT_SECRETS_AWS_SECRET_ACCESS_KEY", ""),
+		AWSSessionToken:       getEnv("CONDUCTOR_AGENT_SECRETS_AWS_SESSION_TOKEN", ""),
+		EnvFileDir:            getEnv("CONDUCTOR_AGENT_SECRETS_ENV_FILE_DIR", ""),
+		EnvFileKey:            getEnv("CONDUCTOR_AGENT_SECRETS_ENV_FILE_KEY", ""),
+		SecretsCacheTTL:       getEnvDuration("CONDUCTOR_AGENT_SECRETS_CACHE_TTL", 5*time.Minute),
 		ResourceCheckInterval: getEnvDuration("CONDUCTOR_AGENT_RESOURCE_CHECK_INTERVAL", 10*time.Second),
 		CPUThreshold:          getEnvFloat64("CONDUCTOR_AGENT_CPU_THRESHOLD", 90.0),
 		MemoryThreshold:       getEnvFloat64("CONDUCTOR_AGENT_MEMORY_THRESHOLD", 90.0),
 		DiskThreshold:         getEnvFloat64("CONDUCTOR_AGENT_DISK_THRESHOLD", 90.0),
 	}
 
-	if cfg.SecretsProvider == "" && cfg.VaultAddress != "" {
-		cfg.SecretsProvider = "vault"
+	if cfg.SecretsProvider == "" {
+		switch {
+		case cfg.VaultAddress != "":
+			cfg.SecretsProvider = "vault"
+		case cfg.AWSRegion != "":
+			cfg.SecretsProvider = "aws"
+		case cfg.EnvFileDir != "":
+			cfg.SecretsProvider = "envfile"
+		}
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -213,6 +335,11 @@ func (c *Config) Validate() error {
 	if c.AgentToken == "" {
 		errs = append(errs, errors.New("CONDUCTOR_AGENT_TOKEN is required"))
 	}
+	if c.Project != "" {
+		if _, err := uuid.Parse(c.Project); err != nil {
+			errs = append(errs, errors.New("CONDUCTOR_AGENT_PROJECT must be a project ID"))
+		}
+	}
 
 	// Validate MaxParallel
 	if c.MaxParallel < 1 {
@@ -221,6 +348,9 @@ func (c *Config) Validate() error {
 	if c.MaxParallel > 100 {
 		errs = append(errs, errors.New("CONDUCTOR_AGENT_MAX_PARALLEL cannot exceed 100"))
 	}
+	if c.MaxArtifactBytes < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_AGENT_MAX_ARTIFACT_BYTES cannot be negative"))
+	}
 
 	// Validate directories are absolute paths
 	if c.WorkspaceDir != "" && !strings.HasPrefix(c.WorkspaceDir, "/") && runtime.GOOS != "windows" {
@@ -232,6 +362,12 @@ func (c *Config) Validate() error {
 	if c.StateDir != "" && !strings.HasPrefix(c.StateDir, "/") && runtime.GOOS != "windows" {
 		errs = append(errs, errors.New("CONDUCTOR_AGENT_STATE_DIR must be an absolute path"))
 	}
+	if c.DependencyCacheDir != "" && !strings.HasPrefix(c.DependencyCacheDir, "/") && runtime.GOOS != "windows" {
+		errs = append(errs, errors.New("CONDUCTOR_AGENT_DEPENDENCY_CACHE_DIR must be an absolute path"))
+	}
+	if c.DependencyCacheSizeMB < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_AGENT_DEPENDENCY_CACHE_SIZE_MB cannot be negative"))
+	}
 
 	// Validate intervals
 	if c.HeartbeatInterval < 5*time.Second {
@@ -243,19 +379,34 @@ func (c *Config) Validate() error {
 	if c.ReconnectMaxInterval < c.ReconnectMinInterval {
 		errs = append(errs, errors.New("CONDUCTOR_AGENT_RECONNECT_MAX_INTERVAL must be >= MIN_INTERVAL"))
 	}
+	if c.ReportBufferSize < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_AGENT_REPORT_BUFFER_SIZE cannot be negative"))
+	}
+	if c.ReportRateLimit < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_AGENT_REPORT_RATE_LIMIT cannot be negative"))
+	}
+	if c.Compression != "" && !compression.Valid(c.Compression) {
+		errs = append(errs, errors.New("CONDUCTOR_AGENT_COMPRESSION must be one of: zstd, gzip, none"))
+	}
 	if c.DefaultTimeout < 1*time.Minute {
 		errs = append(errs, errors.New("CONDUCTOR_AGENT_DEFAULT_TIMEOUT must be at least 1 minute"))
 	}
 
 	// Validate log settings
-	validLevels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
-	if !validLevels[strings.ToLower(c.LogLevel)] {
-		errs = append(errs, errors.New("CONDUCTOR_AGENT_LOG_LEVEL must be one of: debug, info, warn, error"))
+	if _, _, err := conductorlog.ParseLevels(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("CONDUCTOR_AGENT_LOG_LEVEL must be one of: debug, info, warn, error, optionally followed by component=level pairs: %w", err))
 	}
 	validFormats := map[string]bool{"json": true, "console": true}
 	if !validFormats[strings.ToLower(c.LogFormat)] {
 		errs = append(errs, errors.New("CONDUCTOR_AGENT_LOG_FORMAT must be one of: json, console"))
 	}
+	if c.LogBufferLines < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_AGENT_LOG_BUFFER_LINES cannot be negative"))
+	}
+
+	if c.DiagnosticsEnabled && c.DiagnosticsToken == "" {
+		errs = append(errs, errors.New("CONDUCTOR_AGENT_DIAGNOSTICS_TOKEN is required when diagnostics are enabled"))
+	}
 
 	// Validate TLS settings
 	if c.TLSEnabled {
@@ -268,10 +419,11 @@ func (c *Config) Validate() error {
 	}
 
 	// Validate secrets settings
-	if c.SecretsProvider != "" && c.SecretsProvider != "vault" {
-		errs = append(errs, errors.New("CONDUCTOR_AGENT_SECRETS_PROVIDER must be empty or 'vault'"))
+	validProviders := map[string]bool{"": true, "vault": true, "aws": true, "envfile": true}
+	if !validProviders[c.SecretsProvider] {
+		errs = append(errs, errors.New("CONDUCTOR_AGENT_SECRETS_PROVIDER must be empty or one of: vault, aws, envfile"))
 	}
-	if c.SecretsProvider == "vault" {
+	if c.SecretsProvider == "vault" || c.VaultAddress != "" {
 		if c.VaultAddress == "" {
 			errs = append(errs, errors.New("CONDUCTOR_AGENT_SECRETS_VAULT_ADDR is required when secrets provider is vault"))
 		}
@@ -279,6 +431,20 @@ func (c *Config) Validate() error {
 			errs = append(errs, errors.New("CONDUCTOR_AGENT_SECRETS_VAULT_TOKEN is required when secrets provider is vault"))
 		}
 	}
+	if c.SecretsProvider == "aws" && c.AWSRegion == "" {
+		errs = append(errs, errors.New("CONDUCTOR_AGENT_SECRETS_AWS_REGION is required when secrets provider is aws"))
+	}
+	if c.SecretsProvider == "envfile" || c.EnvFileDir != "" {
+		if c.EnvFileDir == "" {
+			errs = append(errs, errors.New("CONDUCTOR_AGENT_SECRETS_ENV_FILE_DIR is required when secrets provider is envfile"))
+		}
+		if _, err := secrets.NewCipherFromBase64(c.EnvFileKey); err != nil {
+			errs = append(errs, errors.New("CONDUCTOR_AGENT_SECRETS_ENV_FILE_KEY must be a base64-encoded 32-byte key"))
+		}
+	}
+	if c.SecretsCacheTTL < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_AGENT_SECRETS_CACHE_TTL cannot be negative"))
+	}
 
 	// Validate resource thresholds
 	if c.CPUThreshold <= 0 || c.CPUThreshold > 100 {
@@ -339,6 +505,15 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if intVal, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return intVal
+		}
+	}
+	return defaultValue
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolVal, err := strconv.ParseBool(value); err == nil {
@@ -101,6 +101,29 @@ func TestConfig_Validate_Required(t *testing.T) {
 			},
 			wantErrs: nil,
 		},
+		{
+			name: "invalid project",
+			config: Config{
+				AgentID:              "test-agent",
+				AgentToken:           "token",
+				ControlPlaneURL:      "localhost:50051",
+				Project:              "payments",
+				MaxParallel:          4,
+				WorkspaceDir:         "/tmp/workspaces",
+				CacheDir:             "/tmp/cache",
+				StateDir:             "/var/lib/conductor",
+				HeartbeatInterval:    30 * time.Second,
+				ReconnectMinInterval: 1 * time.Second,
+				ReconnectMaxInterval: 60 * time.Second,
+				DefaultTimeout:       30 * time.Minute,
+				LogLevel:             "info",
+				LogFormat:            "json",
+				CPUThreshold:         90,
+				MemoryThreshold:      90,
+				DiskThreshold:        90,
+			},
+			wantErrs: []string{"CONDUCTOR_AGENT_PROJECT must be a project ID"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -298,7 +321,7 @@ func TestConfig_Validate_LogSettings(t *testing.T) {
 	})
 
 	t.Run("valid log settings", func(t *testing.T) {
-		for _, level := range []string{"debug", "info", "warn", "error"} {
+		for _, level := range []string{"debug", "info", "warn", "error", "info,client=debug"} {
 			for _, format := range []string{"json", "console"} {
 				cfg := baseConfig()
 				cfg.LogLevel = level
@@ -377,6 +400,38 @@ func TestConfig_Validate_TLS(t *testing.T) {
 	})
 }
 
+func TestConfig_Validate_Diagnostics(t *testing.T) {
+	cfg := Config{
+		AgentID:              "test-agent",
+		AgentToken:           "token",
+		ControlPlaneURL:      "localhost:50051",
+		MaxParallel:          4,
+		WorkspaceDir:         "/tmp/workspaces",
+		CacheDir:             "/tmp/cache",
+		StateDir:             "/var/lib/conductor",
+		HeartbeatInterval:    30 * time.Second,
+		ReconnectMinInterval: 1 * time.Second,
+		ReconnectMaxInterval: 60 * time.Second,
+		DefaultTimeout:       30 * time.Minute,
+		LogLevel:             "info",
+		LogFormat:            "json",
+		CPUThreshold:         90,
+		MemoryThreshold:      90,
+		DiskThreshold:        90,
+		DiagnosticsEnabled:   true,
+	}
+
+	err := cfg.Validate()
+	if err == nil || !containsSubstring(err.Error(), "DIAGNOSTICS_TOKEN is required") {
+		t.Errorf("expected diagnostics token error, got %v", err)
+	}
+
+	cfg.DiagnosticsToken = "debug-token"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+}
+
 func TestConfig_Validate_ResourceThresholds(t *testing.T) {
 	baseConfig := func() Config {
 		return Config{
@@ -442,9 +497,12 @@ func TestLoad_FromEnvironment(t *testing.T) {
 	os.Setenv("CONDUCTOR_AGENT_CONTROL_PLANE_URL", "localhost:50051")
 	os.Setenv("CONDUCTOR_AGENT_TOKEN", "secret-token")
 	os.Setenv("CONDUCTOR_AGENT_MAX_PARALLEL", "8")
+	os.Setenv("CONDUCTOR_AGENT_MAX_ARTIFACT_BYTES", "104857600")
 	os.Setenv("CONDUCTOR_AGENT_NETWORK_ZONES", "zone1,zone2,zone3")
 	os.Setenv("CONDUCTOR_AGENT_RUNTIMES", "node18, python3.11, go1.21")
 	os.Setenv("CONDUCTOR_AGENT_LABELS", "env=prod,region=us-east-1")
+	os.Setenv("CONDUCTOR_AGENT_POOL", "gpu")
+	os.Setenv("CONDUCTOR_AGENT_PROJECT", "6f1c7a52-3d0e-4b8a-9f51-2c4d7e8a9b10")
 	os.Setenv("CONDUCTOR_AGENT_HEARTBEAT_INTERVAL", "45s")
 	os.Setenv("CONDUCTOR_AGENT_LOG_LEVEL", "debug")
 	os.Setenv("CONDUCTOR_AGENT_DOCKER_ENABLED", "false")
@@ -468,6 +526,9 @@ func TestLoad_FromEnvironment(t *testing.T) {
 	if cfg.MaxParallel != 8 {
 		t.Errorf("MaxParallel = %d, want %d", cfg.MaxParallel, 8)
 	}
+	if cfg.MaxArtifactBytes != 100<<20 {
+		t.Errorf("MaxArtifactBytes = %d, want %d", cfg.MaxArtifactBytes, 100<<20)
+	}
 
 	// Check network zones
 	expectedZones := []string{"zone1", "zone2", "zone3"}
@@ -493,6 +554,12 @@ func TestLoad_FromEnvironment(t *testing.T) {
 	if cfg.Labels["region"] != "us-east-1" {
 		t.Errorf("Labels[region] = %q, want %q", cfg.Labels["region"], "us-east-1")
 	}
+	if cfg.Pool != "gpu" {
+		t.Errorf("Pool = %q, want %q", cfg.Pool, "gpu")
+	}
+	if cfg.Project != "6f1c7a52-3d0e-4b8a-9f51-2c4d7e8a9b10" {
+		t.Errorf("Project = %q, want %q", cfg.Project, "6f1c7a52-3d0e-4b8a-9f51-2c4d7e8a9b10")
+	}
 
 	// Check parsed values
 	if cfg.HeartbeatInterval != 45*time.Second {
@@ -532,6 +599,15 @@ func TestLoad_Defaults(t *testing.T) {
 	if cfg.CacheDir != "/tmp/conductor/cache" {
 		t.Errorf("CacheDir = %q, want default", cfg.CacheDir)
 	}
+	if cfg.DependencyCacheDir != "/tmp/conductor/depcache" {
+		t.Errorf("DependencyCacheDir = %q, want default", cfg.DependencyCacheDir)
+	}
+	if cfg.DependencyCacheSizeMB != 10240 {
+		t.Errorf("DependencyCacheSizeMB = %d, want default %d", cfg.DependencyCacheSizeMB, 10240)
+	}
+	if cfg.GitSubmodules || cfg.GitLFS {
+		t.Errorf("GitSubmodules = %v, GitLFS = %v, want both disabled by default", cfg.GitSubmodules, cfg.GitLFS)
+	}
 	if cfg.HeartbeatInterval != 30*time.Second {
 		t.Errorf("HeartbeatInterval = %v, want default %v", cfg.HeartbeatInterval, 30*time.Second)
 	}
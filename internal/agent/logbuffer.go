@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// logLine is a line kept by LogBuffer.
+type logLine struct {
+	level zerolog.Level
+	text  string
+}
+
+// LogBuffer keeps the most recent lines of the agent's log in memory so
+// that the control plane can retrieve them. It implements
+// zerolog.LevelWriter.
+type LogBuffer struct {
+	mu    sync.Mutex
+	lines []logLine
+	next  int
+	full  bool
+}
+
+// NewLogBuffer creates a buffer of the given number of lines.
+func NewLogBuffer(size int) *LogBuffer {
+	return &LogBuffer{lines: make([]logLine, size)}
+}
+
+// Write stores a line without a level.
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	return b.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel stores a line, replacing the oldest once the buffer is full.
+func (b *LogBuffer) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if len(b.lines) == 0 {
+		return len(p), nil
+	}
+	text := string(bytes.TrimRight(p, "\n"))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lines[b.next] = logLine{level: level, text: text}
+	b.next = (b.next + 1) % len(b.lines)
+	if b.next == 0 {
+		b.full = true
+	}
+	return len(p), nil
+}
+
+// Tail returns up to limit of the most recent lines at or above minLevel,
+// oldest first, and whether older matching lines were left out. A limit of
+// 0 returns every matching line.
+func (b *LogBuffer) Tail(limit int, minLevel zerolog.Level) ([]logLine, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var ordered []logLine
+	if b.full {
+		ordered = append(ordered, b.lines[b.next:]...)
+	}
+	ordered = append(ordered, b.lines[:b.next]...)
+
+	var matching []logLine
+	for _, line := range ordered {
+		if line.level >= minLevel {
+			matching = append(matching, line)
+		}
+	}
+	if limit > 0 && len(matching) > limit {
+		return matching[len(matching)-limit:], true
+	}
+	return matching, false
+}
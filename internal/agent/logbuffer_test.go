@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func texts(lines []logLine) []string {
+	var out []string
+	for _, line := range lines {
+		out = append(out, line.text)
+	}
+	return out
+}
+
+func TestLogBuffer(t *testing.T) {
+	logs := NewLogBuffer(3)
+	logger := zerolog.New(logs)
+
+	logger.Info().Msg("one")
+	logger.Error().Msg("two")
+	logger.Debug().Msg("three")
+	logger.Warn().Msg("four")
+
+	lines, truncated := logs.Tail(0, zerolog.TraceLevel)
+	if truncated {
+		t.Error("Tail(0) truncated = true, want false")
+	}
+	want := []string{
+		`{"level":"error","message":"two"}`,
+		`{"level":"debug","message":"three"}`,
+		`{"level":"warn","message":"four"}`,
+	}
+	if got := texts(lines); !reflect.DeepEqual(got, want) {
+		t.Errorf("Tail(0) = %v, want %v", got, want)
+	}
+
+	lines, truncated = logs.Tail(1, zerolog.WarnLevel)
+	if !truncated {
+		t.Error("Tail(1, warn) truncated = false, want true")
+	}
+	if got := texts(lines); !reflect.DeepEqual(got, want[2:]) {
+		t.Errorf("Tail(1, warn) = %v, want %v", got, want[2:])
+	}
+	if lines[0].level != zerolog.WarnLevel {
+		t.Errorf("level = %v, want warn", lines[0].level)
+	}
+}
+
+func TestLogBufferDisabled(t *testing.T) {
+	logs := NewLogBuffer(0)
+	if _, err := logs.Write([]byte("dropped\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if lines, _ := logs.Tail(0, zerolog.TraceLevel); len(lines) != 0 {
+		t.Errorf("Tail() = %v, want no lines", lines)
+	}
+}
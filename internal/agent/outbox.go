@@ -0,0 +1,343 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/time/rate"
+	"google.golang.org/protobuf/proto"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+const (
+	// defaultOutboxSize is the number of messages kept in memory if the
+	// configuration does not say.
+	defaultOutboxSize = 10000
+
+	// ackWindow is how many sent messages may wait for the control plane's
+	// acknowledgement before the outbox stops sending.
+	ackWindow = 1024
+
+	// unspoolBatch is how many spooled messages are loaded at once.
+	unspoolBatch = 256
+)
+
+// outboxSpool stores the messages that do not fit in memory.
+type outboxSpool interface {
+	SpoolMessage(sequence int64, message []byte) error
+	UnspoolMessages(limit int) ([][]byte, error)
+	SpooledMessageCount() (int, error)
+}
+
+// outbox buffers the result stream messages of the reporter, so producing
+// results never waits for the control plane. Messages are sent in order at
+// a bounded rate while the agent is connected. Memory is bounded: messages
+// beyond maxMemory are spooled to disk and loaded again once the messages
+// before them are sent. While the agent is disconnected every message is
+// spooled, so runs finishing offline survive an agent restart. Control
+// planes that acknowledge results get a window of sent messages that is
+// replayed after a reconnect, so messages lost with a broken stream are
+// sent again.
+type outbox struct {
+	send      func(*conductorv1.AgentMessage) error
+	spool     outboxSpool
+	limiter   *rate.Limiter
+	maxMemory int
+	logger    zerolog.Logger
+	wake      chan struct{}
+
+	// encode and decode serialize spooled messages.
+	encode func(*conductorv1.AgentMessage) ([]byte, error)
+	decode func([]byte) (*conductorv1.AgentMessage, error)
+
+	mu sync.Mutex
+	// queue holds the messages waiting to be sent, oldest first; spooled
+	// messages are newer than all of them.
+	queue   []*conductorv1.AgentMessage
+	spooled int
+	// inflight holds the sent messages the control plane has not
+	// acknowledged yet, if it acknowledges results.
+	inflight  []*conductorv1.AgentMessage
+	connected bool
+	acks      bool
+	// processed is the highest sequence the control plane reported as
+	// processed when the agent reconnected; spooled messages up to it are
+	// dropped instead of sent again.
+	processed int64
+}
+
+// newOutbox creates an outbox that sends messages with send, at most
+// perSecond a second unless it is 0. Messages spooled by an earlier
+// process are sent first.
+func newOutbox(send func(*conductorv1.AgentMessage) error, spool outboxSpool, maxMemory int, perSecond float64, logger zerolog.Logger) *outbox {
+	if maxMemory <= 0 {
+		maxMemory = defaultOutboxSize
+	}
+	limit := rate.Inf
+	burst := 1
+	if perSecond > 0 {
+		limit = rate.Limit(perSecond)
+		burst = max(1, int(perSecond))
+	}
+
+	o := &outbox{
+		send:      send,
+		spool:     spool,
+		limiter:   rate.NewLimiter(limit, burst),
+		maxMemory: maxMemory,
+		logger:    logger,
+		wake:      make(chan struct{}, 1),
+		encode:    encodeMessage,
+		decode:    decodeMessage,
+	}
+	if spool != nil {
+		count, err := spool.SpooledMessageCount()
+		if err != nil {
+			logger.Warn().Err(err).Msg("Failed to count spooled messages")
+		}
+		if count > 0 {
+			logger.Info().Int("messages", count).Msg("Replaying messages spooled before restart")
+		}
+		o.spooled = count
+	}
+	return o
+}
+
+// push queues a message for sending. It only fails if the message fits
+// neither in memory nor in the spool, in which case it is lost.
+func (o *outbox) push(msg *conductorv1.AgentMessage) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	defer o.notify()
+
+	// Once messages are spooled, newer ones follow them to keep the order
+	fits := o.spooled == 0 && len(o.queue)+len(o.inflight) < o.maxMemory
+	if fits && o.connected {
+		o.queue = append(o.queue, msg)
+		return nil
+	}
+	if err := o.spill(msg); err != nil {
+		if !fits {
+			return fmt.Errorf("outbox full and message could not be spooled: %w", err)
+		}
+		o.logger.Warn().Err(err).Msg("Failed to spool message, keeping it in memory")
+		o.queue = append(o.queue, msg)
+	}
+	return nil
+}
+
+// run sends the queued messages until ctx is done.
+func (o *outbox) run(ctx context.Context) {
+	for {
+		msg := o.next()
+		if msg == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-o.wake:
+			}
+			continue
+		}
+
+		if err := o.limiter.Wait(ctx); err != nil {
+			return
+		}
+		if err := o.send(msg); err != nil {
+			// The stream is broken; the agent resumes once it reconnects
+			o.logger.Debug().Err(err).Msg("Failed to send message, holding messages until reconnect")
+			o.pause()
+			continue
+		}
+		o.sent(msg)
+	}
+}
+
+// next returns the next message to send, or nil if there is none or the
+// outbox waits for a reconnect or for acknowledgements.
+func (o *outbox) next() *conductorv1.AgentMessage {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !o.connected || (o.acks && len(o.inflight) >= ackWindow) {
+		return nil
+	}
+	if len(o.queue) == 0 && o.spooled > 0 {
+		o.refill()
+	}
+	if len(o.queue) == 0 {
+		return nil
+	}
+	return o.queue[0]
+}
+
+// sent removes a sent message from the queue, keeping it until it is
+// acknowledged if the control plane acknowledges results.
+func (o *outbox) sent(msg *conductorv1.AgentMessage) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// A resume may have put replayed messages before it meanwhile
+	if i := slices.Index(o.queue, msg); i >= 0 {
+		o.queue = slices.Delete(o.queue, i, i+1)
+	}
+	if o.acks {
+		o.inflight = append(o.inflight, msg)
+	}
+}
+
+// pause holds messages until resume is called. The messages in memory are
+// spooled, so they are not lost if the agent stops before it reconnects.
+func (o *outbox) pause() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.connected = false
+
+	// Spooled messages must stay newer than those in memory, so spooling
+	// starts with the newest message and stops at the first failure
+	pending := append(slices.Clone(o.inflight), o.queue...)
+	kept := len(pending)
+	for kept > 0 && o.spill(pending[kept-1]) == nil {
+		kept--
+	}
+	if kept < len(o.inflight) {
+		o.inflight = o.inflight[:kept]
+		o.queue = nil
+	} else {
+		o.queue = o.queue[:kept-len(o.inflight)]
+	}
+}
+
+// resume sends messages on a new stream. If the control plane acknowledges
+// results, sent messages after processed, the highest sequence it has
+// processed, are replayed first.
+func (o *outbox) resume(acks bool, processed int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	defer o.notify()
+
+	var replay []*conductorv1.AgentMessage
+	for _, msg := range o.inflight {
+		if messageSequence(msg) > processed {
+			replay = append(replay, msg)
+		}
+	}
+	if len(replay) > 0 {
+		o.logger.Info().Int("messages", len(replay)).Msg("Replaying unacknowledged messages")
+	}
+	o.queue = append(replay, o.queue...)
+	o.inflight = nil
+	o.acks = acks
+	o.processed = 0
+	if acks {
+		o.processed = processed
+	}
+	o.connected = true
+}
+
+// ack releases the sent messages up to sequence, which the control plane
+// has processed.
+func (o *outbox) ack(sequence int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	defer o.notify()
+
+	n := 0
+	for n < len(o.inflight) && messageSequence(o.inflight[n]) <= sequence {
+		n++
+	}
+	o.inflight = o.inflight[n:]
+}
+
+// close spools the messages in memory, so an agent that stops before
+// sending them sends them after it restarts. Sent messages that were not
+// acknowledged are spooled too.
+func (o *outbox) close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var errs []error
+	for _, msg := range append(o.inflight, o.queue...) {
+		if err := o.spill(msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	o.inflight = nil
+	o.queue = nil
+	return errors.Join(errs...)
+}
+
+// refill loads the oldest spooled messages. The caller holds o.mu.
+func (o *outbox) refill() {
+	limit := min(unspoolBatch, o.maxMemory-len(o.inflight))
+	if limit <= 0 {
+		return
+	}
+
+	batch, err := o.spool.UnspoolMessages(limit)
+	if err != nil {
+		o.logger.Warn().Err(err).Msg("Failed to load spooled messages")
+		return
+	}
+	if len(batch) == 0 {
+		o.spooled = 0
+		return
+	}
+	o.spooled = max(0, o.spooled-len(batch))
+
+	for _, data := range batch {
+		msg, err := o.decode(data)
+		if err != nil {
+			o.logger.Warn().Err(err).Msg("Dropping unreadable spooled message")
+			continue
+		}
+		if messageSequence(msg) <= o.processed {
+			continue
+		}
+		o.queue = append(o.queue, msg)
+	}
+}
+
+// spill spools a message. The caller holds o.mu.
+func (o *outbox) spill(msg *conductorv1.AgentMessage) error {
+	if o.spool == nil {
+		return errors.New("no spool")
+	}
+	data, err := o.encode(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	if err := o.spool.SpoolMessage(messageSequence(msg), data); err != nil {
+		return err
+	}
+	o.spooled++
+	return nil
+}
+
+// notify wakes run without blocking.
+func (o *outbox) notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+func messageSequence(msg *conductorv1.AgentMessage) int64 {
+	return msg.GetResultStream().GetSequence()
+}
+
+func encodeMessage(msg *conductorv1.AgentMessage) ([]byte, error) {
+	return proto.Marshal(msg)
+}
+
+func decodeMessage(data []byte) (*conductorv1.AgentMessage, error) {
+	msg := &conductorv1.AgentMessage{}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
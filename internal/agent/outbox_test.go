@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+func sequencedMessage(sequence int64) *conductorv1.AgentMessage {
+	return &conductorv1.AgentMessage{
+		Message: &conductorv1.AgentMessage_ResultStream{
+			ResultStream: &conductorv1.ResultStream{Sequence: sequence},
+		},
+	}
+}
+
+// newTestOutbox creates an outbox spooling to a state database, with a codec
+// that only keeps the sequence.
+func newTestOutbox(t *testing.T, send func(*conductorv1.AgentMessage) error, maxMemory int) (*outbox, *State) {
+	t.Helper()
+	state, err := NewState(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewState: %v", err)
+	}
+	t.Cleanup(func() { state.Close() })
+
+	o := newOutbox(send, state, maxMemory, 0, zerolog.Nop())
+	o.encode = func(msg *conductorv1.AgentMessage) ([]byte, error) {
+		return []byte(strconv.FormatInt(messageSequence(msg), 10)), nil
+	}
+	o.decode = func(data []byte) (*conductorv1.AgentMessage, error) {
+		sequence, err := strconv.ParseInt(string(data), 10, 64)
+		return sequencedMessage(sequence), err
+	}
+	return o, state
+}
+
+// drain sends messages the way run does until the outbox holds them back.
+func drain(o *outbox) {
+	for msg := o.next(); msg != nil; msg = o.next() {
+		if err := o.send(msg); err != nil {
+			o.pause()
+			return
+		}
+		o.sent(msg)
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	t.Run("spools messages until connected", func(t *testing.T) {
+		var sent []int64
+		o, state := newTestOutbox(t, func(msg *conductorv1.AgentMessage) error {
+			sent = append(sent, messageSequence(msg))
+			return nil
+		}, 10)
+
+		for seq := int64(1); seq <= 3; seq++ {
+			if err := o.push(sequencedMessage(seq)); err != nil {
+				t.Fatalf("push: %v", err)
+			}
+		}
+		drain(o)
+		if len(sent) != 0 {
+			t.Fatalf("sent %v before connecting", sent)
+		}
+		if count, _ := state.SpooledMessageCount(); count != 3 {
+			t.Errorf("spooled %d messages, want 3", count)
+		}
+
+		o.resume(false, 0)
+		drain(o)
+		if want := []int64{1, 2, 3}; !slices.Equal(sent, want) {
+			t.Errorf("sent = %v, want %v", sent, want)
+		}
+	})
+
+	t.Run("spools messages beyond memory in order", func(t *testing.T) {
+		var sent []int64
+		o, state := newTestOutbox(t, func(msg *conductorv1.AgentMessage) error {
+			sent = append(sent, messageSequence(msg))
+			return nil
+		}, 2)
+		o.resume(false, 0)
+
+		for seq := int64(1); seq <= 5; seq++ {
+			if err := o.push(sequencedMessage(seq)); err != nil {
+				t.Fatalf("push: %v", err)
+			}
+		}
+		if count, _ := state.SpooledMessageCount(); count != 3 {
+			t.Errorf("spooled %d messages, want 3", count)
+		}
+
+		drain(o)
+		if want := []int64{1, 2, 3, 4, 5}; !slices.Equal(sent, want) {
+			t.Errorf("sent = %v, want %v", sent, want)
+		}
+		if count, _ := state.SpooledMessageCount(); count != 0 {
+			t.Errorf("%d messages left in the spool", count)
+		}
+	})
+
+	t.Run("replays unacknowledged messages after a reconnect", func(t *testing.T) {
+		var sent []int64
+		broken := false
+		o, state := newTestOutbox(t, func(msg *conductorv1.AgentMessage) error {
+			if broken {
+				return errors.New("stream closed")
+			}
+			sent = append(sent, messageSequence(msg))
+			return nil
+		}, 10)
+		o.resume(true, 0)
+
+		for seq := int64(1); seq <= 3; seq++ {
+			o.push(sequencedMessage(seq))
+		}
+		drain(o)
+		o.ack(1)
+
+		broken = true
+		o.push(sequencedMessage(4))
+		drain(o)
+		if o.next() != nil {
+			t.Fatal("outbox kept sending on a broken stream")
+		}
+		if count, _ := state.SpooledMessageCount(); count != 3 {
+			t.Errorf("spooled %d messages on disconnect, want 3", count)
+		}
+
+		// The control plane processed 2 before the stream broke
+		broken = false
+		sent = nil
+		o.resume(true, 2)
+		drain(o)
+		if want := []int64{3, 4}; !slices.Equal(sent, want) {
+			t.Errorf("sent = %v, want %v", sent, want)
+		}
+	})
+
+	t.Run("close spools unsent messages for the next start", func(t *testing.T) {
+		o, state := newTestOutbox(t, func(*conductorv1.AgentMessage) error { return nil }, 10)
+		o.resume(true, 0)
+		o.push(sequencedMessage(1))
+		drain(o)
+		o.push(sequencedMessage(2))
+
+		if err := o.close(); err != nil {
+			t.Fatalf("close: %v", err)
+		}
+		if count, _ := state.SpooledMessageCount(); count != 2 {
+			t.Errorf("spooled %d messages, want 2", count)
+		}
+
+		var sent []int64
+		restarted := newOutbox(func(msg *conductorv1.AgentMessage) error {
+			sent = append(sent, messageSequence(msg))
+			return nil
+		}, state, 10, 0, zerolog.Nop())
+		restarted.decode = o.decode
+		restarted.resume(false, 0)
+		drain(restarted)
+		if want := []int64{1, 2}; !slices.Equal(sent, want) {
+			t.Errorf("sent = %v, want %v", sent, want)
+		}
+	})
+}
@@ -40,6 +40,13 @@ type CloneOptions struct {
 	Tag         string
 	Depth       int
 	Credentials *Credentials
+	// PullRequest is the pull/merge request number, used to fetch its refs
+	// when CommitSHA cannot be fetched directly.
+	PullRequest int64
+	// Submodules checks out submodules recursively after the main checkout.
+	Submodules bool
+	// LFS pulls Git LFS objects for the checked out commit.
+	LFS bool
 }
 
 // Credentials contains authentication credentials for git operations.
@@ -52,6 +59,8 @@ type Credentials struct {
 	SSHKey string
 	// SSHKeyPath is the path to the SSH key file
 	SSHKeyPath string
+	// KnownHosts pins host keys for SSH clones; when empty, host keys are not verified
+	KnownHosts string
 }
 
 // NewManager creates a new repository manager.
@@ -80,6 +89,17 @@ func (m *Manager) Clone(ctx context.Context, opts *CloneOptions, targetPath stri
 		Str("target", targetPath).
 		Msg("Cloning repository")
 
+	env, cleanup, err := m.buildGitEnv(opts.Credentials)
+	if err != nil {
+		return fmt.Errorf("failed to prepare git credentials: %w", err)
+	}
+	defer cleanup()
+
+	// Defer LFS downloads to a single batched pull after checkout
+	if opts.LFS {
+		env = append(env, "GIT_LFS_SKIP_SMUDGE=1")
+	}
+
 	// Check if we have a cached copy
 	cached := m.GetCached(opts.URL)
 	if cached != "" {
@@ -88,26 +108,32 @@ func (m *Manager) Clone(ctx context.Context, opts *CloneOptions, targetPath stri
 			m.logger.Warn().Err(err).Msg("Failed to copy from cache, cloning fresh")
 		} else {
 			// Checkout the specific ref
-			if err := m.Checkout(ctx, targetPath, opts.Branch, opts.CommitSHA, opts.Tag); err != nil {
+			if err := m.checkout(ctx, targetPath, opts.Branch, opts.CommitSHA, opts.Tag, env); err != nil {
 				return fmt.Errorf("failed to checkout: %w", err)
 			}
-			return nil
+			return m.checkoutExtras(ctx, opts, targetPath, env)
 		}
 	}
 
 	// Fresh clone
-	if err := m.cloneFresh(ctx, opts, targetPath); err != nil {
+	if err := m.cloneFresh(ctx, opts, targetPath, env); err != nil {
 		return err
 	}
 
 	// Update cache
 	m.updateCache(opts.URL, targetPath)
 
-	return nil
+	return m.checkoutExtras(ctx, opts, targetPath, env)
 }
 
 // cloneFresh performs a fresh git clone.
-func (m *Manager) cloneFresh(ctx context.Context, opts *CloneOptions, targetPath string) error {
+func (m *Manager) cloneFresh(ctx context.Context, opts *CloneOptions, targetPath string, env []string) error {
+	// A specific commit may not be on any branch (e.g. PR merge commits), so
+	// fetch it directly instead of cloning a branch
+	if opts.CommitSHA != "" {
+		return m.cloneCommit(ctx, opts, targetPath, env)
+	}
+
 	// Build clone command
 	args := []string{"clone"}
 
@@ -132,25 +158,149 @@ func (m *Manager) cloneFresh(ctx context.Context, opts *CloneOptions, targetPath
 
 	// Execute clone
 	cmd := exec.CommandContext(ctx, "git", args...)
-	cmd.Env = m.buildGitEnv(opts.Credentials)
+	cmd.Env = env
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("git clone failed: %w\nOutput: %s", err, string(output))
 	}
 
-	// If specific commit is requested, checkout that commit
-	if opts.CommitSHA != "" {
-		if err := m.Checkout(ctx, targetPath, opts.Branch, opts.CommitSHA, opts.Tag); err != nil {
-			return fmt.Errorf("failed to checkout commit: %w", err)
+	return nil
+}
+
+// cloneCommit initializes an empty repository and fetches a single commit
+// by SHA, falling back to pull request refs and full history when the
+// server does not allow fetching the SHA directly.
+func (m *Manager) cloneCommit(ctx context.Context, opts *CloneOptions, targetPath string, env []string) error {
+	if err := os.MkdirAll(targetPath, 0755); err != nil {
+		return fmt.Errorf("failed to create target directory: %w", err)
+	}
+
+	cloneURL := m.buildAuthenticatedURL(opts.URL, opts.Credentials)
+	if output, err := m.git(ctx, targetPath, env, "init", "--quiet"); err != nil {
+		return fmt.Errorf("git init failed: %w\nOutput: %s", err, string(output))
+	}
+	if output, err := m.git(ctx, targetPath, env, "remote", "add", "origin", cloneURL); err != nil {
+		return fmt.Errorf("git remote add failed: %w\nOutput: %s", err, string(output))
+	}
+
+	if err := m.fetchCommit(ctx, targetPath, opts.CommitSHA, opts.Depth, opts.PullRequest, env); err != nil {
+		return err
+	}
+
+	if output, err := m.git(ctx, targetPath, env, "checkout", "--quiet", "--detach", opts.CommitSHA); err != nil {
+		return fmt.Errorf("git checkout failed: %w\nOutput: %s", err, string(output))
+	}
+
+	return nil
+}
+
+// fetchCommit makes commitSHA available in the repository at repoPath.
+func (m *Manager) fetchCommit(ctx context.Context, repoPath, commitSHA string, depth int, pullRequest int64, env []string) error {
+	args := []string{"fetch", "--no-tags"}
+	if depth > 0 {
+		args = append(args, "--depth", fmt.Sprintf("%d", depth))
+	}
+	args = append(args, "origin", commitSHA)
+
+	output, err := m.git(ctx, repoPath, env, args...)
+	if err == nil {
+		return nil
+	}
+	m.logger.Debug().Err(err).Str("output", string(output)).Msg("Fetch by SHA failed, trying pull request refs")
+
+	if pullRequest > 0 {
+		// GitHub and Gitea publish refs/pull/N/*, GitLab refs/merge-requests/N/*
+		for _, ref := range []string{
+			fmt.Sprintf("refs/pull/%d/head", pullRequest),
+			fmt.Sprintf("refs/pull/%d/merge", pullRequest),
+			fmt.Sprintf("refs/merge-requests/%d/head", pullRequest),
+		} {
+			_, _ = m.git(ctx, repoPath, env, "fetch", "--no-tags", "origin", "+"+ref+":refs/remotes/origin/"+strings.TrimPrefix(ref, "refs/"))
+		}
+		if m.hasCommit(ctx, repoPath, commitSHA) {
+			return nil
+		}
+	}
+
+	m.logger.Debug().Str("commit", commitSHA).Msg("Fetching full history to find commit")
+	fetchArgs := []string{"fetch", "--no-tags"}
+	if m.isShallow(ctx, repoPath) {
+		fetchArgs = append(fetchArgs, "--unshallow")
+	}
+	fetchArgs = append(fetchArgs, "origin", "+refs/heads/*:refs/remotes/origin/*")
+	if output, err := m.git(ctx, repoPath, env, fetchArgs...); err != nil {
+		return fmt.Errorf("git fetch failed: %w\nOutput: %s", err, string(output))
+	}
+
+	if !m.hasCommit(ctx, repoPath, commitSHA) {
+		return fmt.Errorf("commit %s not found in remote repository", commitSHA)
+	}
+	return nil
+}
+
+// hasCommit reports whether the commit exists in the repository.
+func (m *Manager) hasCommit(ctx context.Context, repoPath, commitSHA string) bool {
+	_, err := m.git(ctx, repoPath, nil, "cat-file", "-e", commitSHA+"^{commit}")
+	return err == nil
+}
+
+// isShallow reports whether the repository is a shallow clone.
+func (m *Manager) isShallow(ctx context.Context, repoPath string) bool {
+	output, err := m.git(ctx, repoPath, nil, "rev-parse", "--is-shallow-repository")
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+// checkoutExtras checks out submodules and pulls LFS objects as requested.
+func (m *Manager) checkoutExtras(ctx context.Context, opts *CloneOptions, repoPath string, env []string) error {
+	if opts.Submodules {
+		if output, err := m.git(ctx, repoPath, env, "submodule", "sync", "--recursive"); err != nil {
+			return fmt.Errorf("git submodule sync failed: %w\nOutput: %s", err, string(output))
+		}
+		if output, err := m.git(ctx, repoPath, env, "submodule", "update", "--init", "--recursive", "--jobs", "4"); err != nil {
+			return fmt.Errorf("git submodule update failed: %w\nOutput: %s", err, string(output))
+		}
+	}
+
+	if opts.LFS {
+		if output, err := m.git(ctx, repoPath, env, "lfs", "install", "--local"); err != nil {
+			return fmt.Errorf("git lfs install failed (is git-lfs installed?): %w\nOutput: %s", err, string(output))
+		}
+		if output, err := m.git(ctx, repoPath, env, "lfs", "pull"); err != nil {
+			return fmt.Errorf("git lfs pull failed: %w\nOutput: %s", err, string(output))
+		}
+		if opts.Submodules {
+			if output, err := m.git(ctx, repoPath, env, "submodule", "foreach", "--recursive", "git lfs pull"); err != nil {
+				return fmt.Errorf("git lfs pull in submodules failed: %w\nOutput: %s", err, string(output))
+			}
 		}
 	}
 
 	return nil
 }
 
+// git runs a git command in dir and returns its combined output.
+// A nil env inherits the agent's environment.
+func (m *Manager) git(ctx context.Context, dir string, env []string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	return cmd.CombinedOutput()
+}
+
 // Checkout checks out a specific ref in the repository.
 func (m *Manager) Checkout(ctx context.Context, repoPath, branch, commitSHA, tag string) error {
+	env, cleanup, err := m.buildGitEnv(nil)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	return m.checkout(ctx, repoPath, branch, commitSHA, tag, env)
+}
+
+// checkout checks out a ref using the given git environment for fetches.
+func (m *Manager) checkout(ctx context.Context, repoPath, branch, commitSHA, tag string, env []string) error {
 	// Determine what to checkout
 	ref := commitSHA
 	if ref == "" && tag != "" {
@@ -169,17 +319,9 @@ func (m *Manager) Checkout(ctx context.Context, repoPath, branch, commitSHA, tag
 		Msg("Checking out ref")
 
 	// Fetch if we need a specific commit that might not be in shallow clone
-	if commitSHA != "" {
-		fetchArgs := []string{"fetch", "origin", commitSHA}
-		fetchCmd := exec.CommandContext(ctx, "git", fetchArgs...)
-		fetchCmd.Dir = repoPath
-		if output, err := fetchCmd.CombinedOutput(); err != nil {
-			m.logger.Debug().Err(err).Str("output", string(output)).Msg("Fetch specific commit failed, trying full fetch")
-
-			// Try full fetch
-			fetchCmd = exec.CommandContext(ctx, "git", "fetch", "--unshallow")
-			fetchCmd.Dir = repoPath
-			_, _ = fetchCmd.CombinedOutput() // Ignore error if already unshallowed
+	if commitSHA != "" && !m.hasCommit(ctx, repoPath, commitSHA) {
+		if err := m.fetchCommit(ctx, repoPath, commitSHA, 0, 0, env); err != nil {
+			m.logger.Debug().Err(err).Str("commit", commitSHA).Msg("Fetch specific commit failed")
 		}
 	}
 
@@ -187,6 +329,7 @@ func (m *Manager) Checkout(ctx context.Context, repoPath, branch, commitSHA, tag
 	checkoutArgs := []string{"checkout", ref}
 	cmd := exec.CommandContext(ctx, "git", checkoutArgs...)
 	cmd.Dir = repoPath
+	cmd.Env = env
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -345,18 +488,77 @@ func (m *Manager) buildAuthenticatedURL(url string, creds *Credentials) string {
 }
 
 // buildGitEnv builds environment variables for git commands.
-func (m *Manager) buildGitEnv(creds *Credentials) []string {
+// Inline SSH keys and known_hosts entries are written to private temporary
+// files; the returned cleanup function removes them.
+func (m *Manager) buildGitEnv(creds *Credentials) ([]string, func(), error) {
 	env := os.Environ()
+	var tempFiles []string
+	cleanup := func() {
+		for _, path := range tempFiles {
+			_ = os.Remove(path)
+		}
+	}
 
 	// Disable prompts
 	env = append(env, "GIT_TERMINAL_PROMPT=0")
 
-	// Add SSH key if provided
-	if creds != nil && creds.SSHKeyPath != "" {
-		env = append(env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -i %s -o StrictHostKeyChecking=no", creds.SSHKeyPath))
+	if creds == nil || (creds.SSHKey == "" && creds.SSHKeyPath == "") {
+		return env, cleanup, nil
+	}
+
+	keyPath := creds.SSHKeyPath
+	if creds.SSHKey != "" {
+		key := creds.SSHKey
+		if !strings.HasSuffix(key, "\n") {
+			key += "\n"
+		}
+		path, err := writeTempFile(m.cacheDir, "deploy-key-*", key)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("failed to write ssh key: %w", err)
+		}
+		tempFiles = append(tempFiles, path)
+		keyPath = path
+	}
+
+	sshCommand := fmt.Sprintf("ssh -i %s -o IdentitiesOnly=yes", keyPath)
+	if creds.KnownHosts != "" {
+		path, err := writeTempFile(m.cacheDir, "known-hosts-*", creds.KnownHosts)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("failed to write known_hosts: %w", err)
+		}
+		tempFiles = append(tempFiles, path)
+		sshCommand += fmt.Sprintf(" -o UserKnownHostsFile=%s -o StrictHostKeyChecking=yes", path)
+	} else {
+		sshCommand += " -o StrictHostKeyChecking=no"
 	}
+	env = append(env, "GIT_SSH_COMMAND="+sshCommand)
+
+	return env, cleanup, nil
+}
 
-	return env
+// writeTempFile writes content to a new file readable only by the agent user.
+func writeTempFile(dir, pattern, content string) (string, error) {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return "", err
+	}
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
 }
 
 // cacheKey generates a cache key for a URL.
@@ -0,0 +1,133 @@
+package repo
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null",
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func commitFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "commit", "--quiet", "-m", "update "+name)
+	return runGit(t, dir, "rev-parse", "HEAD")
+}
+
+// newUpstream creates a repository with one commit on main and a second
+// commit reachable only from refs/pull/7/head, like a PR merge commit.
+func newUpstream(t *testing.T) (url, mainSHA, prSHA string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "--quiet", "--initial-branch=main")
+	mainSHA = commitFile(t, dir, "README.md", "main")
+
+	runGit(t, dir, "checkout", "--quiet", "-b", "pr")
+	prSHA = commitFile(t, dir, "README.md", "pull request")
+	runGit(t, dir, "update-ref", "refs/pull/7/head", prSHA)
+	runGit(t, dir, "checkout", "--quiet", "main")
+	runGit(t, dir, "branch", "--quiet", "-D", "pr")
+
+	return "file://" + dir, mainSHA, prSHA
+}
+
+// newTestManager creates a manager whose cache directory is removed on a
+// best-effort basis, since repositories are mirrored into it asynchronously.
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	cacheDir, err := os.MkdirTemp("", "repo-cache-")
+	if err != nil {
+		t.Fatalf("create cache dir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(cacheDir) })
+
+	mgr, err := NewManager(cacheDir, zerolog.New(io.Discard))
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	return mgr
+}
+
+func TestCloneCommitNotOnBranch(t *testing.T) {
+	url, _, prSHA := newUpstream(t)
+
+	mgr := newTestManager(t)
+
+	target := filepath.Join(t.TempDir(), "ws")
+	err := mgr.Clone(context.Background(), &CloneOptions{
+		URL:         url,
+		Branch:      "main",
+		CommitSHA:   prSHA,
+		Depth:       1,
+		PullRequest: 7,
+	}, target)
+	if err != nil {
+		t.Fatalf("Clone: %v", err)
+	}
+
+	if head := runGit(t, target, "rev-parse", "HEAD"); head != prSHA {
+		t.Fatalf("HEAD = %s, want %s", head, prSHA)
+	}
+	data, err := os.ReadFile(filepath.Join(target, "README.md"))
+	if err != nil || string(data) != "pull request" {
+		t.Fatalf("unexpected README content %q (%v)", data, err)
+	}
+}
+
+func TestCloneBranch(t *testing.T) {
+	url, mainSHA, _ := newUpstream(t)
+
+	mgr := newTestManager(t)
+
+	target := filepath.Join(t.TempDir(), "ws")
+	if err := mgr.Clone(context.Background(), &CloneOptions{URL: url, Branch: "main", Depth: 1}, target); err != nil {
+		t.Fatalf("Clone: %v", err)
+	}
+
+	if head := runGit(t, target, "rev-parse", "HEAD"); head != mainSHA {
+		t.Fatalf("HEAD = %s, want %s", head, mainSHA)
+	}
+}
+
+func TestCloneUnknownCommit(t *testing.T) {
+	url, _, _ := newUpstream(t)
+
+	mgr := newTestManager(t)
+
+	err := mgr.Clone(context.Background(), &CloneOptions{
+		URL:       url,
+		CommitSHA: strings.Repeat("a", 40),
+		Depth:     1,
+	}, filepath.Join(t.TempDir(), "ws"))
+	if err == nil {
+		t.Fatal("expected error for unknown commit")
+	}
+}
@@ -5,34 +5,88 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
 	"github.com/conductor/conductor/internal/agent/executor"
 	"github.com/rs/zerolog"
 )
 
-// Reporter handles reporting results back to the control plane.
+// maxChunkBytes bounds the log data or result text of one result stream
+// message, well below the 16MB gRPC message limit. Larger payloads are sent
+// in several messages.
+const maxChunkBytes = 4 << 20
+
+// Reporter handles reporting results back to the control plane. Reports
+// are queued in an outbox and sent in the background, so executions never
+// wait for the control plane.
 type Reporter struct {
 	client   *Client
+	outbox   *outbox
 	logger   zerolog.Logger
 	sequence atomic.Int64
 	mu       sync.Mutex
 }
 
-// NewReporter creates a new result reporter.
-func NewReporter(client *Client, logger zerolog.Logger) *Reporter {
-	return &Reporter{
+// NewReporter creates a new result reporter that spools the reports that
+// do not fit in memory to spool.
+func NewReporter(client *Client, spool outboxSpool, cfg *Config, logger zerolog.Logger) *Reporter {
+	logger = logger.With().Str("component", "reporter").Logger()
+	r := &Reporter{
 		client: client,
-		logger: logger.With().Str("component", "reporter").Logger(),
+		outbox: newOutbox(client.Send, spool, cfg.ReportBufferSize, cfg.ReportRateLimit, logger),
+		logger: logger,
 	}
+	// Sequence numbers continue from the clock, so they increase across
+	// restarts and messages spooled by an earlier process are sent first.
+	r.sequence.Store(time.Now().UnixNano())
+	return r
+}
+
+// Run sends the queued reports until ctx is done. Reports are held until
+// Resume is called for a registered work stream.
+func (r *Reporter) Run(ctx context.Context) {
+	r.outbox.run(ctx)
+}
+
+// Resume sends reports on a newly registered work stream. If the control
+// plane acknowledges results, the sent reports it has not processed are
+// replayed first.
+func (r *Reporter) Resume(acks bool, processed int64) {
+	r.outbox.resume(acks, processed)
+}
+
+// Pause holds reports while the agent reconnects.
+func (r *Reporter) Pause() {
+	r.outbox.pause()
 }
 
-// StreamLogs streams log output to the control plane.
+// Ack releases the sent reports up to sequence, which the control plane
+// has processed.
+func (r *Reporter) Ack(sequence int64) {
+	r.outbox.ack(sequence)
+}
+
+// Close spools the reports that were not sent or acknowledged, to send
+// them after a restart.
+func (r *Reporter) Close() error {
+	return r.outbox.close()
+}
+
+// StreamLogs streams log output to the control plane, in chunks of at most
+// maxChunkBytes.
 func (r *Reporter) StreamLogs(ctx context.Context, runID, shardID string, stream conductorv1.LogStream, data []byte) error {
-	if len(data) == 0 {
-		return nil
+	for len(data) > 0 {
+		n := cutIndex(data, maxChunkBytes)
+		if err := r.sendLogChunk(runID, shardID, stream, data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
 	}
+	return nil
+}
 
+func (r *Reporter) sendLogChunk(runID, shardID string, stream conductorv1.LogStream, data []byte) error {
 	msg := &conductorv1.AgentMessage{
 		Message: &conductorv1.AgentMessage_ResultStream{
 			ResultStream: &conductorv1.ResultStream{
@@ -49,25 +103,93 @@ func (r *Reporter) StreamLogs(ctx context.Context, runID, shardID string, stream
 		},
 	}
 
-	return r.client.Send(msg)
+	return r.outbox.push(msg)
 }
 
-// ReportTestResult reports an individual test result.
+// ReportTestResult reports an individual test result. Results with more
+// than maxChunkBytes of text are sent in parts.
 func (r *Reporter) ReportTestResult(ctx context.Context, runID, shardID string, result *conductorv1.TestResultEvent) error {
-	msg := &conductorv1.AgentMessage{
-		Message: &conductorv1.AgentMessage_ResultStream{
-			ResultStream: &conductorv1.ResultStream{
-				RunId:    runID,
-				ShardId:  shardID,
-				Sequence: r.sequence.Add(1),
-				Payload: &conductorv1.ResultStream_TestResult{
-					TestResult: result,
+	for _, part := range splitTestResult(result, maxChunkBytes) {
+		msg := &conductorv1.AgentMessage{
+			Message: &conductorv1.AgentMessage_ResultStream{
+				ResultStream: &conductorv1.ResultStream{
+					RunId:    runID,
+					ShardId:  shardID,
+					Sequence: r.sequence.Add(1),
+					Payload: &conductorv1.ResultStream_TestResult{
+						TestResult: part,
+					},
 				},
 			},
-		},
+		}
+		if err := r.outbox.push(msg); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	return r.client.Send(msg)
+// splitTestResult splits a result whose text exceeds limit bytes into parts.
+// The first part carries the whole result with as much of its error message
+// and stack trace as fits; the others carry the rest and identify the test.
+// All but the last part are marked partial.
+func splitTestResult(result *conductorv1.TestResultEvent, limit int) []*conductorv1.TestResultEvent {
+	metadataSize := 0
+	for key, value := range result.Metadata {
+		metadataSize += len(key) + len(value)
+	}
+	if metadataSize+len(result.ErrorMessage)+len(result.StackTrace) <= limit {
+		return []*conductorv1.TestResultEvent{result}
+	}
+
+	part := &conductorv1.TestResultEvent{
+		TestId:       result.TestId,
+		TestName:     result.TestName,
+		Status:       result.Status,
+		Duration:     result.Duration,
+		RetryAttempt: result.RetryAttempt,
+		Timestamp:    result.Timestamp,
+		Metadata:     result.Metadata,
+	}
+	budget := max(limit-metadataSize, limit/2)
+
+	var parts []*conductorv1.TestResultEvent
+	errorMessage, stackTrace := result.ErrorMessage, result.StackTrace
+	for {
+		n := cutIndex(errorMessage, budget)
+		part.ErrorMessage, errorMessage = errorMessage[:n], errorMessage[n:]
+		n = cutIndex(stackTrace, budget-len(part.ErrorMessage))
+		part.StackTrace, stackTrace = stackTrace[:n], stackTrace[n:]
+		parts = append(parts, part)
+		if errorMessage == "" && stackTrace == "" {
+			return parts
+		}
+
+		part.Partial = true
+		part = &conductorv1.TestResultEvent{
+			TestId:       result.TestId,
+			TestName:     result.TestName,
+			RetryAttempt: result.RetryAttempt,
+		}
+		budget = limit
+	}
+}
+
+// cutIndex returns where to cut text to keep at most limit bytes, without
+// splitting a UTF-8 character.
+func cutIndex[T string | []byte](text T, limit int) int {
+	if len(text) <= limit {
+		return len(text)
+	}
+	n := limit
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	if n == 0 {
+		// Not UTF-8; cut at the limit
+		return limit
+	}
+	return n
 }
 
 // ReportProgress reports execution progress.
@@ -91,7 +213,25 @@ func (r *Reporter) ReportProgress(ctx context.Context, runID, shardID string, ph
 		},
 	}
 
-	return r.client.Send(msg)
+	return r.outbox.push(msg)
+}
+
+// ReportAnnotation attaches a warning about the agent's environment to a run.
+func (r *Reporter) ReportAnnotation(ctx context.Context, runID, shardID string, annotation *conductorv1.RunAnnotation) error {
+	msg := &conductorv1.AgentMessage{
+		Message: &conductorv1.AgentMessage_ResultStream{
+			ResultStream: &conductorv1.ResultStream{
+				RunId:    runID,
+				ShardId:  shardID,
+				Sequence: r.sequence.Add(1),
+				Payload: &conductorv1.ResultStream_Annotation{
+					Annotation: annotation,
+				},
+			},
+		},
+	}
+
+	return r.outbox.push(msg)
 }
 
 // ReportComplete reports run completion status.
@@ -113,7 +253,7 @@ func (r *Reporter) ReportComplete(ctx context.Context, runID, shardID string, st
 		},
 	}
 
-	return r.client.Send(msg)
+	return r.outbox.push(msg)
 }
 
 // ReportRunComplete reports full run completion with summary.
@@ -163,15 +303,16 @@ func (r *Reporter) ReportRunComplete(ctx context.Context, runID, shardID string,
 		},
 	}
 
-	return r.client.Send(msg)
+	return r.outbox.push(msg)
 }
 
-// UploadArtifact uploads an artifact file to storage.
-func (r *Reporter) UploadArtifact(ctx context.Context, runID string, artifactPath string) error {
+// UploadArtifact uploads an artifact file of a test to storage.
+func (r *Reporter) UploadArtifact(ctx context.Context, runID, testID, artifactPath string) error {
 	// TODO: Implement artifact upload to S3/MinIO
 	// For now, just log that we would upload
 	r.logger.Debug().
 		Str("run_id", runID).
+		Str("test_id", testID).
 		Str("path", artifactPath).
 		Msg("Would upload artifact")
 
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+func TestSplitTestResult(t *testing.T) {
+	t.Run("small results are sent whole", func(t *testing.T) {
+		result := &conductorv1.TestResultEvent{TestName: "TestLogin", ErrorMessage: "boom"}
+		parts := splitTestResult(result, 100)
+		if len(parts) != 1 || parts[0] != result {
+			t.Fatalf("parts = %v, want the result", parts)
+		}
+	})
+
+	t.Run("large results are split", func(t *testing.T) {
+		result := &conductorv1.TestResultEvent{
+			TestId:       "def-1",
+			TestName:     "TestCheckout",
+			Status:       conductorv1.TestStatus_TEST_STATUS_FAIL,
+			RetryAttempt: 1,
+			ErrorMessage: strings.Repeat("e", 150),
+			StackTrace:   strings.Repeat("s", 120),
+			Metadata:     map[string]string{"k": "v"},
+		}
+		parts := splitTestResult(result, 100)
+		if len(parts) != 3 {
+			t.Fatalf("got %d parts, want 3", len(parts))
+		}
+
+		var errorMessage, stackTrace string
+		for i, part := range parts {
+			if size := len(part.ErrorMessage) + len(part.StackTrace); size > 100 {
+				t.Errorf("part %d carries %d bytes", i, size)
+			}
+			if part.Partial != (i < len(parts)-1) {
+				t.Errorf("part %d partial = %v", i, part.Partial)
+			}
+			if part.TestName != "TestCheckout" || part.TestId != "def-1" || part.RetryAttempt != 1 {
+				t.Errorf("part %d does not identify the result: %v", i, part)
+			}
+			errorMessage += part.ErrorMessage
+			stackTrace += part.StackTrace
+		}
+		if errorMessage != result.ErrorMessage || stackTrace != result.StackTrace {
+			t.Error("parts do not add up to the result")
+		}
+		if parts[0].Status != conductorv1.TestStatus_TEST_STATUS_FAIL || parts[0].Metadata["k"] != "v" {
+			t.Errorf("first part = %v, want the whole result", parts[0])
+		}
+	})
+}
+
+func TestCutIndex(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  int
+	}{
+		{"short", 10, 5},
+		{"abcdef", 4, 4},
+		{"ab€", 3, 2}, // € is 3 bytes and is not split
+		{"\x80\x80\x80\x80", 2, 2},
+	}
+	for _, tt := range tests {
+		if got := cutIndex(tt.text, tt.limit); got != tt.want {
+			t.Errorf("cutIndex(%q, %d) = %d, want %d", tt.text, tt.limit, got, tt.want)
+		}
+		if got := cutIndex([]byte(tt.text), tt.limit); got != tt.want {
+			t.Errorf("cutIndex([]byte(%q), %d) = %d, want %d", tt.text, tt.limit, got, tt.want)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/pkg/metrics"
 	"github.com/rs/zerolog"
 )
 
@@ -81,6 +82,14 @@ func (m *Monitor) GetDiskUsage() int64 {
 	return m.diskBytes
 }
 
+// GetDiskUsagePercent returns current disk usage as a percentage of the
+// workspace disk.
+func (m *Monitor) GetDiskUsagePercent() float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return usagePercent(m.diskBytes, m.diskTotal)
+}
+
 // GetUsage returns the current resource usage as a proto message.
 func (m *Monitor) GetUsage() *conductorv1.ResourceUsage {
 	m.mu.RLock()
@@ -107,6 +116,27 @@ func (m *Monitor) GetResources() *conductorv1.Resources {
 	}
 }
 
+// ReportMetrics exports the current resource usage as Prometheus gauges.
+func (m *Monitor) ReportMetrics(am *metrics.AgentMetrics) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	am.SetCPUUsage(m.cpuPercent)
+	am.SetMemoryUsage(usagePercent(m.memoryBytes, m.memoryTotal))
+	am.SetDiskUsage(usagePercent(m.diskBytes, m.diskTotal))
+	am.SetMemoryBytes(uint64(m.memoryBytes), uint64(max(m.memoryTotal-m.memoryBytes, 0)), uint64(m.memoryTotal))
+	am.SetDiskBytes(uint64(m.diskBytes), uint64(max(m.diskTotal-m.diskBytes, 0)), uint64(m.diskTotal))
+}
+
+// usagePercent returns used as a percentage of total, or 0 if the total is
+// unknown.
+func usagePercent(used, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(used) / float64(total) * 100
+}
+
 // CanAcceptWork checks if the system has enough resources to accept more work.
 func (m *Monitor) CanAcceptWork() bool {
 	m.mu.RLock()
@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/agent/executor"
+	"github.com/conductor/conductor/pkg/metrics"
+)
+
+func TestMonitor_ReportMetrics(t *testing.T) {
+	m := &Monitor{
+		logger:      zerolog.Nop(),
+		cpuPercent:  42,
+		memoryBytes: 1 << 30,
+		memoryTotal: 4 << 30,
+		diskBytes:   30 << 30,
+		diskTotal:   100 << 30,
+	}
+	am := metrics.NewAgentMetrics().Agent
+
+	m.ReportMetrics(am)
+
+	if got := testutil.ToFloat64(am.CPUUsage); got != 42 {
+		t.Errorf("cpu usage = %v, want 42", got)
+	}
+	if got := testutil.ToFloat64(am.MemoryUsage); got != 25 {
+		t.Errorf("memory usage = %v, want 25", got)
+	}
+	if got := testutil.ToFloat64(am.DiskUsage); got != 30 {
+		t.Errorf("disk usage = %v, want 30", got)
+	}
+	if got := testutil.ToFloat64(am.MemoryBytes.WithLabelValues("available")); got != 3<<30 {
+		t.Errorf("available memory = %v, want %v", got, 3<<30)
+	}
+	if got := testutil.ToFloat64(am.DiskBytes.WithLabelValues("total")); got != 100<<30 {
+		t.Errorf("total disk = %v, want %v", got, 100<<30)
+	}
+}
+
+func TestAgent_RecordWorkMetrics(t *testing.T) {
+	am := metrics.NewAgentMetrics().Agent
+	a := &Agent{metrics: am}
+
+	work := &conductorv1.AssignWork{ExecutionType: conductorv1.ExecutionType_EXECUTION_TYPE_CONTAINER}
+	a.recordWorkMetrics(work, conductorv1.RunStatus_RUN_STATUS_FAILED, 90*time.Second, &executor.ExecutionResult{
+		TestResults: []*executor.TestResult{
+			{Status: conductorv1.TestStatus_TEST_STATUS_PASS, Duration: time.Second},
+			{Status: conductorv1.TestStatus_TEST_STATUS_FAIL, Duration: 2 * time.Second},
+		},
+	})
+	a.recordWorkMetrics(&conductorv1.AssignWork{}, conductorv1.RunStatus_RUN_STATUS_ERROR, time.Second, nil)
+
+	if got := testutil.ToFloat64(am.WorkTotal.WithLabelValues("failed", "container")); got != 1 {
+		t.Errorf("failed container work = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(am.WorkTotal.WithLabelValues("error", "subprocess")); got != 1 {
+		t.Errorf("errored subprocess work = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(am.WorkDuration); got != 2 {
+		t.Errorf("work duration series = %d, want 2", got)
+	}
+	if got := testutil.ToFloat64(am.TestsTotal.WithLabelValues("fail")); got != 1 {
+		t.Errorf("failed tests = %v, want 1", got)
+	}
+}
@@ -72,6 +72,12 @@ func createTables(db *sql.DB) error {
 
 		CREATE INDEX IF NOT EXISTS idx_runs_status ON runs(status);
 		CREATE INDEX IF NOT EXISTS idx_runs_created_at ON runs(created_at);
+
+		CREATE TABLE IF NOT EXISTS spooled_messages (
+			sequence INTEGER PRIMARY KEY,
+			message BLOB NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
 	`
 
 	_, err := db.Exec(schema)
@@ -212,6 +218,87 @@ func (s *State) Cleanup(maxAge time.Duration) error {
 	return nil
 }
 
+// SpoolMessage stores a message for the control plane that does not fit in
+// memory until it can be sent. Messages are kept by their sequence number.
+func (s *State) SpoolMessage(sequence int64, message []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO spooled_messages (sequence, message)
+		VALUES (?, ?)
+		ON CONFLICT(sequence) DO UPDATE SET message = excluded.message
+	`, sequence, message)
+	if err != nil {
+		return fmt.Errorf("failed to spool message: %w", err)
+	}
+
+	return nil
+}
+
+// UnspoolMessages removes and returns up to limit spooled messages, oldest
+// first.
+func (s *State) UnspoolMessages(limit int) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT sequence, message
+		FROM spooled_messages
+		ORDER BY sequence ASC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query spooled messages: %w", err)
+	}
+
+	var messages [][]byte
+	var last int64
+	for rows.Next() {
+		var message []byte
+		if err := rows.Scan(&last, &message); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan spooled message: %w", err)
+		}
+		messages = append(messages, message)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	if _, err := tx.Exec("DELETE FROM spooled_messages WHERE sequence <= ?", last); err != nil {
+		return nil, fmt.Errorf("failed to delete spooled messages: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return messages, nil
+}
+
+// SpooledMessageCount returns the number of spooled messages.
+func (s *State) SpooledMessageCount() (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM spooled_messages").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count spooled messages: %w", err)
+	}
+
+	return count, nil
+}
+
 // Close closes the database connection.
 func (s *State) Close() error {
 	s.mu.Lock()
@@ -11,6 +11,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/placement"
 )
 
 // AgentManager defines the interface for agent management operations.
@@ -94,6 +95,7 @@ type AgentInfo struct {
 	OS              string
 	Arch            string
 	Hostname        string
+	Labels          map[string]string
 }
 
 // AvailableSlots returns the number of additional runs this agent can accept.
@@ -197,6 +199,7 @@ func (m *Manager) RegisterAgent(ctx context.Context, req *RegisterRequest) (*Reg
 	}
 
 	now := time.Now().UTC()
+	labels := placement.AgentLabels(req.Labels, req.OS, req.Arch)
 
 	if existing != nil {
 		// Update existing agent
@@ -205,6 +208,7 @@ func (m *Manager) RegisterAgent(ctx context.Context, req *RegisterRequest) (*Reg
 		existing.NetworkZones = req.NetworkZones
 		existing.MaxParallel = req.MaxParallel
 		existing.DockerAvailable = req.DockerAvailable
+		existing.Labels = labels
 		existing.Status = database.AgentStatusIdle
 		existing.LastHeartbeat = &now
 
@@ -226,6 +230,7 @@ func (m *Manager) RegisterAgent(ctx context.Context, req *RegisterRequest) (*Reg
 			DockerAvailable: req.DockerAvailable,
 			LastHeartbeat:   &now,
 			RegisteredAt:    now,
+			Labels:          labels,
 		}
 
 		if err := m.agentRepo.Create(ctx, agent); err != nil {
@@ -422,6 +427,7 @@ func (m *Manager) GetAvailableAgents(ctx context.Context, zones []string) ([]*Ag
 			MaxParallel:     dbAgent.MaxParallel,
 			ActiveRuns:      conn.ActiveRunCount(),
 			DockerAvailable: dbAgent.DockerAvailable,
+			Labels:          dbAgent.Labels,
 		}
 
 		if dbAgent.Version != nil {
@@ -457,6 +463,7 @@ func (m *Manager) GetAgent(ctx context.Context, agentID uuid.UUID) (*AgentInfo,
 		NetworkZones:    dbAgent.NetworkZones,
 		MaxParallel:     dbAgent.MaxParallel,
 		DockerAvailable: dbAgent.DockerAvailable,
+		Labels:          dbAgent.Labels,
 	}
 
 	if dbAgent.Version != nil {
@@ -597,14 +604,14 @@ func (m *Manager) heartbeatChecker(ctx context.Context) {
 
 // checkHeartbeatTimeouts marks agents as offline if they've missed heartbeats.
 func (m *Manager) checkHeartbeatTimeouts(ctx context.Context) {
-	count, err := m.agentRepo.MarkOfflineAgents(ctx)
+	agents, err := m.agentRepo.MarkOfflineAgents(ctx, m.heartbeatTimeout)
 	if err != nil {
 		m.logger.Error("failed to mark offline agents", "error", err)
 		return
 	}
 
-	if count > 0 {
-		m.logger.Info("marked agents as offline", "count", count)
+	if len(agents) > 0 {
+		m.logger.Info("marked agents as offline", "count", len(agents))
 	}
 
 	// Also check connections
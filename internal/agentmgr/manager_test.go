@@ -151,8 +151,8 @@ func (m *mockAgentRepository) CountByStatus(ctx context.Context) (map[database.A
 	return m.countByStatus, nil
 }
 
-func (m *mockAgentRepository) MarkOfflineAgents(ctx context.Context) (int64, error) {
-	return 0, nil
+func (m *mockAgentRepository) MarkOfflineAgents(ctx context.Context, timeout time.Duration) ([]database.Agent, error) {
+	return nil, nil
 }
 
 type mockServiceRepository struct {
@@ -219,6 +219,9 @@ func (m *mockTestDefinitionRepository) ListByService(ctx context.Context, servic
 func (m *mockTestDefinitionRepository) ListByTags(ctx context.Context, serviceID uuid.UUID, tags []string, p database.Pagination) ([]database.TestDefinition, error) {
 	return m.tests[serviceID], nil
 }
+func (m *mockTestDefinitionRepository) ApplySync(ctx context.Context, sync *database.TestDefinitionSync) error {
+	return nil
+}
 
 type mockTestRunRepository struct {
 	runs map[uuid.UUID]*database.TestRun
@@ -241,12 +244,24 @@ func (m *mockTestRunRepository) Update(ctx context.Context, run *database.TestRu
 func (m *mockTestRunRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status database.RunStatus) error {
 	return nil
 }
+func (m *mockTestRunRepository) Approve(ctx context.Context, id uuid.UUID, approvedBy string) error {
+	return nil
+}
+func (m *mockTestRunRepository) Retarget(ctx context.Context, id uuid.UUID, gitSHA *string) error {
+	return nil
+}
+func (m *mockTestRunRepository) Annotate(ctx context.Context, id uuid.UUID, metadata database.RunMetadata) (*database.RunMetadata, error) {
+	return &metadata, nil
+}
 func (m *mockTestRunRepository) Start(ctx context.Context, id uuid.UUID, agentID uuid.UUID) error {
 	return nil
 }
 func (m *mockTestRunRepository) Finish(ctx context.Context, id uuid.UUID, status database.RunStatus, results database.RunResults) error {
 	return nil
 }
+func (m *mockTestRunRepository) FinishActive(ctx context.Context, id uuid.UUID, status database.RunStatus, results database.RunResults) error {
+	return nil
+}
 func (m *mockTestRunRepository) UpdateShardStats(ctx context.Context, id uuid.UUID, shardCount int, shardsFailed int, results database.RunResults) error {
 	return nil
 }
@@ -265,6 +280,12 @@ func (m *mockTestRunRepository) ListByServiceAndStatus(ctx context.Context, serv
 func (m *mockTestRunRepository) ListByDateRange(ctx context.Context, start, end time.Time, p database.Pagination) ([]database.TestRun, error) {
 	return nil, nil
 }
+func (m *mockTestRunRepository) ListByAgent(ctx context.Context, agentID uuid.UUID, start, end time.Time, p database.Pagination) ([]database.TestRun, error) {
+	return nil, nil
+}
+func (m *mockTestRunRepository) Search(ctx context.Context, filter database.RunSearchFilter, p database.Pagination) ([]database.TestRun, int, error) {
+	return nil, 0, nil
+}
 func (m *mockTestRunRepository) GetPending(ctx context.Context, limit int) ([]database.TestRun, error) {
 	return nil, nil
 }
@@ -275,6 +296,12 @@ func (m *mockTestRunRepository) Count(ctx context.Context) (int64, error) { retu
 func (m *mockTestRunRepository) CountByStatus(ctx context.Context) (map[database.RunStatus]int64, error) {
 	return nil, nil
 }
+func (m *mockTestRunRepository) ReserveResults(ctx context.Context, id uuid.UUID, n, limit int) (int, int64, error) {
+	return n, 0, nil
+}
+func (m *mockTestRunRepository) ReserveArtifacts(ctx context.Context, id uuid.UUID, n, limit int) (int, int64, error) {
+	return n, 0, nil
+}
 
 // Tests
 
@@ -0,0 +1,168 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// RegressionConfig controls when a run or test duration is a regression.
+type RegressionConfig struct {
+	// Sigma is how many standard deviations above the mean a duration must
+	// be to be a regression.
+	Sigma float64
+	// Window is how many previous passed runs form the baseline.
+	Window int
+	// MinRuns is how many previous passed runs are needed before durations
+	// are checked.
+	MinRuns int
+	// MinDelta is the smallest increase of a run's duration over the mean
+	// that is a regression.
+	MinDelta time.Duration
+	// TestMinDelta is the smallest increase of a test's duration over the
+	// mean that is a regression.
+	TestMinDelta time.Duration
+}
+
+// DefaultRegressionConfig returns the default regression thresholds.
+func DefaultRegressionConfig() RegressionConfig {
+	return RegressionConfig{
+		Sigma:        3,
+		Window:       20,
+		MinRuns:      5,
+		MinDelta:     30 * time.Second,
+		TestMinDelta: time.Second,
+	}
+}
+
+// RegressionDetector compares the durations of finished runs and their
+// passed tests with earlier passed runs of the same service branch, and
+// records the runs and tests that took significantly longer.
+type RegressionDetector struct {
+	repo   database.DurationRegressionRepository
+	config RegressionConfig
+	logger *slog.Logger
+}
+
+// NewRegressionDetector creates a new RegressionDetector. Unset thresholds
+// take their defaults.
+func NewRegressionDetector(repo database.DurationRegressionRepository, config RegressionConfig, logger *slog.Logger) *RegressionDetector {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	defaults := DefaultRegressionConfig()
+	if config.Sigma <= 0 {
+		config.Sigma = defaults.Sigma
+	}
+	if config.Window <= 0 {
+		config.Window = defaults.Window
+	}
+	if config.MinRuns <= 0 {
+		config.MinRuns = defaults.MinRuns
+	}
+	if config.MinRuns > config.Window {
+		config.MinRuns = config.Window
+	}
+
+	return &RegressionDetector{
+		repo:   repo,
+		config: config,
+		logger: logger.With("component", "regression_detector"),
+	}
+}
+
+// Detect records and returns the regressions of a finished run, the run
+// itself first. The run's duration is only checked when it passed, as
+// failed runs often stop early; passed tests are checked in any run.
+func (d *RegressionDetector) Detect(ctx context.Context, run *database.TestRun) ([]database.DurationRegression, error) {
+	if run == nil || !run.IsTerminal() {
+		return nil, nil
+	}
+
+	var regressions []database.DurationRegression
+
+	if run.Status == database.RunStatusPassed && run.DurationMs != nil {
+		baseline, err := d.repo.RunDurationBaseline(ctx, run, d.config.Window)
+		if err != nil {
+			return nil, err
+		}
+		if reg := d.regression(*run.DurationMs, baseline, d.config.MinDelta); reg != nil {
+			regressions = append(regressions, *reg)
+		}
+	}
+
+	tests, err := d.repo.TestDurationBaselines(ctx, run, d.config.Window)
+	if err != nil {
+		return nil, err
+	}
+	for _, test := range tests {
+		if reg := d.regression(test.DurationMs, test.BaselineMs, d.config.TestMinDelta); reg != nil {
+			name := test.TestName
+			reg.TestName = &name
+			regressions = append(regressions, *reg)
+		}
+	}
+
+	for i := range regressions {
+		regressions[i].RunID = run.ID
+		if err := d.repo.Create(ctx, &regressions[i]); err != nil {
+			return nil, fmt.Errorf("failed to record duration regression: %w", err)
+		}
+	}
+
+	if len(regressions) > 0 {
+		d.logger.Info("duration regressions detected",
+			"run_id", run.ID,
+			"service_id", run.ServiceID,
+			"regressions", len(regressions),
+		)
+	}
+	return regressions, nil
+}
+
+// regression compares a duration with the mean and standard deviation of
+// the baseline durations, returning nil unless it is a regression.
+func (d *RegressionDetector) regression(durationMs int64, baseline []int64, minDelta time.Duration) *database.DurationRegression {
+	if len(baseline) == 0 || len(baseline) < d.config.MinRuns {
+		return nil
+	}
+
+	var sum float64
+	for _, ms := range baseline {
+		sum += float64(ms)
+	}
+	mean := sum / float64(len(baseline))
+
+	var variance float64
+	for _, ms := range baseline {
+		variance += (float64(ms) - mean) * (float64(ms) - mean)
+	}
+	stdDev := math.Sqrt(variance / float64(len(baseline)))
+
+	delta := float64(durationMs) - mean
+	if delta <= 0 || delta < float64(minDelta.Milliseconds()) {
+		return nil
+	}
+
+	// Identical baselines have no spread; any increase beyond minDelta counts.
+	sigma := math.Inf(1)
+	if stdDev > 0 {
+		sigma = delta / stdDev
+	}
+	if sigma < d.config.Sigma {
+		return nil
+	}
+
+	return &database.DurationRegression{
+		DurationMs:       durationMs,
+		BaselineMeanMs:   int64(math.Round(mean)),
+		BaselineStdDevMs: int64(math.Round(stdDev)),
+		Sigma:            sigma,
+		BaselineRuns:     len(baseline),
+	}
+}
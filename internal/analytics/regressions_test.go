@@ -0,0 +1,98 @@
+package analytics
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// fakeRegressionRepo returns fixed baselines and keeps created regressions.
+type fakeRegressionRepo struct {
+	runBaseline   []int64
+	testBaselines []database.TestDurationBaseline
+	limit         int
+	created       []database.DurationRegression
+}
+
+func (f *fakeRegressionRepo) Create(ctx context.Context, regression *database.DurationRegression) error {
+	regression.ID = uuid.New()
+	f.created = append(f.created, *regression)
+	return nil
+}
+
+func (f *fakeRegressionRepo) ListByRun(ctx context.Context, runID uuid.UUID) ([]database.DurationRegression, error) {
+	return f.created, nil
+}
+
+func (f *fakeRegressionRepo) RunDurationBaseline(ctx context.Context, run *database.TestRun, limit int) ([]int64, error) {
+	f.limit = limit
+	return f.runBaseline, nil
+}
+
+func (f *fakeRegressionRepo) TestDurationBaselines(ctx context.Context, run *database.TestRun, limit int) ([]database.TestDurationBaseline, error) {
+	return f.testBaselines, nil
+}
+
+func TestRegressionDetectorDetect(t *testing.T) {
+	ms := func(v int64) *int64 { return &v }
+	// Mean 10m, standard deviation 20s
+	baseline := []int64{580_000, 620_000, 580_000, 620_000, 580_000, 620_000}
+
+	repo := &fakeRegressionRepo{
+		runBaseline: baseline,
+		testBaselines: []database.TestDurationBaseline{
+			{TestName: "TestCheckout", DurationMs: 9_000, BaselineMs: []int64{2_000, 2_000, 2_000, 2_000, 2_000}},
+			{TestName: "TestSteady", DurationMs: 2_100, BaselineMs: []int64{1_900, 2_100, 1_900, 2_100, 1_900}},
+			{TestName: "TestFaster", DurationMs: 100, BaselineMs: []int64{9_000, 9_000, 9_000, 9_000, 9_000}},
+			{TestName: "TestNew", DurationMs: 60_000, BaselineMs: []int64{1_000}},
+		},
+	}
+	detector := NewRegressionDetector(repo, RegressionConfig{Window: 10}, nil)
+
+	run := &database.TestRun{ID: uuid.New(), ServiceID: uuid.New(), Status: database.RunStatusPassed, DurationMs: ms(900_000)}
+	regressions, err := detector.Detect(context.Background(), run)
+	require.NoError(t, err)
+	assert.Equal(t, 10, repo.limit)
+	require.Len(t, regressions, 2)
+	assert.Len(t, repo.created, 2)
+
+	assert.Nil(t, regressions[0].TestName, "the run regression comes first")
+	assert.Equal(t, run.ID, regressions[0].RunID)
+	assert.Equal(t, int64(600_000), regressions[0].BaselineMeanMs)
+	assert.Equal(t, int64(20_000), regressions[0].BaselineStdDevMs)
+	assert.Equal(t, 15.0, regressions[0].Sigma)
+	assert.Equal(t, 6, regressions[0].BaselineRuns)
+
+	require.NotNil(t, regressions[1].TestName)
+	assert.Equal(t, "TestCheckout", *regressions[1].TestName)
+	assert.True(t, math.IsInf(regressions[1].Sigma, 1), "identical baselines")
+
+	failed := &database.TestRun{ID: uuid.New(), ServiceID: run.ServiceID, Status: database.RunStatusFailed, DurationMs: ms(900_000)}
+	regressions, err = detector.Detect(context.Background(), failed)
+	require.NoError(t, err)
+	require.Len(t, regressions, 1, "only tests are checked in failed runs")
+	assert.Equal(t, "TestCheckout", *regressions[0].TestName)
+
+	running := &database.TestRun{ID: uuid.New(), Status: database.RunStatusRunning}
+	regressions, err = detector.Detect(context.Background(), running)
+	require.NoError(t, err)
+	assert.Empty(t, regressions)
+}
+
+func TestRegressionThresholds(t *testing.T) {
+	detector := NewRegressionDetector(&fakeRegressionRepo{}, RegressionConfig{}, nil)
+	baseline := []int64{580_000, 620_000, 580_000, 620_000, 580_000, 620_000}
+
+	assert.Nil(t, detector.regression(650_000, baseline, 30*time.Second), "within threshold")
+	assert.NotNil(t, detector.regression(700_000, baseline, 30*time.Second))
+	assert.Nil(t, detector.regression(700_000, baseline, 2*time.Minute), "below minimum delta")
+	assert.Nil(t, detector.regression(60_000, baseline, 0), "faster runs are not regressions")
+	assert.Nil(t, detector.regression(900_000, baseline[:4], 0), "too few runs")
+}
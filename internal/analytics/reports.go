@@ -0,0 +1,166 @@
+// Package analytics computes service reliability reports from run history.
+package analytics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+const (
+	// DefaultWindowDays is the window of reports that do not specify one.
+	DefaultWindowDays = 30
+	// MaxWindowDays is the longest window a report can cover.
+	MaxWindowDays = 365
+)
+
+// ErrInvalidWindow is returned for windows outside 1 to MaxWindowDays days.
+var ErrInvalidWindow = errors.New("report window must be between 1 and 365 days")
+
+// Repository reads and refreshes the report views.
+// database.AnalyticsRepository implements it.
+type Repository interface {
+	GetServiceReports(ctx context.Context, filter database.ServiceReportFilter) ([]database.ServiceReport, error)
+	RefreshServiceReports(ctx context.Context) error
+}
+
+// Query selects the runs a report covers.
+type Query struct {
+	// WindowDays is the number of UTC days covered, including today.
+	// Zero selects DefaultWindowDays.
+	WindowDays int
+	// Branch limits the report to runs of one branch; empty includes all.
+	Branch string
+}
+
+// Report is the reliability of a service over a window.
+type Report struct {
+	database.ServiceReport
+	// SuccessRate is the fraction of completed runs that passed, 0 without
+	// runs. Cancelled runs are not counted.
+	SuccessRate float64
+}
+
+// Reports are the reports of services over the same window.
+type Reports struct {
+	// WindowDays is the number of days covered.
+	WindowDays int
+	// Since is the start of the first day covered.
+	Since time.Time
+	// Services holds one report per service, ordered by service name.
+	Services []Report
+}
+
+// Reporter computes service reports and keeps the views they are read from
+// up to date. Reports lag behind runs by up to the refresh interval.
+type Reporter struct {
+	repo   Repository
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// NewReporter creates a new Reporter.
+func NewReporter(repo Repository, logger *slog.Logger) *Reporter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Reporter{
+		repo:   repo,
+		logger: logger.With("component", "reporter"),
+		now:    time.Now,
+	}
+}
+
+// ServiceReports returns the reports of every service.
+func (r *Reporter) ServiceReports(ctx context.Context, query Query) (*Reports, error) {
+	return r.reports(ctx, nil, query)
+}
+
+// ServiceReport returns the report of a service, or database.ErrNotFound if
+// the service does not exist.
+func (r *Reporter) ServiceReport(ctx context.Context, serviceID uuid.UUID, query Query) (*Reports, error) {
+	reports, err := r.reports(ctx, &serviceID, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(reports.Services) == 0 {
+		return nil, database.ErrNotFound
+	}
+	return reports, nil
+}
+
+// reports returns the reports of a service, or of every service when
+// serviceID is nil.
+func (r *Reporter) reports(ctx context.Context, serviceID *uuid.UUID, query Query) (*Reports, error) {
+	days := query.WindowDays
+	if days == 0 {
+		days = DefaultWindowDays
+	}
+	if days < 1 || days > MaxWindowDays {
+		return nil, ErrInvalidWindow
+	}
+
+	filter := database.ServiceReportFilter{
+		Since:     r.now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-days),
+		ServiceID: serviceID,
+	}
+	if query.Branch != "" {
+		filter.GitRef = &query.Branch
+	}
+
+	rows, err := r.repo.GetServiceReports(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := &Reports{
+		WindowDays: days,
+		Since:      filter.Since,
+		Services:   make([]Report, len(rows)),
+	}
+	for i, row := range rows {
+		reports.Services[i] = Report{ServiceReport: row}
+		if row.TotalRuns > 0 {
+			reports.Services[i].SuccessRate = float64(row.PassedRuns) / float64(row.TotalRuns)
+		}
+	}
+	return reports, nil
+}
+
+// Start refreshes the report views every interval until the context is
+// canceled, starting right away.
+func (r *Reporter) Start(ctx context.Context, interval time.Duration) {
+	r.logger.Info("starting report refresher", "interval", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			r.refresh(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// refresh recomputes the report views.
+func (r *Reporter) refresh(ctx context.Context) {
+	start := time.Now()
+	if err := r.repo.RefreshServiceReports(ctx); err != nil {
+		if ctx.Err() == nil {
+			r.logger.Error("failed to refresh service reports", "error", err)
+		}
+		return
+	}
+	r.logger.Debug("refreshed service reports", "duration", time.Since(start))
+}
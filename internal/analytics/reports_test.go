@@ -0,0 +1,68 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// fakeRepo returns fixed reports and records the filters it was called with.
+type fakeRepo struct {
+	reports []database.ServiceReport
+	filters []database.ServiceReportFilter
+}
+
+func (f *fakeRepo) GetServiceReports(ctx context.Context, filter database.ServiceReportFilter) ([]database.ServiceReport, error) {
+	f.filters = append(f.filters, filter)
+	if filter.ServiceID != nil {
+		for _, report := range f.reports {
+			if report.ServiceID == *filter.ServiceID {
+				return []database.ServiceReport{report}, nil
+			}
+		}
+		return nil, nil
+	}
+	return f.reports, nil
+}
+
+func (f *fakeRepo) RefreshServiceReports(ctx context.Context) error {
+	return nil
+}
+
+func TestServiceReports(t *testing.T) {
+	checkout := database.ServiceReport{ServiceID: uuid.New(), ServiceName: "checkout", TotalRuns: 8, PassedRuns: 6, FailedRuns: 2}
+	idle := database.ServiceReport{ServiceID: uuid.New(), ServiceName: "idle"}
+	repo := &fakeRepo{reports: []database.ServiceReport{checkout, idle}}
+
+	r := NewReporter(repo, nil)
+	r.now = func() time.Time { return time.Date(2026, 3, 10, 15, 4, 5, 0, time.UTC) }
+
+	reports, err := r.ServiceReports(context.Background(), Query{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultWindowDays, reports.WindowDays)
+	assert.Equal(t, time.Date(2026, 2, 9, 0, 0, 0, 0, time.UTC), reports.Since)
+	require.Len(t, reports.Services, 2)
+	assert.InDelta(t, 0.75, reports.Services[0].SuccessRate, 1e-9)
+	assert.Zero(t, reports.Services[1].SuccessRate, "services without runs")
+	assert.Nil(t, repo.filters[0].GitRef)
+
+	reports, err = r.ServiceReport(context.Background(), checkout.ServiceID, Query{WindowDays: 1, Branch: "main"})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), reports.Since, "a one-day window covers today")
+	require.NotNil(t, repo.filters[1].GitRef)
+	assert.Equal(t, "main", *repo.filters[1].GitRef)
+
+	_, err = r.ServiceReport(context.Background(), uuid.New(), Query{})
+	assert.ErrorIs(t, err, database.ErrNotFound)
+
+	for _, days := range []int{-1, MaxWindowDays + 1} {
+		_, err = r.ServiceReports(context.Background(), Query{WindowDays: days})
+		assert.ErrorIs(t, err, ErrInvalidWindow, "window of %d days", days)
+	}
+}
@@ -0,0 +1,90 @@
+// Package apidocs serves the OpenAPI description of the REST API and a
+// Swagger UI page to explore it. static/openapi.yaml is generated from the
+// google.api.http annotations of the protos by `make proto`; do not edit it
+// by hand. Spec describes its error responses as problem details.
+package apidocs
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+//go:embed static
+var staticFS embed.FS
+
+// SpecFile is the name the OpenAPI description is served under.
+const SpecFile = "openapi.yaml"
+
+// contentSecurityPolicy allows the Swagger UI assets from unpkg and API
+// calls to the same origin only. Swagger UI sets inline styles.
+const contentSecurityPolicy = "default-src 'self'; script-src 'self' https://unpkg.com; style-src 'self' 'unsafe-inline' https://unpkg.com; img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'"
+
+// Spec returns the OpenAPI v3 description of the REST API, with its error
+// responses described as the problem details the gateway answers with.
+func Spec() []byte {
+	return spec()
+}
+
+var spec = sync.OnceValue(func() []byte {
+	// The embedded directory is fixed at compile time.
+	data, err := staticFS.ReadFile("static/" + SpecFile)
+	if err != nil {
+		panic(err)
+	}
+	data, err = withProblemResponses(data)
+	if err != nil {
+		panic(err)
+	}
+	return data
+})
+
+// Handler returns an http.Handler serving the API explorer under prefix
+// (e.g. "/api/docs") and the OpenAPI description at prefix + "/openapi.yaml".
+func Handler(prefix string) http.Handler {
+	prefix = "/" + strings.Trim(prefix, "/")
+
+	assets, err := fs.Sub(staticFS, "static")
+	if err != nil {
+		panic(err)
+	}
+
+	return http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "no-cache")
+
+		var contentType string
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		switch name {
+		case "", "index.html":
+			name, contentType = "index.html", "text/html; charset=utf-8"
+			w.Header().Set("Content-Security-Policy", contentSecurityPolicy)
+		case "explorer.js":
+			contentType = "text/javascript; charset=utf-8"
+		case SpecFile:
+			contentType = "application/yaml"
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		var data []byte
+		if name == SpecFile {
+			data = Spec()
+		} else if data, err = fs.ReadFile(assets, name); err != nil {
+			http.Error(w, "api docs not available", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(data)
+	}))
+}
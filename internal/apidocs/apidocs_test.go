@@ -0,0 +1,105 @@
+package apidocs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func serve(t *testing.T, method, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handler("/api/docs").ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestSpecIsOpenAPI(t *testing.T) {
+	var spec struct {
+		OpenAPI string                    `yaml:"openapi"`
+		Paths   map[string]map[string]any `yaml:"paths"`
+	}
+	if err := yaml.Unmarshal(Spec(), &spec); err != nil {
+		t.Fatalf("spec is not valid YAML: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Fatalf("openapi = %q, want 3.x", spec.OpenAPI)
+	}
+	if _, ok := spec.Paths["/api/v1/runs/{runId}"]["get"]; !ok {
+		t.Fatal("spec does not describe GET /api/v1/runs/{runId}")
+	}
+}
+
+func TestSpecDescribesProblems(t *testing.T) {
+	var spec struct {
+		Paths      map[string]map[string]map[string]any `yaml:"paths"`
+		Components struct {
+			Responses map[string]any `yaml:"responses"`
+			Schemas   map[string]any `yaml:"schemas"`
+		} `yaml:"components"`
+	}
+	if err := yaml.Unmarshal(Spec(), &spec); err != nil {
+		t.Fatalf("spec is not valid YAML: %v", err)
+	}
+
+	for path, operations := range spec.Paths {
+		for method, operation := range operations {
+			responses, _ := operation["responses"].(map[string]any)
+			want := map[string]any{"$ref": "#/components/responses/Problem"}
+			if got := responses["default"]; !reflect.DeepEqual(got, want) {
+				t.Errorf("%s %s: default response = %v, want %v", method, path, got, want)
+			}
+		}
+	}
+	if _, ok := spec.Components.Responses["Problem"]; !ok {
+		t.Error("spec does not describe the Problem response")
+	}
+	for _, name := range []string{"Problem", "FieldViolation"} {
+		if _, ok := spec.Components.Schemas[name]; !ok {
+			t.Errorf("spec does not describe the %s schema", name)
+		}
+	}
+	if _, ok := spec.Components.Schemas["Status"]; ok {
+		t.Error("spec still describes the google.rpc.Status schema")
+	}
+}
+
+func TestHandlerServesExplorer(t *testing.T) {
+	for _, target := range []string{"/api/docs/", "/api/docs/index.html"} {
+		rec := serve(t, http.MethodGet, target)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", target, rec.Code, http.StatusOK)
+		}
+		if !strings.Contains(rec.Body.String(), `<script src="explorer.js">`) {
+			t.Fatalf("%s: expected the explorer page, got %q", target, rec.Body.String())
+		}
+		if rec.Header().Get("Content-Security-Policy") == "" {
+			t.Fatalf("%s: missing Content-Security-Policy header", target)
+		}
+	}
+}
+
+func TestHandlerServesSpec(t *testing.T) {
+	rec := serve(t, http.MethodGet, "/api/docs/openapi.yaml")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/yaml" {
+		t.Fatalf("Content-Type = %q, want application/yaml", ct)
+	}
+	if rec.Body.String() != string(Spec()) {
+		t.Fatal("served spec differs from the embedded spec")
+	}
+}
+
+func TestHandlerRejectsUnknownPaths(t *testing.T) {
+	if rec := serve(t, http.MethodGet, "/api/docs/runs"); rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := serve(t, http.MethodPost, "/api/docs/"); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,191 @@
+package apidocs
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// problemComponents describe the error responses of the API, which the
+// generator does not know about: the gateway answers errors with RFC 7807
+// problem details rather than with the google.rpc.Status it describes.
+const problemComponents = `
+responses:
+    Problem:
+        description: Error response, as RFC 7807 problem details.
+        headers:
+            X-Conductor-Error-Code:
+                description: The machine-readable error code, as in the body.
+                schema:
+                    type: string
+            X-Request-ID:
+                description: The ID of the request, as in the body.
+                schema:
+                    type: string
+        content:
+            application/problem+json:
+                schema:
+                    $ref: '#/components/schemas/Problem'
+schemas:
+    FieldViolation:
+        type: object
+        properties:
+            field:
+                type: string
+                description: Path of the field, such as service_id or git_ref.branch.
+            description:
+                type: string
+                description: What is wrong with the field.
+        description: A request field that is missing or malformed.
+    Problem:
+        type: object
+        properties:
+            type:
+                type: string
+                description: URI identifying the error code, such as https://conductor.dev/errors/CONDUCTOR_RUN_NOT_FOUND.
+            title:
+                type: string
+                description: Description of the error code, the same for every error with the code.
+            status:
+                type: integer
+                description: HTTP status code of the response.
+                format: int32
+            detail:
+                type: string
+                description: Description of this occurrence of the error; it may change between releases.
+            instance:
+                type: string
+                description: Path of the request that failed.
+            code:
+                type: string
+                description: Machine-readable error code, such as CONDUCTOR_RUN_NOT_FOUND. Clients should branch on it.
+            grpcStatus:
+                type: string
+                description: Name of the gRPC status code of the error, such as NotFound.
+            requestId:
+                type: string
+                description: X-Request-ID of the request that failed.
+            metadata:
+                type: object
+                additionalProperties:
+                    type: string
+                description: Metadata of the error, such as the ID of the missing resource.
+            fieldViolations:
+                type: array
+                items:
+                    $ref: '#/components/schemas/FieldViolation'
+                description: Request fields that are missing or malformed.
+        description: Problem details (RFC 7807) of an error, with its Conductor error code.
+`
+
+// statusSchemas are the schemas of the google.rpc.Status the generator
+// describes errors with, which no operation answers with.
+var statusSchemas = []string{"Status", "GoogleProtobufAny"}
+
+// withProblemResponses returns the generated spec with the default response
+// of every operation described as problem details.
+func withProblemResponses(generated []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(generated, &doc); err != nil {
+		return nil, err
+	}
+	var extra yaml.Node
+	if err := yaml.Unmarshal([]byte(problemComponents), &extra); err != nil {
+		return nil, err
+	}
+	root := doc.Content[0]
+
+	paths := mapValue(root, "paths")
+	if paths == nil {
+		return nil, errors.New("spec has no paths")
+	}
+	for i := 1; i < len(paths.Content); i += 2 {
+		operations := paths.Content[i]
+		for j := 1; j < len(operations.Content); j += 2 {
+			responses := mapValue(operations.Content[j], "responses")
+			if responses == nil {
+				continue
+			}
+			setMapValue(responses, "default", refNode("#/components/responses/Problem"))
+		}
+	}
+
+	components := mapValue(root, "components")
+	if components == nil {
+		return nil, errors.New("spec has no components")
+	}
+	schemas := mapValue(components, "schemas")
+	for _, name := range statusSchemas {
+		deleteMapValue(schemas, name)
+	}
+	extraRoot := extra.Content[0]
+	setMapValue(components, "responses", mapValue(extraRoot, "responses"))
+	extraSchemas := mapValue(extraRoot, "schemas")
+	for i := 0; i+1 < len(extraSchemas.Content); i += 2 {
+		insertSorted(schemas, extraSchemas.Content[i].Value, extraSchemas.Content[i+1])
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(4)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mapValue returns the value of key in a mapping node, or nil.
+func mapValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMapValue sets the value of key in a mapping node, appending the key if
+// the node does not have it.
+func setMapValue(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// deleteMapValue deletes key from a mapping node.
+func deleteMapValue(m *yaml.Node, key string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// insertSorted inserts key into a mapping node sorted by key, as the
+// generator sorts schemas, before the first key after it.
+func insertSorted(m *yaml.Node, key string, value *yaml.Node) {
+	i := 0
+	for i+1 < len(m.Content) && m.Content[i].Value < key {
+		i += 2
+	}
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	m.Content = slices.Insert(m.Content, i, keyNode, value)
+}
+
+// refNode returns a mapping node referencing ref.
+func refNode(ref string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: "$ref"},
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: ref},
+	}}
+}
@@ -0,0 +1,9 @@
+// Renders the OpenAPI description served next to this page.
+window.addEventListener("load", function () {
+  window.ui = SwaggerUIBundle({
+    url: "openapi.yaml",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    tryItOutEnabled: false,
+  });
+});
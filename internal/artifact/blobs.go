@@ -0,0 +1,120 @@
+package artifact
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// BlobStorage defines the storage operations needed for content-addressed
+// blobs.
+type BlobStorage interface {
+	// UploadBlob uploads the content with a digest and returns its storage path.
+	UploadBlob(ctx context.Context, digest string, reader io.Reader, size int64, contentType string) (string, error)
+}
+
+// BlobStore stores artifact content once per SHA-256 digest, so identical
+// artifacts of different runs share one object. Artifacts reference the blob
+// they hold; cleanup removes blobs once no artifact does.
+type BlobStore struct {
+	repo    database.ArtifactBlobRepository
+	storage BlobStorage
+	logger  *slog.Logger
+}
+
+// NewBlobStore creates a new BlobStore.
+func NewBlobStore(repo database.ArtifactBlobRepository, storage BlobStorage, logger *slog.Logger) *BlobStore {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &BlobStore{
+		repo:    repo,
+		storage: storage,
+		logger:  logger.With("component", "artifact_blobs"),
+	}
+}
+
+// Put stores content as a blob and returns it. The content is hashed while
+// it is spooled to a temporary file; if a blob with the same digest is
+// stored already, it is reused and nothing is uploaded. Either way the blob
+// is marked as referenced now, so cleanup keeps it until the artifact
+// holding it is recorded.
+func (b *BlobStore) Put(ctx context.Context, reader io.Reader, contentType string) (*database.ArtifactBlob, error) {
+	spool, err := os.CreateTemp("", "conductor-blob-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spool file: %w", err)
+	}
+	defer func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(spool, hash), reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact: %w", err)
+	}
+	digest := hex.EncodeToString(hash.Sum(nil))
+
+	blob, err := b.repo.Touch(ctx, digest)
+	if err == nil {
+		b.logger.Debug("reusing artifact blob",
+			"digest", digest,
+			"size", size,
+			"ref_count", blob.RefCount,
+		)
+		return blob, nil
+	}
+	if !database.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to look up blob: %w", err)
+	}
+
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind spool file: %w", err)
+	}
+	objectPath, err := b.storage.UploadBlob(ctx, digest, spool, size, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	blob = &database.ArtifactBlob{
+		Digest:      digest,
+		Path:        objectPath,
+		SizeBytes:   size,
+		ContentType: database.NullString(contentType),
+	}
+	if err := b.repo.Upsert(ctx, blob); err != nil {
+		return nil, fmt.Errorf("failed to record blob: %w", err)
+	}
+
+	b.logger.Info("stored artifact blob",
+		"digest", digest,
+		"path", blob.Path,
+		"size", size,
+	)
+	return blob, nil
+}
+
+// BlobArtifact returns the record of an artifact of a run held in a blob.
+func BlobArtifact(runID uuid.UUID, name string, blob *database.ArtifactBlob) *database.Artifact {
+	size := blob.SizeBytes
+	digest := blob.Digest
+	return &database.Artifact{
+		RunID:       runID,
+		Name:        name,
+		Path:        blob.Path,
+		ContentType: blob.ContentType,
+		SizeBytes:   &size,
+		Checksum:    &digest,
+		BlobDigest:  &digest,
+	}
+}
@@ -0,0 +1,136 @@
+package artifact
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// fakeBlobRepo keeps blobs in memory. Reference counts are set by tests, as
+// the database keeps them.
+type fakeBlobRepo struct {
+	blobs map[string]*database.ArtifactBlob
+}
+
+func newFakeBlobRepo() *fakeBlobRepo {
+	return &fakeBlobRepo{blobs: make(map[string]*database.ArtifactBlob)}
+}
+
+func (r *fakeBlobRepo) Upsert(ctx context.Context, blob *database.ArtifactBlob) error {
+	if existing, ok := r.blobs[blob.Digest]; ok {
+		existing.LastReferencedAt = time.Now()
+		*blob = *existing
+		return nil
+	}
+	blob.CreatedAt = time.Now()
+	blob.LastReferencedAt = blob.CreatedAt
+	stored := *blob
+	r.blobs[blob.Digest] = &stored
+	return nil
+}
+
+func (r *fakeBlobRepo) Touch(ctx context.Context, digest string) (*database.ArtifactBlob, error) {
+	blob, ok := r.blobs[digest]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	blob.LastReferencedAt = time.Now()
+	touched := *blob
+	return &touched, nil
+}
+
+func (r *fakeBlobRepo) Get(ctx context.Context, digest string) (*database.ArtifactBlob, error) {
+	blob, ok := r.blobs[digest]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	fetched := *blob
+	return &fetched, nil
+}
+
+func (r *fakeBlobRepo) ListUnreferenced(ctx context.Context, before time.Time, limit int) ([]database.ArtifactBlob, error) {
+	var result []database.ArtifactBlob
+	for _, blob := range r.blobs {
+		if blob.RefCount == 0 && blob.LastReferencedAt.Before(before) && len(result) < limit {
+			result = append(result, *blob)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeBlobRepo) DeleteUnreferenced(ctx context.Context, digest string, before time.Time) error {
+	blob, ok := r.blobs[digest]
+	if !ok || blob.RefCount > 0 || !blob.LastReferencedAt.Before(before) {
+		return database.ErrNotFound
+	}
+	delete(r.blobs, digest)
+	return nil
+}
+
+type fakeBlobStorage struct {
+	uploads map[string]string
+}
+
+func (s *fakeBlobStorage) UploadBlob(ctx context.Context, digest string, reader io.Reader, size int64, contentType string) (string, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) != size {
+		return "", io.ErrShortWrite
+	}
+	path := "artifacts/blobs/sha256/" + digest[:2] + "/" + digest
+	s.uploads[path] = string(data)
+	return path, nil
+}
+
+func TestBlobStore_Put(t *testing.T) {
+	repo := newFakeBlobRepo()
+	storage := &fakeBlobStorage{uploads: make(map[string]string)}
+	store := NewBlobStore(repo, storage, nil)
+	ctx := context.Background()
+
+	sum := sha256.Sum256([]byte("compiled fixtures"))
+	digest := hex.EncodeToString(sum[:])
+
+	blob, err := store.Put(ctx, strings.NewReader("compiled fixtures"), "application/x-tar")
+	require.NoError(t, err)
+	assert.Equal(t, digest, blob.Digest)
+	assert.Equal(t, int64(17), blob.SizeBytes)
+	assert.Equal(t, "compiled fixtures", storage.uploads[blob.Path])
+
+	t.Run("identical content is stored once", func(t *testing.T) {
+		delete(storage.uploads, blob.Path)
+		again, err := store.Put(ctx, strings.NewReader("compiled fixtures"), "application/x-tar")
+		require.NoError(t, err)
+		assert.Equal(t, blob.Path, again.Path)
+		assert.Empty(t, storage.uploads, "existing blobs are not uploaded again")
+	})
+
+	t.Run("different content gets its own blob", func(t *testing.T) {
+		other, err := store.Put(ctx, strings.NewReader("other fixtures"), "")
+		require.NoError(t, err)
+		assert.NotEqual(t, blob.Digest, other.Digest)
+		assert.Len(t, repo.blobs, 2)
+	})
+
+	t.Run("artifacts reference the blob", func(t *testing.T) {
+		runID := uuid.New()
+		artifact := BlobArtifact(runID, "fixtures.tar", blob)
+		assert.Equal(t, runID, artifact.RunID)
+		assert.Equal(t, blob.Path, artifact.Path)
+		assert.Equal(t, digest, *artifact.BlobDigest)
+		assert.Equal(t, digest, *artifact.Checksum)
+		assert.Equal(t, int64(17), *artifact.SizeBytes)
+	})
+}
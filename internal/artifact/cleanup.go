@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +17,9 @@ type CleanupConfig struct {
 	Interval  time.Duration
 	Retention time.Duration
 	BatchSize int
+	// BlobGracePeriod is how long blobs no artifact holds are kept after
+	// they were last referenced, so uploads not yet recorded keep theirs.
+	BlobGracePeriod time.Duration
 }
 
 // CleanupService removes expired artifacts from storage and the database.
@@ -23,9 +27,16 @@ type CleanupService struct {
 	repo      database.ArtifactRepository
 	storage   ArtifactStorage
 	logger    *slog.Logger
+	batchSize int
+
+	blobs           database.ArtifactBlobRepository
+	blobGracePeriod time.Duration
+
+	mu        sync.Mutex
 	interval  time.Duration
 	retention time.Duration
-	batchSize int
+	// rescheduled is signaled when the interval changes.
+	rescheduled chan struct{}
 }
 
 // NewCleanupService creates a new CleanupService.
@@ -54,41 +65,96 @@ func NewCleanupService(
 		batchSize = 100
 	}
 
+	blobGracePeriod := config.BlobGracePeriod
+	if blobGracePeriod <= 0 {
+		blobGracePeriod = 24 * time.Hour
+	}
+
 	return &CleanupService{
-		repo:      repo,
-		storage:   storage,
-		logger:    logger.With("component", "artifact_cleanup"),
-		interval:  interval,
-		retention: retention,
-		batchSize: batchSize,
+		repo:            repo,
+		storage:         storage,
+		logger:          logger.With("component", "artifact_cleanup"),
+		interval:        interval,
+		retention:       retention,
+		batchSize:       batchSize,
+		blobGracePeriod: blobGracePeriod,
+		rescheduled:     make(chan struct{}, 1),
+	}
+}
+
+// SetBlobRepository enables removing content-addressed blobs no artifact
+// holds anymore.
+func (s *CleanupService) SetBlobRepository(blobs database.ArtifactBlobRepository) {
+	s.blobs = blobs
+}
+
+// SetSchedule changes how often cleanup runs and how long artifacts are kept,
+// also while the service runs. Values that are not positive are unchanged.
+func (s *CleanupService) SetSchedule(interval, retention time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if retention > 0 {
+		s.retention = retention
+	}
+	if interval > 0 && interval != s.interval {
+		s.interval = interval
+		select {
+		case s.rescheduled <- struct{}{}:
+		default:
+		}
 	}
 }
 
+// schedule returns the cleanup interval and retention.
+func (s *CleanupService) schedule() (time.Duration, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.interval, s.retention
+}
+
 // Start begins the cleanup loop until the context is canceled.
 func (s *CleanupService) Start(ctx context.Context) {
+	interval, retention := s.schedule()
 	s.logger.Info("starting artifact cleanup",
-		"interval", s.interval,
-		"retention", s.retention,
+		"interval", interval,
+		"retention", retention,
 		"batch_size", s.batchSize,
 	)
 
 	go func() {
-		ticker := time.NewTicker(s.interval)
+		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 
 		for {
 			s.run(ctx)
-			select {
-			case <-ctx.Done():
+			if !s.wait(ctx, ticker) {
 				return
-			case <-ticker.C:
 			}
 		}
 	}()
 }
 
+// wait waits until the next cleanup is due, and reports whether it is, as
+// opposed to ctx being done. A changed interval restarts the wait.
+func (s *CleanupService) wait(ctx context.Context, ticker *time.Ticker) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			return true
+		case <-s.rescheduled:
+			interval, _ := s.schedule()
+			ticker.Reset(interval)
+			s.logger.Info("artifact cleanup rescheduled", "interval", interval)
+		}
+	}
+}
+
 func (s *CleanupService) run(ctx context.Context) {
-	cutoff := time.Now().Add(-s.retention)
+	_, retention := s.schedule()
+	cutoff := time.Now().Add(-retention)
 	deleted := 0
 
 	for {
@@ -124,11 +190,18 @@ func (s *CleanupService) run(ctx context.Context) {
 			"cutoff", cutoff,
 		)
 	}
+
+	s.sweepBlobs(ctx)
 }
 
+// deleteArtifact deletes an artifact and its object. Artifacts held in a
+// shared blob only release their reference; the blob is removed by
+// sweepBlobs once no artifact holds it.
 func (s *CleanupService) deleteArtifact(ctx context.Context, artifact database.Artifact) error {
-	if err := s.storage.Delete(ctx, artifact.Path); err != nil {
-		return fmt.Errorf("delete storage: %w", err)
+	if artifact.BlobDigest == nil {
+		if err := s.storage.Delete(ctx, artifact.Path); err != nil {
+			return fmt.Errorf("delete storage: %w", err)
+		}
 	}
 
 	if err := s.repo.Delete(ctx, artifact.ID); err != nil {
@@ -144,7 +217,60 @@ func (s *CleanupService) deleteArtifact(ctx context.Context, artifact database.A
 	return nil
 }
 
-// DeleteRunArtifacts removes all artifacts associated with a run.
+// sweepBlobs removes blobs no artifact has held for the grace period. The
+// record is deleted first, and only if the blob is still unreferenced, so a
+// blob referenced again in between keeps its object.
+func (s *CleanupService) sweepBlobs(ctx context.Context) {
+	if s.blobs == nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-s.blobGracePeriod)
+	deleted := 0
+
+	for {
+		blobs, err := s.blobs.ListUnreferenced(ctx, cutoff, s.batchSize)
+		if err != nil {
+			s.logger.Error("failed to list unreferenced blobs", "error", err)
+			return
+		}
+
+		batchDeleted := 0
+		for _, blob := range blobs {
+			if err := s.blobs.DeleteUnreferenced(ctx, blob.Digest, cutoff); err != nil {
+				if !database.IsNotFound(err) {
+					s.logger.Warn("failed to delete blob record", "digest", blob.Digest, "error", err)
+				}
+				continue
+			}
+			if err := s.storage.Delete(ctx, blob.Path); err != nil {
+				s.logger.Warn("failed to delete blob",
+					"digest", blob.Digest,
+					"path", blob.Path,
+					"error", err,
+				)
+				continue
+			}
+			batchDeleted++
+		}
+		deleted += batchDeleted
+
+		// A batch that deleted nothing would be listed again.
+		if len(blobs) < s.batchSize || batchDeleted == 0 {
+			break
+		}
+	}
+
+	if deleted > 0 {
+		s.logger.Info("unreferenced blob cleanup completed",
+			"deleted", deleted,
+			"cutoff", cutoff,
+		)
+	}
+}
+
+// DeleteRunArtifacts removes all artifacts associated with a run. Blobs the
+// artifacts held are stored outside the run and removed by the sweep.
 func (s *CleanupService) DeleteRunArtifacts(ctx context.Context, runID uuid.UUID) error {
 	if err := s.storage.DeleteByRun(ctx, runID); err != nil {
 		return fmt.Errorf("delete run storage: %w", err)
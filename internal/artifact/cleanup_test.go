@@ -0,0 +1,93 @@
+package artifact
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// expiringArtifactRepo lists its artifacts as expired until deleted.
+type expiringArtifactRepo struct {
+	database.ArtifactRepository
+	artifacts []database.Artifact
+}
+
+func (r *expiringArtifactRepo) ListOlderThan(ctx context.Context, before time.Time, limit int) ([]database.Artifact, error) {
+	return r.artifacts, nil
+}
+
+func (r *expiringArtifactRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	for i, artifact := range r.artifacts {
+		if artifact.ID == id {
+			r.artifacts = append(r.artifacts[:i], r.artifacts[i+1:]...)
+			return nil
+		}
+	}
+	return database.ErrNotFound
+}
+
+// deletingStorage records the paths it deletes.
+type deletingStorage struct {
+	ArtifactStorage
+	deleted []string
+}
+
+func (s *deletingStorage) Delete(ctx context.Context, path string) error {
+	s.deleted = append(s.deleted, path)
+	return nil
+}
+
+func TestCleanupService_SetSchedule(t *testing.T) {
+	s := NewCleanupService(nil, nil, CleanupConfig{}, nil)
+
+	interval, retention := s.schedule()
+	assert.Equal(t, time.Hour, interval)
+	assert.Equal(t, 30*24*time.Hour, retention)
+
+	s.SetSchedule(0, 7*24*time.Hour)
+	interval, retention = s.schedule()
+	assert.Equal(t, time.Hour, interval)
+	assert.Equal(t, 7*24*time.Hour, retention)
+	assert.Empty(t, s.rescheduled, "unchanged interval must not reschedule")
+
+	s.SetSchedule(15*time.Minute, -1)
+	interval, retention = s.schedule()
+	assert.Equal(t, 15*time.Minute, interval)
+	assert.Equal(t, 7*24*time.Hour, retention)
+	assert.Len(t, s.rescheduled, 1)
+}
+
+func TestCleanupService_Blobs(t *testing.T) {
+	shared := "aa" + strings.Repeat("0", 62)
+	orphaned := "bb" + strings.Repeat("0", 62)
+	recent := "cc" + strings.Repeat("0", 62)
+	old := time.Now().Add(-48 * time.Hour)
+
+	blobs := newFakeBlobRepo()
+	blobs.blobs[shared] = &database.ArtifactBlob{Digest: shared, Path: "blobs/" + shared, RefCount: 1, LastReferencedAt: old}
+	blobs.blobs[orphaned] = &database.ArtifactBlob{Digest: orphaned, Path: "blobs/" + orphaned, LastReferencedAt: old}
+	blobs.blobs[recent] = &database.ArtifactBlob{Digest: recent, Path: "blobs/" + recent, LastReferencedAt: time.Now()}
+
+	repo := &expiringArtifactRepo{artifacts: []database.Artifact{
+		{ID: uuid.New(), Path: "artifacts/run/output.log"},
+		{ID: uuid.New(), Path: "blobs/" + shared, BlobDigest: &shared},
+	}}
+	storage := &deletingStorage{}
+	s := NewCleanupService(repo, storage, CleanupConfig{BlobGracePeriod: 24 * time.Hour}, nil)
+	s.SetBlobRepository(blobs)
+
+	s.run(context.Background())
+
+	assert.Empty(t, repo.artifacts)
+	assert.Equal(t, []string{"artifacts/run/output.log", "blobs/" + orphaned}, storage.deleted,
+		"blob artifacts only release their blob; only unreferenced blobs past the grace period are deleted")
+	assert.Contains(t, blobs.blobs, shared)
+	assert.Contains(t, blobs.blobs, recent)
+	assert.NotContains(t, blobs.blobs, orphaned)
+}
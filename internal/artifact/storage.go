@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"mime"
 	"net/url"
 	"path"
 	"strings"
@@ -48,6 +49,12 @@ type ArtifactMetadata struct {
 	ContentType  string
 	LastModified time.Time
 	ETag         string
+	StorageClass string
+	// RestoreOngoing is true while an archived object is being restored.
+	RestoreOngoing bool
+	// RestoreExpiresAt is when the restored copy of an archived object is
+	// removed again. It is zero if the object has not been restored.
+	RestoreExpiresAt time.Time
 }
 
 // StorageConfig holds configuration for artifact storage.
@@ -178,6 +185,52 @@ func (s *Storage) UploadWithSize(ctx context.Context, runID uuid.UUID, name stri
 	return objectPath, nil
 }
 
+// UploadBlob uploads content-addressed artifact content and returns the
+// storage path. Blobs are stored under blobs/sha256/ by digest, outside the
+// paths of runs, so deleting the artifacts of a run leaves them in place.
+func (s *Storage) UploadBlob(ctx context.Context, digest string, reader io.Reader, size int64, contentType string) (string, error) {
+	if len(digest) < 2 || strings.ContainsAny(digest, "/.") {
+		return "", fmt.Errorf("invalid blob digest %q", digest)
+	}
+	objectPath := fmt.Sprintf("%s/blobs/sha256/%s/%s", s.pathPrefix, digest[:2], digest)
+
+	info, err := s.client.PutObject(ctx, s.bucket, objectPath, reader, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload blob: %w", err)
+	}
+
+	s.logger.Info("uploaded blob",
+		"digest", digest,
+		"path", objectPath,
+		"size", info.Size,
+	)
+
+	return objectPath, nil
+}
+
+// UploadResultArchive uploads a gzipped export of test results, such as a
+// month of results past their retention, and returns the storage path.
+// Exports are stored under result-archives/ in the artifact bucket.
+func (s *Storage) UploadResultArchive(ctx context.Context, name string, reader io.Reader) (string, error) {
+	objectPath := "result-archives/" + strings.TrimPrefix(path.Clean(name), "/")
+
+	info, err := s.client.PutObject(ctx, s.bucket, objectPath, reader, -1, minio.PutObjectOptions{
+		ContentType: "application/gzip",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload result archive: %w", err)
+	}
+
+	s.logger.Info("uploaded result archive",
+		"path", objectPath,
+		"size", info.Size,
+	)
+
+	return objectPath, nil
+}
+
 // Download retrieves an artifact by its storage path.
 func (s *Storage) Download(ctx context.Context, objectPath string) (io.ReadCloser, error) {
 	s.logger.Debug("downloading artifact", "path", objectPath)
@@ -197,8 +250,25 @@ func (s *Storage) Download(ctx context.Context, objectPath string) (io.ReadClose
 	return obj, nil
 }
 
+// PresignOptions sets the response headers a presigned URL serves an
+// object with.
+type PresignOptions struct {
+	// ContentType overrides the content type the object was stored with.
+	ContentType string
+	// Inline asks browsers to display the object instead of saving it.
+	Inline bool
+	// FileName is the name browsers save or display the object as.
+	FileName string
+}
+
 // GetPresignedURL generates a presigned URL for downloading an artifact.
 func (s *Storage) GetPresignedURL(ctx context.Context, objectPath string, expires time.Duration) (string, error) {
+	return s.GetPresignedURLWithOptions(ctx, objectPath, expires, PresignOptions{})
+}
+
+// GetPresignedURLWithOptions generates a presigned URL for an artifact that
+// overrides the response headers the object is served with.
+func (s *Storage) GetPresignedURLWithOptions(ctx context.Context, objectPath string, expires time.Duration, opts PresignOptions) (string, error) {
 	if expires <= 0 {
 		expires = 1 * time.Hour // Default expiry
 	}
@@ -212,6 +282,20 @@ func (s *Storage) GetPresignedURL(ctx context.Context, objectPath string, expire
 	)
 
 	reqParams := make(url.Values)
+	if opts.ContentType != "" {
+		reqParams.Set("response-content-type", opts.ContentType)
+	}
+	if opts.Inline || opts.FileName != "" {
+		disposition := "attachment"
+		if opts.Inline {
+			disposition = "inline"
+		}
+		var params map[string]string
+		if opts.FileName != "" {
+			params = map[string]string{"filename": opts.FileName}
+		}
+		reqParams.Set("response-content-disposition", mime.FormatMediaType(disposition, params))
+	}
 	presignedURL, err := s.client.PresignedGetObject(ctx, s.bucket, objectPath, expires, reqParams)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
@@ -280,14 +364,80 @@ func (s *Storage) GetMetadata(ctx context.Context, objectPath string) (*Artifact
 		return nil, fmt.Errorf("failed to get object metadata: %w", err)
 	}
 
-	return &ArtifactMetadata{
+	metadata := &ArtifactMetadata{
 		Path:         objectPath,
 		Name:         path.Base(objectPath),
 		Size:         info.Size,
 		ContentType:  info.ContentType,
 		LastModified: info.LastModified,
 		ETag:         info.ETag,
-	}, nil
+		StorageClass: info.StorageClass,
+	}
+	if info.Restore != nil {
+		metadata.RestoreOngoing = info.Restore.OngoingRestore
+		metadata.RestoreExpiresAt = info.Restore.ExpiryTime
+	}
+
+	return metadata, nil
+}
+
+// Transition moves an artifact to another storage class by copying the
+// object onto itself.
+func (s *Storage) Transition(ctx context.Context, objectPath, storageClass string) error {
+	info, err := s.client.StatObject(ctx, s.bucket, objectPath, minio.StatObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get object metadata: %w", err)
+	}
+
+	userMetadata := make(map[string]string, len(info.UserMetadata)+1)
+	for k, v := range info.UserMetadata {
+		userMetadata[k] = v
+	}
+	userMetadata["X-Amz-Storage-Class"] = storageClass
+
+	_, err = s.client.CopyObject(ctx,
+		minio.CopyDestOptions{
+			Bucket:          s.bucket,
+			Object:          objectPath,
+			ReplaceMetadata: true,
+			UserMetadata:    userMetadata,
+			ContentType:     info.ContentType,
+		},
+		minio.CopySrcOptions{
+			Bucket: s.bucket,
+			Object: objectPath,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to transition artifact: %w", err)
+	}
+
+	s.logger.Debug("transitioned artifact",
+		"path", objectPath,
+		"storage_class", storageClass,
+	)
+	return nil
+}
+
+// Restore requests a temporary copy of an archived artifact that is kept
+// for the given number of days. A restore that is already in progress is
+// not an error.
+func (s *Storage) Restore(ctx context.Context, objectPath string, days int, tier string) error {
+	req := minio.RestoreRequest{}
+	req.SetDays(days)
+	req.SetGlacierJobParameters(minio.GlacierJobParameters{Tier: minio.TierType(tier)})
+
+	err := s.client.RestoreObject(ctx, s.bucket, objectPath, "", req)
+	if err != nil && minio.ToErrorResponse(err).Code != "RestoreAlreadyInProgress" {
+		return fmt.Errorf("failed to restore artifact: %w", err)
+	}
+
+	s.logger.Info("requested artifact restore",
+		"path", objectPath,
+		"days", days,
+		"tier", tier,
+	)
+	return nil
 }
 
 // List lists artifacts for a test run.
@@ -0,0 +1,292 @@
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// Storage classes artifacts can be stored in.
+const (
+	StorageClassStandard    = "STANDARD"
+	StorageClassStandardIA  = "STANDARD_IA"
+	StorageClassOneZoneIA   = "ONEZONE_IA"
+	StorageClassGlacierIR   = "GLACIER_IR"
+	StorageClassGlacier     = "GLACIER"
+	StorageClassDeepArchive = "DEEP_ARCHIVE"
+)
+
+// Restore retrieval tiers.
+const (
+	RestoreTierExpedited = "Expedited"
+	RestoreTierStandard  = "Standard"
+	RestoreTierBulk      = "Bulk"
+)
+
+// RequiresRestore reports whether objects in a storage class must be
+// restored before they can be downloaded.
+func RequiresRestore(storageClass string) bool {
+	return storageClass == StorageClassGlacier || storageClass == StorageClassDeepArchive
+}
+
+// EstimateRestoreDuration returns the upper bound of the documented S3
+// retrieval time for a storage class and tier.
+func EstimateRestoreDuration(storageClass, tier string) time.Duration {
+	if storageClass == StorageClassDeepArchive {
+		if tier == RestoreTierBulk {
+			return 48 * time.Hour
+		}
+		return 12 * time.Hour
+	}
+
+	switch tier {
+	case RestoreTierExpedited:
+		return 5 * time.Minute
+	case RestoreTierBulk:
+		return 12 * time.Hour
+	default:
+		return 5 * time.Hour
+	}
+}
+
+// ArchiveStorage defines the storage operations needed for tiering.
+type ArchiveStorage interface {
+	// Transition moves an artifact to another storage class.
+	Transition(ctx context.Context, path, storageClass string) error
+
+	// Restore requests a temporary copy of an archived artifact.
+	Restore(ctx context.Context, path string, days int, tier string) error
+
+	// GetMetadata retrieves metadata for an artifact.
+	GetMetadata(ctx context.Context, path string) (*ArtifactMetadata, error)
+}
+
+// TieringConfig defines archival tiering settings.
+type TieringConfig struct {
+	Interval     time.Duration
+	ArchiveAfter time.Duration
+	StorageClass string
+	BatchSize    int
+	RestoreDays  int
+	RestoreTier  string
+}
+
+// TieringService moves old artifacts to a cheaper storage class and
+// restores them on demand when they are downloaded.
+type TieringService struct {
+	repo         database.ArtifactRepository
+	storage      ArchiveStorage
+	logger       *slog.Logger
+	interval     time.Duration
+	archiveAfter time.Duration
+	storageClass string
+	batchSize    int
+	restoreDays  int
+	restoreTier  string
+	now          func() time.Time
+}
+
+// NewTieringService creates a new TieringService.
+func NewTieringService(
+	repo database.ArtifactRepository,
+	storage ArchiveStorage,
+	config TieringConfig,
+	logger *slog.Logger,
+) *TieringService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	interval := config.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	archiveAfter := config.ArchiveAfter
+	if archiveAfter <= 0 {
+		archiveAfter = 90 * 24 * time.Hour
+	}
+
+	storageClass := config.StorageClass
+	if storageClass == "" {
+		storageClass = StorageClassGlacier
+	}
+
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	restoreDays := config.RestoreDays
+	if restoreDays <= 0 {
+		restoreDays = 7
+	}
+
+	restoreTier := config.RestoreTier
+	if restoreTier == "" {
+		restoreTier = RestoreTierStandard
+	}
+
+	return &TieringService{
+		repo:         repo,
+		storage:      storage,
+		logger:       logger.With("component", "artifact_tiering"),
+		interval:     interval,
+		archiveAfter: archiveAfter,
+		storageClass: storageClass,
+		batchSize:    batchSize,
+		restoreDays:  restoreDays,
+		restoreTier:  restoreTier,
+		now:          time.Now,
+	}
+}
+
+// Start begins the archival loop until the context is canceled.
+func (s *TieringService) Start(ctx context.Context) {
+	s.logger.Info("starting artifact tiering",
+		"interval", s.interval,
+		"archive_after", s.archiveAfter,
+		"storage_class", s.storageClass,
+	)
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.run(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *TieringService) run(ctx context.Context) {
+	cutoff := s.now().Add(-s.archiveAfter)
+	archived := 0
+
+	for {
+		artifacts, err := s.repo.ListForArchival(ctx, cutoff, s.batchSize)
+		if err != nil {
+			s.logger.Error("failed to list artifacts for archival", "error", err)
+			return
+		}
+		if len(artifacts) == 0 {
+			break
+		}
+
+		failed := 0
+		for _, artifact := range artifacts {
+			if err := s.archive(ctx, artifact); err != nil {
+				s.logger.Warn("failed to archive artifact",
+					"artifact_id", artifact.ID,
+					"path", artifact.Path,
+					"error", err,
+				)
+				failed++
+				continue
+			}
+			archived++
+		}
+
+		// Failed artifacts stay in the STANDARD class and would be listed
+		// again, so stop once a batch makes no progress.
+		if len(artifacts) < s.batchSize || failed == len(artifacts) {
+			break
+		}
+	}
+
+	if archived > 0 {
+		s.logger.Info("artifact archival completed",
+			"archived", archived,
+			"cutoff", cutoff,
+		)
+	}
+}
+
+func (s *TieringService) archive(ctx context.Context, artifact database.Artifact) error {
+	if err := s.storage.Transition(ctx, artifact.Path, s.storageClass); err != nil {
+		return fmt.Errorf("transition storage: %w", err)
+	}
+
+	if err := s.repo.MarkArchived(ctx, artifact.ID, s.storageClass, s.now()); err != nil {
+		return fmt.Errorf("update record: %w", err)
+	}
+
+	return nil
+}
+
+// PrepareDownload reports whether an artifact can be downloaded now. For
+// archived artifacts that have not been restored it starts a restore, if
+// one is not already running, and returns when it is expected to finish.
+func (s *TieringService) PrepareDownload(ctx context.Context, artifact *database.Artifact) (bool, time.Time, error) {
+	if !RequiresRestore(artifact.StorageClass) {
+		return true, time.Time{}, nil
+	}
+
+	now := s.now()
+	if artifact.RestoredUntil != nil && now.Before(*artifact.RestoredUntil) {
+		return true, time.Time{}, nil
+	}
+
+	metadata, err := s.storage.GetMetadata(ctx, artifact.Path)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	if !RequiresRestore(metadata.StorageClass) {
+		return true, time.Time{}, nil
+	}
+
+	if metadata.RestoreOngoing {
+		return false, s.restoreETA(artifact, now), nil
+	}
+
+	if now.Before(metadata.RestoreExpiresAt) {
+		if err := s.repo.MarkRestored(ctx, artifact.ID, metadata.RestoreExpiresAt); err != nil {
+			s.logger.Warn("failed to record artifact restore",
+				"artifact_id", artifact.ID,
+				"error", err,
+			)
+		}
+		return true, time.Time{}, nil
+	}
+
+	if err := s.storage.Restore(ctx, artifact.Path, s.restoreDays, s.restoreTier); err != nil {
+		return false, time.Time{}, err
+	}
+
+	if err := s.repo.MarkRestoreRequested(ctx, artifact.ID, now); err != nil {
+		s.logger.Warn("failed to record artifact restore request",
+			"artifact_id", artifact.ID,
+			"error", err,
+		)
+	}
+
+	s.logger.Info("restoring archived artifact",
+		"artifact_id", artifact.ID,
+		"storage_class", metadata.StorageClass,
+		"tier", s.restoreTier,
+	)
+
+	return false, now.Add(EstimateRestoreDuration(metadata.StorageClass, s.restoreTier)), nil
+}
+
+// restoreETA estimates when an in-progress restore finishes.
+func (s *TieringService) restoreETA(artifact *database.Artifact, now time.Time) time.Time {
+	if artifact.RestoreRequestedAt == nil {
+		return now.Add(EstimateRestoreDuration(artifact.StorageClass, s.restoreTier))
+	}
+
+	eta := artifact.RestoreRequestedAt.Add(EstimateRestoreDuration(artifact.StorageClass, s.restoreTier))
+	if eta.Before(now) {
+		return now
+	}
+	return eta
+}
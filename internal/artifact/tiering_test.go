@@ -0,0 +1,235 @@
+package artifact
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+type fakeArtifactRepo struct {
+	database.ArtifactRepository
+
+	pending          []database.Artifact
+	archived         map[uuid.UUID]string
+	restoreRequested map[uuid.UUID]time.Time
+	restored         map[uuid.UUID]time.Time
+}
+
+func newFakeArtifactRepo(pending ...database.Artifact) *fakeArtifactRepo {
+	return &fakeArtifactRepo{
+		pending:          pending,
+		archived:         make(map[uuid.UUID]string),
+		restoreRequested: make(map[uuid.UUID]time.Time),
+		restored:         make(map[uuid.UUID]time.Time),
+	}
+}
+
+func (r *fakeArtifactRepo) ListForArchival(ctx context.Context, before time.Time, limit int) ([]database.Artifact, error) {
+	var result []database.Artifact
+	for _, a := range r.pending {
+		if _, done := r.archived[a.ID]; !done && a.CreatedAt.Before(before) && len(result) < limit {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeArtifactRepo) MarkArchived(ctx context.Context, id uuid.UUID, storageClass string, at time.Time) error {
+	r.archived[id] = storageClass
+	return nil
+}
+
+func (r *fakeArtifactRepo) MarkRestoreRequested(ctx context.Context, id uuid.UUID, at time.Time) error {
+	r.restoreRequested[id] = at
+	return nil
+}
+
+func (r *fakeArtifactRepo) MarkRestored(ctx context.Context, id uuid.UUID, until time.Time) error {
+	r.restored[id] = until
+	return nil
+}
+
+type fakeArchiveStorage struct {
+	metadata    map[string]*ArtifactMetadata
+	transitions map[string]string
+	restores    []string
+	failPaths   map[string]bool
+}
+
+func newFakeArchiveStorage() *fakeArchiveStorage {
+	return &fakeArchiveStorage{
+		metadata:    make(map[string]*ArtifactMetadata),
+		transitions: make(map[string]string),
+		failPaths:   make(map[string]bool),
+	}
+}
+
+func (s *fakeArchiveStorage) Transition(ctx context.Context, path, storageClass string) error {
+	if s.failPaths[path] {
+		return errors.New("transition failed")
+	}
+	s.transitions[path] = storageClass
+	return nil
+}
+
+func (s *fakeArchiveStorage) Restore(ctx context.Context, path string, days int, tier string) error {
+	s.restores = append(s.restores, path)
+	return nil
+}
+
+func (s *fakeArchiveStorage) GetMetadata(ctx context.Context, path string) (*ArtifactMetadata, error) {
+	if m, ok := s.metadata[path]; ok {
+		return m, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func newTestTieringService(repo *fakeArtifactRepo, storage *fakeArchiveStorage, now time.Time) *TieringService {
+	s := NewTieringService(repo, storage, TieringConfig{
+		ArchiveAfter: 24 * time.Hour,
+		StorageClass: StorageClassGlacier,
+		BatchSize:    2,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestTieringArchivesOldArtifacts(t *testing.T) {
+	now := time.Now()
+	old1 := database.Artifact{ID: uuid.New(), Path: "artifacts/a", CreatedAt: now.Add(-72 * time.Hour)}
+	old2 := database.Artifact{ID: uuid.New(), Path: "artifacts/b", CreatedAt: now.Add(-48 * time.Hour)}
+	old3 := database.Artifact{ID: uuid.New(), Path: "artifacts/c", CreatedAt: now.Add(-36 * time.Hour)}
+	recent := database.Artifact{ID: uuid.New(), Path: "artifacts/d", CreatedAt: now.Add(-time.Hour)}
+
+	repo := newFakeArtifactRepo(old1, old2, old3, recent)
+	storage := newFakeArchiveStorage()
+	s := newTestTieringService(repo, storage, now)
+
+	s.run(context.Background())
+
+	assert.Equal(t, map[string]string{
+		"artifacts/a": StorageClassGlacier,
+		"artifacts/b": StorageClassGlacier,
+		"artifacts/c": StorageClassGlacier,
+	}, storage.transitions)
+	assert.Len(t, repo.archived, 3)
+	assert.NotContains(t, repo.archived, recent.ID)
+}
+
+func TestTieringStopsWhenBatchFails(t *testing.T) {
+	now := time.Now()
+	a := database.Artifact{ID: uuid.New(), Path: "artifacts/a", CreatedAt: now.Add(-72 * time.Hour)}
+	b := database.Artifact{ID: uuid.New(), Path: "artifacts/b", CreatedAt: now.Add(-48 * time.Hour)}
+
+	repo := newFakeArtifactRepo(a, b)
+	storage := newFakeArchiveStorage()
+	storage.failPaths[a.Path] = true
+	storage.failPaths[b.Path] = true
+	s := newTestTieringService(repo, storage, now)
+
+	done := make(chan struct{})
+	go func() {
+		s.run(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not stop on a batch with no progress")
+	}
+	assert.Empty(t, repo.archived)
+}
+
+func TestPrepareDownload(t *testing.T) {
+	now := time.Now()
+
+	t.Run("standard class is ready", func(t *testing.T) {
+		s := newTestTieringService(newFakeArtifactRepo(), newFakeArchiveStorage(), now)
+		ready, _, err := s.PrepareDownload(context.Background(), &database.Artifact{StorageClass: StorageClassStandard})
+		require.NoError(t, err)
+		assert.True(t, ready)
+	})
+
+	t.Run("infrequent access class is ready", func(t *testing.T) {
+		s := newTestTieringService(newFakeArtifactRepo(), newFakeArchiveStorage(), now)
+		ready, _, err := s.PrepareDownload(context.Background(), &database.Artifact{StorageClass: StorageClassStandardIA})
+		require.NoError(t, err)
+		assert.True(t, ready)
+	})
+
+	t.Run("archived starts restore", func(t *testing.T) {
+		repo := newFakeArtifactRepo()
+		storage := newFakeArchiveStorage()
+		storage.metadata["artifacts/a"] = &ArtifactMetadata{StorageClass: StorageClassGlacier}
+		s := newTestTieringService(repo, storage, now)
+
+		artifact := &database.Artifact{ID: uuid.New(), Path: "artifacts/a", StorageClass: StorageClassGlacier}
+		ready, eta, err := s.PrepareDownload(context.Background(), artifact)
+		require.NoError(t, err)
+		assert.False(t, ready)
+		assert.Equal(t, now.Add(5*time.Hour), eta)
+		assert.Equal(t, []string{"artifacts/a"}, storage.restores)
+		assert.Equal(t, now, repo.restoreRequested[artifact.ID])
+	})
+
+	t.Run("restore in progress is not restarted", func(t *testing.T) {
+		storage := newFakeArchiveStorage()
+		storage.metadata["artifacts/a"] = &ArtifactMetadata{StorageClass: StorageClassGlacier, RestoreOngoing: true}
+		s := newTestTieringService(newFakeArtifactRepo(), storage, now)
+
+		requestedAt := now.Add(-time.Hour)
+		ready, eta, err := s.PrepareDownload(context.Background(), &database.Artifact{
+			ID:                 uuid.New(),
+			Path:               "artifacts/a",
+			StorageClass:       StorageClassGlacier,
+			RestoreRequestedAt: &requestedAt,
+		})
+		require.NoError(t, err)
+		assert.False(t, ready)
+		assert.Equal(t, requestedAt.Add(5*time.Hour), eta)
+		assert.Empty(t, storage.restores)
+	})
+
+	t.Run("restored copy is ready", func(t *testing.T) {
+		repo := newFakeArtifactRepo()
+		storage := newFakeArchiveStorage()
+		expires := now.Add(48 * time.Hour)
+		storage.metadata["artifacts/a"] = &ArtifactMetadata{StorageClass: StorageClassGlacier, RestoreExpiresAt: expires}
+		s := newTestTieringService(repo, storage, now)
+
+		artifact := &database.Artifact{ID: uuid.New(), Path: "artifacts/a", StorageClass: StorageClassGlacier}
+		ready, _, err := s.PrepareDownload(context.Background(), artifact)
+		require.NoError(t, err)
+		assert.True(t, ready)
+		assert.Equal(t, expires, repo.restored[artifact.ID])
+	})
+
+	t.Run("recorded restore skips storage lookup", func(t *testing.T) {
+		until := now.Add(time.Hour)
+		s := newTestTieringService(newFakeArtifactRepo(), newFakeArchiveStorage(), now)
+		ready, _, err := s.PrepareDownload(context.Background(), &database.Artifact{
+			Path:          "artifacts/missing",
+			StorageClass:  StorageClassDeepArchive,
+			RestoredUntil: &until,
+		})
+		require.NoError(t, err)
+		assert.True(t, ready)
+	})
+}
+
+func TestEstimateRestoreDuration(t *testing.T) {
+	assert.Equal(t, 5*time.Minute, EstimateRestoreDuration(StorageClassGlacier, RestoreTierExpedited))
+	assert.Equal(t, 5*time.Hour, EstimateRestoreDuration(StorageClassGlacier, RestoreTierStandard))
+	assert.Equal(t, 12*time.Hour, EstimateRestoreDuration(StorageClassDeepArchive, RestoreTierStandard))
+	assert.Equal(t, 48*time.Hour, EstimateRestoreDuration(StorageClassDeepArchive, RestoreTierBulk))
+}
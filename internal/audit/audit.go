@@ -0,0 +1,80 @@
+// Package audit keeps the audit log of mutating API calls: it redacts
+// secrets from recorded payloads and deletes entries past their retention.
+// The calls themselves are recorded by the API servers.
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Redacted replaces the values of sensitive fields.
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are the field name fragments whose values are redacted.
+// Names are compared in lower case without underscores and dashes, so they
+// match both proto and JSON names.
+var sensitiveKeys = []string{
+	"secret",
+	"password",
+	"passwd",
+	"token",
+	"privatekey",
+	"apikey",
+	"authorization",
+	"credential",
+	"webhookurl",
+	"routingkey",
+}
+
+// Redact returns a copy of a JSON document with the values of sensitive
+// fields, at any depth, replaced by Redacted. Documents that are not valid
+// JSON are dropped, since they cannot be checked for secrets.
+func Redact(data []byte) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil
+	}
+
+	redacted, err := json.Marshal(redact(doc))
+	if err != nil {
+		return nil
+	}
+	return redacted
+}
+
+func redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if value != nil && sensitive(key) {
+				v[key] = Redacted
+			} else {
+				v[key] = redact(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = redact(value)
+		}
+	}
+	return v
+}
+
+// sensitive reports whether a field name looks like it holds a secret.
+func sensitive(key string) bool {
+	key = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	for _, fragment := range sensitiveKeys {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// pruneInterval is how often audit logs past their retention are deleted.
+const pruneInterval = time.Hour
+
+// Pruner deletes audit logs older than the retention.
+type Pruner struct {
+	repo      database.AuditLogRepository
+	retention time.Duration
+	logger    *slog.Logger
+	now       func() time.Time
+}
+
+// NewPruner creates a new Pruner. A retention of 0 keeps audit logs forever.
+func NewPruner(repo database.AuditLogRepository, retention time.Duration, logger *slog.Logger) *Pruner {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Pruner{
+		repo:      repo,
+		retention: retention,
+		logger:    logger.With("component", "audit_pruner"),
+		now:       time.Now,
+	}
+}
+
+// Start deletes expired audit logs now and then every hour until the
+// context is cancelled. It does nothing if the retention is 0.
+func (p *Pruner) Start(ctx context.Context) {
+	if p.retention <= 0 {
+		p.logger.Info("audit log retention disabled, keeping audit logs")
+		return
+	}
+
+	p.logger.Info("starting audit log pruner", "retention", p.retention)
+
+	go func() {
+		ticker := time.NewTicker(pruneInterval)
+		defer ticker.Stop()
+
+		for {
+			p.prune(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// prune deletes the audit logs recorded before the retention.
+func (p *Pruner) prune(ctx context.Context) {
+	cutoff := p.now().Add(-p.retention)
+	deleted, err := p.repo.DeleteBefore(ctx, cutoff)
+	if err != nil {
+		p.logger.Error("failed to delete expired audit logs", "error", err)
+		return
+	}
+	if deleted > 0 {
+		p.logger.Info("deleted expired audit logs", "count", deleted, "cutoff", cutoff)
+	}
+}
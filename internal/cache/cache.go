@@ -0,0 +1,145 @@
+// Package cache caches hot reads, such as the service, agent and run
+// lookups of dashboards polling the API, in Redis. Control plane replicas
+// share the cache, so a write on one replica invalidates what the others
+// read.
+//
+// Caching is best effort: reads fall back to the database, and writes still
+// succeed, when Redis is unavailable.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// ErrMiss is returned by a Store for keys it does not hold.
+var ErrMiss = errors.New("cache miss")
+
+// Store holds the cached values.
+type Store interface {
+	// Get returns the value of key, or ErrMiss.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set stores the value of key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Incr increments the counter at key, sets it to expire after ttl, and
+	// returns its new value.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
+
+// Config configures a Cache.
+type Config struct {
+	// TTL bounds how long a value is cached (default: 30s). Writes made
+	// through the cached repositories invalidate what they change; the TTL
+	// bounds the staleness of changes made otherwise, such as within
+	// transactions.
+	TTL time.Duration
+
+	// Prefix is prepended to every key (default: conductor:).
+	Prefix string
+}
+
+// Cache caches values in a Store.
+type Cache struct {
+	store  Store
+	ttl    time.Duration
+	prefix string
+	logger *slog.Logger
+}
+
+// New creates a new Cache.
+func New(store Store, cfg Config, logger *slog.Logger) *Cache {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 30 * time.Second
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "conductor:"
+	}
+	return &Cache{
+		store:  store,
+		ttl:    cfg.TTL,
+		prefix: cfg.Prefix,
+		logger: logger.With("component", "cache"),
+	}
+}
+
+// load returns the cached value of key, or calls fetch and caches what it
+// returns. Errors of fetch are not cached.
+func load[T any](ctx context.Context, c *Cache, key string, fetch func() (T, error)) (T, error) {
+	if data, err := c.get(ctx, key); err == nil {
+		var value T
+		if err := json.Unmarshal(data, &value); err == nil {
+			return value, nil
+		}
+		c.logger.Warn("failed to decode cached value", "key", key)
+	}
+
+	value, err := fetch()
+	if err != nil {
+		return value, err
+	}
+	if data, err := json.Marshal(value); err == nil {
+		c.set(ctx, key, data, c.ttl)
+	}
+	return value, nil
+}
+
+// get returns the value of key.
+func (c *Cache) get(ctx context.Context, key string) ([]byte, error) {
+	data, err := c.store.Get(ctx, c.prefix+key)
+	if err != nil && !errors.Is(err, ErrMiss) {
+		c.logger.Warn("failed to read from cache", "key", key, "error", err)
+	}
+	return data, err
+}
+
+// set caches the value of key for ttl.
+func (c *Cache) set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	if err := c.store.Set(ctx, c.prefix+key, value, ttl); err != nil {
+		c.logger.Warn("failed to write to cache", "key", key, "error", err)
+	}
+}
+
+// generation returns the current generation of a namespace. Keys of the
+// namespace embed it, so that bumping it invalidates them all at once.
+func (c *Cache) generation(ctx context.Context, namespace string) (string, error) {
+	data, err := c.get(ctx, namespace+":gen")
+	switch {
+	case errors.Is(err, ErrMiss):
+		return "0", nil
+	case err != nil:
+		return "", err
+	}
+	return string(data), nil
+}
+
+// invalidate bumps the generation of a namespace, which invalidates its
+// keys. Reads that fetched a value before a write stored it under the old
+// generation, so they cannot resurrect it. The generation outlives the
+// values stored under any of its predecessors, so it can expire and restart
+// from 0 once the namespace is not written to.
+//
+// It uses a context that outlives ctx, so that a write whose request was
+// cancelled after it reached the database still invalidates what it changed.
+func (c *Cache) invalidate(ctx context.Context, namespace string) {
+	if _, err := c.store.Incr(context.WithoutCancel(ctx), c.prefix+namespace+":gen", 2*c.ttl); err != nil {
+		c.logger.Warn("failed to invalidate cache", "namespace", namespace, "error", err)
+	}
+}
+
+// loadIn is like load for a key of a namespace. Values are fetched without
+// being cached while the generation of the namespace cannot be read.
+func loadIn[T any](ctx context.Context, c *Cache, namespace, key string, fetch func() (T, error)) (T, error) {
+	gen, err := c.generation(ctx, namespace)
+	if err != nil {
+		return fetch()
+	}
+	return load(ctx, c, namespace+":"+gen+":"+key, fetch)
+}
@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is a Store in memory. Values do not expire.
+type memoryStore struct {
+	mu     sync.Mutex
+	values map[string][]byte
+	err    error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{values: make(map[string][]byte)}
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	value, ok := s.values[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	return value, nil
+}
+
+func (s *memoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.values[key] = value
+	return nil
+}
+
+func (s *memoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return 0, s.err
+	}
+	n, _ := strconv.ParseInt(string(s.values[key]), 10, 64)
+	n++
+	s.values[key] = []byte(strconv.FormatInt(n, 10))
+	return n, nil
+}
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	c := New(store, Config{}, nil)
+
+	fetches := 0
+	fetch := func() (string, error) {
+		fetches++
+		return "value-" + strconv.Itoa(fetches), nil
+	}
+
+	t.Run("caches values until their namespace is invalidated", func(t *testing.T) {
+		value, err := loadIn(ctx, c, "ns", "key", fetch)
+		require.NoError(t, err)
+		assert.Equal(t, "value-1", value)
+
+		value, err = loadIn(ctx, c, "ns", "key", fetch)
+		require.NoError(t, err)
+		assert.Equal(t, "value-1", value)
+
+		c.invalidate(ctx, "ns")
+		value, err = loadIn(ctx, c, "ns", "key", fetch)
+		require.NoError(t, err)
+		assert.Equal(t, "value-2", value)
+	})
+
+	t.Run("reads during a write do not cache what it changed", func(t *testing.T) {
+		value, err := loadIn(ctx, c, "race", "key", func() (string, error) {
+			// The value is written and invalidated while it is fetched
+			c.invalidate(ctx, "race")
+			return "stale", nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "stale", value)
+
+		value, err = loadIn(ctx, c, "race", "key", func() (string, error) { return "fresh", nil })
+		require.NoError(t, err)
+		assert.Equal(t, "fresh", value)
+	})
+
+	t.Run("does not cache errors", func(t *testing.T) {
+		_, err := loadIn(ctx, c, "errors", "key", func() (string, error) { return "", assert.AnError })
+		assert.ErrorIs(t, err, assert.AnError)
+
+		value, err := loadIn(ctx, c, "errors", "key", func() (string, error) { return "ok", nil })
+		require.NoError(t, err)
+		assert.Equal(t, "ok", value)
+	})
+
+	t.Run("reads through while the store fails", func(t *testing.T) {
+		store.err = errors.New("connection refused")
+		defer func() { store.err = nil }()
+
+		before := fetches
+		value, err := loadIn(ctx, c, "ns", "key", fetch)
+		require.NoError(t, err)
+		assert.Equal(t, "value-"+strconv.Itoa(before+1), value)
+		c.invalidate(ctx, "ns")
+	})
+}
+
+func TestResponses(t *testing.T) {
+	ctx := context.Background()
+	c := New(newMemoryStore(), Config{TTL: time.Minute}, nil)
+	responses := c.Responses(time.Hour)
+	assert.Equal(t, time.Minute, responses.ttl, "responses are not cached longer than values")
+
+	fetch := func(body string) func() ([]byte, error) {
+		return func() ([]byte, error) { return []byte(body), nil }
+	}
+	data, err := responses.Load(ctx, "key", fetch("first"))
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(data))
+
+	data, err = responses.Load(ctx, "key", fetch("second"))
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(data))
+
+	responses.Invalidate(ctx)
+	data, err = responses.Load(ctx, "key", fetch("third"))
+	require.NoError(t, err)
+	assert.Equal(t, "third", string(data))
+}
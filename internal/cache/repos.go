@@ -0,0 +1,301 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// agentsNamespace holds the cached agent lists.
+const agentsNamespace = "agents"
+
+// scopeKey identifies the project scope of ctx in keys, as cached values
+// are only valid for the scope they were read in.
+func scopeKey(ctx context.Context) string {
+	ids, ok := database.ProjectScope(ctx)
+	if !ok {
+		return "*"
+	}
+	scope := make([]string, len(ids))
+	for i, id := range ids {
+		scope[i] = id.String()
+	}
+	slices.Sort(scope)
+	return strings.Join(scope, ",")
+}
+
+// pageKey identifies a page in keys.
+func pageKey(page database.Pagination) string {
+	key := strconv.Itoa(page.Limit) + ":" + strconv.Itoa(page.Offset)
+	if after := page.After; after != nil {
+		key += ":" + after.ID.String() + ":" + after.Name
+	}
+	return key
+}
+
+// serviceRepo caches services by ID.
+type serviceRepo struct {
+	database.ServiceRepository
+	cache *Cache
+}
+
+// Services caches the services repo looks up by ID. Services are cached
+// whatever the project scope they were read in and checked against the
+// scope of each lookup.
+func Services(repo database.ServiceRepository, c *Cache) database.ServiceRepository {
+	return &serviceRepo{ServiceRepository: repo, cache: c}
+}
+
+func serviceNamespace(id uuid.UUID) string {
+	return "service:" + id.String()
+}
+
+// Get retrieves a service by ID.
+func (r *serviceRepo) Get(ctx context.Context, id uuid.UUID) (*database.Service, error) {
+	svc, err := loadIn(ctx, r.cache, serviceNamespace(id), "v", func() (*database.Service, error) {
+		return r.ServiceRepository.Get(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !database.InProjectScope(ctx, svc.ProjectID) {
+		return nil, database.ErrNotFound
+	}
+	return svc, nil
+}
+
+// Update updates an existing service.
+func (r *serviceRepo) Update(ctx context.Context, svc *database.Service) error {
+	if err := r.ServiceRepository.Update(ctx, svc); err != nil {
+		return err
+	}
+	r.cache.invalidate(ctx, serviceNamespace(svc.ID))
+	return nil
+}
+
+// Delete deletes a service by ID.
+func (r *serviceRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.ServiceRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.cache.invalidate(ctx, serviceNamespace(id))
+	return nil
+}
+
+// agentRepo caches agent lists.
+type agentRepo struct {
+	database.AgentRepository
+	cache *Cache
+
+	// statuses are the statuses agents last reported in heartbeats.
+	statuses sync.Map
+}
+
+// Agents caches the agent lists of repo. Heartbeats only invalidate the
+// lists when they change the status of an agent; the heartbeat times of
+// listed agents are up to the cache TTL old.
+func Agents(repo database.AgentRepository, c *Cache) database.AgentRepository {
+	return &agentRepo{AgentRepository: repo, cache: c}
+}
+
+// List returns agents with pagination.
+func (r *agentRepo) List(ctx context.Context, page database.Pagination) ([]database.Agent, error) {
+	key := "list:" + scopeKey(ctx) + ":" + pageKey(page)
+	return loadIn(ctx, r.cache, agentsNamespace, key, func() ([]database.Agent, error) {
+		return r.AgentRepository.List(ctx, page)
+	})
+}
+
+// ListByStatus returns agents with a specific status.
+func (r *agentRepo) ListByStatus(ctx context.Context, status database.AgentStatus, page database.Pagination) ([]database.Agent, error) {
+	key := "status:" + string(status) + ":" + scopeKey(ctx) + ":" + pageKey(page)
+	return loadIn(ctx, r.cache, agentsNamespace, key, func() ([]database.Agent, error) {
+		return r.AgentRepository.ListByStatus(ctx, status, page)
+	})
+}
+
+// Create creates a new agent.
+func (r *agentRepo) Create(ctx context.Context, agent *database.Agent) error {
+	if err := r.AgentRepository.Create(ctx, agent); err != nil {
+		return err
+	}
+	r.cache.invalidate(ctx, agentsNamespace)
+	return nil
+}
+
+// Update updates an agent.
+func (r *agentRepo) Update(ctx context.Context, agent *database.Agent) error {
+	if err := r.AgentRepository.Update(ctx, agent); err != nil {
+		return err
+	}
+	r.statuses.Delete(agent.ID)
+	r.cache.invalidate(ctx, agentsNamespace)
+	return nil
+}
+
+// Delete deletes an agent.
+func (r *agentRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.AgentRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.statuses.Delete(id)
+	r.cache.invalidate(ctx, agentsNamespace)
+	return nil
+}
+
+// UpdateStatus updates only the agent's status.
+func (r *agentRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status database.AgentStatus) error {
+	if err := r.AgentRepository.UpdateStatus(ctx, id, status); err != nil {
+		return err
+	}
+	r.statuses.Delete(id)
+	r.cache.invalidate(ctx, agentsNamespace)
+	return nil
+}
+
+// UpdateHeartbeat updates the agent's heartbeat time and status.
+func (r *agentRepo) UpdateHeartbeat(ctx context.Context, id uuid.UUID, status database.AgentStatus) error {
+	if err := r.AgentRepository.UpdateHeartbeat(ctx, id, status); err != nil {
+		return err
+	}
+	if last, ok := r.statuses.Swap(id, status); !ok || last != status {
+		r.cache.invalidate(ctx, agentsNamespace)
+	}
+	return nil
+}
+
+// MarkOfflineAgents marks agents as offline whose last heartbeat is older
+// than timeout and returns them.
+func (r *agentRepo) MarkOfflineAgents(ctx context.Context, timeout time.Duration) ([]database.Agent, error) {
+	agents, err := r.AgentRepository.MarkOfflineAgents(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if len(agents) > 0 {
+		for _, agent := range agents {
+			r.statuses.Delete(agent.ID)
+		}
+		r.cache.invalidate(ctx, agentsNamespace)
+	}
+	return agents, nil
+}
+
+// runRepo caches runs by ID.
+type runRepo struct {
+	database.TestRunRepository
+	services database.ServiceRepository
+	cache    *Cache
+}
+
+// Runs caches the runs repo looks up by ID, such as those whose status
+// dashboards poll. Runs are cached whatever the project scope they were
+// read in; a run is found in the scopes its service is found in by
+// services.
+func Runs(repo database.TestRunRepository, services database.ServiceRepository, c *Cache) database.TestRunRepository {
+	return &runRepo{TestRunRepository: repo, services: services, cache: c}
+}
+
+func runNamespace(id uuid.UUID) string {
+	return "run:" + id.String()
+}
+
+// Get retrieves a test run by ID.
+func (r *runRepo) Get(ctx context.Context, id uuid.UUID) (*database.TestRun, error) {
+	fetched := false
+	run, err := loadIn(ctx, r.cache, runNamespace(id), "v", func() (*database.TestRun, error) {
+		fetched = true
+		return r.TestRunRepository.Get(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, scoped := database.ProjectScope(ctx); scoped && !fetched {
+		if _, err := r.services.Get(ctx, run.ServiceID); err != nil {
+			if database.IsNotFound(err) {
+				return nil, database.ErrNotFound
+			}
+			return nil, err
+		}
+	}
+	return run, nil
+}
+
+// Update updates a test run.
+func (r *runRepo) Update(ctx context.Context, run *database.TestRun) error {
+	defer r.cache.invalidate(ctx, runNamespace(run.ID))
+	return r.TestRunRepository.Update(ctx, run)
+}
+
+// UpdateStatus updates only the run's status.
+func (r *runRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status database.RunStatus) error {
+	defer r.cache.invalidate(ctx, runNamespace(id))
+	return r.TestRunRepository.UpdateStatus(ctx, id, status)
+}
+
+// Approve releases a run waiting for approval.
+func (r *runRepo) Approve(ctx context.Context, id uuid.UUID, approvedBy string) error {
+	defer r.cache.invalidate(ctx, runNamespace(id))
+	return r.TestRunRepository.Approve(ctx, id, approvedBy)
+}
+
+// Annotate merges annotations and links into the metadata of a run.
+func (r *runRepo) Annotate(ctx context.Context, id uuid.UUID, metadata database.RunMetadata) (*database.RunMetadata, error) {
+	defer r.cache.invalidate(ctx, runNamespace(id))
+	return r.TestRunRepository.Annotate(ctx, id, metadata)
+}
+
+// Retarget moves a pending run to another commit.
+func (r *runRepo) Retarget(ctx context.Context, id uuid.UUID, gitSHA *string) error {
+	defer r.cache.invalidate(ctx, runNamespace(id))
+	return r.TestRunRepository.Retarget(ctx, id, gitSHA)
+}
+
+// Start marks a run as started with the given agent.
+func (r *runRepo) Start(ctx context.Context, id uuid.UUID, agentID uuid.UUID) error {
+	defer r.cache.invalidate(ctx, runNamespace(id))
+	return r.TestRunRepository.Start(ctx, id, agentID)
+}
+
+// Finish marks a run as finished with results.
+func (r *runRepo) Finish(ctx context.Context, id uuid.UUID, status database.RunStatus, results database.RunResults) error {
+	defer r.cache.invalidate(ctx, runNamespace(id))
+	return r.TestRunRepository.Finish(ctx, id, status, results)
+}
+
+// FinishActive marks a pending or running run as finished with results.
+func (r *runRepo) FinishActive(ctx context.Context, id uuid.UUID, status database.RunStatus, results database.RunResults) error {
+	defer r.cache.invalidate(ctx, runNamespace(id))
+	return r.TestRunRepository.FinishActive(ctx, id, status, results)
+}
+
+// UpdateShardStats updates shard completion and result counts.
+func (r *runRepo) UpdateShardStats(ctx context.Context, id uuid.UUID, completed int, failed int, results database.RunResults) error {
+	defer r.cache.invalidate(ctx, runNamespace(id))
+	return r.TestRunRepository.UpdateShardStats(ctx, id, completed, failed, results)
+}
+
+// ReserveResults accepts up to n results for the run without exceeding
+// limit stored results.
+func (r *runRepo) ReserveResults(ctx context.Context, id uuid.UUID, n, limit int) (int, int64, error) {
+	accepted, dropped, err := r.TestRunRepository.ReserveResults(ctx, id, n, limit)
+	if err == nil && accepted < n {
+		r.cache.invalidate(ctx, runNamespace(id))
+	}
+	return accepted, dropped, err
+}
+
+// ReserveArtifacts is like ReserveResults for artifacts.
+func (r *runRepo) ReserveArtifacts(ctx context.Context, id uuid.UUID, n, limit int) (int, int64, error) {
+	accepted, dropped, err := r.TestRunRepository.ReserveArtifacts(ctx, id, n, limit)
+	if err == nil && accepted < n {
+		r.cache.invalidate(ctx, runNamespace(id))
+	}
+	return accepted, dropped, err
+}
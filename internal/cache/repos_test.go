@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// fakeServiceRepo counts the services it looks up.
+type fakeServiceRepo struct {
+	database.ServiceRepository
+	services map[uuid.UUID]database.Service
+	gets     int
+}
+
+func (r *fakeServiceRepo) Get(ctx context.Context, id uuid.UUID) (*database.Service, error) {
+	r.gets++
+	svc, ok := r.services[id]
+	if !ok || !database.InProjectScope(ctx, svc.ProjectID) {
+		return nil, database.ErrNotFound
+	}
+	return &svc, nil
+}
+
+func (r *fakeServiceRepo) Update(ctx context.Context, svc *database.Service) error {
+	r.services[svc.ID] = *svc
+	return nil
+}
+
+// fakeAgentRepo counts the agent lists it reads.
+type fakeAgentRepo struct {
+	database.AgentRepository
+	agents []database.Agent
+	lists  int
+}
+
+func (r *fakeAgentRepo) List(ctx context.Context, page database.Pagination) ([]database.Agent, error) {
+	r.lists++
+	return append([]database.Agent(nil), r.agents...), nil
+}
+
+func (r *fakeAgentRepo) UpdateHeartbeat(ctx context.Context, id uuid.UUID, status database.AgentStatus) error {
+	for i := range r.agents {
+		if r.agents[i].ID == id {
+			r.agents[i].Status = status
+		}
+	}
+	return nil
+}
+
+// fakeRunRepo counts the runs it looks up.
+type fakeRunRepo struct {
+	database.TestRunRepository
+	runs map[uuid.UUID]database.TestRun
+	gets int
+}
+
+func (r *fakeRunRepo) Get(ctx context.Context, id uuid.UUID) (*database.TestRun, error) {
+	r.gets++
+	run, ok := r.runs[id]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	return &run, nil
+}
+
+func (r *fakeRunRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status database.RunStatus) error {
+	run := r.runs[id]
+	run.Status = status
+	r.runs[id] = run
+	return nil
+}
+
+func TestServices(t *testing.T) {
+	ctx := context.Background()
+	projectID := uuid.New()
+	svc := database.Service{ID: uuid.New(), Name: "api", ProjectID: projectID}
+	repo := &fakeServiceRepo{services: map[uuid.UUID]database.Service{svc.ID: svc}}
+	services := Services(repo, New(newMemoryStore(), Config{}, nil))
+
+	got, err := services.Get(ctx, svc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "api", got.Name)
+	_, err = services.Get(ctx, svc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.gets)
+
+	t.Run("checks the scope of cached services", func(t *testing.T) {
+		_, err := services.Get(database.WithProjectScope(ctx, []uuid.UUID{uuid.New()}), svc.ID)
+		assert.ErrorIs(t, err, database.ErrNotFound)
+
+		_, err = services.Get(database.WithProjectScope(ctx, []uuid.UUID{projectID}), svc.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, repo.gets)
+	})
+
+	t.Run("updates invalidate the service", func(t *testing.T) {
+		updated := svc
+		updated.Name = "gateway"
+		require.NoError(t, services.Update(ctx, &updated))
+
+		got, err := services.Get(ctx, svc.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "gateway", got.Name)
+		assert.Equal(t, 2, repo.gets)
+	})
+}
+
+func TestAgents(t *testing.T) {
+	ctx := context.Background()
+	agent := database.Agent{ID: uuid.New(), Name: "agent-1", Status: database.AgentStatusIdle}
+	repo := &fakeAgentRepo{agents: []database.Agent{agent}}
+	agents := Agents(repo, New(newMemoryStore(), Config{TTL: time.Minute}, nil))
+	page := database.Pagination{Limit: 10}
+
+	list := func() []database.Agent {
+		t.Helper()
+		got, err := agents.List(ctx, page)
+		require.NoError(t, err)
+		return got
+	}
+
+	// The first heartbeat of an agent invalidates, as its last status is
+	// not known
+	require.NoError(t, agents.UpdateHeartbeat(ctx, agent.ID, database.AgentStatusIdle))
+	list()
+	list()
+	assert.Equal(t, 1, repo.lists)
+
+	t.Run("scopes are cached separately", func(t *testing.T) {
+		_, err := agents.List(database.WithProjectScope(ctx, []uuid.UUID{uuid.New()}), page)
+		require.NoError(t, err)
+		assert.Equal(t, 2, repo.lists)
+	})
+
+	t.Run("heartbeats only invalidate status changes", func(t *testing.T) {
+		lists := repo.lists
+		require.NoError(t, agents.UpdateHeartbeat(ctx, agent.ID, database.AgentStatusIdle))
+		list()
+		assert.Equal(t, lists, repo.lists)
+
+		require.NoError(t, agents.UpdateHeartbeat(ctx, agent.ID, database.AgentStatusBusy))
+		got := list()
+		assert.Equal(t, lists+1, repo.lists)
+		require.Len(t, got, 1)
+		assert.Equal(t, database.AgentStatusBusy, got[0].Status)
+	})
+}
+
+func TestRuns(t *testing.T) {
+	ctx := context.Background()
+	projectID := uuid.New()
+	svc := database.Service{ID: uuid.New(), Name: "api", ProjectID: projectID}
+	run := database.TestRun{ID: uuid.New(), ServiceID: svc.ID, Status: database.RunStatusRunning}
+
+	c := New(newMemoryStore(), Config{}, nil)
+	serviceRepo := &fakeServiceRepo{services: map[uuid.UUID]database.Service{svc.ID: svc}}
+	repo := &fakeRunRepo{runs: map[uuid.UUID]database.TestRun{run.ID: run}}
+	runs := Runs(repo, Services(serviceRepo, c), c)
+
+	_, err := runs.Get(ctx, run.ID)
+	require.NoError(t, err)
+	_, err = runs.Get(ctx, run.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.gets)
+
+	t.Run("checks the scope of cached runs", func(t *testing.T) {
+		_, err := runs.Get(database.WithProjectScope(ctx, []uuid.UUID{uuid.New()}), run.ID)
+		assert.ErrorIs(t, err, database.ErrNotFound)
+
+		_, err = runs.Get(database.WithProjectScope(ctx, []uuid.UUID{projectID}), run.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, repo.gets)
+	})
+
+	t.Run("status updates invalidate the run", func(t *testing.T) {
+		require.NoError(t, runs.UpdateStatus(ctx, run.ID, database.RunStatusPassed))
+
+		got, err := runs.Get(ctx, run.ID)
+		require.NoError(t, err)
+		assert.Equal(t, database.RunStatusPassed, got.Status)
+		assert.Equal(t, 2, repo.gets)
+	})
+}
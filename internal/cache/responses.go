@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// responsesNamespace holds the cached responses of the HTTP gateway.
+const responsesNamespace = "http"
+
+// Responses caches the responses of the HTTP gateway.
+type Responses struct {
+	cache *Cache
+	ttl   time.Duration
+}
+
+// Responses returns a cache of HTTP responses that keeps them for ttl, at
+// most the TTL of c.
+func (c *Cache) Responses(ttl time.Duration) *Responses {
+	if ttl <= 0 || ttl > c.ttl {
+		ttl = c.ttl
+	}
+	return &Responses{cache: c, ttl: ttl}
+}
+
+// Load returns the cached response of key, or calls fetch and caches the
+// response it returns. Errors of fetch are not cached.
+func (r *Responses) Load(ctx context.Context, key string, fetch func() ([]byte, error)) ([]byte, error) {
+	gen, err := r.cache.generation(ctx, responsesNamespace)
+	if err != nil {
+		return fetch()
+	}
+	key = responsesNamespace + ":" + gen + ":" + key
+	if data, err := r.cache.get(ctx, key); err == nil {
+		return data, nil
+	}
+
+	data, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	r.cache.set(ctx, key, data, r.ttl)
+	return data, nil
+}
+
+// Invalidate drops every cached response.
+func (r *Responses) Invalidate(ctx context.Context) {
+	r.cache.invalidate(ctx, responsesNamespace)
+}
@@ -1,14 +1,21 @@
 // Package config provides configuration management for the Conductor control plane.
-// Configuration is loaded from environment variables with the CONDUCTOR_ prefix.
+// Configuration is loaded from environment variables with the CONDUCTOR_ prefix,
+// and from an optional YAML config file named by CONDUCTOR_CONFIG_FILE.
 package config
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+
+	conductorlog "github.com/conductor/conductor/pkg/log"
 )
 
 // Config holds all configuration settings for the control plane.
@@ -21,9 +28,29 @@ type Config struct {
 	Agent         AgentConfig
 	Git           GitConfig
 	Webhook       WebhookConfig
+	Trigger       TriggerConfig
+	RateLimit     RateLimitConfig
+	Ingestion     IngestionConfig
+	Energy        EnergyConfig
+	Preflight     PreflightConfig
 	Notifications NotificationConfig
+	Reports       ReportsConfig
+	Regressions   RegressionsConfig
+	EventWebhooks EventWebhooksConfig
+	Events        EventsConfig
+	Audit         AuditConfig
+	Results       ResultsConfig
+	Leader        LeaderConfig
+	Replica       ReplicaConfig
 	Log           LogConfig
 	Observability ObservabilityConfig
+
+	// File is the config file settings were read from, if any
+	File string
+	// settings holds the value of every setting by environment variable
+	settings map[string]string
+	// fileInfo describes File as it was read
+	fileInfo os.FileInfo
 }
 
 // ServerConfig holds HTTP, gRPC, and metrics server settings.
@@ -36,6 +63,19 @@ type ServerConfig struct {
 	MetricsPort int
 	// ShutdownTimeout is the graceful shutdown timeout (default: 30s)
 	ShutdownTimeout time.Duration
+	// DrainTimeout is how long shutdown waits for connected agents to move
+	// their work streams to another replica before stopping; 0 disables
+	// draining (default: 20s)
+	DrainTimeout time.Duration
+	// DrainJitter is the maximum delay before a draining agent reconnects,
+	// which spreads the agents over the remaining replicas (default: 5s)
+	DrainJitter time.Duration
+	// UIEnabled serves the embedded web UI on the HTTP port (default: true)
+	UIEnabled bool
+	// UIPath is the path the embedded web UI is served under (default: /ui)
+	UIPath string
+	// APIDocsEnabled serves the OpenAPI description and API explorer under /api/docs (default: true)
+	APIDocsEnabled bool
 }
 
 // DatabaseConfig holds PostgreSQL connection settings.
@@ -52,6 +92,17 @@ type DatabaseConfig struct {
 	ConnMaxIdleTime time.Duration
 	// QueryTimeout is the default query timeout (default: 30s)
 	QueryTimeout time.Duration
+	// SlowQueryThreshold logs queries taking at least this long; 0 disables
+	// slow query logging (default: 1s)
+	SlowQueryThreshold time.Duration
+	// ReplicaURL is the connection string of a read replica serving the
+	// read-only API calls; empty disables it (optional)
+	ReplicaURL string
+	// ReplicaMaxLag is the replication lag above which reads fall back to the
+	// primary (default: 10s)
+	ReplicaMaxLag time.Duration
+	// ReplicaCheckInterval is how often the replication lag is checked (default: 5s)
+	ReplicaCheckInterval time.Duration
 }
 
 // StorageConfig holds S3/MinIO artifact storage settings.
@@ -78,6 +129,26 @@ type StorageConfig struct {
 	RetentionPeriod time.Duration
 	// CleanupBatchSize limits artifacts deleted per run (default: 100)
 	CleanupBatchSize int
+	// BlobGracePeriod is how long deduplicated blobs no artifact holds are kept (default: 24h)
+	BlobGracePeriod time.Duration
+	// DownloadProxy serves artifact downloads through the control plane
+	// instead of presigned storage URLs (default: false)
+	DownloadProxy bool
+	// DownloadProxyURL is the external URL of the control plane proxied
+	// download URLs point to; they are relative if empty (default: "")
+	DownloadProxyURL string
+	// ArchiveEnabled enables transitioning old artifacts to a cheaper storage class (default: false)
+	ArchiveEnabled bool
+	// ArchiveAfter is the artifact age at which it is archived (default: 90d)
+	ArchiveAfter time.Duration
+	// ArchiveStorageClass is the storage class artifacts are archived to (default: GLACIER)
+	ArchiveStorageClass string
+	// ArchiveInterval is how often to look for artifacts to archive (default: 1h)
+	ArchiveInterval time.Duration
+	// RestoreDays is how long a restored copy of an archived artifact is kept (default: 7)
+	RestoreDays int
+	// RestoreTier is the retrieval tier used for restores (default: Standard)
+	RestoreTier string
 }
 
 // RedisConfig holds Redis connection settings.
@@ -94,6 +165,12 @@ type RedisConfig struct {
 	ReadTimeout time.Duration
 	// WriteTimeout is the write timeout (default: 3s)
 	WriteTimeout time.Duration
+	// CacheTTL bounds how long services, agent lists and runs are cached;
+	// writes invalidate them earlier (default: 30s)
+	CacheTTL time.Duration
+	// ResponseCacheTTL is how long the HTTP gateway caches the responses of
+	// GET requests, at most CacheTTL; 0 disables it (default: 5s)
+	ResponseCacheTTL time.Duration
 }
 
 // AuthConfig holds authentication and authorization settings.
@@ -122,8 +199,12 @@ type AgentConfig struct {
 	DefaultTestTimeout time.Duration
 	// MaxTestTimeout is the maximum allowed test timeout (default: 4h)
 	MaxTestTimeout time.Duration
-	// ResultStreamBufferSize is the buffer size for result streaming (default: 100)
+	// ResultStreamBufferSize is how many streamed test results are buffered
+	// and stored at once (default: 100)
 	ResultStreamBufferSize int
+	// ResultStreamFlushInterval is how long a streamed test result waits for
+	// its batch to fill up before it is stored (default: 1s)
+	ResultStreamFlushInterval time.Duration
 }
 
 // GitConfig holds git provider settings.
@@ -140,12 +221,37 @@ type GitConfig struct {
 	GitLabWebhookSecret string
 	// BitbucketWebhookSecret is the secret for Bitbucket webhooks
 	BitbucketWebhookSecret string
+	// GiteaWebhookSecret is the secret for Gitea and Forgejo webhooks
+	GiteaWebhookSecret string
+	// AzureDevOpsWebhookSecret is the basic auth password or bearer token
+	// configured on Azure DevOps service hooks
+	AzureDevOpsWebhookSecret string
 	// AppID is the GitHub App ID (optional, for app authentication)
 	AppID int64
 	// AppPrivateKeyPath is the path to the GitHub App private key file
 	AppPrivateKeyPath string
 	// AppInstallationID is the GitHub App installation ID
 	AppInstallationID int64
+	// DeployKeyEncryptionKey is the base64-encoded 32-byte key used to encrypt
+	// service deploy keys at rest (optional, enables deploy keys if set)
+	DeployKeyEncryptionKey string
+	// StatusEnabled reports finished pull request runs as commit statuses (default: true)
+	StatusEnabled bool
+	// StatusContext is the name of the commit status (default: conductor)
+	StatusContext string
+	// StatusRetryAttempts is how many times a status report is attempted (default: 5)
+	StatusRetryAttempts int
+	// StatusRetryDelay is the initial delay between status report attempts (default: 10s)
+	StatusRetryDelay time.Duration
+	// CheckRunsEnabled reports pull request runs as GitHub check runs with
+	// failure annotations; requires GitHub App credentials (default: false)
+	CheckRunsEnabled bool
+	// SyncInterval is how often test definitions are re-synced from each
+	// service repository; services may override it, 0 disables (default: 0)
+	SyncInterval time.Duration
+	// HealthCheckEnabled checks that the git provider's API is reachable when
+	// all dependencies are checked for readiness (default: false)
+	HealthCheckEnabled bool
 }
 
 // WebhookConfig holds configuration for webhook handling.
@@ -154,11 +260,274 @@ type WebhookConfig struct {
 	Enabled bool
 	// BaseURL is the external URL for the control plane (used in status URLs)
 	BaseURL string
+	// Strict rejects unauthenticated deliveries and deliveries without a
+	// delivery ID, and detects replays by payload digest (default: false)
+	Strict bool
+	// TimestampTolerance is the maximum age or clock skew of deliveries that
+	// carry a send time; 0 disables the check (default: 5m)
+	TimestampTolerance time.Duration
+	// ReplayWindow is how long delivery IDs are remembered to reject
+	// replays; 0 disables replay protection (default: 24h)
+	ReplayWindow time.Duration
+}
+
+// TriggerConfig holds run trigger settings.
+type TriggerConfig struct {
+	// ThrottleWindow is the period over which runs per service and branch
+	// are limited; pushes beyond the limit are coalesced into one run of the
+	// latest commit. 0 disables throttling (default: 0)
+	ThrottleWindow time.Duration
+	// ThrottleRuns is the maximum number of runs per service and branch
+	// within ThrottleWindow (default: 1)
+	ThrottleRuns int
+	// IdempotencyTTL is how long the run created by a request with an
+	// idempotency key, or by a webhook delivery, is returned to retries of
+	// it instead of creating another run (default: 24h)
+	IdempotencyTTL time.Duration
+}
+
+// RateLimitConfig holds API rate limits. Each client, identified by its
+// token or else its IP address, has a token bucket for the HTTP and gRPC
+// APIs; each webhook sender IP has one for webhooks.
+type RateLimitConfig struct {
+	// Enabled enables rate limiting (default: true)
+	Enabled bool
+	// RequestsPerSecond is the rate at which a client's bucket refills (default: 50)
+	RequestsPerSecond float64
+	// Burst is the size of a client's bucket (default: 100)
+	Burst int
+	// WebhookRequestsPerSecond is the rate at which a webhook sender's
+	// bucket refills (default: 20)
+	WebhookRequestsPerSecond float64
+	// WebhookBurst is the size of a webhook sender's bucket (default: 100)
+	WebhookBurst int
+}
+
+// IngestionConfig holds limits on what a single run may store.
+type IngestionConfig struct {
+	// MaxResultsPerRun caps the test results stored per run; further results
+	// are dropped and counted on the run. 0 disables the cap (default: 1000000)
+	MaxResultsPerRun int
+	// MaxArtifactsPerRun caps the artifacts recorded per run; 0 disables the
+	// cap (default: 10000)
+	MaxArtifactsPerRun int
+	// MaxArtifactBytes caps the size of a single artifact. Agents learn the
+	// limit when they register and skip larger artifacts; 0 disables the cap
+	// (default: 1 GiB)
+	MaxArtifactBytes int64
+}
+
+// EnergyConfig holds the model used to estimate the energy and carbon
+// emissions of runs from agent resource usage.
+type EnergyConfig struct {
+	// Enabled records energy samples of runs from agent heartbeats (default: true)
+	Enabled bool
+	// WattsPerCore is the power of a fully utilized CPU core (default: 10)
+	WattsPerCore float64
+	// WattsPerMemoryGB is the power of one GiB of memory in use (default: 0.375)
+	WattsPerMemoryGB float64
+	// CarbonIntensity is the grid carbon intensity in gCO2e/kWh for zones
+	// without a factor of their own (default: 475)
+	CarbonIntensity float64
+	// ZoneCarbonIntensity maps network zones to their grid carbon intensity
+	// in gCO2e/kWh, e.g. "eu-north=40,us-east=380"
+	ZoneCarbonIntensity map[string]float64
+}
+
+// PreflightConfig holds the checks run when a run is created, before it is
+// queued. Commits are checked through the git provider credentials; secrets
+// only for the providers configured here.
+type PreflightConfig struct {
+	// Enabled runs pre-flight checks on new runs (default: false)
+	Enabled bool
+	// Timeout bounds all checks of a run (default: 15s)
+	Timeout time.Duration
+	// CheckImages verifies that container images exist in their registry
+	// (default: true)
+	CheckImages bool
+	// SecretsProvider is the provider of secret references that name none,
+	// e.g. vault or aws
+	SecretsProvider string
+	// VaultAddress enables checking Vault secrets
+	VaultAddress string
+	// VaultToken is the Vault token used to read secrets
+	VaultToken string
+	// VaultNamespace is the optional Vault namespace
+	VaultNamespace string
+	// VaultMount is the KV mount path (default: secret)
+	VaultMount string
+	// AWSRegion enables checking AWS Secrets Manager secrets in the region,
+	// using the standard AWS credential environment variables
+	AWSRegion string
+	// AWSEndpoint overrides the Secrets Manager endpoint
+	AWSEndpoint string
 }
 
 // NotificationConfig holds notification-related settings.
 type NotificationConfig struct {
 	Email EmailConfig
+	// DedupWindow is how long a delivered (event, channel) pair suppresses repeats (default: 10m)
+	DedupWindow time.Duration
+	// CollapseRules sends one message per channel listing all matched rules (default: false)
+	CollapseRules bool
+	// DeadLetterInterval is how often undelivered notifications are redelivered; 0 disables (default: 1m)
+	DeadLetterInterval time.Duration
+	// DeadLetterMaxAttempts is how many automatic redeliveries an undelivered notification gets (default: 5)
+	DeadLetterMaxAttempts int
+	// DurationAnomaly notifies when passed runs take unusually long or short
+	DurationAnomaly DurationAnomalyConfig
+}
+
+// ReportsConfig holds service report settings.
+type ReportsConfig struct {
+	// RefreshInterval is how often the statistics reports are computed from
+	// are refreshed; 0 disables refreshing (default: 5m)
+	RefreshInterval time.Duration
+}
+
+// RegressionsConfig holds the thresholds of duration regression detection.
+// A run or test regressed when its duration is at least Sigma standard
+// deviations and the minimum delta above the mean of the previous passed runs
+// of its service branch.
+type RegressionsConfig struct {
+	// Enabled records regressions of finished runs and their tests (default: true)
+	Enabled bool
+	// Sigma is the regression threshold in standard deviations (default: 3)
+	Sigma float64
+	// Window is how many previous passed runs form the baseline (default: 20)
+	Window int
+	// MinRuns is how many previous passed runs are needed to check a duration (default: 5)
+	MinRuns int
+	// MinDelta is the smallest increase of a run's duration that is a regression (default: 30s)
+	MinDelta time.Duration
+	// TestMinDelta is the smallest increase of a test's duration that is a regression (default: 1s)
+	TestMinDelta time.Duration
+}
+
+// EventWebhooksConfig holds the endpoints that receive signed run lifecycle
+// events. Endpoints are named in CONDUCTOR_EVENT_WEBHOOKS and each configured
+// by CONDUCTOR_EVENT_WEBHOOK_<NAME>_URL, _SECRET and _EVENTS, where <NAME> is
+// the name in upper case with other characters than letters and digits
+// replaced by underscores.
+type EventWebhooksConfig struct {
+	// Endpoints are the configured endpoints
+	Endpoints []EventWebhookEndpoint
+	// Interval is how often new events and due retries are delivered (default: 5s)
+	Interval time.Duration
+	// Timeout bounds each delivery attempt (default: 10s)
+	Timeout time.Duration
+	// MaxAttempts is how many times a delivery is attempted before it fails (default: 8)
+	MaxAttempts int
+	// RetryBackoff is the wait before the first retry; it doubles with every retry (default: 30s)
+	RetryBackoff time.Duration
+	// Retention is how long delivered events are kept; 0 keeps them (default: 168h)
+	Retention time.Duration
+}
+
+// EventWebhookEndpoint is an endpoint that receives run lifecycle events.
+type EventWebhookEndpoint struct {
+	// Name identifies the endpoint in logs and deliveries
+	Name string
+	// URL receives the events as JSON POST requests
+	URL string
+	// Secret signs the requests with HMAC-SHA256
+	Secret string
+	// Events are the event types sent to the endpoint, e.g. run.finished;
+	// empty sends all events
+	Events []string
+}
+
+// EventWebhookEvents are the event types endpoints can subscribe to.
+var EventWebhookEvents = []string{"run.created", "run.started", "run.finished"}
+
+// EventsConfig holds the event bus that domain events are published to.
+type EventsConfig struct {
+	// Backend is the event bus: nats, kafka, or empty to disable publishing (default: "")
+	Backend string
+	// URLs are the NATS servers or Kafka brokers (required when a backend is set)
+	URLs []string
+	// Prefix is the first token of every NATS subject or Kafka topic (default: conductor)
+	Prefix string
+	// NATSStream is the JetStream stream events are stored in (default: CONDUCTOR_EVENTS)
+	NATSStream string
+	// Username authenticates with NATS user credentials or Kafka SASL/PLAIN
+	Username string
+	// Password authenticates with NATS user credentials or Kafka SASL/PLAIN
+	Password string
+	// TLS requires TLS connections to the event bus (default: false)
+	TLS bool
+	// BufferSize is how many events may wait for the event bus before new events are dropped (default: 10000)
+	BufferSize int
+	// PublishTimeout bounds each attempt to publish a batch of events (default: 10s)
+	PublishTimeout time.Duration
+}
+
+// EventBackends are the supported event bus backends.
+var EventBackends = []string{"nats", "kafka"}
+
+// AuditConfig holds the audit log of mutating API calls.
+type AuditConfig struct {
+	// Enabled records mutating API calls in the audit log (default: true)
+	Enabled bool
+	// Retention is how long audit logs are kept; 0 keeps them forever (default: 8760h)
+	Retention time.Duration
+}
+
+// ResultsConfig holds the maintenance of the monthly partitions of test
+// results and their retention.
+type ResultsConfig struct {
+	// Retention is how long test results are kept; months that ended longer
+	// ago are archived and dropped, 0 keeps them forever (default: 0)
+	Retention time.Duration
+	// ArchiveEnabled exports the results of a month to object storage as
+	// gzipped CSV before it is dropped (default: true)
+	ArchiveEnabled bool
+	// PartitionsAhead is how many months ahead partitions are created (default: 3)
+	PartitionsAhead int
+	// MaintenanceInterval is how often partitions are created and expired
+	// months archived (default: 6h)
+	MaintenanceInterval time.Duration
+}
+
+// LeaderConfig holds the election of the replica that runs the background
+// jobs, such as the schedule runner, git sync and cleanup, when several
+// control plane replicas share a database.
+type LeaderConfig struct {
+	// Enabled elects a leader with a Postgres advisory lock; when disabled
+	// every replica runs the background jobs (default: true)
+	Enabled bool
+	// LockID is the ID of the advisory lock (default: 1129270852)
+	LockID int64
+	// RetryInterval is how often followers try to become the leader and the
+	// leader checks that it still holds the lock (default: 5s)
+	RetryInterval time.Duration
+}
+
+// ReplicaConfig identifies this control plane replica to the others, which
+// route the messages for agents connected to it through it.
+type ReplicaConfig struct {
+	// ID identifies the replica; it must be unique and stable across
+	// restarts (default: hostname)
+	ID string
+	// Address is the gRPC address other replicas reach this one at
+	// (default: ID:GRPCPort)
+	Address string
+}
+
+// DurationAnomalyConfig holds the thresholds of run duration anomaly alerts.
+// A passed run is an anomaly when its duration is at least Sigma standard
+// deviations and MinDelta from the mean of the service's previous passed runs.
+type DurationAnomalyConfig struct {
+	// Enabled checks the duration of passed runs (default: true)
+	Enabled bool
+	// Sigma is the deviation threshold in standard deviations (default: 3)
+	Sigma float64
+	// Window is how many previous passed runs form the baseline (default: 20)
+	Window int
+	// MinRuns is how many previous passed runs are needed to check a run (default: 5)
+	MinRuns int
+	// MinDelta is the smallest reported difference from the mean (default: 30s)
+	MinDelta time.Duration
 }
 
 // EmailConfig holds SMTP settings for email notifications.
@@ -176,8 +545,12 @@ type EmailConfig struct {
 
 // LogConfig holds logging settings.
 type LogConfig struct {
-	// Level is the log level (debug, info, warn, error) (default: info)
+	// Level is the log level (debug, info, warn, error), optionally followed
+	// by levels of components, such as info,scheduler=debug (default: info)
 	Level string
+	// LevelFile is a file the log levels are reloaded from on SIGHUP, in the
+	// format of Level; if empty, SIGHUP restores Level
+	LevelFile string
 	// Format is the log format (json, console) (default: json)
 	Format string
 }
@@ -194,17 +567,45 @@ type ObservabilityConfig struct {
 	TracingSampleRate float64
 	// Environment is the deployment environment (e.g., "production", "staging")
 	Environment string
+	// DiagnosticsEnabled serves pprof profiles and runtime diagnostics on the
+	// metrics server (default: false)
+	DiagnosticsEnabled bool
+	// DiagnosticsToken is the bearer token required by the diagnostics
+	// endpoints
+	DiagnosticsToken string
 }
 
 // Load reads configuration from environment variables.
-// Environment variables use the CONDUCTOR_ prefix.
+// Environment variables use the CONDUCTOR_ prefix. Settings that are not set
+// are read from the config file named by CONDUCTOR_CONFIG_FILE, if any.
 func Load() (*Config, error) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	path := os.Getenv(fileEnv)
+	src, err := readSettings(path)
+	if err != nil {
+		return nil, err
+	}
+	loading = src
+	defer func() { loading = nil }()
+
+	hostname, _ := os.Hostname()
+
 	cfg := &Config{
+		File:     path,
+		settings: src.values,
+		fileInfo: src.info,
 		Server: ServerConfig{
 			HTTPPort:        getEnvInt("CONDUCTOR_HTTP_PORT", 8080),
 			GRPCPort:        getEnvInt("CONDUCTOR_GRPC_PORT", 9090),
 			MetricsPort:     getEnvInt("CONDUCTOR_METRICS_PORT", 9091),
 			ShutdownTimeout: getEnvDuration("CONDUCTOR_SHUTDOWN_TIMEOUT", 30*time.Second),
+			DrainTimeout:    getEnvDuration("CONDUCTOR_SHUTDOWN_DRAIN_TIMEOUT", 20*time.Second),
+			DrainJitter:     getEnvDuration("CONDUCTOR_SHUTDOWN_DRAIN_JITTER", 5*time.Second),
+			UIEnabled:       getEnvBool("CONDUCTOR_UI_ENABLED", true),
+			UIPath:          getEnv("CONDUCTOR_UI_PATH", "/ui"),
+			APIDocsEnabled:  getEnvBool("CONDUCTOR_API_DOCS_ENABLED", true),
 		},
 		Database: DatabaseConfig{
 			URL:             getEnv("CONDUCTOR_DATABASE_URL", ""),
@@ -213,6 +614,12 @@ func Load() (*Config, error) {
 			ConnMaxLifetime: getEnvDuration("CONDUCTOR_DATABASE_CONN_MAX_LIFETIME", 5*time.Minute),
 			ConnMaxIdleTime: getEnvDuration("CONDUCTOR_DATABASE_CONN_MAX_IDLE_TIME", 1*time.Minute),
 			QueryTimeout:    getEnvDuration("CONDUCTOR_DATABASE_QUERY_TIMEOUT", 30*time.Second),
+
+			SlowQueryThreshold: getEnvDuration("CONDUCTOR_DATABASE_SLOW_QUERY_THRESHOLD", time.Second),
+
+			ReplicaURL:           getEnv("CONDUCTOR_DATABASE_REPLICA_URL", ""),
+			ReplicaMaxLag:        getEnvDuration("CONDUCTOR_DATABASE_REPLICA_MAX_LAG", 10*time.Second),
+			ReplicaCheckInterval: getEnvDuration("CONDUCTOR_DATABASE_REPLICA_CHECK_INTERVAL", 5*time.Second),
 		},
 		Storage: StorageConfig{
 			Endpoint:         getEnv("CONDUCTOR_STORAGE_ENDPOINT", ""),
@@ -226,6 +633,17 @@ func Load() (*Config, error) {
 			CleanupInterval:  getEnvDuration("CONDUCTOR_STORAGE_CLEANUP_INTERVAL", time.Hour),
 			RetentionPeriod:  getEnvDuration("CONDUCTOR_STORAGE_RETENTION", 30*24*time.Hour),
 			CleanupBatchSize: getEnvInt("CONDUCTOR_STORAGE_CLEANUP_BATCH_SIZE", 100),
+			BlobGracePeriod:  getEnvDuration("CONDUCTOR_STORAGE_BLOB_GRACE_PERIOD", 24*time.Hour),
+
+			DownloadProxy:    getEnvBool("CONDUCTOR_STORAGE_DOWNLOAD_PROXY", false),
+			DownloadProxyURL: getEnv("CONDUCTOR_STORAGE_DOWNLOAD_PROXY_URL", ""),
+
+			ArchiveEnabled:      getEnvBool("CONDUCTOR_STORAGE_ARCHIVE_ENABLED", false),
+			ArchiveAfter:        getEnvDuration("CONDUCTOR_STORAGE_ARCHIVE_AFTER", 90*24*time.Hour),
+			ArchiveStorageClass: getEnv("CONDUCTOR_STORAGE_ARCHIVE_STORAGE_CLASS", "GLACIER"),
+			ArchiveInterval:     getEnvDuration("CONDUCTOR_STORAGE_ARCHIVE_INTERVAL", time.Hour),
+			RestoreDays:         getEnvInt("CONDUCTOR_STORAGE_RESTORE_DAYS", 7),
+			RestoreTier:         getEnv("CONDUCTOR_STORAGE_RESTORE_TIER", "Standard"),
 		},
 		Redis: RedisConfig{
 			URL:          getEnv("CONDUCTOR_REDIS_URL", ""),
@@ -234,6 +652,9 @@ func Load() (*Config, error) {
 			DialTimeout:  getEnvDuration("CONDUCTOR_REDIS_DIAL_TIMEOUT", 5*time.Second),
 			ReadTimeout:  getEnvDuration("CONDUCTOR_REDIS_READ_TIMEOUT", 3*time.Second),
 			WriteTimeout: getEnvDuration("CONDUCTOR_REDIS_WRITE_TIMEOUT", 3*time.Second),
+
+			CacheTTL:         getEnvDuration("CONDUCTOR_REDIS_CACHE_TTL", 30*time.Second),
+			ResponseCacheTTL: getEnvDuration("CONDUCTOR_REDIS_RESPONSE_CACHE_TTL", 5*time.Second),
 		},
 		Auth: AuthConfig{
 			JWTSecret:        getEnv("CONDUCTOR_AUTH_JWT_SECRET", ""),
@@ -245,25 +666,75 @@ func Load() (*Config, error) {
 			OIDCRedirectURL:  getEnv("CONDUCTOR_AUTH_OIDC_REDIRECT_URL", ""),
 		},
 		Agent: AgentConfig{
-			HeartbeatTimeout:       getEnvDuration("CONDUCTOR_AGENT_HEARTBEAT_TIMEOUT", 90*time.Second),
-			DefaultTestTimeout:     getEnvDuration("CONDUCTOR_AGENT_DEFAULT_TEST_TIMEOUT", 30*time.Minute),
-			MaxTestTimeout:         getEnvDuration("CONDUCTOR_AGENT_MAX_TEST_TIMEOUT", 4*time.Hour),
-			ResultStreamBufferSize: getEnvInt("CONDUCTOR_AGENT_RESULT_STREAM_BUFFER_SIZE", 100),
+			HeartbeatTimeout:          getEnvDuration("CONDUCTOR_AGENT_HEARTBEAT_TIMEOUT", 90*time.Second),
+			DefaultTestTimeout:        getEnvDuration("CONDUCTOR_AGENT_DEFAULT_TEST_TIMEOUT", 30*time.Minute),
+			MaxTestTimeout:            getEnvDuration("CONDUCTOR_AGENT_MAX_TEST_TIMEOUT", 4*time.Hour),
+			ResultStreamBufferSize:    getEnvInt("CONDUCTOR_AGENT_RESULT_STREAM_BUFFER_SIZE", 100),
+			ResultStreamFlushInterval: getEnvDuration("CONDUCTOR_AGENT_RESULT_STREAM_FLUSH_INTERVAL", time.Second),
 		},
 		Git: GitConfig{
-			Provider:               getEnv("CONDUCTOR_GIT_PROVIDER", "github"),
-			Token:                  getEnv("CONDUCTOR_GIT_TOKEN", ""),
-			BaseURL:                getEnv("CONDUCTOR_GIT_BASE_URL", ""),
-			WebhookSecret:          getEnv("CONDUCTOR_GIT_WEBHOOK_SECRET", ""),
-			GitLabWebhookSecret:    getEnv("CONDUCTOR_GITLAB_WEBHOOK_SECRET", ""),
-			BitbucketWebhookSecret: getEnv("CONDUCTOR_BITBUCKET_WEBHOOK_SECRET", ""),
-			AppID:                  int64(getEnvInt("CONDUCTOR_GIT_APP_ID", 0)),
-			AppPrivateKeyPath:      getEnv("CONDUCTOR_GIT_APP_PRIVATE_KEY_PATH", ""),
-			AppInstallationID:      int64(getEnvInt("CONDUCTOR_GIT_APP_INSTALLATION_ID", 0)),
+			Provider:                 getEnv("CONDUCTOR_GIT_PROVIDER", "github"),
+			Token:                    getEnv("CONDUCTOR_GIT_TOKEN", ""),
+			BaseURL:                  getEnv("CONDUCTOR_GIT_BASE_URL", ""),
+			WebhookSecret:            getEnv("CONDUCTOR_GIT_WEBHOOK_SECRET", ""),
+			GitLabWebhookSecret:      getEnv("CONDUCTOR_GITLAB_WEBHOOK_SECRET", ""),
+			BitbucketWebhookSecret:   getEnv("CONDUCTOR_BITBUCKET_WEBHOOK_SECRET", ""),
+			GiteaWebhookSecret:       getEnv("CONDUCTOR_GITEA_WEBHOOK_SECRET", ""),
+			AzureDevOpsWebhookSecret: getEnv("CONDUCTOR_AZURE_DEVOPS_WEBHOOK_SECRET", ""),
+			AppID:                    int64(getEnvInt("CONDUCTOR_GIT_APP_ID", 0)),
+			AppPrivateKeyPath:        getEnv("CONDUCTOR_GIT_APP_PRIVATE_KEY_PATH", ""),
+			AppInstallationID:        int64(getEnvInt("CONDUCTOR_GIT_APP_INSTALLATION_ID", 0)),
+			DeployKeyEncryptionKey:   getEnv("CONDUCTOR_GIT_DEPLOY_KEY_ENCRYPTION_KEY", ""),
+			StatusEnabled:            getEnvBool("CONDUCTOR_GIT_STATUS_ENABLED", true),
+			StatusContext:            getEnv("CONDUCTOR_GIT_STATUS_CONTEXT", "conductor"),
+			StatusRetryAttempts:      getEnvInt("CONDUCTOR_GIT_STATUS_RETRY_ATTEMPTS", 5),
+			StatusRetryDelay:         getEnvDuration("CONDUCTOR_GIT_STATUS_RETRY_DELAY", 10*time.Second),
+			CheckRunsEnabled:         getEnvBool("CONDUCTOR_GIT_CHECK_RUNS_ENABLED", false),
+			SyncInterval:             getEnvDuration("CONDUCTOR_GIT_SYNC_INTERVAL", 0),
+			HealthCheckEnabled:       getEnvBool("CONDUCTOR_GIT_HEALTH_CHECK_ENABLED", false),
 		},
 		Webhook: WebhookConfig{
-			Enabled: getEnvBool("CONDUCTOR_WEBHOOK_ENABLED", true),
-			BaseURL: getEnv("CONDUCTOR_WEBHOOK_BASE_URL", ""),
+			Enabled:            getEnvBool("CONDUCTOR_WEBHOOK_ENABLED", true),
+			BaseURL:            getEnv("CONDUCTOR_WEBHOOK_BASE_URL", ""),
+			Strict:             getEnvBool("CONDUCTOR_WEBHOOK_STRICT", false),
+			TimestampTolerance: getEnvDuration("CONDUCTOR_WEBHOOK_TIMESTAMP_TOLERANCE", 5*time.Minute),
+			ReplayWindow:       getEnvDuration("CONDUCTOR_WEBHOOK_REPLAY_WINDOW", 24*time.Hour),
+		},
+		Trigger: TriggerConfig{
+			ThrottleWindow: getEnvDuration("CONDUCTOR_TRIGGER_THROTTLE_WINDOW", 0),
+			ThrottleRuns:   getEnvInt("CONDUCTOR_TRIGGER_THROTTLE_RUNS", 1),
+			IdempotencyTTL: getEnvDuration("CONDUCTOR_TRIGGER_IDEMPOTENCY_TTL", 24*time.Hour),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:                  getEnvBool("CONDUCTOR_RATE_LIMIT_ENABLED", true),
+			RequestsPerSecond:        getEnvFloat("CONDUCTOR_RATE_LIMIT_REQUESTS_PER_SECOND", 50),
+			Burst:                    getEnvInt("CONDUCTOR_RATE_LIMIT_BURST", 100),
+			WebhookRequestsPerSecond: getEnvFloat("CONDUCTOR_RATE_LIMIT_WEBHOOK_REQUESTS_PER_SECOND", 20),
+			WebhookBurst:             getEnvInt("CONDUCTOR_RATE_LIMIT_WEBHOOK_BURST", 100),
+		},
+		Ingestion: IngestionConfig{
+			MaxResultsPerRun:   getEnvInt("CONDUCTOR_INGESTION_MAX_RESULTS_PER_RUN", 1000000),
+			MaxArtifactsPerRun: getEnvInt("CONDUCTOR_INGESTION_MAX_ARTIFACTS_PER_RUN", 10000),
+			MaxArtifactBytes:   getEnvInt64("CONDUCTOR_INGESTION_MAX_ARTIFACT_BYTES", 1<<30),
+		},
+		Energy: EnergyConfig{
+			Enabled:             getEnvBool("CONDUCTOR_ENERGY_ENABLED", true),
+			WattsPerCore:        getEnvFloat("CONDUCTOR_ENERGY_WATTS_PER_CORE", 10),
+			WattsPerMemoryGB:    getEnvFloat("CONDUCTOR_ENERGY_WATTS_PER_MEMORY_GB", 0.375),
+			CarbonIntensity:     getEnvFloat("CONDUCTOR_ENERGY_CARBON_INTENSITY", 475),
+			ZoneCarbonIntensity: getEnvFloatMap("CONDUCTOR_ENERGY_ZONE_CARBON_INTENSITY"),
+		},
+		Preflight: PreflightConfig{
+			Enabled:         getEnvBool("CONDUCTOR_PREFLIGHT_ENABLED", false),
+			Timeout:         getEnvDuration("CONDUCTOR_PREFLIGHT_TIMEOUT", 15*time.Second),
+			CheckImages:     getEnvBool("CONDUCTOR_PREFLIGHT_CHECK_IMAGES", true),
+			SecretsProvider: getEnv("CONDUCTOR_PREFLIGHT_SECRETS_PROVIDER", ""),
+			VaultAddress:    getEnv("CONDUCTOR_PREFLIGHT_SECRETS_VAULT_ADDR", ""),
+			VaultToken:      getEnv("CONDUCTOR_PREFLIGHT_SECRETS_VAULT_TOKEN", ""),
+			VaultNamespace:  getEnv("CONDUCTOR_PREFLIGHT_SECRETS_VAULT_NAMESPACE", ""),
+			VaultMount:      getEnv("CONDUCTOR_PREFLIGHT_SECRETS_VAULT_MOUNT", "secret"),
+			AWSRegion:       getEnv("CONDUCTOR_PREFLIGHT_SECRETS_AWS_REGION", ""),
+			AWSEndpoint:     getEnv("CONDUCTOR_PREFLIGHT_SECRETS_AWS_ENDPOINT", ""),
 		},
 		Notifications: NotificationConfig{
 			Email: EmailConfig{
@@ -277,21 +748,95 @@ func Load() (*Config, error) {
 				SkipVerify:  getEnvBool("CONDUCTOR_NOTIFICATIONS_EMAIL_SKIP_VERIFY", false),
 				ConnTimeout: getEnvDuration("CONDUCTOR_NOTIFICATIONS_EMAIL_CONN_TIMEOUT", 30*time.Second),
 			},
+			DedupWindow:           getEnvDuration("CONDUCTOR_NOTIFICATIONS_DEDUP_WINDOW", 10*time.Minute),
+			CollapseRules:         getEnvBool("CONDUCTOR_NOTIFICATIONS_COLLAPSE_RULES", false),
+			DeadLetterInterval:    getEnvDuration("CONDUCTOR_NOTIFICATIONS_DEAD_LETTER_INTERVAL", time.Minute),
+			DeadLetterMaxAttempts: getEnvInt("CONDUCTOR_NOTIFICATIONS_DEAD_LETTER_MAX_ATTEMPTS", 5),
+			DurationAnomaly: DurationAnomalyConfig{
+				Enabled:  getEnvBool("CONDUCTOR_NOTIFICATIONS_DURATION_ANOMALY_ENABLED", true),
+				Sigma:    getEnvFloat("CONDUCTOR_NOTIFICATIONS_DURATION_ANOMALY_SIGMA", 3),
+				Window:   getEnvInt("CONDUCTOR_NOTIFICATIONS_DURATION_ANOMALY_WINDOW", 20),
+				MinRuns:  getEnvInt("CONDUCTOR_NOTIFICATIONS_DURATION_ANOMALY_MIN_RUNS", 5),
+				MinDelta: getEnvDuration("CONDUCTOR_NOTIFICATIONS_DURATION_ANOMALY_MIN_DELTA", 30*time.Second),
+			},
+		},
+		Reports: ReportsConfig{
+			RefreshInterval: getEnvDuration("CONDUCTOR_REPORTS_REFRESH_INTERVAL", 5*time.Minute),
+		},
+		Regressions: RegressionsConfig{
+			Enabled:      getEnvBool("CONDUCTOR_REGRESSIONS_ENABLED", true),
+			Sigma:        getEnvFloat("CONDUCTOR_REGRESSIONS_SIGMA", 3),
+			Window:       getEnvInt("CONDUCTOR_REGRESSIONS_WINDOW", 20),
+			MinRuns:      getEnvInt("CONDUCTOR_REGRESSIONS_MIN_RUNS", 5),
+			MinDelta:     getEnvDuration("CONDUCTOR_REGRESSIONS_MIN_DELTA", 30*time.Second),
+			TestMinDelta: getEnvDuration("CONDUCTOR_REGRESSIONS_TEST_MIN_DELTA", time.Second),
+		},
+		EventWebhooks: EventWebhooksConfig{
+			Endpoints:    getEventWebhookEndpoints(),
+			Interval:     getEnvDuration("CONDUCTOR_EVENT_WEBHOOKS_INTERVAL", 5*time.Second),
+			Timeout:      getEnvDuration("CONDUCTOR_EVENT_WEBHOOKS_TIMEOUT", 10*time.Second),
+			MaxAttempts:  getEnvInt("CONDUCTOR_EVENT_WEBHOOKS_MAX_ATTEMPTS", 8),
+			RetryBackoff: getEnvDuration("CONDUCTOR_EVENT_WEBHOOKS_RETRY_BACKOFF", 30*time.Second),
+			Retention:    getEnvDuration("CONDUCTOR_EVENT_WEBHOOKS_RETENTION", 7*24*time.Hour),
+		},
+		Events: EventsConfig{
+			Backend:        getEnv("CONDUCTOR_EVENTS_BACKEND", ""),
+			URLs:           getEnvList("CONDUCTOR_EVENTS_URLS"),
+			Prefix:         getEnv("CONDUCTOR_EVENTS_PREFIX", "conductor"),
+			NATSStream:     getEnv("CONDUCTOR_EVENTS_NATS_STREAM", "CONDUCTOR_EVENTS"),
+			Username:       getEnv("CONDUCTOR_EVENTS_USERNAME", ""),
+			Password:       getEnv("CONDUCTOR_EVENTS_PASSWORD", ""),
+			TLS:            getEnvBool("CONDUCTOR_EVENTS_TLS", false),
+			BufferSize:     getEnvInt("CONDUCTOR_EVENTS_BUFFER_SIZE", 10000),
+			PublishTimeout: getEnvDuration("CONDUCTOR_EVENTS_PUBLISH_TIMEOUT", 10*time.Second),
+		},
+		Audit: AuditConfig{
+			Enabled:   getEnvBool("CONDUCTOR_AUDIT_ENABLED", true),
+			Retention: getEnvDuration("CONDUCTOR_AUDIT_RETENTION", 365*24*time.Hour),
+		},
+		Results: ResultsConfig{
+			Retention:           getEnvDuration("CONDUCTOR_RESULTS_RETENTION", 0),
+			ArchiveEnabled:      getEnvBool("CONDUCTOR_RESULTS_ARCHIVE_ENABLED", true),
+			PartitionsAhead:     getEnvInt("CONDUCTOR_RESULTS_PARTITIONS_AHEAD", 3),
+			MaintenanceInterval: getEnvDuration("CONDUCTOR_RESULTS_MAINTENANCE_INTERVAL", 6*time.Hour),
+		},
+		Leader: LeaderConfig{
+			Enabled:       getEnvBool("CONDUCTOR_LEADER_ELECTION_ENABLED", true),
+			LockID:        getEnvInt64("CONDUCTOR_LEADER_ELECTION_LOCK_ID", 1129270852),
+			RetryInterval: getEnvDuration("CONDUCTOR_LEADER_ELECTION_RETRY_INTERVAL", 5*time.Second),
+		},
+		Replica: ReplicaConfig{
+			ID:      getEnv("CONDUCTOR_REPLICA_ID", hostname),
+			Address: getEnv("CONDUCTOR_REPLICA_ADDRESS", ""),
 		},
 		Log: LogConfig{
-			Level:  getEnv("CONDUCTOR_LOG_LEVEL", "info"),
-			Format: getEnv("CONDUCTOR_LOG_FORMAT", "json"),
+			Level:     getEnv("CONDUCTOR_LOG_LEVEL", "info"),
+			LevelFile: getEnv("CONDUCTOR_LOG_LEVEL_FILE", ""),
+			Format:    getEnv("CONDUCTOR_LOG_FORMAT", "json"),
 		},
 		Observability: ObservabilityConfig{
-			TracingEnabled:    getEnvBool("CONDUCTOR_TRACING_ENABLED", false),
-			TracingEndpoint:   getEnv("CONDUCTOR_TRACING_ENDPOINT", ""),
-			TracingInsecure:   getEnvBool("CONDUCTOR_TRACING_INSECURE", true),
-			TracingSampleRate: getEnvFloat("CONDUCTOR_TRACING_SAMPLE_RATE", 1.0),
-			Environment:       getEnv("CONDUCTOR_ENVIRONMENT", "development"),
+			TracingEnabled:     getEnvBool("CONDUCTOR_TRACING_ENABLED", false),
+			TracingEndpoint:    getEnv("CONDUCTOR_TRACING_ENDPOINT", ""),
+			TracingInsecure:    getEnvBool("CONDUCTOR_TRACING_INSECURE", true),
+			TracingSampleRate:  getEnvFloat("CONDUCTOR_TRACING_SAMPLE_RATE", 1.0),
+			Environment:        getEnv("CONDUCTOR_ENVIRONMENT", "development"),
+			DiagnosticsEnabled: getEnvBool("CONDUCTOR_DIAGNOSTICS_ENABLED", false),
+			DiagnosticsToken:   getEnv("CONDUCTOR_DIAGNOSTICS_TOKEN", ""),
 		},
 	}
+	if cfg.Replica.Address == "" {
+		cfg.Replica.Address = net.JoinHostPort(cfg.Replica.ID, strconv.Itoa(cfg.Server.GRPCPort))
+	}
 
-	if err := cfg.Validate(); err != nil {
+	err = cfg.Validate()
+	if fileErrs := src.fileErrors(); len(fileErrs) > 0 {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			fileErrs = append(fileErrs, validationErr.Errors...)
+		}
+		err = &ValidationError{Errors: fileErrs}
+	}
+	if err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
 
@@ -303,9 +848,99 @@ func (c *Config) Validate() error {
 	var errs []error
 
 	// Server validation
+	uiPath := strings.Trim(c.Server.UIPath, "/")
+	if c.Server.UIEnabled && (!strings.HasPrefix(c.Server.UIPath, "/") || uiPath == "" || uiPath == "ws" || uiPath == "api" || strings.HasPrefix(uiPath, "api/")) {
+		errs = append(errs, errors.New("CONDUCTOR_UI_PATH must be an absolute path other than /, /ws, or under /api/"))
+	}
 	if c.Server.HTTPPort < 1 || c.Server.HTTPPort > 65535 {
 		errs = append(errs, errors.New("CONDUCTOR_HTTP_PORT must be between 1 and 65535"))
 	}
+	if c.Server.DrainTimeout < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_SHUTDOWN_DRAIN_TIMEOUT must not be negative"))
+	}
+	if c.Server.DrainJitter < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_SHUTDOWN_DRAIN_JITTER must not be negative"))
+	} else if c.Server.DrainTimeout > 0 && c.Server.DrainJitter >= c.Server.DrainTimeout {
+		errs = append(errs, errors.New("CONDUCTOR_SHUTDOWN_DRAIN_JITTER must be less than CONDUCTOR_SHUTDOWN_DRAIN_TIMEOUT"))
+	}
+
+	if c.Notifications.DedupWindow < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_NOTIFICATIONS_DEDUP_WINDOW must not be negative"))
+	}
+	if c.Notifications.DeadLetterInterval < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_NOTIFICATIONS_DEAD_LETTER_INTERVAL must not be negative"))
+	}
+	if c.Notifications.DeadLetterMaxAttempts < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_NOTIFICATIONS_DEAD_LETTER_MAX_ATTEMPTS must not be negative"))
+	}
+
+	if c.Reports.RefreshInterval < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_REPORTS_REFRESH_INTERVAL must not be negative"))
+	}
+
+	if regressions := c.Regressions; regressions.Enabled {
+		if regressions.Sigma <= 0 {
+			errs = append(errs, errors.New("CONDUCTOR_REGRESSIONS_SIGMA must be positive"))
+		}
+		if regressions.Window < 2 {
+			errs = append(errs, errors.New("CONDUCTOR_REGRESSIONS_WINDOW must be at least 2"))
+		}
+		if regressions.MinRuns < 2 || regressions.MinRuns > regressions.Window {
+			errs = append(errs, errors.New("CONDUCTOR_REGRESSIONS_MIN_RUNS must be between 2 and the window"))
+		}
+		if regressions.MinDelta < 0 {
+			errs = append(errs, errors.New("CONDUCTOR_REGRESSIONS_MIN_DELTA must not be negative"))
+		}
+		if regressions.TestMinDelta < 0 {
+			errs = append(errs, errors.New("CONDUCTOR_REGRESSIONS_TEST_MIN_DELTA must not be negative"))
+		}
+	}
+
+	if c.Redis.CacheTTL <= 0 {
+		errs = append(errs, errors.New("CONDUCTOR_REDIS_CACHE_TTL must be positive"))
+	}
+	if c.Redis.ResponseCacheTTL < 0 || c.Redis.ResponseCacheTTL > c.Redis.CacheTTL {
+		errs = append(errs, errors.New("CONDUCTOR_REDIS_RESPONSE_CACHE_TTL must be between 0 and CONDUCTOR_REDIS_CACHE_TTL"))
+	}
+
+	errs = append(errs, c.EventWebhooks.validate()...)
+	errs = append(errs, c.Events.validate()...)
+
+	if c.Audit.Retention < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_AUDIT_RETENTION must not be negative"))
+	}
+
+	if c.Results.Retention < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_RESULTS_RETENTION must not be negative"))
+	}
+	if c.Results.PartitionsAhead < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_RESULTS_PARTITIONS_AHEAD must not be negative"))
+	}
+	if c.Results.MaintenanceInterval <= 0 {
+		errs = append(errs, errors.New("CONDUCTOR_RESULTS_MAINTENANCE_INTERVAL must be positive"))
+	}
+
+	if c.Leader.Enabled && c.Leader.RetryInterval <= 0 {
+		errs = append(errs, errors.New("CONDUCTOR_LEADER_ELECTION_RETRY_INTERVAL must be positive"))
+	}
+	if c.Replica.ID == "" {
+		errs = append(errs, errors.New("CONDUCTOR_REPLICA_ID is required when the hostname is unknown"))
+	}
+
+	if anomaly := c.Notifications.DurationAnomaly; anomaly.Enabled {
+		if anomaly.Sigma <= 0 {
+			errs = append(errs, errors.New("CONDUCTOR_NOTIFICATIONS_DURATION_ANOMALY_SIGMA must be positive"))
+		}
+		if anomaly.Window < 2 {
+			errs = append(errs, errors.New("CONDUCTOR_NOTIFICATIONS_DURATION_ANOMALY_WINDOW must be at least 2"))
+		}
+		if anomaly.MinRuns < 2 || anomaly.MinRuns > anomaly.Window {
+			errs = append(errs, errors.New("CONDUCTOR_NOTIFICATIONS_DURATION_ANOMALY_MIN_RUNS must be between 2 and the window"))
+		}
+		if anomaly.MinDelta < 0 {
+			errs = append(errs, errors.New("CONDUCTOR_NOTIFICATIONS_DURATION_ANOMALY_MIN_DELTA must not be negative"))
+		}
+	}
 
 	if c.Notifications.Email.SMTPHost != "" {
 		if c.Notifications.Email.SMTPPort <= 0 {
@@ -321,6 +956,9 @@ func (c *Config) Validate() error {
 	if c.Server.MetricsPort < 1 || c.Server.MetricsPort > 65535 {
 		errs = append(errs, errors.New("CONDUCTOR_METRICS_PORT must be between 1 and 65535"))
 	}
+	if c.Observability.DiagnosticsEnabled && c.Observability.DiagnosticsToken == "" {
+		errs = append(errs, errors.New("CONDUCTOR_DIAGNOSTICS_TOKEN is required when diagnostics are enabled"))
+	}
 
 	// Database validation (required)
 	if c.Database.URL == "" {
@@ -335,6 +973,17 @@ func (c *Config) Validate() error {
 	if c.Database.MaxIdleConns > c.Database.MaxOpenConns {
 		errs = append(errs, errors.New("CONDUCTOR_DATABASE_MAX_IDLE_CONNS cannot exceed MAX_OPEN_CONNS"))
 	}
+	if c.Database.SlowQueryThreshold < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_DATABASE_SLOW_QUERY_THRESHOLD cannot be negative"))
+	}
+	if c.Database.ReplicaURL != "" {
+		if c.Database.ReplicaMaxLag <= 0 {
+			errs = append(errs, errors.New("CONDUCTOR_DATABASE_REPLICA_MAX_LAG must be positive"))
+		}
+		if c.Database.ReplicaCheckInterval <= 0 {
+			errs = append(errs, errors.New("CONDUCTOR_DATABASE_REPLICA_CHECK_INTERVAL must be positive"))
+		}
+	}
 
 	// Storage validation (required)
 	if c.Storage.Bucket == "" {
@@ -351,6 +1000,30 @@ func (c *Config) Validate() error {
 			errs = append(errs, errors.New("CONDUCTOR_STORAGE_CLEANUP_BATCH_SIZE must be greater than 0 when cleanup is enabled"))
 		}
 	}
+	if c.Storage.ArchiveEnabled {
+		if c.Storage.ArchiveAfter <= 0 {
+			errs = append(errs, errors.New("CONDUCTOR_STORAGE_ARCHIVE_AFTER must be greater than 0 when archival is enabled"))
+		}
+		if c.Storage.CleanupEnabled && c.Storage.ArchiveAfter >= c.Storage.RetentionPeriod {
+			errs = append(errs, errors.New("CONDUCTOR_STORAGE_ARCHIVE_AFTER must be less than CONDUCTOR_STORAGE_RETENTION"))
+		}
+		if c.Storage.ArchiveInterval <= 0 {
+			errs = append(errs, errors.New("CONDUCTOR_STORAGE_ARCHIVE_INTERVAL must be greater than 0 when archival is enabled"))
+		}
+		validClasses := map[string]bool{"STANDARD_IA": true, "ONEZONE_IA": true, "GLACIER_IR": true, "GLACIER": true, "DEEP_ARCHIVE": true}
+		if !validClasses[c.Storage.ArchiveStorageClass] {
+			errs = append(errs, errors.New("CONDUCTOR_STORAGE_ARCHIVE_STORAGE_CLASS must be one of: STANDARD_IA, ONEZONE_IA, GLACIER_IR, GLACIER, DEEP_ARCHIVE"))
+		}
+		if c.Storage.RestoreDays <= 0 {
+			errs = append(errs, errors.New("CONDUCTOR_STORAGE_RESTORE_DAYS must be greater than 0 when archival is enabled"))
+		}
+		validTiers := map[string]bool{"Expedited": true, "Standard": true, "Bulk": true}
+		if !validTiers[c.Storage.RestoreTier] {
+			errs = append(errs, errors.New("CONDUCTOR_STORAGE_RESTORE_TIER must be one of: Expedited, Standard, Bulk"))
+		} else if c.Storage.RestoreTier == "Expedited" && c.Storage.ArchiveStorageClass == "DEEP_ARCHIVE" {
+			errs = append(errs, errors.New("CONDUCTOR_STORAGE_RESTORE_TIER Expedited is not supported for DEEP_ARCHIVE"))
+		}
+	}
 	if c.Storage.AccessKeyID == "" {
 		errs = append(errs, errors.New("CONDUCTOR_STORAGE_ACCESS_KEY_ID is required"))
 	}
@@ -392,11 +1065,16 @@ func (c *Config) Validate() error {
 	if c.Agent.MaxTestTimeout < c.Agent.DefaultTestTimeout {
 		errs = append(errs, errors.New("CONDUCTOR_AGENT_MAX_TEST_TIMEOUT must be >= DEFAULT_TEST_TIMEOUT"))
 	}
+	if c.Agent.ResultStreamBufferSize < 1 {
+		errs = append(errs, errors.New("CONDUCTOR_AGENT_RESULT_STREAM_BUFFER_SIZE must be at least 1"))
+	}
+	if c.Agent.ResultStreamFlushInterval <= 0 {
+		errs = append(errs, errors.New("CONDUCTOR_AGENT_RESULT_STREAM_FLUSH_INTERVAL must be positive"))
+	}
 
 	// Log validation
-	validLevels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
-	if !validLevels[strings.ToLower(c.Log.Level)] {
-		errs = append(errs, errors.New("CONDUCTOR_LOG_LEVEL must be one of: debug, info, warn, error"))
+	if _, _, err := conductorlog.ParseLevels(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("CONDUCTOR_LOG_LEVEL must be one of: debug, info, warn, error, optionally followed by component=level pairs: %w", err))
 	}
 	validFormats := map[string]bool{"json": true, "console": true}
 	if !validFormats[strings.ToLower(c.Log.Format)] {
@@ -420,6 +1098,106 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Deploy key encryption validation (conditional)
+	if c.Git.DeployKeyEncryptionKey != "" {
+		key, err := base64.StdEncoding.DecodeString(c.Git.DeployKeyEncryptionKey)
+		if err != nil || len(key) != 32 {
+			errs = append(errs, errors.New("CONDUCTOR_GIT_DEPLOY_KEY_ENCRYPTION_KEY must be a base64-encoded 32-byte key"))
+		}
+	}
+
+	// Commit status reporting validation (conditional)
+	if c.Git.StatusEnabled {
+		if c.Git.StatusContext == "" {
+			errs = append(errs, errors.New("CONDUCTOR_GIT_STATUS_CONTEXT is required when status reporting is enabled"))
+		}
+		if c.Git.StatusRetryAttempts < 1 {
+			errs = append(errs, errors.New("CONDUCTOR_GIT_STATUS_RETRY_ATTEMPTS must be at least 1"))
+		}
+		if c.Git.StatusRetryDelay <= 0 {
+			errs = append(errs, errors.New("CONDUCTOR_GIT_STATUS_RETRY_DELAY must be positive"))
+		}
+	}
+
+	if c.Git.SyncInterval < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_GIT_SYNC_INTERVAL must not be negative"))
+	}
+
+	// Webhook replay protection validation
+	if c.Webhook.TimestampTolerance < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_WEBHOOK_TIMESTAMP_TOLERANCE must not be negative"))
+	}
+	if c.Webhook.ReplayWindow < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_WEBHOOK_REPLAY_WINDOW must not be negative"))
+	}
+	if c.Webhook.Enabled && c.Webhook.Strict && !c.HasWebhookSecrets() {
+		errs = append(errs, errors.New("CONDUCTOR_WEBHOOK_STRICT requires at least one webhook secret"))
+	}
+
+	// Trigger throttle validation
+	if c.Trigger.ThrottleWindow < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_TRIGGER_THROTTLE_WINDOW must not be negative"))
+	}
+	if c.Trigger.ThrottleWindow > 0 && c.Trigger.ThrottleRuns < 1 {
+		errs = append(errs, errors.New("CONDUCTOR_TRIGGER_THROTTLE_RUNS must be at least 1"))
+	}
+	if c.Trigger.IdempotencyTTL <= 0 {
+		errs = append(errs, errors.New("CONDUCTOR_TRIGGER_IDEMPOTENCY_TTL must be positive"))
+	}
+
+	// Rate limit validation
+	if c.RateLimit.Enabled {
+		if c.RateLimit.RequestsPerSecond <= 0 {
+			errs = append(errs, errors.New("CONDUCTOR_RATE_LIMIT_REQUESTS_PER_SECOND must be positive"))
+		}
+		if c.RateLimit.Burst < 1 {
+			errs = append(errs, errors.New("CONDUCTOR_RATE_LIMIT_BURST must be at least 1"))
+		}
+		if c.RateLimit.WebhookRequestsPerSecond <= 0 {
+			errs = append(errs, errors.New("CONDUCTOR_RATE_LIMIT_WEBHOOK_REQUESTS_PER_SECOND must be positive"))
+		}
+		if c.RateLimit.WebhookBurst < 1 {
+			errs = append(errs, errors.New("CONDUCTOR_RATE_LIMIT_WEBHOOK_BURST must be at least 1"))
+		}
+	}
+
+	// Ingestion cap validation
+	if c.Ingestion.MaxResultsPerRun < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_INGESTION_MAX_RESULTS_PER_RUN cannot be negative"))
+	}
+	if c.Ingestion.MaxArtifactsPerRun < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_INGESTION_MAX_ARTIFACTS_PER_RUN cannot be negative"))
+	}
+	if c.Ingestion.MaxArtifactBytes < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_INGESTION_MAX_ARTIFACT_BYTES cannot be negative"))
+	}
+
+	// Energy model validation
+	if c.Energy.WattsPerCore < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_ENERGY_WATTS_PER_CORE cannot be negative"))
+	}
+	if c.Energy.WattsPerMemoryGB < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_ENERGY_WATTS_PER_MEMORY_GB cannot be negative"))
+	}
+	if c.Energy.CarbonIntensity < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_ENERGY_CARBON_INTENSITY cannot be negative"))
+	}
+	for zone, intensity := range c.Energy.ZoneCarbonIntensity {
+		if intensity < 0 {
+			errs = append(errs, fmt.Errorf("CONDUCTOR_ENERGY_ZONE_CARBON_INTENSITY for zone %q cannot be negative", zone))
+		}
+	}
+
+	// Pre-flight validation
+	if c.Preflight.Timeout < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_PREFLIGHT_TIMEOUT cannot be negative"))
+	}
+	switch c.Preflight.SecretsProvider {
+	case "", "vault", "aws":
+	default:
+		errs = append(errs, fmt.Errorf("CONDUCTOR_PREFLIGHT_SECRETS_PROVIDER must be vault or aws, got %q", c.Preflight.SecretsProvider))
+	}
+
 	if len(errs) > 0 {
 		return &ValidationError{Errors: errs}
 	}
@@ -467,50 +1245,196 @@ func (c *Config) WebhooksEnabled() bool {
 
 // HasWebhookSecrets returns true if any webhook secret is configured.
 func (c *Config) HasWebhookSecrets() bool {
-	return c.Git.WebhookSecret != "" || c.Git.GitLabWebhookSecret != "" || c.Git.BitbucketWebhookSecret != ""
+	return c.Git.WebhookSecret != "" || c.Git.GitLabWebhookSecret != "" || c.Git.BitbucketWebhookSecret != "" ||
+		c.Git.GiteaWebhookSecret != "" || c.Git.AzureDevOpsWebhookSecret != ""
+}
+
+// validate checks the event webhook endpoints and delivery settings.
+func (c EventWebhooksConfig) validate() []error {
+	var errs []error
+	if c.Interval <= 0 {
+		errs = append(errs, errors.New("CONDUCTOR_EVENT_WEBHOOKS_INTERVAL must be positive"))
+	}
+	if c.Timeout <= 0 {
+		errs = append(errs, errors.New("CONDUCTOR_EVENT_WEBHOOKS_TIMEOUT must be positive"))
+	}
+	if c.MaxAttempts < 1 {
+		errs = append(errs, errors.New("CONDUCTOR_EVENT_WEBHOOKS_MAX_ATTEMPTS must be at least 1"))
+	}
+	if c.RetryBackoff < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_EVENT_WEBHOOKS_RETRY_BACKOFF must not be negative"))
+	}
+	if c.Retention < 0 {
+		errs = append(errs, errors.New("CONDUCTOR_EVENT_WEBHOOKS_RETENTION must not be negative"))
+	}
+
+	seen := make(map[string]bool)
+	for _, endpoint := range c.Endpoints {
+		prefix := eventWebhookEnvPrefix(endpoint.Name)
+		if seen[prefix] {
+			errs = append(errs, fmt.Errorf("event webhook %q is configured more than once", endpoint.Name))
+		}
+		seen[prefix] = true
+
+		if u, err := url.Parse(endpoint.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s_URL must be an http or https URL", prefix))
+		}
+		if endpoint.Secret == "" {
+			errs = append(errs, fmt.Errorf("%s_SECRET is required", prefix))
+		}
+		for _, event := range endpoint.Events {
+			if !slices.Contains(EventWebhookEvents, event) {
+				errs = append(errs, fmt.Errorf("%s_EVENTS must only contain %s", prefix, strings.Join(EventWebhookEvents, ", ")))
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// validate checks the event bus settings when a backend is set.
+func (c EventsConfig) validate() []error {
+	if c.Backend == "" {
+		return nil
+	}
+
+	var errs []error
+	if !slices.Contains(EventBackends, c.Backend) {
+		errs = append(errs, fmt.Errorf("CONDUCTOR_EVENTS_BACKEND must be one of %s", strings.Join(EventBackends, ", ")))
+	}
+	if len(c.URLs) == 0 {
+		errs = append(errs, errors.New("CONDUCTOR_EVENTS_URLS is required when CONDUCTOR_EVENTS_BACKEND is set"))
+	}
+	if c.Prefix == "" || strings.ContainsAny(c.Prefix, " *>") {
+		errs = append(errs, errors.New("CONDUCTOR_EVENTS_PREFIX must be non-empty and must not contain spaces, * or >"))
+	}
+	if c.Backend == "nats" && c.NATSStream == "" {
+		errs = append(errs, errors.New("CONDUCTOR_EVENTS_NATS_STREAM is required for the nats backend"))
+	}
+	if c.BufferSize < 1 {
+		errs = append(errs, errors.New("CONDUCTOR_EVENTS_BUFFER_SIZE must be at least 1"))
+	}
+	if c.PublishTimeout <= 0 {
+		errs = append(errs, errors.New("CONDUCTOR_EVENTS_PUBLISH_TIMEOUT must be positive"))
+	}
+	return errs
 }
 
-// Helper functions for reading environment variables
+// Helper functions for reading environment variables, and the config file
+// for those that are not set
 
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
 func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if intVal, err := strconv.Atoi(value); err == nil {
 			return intVal
 		}
+		invalidValue(key, "an integer")
+	}
+	return defaultValue
+}
+
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value := lookupEnv(key); value != "" {
+		if intVal, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return intVal
+		}
+		invalidValue(key, "an integer")
 	}
 	return defaultValue
 }
 
 func getEnvBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if boolVal, err := strconv.ParseBool(value); err == nil {
 			return boolVal
 		}
+		invalidValue(key, "true or false")
 	}
 	return defaultValue
 }
 
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
 			return duration
 		}
+		invalidValue(key, "a duration such as 30s or 1h")
 	}
 	return defaultValue
 }
 
 func getEnvFloat(key string, defaultValue float64) float64 {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
 			return floatVal
 		}
+		invalidValue(key, "a number")
 	}
 	return defaultValue
 }
+
+// getEventWebhookEndpoints reads the endpoints named in
+// CONDUCTOR_EVENT_WEBHOOKS.
+func getEventWebhookEndpoints() []EventWebhookEndpoint {
+	var endpoints []EventWebhookEndpoint
+	for _, name := range getEnvList("CONDUCTOR_EVENT_WEBHOOKS") {
+		prefix := eventWebhookEnvPrefix(name)
+		endpoints = append(endpoints, EventWebhookEndpoint{
+			Name:   name,
+			URL:    getEnv(prefix+"_URL", ""),
+			Secret: getEnv(prefix+"_SECRET", ""),
+			Events: getEnvList(prefix + "_EVENTS"),
+		})
+	}
+	return endpoints
+}
+
+// eventWebhookEnvPrefix returns the prefix of the variables that configure an
+// event webhook endpoint.
+func eventWebhookEnvPrefix(name string) string {
+	return "CONDUCTOR_EVENT_WEBHOOK_" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// getEnvList parses a comma-separated list. Empty items are skipped.
+func getEnvList(key string) []string {
+	var result []string
+	for _, item := range strings.Split(lookupEnv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getEnvFloatMap parses a comma-separated list of key=value pairs with float
+// values. Malformed pairs are skipped.
+func getEnvFloatMap(key string) map[string]float64 {
+	result := make(map[string]float64)
+	for _, pair := range strings.Split(lookupEnv(key), ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			continue
+		}
+		if floatVal, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			result[name] = floatVal
+		}
+	}
+	return result
+}
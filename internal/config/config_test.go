@@ -75,12 +75,19 @@ func TestLoad_Defaults(t *testing.T) {
 	assert.Equal(t, 9090, cfg.Server.GRPCPort)
 	assert.Equal(t, 9091, cfg.Server.MetricsPort)
 	assert.Equal(t, 30*time.Second, cfg.Server.ShutdownTimeout)
+	assert.Equal(t, 20*time.Second, cfg.Server.DrainTimeout)
+	assert.Equal(t, 5*time.Second, cfg.Server.DrainJitter)
+	assert.Equal(t, 10*time.Second, cfg.Database.ReplicaMaxLag)
+	assert.True(t, cfg.Server.UIEnabled)
+	assert.Equal(t, "/ui", cfg.Server.UIPath)
+	assert.True(t, cfg.Server.APIDocsEnabled)
 
 	// Database defaults
 	assert.Equal(t, 25, cfg.Database.MaxOpenConns)
 	assert.Equal(t, 5, cfg.Database.MaxIdleConns)
 	assert.Equal(t, 5*time.Minute, cfg.Database.ConnMaxLifetime)
 	assert.Equal(t, 30*time.Second, cfg.Database.QueryTimeout)
+	assert.Equal(t, time.Second, cfg.Database.SlowQueryThreshold)
 
 	// Storage defaults
 	assert.Equal(t, "us-east-1", cfg.Storage.Region)
@@ -100,6 +107,89 @@ func TestLoad_Defaults(t *testing.T) {
 	assert.Equal(t, 30*time.Minute, cfg.Agent.DefaultTestTimeout)
 	assert.Equal(t, 4*time.Hour, cfg.Agent.MaxTestTimeout)
 	assert.Equal(t, 100, cfg.Agent.ResultStreamBufferSize)
+	assert.Equal(t, time.Second, cfg.Agent.ResultStreamFlushInterval)
+
+	// Git defaults
+	assert.True(t, cfg.Git.StatusEnabled)
+	assert.Equal(t, "conductor", cfg.Git.StatusContext)
+	assert.Equal(t, 5, cfg.Git.StatusRetryAttempts)
+	assert.Equal(t, 10*time.Second, cfg.Git.StatusRetryDelay)
+	assert.Zero(t, cfg.Git.SyncInterval)
+	assert.False(t, cfg.Git.CheckRunsEnabled)
+	assert.False(t, cfg.Git.HealthCheckEnabled)
+
+	// Webhook defaults
+	assert.False(t, cfg.Webhook.Strict)
+	assert.Equal(t, 5*time.Minute, cfg.Webhook.TimestampTolerance)
+	assert.Equal(t, 24*time.Hour, cfg.Webhook.ReplayWindow)
+
+	// Trigger defaults
+	assert.Equal(t, time.Duration(0), cfg.Trigger.ThrottleWindow)
+	assert.Equal(t, 24*time.Hour, cfg.Trigger.IdempotencyTTL)
+	assert.Equal(t, 1, cfg.Trigger.ThrottleRuns)
+
+	// Ingestion defaults
+	assert.Equal(t, 1000000, cfg.Ingestion.MaxResultsPerRun)
+	assert.Equal(t, 10000, cfg.Ingestion.MaxArtifactsPerRun)
+	assert.Equal(t, int64(1<<30), cfg.Ingestion.MaxArtifactBytes)
+
+	// Energy defaults
+	assert.True(t, cfg.Energy.Enabled)
+	assert.Equal(t, 10.0, cfg.Energy.WattsPerCore)
+	assert.Equal(t, 0.375, cfg.Energy.WattsPerMemoryGB)
+	assert.Equal(t, 475.0, cfg.Energy.CarbonIntensity)
+	assert.Empty(t, cfg.Energy.ZoneCarbonIntensity)
+
+	// Pre-flight defaults
+	assert.False(t, cfg.Preflight.Enabled)
+	assert.Equal(t, 15*time.Second, cfg.Preflight.Timeout)
+	assert.True(t, cfg.Preflight.CheckImages)
+	assert.Equal(t, "secret", cfg.Preflight.VaultMount)
+
+	// Dead-letter defaults
+	assert.Equal(t, time.Minute, cfg.Notifications.DeadLetterInterval)
+	assert.Equal(t, 5, cfg.Notifications.DeadLetterMaxAttempts)
+
+	// Report defaults
+	assert.Equal(t, 5*time.Minute, cfg.Reports.RefreshInterval)
+
+	// Duration regression defaults
+	assert.True(t, cfg.Regressions.Enabled)
+	assert.Equal(t, 3.0, cfg.Regressions.Sigma)
+	assert.Equal(t, 20, cfg.Regressions.Window)
+	assert.Equal(t, 5, cfg.Regressions.MinRuns)
+	assert.Equal(t, 30*time.Second, cfg.Regressions.MinDelta)
+	assert.Equal(t, time.Second, cfg.Regressions.TestMinDelta)
+	assert.Empty(t, cfg.EventWebhooks.Endpoints)
+	assert.Equal(t, 5*time.Second, cfg.EventWebhooks.Interval)
+	assert.Equal(t, 10*time.Second, cfg.EventWebhooks.Timeout)
+	assert.Equal(t, 8, cfg.EventWebhooks.MaxAttempts)
+	assert.Equal(t, 30*time.Second, cfg.EventWebhooks.RetryBackoff)
+	assert.Equal(t, 7*24*time.Hour, cfg.EventWebhooks.Retention)
+	assert.Empty(t, cfg.Events.Backend)
+	assert.Equal(t, "conductor", cfg.Events.Prefix)
+	assert.Equal(t, "CONDUCTOR_EVENTS", cfg.Events.NATSStream)
+	assert.Equal(t, 10000, cfg.Events.BufferSize)
+	assert.Equal(t, 10*time.Second, cfg.Events.PublishTimeout)
+	assert.True(t, cfg.Audit.Enabled)
+	assert.Equal(t, 365*24*time.Hour, cfg.Audit.Retention)
+	assert.Zero(t, cfg.Results.Retention)
+	assert.True(t, cfg.Results.ArchiveEnabled)
+	assert.Equal(t, 3, cfg.Results.PartitionsAhead)
+	assert.Equal(t, 6*time.Hour, cfg.Results.MaintenanceInterval)
+	assert.True(t, cfg.Leader.Enabled)
+	assert.Equal(t, int64(1129270852), cfg.Leader.LockID)
+	assert.Equal(t, 5*time.Second, cfg.Leader.RetryInterval)
+	hostname, _ := os.Hostname()
+	assert.Equal(t, hostname, cfg.Replica.ID)
+	assert.Equal(t, hostname+":9090", cfg.Replica.Address)
+
+	// Duration anomaly defaults
+	assert.True(t, cfg.Notifications.DurationAnomaly.Enabled)
+	assert.Equal(t, 3.0, cfg.Notifications.DurationAnomaly.Sigma)
+	assert.Equal(t, 20, cfg.Notifications.DurationAnomaly.Window)
+	assert.Equal(t, 5, cfg.Notifications.DurationAnomaly.MinRuns)
+	assert.Equal(t, 30*time.Second, cfg.Notifications.DurationAnomaly.MinDelta)
 
 	// Log defaults
 	assert.Equal(t, "info", cfg.Log.Level)
@@ -136,6 +226,21 @@ func TestLoad_MissingStorageCredentials(t *testing.T) {
 	assert.Contains(t, err.Error(), "CONDUCTOR_STORAGE_ACCESS_KEY_ID is required")
 }
 
+func TestLoad_InvalidArchiveSettings(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_STORAGE_ARCHIVE_ENABLED"] = "true"
+	env["CONDUCTOR_STORAGE_ARCHIVE_STORAGE_CLASS"] = "COLD"
+	env["CONDUCTOR_STORAGE_CLEANUP_ENABLED"] = "true"
+	env["CONDUCTOR_STORAGE_RETENTION"] = "720h"
+	env["CONDUCTOR_STORAGE_ARCHIVE_AFTER"] = "2160h"
+	setTestEnv(t, env)
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_STORAGE_ARCHIVE_STORAGE_CLASS must be one of")
+	assert.Contains(t, err.Error(), "CONDUCTOR_STORAGE_ARCHIVE_AFTER must be less than CONDUCTOR_STORAGE_RETENTION")
+}
+
 func TestLoad_MissingJWTSecret(t *testing.T) {
 	env := minimalValidEnv()
 	delete(env, "CONDUCTOR_AUTH_JWT_SECRET")
@@ -316,6 +421,445 @@ func TestLoad_GitHubAppConfigWrongProvider(t *testing.T) {
 	assert.Contains(t, err.Error(), "CONDUCTOR_GIT_APP_* settings require")
 }
 
+func TestLoad_DeployKeyEncryptionKey(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_GIT_DEPLOY_KEY_ENCRYPTION_KEY"] = "dG9vLXNob3J0"
+	setTestEnv(t, env)
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_GIT_DEPLOY_KEY_ENCRYPTION_KEY must be a base64-encoded 32-byte key")
+
+	env["CONDUCTOR_GIT_DEPLOY_KEY_ENCRYPTION_KEY"] = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	setTestEnv(t, env)
+
+	_, err = Load()
+	require.NoError(t, err)
+}
+
+func TestLoad_InvalidStatusRetrySettings(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_GIT_STATUS_RETRY_ATTEMPTS"] = "-1"
+	env["CONDUCTOR_GIT_STATUS_RETRY_DELAY"] = "-5s"
+	setTestEnv(t, env)
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_GIT_STATUS_RETRY_ATTEMPTS must be at least 1")
+	assert.Contains(t, err.Error(), "CONDUCTOR_GIT_STATUS_RETRY_DELAY must be positive")
+
+	env["CONDUCTOR_GIT_STATUS_ENABLED"] = "false"
+	setTestEnv(t, env)
+
+	_, err = Load()
+	require.NoError(t, err)
+}
+
+func TestLoad_WebhookStrictRequiresSecret(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_WEBHOOK_STRICT"] = "true"
+	env["CONDUCTOR_WEBHOOK_REPLAY_WINDOW"] = "-1h"
+	setTestEnv(t, env)
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_WEBHOOK_STRICT requires at least one webhook secret")
+	assert.Contains(t, err.Error(), "CONDUCTOR_WEBHOOK_REPLAY_WINDOW must not be negative")
+
+	env["CONDUCTOR_WEBHOOK_REPLAY_WINDOW"] = "1h"
+	env["CONDUCTOR_GIT_WEBHOOK_SECRET"] = "github-secret"
+	setTestEnv(t, env)
+
+	_, err = Load()
+	require.NoError(t, err)
+}
+
+func TestLoad_TriggerThrottleValidation(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_TRIGGER_THROTTLE_WINDOW"] = "2m"
+	env["CONDUCTOR_TRIGGER_THROTTLE_RUNS"] = "0"
+	setTestEnv(t, env)
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_TRIGGER_THROTTLE_RUNS must be at least 1")
+
+	env["CONDUCTOR_TRIGGER_THROTTLE_RUNS"] = "2"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, cfg.Trigger.ThrottleWindow)
+	assert.Equal(t, 2, cfg.Trigger.ThrottleRuns)
+}
+
+func TestLoad_TriggerIdempotencyTTL(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_TRIGGER_IDEMPOTENCY_TTL"] = "0s"
+	setTestEnv(t, env)
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_TRIGGER_IDEMPOTENCY_TTL must be positive")
+
+	env["CONDUCTOR_TRIGGER_IDEMPOTENCY_TTL"] = "2h"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Hour, cfg.Trigger.IdempotencyTTL)
+}
+
+func TestLoad_RateLimit(t *testing.T) {
+	setTestEnv(t, minimalValidEnv())
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.RateLimit.Enabled)
+	assert.Equal(t, 50.0, cfg.RateLimit.RequestsPerSecond)
+	assert.Equal(t, 100, cfg.RateLimit.Burst)
+	assert.Equal(t, 20.0, cfg.RateLimit.WebhookRequestsPerSecond)
+	assert.Equal(t, 100, cfg.RateLimit.WebhookBurst)
+
+	env := minimalValidEnv()
+	env["CONDUCTOR_RATE_LIMIT_REQUESTS_PER_SECOND"] = "0"
+	env["CONDUCTOR_RATE_LIMIT_WEBHOOK_BURST"] = "0"
+	setTestEnv(t, env)
+
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_RATE_LIMIT_REQUESTS_PER_SECOND must be positive")
+	assert.Contains(t, err.Error(), "CONDUCTOR_RATE_LIMIT_WEBHOOK_BURST must be at least 1")
+
+	env["CONDUCTOR_RATE_LIMIT_ENABLED"] = "false"
+	setTestEnv(t, env)
+
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.RateLimit.Enabled)
+}
+
+func TestLoad_ComponentLogLevels(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_LOG_LEVEL"] = "info,scheduler=debug"
+	env["CONDUCTOR_LOG_LEVEL_FILE"] = "/etc/conductor/log-level"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "info,scheduler=debug", cfg.Log.Level)
+	assert.Equal(t, "/etc/conductor/log-level", cfg.Log.LevelFile)
+
+	env["CONDUCTOR_LOG_LEVEL"] = "info,scheduler"
+	setTestEnv(t, env)
+
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_LOG_LEVEL must be one of")
+}
+
+func TestLoad_EnergyModel(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_ENERGY_ZONE_CARBON_INTENSITY"] = "eu-north=40, us-east = 380,broken,bad=x"
+	env["CONDUCTOR_ENERGY_CARBON_INTENSITY"] = "300"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 300.0, cfg.Energy.CarbonIntensity)
+	assert.Equal(t, map[string]float64{"eu-north": 40, "us-east": 380}, cfg.Energy.ZoneCarbonIntensity)
+
+	env["CONDUCTOR_ENERGY_ZONE_CARBON_INTENSITY"] = "eu-north=-1"
+	env["CONDUCTOR_ENERGY_WATTS_PER_CORE"] = "-5"
+	setTestEnv(t, env)
+
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_ENERGY_WATTS_PER_CORE cannot be negative")
+	assert.Contains(t, err.Error(), `CONDUCTOR_ENERGY_ZONE_CARBON_INTENSITY for zone "eu-north" cannot be negative`)
+}
+
+func TestLoad_PreflightValidation(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_PREFLIGHT_TIMEOUT"] = "-1s"
+	env["CONDUCTOR_PREFLIGHT_SECRETS_PROVIDER"] = "envfile"
+	setTestEnv(t, env)
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_PREFLIGHT_TIMEOUT cannot be negative")
+	assert.Contains(t, err.Error(), `CONDUCTOR_PREFLIGHT_SECRETS_PROVIDER must be vault or aws, got "envfile"`)
+}
+
+func TestLoad_ReportsValidation(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_REPORTS_REFRESH_INTERVAL"] = "-1m"
+	setTestEnv(t, env)
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_REPORTS_REFRESH_INTERVAL must not be negative")
+}
+
+func TestLoad_RegressionsValidation(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_REGRESSIONS_WINDOW"] = "1"
+	env["CONDUCTOR_REGRESSIONS_TEST_MIN_DELTA"] = "-1s"
+	setTestEnv(t, env)
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_REGRESSIONS_WINDOW must be at least 2")
+	assert.Contains(t, err.Error(), "CONDUCTOR_REGRESSIONS_TEST_MIN_DELTA must not be negative")
+
+	env["CONDUCTOR_REGRESSIONS_ENABLED"] = "false"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Regressions.Enabled)
+}
+
+func TestLoad_EventWebhooks(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_EVENT_WEBHOOKS"] = "deploy-bot, audit"
+	env["CONDUCTOR_EVENT_WEBHOOK_DEPLOY_BOT_URL"] = "https://deploy.example.com/hooks/conductor"
+	env["CONDUCTOR_EVENT_WEBHOOK_DEPLOY_BOT_SECRET"] = "s3cret"
+	env["CONDUCTOR_EVENT_WEBHOOK_DEPLOY_BOT_EVENTS"] = "run.finished"
+	env["CONDUCTOR_EVENT_WEBHOOK_AUDIT_URL"] = "http://audit.internal/events"
+	env["CONDUCTOR_EVENT_WEBHOOK_AUDIT_SECRET"] = "another"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []EventWebhookEndpoint{
+		{Name: "deploy-bot", URL: "https://deploy.example.com/hooks/conductor", Secret: "s3cret", Events: []string{"run.finished"}},
+		{Name: "audit", URL: "http://audit.internal/events", Secret: "another"},
+	}, cfg.EventWebhooks.Endpoints)
+
+	env["CONDUCTOR_EVENT_WEBHOOK_DEPLOY_BOT_URL"] = "deploy.example.com"
+	env["CONDUCTOR_EVENT_WEBHOOK_DEPLOY_BOT_EVENTS"] = "run.finished,run.deleted"
+	env["CONDUCTOR_EVENT_WEBHOOK_AUDIT_SECRET"] = ""
+	env["CONDUCTOR_EVENT_WEBHOOKS_MAX_ATTEMPTS"] = "0"
+	setTestEnv(t, env)
+
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_EVENT_WEBHOOK_DEPLOY_BOT_URL must be an http or https URL")
+	assert.Contains(t, err.Error(), "CONDUCTOR_EVENT_WEBHOOK_DEPLOY_BOT_EVENTS must only contain run.created, run.started, run.finished")
+	assert.Contains(t, err.Error(), "CONDUCTOR_EVENT_WEBHOOK_AUDIT_SECRET is required")
+	assert.Contains(t, err.Error(), "CONDUCTOR_EVENT_WEBHOOKS_MAX_ATTEMPTS must be at least 1")
+}
+
+func TestLoad_Events(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_EVENTS_BACKEND"] = "kafka"
+	env["CONDUCTOR_EVENTS_URLS"] = "kafka-1:9092, kafka-2:9092"
+	env["CONDUCTOR_EVENTS_PREFIX"] = "ci"
+	env["CONDUCTOR_EVENTS_USERNAME"] = "conductor"
+	env["CONDUCTOR_EVENTS_PASSWORD"] = "s3cret"
+	env["CONDUCTOR_EVENTS_TLS"] = "true"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "kafka", cfg.Events.Backend)
+	assert.Equal(t, []string{"kafka-1:9092", "kafka-2:9092"}, cfg.Events.URLs)
+	assert.Equal(t, "ci", cfg.Events.Prefix)
+	assert.Equal(t, "conductor", cfg.Events.Username)
+	assert.Equal(t, "s3cret", cfg.Events.Password)
+	assert.True(t, cfg.Events.TLS)
+
+	env["CONDUCTOR_EVENTS_BACKEND"] = "rabbitmq"
+	env["CONDUCTOR_EVENTS_URLS"] = ""
+	env["CONDUCTOR_EVENTS_PREFIX"] = "ci.>"
+	env["CONDUCTOR_EVENTS_BUFFER_SIZE"] = "0"
+	setTestEnv(t, env)
+
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_EVENTS_BACKEND must be one of nats, kafka")
+	assert.Contains(t, err.Error(), "CONDUCTOR_EVENTS_URLS is required")
+	assert.Contains(t, err.Error(), "CONDUCTOR_EVENTS_PREFIX must be non-empty")
+	assert.Contains(t, err.Error(), "CONDUCTOR_EVENTS_BUFFER_SIZE must be at least 1")
+}
+
+func TestLoad_Audit(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_AUDIT_ENABLED"] = "false"
+	env["CONDUCTOR_AUDIT_RETENTION"] = "0"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Audit.Enabled)
+	assert.Zero(t, cfg.Audit.Retention)
+
+	env["CONDUCTOR_AUDIT_RETENTION"] = "-1h"
+	setTestEnv(t, env)
+
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_AUDIT_RETENTION must not be negative")
+}
+
+func TestLoad_Results(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_RESULTS_RETENTION"] = "4320h"
+	env["CONDUCTOR_RESULTS_ARCHIVE_ENABLED"] = "false"
+	env["CONDUCTOR_RESULTS_PARTITIONS_AHEAD"] = "1"
+	env["CONDUCTOR_RESULTS_MAINTENANCE_INTERVAL"] = "1h"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 180*24*time.Hour, cfg.Results.Retention)
+	assert.False(t, cfg.Results.ArchiveEnabled)
+	assert.Equal(t, 1, cfg.Results.PartitionsAhead)
+	assert.Equal(t, time.Hour, cfg.Results.MaintenanceInterval)
+
+	env["CONDUCTOR_RESULTS_RETENTION"] = "-1h"
+	env["CONDUCTOR_RESULTS_PARTITIONS_AHEAD"] = "-1"
+	env["CONDUCTOR_RESULTS_MAINTENANCE_INTERVAL"] = "0"
+	setTestEnv(t, env)
+
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_RESULTS_RETENTION must not be negative")
+	assert.Contains(t, err.Error(), "CONDUCTOR_RESULTS_PARTITIONS_AHEAD must not be negative")
+	assert.Contains(t, err.Error(), "CONDUCTOR_RESULTS_MAINTENANCE_INTERVAL must be positive")
+}
+
+func TestLoad_Leader(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_LEADER_ELECTION_LOCK_ID"] = "42"
+	env["CONDUCTOR_LEADER_ELECTION_RETRY_INTERVAL"] = "0"
+	setTestEnv(t, env)
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_LEADER_ELECTION_RETRY_INTERVAL must be positive")
+
+	env["CONDUCTOR_LEADER_ELECTION_ENABLED"] = "false"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Leader.Enabled)
+	assert.Equal(t, int64(42), cfg.Leader.LockID)
+}
+
+func TestLoad_Replica(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_REPLICA_ID"] = "control-plane-1"
+	env["CONDUCTOR_GRPC_PORT"] = "9443"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "control-plane-1", cfg.Replica.ID)
+	assert.Equal(t, "control-plane-1:9443", cfg.Replica.Address, "the address defaults to the ID and gRPC port")
+
+	env["CONDUCTOR_REPLICA_ADDRESS"] = "10.0.0.7:9090"
+	setTestEnv(t, env)
+
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.7:9090", cfg.Replica.Address)
+}
+
+func TestLoad_Drain(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_SHUTDOWN_DRAIN_TIMEOUT"] = "10s"
+	env["CONDUCTOR_SHUTDOWN_DRAIN_JITTER"] = "10s"
+	setTestEnv(t, env)
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_SHUTDOWN_DRAIN_JITTER must be less than CONDUCTOR_SHUTDOWN_DRAIN_TIMEOUT")
+
+	env["CONDUCTOR_SHUTDOWN_DRAIN_TIMEOUT"] = "0"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err, "the jitter is not bounded when draining is disabled")
+	assert.Zero(t, cfg.Server.DrainTimeout)
+}
+
+func TestLoad_DatabaseReplica(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_DATABASE_REPLICA_MAX_LAG"] = "0"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err, "replica settings are not checked without a replica")
+	assert.Empty(t, cfg.Database.ReplicaURL)
+
+	env["CONDUCTOR_DATABASE_REPLICA_URL"] = "postgres://replica:5432/conductor"
+	setTestEnv(t, env)
+
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_DATABASE_REPLICA_MAX_LAG must be positive")
+
+	env["CONDUCTOR_DATABASE_REPLICA_MAX_LAG"] = "30s"
+	setTestEnv(t, env)
+
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://replica:5432/conductor", cfg.Database.ReplicaURL)
+	assert.Equal(t, 30*time.Second, cfg.Database.ReplicaMaxLag)
+	assert.Equal(t, 5*time.Second, cfg.Database.ReplicaCheckInterval)
+}
+
+func TestLoad_IngestionCapValidation(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_INGESTION_MAX_RESULTS_PER_RUN"] = "-1"
+	env["CONDUCTOR_INGESTION_MAX_ARTIFACT_BYTES"] = "-1"
+	env["CONDUCTOR_DATABASE_SLOW_QUERY_THRESHOLD"] = "-1s"
+	setTestEnv(t, env)
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_INGESTION_MAX_RESULTS_PER_RUN cannot be negative")
+	assert.Contains(t, err.Error(), "CONDUCTOR_INGESTION_MAX_ARTIFACT_BYTES cannot be negative")
+	assert.Contains(t, err.Error(), "CONDUCTOR_DATABASE_SLOW_QUERY_THRESHOLD cannot be negative")
+
+	env["CONDUCTOR_INGESTION_MAX_RESULTS_PER_RUN"] = "0"
+	env["CONDUCTOR_INGESTION_MAX_ARTIFACTS_PER_RUN"] = "50"
+	env["CONDUCTOR_INGESTION_MAX_ARTIFACT_BYTES"] = "10485760"
+	env["CONDUCTOR_DATABASE_SLOW_QUERY_THRESHOLD"] = "250ms"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 0, cfg.Ingestion.MaxResultsPerRun)
+	assert.Equal(t, 50, cfg.Ingestion.MaxArtifactsPerRun)
+	assert.Equal(t, int64(10<<20), cfg.Ingestion.MaxArtifactBytes)
+	assert.Equal(t, 250*time.Millisecond, cfg.Database.SlowQueryThreshold)
+}
+
+func TestLoad_DurationAnomalyValidation(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_NOTIFICATIONS_DURATION_ANOMALY_SIGMA"] = "0"
+	env["CONDUCTOR_NOTIFICATIONS_DURATION_ANOMALY_WINDOW"] = "10"
+	env["CONDUCTOR_NOTIFICATIONS_DURATION_ANOMALY_MIN_RUNS"] = "11"
+	setTestEnv(t, env)
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_NOTIFICATIONS_DURATION_ANOMALY_SIGMA must be positive")
+	assert.Contains(t, err.Error(), "CONDUCTOR_NOTIFICATIONS_DURATION_ANOMALY_MIN_RUNS must be between 2 and the window")
+
+	// Thresholds are not checked while disabled
+	env["CONDUCTOR_NOTIFICATIONS_DURATION_ANOMALY_ENABLED"] = "false"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.False(t, cfg.Notifications.DurationAnomaly.Enabled)
+}
+
 func TestLoad_AgentHeartbeatTooShort(t *testing.T) {
 	env := minimalValidEnv()
 	env["CONDUCTOR_AGENT_HEARTBEAT_TIMEOUT"] = "5s"
@@ -347,6 +891,27 @@ func TestLoad_MaxTestTimeoutLessThanDefault(t *testing.T) {
 	assert.Contains(t, err.Error(), "MAX_TEST_TIMEOUT must be >= DEFAULT_TEST_TIMEOUT")
 }
 
+func TestLoad_ResultStreamBatching(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_AGENT_RESULT_STREAM_BUFFER_SIZE"] = "500"
+	env["CONDUCTOR_AGENT_RESULT_STREAM_FLUSH_INTERVAL"] = "250ms"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 500, cfg.Agent.ResultStreamBufferSize)
+	assert.Equal(t, 250*time.Millisecond, cfg.Agent.ResultStreamFlushInterval)
+
+	env["CONDUCTOR_AGENT_RESULT_STREAM_BUFFER_SIZE"] = "0"
+	env["CONDUCTOR_AGENT_RESULT_STREAM_FLUSH_INTERVAL"] = "0"
+	setTestEnv(t, env)
+
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_AGENT_RESULT_STREAM_BUFFER_SIZE must be at least 1")
+	assert.Contains(t, err.Error(), "CONDUCTOR_AGENT_RESULT_STREAM_FLUSH_INTERVAL must be positive")
+}
+
 func TestLoad_DatabaseMaxIdleExceedsMaxOpen(t *testing.T) {
 	env := minimalValidEnv()
 	env["CONDUCTOR_DATABASE_MAX_OPEN_CONNS"] = "5"
@@ -406,6 +971,61 @@ func TestRedisEnabled(t *testing.T) {
 	})
 }
 
+func TestLoad_Diagnostics(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_DIAGNOSTICS_ENABLED"] = "true"
+	env["CONDUCTOR_DIAGNOSTICS_TOKEN"] = "debug-token"
+	setTestEnv(t, env)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Observability.DiagnosticsEnabled)
+	assert.Equal(t, "debug-token", cfg.Observability.DiagnosticsToken)
+
+	env["CONDUCTOR_DIAGNOSTICS_TOKEN"] = ""
+	setTestEnv(t, env)
+
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CONDUCTOR_DIAGNOSTICS_TOKEN is required when diagnostics are enabled")
+}
+
+func TestLoad_RedisCache(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		setTestEnv(t, minimalValidEnv())
+
+		cfg, err := Load()
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Second, cfg.Redis.CacheTTL)
+		assert.Equal(t, 5*time.Second, cfg.Redis.ResponseCacheTTL)
+	})
+
+	t.Run("response cache disabled", func(t *testing.T) {
+		env := minimalValidEnv()
+		env["CONDUCTOR_REDIS_RESPONSE_CACHE_TTL"] = "0s"
+		setTestEnv(t, env)
+
+		cfg, err := Load()
+		require.NoError(t, err)
+		assert.Zero(t, cfg.Redis.ResponseCacheTTL)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for name, value := range map[string]string{
+			"CONDUCTOR_REDIS_CACHE_TTL":          "0s",
+			"CONDUCTOR_REDIS_RESPONSE_CACHE_TTL": "1m",
+		} {
+			env := minimalValidEnv()
+			env[name] = value
+			setTestEnv(t, env)
+
+			_, err := Load()
+			require.Error(t, err, name)
+			assert.Contains(t, err.Error(), name)
+		}
+	})
+}
+
 func TestValidationError_SingleError(t *testing.T) {
 	err := &ValidationError{
 		Errors: []error{
@@ -0,0 +1,315 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileEnv names the config file settings are read from, in YAML (or JSON).
+// A setting of the file is named by the path of its keys, which joined with
+// underscores and prefixed with CONDUCTOR_ give its environment variable:
+// log: {level: debug} sets CONDUCTOR_LOG_LEVEL. Environment variables
+// override the file.
+const fileEnv = "CONDUCTOR_CONFIG_FILE"
+
+// envPrefix is the prefix of the environment variables of all settings.
+const envPrefix = "CONDUCTOR_"
+
+// reloadable are the settings that take effect without a restart when the
+// config file changes.
+var reloadable = []string{
+	"CONDUCTOR_LOG_LEVEL",
+	"CONDUCTOR_STORAGE_CLEANUP_INTERVAL",
+	"CONDUCTOR_STORAGE_RETENTION",
+	"CONDUCTOR_NOTIFICATIONS_DEDUP_WINDOW",
+	"CONDUCTOR_NOTIFICATIONS_COLLAPSE_RULES",
+}
+
+// Reloadable reports whether a setting, named by its environment variable,
+// takes effect without a restart.
+func Reloadable(key string) bool {
+	return slices.Contains(reloadable, key)
+}
+
+// fileSetting is a setting of a config file.
+type fileSetting struct {
+	// name is the path of the keys of the setting, such as log.level.
+	name  string
+	value string
+	line  int
+}
+
+// settings are what Load reads: environment variables, and the settings of
+// the config file for those that are not set.
+type settings struct {
+	path string
+	// info describes the config file as it was read.
+	info os.FileInfo
+	// file holds the settings of the config file by environment variable.
+	file map[string]fileSetting
+	// values holds the value of every setting read by environment variable.
+	values map[string]string
+	errs   []error
+}
+
+var (
+	// loadMu serializes Load, which reads settings through loading.
+	loadMu sync.Mutex
+	// loading holds the settings while Load runs. Without them, settings are
+	// read from the environment only.
+	loading *settings
+)
+
+// readSettings reads the config file at path, if any.
+func readSettings(path string) (*settings, error) {
+	s := &settings{
+		path:   path,
+		file:   make(map[string]fileSetting),
+		values: make(map[string]string),
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	s.info = info
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return s, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: config file must be a mapping of settings", path, root.Line)
+	}
+	if err := s.add(nil, root); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// add adds the settings of a mapping whose keys are below the keys of path.
+func (s *settings) add(path []string, node *yaml.Node) error {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keys := append(slices.Clone(path), key.Value)
+		name := strings.Join(keys, ".")
+
+		if value.Kind == yaml.AliasNode {
+			value = value.Alias
+		}
+		switch value.Kind {
+		case yaml.MappingNode:
+			if err := s.add(keys, value); err != nil {
+				return err
+			}
+			continue
+		case yaml.ScalarNode:
+			if value.Tag == "!!null" {
+				continue
+			}
+		case yaml.SequenceNode:
+			for _, item := range value.Content {
+				if item.Kind != yaml.ScalarNode {
+					return fmt.Errorf("%s:%d: %s must be a list of values", s.path, item.Line, name)
+				}
+			}
+		}
+
+		env := envName(keys)
+		if other, ok := s.file[env]; ok {
+			return fmt.Errorf("%s:%d: %s sets %s, already set by %s on line %d", s.path, key.Line, name, env, other.name, other.line)
+		}
+		s.file[env] = fileSetting{name: name, value: scalarValue(value), line: key.Line}
+	}
+	return nil
+}
+
+// envName returns the environment variable of the setting at a path of keys.
+// The first key may already be the variable, such as CONDUCTOR_HTTP_PORT.
+func envName(keys []string) string {
+	env := strings.ToUpper(strings.ReplaceAll(strings.Join(keys, "_"), "-", "_"))
+	if !strings.HasPrefix(env, envPrefix) {
+		env = envPrefix + env
+	}
+	return env
+}
+
+// scalarValue returns the value of a node as that of an environment variable.
+// Lists are comma-separated.
+func scalarValue(node *yaml.Node) string {
+	if node.Kind != yaml.SequenceNode {
+		return node.Value
+	}
+	values := make([]string, len(node.Content))
+	for i, item := range node.Content {
+		values[i] = item.Value
+	}
+	return strings.Join(values, ",")
+}
+
+// lookupEnv returns the value of a setting: its environment variable if set,
+// and otherwise its value in the config file being loaded.
+func lookupEnv(key string) string {
+	value := os.Getenv(key)
+	if loading == nil {
+		return value
+	}
+	if value == "" {
+		value = loading.file[key].value
+	}
+	loading.values[key] = value
+	return value
+}
+
+// invalidValue records that the value of a setting is not of the type it
+// must be. Only values of the config file are errors: invalid environment
+// variables fall back to the default.
+func invalidValue(key, kind string) {
+	if loading == nil || os.Getenv(key) != "" {
+		return
+	}
+	if setting, ok := loading.file[key]; ok {
+		loading.errs = append(loading.errs, fmt.Errorf("%s:%d: %s must be %s, got %q", loading.path, setting.line, setting.name, kind, setting.value))
+	}
+}
+
+// fileErrors returns the errors of the config file, including its settings
+// that were not read because they do not exist, sorted by line.
+func (s *settings) fileErrors() []error {
+	type lineError struct {
+		line int
+		err  error
+	}
+	var errs []lineError
+	for env, setting := range s.file {
+		if _, ok := s.values[env]; ok {
+			continue
+		}
+		err := fmt.Errorf("%s:%d: unknown setting %s (%s)", s.path, setting.line, setting.name, env)
+		if suggestion := closestSetting(env, s.values); suggestion != "" {
+			err = fmt.Errorf("%w; did you mean %s?", err, suggestion)
+		}
+		errs = append(errs, lineError{line: setting.line, err: err})
+	}
+	slices.SortFunc(errs, func(a, b lineError) int { return a.line - b.line })
+
+	result := slices.Clone(s.errs)
+	for _, e := range errs {
+		result = append(result, e.err)
+	}
+	return result
+}
+
+// closestSetting returns the setting whose name is closest to env, if it is
+// close enough to be a likely misspelling.
+func closestSetting(env string, known map[string]string) string {
+	best, bestDistance := "", 4
+	for _, name := range slices.Sorted(maps.Keys(known)) {
+		if d := editDistance(env, name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// Changed returns the settings, by environment variable, whose values differ
+// from those of prev, sorted.
+func (c *Config) Changed(prev *Config) []string {
+	var changed []string
+	for key, value := range c.settings {
+		if prevValue, ok := prev.settings[key]; !ok || prevValue != value {
+			changed = append(changed, key)
+		}
+	}
+	for key := range prev.settings {
+		if _, ok := c.settings[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// Watch checks the config file of cfg every interval until ctx is done, and
+// loads the configuration again whenever the file changes. reload is called
+// with the previous and the new configuration, or with the error if the new
+// one is invalid, in which case the previous one stays in use. Nothing is
+// watched without a config file.
+func Watch(ctx context.Context, cfg *Config, interval time.Duration, reload func(prev, next *Config, err error)) {
+	if cfg.File == "" {
+		return
+	}
+
+	last := cfg.fileInfo
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(cfg.File)
+		if err != nil {
+			if last != nil {
+				reload(cfg, nil, fmt.Errorf("failed to read config file: %w", err))
+			}
+			last = nil
+			continue
+		}
+		if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+			continue
+		}
+
+		next, err := Load()
+		if err != nil {
+			last = info
+			reload(cfg, nil, err)
+			continue
+		}
+		last = next.fileInfo
+		reload(cfg, next, nil)
+		cfg = next
+	}
+}
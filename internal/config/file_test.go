@@ -0,0 +1,198 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfigFile writes a config file and points CONDUCTOR_CONFIG_FILE at it.
+func writeConfigFile(t *testing.T, env map[string]string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "conductor.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	env[fileEnv] = path
+	setTestEnv(t, env)
+	return path
+}
+
+func TestLoad_ConfigFile(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_GRPC_PORT"] = "9191"
+	path := writeConfigFile(t, env, `
+http_port: 8181
+grpc_port: 9292
+log:
+  level: [info, scheduler=debug]
+  format: console
+storage:
+  cleanup-interval: 15m
+notifications:
+  collapse_rules: true
+  dedup_window: ~
+CONDUCTOR_UI_PATH: /dashboard
+`)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, path, cfg.File)
+	assert.Equal(t, 8181, cfg.Server.HTTPPort)
+	assert.Equal(t, 9191, cfg.Server.GRPCPort, "environment variables override the file")
+	assert.Equal(t, "info,scheduler=debug", cfg.Log.Level)
+	assert.Equal(t, "console", cfg.Log.Format)
+	assert.Equal(t, 15*time.Minute, cfg.Storage.CleanupInterval)
+	assert.True(t, cfg.Notifications.CollapseRules)
+	assert.Equal(t, 10*time.Minute, cfg.Notifications.DedupWindow, "null keeps the default")
+	assert.Equal(t, "/dashboard", cfg.Server.UIPath)
+}
+
+func TestLoad_ConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errors  []string
+	}{
+		{
+			name:    "unknown setting",
+			content: "log:\n  levle: debug\n",
+			errors:  []string{":2: unknown setting log.levle (CONDUCTOR_LOG_LEVLE); did you mean CONDUCTOR_LOG_LEVEL?"},
+		},
+		{
+			name:    "unknown setting without suggestion",
+			content: "frobnicate: true\n",
+			errors:  []string{":1: unknown setting frobnicate (CONDUCTOR_FROBNICATE)"},
+		},
+		{
+			name:    "invalid values",
+			content: "http_port: eighty\nstorage:\n  cleanup_interval: hourly\n",
+			errors: []string{
+				`:1: http_port must be an integer, got "eighty"`,
+				`:3: storage.cleanup_interval must be a duration such as 30s or 1h, got "hourly"`,
+			},
+		},
+		{
+			name:    "validation errors",
+			content: "http_port: 0\n",
+			errors:  []string{"CONDUCTOR_HTTP_PORT must be between 1 and 65535"},
+		},
+		{
+			name:    "not a mapping",
+			content: "- debug\n",
+			errors:  []string{":1: config file must be a mapping of settings"},
+		},
+		{
+			name:    "nested list",
+			content: "log:\n  level:\n    - {scheduler: debug}\n",
+			errors:  []string{":3: log.level must be a list of values"},
+		},
+		{
+			name:    "set twice",
+			content: "log_level: debug\nlog:\n  level: info\n",
+			errors:  []string{":3: log.level sets CONDUCTOR_LOG_LEVEL, already set by log_level on line 1"},
+		},
+		{
+			name:    "syntax error",
+			content: "log: [debug\n",
+			errors:  []string{"failed to parse config file"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigFile(t, minimalValidEnv(), tt.content)
+
+			_, err := Load()
+			require.Error(t, err)
+			for _, msg := range tt.errors {
+				assert.Contains(t, err.Error(), msg)
+			}
+		})
+	}
+}
+
+func TestLoad_ConfigFileInvalidEnvironmentOverride(t *testing.T) {
+	env := minimalValidEnv()
+	env["CONDUCTOR_HTTP_PORT"] = "eighty"
+	writeConfigFile(t, env, "http_port: 8181\n")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.Server.HTTPPort, "invalid environment variables fall back to the default")
+}
+
+func TestLoad_MissingConfigFile(t *testing.T) {
+	env := minimalValidEnv()
+	env[fileEnv] = filepath.Join(t.TempDir(), "missing.yaml")
+	setTestEnv(t, env)
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read config file")
+}
+
+func TestConfig_Changed(t *testing.T) {
+	env := minimalValidEnv()
+	path := writeConfigFile(t, env, "log:\n  level: info\nhttp_port: 8181\n")
+	prev, err := Load()
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: debug\nhttp_port: 8181\nstorage:\n  retention: 48h\n"), 0o600))
+	next, err := Load()
+	require.NoError(t, err)
+
+	assert.Empty(t, prev.Changed(prev))
+	assert.Equal(t, []string{"CONDUCTOR_LOG_LEVEL", "CONDUCTOR_STORAGE_RETENTION"}, next.Changed(prev))
+	assert.True(t, Reloadable("CONDUCTOR_LOG_LEVEL"))
+	assert.False(t, Reloadable("CONDUCTOR_HTTP_PORT"))
+}
+
+func TestWatch(t *testing.T) {
+	env := minimalValidEnv()
+	path := writeConfigFile(t, env, "log:\n  level: info\n")
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	type reload struct {
+		prev, next *Config
+		err        error
+	}
+	reloads := make(chan reload, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go Watch(ctx, cfg, 10*time.Millisecond, func(prev, next *Config, err error) {
+		reloads <- reload{prev, next, err}
+	})
+
+	// Changing the size as well as the modification time
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: debug\n"), 0o600))
+	r := <-reloads
+	require.NoError(t, r.err)
+	assert.Same(t, cfg, r.prev)
+	assert.Equal(t, "debug", r.next.Log.Level)
+
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: verbose\n"), 0o600))
+	r = <-reloads
+	require.Error(t, r.err)
+	assert.Nil(t, r.next)
+
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: warn\n"), 0o600))
+	r = <-reloads
+	require.NoError(t, r.err)
+	assert.Equal(t, "debug", r.prev.Log.Level, "invalid configurations are not used")
+	assert.Equal(t, "warn", r.next.Log.Level)
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("level", "level"))
+	assert.Equal(t, 2, editDistance("levle", "level"))
+	assert.Equal(t, 5, editDistance("", "level"))
+	assert.Equal(t, "CONDUCTOR_HTTP_PORT", closestSetting("CONDUCTOR_HTTP_PROT", map[string]string{"CONDUCTOR_HTTP_PORT": ""}))
+	assert.Empty(t, closestSetting("CONDUCTOR_FROBNICATE", map[string]string{"CONDUCTOR_HTTP_PORT": ""}))
+}
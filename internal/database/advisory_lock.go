@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AdvisoryLock is a Postgres session-level advisory lock held on a
+// connection taken out of the pool. The lock lives as long as the session,
+// so it is released when the lock is unlocked, when the connection is
+// closed or when Postgres notices the session is gone.
+type AdvisoryLock struct {
+	id   int64
+	conn *pgx.Conn
+}
+
+// TryAdvisoryLock takes the advisory lock with the given ID without
+// waiting. It returns nil if another session holds it.
+func (db *DB) TryAdvisoryLock(ctx context.Context, id int64) (*AdvisoryLock, error) {
+	conn, err := db.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, id).Scan(&locked); err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+	if !locked {
+		conn.Release()
+		return nil, nil
+	}
+
+	// Keep the session out of the pool, so that no query runs on it while it
+	// holds the lock and closing it releases the lock.
+	return &AdvisoryLock{id: id, conn: conn.Hijack()}, nil
+}
+
+// Ping checks that the session holding the lock is alive. An error means
+// the lock may have been lost.
+func (l *AdvisoryLock) Ping(ctx context.Context) error {
+	if err := l.conn.Ping(ctx); err != nil {
+		return fmt.Errorf("advisory lock session lost: %w", err)
+	}
+	return nil
+}
+
+// Unlock releases the lock and closes its session.
+func (l *AdvisoryLock) Unlock(ctx context.Context) error {
+	_, err := l.conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, l.id)
+	if closeErr := l.conn.Close(ctx); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release advisory lock: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// agentConnectionRepo implements AgentConnectionRepository.
+type agentConnectionRepo struct {
+	db *DB
+}
+
+// NewAgentConnectionRepo creates a new agent connection repository.
+func NewAgentConnectionRepo(db *DB) AgentConnectionRepository {
+	return &agentConnectionRepo{db: db}
+}
+
+// Register records the replica an agent connected to.
+func (r *agentConnectionRepo) Register(ctx context.Context, conn *AgentConnection) error {
+	err := r.db.pool.QueryRow(ctx, AgentConnectionUpsert,
+		conn.AgentID, conn.ReplicaID, conn.ReplicaAddress,
+	).Scan(&conn.ConnectedAt)
+	if err != nil {
+		return fmt.Errorf("failed to register agent connection: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// Get retrieves the connection of an agent.
+func (r *agentConnectionRepo) Get(ctx context.Context, agentID uuid.UUID) (*AgentConnection, error) {
+	conn := &AgentConnection{}
+	err := r.db.pool.QueryRow(ctx, AgentConnectionGet, agentID).Scan(
+		&conn.AgentID, &conn.ReplicaID, &conn.ReplicaAddress, &conn.ConnectedAt,
+	)
+	if err != nil {
+		return nil, WrapDBError(err)
+	}
+	return conn, nil
+}
+
+// Unregister removes the connection of an agent to a replica.
+func (r *agentConnectionRepo) Unregister(ctx context.Context, agentID uuid.UUID, replicaID string) error {
+	if _, err := r.db.pool.Exec(ctx, AgentConnectionDelete, agentID, replicaID); err != nil {
+		return fmt.Errorf("failed to unregister agent connection: %w", err)
+	}
+	return nil
+}
+
+// UnregisterReplica removes every connection to a replica.
+func (r *agentConnectionRepo) UnregisterReplica(ctx context.Context, replicaID string) (int64, error) {
+	result, err := r.db.pool.Exec(ctx, AgentConnectionDeleteByReplica, replicaID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to unregister replica connections: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -27,6 +28,9 @@ func (r *agentRepo) Create(ctx context.Context, agent *Agent) error {
 		agent.NetworkZones,
 		agent.MaxParallel,
 		agent.DockerAvailable,
+		agentLabels(agent.Labels),
+		agent.Pool,
+		projectIDOrDefault(agent.ProjectID),
 	).Scan(&agent.ID, &agent.RegisteredAt)
 
 	if err != nil {
@@ -38,7 +42,7 @@ func (r *agentRepo) Create(ctx context.Context, agent *Agent) error {
 // Get retrieves an agent by ID.
 func (r *agentRepo) Get(ctx context.Context, id uuid.UUID) (*Agent, error) {
 	agent := &Agent{}
-	err := r.db.pool.QueryRow(ctx, AgentGetByID, id).Scan(
+	err := r.db.reader(ctx).QueryRow(ctx, AgentGetByID, id, projectScopeArg(ctx)).Scan(
 		&agent.ID,
 		&agent.Name,
 		&agent.Status,
@@ -48,6 +52,9 @@ func (r *agentRepo) Get(ctx context.Context, id uuid.UUID) (*Agent, error) {
 		&agent.DockerAvailable,
 		&agent.LastHeartbeat,
 		&agent.RegisteredAt,
+		&agent.Labels,
+		&agent.Pool,
+		&agent.ProjectID,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -61,7 +68,7 @@ func (r *agentRepo) Get(ctx context.Context, id uuid.UUID) (*Agent, error) {
 // GetByName retrieves an agent by name.
 func (r *agentRepo) GetByName(ctx context.Context, name string) (*Agent, error) {
 	agent := &Agent{}
-	err := r.db.pool.QueryRow(ctx, AgentGetByName, name).Scan(
+	err := r.db.reader(ctx).QueryRow(ctx, AgentGetByName, name, projectScopeArg(ctx)).Scan(
 		&agent.ID,
 		&agent.Name,
 		&agent.Status,
@@ -71,6 +78,9 @@ func (r *agentRepo) GetByName(ctx context.Context, name string) (*Agent, error)
 		&agent.DockerAvailable,
 		&agent.LastHeartbeat,
 		&agent.RegisteredAt,
+		&agent.Labels,
+		&agent.Pool,
+		&agent.ProjectID,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -91,6 +101,10 @@ func (r *agentRepo) Update(ctx context.Context, agent *Agent) error {
 		agent.NetworkZones,
 		agent.MaxParallel,
 		agent.DockerAvailable,
+		agentLabels(agent.Labels),
+		agent.Pool,
+		projectIDOrDefault(agent.ProjectID),
+		projectScopeArg(ctx),
 	)
 
 	if err != nil {
@@ -104,7 +118,7 @@ func (r *agentRepo) Update(ctx context.Context, agent *Agent) error {
 
 // Delete deletes an agent.
 func (r *agentRepo) Delete(ctx context.Context, id uuid.UUID) error {
-	result, err := r.db.pool.Exec(ctx, AgentDelete, id)
+	result, err := r.db.pool.Exec(ctx, AgentDelete, id, projectScopeArg(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to delete agent: %w", err)
 	}
@@ -116,7 +130,8 @@ func (r *agentRepo) Delete(ctx context.Context, id uuid.UUID) error {
 
 // List returns agents with pagination.
 func (r *agentRepo) List(ctx context.Context, page Pagination) ([]Agent, error) {
-	rows, err := r.db.pool.Query(ctx, AgentList, page.Limit, page.Offset)
+	afterName, afterID := page.afterName()
+	rows, err := r.db.reader(ctx).Query(ctx, AgentList, page.Limit, page.Offset, projectScopeArg(ctx), afterName, afterID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list agents: %w", err)
 	}
@@ -127,7 +142,8 @@ func (r *agentRepo) List(ctx context.Context, page Pagination) ([]Agent, error)
 
 // ListByStatus returns agents with a specific status.
 func (r *agentRepo) ListByStatus(ctx context.Context, status AgentStatus, page Pagination) ([]Agent, error) {
-	rows, err := r.db.pool.Query(ctx, AgentListByStatus, status, page.Limit, page.Offset)
+	afterName, afterID := page.afterName()
+	rows, err := r.db.reader(ctx).Query(ctx, AgentListByStatus, status, page.Limit, page.Offset, projectScopeArg(ctx), afterName, afterID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list agents by status: %w", err)
 	}
@@ -171,18 +187,21 @@ func (r *agentRepo) GetAvailable(ctx context.Context, zones []string, limit int)
 	return scanAgents(rows)
 }
 
-// MarkOfflineAgents marks agents as offline if they haven't sent a heartbeat recently.
-func (r *agentRepo) MarkOfflineAgents(ctx context.Context) (int64, error) {
-	result, err := r.db.pool.Exec(ctx, AgentMarkOffline)
+// MarkOfflineAgents marks agents as offline whose last heartbeat is older
+// than timeout and returns them.
+func (r *agentRepo) MarkOfflineAgents(ctx context.Context, timeout time.Duration) ([]Agent, error) {
+	rows, err := r.db.pool.Query(ctx, AgentMarkOffline, timeout.Seconds())
 	if err != nil {
-		return 0, fmt.Errorf("failed to mark agents offline: %w", err)
+		return nil, fmt.Errorf("failed to mark agents offline: %w", err)
 	}
-	return result.RowsAffected(), nil
+	defer rows.Close()
+
+	return scanAgents(rows)
 }
 
 // CountByStatus returns the count of agents grouped by status.
 func (r *agentRepo) CountByStatus(ctx context.Context) (map[AgentStatus]int64, error) {
-	rows, err := r.db.pool.Query(ctx, AgentCount)
+	rows, err := r.db.reader(ctx).Query(ctx, AgentCount, projectScopeArg(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to count agents by status: %w", err)
 	}
@@ -220,6 +239,9 @@ func scanAgents(rows pgx.Rows) ([]Agent, error) {
 			&agent.DockerAvailable,
 			&agent.LastHeartbeat,
 			&agent.RegisteredAt,
+			&agent.Labels,
+			&agent.Pool,
+			&agent.ProjectID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan agent: %w", err)
@@ -233,3 +255,174 @@ func scanAgents(rows pgx.Rows) ([]Agent, error) {
 
 	return agents, nil
 }
+
+// agentLabels returns labels for the non-null labels column.
+func agentLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return map[string]string{}
+	}
+	return labels
+}
+
+// agentPoolRepo implements AgentPoolRepository.
+type agentPoolRepo struct {
+	db *DB
+}
+
+// NewAgentPoolRepo creates a new agent pool repository.
+func NewAgentPoolRepo(db *DB) AgentPoolRepository {
+	return &agentPoolRepo{db: db}
+}
+
+// Create creates a new agent pool.
+func (r *agentPoolRepo) Create(ctx context.Context, pool *AgentPool) error {
+	err := r.db.pool.QueryRow(ctx, AgentPoolInsert,
+		pool.Name,
+		pool.Description,
+		pool.MaxConcurrency,
+		pool.MaxConcurrencyPerService,
+		pool.MaxArtifactBytes,
+	).Scan(&pool.ID, &pool.CreatedAt, &pool.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create agent pool: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// GetByName retrieves an agent pool by name.
+func (r *agentPoolRepo) GetByName(ctx context.Context, name string) (*AgentPool, error) {
+	pool := &AgentPool{}
+	err := r.db.reader(ctx).QueryRow(ctx, AgentPoolGetByName, name).Scan(
+		&pool.ID,
+		&pool.Name,
+		&pool.Description,
+		&pool.MaxConcurrency,
+		&pool.MaxConcurrencyPerService,
+		&pool.MaxArtifactBytes,
+		&pool.CreatedAt,
+		&pool.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get agent pool: %w", err)
+	}
+	return pool, nil
+}
+
+// List returns all agent pools.
+func (r *agentPoolRepo) List(ctx context.Context) ([]AgentPool, error) {
+	rows, err := r.db.reader(ctx).Query(ctx, AgentPoolList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent pools: %w", err)
+	}
+	defer rows.Close()
+
+	var pools []AgentPool
+	for rows.Next() {
+		var pool AgentPool
+		err := rows.Scan(
+			&pool.ID,
+			&pool.Name,
+			&pool.Description,
+			&pool.MaxConcurrency,
+			&pool.MaxConcurrencyPerService,
+			&pool.MaxArtifactBytes,
+			&pool.CreatedAt,
+			&pool.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan agent pool: %w", err)
+		}
+		pools = append(pools, pool)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating agent pools: %w", err)
+	}
+
+	return pools, nil
+}
+
+// Update updates the description, quotas and artifact limit of an agent pool.
+func (r *agentPoolRepo) Update(ctx context.Context, pool *AgentPool) error {
+	err := r.db.pool.QueryRow(ctx, AgentPoolUpdate,
+		pool.Name,
+		pool.Description,
+		pool.MaxConcurrency,
+		pool.MaxConcurrencyPerService,
+		pool.MaxArtifactBytes,
+	).Scan(&pool.ID, &pool.CreatedAt, &pool.UpdatedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to update agent pool: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// Delete deletes an agent pool by name.
+func (r *agentPoolRepo) Delete(ctx context.Context, name string) error {
+	result, err := r.db.pool.Exec(ctx, AgentPoolDelete, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete agent pool: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Stats returns the current usage of every agent pool.
+func (r *agentPoolRepo) Stats(ctx context.Context) ([]AgentPoolStats, error) {
+	rows, err := r.db.reader(ctx).Query(ctx, AgentPoolGetStats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent pool stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []AgentPoolStats
+	for rows.Next() {
+		var s AgentPoolStats
+		if err := rows.Scan(&s.Pool, &s.Agents, &s.OnlineAgents, &s.RunningShards); err != nil {
+			return nil, fmt.Errorf("failed to scan agent pool stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating agent pool stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// RunningShardsByService counts the running shards on the agents of a pool
+// per service.
+func (r *agentPoolRepo) RunningShardsByService(ctx context.Context, name string) (map[uuid.UUID]int, error) {
+	rows, err := r.db.pool.Query(ctx, AgentPoolRunningShards, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count running shards of agent pool: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var serviceID uuid.UUID
+		var count int
+		if err := rows.Scan(&serviceID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan running shard count: %w", err)
+		}
+		counts[serviceID] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating running shard counts: %w", err)
+	}
+
+	return counts, nil
+}
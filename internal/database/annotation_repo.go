@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// runAnnotationRepo implements RunAnnotationRepository.
+type runAnnotationRepo struct {
+	db *DB
+}
+
+// NewRunAnnotationRepo creates a new run annotation repository.
+func NewRunAnnotationRepo(db *DB) RunAnnotationRepository {
+	return &runAnnotationRepo{db: db}
+}
+
+// Create records an annotation of a run.
+func (r *runAnnotationRepo) Create(ctx context.Context, annotation *RunAnnotation) error {
+	if annotation.CreatedAt.IsZero() {
+		annotation.CreatedAt = time.Now()
+	}
+	if annotation.Severity == "" {
+		annotation.Severity = AnnotationSeverityWarning
+	}
+	metadata := annotation.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	err := r.db.pool.QueryRow(ctx, RunAnnotationInsert,
+		annotation.RunID,
+		annotation.ShardID,
+		annotation.AgentID,
+		annotation.Kind,
+		annotation.Severity,
+		annotation.Message,
+		metadata,
+		annotation.CreatedAt,
+	).Scan(&annotation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create run annotation: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// ListByRun lists the annotations of a run, oldest first.
+func (r *runAnnotationRepo) ListByRun(ctx context.Context, runID uuid.UUID) ([]RunAnnotation, error) {
+	rows, err := r.db.reader(ctx).Query(ctx, RunAnnotationListByRun, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list run annotations: %w", err)
+	}
+	defer rows.Close()
+
+	var annotations []RunAnnotation
+	for rows.Next() {
+		var a RunAnnotation
+		err := rows.Scan(
+			&a.ID,
+			&a.RunID,
+			&a.ShardID,
+			&a.AgentID,
+			&a.Kind,
+			&a.Severity,
+			&a.Message,
+			&a.Metadata,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan run annotation: %w", err)
+		}
+		annotations = append(annotations, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating run annotations: %w", err)
+	}
+
+	return annotations, nil
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// artifactBlobRepo implements ArtifactBlobRepository.
+type artifactBlobRepo struct {
+	db *DB
+}
+
+// NewArtifactBlobRepo creates a new artifact blob repository.
+func NewArtifactBlobRepo(db *DB) ArtifactBlobRepository {
+	return &artifactBlobRepo{db: db}
+}
+
+// Upsert records a stored blob, or marks an existing blob with the same
+// digest as referenced now.
+func (r *artifactBlobRepo) Upsert(ctx context.Context, blob *ArtifactBlob) error {
+	err := r.db.pool.QueryRow(ctx, ArtifactBlobUpsert,
+		blob.Digest,
+		blob.Path,
+		blob.SizeBytes,
+		blob.ContentType,
+	).Scan(&blob.Path, &blob.RefCount, &blob.CreatedAt, &blob.LastReferencedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save artifact blob: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// Touch marks a blob as referenced now and returns it.
+func (r *artifactBlobRepo) Touch(ctx context.Context, digest string) (*ArtifactBlob, error) {
+	blob, err := scanArtifactBlob(r.db.pool.QueryRow(ctx, ArtifactBlobTouch, digest))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to touch artifact blob: %w", err)
+	}
+	return blob, nil
+}
+
+// Get retrieves a blob by digest.
+func (r *artifactBlobRepo) Get(ctx context.Context, digest string) (*ArtifactBlob, error) {
+	blob, err := scanArtifactBlob(r.db.pool.QueryRow(ctx, ArtifactBlobGet, digest))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get artifact blob: %w", err)
+	}
+	return blob, nil
+}
+
+// ListUnreferenced returns blobs no artifact holds that were last referenced
+// before a timestamp.
+func (r *artifactBlobRepo) ListUnreferenced(ctx context.Context, before time.Time, limit int) ([]ArtifactBlob, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := r.db.pool.Query(ctx, ArtifactBlobListUnreferenced, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unreferenced artifact blobs: %w", err)
+	}
+	defer rows.Close()
+
+	var blobs []ArtifactBlob
+	for rows.Next() {
+		blob, err := scanArtifactBlob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan artifact blob: %w", err)
+		}
+		blobs = append(blobs, *blob)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating artifact blobs: %w", err)
+	}
+	return blobs, nil
+}
+
+// DeleteUnreferenced deletes a blob record if it is still unreferenced and
+// was last referenced before a timestamp.
+func (r *artifactBlobRepo) DeleteUnreferenced(ctx context.Context, digest string, before time.Time) error {
+	result, err := r.db.pool.Exec(ctx, ArtifactBlobDeleteUnreferenced, digest, before)
+	if err != nil {
+		return fmt.Errorf("failed to delete artifact blob: %w", WrapDBError(err))
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanArtifactBlob(row pgx.Row) (*ArtifactBlob, error) {
+	blob := &ArtifactBlob{}
+	err := row.Scan(
+		&blob.Digest,
+		&blob.Path,
+		&blob.SizeBytes,
+		&blob.ContentType,
+		&blob.RefCount,
+		&blob.CreatedAt,
+		&blob.LastReferencedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
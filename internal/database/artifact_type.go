@@ -0,0 +1,47 @@
+package database
+
+import (
+	"path"
+	"strings"
+)
+
+var (
+	screenshotExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".bmp": true}
+	videoExtensions      = map[string]bool{".mp4": true, ".webm": true, ".mov": true, ".avi": true, ".mkv": true}
+)
+
+// DetectArtifactType returns the type of an artifact from its name, which
+// may include directories, and its content type. Images and videos are
+// recognized by either; coverage reports by coverage tool names anywhere in
+// the name; JUnit reports are XML files named after tests; logs are plain
+// text. Anything else is binary.
+func DetectArtifactType(name, contentType string) ArtifactType {
+	name = strings.ToLower(name)
+	base := path.Base(name)
+	ext := path.Ext(base)
+	mediaType, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	mediaType = strings.TrimSpace(mediaType)
+
+	switch {
+	case strings.HasPrefix(mediaType, "image/") || screenshotExtensions[ext]:
+		return ArtifactTypeScreenshot
+	case strings.HasPrefix(mediaType, "video/") || videoExtensions[ext]:
+		return ArtifactTypeVideo
+	case containsAny(name, "coverage", "lcov", "jacoco", "cobertura") || base == "cover.out":
+		return ArtifactTypeCoverage
+	case ext == ".xml" && containsAny(base, "junit", "test"):
+		return ArtifactTypeJUnit
+	case mediaType == "text/plain" || ext == ".log" || ext == ".txt":
+		return ArtifactTypeLog
+	}
+	return ArtifactTypeBinary
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
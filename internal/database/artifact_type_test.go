@@ -0,0 +1,32 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectArtifactType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		want        ArtifactType
+	}{
+		{"screenshots/login.PNG", "", ArtifactTypeScreenshot},
+		{"failure", "image/jpeg", ArtifactTypeScreenshot},
+		{"videos/checkout.webm", "", ArtifactTypeVideo},
+		{"coverage/index.html", "text/html", ArtifactTypeCoverage},
+		{"lcov.info", "", ArtifactTypeCoverage},
+		{"cover.out", "text/plain", ArtifactTypeCoverage},
+		{"reports/TEST-com.example.ApiTest.xml", "application/xml", ArtifactTypeJUnit},
+		{"junit.xml", "", ArtifactTypeJUnit},
+		{"tests/config.xml", "", ArtifactTypeBinary},
+		{"server.log", "", ArtifactTypeLog},
+		{"output", "text/plain; charset=utf-8", ArtifactTypeLog},
+		{"bin/api", "application/octet-stream", ArtifactTypeBinary},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, DetectArtifactType(tt.name, tt.contentType), tt.name)
+	}
+}
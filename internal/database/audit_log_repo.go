@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// auditLogRepo implements AuditLogRepository.
+type auditLogRepo struct {
+	db *DB
+}
+
+// NewAuditLogRepo creates a new audit log repository.
+func NewAuditLogRepo(db *DB) AuditLogRepository {
+	return &auditLogRepo{db: db}
+}
+
+// Create records an audited call.
+func (r *auditLogRepo) Create(ctx context.Context, log *AuditLog) error {
+	err := r.db.pool.QueryRow(ctx, AuditLogInsert,
+		log.Actor,
+		log.ActorEmail,
+		log.Action,
+		log.Protocol,
+		log.ResourceType,
+		log.ResourceID,
+		log.Request,
+		log.Before,
+		log.After,
+		log.Status,
+		log.Error,
+		log.SourceIP,
+		log.RequestID,
+		log.UserAgent,
+	).Scan(&log.ID, &log.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
+
+// List returns audit logs matching the filter, newest first, with the total
+// number of matches.
+func (r *auditLogRepo) List(ctx context.Context, filter AuditLogFilter, page Pagination) ([]AuditLog, int, error) {
+	args := []any{
+		filter.Actor,
+		filter.Action,
+		filter.ResourceType,
+		filter.ResourceID,
+		filter.Since,
+		filter.Until,
+	}
+
+	var total int
+	if err := r.db.reader(ctx).QueryRow(ctx, AuditLogCount, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	rows, err := r.db.reader(ctx).Query(ctx, AuditLogList, append(args, page.Limit, page.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []AuditLog
+	for rows.Next() {
+		var l AuditLog
+		err := rows.Scan(
+			&l.ID,
+			&l.Actor,
+			&l.ActorEmail,
+			&l.Action,
+			&l.Protocol,
+			&l.ResourceType,
+			&l.ResourceID,
+			&l.Request,
+			&l.Before,
+			&l.After,
+			&l.Status,
+			&l.Error,
+			&l.SourceIP,
+			&l.RequestID,
+			&l.UserAgent,
+			&l.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		logs = append(logs, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating audit logs: %w", err)
+	}
+	return logs, total, nil
+}
+
+// DeleteBefore deletes audit logs recorded before the given time.
+func (r *auditLogRepo) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.pool.Exec(ctx, AuditLogDeleteBefore, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete audit logs: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
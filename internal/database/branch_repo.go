@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// branchRepo implements BranchRepository.
+type branchRepo struct {
+	db *DB
+}
+
+// NewBranchRepo creates a new branch repository.
+func NewBranchRepo(db *DB) BranchRepository {
+	return &branchRepo{db: db}
+}
+
+// RecordPush creates a branch or moves it to the pushed commit.
+func (r *branchRepo) RecordPush(ctx context.Context, serviceID uuid.UUID, name, sha string) error {
+	_, err := r.db.pool.Exec(ctx, BranchRecordPush, serviceID, name, NullString(sha))
+	if err != nil {
+		return fmt.Errorf("failed to record branch push: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// Get retrieves a branch of a service by name.
+func (r *branchRepo) Get(ctx context.Context, serviceID uuid.UUID, name string) (*Branch, error) {
+	branch, err := scanBranch(r.db.reader(ctx).QueryRow(ctx, BranchGet, serviceID, name))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get branch: %w", err)
+	}
+	return branch, nil
+}
+
+// ListByService lists a service's branches, protected branches first and
+// then by latest activity.
+func (r *branchRepo) ListByService(ctx context.Context, serviceID uuid.UUID, page Pagination) ([]Branch, int, error) {
+	var total int
+	if err := r.db.reader(ctx).QueryRow(ctx, BranchCountByService, serviceID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count branches: %w", err)
+	}
+
+	rows, err := r.db.reader(ctx).Query(ctx, BranchListByService, serviceID, page.Limit, page.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list branches: %w", err)
+	}
+	defer rows.Close()
+
+	var branches []Branch
+	for rows.Next() {
+		branch, err := scanBranch(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan branch: %w", err)
+		}
+		branches = append(branches, *branch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating branches: %w", err)
+	}
+	return branches, total, nil
+}
+
+// SetProtected marks a branch as protected or not.
+func (r *branchRepo) SetProtected(ctx context.Context, serviceID uuid.UUID, name string, protected bool) error {
+	result, err := r.db.pool.Exec(ctx, BranchSetProtected, serviceID, name, protected)
+	if err != nil {
+		return fmt.Errorf("failed to update branch: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a branch of a service.
+func (r *branchRepo) Delete(ctx context.Context, serviceID uuid.UUID, name string) error {
+	result, err := r.db.pool.Exec(ctx, BranchDelete, serviceID, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete branch: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanBranch(row pgx.Row) (*Branch, error) {
+	branch := &Branch{}
+	err := row.Scan(
+		&branch.ServiceID,
+		&branch.Name,
+		&branch.LastSHA,
+		&branch.LastRunID,
+		&branch.Protected,
+		&branch.LastPushedAt,
+		&branch.CreatedAt,
+		&branch.UpdatedAt,
+		&branch.LastRunStatus,
+		&branch.LastRunAt,
+		&branch.RecentRuns,
+		&branch.RecentPassed,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return branch, nil
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// concurrencyGroupRepo implements ConcurrencyGroupRepository.
+type concurrencyGroupRepo struct {
+	db *DB
+}
+
+// NewConcurrencyGroupRepo creates a new concurrency group repository.
+func NewConcurrencyGroupRepo(db *DB) ConcurrencyGroupRepository {
+	return &concurrencyGroupRepo{db: db}
+}
+
+// saveServiceConcurrency saves the concurrency group of a service with q,
+// or deletes it when the group is empty.
+func saveServiceConcurrency(ctx context.Context, q Querier, concurrency *ServiceConcurrency) error {
+	if concurrency.Group == "" {
+		if _, err := q.Exec(ctx, ServiceConcurrencyDelete, concurrency.ServiceID); err != nil {
+			return fmt.Errorf("failed to delete service concurrency group: %w", err)
+		}
+		return nil
+	}
+
+	err := q.QueryRow(ctx, ServiceConcurrencyUpsert,
+		concurrency.ServiceID,
+		concurrency.Group,
+		concurrency.CancelInProgress,
+	).Scan(&concurrency.CreatedAt, &concurrency.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save service concurrency group: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// GetByService retrieves the concurrency group of a service.
+func (r *concurrencyGroupRepo) GetByService(ctx context.Context, serviceID uuid.UUID) (*ServiceConcurrency, error) {
+	concurrency := &ServiceConcurrency{}
+	err := r.db.pool.QueryRow(ctx, ServiceConcurrencyGetByService, serviceID).Scan(
+		&concurrency.ServiceID,
+		&concurrency.Group,
+		&concurrency.CancelInProgress,
+		&concurrency.CreatedAt,
+		&concurrency.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get service concurrency group: %w", err)
+	}
+	return concurrency, nil
+}
+
+// Claim makes a run the holder of its concurrency group unless another
+// pending or running run holds it.
+func (r *concurrencyGroupRepo) Claim(ctx context.Context, group string, runID uuid.UUID) (bool, error) {
+	var holder uuid.UUID
+	err := r.db.pool.QueryRow(ctx, ConcurrencyGroupClaim, group, runID).Scan(&holder)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to claim concurrency group: %w", err)
+	}
+	return true, nil
+}
+
+// ListActive returns the IDs of the pending and running runs of a group.
+func (r *concurrencyGroupRepo) ListActive(ctx context.Context, group string) ([]uuid.UUID, error) {
+	rows, err := r.db.pool.Query(ctx, ConcurrencyGroupListActive, group)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs of concurrency group: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan run ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
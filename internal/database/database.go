@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -38,6 +39,27 @@ type Config struct {
 	// HealthCheckPeriod is the interval between health checks.
 	// Default: 1 minute
 	HealthCheckPeriod time.Duration
+
+	// SlowQueryThreshold logs queries that take at least this long.
+	// Default: 0 (disabled)
+	SlowQueryThreshold time.Duration
+
+	// Logger receives slow query and read replica logs. Default: slog.Default()
+	Logger *slog.Logger
+
+	// ReplicaURL is the connection string of a read replica. Read-only
+	// queries made with a context from WithReplicaReads are sent to it.
+	// Default: "" (no replica)
+	ReplicaURL string
+
+	// ReplicaMaxLag is the replication lag above which reads fall back to
+	// the primary.
+	// Default: 10 seconds
+	ReplicaMaxLag time.Duration
+
+	// ReplicaCheckInterval is the interval between replication lag checks.
+	// Default: 5 seconds
+	ReplicaCheckInterval time.Duration
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -49,17 +71,51 @@ func DefaultConfig(url string) Config {
 		MaxConnLifetime:   time.Hour,
 		MaxConnIdleTime:   30 * time.Minute,
 		HealthCheckPeriod: time.Minute,
+
+		ReplicaMaxLag:        10 * time.Second,
+		ReplicaCheckInterval: 5 * time.Second,
 	}
 }
 
 // DB wraps a pgxpool.Pool and provides database operations.
 type DB struct {
 	pool *pgxpool.Pool
+
+	// replica serves read-only queries when configured.
+	replica *replica
 }
 
-// New creates a new database connection pool.
+// New creates a new database connection pool. If cfg.ReplicaURL is set, it
+// also connects to the read replica and starts monitoring its replication
+// lag.
 func New(ctx context.Context, cfg Config) (*DB, error) {
-	poolConfig, err := pgxpool.ParseConfig(cfg.URL)
+	pool, err := newPool(ctx, cfg.URL, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify connectivity
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	db := &DB{pool: pool}
+	if cfg.ReplicaURL != "" {
+		replicaPool, err := newPool(ctx, cfg.ReplicaURL, cfg)
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("read replica: %w", err)
+		}
+		db.replica = newReplica(replicaPool, cfg)
+		db.replica.start(ctx)
+	}
+	return db, nil
+}
+
+// newPool creates a connection pool to url with the settings of cfg.
+func newPool(ctx context.Context, url string, cfg Config) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse database URL: %w", err)
 	}
@@ -80,23 +136,22 @@ func New(ctx context.Context, cfg Config) (*DB, error) {
 	if cfg.HealthCheckPeriod > 0 {
 		poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
 	}
+	if cfg.SlowQueryThreshold > 0 {
+		poolConfig.ConnConfig.Tracer = newSlowQueryTracer(cfg.SlowQueryThreshold, cfg.Logger)
+	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create connection pool: %w", err)
 	}
-
-	// Verify connectivity
-	if err := pool.Ping(ctx); err != nil {
-		pool.Close()
-		return nil, fmt.Errorf("failed to ping database: %w", err)
-	}
-
-	return &DB{pool: pool}, nil
+	return pool, nil
 }
 
-// Close closes the database connection pool.
+// Close closes the database connection pools.
 func (db *DB) Close() {
+	if db.replica != nil {
+		db.replica.close()
+	}
 	if db.pool != nil {
 		db.pool.Close()
 	}
@@ -125,12 +180,21 @@ type HealthStats struct {
 	AcquireCount      int64
 	AcquireDuration   time.Duration
 	EmptyAcquireCount int64
+
+	// Replica reports whether a read replica is configured.
+	Replica bool
+	// ReplicaLag is the last measured replication lag of the read replica.
+	ReplicaLag time.Duration
+	// ReplicaInUse reports whether reads are sent to the read replica. They
+	// fall back to the primary while it is unreachable or lags behind.
+	ReplicaInUse bool
 }
 
-// Stats returns connection pool statistics.
+// Stats returns connection pool statistics of the primary and the state of
+// the read replica.
 func (db *DB) Stats() HealthStats {
 	stat := db.pool.Stat()
-	return HealthStats{
+	stats := HealthStats{
 		TotalConns:        stat.TotalConns(),
 		AcquiredConns:     stat.AcquiredConns(),
 		IdleConns:         stat.IdleConns(),
@@ -139,6 +203,12 @@ func (db *DB) Stats() HealthStats {
 		AcquireDuration:   stat.AcquireDuration(),
 		EmptyAcquireCount: stat.EmptyAcquireCount(),
 	}
+	if db.replica != nil {
+		stats.Replica = true
+		stats.ReplicaLag = time.Duration(db.replica.lag.Load())
+		stats.ReplicaInUse = db.replica.usable.Load()
+	}
+	return stats
 }
 
 // TxFunc is a function that executes within a transaction.
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// energyRepo implements EnergyRepository.
+type energyRepo struct {
+	db *DB
+}
+
+// NewEnergyRepo creates a new energy repository.
+func NewEnergyRepo(db *DB) EnergyRepository {
+	return &energyRepo{db: db}
+}
+
+// RecordSample records an energy sample of a run.
+func (r *energyRepo) RecordSample(ctx context.Context, sample *EnergySample) error {
+	if sample.SampledAt.IsZero() {
+		sample.SampledAt = time.Now()
+	}
+
+	err := r.db.pool.QueryRow(ctx, EnergySampleInsert,
+		sample.RunID,
+		sample.AgentID,
+		sample.Zone,
+		sample.CPUPercent,
+		sample.CPUCores,
+		sample.MemoryBytes,
+		sample.EnergyWh,
+		sample.SampledAt,
+	).Scan(&sample.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record energy sample: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// GetRunEnergy sums the energy of a run per zone.
+func (r *energyRepo) GetRunEnergy(ctx context.Context, runID uuid.UUID) ([]ZoneEnergy, error) {
+	rows, err := r.db.reader(ctx).Query(ctx, EnergyGetByRun, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run energy: %w", err)
+	}
+	defer rows.Close()
+
+	var zones []ZoneEnergy
+	for rows.Next() {
+		var zone ZoneEnergy
+		if err := rows.Scan(&zone.Zone, &zone.EnergyWh); err != nil {
+			return nil, fmt.Errorf("failed to scan zone energy: %w", err)
+		}
+		zones = append(zones, zone)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating zone energy: %w", err)
+	}
+
+	return zones, nil
+}
+
+// GetMonthlyEnergy sums the energy of runs per service, month and zone.
+func (r *energyRepo) GetMonthlyEnergy(ctx context.Context, serviceID *uuid.UUID, start, end time.Time) ([]MonthlyEnergy, error) {
+	rows, err := r.db.reader(ctx).Query(ctx, EnergyGetMonthly, serviceID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monthly energy: %w", err)
+	}
+	defer rows.Close()
+
+	var months []MonthlyEnergy
+	for rows.Next() {
+		var m MonthlyEnergy
+		err := rows.Scan(
+			&m.ServiceID,
+			&m.ServiceName,
+			&m.Month,
+			&m.Zone,
+			&m.EnergyWh,
+			&m.RunCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan monthly energy: %w", err)
+		}
+		months = append(months, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating monthly energy: %w", err)
+	}
+
+	return months, nil
+}
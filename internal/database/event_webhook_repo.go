@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// eventWebhookRepo implements EventWebhookRepository.
+type eventWebhookRepo struct {
+	db *DB
+}
+
+// NewEventWebhookRepo creates a new event webhook repository.
+func NewEventWebhookRepo(db *DB) EventWebhookRepository {
+	return &eventWebhookRepo{db: db}
+}
+
+// ListUndispatchedEvents returns up to limit events that have no deliveries
+// yet, oldest first.
+func (r *eventWebhookRepo) ListUndispatchedEvents(ctx context.Context, limit int) ([]RunEvent, error) {
+	rows, err := r.db.pool.Query(ctx, RunEventListUndispatched, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list run events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []RunEvent
+	for rows.Next() {
+		var e RunEvent
+		if err := rows.Scan(scanRunEventDest(&e)...); err != nil {
+			return nil, fmt.Errorf("failed to scan run event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating run events: %w", err)
+	}
+	return events, nil
+}
+
+// DispatchEvent creates a pending delivery of an event to each endpoint and
+// marks the event dispatched. Deliveries that already exist are kept.
+func (r *eventWebhookRepo) DispatchEvent(ctx context.Context, eventID int64, endpoints []string) error {
+	if endpoints == nil {
+		endpoints = []string{}
+	}
+	result, err := r.db.pool.Exec(ctx, RunEventDispatch, eventID, endpoints)
+	if err != nil {
+		return fmt.Errorf("failed to dispatch run event: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ClaimDueDeliveries returns up to limit pending deliveries due at now with
+// their events and postpones them by lease.
+func (r *eventWebhookRepo) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]EventWebhookDelivery, error) {
+	rows, err := r.db.pool.Query(ctx, EventWebhookDeliveryClaimDue, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim event webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []EventWebhookDelivery
+	for rows.Next() {
+		var d EventWebhookDelivery
+		dest := []any{
+			&d.ID,
+			&d.EventID,
+			&d.Endpoint,
+			&d.Status,
+			&d.Attempts,
+			&d.NextAttemptAt,
+			&d.ResponseStatus,
+			&d.Error,
+			&d.CreatedAt,
+			&d.DeliveredAt,
+		}
+		if err := rows.Scan(append(dest, scanRunEventDest(&d.Event)...)...); err != nil {
+			return nil, fmt.Errorf("failed to scan event webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating event webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// UpdateDelivery records the outcome of a delivery attempt.
+func (r *eventWebhookRepo) UpdateDelivery(ctx context.Context, delivery *EventWebhookDelivery) error {
+	result, err := r.db.pool.Exec(ctx, EventWebhookDeliveryUpdate,
+		delivery.ID,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.ResponseStatus,
+		delivery.Error,
+		delivery.DeliveredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update event webhook delivery: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteEventsBefore deletes dispatched events recorded before the given
+// time, with their deliveries, unless a delivery is still pending.
+func (r *eventWebhookRepo) DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.pool.Exec(ctx, RunEventDeleteBefore, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete run events: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// scanRunEventDest returns the scan destinations of a run event row.
+func scanRunEventDest(e *RunEvent) []any {
+	return []any{
+		&e.ID,
+		&e.EventID,
+		&e.Type,
+		&e.RunID,
+		&e.ServiceID,
+		&e.Run,
+		&e.CreatedAt,
+		&e.DispatchedAt,
+	}
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// idempotencyKeyRepo implements IdempotencyKeyRepository.
+type idempotencyKeyRepo struct {
+	db *DB
+}
+
+// NewIdempotencyKeyRepo creates a new idempotency key repository.
+func NewIdempotencyKeyRepo(db *DB) IdempotencyKeyRepository {
+	return &idempotencyKeyRepo{db: db}
+}
+
+// Claim records a key in progress, or returns the unexpired key with the
+// same scope.
+func (r *idempotencyKeyRepo) Claim(ctx context.Context, key *IdempotencyKey) (*IdempotencyKey, error) {
+	// The existing key may be released between the two statements, in
+	// which case the claim is tried again
+	for attempt := 0; attempt < 2; attempt++ {
+		err := r.db.pool.QueryRow(ctx, IdempotencyKeyClaim,
+			key.Scope, key.Key, key.RequestHash, key.ExpiresAt,
+		).Scan(&key.CreatedAt)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+		}
+
+		existing, err := r.get(ctx, key.Scope, key.Key)
+		if err == nil {
+			return existing, nil
+		}
+		if !IsNotFound(err) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("failed to claim idempotency key: %s is contended", key.Key)
+}
+
+// get retrieves a key.
+func (r *idempotencyKeyRepo) get(ctx context.Context, scope, key string) (*IdempotencyKey, error) {
+	k := &IdempotencyKey{}
+	err := r.db.pool.QueryRow(ctx, IdempotencyKeyGet, scope, key).Scan(
+		&k.Scope, &k.Key, &k.RequestHash, &k.Response, &k.CreatedAt, &k.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return k, nil
+}
+
+// Complete stores the response of a claimed key.
+func (r *idempotencyKeyRepo) Complete(ctx context.Context, scope, key string, response []byte, expiresAt time.Time) error {
+	result, err := r.db.pool.Exec(ctx, IdempotencyKeyComplete, scope, key, response, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Release deletes a claimed key.
+func (r *idempotencyKeyRepo) Release(ctx context.Context, scope, key string) error {
+	if _, err := r.db.pool.Exec(ctx, IdempotencyKeyRelease, scope, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired deletes the keys that expired before the given time.
+func (r *idempotencyKeyRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.pool.Exec(ctx, IdempotencyKeyDeleteExpired, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
@@ -4,9 +4,13 @@
 package database
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"io/fs"
+	"math"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -496,6 +500,94 @@ func TestTestDefinitionRepository(t *testing.T) {
 		assert.Equal(t, def.Tags, fetched.Tags)
 	})
 
+	t.Run("ContainerImage", func(t *testing.T) {
+		def := &TestDefinition{
+			ServiceID:      svc.ID,
+			Name:           "test-def-image-" + uuid.New().String()[:8],
+			ExecutionType:  "container",
+			Command:        "npx playwright test",
+			TimeoutSeconds: 600,
+			ContainerImage: NullString("mcr.microsoft.com/playwright:v1.40.0"),
+		}
+		require.NoError(t, defRepo.Create(ctx, def))
+		defer defRepo.Delete(ctx, def.ID)
+
+		fetched, err := defRepo.Get(ctx, def.ID)
+		require.NoError(t, err)
+		require.NotNil(t, fetched.ContainerImage)
+		assert.Equal(t, "mcr.microsoft.com/playwright:v1.40.0", *fetched.ContainerImage)
+	})
+
+	t.Run("Architectures", func(t *testing.T) {
+		def := &TestDefinition{
+			ServiceID:      svc.ID,
+			Name:           "test-def-arch-" + uuid.New().String()[:8],
+			ExecutionType:  "subprocess",
+			Command:        "go test ./...",
+			TimeoutSeconds: 600,
+			Architectures:  []string{"amd64", "arm64"},
+		}
+		require.NoError(t, defRepo.Create(ctx, def))
+		defer defRepo.Delete(ctx, def.ID)
+
+		fetched, err := defRepo.Get(ctx, def.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"amd64", "arm64"}, fetched.Architectures)
+	})
+
+	t.Run("Matrix", func(t *testing.T) {
+		def := &TestDefinition{
+			ServiceID:      svc.ID,
+			Name:           "test-def-matrix-" + uuid.New().String()[:8],
+			ExecutionType:  "subprocess",
+			Command:        "go test ./...",
+			TimeoutSeconds: 600,
+			Matrix: &TestMatrix{
+				Env:    map[string][]string{"GO_VERSION": {"1.22", "1.23"}},
+				Labels: map[string][]string{"os": {"linux"}},
+			},
+		}
+		require.NoError(t, defRepo.Create(ctx, def))
+		defer defRepo.Delete(ctx, def.ID)
+
+		fetched, err := defRepo.Get(ctx, def.ID)
+		require.NoError(t, err)
+		assert.Equal(t, def.Matrix, fetched.Matrix)
+
+		fetched.Matrix = nil
+		require.NoError(t, defRepo.Update(ctx, fetched))
+		fetched, err = defRepo.Get(ctx, def.ID)
+		require.NoError(t, err)
+		assert.Nil(t, fetched.Matrix)
+	})
+
+	t.Run("Parameters", func(t *testing.T) {
+		staging := "staging"
+		def := &TestDefinition{
+			ServiceID:      svc.ID,
+			Name:           "test-def-parameters-" + uuid.New().String()[:8],
+			ExecutionType:  "subprocess",
+			Command:        "npm run e2e",
+			TimeoutSeconds: 600,
+			Parameters: TestParameters{
+				{Name: "TARGET_ENV", Default: &staging, Choices: []string{"staging", "production"}},
+				{Name: "WORKERS", Type: TestParameterInteger, Required: true},
+			},
+		}
+		require.NoError(t, defRepo.Create(ctx, def))
+		defer defRepo.Delete(ctx, def.ID)
+
+		fetched, err := defRepo.Get(ctx, def.ID)
+		require.NoError(t, err)
+		assert.Equal(t, def.Parameters, fetched.Parameters)
+
+		fetched.Parameters = nil
+		require.NoError(t, defRepo.Update(ctx, fetched))
+		fetched, err = defRepo.Get(ctx, def.ID)
+		require.NoError(t, err)
+		assert.Empty(t, fetched.Parameters)
+	})
+
 	t.Run("Update", func(t *testing.T) {
 		def := &TestDefinition{
 			ServiceID:      svc.ID,
@@ -577,6 +669,61 @@ func TestTestDefinitionRepository(t *testing.T) {
 		_, err = defRepo.Get(ctx, def.ID)
 		assert.True(t, IsNotFound(err))
 	})
+
+	t.Run("ApplySync", func(t *testing.T) {
+		envRepo := NewServiceEnvironmentRepo(testDB.db)
+		existing := &TestDefinition{
+			ServiceID:      svc.ID,
+			Name:           "test-def-sync-" + uuid.New().String()[:8],
+			ExecutionType:  "subprocess",
+			Command:        "make",
+			TimeoutSeconds: 300,
+		}
+		require.NoError(t, defRepo.Create(ctx, existing))
+		defer defRepo.Delete(ctx, existing.ID)
+
+		added := &TestDefinition{
+			ServiceID:      svc.ID,
+			Name:           "test-def-sync-" + uuid.New().String()[:8],
+			ExecutionType:  "subprocess",
+			Command:        "make",
+			TimeoutSeconds: 300,
+		}
+		updated := *existing
+		updated.Command = "make test"
+
+		// A failing update rolls back the create before it
+		missing := updated
+		missing.ID = uuid.New()
+		err := defRepo.ApplySync(ctx, &TestDefinitionSync{
+			Create: []*TestDefinition{added},
+			Update: []*TestDefinition{&missing},
+		})
+		require.Error(t, err)
+		defs, err := defRepo.ListByService(ctx, svc.ID, DefaultPagination())
+		require.NoError(t, err)
+		for _, def := range defs {
+			assert.NotEqual(t, added.Name, def.Name)
+		}
+
+		err = defRepo.ApplySync(ctx, &TestDefinitionSync{
+			Create:      []*TestDefinition{added},
+			Update:      []*TestDefinition{&updated},
+			Environment: &ServiceEnvironment{ServiceID: svc.ID, Environment: map[string]string{"CI": "true"}},
+		})
+		require.NoError(t, err)
+		defer defRepo.Delete(ctx, added.ID)
+
+		got, err := defRepo.Get(ctx, existing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "make test", got.Command)
+		_, err = defRepo.Get(ctx, added.ID)
+		require.NoError(t, err)
+
+		env, err := envRepo.GetByService(ctx, svc.ID)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"CI": "true"}, env.Environment)
+	})
 }
 
 // ============================================================================
@@ -752,821 +899,3209 @@ func TestAgentRepository(t *testing.T) {
 		require.NoError(t, err)
 		assert.GreaterOrEqual(t, counts[AgentStatusIdle], int64(1))
 	})
-}
-
-// ============================================================================
-// TEST RUN REPOSITORY TESTS
-// ============================================================================
-
-func TestTestRunRepository(t *testing.T) {
-	ctx := context.Background()
-	svcRepo := NewServiceRepo(testDB.db)
-	runRepo := NewRunRepo(testDB.db)
-	agentRepo := NewAgentRepo(testDB.db)
-
-	// Create a service
-	svc := &Service{
-		Name:          "test-run-service-" + uuid.New().String()[:8],
-		GitURL:        "https://github.com/example/repo.git",
-		DefaultBranch: "main",
-	}
-	err := svcRepo.Create(ctx, svc)
-	require.NoError(t, err)
-	defer svcRepo.Delete(ctx, svc.ID)
-
-	t.Run("Create", func(t *testing.T) {
-		gitRef := "main"
-		gitSHA := "abc123"
-		triggerType := TriggerTypeManual
-		triggeredBy := "test-user"
 
-		run := &TestRun{
-			ServiceID:   svc.ID,
-			Status:      RunStatusPending,
-			GitRef:      &gitRef,
-			GitSHA:      &gitSHA,
-			TriggerType: &triggerType,
-			TriggeredBy: &triggeredBy,
-			Priority:    1,
+	t.Run("MarkOfflineAgents", func(t *testing.T) {
+		stale := &Agent{
+			Name:         "test-agent-stale-" + uuid.New().String()[:8],
+			Status:       AgentStatusIdle,
+			NetworkZones: []string{"default"},
+			MaxParallel:  4,
 		}
+		require.NoError(t, repo.Create(ctx, stale))
+		defer repo.Delete(ctx, stale.ID)
 
-		err := runRepo.Create(ctx, run)
-		require.NoError(t, err)
-		assert.NotEqual(t, uuid.Nil, run.ID)
-		assert.False(t, run.CreatedAt.IsZero())
-
-		t.Cleanup(func() {
-			testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
-		})
-	})
-
-	t.Run("Get", func(t *testing.T) {
-		gitRef := "feature-branch"
-		run := &TestRun{
-			ServiceID: svc.ID,
-			Status:    RunStatusPending,
-			GitRef:    &gitRef,
+		live := &Agent{
+			Name:         "test-agent-live-" + uuid.New().String()[:8],
+			Status:       AgentStatusIdle,
+			NetworkZones: []string{"default"},
+			MaxParallel:  4,
 		}
-		err := runRepo.Create(ctx, run)
-		require.NoError(t, err)
-		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+		require.NoError(t, repo.Create(ctx, live))
+		defer repo.Delete(ctx, live.ID)
+		require.NoError(t, repo.UpdateHeartbeat(ctx, live.ID, AgentStatusIdle))
 
-		fetched, err := runRepo.Get(ctx, run.ID)
+		agents, err := repo.MarkOfflineAgents(ctx, time.Minute)
 		require.NoError(t, err)
-		assert.Equal(t, run.ServiceID, fetched.ServiceID)
-		assert.Equal(t, run.Status, fetched.Status)
-		assert.Equal(t, *run.GitRef, *fetched.GitRef)
-	})
 
-	t.Run("UpdateStatus", func(t *testing.T) {
-		run := &TestRun{
-			ServiceID: svc.ID,
-			Status:    RunStatusPending,
+		var marked []uuid.UUID
+		for _, a := range agents {
+			marked = append(marked, a.ID)
 		}
-		err := runRepo.Create(ctx, run)
-		require.NoError(t, err)
-		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+		assert.Contains(t, marked, stale.ID)
+		assert.NotContains(t, marked, live.ID)
 
-		err = runRepo.UpdateStatus(ctx, run.ID, RunStatusRunning)
+		fetched, err := repo.Get(ctx, live.ID)
 		require.NoError(t, err)
+		assert.Equal(t, AgentStatusIdle, fetched.Status)
 
-		fetched, err := runRepo.Get(ctx, run.ID)
+		fetched, err = repo.Get(ctx, stale.ID)
 		require.NoError(t, err)
-		assert.Equal(t, RunStatusRunning, fetched.Status)
+		assert.Equal(t, AgentStatusOffline, fetched.Status)
 	})
 
-	t.Run("Start", func(t *testing.T) {
-		// Create an agent
+	t.Run("Labels", func(t *testing.T) {
 		agent := &Agent{
-			Name:         "test-run-agent-" + uuid.New().String()[:8],
+			Name:         "test-agent-labels-" + uuid.New().String()[:8],
 			Status:       AgentStatusIdle,
 			NetworkZones: []string{"default"},
-			MaxParallel:  4,
-		}
-		err := agentRepo.Create(ctx, agent)
-		require.NoError(t, err)
-		defer agentRepo.Delete(ctx, agent.ID)
-
-		run := &TestRun{
-			ServiceID: svc.ID,
-			Status:    RunStatusPending,
+			MaxParallel:  1,
+			Labels:       map[string]string{"gpu": "true", "os": "linux"},
 		}
-		err = runRepo.Create(ctx, run)
-		require.NoError(t, err)
-		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+		require.NoError(t, repo.Create(ctx, agent))
+		defer repo.Delete(ctx, agent.ID)
 
-		err = runRepo.Start(ctx, run.ID, agent.ID)
+		fetched, err := repo.Get(ctx, agent.ID)
 		require.NoError(t, err)
+		assert.Equal(t, agent.Labels, fetched.Labels)
 
-		fetched, err := runRepo.Get(ctx, run.ID)
+		agent.Labels = nil
+		require.NoError(t, repo.Update(ctx, agent))
+		fetched, err = repo.Get(ctx, agent.ID)
 		require.NoError(t, err)
-		assert.Equal(t, RunStatusRunning, fetched.Status)
-		assert.Equal(t, agent.ID, *fetched.AgentID)
-		assert.NotNil(t, fetched.StartedAt)
+		assert.Empty(t, fetched.Labels)
 	})
+}
 
-	t.Run("Finish", func(t *testing.T) {
-		run := &TestRun{
-			ServiceID: svc.ID,
-			Status:    RunStatusRunning,
-		}
-		err := runRepo.Create(ctx, run)
-		require.NoError(t, err)
-		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+func TestAgentPoolRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewAgentPoolRepo(testDB.db)
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	agentRepo := NewAgentRepo(testDB.db)
+	shardRepo := NewRunShardRepo(testDB.db)
 
-		results := RunResults{
-			TotalTests:   10,
-			PassedTests:  8,
-			FailedTests:  1,
-			SkippedTests: 1,
-			DurationMs:   5000,
-		}
+	name := "test-pool-" + uuid.New().String()[:8]
+	description := "GPU runners"
+	pool := &AgentPool{Name: name, Description: &description, MaxConcurrency: 4, MaxConcurrencyPerService: 2}
 
-		err = runRepo.Finish(ctx, run.ID, RunStatusFailed, results)
+	t.Run("CRUD", func(t *testing.T) {
+		require.NoError(t, repo.Create(ctx, pool))
+		assert.NotEqual(t, uuid.Nil, pool.ID)
+
+		err := repo.Create(ctx, &AgentPool{Name: name})
+		assert.True(t, IsDuplicate(err))
+
+		fetched, err := repo.GetByName(ctx, name)
 		require.NoError(t, err)
+		assert.Equal(t, description, *fetched.Description)
+		assert.Equal(t, 4, fetched.MaxConcurrency)
+		assert.Equal(t, 2, fetched.MaxConcurrencyPerService)
+		assert.Equal(t, int64(0), fetched.MaxArtifactBytes)
+
+		fetched.MaxConcurrency = 0
+		fetched.MaxArtifactBytes = 64 << 20
+		require.NoError(t, repo.Update(ctx, fetched))
+		fetched, err = repo.GetByName(ctx, name)
+		require.NoError(t, err)
+		assert.Equal(t, 0, fetched.MaxConcurrency)
+		assert.Equal(t, int64(64<<20), fetched.MaxArtifactBytes)
 
-		fetched, err := runRepo.Get(ctx, run.ID)
+		pools, err := repo.List(ctx)
 		require.NoError(t, err)
-		assert.Equal(t, RunStatusFailed, fetched.Status)
-		assert.Equal(t, 10, fetched.TotalTests)
-		assert.Equal(t, 8, fetched.PassedTests)
-		assert.Equal(t, 1, fetched.FailedTests)
-		assert.Equal(t, 1, fetched.SkippedTests)
-		assert.NotNil(t, fetched.FinishedAt)
+		var names []string
+		for _, p := range pools {
+			names = append(names, p.Name)
+		}
+		assert.Contains(t, names, name)
+
+		assert.True(t, IsNotFound(repo.Update(ctx, &AgentPool{Name: "missing-" + name})))
 	})
 
-	t.Run("ListByService", func(t *testing.T) {
-		for i := 0; i < 3; i++ {
-			run := &TestRun{
-				ServiceID: svc.ID,
-				Status:    RunStatusPending,
-			}
-			err := runRepo.Create(ctx, run)
-			require.NoError(t, err)
-			defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+	t.Run("Usage", func(t *testing.T) {
+		svc := &Service{
+			Name:          "test-pool-service-" + uuid.New().String()[:8],
+			GitURL:        "https://github.com/example/repo.git",
+			DefaultBranch: "main",
+			AgentPool:     &name,
 		}
+		require.NoError(t, svcRepo.Create(ctx, svc))
+		defer svcRepo.Delete(ctx, svc.ID)
 
-		runs, err := runRepo.ListByService(ctx, svc.ID, DefaultPagination())
+		fetchedSvc, err := svcRepo.Get(ctx, svc.ID)
 		require.NoError(t, err)
-		assert.GreaterOrEqual(t, len(runs), 3)
-	})
+		assert.Equal(t, name, *fetchedSvc.AgentPool)
 
-	t.Run("ListByStatus", func(t *testing.T) {
-		run := &TestRun{
-			ServiceID: svc.ID,
-			Status:    RunStatusCancelled,
-		}
-		err := runRepo.Create(ctx, run)
+		busy := &Agent{Name: "test-pool-busy-" + uuid.New().String()[:8], Status: AgentStatusBusy, MaxParallel: 1, Pool: &name}
+		require.NoError(t, agentRepo.Create(ctx, busy))
+		defer agentRepo.Delete(ctx, busy.ID)
+
+		offline := &Agent{Name: "test-pool-offline-" + uuid.New().String()[:8], Status: AgentStatusOffline, MaxParallel: 1, Pool: &name}
+		require.NoError(t, agentRepo.Create(ctx, offline))
+		defer agentRepo.Delete(ctx, offline.ID)
+
+		fetchedAgent, err := agentRepo.Get(ctx, busy.ID)
 		require.NoError(t, err)
-		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+		assert.Equal(t, name, *fetchedAgent.Pool)
 
-		runs, err := runRepo.ListByStatus(ctx, RunStatusCancelled, DefaultPagination())
+		run := &TestRun{ServiceID: svc.ID, Status: RunStatusRunning}
+		require.NoError(t, runRepo.Create(ctx, run))
+
+		shard := &RunShard{RunID: run.ID, ShardIndex: 0, ShardCount: 1, Status: ShardStatusPending}
+		require.NoError(t, shardRepo.Create(ctx, shard))
+		require.NoError(t, shardRepo.Start(ctx, shard.ID, busy.ID))
+
+		running, err := repo.RunningShardsByService(ctx, name)
 		require.NoError(t, err)
+		assert.Equal(t, map[uuid.UUID]int{svc.ID: 1}, running)
 
-		found := false
-		for _, r := range runs {
-			if r.ID == run.ID {
-				found = true
-				break
+		stats, err := repo.Stats(ctx)
+		require.NoError(t, err)
+		var found *AgentPoolStats
+		for i := range stats {
+			if stats[i].Pool == name {
+				found = &stats[i]
 			}
 		}
-		assert.True(t, found)
+		require.NotNil(t, found)
+		assert.Equal(t, 2, found.Agents)
+		assert.Equal(t, 1, found.OnlineAgents)
+		assert.Equal(t, 1, found.RunningShards)
 	})
 
-	t.Run("GetPending", func(t *testing.T) {
-		// Create runs with different priorities
-		for i := 0; i < 3; i++ {
-			run := &TestRun{
-				ServiceID: svc.ID,
-				Status:    RunStatusPending,
-				Priority:  i * 10,
-			}
-			err := runRepo.Create(ctx, run)
-			require.NoError(t, err)
-			defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
-		}
-
-		runs, err := runRepo.GetPending(ctx, 10)
-		require.NoError(t, err)
-		assert.GreaterOrEqual(t, len(runs), 3)
-
-		// Should be ordered by priority (highest first)
-		for i := 1; i < len(runs); i++ {
-			assert.GreaterOrEqual(t, runs[i-1].Priority, runs[i].Priority)
-		}
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, repo.Delete(ctx, name))
+		_, err := repo.GetByName(ctx, name)
+		assert.True(t, IsNotFound(err))
+		assert.True(t, IsNotFound(repo.Delete(ctx, name)))
 	})
+}
 
-	t.Run("GetRunning", func(t *testing.T) {
-		run := &TestRun{
-			ServiceID: svc.ID,
-			Status:    RunStatusRunning,
-		}
-		err := runRepo.Create(ctx, run)
-		require.NoError(t, err)
-		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+func TestRunShardRepository_Arch(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	shardRepo := NewRunShardRepo(testDB.db)
 
-		runs, err := runRepo.GetRunning(ctx)
-		require.NoError(t, err)
+	svc := &Service{
+		Name:          "test-shard-arch-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
 
-		found := false
-		for _, r := range runs {
-			if r.ID == run.ID {
-				found = true
-				break
-			}
-		}
-		assert.True(t, found)
-	})
+	run := &TestRun{ServiceID: svc.ID, Status: RunStatusPending}
+	require.NoError(t, runRepo.Create(ctx, run))
 
-	t.Run("CountByStatus", func(t *testing.T) {
-		run := &TestRun{
-			ServiceID: svc.ID,
-			Status:    RunStatusPending,
-		}
-		err := runRepo.Create(ctx, run)
-		require.NoError(t, err)
-		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+	anyArch := &RunShard{RunID: run.ID, ShardIndex: 0, ShardCount: 1}
+	require.NoError(t, shardRepo.Create(ctx, anyArch))
+	arm := &RunShard{RunID: run.ID, ShardIndex: 0, ShardCount: 1, Arch: NullString("arm64")}
+	require.NoError(t, shardRepo.Create(ctx, arm))
 
-		counts, err := runRepo.CountByStatus(ctx)
-		require.NoError(t, err)
-		assert.GreaterOrEqual(t, counts[RunStatusPending], int64(1))
-	})
+	shards, err := shardRepo.ListByRun(ctx, run.ID)
+	require.NoError(t, err)
+	require.Len(t, shards, 2)
+	assert.Nil(t, shards[0].Arch)
+	require.NotNil(t, shards[1].Arch)
+	assert.Equal(t, "arm64", *shards[1].Arch)
 }
 
-// ============================================================================
-// RESULT REPOSITORY TESTS
-// ============================================================================
+func TestRunShardRepository_Matrix(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	shardRepo := NewRunShardRepo(testDB.db)
 
-func TestResultRepository(t *testing.T) {
+	svc := &Service{
+		Name:          "test-shard-matrix-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	run := &TestRun{ServiceID: svc.ID, Status: RunStatusPending}
+	require.NoError(t, runRepo.Create(ctx, run))
+
+	cell := &MatrixCell{Env: map[string]string{"GO_VERSION": "1.23"}, Labels: map[string]string{"os": "linux"}}
+	require.NoError(t, shardRepo.Create(ctx, &RunShard{RunID: run.ID, ShardIndex: 0, ShardCount: 1, Matrix: cell}))
+	require.NoError(t, shardRepo.Create(ctx, &RunShard{RunID: run.ID, ShardIndex: 0, ShardCount: 1}))
+
+	shards, err := shardRepo.ListByRun(ctx, run.ID)
+	require.NoError(t, err)
+	require.Len(t, shards, 2)
+	assert.Nil(t, shards[0].Matrix)
+	assert.Equal(t, cell, shards[1].Matrix)
+}
+
+func TestRunShardRepository_Stage(t *testing.T) {
 	ctx := context.Background()
 	svcRepo := NewServiceRepo(testDB.db)
 	runRepo := NewRunRepo(testDB.db)
-	resultRepo := NewResultRepo(testDB.db)
+	shardRepo := NewRunShardRepo(testDB.db)
 
-	// Create service and run
 	svc := &Service{
-		Name:          "test-result-service-" + uuid.New().String()[:8],
+		Name:          "test-shard-stage-service-" + uuid.New().String()[:8],
 		GitURL:        "https://github.com/example/repo.git",
 		DefaultBranch: "main",
 	}
-	err := svcRepo.Create(ctx, svc)
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	run := &TestRun{ServiceID: svc.ID, Status: RunStatusPending}
+	require.NoError(t, runRepo.Create(ctx, run))
+
+	require.NoError(t, shardRepo.Create(ctx, &RunShard{RunID: run.ID, ShardIndex: 0, ShardCount: 2, Stage: 1}))
+	require.NoError(t, shardRepo.Create(ctx, &RunShard{RunID: run.ID, ShardIndex: 1, ShardCount: 2, Stage: 0}))
+
+	shards, err := shardRepo.ListByRun(ctx, run.ID)
 	require.NoError(t, err)
+	require.Len(t, shards, 2)
+	assert.Equal(t, 0, shards[0].Stage, "shards are listed in stage order")
+	assert.Equal(t, 1, shards[1].Stage)
+}
+
+func TestRunRepository_ListByAgent(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	agentRepo := NewAgentRepo(testDB.db)
+	shardRepo := NewRunShardRepo(testDB.db)
+
+	svc := &Service{
+		Name:          "test-agent-audit-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
 	defer svcRepo.Delete(ctx, svc.ID)
 
-	run := &TestRun{
-		ServiceID: svc.ID,
-		Status:    RunStatusRunning,
+	agent := &Agent{
+		Name:         "test-agent-audit-" + uuid.New().String()[:8],
+		Status:       AgentStatusIdle,
+		MaxParallel:  1,
+		NetworkZones: []string{"eu-north"},
 	}
-	err = runRepo.Create(ctx, run)
+	require.NoError(t, agentRepo.Create(ctx, agent))
+
+	run := &TestRun{ServiceID: svc.ID, Status: RunStatusPending}
+	require.NoError(t, runRepo.Create(ctx, run))
+	require.NoError(t, runRepo.Start(ctx, run.ID, agent.ID))
+
+	sharded := &TestRun{ServiceID: svc.ID, Status: RunStatusPending}
+	require.NoError(t, runRepo.Create(ctx, sharded))
+	shard := &RunShard{RunID: sharded.ID, ShardIndex: 0, ShardCount: 1}
+	require.NoError(t, shardRepo.Create(ctx, shard))
+	require.NoError(t, shardRepo.Start(ctx, shard.ID, agent.ID))
+
+	// Assignment history survives the agent
+	require.NoError(t, agentRepo.Delete(ctx, agent.ID))
+
+	start, end := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	runs, err := runRepo.ListByAgent(ctx, agent.ID, start, end, Pagination{Limit: 10})
 	require.NoError(t, err)
-	defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+	require.Len(t, runs, 2)
 
-	t.Run("Create", func(t *testing.T) {
-		durationMs := int64(150)
-		result := &TestResult{
-			RunID:      run.ID,
-			TestName:   "TestExample",
-			SuiteName:  NullString("ExampleSuite"),
-			Status:     ResultStatusPass,
-			DurationMs: &durationMs,
+	var direct *TestRun
+	for i := range runs {
+		if runs[i].ID == run.ID {
+			direct = &runs[i]
 		}
+	}
+	require.NotNil(t, direct)
+	require.NotNil(t, direct.AgentName)
+	assert.Equal(t, agent.Name, *direct.AgentName)
+	assert.Equal(t, []string{"eu-north"}, direct.AgentNetworkZones)
 
-		err := resultRepo.Create(ctx, result)
-		require.NoError(t, err)
-		assert.NotEqual(t, uuid.Nil, result.ID)
+	shards, err := shardRepo.ListByRun(ctx, sharded.ID)
+	require.NoError(t, err)
+	require.Len(t, shards, 1)
+	require.NotNil(t, shards[0].AgentName)
+	assert.Equal(t, agent.Name, *shards[0].AgentName)
 
-		t.Cleanup(func() {
-			testDB.db.Pool().Exec(ctx, "DELETE FROM test_results WHERE id = $1", result.ID)
-		})
-	})
+	runs, err = runRepo.ListByAgent(ctx, agent.ID, end, end.Add(time.Hour), Pagination{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+}
 
-	t.Run("BatchCreate", func(t *testing.T) {
-		results := make([]TestResult, 5)
-		for i := 0; i < 5; i++ {
-			durationMs := int64(i * 100)
-			results[i] = TestResult{
-				RunID:      run.ID,
-				TestName:   "TestBatch" + string(rune('A'+i)),
-				Status:     ResultStatusPass,
-				DurationMs: &durationMs,
-			}
-		}
+func TestRunAnnotationRepository(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	annotationRepo := NewRunAnnotationRepo(testDB.db)
 
-		err := resultRepo.BatchCreate(ctx, results)
-		require.NoError(t, err)
+	svc := &Service{
+		Name:          "test-annotation-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
 
-		// Verify all were created
-		fetched, err := resultRepo.ListByRun(ctx, run.ID)
-		require.NoError(t, err)
-		assert.GreaterOrEqual(t, len(fetched), 5)
+	run := &TestRun{ServiceID: svc.ID, Status: RunStatusRunning}
+	require.NoError(t, runRepo.Create(ctx, run))
 
-		t.Cleanup(func() {
-			for _, r := range results {
-				testDB.db.Pool().Exec(ctx, "DELETE FROM test_results WHERE id = $1", r.ID)
-			}
-		})
-	})
+	agentID := uuid.New()
+	skew := &RunAnnotation{
+		RunID:    run.ID,
+		AgentID:  &agentID,
+		Kind:     "clock_skew",
+		Message:  "agent clock is 1m30s behind the control plane",
+		Metadata: map[string]string{"skew_seconds": "90.0"},
+	}
+	require.NoError(t, annotationRepo.Create(ctx, skew))
+	assert.NotEqual(t, uuid.Nil, skew.ID)
 
-	t.Run("Get", func(t *testing.T) {
-		durationMs := int64(200)
-		errMsg := "assertion failed"
-		result := &TestResult{
-			RunID:        run.ID,
-			TestName:     "TestGetExample",
-			Status:       ResultStatusFail,
-			DurationMs:   &durationMs,
-			ErrorMessage: &errMsg,
-		}
-		err := resultRepo.Create(ctx, result)
-		require.NoError(t, err)
-		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_results WHERE id = $1", result.ID)
+	note := &RunAnnotation{RunID: run.ID, Kind: "note", Severity: AnnotationSeverityInfo, Message: "cache warmed"}
+	require.NoError(t, annotationRepo.Create(ctx, note))
 
-		fetched, err := resultRepo.Get(ctx, result.ID)
-		require.NoError(t, err)
-		assert.Equal(t, result.TestName, fetched.TestName)
-		assert.Equal(t, result.Status, fetched.Status)
-		assert.Equal(t, *result.ErrorMessage, *fetched.ErrorMessage)
-	})
+	annotations, err := annotationRepo.ListByRun(ctx, run.ID)
+	require.NoError(t, err)
+	require.Len(t, annotations, 2)
+	assert.Equal(t, "clock_skew", annotations[0].Kind)
+	assert.Equal(t, AnnotationSeverityWarning, annotations[0].Severity)
+	assert.Equal(t, agentID, *annotations[0].AgentID)
+	assert.Equal(t, "90.0", annotations[0].Metadata["skew_seconds"])
+	assert.Equal(t, AnnotationSeverityInfo, annotations[1].Severity)
+	assert.Empty(t, annotations[1].Metadata)
+}
 
-	t.Run("ListByRun", func(t *testing.T) {
-		// Create new run for isolation
-		run2 := &TestRun{
-			ServiceID: svc.ID,
-			Status:    RunStatusRunning,
-		}
-		err := runRepo.Create(ctx, run2)
-		require.NoError(t, err)
-		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run2.ID)
+func TestArtifactRepository_UsageByTest(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	defRepo := NewTestDefinitionRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	artifactRepo := NewArtifactRepo(testDB.db)
 
-		for i := 0; i < 3; i++ {
-			result := &TestResult{
-				RunID:    run2.ID,
-				TestName: "TestList" + string(rune('A'+i)),
-				Status:   ResultStatusPass,
-			}
-			err := resultRepo.Create(ctx, result)
-			require.NoError(t, err)
-		}
+	svc := &Service{
+		Name:          "test-artifact-budget-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
 
-		results, err := resultRepo.ListByRun(ctx, run2.ID)
-		require.NoError(t, err)
-		assert.Len(t, results, 3)
-	})
+	maxBytes, maxFiles := int64(1<<20), 10
+	def := &TestDefinition{
+		ServiceID:        svc.ID,
+		Name:             "e2e",
+		ExecutionType:    "subprocess",
+		Command:          "npx playwright test",
+		TimeoutSeconds:   600,
+		ArtifactMaxBytes: &maxBytes,
+		ArtifactMaxFiles: &maxFiles,
+		Owner:            NullString("team-checkout"),
+	}
+	require.NoError(t, defRepo.Create(ctx, def))
 
-	t.Run("ListByRunAndStatus", func(t *testing.T) {
-		// Create new run for isolation
-		run3 := &TestRun{
-			ServiceID: svc.ID,
-			Status:    RunStatusRunning,
-		}
-		err := runRepo.Create(ctx, run3)
-		require.NoError(t, err)
-		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run3.ID)
+	fetched, err := defRepo.Get(ctx, def.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &maxBytes, fetched.ArtifactMaxBytes)
+	assert.Equal(t, &maxFiles, fetched.ArtifactMaxFiles)
+	assert.Equal(t, def.Owner, fetched.Owner)
+
+	run := &TestRun{ServiceID: svc.ID, Status: RunStatusRunning}
+	require.NoError(t, runRepo.Create(ctx, run))
+
+	for _, artifact := range []*Artifact{
+		{RunID: run.ID, Name: "trace.zip", Path: "runs/trace.zip", SizeBytes: NullInt64(300), TestDefinitionID: &def.ID},
+		{RunID: run.ID, Name: "video.webm", Path: "runs/video.webm", SizeBytes: NullInt64(700), TestDefinitionID: &def.ID},
+		{RunID: run.ID, Name: "report.html", Path: "runs/report.html", SizeBytes: NullInt64(5000)},
+	} {
+		require.NoError(t, artifactRepo.Create(ctx, artifact))
+	}
 
-		// Create mixed results
-		statuses := []ResultStatus{ResultStatusPass, ResultStatusPass, ResultStatusFail, ResultStatusSkip}
-		for i, status := range statuses {
-			result := &TestResult{
-				RunID:    run3.ID,
-				TestName: "TestStatus" + string(rune('A'+i)),
-				Status:   status,
-			}
-			err := resultRepo.Create(ctx, result)
-			require.NoError(t, err)
-		}
+	files, bytes, err := artifactRepo.UsageByTest(ctx, run.ID, def.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, files)
+	assert.Equal(t, int64(1000), bytes)
 
-		// Get only failed
-		failed, err := resultRepo.ListByRunAndStatus(ctx, run3.ID, ResultStatusFail)
-		require.NoError(t, err)
-		assert.Len(t, failed, 1)
-	})
+	files, bytes, err = artifactRepo.UsageByTest(ctx, run.ID, uuid.New())
+	require.NoError(t, err)
+	assert.Zero(t, files)
+	assert.Zero(t, bytes)
+}
 
-	t.Run("CountByRun", func(t *testing.T) {
-		// Create new run for isolation
-		run4 := &TestRun{
-			ServiceID: svc.ID,
-			Status:    RunStatusRunning,
-		}
-		err := runRepo.Create(ctx, run4)
-		require.NoError(t, err)
-		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run4.ID)
+func TestArtifactRepository_Types(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	artifactRepo := NewArtifactRepo(testDB.db)
 
-		// Create mixed results
-		for i := 0; i < 5; i++ {
-			status := ResultStatusPass
-			if i < 2 {
-				status = ResultStatusFail
-			}
-			result := &TestResult{
-				RunID:    run4.ID,
-				TestName: "TestCount" + string(rune('A'+i)),
-				Status:   status,
-			}
-			err := resultRepo.Create(ctx, result)
-			require.NoError(t, err)
-		}
+	svc := &Service{
+		Name:          "test-artifact-type-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	run := &TestRun{ServiceID: svc.ID, Status: RunStatusRunning}
+	require.NoError(t, runRepo.Create(ctx, run))
+
+	detected := &Artifact{RunID: run.ID, Name: "screenshots/login.png", Path: "runs/login.png", Checksum: NullString("9f86d081884c7d65")}
+	typed := &Artifact{RunID: run.ID, Name: "output", Path: "runs/output", Type: ArtifactTypeLog}
+	require.NoError(t, artifactRepo.Create(ctx, detected))
+	require.NoError(t, artifactRepo.Create(ctx, typed))
+
+	fetched, err := artifactRepo.Get(ctx, detected.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ArtifactTypeScreenshot, fetched.Type, "untyped artifacts are typed by name")
+	assert.Equal(t, detected.Checksum, fetched.Checksum)
+
+	artifacts, err := artifactRepo.ListByRun(ctx, run.ID)
+	require.NoError(t, err)
+	require.Len(t, artifacts, 2)
+	assert.Equal(t, ArtifactTypeLog, artifacts[0].Type)
+	assert.Nil(t, artifacts[0].Checksum)
+}
+
+func TestRunRepository_HasMediaArtifacts(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	testRepo := NewTestDefinitionRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	artifactRepo := NewArtifactRepo(testDB.db)
+
+	svc := &Service{
+		Name:          "test-media-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	def := &TestDefinition{
+		ServiceID:       svc.ID,
+		Name:            "e2e",
+		ExecutionType:   "container",
+		Command:         "npx playwright test",
+		TimeoutSeconds:  600,
+		CapturePatterns: []string{"playwright-report/", "cypress/videos/"},
+	}
+	require.NoError(t, testRepo.Create(ctx, def))
+	fetchedDef, err := testRepo.Get(ctx, def.ID)
+	require.NoError(t, err)
+	assert.Equal(t, def.CapturePatterns, fetchedDef.CapturePatterns)
+
+	run := &TestRun{ServiceID: svc.ID, Status: RunStatusRunning}
+	require.NoError(t, runRepo.Create(ctx, run))
+
+	require.NoError(t, artifactRepo.Create(ctx, &Artifact{RunID: run.ID, Name: "output.log", Path: "runs/output.log"}))
+	fetched, err := runRepo.Get(ctx, run.ID)
+	require.NoError(t, err)
+	assert.False(t, fetched.HasMediaArtifacts)
+
+	require.NoError(t, artifactRepo.Create(ctx, &Artifact{RunID: run.ID, Name: "videos/login.webm", Path: "runs/login.webm"}))
+	fetched, err = runRepo.Get(ctx, run.ID)
+	require.NoError(t, err)
+	assert.True(t, fetched.HasMediaArtifacts, "recording a video flags the run")
+}
+
+func TestRunRepository_Annotate(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+
+	svc := &Service{
+		Name:          "test-metadata-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	run := &TestRun{
+		ServiceID: svc.ID,
+		Status:    RunStatusPending,
+		Metadata: RunMetadata{
+			Annotations: map[string]string{"deployment_id": "d-42"},
+			Links:       map[string]string{"build": "https://ci.example.com/builds/7"},
+		},
+	}
+	require.NoError(t, runRepo.Create(ctx, run))
+
+	fetched, err := runRepo.Get(ctx, run.ID)
+	require.NoError(t, err)
+	assert.Equal(t, run.Metadata, fetched.Metadata)
+
+	metadata, err := runRepo.Annotate(ctx, run.ID, RunMetadata{
+		Annotations: map[string]string{"deployment_id": "", "release": "1.4.0"},
+		Links:       map[string]string{"ticket": "https://tracker.example.com/OPS-1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"release": "1.4.0"}, metadata.Annotations, "empty values remove annotations")
+	assert.Equal(t, map[string]string{
+		"build":  "https://ci.example.com/builds/7",
+		"ticket": "https://tracker.example.com/OPS-1",
+	}, metadata.Links)
+
+	fetched, err = runRepo.Get(ctx, run.ID)
+	require.NoError(t, err)
+	assert.Equal(t, *metadata, fetched.Metadata)
+
+	_, err = runRepo.Annotate(ctx, uuid.New(), RunMetadata{})
+	assert.True(t, IsNotFound(err))
+}
+
+func TestRunRepository_Parameters(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+
+	svc := &Service{
+		Name:          "test-parameters-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	run := &TestRun{
+		ServiceID:  svc.ID,
+		Status:     RunStatusPending,
+		Parameters: map[string]string{"TARGET_ENV": "staging"},
+	}
+	require.NoError(t, runRepo.Create(ctx, run))
+
+	fetched, err := runRepo.Get(ctx, run.ID)
+	require.NoError(t, err)
+	assert.Equal(t, run.Parameters, fetched.Parameters)
+
+	// Runs without parameters store an empty object
+	run = &TestRun{ServiceID: svc.ID, Status: RunStatusPending}
+	require.NoError(t, runRepo.Create(ctx, run))
+	fetched, err = runRepo.Get(ctx, run.ID)
+	require.NoError(t, err)
+	assert.Empty(t, fetched.Parameters)
+}
+
+func TestArtifactBlobRepository_RefCounts(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	artifactRepo := NewArtifactRepo(testDB.db)
+	blobRepo := NewArtifactBlobRepo(testDB.db)
+
+	svc := &Service{
+		Name:          "test-artifact-blob-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	first := &TestRun{ServiceID: svc.ID, Status: RunStatusRunning}
+	second := &TestRun{ServiceID: svc.ID, Status: RunStatusRunning}
+	require.NoError(t, runRepo.Create(ctx, first))
+	require.NoError(t, runRepo.Create(ctx, second))
+
+	digest := strings.Repeat("ab", 32)
+	blob := &ArtifactBlob{Digest: digest, Path: "artifacts/blobs/sha256/ab/" + digest, SizeBytes: 1024}
+	require.NoError(t, blobRepo.Upsert(ctx, blob))
+	defer testDB.db.Pool().Exec(ctx, `DELETE FROM artifact_blobs WHERE digest = $1`, digest)
+	assert.Equal(t, 0, blob.RefCount)
+
+	for _, run := range []*TestRun{first, second} {
+		artifact := &Artifact{RunID: run.ID, Name: "fixtures.tar", Path: blob.Path, BlobDigest: &digest}
+		require.NoError(t, artifactRepo.Create(ctx, artifact))
+	}
+
+	fetched, err := blobRepo.Get(ctx, digest)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fetched.RefCount)
+
+	artifacts, err := artifactRepo.ListByRun(ctx, first.ID)
+	require.NoError(t, err)
+	require.Len(t, artifacts, 1)
+	assert.Equal(t, digest, *artifacts[0].BlobDigest)
+
+	// Deleting the artifacts of a run releases their references
+	require.NoError(t, artifactRepo.DeleteByRun(ctx, first.ID))
+	fetched, err = blobRepo.Get(ctx, digest)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fetched.RefCount)
+
+	future := time.Now().Add(time.Hour)
+	assert.True(t, IsNotFound(blobRepo.DeleteUnreferenced(ctx, digest, future)), "referenced blobs are kept")
+
+	require.NoError(t, artifactRepo.DeleteByRun(ctx, second.ID))
+	unreferenced, err := blobRepo.ListUnreferenced(ctx, future, 100)
+	require.NoError(t, err)
+	var digests []string
+	for _, b := range unreferenced {
+		digests = append(digests, b.Digest)
+	}
+	assert.Contains(t, digests, digest)
+
+	assert.True(t, IsNotFound(blobRepo.DeleteUnreferenced(ctx, digest, time.Now().Add(-time.Hour))), "blobs in their grace period are kept")
+	require.NoError(t, blobRepo.DeleteUnreferenced(ctx, digest, future))
+	_, err = blobRepo.Touch(ctx, digest)
+	assert.True(t, IsNotFound(err))
+}
+
+func TestRunShardRepository_RequeueOrphaned(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	agentRepo := NewAgentRepo(testDB.db)
+	shardRepo := NewRunShardRepo(testDB.db)
+
+	svc := &Service{
+		Name:          "test-requeue-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	offline := &Agent{Name: "test-requeue-offline-" + uuid.New().String()[:8], Status: AgentStatusOffline, MaxParallel: 1}
+	require.NoError(t, agentRepo.Create(ctx, offline))
+	defer agentRepo.Delete(ctx, offline.ID)
+
+	online := &Agent{Name: "test-requeue-online-" + uuid.New().String()[:8], Status: AgentStatusBusy, MaxParallel: 1}
+	require.NoError(t, agentRepo.Create(ctx, online))
+	defer agentRepo.Delete(ctx, online.ID)
+
+	run := &TestRun{ServiceID: svc.ID, Status: RunStatusRunning}
+	require.NoError(t, runRepo.Create(ctx, run))
+
+	orphaned := &RunShard{RunID: run.ID, ShardIndex: 0, ShardCount: 2, Status: ShardStatusPending}
+	require.NoError(t, shardRepo.Create(ctx, orphaned))
+	require.NoError(t, shardRepo.Start(ctx, orphaned.ID, offline.ID))
+
+	healthy := &RunShard{RunID: run.ID, ShardIndex: 1, ShardCount: 2, Status: ShardStatusPending}
+	require.NoError(t, shardRepo.Create(ctx, healthy))
+	require.NoError(t, shardRepo.Start(ctx, healthy.ID, online.ID))
+
+	requeued, err := shardRepo.RequeueOrphaned(ctx)
+	require.NoError(t, err)
+
+	var found *RunShard
+	for i := range requeued {
+		assert.NotEqual(t, healthy.ID, requeued[i].ID)
+		if requeued[i].ID == orphaned.ID {
+			found = &requeued[i]
+		}
+	}
+	require.NotNil(t, found)
+	assert.Equal(t, ShardStatusPending, found.Status)
+	assert.Equal(t, offline.ID, *found.AgentID)
+
+	fetched, err := shardRepo.Get(ctx, orphaned.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ShardStatusPending, fetched.Status)
+	assert.Nil(t, fetched.AgentID)
+
+	fetched, err = shardRepo.Get(ctx, healthy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ShardStatusRunning, fetched.Status)
+}
+
+func TestEnergyRepository(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	repo := NewEnergyRepo(testDB.db)
+
+	svc := &Service{
+		Name:          "test-energy-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	first := &TestRun{ServiceID: svc.ID, Status: RunStatusPassed}
+	require.NoError(t, runRepo.Create(ctx, first))
+	second := &TestRun{ServiceID: svc.ID, Status: RunStatusPassed}
+	require.NoError(t, runRepo.Create(ctx, second))
+
+	jan := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	feb := time.Date(2025, 2, 3, 12, 0, 0, 0, time.UTC)
+	for _, sample := range []*EnergySample{
+		{RunID: first.ID, Zone: "eu-north", CPUPercent: 50, CPUCores: 4, EnergyWh: 1.5, SampledAt: jan},
+		{RunID: first.ID, Zone: "eu-north", CPUPercent: 50, CPUCores: 4, EnergyWh: 2.5, SampledAt: jan.Add(time.Minute)},
+		{RunID: first.ID, Zone: "us-east", CPUPercent: 10, CPUCores: 2, EnergyWh: 1, SampledAt: jan.Add(2 * time.Minute)},
+		{RunID: second.ID, Zone: "eu-north", CPUPercent: 80, CPUCores: 4, EnergyWh: 3, SampledAt: feb},
+	} {
+		require.NoError(t, repo.RecordSample(ctx, sample))
+		assert.NotZero(t, sample.ID)
+	}
+
+	t.Run("GetRunEnergy", func(t *testing.T) {
+		zones, err := repo.GetRunEnergy(ctx, first.ID)
+		require.NoError(t, err)
+		require.Len(t, zones, 2)
+		assert.Equal(t, "eu-north", zones[0].Zone)
+		assert.InDelta(t, 4.0, zones[0].EnergyWh, 1e-9)
+		assert.InDelta(t, 1.0, zones[1].EnergyWh, 1e-9)
+	})
+
+	t.Run("GetMonthlyEnergy", func(t *testing.T) {
+		months, err := repo.GetMonthlyEnergy(ctx, &svc.ID, jan.AddDate(0, -1, 0), feb.AddDate(0, 1, 0))
+		require.NoError(t, err)
+		require.Len(t, months, 3)
+		assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), months[0].Month.UTC())
+		assert.Equal(t, svc.Name, months[0].ServiceName)
+		assert.InDelta(t, 4.0, months[0].EnergyWh, 1e-9)
+		assert.Equal(t, 1, months[0].RunCount)
+		assert.Equal(t, "us-east", months[1].Zone)
+		assert.Equal(t, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), months[2].Month.UTC())
+	})
+
+	t.Run("GetMonthlyEnergy_Range", func(t *testing.T) {
+		months, err := repo.GetMonthlyEnergy(ctx, &svc.ID, feb.AddDate(0, 0, -1), feb.AddDate(0, 1, 0))
+		require.NoError(t, err)
+		require.Len(t, months, 1)
+		assert.InDelta(t, 3.0, months[0].EnergyWh, 1e-9)
+	})
+}
+
+func TestAnalyticsRepository_ServiceReports(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	repo := NewAnalyticsRepo(testDB.db)
+
+	svc := &Service{
+		Name:          "test-report-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	yesterday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	for _, r := range []struct {
+		ref      string
+		status   RunStatus
+		finished time.Duration
+		duration int64
+	}{
+		{"main", RunStatusFailed, time.Hour, 100},
+		{"main", RunStatusTimeout, 2 * time.Hour, 200},
+		{"main", RunStatusPassed, 4 * time.Hour, 300},
+		{"feature", RunStatusPassed, time.Hour, 1000},
+		{"feature", RunStatusCancelled, 2 * time.Hour, 50},
+	} {
+		ref := r.ref
+		run := &TestRun{ServiceID: svc.ID, Status: r.status, GitRef: &ref}
+		require.NoError(t, runRepo.Create(ctx, run))
+		_, err := testDB.db.Pool().Exec(ctx, "UPDATE test_runs SET finished_at = $2, duration_ms = $3 WHERE id = $1",
+			run.ID, yesterday.Add(r.finished), r.duration)
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.RefreshServiceReports(ctx))
+
+	t.Run("AllBranches", func(t *testing.T) {
+		reports, err := repo.GetServiceReports(ctx, ServiceReportFilter{Since: yesterday.AddDate(0, 0, -6), ServiceID: &svc.ID})
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		report := reports[0]
+		assert.Equal(t, svc.Name, report.ServiceName)
+		assert.Equal(t, 4, report.TotalRuns, "cancelled runs are not counted")
+		assert.Equal(t, 2, report.PassedRuns)
+		assert.Equal(t, 2, report.FailedRuns)
+		assert.Equal(t, int64(400), report.AvgDurationMs)
+		assert.Equal(t, int64(895), report.P95DurationMs)
+		assert.Equal(t, 1, report.Recoveries)
+		assert.Equal(t, (3 * time.Hour).Milliseconds(), report.MTTRMs)
+	})
+
+	t.Run("Branch", func(t *testing.T) {
+		ref := "main"
+		reports, err := repo.GetServiceReports(ctx, ServiceReportFilter{Since: yesterday, ServiceID: &svc.ID, GitRef: &ref})
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		assert.Equal(t, 3, reports[0].TotalRuns)
+		assert.Equal(t, int64(200), reports[0].AvgDurationMs)
+		assert.Equal(t, int64(290), reports[0].P95DurationMs)
+		assert.Equal(t, 1, reports[0].Recoveries)
+	})
+
+	t.Run("OutsideWindow", func(t *testing.T) {
+		reports, err := repo.GetServiceReports(ctx, ServiceReportFilter{Since: yesterday.AddDate(0, 0, 1), ServiceID: &svc.ID})
+		require.NoError(t, err)
+		require.Len(t, reports, 1, "services without runs are reported")
+		assert.Zero(t, reports[0].TotalRuns)
+		assert.Zero(t, reports[0].Recoveries)
+	})
+}
+
+func TestDurationRegressionRepository(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	resultRepo := NewResultRepo(testDB.db)
+	repo := NewDurationRegressionRepo(testDB.db)
+
+	svc := &Service{
+		Name:          "test-regression-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	start := time.Now().Add(-time.Hour)
+	createRun := func(ref string, status RunStatus, minute int, durationMs int64, testMs int64) *TestRun {
+		run := &TestRun{ServiceID: svc.ID, Status: status, GitRef: &ref}
+		require.NoError(t, runRepo.Create(ctx, run))
+		run.CreatedAt = start.Add(time.Duration(minute) * time.Minute)
+		_, err := testDB.db.Pool().Exec(ctx, "UPDATE test_runs SET created_at = $2, duration_ms = $3 WHERE id = $1",
+			run.ID, run.CreatedAt, durationMs)
+		require.NoError(t, err)
+		require.NoError(t, resultRepo.Create(ctx, &TestResult{
+			RunID: run.ID, TestName: "TestCheckout", Status: ResultStatusPass, DurationMs: &testMs,
+		}))
+		return run
+	}
+
+	createRun("main", RunStatusPassed, 0, 100, 10)
+	createRun("main", RunStatusFailed, 1, 5, 20)
+	createRun("feature", RunStatusPassed, 2, 999, 999)
+	createRun("main", RunStatusPassed, 3, 300, 30)
+	run := createRun("main", RunStatusPassed, 4, 900, 90)
+
+	t.Run("RunDurationBaseline", func(t *testing.T) {
+		durations, err := repo.RunDurationBaseline(ctx, run, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{300, 100}, durations, "passed runs of the branch, newest first")
+
+		durations, err = repo.RunDurationBaseline(ctx, run, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{300}, durations)
+	})
+
+	t.Run("TestDurationBaselines", func(t *testing.T) {
+		baselines, err := repo.TestDurationBaselines(ctx, run, 10)
+		require.NoError(t, err)
+		require.Len(t, baselines, 1)
+		assert.Equal(t, "TestCheckout", baselines[0].TestName)
+		assert.Equal(t, int64(90), baselines[0].DurationMs)
+		assert.Equal(t, []int64{30, 20, 10}, baselines[0].BaselineMs, "passed results of any run on the branch")
+	})
+
+	t.Run("CreateAndList", func(t *testing.T) {
+		testName := "TestCheckout"
+		testRegression := &DurationRegression{RunID: run.ID, TestName: &testName, DurationMs: 90, BaselineMeanMs: 20, Sigma: math.Inf(1), BaselineRuns: 3}
+		require.NoError(t, repo.Create(ctx, testRegression))
+		assert.NotEqual(t, uuid.Nil, testRegression.ID)
+		runRegression := &DurationRegression{RunID: run.ID, DurationMs: 900, BaselineMeanMs: 200, BaselineStdDevMs: 100, Sigma: 7, BaselineRuns: 2}
+		require.NoError(t, repo.Create(ctx, runRegression))
+
+		duplicate := &DurationRegression{RunID: run.ID, DurationMs: 900, BaselineMeanMs: 200, Sigma: 7, BaselineRuns: 2}
+		require.NoError(t, repo.Create(ctx, duplicate), "recording a regression again is a no-op")
+
+		regressions, err := repo.ListByRun(ctx, run.ID)
+		require.NoError(t, err)
+		require.Len(t, regressions, 2)
+		assert.Nil(t, regressions[0].TestName, "the run regression comes first")
+		assert.Equal(t, int64(100), regressions[0].BaselineStdDevMs)
+		require.NotNil(t, regressions[1].TestName)
+		assert.True(t, math.IsInf(regressions[1].Sigma, 1))
+	})
+}
+
+// ============================================================================
+// TEST RUN REPOSITORY TESTS
+// ============================================================================
+
+func TestEventWebhookRepository(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	repo := NewEventWebhookRepo(testDB.db)
+
+	svc := &Service{
+		Name:          "test-event-webhook-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	ref := "main"
+	run := &TestRun{ServiceID: svc.ID, Status: RunStatusPending, GitRef: &ref}
+	require.NoError(t, runRepo.Create(ctx, run))
+	require.NoError(t, runRepo.UpdateStatus(ctx, run.ID, RunStatusRunning))
+	require.NoError(t, runRepo.UpdateStatus(ctx, run.ID, RunStatusRunning))
+	require.NoError(t, runRepo.UpdateStatus(ctx, run.ID, RunStatusPassed))
+
+	// Dispatch every recorded event, the events of this run to one endpoint
+	var events []RunEvent
+	for {
+		batch, err := repo.ListUndispatchedEvents(ctx, 100)
+		require.NoError(t, err)
+		if len(batch) == 0 {
+			break
+		}
+		for _, event := range batch {
+			var endpoints []string
+			if event.RunID == run.ID {
+				events = append(events, event)
+				endpoints = []string{"integration"}
+			}
+			require.NoError(t, repo.DispatchEvent(ctx, event.ID, endpoints))
+		}
+	}
+
+	t.Run("RecordsTransitions", func(t *testing.T) {
+		require.Len(t, events, 3)
+		assert.Equal(t, RunEventCreated, events[0].Type)
+		assert.Equal(t, RunEventStarted, events[1].Type, "repeated updates to the same status are not recorded")
+		assert.Equal(t, RunEventFinished, events[2].Type)
+		assert.Equal(t, svc.ID, events[2].ServiceID)
+
+		var snapshot struct {
+			Status string `json:"status"`
+			GitRef string `json:"git_ref"`
+		}
+		require.NoError(t, json.Unmarshal(events[2].Run, &snapshot))
+		assert.Equal(t, "passed", snapshot.Status)
+		assert.Equal(t, "main", snapshot.GitRef)
+
+		require.NoError(t, repo.DispatchEvent(ctx, events[0].ID, []string{"integration"}), "dispatching again keeps deliveries")
+		assert.ErrorIs(t, repo.DispatchEvent(ctx, -1, nil), ErrNotFound)
+	})
+
+	claim := func(now time.Time) []EventWebhookDelivery {
+		claimed, err := repo.ClaimDueDeliveries(ctx, now, time.Minute, 100)
+		require.NoError(t, err)
+		var deliveries []EventWebhookDelivery
+		for _, d := range claimed {
+			if d.Event.RunID == run.ID {
+				deliveries = append(deliveries, d)
+			}
+		}
+		return deliveries
+	}
+
+	t.Run("ClaimAndUpdate", func(t *testing.T) {
+		now := time.Now().Add(time.Second)
+		deliveries := claim(now)
+		require.Len(t, deliveries, 3)
+		assert.Equal(t, "integration", deliveries[0].Endpoint)
+		assert.Equal(t, RunEventCreated, deliveries[0].Event.Type)
+		assert.Equal(t, EventWebhookDeliveryPending, deliveries[0].Status)
+
+		assert.Empty(t, claim(now), "claimed deliveries are leased")
+
+		status := 204
+		delivered := deliveries[0]
+		delivered.Status = EventWebhookDeliveryDelivered
+		delivered.Attempts = 1
+		delivered.ResponseStatus = &status
+		delivered.DeliveredAt = &now
+		require.NoError(t, repo.UpdateDelivery(ctx, &delivered))
+
+		retried := deliveries[1]
+		retried.Attempts = 1
+		retried.Error = "endpoint returned status 503"
+		retried.NextAttemptAt = now
+		require.NoError(t, repo.UpdateDelivery(ctx, &retried))
+
+		deliveries = claim(now)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, retried.ID, deliveries[0].ID)
+		assert.Equal(t, 1, deliveries[0].Attempts)
+		assert.Equal(t, "endpoint returned status 503", deliveries[0].Error)
+	})
+
+	t.Run("DeleteEventsBefore", func(t *testing.T) {
+		_, err := repo.DeleteEventsBefore(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+
+		var remaining int
+		require.NoError(t, testDB.db.Pool().QueryRow(ctx,
+			"SELECT COUNT(*) FROM run_events WHERE run_id = $1", run.ID).Scan(&remaining))
+		assert.Equal(t, 2, remaining, "events with pending deliveries are kept")
+	})
+}
+
+func TestTestRunRepository(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	agentRepo := NewAgentRepo(testDB.db)
+
+	// Create a service
+	svc := &Service{
+		Name:          "test-run-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	err := svcRepo.Create(ctx, svc)
+	require.NoError(t, err)
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	t.Run("Create", func(t *testing.T) {
+		gitRef := "main"
+		gitSHA := "abc123"
+		triggerType := TriggerTypeManual
+		triggeredBy := "test-user"
+
+		run := &TestRun{
+			ServiceID:   svc.ID,
+			Status:      RunStatusPending,
+			GitRef:      &gitRef,
+			GitSHA:      &gitSHA,
+			TriggerType: &triggerType,
+			TriggeredBy: &triggeredBy,
+			Priority:    1,
+		}
+
+		err := runRepo.Create(ctx, run)
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, run.ID)
+		assert.False(t, run.CreatedAt.IsZero())
+
+		t.Cleanup(func() {
+			testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+		})
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		gitRef := "feature-branch"
+		run := &TestRun{
+			ServiceID: svc.ID,
+			Status:    RunStatusPending,
+			GitRef:    &gitRef,
+		}
+		err := runRepo.Create(ctx, run)
+		require.NoError(t, err)
+		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+
+		fetched, err := runRepo.Get(ctx, run.ID)
+		require.NoError(t, err)
+		assert.Equal(t, run.ServiceID, fetched.ServiceID)
+		assert.Equal(t, run.Status, fetched.Status)
+		assert.Equal(t, *run.GitRef, *fetched.GitRef)
+	})
+
+	t.Run("UpdateStatus", func(t *testing.T) {
+		run := &TestRun{
+			ServiceID: svc.ID,
+			Status:    RunStatusPending,
+		}
+		err := runRepo.Create(ctx, run)
+		require.NoError(t, err)
+		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+
+		err = runRepo.UpdateStatus(ctx, run.ID, RunStatusRunning)
+		require.NoError(t, err)
+
+		fetched, err := runRepo.Get(ctx, run.ID)
+		require.NoError(t, err)
+		assert.Equal(t, RunStatusRunning, fetched.Status)
+	})
+
+	t.Run("Start", func(t *testing.T) {
+		// Create an agent
+		agent := &Agent{
+			Name:         "test-run-agent-" + uuid.New().String()[:8],
+			Status:       AgentStatusIdle,
+			NetworkZones: []string{"default"},
+			MaxParallel:  4,
+		}
+		err := agentRepo.Create(ctx, agent)
+		require.NoError(t, err)
+		defer agentRepo.Delete(ctx, agent.ID)
+
+		run := &TestRun{
+			ServiceID: svc.ID,
+			Status:    RunStatusPending,
+		}
+		err = runRepo.Create(ctx, run)
+		require.NoError(t, err)
+		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+
+		err = runRepo.Start(ctx, run.ID, agent.ID)
+		require.NoError(t, err)
+
+		fetched, err := runRepo.Get(ctx, run.ID)
+		require.NoError(t, err)
+		assert.Equal(t, RunStatusRunning, fetched.Status)
+		assert.Equal(t, agent.ID, *fetched.AgentID)
+		assert.NotNil(t, fetched.StartedAt)
+	})
+
+	t.Run("Finish", func(t *testing.T) {
+		run := &TestRun{
+			ServiceID: svc.ID,
+			Status:    RunStatusRunning,
+		}
+		err := runRepo.Create(ctx, run)
+		require.NoError(t, err)
+		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+
+		results := RunResults{
+			TotalTests:   10,
+			PassedTests:  8,
+			FailedTests:  1,
+			SkippedTests: 1,
+			DurationMs:   5000,
+		}
+
+		err = runRepo.Finish(ctx, run.ID, RunStatusFailed, results)
+		require.NoError(t, err)
+
+		fetched, err := runRepo.Get(ctx, run.ID)
+		require.NoError(t, err)
+		assert.Equal(t, RunStatusFailed, fetched.Status)
+		assert.Equal(t, 10, fetched.TotalTests)
+		assert.Equal(t, 8, fetched.PassedTests)
+		assert.Equal(t, 1, fetched.FailedTests)
+		assert.Equal(t, 1, fetched.SkippedTests)
+		assert.NotNil(t, fetched.FinishedAt)
+	})
+
+	t.Run("ListByService", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			run := &TestRun{
+				ServiceID: svc.ID,
+				Status:    RunStatusPending,
+			}
+			err := runRepo.Create(ctx, run)
+			require.NoError(t, err)
+			defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+		}
+
+		runs, err := runRepo.ListByService(ctx, svc.ID, DefaultPagination())
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, len(runs), 3)
+	})
+
+	t.Run("ListByStatus", func(t *testing.T) {
+		run := &TestRun{
+			ServiceID: svc.ID,
+			Status:    RunStatusCancelled,
+		}
+		err := runRepo.Create(ctx, run)
+		require.NoError(t, err)
+		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+
+		runs, err := runRepo.ListByStatus(ctx, RunStatusCancelled, DefaultPagination())
+		require.NoError(t, err)
+
+		found := false
+		for _, r := range runs {
+			if r.ID == run.ID {
+				found = true
+				break
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("GetPending", func(t *testing.T) {
+		// Create runs with different priorities
+		for i := 0; i < 3; i++ {
+			run := &TestRun{
+				ServiceID: svc.ID,
+				Status:    RunStatusPending,
+				Priority:  i * 10,
+			}
+			err := runRepo.Create(ctx, run)
+			require.NoError(t, err)
+			defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+		}
+
+		runs, err := runRepo.GetPending(ctx, 10)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, len(runs), 3)
+
+		// Should be ordered by priority (highest first)
+		for i := 1; i < len(runs); i++ {
+			assert.GreaterOrEqual(t, runs[i-1].Priority, runs[i].Priority)
+		}
+	})
+
+	t.Run("GetPending_NotBefore", func(t *testing.T) {
+		later := time.Now().Add(time.Hour)
+		run := &TestRun{
+			ServiceID: svc.ID,
+			Status:    RunStatusPending,
+			NotBefore: &later,
+		}
+		require.NoError(t, runRepo.Create(ctx, run))
+		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+
+		retry := &TestRun{
+			ServiceID:    svc.ID,
+			Status:       RunStatusPending,
+			Attempt:      2,
+			RetryOfRunID: &run.ID,
+		}
+		require.NoError(t, runRepo.Create(ctx, retry))
+		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", retry.ID)
+
+		got, err := runRepo.Get(ctx, retry.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, got.Attempt)
+		assert.Equal(t, run.ID, *got.RetryOfRunID)
+
+		runs, err := runRepo.GetPending(ctx, 1000)
+		require.NoError(t, err)
+		for _, r := range runs {
+			assert.NotEqual(t, run.ID, r.ID, "run with a future not_before should not be pending")
+		}
+	})
+
+	t.Run("GetRunning", func(t *testing.T) {
+		run := &TestRun{
+			ServiceID: svc.ID,
+			Status:    RunStatusRunning,
+		}
+		err := runRepo.Create(ctx, run)
+		require.NoError(t, err)
+		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+
+		runs, err := runRepo.GetRunning(ctx)
+		require.NoError(t, err)
+
+		found := false
+		for _, r := range runs {
+			if r.ID == run.ID {
+				found = true
+				break
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("CountByStatus", func(t *testing.T) {
+		run := &TestRun{
+			ServiceID: svc.ID,
+			Status:    RunStatusPending,
+		}
+		err := runRepo.Create(ctx, run)
+		require.NoError(t, err)
+		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+
+		counts, err := runRepo.CountByStatus(ctx)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, counts[RunStatusPending], int64(1))
+	})
+}
+
+// ============================================================================
+// BRANCH REPOSITORY TESTS
+// ============================================================================
+
+func TestBranchRepository(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	branchRepo := NewBranchRepo(testDB.db)
+
+	svc := &Service{
+		Name:          "test-branch-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	t.Run("RecordPush", func(t *testing.T) {
+		require.NoError(t, branchRepo.RecordPush(ctx, svc.ID, "feature/x", "abc123"))
+		require.NoError(t, branchRepo.RecordPush(ctx, svc.ID, "feature/x", "def456"))
+
+		branch, err := branchRepo.Get(ctx, svc.ID, "feature/x")
+		require.NoError(t, err)
+		assert.Equal(t, "def456", *branch.LastSHA)
+		assert.NotNil(t, branch.LastPushedAt)
+		assert.Nil(t, branch.LastRunID)
+	})
+
+	t.Run("RunsUpdateBranch", func(t *testing.T) {
+		require.NoError(t, branchRepo.RecordPush(ctx, svc.ID, "main", "abc123"))
+
+		gitRef := "main"
+		var last *TestRun
+		for _, status := range []RunStatus{RunStatusPassed, RunStatusFailed, RunStatusPending} {
+			run := &TestRun{ServiceID: svc.ID, Status: RunStatusPending, GitRef: &gitRef}
+			require.NoError(t, runRepo.Create(ctx, run))
+			require.NoError(t, runRepo.UpdateStatus(ctx, run.ID, status))
+			last = run
+		}
+
+		branch, err := branchRepo.Get(ctx, svc.ID, "main")
+		require.NoError(t, err)
+		require.NotNil(t, branch.LastRunID)
+		assert.Equal(t, last.ID, *branch.LastRunID)
+		assert.Equal(t, RunStatusPending, *branch.LastRunStatus)
+		assert.Equal(t, 2, branch.RecentRuns)
+		assert.Equal(t, 1, branch.RecentPassed)
+	})
+
+	t.Run("RunForUnknownBranch", func(t *testing.T) {
+		gitRef := "v1.0.0"
+		run := &TestRun{ServiceID: svc.ID, Status: RunStatusPending, GitRef: &gitRef}
+		require.NoError(t, runRepo.Create(ctx, run))
+
+		_, err := branchRepo.Get(ctx, svc.ID, "v1.0.0")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("ListByService", func(t *testing.T) {
+		require.NoError(t, branchRepo.SetProtected(ctx, svc.ID, "main", true))
+
+		branches, total, err := branchRepo.ListByService(ctx, svc.ID, Pagination{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		require.Len(t, branches, 2)
+		assert.Equal(t, "main", branches[0].Name)
+		assert.True(t, branches[0].Protected)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, branchRepo.Delete(ctx, svc.ID, "feature/x"))
+		assert.ErrorIs(t, branchRepo.Delete(ctx, svc.ID, "feature/x"), ErrNotFound)
+		assert.ErrorIs(t, branchRepo.SetProtected(ctx, svc.ID, "feature/x", true), ErrNotFound)
+	})
+}
+
+func TestRetryPolicyRepository(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	defRepo := NewTestDefinitionRepo(testDB.db)
+	policyRepo := NewRetryPolicyRepo(testDB.db)
+
+	svc := &Service{
+		Name:          "test-retry-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	def := &TestDefinition{
+		ServiceID:      svc.ID,
+		Name:           "e2e",
+		ExecutionType:  "subprocess",
+		Command:        "make",
+		TimeoutSeconds: 300,
+	}
+	require.NoError(t, defRepo.Create(ctx, def))
+
+	t.Run("Upsert", func(t *testing.T) {
+		policy := &RetryPolicy{ServiceID: svc.ID, MaxAttempts: 2, BackoffSeconds: 30, MaxBackoffSeconds: 300, RetryOn: RetryOnError}
+		require.NoError(t, policyRepo.Upsert(ctx, policy))
+		assert.NotEqual(t, uuid.Nil, policy.ID)
+
+		// Upserting again replaces the service policy
+		policy.MaxAttempts = 3
+		require.NoError(t, policyRepo.Upsert(ctx, policy))
+
+		defPolicy := &RetryPolicy{ServiceID: svc.ID, TestDefinitionID: &def.ID, MaxAttempts: 5, BackoffSeconds: 10, MaxBackoffSeconds: 60, RetryOn: RetryOnFailure}
+		require.NoError(t, policyRepo.Upsert(ctx, defPolicy))
+	})
+
+	t.Run("ListByService", func(t *testing.T) {
+		policies, err := policyRepo.ListByService(ctx, svc.ID)
+		require.NoError(t, err)
+		require.Len(t, policies, 2)
+		assert.Nil(t, policies[0].TestDefinitionID)
+		assert.Equal(t, 3, policies[0].MaxAttempts)
+		assert.Equal(t, def.ID, *policies[1].TestDefinitionID)
+		assert.Equal(t, RetryOnFailure, policies[1].RetryOn)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, policyRepo.Delete(ctx, svc.ID, &def.ID))
+		assert.ErrorIs(t, policyRepo.Delete(ctx, svc.ID, &def.ID), ErrNotFound)
+
+		policies, err := policyRepo.ListByService(ctx, svc.ID)
+		require.NoError(t, err)
+		require.Len(t, policies, 1)
+		assert.Nil(t, policies[0].TestDefinitionID)
+	})
+}
+
+func TestScheduleRepository(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	scheduleRepo := NewScheduleRepo(testDB.db)
+
+	svc := &Service{
+		Name:          "test-schedule-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	next := time.Now().Add(-time.Minute).UTC().Truncate(time.Microsecond)
+	schedule := &ScheduledRun{
+		ServiceID:      svc.ID,
+		Name:           "nightly",
+		CronExpression: "30 2 * * *",
+		Timezone:       "America/New_York",
+		GitRef:         "main",
+		Enabled:        true,
+		NextRunAt:      &next,
+	}
+	require.NoError(t, scheduleRepo.Create(ctx, schedule))
+
+	t.Run("Timezone", func(t *testing.T) {
+		got, err := scheduleRepo.Get(ctx, schedule.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "America/New_York", got.Timezone)
+
+		got.Timezone = "Europe/Stockholm"
+		require.NoError(t, scheduleRepo.Update(ctx, got))
+
+		schedules, err := scheduleRepo.ListByService(ctx, svc.ID)
+		require.NoError(t, err)
+		require.Len(t, schedules, 1)
+		assert.Equal(t, "Europe/Stockholm", schedules[0].Timezone)
+
+		// Timezone defaults to UTC
+		utc := &ScheduledRun{ServiceID: svc.ID, Name: "hourly", CronExpression: "@hourly", GitRef: "main"}
+		require.NoError(t, scheduleRepo.Create(ctx, utc))
+		got, err = scheduleRepo.Get(ctx, utc.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "UTC", got.Timezone)
+	})
+
+	t.Run("ListDue", func(t *testing.T) {
+		due, err := scheduleRepo.ListDue(ctx)
+		require.NoError(t, err)
+		var found bool
+		for _, s := range due {
+			if s.ID == schedule.ID {
+				found = true
+				assert.Equal(t, "Europe/Stockholm", s.Timezone)
+			}
+		}
+		assert.True(t, found, "due schedule should be listed")
+
+		require.NoError(t, scheduleRepo.UpdateAfterRun(ctx, schedule.ID, next.Add(24*time.Hour)))
+		got, err := scheduleRepo.Get(ctx, schedule.ID)
+		require.NoError(t, err)
+		require.NotNil(t, got.LastRunAt)
+		assert.True(t, got.NextRunAt.Equal(next.Add(24*time.Hour)))
+	})
+}
+
+func TestServiceSyncRepository(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	syncRepo := NewServiceSyncRepo(testDB.db)
+
+	interval := 3600
+	svc := &Service{
+		Name:                "test-sync-service-" + uuid.New().String()[:8],
+		GitURL:              "https://github.com/example/repo.git",
+		DefaultBranch:       "main",
+		SyncIntervalSeconds: &interval,
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	isDue := func(t *testing.T) bool {
+		due, err := syncRepo.ListDue(ctx, 0, 1000)
+		require.NoError(t, err)
+		for _, d := range due {
+			if d.ID == svc.ID {
+				assert.Equal(t, interval, *d.SyncIntervalSeconds)
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Run("NeverSyncedIsDue", func(t *testing.T) {
+		_, err := syncRepo.Latest(ctx, svc.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.True(t, isDue(t))
+	})
+
+	t.Run("ArchivedIsNotDue", func(t *testing.T) {
+		archivedAt := time.Now().UTC().Truncate(time.Microsecond)
+		svc.ArchivedAt = &archivedAt
+		require.NoError(t, svcRepo.Update(ctx, svc))
+
+		got, err := svcRepo.Get(ctx, svc.ID)
+		require.NoError(t, err)
+		require.NotNil(t, got.ArchivedAt)
+		assert.True(t, archivedAt.Equal(*got.ArchivedAt))
+		assert.False(t, isDue(t))
+
+		svc.ArchivedAt = nil
+		require.NoError(t, svcRepo.Update(ctx, svc))
+		assert.True(t, isDue(t))
+	})
+
+	t.Run("Record", func(t *testing.T) {
+		now := time.Now().UTC()
+		for i, status := range []SyncStatus{SyncStatusFailed, SyncStatusPartial} {
+			sync := &ServiceSync{
+				ServiceID:  svc.ID,
+				Branch:     "main",
+				Trigger:    SyncTriggerScheduled,
+				Status:     status,
+				TestsAdded: i,
+				StartedAt:  now.Add(time.Duration(i-1) * time.Minute),
+				FinishedAt: now,
+			}
+			if status == SyncStatusPartial {
+				sync.Errors = []string{"invalid test config 'e2e': test command is required"}
+			}
+			require.NoError(t, syncRepo.Record(ctx, sync))
+			assert.NotEqual(t, uuid.Nil, sync.ID)
+		}
+
+		latest, err := syncRepo.Latest(ctx, svc.ID)
+		require.NoError(t, err)
+		assert.Equal(t, SyncStatusPartial, latest.Status)
+		assert.Equal(t, []string{"invalid test config 'e2e': test command is required"}, latest.Errors)
+	})
+
+	t.Run("RecentlySyncedIsNotDue", func(t *testing.T) {
+		assert.False(t, isDue(t))
+	})
+
+	t.Run("ListByService", func(t *testing.T) {
+		syncs, total, err := syncRepo.ListByService(ctx, svc.ID, Pagination{Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		require.Len(t, syncs, 1)
+		assert.Equal(t, SyncStatusPartial, syncs[0].Status)
+	})
+
+	t.Run("DisabledIsNeverDue", func(t *testing.T) {
+		disabled := 0
+		svc.SyncIntervalSeconds = &disabled
+		require.NoError(t, svcRepo.Update(ctx, svc))
+
+		due, err := syncRepo.ListDue(ctx, time.Minute, 1000)
+		require.NoError(t, err)
+		for _, d := range due {
+			assert.NotEqual(t, svc.ID, d.ID)
+		}
+	})
+}
+
+// ============================================================================
+// RESULT REPOSITORY TESTS
+// ============================================================================
+
+func TestResultRepository(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	resultRepo := NewResultRepo(testDB.db)
+
+	// Create service and run
+	svc := &Service{
+		Name:          "test-result-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	err := svcRepo.Create(ctx, svc)
+	require.NoError(t, err)
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	run := &TestRun{
+		ServiceID: svc.ID,
+		Status:    RunStatusRunning,
+	}
+	err = runRepo.Create(ctx, run)
+	require.NoError(t, err)
+	defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run.ID)
+
+	t.Run("Create", func(t *testing.T) {
+		durationMs := int64(150)
+		result := &TestResult{
+			RunID:      run.ID,
+			TestName:   "TestExample",
+			SuiteName:  NullString("ExampleSuite"),
+			Status:     ResultStatusPass,
+			DurationMs: &durationMs,
+		}
+
+		err := resultRepo.Create(ctx, result)
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, result.ID)
+
+		t.Cleanup(func() {
+			testDB.db.Pool().Exec(ctx, "DELETE FROM test_results WHERE id = $1", result.ID)
+		})
+	})
+
+	t.Run("BatchCreate", func(t *testing.T) {
+		results := make([]TestResult, 5)
+		for i := 0; i < 5; i++ {
+			durationMs := int64(i * 100)
+			results[i] = TestResult{
+				RunID:      run.ID,
+				TestName:   "TestBatch" + string(rune('A'+i)),
+				Status:     ResultStatusPass,
+				DurationMs: &durationMs,
+			}
+		}
+
+		err := resultRepo.BatchCreate(ctx, results)
+		require.NoError(t, err)
+		for _, r := range results {
+			assert.NotEqual(t, uuid.Nil, r.ID)
+			assert.False(t, r.CreatedAt.IsZero())
+		}
+
+		got, err := resultRepo.Get(ctx, results[2].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "TestBatchC", got.TestName)
+		assert.Equal(t, ResultStatusPass, got.Status)
+		require.NotNil(t, got.DurationMs)
+		assert.Equal(t, int64(200), *got.DurationMs)
+
+		// Verify all were created
+		fetched, err := resultRepo.ListByRun(ctx, run.ID)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, len(fetched), 5)
+
+		t.Cleanup(func() {
+			for _, r := range results {
+				testDB.db.Pool().Exec(ctx, "DELETE FROM test_results WHERE id = $1", r.ID)
+			}
+		})
+	})
+
+	t.Run("BatchCreateSkipsReplayedSequences", func(t *testing.T) {
+		t.Cleanup(func() {
+			testDB.db.Pool().Exec(ctx, "DELETE FROM test_results WHERE run_id = $1 AND test_name LIKE 'TestReplay%'", run.ID)
+		})
+
+		first := []TestResult{
+			{RunID: run.ID, TestName: "TestReplayA", Status: ResultStatusPass, StreamSequence: 101},
+			{RunID: run.ID, TestName: "TestReplayB", Status: ResultStatusPass, StreamSequence: 102},
+		}
+		require.NoError(t, resultRepo.BatchCreate(ctx, first))
+
+		// The agent replays B and sends C after a reconnect
+		replay := []TestResult{
+			{RunID: run.ID, TestName: "TestReplayB", Status: ResultStatusPass, StreamSequence: 102},
+			{RunID: run.ID, TestName: "TestReplayC", Status: ResultStatusFail, StreamSequence: 103},
+			{RunID: run.ID, TestName: "TestReplayC", Status: ResultStatusFail, StreamSequence: 103},
+		}
+		require.NoError(t, resultRepo.BatchCreate(ctx, replay))
+
+		fetched, err := resultRepo.ListByRun(ctx, run.ID)
+		require.NoError(t, err)
+		counts := make(map[string]int)
+		for _, result := range fetched {
+			counts[result.TestName]++
+		}
+		assert.Equal(t, 1, counts["TestReplayA"])
+		assert.Equal(t, 1, counts["TestReplayB"])
+		assert.Equal(t, 1, counts["TestReplayC"])
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		durationMs := int64(200)
+		errMsg := "assertion failed"
+		result := &TestResult{
+			RunID:        run.ID,
+			TestName:     "TestGetExample",
+			Status:       ResultStatusFail,
+			DurationMs:   &durationMs,
+			ErrorMessage: &errMsg,
+		}
+		err := resultRepo.Create(ctx, result)
+		require.NoError(t, err)
+		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_results WHERE id = $1", result.ID)
+
+		fetched, err := resultRepo.Get(ctx, result.ID)
+		require.NoError(t, err)
+		assert.Equal(t, result.TestName, fetched.TestName)
+		assert.Equal(t, result.Status, fetched.Status)
+		assert.Equal(t, *result.ErrorMessage, *fetched.ErrorMessage)
+	})
+
+	t.Run("ListByRun", func(t *testing.T) {
+		// Create new run for isolation
+		run2 := &TestRun{
+			ServiceID: svc.ID,
+			Status:    RunStatusRunning,
+		}
+		err := runRepo.Create(ctx, run2)
+		require.NoError(t, err)
+		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run2.ID)
+
+		for i := 0; i < 3; i++ {
+			result := &TestResult{
+				RunID:    run2.ID,
+				TestName: "TestList" + string(rune('A'+i)),
+				Status:   ResultStatusPass,
+			}
+			err := resultRepo.Create(ctx, result)
+			require.NoError(t, err)
+		}
+
+		results, err := resultRepo.ListByRun(ctx, run2.ID)
+		require.NoError(t, err)
+		assert.Len(t, results, 3)
+	})
+
+	t.Run("ListByRunAndStatus", func(t *testing.T) {
+		// Create new run for isolation
+		run3 := &TestRun{
+			ServiceID: svc.ID,
+			Status:    RunStatusRunning,
+		}
+		err := runRepo.Create(ctx, run3)
+		require.NoError(t, err)
+		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run3.ID)
+
+		// Create mixed results
+		statuses := []ResultStatus{ResultStatusPass, ResultStatusPass, ResultStatusFail, ResultStatusSkip}
+		for i, status := range statuses {
+			result := &TestResult{
+				RunID:    run3.ID,
+				TestName: "TestStatus" + string(rune('A'+i)),
+				Status:   status,
+			}
+			err := resultRepo.Create(ctx, result)
+			require.NoError(t, err)
+		}
+
+		// Get only failed
+		failed, err := resultRepo.ListByRunAndStatus(ctx, run3.ID, ResultStatusFail)
+		require.NoError(t, err)
+		assert.Len(t, failed, 1)
+	})
+
+	t.Run("ListPage", func(t *testing.T) {
+		run4 := &TestRun{
+			ServiceID: svc.ID,
+			Status:    RunStatusRunning,
+		}
+		err := runRepo.Create(ctx, run4)
+		require.NoError(t, err)
+		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run4.ID)
+
+		for i, status := range []ResultStatus{ResultStatusPass, ResultStatusFail, ResultStatusPass, ResultStatusPass} {
+			err := resultRepo.Create(ctx, &TestResult{
+				RunID:    run4.ID,
+				TestName: "TestPage" + string(rune('A'+i)),
+				Status:   status,
+			})
+			require.NoError(t, err)
+		}
+
+		statuses := []ResultStatus{ResultStatusPass}
+		first, err := resultRepo.ListPage(ctx, run4.ID, statuses, Pagination{Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, first, 2)
+		assert.Equal(t, "TestPageA", first[0].TestName)
+		assert.Equal(t, "TestPageC", first[1].TestName)
+
+		last := first[1]
+		rest, err := resultRepo.ListPage(ctx, run4.ID, statuses, Pagination{
+			Limit: 2,
+			After: &Cursor{ID: last.ID, Name: last.TestName},
+		})
+		require.NoError(t, err)
+		require.Len(t, rest, 1)
+		assert.Equal(t, "TestPageD", rest[0].TestName)
+
+		byOffset, err := resultRepo.ListPage(ctx, run4.ID, nil, Pagination{Limit: 10, Offset: 3})
+		require.NoError(t, err)
+		require.Len(t, byOffset, 1)
+		assert.Equal(t, "TestPageD", byOffset[0].TestName)
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		var runs []*TestRun
+		for _, ref := range []string{"main", "refs/heads/main", "feature"} {
+			r := &TestRun{
+				ServiceID: svc.ID,
+				Status:    RunStatusFailed,
+				GitRef:    NullString(ref),
+			}
+			err := runRepo.Create(ctx, r)
+			require.NoError(t, err)
+			defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", r.ID)
+			runs = append(runs, r)
+		}
+		for _, r := range runs {
+			err := resultRepo.Create(ctx, &TestResult{
+				RunID:        r.ID,
+				TestName:     "TestCheckout",
+				Status:       ResultStatusFail,
+				ErrorMessage: NullString("dial tcp 10.0.0.5:5432: connection refused"),
+				StackTrace:   NullString("at db.Connect (db.go:42)"),
+			})
+			require.NoError(t, err)
+		}
+
+		branch := "main"
+		text := "connection refused"
+		filter := RunSearchFilter{ServiceID: &svc.ID, Branch: &branch, Text: &text}
+		found, total, err := runRepo.Search(ctx, filter, Pagination{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		require.Len(t, found, 2)
+		assert.Equal(t, runs[1].ID, found[0].ID, "newest first")
+		assert.Equal(t, runs[0].ID, found[1].ID)
+
+		// Substrings of stack traces match too
+		text = "db.Conn"
+		found, _, err = runRepo.Search(ctx, filter, Pagination{Limit: 1, After: &Cursor{ID: runs[1].ID, CreatedAt: found[0].CreatedAt}})
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, runs[0].ID, found[0].ID)
+
+		text = "no such error"
+		_, total, err = runRepo.Search(ctx, filter, Pagination{Limit: 10})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+
+		matching, err := resultRepo.SearchByRun(ctx, runs[2].ID, "connection refused", 5)
+		require.NoError(t, err)
+		require.Len(t, matching, 1)
+		assert.Equal(t, "TestCheckout", matching[0].TestName)
+	})
+
+	t.Run("CountByRun", func(t *testing.T) {
+		// Create new run for isolation
+		run4 := &TestRun{
+			ServiceID: svc.ID,
+			Status:    RunStatusRunning,
+		}
+		err := runRepo.Create(ctx, run4)
+		require.NoError(t, err)
+		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run4.ID)
+
+		// Create mixed results
+		for i := 0; i < 5; i++ {
+			status := ResultStatusPass
+			if i < 2 {
+				status = ResultStatusFail
+			}
+			result := &TestResult{
+				RunID:    run4.ID,
+				TestName: "TestCount" + string(rune('A'+i)),
+				Status:   status,
+			}
+			err := resultRepo.Create(ctx, result)
+			require.NoError(t, err)
+		}
+
+		counts, err := resultRepo.CountByRun(ctx, run4.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), counts[ResultStatusFail])
+		assert.Equal(t, int64(3), counts[ResultStatusPass])
+	})
+
+	t.Run("DeleteByRun", func(t *testing.T) {
+		// Create new run for isolation
+		run5 := &TestRun{
+			ServiceID: svc.ID,
+			Status:    RunStatusRunning,
+		}
+		err := runRepo.Create(ctx, run5)
+		require.NoError(t, err)
+		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run5.ID)
+
+		// Create results
+		for i := 0; i < 3; i++ {
+			result := &TestResult{
+				RunID:    run5.ID,
+				TestName: "TestDelete" + string(rune('A'+i)),
+				Status:   ResultStatusPass,
+			}
+			err := resultRepo.Create(ctx, result)
+			require.NoError(t, err)
+		}
+
+		err = resultRepo.DeleteByRun(ctx, run5.ID)
+		require.NoError(t, err)
+
+		results, err := resultRepo.ListByRun(ctx, run5.ID)
+		require.NoError(t, err)
+		assert.Len(t, results, 0)
+	})
+
+	t.Run("GetLastPassing", func(t *testing.T) {
+		create := func(status ResultStatus, stdout string) *TestResult {
+			r := &TestRun{ServiceID: svc.ID, Status: RunStatusRunning}
+			require.NoError(t, runRepo.Create(ctx, r))
+			t.Cleanup(func() {
+				testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", r.ID)
+			})
+			result := &TestResult{
+				RunID:     r.ID,
+				TestName:  "TestDiff",
+				SuiteName: NullString("DiffSuite"),
+				Status:    status,
+				Stdout:    &stdout,
+			}
+			require.NoError(t, resultRepo.Create(ctx, result))
+			return result
+		}
+
+		create(ResultStatusPass, "old")
+		passing := create(ResultStatusPass, "latest")
+		failing := create(ResultStatusFail, "broken")
+
+		last, err := resultRepo.GetLastPassing(ctx, svc.ID, "TestDiff", NullString("DiffSuite"), failing.CreatedAt)
+		require.NoError(t, err)
+		assert.Equal(t, passing.ID, last.ID)
+		assert.Equal(t, "latest", *last.Stdout)
+
+		_, err = resultRepo.GetLastPassing(ctx, svc.ID, "TestDiff", nil, failing.CreatedAt)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+// ============================================================================
+// NOTIFICATION REPOSITORY TESTS
+// ============================================================================
+
+func TestNotificationRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewNotificationRepo(testDB.db)
+	svcRepo := NewServiceRepo(testDB.db)
+
+	t.Run("CreateChannel", func(t *testing.T) {
+		channel := &NotificationChannel{
+			Name:    "test-channel-" + uuid.New().String()[:8],
+			Type:    ChannelTypeSlack,
+			Config:  []byte(`{"webhook_url": "https://hooks.slack.com/test"}`),
+			Enabled: true,
+		}
+
+		err := repo.CreateChannel(ctx, channel)
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, channel.ID)
+
+		t.Cleanup(func() {
+			repo.DeleteChannel(ctx, channel.ID)
+		})
+	})
+
+	t.Run("GetChannel", func(t *testing.T) {
+		channel := &NotificationChannel{
+			Name:    "test-channel-get-" + uuid.New().String()[:8],
+			Type:    ChannelTypeEmail,
+			Config:  []byte(`{"recipients": ["test@example.com"]}`),
+			Enabled: true,
+		}
+		err := repo.CreateChannel(ctx, channel)
+		require.NoError(t, err)
+		defer repo.DeleteChannel(ctx, channel.ID)
+
+		fetched, err := repo.GetChannel(ctx, channel.ID)
+		require.NoError(t, err)
+		assert.Equal(t, channel.Name, fetched.Name)
+		assert.Equal(t, channel.Type, fetched.Type)
+		assert.Equal(t, channel.Enabled, fetched.Enabled)
+	})
+
+	t.Run("UpdateChannel", func(t *testing.T) {
+		channel := &NotificationChannel{
+			Name:    "test-channel-update-" + uuid.New().String()[:8],
+			Type:    ChannelTypeWebhook,
+			Config:  []byte(`{"url": "https://example.com/webhook"}`),
+			Enabled: true,
+		}
+		err := repo.CreateChannel(ctx, channel)
+		require.NoError(t, err)
+		defer repo.DeleteChannel(ctx, channel.ID)
+
+		channel.Enabled = false
+		channel.Config = []byte(`{"url": "https://example.com/webhook-v2"}`)
+
+		err = repo.UpdateChannel(ctx, channel)
+		require.NoError(t, err)
+
+		fetched, err := repo.GetChannel(ctx, channel.ID)
+		require.NoError(t, err)
+		assert.False(t, fetched.Enabled)
+	})
+
+	t.Run("ListChannels", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			channel := &NotificationChannel{
+				Name:    "test-channel-list-" + uuid.New().String()[:8],
+				Type:    ChannelTypeSlack,
+				Config:  []byte(`{}`),
+				Enabled: true,
+			}
+			err := repo.CreateChannel(ctx, channel)
+			require.NoError(t, err)
+			defer repo.DeleteChannel(ctx, channel.ID)
+		}
+
+		channels, err := repo.ListChannels(ctx, DefaultPagination())
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, len(channels), 2)
+	})
+
+	t.Run("ListEnabledChannels", func(t *testing.T) {
+		enabledChannel := &NotificationChannel{
+			Name:    "test-channel-enabled-" + uuid.New().String()[:8],
+			Type:    ChannelTypeSlack,
+			Config:  []byte(`{}`),
+			Enabled: true,
+		}
+		err := repo.CreateChannel(ctx, enabledChannel)
+		require.NoError(t, err)
+		defer repo.DeleteChannel(ctx, enabledChannel.ID)
+
+		disabledChannel := &NotificationChannel{
+			Name:    "test-channel-disabled-" + uuid.New().String()[:8],
+			Type:    ChannelTypeSlack,
+			Config:  []byte(`{}`),
+			Enabled: false,
+		}
+		err = repo.CreateChannel(ctx, disabledChannel)
+		require.NoError(t, err)
+		defer repo.DeleteChannel(ctx, disabledChannel.ID)
+
+		channels, err := repo.ListEnabledChannels(ctx)
+		require.NoError(t, err)
+
+		foundEnabled := false
+		foundDisabled := false
+		for _, ch := range channels {
+			if ch.ID == enabledChannel.ID {
+				foundEnabled = true
+			}
+			if ch.ID == disabledChannel.ID {
+				foundDisabled = true
+			}
+		}
+		assert.True(t, foundEnabled, "enabled channel should be in list")
+		assert.False(t, foundDisabled, "disabled channel should not be in list")
+	})
+
+	t.Run("CreateRule", func(t *testing.T) {
+		channel := &NotificationChannel{
+			Name:    "test-rule-channel-" + uuid.New().String()[:8],
+			Type:    ChannelTypeSlack,
+			Config:  []byte(`{}`),
+			Enabled: true,
+		}
+		err := repo.CreateChannel(ctx, channel)
+		require.NoError(t, err)
+		defer repo.DeleteChannel(ctx, channel.ID)
+
+		rule := &NotificationRule{
+			ChannelID: channel.ID,
+			TriggerOn: []TriggerEvent{TriggerEventFailure, TriggerEventRecovery},
+			Enabled:   true,
+		}
+
+		err = repo.CreateRule(ctx, rule)
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, rule.ID)
+
+		t.Cleanup(func() {
+			repo.DeleteRule(ctx, rule.ID)
+		})
+	})
+
+	t.Run("GetRule", func(t *testing.T) {
+		channel := &NotificationChannel{
+			Name:    "test-getrule-channel-" + uuid.New().String()[:8],
+			Type:    ChannelTypeSlack,
+			Config:  []byte(`{}`),
+			Enabled: true,
+		}
+		err := repo.CreateChannel(ctx, channel)
+		require.NoError(t, err)
+		defer repo.DeleteChannel(ctx, channel.ID)
+
+		rule := &NotificationRule{
+			ChannelID: channel.ID,
+			Owner:     NullString("team-payments"),
+			TriggerOn: []TriggerEvent{TriggerEventAlways},
+			Enabled:   true,
+			// Templates are stored verbatim.
+			MessageTemplate: NullString("{{.FailedTests}} of {{.TotalTests}} failed"),
+		}
+		err = repo.CreateRule(ctx, rule)
+		require.NoError(t, err)
+		defer repo.DeleteRule(ctx, rule.ID)
+
+		fetched, err := repo.GetRule(ctx, rule.ID)
+		require.NoError(t, err)
+		assert.Equal(t, rule.ChannelID, fetched.ChannelID)
+		assert.Equal(t, rule.TriggerOn, fetched.TriggerOn)
+		assert.Equal(t, rule.Owner, fetched.Owner)
+		assert.Nil(t, fetched.TitleTemplate)
+		assert.Equal(t, rule.MessageTemplate, fetched.MessageTemplate)
+	})
+
+	t.Run("ListRulesByService", func(t *testing.T) {
+		// Create service
+		svc := &Service{
+			Name:          "test-rule-service-" + uuid.New().String()[:8],
+			GitURL:        "https://github.com/example/repo.git",
+			DefaultBranch: "main",
+		}
+		err := svcRepo.Create(ctx, svc)
+		require.NoError(t, err)
+		defer svcRepo.Delete(ctx, svc.ID)
+
+		// Create channel
+		channel := &NotificationChannel{
+			Name:    "test-listsvc-channel-" + uuid.New().String()[:8],
+			Type:    ChannelTypeSlack,
+			Config:  []byte(`{}`),
+			Enabled: true,
+		}
+		err = repo.CreateChannel(ctx, channel)
+		require.NoError(t, err)
+		defer repo.DeleteChannel(ctx, channel.ID)
+
+		// Create rule for specific service
+		rule := &NotificationRule{
+			ChannelID: channel.ID,
+			ServiceID: &svc.ID,
+			TriggerOn: []TriggerEvent{TriggerEventFailure},
+			Enabled:   true,
+		}
+		err = repo.CreateRule(ctx, rule)
+		require.NoError(t, err)
+		defer repo.DeleteRule(ctx, rule.ID)
+
+		// Create global rule (nil ServiceID)
+		globalRule := &NotificationRule{
+			ChannelID: channel.ID,
+			ServiceID: nil,
+			TriggerOn: []TriggerEvent{TriggerEventAlways},
+			Enabled:   true,
+		}
+		err = repo.CreateRule(ctx, globalRule)
+		require.NoError(t, err)
+		defer repo.DeleteRule(ctx, globalRule.ID)
+
+		rules, err := repo.ListRulesByService(ctx, svc.ID)
+		require.NoError(t, err)
+
+		// Should include both service-specific and global rules
+		foundSpecific := false
+		foundGlobal := false
+		for _, r := range rules {
+			if r.ID == rule.ID {
+				foundSpecific = true
+			}
+			if r.ID == globalRule.ID {
+				foundGlobal = true
+			}
+		}
+		assert.True(t, foundSpecific, "service-specific rule should be in list")
+		assert.True(t, foundGlobal, "global rule should be in list")
+	})
+
+	t.Run("Deliveries", func(t *testing.T) {
+		svc := &Service{
+			Name:          "test-delivery-service-" + uuid.New().String()[:8],
+			GitURL:        "https://github.com/example/repo.git",
+			DefaultBranch: "main",
+		}
+		require.NoError(t, svcRepo.Create(ctx, svc))
+		defer svcRepo.Delete(ctx, svc.ID)
+
+		channel := &NotificationChannel{
+			Name:    "test-delivery-channel-" + uuid.New().String()[:8],
+			Type:    ChannelTypeWebhook,
+			Config:  []byte(`{}`),
+			Enabled: true,
+		}
+		require.NoError(t, repo.CreateChannel(ctx, channel))
+
+		ruleID := uuid.New()
+		now := time.Now().Truncate(time.Microsecond)
+		var failedID uuid.UUID
+		for i, status := range []DeliveryStatus{DeliveryStatusSent, DeliveryStatusFailed, DeliveryStatusSent} {
+			delivery := &NotificationDelivery{
+				EventType:   "run_failed",
+				ServiceID:   &svc.ID,
+				ChannelID:   &channel.ID,
+				ChannelType: ChannelTypeWebhook,
+				Status:      status,
+				Attempts:    i + 1,
+				SentAt:      now.Add(time.Duration(i) * time.Minute),
+			}
+			if i == 1 {
+				delivery.RuleIDs = []uuid.UUID{ruleID}
+				delivery.Error = "webhook returned status 500"
+				delivery.ResponseExcerpt = "internal error"
+				delivery.Payload = []byte(`{"Title": "Tests Failed"}`)
+				delivery.PayloadHash = "5f2b1c"
+			}
+			require.NoError(t, repo.RecordDelivery(ctx, delivery))
+			assert.NotEqual(t, uuid.Nil, delivery.ID)
+			if status == DeliveryStatusFailed {
+				failedID = delivery.ID
+			}
+		}
+
+		got, err := repo.GetDelivery(ctx, failedID)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"Title": "Tests Failed"}`, string(got.Payload))
+		assert.Equal(t, "5f2b1c", got.PayloadHash)
+		assert.Nil(t, got.RedeliveryOf)
+
+		_, err = repo.GetDelivery(ctx, uuid.New())
+		assert.True(t, IsNotFound(err))
+
+		all, total, err := repo.ListDeliveries(ctx, NotificationDeliveryFilter{ServiceID: &svc.ID}, Pagination{Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		require.Len(t, all, 2)
+		assert.Equal(t, 3, all[0].Attempts, "newest delivery first")
+
+		failed := DeliveryStatusFailed
+		matches, total, err := repo.ListDeliveries(ctx, NotificationDeliveryFilter{ServiceID: &svc.ID, Status: &failed}, Pagination{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, matches, 1)
+		assert.Equal(t, []uuid.UUID{ruleID}, matches[0].RuleIDs)
+		assert.Equal(t, "internal error", matches[0].ResponseExcerpt)
+		assert.Equal(t, "5f2b1c", matches[0].PayloadHash)
+		assert.Nil(t, matches[0].Payload, "lists do not load payloads")
+
+		matches, _, err = repo.ListDeliveries(ctx, NotificationDeliveryFilter{RuleID: &ruleID}, Pagination{Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, matches, 1)
+
+		since := now.Add(time.Minute)
+		_, total, err = repo.ListDeliveries(ctx, NotificationDeliveryFilter{ServiceID: &svc.ID, Since: &since}, Pagination{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+
+		// Deleting the channel keeps its deliveries
+		require.NoError(t, repo.DeleteChannel(ctx, channel.ID))
+		kept, _, err := repo.ListDeliveries(ctx, NotificationDeliveryFilter{ServiceID: &svc.ID}, Pagination{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, kept, 3)
+		assert.Nil(t, kept[0].ChannelID)
+		assert.Equal(t, ChannelTypeWebhook, kept[0].ChannelType)
+
+		redelivery := &NotificationDelivery{
+			EventType:    "run_failed",
+			ServiceID:    &svc.ID,
+			ChannelType:  ChannelTypeWebhook,
+			Status:       DeliveryStatusSent,
+			Payload:      got.Payload,
+			PayloadHash:  got.PayloadHash,
+			RedeliveryOf: &failedID,
+		}
+		require.NoError(t, repo.RecordDelivery(ctx, redelivery))
+		got, err = repo.GetDelivery(ctx, redelivery.ID)
+		require.NoError(t, err)
+		assert.Equal(t, &failedID, got.RedeliveryOf)
+	})
+
+	t.Run("DeadLetters", func(t *testing.T) {
+		channel := &NotificationChannel{
+			Name:    "test-dead-letter-channel-" + uuid.New().String()[:8],
+			Type:    ChannelTypeWebhook,
+			Config:  []byte(`{}`),
+			Enabled: true,
+		}
+		require.NoError(t, repo.CreateChannel(ctx, channel))
+
+		failed := func() uuid.UUID {
+			delivery := &NotificationDelivery{
+				EventType:   "run_failed",
+				ChannelID:   &channel.ID,
+				ChannelType: ChannelTypeWebhook,
+				Status:      DeliveryStatusFailed,
+				Payload:     []byte(`{}`),
+			}
+			require.NoError(t, repo.RecordDelivery(ctx, delivery))
+			return delivery.ID
+		}
+
+		before, err := repo.CountDeadLetters(ctx)
+		require.NoError(t, err)
+
+		now := time.Now()
+		due, later := now.Add(-time.Minute), now.Add(time.Hour)
+		pending := &NotificationDeadLetter{DeliveryID: failed(), ChannelID: channel.ID, Error: "timeout", Transient: true, NextAttemptAt: &due}
+		waiting := &NotificationDeadLetter{DeliveryID: failed(), ChannelID: channel.ID, Error: "timeout", Transient: true, NextAttemptAt: &later}
+		exhausted := &NotificationDeadLetter{DeliveryID: failed(), ChannelID: channel.ID, Error: "status 410", Attempts: 5}
+		for _, d := range []*NotificationDeadLetter{pending, waiting, exhausted} {
+			require.NoError(t, repo.CreateDeadLetter(ctx, d))
+			assert.NotEqual(t, uuid.Nil, d.ID)
+		}
+
+		counts, err := repo.CountDeadLetters(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, before.Pending+2, counts.Pending)
+		assert.Equal(t, before.Exhausted+1, counts.Exhausted)
+
+		list, err := repo.ListDueDeadLetters(ctx, now, 100)
+		require.NoError(t, err)
+		var ids []uuid.UUID
+		for _, d := range list {
+			ids = append(ids, d.ID)
+		}
+		assert.Contains(t, ids, pending.ID)
+		assert.NotContains(t, ids, waiting.ID)
+		assert.NotContains(t, ids, exhausted.ID)
+
+		// A failed redelivery moves the dead letter to the new delivery
+		pending.DeliveryID = failed()
+		pending.Attempts = 1
+		pending.NextAttemptAt = &later
+		require.NoError(t, repo.UpdateDeadLetter(ctx, pending))
+		got, err := repo.GetDeadLetterByDelivery(ctx, pending.DeliveryID)
+		require.NoError(t, err)
+		assert.Equal(t, pending.ID, got.ID)
+		assert.Equal(t, 1, got.Attempts)
+		assert.True(t, got.Transient)
+
+		require.NoError(t, repo.DeleteDeadLetter(ctx, pending.ID))
+		_, err = repo.GetDeadLetterByDelivery(ctx, pending.DeliveryID)
+		assert.True(t, IsNotFound(err))
+		assert.True(t, IsNotFound(repo.DeleteDeadLetter(ctx, pending.ID)))
+
+		// Deleting the channel drops its dead letters
+		require.NoError(t, repo.DeleteChannel(ctx, channel.ID))
+		_, err = repo.GetDeadLetterByDelivery(ctx, waiting.DeliveryID)
+		assert.True(t, IsNotFound(err))
+	})
+}
+
+// ============================================================================
+// TRANSACTION TESTS
+// ============================================================================
+
+func TestTransactions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Commit", func(t *testing.T) {
+		var serviceID uuid.UUID
+
+		err := testDB.db.WithTx(ctx, func(tx pgx.Tx) error {
+			name := "tx-test-commit-" + uuid.New().String()[:8]
+			err := tx.QueryRow(ctx, `
+				INSERT INTO services (name, git_url, default_branch)
+				VALUES ($1, $2, $3)
+				RETURNING id
+			`, name, "https://github.com/example/repo.git", "main").Scan(&serviceID)
+			return err
+		})
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, serviceID)
+
+		// Verify committed
+		repo := NewServiceRepo(testDB.db)
+		svc, err := repo.Get(ctx, serviceID)
+		require.NoError(t, err)
+		assert.NotNil(t, svc)
+
+		// Cleanup
+		repo.Delete(ctx, serviceID)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		name := "tx-test-rollback-" + uuid.New().String()[:8]
+		var serviceID uuid.UUID
+
+		err := testDB.db.WithTx(ctx, func(tx pgx.Tx) error {
+			err := tx.QueryRow(ctx, `
+				INSERT INTO services (name, git_url, default_branch)
+				VALUES ($1, $2, $3)
+				RETURNING id
+			`, name, "https://github.com/example/repo.git", "main").Scan(&serviceID)
+			if err != nil {
+				return err
+			}
+
+			// Return error to trigger rollback
+			return assert.AnError
+		})
+		require.Error(t, err)
+
+		// Verify rolled back
+		repo := NewServiceRepo(testDB.db)
+		_, err = repo.GetByName(ctx, name)
+		assert.True(t, IsNotFound(err), "service should not exist after rollback")
+	})
+}
+
+// ============================================================================
+// DATABASE CONNECTION TESTS
+// ============================================================================
+
+func TestDatabaseConnection(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Health", func(t *testing.T) {
+		err := testDB.db.Health(ctx)
+		require.NoError(t, err)
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		stats := testDB.db.Stats()
+		assert.GreaterOrEqual(t, stats.MaxConns, int32(1))
+		assert.GreaterOrEqual(t, stats.TotalConns, int32(0))
+	})
+}
+
+// ============================================================================
+// HELPER TEST FOR MIGRATIONS FS
+// ============================================================================
+
+// Verify migrations directory exists and has expected files
+func TestMigrationsFilesystem(t *testing.T) {
+	migrationsFS := os.DirFS("../../migrations")
+
+	var foundFiles []string
+	err := fs.WalkDir(migrationsFS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			foundFiles = append(foundFiles, path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Should have at least 8 files (4 migrations x 2 up/down)
+	assert.GreaterOrEqual(t, len(foundFiles), 8, "should have at least 8 migration files")
+
+	// Check for expected migration patterns
+	expectedPatterns := []string{
+		"initial_schema.up.sql",
+		"initial_schema.down.sql",
+		"notifications.up.sql",
+		"notifications.down.sql",
+	}
+	for _, pattern := range expectedPatterns {
+		found := false
+		for _, file := range foundFiles {
+			if contains(file, pattern) {
+				found = true
+				break
+			}
+		}
+		assert.True(t, found, "should find migration file matching %s", pattern)
+	}
+}
+
+func contains(s, substr string) bool {
+	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
+}
+
+func containsHelper(s, substr string) bool {
+	for i := 0; i <= len(s)-len(substr); i++ {
+		if s[i:i+len(substr)] == substr {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAuditLogRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewAuditLogRepo(testDB.db)
+
+	actor := "user-" + uuid.New().String()[:8]
+	resourceID := uuid.New().String()
+	update := &AuditLog{
+		Actor:        actor,
+		ActorEmail:   "dev@example.com",
+		Action:       "/conductor.v1.ServiceRegistryService/UpdateService",
+		Protocol:     AuditProtocolGRPC,
+		ResourceType: "service",
+		ResourceID:   resourceID,
+		Request:      json.RawMessage(`{"serviceId":"` + resourceID + `","name":"checkout"}`),
+		Before:       json.RawMessage(`{"service":{"name":"cart"}}`),
+		After:        json.RawMessage(`{"service":{"name":"checkout"}}`),
+		Status:       "OK",
+		SourceIP:     "10.0.0.7",
+		RequestID:    "req-1",
+		UserAgent:    "grpc-go/1.70.0",
+	}
+	require.NoError(t, repo.Create(ctx, update))
+	assert.NotEqual(t, uuid.Nil, update.ID)
+	assert.False(t, update.CreatedAt.IsZero())
+
+	webhook := &AuditLog{
+		Actor:    actor,
+		Action:   "POST /api/v1/webhooks/github",
+		Protocol: AuditProtocolHTTP,
+		Status:   "202",
+	}
+	require.NoError(t, repo.Create(ctx, webhook))
 
-		counts, err := resultRepo.CountByRun(ctx, run4.ID)
+	t.Run("List", func(t *testing.T) {
+		logs, total, err := repo.List(ctx, AuditLogFilter{Actor: &actor}, Pagination{Limit: 10})
 		require.NoError(t, err)
-		assert.Equal(t, int64(2), counts[ResultStatusFail])
-		assert.Equal(t, int64(3), counts[ResultStatusPass])
-	})
-
-	t.Run("DeleteByRun", func(t *testing.T) {
-		// Create new run for isolation
-		run5 := &TestRun{
-			ServiceID: svc.ID,
-			Status:    RunStatusRunning,
-		}
-		err := runRepo.Create(ctx, run5)
+		assert.Equal(t, 2, total)
+		require.Len(t, logs, 2)
+		assert.Equal(t, webhook.ID, logs[0].ID, "newest first")
+		assert.Nil(t, logs[0].Request)
+		assert.Equal(t, AuditProtocolHTTP, logs[0].Protocol)
+
+		resourceType := "service"
+		logs, total, err = repo.List(ctx, AuditLogFilter{ResourceType: &resourceType, ResourceID: &resourceID}, Pagination{Limit: 10})
 		require.NoError(t, err)
-		defer testDB.db.Pool().Exec(ctx, "DELETE FROM test_runs WHERE id = $1", run5.ID)
-
-		// Create results
-		for i := 0; i < 3; i++ {
-			result := &TestResult{
-				RunID:    run5.ID,
-				TestName: "TestDelete" + string(rune('A'+i)),
-				Status:   ResultStatusPass,
-			}
-			err := resultRepo.Create(ctx, result)
-			require.NoError(t, err)
-		}
+		assert.Equal(t, 1, total)
+		require.Len(t, logs, 1)
+		assert.Equal(t, "dev@example.com", logs[0].ActorEmail)
+		assert.JSONEq(t, `{"service":{"name":"cart"}}`, string(logs[0].Before))
+		assert.JSONEq(t, `{"service":{"name":"checkout"}}`, string(logs[0].After))
+		assert.Equal(t, "10.0.0.7", logs[0].SourceIP)
+
+		since := time.Now().Add(time.Hour)
+		logs, total, err = repo.List(ctx, AuditLogFilter{Actor: &actor, Since: &since}, Pagination{Limit: 10})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, logs)
+	})
 
-		err = resultRepo.DeleteByRun(ctx, run5.ID)
+	t.Run("DeleteBefore", func(t *testing.T) {
+		deleted, err := repo.DeleteBefore(ctx, time.Now().Add(time.Minute))
 		require.NoError(t, err)
+		assert.GreaterOrEqual(t, deleted, int64(2))
 
-		results, err := resultRepo.ListByRun(ctx, run5.ID)
+		_, total, err := repo.List(ctx, AuditLogFilter{Actor: &actor}, Pagination{Limit: 10})
 		require.NoError(t, err)
-		assert.Len(t, results, 0)
+		assert.Zero(t, total)
 	})
 }
 
-// ============================================================================
-// NOTIFICATION REPOSITORY TESTS
-// ============================================================================
-
-func TestNotificationRepository(t *testing.T) {
+func TestTenancyRepositories(t *testing.T) {
 	ctx := context.Background()
-	repo := NewNotificationRepo(testDB.db)
+	orgRepo := NewOrganizationRepo(testDB.db)
+	projectRepo := NewProjectRepo(testDB.db)
 	svcRepo := NewServiceRepo(testDB.db)
 
-	t.Run("CreateChannel", func(t *testing.T) {
-		channel := &NotificationChannel{
-			Name:    "test-channel-" + uuid.New().String()[:8],
-			Type:    ChannelTypeSlack,
-			Config:  []byte(`{"webhook_url": "https://hooks.slack.com/test"}`),
-			Enabled: true,
-		}
+	org := &Organization{Name: "org-" + uuid.New().String()[:8]}
+	require.NoError(t, orgRepo.Create(ctx, org))
+	assert.NotEqual(t, uuid.Nil, org.ID)
 
-		err := repo.CreateChannel(ctx, channel)
-		require.NoError(t, err)
-		assert.NotEqual(t, uuid.Nil, channel.ID)
+	err := orgRepo.Create(ctx, &Organization{Name: org.Name})
+	assert.True(t, IsDuplicate(err))
 
-		t.Cleanup(func() {
-			repo.DeleteChannel(ctx, channel.ID)
-		})
-	})
+	payments := &Project{OrganizationID: org.ID, Name: "payments"}
+	search := &Project{OrganizationID: org.ID, Name: "search"}
+	require.NoError(t, projectRepo.Create(ctx, payments))
+	require.NoError(t, projectRepo.Create(ctx, search))
 
-	t.Run("GetChannel", func(t *testing.T) {
-		channel := &NotificationChannel{
-			Name:    "test-channel-get-" + uuid.New().String()[:8],
-			Type:    ChannelTypeEmail,
-			Config:  []byte(`{"recipients": ["test@example.com"]}`),
-			Enabled: true,
-		}
-		err := repo.CreateChannel(ctx, channel)
+	err = projectRepo.Create(ctx, &Project{OrganizationID: org.ID, Name: "payments"})
+	assert.True(t, IsDuplicate(err))
+
+	t.Run("ListByOrganization", func(t *testing.T) {
+		projects, total, err := projectRepo.ListByOrganization(ctx, org.ID, Pagination{Limit: 10})
 		require.NoError(t, err)
-		defer repo.DeleteChannel(ctx, channel.ID)
+		assert.Equal(t, 2, total)
+		require.Len(t, projects, 2)
+		assert.Equal(t, "payments", projects[0].Name)
 
-		fetched, err := repo.GetChannel(ctx, channel.ID)
+		scoped := WithProjectScope(ctx, []uuid.UUID{search.ID})
+		projects, total, err = projectRepo.ListByOrganization(scoped, org.ID, Pagination{Limit: 10})
 		require.NoError(t, err)
-		assert.Equal(t, channel.Name, fetched.Name)
-		assert.Equal(t, channel.Type, fetched.Type)
-		assert.Equal(t, channel.Enabled, fetched.Enabled)
+		assert.Equal(t, 1, total)
+		require.Len(t, projects, 1)
+		assert.Equal(t, search.ID, projects[0].ID)
 	})
 
-	t.Run("UpdateChannel", func(t *testing.T) {
-		channel := &NotificationChannel{
-			Name:    "test-channel-update-" + uuid.New().String()[:8],
-			Type:    ChannelTypeWebhook,
-			Config:  []byte(`{"url": "https://example.com/webhook"}`),
-			Enabled: true,
+	t.Run("ScopedServices", func(t *testing.T) {
+		svc := &Service{
+			Name:          "test-service-scoped-" + uuid.New().String()[:8],
+			GitURL:        "https://github.com/example/repo.git",
+			DefaultBranch: "main",
+			ProjectID:     payments.ID,
 		}
-		err := repo.CreateChannel(ctx, channel)
+		require.NoError(t, svcRepo.Create(ctx, svc))
+		t.Cleanup(func() { svcRepo.Delete(ctx, svc.ID) })
+
+		got, err := svcRepo.Get(WithProjectScope(ctx, []uuid.UUID{payments.ID}), svc.ID)
 		require.NoError(t, err)
-		defer repo.DeleteChannel(ctx, channel.ID)
+		assert.Equal(t, payments.ID, got.ProjectID)
 
-		channel.Enabled = false
-		channel.Config = []byte(`{"url": "https://example.com/webhook-v2"}`)
+		_, err = svcRepo.Get(WithProjectScope(ctx, []uuid.UUID{search.ID}), svc.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
 
-		err = repo.UpdateChannel(ctx, channel)
+		services, err := svcRepo.List(WithProjectScope(ctx, []uuid.UUID{search.ID}), Pagination{Limit: 100})
 		require.NoError(t, err)
+		for _, s := range services {
+			assert.NotEqual(t, svc.ID, s.ID)
+		}
 
-		fetched, err := repo.GetChannel(ctx, channel.ID)
+		unscoped := &Service{
+			Name:          "test-service-default-" + uuid.New().String()[:8],
+			GitURL:        "https://github.com/example/repo.git",
+			DefaultBranch: "main",
+		}
+		require.NoError(t, svcRepo.Create(ctx, unscoped))
+		t.Cleanup(func() { svcRepo.Delete(ctx, unscoped.ID) })
+
+		got, err = svcRepo.Get(ctx, unscoped.ID)
 		require.NoError(t, err)
-		assert.False(t, fetched.Enabled)
+		assert.Equal(t, DefaultProjectID, got.ProjectID)
 	})
+}
 
-	t.Run("ListChannels", func(t *testing.T) {
-		for i := 0; i < 2; i++ {
-			channel := &NotificationChannel{
-				Name:    "test-channel-list-" + uuid.New().String()[:8],
-				Type:    ChannelTypeSlack,
-				Config:  []byte(`{}`),
-				Enabled: true,
-			}
-			err := repo.CreateChannel(ctx, channel)
-			require.NoError(t, err)
-			defer repo.DeleteChannel(ctx, channel.ID)
-		}
+func TestPreferenceRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPreferenceRepo(testDB.db)
+	svcRepo := NewServiceRepo(testDB.db)
 
-		channels, err := repo.ListChannels(ctx, DefaultPagination())
+	svc := &Service{
+		Name:          "test-service-pinned-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	t.Cleanup(func() { svcRepo.Delete(ctx, svc.ID) })
+
+	user := "user-" + uuid.New().String()[:8]
+
+	t.Run("PinnedServices", func(t *testing.T) {
+		require.NoError(t, repo.PinService(ctx, user, svc.ID))
+		require.NoError(t, repo.PinService(ctx, user, svc.ID), "pinning again is a no-op")
+
+		err := repo.PinService(ctx, user, uuid.New())
+		assert.True(t, IsForeignKeyViolation(err))
+
+		services, err := repo.ListPinnedServices(ctx, user)
 		require.NoError(t, err)
-		assert.GreaterOrEqual(t, len(channels), 2)
+		require.Len(t, services, 1)
+		assert.Equal(t, svc.ID, services[0].ID)
+
+		services, err = repo.ListPinnedServices(WithProjectScope(ctx, []uuid.UUID{uuid.New()}), user)
+		require.NoError(t, err)
+		assert.Empty(t, services, "pinned services outside the scope are hidden")
+
+		require.NoError(t, repo.UnpinService(ctx, user, svc.ID))
+		assert.ErrorIs(t, repo.UnpinService(ctx, user, svc.ID), ErrNotFound)
 	})
 
-	t.Run("ListEnabledChannels", func(t *testing.T) {
-		enabledChannel := &NotificationChannel{
-			Name:    "test-channel-enabled-" + uuid.New().String()[:8],
-			Type:    ChannelTypeSlack,
-			Config:  []byte(`{}`),
-			Enabled: true,
+	t.Run("SavedViews", func(t *testing.T) {
+		failing := &SavedView{
+			UserID:    user,
+			Name:      "failing",
+			Page:      "runs",
+			Filters:   json.RawMessage(`{"status":["failed"]}`),
+			IsDefault: true,
 		}
-		err := repo.CreateChannel(ctx, enabledChannel)
-		require.NoError(t, err)
-		defer repo.DeleteChannel(ctx, enabledChannel.ID)
+		require.NoError(t, repo.CreateView(ctx, failing))
+		assert.NotEqual(t, uuid.Nil, failing.ID)
 
-		disabledChannel := &NotificationChannel{
-			Name:    "test-channel-disabled-" + uuid.New().String()[:8],
-			Type:    ChannelTypeSlack,
-			Config:  []byte(`{}`),
-			Enabled: false,
+		err := repo.CreateView(ctx, &SavedView{UserID: user, Name: "failing", Page: "runs", Filters: json.RawMessage(`{}`)})
+		assert.True(t, IsDuplicate(err))
+
+		scoped := &SavedView{
+			UserID:    user,
+			Name:      "failing",
+			Page:      "runs",
+			ServiceID: &svc.ID,
+			Filters:   json.RawMessage(`{}`),
+			IsDefault: true,
 		}
-		err = repo.CreateChannel(ctx, disabledChannel)
+		require.NoError(t, repo.CreateView(ctx, scoped), "views of a service are separate from the page's")
+
+		mine := &SavedView{UserID: user, Name: "mine", Page: "runs", Filters: json.RawMessage(`{"author":"me"}`), IsDefault: true}
+		require.NoError(t, repo.CreateView(ctx, mine))
+
+		got, err := repo.GetView(ctx, user, failing.ID)
 		require.NoError(t, err)
-		defer repo.DeleteChannel(ctx, disabledChannel.ID)
+		assert.False(t, got.IsDefault, "a new default view clears the previous default")
+		assert.JSONEq(t, `{"status":["failed"]}`, string(got.Filters))
 
-		channels, err := repo.ListEnabledChannels(ctx)
+		got, err = repo.GetView(ctx, user, scoped.ID)
 		require.NoError(t, err)
+		assert.True(t, got.IsDefault, "defaults are per service")
 
-		foundEnabled := false
-		foundDisabled := false
-		for _, ch := range channels {
-			if ch.ID == enabledChannel.ID {
-				foundEnabled = true
-			}
-			if ch.ID == disabledChannel.ID {
-				foundDisabled = true
+		failing.IsDefault = true
+		failing.Name = "failing on main"
+		require.NoError(t, repo.UpdateView(ctx, failing))
+
+		page := "runs"
+		views, err := repo.ListViews(ctx, user, SavedViewFilter{Page: &page})
+		require.NoError(t, err)
+		require.Len(t, views, 3)
+		for _, v := range views {
+			if v.ServiceID == nil {
+				assert.Equal(t, v.ID == failing.ID, v.IsDefault, v.Name)
 			}
 		}
-		assert.True(t, foundEnabled, "enabled channel should be in list")
-		assert.False(t, foundDisabled, "disabled channel should not be in list")
-	})
 
-	t.Run("CreateRule", func(t *testing.T) {
-		channel := &NotificationChannel{
-			Name:    "test-rule-channel-" + uuid.New().String()[:8],
-			Type:    ChannelTypeSlack,
-			Config:  []byte(`{}`),
-			Enabled: true,
-		}
-		err := repo.CreateChannel(ctx, channel)
+		views, err = repo.ListViews(ctx, user, SavedViewFilter{ServiceID: &svc.ID})
 		require.NoError(t, err)
-		defer repo.DeleteChannel(ctx, channel.ID)
+		require.Len(t, views, 1)
+		assert.Equal(t, scoped.ID, views[0].ID)
+
+		_, err = repo.GetView(ctx, "someone-else", failing.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, repo.DeleteView(ctx, "someone-else", failing.ID), ErrNotFound)
+
+		for _, v := range []*SavedView{failing, scoped, mine} {
+			require.NoError(t, repo.DeleteView(ctx, user, v.ID))
+		}
+	})
+}
+
+func TestAdvisoryLock(t *testing.T) {
+	ctx := context.Background()
+	const id = 424242
+
+	held, err := testDB.db.TryAdvisoryLock(ctx, id)
+	require.NoError(t, err)
+	require.NotNil(t, held)
+	require.NoError(t, held.Ping(ctx))
+
+	other, err := testDB.db.TryAdvisoryLock(ctx, id)
+	require.NoError(t, err)
+	assert.Nil(t, other, "only one session holds the lock")
+
+	require.NoError(t, held.Unlock(ctx))
+
+	other, err = testDB.db.TryAdvisoryLock(ctx, id)
+	require.NoError(t, err)
+	require.NotNil(t, other, "the lock is free once unlocked")
+	require.NoError(t, other.Unlock(ctx))
+}
+
+func TestAgentConnectionRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewAgentConnectionRepo(testDB.db)
+	agentRepo := NewAgentRepo(testDB.db)
+
+	agent := &Agent{
+		Name:        "test-agent-routed-" + uuid.New().String()[:8],
+		Status:      AgentStatusIdle,
+		MaxParallel: 1,
+	}
+	require.NoError(t, agentRepo.Create(ctx, agent))
+	t.Cleanup(func() { agentRepo.Delete(ctx, agent.ID) })
+
+	_, err := repo.Get(ctx, agent.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	conn := &AgentConnection{AgentID: agent.ID, ReplicaID: "replica-a", ReplicaAddress: "10.0.0.1:9090"}
+	require.NoError(t, repo.Register(ctx, conn))
+	assert.False(t, conn.ConnectedAt.IsZero())
+
+	// The agent reconnects to another replica before the first one notices
+	require.NoError(t, repo.Register(ctx, &AgentConnection{AgentID: agent.ID, ReplicaID: "replica-b", ReplicaAddress: "10.0.0.2:9090"}))
+	require.NoError(t, repo.Unregister(ctx, agent.ID, "replica-a"))
+
+	got, err := repo.Get(ctx, agent.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "replica-b", got.ReplicaID)
+	assert.Equal(t, "10.0.0.2:9090", got.ReplicaAddress)
+
+	n, err := repo.UnregisterReplica(ctx, "replica-b")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	_, err = repo.Get(ctx, agent.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestReadReplica(t *testing.T) {
+	ctx := context.Background()
+
+	// The primary stands in for its replica; it has no replication lag
+	cfg := DefaultConfig(testDB.container.ConnStr)
+	cfg.MaxConns = 2
+	cfg.MinConns = 1
+	cfg.ReplicaURL = testDB.container.ConnStr
+	db, err := New(ctx, cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	stats := db.Stats()
+	assert.True(t, stats.Replica)
+	assert.True(t, stats.ReplicaInUse)
+	assert.Zero(t, stats.ReplicaLag)
+
+	repo := NewServiceRepo(db)
+	svc := &Service{Name: "replica-svc-" + uuid.NewString()[:8], GitURL: "https://github.com/example/replica.git", DefaultBranch: "main"}
+	require.NoError(t, repo.Create(ctx, svc))
+
+	got, err := repo.Get(WithReplicaReads(ctx), svc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, svc.Name, got.Name)
+}
+
+func TestResultPartitionRepository(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	resultRepo := NewResultRepo(testDB.db)
+	repo := NewResultPartitionRepo(testDB.db)
+
+	svc := &Service{
+		Name:          "test-partition-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	run := &TestRun{ServiceID: svc.ID, Status: RunStatusPassed}
+	require.NoError(t, runRepo.Create(ctx, run))
+
+	// A result of a month without a partition lands in the default partition
+	month := time.Date(2001, time.March, 1, 0, 0, 0, 0, time.UTC)
+	result := &TestResult{RunID: run.ID, TestName: "TestArchived", Status: ResultStatusPass}
+	require.NoError(t, resultRepo.Create(ctx, result))
+	_, err := testDB.db.Pool().Exec(ctx, "UPDATE test_results SET created_at = $2 WHERE id = $1", result.ID, month.Add(36*time.Hour))
+	require.NoError(t, err)
+
+	require.NoError(t, repo.EnsurePartition(ctx, month.Add(10*24*time.Hour)))
+	require.NoError(t, repo.EnsurePartition(ctx, month), "creating a partition again is a no-op")
+	require.NoError(t, repo.EnsurePartition(ctx, time.Now()))
+
+	partitions, err := repo.ListPartitions(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, partitions)
+	partition := partitions[0]
+	assert.Equal(t, "test_results_2001_03", partition.Name, "oldest first")
+	assert.Equal(t, month, partition.Month)
+
+	var moved string
+	err = testDB.db.Pool().QueryRow(ctx, "SELECT tableoid::regclass::text FROM test_results WHERE id = $1", result.ID).Scan(&moved)
+	require.NoError(t, err)
+	assert.Equal(t, partition.Name, moved, "results are moved out of the default partition")
+
+	var csv bytes.Buffer
+	n, err := repo.ExportPartition(ctx, partition, &csv)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "id,run_id,"), "the header names the columns")
+	assert.Contains(t, lines[1], "TestArchived")
+
+	objectPath := "result-archives/test_results_2001_03.csv.gz"
+	n, err = repo.DropPartition(ctx, partition, &objectPath)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	_, err = resultRepo.Get(ctx, result.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	partitions, err = repo.ListPartitions(ctx)
+	require.NoError(t, err)
+	for _, p := range partitions {
+		assert.NotEqual(t, partition.Name, p.Name)
+	}
+
+	var archived string
+	var rows int64
+	err = testDB.db.Pool().QueryRow(ctx, "SELECT object_path, row_count FROM test_result_archives WHERE month = $1", month).Scan(&archived, &rows)
+	require.NoError(t, err)
+	assert.Equal(t, objectPath, archived)
+	assert.Equal(t, int64(1), rows)
+}
+
+func TestRunRepository_TraceParent(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+
+	svc := &Service{
+		Name:          "test-trace-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
+
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	run := &TestRun{ServiceID: svc.ID, Status: RunStatusPending, TraceParent: &traceParent}
+	require.NoError(t, runRepo.Create(ctx, run))
+	untraced := &TestRun{ServiceID: svc.ID, Status: RunStatusPending}
+	require.NoError(t, runRepo.Create(ctx, untraced))
+
+	got, err := runRepo.Get(ctx, run.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.TraceParent)
+	assert.Equal(t, traceParent, *got.TraceParent)
+
+	got, err = runRepo.Get(ctx, untraced.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.TraceParent)
+}
+
+func TestIdempotencyKeyRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewIdempotencyKeyRepo(testDB.db)
+	scope := "runs.create:" + uuid.NewString()[:8]
+
+	key := &IdempotencyKey{Scope: scope, Key: "retry-1", RequestHash: "hash-a", ExpiresAt: time.Now().Add(time.Minute)}
+	existing, err := repo.Claim(ctx, key)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	assert.False(t, key.CreatedAt.IsZero())
+
+	// A concurrent retry finds the key in progress
+	existing, err = repo.Claim(ctx, &IdempotencyKey{Scope: scope, Key: "retry-1", RequestHash: "hash-a", ExpiresAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, "hash-a", existing.RequestHash)
+	assert.Nil(t, existing.Response)
+
+	response := []byte(`{"id":"run-1"}`)
+	require.NoError(t, repo.Complete(ctx, scope, "retry-1", response, time.Now().Add(time.Hour)))
+	assert.ErrorIs(t, repo.Complete(ctx, scope, "retry-1", response, time.Now().Add(time.Hour)), ErrNotFound)
+
+	// Completed keys are answered with their response and not released
+	require.NoError(t, repo.Release(ctx, scope, "retry-1"))
+	existing, err = repo.Claim(ctx, &IdempotencyKey{Scope: scope, Key: "retry-1", RequestHash: "hash-b", ExpiresAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, "hash-a", existing.RequestHash)
+	assert.JSONEq(t, string(response), string(existing.Response))
+
+	// Released keys can be claimed again
+	released := &IdempotencyKey{Scope: scope, Key: "retry-2", RequestHash: "hash-a", ExpiresAt: time.Now().Add(time.Minute)}
+	_, err = repo.Claim(ctx, released)
+	require.NoError(t, err)
+	require.NoError(t, repo.Release(ctx, scope, "retry-2"))
+	existing, err = repo.Claim(ctx, released)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// Expired keys are claimed over and deleted
+	expired := &IdempotencyKey{Scope: scope, Key: "retry-3", RequestHash: "hash-a", ExpiresAt: time.Now().Add(-time.Minute)}
+	_, err = repo.Claim(ctx, expired)
+	require.NoError(t, err)
+	existing, err = repo.Claim(ctx, &IdempotencyKey{Scope: scope, Key: "retry-3", RequestHash: "hash-b", ExpiresAt: time.Now().Add(-time.Minute)})
+	require.NoError(t, err)
+	assert.Nil(t, existing)
 
-		rule := &NotificationRule{
-			ChannelID: channel.ID,
-			TriggerOn: []TriggerEvent{TriggerEventFailure, TriggerEventRecovery},
-			Enabled:   true,
-		}
+	n, err := repo.DeleteExpired(ctx, time.Now())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, n, int64(1))
+	existing, err = repo.Claim(ctx, &IdempotencyKey{Scope: scope, Key: "retry-1", RequestHash: "hash-b", ExpiresAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	assert.NotNil(t, existing, "unexpired keys are kept")
+}
 
-		err = repo.CreateRule(ctx, rule)
-		require.NoError(t, err)
-		assert.NotEqual(t, uuid.Nil, rule.ID)
+func TestRunRepository_BranchRunPolicy(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	agentRepo := NewAgentRepo(testDB.db)
 
-		t.Cleanup(func() {
-			repo.DeleteRule(ctx, rule.ID)
-		})
-	})
+	svc := &Service{
+		Name:          "test-branch-policy-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
 
-	t.Run("GetRule", func(t *testing.T) {
-		channel := &NotificationChannel{
-			Name:    "test-getrule-channel-" + uuid.New().String()[:8],
-			Type:    ChannelTypeSlack,
-			Config:  []byte(`{}`),
-			Enabled: true,
-		}
-		err := repo.CreateChannel(ctx, channel)
+	t.Run("services allow every run by default", func(t *testing.T) {
+		got, err := svcRepo.Get(ctx, svc.ID)
 		require.NoError(t, err)
-		defer repo.DeleteChannel(ctx, channel.ID)
+		assert.Equal(t, BranchRunPolicyAllow, got.BranchRunPolicy)
 
-		rule := &NotificationRule{
-			ChannelID: channel.ID,
-			TriggerOn: []TriggerEvent{TriggerEventAlways},
-			Enabled:   true,
-		}
-		err = repo.CreateRule(ctx, rule)
+		got.BranchRunPolicy = BranchRunPolicyCoalesce
+		require.NoError(t, svcRepo.Update(ctx, got))
+		got, err = svcRepo.Get(ctx, svc.ID)
 		require.NoError(t, err)
-		defer repo.DeleteRule(ctx, rule.ID)
+		assert.Equal(t, BranchRunPolicyCoalesce, got.BranchRunPolicy)
+	})
 
-		fetched, err := repo.GetRule(ctx, rule.ID)
+	t.Run("retargets pending runs only", func(t *testing.T) {
+		branch, oldSHA, newSHA := "main", "aaa", "bbb"
+		run := &TestRun{ServiceID: svc.ID, Status: RunStatusPending, GitRef: &branch, GitSHA: &oldSHA}
+		require.NoError(t, runRepo.Create(ctx, run))
+
+		require.NoError(t, runRepo.Retarget(ctx, run.ID, &newSHA))
+		got, err := runRepo.Get(ctx, run.ID)
 		require.NoError(t, err)
-		assert.Equal(t, rule.ChannelID, fetched.ChannelID)
-		assert.Equal(t, rule.TriggerOn, fetched.TriggerOn)
+		require.NotNil(t, got.GitSHA)
+		assert.Equal(t, newSHA, *got.GitSHA)
+
+		agent := &Agent{Name: "test-branch-policy-agent-" + uuid.New().String()[:8], Status: AgentStatusIdle}
+		require.NoError(t, agentRepo.Create(ctx, agent))
+		defer agentRepo.Delete(ctx, agent.ID)
+		require.NoError(t, runRepo.Start(ctx, run.ID, agent.ID))
+
+		err = runRepo.Retarget(ctx, run.ID, &oldSHA)
+		assert.True(t, IsNotFound(err))
 	})
+}
 
-	t.Run("ListRulesByService", func(t *testing.T) {
-		// Create service
-		svc := &Service{
-			Name:          "test-rule-service-" + uuid.New().String()[:8],
-			GitURL:        "https://github.com/example/repo.git",
-			DefaultBranch: "main",
-		}
-		err := svcRepo.Create(ctx, svc)
-		require.NoError(t, err)
-		defer svcRepo.Delete(ctx, svc.ID)
+func TestRunRepository_Approve(t *testing.T) {
+	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	defRepo := NewTestDefinitionRepo(testDB.db)
 
-		// Create channel
-		channel := &NotificationChannel{
-			Name:    "test-listsvc-channel-" + uuid.New().String()[:8],
-			Type:    ChannelTypeSlack,
-			Config:  []byte(`{}`),
-			Enabled: true,
-		}
-		err = repo.CreateChannel(ctx, channel)
-		require.NoError(t, err)
-		defer repo.DeleteChannel(ctx, channel.ID)
+	svc := &Service{
+		Name:          "test-approval-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
 
-		// Create rule for specific service
-		rule := &NotificationRule{
-			ChannelID: channel.ID,
-			ServiceID: &svc.ID,
-			TriggerOn: []TriggerEvent{TriggerEventFailure},
-			Enabled:   true,
-		}
-		err = repo.CreateRule(ctx, rule)
-		require.NoError(t, err)
-		defer repo.DeleteRule(ctx, rule.ID)
+	t.Run("stores whether tests require approval", func(t *testing.T) {
+		def := &TestDefinition{ServiceID: svc.ID, Name: "deploy-smoke", Command: "make smoke", ExecutionType: "subprocess", RequiresApproval: true}
+		require.NoError(t, defRepo.Create(ctx, def))
 
-		// Create global rule (nil ServiceID)
-		globalRule := &NotificationRule{
-			ChannelID: channel.ID,
-			ServiceID: nil,
-			TriggerOn: []TriggerEvent{TriggerEventAlways},
-			Enabled:   true,
-		}
-		err = repo.CreateRule(ctx, globalRule)
+		got, err := defRepo.Get(ctx, def.ID)
 		require.NoError(t, err)
-		defer repo.DeleteRule(ctx, globalRule.ID)
+		assert.True(t, got.RequiresApproval)
+	})
 
-		rules, err := repo.ListRulesByService(ctx, svc.ID)
-		require.NoError(t, err)
+	t.Run("releases waiting runs only", func(t *testing.T) {
+		reason := "branch main is protected"
+		run := &TestRun{ServiceID: svc.ID, Status: RunStatusWaitingApproval, ApprovalReason: &reason}
+		require.NoError(t, runRepo.Create(ctx, run))
 
-		// Should include both service-specific and global rules
-		foundSpecific := false
-		foundGlobal := false
-		for _, r := range rules {
-			if r.ID == rule.ID {
-				foundSpecific = true
-			}
-			if r.ID == globalRule.ID {
-				foundGlobal = true
-			}
+		pending, err := runRepo.GetPending(ctx, 100)
+		require.NoError(t, err)
+		for _, p := range pending {
+			assert.NotEqual(t, run.ID, p.ID, "waiting runs are not scheduled")
 		}
-		assert.True(t, foundSpecific, "service-specific rule should be in list")
-		assert.True(t, foundGlobal, "global rule should be in list")
+
+		require.NoError(t, runRepo.Approve(ctx, run.ID, "u-1"))
+		got, err := runRepo.Get(ctx, run.ID)
+		require.NoError(t, err)
+		assert.Equal(t, RunStatusPending, got.Status)
+		require.NotNil(t, got.ApprovalReason)
+		assert.Equal(t, reason, *got.ApprovalReason)
+		require.NotNil(t, got.ApprovedBy)
+		assert.Equal(t, "u-1", *got.ApprovedBy)
+		assert.NotNil(t, got.ApprovedAt)
+
+		err = runRepo.Approve(ctx, run.ID, "u-2")
+		assert.True(t, IsNotFound(err))
 	})
 }
 
-// ============================================================================
-// TRANSACTION TESTS
-// ============================================================================
-
-func TestTransactions(t *testing.T) {
+func TestConcurrencyGroupRepository(t *testing.T) {
 	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	defRepo := NewTestDefinitionRepo(testDB.db)
+	concurrencyRepo := NewConcurrencyGroupRepo(testDB.db)
 
-	t.Run("Commit", func(t *testing.T) {
-		var serviceID uuid.UUID
+	svc := &Service{
+		Name:          "test-concurrency-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
 
-		err := testDB.db.WithTx(ctx, func(tx pgx.Tx) error {
-			name := "tx-test-commit-" + uuid.New().String()[:8]
-			err := tx.QueryRow(ctx, `
-				INSERT INTO services (name, git_url, default_branch)
-				VALUES ($1, $2, $3)
-				RETURNING id
-			`, name, "https://github.com/example/repo.git", "main").Scan(&serviceID)
-			return err
+	t.Run("saves and deletes the group of a service on sync", func(t *testing.T) {
+		_, err := concurrencyRepo.GetByService(ctx, svc.ID)
+		assert.True(t, IsNotFound(err))
+
+		err = defRepo.ApplySync(ctx, &TestDefinitionSync{
+			Concurrency: &ServiceConcurrency{ServiceID: svc.ID, Group: "${GIT_BRANCH}", CancelInProgress: true},
 		})
 		require.NoError(t, err)
-		assert.NotEqual(t, uuid.Nil, serviceID)
-
-		// Verify committed
-		repo := NewServiceRepo(testDB.db)
-		svc, err := repo.Get(ctx, serviceID)
+		got, err := concurrencyRepo.GetByService(ctx, svc.ID)
 		require.NoError(t, err)
-		assert.NotNil(t, svc)
+		assert.Equal(t, "${GIT_BRANCH}", got.Group)
+		assert.True(t, got.CancelInProgress)
 
-		// Cleanup
-		repo.Delete(ctx, serviceID)
+		err = defRepo.ApplySync(ctx, &TestDefinitionSync{Concurrency: &ServiceConcurrency{ServiceID: svc.ID}})
+		require.NoError(t, err)
+		_, err = concurrencyRepo.GetByService(ctx, svc.ID)
+		assert.True(t, IsNotFound(err))
 	})
 
-	t.Run("Rollback", func(t *testing.T) {
-		name := "tx-test-rollback-" + uuid.New().String()[:8]
-		var serviceID uuid.UUID
+	t.Run("one active run holds a group", func(t *testing.T) {
+		group := "test-concurrency-group-" + uuid.New().String()[:8]
+		first := &TestRun{ServiceID: svc.ID, Status: RunStatusPending, ConcurrencyGroup: &group}
+		second := &TestRun{ServiceID: svc.ID, Status: RunStatusPending, ConcurrencyGroup: &group}
+		require.NoError(t, runRepo.Create(ctx, first))
+		require.NoError(t, runRepo.Create(ctx, second))
 
-		err := testDB.db.WithTx(ctx, func(tx pgx.Tx) error {
-			err := tx.QueryRow(ctx, `
-				INSERT INTO services (name, git_url, default_branch)
-				VALUES ($1, $2, $3)
-				RETURNING id
-			`, name, "https://github.com/example/repo.git", "main").Scan(&serviceID)
-			if err != nil {
-				return err
-			}
+		got, err := runRepo.Get(ctx, first.ID)
+		require.NoError(t, err)
+		require.NotNil(t, got.ConcurrencyGroup)
+		assert.Equal(t, group, *got.ConcurrencyGroup)
 
-			// Return error to trigger rollback
-			return assert.AnError
-		})
-		require.Error(t, err)
+		active, err := concurrencyRepo.ListActive(ctx, group)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{first.ID, second.ID}, active)
 
-		// Verify rolled back
-		repo := NewServiceRepo(testDB.db)
-		_, err = repo.GetByName(ctx, name)
-		assert.True(t, IsNotFound(err), "service should not exist after rollback")
+		claimed, err := concurrencyRepo.Claim(ctx, group, first.ID)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+		claimed, err = concurrencyRepo.Claim(ctx, group, first.ID)
+		require.NoError(t, err)
+		assert.True(t, claimed, "the holder keeps the group")
+		claimed, err = concurrencyRepo.Claim(ctx, group, second.ID)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+
+		require.NoError(t, runRepo.Finish(ctx, first.ID, RunStatusPassed, RunResults{}))
+		claimed, err = concurrencyRepo.Claim(ctx, group, second.ID)
+		require.NoError(t, err)
+		assert.True(t, claimed, "finished runs release the group")
+
+		active, err = concurrencyRepo.ListActive(ctx, group)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{second.ID}, active)
 	})
 }
 
-// ============================================================================
-// DATABASE CONNECTION TESTS
-// ============================================================================
-
-func TestDatabaseConnection(t *testing.T) {
+func TestServiceQuotaRepository(t *testing.T) {
 	ctx := context.Background()
+	svcRepo := NewServiceRepo(testDB.db)
+	runRepo := NewRunRepo(testDB.db)
+	shardRepo := NewRunShardRepo(testDB.db)
+	quotaRepo := NewServiceQuotaRepo(testDB.db)
 
-	t.Run("Health", func(t *testing.T) {
-		err := testDB.db.Health(ctx)
-		require.NoError(t, err)
-	})
+	svc := &Service{
+		Name:          "test-quota-service-" + uuid.New().String()[:8],
+		GitURL:        "https://github.com/example/repo.git",
+		DefaultBranch: "main",
+	}
+	require.NoError(t, svcRepo.Create(ctx, svc))
+	defer svcRepo.Delete(ctx, svc.ID)
 
-	t.Run("Stats", func(t *testing.T) {
-		stats := testDB.db.Stats()
-		assert.GreaterOrEqual(t, stats.MaxConns, int32(1))
-		assert.GreaterOrEqual(t, stats.TotalConns, int32(0))
-	})
-}
+	t.Run("saves and deletes the quota of a service", func(t *testing.T) {
+		_, err := quotaRepo.GetByService(ctx, svc.ID)
+		assert.True(t, IsNotFound(err))
 
-// ============================================================================
-// HELPER TEST FOR MIGRATIONS FS
-// ============================================================================
+		require.NoError(t, quotaRepo.Upsert(ctx, &ServiceQuota{ServiceID: svc.ID, MaxRunsPerDay: 10}))
+		quota := &ServiceQuota{ServiceID: svc.ID, MaxRunsPerDay: 20, MaxMinutesPerMonth: 600}
+		require.NoError(t, quotaRepo.Upsert(ctx, quota))
+		assert.False(t, quota.CreatedAt.IsZero())
 
-// Verify migrations directory exists and has expected files
-func TestMigrationsFilesystem(t *testing.T) {
-	migrationsFS := os.DirFS("../../migrations")
+		got, err := quotaRepo.GetByService(ctx, svc.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 20, got.MaxRunsPerDay)
+		assert.Equal(t, 600, got.MaxMinutesPerMonth)
 
-	var foundFiles []string
-	err := fs.WalkDir(migrationsFS, ".", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if !d.IsDir() {
-			foundFiles = append(foundFiles, path)
-		}
-		return nil
+		require.NoError(t, quotaRepo.Delete(ctx, svc.ID))
+		assert.True(t, IsNotFound(quotaRepo.Delete(ctx, svc.ID)))
 	})
-	require.NoError(t, err)
 
-	// Should have at least 8 files (4 migrations x 2 up/down)
-	assert.GreaterOrEqual(t, len(foundFiles), 8, "should have at least 8 migration files")
+	t.Run("counts runs and shard minutes of the periods", func(t *testing.T) {
+		now := time.Now().UTC()
+		dayStart := now.Add(-time.Hour)
+		monthStart := now.Add(-24 * time.Hour)
 
-	// Check for expected migration patterns
-	expectedPatterns := []string{
-		"initial_schema.up.sql",
-		"initial_schema.down.sql",
-		"notifications.up.sql",
-		"notifications.down.sql",
-	}
-	for _, pattern := range expectedPatterns {
-		found := false
-		for _, file := range foundFiles {
-			if contains(file, pattern) {
-				found = true
-				break
-			}
-		}
-		assert.True(t, found, "should find migration file matching %s", pattern)
-	}
-}
+		old := &TestRun{ServiceID: svc.ID, Status: RunStatusPassed}
+		require.NoError(t, runRepo.Create(ctx, old))
+		_, err := testDB.db.Pool().Exec(ctx, "UPDATE test_runs SET created_at = $2 WHERE id = $1", old.ID, now.Add(-2*time.Hour))
+		require.NoError(t, err)
+		run := &TestRun{ServiceID: svc.ID, Status: RunStatusRunning}
+		require.NoError(t, runRepo.Create(ctx, run))
 
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
-}
+		finished := &RunShard{RunID: old.ID, ShardIndex: 0, ShardCount: 1, Status: ShardStatusPassed}
+		require.NoError(t, shardRepo.Create(ctx, finished))
+		_, err = testDB.db.Pool().Exec(ctx, "UPDATE run_shards SET started_at = $2, finished_at = $3 WHERE id = $1",
+			finished.ID, now.Add(-2*time.Hour), now.Add(-2*time.Hour+90*time.Second))
+		require.NoError(t, err)
+		before := &RunShard{RunID: old.ID, ShardIndex: 0, ShardCount: 1, Status: ShardStatusPassed}
+		require.NoError(t, shardRepo.Create(ctx, before))
+		_, err = testDB.db.Pool().Exec(ctx, "UPDATE run_shards SET started_at = $2, finished_at = $3 WHERE id = $1",
+			before.ID, now.Add(-48*time.Hour), now.Add(-47*time.Hour))
+		require.NoError(t, err)
 
-func containsHelper(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
-	}
-	return false
+		usage, err := quotaRepo.Usage(ctx, svc.ID, dayStart, monthStart)
+		require.NoError(t, err)
+		assert.Equal(t, 1, usage.RunsToday)
+		assert.Equal(t, 2, usage.MinutesThisMonth, "minutes are rounded up")
+	})
 }
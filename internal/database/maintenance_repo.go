@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// maintenanceWindowRepo implements MaintenanceWindowRepository.
+type maintenanceWindowRepo struct {
+	db *DB
+}
+
+// NewMaintenanceWindowRepo creates a new maintenance window repository.
+func NewMaintenanceWindowRepo(db *DB) MaintenanceWindowRepository {
+	return &maintenanceWindowRepo{db: db}
+}
+
+// Create creates a new maintenance window.
+func (r *maintenanceWindowRepo) Create(ctx context.Context, window *MaintenanceWindow) error {
+	if window.Timezone == "" {
+		window.Timezone = "UTC"
+	}
+	if window.Notifications == "" {
+		window.Notifications = MaintenanceNotificationsSuppress
+	}
+
+	err := r.db.pool.QueryRow(ctx, MaintenanceWindowInsert,
+		window.Name,
+		window.Description,
+		window.ServiceID,
+		window.AgentPool,
+		window.CronExpression,
+		window.Timezone,
+		window.DurationSeconds,
+		window.StartsAt,
+		window.EndsAt,
+		window.Notifications,
+		window.Enabled,
+		window.CreatedBy,
+	).Scan(&window.ID, &window.CreatedAt, &window.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create maintenance window: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// Get retrieves a maintenance window by ID.
+func (r *maintenanceWindowRepo) Get(ctx context.Context, id uuid.UUID) (*MaintenanceWindow, error) {
+	window, err := scanMaintenanceWindow(r.db.reader(ctx).QueryRow(ctx, MaintenanceWindowGet, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get maintenance window: %w", err)
+	}
+	return window, nil
+}
+
+// Update updates a maintenance window.
+func (r *maintenanceWindowRepo) Update(ctx context.Context, window *MaintenanceWindow) error {
+	err := r.db.pool.QueryRow(ctx, MaintenanceWindowUpdate,
+		window.ID,
+		window.Name,
+		window.Description,
+		window.ServiceID,
+		window.AgentPool,
+		window.CronExpression,
+		window.Timezone,
+		window.DurationSeconds,
+		window.StartsAt,
+		window.EndsAt,
+		window.Notifications,
+		window.Enabled,
+	).Scan(&window.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to update maintenance window: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// Delete deletes a maintenance window.
+func (r *maintenanceWindowRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.pool.Exec(ctx, MaintenanceWindowDelete, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete maintenance window: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List lists the maintenance windows by name.
+func (r *maintenanceWindowRepo) List(ctx context.Context) ([]MaintenanceWindow, error) {
+	return r.list(ctx, MaintenanceWindowList)
+}
+
+// ListEnabled lists the enabled maintenance windows that have not ended.
+func (r *maintenanceWindowRepo) ListEnabled(ctx context.Context) ([]MaintenanceWindow, error) {
+	return r.list(ctx, MaintenanceWindowListEnabled)
+}
+
+func (r *maintenanceWindowRepo) list(ctx context.Context, query string) ([]MaintenanceWindow, error) {
+	rows, err := r.db.reader(ctx).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance windows: %w", err)
+	}
+	defer rows.Close()
+
+	var windows []MaintenanceWindow
+	for rows.Next() {
+		window, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan maintenance window: %w", err)
+		}
+		windows = append(windows, *window)
+	}
+	return windows, rows.Err()
+}
+
+func scanMaintenanceWindow(row pgx.Row) (*MaintenanceWindow, error) {
+	window := &MaintenanceWindow{}
+	err := row.Scan(
+		&window.ID,
+		&window.Name,
+		&window.Description,
+		&window.ServiceID,
+		&window.AgentPool,
+		&window.CronExpression,
+		&window.Timezone,
+		&window.DurationSeconds,
+		&window.StartsAt,
+		&window.EndsAt,
+		&window.Notifications,
+		&window.Enabled,
+		&window.CreatedBy,
+		&window.CreatedAt,
+		&window.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return window, nil
+}
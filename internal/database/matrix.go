@@ -0,0 +1,117 @@
+package database
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// MaxMatrixCells bounds the combinations a test matrix fans out into.
+const MaxMatrixCells = 64
+
+// TestMatrix fans a test out over every combination of the values of its
+// axes. Env axes set an environment variable and Labels axes select agents
+// carrying a label, once per combination.
+type TestMatrix struct {
+	Env    map[string][]string `json:"env,omitempty"`
+	Labels map[string][]string `json:"labels,omitempty"`
+}
+
+// MatrixCell is one combination of the values of a test matrix.
+type MatrixCell struct {
+	Env    map[string]string `json:"env,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Validate checks that every axis is named and has distinct, non-empty
+// values, and that the matrix has at most MaxMatrixCells combinations.
+func (m *TestMatrix) Validate() error {
+	if m == nil {
+		return nil
+	}
+
+	cells := 1
+	for _, axes := range []struct {
+		kind string
+		axes map[string][]string
+	}{{"env", m.Env}, {"label", m.Labels}} {
+		for name, values := range axes.axes {
+			if strings.TrimSpace(name) == "" || strings.Contains(name, "=") {
+				return fmt.Errorf("invalid %s axis name %q", axes.kind, name)
+			}
+			if len(values) == 0 {
+				return fmt.Errorf("%s axis %s has no values", axes.kind, name)
+			}
+			seen := make(map[string]bool, len(values))
+			for _, value := range values {
+				if value == "" {
+					return fmt.Errorf("%s axis %s has an empty value", axes.kind, name)
+				}
+				if seen[value] {
+					return fmt.Errorf("%s axis %s repeats value %q", axes.kind, name, value)
+				}
+				seen[value] = true
+			}
+			cells *= len(values)
+			if cells > MaxMatrixCells {
+				return fmt.Errorf("matrix has more than %d combinations", MaxMatrixCells)
+			}
+		}
+	}
+	return nil
+}
+
+// Cells returns every combination of the matrix values, with the env axes
+// then the label axes ordered by name and the first axis varying slowest.
+// It returns nil for a nil or empty matrix.
+func (m *TestMatrix) Cells() []MatrixCell {
+	if m == nil || len(m.Env) == 0 && len(m.Labels) == 0 {
+		return nil
+	}
+
+	cells := []MatrixCell{{}}
+	expand := func(axes map[string][]string, set func(cell *MatrixCell, name, value string)) {
+		for _, name := range slices.Sorted(maps.Keys(axes)) {
+			next := make([]MatrixCell, 0, len(cells)*len(axes[name]))
+			for _, cell := range cells {
+				for _, value := range axes[name] {
+					c := MatrixCell{Env: maps.Clone(cell.Env), Labels: maps.Clone(cell.Labels)}
+					set(&c, name, value)
+					next = append(next, c)
+				}
+			}
+			cells = next
+		}
+	}
+	expand(m.Env, func(cell *MatrixCell, name, value string) {
+		if cell.Env == nil {
+			cell.Env = make(map[string]string)
+		}
+		cell.Env[name] = value
+	})
+	expand(m.Labels, func(cell *MatrixCell, name, value string) {
+		if cell.Labels == nil {
+			cell.Labels = make(map[string]string)
+		}
+		cell.Labels[name] = value
+	})
+	return cells
+}
+
+// Name identifies a matrix combination as its env then label values
+// ordered by name, e.g. "GO_VERSION=1.23, os=windows". It returns "" for a
+// nil combination.
+func (c *MatrixCell) Name() string {
+	if c == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, values := range []map[string]string{c.Env, c.Labels} {
+		for _, name := range slices.Sorted(maps.Keys(values)) {
+			parts = append(parts, name+"="+values[name])
+		}
+	}
+	return strings.Join(parts, ", ")
+}
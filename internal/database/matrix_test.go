@@ -0,0 +1,51 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestMatrix_Cells(t *testing.T) {
+	assert.Nil(t, (*TestMatrix)(nil).Cells())
+	assert.Nil(t, (&TestMatrix{}).Cells())
+
+	matrix := &TestMatrix{
+		Env:    map[string][]string{"GO_VERSION": {"1.22", "1.23"}},
+		Labels: map[string][]string{"os": {"linux", "windows"}},
+	}
+	require.NoError(t, matrix.Validate())
+
+	var names []string
+	for _, cell := range matrix.Cells() {
+		names = append(names, cell.Name())
+	}
+	assert.Equal(t, []string{
+		"GO_VERSION=1.22, os=linux",
+		"GO_VERSION=1.22, os=windows",
+		"GO_VERSION=1.23, os=linux",
+		"GO_VERSION=1.23, os=windows",
+	}, names)
+
+	cell := matrix.Cells()[3]
+	assert.Equal(t, map[string]string{"GO_VERSION": "1.23"}, cell.Env)
+	assert.Equal(t, map[string]string{"os": "windows"}, cell.Labels)
+	assert.Empty(t, (*MatrixCell)(nil).Name())
+}
+
+func TestTestMatrix_Validate(t *testing.T) {
+	assert.NoError(t, (*TestMatrix)(nil).Validate())
+
+	assert.ErrorContains(t, (&TestMatrix{Env: map[string][]string{"GO": nil}}).Validate(), "env axis GO has no values")
+	assert.ErrorContains(t, (&TestMatrix{Labels: map[string][]string{"os": {"linux", ""}}}).Validate(), "empty value")
+	assert.ErrorContains(t, (&TestMatrix{Env: map[string][]string{"GO": {"1", "1"}}}).Validate(), `repeats value "1"`)
+	assert.ErrorContains(t, (&TestMatrix{Env: map[string][]string{"A=B": {"1"}}}).Validate(), "invalid env axis name")
+
+	values := make([]string, 9)
+	for i := range values {
+		values[i] = string(rune('a' + i))
+	}
+	large := &TestMatrix{Env: map[string][]string{"A": values, "B": values}}
+	assert.ErrorContains(t, large.Validate(), "more than 64 combinations")
+}
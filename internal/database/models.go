@@ -3,11 +3,39 @@ package database
 import (
 	"database/sql"
 	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// DefaultOrganizationID and DefaultProjectID identify the organization and
+// project that keep the resources created without a project.
+var (
+	DefaultOrganizationID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	DefaultProjectID      = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+)
+
+// Organization is a tenant of the control plane. It groups projects.
+type Organization struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	DisplayName *string   `json:"display_name,omitempty" db:"display_name"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Project belongs to an organization and owns services, agents and
+// notification channels. Callers only see the projects they are members of.
+type Project struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	OrganizationID uuid.UUID `json:"organization_id" db:"organization_id"`
+	Name           string    `json:"name" db:"name"`
+	DisplayName    *string   `json:"display_name,omitempty" db:"display_name"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
 // Service represents a registered microservice with test suites.
 type Service struct {
 	ID            uuid.UUID `json:"id" db:"id"`
@@ -22,8 +50,41 @@ type Service struct {
 	ContactEmail  *string   `json:"contact_email,omitempty" db:"contact_email"`
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	// RootPath is the repository directory the service lives in, for
+	// services sharing a monorepo. Nil means the whole repository.
+	RootPath *string `json:"root_path,omitempty" db:"root_path"`
+	// SyncIntervalSeconds overrides how often test definitions are re-synced
+	// from the repository; 0 disables periodic sync. Nil uses the server default.
+	SyncIntervalSeconds *int `json:"sync_interval_seconds,omitempty" db:"sync_interval_seconds"`
+	// AgentPool pins the service's runs to the agents of a pool. Nil lets
+	// agents of any pool run them.
+	AgentPool *string `json:"agent_pool,omitempty" db:"agent_pool"`
+	// ArchivedAt is when the service was archived. Archived services keep
+	// their history but are not triggered or synced. Nil means active.
+	ArchivedAt *time.Time `json:"archived_at,omitempty" db:"archived_at"`
+	// ProjectID is the project the service belongs to. uuid.Nil is stored as
+	// the default project.
+	ProjectID uuid.UUID `json:"project_id" db:"project_id"`
+	// BranchRunPolicy decides what a new run of a branch does to the
+	// pending and running runs of the same branch. Empty is stored as allow.
+	BranchRunPolicy BranchRunPolicy `json:"branch_run_policy" db:"branch_run_policy"`
 }
 
+// BranchRunPolicy decides what happens to the active runs of a branch when
+// a new run of the branch is created.
+type BranchRunPolicy string
+
+const (
+	// BranchRunPolicyAllow keeps every run of the branch.
+	BranchRunPolicyAllow BranchRunPolicy = "allow"
+	// BranchRunPolicySupersede cancels the pending and running runs of the
+	// branch once the new run is created.
+	BranchRunPolicySupersede BranchRunPolicy = "supersede"
+	// BranchRunPolicyCoalesce moves a pending run of the branch to the new
+	// commit instead of creating another run.
+	BranchRunPolicyCoalesce BranchRunPolicy = "coalesce"
+)
+
 // TestDefinition defines an individual test or test suite that can be executed.
 type TestDefinition struct {
 	ID               uuid.UUID `json:"id" db:"id"`
@@ -41,10 +102,182 @@ type TestDefinition struct {
 	DependsOn        []string  `json:"depends_on,omitempty" db:"depends_on"`
 	Retries          int       `json:"retries" db:"retries"`
 	AllowFailure     bool      `json:"allow_failure" db:"allow_failure"`
+	CacheKey         *string   `json:"cache_key,omitempty" db:"cache_key"`
+	CachePaths       []string  `json:"cache_paths,omitempty" db:"cache_paths"`
+	// Environment and Secrets override the service environment set.
+	Environment map[string]string `json:"environment,omitempty" db:"environment"`
+	Secrets     []SecretRef       `json:"secrets,omitempty" db:"secrets"`
+	// LabelSelector lists the labels an agent must carry to run this test.
+	LabelSelector map[string]string `json:"label_selector,omitempty" db:"label_selector"`
+	// ContainerImage is the image container tests run in.
+	ContainerImage *string `json:"container_image,omitempty" db:"container_image"`
+	// Architectures lists the CPU architectures the test runs on, once
+	// each. Empty runs it once on any architecture.
+	Architectures []string `json:"architectures,omitempty" db:"architectures"`
+	// ArtifactMaxBytes and ArtifactMaxFiles cap the artifacts kept per run
+	// of the test. Nil is unlimited.
+	ArtifactMaxBytes *int64 `json:"artifact_max_bytes,omitempty" db:"artifact_max_bytes"`
+	ArtifactMaxFiles *int   `json:"artifact_max_files,omitempty" db:"artifact_max_files"`
+	// Owner is the team owning the test. Nil leaves it to the service's
+	// owner.
+	Owner *string `json:"owner,omitempty" db:"owner"`
+	// Matrix fans the test out over combinations of environment variables
+	// and agent labels. Nil runs it once.
+	Matrix *TestMatrix `json:"matrix,omitempty" db:"matrix"`
+	// RequiresApproval holds runs including the test until an authorized
+	// user approves them.
+	RequiresApproval bool `json:"requires_approval" db:"requires_approval"`
+	// CapturePatterns are files or directories browser tests write
+	// screenshots, videos and reports to. Agents upload media as is and
+	// compress everything else.
+	CapturePatterns []string `json:"capture_patterns,omitempty" db:"capture_patterns"`
+	// Parameters declares the parameters runs of the test can set.
+	Parameters TestParameters `json:"parameters,omitempty" db:"parameters"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// TestDefinitionSync is the set of changes a manifest sync applies to a
+// service. Environment is nil when the manifest does not declare one.
+// Concurrency is nil when the manifest was not read, and has an empty Group
+// when it declares no concurrency group.
+type TestDefinitionSync struct {
+	Create      []*TestDefinition
+	Update      []*TestDefinition
+	Environment *ServiceEnvironment
+	Concurrency *ServiceConcurrency
+}
+
+// SecretRef references a secret that agents resolve into an environment
+// variable at run time. Only the reference is stored, never the value.
+type SecretRef struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider,omitempty"` // vault, aws, envfile; empty uses the agent default
+	Path     string `json:"path"`
+	Key      string `json:"key,omitempty"`
+	Version  int    `json:"version,omitempty"`
+}
+
+// ServiceEnvironment holds the environment variables and secret references
+// inherited by every test definition of a service.
+type ServiceEnvironment struct {
+	ServiceID   uuid.UUID         `json:"service_id" db:"service_id"`
+	Environment map[string]string `json:"environment" db:"environment"`
+	Secrets     []SecretRef       `json:"secrets" db:"secrets"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// ServiceConcurrency is the concurrency group of the runs of a service.
+// Runs of a group execute one at a time, in the order the scheduler picks
+// them.
+type ServiceConcurrency struct {
+	ServiceID uuid.UUID `json:"service_id" db:"service_id"`
+	// Group is the group key template; ${SERVICE_NAME}, ${GIT_REF} and
+	// ${GIT_BRANCH} are replaced per run.
+	Group string `json:"group" db:"group_key"`
+	// CancelInProgress cancels the pending and running runs of the group
+	// when a run is created, instead of queueing it behind them.
+	CancelInProgress bool      `json:"cancel_in_progress" db:"cancel_in_progress"`
 	CreatedAt        time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// ServiceQuota limits the runs a service may create per day and the
+// execution minutes its runs may use per month. Zero means unlimited.
+type ServiceQuota struct {
+	ServiceID          uuid.UUID `json:"service_id" db:"service_id"`
+	MaxRunsPerDay      int       `json:"max_runs_per_day" db:"max_runs_per_day"`
+	MaxMinutesPerMonth int       `json:"max_minutes_per_month" db:"max_minutes_per_month"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ServiceQuotaUsage is what a service used of its quota in the current
+// periods.
+type ServiceQuotaUsage struct {
+	// RunsToday counts the runs created since the start of the day.
+	RunsToday int `json:"runs_today"`
+	// MinutesThisMonth sums the execution time of the shards started since
+	// the start of the month, rounded up to whole minutes.
+	MinutesThisMonth int `json:"minutes_this_month"`
+}
+
+// ServiceTriggerRules decides which webhook events trigger runs for a
+// service. Patterns are globs where "*" matches within a path segment and
+// "**" matches across segments.
+type ServiceTriggerRules struct {
+	ServiceID uuid.UUID `json:"service_id" db:"service_id"`
+	// Branches that trigger runs; empty means all branches.
+	Branches []string `json:"branches" db:"branches"`
+	// IgnoreBranches never trigger runs.
+	IgnoreBranches []string `json:"ignore_branches" db:"ignore_branches"`
+	// Paths of which at least one changed file must match; empty means any.
+	Paths []string `json:"paths" db:"paths"`
+	// IgnorePaths are disregarded when deciding whether files changed.
+	IgnorePaths []string `json:"ignore_paths" db:"ignore_paths"`
+	// Tags whose pushes trigger runs; empty means tag pushes are ignored.
+	Tags []string `json:"tags" db:"tags"`
+	// SkipMarkers suppress a run when found in the head commit message or
+	// pull request title, e.g. "[skip ci]".
+	SkipMarkers []string  `json:"skip_markers" db:"skip_markers"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RetryOn selects which run outcomes a retry policy retries.
+type RetryOn string
+
+const (
+	// RetryOnError retries runs that errored, e.g. on clone failures or agent crashes.
+	RetryOnError RetryOn = "error"
+	// RetryOnFailure also retries runs that failed or timed out.
+	RetryOnFailure RetryOn = "failure"
+)
+
+// RetryPolicy automatically reschedules runs of a service that errored or
+// failed. A policy with a TestDefinitionID applies to runs in which that
+// test definition errored or failed and takes precedence over the service
+// policy.
+type RetryPolicy struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	ServiceID        uuid.UUID  `json:"service_id" db:"service_id"`
+	TestDefinitionID *uuid.UUID `json:"test_definition_id,omitempty" db:"test_definition_id"`
+	// MaxAttempts is the total number of attempts, including the first run.
+	MaxAttempts int `json:"max_attempts" db:"max_attempts"`
+	// BackoffSeconds delays the first retry and doubles with every further
+	// attempt, up to MaxBackoffSeconds.
+	BackoffSeconds    int       `json:"backoff_seconds" db:"backoff_seconds"`
+	MaxBackoffSeconds int       `json:"max_backoff_seconds" db:"max_backoff_seconds"`
+	RetryOn           RetryOn   `json:"retry_on" db:"retry_on"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ServiceDeployKey is an SSH deploy key used to clone a service repository.
+// The private key is stored encrypted and is never returned by the API.
+type ServiceDeployKey struct {
+	ServiceID           uuid.UUID `json:"service_id" db:"service_id"`
+	EncryptedPrivateKey []byte    `json:"-" db:"encrypted_private_key"`
+	PublicKey           string    `json:"public_key" db:"public_key"`
+	Fingerprint         string    `json:"fingerprint" db:"fingerprint"`
+	KnownHosts          *string   `json:"known_hosts,omitempty" db:"known_hosts"`
+	CreatedAt           time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ServiceGitCredential is a git provider API token used to report commit
+// statuses for a service. The token is stored encrypted and is never
+// returned by the API.
+type ServiceGitCredential struct {
+	ServiceID      uuid.UUID `json:"service_id" db:"service_id"`
+	Provider       string    `json:"provider" db:"provider"`
+	BaseURL        *string   `json:"base_url,omitempty" db:"base_url"`
+	EncryptedToken []byte    `json:"-" db:"encrypted_token"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
 // AgentStatus represents the current status of an agent.
 type AgentStatus string
 
@@ -66,6 +299,14 @@ type Agent struct {
 	DockerAvailable bool        `json:"docker_available" db:"docker_available"`
 	LastHeartbeat   *time.Time  `json:"last_heartbeat,omitempty" db:"last_heartbeat"`
 	RegisteredAt    time.Time   `json:"registered_at" db:"registered_at"`
+	// Labels are matched against label selectors; they include the built-in
+	// os and arch labels.
+	Labels map[string]string `json:"labels,omitempty" db:"labels"`
+	// Pool is the name of the agent pool the agent joined, if any.
+	Pool *string `json:"pool,omitempty" db:"pool"`
+	// ProjectID is the project the agent belongs to; it only runs the tests
+	// of the project's services. uuid.Nil is stored as the default project.
+	ProjectID uuid.UUID `json:"project_id" db:"project_id"`
 }
 
 // IsOnline returns true if the agent is considered online (received heartbeat within timeout).
@@ -76,6 +317,33 @@ func (a *Agent) IsOnline(timeout time.Duration) bool {
 	return time.Since(*a.LastHeartbeat) < timeout
 }
 
+// AgentPool is a named group of agents. Agents join a pool by name when they
+// register, and services pinned to the pool only run on its agents. Quotas
+// of 0 are unlimited.
+type AgentPool struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	// MaxConcurrency caps the shards running on the pool's agents at once.
+	MaxConcurrency int `json:"max_concurrency" db:"max_concurrency"`
+	// MaxConcurrencyPerService caps the shards of one service running on
+	// the pool's agents at once.
+	MaxConcurrencyPerService int `json:"max_concurrency_per_service" db:"max_concurrency_per_service"`
+	// MaxArtifactBytes caps the size of each artifact the pool's agents
+	// upload; 0 means no pool limit.
+	MaxArtifactBytes int64     `json:"max_artifact_bytes" db:"max_artifact_bytes"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AgentPoolStats is the current usage of an agent pool.
+type AgentPoolStats struct {
+	Pool          string `json:"pool"`
+	Agents        int    `json:"agents"`
+	OnlineAgents  int    `json:"online_agents"`
+	RunningShards int    `json:"running_shards"`
+}
+
 // RunStatus represents the status of a test run.
 type RunStatus string
 
@@ -87,6 +355,9 @@ const (
 	RunStatusError     RunStatus = "error"
 	RunStatusTimeout   RunStatus = "timeout"
 	RunStatusCancelled RunStatus = "cancelled"
+	// RunStatusWaitingApproval holds a run of a protected branch or of a
+	// test requiring approval until an authorized user approves it.
+	RunStatusWaitingApproval RunStatus = "waiting_approval"
 )
 
 // TriggerType represents what triggered a test run.
@@ -122,6 +393,109 @@ type TestRun struct {
 	MaxParallel  int          `json:"max_parallel_tests" db:"max_parallel_tests"`
 	DurationMs   *int64       `json:"duration_ms,omitempty" db:"duration_ms"`
 	ErrorMessage *string      `json:"error_message,omitempty" db:"error_message"`
+	// PullRequestNumber is set for runs triggered by a pull/merge request.
+	PullRequestNumber *int64 `json:"pull_request_number,omitempty" db:"pull_request_number"`
+	// ResultsDropped and ArtifactsDropped count what was discarded after the
+	// run reached its per-run ingestion caps.
+	ResultsDropped   int64 `json:"results_dropped" db:"results_dropped"`
+	ArtifactsDropped int   `json:"artifacts_dropped" db:"artifacts_dropped"`
+	// Attempt is 1 for a new run and incremented by automatic retries.
+	Attempt int `json:"attempt" db:"attempt"`
+	// RetryOfRunID is the run this run automatically retries.
+	RetryOfRunID *uuid.UUID `json:"retry_of_run_id,omitempty" db:"retry_of_run_id"`
+	// NotBefore delays scheduling of a pending run, for retry backoff.
+	NotBefore *time.Time `json:"not_before,omitempty" db:"not_before"`
+	// LabelSelector lists the labels an agent must carry to run any shard of
+	// the run, in addition to the selectors of its test definitions.
+	LabelSelector map[string]string `json:"label_selector,omitempty" db:"label_selector"`
+	// AgentName and AgentNetworkZones record the executing agent when the
+	// run started, and are kept after the agent is deleted.
+	AgentName         *string  `json:"agent_name,omitempty" db:"agent_name"`
+	AgentNetworkZones []string `json:"agent_network_zones,omitempty" db:"agent_network_zones"`
+	// TraceParent is the W3C traceparent of the span the run was created in,
+	// which its scheduling and execution continue.
+	TraceParent *string `json:"trace_parent,omitempty" db:"trace_parent"`
+	// ConcurrencyGroup is the group whose runs execute one at a time. Nil
+	// for runs without a group.
+	ConcurrencyGroup *string `json:"concurrency_group,omitempty" db:"concurrency_group"`
+	// ApprovalReason is why the run waited for approval. Nil for runs that
+	// did not need approval.
+	ApprovalReason *string `json:"approval_reason,omitempty" db:"approval_reason"`
+	// ApprovedBy and ApprovedAt record who approved the run and when.
+	ApprovedBy *string    `json:"approved_by,omitempty" db:"approved_by"`
+	ApprovedAt *time.Time `json:"approved_at,omitempty" db:"approved_at"`
+	// HasMediaArtifacts is set once a screenshot or video artifact of the
+	// run is recorded.
+	HasMediaArtifacts bool `json:"has_media_artifacts" db:"has_media_artifacts"`
+	// Metadata holds the annotations and external links API clients
+	// attached to the run.
+	Metadata RunMetadata `json:"metadata" db:"metadata"`
+	// Parameters holds the parameter values the run was created with.
+	Parameters map[string]string `json:"parameters,omitempty" db:"parameters"`
+}
+
+// RunMetadata holds the annotations and external links API clients attach to
+// a run, so CI systems can cross-reference their builds, tickets and
+// deployments with it.
+type RunMetadata struct {
+	// Annotations are key/value pairs, e.g. deployment_id.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Links maps names to URLs, e.g. build or ticket.
+	Links map[string]string `json:"links,omitempty"`
+}
+
+// Branch is a branch of a service repository. Branches are created by push
+// webhooks, link to their most recent run, and are removed when the branch
+// is deleted.
+type Branch struct {
+	ServiceID    uuid.UUID  `json:"service_id" db:"service_id"`
+	Name         string     `json:"name" db:"name"`
+	LastSHA      *string    `json:"last_sha,omitempty" db:"last_sha"`
+	LastRunID    *uuid.UUID `json:"last_run_id,omitempty" db:"last_run_id"`
+	Protected    bool       `json:"protected" db:"protected"`
+	LastPushedAt *time.Time `json:"last_pushed_at,omitempty" db:"last_pushed_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+
+	// LastRunStatus and LastRunAt describe the run referenced by LastRunID.
+	LastRunStatus *RunStatus `json:"last_run_status,omitempty" db:"last_run_status"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty" db:"last_run_at"`
+	// RecentRuns and RecentPassed count the branch's latest finished runs,
+	// up to 20 of them.
+	RecentRuns   int `json:"recent_runs" db:"recent_runs"`
+	RecentPassed int `json:"recent_passed" db:"recent_passed"`
+}
+
+// SyncTrigger is what started a sync of test definitions.
+type SyncTrigger string
+
+const (
+	SyncTriggerManual    SyncTrigger = "manual"
+	SyncTriggerScheduled SyncTrigger = "scheduled"
+)
+
+// SyncStatus is the outcome of a sync of test definitions.
+type SyncStatus string
+
+const (
+	SyncStatusSuccess SyncStatus = "success"
+	SyncStatusPartial SyncStatus = "partial" // some definitions were rejected
+	SyncStatusFailed  SyncStatus = "failed"
+)
+
+// ServiceSync records a sync of a service's test definitions from its repository.
+type ServiceSync struct {
+	ID           uuid.UUID   `json:"id" db:"id"`
+	ServiceID    uuid.UUID   `json:"service_id" db:"service_id"`
+	Branch       string      `json:"branch" db:"branch"`
+	Trigger      SyncTrigger `json:"trigger" db:"trigger"`
+	Status       SyncStatus  `json:"status" db:"status"`
+	TestsAdded   int         `json:"tests_added" db:"tests_added"`
+	TestsUpdated int         `json:"tests_updated" db:"tests_updated"`
+	TestsRemoved int         `json:"tests_removed" db:"tests_removed"`
+	Errors       []string    `json:"errors" db:"errors"`
+	StartedAt    time.Time   `json:"started_at" db:"started_at"`
+	FinishedAt   time.Time   `json:"finished_at" db:"finished_at"`
 }
 
 // IsTerminal returns true if the run is in a terminal state.
@@ -134,6 +508,21 @@ func (r *TestRun) IsTerminal() bool {
 	}
 }
 
+// RunSearchFilter selects the runs to search. Nil fields match everything.
+type RunSearchFilter struct {
+	ServiceID *uuid.UUID
+	Statuses  []RunStatus
+	// Branch matches the git ref of runs, with or without refs/heads/.
+	Branch *string
+	// Text matches runs whose error message, or the error message or stack
+	// trace of one of their results, contains it or matches it as a
+	// full-text query.
+	Text *string
+	// Since and Until bound CreatedAt; Since is inclusive, Until exclusive.
+	Since *time.Time
+	Until *time.Time
+}
+
 // ResultStatus represents the status of an individual test result.
 type ResultStatus string
 
@@ -160,6 +549,18 @@ type TestResult struct {
 	Stderr           *string      `json:"stderr,omitempty" db:"stderr"`
 	RetryCount       int          `json:"retry_count" db:"retry_count"`
 	CreatedAt        time.Time    `json:"created_at" db:"created_at"`
+	// StreamSequence is the result stream sequence the result arrived with
+	// from an agent that replays results, or 0. Results with one are
+	// stored once per run and sequence.
+	StreamSequence int64 `json:"-" db:"-"`
+}
+
+// ResultPartition is the partition of test_results holding the results
+// created in a month.
+type ResultPartition struct {
+	Name string `json:"name"`
+	// Month is the first day of the month, in UTC.
+	Month time.Time `json:"month"`
 }
 
 // ShardStatus represents the status of a run shard.
@@ -190,27 +591,395 @@ type RunShard struct {
 	StartedAt    *time.Time  `json:"started_at,omitempty" db:"started_at"`
 	FinishedAt   *time.Time  `json:"finished_at,omitempty" db:"finished_at"`
 	CreatedAt    time.Time   `json:"created_at" db:"created_at"`
+	// Arch is the CPU architecture the shard runs on; nil runs on any.
+	Arch *string `json:"arch,omitempty" db:"arch"`
+	// AgentName and AgentNetworkZones record the executing agent when the
+	// shard started.
+	AgentName         *string  `json:"agent_name,omitempty" db:"agent_name"`
+	AgentNetworkZones []string `json:"agent_network_zones,omitempty" db:"agent_network_zones"`
+	// Matrix is the matrix combination the shard runs its tests with; nil
+	// for tests without a matrix.
+	Matrix *MatrixCell `json:"matrix,omitempty" db:"matrix"`
+	// Stage is the pipeline stage of the shard's tests; a stage starts once
+	// every earlier stage passed.
+	Stage int `json:"stage" db:"stage"`
+}
+
+// FailureMessage returns the shard's error message, prefixed with its
+// architecture and matrix combination when it has them so failures of
+// fanned out tests are told apart.
+func (s *RunShard) FailureMessage() string {
+	if s.ErrorMessage == nil {
+		return ""
+	}
+	var variant []string
+	if s.Arch != nil {
+		variant = append(variant, *s.Arch)
+	}
+	if name := s.Matrix.Name(); name != "" {
+		variant = append(variant, name)
+	}
+	if len(variant) > 0 {
+		return strings.Join(variant, ", ") + ": " + *s.ErrorMessage
+	}
+	return *s.ErrorMessage
+}
+
+// AnnotationSeverity represents the severity of a run annotation.
+type AnnotationSeverity string
+
+const (
+	AnnotationSeverityInfo    AnnotationSeverity = "info"
+	AnnotationSeverityWarning AnnotationSeverity = "warning"
+)
+
+// RunAnnotation is a warning an agent attached to a run about its
+// environment, such as clock skew or a nearly full disk.
+type RunAnnotation struct {
+	ID        uuid.UUID          `json:"id" db:"id"`
+	RunID     uuid.UUID          `json:"run_id" db:"run_id"`
+	ShardID   *uuid.UUID         `json:"shard_id,omitempty" db:"shard_id"`
+	AgentID   *uuid.UUID         `json:"agent_id,omitempty" db:"agent_id"`
+	Kind      string             `json:"kind" db:"kind"`
+	Severity  AnnotationSeverity `json:"severity" db:"severity"`
+	Message   string             `json:"message" db:"message"`
+	Metadata  map[string]string  `json:"metadata,omitempty" db:"metadata"`
+	CreatedAt time.Time          `json:"created_at" db:"created_at"`
+}
+
+// DurationRegression is a run or test that took significantly longer than
+// its recent passed runs on the same service branch.
+type DurationRegression struct {
+	ID    uuid.UUID `json:"id" db:"id"`
+	RunID uuid.UUID `json:"run_id" db:"run_id"`
+	// TestName is the regressed test, or nil when the run as a whole
+	// regressed.
+	TestName         *string `json:"test_name,omitempty" db:"test_name"`
+	DurationMs       int64   `json:"duration_ms" db:"duration_ms"`
+	BaselineMeanMs   int64   `json:"baseline_mean_ms" db:"baseline_mean_ms"`
+	BaselineStdDevMs int64   `json:"baseline_stddev_ms" db:"baseline_stddev_ms"`
+	// Sigma is how many standard deviations the duration is above the
+	// baseline mean; +Inf when the baseline durations are identical.
+	Sigma        float64   `json:"sigma" db:"sigma"`
+	BaselineRuns int       `json:"baseline_runs" db:"baseline_runs"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// TestDurationBaseline is the duration of a test in a run and its durations
+// in earlier runs, newest first.
+type TestDurationBaseline struct {
+	TestName   string
+	DurationMs int64
+	BaselineMs []int64
+}
+
+// RunEventType is a run lifecycle transition published to event webhooks.
+type RunEventType string
+
+const (
+	RunEventCreated  RunEventType = "run.created"
+	RunEventStarted  RunEventType = "run.started"
+	RunEventFinished RunEventType = "run.finished"
+)
+
+// RunEvent is a run lifecycle transition, recorded by a trigger on test_runs
+// whenever a run is created, starts or finishes.
+type RunEvent struct {
+	// ID orders events in the order they were recorded.
+	ID int64 `json:"-" db:"id"`
+	// EventID identifies the event to receivers.
+	EventID   uuid.UUID    `json:"event_id" db:"event_id"`
+	Type      RunEventType `json:"event_type" db:"event_type"`
+	RunID     uuid.UUID    `json:"run_id" db:"run_id"`
+	ServiceID uuid.UUID    `json:"service_id" db:"service_id"`
+	// Run is a JSON snapshot of the test_runs row at the time of the event.
+	Run          json.RawMessage `json:"run" db:"run"`
+	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+	DispatchedAt *time.Time      `json:"dispatched_at,omitempty" db:"dispatched_at"`
+}
+
+// EventWebhookDeliveryStatus is the state of an event webhook delivery.
+type EventWebhookDeliveryStatus string
+
+const (
+	EventWebhookDeliveryPending   EventWebhookDeliveryStatus = "pending"
+	EventWebhookDeliveryDelivered EventWebhookDeliveryStatus = "delivered"
+	EventWebhookDeliveryFailed    EventWebhookDeliveryStatus = "failed"
+)
+
+// EventWebhookDelivery is the delivery of a run event to one event webhook
+// endpoint.
+type EventWebhookDelivery struct {
+	ID      uuid.UUID `json:"id" db:"id"`
+	EventID int64     `json:"-" db:"event_id"`
+	// Endpoint is the name of the configured endpoint.
+	Endpoint string                     `json:"endpoint" db:"endpoint"`
+	Status   EventWebhookDeliveryStatus `json:"status" db:"status"`
+	Attempts int                        `json:"attempts" db:"attempts"`
+	// NextAttemptAt is when a pending delivery is attempted next.
+	NextAttemptAt time.Time `json:"next_attempt_at" db:"next_attempt_at"`
+	// ResponseStatus is the HTTP status of the last attempt, nil when no
+	// response was received.
+	ResponseStatus *int       `json:"response_status,omitempty" db:"response_status"`
+	Error          string     `json:"error,omitempty" db:"error"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+	// Event is the delivered event. It is only loaded by
+	// ClaimDueDeliveries.
+	Event RunEvent `json:"event" db:"-"`
+}
+
+// AuditProtocol is the API an audited call was made through.
+type AuditProtocol string
+
+const (
+	AuditProtocolGRPC AuditProtocol = "grpc"
+	AuditProtocolHTTP AuditProtocol = "http"
+)
+
+// AuditLog records a mutating API call.
+type AuditLog struct {
+	ID uuid.UUID `json:"id" db:"id"`
+	// Actor is the subject of the caller's token, or "anonymous".
+	Actor      string `json:"actor" db:"actor"`
+	ActorEmail string `json:"actor_email,omitempty" db:"actor_email"`
+	// Action is the full gRPC method, or the HTTP method and path.
+	Action   string        `json:"action" db:"action"`
+	Protocol AuditProtocol `json:"protocol" db:"protocol"`
+	// ResourceType and ResourceID identify the changed resource, if known.
+	ResourceType string `json:"resource_type,omitempty" db:"resource_type"`
+	ResourceID   string `json:"resource_id,omitempty" db:"resource_id"`
+	// Request, Before and After are JSON with secrets redacted: the request,
+	// the resource before the call and the response.
+	Request json.RawMessage `json:"request,omitempty" db:"request"`
+	Before  json.RawMessage `json:"before,omitempty" db:"before"`
+	After   json.RawMessage `json:"after,omitempty" db:"after"`
+	// Status is the gRPC status code name or the HTTP status code.
+	Status    string    `json:"status" db:"status"`
+	Error     string    `json:"error,omitempty" db:"error"`
+	SourceIP  string    `json:"source_ip,omitempty" db:"source_ip"`
+	RequestID string    `json:"request_id,omitempty" db:"request_id"`
+	UserAgent string    `json:"user_agent,omitempty" db:"user_agent"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
+// AuditLogFilter selects audit logs. Nil fields match everything.
+type AuditLogFilter struct {
+	Actor        *string
+	Action       *string
+	ResourceType *string
+	ResourceID   *string
+	// Since and Until bound CreatedAt; Since is inclusive, Until exclusive.
+	Since *time.Time
+	Until *time.Time
+}
+
+// SavedView is a named set of dashboard filters a user saved.
+type SavedView struct {
+	ID uuid.UUID `json:"id" db:"id"`
+	// UserID is the subject of the token of the user who saved the view.
+	UserID string `json:"user_id" db:"user_id"`
+	Name   string `json:"name" db:"name"`
+	// Page is the dashboard page the view applies to, e.g. runs.
+	Page string `json:"page" db:"page"`
+	// ServiceID limits the view to the dashboard of a service; nil for the
+	// whole page.
+	ServiceID *uuid.UUID `json:"service_id,omitempty" db:"service_id"`
+	// Filters is the JSON object of filters and display settings the
+	// frontend restores. The control plane does not interpret it.
+	Filters json.RawMessage `json:"filters" db:"filters"`
+	// IsDefault marks the view a page opens with. A page has at most one
+	// default view per user and service.
+	IsDefault bool      `json:"is_default" db:"is_default"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SavedViewFilter selects saved views. Nil fields match everything.
+type SavedViewFilter struct {
+	Page      *string
+	ServiceID *uuid.UUID
+}
+
+// AgentConnection records the control plane replica an agent holds its
+// work stream with.
+type AgentConnection struct {
+	AgentID uuid.UUID `json:"agent_id" db:"agent_id"`
+	// ReplicaID identifies the control plane replica.
+	ReplicaID string `json:"replica_id" db:"replica_id"`
+	// ReplicaAddress is the gRPC address other replicas reach it at.
+	ReplicaAddress string    `json:"replica_address" db:"replica_address"`
+	ConnectedAt    time.Time `json:"connected_at" db:"connected_at"`
+}
+
+// SchedulingPause records that scheduling is paused, globally or for one
+// service. Runs keep queueing while paused but are not assigned to agents.
+type SchedulingPause struct {
+	ID uuid.UUID `json:"id" db:"id"`
+	// ServiceID is the paused service, or nil if scheduling is paused
+	// globally.
+	ServiceID *uuid.UUID `json:"service_id,omitempty" db:"service_id"`
+	Reason    string     `json:"reason" db:"reason"`
+	// PausedBy is the subject of the operator who paused scheduling.
+	PausedBy string    `json:"paused_by" db:"paused_by"`
+	PausedAt time.Time `json:"paused_at" db:"paused_at"`
+}
+
+// MaintenanceNotifications is what happens to notifications during a
+// maintenance window.
+type MaintenanceNotifications string
+
+const (
+	// MaintenanceNotificationsSuppress drops the notifications.
+	MaintenanceNotificationsSuppress MaintenanceNotifications = "suppress"
+	// MaintenanceNotificationsTag sends the notifications marked as sent
+	// during maintenance.
+	MaintenanceNotificationsTag MaintenanceNotifications = "tag"
+)
+
+// MaintenanceWindow is a recurring or one-off period in which runs of a
+// service, or work for the agents of a pool, are deferred. A window with
+// neither a service nor a pool applies to every service.
+type MaintenanceWindow struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	Description *string    `json:"description,omitempty" db:"description"`
+	ServiceID   *uuid.UUID `json:"service_id,omitempty" db:"service_id"`
+	// AgentPool is the name of the agent pool whose agents get no work.
+	AgentPool *string `json:"agent_pool,omitempty" db:"agent_pool"`
+	// CronExpression sets the start times of a recurring window, each
+	// lasting DurationSeconds. Nil makes a one-off window from StartsAt to
+	// EndsAt.
+	CronExpression *string `json:"cron_expression,omitempty" db:"cron_expression"`
+	// Timezone is the IANA time zone the cron expression is evaluated in.
+	Timezone        string `json:"timezone" db:"timezone"`
+	DurationSeconds int    `json:"duration_seconds" db:"duration_seconds"`
+	// StartsAt and EndsAt bound a recurring window, or are the period of a
+	// one-off window.
+	StartsAt      *time.Time               `json:"starts_at,omitempty" db:"starts_at"`
+	EndsAt        *time.Time               `json:"ends_at,omitempty" db:"ends_at"`
+	Notifications MaintenanceNotifications `json:"notifications" db:"notifications"`
+	Enabled       bool                     `json:"enabled" db:"enabled"`
+	// CreatedBy is the subject of the operator who created the window.
+	CreatedBy string    `json:"created_by" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// IdempotencyKey records a request that must not be applied twice, so that
+// a retry of it is answered with the response of the first.
+type IdempotencyKey struct {
+	// Scope is the operation and caller the key belongs to; the same key
+	// may be used in different scopes.
+	Scope string `json:"scope" db:"scope"`
+	Key   string `json:"key" db:"key"`
+	// RequestHash identifies the request, to reject the key when it is
+	// reused with another request.
+	RequestHash string `json:"request_hash" db:"request_hash"`
+	// Response is the snapshot of the response, as JSON. It is nil while
+	// the request is in progress.
+	Response  []byte    `json:"response,omitempty" db:"response"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// ExpiresAt is when the key may be claimed again.
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// EnergySample is the estimated energy a run used between two heartbeats of
+// its agent. When an agent runs several runs at once, the energy is split
+// evenly between them.
+type EnergySample struct {
+	ID          int64      `json:"id" db:"id"`
+	RunID       uuid.UUID  `json:"run_id" db:"run_id"`
+	AgentID     *uuid.UUID `json:"agent_id,omitempty" db:"agent_id"`
+	Zone        string     `json:"zone" db:"zone"`
+	CPUPercent  float64    `json:"cpu_percent" db:"cpu_percent"`
+	CPUCores    int        `json:"cpu_cores" db:"cpu_cores"`
+	MemoryBytes int64      `json:"memory_bytes" db:"memory_bytes"`
+	EnergyWh    float64    `json:"energy_wh" db:"energy_wh"`
+	SampledAt   time.Time  `json:"sampled_at" db:"sampled_at"`
+}
+
+// ZoneEnergy is the estimated energy used in a network zone.
+type ZoneEnergy struct {
+	Zone     string  `json:"zone" db:"zone"`
+	EnergyWh float64 `json:"energy_wh" db:"energy_wh"`
+}
+
+// MonthlyEnergy is the estimated energy the runs of a service used in a
+// network zone during a calendar month (UTC).
+type MonthlyEnergy struct {
+	ServiceID   uuid.UUID `json:"service_id" db:"service_id"`
+	ServiceName string    `json:"service_name" db:"service_name"`
+	Month       time.Time `json:"month" db:"month"`
+	Zone        string    `json:"zone" db:"zone"`
+	EnergyWh    float64   `json:"energy_wh" db:"energy_wh"`
+	// RunCount is the number of runs of the service sampled in the month,
+	// across all zones.
+	RunCount int `json:"run_count" db:"run_count"`
+}
+
+// ArtifactType is the kind of content an artifact holds.
+type ArtifactType string
+
+const (
+	ArtifactTypeLog        ArtifactType = "log"
+	ArtifactTypeJUnit      ArtifactType = "junit"
+	ArtifactTypeCoverage   ArtifactType = "coverage"
+	ArtifactTypeScreenshot ArtifactType = "screenshot"
+	ArtifactTypeVideo      ArtifactType = "video"
+	ArtifactTypeBinary     ArtifactType = "binary"
+)
+
 // Artifact represents a test artifact stored in S3/MinIO.
 type Artifact struct {
-	ID          uuid.UUID `json:"id" db:"id"`
-	RunID       uuid.UUID `json:"run_id" db:"run_id"`
-	Name        string    `json:"name" db:"name"`
-	Path        string    `json:"path" db:"path"` // S3 object path
-	ContentType *string   `json:"content_type,omitempty" db:"content_type"`
-	SizeBytes   *int64    `json:"size_bytes,omitempty" db:"size_bytes"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	ID          uuid.UUID    `json:"id" db:"id"`
+	RunID       uuid.UUID    `json:"run_id" db:"run_id"`
+	Name        string       `json:"name" db:"name"`
+	Path        string       `json:"path" db:"path"` // S3 object path
+	ContentType *string      `json:"content_type,omitempty" db:"content_type"`
+	SizeBytes   *int64       `json:"size_bytes,omitempty" db:"size_bytes"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+	Type        ArtifactType `json:"type" db:"artifact_type"`
+	// Checksum is the hex SHA-256 of the content, if the agent reported it.
+	Checksum *string `json:"checksum,omitempty" db:"checksum"`
+	// BlobDigest is the digest of the blob holding the content, shared with
+	// other artifacts of the same content. Path is the blob's path then.
+	BlobDigest *string `json:"blob_digest,omitempty" db:"blob_digest"`
+
+	// StorageClass is the S3 storage class the object currently lives in.
+	StorageClass       string     `json:"storage_class" db:"storage_class"`
+	ArchivedAt         *time.Time `json:"archived_at,omitempty" db:"archived_at"`
+	RestoreRequestedAt *time.Time `json:"restore_requested_at,omitempty" db:"restore_requested_at"`
+	RestoredUntil      *time.Time `json:"restored_until,omitempty" db:"restored_until"`
+
+	// TestDefinitionID is the test whose artifact patterns collected the
+	// artifact; the artifact counts against its artifact budget.
+	TestDefinitionID *uuid.UUID `json:"test_definition_id,omitempty" db:"test_definition_id"`
+}
+
+// ArtifactBlob is artifact content stored once, keyed by its SHA-256
+// digest, and shared by the artifacts that hold it.
+type ArtifactBlob struct {
+	Digest      string  `json:"digest" db:"digest"`
+	Path        string  `json:"path" db:"path"`
+	SizeBytes   int64   `json:"size_bytes" db:"size_bytes"`
+	ContentType *string `json:"content_type,omitempty" db:"content_type"`
+	// RefCount is the number of artifacts holding the blob.
+	RefCount         int       `json:"ref_count" db:"ref_count"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	LastReferencedAt time.Time `json:"last_referenced_at" db:"last_referenced_at"`
 }
 
 // ChannelType represents the type of notification channel.
 type ChannelType string
 
 const (
-	ChannelTypeSlack   ChannelType = "slack"
-	ChannelTypeEmail   ChannelType = "email"
-	ChannelTypeWebhook ChannelType = "webhook"
-	ChannelTypeTeams   ChannelType = "teams"
+	ChannelTypeSlack      ChannelType = "slack"
+	ChannelTypeEmail      ChannelType = "email"
+	ChannelTypeWebhook    ChannelType = "webhook"
+	ChannelTypeTeams      ChannelType = "teams"
+	ChannelTypeDiscord    ChannelType = "discord"
+	ChannelTypeMattermost ChannelType = "mattermost"
 )
 
 // NotificationChannel defines a notification destination.
@@ -222,15 +991,36 @@ type NotificationChannel struct {
 	Enabled   bool            `json:"enabled" db:"enabled"`
 	CreatedAt time.Time       `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time       `json:"updated_at" db:"updated_at"`
+	// ProjectID is the project the channel belongs to. uuid.Nil is stored as
+	// the default project.
+	ProjectID uuid.UUID `json:"project_id" db:"project_id"`
 }
 
+// SlackMessageFormat selects the layout of Slack messages.
+type SlackMessageFormat string
+
+const (
+	// SlackMessageFormatAttachments wraps the message in a legacy attachment
+	// with a status color bar. It is the default.
+	SlackMessageFormatAttachments SlackMessageFormat = "attachments"
+	// SlackMessageFormatBlocks posts top-level Block Kit blocks.
+	SlackMessageFormatBlocks SlackMessageFormat = "blocks"
+)
+
 // SlackChannelConfig holds Slack-specific configuration.
 type SlackChannelConfig struct {
-	WebhookURL string `json:"webhook_url"`
-	Channel    string `json:"channel,omitempty"`
-	Username   string `json:"username,omitempty"`
-	IconEmoji  string `json:"icon_emoji,omitempty"`
-	Token      string `json:"token,omitempty"`
+	WebhookURL    string             `json:"webhook_url"`
+	Channel       string             `json:"channel,omitempty"`
+	Username      string             `json:"username,omitempty"`
+	IconEmoji     string             `json:"icon_emoji,omitempty"`
+	Token         string             `json:"token,omitempty"`
+	MessageFormat SlackMessageFormat `json:"message_format,omitempty"`
+	// ThreadReplies posts recoveries as replies to the failure they
+	// recover from. Requires Token.
+	ThreadReplies bool `json:"thread_replies,omitempty"`
+	// UpdateInPlace updates the message of a run as it progresses instead
+	// of posting a message per event. Requires Token.
+	UpdateInPlace bool `json:"update_in_place,omitempty"`
 }
 
 // EmailChannelConfig holds email-specific configuration.
@@ -241,6 +1031,21 @@ type EmailChannelConfig struct {
 	IncludeLogs  bool     `json:"include_logs,omitempty"`
 }
 
+// DiscordChannelConfig holds Discord-specific configuration.
+type DiscordChannelConfig struct {
+	WebhookURL string `json:"webhook_url"`
+	Username   string `json:"username,omitempty"`
+	AvatarURL  string `json:"avatar_url,omitempty"`
+}
+
+// MattermostChannelConfig holds Mattermost-specific configuration.
+type MattermostChannelConfig struct {
+	WebhookURL string `json:"webhook_url"`
+	Channel    string `json:"channel,omitempty"`
+	Username   string `json:"username,omitempty"`
+	IconURL    string `json:"icon_url,omitempty"`
+}
+
 // WebhookChannelConfig holds webhook-specific configuration.
 type WebhookChannelConfig struct {
 	URL      string            `json:"url"`
@@ -257,32 +1062,128 @@ const (
 	TriggerEventRecovery TriggerEvent = "recovery"
 	TriggerEventFlaky    TriggerEvent = "flaky"
 	TriggerEventAlways   TriggerEvent = "always"
+	// TriggerEventDurationAnomaly fires when a passed run's duration deviates
+	// from the service's recent runs.
+	TriggerEventDurationAnomaly TriggerEvent = "duration_anomaly"
+	// TriggerEventDurationRegression fires when a run or its tests take
+	// significantly longer than recent passed runs of the same branch.
+	TriggerEventDurationRegression TriggerEvent = "duration_regression"
 )
 
 // NotificationRule defines when and what notifications to send.
 type NotificationRule struct {
-	ID        uuid.UUID      `json:"id" db:"id"`
-	ChannelID uuid.UUID      `json:"channel_id" db:"channel_id"`
-	ServiceID *uuid.UUID     `json:"service_id,omitempty" db:"service_id"` // NULL means all services
+	ID        uuid.UUID  `json:"id" db:"id"`
+	ChannelID uuid.UUID  `json:"channel_id" db:"channel_id"`
+	ServiceID *uuid.UUID `json:"service_id,omitempty" db:"service_id"` // NULL means all services
+	// TestDefinitionID limits the rule to one test definition of ServiceID.
+	// Such rules take precedence over service and global rules.
+	TestDefinitionID *uuid.UUID `json:"test_definition_id,omitempty" db:"test_definition_id"`
+	// Owner limits the rule to the tests of one owner. Such rules take
+	// precedence like test definition rules.
+	Owner     *string        `json:"owner,omitempty" db:"owner"`
 	TriggerOn []TriggerEvent `json:"trigger_on" db:"trigger_on"`
 	Enabled   bool           `json:"enabled" db:"enabled"`
-	CreatedAt time.Time      `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at" db:"updated_at"`
+	// TitleTemplate and MessageTemplate are Go templates replacing the
+	// built-in title and message of notifications the rule sends. Nil uses
+	// the built-in one.
+	TitleTemplate   *string   `json:"title_template,omitempty" db:"title_template"`
+	MessageTemplate *string   `json:"message_template,omitempty" db:"message_template"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DeliveryStatus is the outcome of a notification delivery.
+type DeliveryStatus string
+
+const (
+	DeliveryStatusSent   DeliveryStatus = "sent"
+	DeliveryStatusFailed DeliveryStatus = "failed"
+)
+
+// NotificationDelivery records a notification sent to a channel.
+type NotificationDelivery struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	EventType string     `json:"event_type" db:"event_type"`
+	ServiceID *uuid.UUID `json:"service_id,omitempty" db:"service_id"`
+	RunID     *uuid.UUID `json:"run_id,omitempty" db:"run_id"`
+	// ChannelID is nil once the channel is deleted.
+	ChannelID   *uuid.UUID     `json:"channel_id,omitempty" db:"channel_id"`
+	ChannelType ChannelType    `json:"channel_type" db:"channel_type"`
+	RuleIDs     []uuid.UUID    `json:"rule_ids" db:"rule_ids"`
+	Status      DeliveryStatus `json:"status" db:"status"`
+	// Attempts counts the requests made to the channel, including retries.
+	Attempts        int       `json:"attempts" db:"attempts"`
+	LatencyMs       int64     `json:"latency_ms" db:"latency_ms"`
+	Error           string    `json:"error,omitempty" db:"error"`
+	ResponseExcerpt string    `json:"response_excerpt,omitempty" db:"response_excerpt"`
+	SentAt          time.Time `json:"sent_at" db:"sent_at"`
+	// Payload is the notification sent to the channel. It is only loaded
+	// by GetDelivery and is nil for deliveries recorded before payloads
+	// were stored.
+	Payload     json.RawMessage `json:"payload,omitempty" db:"payload"`
+	PayloadHash string          `json:"payload_hash,omitempty" db:"payload_hash"`
+	// RedeliveryOf is the failed delivery this delivery redelivers.
+	RedeliveryOf *uuid.UUID `json:"redelivery_of,omitempty" db:"redelivery_of"`
+}
+
+// NotificationDeadLetter is a notification that could not be delivered after
+// all retries, waiting to be redelivered.
+type NotificationDeadLetter struct {
+	ID uuid.UUID `json:"id" db:"id"`
+	// DeliveryID is the latest failed delivery; its payload is redelivered.
+	DeliveryID uuid.UUID `json:"delivery_id" db:"delivery_id"`
+	ChannelID  uuid.UUID `json:"channel_id" db:"channel_id"`
+	Error      string    `json:"error" db:"error"`
+	// Transient reports whether the last failure may succeed when retried.
+	Transient bool `json:"transient" db:"transient"`
+	// Attempts counts the redeliveries since the notification was
+	// dead-lettered.
+	Attempts int `json:"attempts" db:"attempts"`
+	// NextAttemptAt is when the notification is redelivered automatically,
+	// nil once it is no longer retried.
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationDeadLetterCounts is the depth of the dead-letter queue.
+type NotificationDeadLetterCounts struct {
+	// Pending dead letters are redelivered automatically.
+	Pending int
+	// Exhausted dead letters failed permanently or ran out of attempts and
+	// are only redelivered manually.
+	Exhausted int
+}
+
+// NotificationDeliveryFilter selects notification deliveries. Nil fields
+// match every delivery.
+type NotificationDeliveryFilter struct {
+	ServiceID *uuid.UUID
+	RunID     *uuid.UUID
+	ChannelID *uuid.UUID
+	RuleID    *uuid.UUID
+	Status    *DeliveryStatus
+	EventType *string
+	// Since and Until bound SentAt; Since is inclusive, Until exclusive.
+	Since *time.Time
+	Until *time.Time
 }
 
 // ScheduledRun defines a recurring test run schedule.
 type ScheduledRun struct {
-	ID             uuid.UUID  `json:"id" db:"id"`
-	ServiceID      uuid.UUID  `json:"service_id" db:"service_id"`
-	Name           string     `json:"name" db:"name"`
-	CronExpression string     `json:"cron_expression" db:"cron_expression"`
-	GitRef         string     `json:"git_ref" db:"git_ref"`
-	TestFilter     []string   `json:"test_filter,omitempty" db:"test_filter"`
-	Enabled        bool       `json:"enabled" db:"enabled"`
-	LastRunAt      *time.Time `json:"last_run_at,omitempty" db:"last_run_at"`
-	NextRunAt      *time.Time `json:"next_run_at,omitempty" db:"next_run_at"`
-	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+	ID             uuid.UUID `json:"id" db:"id"`
+	ServiceID      uuid.UUID `json:"service_id" db:"service_id"`
+	Name           string    `json:"name" db:"name"`
+	CronExpression string    `json:"cron_expression" db:"cron_expression"`
+	// Timezone is the IANA time zone the cron expression is evaluated in.
+	Timezone   string     `json:"timezone" db:"timezone"`
+	GitRef     string     `json:"git_ref" db:"git_ref"`
+	TestFilter []string   `json:"test_filter,omitempty" db:"test_filter"`
+	Enabled    bool       `json:"enabled" db:"enabled"`
+	LastRunAt  *time.Time `json:"last_run_at,omitempty" db:"last_run_at"`
+	NextRunAt  *time.Time `json:"next_run_at,omitempty" db:"next_run_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // DailyStats holds pre-aggregated daily statistics per service.
@@ -342,10 +1243,68 @@ type ServiceHealthSummary struct {
 	LastRunStatus   *RunStatus `json:"last_run_status,omitempty" db:"last_run_status"`
 }
 
-// Pagination parameters for list operations.
+// ServiceReport holds the run statistics of a service over a window of days,
+// read from the materialized report views.
+type ServiceReport struct {
+	ServiceID     uuid.UUID `json:"service_id" db:"service_id"`
+	ServiceName   string    `json:"service_name" db:"service_name"`
+	TotalRuns     int       `json:"total_runs" db:"total_runs"`
+	PassedRuns    int       `json:"passed_runs" db:"passed_runs"`
+	FailedRuns    int       `json:"failed_runs" db:"failed_runs"`
+	AvgDurationMs int64     `json:"avg_duration_ms" db:"avg_duration_ms"`
+	P95DurationMs int64     `json:"p95_duration_ms" db:"p95_duration_ms"`
+	Recoveries    int       `json:"recoveries" db:"recoveries"`
+	MTTRMs        int64     `json:"mttr_ms" db:"mttr_ms"`
+}
+
+// ServiceReportFilter selects the runs a service report covers.
+type ServiceReportFilter struct {
+	// Since is the first UTC day of the window.
+	Since time.Time
+	// ServiceID limits the report to one service; nil reports every service.
+	ServiceID *uuid.UUID
+	// GitRef limits the report to runs of one branch; nil includes all.
+	GitRef *string
+}
+
+// Pagination parameters for list operations. A page starts either at
+// Offset or, for lists that support keyset pagination, right after the
+// cursor After; keyset pages stay fast however deep they are.
 type Pagination struct {
-	Limit  int `json:"limit"`
-	Offset int `json:"offset"`
+	Limit  int     `json:"limit"`
+	Offset int     `json:"offset"`
+	After  *Cursor `json:"after,omitempty"`
+}
+
+// Cursor is the position of an item in a list for keyset pagination. It
+// holds the ID of the item and the values it is sorted by; each list uses
+// the values of its own order.
+type Cursor struct {
+	ID uuid.UUID `json:"id"`
+	// Name sorts services and agents by name, and test results by test name.
+	Name string `json:"name,omitempty"`
+	// CreatedAt, StartedAt and Priority sort test runs.
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	StartedAt time.Time `json:"started_at,omitzero"`
+	Priority  int       `json:"priority,omitempty"`
+}
+
+// afterName returns the query arguments of the cursor of a list sorted by
+// name and ID: NULL without a cursor.
+func (p Pagination) afterName() (name, id any) {
+	if p.After == nil {
+		return nil, nil
+	}
+	return p.After.Name, p.After.ID
+}
+
+// afterCreated returns the query arguments of the cursor of a list sorted
+// by creation time and ID: NULL without a cursor.
+func (p Pagination) afterCreated() (createdAt, id any) {
+	if p.After == nil {
+		return nil, nil
+	}
+	return p.After.CreatedAt, p.After.ID
 }
 
 // DefaultPagination returns default pagination settings.
@@ -26,6 +26,7 @@ func (r *notificationRepo) CreateChannel(ctx context.Context, channel *Notificat
 		channel.Type,
 		channel.Config,
 		channel.Enabled,
+		projectIDOrDefault(channel.ProjectID),
 	).Scan(&channel.ID, &channel.CreatedAt, &channel.UpdatedAt)
 
 	if err != nil {
@@ -37,7 +38,7 @@ func (r *notificationRepo) CreateChannel(ctx context.Context, channel *Notificat
 // GetChannel retrieves a channel by ID.
 func (r *notificationRepo) GetChannel(ctx context.Context, id uuid.UUID) (*NotificationChannel, error) {
 	channel := &NotificationChannel{}
-	err := r.db.pool.QueryRow(ctx, NotificationChannelGetByID, id).Scan(
+	err := r.db.reader(ctx).QueryRow(ctx, NotificationChannelGetByID, id, projectScopeArg(ctx)).Scan(
 		&channel.ID,
 		&channel.Name,
 		&channel.Type,
@@ -45,6 +46,7 @@ func (r *notificationRepo) GetChannel(ctx context.Context, id uuid.UUID) (*Notif
 		&channel.Enabled,
 		&channel.CreatedAt,
 		&channel.UpdatedAt,
+		&channel.ProjectID,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -60,7 +62,7 @@ func (r *notificationRepo) UpdateChannel(ctx context.Context, channel *Notificat
 	const query = `
 		UPDATE notification_channels
 		SET name = $2, type = $3, config = $4, enabled = $5
-		WHERE id = $1
+		WHERE id = $1 AND ($6::uuid[] IS NULL OR project_id = ANY($6))
 		RETURNING updated_at`
 
 	err := r.db.pool.QueryRow(ctx, query,
@@ -69,6 +71,7 @@ func (r *notificationRepo) UpdateChannel(ctx context.Context, channel *Notificat
 		channel.Type,
 		channel.Config,
 		channel.Enabled,
+		projectScopeArg(ctx),
 	).Scan(&channel.UpdatedAt)
 
 	if err != nil {
@@ -82,8 +85,8 @@ func (r *notificationRepo) UpdateChannel(ctx context.Context, channel *Notificat
 
 // DeleteChannel deletes a notification channel.
 func (r *notificationRepo) DeleteChannel(ctx context.Context, id uuid.UUID) error {
-	const query = `DELETE FROM notification_channels WHERE id = $1`
-	result, err := r.db.pool.Exec(ctx, query, id)
+	const query = `DELETE FROM notification_channels WHERE id = $1 AND ($2::uuid[] IS NULL OR project_id = ANY($2))`
+	result, err := r.db.pool.Exec(ctx, query, id, projectScopeArg(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to delete notification channel: %w", err)
 	}
@@ -95,7 +98,7 @@ func (r *notificationRepo) DeleteChannel(ctx context.Context, id uuid.UUID) erro
 
 // ListChannels returns all channels with pagination.
 func (r *notificationRepo) ListChannels(ctx context.Context, page Pagination) ([]NotificationChannel, error) {
-	rows, err := r.db.pool.Query(ctx, NotificationChannelList, page.Limit, page.Offset)
+	rows, err := r.db.reader(ctx).Query(ctx, NotificationChannelList, page.Limit, page.Offset, projectScopeArg(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list notification channels: %w", err)
 	}
@@ -128,6 +131,7 @@ func scanNotificationChannels(rows pgx.Rows) ([]NotificationChannel, error) {
 			&channel.Enabled,
 			&channel.CreatedAt,
 			&channel.UpdatedAt,
+			&channel.ProjectID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan notification channel: %w", err)
@@ -147,8 +151,12 @@ func (r *notificationRepo) CreateRule(ctx context.Context, rule *NotificationRul
 	err := r.db.pool.QueryRow(ctx, NotificationRuleInsert,
 		rule.ChannelID,
 		rule.ServiceID,
+		rule.TestDefinitionID,
+		rule.Owner,
 		rule.TriggerOn,
 		rule.Enabled,
+		rule.TitleTemplate,
+		rule.MessageTemplate,
 	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
 
 	if err != nil {
@@ -160,17 +168,23 @@ func (r *notificationRepo) CreateRule(ctx context.Context, rule *NotificationRul
 // GetRule retrieves a rule by ID.
 func (r *notificationRepo) GetRule(ctx context.Context, id uuid.UUID) (*NotificationRule, error) {
 	const query = `
-		SELECT id, channel_id, service_id, trigger_on, enabled, created_at, updated_at
+		SELECT id, channel_id, service_id, test_definition_id, owner, trigger_on, enabled,
+			   title_template, message_template, created_at, updated_at
 		FROM notification_rules
-		WHERE id = $1`
+		WHERE id = $1
+		  AND ($2::uuid[] IS NULL OR channel_id IN (SELECT id FROM notification_channels WHERE project_id = ANY($2)))`
 
 	rule := &NotificationRule{}
-	err := r.db.pool.QueryRow(ctx, query, id).Scan(
+	err := r.db.reader(ctx).QueryRow(ctx, query, id, projectScopeArg(ctx)).Scan(
 		&rule.ID,
 		&rule.ChannelID,
 		&rule.ServiceID,
+		&rule.TestDefinitionID,
+		&rule.Owner,
 		&rule.TriggerOn,
 		&rule.Enabled,
+		&rule.TitleTemplate,
+		&rule.MessageTemplate,
 		&rule.CreatedAt,
 		&rule.UpdatedAt,
 	)
@@ -187,16 +201,23 @@ func (r *notificationRepo) GetRule(ctx context.Context, id uuid.UUID) (*Notifica
 func (r *notificationRepo) UpdateRule(ctx context.Context, rule *NotificationRule) error {
 	const query = `
 		UPDATE notification_rules
-		SET channel_id = $2, service_id = $3, trigger_on = $4, enabled = $5
+		SET channel_id = $2, service_id = $3, test_definition_id = $4, owner = $5, trigger_on = $6, enabled = $7,
+			title_template = $8, message_template = $9
 		WHERE id = $1
+		  AND ($10::uuid[] IS NULL OR channel_id IN (SELECT id FROM notification_channels WHERE project_id = ANY($10)))
 		RETURNING updated_at`
 
 	err := r.db.pool.QueryRow(ctx, query,
 		rule.ID,
 		rule.ChannelID,
 		rule.ServiceID,
+		rule.TestDefinitionID,
+		rule.Owner,
 		rule.TriggerOn,
 		rule.Enabled,
+		rule.TitleTemplate,
+		rule.MessageTemplate,
+		projectScopeArg(ctx),
 	).Scan(&rule.UpdatedAt)
 
 	if err != nil {
@@ -210,8 +231,11 @@ func (r *notificationRepo) UpdateRule(ctx context.Context, rule *NotificationRul
 
 // DeleteRule deletes a notification rule.
 func (r *notificationRepo) DeleteRule(ctx context.Context, id uuid.UUID) error {
-	const query = `DELETE FROM notification_rules WHERE id = $1`
-	result, err := r.db.pool.Exec(ctx, query, id)
+	const query = `
+		DELETE FROM notification_rules
+		WHERE id = $1
+		  AND ($2::uuid[] IS NULL OR channel_id IN (SELECT id FROM notification_channels WHERE project_id = ANY($2)))`
+	result, err := r.db.pool.Exec(ctx, query, id, projectScopeArg(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to delete notification rule: %w", err)
 	}
@@ -223,7 +247,7 @@ func (r *notificationRepo) DeleteRule(ctx context.Context, id uuid.UUID) error {
 
 // ListRulesByService returns rules for a service (including global rules).
 func (r *notificationRepo) ListRulesByService(ctx context.Context, serviceID uuid.UUID) ([]NotificationRule, error) {
-	rows, err := r.db.pool.Query(ctx, NotificationRuleListByService, serviceID)
+	rows, err := r.db.reader(ctx).Query(ctx, NotificationRuleListByService, serviceID, projectScopeArg(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list notification rules by service: %w", err)
 	}
@@ -234,7 +258,7 @@ func (r *notificationRepo) ListRulesByService(ctx context.Context, serviceID uui
 
 // ListRulesByChannel returns rules for a channel.
 func (r *notificationRepo) ListRulesByChannel(ctx context.Context, channelID uuid.UUID) ([]NotificationRule, error) {
-	rows, err := r.db.pool.Query(ctx, NotificationRuleListByChannel, channelID)
+	rows, err := r.db.reader(ctx).Query(ctx, NotificationRuleListByChannel, channelID, projectScopeArg(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list notification rules by channel: %w", err)
 	}
@@ -252,8 +276,12 @@ func scanNotificationRules(rows pgx.Rows) ([]NotificationRule, error) {
 			&rule.ID,
 			&rule.ChannelID,
 			&rule.ServiceID,
+			&rule.TestDefinitionID,
+			&rule.Owner,
 			&rule.TriggerOn,
 			&rule.Enabled,
+			&rule.TitleTemplate,
+			&rule.MessageTemplate,
 			&rule.CreatedAt,
 			&rule.UpdatedAt,
 		)
@@ -270,6 +298,240 @@ func scanNotificationRules(rows pgx.Rows) ([]NotificationRule, error) {
 	return rules, nil
 }
 
+// RecordDelivery records a notification sent to a channel.
+func (r *notificationRepo) RecordDelivery(ctx context.Context, delivery *NotificationDelivery) error {
+	if delivery.RuleIDs == nil {
+		delivery.RuleIDs = []uuid.UUID{}
+	}
+	if delivery.SentAt.IsZero() {
+		delivery.SentAt = time.Now()
+	}
+
+	err := r.db.pool.QueryRow(ctx, NotificationDeliveryInsert,
+		delivery.EventType,
+		delivery.ServiceID,
+		delivery.RunID,
+		delivery.ChannelID,
+		delivery.ChannelType,
+		delivery.RuleIDs,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.LatencyMs,
+		delivery.Error,
+		delivery.ResponseExcerpt,
+		delivery.SentAt,
+		delivery.Payload,
+		delivery.PayloadHash,
+		delivery.RedeliveryOf,
+	).Scan(&delivery.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to record notification delivery: %w", err)
+	}
+	return nil
+}
+
+// GetDelivery retrieves a delivery by ID, including its payload.
+func (r *notificationRepo) GetDelivery(ctx context.Context, id uuid.UUID) (*NotificationDelivery, error) {
+	var d NotificationDelivery
+	err := r.db.reader(ctx).QueryRow(ctx, NotificationDeliveryGetByID, id, projectScopeArg(ctx)).Scan(
+		&d.ID,
+		&d.EventType,
+		&d.ServiceID,
+		&d.RunID,
+		&d.ChannelID,
+		&d.ChannelType,
+		&d.RuleIDs,
+		&d.Status,
+		&d.Attempts,
+		&d.LatencyMs,
+		&d.Error,
+		&d.ResponseExcerpt,
+		&d.SentAt,
+		&d.PayloadHash,
+		&d.RedeliveryOf,
+		&d.Payload,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get notification delivery: %w", err)
+	}
+	return &d, nil
+}
+
+// ListDeliveries returns deliveries matching the filter, newest first, with
+// the total number of matches.
+func (r *notificationRepo) ListDeliveries(ctx context.Context, filter NotificationDeliveryFilter, page Pagination) ([]NotificationDelivery, int, error) {
+	args := []any{
+		filter.ServiceID,
+		filter.RunID,
+		filter.ChannelID,
+		filter.RuleID,
+		filter.Status,
+		filter.EventType,
+		filter.Since,
+		filter.Until,
+		projectScopeArg(ctx),
+	}
+
+	var total int
+	if err := r.db.reader(ctx).QueryRow(ctx, NotificationDeliveryCount, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count notification deliveries: %w", err)
+	}
+
+	rows, err := r.db.reader(ctx).Query(ctx, NotificationDeliveryList, append(args, page.Limit, page.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list notification deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []NotificationDelivery
+	for rows.Next() {
+		var d NotificationDelivery
+		err := rows.Scan(
+			&d.ID,
+			&d.EventType,
+			&d.ServiceID,
+			&d.RunID,
+			&d.ChannelID,
+			&d.ChannelType,
+			&d.RuleIDs,
+			&d.Status,
+			&d.Attempts,
+			&d.LatencyMs,
+			&d.Error,
+			&d.ResponseExcerpt,
+			&d.SentAt,
+			&d.PayloadHash,
+			&d.RedeliveryOf,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan notification delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating notification deliveries: %w", err)
+	}
+	return deliveries, total, nil
+}
+
+// CreateDeadLetter dead-letters a failed delivery.
+func (r *notificationRepo) CreateDeadLetter(ctx context.Context, deadLetter *NotificationDeadLetter) error {
+	err := r.db.pool.QueryRow(ctx, NotificationDeadLetterInsert,
+		deadLetter.DeliveryID,
+		deadLetter.ChannelID,
+		deadLetter.Error,
+		deadLetter.Transient,
+		deadLetter.Attempts,
+		deadLetter.NextAttemptAt,
+	).Scan(&deadLetter.ID, &deadLetter.CreatedAt, &deadLetter.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create notification dead letter: %w", err)
+	}
+	return nil
+}
+
+// GetDeadLetterByDelivery retrieves the dead letter of a failed delivery.
+func (r *notificationRepo) GetDeadLetterByDelivery(ctx context.Context, deliveryID uuid.UUID) (*NotificationDeadLetter, error) {
+	row := r.db.pool.QueryRow(ctx, NotificationDeadLetterGetByDelivery, deliveryID)
+	deadLetter, err := scanDeadLetter(row)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get notification dead letter: %w", err)
+	}
+	return deadLetter, nil
+}
+
+// UpdateDeadLetter updates a dead letter after a failed redelivery.
+func (r *notificationRepo) UpdateDeadLetter(ctx context.Context, deadLetter *NotificationDeadLetter) error {
+	err := r.db.pool.QueryRow(ctx, NotificationDeadLetterUpdate,
+		deadLetter.ID,
+		deadLetter.DeliveryID,
+		deadLetter.Error,
+		deadLetter.Transient,
+		deadLetter.Attempts,
+		deadLetter.NextAttemptAt,
+	).Scan(&deadLetter.UpdatedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to update notification dead letter: %w", err)
+	}
+	return nil
+}
+
+// DeleteDeadLetter removes a dead letter.
+func (r *notificationRepo) DeleteDeadLetter(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.pool.Exec(ctx, NotificationDeadLetterDelete, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete notification dead letter: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListDueDeadLetters returns up to limit dead letters due for redelivery at
+// now, most overdue first.
+func (r *notificationRepo) ListDueDeadLetters(ctx context.Context, now time.Time, limit int) ([]NotificationDeadLetter, error) {
+	rows, err := r.db.pool.Query(ctx, NotificationDeadLetterListDue, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var deadLetters []NotificationDeadLetter
+	for rows.Next() {
+		deadLetter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification dead letter: %w", err)
+		}
+		deadLetters = append(deadLetters, *deadLetter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification dead letters: %w", err)
+	}
+	return deadLetters, nil
+}
+
+// CountDeadLetters returns the depth of the dead-letter queue.
+func (r *notificationRepo) CountDeadLetters(ctx context.Context) (*NotificationDeadLetterCounts, error) {
+	var counts NotificationDeadLetterCounts
+	if err := r.db.pool.QueryRow(ctx, NotificationDeadLetterCount).Scan(&counts.Pending, &counts.Exhausted); err != nil {
+		return nil, fmt.Errorf("failed to count notification dead letters: %w", err)
+	}
+	return &counts, nil
+}
+
+// scanDeadLetter scans a dead letter row.
+func scanDeadLetter(row pgx.Row) (*NotificationDeadLetter, error) {
+	var d NotificationDeadLetter
+	err := row.Scan(
+		&d.ID,
+		&d.DeliveryID,
+		&d.ChannelID,
+		&d.Error,
+		&d.Transient,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
 // scheduleRepo implements ScheduleRepository.
 type scheduleRepo struct {
 	db *DB
@@ -282,10 +544,15 @@ func NewScheduleRepo(db *DB) ScheduleRepository {
 
 // Create creates a new scheduled run.
 func (r *scheduleRepo) Create(ctx context.Context, schedule *ScheduledRun) error {
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+
 	err := r.db.pool.QueryRow(ctx, ScheduleInsert,
 		schedule.ServiceID,
 		schedule.Name,
 		schedule.CronExpression,
+		schedule.Timezone,
 		schedule.GitRef,
 		schedule.TestFilter,
 		schedule.Enabled,
@@ -301,11 +568,12 @@ func (r *scheduleRepo) Create(ctx context.Context, schedule *ScheduledRun) error
 // Get retrieves a scheduled run by ID.
 func (r *scheduleRepo) Get(ctx context.Context, id uuid.UUID) (*ScheduledRun, error) {
 	schedule := &ScheduledRun{}
-	err := r.db.pool.QueryRow(ctx, ScheduleGetByID, id).Scan(
+	err := r.db.reader(ctx).QueryRow(ctx, ScheduleGetByID, id).Scan(
 		&schedule.ID,
 		&schedule.ServiceID,
 		&schedule.Name,
 		&schedule.CronExpression,
+		&schedule.Timezone,
 		&schedule.GitRef,
 		&schedule.TestFilter,
 		&schedule.Enabled,
@@ -327,8 +595,8 @@ func (r *scheduleRepo) Get(ctx context.Context, id uuid.UUID) (*ScheduledRun, er
 func (r *scheduleRepo) Update(ctx context.Context, schedule *ScheduledRun) error {
 	const query = `
 		UPDATE scheduled_runs
-		SET name = $2, cron_expression = $3, git_ref = $4, test_filter = $5,
-			enabled = $6, next_run_at = $7
+		SET name = $2, cron_expression = $3, timezone = $4, git_ref = $5,
+			test_filter = $6, enabled = $7, next_run_at = $8
 		WHERE id = $1
 		RETURNING updated_at`
 
@@ -336,6 +604,7 @@ func (r *scheduleRepo) Update(ctx context.Context, schedule *ScheduledRun) error
 		schedule.ID,
 		schedule.Name,
 		schedule.CronExpression,
+		schedule.Timezone,
 		schedule.GitRef,
 		schedule.TestFilter,
 		schedule.Enabled,
@@ -366,7 +635,7 @@ func (r *scheduleRepo) Delete(ctx context.Context, id uuid.UUID) error {
 
 // ListByService returns schedules for a service.
 func (r *scheduleRepo) ListByService(ctx context.Context, serviceID uuid.UUID) ([]ScheduledRun, error) {
-	rows, err := r.db.pool.Query(ctx, ScheduleListByService, serviceID)
+	rows, err := r.db.reader(ctx).Query(ctx, ScheduleListByService, serviceID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list schedules by service: %w", err)
 	}
@@ -408,6 +677,7 @@ func scanScheduledRuns(rows pgx.Rows) ([]ScheduledRun, error) {
 			&schedule.ServiceID,
 			&schedule.Name,
 			&schedule.CronExpression,
+			&schedule.Timezone,
 			&schedule.GitRef,
 			&schedule.TestFilter,
 			&schedule.Enabled,
@@ -462,7 +732,7 @@ func (r *analyticsRepo) UpsertDailyStats(ctx context.Context, stats *DailyStats)
 
 // GetDailyStats retrieves daily stats for a service within a date range.
 func (r *analyticsRepo) GetDailyStats(ctx context.Context, serviceID uuid.UUID, start, end time.Time) ([]DailyStats, error) {
-	rows, err := r.db.pool.Query(ctx, DailyStatsGetByServiceAndRange, serviceID, start, end)
+	rows, err := r.db.reader(ctx).Query(ctx, DailyStatsGetByServiceAndRange, serviceID, start, end)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get daily stats: %w", err)
 	}
@@ -509,7 +779,7 @@ func (r *analyticsRepo) UpsertFlakyTest(ctx context.Context, serviceID uuid.UUID
 
 // ListFlakyTests returns flaky tests for a service.
 func (r *analyticsRepo) ListFlakyTests(ctx context.Context, serviceID uuid.UUID, page Pagination) ([]FlakyTest, error) {
-	rows, err := r.db.pool.Query(ctx, FlakyTestListByService, serviceID, page.Limit, page.Offset)
+	rows, err := r.db.reader(ctx).Query(ctx, FlakyTestListByService, serviceID, page.Limit, page.Offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list flaky tests: %w", err)
 	}
@@ -598,7 +868,7 @@ func (r *analyticsRepo) RecordTestHistory(ctx context.Context, history *TestHist
 
 // GetTestHistory retrieves recent history for a test.
 func (r *analyticsRepo) GetTestHistory(ctx context.Context, serviceID uuid.UUID, testName string, limit int) ([]TestHistory, error) {
-	rows, err := r.db.pool.Query(ctx, TestHistoryGetRecent, serviceID, testName, limit)
+	rows, err := r.db.reader(ctx).Query(ctx, TestHistoryGetRecent, serviceID, testName, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get test history: %w", err)
 	}
@@ -631,7 +901,7 @@ func (r *analyticsRepo) GetTestHistory(ctx context.Context, serviceID uuid.UUID,
 
 // GetServiceHealthSummary retrieves health summaries for all services.
 func (r *analyticsRepo) GetServiceHealthSummary(ctx context.Context) ([]ServiceHealthSummary, error) {
-	rows, err := r.db.pool.Query(ctx, ServiceHealthSummaryGet)
+	rows, err := r.db.reader(ctx).Query(ctx, ServiceHealthSummaryGet)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get service health summary: %w", err)
 	}
@@ -643,7 +913,7 @@ func (r *analyticsRepo) GetServiceHealthSummary(ctx context.Context) ([]ServiceH
 // GetServiceHealthSummaryByID retrieves health summary for a specific service.
 func (r *analyticsRepo) GetServiceHealthSummaryByID(ctx context.Context, serviceID uuid.UUID) (*ServiceHealthSummary, error) {
 	summary := &ServiceHealthSummary{}
-	err := r.db.pool.QueryRow(ctx, ServiceHealthSummaryGetByID, serviceID).Scan(
+	err := r.db.reader(ctx).QueryRow(ctx, ServiceHealthSummaryGetByID, serviceID).Scan(
 		&summary.ServiceID,
 		&summary.ServiceName,
 		&summary.DisplayName,
@@ -664,6 +934,52 @@ func (r *analyticsRepo) GetServiceHealthSummaryByID(ctx context.Context, service
 	return summary, nil
 }
 
+// GetServiceReports computes the reports of the services matching the filter
+// from the report views, ordered by service name.
+func (r *analyticsRepo) GetServiceReports(ctx context.Context, filter ServiceReportFilter) ([]ServiceReport, error) {
+	rows, err := r.db.reader(ctx).Query(ctx, ServiceReportGet, filter.Since, filter.ServiceID, filter.GitRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service reports: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []ServiceReport
+	for rows.Next() {
+		var report ServiceReport
+		err := rows.Scan(
+			&report.ServiceID,
+			&report.ServiceName,
+			&report.TotalRuns,
+			&report.PassedRuns,
+			&report.FailedRuns,
+			&report.AvgDurationMs,
+			&report.P95DurationMs,
+			&report.Recoveries,
+			&report.MTTRMs,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan service report: %w", err)
+		}
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating service reports: %w", err)
+	}
+
+	return reports, nil
+}
+
+// RefreshServiceReports recomputes the report views from the runs.
+func (r *analyticsRepo) RefreshServiceReports(ctx context.Context) error {
+	for _, query := range []string{ServiceRunDailyStatsRefresh, ServiceRecoveriesRefresh} {
+		if _, err := r.db.pool.Exec(ctx, query); err != nil {
+			return fmt.Errorf("failed to refresh service reports: %w", err)
+		}
+	}
+	return nil
+}
+
 // scanServiceHealthSummaries scans rows into a slice of service health summaries.
 func scanServiceHealthSummaries(rows pgx.Rows) ([]ServiceHealthSummary, error) {
 	var summaries []ServiceHealthSummary
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// MaxTestParameters bounds the parameters a test declares.
+const MaxTestParameters = 32
+
+// TestParameterType is the type of the values of a test parameter.
+type TestParameterType string
+
+const (
+	// TestParameterString accepts any value. Empty types are strings.
+	TestParameterString TestParameterType = "string"
+	// TestParameterInteger accepts decimal integers.
+	TestParameterInteger TestParameterType = "integer"
+	// TestParameterBoolean accepts true and false.
+	TestParameterBoolean TestParameterType = "boolean"
+)
+
+// parameterName matches the names of parameters, which are passed to tests
+// as environment variables.
+var parameterName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// TestParameter declares a parameter runs of a test can set, such as
+// TARGET_ENV=staging. Its value is passed to the test as the environment
+// variable Name and replaces ${params.Name} in the test's command.
+type TestParameter struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Type        TestParameterType `json:"type,omitempty"`
+	// Required parameters must be set when runs are created through the
+	// API, unless they have a default.
+	Required bool `json:"required,omitempty"`
+	// Default is the value of the parameter in runs that do not set it.
+	// Nil leaves the parameter unset.
+	Default *string `json:"default,omitempty"`
+	// Choices lists the values the parameter accepts. Empty accepts any
+	// value of its type.
+	Choices []string `json:"choices,omitempty"`
+}
+
+// TestParameters is the parameter schema of a test.
+type TestParameters []TestParameter
+
+// Validate checks that parameters have distinct names that are valid
+// environment variable names, known types, choices of their type, and
+// defaults they accept.
+func (p TestParameters) Validate() error {
+	if len(p) > MaxTestParameters {
+		return fmt.Errorf("tests declare at most %d parameters", MaxTestParameters)
+	}
+
+	seen := make(map[string]bool, len(p))
+	for _, param := range p {
+		if !parameterName.MatchString(param.Name) {
+			return fmt.Errorf("invalid parameter name %q", param.Name)
+		}
+		if seen[param.Name] {
+			return fmt.Errorf("parameter %s is declared twice", param.Name)
+		}
+		seen[param.Name] = true
+
+		switch param.Type {
+		case "", TestParameterString, TestParameterInteger, TestParameterBoolean:
+		default:
+			return fmt.Errorf("parameter %s has unknown type %q", param.Name, param.Type)
+		}
+		for _, choice := range param.Choices {
+			if err := param.checkType(choice); err != nil {
+				return err
+			}
+		}
+		if param.Default != nil {
+			if err := param.Check(*param.Default); err != nil {
+				return fmt.Errorf("default of %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Check checks that a value is of the parameter's type and one of its
+// choices.
+func (p *TestParameter) Check(value string) error {
+	if err := p.checkType(value); err != nil {
+		return err
+	}
+	if len(p.Choices) > 0 && !slices.Contains(p.Choices, value) {
+		return fmt.Errorf("parameter %s must be one of %v, got %q", p.Name, p.Choices, value)
+	}
+	return nil
+}
+
+func (p *TestParameter) checkType(value string) error {
+	switch p.Type {
+	case TestParameterInteger:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("parameter %s must be an integer, got %q", p.Name, value)
+		}
+	case TestParameterBoolean:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("parameter %s must be true or false, got %q", p.Name, value)
+		}
+	}
+	return nil
+}
+
+// Resolve returns the values of the parameters for the values a run set,
+// with defaults for the parameters it did not set. Values of parameters the
+// schema does not declare are ignored. Missing required parameters and
+// invalid values are errors.
+func (p TestParameters) Resolve(values map[string]string) (map[string]string, error) {
+	if len(p) == 0 {
+		return nil, nil
+	}
+
+	resolved := make(map[string]string, len(p))
+	for _, param := range p {
+		value, ok := values[param.Name]
+		switch {
+		case ok:
+			if err := param.Check(value); err != nil {
+				return nil, err
+			}
+		case param.Default != nil:
+			value = *param.Default
+		case param.Required:
+			return nil, fmt.Errorf("parameter %s is required", param.Name)
+		default:
+			continue
+		}
+		resolved[param.Name] = value
+	}
+	return resolved, nil
+}
+
+// Declares reports whether the schema declares a parameter.
+func (p TestParameters) Declares(name string) bool {
+	return slices.ContainsFunc(p, func(param TestParameter) bool { return param.Name == name })
+}
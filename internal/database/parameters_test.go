@@ -0,0 +1,58 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestParameters_Validate(t *testing.T) {
+	staging := "staging"
+	many := "many"
+
+	params := TestParameters{
+		{Name: "TARGET_ENV", Default: &staging, Choices: []string{"staging", "production"}},
+		{Name: "WORKERS", Type: TestParameterInteger},
+		{Name: "VERBOSE", Type: TestParameterBoolean},
+	}
+	require.NoError(t, params.Validate())
+	assert.NoError(t, TestParameters(nil).Validate())
+
+	assert.ErrorContains(t, TestParameters{{Name: "A=B"}}.Validate(), "invalid parameter name")
+	assert.ErrorContains(t, TestParameters{{Name: "A"}, {Name: "A"}}.Validate(), "declared twice")
+	assert.ErrorContains(t, TestParameters{{Name: "A", Type: "float"}}.Validate(), "unknown type")
+	assert.ErrorContains(t, TestParameters{{Name: "A", Type: TestParameterInteger, Choices: []string{"x"}}}.Validate(), "must be an integer")
+	assert.ErrorContains(t, TestParameters{{Name: "A", Type: TestParameterInteger, Default: &many}}.Validate(), "default of parameter A")
+	assert.ErrorContains(t, make(TestParameters, MaxTestParameters+1).Validate(), "at most")
+}
+
+func TestTestParameters_Resolve(t *testing.T) {
+	staging := "staging"
+	params := TestParameters{
+		{Name: "TARGET_ENV", Default: &staging, Choices: []string{"staging", "production"}},
+		{Name: "WORKERS", Type: TestParameterInteger},
+		{Name: "TOKEN", Required: true},
+	}
+
+	resolved, err := params.Resolve(map[string]string{"TOKEN": "t", "OTHER": "x"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"TARGET_ENV": "staging", "TOKEN": "t"}, resolved)
+
+	resolved, err = params.Resolve(map[string]string{"TOKEN": "t", "TARGET_ENV": "production", "WORKERS": "4"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"TARGET_ENV": "production", "TOKEN": "t", "WORKERS": "4"}, resolved)
+
+	_, err = params.Resolve(nil)
+	assert.ErrorContains(t, err, "parameter TOKEN is required")
+	_, err = params.Resolve(map[string]string{"TOKEN": "t", "TARGET_ENV": "dev"})
+	assert.ErrorContains(t, err, "must be one of")
+	_, err = params.Resolve(map[string]string{"TOKEN": "t", "WORKERS": "four"})
+	assert.ErrorContains(t, err, "must be an integer")
+
+	resolved, err = TestParameters(nil).Resolve(map[string]string{"A": "b"})
+	require.NoError(t, err)
+	assert.Nil(t, resolved)
+	assert.True(t, params.Declares("WORKERS"))
+	assert.False(t, params.Declares("OTHER"))
+}
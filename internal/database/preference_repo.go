@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// preferenceRepo implements PreferenceRepository.
+type preferenceRepo struct {
+	db *DB
+}
+
+// NewPreferenceRepo creates a new preference repository.
+func NewPreferenceRepo(db *DB) PreferenceRepository {
+	return &preferenceRepo{db: db}
+}
+
+// PinService pins a service for a user.
+func (r *preferenceRepo) PinService(ctx context.Context, userID string, serviceID uuid.UUID) error {
+	if _, err := r.db.pool.Exec(ctx, PinnedServiceInsert, userID, serviceID); err != nil {
+		return fmt.Errorf("failed to pin service: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// UnpinService unpins a service for a user.
+func (r *preferenceRepo) UnpinService(ctx context.Context, userID string, serviceID uuid.UUID) error {
+	result, err := r.db.pool.Exec(ctx, PinnedServiceDelete, userID, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to unpin service: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListPinnedServices lists the services a user pinned.
+func (r *preferenceRepo) ListPinnedServices(ctx context.Context, userID string) ([]Service, error) {
+	rows, err := r.db.reader(ctx).Query(ctx, PinnedServiceList, userID, projectScopeArg(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pinned services: %w", err)
+	}
+	defer rows.Close()
+
+	return scanServices(rows)
+}
+
+// CreateView saves a view.
+func (r *preferenceRepo) CreateView(ctx context.Context, view *SavedView) error {
+	return r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		if view.IsDefault {
+			if _, err := tx.Exec(ctx, SavedViewClearDefault, view.UserID, view.Page, view.ServiceID, uuid.Nil); err != nil {
+				return fmt.Errorf("failed to clear default view: %w", err)
+			}
+		}
+
+		err := tx.QueryRow(ctx, SavedViewInsert,
+			view.UserID,
+			view.Name,
+			view.Page,
+			view.ServiceID,
+			view.Filters,
+			view.IsDefault,
+		).Scan(&view.ID, &view.CreatedAt, &view.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create saved view: %w", WrapDBError(err))
+		}
+		return nil
+	})
+}
+
+// GetView retrieves a view of a user by ID.
+func (r *preferenceRepo) GetView(ctx context.Context, userID string, id uuid.UUID) (*SavedView, error) {
+	view := &SavedView{}
+	err := r.db.reader(ctx).QueryRow(ctx, SavedViewGetByID, id, userID).Scan(
+		&view.ID,
+		&view.UserID,
+		&view.Name,
+		&view.Page,
+		&view.ServiceID,
+		&view.Filters,
+		&view.IsDefault,
+		&view.CreatedAt,
+		&view.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get saved view: %w", err)
+	}
+	return view, nil
+}
+
+// UpdateView updates the name, filters and default flag of a view.
+func (r *preferenceRepo) UpdateView(ctx context.Context, view *SavedView) error {
+	return r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		if view.IsDefault {
+			if _, err := tx.Exec(ctx, SavedViewClearDefault, view.UserID, view.Page, view.ServiceID, view.ID); err != nil {
+				return fmt.Errorf("failed to clear default view: %w", err)
+			}
+		}
+
+		err := tx.QueryRow(ctx, SavedViewUpdate,
+			view.ID,
+			view.UserID,
+			view.Name,
+			view.Filters,
+			view.IsDefault,
+		).Scan(&view.UpdatedAt)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return ErrNotFound
+			}
+			return fmt.Errorf("failed to update saved view: %w", WrapDBError(err))
+		}
+		return nil
+	})
+}
+
+// DeleteView deletes a view of a user.
+func (r *preferenceRepo) DeleteView(ctx context.Context, userID string, id uuid.UUID) error {
+	result, err := r.db.pool.Exec(ctx, SavedViewDelete, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved view: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListViews lists the views of a user matching the filter.
+func (r *preferenceRepo) ListViews(ctx context.Context, userID string, filter SavedViewFilter) ([]SavedView, error) {
+	rows, err := r.db.reader(ctx).Query(ctx, SavedViewList, userID, filter.Page, filter.ServiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved views: %w", err)
+	}
+	defer rows.Close()
+
+	var views []SavedView
+	for rows.Next() {
+		var view SavedView
+		err := rows.Scan(
+			&view.ID,
+			&view.UserID,
+			&view.Name,
+			&view.Page,
+			&view.ServiceID,
+			&view.Filters,
+			&view.IsDefault,
+			&view.CreatedAt,
+			&view.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved view: %w", err)
+		}
+		views = append(views, view)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate saved views: %w", err)
+	}
+	return views, nil
+}
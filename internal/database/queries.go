@@ -9,64 +9,75 @@ const (
 	ServiceInsert = `
 		INSERT INTO services (
 			name, display_name, git_url, git_provider, default_branch,
-			network_zones, owner, contact_slack, contact_email
+			network_zones, owner, contact_slack, contact_email, root_path,
+			sync_interval_seconds, agent_pool, project_id, branch_run_policy
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, COALESCE(NULLIF($14, ''), 'allow')
 		) RETURNING id, created_at, updated_at`
 
-	// ServiceGetByID retrieves a service by ID.
+	// ServiceGetByID retrieves a service by ID within the project scope $2.
 	ServiceGetByID = `
 		SELECT id, name, display_name, git_url, git_provider, default_branch,
-			   network_zones, owner, contact_slack, contact_email, created_at, updated_at
+			   network_zones, owner, contact_slack, contact_email, root_path, sync_interval_seconds, agent_pool, archived_at, created_at, updated_at, project_id, branch_run_policy
 		FROM services
-		WHERE id = $1`
+		WHERE id = $1 AND ($2::uuid[] IS NULL OR project_id = ANY($2))`
 
-	// ServiceGetByName retrieves a service by name.
+	// ServiceGetByName retrieves a service by name within the project scope $2.
 	ServiceGetByName = `
 		SELECT id, name, display_name, git_url, git_provider, default_branch,
-			   network_zones, owner, contact_slack, contact_email, created_at, updated_at
+			   network_zones, owner, contact_slack, contact_email, root_path, sync_interval_seconds, agent_pool, archived_at, created_at, updated_at, project_id, branch_run_policy
 		FROM services
-		WHERE name = $1`
+		WHERE name = $1 AND ($2::uuid[] IS NULL OR project_id = ANY($2))`
 
-	// ServiceUpdate updates an existing service.
+	// ServiceUpdate updates an existing service within the project scope $15.
 	ServiceUpdate = `
 		UPDATE services
 		SET name = $2, display_name = $3, git_url = $4, git_provider = $5,
 			default_branch = $6, network_zones = $7, owner = $8,
-			contact_slack = $9, contact_email = $10
-		WHERE id = $1
+			contact_slack = $9, contact_email = $10, root_path = $11,
+			sync_interval_seconds = $12, agent_pool = $13, archived_at = $14,
+			branch_run_policy = COALESCE(NULLIF($16, ''), 'allow')
+		WHERE id = $1 AND ($15::uuid[] IS NULL OR project_id = ANY($15))
 		RETURNING updated_at`
 
-	// ServiceDelete deletes a service by ID.
-	ServiceDelete = `DELETE FROM services WHERE id = $1`
+	// ServiceDelete deletes a service by ID within the project scope $2.
+	ServiceDelete = `DELETE FROM services WHERE id = $1 AND ($2::uuid[] IS NULL OR project_id = ANY($2))`
 
-	// ServiceList lists services with pagination.
+	// ServiceList lists services within the project scope $3 with pagination,
+	// after the name and ID $4 and $5 if set.
 	ServiceList = `
 		SELECT id, name, display_name, git_url, git_provider, default_branch,
-			   network_zones, owner, contact_slack, contact_email, created_at, updated_at
+			   network_zones, owner, contact_slack, contact_email, root_path, sync_interval_seconds, agent_pool, archived_at, created_at, updated_at, project_id, branch_run_policy
 		FROM services
-		ORDER BY name ASC
+		WHERE ($3::uuid[] IS NULL OR project_id = ANY($3))
+		  AND ($4::text IS NULL OR (name, id) > ($4, $5))
+		ORDER BY name ASC, id ASC
 		LIMIT $1 OFFSET $2`
 
-	// ServiceCount counts total services.
-	ServiceCount = `SELECT COUNT(*) FROM services`
+	// ServiceCount counts the services within the project scope $1.
+	ServiceCount = `SELECT COUNT(*) FROM services WHERE ($1::uuid[] IS NULL OR project_id = ANY($1))`
 
-	// ServiceListByOwner lists services by owner.
+	// ServiceListByOwner lists services by owner within the project scope $4,
+	// after the name and ID $5 and $6 if set.
 	ServiceListByOwner = `
 		SELECT id, name, display_name, git_url, git_provider, default_branch,
-			   network_zones, owner, contact_slack, contact_email, created_at, updated_at
+			   network_zones, owner, contact_slack, contact_email, root_path, sync_interval_seconds, agent_pool, archived_at, created_at, updated_at, project_id, branch_run_policy
 		FROM services
-		WHERE owner = $1
-		ORDER BY name ASC
+		WHERE owner = $1 AND ($4::uuid[] IS NULL OR project_id = ANY($4))
+		  AND ($5::text IS NULL OR (name, id) > ($5, $6))
+		ORDER BY name ASC, id ASC
 		LIMIT $2 OFFSET $3`
 
-	// ServiceSearch searches services by name pattern.
+	// ServiceSearch searches services by name pattern within the project scope
+	// $4, after the name and ID $5 and $6 if set.
 	ServiceSearch = `
 		SELECT id, name, display_name, git_url, git_provider, default_branch,
-			   network_zones, owner, contact_slack, contact_email, created_at, updated_at
+			   network_zones, owner, contact_slack, contact_email, root_path, sync_interval_seconds, agent_pool, archived_at, created_at, updated_at, project_id, branch_run_policy
 		FROM services
-		WHERE name ILIKE $1 OR display_name ILIKE $1
-		ORDER BY name ASC
+		WHERE (name ILIKE $1 OR display_name ILIKE $1)
+		  AND ($4::uuid[] IS NULL OR project_id = ANY($4))
+		  AND ($5::text IS NULL OR (name, id) > ($5, $6))
+		ORDER BY name ASC, id ASC
 		LIMIT $2 OFFSET $3`
 )
 
@@ -77,16 +88,23 @@ const (
 		INSERT INTO test_definitions (
 			service_id, name, description, execution_type, command, args,
 			timeout_seconds, result_file, result_format, artifact_patterns,
-			tags, depends_on, retries, allow_failure
+			tags, depends_on, retries, allow_failure, cache_key, cache_paths,
+			environment, secrets, label_selector, container_image, architectures,
+			artifact_max_bytes, artifact_max_files, owner, matrix, requires_approval,
+			capture_patterns, parameters
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
+			$17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28
 		) RETURNING id, created_at, updated_at`
 
 	// TestDefGetByID retrieves a test definition by ID.
 	TestDefGetByID = `
 		SELECT id, service_id, name, description, execution_type, command, args,
 			   timeout_seconds, result_file, result_format, artifact_patterns,
-			   tags, depends_on, retries, allow_failure, created_at, updated_at
+			   tags, depends_on, retries, allow_failure, cache_key, cache_paths,
+			   environment, secrets, label_selector, container_image, architectures,
+			   artifact_max_bytes, artifact_max_files, owner, matrix, requires_approval,
+			   capture_patterns, parameters, created_at, updated_at
 		FROM test_definitions
 		WHERE id = $1`
 
@@ -94,7 +112,10 @@ const (
 	TestDefListByService = `
 		SELECT id, service_id, name, description, execution_type, command, args,
 			   timeout_seconds, result_file, result_format, artifact_patterns,
-			   tags, depends_on, retries, allow_failure, created_at, updated_at
+			   tags, depends_on, retries, allow_failure, cache_key, cache_paths,
+			   environment, secrets, label_selector, container_image, architectures,
+			   artifact_max_bytes, artifact_max_files, owner, matrix, requires_approval,
+			   capture_patterns, parameters, created_at, updated_at
 		FROM test_definitions
 		WHERE service_id = $1
 		ORDER BY name ASC
@@ -104,7 +125,10 @@ const (
 	TestDefListByTags = `
 		SELECT id, service_id, name, description, execution_type, command, args,
 			   timeout_seconds, result_file, result_format, artifact_patterns,
-			   tags, depends_on, retries, allow_failure, created_at, updated_at
+			   tags, depends_on, retries, allow_failure, cache_key, cache_paths,
+			   environment, secrets, label_selector, container_image, architectures,
+			   artifact_max_bytes, artifact_max_files, owner, matrix, requires_approval,
+			   capture_patterns, parameters, created_at, updated_at
 		FROM test_definitions
 		WHERE service_id = $1 AND tags && $2
 		ORDER BY name ASC
@@ -116,7 +140,12 @@ const (
 		SET name = $2, description = $3, execution_type = $4, command = $5,
 			args = $6, timeout_seconds = $7, result_file = $8, result_format = $9,
 			artifact_patterns = $10, tags = $11, depends_on = $12, retries = $13,
-			allow_failure = $14
+			allow_failure = $14, cache_key = $15, cache_paths = $16,
+			environment = $17, secrets = $18, label_selector = $19,
+			container_image = $20, architectures = $21,
+			artifact_max_bytes = $22, artifact_max_files = $23, owner = $24,
+			matrix = $25, requires_approval = $26, capture_patterns = $27,
+			parameters = $28
 		WHERE id = $1
 		RETURNING updated_at`
 
@@ -124,36 +153,377 @@ const (
 	TestDefDelete = `DELETE FROM test_definitions WHERE id = $1`
 )
 
+// Deploy key queries
+const (
+	// DeployKeyUpsert creates or replaces the deploy key for a service.
+	DeployKeyUpsert = `
+		INSERT INTO service_deploy_keys (
+			service_id, encrypted_private_key, public_key, fingerprint, known_hosts
+		) VALUES (
+			$1, $2, $3, $4, $5
+		)
+		ON CONFLICT (service_id) DO UPDATE SET
+			encrypted_private_key = EXCLUDED.encrypted_private_key,
+			public_key = EXCLUDED.public_key,
+			fingerprint = EXCLUDED.fingerprint,
+			known_hosts = EXCLUDED.known_hosts
+		RETURNING created_at, updated_at`
+
+	// DeployKeyGetByService retrieves the deploy key for a service.
+	DeployKeyGetByService = `
+		SELECT service_id, encrypted_private_key, public_key, fingerprint,
+			   known_hosts, created_at, updated_at
+		FROM service_deploy_keys
+		WHERE service_id = $1`
+
+	// DeployKeyDelete deletes the deploy key for a service.
+	DeployKeyDelete = `DELETE FROM service_deploy_keys WHERE service_id = $1`
+)
+
+// Git credential queries
+const (
+	// GitCredentialUpsert creates or replaces the git credential for a service.
+	GitCredentialUpsert = `
+		INSERT INTO service_git_credentials (
+			service_id, provider, base_url, encrypted_token
+		) VALUES (
+			$1, $2, $3, $4
+		)
+		ON CONFLICT (service_id) DO UPDATE SET
+			provider = EXCLUDED.provider,
+			base_url = EXCLUDED.base_url,
+			encrypted_token = EXCLUDED.encrypted_token
+		RETURNING created_at, updated_at`
+
+	// GitCredentialGetByService retrieves the git credential for a service.
+	GitCredentialGetByService = `
+		SELECT service_id, provider, base_url, encrypted_token, created_at, updated_at
+		FROM service_git_credentials
+		WHERE service_id = $1`
+
+	// GitCredentialDelete deletes the git credential for a service.
+	GitCredentialDelete = `DELETE FROM service_git_credentials WHERE service_id = $1`
+)
+
+// Service environment queries
+const (
+	// ServiceEnvironmentUpsert creates or replaces the environment set for a service.
+	ServiceEnvironmentUpsert = `
+		INSERT INTO service_environments (
+			service_id, environment, secrets
+		) VALUES (
+			$1, $2, $3
+		)
+		ON CONFLICT (service_id) DO UPDATE SET
+			environment = EXCLUDED.environment,
+			secrets = EXCLUDED.secrets
+		RETURNING created_at, updated_at`
+
+	// ServiceEnvironmentGetByService retrieves the environment set for a service.
+	ServiceEnvironmentGetByService = `
+		SELECT service_id, environment, secrets, created_at, updated_at
+		FROM service_environments
+		WHERE service_id = $1`
+
+	// ServiceEnvironmentDelete deletes the environment set for a service.
+	ServiceEnvironmentDelete = `DELETE FROM service_environments WHERE service_id = $1`
+)
+
+// Concurrency group queries
+const (
+	// ServiceConcurrencyUpsert creates or replaces the concurrency group of a
+	// service.
+	ServiceConcurrencyUpsert = `
+		INSERT INTO service_concurrency (
+			service_id, group_key, cancel_in_progress
+		) VALUES (
+			$1, $2, $3
+		)
+		ON CONFLICT (service_id) DO UPDATE SET
+			group_key = EXCLUDED.group_key,
+			cancel_in_progress = EXCLUDED.cancel_in_progress
+		RETURNING created_at, updated_at`
+
+	// ServiceConcurrencyGetByService retrieves the concurrency group of a
+	// service.
+	ServiceConcurrencyGetByService = `
+		SELECT service_id, group_key, cancel_in_progress, created_at, updated_at
+		FROM service_concurrency
+		WHERE service_id = $1`
+
+	// ServiceConcurrencyDelete deletes the concurrency group of a service.
+	ServiceConcurrencyDelete = `DELETE FROM service_concurrency WHERE service_id = $1`
+
+	// ConcurrencyGroupClaim makes run $2 the holder of group $1 unless another
+	// pending or running run holds it. It returns no row when the group is
+	// held.
+	ConcurrencyGroupClaim = `
+		INSERT INTO concurrency_groups (group_key, run_id)
+		VALUES ($1, $2)
+		ON CONFLICT (group_key) DO UPDATE SET run_id = EXCLUDED.run_id, acquired_at = NOW()
+		WHERE concurrency_groups.run_id = EXCLUDED.run_id
+		   OR NOT EXISTS (
+			SELECT 1 FROM test_runs
+			WHERE id = concurrency_groups.run_id AND status IN ('pending', 'running')
+		)
+		RETURNING run_id`
+
+	// ConcurrencyGroupListActive lists the pending and running runs of a
+	// group, oldest first.
+	ConcurrencyGroupListActive = `
+		SELECT id
+		FROM test_runs
+		WHERE concurrency_group = $1 AND status IN ('pending', 'running')
+		ORDER BY created_at ASC, id ASC`
+)
+
+// Service quota queries
+const (
+	// ServiceQuotaUpsert creates or replaces the quota of a service.
+	ServiceQuotaUpsert = `
+		INSERT INTO service_quotas (
+			service_id, max_runs_per_day, max_minutes_per_month
+		) VALUES (
+			$1, $2, $3
+		)
+		ON CONFLICT (service_id) DO UPDATE SET
+			max_runs_per_day = EXCLUDED.max_runs_per_day,
+			max_minutes_per_month = EXCLUDED.max_minutes_per_month
+		RETURNING created_at, updated_at`
+
+	// ServiceQuotaGetByService retrieves the quota of a service.
+	ServiceQuotaGetByService = `
+		SELECT service_id, max_runs_per_day, max_minutes_per_month, created_at, updated_at
+		FROM service_quotas
+		WHERE service_id = $1`
+
+	// ServiceQuotaDelete deletes the quota of a service.
+	ServiceQuotaDelete = `DELETE FROM service_quotas WHERE service_id = $1`
+
+	// ServiceQuotaGetUsage counts the runs service $1 created since $2 and sums
+	// the execution seconds of its shards started since $3. Shards still
+	// running count up to now.
+	ServiceQuotaGetUsage = `
+		SELECT
+			(SELECT COUNT(*) FROM test_runs WHERE service_id = $1 AND created_at >= $2),
+			(SELECT COALESCE(SUM(EXTRACT(EPOCH FROM (COALESCE(s.finished_at, NOW()) - s.started_at))), 0)::BIGINT
+			 FROM run_shards s
+			 JOIN test_runs r ON r.id = s.run_id
+			 WHERE r.service_id = $1 AND s.started_at >= $3)`
+)
+
+// Service trigger rule queries
+const (
+	// ServiceTriggerRulesUpsert creates or replaces the trigger rules for a service.
+	ServiceTriggerRulesUpsert = `
+		INSERT INTO service_trigger_rules (
+			service_id, branches, ignore_branches, paths, ignore_paths, tags, skip_markers
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		)
+		ON CONFLICT (service_id) DO UPDATE SET
+			branches = EXCLUDED.branches,
+			ignore_branches = EXCLUDED.ignore_branches,
+			paths = EXCLUDED.paths,
+			ignore_paths = EXCLUDED.ignore_paths,
+			tags = EXCLUDED.tags,
+			skip_markers = EXCLUDED.skip_markers
+		RETURNING created_at, updated_at`
+
+	// ServiceTriggerRulesGetByService retrieves the trigger rules for a service.
+	ServiceTriggerRulesGetByService = `
+		SELECT service_id, branches, ignore_branches, paths, ignore_paths, tags, skip_markers,
+			created_at, updated_at
+		FROM service_trigger_rules
+		WHERE service_id = $1`
+
+	// ServiceTriggerRulesDelete deletes the trigger rules for a service.
+	ServiceTriggerRulesDelete = `DELETE FROM service_trigger_rules WHERE service_id = $1`
+)
+
+// Retry policy queries
+const (
+	// RetryPolicyUpsertService creates or replaces the policy of a service.
+	RetryPolicyUpsertService = `
+		INSERT INTO retry_policies (
+			service_id, max_attempts, backoff_seconds, max_backoff_seconds, retry_on
+		) VALUES (
+			$1, $2, $3, $4, $5
+		)
+		ON CONFLICT (service_id) WHERE test_definition_id IS NULL DO UPDATE SET
+			max_attempts = EXCLUDED.max_attempts,
+			backoff_seconds = EXCLUDED.backoff_seconds,
+			max_backoff_seconds = EXCLUDED.max_backoff_seconds,
+			retry_on = EXCLUDED.retry_on
+		RETURNING id, created_at, updated_at`
+
+	// RetryPolicyUpsertTestDefinition creates or replaces the policy of a test definition.
+	RetryPolicyUpsertTestDefinition = `
+		INSERT INTO retry_policies (
+			service_id, test_definition_id, max_attempts, backoff_seconds, max_backoff_seconds, retry_on
+		) VALUES (
+			$1, $2, $3, $4, $5, $6
+		)
+		ON CONFLICT (test_definition_id) WHERE test_definition_id IS NOT NULL DO UPDATE SET
+			max_attempts = EXCLUDED.max_attempts,
+			backoff_seconds = EXCLUDED.backoff_seconds,
+			max_backoff_seconds = EXCLUDED.max_backoff_seconds,
+			retry_on = EXCLUDED.retry_on
+		RETURNING id, created_at, updated_at`
+
+	// RetryPolicyListByService lists the policies of a service, the service
+	// policy first.
+	RetryPolicyListByService = `
+		SELECT id, service_id, test_definition_id, max_attempts, backoff_seconds,
+			max_backoff_seconds, retry_on, created_at, updated_at
+		FROM retry_policies
+		WHERE service_id = $1
+		ORDER BY test_definition_id NULLS FIRST`
+
+	// RetryPolicyDelete deletes the policy of a service or, if $2 is set, of a test definition.
+	RetryPolicyDelete = `
+		DELETE FROM retry_policies
+		WHERE service_id = $1 AND test_definition_id IS NOT DISTINCT FROM $2`
+)
+
+// Service sync queries
+const (
+	// ServiceSyncInsert records a sync of a service's test definitions.
+	ServiceSyncInsert = `
+		INSERT INTO service_syncs (
+			service_id, branch, trigger, status, tests_added, tests_updated,
+			tests_removed, errors, started_at, finished_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		) RETURNING id`
+
+	// serviceSyncSelect selects sync records.
+	serviceSyncSelect = `
+		SELECT id, service_id, branch, trigger, status, tests_added, tests_updated,
+			tests_removed, errors, started_at, finished_at
+		FROM service_syncs`
+
+	// ServiceSyncLatest retrieves the most recent sync of a service.
+	ServiceSyncLatest = serviceSyncSelect + `
+		WHERE service_id = $1
+		ORDER BY started_at DESC
+		LIMIT 1`
+
+	// ServiceSyncListByService lists a service's syncs, most recent first.
+	ServiceSyncListByService = serviceSyncSelect + `
+		WHERE service_id = $1
+		ORDER BY started_at DESC
+		LIMIT $2 OFFSET $3`
+
+	// ServiceSyncCountByService counts a service's syncs.
+	ServiceSyncCountByService = `SELECT COUNT(*) FROM service_syncs WHERE service_id = $1`
+
+	// ServiceSyncListDue lists active services whose periodic sync is due,
+	// never synced services first. $1 is the default interval in seconds for
+	// services without their own.
+	ServiceSyncListDue = `
+		SELECT s.id, s.name, s.display_name, s.git_url, s.git_provider, s.default_branch,
+			   s.network_zones, s.owner, s.contact_slack, s.contact_email, s.root_path,
+			   s.sync_interval_seconds, s.agent_pool, s.archived_at, s.created_at, s.updated_at, s.project_id, s.branch_run_policy
+		FROM services s
+		LEFT JOIN LATERAL (
+			SELECT started_at FROM service_syncs
+			WHERE service_id = s.id
+			ORDER BY started_at DESC
+			LIMIT 1
+		) last ON TRUE
+		WHERE s.archived_at IS NULL
+			AND COALESCE(s.sync_interval_seconds, $1) > 0
+			AND (last.started_at IS NULL
+				OR last.started_at + make_interval(secs => COALESCE(s.sync_interval_seconds, $1)) <= NOW())
+		ORDER BY last.started_at ASC NULLS FIRST
+		LIMIT $2`
+)
+
+// Branch queries
+const (
+	// branchSelect selects a branch with its latest run and recent health.
+	branchSelect = `
+		SELECT b.service_id, b.name, b.last_sha, b.last_run_id, b.protected, b.last_pushed_at,
+			b.created_at, b.updated_at, r.status, r.created_at,
+			COALESCE(h.runs, 0), COALESCE(h.passed, 0)
+		FROM branches b
+		LEFT JOIN test_runs r ON r.id = b.last_run_id
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) AS runs, COUNT(*) FILTER (WHERE recent.status = 'passed') AS passed
+			FROM (
+				SELECT status FROM test_runs
+				WHERE service_id = b.service_id AND git_ref = b.name
+					AND status IN ('passed', 'failed', 'error', 'timeout')
+				ORDER BY created_at DESC
+				LIMIT 20
+			) recent
+		) h ON TRUE`
+
+	// BranchRecordPush creates a branch or moves it to the pushed commit.
+	BranchRecordPush = `
+		INSERT INTO branches (service_id, name, last_sha, last_pushed_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (service_id, name) DO UPDATE SET
+			last_sha = EXCLUDED.last_sha,
+			last_pushed_at = EXCLUDED.last_pushed_at`
+
+	// BranchGet retrieves a branch of a service by name.
+	BranchGet = branchSelect + `
+		WHERE b.service_id = $1 AND b.name = $2`
+
+	// BranchListByService lists a service's branches, protected branches
+	// first and then by latest activity.
+	BranchListByService = branchSelect + `
+		WHERE b.service_id = $1
+		ORDER BY b.protected DESC, GREATEST(r.created_at, b.last_pushed_at, b.created_at) DESC, b.name
+		LIMIT $2 OFFSET $3`
+
+	// BranchCountByService counts a service's branches.
+	BranchCountByService = `SELECT COUNT(*) FROM branches WHERE service_id = $1`
+
+	// BranchSetProtected marks a branch as protected or not.
+	BranchSetProtected = `
+		UPDATE branches SET protected = $3
+		WHERE service_id = $1 AND name = $2`
+
+	// BranchDelete deletes a branch of a service.
+	BranchDelete = `DELETE FROM branches WHERE service_id = $1 AND name = $2`
+)
+
 // Agent queries
 const (
 	// AgentInsert inserts a new agent.
 	AgentInsert = `
 		INSERT INTO agents (
-			name, status, version, network_zones, max_parallel, docker_available
+			name, status, version, network_zones, max_parallel, docker_available,
+			labels, pool, project_id
 		) VALUES (
-			$1, $2, $3, $4, $5, $6
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
 		) RETURNING id, registered_at`
 
-	// AgentGetByID retrieves an agent by ID.
+	// AgentGetByID retrieves an agent by ID within the project scope $2.
 	AgentGetByID = `
 		SELECT id, name, status, version, network_zones, max_parallel,
-			   docker_available, last_heartbeat, registered_at
+			   docker_available, last_heartbeat, registered_at, labels, pool, project_id
 		FROM agents
-		WHERE id = $1`
+		WHERE id = $1 AND ($2::uuid[] IS NULL OR project_id = ANY($2))`
 
-	// AgentGetByName retrieves an agent by name.
+	// AgentGetByName retrieves an agent by name within the project scope $2.
 	AgentGetByName = `
 		SELECT id, name, status, version, network_zones, max_parallel,
-			   docker_available, last_heartbeat, registered_at
+			   docker_available, last_heartbeat, registered_at, labels, pool, project_id
 		FROM agents
-		WHERE name = $1`
+		WHERE name = $1 AND ($2::uuid[] IS NULL OR project_id = ANY($2))`
 
-	// AgentUpdate updates an agent.
+	// AgentUpdate updates an agent within the project scope $11. An agent
+	// re-registering may move to another project.
 	AgentUpdate = `
 		UPDATE agents
 		SET name = $2, status = $3, version = $4, network_zones = $5,
-			max_parallel = $6, docker_available = $7
-		WHERE id = $1`
+			max_parallel = $6, docker_available = $7, labels = $8, pool = $9,
+			project_id = $10
+		WHERE id = $1 AND ($11::uuid[] IS NULL OR project_id = ANY($11))`
 
 	// AgentUpdateStatus updates only the agent's status.
 	AgentUpdateStatus = `
@@ -167,31 +537,36 @@ const (
 		SET last_heartbeat = NOW(), status = $2
 		WHERE id = $1`
 
-	// AgentDelete deletes an agent.
-	AgentDelete = `DELETE FROM agents WHERE id = $1`
+	// AgentDelete deletes an agent within the project scope $2.
+	AgentDelete = `DELETE FROM agents WHERE id = $1 AND ($2::uuid[] IS NULL OR project_id = ANY($2))`
 
-	// AgentList lists all agents with pagination.
+	// AgentList lists the agents within the project scope $3 with pagination,
+	// after the name and ID $4 and $5 if set.
 	AgentList = `
 		SELECT id, name, status, version, network_zones, max_parallel,
-			   docker_available, last_heartbeat, registered_at
+			   docker_available, last_heartbeat, registered_at, labels, pool, project_id
 		FROM agents
-		ORDER BY name ASC
+		WHERE ($3::uuid[] IS NULL OR project_id = ANY($3))
+		  AND ($4::text IS NULL OR (name, id) > ($4, $5))
+		ORDER BY name ASC, id ASC
 		LIMIT $1 OFFSET $2`
 
-	// AgentListByStatus lists agents by status.
+	// AgentListByStatus lists agents by status within the project scope $4,
+	// after the name and ID $5 and $6 if set.
 	AgentListByStatus = `
 		SELECT id, name, status, version, network_zones, max_parallel,
-			   docker_available, last_heartbeat, registered_at
+			   docker_available, last_heartbeat, registered_at, labels, pool, project_id
 		FROM agents
-		WHERE status = $1
-		ORDER BY name ASC
+		WHERE status = $1 AND ($4::uuid[] IS NULL OR project_id = ANY($4))
+		  AND ($5::text IS NULL OR (name, id) > ($5, $6))
+		ORDER BY name ASC, id ASC
 		LIMIT $2 OFFSET $3`
 
 	// AgentGetAvailable retrieves agents that can run tests for a service's network zones.
 	// Agents must be idle or have capacity, and have at least one matching network zone.
 	AgentGetAvailable = `
 		SELECT id, name, status, version, network_zones, max_parallel,
-			   docker_available, last_heartbeat, registered_at
+			   docker_available, last_heartbeat, registered_at, labels, pool, project_id
 		FROM agents
 		WHERE status IN ('idle', 'busy')
 		  AND last_heartbeat > NOW() - INTERVAL '90 seconds'
@@ -201,39 +576,117 @@ const (
 			last_heartbeat DESC
 		LIMIT $2`
 
-	// AgentMarkOffline marks agents as offline if they haven't sent a heartbeat recently.
+	// AgentMarkOffline marks agents as offline whose last heartbeat is older
+	// than $1 seconds.
 	AgentMarkOffline = `
 		UPDATE agents
 		SET status = 'offline'
 		WHERE status != 'offline'
-		  AND (last_heartbeat IS NULL OR last_heartbeat < NOW() - INTERVAL '90 seconds')`
+		  AND (last_heartbeat IS NULL OR last_heartbeat < NOW() - make_interval(secs => $1))
+		RETURNING id, name, status, version, network_zones, max_parallel,
+			docker_available, last_heartbeat, registered_at, labels, pool, project_id`
 
-	// AgentCount counts agents by status.
+	// AgentCount counts the agents within the project scope $1 by status.
 	AgentCount = `
 		SELECT status, COUNT(*) as count
 		FROM agents
+		WHERE ($1::uuid[] IS NULL OR project_id = ANY($1))
 		GROUP BY status`
 )
 
+// Agent pool queries
+const (
+	// AgentPoolInsert inserts a new agent pool.
+	AgentPoolInsert = `
+		INSERT INTO agent_pools (
+			name, description, max_concurrency, max_concurrency_per_service,
+			max_artifact_bytes
+		) VALUES (
+			$1, $2, $3, $4, $5
+		) RETURNING id, created_at, updated_at`
+
+	// AgentPoolGetByName retrieves an agent pool by name.
+	AgentPoolGetByName = `
+		SELECT id, name, description, max_concurrency, max_concurrency_per_service,
+			   max_artifact_bytes, created_at, updated_at
+		FROM agent_pools
+		WHERE name = $1`
+
+	// AgentPoolList lists all agent pools.
+	AgentPoolList = `
+		SELECT id, name, description, max_concurrency, max_concurrency_per_service,
+			   max_artifact_bytes, created_at, updated_at
+		FROM agent_pools
+		ORDER BY name ASC`
+
+	// AgentPoolUpdate updates the description, quotas and artifact limit of
+	// an agent pool.
+	AgentPoolUpdate = `
+		UPDATE agent_pools
+		SET description = $2, max_concurrency = $3, max_concurrency_per_service = $4,
+			max_artifact_bytes = $5
+		WHERE name = $1
+		RETURNING id, created_at, updated_at`
+
+	// AgentPoolDelete deletes an agent pool by name.
+	AgentPoolDelete = `DELETE FROM agent_pools WHERE name = $1`
+
+	// AgentPoolGetStats counts the agents, online agents and running shards of
+	// every agent pool.
+	AgentPoolGetStats = `
+		SELECT p.name,
+			   (SELECT COUNT(*) FROM agents a WHERE a.pool = p.name),
+			   (SELECT COUNT(*) FROM agents a WHERE a.pool = p.name AND a.status != 'offline'),
+			   (SELECT COUNT(*) FROM run_shards s
+				JOIN agents a ON a.id = s.agent_id
+				WHERE a.pool = p.name AND s.status = 'running')
+		FROM agent_pools p
+		ORDER BY p.name ASC`
+
+	// AgentPoolRunningShards counts the running shards on the agents of a
+	// pool per service.
+	AgentPoolRunningShards = `
+		SELECT r.service_id, COUNT(*)
+		FROM run_shards s
+		JOIN agents a ON a.id = s.agent_id
+		JOIN test_runs r ON r.id = s.run_id
+		WHERE a.pool = $1 AND s.status = 'running'
+		GROUP BY r.service_id`
+)
+
 // Test Run queries
 const (
-	// RunInsert inserts a new test run.
+	// RunInsert inserts a new test run and links it to its branch, if the
+	// branch is known.
 	RunInsert = `
-		INSERT INTO test_runs (
-			service_id, status, git_ref, git_sha, trigger_type, triggered_by, priority
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7
-		) RETURNING id, created_at`
+		WITH run AS (
+			INSERT INTO test_runs (
+				service_id, status, git_ref, git_sha, trigger_type, triggered_by, priority,
+				pull_request_number, attempt, retry_of_run_id, not_before, label_selector,
+				trace_parent, concurrency_group, approval_reason, metadata, parameters
+			) VALUES (
+				$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+			) RETURNING id, created_at, service_id, git_ref
+		), branch AS (
+			UPDATE branches b SET last_run_id = run.id
+			FROM run
+			WHERE b.service_id = run.service_id AND b.name = run.git_ref
+		)
+		SELECT id, created_at FROM run`
 
-	// RunGetByID retrieves a test run by ID.
+	// RunGetByID retrieves a test run by ID within the project scope $2.
 	RunGetByID = `
 		SELECT id, service_id, agent_id, status, git_ref, git_sha, trigger_type,
 			   triggered_by, priority, created_at, started_at, finished_at,
 			   total_tests, passed_tests, failed_tests, skipped_tests,
 			   shard_count, shards_completed, shards_failed, max_parallel_tests,
-			   duration_ms, error_message
+			   duration_ms, error_message, pull_request_number,
+			   results_dropped, artifacts_dropped, attempt, retry_of_run_id, not_before,
+			   label_selector, agent_name, agent_network_zones, trace_parent, concurrency_group,
+			   approval_reason, approved_by, approved_at, has_media_artifacts, metadata,
+			   parameters
 		FROM test_runs
-		WHERE id = $1`
+		WHERE id = $1 AND ($2::uuid[] IS NULL OR service_id IN (SELECT id FROM services WHERE project_id = ANY($2)))`
 
 	// RunUpdate updates a test run.
 	RunUpdate = `
@@ -252,16 +705,72 @@ const (
 			duration_ms = $8, error_message = $9
 		WHERE id = $1`
 
+	// RunReserveResults accepts up to $2 results for a run while keeping the
+	// run at or below $3 stored results; the remainder is counted as dropped.
+	RunReserveResults = `
+		UPDATE test_runs t
+		SET results_ingested = t.results_ingested + q.accepted,
+			results_dropped = t.results_dropped + $2 - q.accepted
+		FROM (
+			SELECT id, LEAST($2, GREATEST($3 - results_ingested, 0)) AS accepted
+			FROM test_runs
+			WHERE id = $1
+			FOR UPDATE
+		) q
+		WHERE t.id = q.id
+		RETURNING q.accepted, t.results_dropped`
+
+	// RunReserveArtifacts accepts up to $2 artifacts for a run while keeping
+	// the run at or below $3 stored artifacts; the remainder is counted as dropped.
+	RunReserveArtifacts = `
+		UPDATE test_runs t
+		SET artifacts_ingested = t.artifacts_ingested + q.accepted,
+			artifacts_dropped = t.artifacts_dropped + $2 - q.accepted
+		FROM (
+			SELECT id, LEAST($2, GREATEST($3 - artifacts_ingested, 0)) AS accepted
+			FROM test_runs
+			WHERE id = $1
+			FOR UPDATE
+		) q
+		WHERE t.id = q.id
+		RETURNING q.accepted, t.artifacts_dropped`
+
 	// RunUpdateStatus updates only the run's status.
 	RunUpdateStatus = `
 		UPDATE test_runs
 		SET status = $2
 		WHERE id = $1`
 
+	// RunApprove releases a run waiting for approval to the scheduler.
+	RunApprove = `
+		UPDATE test_runs
+		SET status = 'pending', approved_by = $2, approved_at = NOW()
+		WHERE id = $1 AND status = 'waiting_approval'`
+
+	// RunAnnotate merges annotations and links into the metadata of a run.
+	// Null values remove their keys.
+	RunAnnotate = `
+		UPDATE test_runs
+		SET metadata = jsonb_strip_nulls(jsonb_build_object(
+			'annotations', COALESCE(metadata->'annotations', '{}') || $2::jsonb,
+			'links', COALESCE(metadata->'links', '{}') || $3::jsonb))
+		WHERE id = $1
+		RETURNING metadata`
+
+	// RunRetarget moves a run to another commit while no shard of it has
+	// been scheduled.
+	RunRetarget = `
+		UPDATE test_runs
+		SET git_sha = $2
+		WHERE id = $1 AND status IN ('pending', 'waiting_approval')
+		  AND NOT EXISTS (SELECT 1 FROM run_shards WHERE run_id = $1)`
+
 	// RunStart marks a run as started.
 	RunStart = `
 		UPDATE test_runs
-		SET status = 'running', agent_id = $2, started_at = NOW()
+		SET status = 'running', agent_id = $2, started_at = NOW(),
+			agent_name = (SELECT name FROM agents WHERE id = $2),
+			agent_network_zones = (SELECT network_zones FROM agents WHERE id = $2)
 		WHERE id = $1`
 
 	// RunFinish marks a run as finished.
@@ -272,90 +781,217 @@ const (
 			duration_ms = $7, error_message = $8
 		WHERE id = $1`
 
-	// RunList lists test runs with pagination.
+	// RunFinishActive marks a run as finished unless it has finished.
+	RunFinishActive = `
+		UPDATE test_runs
+		SET status = $2, finished_at = NOW(),
+			total_tests = $3, passed_tests = $4, failed_tests = $5, skipped_tests = $6,
+			duration_ms = $7, error_message = $8
+		WHERE id = $1 AND status IN ('pending', 'running')`
+
+	// RunList lists the test runs within the project scope $3 with pagination,
+	// after the creation time and ID $4 and $5 if set.
 	RunList = `
 		SELECT id, service_id, agent_id, status, git_ref, git_sha, trigger_type,
 			   triggered_by, priority, created_at, started_at, finished_at,
 			   total_tests, passed_tests, failed_tests, skipped_tests,
 			   shard_count, shards_completed, shards_failed, max_parallel_tests,
-			   duration_ms, error_message
+			   duration_ms, error_message, pull_request_number,
+			   results_dropped, artifacts_dropped, attempt, retry_of_run_id, not_before,
+			   label_selector, agent_name, agent_network_zones, trace_parent, concurrency_group,
+			   approval_reason, approved_by, approved_at, has_media_artifacts, metadata,
+			   parameters
 		FROM test_runs
-		ORDER BY created_at DESC
+		WHERE ($3::uuid[] IS NULL OR service_id IN (SELECT id FROM services WHERE project_id = ANY($3)))
+		  AND ($4::timestamptz IS NULL OR (created_at, id) < ($4, $5))
+		ORDER BY created_at DESC, id DESC
 		LIMIT $1 OFFSET $2`
 
-	// RunListByService lists test runs for a service.
+	// RunListByService lists test runs for a service within the project scope
+	// $4, after the creation time and ID $5 and $6 if set.
 	RunListByService = `
 		SELECT id, service_id, agent_id, status, git_ref, git_sha, trigger_type,
 			   triggered_by, priority, created_at, started_at, finished_at,
 			   total_tests, passed_tests, failed_tests, skipped_tests,
 			   shard_count, shards_completed, shards_failed, max_parallel_tests,
-			   duration_ms, error_message
+			   duration_ms, error_message, pull_request_number,
+			   results_dropped, artifacts_dropped, attempt, retry_of_run_id, not_before,
+			   label_selector, agent_name, agent_network_zones, trace_parent, concurrency_group,
+			   approval_reason, approved_by, approved_at, has_media_artifacts, metadata,
+			   parameters
 		FROM test_runs
-		WHERE service_id = $1
-		ORDER BY created_at DESC
+		WHERE service_id = $1 AND ($4::uuid[] IS NULL OR service_id IN (SELECT id FROM services WHERE project_id = ANY($4)))
+		  AND ($5::timestamptz IS NULL OR (created_at, id) < ($5, $6))
+		ORDER BY created_at DESC, id DESC
 		LIMIT $2 OFFSET $3`
 
-	// RunListByStatus lists test runs by status.
+	// RunListByStatus lists test runs by status within the project scope $4,
+	// after the creation time, priority and ID $5, $6 and $7 if set.
 	RunListByStatus = `
 		SELECT id, service_id, agent_id, status, git_ref, git_sha, trigger_type,
 			   triggered_by, priority, created_at, started_at, finished_at,
 			   total_tests, passed_tests, failed_tests, skipped_tests,
 			   shard_count, shards_completed, shards_failed, max_parallel_tests,
-			   duration_ms, error_message
+			   duration_ms, error_message, pull_request_number,
+			   results_dropped, artifacts_dropped, attempt, retry_of_run_id, not_before,
+			   label_selector, agent_name, agent_network_zones, trace_parent, concurrency_group,
+			   approval_reason, approved_by, approved_at, has_media_artifacts, metadata,
+			   parameters
 		FROM test_runs
-		WHERE status = $1
-		ORDER BY priority DESC, created_at ASC
+		WHERE status = $1 AND ($4::uuid[] IS NULL OR service_id IN (SELECT id FROM services WHERE project_id = ANY($4)))
+		  AND ($5::timestamptz IS NULL OR priority < $6 OR (priority = $6 AND (created_at, id) > ($5, $7)))
+		ORDER BY priority DESC, created_at ASC, id ASC
 		LIMIT $2 OFFSET $3`
 
-	// RunGetPending retrieves pending runs ordered by priority.
+	// RunGetPending retrieves pending runs within the project scope $2 ordered
+	// by priority.
 	RunGetPending = `
 		SELECT id, service_id, agent_id, status, git_ref, git_sha, trigger_type,
 			   triggered_by, priority, created_at, started_at, finished_at,
 			   total_tests, passed_tests, failed_tests, skipped_tests,
 			   shard_count, shards_completed, shards_failed, max_parallel_tests,
-			   duration_ms, error_message
+			   duration_ms, error_message, pull_request_number,
+			   results_dropped, artifacts_dropped, attempt, retry_of_run_id, not_before,
+			   label_selector, agent_name, agent_network_zones, trace_parent, concurrency_group,
+			   approval_reason, approved_by, approved_at, has_media_artifacts, metadata,
+			   parameters
 		FROM test_runs
-		WHERE status = 'pending'
+		WHERE status = 'pending' AND (not_before IS NULL OR not_before <= NOW())
+		  AND ($2::uuid[] IS NULL OR service_id IN (SELECT id FROM services WHERE project_id = ANY($2)))
 		ORDER BY priority DESC, created_at ASC
 		LIMIT $1`
 
-	// RunGetRunning retrieves currently running tests.
+	// RunGetRunning retrieves currently running tests within the project scope $1.
 	RunGetRunning = `
 		SELECT id, service_id, agent_id, status, git_ref, git_sha, trigger_type,
 			   triggered_by, priority, created_at, started_at, finished_at,
 			   total_tests, passed_tests, failed_tests, skipped_tests,
 			   shard_count, shards_completed, shards_failed, max_parallel_tests,
-			   duration_ms, error_message
+			   duration_ms, error_message, pull_request_number,
+			   results_dropped, artifacts_dropped, attempt, retry_of_run_id, not_before,
+			   label_selector, agent_name, agent_network_zones, trace_parent, concurrency_group,
+			   approval_reason, approved_by, approved_at, has_media_artifacts, metadata,
+			   parameters
 		FROM test_runs
-		WHERE status = 'running'
+		WHERE status = 'running' AND ($1::uuid[] IS NULL OR service_id IN (SELECT id FROM services WHERE project_id = ANY($1)))
 		ORDER BY started_at ASC`
 
-	// RunListByServiceAndStatus lists runs for a service with a specific status.
+	// RunListByServiceAndStatus lists runs for a service with a specific status
+	// within the project scope $5, after the creation time and ID $6 and $7 if
+	// set.
 	RunListByServiceAndStatus = `
 		SELECT id, service_id, agent_id, status, git_ref, git_sha, trigger_type,
 			   triggered_by, priority, created_at, started_at, finished_at,
 			   total_tests, passed_tests, failed_tests, skipped_tests,
 			   shard_count, shards_completed, shards_failed, max_parallel_tests,
-			   duration_ms, error_message
+			   duration_ms, error_message, pull_request_number,
+			   results_dropped, artifacts_dropped, attempt, retry_of_run_id, not_before,
+			   label_selector, agent_name, agent_network_zones, trace_parent, concurrency_group,
+			   approval_reason, approved_by, approved_at, has_media_artifacts, metadata,
+			   parameters
 		FROM test_runs
-		WHERE service_id = $1 AND status = $2
-		ORDER BY created_at DESC
+		WHERE service_id = $1 AND status = $2 AND ($5::uuid[] IS NULL OR service_id IN (SELECT id FROM services WHERE project_id = ANY($5)))
+		  AND ($6::timestamptz IS NULL OR (created_at, id) < ($6, $7))
+		ORDER BY created_at DESC, id DESC
 		LIMIT $3 OFFSET $4`
 
-	// RunListByDateRange lists runs within a date range.
+	// RunListByDateRange lists runs within a date range and the project scope
+	// $5, after the creation time and ID $6 and $7 if set.
 	RunListByDateRange = `
 		SELECT id, service_id, agent_id, status, git_ref, git_sha, trigger_type,
 			   triggered_by, priority, created_at, started_at, finished_at,
 			   total_tests, passed_tests, failed_tests, skipped_tests,
 			   shard_count, shards_completed, shards_failed, max_parallel_tests,
-			   duration_ms, error_message
+			   duration_ms, error_message, pull_request_number,
+			   results_dropped, artifacts_dropped, attempt, retry_of_run_id, not_before,
+			   label_selector, agent_name, agent_network_zones, trace_parent, concurrency_group,
+			   approval_reason, approved_by, approved_at, has_media_artifacts, metadata,
+			   parameters
 		FROM test_runs
-		WHERE created_at >= $1 AND created_at < $2
-		ORDER BY created_at DESC
+		WHERE created_at >= $1 AND created_at < $2 AND ($5::uuid[] IS NULL OR service_id IN (SELECT id FROM services WHERE project_id = ANY($5)))
+		  AND ($6::timestamptz IS NULL OR (created_at, id) < ($6, $7))
+		ORDER BY created_at DESC, id DESC
 		LIMIT $3 OFFSET $4`
 
-	// RunCount counts total runs.
-	RunCount = `SELECT COUNT(*) FROM test_runs`
+	// RunListByAgent lists runs an agent executed, or executed a shard of,
+	// that started within a time range, within the project scope $6, after
+	// the start time and ID $7 and $8 if set.
+	RunListByAgent = `
+		SELECT id, service_id, agent_id, status, git_ref, git_sha, trigger_type,
+			   triggered_by, priority, created_at, started_at, finished_at,
+			   total_tests, passed_tests, failed_tests, skipped_tests,
+			   shard_count, shards_completed, shards_failed, max_parallel_tests,
+			   duration_ms, error_message, pull_request_number,
+			   results_dropped, artifacts_dropped, attempt, retry_of_run_id, not_before,
+			   label_selector, agent_name, agent_network_zones, trace_parent, concurrency_group,
+			   approval_reason, approved_by, approved_at, has_media_artifacts, metadata,
+			   parameters
+		FROM test_runs
+		WHERE (agent_id = $1 OR id IN (SELECT run_id FROM run_shards WHERE agent_id = $1))
+		  AND started_at >= $2 AND started_at < $3
+		  AND ($6::uuid[] IS NULL OR service_id IN (SELECT id FROM services WHERE project_id = ANY($6)))
+		  AND ($7::timestamptz IS NULL OR (started_at, id) < ($7, $8))
+		ORDER BY started_at DESC, id DESC
+		LIMIT $4 OFFSET $5`
+
+	// RunSearch searches runs matching optional filters within the project
+	// scope $7, newest first, after the creation time and ID $10 and $11 if
+	// set. The text $4 matches runs whose error message, or the error message
+	// or stack trace of one of their results, contains it or matches it as a
+	// full-text query. Results are stored after their run was created, so
+	// only those created since $5 are searched.
+	RunSearch = `
+		SELECT id, service_id, agent_id, status, git_ref, git_sha, trigger_type,
+			   triggered_by, priority, created_at, started_at, finished_at,
+			   total_tests, passed_tests, failed_tests, skipped_tests,
+			   shard_count, shards_completed, shards_failed, max_parallel_tests,
+			   duration_ms, error_message, pull_request_number,
+			   results_dropped, artifacts_dropped, attempt, retry_of_run_id, not_before,
+			   label_selector, agent_name, agent_network_zones, trace_parent, concurrency_group,
+			   approval_reason, approved_by, approved_at, has_media_artifacts, metadata,
+			   parameters
+		FROM test_runs
+		WHERE ($1::uuid IS NULL OR service_id = $1)
+		  AND ($2::text[] IS NULL OR status = ANY($2))
+		  AND ($3::text IS NULL OR git_ref = $3 OR git_ref = 'refs/heads/' || $3)
+		  AND ($4::text IS NULL
+		       OR to_tsvector('simple', COALESCE(error_message, '')) @@ websearch_to_tsquery('simple', $4)
+		       OR error_message ILIKE '%' || $4 || '%'
+		       OR id IN (
+		           SELECT run_id FROM test_results
+		           WHERE ($5::timestamptz IS NULL OR created_at >= $5)
+		             AND (to_tsvector('simple', COALESCE(error_message, '') || ' ' || COALESCE(stack_trace, '')) @@ websearch_to_tsquery('simple', $4)
+		                  OR error_message ILIKE '%' || $4 || '%'
+		                  OR stack_trace ILIKE '%' || $4 || '%')))
+		  AND ($5::timestamptz IS NULL OR created_at >= $5)
+		  AND ($6::timestamptz IS NULL OR created_at < $6)
+		  AND ($7::uuid[] IS NULL OR service_id IN (SELECT id FROM services WHERE project_id = ANY($7)))
+		  AND ($10::timestamptz IS NULL OR (created_at, id) < ($10, $11))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $8 OFFSET $9`
+
+	// RunSearchCount counts the runs matching the filters of RunSearch.
+	RunSearchCount = `
+		SELECT COUNT(*)
+		FROM test_runs
+		WHERE ($1::uuid IS NULL OR service_id = $1)
+		  AND ($2::text[] IS NULL OR status = ANY($2))
+		  AND ($3::text IS NULL OR git_ref = $3 OR git_ref = 'refs/heads/' || $3)
+		  AND ($4::text IS NULL
+		       OR to_tsvector('simple', COALESCE(error_message, '')) @@ websearch_to_tsquery('simple', $4)
+		       OR error_message ILIKE '%' || $4 || '%'
+		       OR id IN (
+		           SELECT run_id FROM test_results
+		           WHERE ($5::timestamptz IS NULL OR created_at >= $5)
+		             AND (to_tsvector('simple', COALESCE(error_message, '') || ' ' || COALESCE(stack_trace, '')) @@ websearch_to_tsquery('simple', $4)
+		                  OR error_message ILIKE '%' || $4 || '%'
+		                  OR stack_trace ILIKE '%' || $4 || '%')))
+		  AND ($5::timestamptz IS NULL OR created_at >= $5)
+		  AND ($6::timestamptz IS NULL OR created_at < $6)
+		  AND ($7::uuid[] IS NULL OR service_id IN (SELECT id FROM services WHERE project_id = ANY($7)))`
+
+	// RunCount counts the runs within the project scope $1.
+	RunCount = `SELECT COUNT(*) FROM test_runs WHERE ($1::uuid[] IS NULL OR service_id IN (SELECT id FROM services WHERE project_id = ANY($1)))`
 
 	// RunCountByStatus counts runs by status.
 	RunCountByStatus = `
@@ -375,6 +1011,14 @@ const (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
 		) RETURNING id, created_at`
 
+	// ResultClaimSequences records the result stream sequences $2 of the
+	// runs $1, returning those not recorded before.
+	ResultClaimSequences = `
+		INSERT INTO run_result_sequences (run_id, sequence)
+		SELECT * FROM unnest($1::uuid[], $2::bigint[])
+		ON CONFLICT DO NOTHING
+		RETURNING run_id, sequence`
+
 	// ResultGetByID retrieves a test result by ID.
 	ResultGetByID = `
 		SELECT id, run_id, shard_id, test_definition_id, test_name, suite_name, status,
@@ -401,6 +1045,46 @@ const (
 		WHERE run_id = $1 AND status = $2
 		ORDER BY test_name ASC`
 
+	// ResultListPage lists a page of the results for a test run, with any of
+	// the statuses $2 if set, after the test name and ID $5 and $6 if set.
+	ResultListPage = `
+		SELECT id, run_id, shard_id, test_definition_id, test_name, suite_name, status,
+			   duration_ms, error_message, stack_trace, stdout, stderr,
+			   retry_count, created_at
+		FROM test_results
+		WHERE run_id = $1 AND ($2::text[] IS NULL OR status = ANY($2))
+		  AND ($5::text IS NULL OR (test_name, id) > ($5, $6))
+		ORDER BY test_name ASC, id ASC
+		LIMIT $3 OFFSET $4`
+
+	// ResultSearchByRun lists the results of run $1 whose error message or
+	// stack trace contains the text $2 or matches it as a full-text query,
+	// failures first.
+	ResultSearchByRun = `
+		SELECT id, run_id, shard_id, test_definition_id, test_name, suite_name, status,
+			   duration_ms, error_message, stack_trace, stdout, stderr,
+			   retry_count, created_at
+		FROM test_results
+		WHERE run_id = $1
+		  AND (to_tsvector('simple', COALESCE(error_message, '') || ' ' || COALESCE(stack_trace, '')) @@ websearch_to_tsquery('simple', $2)
+		       OR error_message ILIKE '%' || $2 || '%'
+		       OR stack_trace ILIKE '%' || $2 || '%')
+		ORDER BY status IN ('fail', 'error') DESC, test_name ASC, id ASC
+		LIMIT $3`
+
+	// ResultGetLastPassing retrieves the latest passing result of a test in a
+	// service created before the given time.
+	ResultGetLastPassing = `
+		SELECT r.id, r.run_id, r.shard_id, r.test_definition_id, r.test_name, r.suite_name, r.status,
+			   r.duration_ms, r.error_message, r.stack_trace, r.stdout, r.stderr,
+			   r.retry_count, r.created_at
+		FROM test_results r
+		JOIN test_runs tr ON tr.id = r.run_id
+		WHERE tr.service_id = $1 AND r.test_name = $2 AND r.suite_name IS NOT DISTINCT FROM $3
+		  AND r.status = 'pass' AND r.created_at < $4
+		ORDER BY r.created_at DESC
+		LIMIT 1`
+
 	// ResultCountByRun counts results by status for a run.
 	ResultCountByRun = `
 		SELECT status, COUNT(*) as count
@@ -412,21 +1096,68 @@ const (
 	ResultDelete = `DELETE FROM test_results WHERE run_id = $1`
 )
 
+// Test result partition queries
+const (
+	// ResultPartitionCreate creates the partition of the month containing a
+	// date, if it does not exist.
+	ResultPartitionCreate = `SELECT create_test_results_partition($1)`
+
+	// ResultPartitionList lists the monthly partitions of test_results.
+	ResultPartitionList = `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'test_results'::regclass
+		  AND c.relname ~ '^test_results_[0-9]{4}_[0-9]{2}$'
+		ORDER BY c.relname`
+
+	// ResultPartitionExport copies the results of a partition as CSV. The
+	// partition name is substituted for %s.
+	ResultPartitionExport = `
+		COPY (
+			SELECT id, run_id, shard_id, test_definition_id, test_name, suite_name, status,
+				   duration_ms, error_message, stack_trace, stdout, stderr,
+				   retry_count, created_at
+			FROM %s
+			ORDER BY created_at, id
+		) TO STDOUT WITH (FORMAT csv, HEADER)`
+
+	// ResultPartitionCount counts the results of a partition. The partition
+	// name is substituted for %s.
+	ResultPartitionCount = `SELECT COUNT(*) FROM %s`
+
+	// ResultPartitionDrop drops a partition. The partition name is
+	// substituted for %s.
+	ResultPartitionDrop = `DROP TABLE %s`
+
+	// ResultArchiveInsert records a month of results dropped past their
+	// retention.
+	ResultArchiveInsert = `
+		INSERT INTO test_result_archives (month, object_path, row_count)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (month) DO UPDATE SET
+			object_path = EXCLUDED.object_path,
+			row_count = test_result_archives.row_count + EXCLUDED.row_count,
+			archived_at = NOW()`
+)
+
 // Run shard queries
 const (
 	// RunShardInsert inserts a new run shard.
 	RunShardInsert = `
 		INSERT INTO run_shards (
-			run_id, shard_index, shard_count, status, total_tests
+			run_id, shard_index, shard_count, status, total_tests, arch, matrix,
+			stage
 		) VALUES (
-			$1, $2, $3, $4, $5
+			$1, $2, $3, $4, $5, $6, $7, $8
 		) RETURNING id, created_at`
 
 	// RunShardGetByID retrieves a shard by ID.
 	RunShardGetByID = `
 		SELECT id, run_id, shard_index, shard_count, status, agent_id,
 			   total_tests, passed_tests, failed_tests, skipped_tests,
-			   error_message, started_at, finished_at, created_at
+			   error_message, started_at, finished_at, created_at, arch,
+			   agent_name, agent_network_zones, matrix, stage
 		FROM run_shards
 		WHERE id = $1`
 
@@ -434,10 +1165,11 @@ const (
 	RunShardListByRun = `
 		SELECT id, run_id, shard_index, shard_count, status, agent_id,
 			   total_tests, passed_tests, failed_tests, skipped_tests,
-			   error_message, started_at, finished_at, created_at
+			   error_message, started_at, finished_at, created_at, arch,
+			   agent_name, agent_network_zones, matrix, stage
 		FROM run_shards
 		WHERE run_id = $1
-		ORDER BY shard_index ASC`
+		ORDER BY stage ASC, shard_index ASC, arch ASC NULLS FIRST, matrix::text ASC NULLS FIRST`
 
 	// RunShardUpdateStatus updates shard status.
 	RunShardUpdateStatus = `
@@ -448,7 +1180,9 @@ const (
 	// RunShardStart marks a shard as started.
 	RunShardStart = `
 		UPDATE run_shards
-		SET status = 'running', agent_id = $2, started_at = NOW()
+		SET status = 'running', agent_id = $2, started_at = NOW(),
+			agent_name = (SELECT name FROM agents WHERE id = $2),
+			agent_network_zones = (SELECT network_zones FROM agents WHERE id = $2)
 		WHERE id = $1`
 
 	// RunShardFinish marks a shard as finished.
@@ -459,48 +1193,241 @@ const (
 			error_message = $7
 		WHERE id = $1`
 
+	// RunShardFinishRunning marks a shard as finished if it is running on
+	// agent $8.
+	RunShardFinishRunning = `
+		UPDATE run_shards
+		SET status = $2, finished_at = NOW(),
+			total_tests = $3, passed_tests = $4, failed_tests = $5, skipped_tests = $6,
+			error_message = $7
+		WHERE id = $1 AND status = 'running' AND agent_id = $8`
+
 	// RunShardReset resets a shard for retry.
 	RunShardReset = `
 		UPDATE run_shards
-		SET status = 'pending', agent_id = NULL,
+		SET status = 'pending', agent_id = NULL, agent_name = NULL, agent_network_zones = NULL,
 			started_at = NULL, finished_at = NULL,
 			passed_tests = 0, failed_tests = 0, skipped_tests = 0,
 			error_message = NULL
 		WHERE id = $1`
 
+	// RunShardRequeueOrphaned resets running shards of offline agents so
+	// they are assigned again. The returned agent is the offline agent.
+	RunShardRequeueOrphaned = `
+		WITH orphaned AS (
+			SELECT id, agent_id, agent_name, agent_network_zones
+			FROM run_shards
+			WHERE status = 'running'
+			  AND agent_id IN (SELECT id FROM agents WHERE status = 'offline')
+			FOR UPDATE
+		)
+		UPDATE run_shards s
+		SET status = 'pending', agent_id = NULL, agent_name = NULL, agent_network_zones = NULL,
+			started_at = NULL, finished_at = NULL,
+			passed_tests = 0, failed_tests = 0, skipped_tests = 0,
+			error_message = NULL
+		FROM orphaned o
+		WHERE s.id = o.id
+		RETURNING s.id, s.run_id, s.shard_index, s.shard_count, s.status, o.agent_id,
+			s.total_tests, s.passed_tests, s.failed_tests, s.skipped_tests,
+			s.error_message, s.started_at, s.finished_at, s.created_at, s.arch,
+			o.agent_name, o.agent_network_zones, s.matrix, s.stage`
+
 	// RunShardDeleteByRun deletes shards for a run.
 	RunShardDeleteByRun = `DELETE FROM run_shards WHERE run_id = $1`
 )
 
+// Energy queries
+const (
+	// EnergySampleInsert records an energy sample of a run.
+	EnergySampleInsert = `
+		INSERT INTO run_energy_samples (run_id, agent_id, zone, cpu_percent, cpu_cores, memory_bytes, energy_wh, sampled_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	// EnergyGetByRun sums the energy of a run per zone.
+	EnergyGetByRun = `
+		SELECT zone, SUM(energy_wh)
+		FROM run_energy_samples
+		WHERE run_id = $1
+		GROUP BY zone
+		ORDER BY zone`
+
+	// EnergyGetMonthly sums the energy of runs per service, month and zone.
+	// A NULL $1 includes every service.
+	EnergyGetMonthly = `
+		WITH samples AS (
+			SELECT r.service_id, date_trunc('month', s.sampled_at AT TIME ZONE 'UTC') AS month,
+				s.zone, s.run_id, s.energy_wh
+			FROM run_energy_samples s
+			JOIN test_runs r ON r.id = s.run_id
+			WHERE ($1::uuid IS NULL OR r.service_id = $1)
+			  AND s.sampled_at >= $2 AND s.sampled_at < $3
+		), runs AS (
+			SELECT service_id, month, COUNT(DISTINCT run_id) AS run_count
+			FROM samples
+			GROUP BY service_id, month
+		)
+		SELECT s.service_id, sv.name, s.month, s.zone, SUM(s.energy_wh), runs.run_count
+		FROM samples s
+		JOIN runs ON runs.service_id = s.service_id AND runs.month = s.month
+		JOIN services sv ON sv.id = s.service_id
+		GROUP BY s.service_id, sv.name, s.month, s.zone, runs.run_count
+		ORDER BY s.month, sv.name, s.zone`
+)
+
+// Run annotation queries
+const (
+	// RunAnnotationInsert records an annotation of a run.
+	RunAnnotationInsert = `
+		INSERT INTO run_annotations (run_id, shard_id, agent_id, kind, severity, message, metadata, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	// RunAnnotationListByRun lists the annotations of a run, oldest first.
+	RunAnnotationListByRun = `
+		SELECT id, run_id, shard_id, agent_id, kind, severity, message, metadata, created_at
+		FROM run_annotations
+		WHERE run_id = $1
+		ORDER BY created_at, id`
+)
+
+// Duration regression queries
+const (
+	// DurationRegressionInsert records a duration regression, unless the run
+	// or test already regressed in the run.
+	DurationRegressionInsert = `
+		INSERT INTO run_duration_regressions (
+			run_id, test_name, duration_ms, baseline_mean_ms, baseline_stddev_ms, sigma, baseline_runs
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (run_id, COALESCE(test_name, '')) DO NOTHING
+		RETURNING id, created_at`
+
+	// DurationRegressionListByRun lists the regressions of a run, the run
+	// itself first and then tests by how much longer they took.
+	DurationRegressionListByRun = `
+		SELECT id, run_id, test_name, duration_ms, baseline_mean_ms, baseline_stddev_ms, sigma, baseline_runs, created_at
+		FROM run_duration_regressions
+		WHERE run_id = $1
+		ORDER BY test_name IS NOT NULL, duration_ms - baseline_mean_ms DESC, test_name`
+
+	// RunDurationBaseline lists the durations of the latest passed runs of a
+	// service branch created before a run, newest first.
+	RunDurationBaseline = `
+		SELECT duration_ms
+		FROM test_runs
+		WHERE service_id = $1
+		  AND git_ref IS NOT DISTINCT FROM $2
+		  AND status = 'passed'
+		  AND duration_ms IS NOT NULL
+		  AND created_at < $3
+		ORDER BY created_at DESC
+		LIMIT $4`
+
+	// TestDurationBaselines lists the passed tests of a run with the
+	// durations of their latest passed results in earlier runs of the same
+	// service branch. Tests that ran more than once count their longest run.
+	TestDurationBaselines = `
+		WITH run_tests AS (
+			SELECT DISTINCT ON (test_name) test_name, duration_ms
+			FROM test_results
+			WHERE run_id = $1 AND status = 'pass' AND duration_ms IS NOT NULL
+			ORDER BY test_name, duration_ms DESC
+		)
+		SELECT c.test_name, c.duration_ms, COALESCE(b.durations, '{}')
+		FROM run_tests c
+		CROSS JOIN LATERAL (
+			SELECT ARRAY_AGG(recent.duration_ms) AS durations
+			FROM (
+				SELECT r.duration_ms
+				FROM test_results r
+				JOIN test_runs tr ON tr.id = r.run_id
+				WHERE r.test_name = c.test_name
+				  AND r.status = 'pass'
+				  AND r.duration_ms IS NOT NULL
+				  AND tr.service_id = $2
+				  AND tr.git_ref IS NOT DISTINCT FROM $3
+				  AND tr.created_at < $4
+				ORDER BY tr.created_at DESC
+				LIMIT $5
+			) recent
+		) b
+		ORDER BY c.test_name`
+)
+
 // Artifact queries
 const (
 	// ArtifactInsert inserts a new artifact.
 	ArtifactInsert = `
-		INSERT INTO artifacts (run_id, name, path, content_type, size_bytes)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, created_at`
+		INSERT INTO artifacts (run_id, name, path, content_type, size_bytes, test_definition_id, artifact_type, checksum, blob_digest)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, storage_class`
 
 	// ArtifactGetByID retrieves an artifact by ID.
 	ArtifactGetByID = `
-		SELECT id, run_id, name, path, content_type, size_bytes, created_at
+		SELECT id, run_id, name, path, content_type, size_bytes, created_at,
+		       storage_class, archived_at, restore_requested_at, restored_until,
+		       test_definition_id, artifact_type, checksum, blob_digest
 		FROM artifacts
-		WHERE id = $1`
+		WHERE id = $1
+		  AND ($2::uuid[] IS NULL OR run_id IN (
+			SELECT r.id FROM test_runs r JOIN services s ON s.id = r.service_id
+			WHERE s.project_id = ANY($2)
+		  ))`
 
 	// ArtifactListByRun lists artifacts for a run.
 	ArtifactListByRun = `
-		SELECT id, run_id, name, path, content_type, size_bytes, created_at
+		SELECT id, run_id, name, path, content_type, size_bytes, created_at,
+		       storage_class, archived_at, restore_requested_at, restored_until,
+		       test_definition_id, artifact_type, checksum, blob_digest
 		FROM artifacts
 		WHERE run_id = $1
 		ORDER BY name ASC`
 
+	// ArtifactUsageByTest counts the artifacts and bytes recorded for a test
+	// definition in a run.
+	ArtifactUsageByTest = `
+		SELECT COUNT(*), COALESCE(SUM(size_bytes), 0)
+		FROM artifacts
+		WHERE run_id = $1 AND test_definition_id = $2`
+
 	// ArtifactListOlderThan lists artifacts older than a timestamp.
 	ArtifactListOlderThan = `
-		SELECT id, run_id, name, path, content_type, size_bytes, created_at
+		SELECT id, run_id, name, path, content_type, size_bytes, created_at,
+		       storage_class, archived_at, restore_requested_at, restored_until,
+		       test_definition_id, artifact_type, checksum, blob_digest
 		FROM artifacts
 		WHERE created_at < $1
 		ORDER BY created_at ASC
 		LIMIT $2`
 
+	// ArtifactListForArchival lists artifacts still in the STANDARD storage
+	// class that are older than a timestamp. Artifacts held in shared blobs
+	// are left in place, as newer artifacts may hold the same blob.
+	ArtifactListForArchival = `
+		SELECT id, run_id, name, path, content_type, size_bytes, created_at,
+		       storage_class, archived_at, restore_requested_at, restored_until,
+		       test_definition_id, artifact_type, checksum, blob_digest
+		FROM artifacts
+		WHERE storage_class = 'STANDARD' AND created_at < $1 AND blob_digest IS NULL
+		ORDER BY created_at ASC
+		LIMIT $2`
+
+	// ArtifactMarkArchived records a storage class transition.
+	ArtifactMarkArchived = `
+		UPDATE artifacts
+		SET storage_class = $2, archived_at = $3, restore_requested_at = NULL, restored_until = NULL
+		WHERE id = $1`
+
+	// ArtifactMarkRestoreRequested records that a restore was requested.
+	ArtifactMarkRestoreRequested = `
+		UPDATE artifacts
+		SET restore_requested_at = $2, restored_until = NULL
+		WHERE id = $1`
+
+	// ArtifactMarkRestored records until when a restored copy is available.
+	ArtifactMarkRestored = `UPDATE artifacts SET restored_until = $2 WHERE id = $1`
+
 	// ArtifactDelete deletes an artifact.
 	ArtifactDelete = `DELETE FROM artifacts WHERE id = $1`
 
@@ -508,53 +1435,199 @@ const (
 	ArtifactDeleteByRun = `DELETE FROM artifacts WHERE run_id = $1`
 )
 
+// Artifact blob queries
+const (
+	// ArtifactBlobUpsert records a stored blob, or marks an existing one as
+	// referenced now.
+	ArtifactBlobUpsert = `
+		INSERT INTO artifact_blobs (digest, path, size_bytes, content_type)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (digest) DO UPDATE SET last_referenced_at = NOW()
+		RETURNING path, ref_count, created_at, last_referenced_at`
+
+	// ArtifactBlobTouch marks a blob as referenced now, so unreferenced blob
+	// cleanup keeps it while an artifact holding it is recorded.
+	ArtifactBlobTouch = `
+		UPDATE artifact_blobs SET last_referenced_at = NOW()
+		WHERE digest = $1
+		RETURNING digest, path, size_bytes, content_type, ref_count, created_at, last_referenced_at`
+
+	// ArtifactBlobGet retrieves a blob by digest.
+	ArtifactBlobGet = `
+		SELECT digest, path, size_bytes, content_type, ref_count, created_at, last_referenced_at
+		FROM artifact_blobs
+		WHERE digest = $1`
+
+	// ArtifactBlobListUnreferenced lists blobs no artifact holds that were
+	// last referenced before a timestamp.
+	ArtifactBlobListUnreferenced = `
+		SELECT digest, path, size_bytes, content_type, ref_count, created_at, last_referenced_at
+		FROM artifact_blobs
+		WHERE ref_count = 0 AND last_referenced_at < $1
+		ORDER BY last_referenced_at ASC
+		LIMIT $2`
+
+	// ArtifactBlobDeleteUnreferenced deletes a blob if it is still
+	// unreferenced and was last referenced before a timestamp.
+	ArtifactBlobDeleteUnreferenced = `
+		DELETE FROM artifact_blobs
+		WHERE digest = $1 AND ref_count = 0 AND last_referenced_at < $2`
+)
+
 // Notification queries
 const (
 	// NotificationChannelInsert inserts a new notification channel.
 	NotificationChannelInsert = `
-		INSERT INTO notification_channels (name, type, config, enabled)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO notification_channels (name, type, config, enabled, project_id)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at, updated_at`
 
-	// NotificationChannelGetByID retrieves a channel by ID.
+	// NotificationChannelGetByID retrieves a channel by ID within the project
+	// scope $2.
 	NotificationChannelGetByID = `
-		SELECT id, name, type, config, enabled, created_at, updated_at
+		SELECT id, name, type, config, enabled, created_at, updated_at, project_id, branch_run_policy
 		FROM notification_channels
-		WHERE id = $1`
+		WHERE id = $1 AND ($2::uuid[] IS NULL OR project_id = ANY($2))`
 
-	// NotificationChannelList lists all channels.
+	// NotificationChannelList lists the channels within the project scope $3.
 	NotificationChannelList = `
-		SELECT id, name, type, config, enabled, created_at, updated_at
+		SELECT id, name, type, config, enabled, created_at, updated_at, project_id, branch_run_policy
 		FROM notification_channels
+		WHERE ($3::uuid[] IS NULL OR project_id = ANY($3))
 		ORDER BY name ASC
 		LIMIT $1 OFFSET $2`
 
 	// NotificationChannelListEnabled lists enabled channels.
 	NotificationChannelListEnabled = `
-		SELECT id, name, type, config, enabled, created_at, updated_at
+		SELECT id, name, type, config, enabled, created_at, updated_at, project_id, branch_run_policy
 		FROM notification_channels
 		WHERE enabled = true
 		ORDER BY name ASC`
 
 	// NotificationRuleInsert inserts a new notification rule.
 	NotificationRuleInsert = `
-		INSERT INTO notification_rules (channel_id, service_id, trigger_on, enabled)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO notification_rules (channel_id, service_id, test_definition_id, owner, trigger_on, enabled,
+			title_template, message_template)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, created_at, updated_at`
 
 	// NotificationRuleListByService lists rules for a service (including global rules).
 	NotificationRuleListByService = `
-		SELECT id, channel_id, service_id, trigger_on, enabled, created_at, updated_at
+		SELECT id, channel_id, service_id, test_definition_id, owner, trigger_on, enabled,
+			   title_template, message_template, created_at, updated_at
 		FROM notification_rules
 		WHERE enabled = true AND (service_id IS NULL OR service_id = $1)
-		ORDER BY service_id NULLS LAST`
+		  AND ($2::uuid[] IS NULL OR channel_id IN (SELECT id FROM notification_channels WHERE project_id = ANY($2)))
+		ORDER BY test_definition_id NULLS LAST, owner NULLS LAST, service_id NULLS LAST`
 
 	// NotificationRuleListByChannel lists rules for a channel.
 	NotificationRuleListByChannel = `
-		SELECT id, channel_id, service_id, trigger_on, enabled, created_at, updated_at
+		SELECT id, channel_id, service_id, test_definition_id, owner, trigger_on, enabled,
+			   title_template, message_template, created_at, updated_at
 		FROM notification_rules
 		WHERE channel_id = $1
+		  AND ($2::uuid[] IS NULL OR channel_id IN (SELECT id FROM notification_channels WHERE project_id = ANY($2)))
 		ORDER BY created_at ASC`
+
+	// NotificationDeliveryInsert records a notification delivery.
+	NotificationDeliveryInsert = `
+		INSERT INTO notification_deliveries (
+			event_type, service_id, run_id, channel_id, channel_type, rule_ids,
+			status, attempts, latency_ms, error, response_excerpt, sent_at,
+			payload, payload_hash, redelivery_of
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id`
+
+	// NotificationDeliveryGetByID retrieves a delivery with its payload.
+	NotificationDeliveryGetByID = `
+		SELECT id, event_type, service_id, run_id, channel_id, channel_type, rule_ids,
+			status, attempts, latency_ms, error, response_excerpt, sent_at,
+			payload_hash, redelivery_of, payload
+		FROM notification_deliveries
+		WHERE id = $1
+		  AND ($2::uuid[] IS NULL OR channel_id IN (SELECT id FROM notification_channels WHERE project_id = ANY($2)))`
+
+	// NotificationDeliveryList lists deliveries matching optional filters,
+	// newest first.
+	NotificationDeliveryList = `
+		SELECT id, event_type, service_id, run_id, channel_id, channel_type, rule_ids,
+			status, attempts, latency_ms, error, response_excerpt, sent_at,
+			payload_hash, redelivery_of
+		FROM notification_deliveries
+		WHERE ($1::uuid IS NULL OR service_id = $1)
+		  AND ($2::uuid IS NULL OR run_id = $2)
+		  AND ($3::uuid IS NULL OR channel_id = $3)
+		  AND ($4::uuid IS NULL OR $4 = ANY(rule_ids))
+		  AND ($5::text IS NULL OR status = $5)
+		  AND ($6::text IS NULL OR event_type = $6)
+		  AND ($7::timestamptz IS NULL OR sent_at >= $7)
+		  AND ($8::timestamptz IS NULL OR sent_at < $8)
+		  AND ($9::uuid[] IS NULL OR channel_id IN (SELECT id FROM notification_channels WHERE project_id = ANY($9)))
+		ORDER BY sent_at DESC, id
+		LIMIT $10 OFFSET $11`
+
+	// NotificationDeliveryCount counts deliveries matching the filters of
+	// NotificationDeliveryList.
+	NotificationDeliveryCount = `
+		SELECT COUNT(*)
+		FROM notification_deliveries
+		WHERE ($1::uuid IS NULL OR service_id = $1)
+		  AND ($2::uuid IS NULL OR run_id = $2)
+		  AND ($3::uuid IS NULL OR channel_id = $3)
+		  AND ($4::uuid IS NULL OR $4 = ANY(rule_ids))
+		  AND ($5::text IS NULL OR status = $5)
+		  AND ($6::text IS NULL OR event_type = $6)
+		  AND ($7::timestamptz IS NULL OR sent_at >= $7)
+		  AND ($8::timestamptz IS NULL OR sent_at < $8)
+		  AND ($9::uuid[] IS NULL OR channel_id IN (SELECT id FROM notification_channels WHERE project_id = ANY($9)))`
+)
+
+// Notification dead-letter queries
+const (
+	// NotificationDeadLetterInsert dead-letters a failed delivery.
+	NotificationDeadLetterInsert = `
+		INSERT INTO notification_dead_letters (
+			delivery_id, channel_id, error, transient, attempts, next_attempt_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at`
+
+	// NotificationDeadLetterGetByDelivery retrieves the dead letter of a
+	// failed delivery.
+	NotificationDeadLetterGetByDelivery = `
+		SELECT id, delivery_id, channel_id, error, transient, attempts, next_attempt_at,
+			created_at, updated_at
+		FROM notification_dead_letters
+		WHERE delivery_id = $1`
+
+	// NotificationDeadLetterUpdate records another failed redelivery.
+	NotificationDeadLetterUpdate = `
+		UPDATE notification_dead_letters
+		SET delivery_id = $2, error = $3, transient = $4, attempts = $5, next_attempt_at = $6
+		WHERE id = $1
+		RETURNING updated_at`
+
+	// NotificationDeadLetterDelete removes a redelivered dead letter.
+	NotificationDeadLetterDelete = `
+		DELETE FROM notification_dead_letters WHERE id = $1`
+
+	// NotificationDeadLetterListDue lists dead letters due for redelivery,
+	// most overdue first.
+	NotificationDeadLetterListDue = `
+		SELECT id, delivery_id, channel_id, error, transient, attempts, next_attempt_at,
+			created_at, updated_at
+		FROM notification_dead_letters
+		WHERE next_attempt_at <= $1
+		ORDER BY next_attempt_at
+		LIMIT $2`
+
+	// NotificationDeadLetterCount counts pending and exhausted dead letters.
+	NotificationDeadLetterCount = `
+		SELECT
+			COUNT(*) FILTER (WHERE next_attempt_at IS NOT NULL),
+			COUNT(*) FILTER (WHERE next_attempt_at IS NULL)
+		FROM notification_dead_letters`
 )
 
 // Schedule queries
@@ -562,25 +1635,26 @@ const (
 	// ScheduleInsert inserts a new scheduled run.
 	ScheduleInsert = `
 		INSERT INTO scheduled_runs (
-			service_id, name, cron_expression, git_ref, test_filter, enabled, next_run_at
+			service_id, name, cron_expression, timezone, git_ref, test_filter, enabled, next_run_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7
+			$1, $2, $3, $4, $5, $6, $7, $8
 		) RETURNING id, created_at, updated_at`
 
 	// ScheduleGetByID retrieves a schedule by ID.
 	ScheduleGetByID = `
-		SELECT id, service_id, name, cron_expression, git_ref, test_filter,
+		SELECT id, service_id, name, cron_expression, timezone, git_ref, test_filter,
 			   enabled, last_run_at, next_run_at, created_at, updated_at
 		FROM scheduled_runs
 		WHERE id = $1`
 
-	// ScheduleListDue lists schedules that are due to run.
+	// ScheduleListDue lists schedules of active services that are due to run.
 	ScheduleListDue = `
-		SELECT id, service_id, name, cron_expression, git_ref, test_filter,
-			   enabled, last_run_at, next_run_at, created_at, updated_at
-		FROM scheduled_runs
-		WHERE enabled = true AND next_run_at <= NOW()
-		ORDER BY next_run_at ASC`
+		SELECT r.id, r.service_id, r.name, r.cron_expression, r.timezone, r.git_ref, r.test_filter,
+			   r.enabled, r.last_run_at, r.next_run_at, r.created_at, r.updated_at
+		FROM scheduled_runs r
+		JOIN services s ON s.id = r.service_id
+		WHERE r.enabled = true AND r.next_run_at <= NOW() AND s.archived_at IS NULL
+		ORDER BY r.next_run_at ASC`
 
 	// ScheduleUpdateAfterRun updates a schedule after it has run.
 	ScheduleUpdateAfterRun = `
@@ -590,7 +1664,7 @@ const (
 
 	// ScheduleListByService lists schedules for a service.
 	ScheduleListByService = `
-		SELECT id, service_id, name, cron_expression, git_ref, test_filter,
+		SELECT id, service_id, name, cron_expression, timezone, git_ref, test_filter,
 			   enabled, last_run_at, next_run_at, created_at, updated_at
 		FROM scheduled_runs
 		WHERE service_id = $1
@@ -694,4 +1768,441 @@ const (
 			   pass_rate_7_days, flaky_test_count, last_run_at, last_run_status
 		FROM service_health_summary
 		WHERE service_id = $1`
+
+	// ServiceReportGet computes service reports from the report views. Days
+	// and recoveries are included from the first day of the window.
+	ServiceReportGet = `
+		WITH days AS (
+			SELECT * FROM service_run_daily_stats
+			WHERE day >= $1::date
+			  AND ($2::uuid IS NULL OR service_id = $2)
+			  AND ($3::text IS NULL OR git_ref = $3)
+		), runs AS (
+			SELECT service_id,
+				   SUM(total_runs) AS total_runs,
+				   SUM(passed_runs) AS passed_runs,
+				   SUM(failed_runs) AS failed_runs,
+				   SUM(duration_sum_ms) / NULLIF(SUM(timed_runs), 0) AS avg_duration_ms
+			FROM days
+			GROUP BY service_id
+		), durations AS (
+			SELECT service_id, PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY duration_ms) AS p95_duration_ms
+			FROM days, UNNEST(durations_ms) AS duration_ms
+			GROUP BY service_id
+		), recoveries AS (
+			SELECT service_id,
+				   COUNT(*) AS recoveries,
+				   AVG(EXTRACT(EPOCH FROM recovered_at - failed_at) * 1000) AS mttr_ms
+			FROM service_recoveries
+			WHERE recovered_at >= $1::date
+			  AND ($2::uuid IS NULL OR service_id = $2)
+			  AND ($3::text IS NULL OR git_ref = $3)
+			GROUP BY service_id
+		)
+		SELECT s.id, s.name,
+			   COALESCE(r.total_runs, 0)::bigint,
+			   COALESCE(r.passed_runs, 0)::bigint,
+			   COALESCE(r.failed_runs, 0)::bigint,
+			   COALESCE(ROUND(r.avg_duration_ms), 0)::bigint,
+			   COALESCE(ROUND(d.p95_duration_ms), 0)::bigint,
+			   COALESCE(rc.recoveries, 0)::bigint,
+			   COALESCE(ROUND(rc.mttr_ms), 0)::bigint
+		FROM services s
+		LEFT JOIN runs r ON r.service_id = s.id
+		LEFT JOIN durations d ON d.service_id = s.id
+		LEFT JOIN recoveries rc ON rc.service_id = s.id
+		WHERE ($2::uuid IS NULL OR s.id = $2)
+		ORDER BY s.name ASC`
+
+	// ServiceRunDailyStatsRefresh refreshes the daily run statistics view
+	// without blocking reads.
+	ServiceRunDailyStatsRefresh = `REFRESH MATERIALIZED VIEW CONCURRENTLY service_run_daily_stats`
+
+	// ServiceRecoveriesRefresh refreshes the recoveries view without
+	// blocking reads.
+	ServiceRecoveriesRefresh = `REFRESH MATERIALIZED VIEW CONCURRENTLY service_recoveries`
+
+	// RunEventListUndispatched lists events without deliveries, oldest
+	// first.
+	RunEventListUndispatched = `
+		SELECT id, event_id, event_type, run_id, service_id, run, created_at, dispatched_at
+		FROM run_events
+		WHERE dispatched_at IS NULL
+		ORDER BY id
+		LIMIT $1`
+
+	// RunEventDispatch creates the deliveries of an event and marks it
+	// dispatched in one statement, so that an event is never marked without
+	// its deliveries.
+	RunEventDispatch = `
+		WITH deliveries AS (
+			INSERT INTO event_webhook_deliveries (event_id, endpoint)
+			SELECT $1, endpoint FROM unnest($2::text[]) AS endpoint
+			ON CONFLICT (event_id, endpoint) DO NOTHING
+		)
+		UPDATE run_events SET dispatched_at = NOW()
+		WHERE id = $1`
+
+	// EventWebhookDeliveryClaimDue postpones the pending deliveries due at $1
+	// to $2 and returns them with their events, skipping deliveries claimed
+	// by other control planes.
+	EventWebhookDeliveryClaimDue = `
+		WITH due AS (
+			SELECT id
+			FROM event_webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE event_webhook_deliveries d
+			SET next_attempt_at = $2
+			FROM due
+			WHERE d.id = due.id
+			RETURNING d.id, d.event_id, d.endpoint, d.status, d.attempts, d.next_attempt_at,
+				d.response_status, d.error, d.created_at, d.delivered_at
+		)
+		SELECT c.id, c.event_id, c.endpoint, c.status, c.attempts, c.next_attempt_at,
+			c.response_status, c.error, c.created_at, c.delivered_at,
+			e.id, e.event_id, e.event_type, e.run_id, e.service_id, e.run, e.created_at, e.dispatched_at
+		FROM claimed c
+		JOIN run_events e ON e.id = c.event_id
+		ORDER BY e.id`
+
+	// EventWebhookDeliveryUpdate records the outcome of a delivery attempt.
+	EventWebhookDeliveryUpdate = `
+		UPDATE event_webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4, response_status = $5, error = $6, delivered_at = $7
+		WHERE id = $1`
+
+	// RunEventDeleteBefore deletes dispatched events recorded before $1
+	// whose deliveries are all done.
+	RunEventDeleteBefore = `
+		DELETE FROM run_events e
+		WHERE e.created_at < $1
+		  AND e.dispatched_at IS NOT NULL
+		  AND NOT EXISTS (
+			SELECT 1 FROM event_webhook_deliveries d
+			WHERE d.event_id = e.id AND d.status = 'pending'
+		  )`
+)
+
+// Audit log queries
+const (
+	// AuditLogInsert records an audited call.
+	AuditLogInsert = `
+		INSERT INTO audit_logs (
+			actor, actor_email, action, protocol, resource_type, resource_id,
+			request, before, after, status, error, source_ip, request_id, user_agent
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, created_at`
+
+	// AuditLogList retrieves audit logs matching optional filters, newest
+	// first.
+	AuditLogList = `
+		SELECT id, actor, actor_email, action, protocol, resource_type, resource_id,
+			request, before, after, status, error, source_ip, request_id, user_agent, created_at
+		FROM audit_logs
+		WHERE ($1::text IS NULL OR actor = $1)
+		  AND ($2::text IS NULL OR action = $2)
+		  AND ($3::text IS NULL OR resource_type = $3)
+		  AND ($4::text IS NULL OR resource_id = $4)
+		  AND ($5::timestamptz IS NULL OR created_at >= $5)
+		  AND ($6::timestamptz IS NULL OR created_at < $6)
+		ORDER BY created_at DESC, id
+		LIMIT $7 OFFSET $8`
+
+	// AuditLogCount counts audit logs matching the filters of AuditLogList.
+	AuditLogCount = `
+		SELECT COUNT(*)
+		FROM audit_logs
+		WHERE ($1::text IS NULL OR actor = $1)
+		  AND ($2::text IS NULL OR action = $2)
+		  AND ($3::text IS NULL OR resource_type = $3)
+		  AND ($4::text IS NULL OR resource_id = $4)
+		  AND ($5::timestamptz IS NULL OR created_at >= $5)
+		  AND ($6::timestamptz IS NULL OR created_at < $6)`
+
+	// AuditLogDeleteBefore deletes audit logs recorded before $1.
+	AuditLogDeleteBefore = `
+		DELETE FROM audit_logs
+		WHERE created_at < $1`
+)
+
+// Organization queries
+const (
+	// OrganizationInsert inserts a new organization.
+	OrganizationInsert = `
+		INSERT INTO organizations (name, display_name)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at`
+
+	// OrganizationGetByID retrieves an organization by ID.
+	OrganizationGetByID = `
+		SELECT id, name, display_name, created_at, updated_at
+		FROM organizations
+		WHERE id = $1`
+
+	// OrganizationList retrieves organizations by name with pagination.
+	OrganizationList = `
+		SELECT id, name, display_name, created_at, updated_at
+		FROM organizations
+		ORDER BY name
+		LIMIT $1 OFFSET $2`
+
+	// OrganizationCount counts organizations.
+	OrganizationCount = `SELECT COUNT(*) FROM organizations`
+)
+
+// Project queries
+const (
+	// ProjectInsert inserts a new project.
+	ProjectInsert = `
+		INSERT INTO projects (organization_id, name, display_name)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at`
+
+	// ProjectGetByID retrieves a project by ID.
+	ProjectGetByID = `
+		SELECT id, organization_id, name, display_name, created_at, updated_at
+		FROM projects
+		WHERE id = $1`
+
+	// ProjectListByOrganization retrieves the projects of an organization by
+	// name with pagination, limited to the projects in $4 unless it is NULL.
+	ProjectListByOrganization = `
+		SELECT id, organization_id, name, display_name, created_at, updated_at
+		FROM projects
+		WHERE organization_id = $1 AND ($4::uuid[] IS NULL OR id = ANY($4))
+		ORDER BY name
+		LIMIT $2 OFFSET $3`
+
+	// ProjectCountByOrganization counts the projects matched by
+	// ProjectListByOrganization.
+	ProjectCountByOrganization = `
+		SELECT COUNT(*)
+		FROM projects
+		WHERE organization_id = $1 AND ($2::uuid[] IS NULL OR id = ANY($2))`
+)
+
+// Preference queries
+const (
+	// PinnedServiceInsert pins a service for a user. Pinning it again is a
+	// no-op.
+	PinnedServiceInsert = `
+		INSERT INTO user_pinned_services (user_id, service_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, service_id) DO NOTHING`
+
+	// PinnedServiceDelete unpins a service for a user.
+	PinnedServiceDelete = `
+		DELETE FROM user_pinned_services
+		WHERE user_id = $1 AND service_id = $2`
+
+	// PinnedServiceList lists the services a user pinned within the project
+	// scope $2, in the order they were pinned.
+	PinnedServiceList = `
+		SELECT s.id, s.name, s.display_name, s.git_url, s.git_provider, s.default_branch,
+			   s.network_zones, s.owner, s.contact_slack, s.contact_email, s.root_path, s.sync_interval_seconds,
+			   s.agent_pool, s.archived_at, s.created_at, s.updated_at, s.project_id, s.branch_run_policy
+		FROM user_pinned_services p
+		JOIN services s ON s.id = p.service_id
+		WHERE p.user_id = $1 AND ($2::uuid[] IS NULL OR s.project_id = ANY($2))
+		ORDER BY p.created_at ASC, s.name ASC`
+
+	// SavedViewInsert saves a view.
+	SavedViewInsert = `
+		INSERT INTO user_saved_views (user_id, name, page, service_id, filters, is_default)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at`
+
+	// SavedViewGetByID retrieves a view of a user by ID.
+	SavedViewGetByID = `
+		SELECT id, user_id, name, page, service_id, filters, is_default, created_at, updated_at
+		FROM user_saved_views
+		WHERE id = $1 AND user_id = $2`
+
+	// SavedViewList lists the views of a user matching optional filters on
+	// page ($2) and service ($3), by page and name.
+	SavedViewList = `
+		SELECT id, user_id, name, page, service_id, filters, is_default, created_at, updated_at
+		FROM user_saved_views
+		WHERE user_id = $1
+			AND ($2::varchar IS NULL OR page = $2)
+			AND ($3::uuid IS NULL OR service_id = $3)
+		ORDER BY page ASC, name ASC`
+
+	// SavedViewUpdate updates the name, filters and default flag of a view
+	// of a user.
+	SavedViewUpdate = `
+		UPDATE user_saved_views
+		SET name = $3, filters = $4, is_default = $5
+		WHERE id = $1 AND user_id = $2
+		RETURNING updated_at`
+
+	// SavedViewClearDefault clears the default flag of the views of a user
+	// on page $2 for service $3, other than view $4.
+	SavedViewClearDefault = `
+		UPDATE user_saved_views
+		SET is_default = false
+		WHERE user_id = $1 AND page = $2 AND service_id IS NOT DISTINCT FROM $3
+			AND id <> $4 AND is_default`
+
+	// SavedViewDelete deletes a view of a user.
+	SavedViewDelete = `
+		DELETE FROM user_saved_views
+		WHERE id = $1 AND user_id = $2`
+)
+
+// Agent connection queries
+const (
+	// AgentConnectionUpsert records the replica an agent connected to.
+	AgentConnectionUpsert = `
+		INSERT INTO agent_connections (agent_id, replica_id, replica_address)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (agent_id) DO UPDATE SET
+			replica_id = EXCLUDED.replica_id,
+			replica_address = EXCLUDED.replica_address,
+			connected_at = NOW()
+		RETURNING connected_at`
+
+	// AgentConnectionGet retrieves the connection of an agent.
+	AgentConnectionGet = `
+		SELECT agent_id, replica_id, replica_address, connected_at
+		FROM agent_connections
+		WHERE agent_id = $1`
+
+	// AgentConnectionDelete removes the connection of an agent to a replica.
+	AgentConnectionDelete = `
+		DELETE FROM agent_connections
+		WHERE agent_id = $1 AND replica_id = $2`
+
+	// AgentConnectionDeleteByReplica removes every connection to a replica.
+	AgentConnectionDeleteByReplica = `
+		DELETE FROM agent_connections
+		WHERE replica_id = $1`
+)
+
+// Scheduling pause queries
+const (
+	// SchedulingPauseUpsertGlobal pauses scheduling globally.
+	SchedulingPauseUpsertGlobal = `
+		INSERT INTO scheduling_pauses (service_id, reason, paused_by)
+		VALUES (NULL, $1, $2)
+		ON CONFLICT ((service_id IS NULL)) WHERE service_id IS NULL DO UPDATE SET
+			reason = EXCLUDED.reason,
+			paused_by = EXCLUDED.paused_by,
+			paused_at = NOW()
+		RETURNING id, paused_at`
+
+	// SchedulingPauseUpsertService pauses scheduling for a service.
+	SchedulingPauseUpsertService = `
+		INSERT INTO scheduling_pauses (service_id, reason, paused_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (service_id) WHERE service_id IS NOT NULL DO UPDATE SET
+			reason = EXCLUDED.reason,
+			paused_by = EXCLUDED.paused_by,
+			paused_at = NOW()
+		RETURNING id, paused_at`
+
+	// SchedulingPauseDeleteGlobal resumes scheduling globally.
+	SchedulingPauseDeleteGlobal = `
+		DELETE FROM scheduling_pauses
+		WHERE service_id IS NULL`
+
+	// SchedulingPauseDeleteService resumes scheduling for a service.
+	SchedulingPauseDeleteService = `
+		DELETE FROM scheduling_pauses
+		WHERE service_id = $1`
+
+	// SchedulingPauseList lists the pauses, the global pause first.
+	SchedulingPauseList = `
+		SELECT id, service_id, reason, paused_by, paused_at
+		FROM scheduling_pauses
+		ORDER BY service_id NULLS FIRST`
+)
+
+// Maintenance window queries
+const (
+	// maintenanceWindowSelect selects the columns scanned by
+	// scanMaintenanceWindow.
+	maintenanceWindowSelect = `
+		SELECT id, name, description, service_id, agent_pool, cron_expression,
+			timezone, duration_seconds, starts_at, ends_at, notifications, enabled,
+			created_by, created_at, updated_at
+		FROM maintenance_windows`
+
+	// MaintenanceWindowInsert creates a maintenance window.
+	MaintenanceWindowInsert = `
+		INSERT INTO maintenance_windows (
+			name, description, service_id, agent_pool, cron_expression, timezone,
+			duration_seconds, starts_at, ends_at, notifications, enabled, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at, updated_at`
+
+	// MaintenanceWindowGet retrieves a maintenance window by ID.
+	MaintenanceWindowGet = maintenanceWindowSelect + `
+		WHERE id = $1`
+
+	// MaintenanceWindowUpdate updates the definition of a maintenance window.
+	MaintenanceWindowUpdate = `
+		UPDATE maintenance_windows
+		SET name = $2, description = $3, service_id = $4, agent_pool = $5,
+			cron_expression = $6, timezone = $7, duration_seconds = $8,
+			starts_at = $9, ends_at = $10, notifications = $11, enabled = $12
+		WHERE id = $1
+		RETURNING updated_at`
+
+	// MaintenanceWindowDelete deletes a maintenance window.
+	MaintenanceWindowDelete = `
+		DELETE FROM maintenance_windows
+		WHERE id = $1`
+
+	// MaintenanceWindowList lists the maintenance windows by name.
+	MaintenanceWindowList = maintenanceWindowSelect + `
+		ORDER BY name, id`
+
+	// MaintenanceWindowListEnabled lists the enabled maintenance windows
+	// that have not ended.
+	MaintenanceWindowListEnabled = maintenanceWindowSelect + `
+		WHERE enabled AND (ends_at IS NULL OR ends_at > NOW())`
+)
+
+// Idempotency key queries
+const (
+	// IdempotencyKeyClaim records a key unless an unexpired key with the same
+	// scope exists. It returns no row when the key is taken.
+	IdempotencyKeyClaim = `
+		INSERT INTO idempotency_keys (scope, key, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (scope, key) DO UPDATE SET
+			request_hash = EXCLUDED.request_hash,
+			response = NULL,
+			created_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+		RETURNING created_at`
+
+	// IdempotencyKeyGet retrieves a key.
+	IdempotencyKeyGet = `
+		SELECT scope, key, request_hash, response, created_at, expires_at
+		FROM idempotency_keys
+		WHERE scope = $1 AND key = $2`
+
+	// IdempotencyKeyComplete stores the response of a key in progress.
+	IdempotencyKeyComplete = `
+		UPDATE idempotency_keys
+		SET response = $3, expires_at = $4
+		WHERE scope = $1 AND key = $2 AND response IS NULL`
+
+	// IdempotencyKeyRelease deletes a key in progress.
+	IdempotencyKeyRelease = `
+		DELETE FROM idempotency_keys
+		WHERE scope = $1 AND key = $2 AND response IS NULL`
+
+	// IdempotencyKeyDeleteExpired deletes the keys that expired before $1.
+	IdempotencyKeyDeleteExpired = `
+		DELETE FROM idempotency_keys
+		WHERE expires_at <= $1`
 )
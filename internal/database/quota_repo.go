@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// serviceQuotaRepo implements ServiceQuotaRepository.
+type serviceQuotaRepo struct {
+	db *DB
+}
+
+// NewServiceQuotaRepo creates a new service quota repository.
+func NewServiceQuotaRepo(db *DB) ServiceQuotaRepository {
+	return &serviceQuotaRepo{db: db}
+}
+
+// Upsert creates or replaces the quota of a service.
+func (r *serviceQuotaRepo) Upsert(ctx context.Context, quota *ServiceQuota) error {
+	err := r.db.pool.QueryRow(ctx, ServiceQuotaUpsert,
+		quota.ServiceID,
+		quota.MaxRunsPerDay,
+		quota.MaxMinutesPerMonth,
+	).Scan(&quota.CreatedAt, &quota.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save service quota: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// GetByService retrieves the quota of a service.
+func (r *serviceQuotaRepo) GetByService(ctx context.Context, serviceID uuid.UUID) (*ServiceQuota, error) {
+	quota := &ServiceQuota{}
+	err := r.db.pool.QueryRow(ctx, ServiceQuotaGetByService, serviceID).Scan(
+		&quota.ServiceID,
+		&quota.MaxRunsPerDay,
+		&quota.MaxMinutesPerMonth,
+		&quota.CreatedAt,
+		&quota.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get service quota: %w", err)
+	}
+	return quota, nil
+}
+
+// Delete deletes the quota of a service.
+func (r *serviceQuotaRepo) Delete(ctx context.Context, serviceID uuid.UUID) error {
+	result, err := r.db.pool.Exec(ctx, ServiceQuotaDelete, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to delete service quota: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Usage returns the runs a service created since dayStart and the execution
+// minutes its shards used since monthStart.
+func (r *serviceQuotaRepo) Usage(ctx context.Context, serviceID uuid.UUID, dayStart, monthStart time.Time) (*ServiceQuotaUsage, error) {
+	usage := &ServiceQuotaUsage{}
+	var seconds int64
+	err := r.db.pool.QueryRow(ctx, ServiceQuotaGetUsage, serviceID, dayStart, monthStart).Scan(&usage.RunsToday, &seconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service quota usage: %w", err)
+	}
+	usage.MinutesThisMonth = int((seconds + 59) / 60)
+	return usage, nil
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// durationRegressionRepo implements DurationRegressionRepository.
+type durationRegressionRepo struct {
+	db *DB
+}
+
+// NewDurationRegressionRepo creates a new duration regression repository.
+func NewDurationRegressionRepo(db *DB) DurationRegressionRepository {
+	return &durationRegressionRepo{db: db}
+}
+
+// Create records a regression. A run or test regresses at most once per run;
+// recording it again is a no-op.
+func (r *durationRegressionRepo) Create(ctx context.Context, regression *DurationRegression) error {
+	err := r.db.pool.QueryRow(ctx, DurationRegressionInsert,
+		regression.RunID,
+		regression.TestName,
+		regression.DurationMs,
+		regression.BaselineMeanMs,
+		regression.BaselineStdDevMs,
+		regression.Sigma,
+		regression.BaselineRuns,
+	).Scan(&regression.ID, &regression.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("failed to create duration regression: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// ListByRun lists the regressions of a run, the run itself first.
+func (r *durationRegressionRepo) ListByRun(ctx context.Context, runID uuid.UUID) ([]DurationRegression, error) {
+	rows, err := r.db.reader(ctx).Query(ctx, DurationRegressionListByRun, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list duration regressions: %w", err)
+	}
+	defer rows.Close()
+
+	var regressions []DurationRegression
+	for rows.Next() {
+		var reg DurationRegression
+		err := rows.Scan(
+			&reg.ID,
+			&reg.RunID,
+			&reg.TestName,
+			&reg.DurationMs,
+			&reg.BaselineMeanMs,
+			&reg.BaselineStdDevMs,
+			&reg.Sigma,
+			&reg.BaselineRuns,
+			&reg.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan duration regression: %w", err)
+		}
+		regressions = append(regressions, reg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating duration regressions: %w", err)
+	}
+
+	return regressions, nil
+}
+
+// RunDurationBaseline lists the durations of the latest passed runs of the
+// run's service and branch created before it, newest first.
+func (r *durationRegressionRepo) RunDurationBaseline(ctx context.Context, run *TestRun, limit int) ([]int64, error) {
+	rows, err := r.db.pool.Query(ctx, RunDurationBaseline, run.ServiceID, run.GitRef, run.CreatedAt, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run duration baseline: %w", err)
+	}
+	defer rows.Close()
+
+	var durations []int64
+	for rows.Next() {
+		var ms int64
+		if err := rows.Scan(&ms); err != nil {
+			return nil, fmt.Errorf("failed to scan run duration: %w", err)
+		}
+		durations = append(durations, ms)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating run durations: %w", err)
+	}
+
+	return durations, nil
+}
+
+// TestDurationBaselines lists the passed tests of a run with the durations
+// of their latest passed results in earlier runs of the service and branch.
+func (r *durationRegressionRepo) TestDurationBaselines(ctx context.Context, run *TestRun, limit int) ([]TestDurationBaseline, error) {
+	rows, err := r.db.pool.Query(ctx, TestDurationBaselines, run.ID, run.ServiceID, run.GitRef, run.CreatedAt, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test duration baselines: %w", err)
+	}
+	defer rows.Close()
+
+	var baselines []TestDurationBaseline
+	for rows.Next() {
+		var b TestDurationBaseline
+		if err := rows.Scan(&b.TestName, &b.DurationMs, &b.BaselineMs); err != nil {
+			return nil, fmt.Errorf("failed to scan test duration baseline: %w", err)
+		}
+		baselines = append(baselines, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating test duration baselines: %w", err)
+	}
+
+	return baselines, nil
+}
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// replicaLagQuery measures how far a replica is behind its primary. A replica
+// that has replayed everything it received is not behind, even if the
+// primary has been idle since its last transaction.
+const replicaLagQuery = `
+	SELECT CASE
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END::float8`
+
+type replicaReadsKey struct{}
+
+// WithReplicaReads returns a context whose read-only repository queries may
+// be served by the read replica. Use it for requests that tolerate reading
+// slightly stale data, such as read-only API calls; queries made with other
+// contexts, such as those of background jobs and of requests that write, go
+// to the primary.
+func WithReplicaReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaReadsKey{}, true)
+}
+
+// replicaReads reports whether ctx allows reading from the replica.
+func replicaReads(ctx context.Context) bool {
+	ok, _ := ctx.Value(replicaReadsKey{}).(bool)
+	return ok
+}
+
+// reader returns where a read-only query made with ctx is sent: the replica
+// if ctx allows it and the replica is caught up, otherwise the primary.
+func (db *DB) reader(ctx context.Context) Querier {
+	if db.replica != nil && replicaReads(ctx) && db.replica.usable.Load() {
+		return db.replica.pool
+	}
+	return db.pool
+}
+
+// replicaState is what a replication lag check found.
+type replicaState int
+
+const (
+	replicaUnchecked replicaState = iota
+	replicaCaughtUp
+	replicaLagging
+	replicaUnreachable
+)
+
+// replica is a read replica whose replication lag is checked periodically.
+// Reads fall back to the primary while the replica is unreachable or lags
+// more than maxLag.
+type replica struct {
+	pool     *pgxpool.Pool
+	maxLag   time.Duration
+	interval time.Duration
+	logger   *slog.Logger
+
+	// measureLag returns the replication lag of the replica.
+	measureLag func(ctx context.Context) (time.Duration, error)
+
+	usable atomic.Bool
+	lag    atomic.Int64
+
+	// state is what the last check found. Only check uses it.
+	state replicaState
+
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+func newReplica(pool *pgxpool.Pool, cfg Config) *replica {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	r := &replica{
+		pool:     pool,
+		maxLag:   cfg.ReplicaMaxLag,
+		interval: cfg.ReplicaCheckInterval,
+		logger:   logger.With("component", "database_replica"),
+	}
+	if r.maxLag <= 0 {
+		r.maxLag = 10 * time.Second
+	}
+	if r.interval <= 0 {
+		r.interval = 5 * time.Second
+	}
+	r.measureLag = func(ctx context.Context) (time.Duration, error) {
+		var seconds float64
+		if err := r.pool.QueryRow(ctx, replicaLagQuery).Scan(&seconds); err != nil {
+			return 0, err
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	return r
+}
+
+// start checks the lag once, so that reads use the replica right away if it
+// is caught up, then keeps checking it until close.
+func (r *replica) start(ctx context.Context) {
+	r.check(ctx)
+
+	monitorCtx, stop := context.WithCancel(context.WithoutCancel(ctx))
+	r.stop = stop
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-monitorCtx.Done():
+				return
+			case <-ticker.C:
+				r.check(monitorCtx)
+			}
+		}
+	}()
+}
+
+// check measures the replication lag and decides whether reads use the
+// replica. Changes are logged.
+func (r *replica) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, r.interval)
+	defer cancel()
+
+	lag, err := r.measureLag(ctx)
+	state := replicaCaughtUp
+	switch {
+	case err != nil:
+		state = replicaUnreachable
+	case lag > r.maxLag:
+		state = replicaLagging
+	}
+	if err == nil {
+		r.lag.Store(int64(lag))
+	}
+	r.usable.Store(state == replicaCaughtUp)
+
+	if state == r.state {
+		return
+	}
+	r.state = state
+	switch state {
+	case replicaCaughtUp:
+		r.logger.Info("read replica caught up, reading from replica", "lag", lag)
+	case replicaLagging:
+		r.logger.Warn("read replica lags behind, reading from primary", "lag", lag, "max_lag", r.maxLag)
+	case replicaUnreachable:
+		r.logger.Warn("read replica unreachable, reading from primary", "error", err)
+	}
+}
+
+// close stops the lag checks and closes the replica pool.
+func (r *replica) close() {
+	if r.stop != nil {
+		r.stop()
+	}
+	r.wg.Wait()
+	r.pool.Close()
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_Reader(t *testing.T) {
+	primary, replicaPool := &pgxpool.Pool{}, &pgxpool.Pool{}
+	r := &replica{pool: replicaPool}
+	db := &DB{pool: primary, replica: r}
+	ctx := context.Background()
+
+	r.usable.Store(true)
+	assert.Same(t, primary, db.reader(ctx), "reads go to the primary unless the context allows the replica")
+	assert.Same(t, replicaPool, db.reader(WithReplicaReads(ctx)))
+
+	r.usable.Store(false)
+	assert.Same(t, primary, db.reader(WithReplicaReads(ctx)), "reads fall back to the primary")
+
+	assert.Same(t, primary, (&DB{pool: primary}).reader(WithReplicaReads(ctx)), "reads go to the primary without a replica")
+}
+
+func TestReplica_Check(t *testing.T) {
+	var logs bytes.Buffer
+	r := newReplica(nil, Config{
+		ReplicaMaxLag:        time.Second,
+		ReplicaCheckInterval: time.Second,
+		Logger:               slog.New(slog.NewJSONHandler(&logs, nil)),
+	})
+	var lag time.Duration
+	var lagErr error
+	r.measureLag = func(ctx context.Context) (time.Duration, error) {
+		return lag, lagErr
+	}
+	ctx := context.Background()
+
+	r.check(ctx)
+	assert.True(t, r.usable.Load())
+	assert.Contains(t, logs.String(), "read replica caught up")
+
+	logs.Reset()
+	lag = 500 * time.Millisecond
+	r.check(ctx)
+	assert.True(t, r.usable.Load())
+	assert.Empty(t, logs.String(), "only changes are logged")
+
+	lag = 3 * time.Second
+	r.check(ctx)
+	assert.False(t, r.usable.Load(), "reads fall back to the primary while the replica lags")
+	assert.Equal(t, int64(3*time.Second), r.lag.Load())
+	assert.Contains(t, logs.String(), "read replica lags behind")
+
+	logs.Reset()
+	lagErr = errors.New("connection refused")
+	r.check(ctx)
+	assert.False(t, r.usable.Load())
+	assert.Contains(t, logs.String(), "read replica unreachable")
+
+	lag, lagErr = 0, nil
+	r.check(ctx)
+	assert.True(t, r.usable.Load(), "reads go back to the replica once it caught up")
+}
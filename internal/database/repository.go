@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/google/uuid"
@@ -56,6 +57,135 @@ type TestDefinitionRepository interface {
 
 	// ListByTags returns test definitions matching any of the given tags.
 	ListByTags(ctx context.Context, serviceID uuid.UUID, tags []string, page Pagination) ([]TestDefinition, error)
+
+	// ApplySync creates and updates test definitions and saves the service
+	// environment set and concurrency group in a single transaction.
+	ApplySync(ctx context.Context, sync *TestDefinitionSync) error
+}
+
+// DeployKeyRepository defines the interface for service deploy key operations.
+type DeployKeyRepository interface {
+	// Upsert creates or replaces the deploy key for a service.
+	Upsert(ctx context.Context, key *ServiceDeployKey) error
+
+	// GetByService retrieves the deploy key for a service.
+	GetByService(ctx context.Context, serviceID uuid.UUID) (*ServiceDeployKey, error)
+
+	// Delete removes the deploy key for a service.
+	Delete(ctx context.Context, serviceID uuid.UUID) error
+}
+
+// GitCredentialRepository defines the interface for service git credential operations.
+type GitCredentialRepository interface {
+	// Upsert creates or replaces the git credential for a service.
+	Upsert(ctx context.Context, cred *ServiceGitCredential) error
+
+	// GetByService retrieves the git credential for a service.
+	GetByService(ctx context.Context, serviceID uuid.UUID) (*ServiceGitCredential, error)
+
+	// Delete removes the git credential for a service.
+	Delete(ctx context.Context, serviceID uuid.UUID) error
+}
+
+// ServiceEnvironmentRepository defines the interface for service environment set operations.
+type ServiceEnvironmentRepository interface {
+	// Upsert creates or replaces the environment set for a service.
+	Upsert(ctx context.Context, env *ServiceEnvironment) error
+
+	// GetByService retrieves the environment set for a service.
+	GetByService(ctx context.Context, serviceID uuid.UUID) (*ServiceEnvironment, error)
+
+	// Delete removes the environment set for a service.
+	Delete(ctx context.Context, serviceID uuid.UUID) error
+}
+
+// ServiceQuotaRepository defines the interface for service quota operations.
+type ServiceQuotaRepository interface {
+	// Upsert creates or replaces the quota of a service.
+	Upsert(ctx context.Context, quota *ServiceQuota) error
+
+	// GetByService retrieves the quota of a service.
+	GetByService(ctx context.Context, serviceID uuid.UUID) (*ServiceQuota, error)
+
+	// Delete deletes the quota of a service.
+	Delete(ctx context.Context, serviceID uuid.UUID) error
+
+	// Usage returns the runs a service created since dayStart and the
+	// execution minutes its shards used since monthStart.
+	Usage(ctx context.Context, serviceID uuid.UUID, dayStart, monthStart time.Time) (*ServiceQuotaUsage, error)
+}
+
+// ConcurrencyGroupRepository defines the interface for concurrency group operations.
+type ConcurrencyGroupRepository interface {
+	// GetByService retrieves the concurrency group of a service.
+	GetByService(ctx context.Context, serviceID uuid.UUID) (*ServiceConcurrency, error)
+
+	// Claim makes a run the holder of its concurrency group unless another
+	// pending or running run holds it, and reports whether the run holds it.
+	Claim(ctx context.Context, group string, runID uuid.UUID) (bool, error)
+
+	// ListActive returns the IDs of the pending and running runs of a group,
+	// oldest first.
+	ListActive(ctx context.Context, group string) ([]uuid.UUID, error)
+}
+
+// ServiceTriggerRulesRepository defines the interface for service trigger rule operations.
+type ServiceTriggerRulesRepository interface {
+	// Upsert creates or replaces the trigger rules for a service.
+	Upsert(ctx context.Context, rules *ServiceTriggerRules) error
+
+	// GetByService retrieves the trigger rules for a service.
+	GetByService(ctx context.Context, serviceID uuid.UUID) (*ServiceTriggerRules, error)
+
+	// Delete removes the trigger rules for a service.
+	Delete(ctx context.Context, serviceID uuid.UUID) error
+}
+
+// RetryPolicyRepository defines the interface for run retry policy operations.
+type RetryPolicyRepository interface {
+	// Upsert creates or replaces the policy of a service or, if
+	// TestDefinitionID is set, of a test definition.
+	Upsert(ctx context.Context, policy *RetryPolicy) error
+
+	// ListByService retrieves the service policy and test definition policies of a service.
+	ListByService(ctx context.Context, serviceID uuid.UUID) ([]RetryPolicy, error)
+
+	// Delete removes the policy of a service or, if testDefID is set, of a test definition.
+	Delete(ctx context.Context, serviceID uuid.UUID, testDefID *uuid.UUID) error
+}
+
+// ServiceSyncRepository defines the interface for test definition sync history.
+type ServiceSyncRepository interface {
+	// Record stores a finished sync.
+	Record(ctx context.Context, sync *ServiceSync) error
+
+	// Latest retrieves the most recent sync of a service.
+	Latest(ctx context.Context, serviceID uuid.UUID) (*ServiceSync, error)
+
+	// ListByService lists a service's syncs, most recent first.
+	ListByService(ctx context.Context, serviceID uuid.UUID, page Pagination) ([]ServiceSync, int, error)
+
+	// ListDue lists up to limit services whose periodic sync is due.
+	// defaultInterval applies to services without their own interval.
+	ListDue(ctx context.Context, defaultInterval time.Duration, limit int) ([]Service, error)
+}
+
+// BranchRepository defines the interface for branch operations.
+type BranchRepository interface {
+	// RecordPush creates a branch or moves it to the pushed commit.
+	RecordPush(ctx context.Context, serviceID uuid.UUID, name, sha string) error
+
+	// Get retrieves a branch of a service by name.
+	Get(ctx context.Context, serviceID uuid.UUID, name string) (*Branch, error)
+
+	// ListByService lists a service's branches.
+	ListByService(ctx context.Context, serviceID uuid.UUID, page Pagination) ([]Branch, int, error)
+
+	// SetProtected marks a branch as protected or not.
+	SetProtected(ctx context.Context, serviceID uuid.UUID, name string, protected bool) error
+
+	// Delete removes a branch of a service.
+	Delete(ctx context.Context, serviceID uuid.UUID, name string) error
 }
 
 // AgentRepository defines the interface for agent data operations.
@@ -90,13 +220,39 @@ type AgentRepository interface {
 	// GetAvailable returns agents available to run tests for services in the given zones.
 	GetAvailable(ctx context.Context, zones []string, limit int) ([]Agent, error)
 
-	// MarkOfflineAgents marks agents as offline if they haven't sent a heartbeat recently.
-	MarkOfflineAgents(ctx context.Context) (int64, error)
+	// MarkOfflineAgents marks agents as offline whose last heartbeat is older
+	// than timeout and returns them.
+	MarkOfflineAgents(ctx context.Context, timeout time.Duration) ([]Agent, error)
 
 	// CountByStatus returns the count of agents grouped by status.
 	CountByStatus(ctx context.Context) (map[AgentStatus]int64, error)
 }
 
+// AgentPoolRepository defines the interface for agent pool operations.
+type AgentPoolRepository interface {
+	// Create creates a new agent pool.
+	Create(ctx context.Context, pool *AgentPool) error
+
+	// GetByName retrieves an agent pool by name.
+	GetByName(ctx context.Context, name string) (*AgentPool, error)
+
+	// List returns all agent pools.
+	List(ctx context.Context) ([]AgentPool, error)
+
+	// Update updates the description, quotas and artifact limit of an agent pool.
+	Update(ctx context.Context, pool *AgentPool) error
+
+	// Delete deletes an agent pool by name.
+	Delete(ctx context.Context, name string) error
+
+	// Stats returns the current usage of every agent pool.
+	Stats(ctx context.Context) ([]AgentPoolStats, error)
+
+	// RunningShardsByService counts the running shards on the agents of a
+	// pool per service.
+	RunningShardsByService(ctx context.Context, name string) (map[uuid.UUID]int, error)
+}
+
 // TestRunRepository defines the interface for test run data operations.
 type TestRunRepository interface {
 	// Create creates a new test run.
@@ -111,12 +267,32 @@ type TestRunRepository interface {
 	// UpdateStatus updates only the run's status.
 	UpdateStatus(ctx context.Context, id uuid.UUID, status RunStatus) error
 
+	// Approve moves a run waiting for approval to pending and records who
+	// approved it. It returns ErrNotFound unless the run is waiting for
+	// approval.
+	Approve(ctx context.Context, id uuid.UUID, approvedBy string) error
+
+	// Retarget moves a pending run, or a run waiting for approval, to
+	// another commit. It returns ErrNotFound once the run or one of its
+	// shards has been scheduled.
+	Retarget(ctx context.Context, id uuid.UUID, gitSHA *string) error
+
+	// Annotate merges annotations and links into the metadata of a run and
+	// returns the result. Empty values remove their keys. It returns
+	// ErrNotFound if the run does not exist.
+	Annotate(ctx context.Context, id uuid.UUID, metadata RunMetadata) (*RunMetadata, error)
+
 	// Start marks a run as started with the given agent.
 	Start(ctx context.Context, id uuid.UUID, agentID uuid.UUID) error
 
 	// Finish marks a run as finished with results.
 	Finish(ctx context.Context, id uuid.UUID, status RunStatus, results RunResults) error
 
+	// FinishActive marks a pending or running run as finished with results.
+	// It returns ErrNotFound once the run has finished, so a completion
+	// reported more than once finishes the run once.
+	FinishActive(ctx context.Context, id uuid.UUID, status RunStatus, results RunResults) error
+
 	// UpdateShardStats updates shard completion and result counts.
 	UpdateShardStats(ctx context.Context, id uuid.UUID, completed int, failed int, results RunResults) error
 
@@ -135,9 +311,25 @@ type TestRunRepository interface {
 	// ListByDateRange returns test runs within a date range.
 	ListByDateRange(ctx context.Context, start, end time.Time, page Pagination) ([]TestRun, error)
 
+	// ListByAgent returns test runs an agent executed, or executed a shard
+	// of, that started within a time range.
+	ListByAgent(ctx context.Context, agentID uuid.UUID, start, end time.Time, page Pagination) ([]TestRun, error)
+
+	// Search returns the runs matching the filter, newest first, with the
+	// total number of matches.
+	Search(ctx context.Context, filter RunSearchFilter, page Pagination) ([]TestRun, int, error)
+
 	// GetPending returns pending runs ordered by priority.
 	GetPending(ctx context.Context, limit int) ([]TestRun, error)
 
+	// ReserveResults accepts up to n results for the run without exceeding
+	// limit stored results. It returns how many were accepted and the run's
+	// total dropped results; the rest must be discarded by the caller.
+	ReserveResults(ctx context.Context, id uuid.UUID, n, limit int) (accepted int, dropped int64, err error)
+
+	// ReserveArtifacts is like ReserveResults for artifacts.
+	ReserveArtifacts(ctx context.Context, id uuid.UUID, n, limit int) (accepted int, dropped int64, err error)
+
 	// GetRunning returns currently running tests.
 	GetRunning(ctx context.Context) ([]TestRun, error)
 
@@ -163,7 +355,8 @@ type ResultRepository interface {
 	// Create creates a new test result.
 	Create(ctx context.Context, result *TestResult) error
 
-	// BatchCreate creates multiple test results in a single operation.
+	// BatchCreate creates multiple test results in a single operation and
+	// assigns their IDs and creation times.
 	BatchCreate(ctx context.Context, results []TestResult) error
 
 	// Get retrieves a test result by ID.
@@ -175,6 +368,18 @@ type ResultRepository interface {
 	// ListByRunAndStatus returns results for a run with a specific status.
 	ListByRunAndStatus(ctx context.Context, runID uuid.UUID, status ResultStatus) ([]TestResult, error)
 
+	// ListPage returns a page of the results for a run, sorted by test name,
+	// keeping those with any of the statuses if given.
+	ListPage(ctx context.Context, runID uuid.UUID, statuses []ResultStatus, page Pagination) ([]TestResult, error)
+
+	// SearchByRun returns up to limit results of a run whose error message or
+	// stack trace matches text like RunSearchFilter.Text, failures first.
+	SearchByRun(ctx context.Context, runID uuid.UUID, text string, limit int) ([]TestResult, error)
+
+	// GetLastPassing returns the latest passing result of a test in a service
+	// created before the given time.
+	GetLastPassing(ctx context.Context, serviceID uuid.UUID, testName string, suiteName *string, before time.Time) (*TestResult, error)
+
 	// CountByRun returns the count of results grouped by status for a run.
 	CountByRun(ctx context.Context, runID uuid.UUID) (map[ResultStatus]int64, error)
 
@@ -182,6 +387,27 @@ type ResultRepository interface {
 	DeleteByRun(ctx context.Context, runID uuid.UUID) error
 }
 
+// ResultPartitionRepository defines the interface for the maintenance of
+// the monthly partitions of test results.
+type ResultPartitionRepository interface {
+	// EnsurePartition creates the partition of the month containing t, if it
+	// does not exist. Results of the month that were stored in the default
+	// partition are moved to it.
+	EnsurePartition(ctx context.Context, t time.Time) error
+
+	// ListPartitions lists the monthly partitions, oldest first.
+	ListPartitions(ctx context.Context) ([]ResultPartition, error)
+
+	// ExportPartition writes the results of a partition to w as CSV with a
+	// header row and returns how many were written.
+	ExportPartition(ctx context.Context, partition ResultPartition, w io.Writer) (int64, error)
+
+	// DropPartition drops a partition and records the month as archived to
+	// objectPath, or as dropped without export if objectPath is nil. It
+	// returns how many results were dropped.
+	DropPartition(ctx context.Context, partition ResultPartition, objectPath *string) (int64, error)
+}
+
 // ArtifactRepository defines the interface for artifact data operations.
 type ArtifactRepository interface {
 	// Create creates a new artifact record.
@@ -193,9 +419,25 @@ type ArtifactRepository interface {
 	// ListByRun returns all artifacts for a test run.
 	ListByRun(ctx context.Context, runID uuid.UUID) ([]Artifact, error)
 
+	// UsageByTest returns the number and total size of the artifacts
+	// recorded for a test definition in a run.
+	UsageByTest(ctx context.Context, runID, testID uuid.UUID) (files int, bytes int64, err error)
+
 	// ListOlderThan returns artifacts older than a timestamp.
 	ListOlderThan(ctx context.Context, before time.Time, limit int) ([]Artifact, error)
 
+	// ListForArchival returns STANDARD storage class artifacts older than a timestamp.
+	ListForArchival(ctx context.Context, before time.Time, limit int) ([]Artifact, error)
+
+	// MarkArchived records that an artifact moved to another storage class.
+	MarkArchived(ctx context.Context, id uuid.UUID, storageClass string, at time.Time) error
+
+	// MarkRestoreRequested records that a restore of an archived artifact was requested.
+	MarkRestoreRequested(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	// MarkRestored records until when the restored copy of an archived artifact is available.
+	MarkRestored(ctx context.Context, id uuid.UUID, until time.Time) error
+
 	// Delete deletes an artifact record.
 	Delete(ctx context.Context, id uuid.UUID) error
 
@@ -203,6 +445,30 @@ type ArtifactRepository interface {
 	DeleteByRun(ctx context.Context, runID uuid.UUID) error
 }
 
+// ArtifactBlobRepository defines operations for content-addressed artifact
+// blobs. Reference counts are kept by the database as artifacts holding a
+// blob are recorded and deleted.
+type ArtifactBlobRepository interface {
+	// Upsert records a stored blob, or marks an existing blob with the same
+	// digest as referenced now. The blob is updated with the stored record.
+	Upsert(ctx context.Context, blob *ArtifactBlob) error
+
+	// Touch marks a blob as referenced now and returns it.
+	Touch(ctx context.Context, digest string) (*ArtifactBlob, error)
+
+	// Get retrieves a blob by digest.
+	Get(ctx context.Context, digest string) (*ArtifactBlob, error)
+
+	// ListUnreferenced returns blobs no artifact holds that were last
+	// referenced before a timestamp.
+	ListUnreferenced(ctx context.Context, before time.Time, limit int) ([]ArtifactBlob, error)
+
+	// DeleteUnreferenced deletes a blob record if it is still unreferenced
+	// and was last referenced before a timestamp. It returns ErrNotFound if
+	// the blob was referenced again.
+	DeleteUnreferenced(ctx context.Context, digest string, before time.Time) error
+}
+
 // RunShardRepository defines operations for run shard data.
 type RunShardRepository interface {
 	// Create creates a new shard record.
@@ -223,9 +489,19 @@ type RunShardRepository interface {
 	// Finish marks a shard as finished with counts.
 	Finish(ctx context.Context, id uuid.UUID, status ShardStatus, results RunResults) error
 
+	// FinishRunning marks a shard running on an agent as finished with
+	// counts. It returns ErrNotFound unless the shard is running on the
+	// agent, so a completion reported more than once, or after the shard
+	// was requeued, finishes the shard once.
+	FinishRunning(ctx context.Context, id uuid.UUID, agentID uuid.UUID, status ShardStatus, results RunResults) error
+
 	// Reset resets a shard for retry.
 	Reset(ctx context.Context, id uuid.UUID) error
 
+	// RequeueOrphaned resets the running shards of offline agents to pending
+	// and returns them.
+	RequeueOrphaned(ctx context.Context) ([]RunShard, error)
+
 	// DeleteByRun deletes shard records for a run.
 	DeleteByRun(ctx context.Context, runID uuid.UUID) error
 }
@@ -267,6 +543,35 @@ type NotificationRepository interface {
 
 	// ListRulesByChannel returns rules for a channel.
 	ListRulesByChannel(ctx context.Context, channelID uuid.UUID) ([]NotificationRule, error)
+
+	// RecordDelivery records a notification sent to a channel.
+	RecordDelivery(ctx context.Context, delivery *NotificationDelivery) error
+
+	// GetDelivery retrieves a delivery by ID, including its payload.
+	GetDelivery(ctx context.Context, id uuid.UUID) (*NotificationDelivery, error)
+
+	// ListDeliveries returns deliveries matching the filter, newest first,
+	// with the total number of matches.
+	ListDeliveries(ctx context.Context, filter NotificationDeliveryFilter, page Pagination) ([]NotificationDelivery, int, error)
+
+	// CreateDeadLetter dead-letters a failed delivery.
+	CreateDeadLetter(ctx context.Context, deadLetter *NotificationDeadLetter) error
+
+	// GetDeadLetterByDelivery retrieves the dead letter of a failed delivery.
+	GetDeadLetterByDelivery(ctx context.Context, deliveryID uuid.UUID) (*NotificationDeadLetter, error)
+
+	// UpdateDeadLetter updates a dead letter after a failed redelivery.
+	UpdateDeadLetter(ctx context.Context, deadLetter *NotificationDeadLetter) error
+
+	// DeleteDeadLetter removes a dead letter.
+	DeleteDeadLetter(ctx context.Context, id uuid.UUID) error
+
+	// ListDueDeadLetters returns up to limit dead letters due for
+	// redelivery at now, most overdue first.
+	ListDueDeadLetters(ctx context.Context, now time.Time, limit int) ([]NotificationDeadLetter, error)
+
+	// CountDeadLetters returns the depth of the dead-letter queue.
+	CountDeadLetters(ctx context.Context) (*NotificationDeadLetterCounts, error)
 }
 
 // ScheduleRepository defines the interface for scheduled run data operations.
@@ -327,34 +632,306 @@ type AnalyticsRepository interface {
 
 	// GetServiceHealthSummaryByID retrieves health summary for a specific service.
 	GetServiceHealthSummaryByID(ctx context.Context, serviceID uuid.UUID) (*ServiceHealthSummary, error)
+
+	// GetServiceReports computes the reports of the services matching the
+	// filter, ordered by service name.
+	GetServiceReports(ctx context.Context, filter ServiceReportFilter) ([]ServiceReport, error)
+
+	// RefreshServiceReports recomputes the materialized views service
+	// reports are read from.
+	RefreshServiceReports(ctx context.Context) error
+}
+
+// EnergyRepository defines the interface for run energy estimates.
+type EnergyRepository interface {
+	// RecordSample records an energy sample of a run.
+	RecordSample(ctx context.Context, sample *EnergySample) error
+
+	// GetRunEnergy sums the energy of a run per zone.
+	GetRunEnergy(ctx context.Context, runID uuid.UUID) ([]ZoneEnergy, error)
+
+	// GetMonthlyEnergy sums the energy of runs sampled in [start, end) per
+	// service, month and zone. A nil serviceID includes every service.
+	GetMonthlyEnergy(ctx context.Context, serviceID *uuid.UUID, start, end time.Time) ([]MonthlyEnergy, error)
+}
+
+// RunAnnotationRepository defines the interface for run annotations.
+type RunAnnotationRepository interface {
+	// Create records an annotation of a run.
+	Create(ctx context.Context, annotation *RunAnnotation) error
+
+	// ListByRun lists the annotations of a run, oldest first.
+	ListByRun(ctx context.Context, runID uuid.UUID) ([]RunAnnotation, error)
+}
+
+// DurationRegressionRepository defines the interface for run duration
+// regressions and the baselines they are detected against.
+type DurationRegressionRepository interface {
+	// Create records a regression. A run or test regresses at most once per
+	// run; recording it again is a no-op.
+	Create(ctx context.Context, regression *DurationRegression) error
+
+	// ListByRun lists the regressions of a run, the run itself first.
+	ListByRun(ctx context.Context, runID uuid.UUID) ([]DurationRegression, error)
+
+	// RunDurationBaseline lists the durations of the latest passed runs of
+	// the run's service and branch created before it, newest first.
+	RunDurationBaseline(ctx context.Context, run *TestRun, limit int) ([]int64, error)
+
+	// TestDurationBaselines lists the passed tests of a run with the
+	// durations of their latest passed results in earlier runs of the
+	// service and branch.
+	TestDurationBaselines(ctx context.Context, run *TestRun, limit int) ([]TestDurationBaseline, error)
+}
+
+// EventWebhookRepository defines the interface for run lifecycle events and
+// their deliveries to event webhook endpoints.
+type EventWebhookRepository interface {
+	// ListUndispatchedEvents returns up to limit events that have no
+	// deliveries yet, oldest first.
+	ListUndispatchedEvents(ctx context.Context, limit int) ([]RunEvent, error)
+
+	// DispatchEvent creates a pending delivery of an event to each endpoint
+	// and marks the event dispatched. Deliveries that already exist are
+	// kept.
+	DispatchEvent(ctx context.Context, eventID int64, endpoints []string) error
+
+	// ClaimDueDeliveries returns up to limit pending deliveries due at now
+	// with their events, most overdue first, and postpones them by lease so
+	// that no other control plane claims them while they are attempted.
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]EventWebhookDelivery, error)
+
+	// UpdateDelivery records the outcome of a delivery attempt.
+	UpdateDelivery(ctx context.Context, delivery *EventWebhookDelivery) error
+
+	// DeleteEventsBefore deletes dispatched events recorded before the given
+	// time, with their deliveries, unless a delivery is still pending.
+	DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// AuditLogRepository defines the interface for audit log persistence.
+type AuditLogRepository interface {
+	// Create records an audited call.
+	Create(ctx context.Context, log *AuditLog) error
+
+	// List returns audit logs matching the filter, newest first, with the
+	// total number of matches.
+	List(ctx context.Context, filter AuditLogFilter, page Pagination) ([]AuditLog, int, error)
+
+	// DeleteBefore deletes audit logs recorded before the given time.
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// OrganizationRepository defines the interface for organization persistence.
+type OrganizationRepository interface {
+	// Create creates a new organization.
+	Create(ctx context.Context, org *Organization) error
+
+	// Get retrieves an organization by ID.
+	Get(ctx context.Context, id uuid.UUID) (*Organization, error)
+
+	// List returns organizations by name with the total count.
+	List(ctx context.Context, page Pagination) ([]Organization, int, error)
+}
+
+// ProjectRepository defines the interface for project persistence.
+type ProjectRepository interface {
+	// Create creates a new project.
+	Create(ctx context.Context, project *Project) error
+
+	// Get retrieves a project by ID.
+	Get(ctx context.Context, id uuid.UUID) (*Project, error)
+
+	// ListByOrganization returns the projects of an organization by name
+	// with the total count. Only the projects in the scope of ctx are
+	// returned.
+	ListByOrganization(ctx context.Context, orgID uuid.UUID, page Pagination) ([]Project, int, error)
+}
+
+// PreferenceRepository defines the interface for the dashboard preferences
+// of users: the services they pinned and the views they saved. Users are
+// identified by the subject of their token.
+type PreferenceRepository interface {
+	// PinService pins a service for a user. Pinning it again is a no-op.
+	PinService(ctx context.Context, userID string, serviceID uuid.UUID) error
+
+	// UnpinService unpins a service for a user.
+	UnpinService(ctx context.Context, userID string, serviceID uuid.UUID) error
+
+	// ListPinnedServices lists the services a user pinned, in the order
+	// they were pinned. Only the services in the scope of ctx are returned.
+	ListPinnedServices(ctx context.Context, userID string) ([]Service, error)
+
+	// CreateView saves a view. Saving a default view clears the default
+	// flag of the user's other views of the page and service.
+	CreateView(ctx context.Context, view *SavedView) error
+
+	// GetView retrieves a view of a user by ID.
+	GetView(ctx context.Context, userID string, id uuid.UUID) (*SavedView, error)
+
+	// UpdateView updates the name, filters and default flag of a view. Like
+	// CreateView, making it the default clears the flag of the others.
+	UpdateView(ctx context.Context, view *SavedView) error
+
+	// DeleteView deletes a view of a user.
+	DeleteView(ctx context.Context, userID string, id uuid.UUID) error
+
+	// ListViews lists the views of a user matching the filter, by page and
+	// name.
+	ListViews(ctx context.Context, userID string, filter SavedViewFilter) ([]SavedView, error)
+}
+
+// AgentConnectionRepository defines the interface for the registry of the
+// control plane replica each agent is connected to.
+type AgentConnectionRepository interface {
+	// Register records the replica an agent connected to, replacing the
+	// replica it was connected to before.
+	Register(ctx context.Context, conn *AgentConnection) error
+
+	// Get retrieves the connection of an agent.
+	Get(ctx context.Context, agentID uuid.UUID) (*AgentConnection, error)
+
+	// Unregister removes the connection of an agent to a replica. The
+	// connection the agent made to another replica since is kept.
+	Unregister(ctx context.Context, agentID uuid.UUID, replicaID string) error
+
+	// UnregisterReplica removes every connection to a replica and returns
+	// how many were removed.
+	UnregisterReplica(ctx context.Context, replicaID string) (int64, error)
+}
+
+// SchedulingPauseRepository defines the interface for the pauses of
+// scheduling.
+type SchedulingPauseRepository interface {
+	// Pause pauses scheduling globally if pause.ServiceID is nil, or for
+	// the service otherwise. Pausing again replaces the reason and operator.
+	Pause(ctx context.Context, pause *SchedulingPause) error
+
+	// Resume resumes scheduling globally if serviceID is nil, or for the
+	// service otherwise. It returns ErrNotFound if it was not paused.
+	Resume(ctx context.Context, serviceID *uuid.UUID) error
+
+	// List lists the pauses, the global pause first.
+	List(ctx context.Context) ([]SchedulingPause, error)
+}
+
+// MaintenanceWindowRepository defines the interface for maintenance windows.
+type MaintenanceWindowRepository interface {
+	// Create creates a new maintenance window.
+	Create(ctx context.Context, window *MaintenanceWindow) error
+
+	// Get retrieves a maintenance window by ID.
+	Get(ctx context.Context, id uuid.UUID) (*MaintenanceWindow, error)
+
+	// Update updates a maintenance window.
+	Update(ctx context.Context, window *MaintenanceWindow) error
+
+	// Delete deletes a maintenance window.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// List lists the maintenance windows by name.
+	List(ctx context.Context) ([]MaintenanceWindow, error)
+
+	// ListEnabled lists the enabled maintenance windows.
+	ListEnabled(ctx context.Context) ([]MaintenanceWindow, error)
+}
+
+// IdempotencyKeyRepository defines the interface for the idempotency keys
+// of requests. A request claims its key before it is applied and completes
+// it with its response; retries find the completed key and answer with the
+// response instead of applying the request again.
+type IdempotencyKeyRepository interface {
+	// Claim records a key in progress until key.ExpiresAt. If an unexpired
+	// key with the same scope exists, it is returned instead and the key
+	// is not claimed.
+	Claim(ctx context.Context, key *IdempotencyKey) (*IdempotencyKey, error)
+
+	// Complete stores the response of a claimed key and keeps the key
+	// until expiresAt.
+	Complete(ctx context.Context, scope, key string, response []byte, expiresAt time.Time) error
+
+	// Release deletes a claimed key whose request failed, so that it can be
+	// retried. Completed keys are kept.
+	Release(ctx context.Context, scope, key string) error
+
+	// DeleteExpired deletes the keys that expired before the given time.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
 }
 
 // Repositories aggregates all repository interfaces.
 type Repositories struct {
-	Services        ServiceRepository
-	TestDefinitions TestDefinitionRepository
-	Agents          AgentRepository
-	Runs            TestRunRepository
-	RunShards       RunShardRepository
-	Results         ResultRepository
-	Artifacts       ArtifactRepository
-	Notifications   NotificationRepository
-	Schedules       ScheduleRepository
-	Analytics       AnalyticsRepository
+	Services         ServiceRepository
+	TestDefinitions  TestDefinitionRepository
+	DeployKeys       DeployKeyRepository
+	GitCredentials   GitCredentialRepository
+	Environments     ServiceEnvironmentRepository
+	TriggerRules     ServiceTriggerRulesRepository
+	RetryPolicies    RetryPolicyRepository
+	Syncs            ServiceSyncRepository
+	Branches         BranchRepository
+	Agents           AgentRepository
+	AgentPools       AgentPoolRepository
+	Connections      AgentConnectionRepository
+	SchedulingPauses SchedulingPauseRepository
+	Maintenance      MaintenanceWindowRepository
+	Runs             TestRunRepository
+	RunShards        RunShardRepository
+	Energy           EnergyRepository
+	Annotations      RunAnnotationRepository
+	Regressions      DurationRegressionRepository
+	EventWebhooks    EventWebhookRepository
+	AuditLogs        AuditLogRepository
+	Organizations    OrganizationRepository
+	Projects         ProjectRepository
+	Preferences      PreferenceRepository
+	IdempotencyKeys  IdempotencyKeyRepository
+	Concurrency      ConcurrencyGroupRepository
+	Quotas           ServiceQuotaRepository
+	Results          ResultRepository
+	ResultPartitions ResultPartitionRepository
+	Artifacts        ArtifactRepository
+	ArtifactBlobs    ArtifactBlobRepository
+	Notifications    NotificationRepository
+	Schedules        ScheduleRepository
+	Analytics        AnalyticsRepository
 }
 
 // NewRepositories creates all repository implementations backed by the given database.
 func NewRepositories(db *DB) *Repositories {
 	return &Repositories{
-		Services:        NewServiceRepo(db),
-		TestDefinitions: NewTestDefinitionRepo(db),
-		Agents:          NewAgentRepo(db),
-		Runs:            NewRunRepo(db),
-		RunShards:       NewRunShardRepo(db),
-		Results:         NewResultRepo(db),
-		Artifacts:       NewArtifactRepo(db),
-		Notifications:   NewNotificationRepo(db),
-		Schedules:       NewScheduleRepo(db),
-		Analytics:       NewAnalyticsRepo(db),
+		Services:         NewServiceRepo(db),
+		TestDefinitions:  NewTestDefinitionRepo(db),
+		DeployKeys:       NewDeployKeyRepo(db),
+		GitCredentials:   NewGitCredentialRepo(db),
+		Environments:     NewServiceEnvironmentRepo(db),
+		TriggerRules:     NewServiceTriggerRulesRepo(db),
+		RetryPolicies:    NewRetryPolicyRepo(db),
+		Syncs:            NewServiceSyncRepo(db),
+		Branches:         NewBranchRepo(db),
+		Agents:           NewAgentRepo(db),
+		AgentPools:       NewAgentPoolRepo(db),
+		Connections:      NewAgentConnectionRepo(db),
+		SchedulingPauses: NewSchedulingPauseRepo(db),
+		Maintenance:      NewMaintenanceWindowRepo(db),
+		Runs:             NewRunRepo(db),
+		RunShards:        NewRunShardRepo(db),
+		Energy:           NewEnergyRepo(db),
+		Annotations:      NewRunAnnotationRepo(db),
+		Regressions:      NewDurationRegressionRepo(db),
+		EventWebhooks:    NewEventWebhookRepo(db),
+		AuditLogs:        NewAuditLogRepo(db),
+		Organizations:    NewOrganizationRepo(db),
+		Projects:         NewProjectRepo(db),
+		Preferences:      NewPreferenceRepo(db),
+		IdempotencyKeys:  NewIdempotencyKeyRepo(db),
+		Concurrency:      NewConcurrencyGroupRepo(db),
+		Quotas:           NewServiceQuotaRepo(db),
+		Results:          NewResultRepo(db),
+		ResultPartitions: NewResultPartitionRepo(db),
+		Artifacts:        NewArtifactRepo(db),
+		ArtifactBlobs:    NewArtifactBlobRepo(db),
+		Notifications:    NewNotificationRepo(db),
+		Schedules:        NewScheduleRepo(db),
+		Analytics:        NewAnalyticsRepo(db),
 	}
 }
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// resultPartitionPrefix prefixes the names of the monthly partitions of
+// test_results, which end with the year and month.
+const resultPartitionPrefix = "test_results_"
+
+// resultPartitionRepo implements ResultPartitionRepository.
+type resultPartitionRepo struct {
+	db *DB
+}
+
+// NewResultPartitionRepo creates a new result partition repository.
+func NewResultPartitionRepo(db *DB) ResultPartitionRepository {
+	return &resultPartitionRepo{db: db}
+}
+
+// EnsurePartition creates the partition of the month containing t.
+func (r *resultPartitionRepo) EnsurePartition(ctx context.Context, t time.Time) error {
+	var name string
+	if err := r.db.pool.QueryRow(ctx, ResultPartitionCreate, monthOf(t)).Scan(&name); err != nil {
+		return fmt.Errorf("failed to create result partition: %w", err)
+	}
+	return nil
+}
+
+// ListPartitions lists the monthly partitions, oldest first.
+func (r *resultPartitionRepo) ListPartitions(ctx context.Context) ([]ResultPartition, error) {
+	rows, err := r.db.pool.Query(ctx, ResultPartitionList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list result partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var partitions []ResultPartition
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan result partition: %w", err)
+		}
+		month, err := time.Parse("2006_01", strings.TrimPrefix(name, resultPartitionPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid result partition name %q: %w", name, err)
+		}
+		partitions = append(partitions, ResultPartition{Name: name, Month: month})
+	}
+	return partitions, rows.Err()
+}
+
+// ExportPartition writes the results of a partition to w as CSV.
+func (r *resultPartitionRepo) ExportPartition(ctx context.Context, partition ResultPartition, w io.Writer) (int64, error) {
+	conn, err := r.db.pool.Acquire(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	query := fmt.Sprintf(ResultPartitionExport, partitionIdentifier(partition))
+	tag, err := conn.Conn().PgConn().CopyTo(ctx, w, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to export result partition %s: %w", partition.Name, err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// DropPartition drops a partition and records the month as archived.
+func (r *resultPartitionRepo) DropPartition(ctx context.Context, partition ResultPartition, objectPath *string) (int64, error) {
+	table := partitionIdentifier(partition)
+	var count int64
+	err := r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, fmt.Sprintf(ResultPartitionCount, table)).Scan(&count); err != nil {
+			return fmt.Errorf("failed to count results: %w", err)
+		}
+		if _, err := tx.Exec(ctx, ResultArchiveInsert, partition.Month, objectPath, count); err != nil {
+			return fmt.Errorf("failed to record result archive: %w", err)
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(ResultPartitionDrop, table)); err != nil {
+			return fmt.Errorf("failed to drop partition: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to drop result partition %s: %w", partition.Name, err)
+	}
+	return count, nil
+}
+
+// partitionIdentifier returns the quoted table name of a partition. The name
+// is derived from the month, so that a partition listed by another table's
+// name cannot be exported or dropped.
+func partitionIdentifier(partition ResultPartition) string {
+	return pgx.Identifier{resultPartitionPrefix + partition.Month.Format("2006_01")}.Sanitize()
+}
+
+// monthOf returns the first day of the month containing t, in UTC.
+func monthOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -42,53 +43,163 @@ func (r *resultRepo) Create(ctx context.Context, result *TestResult) error {
 	return nil
 }
 
-// BatchCreate creates multiple test results in a single operation.
+// resultCopyColumns are the columns BatchCreate copies results into.
+var resultCopyColumns = []string{
+	"id", "run_id", "shard_id", "test_definition_id", "test_name", "suite_name", "status",
+	"duration_ms", "error_message", "stack_trace", "stdout", "stderr", "retry_count", "created_at",
+}
+
+// BatchCreate creates multiple test results with a single COPY. The IDs and
+// creation times of the results are assigned before they are copied.
+// Results with a stream sequence whose run already has a result with it are
+// replays and are skipped.
 func (r *resultRepo) BatchCreate(ctx context.Context, results []TestResult) error {
 	if len(results) == 0 {
 		return nil
 	}
 
-	// Use a transaction for batch insert
+	now := time.Now()
+	for i := range results {
+		if results[i].ID == uuid.Nil {
+			results[i].ID = uuid.New()
+		}
+		if results[i].CreatedAt.IsZero() {
+			results[i].CreatedAt = now
+		}
+	}
+
+	if !slices.ContainsFunc(results, func(result TestResult) bool { return result.StreamSequence > 0 }) {
+		return copyResults(ctx, r.db.pool, results)
+	}
+
+	// The sequences are claimed in the transaction storing the results, so
+	// a result and its sequence are stored together or not at all
 	return r.db.WithTx(ctx, func(tx pgx.Tx) error {
-		batch := &pgx.Batch{}
+		fresh, err := claimResultSequences(ctx, tx, results)
+		if err != nil {
+			return err
+		}
+		return copyResults(ctx, tx, fresh)
+	})
+}
+
+// resultCopier copies rows; the pool and transactions implement it.
+type resultCopier interface {
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// copyResults copies results into test_results.
+func copyResults(ctx context.Context, conn resultCopier, results []TestResult) error {
+	if len(results) == 0 {
+		return nil
+	}
 
-		for i := range results {
+	_, err := conn.CopyFrom(ctx, pgx.Identifier{"test_results"}, resultCopyColumns,
+		pgx.CopyFromSlice(len(results), func(i int) ([]any, error) {
 			result := &results[i]
-			batch.Queue(ResultInsert,
+			return []any{
+				result.ID,
 				result.RunID,
 				result.ShardID,
 				result.TestDefinitionID,
 				result.TestName,
 				result.SuiteName,
-				result.Status,
+				string(result.Status),
 				result.DurationMs,
 				result.ErrorMessage,
 				result.StackTrace,
 				result.Stdout,
 				result.Stderr,
 				result.RetryCount,
-			)
+				result.CreatedAt,
+			}, nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create test results: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// resultSequence identifies a result an agent streamed.
+type resultSequence struct {
+	runID    uuid.UUID
+	sequence int64
+}
+
+// claimResultSequences records the stream sequences of results and returns
+// the results without one and those whose sequence was not recorded before.
+func claimResultSequences(ctx context.Context, tx pgx.Tx, results []TestResult) ([]TestResult, error) {
+	var runIDs []uuid.UUID
+	var sequences []int64
+	for _, result := range results {
+		if result.StreamSequence > 0 {
+			runIDs = append(runIDs, result.RunID)
+			sequences = append(sequences, result.StreamSequence)
 		}
+	}
 
-		br := tx.SendBatch(ctx, batch)
-		defer br.Close()
+	rows, err := tx.Query(ctx, ResultClaimSequences, runIDs, sequences)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim result sequences: %w", WrapDBError(err))
+	}
+	claimed := make(map[resultSequence]bool)
+	for rows.Next() {
+		var key resultSequence
+		if err := rows.Scan(&key.runID, &key.sequence); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan result sequence: %w", err)
+		}
+		claimed[key] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim result sequences: %w", WrapDBError(err))
+	}
 
-		// Process all results to check for errors and scan IDs
-		for i := range results {
-			err := br.QueryRow().Scan(&results[i].ID, &results[i].CreatedAt)
-			if err != nil {
-				return fmt.Errorf("failed to create test result %d: %w", i, WrapDBError(err))
+	fresh := make([]TestResult, 0, len(results))
+	for _, result := range results {
+		key := resultSequence{result.RunID, result.StreamSequence}
+		if result.StreamSequence > 0 {
+			// A sequence repeated within the batch is claimed once
+			if !claimed[key] {
+				continue
 			}
+			delete(claimed, key)
 		}
-
-		return nil
-	})
+		fresh = append(fresh, result)
+	}
+	return fresh, nil
 }
 
 // Get retrieves a test result by ID.
 func (r *resultRepo) Get(ctx context.Context, id uuid.UUID) (*TestResult, error) {
+	result, err := scanTestResult(r.db.reader(ctx).QueryRow(ctx, ResultGetByID, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get test result: %w", err)
+	}
+	return result, nil
+}
+
+// GetLastPassing returns the latest passing result of a test in a service
+// created before the given time.
+func (r *resultRepo) GetLastPassing(ctx context.Context, serviceID uuid.UUID, testName string, suiteName *string, before time.Time) (*TestResult, error) {
+	result, err := scanTestResult(r.db.pool.QueryRow(ctx, ResultGetLastPassing, serviceID, testName, suiteName, before))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get last passing test result: %w", err)
+	}
+	return result, nil
+}
+
+// scanTestResult scans a single test result row.
+func scanTestResult(row pgx.Row) (*TestResult, error) {
 	result := &TestResult{}
-	err := r.db.pool.QueryRow(ctx, ResultGetByID, id).Scan(
+	err := row.Scan(
 		&result.ID,
 		&result.RunID,
 		&result.ShardID,
@@ -105,17 +216,14 @@ func (r *resultRepo) Get(ctx context.Context, id uuid.UUID) (*TestResult, error)
 		&result.CreatedAt,
 	)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, ErrNotFound
-		}
-		return nil, fmt.Errorf("failed to get test result: %w", err)
+		return nil, err
 	}
 	return result, nil
 }
 
 // ListByRun returns all results for a test run.
 func (r *resultRepo) ListByRun(ctx context.Context, runID uuid.UUID) ([]TestResult, error) {
-	rows, err := r.db.pool.Query(ctx, ResultListByRun, runID)
+	rows, err := r.db.reader(ctx).Query(ctx, ResultListByRun, runID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list test results: %w", err)
 	}
@@ -126,7 +234,7 @@ func (r *resultRepo) ListByRun(ctx context.Context, runID uuid.UUID) ([]TestResu
 
 // ListByRunAndStatus returns results for a run with a specific status.
 func (r *resultRepo) ListByRunAndStatus(ctx context.Context, runID uuid.UUID, status ResultStatus) ([]TestResult, error) {
-	rows, err := r.db.pool.Query(ctx, ResultListByRunAndStatus, runID, status)
+	rows, err := r.db.reader(ctx).Query(ctx, ResultListByRunAndStatus, runID, status)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list test results by status: %w", err)
 	}
@@ -135,9 +243,37 @@ func (r *resultRepo) ListByRunAndStatus(ctx context.Context, runID uuid.UUID, st
 	return scanTestResults(rows)
 }
 
+// ListPage returns a page of the results for a run, sorted by test name.
+func (r *resultRepo) ListPage(ctx context.Context, runID uuid.UUID, statuses []ResultStatus, page Pagination) ([]TestResult, error) {
+	var statusArg []string
+	for _, status := range statuses {
+		statusArg = append(statusArg, string(status))
+	}
+	afterName, afterID := page.afterName()
+	rows, err := r.db.reader(ctx).Query(ctx, ResultListPage, runID, statusArg, page.Limit, page.Offset, afterName, afterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list test results: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTestResults(rows)
+}
+
+// SearchByRun returns up to limit results of a run whose error message or
+// stack trace matches text, failures first.
+func (r *resultRepo) SearchByRun(ctx context.Context, runID uuid.UUID, text string, limit int) ([]TestResult, error) {
+	rows, err := r.db.reader(ctx).Query(ctx, ResultSearchByRun, runID, text, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search test results: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTestResults(rows)
+}
+
 // CountByRun returns the count of results grouped by status for a run.
 func (r *resultRepo) CountByRun(ctx context.Context, runID uuid.UUID) (map[ResultStatus]int64, error) {
-	rows, err := r.db.pool.Query(ctx, ResultCountByRun, runID)
+	rows, err := r.db.reader(ctx).Query(ctx, ResultCountByRun, runID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count results by run: %w", err)
 	}
@@ -213,15 +349,28 @@ func NewArtifactRepo(db *DB) ArtifactRepository {
 	return &artifactRepo{db: db}
 }
 
-// Create creates a new artifact record.
+// Create creates a new artifact record. Artifacts without a type are typed
+// by their name and content type.
 func (r *artifactRepo) Create(ctx context.Context, artifact *Artifact) error {
+	if artifact.Type == "" {
+		var contentType string
+		if artifact.ContentType != nil {
+			contentType = *artifact.ContentType
+		}
+		artifact.Type = DetectArtifactType(artifact.Name, contentType)
+	}
+
 	err := r.db.pool.QueryRow(ctx, ArtifactInsert,
 		artifact.RunID,
 		artifact.Name,
 		artifact.Path,
 		artifact.ContentType,
 		artifact.SizeBytes,
-	).Scan(&artifact.ID, &artifact.CreatedAt)
+		artifact.TestDefinitionID,
+		artifact.Type,
+		artifact.Checksum,
+		artifact.BlobDigest,
+	).Scan(&artifact.ID, &artifact.CreatedAt, &artifact.StorageClass)
 
 	if err != nil {
 		return fmt.Errorf("failed to create artifact: %w", WrapDBError(err))
@@ -232,7 +381,7 @@ func (r *artifactRepo) Create(ctx context.Context, artifact *Artifact) error {
 // Get retrieves an artifact by ID.
 func (r *artifactRepo) Get(ctx context.Context, id uuid.UUID) (*Artifact, error) {
 	artifact := &Artifact{}
-	err := r.db.pool.QueryRow(ctx, ArtifactGetByID, id).Scan(
+	err := r.db.reader(ctx).QueryRow(ctx, ArtifactGetByID, id, projectScopeArg(ctx)).Scan(
 		&artifact.ID,
 		&artifact.RunID,
 		&artifact.Name,
@@ -240,6 +389,14 @@ func (r *artifactRepo) Get(ctx context.Context, id uuid.UUID) (*Artifact, error)
 		&artifact.ContentType,
 		&artifact.SizeBytes,
 		&artifact.CreatedAt,
+		&artifact.StorageClass,
+		&artifact.ArchivedAt,
+		&artifact.RestoreRequestedAt,
+		&artifact.RestoredUntil,
+		&artifact.TestDefinitionID,
+		&artifact.Type,
+		&artifact.Checksum,
+		&artifact.BlobDigest,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -252,35 +409,23 @@ func (r *artifactRepo) Get(ctx context.Context, id uuid.UUID) (*Artifact, error)
 
 // ListByRun returns all artifacts for a test run.
 func (r *artifactRepo) ListByRun(ctx context.Context, runID uuid.UUID) ([]Artifact, error) {
-	rows, err := r.db.pool.Query(ctx, ArtifactListByRun, runID)
+	rows, err := r.db.reader(ctx).Query(ctx, ArtifactListByRun, runID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list artifacts: %w", err)
 	}
-	defer rows.Close()
-
-	var artifacts []Artifact
-	for rows.Next() {
-		var artifact Artifact
-		err := rows.Scan(
-			&artifact.ID,
-			&artifact.RunID,
-			&artifact.Name,
-			&artifact.Path,
-			&artifact.ContentType,
-			&artifact.SizeBytes,
-			&artifact.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan artifact: %w", err)
-		}
-		artifacts = append(artifacts, artifact)
-	}
+	return scanArtifacts(rows)
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating artifacts: %w", err)
+// UsageByTest returns the number and total size of the artifacts recorded
+// for a test definition in a run.
+func (r *artifactRepo) UsageByTest(ctx context.Context, runID, testID uuid.UUID) (int, int64, error) {
+	var files int
+	var bytes int64
+	err := r.db.pool.QueryRow(ctx, ArtifactUsageByTest, runID, testID).Scan(&files, &bytes)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get artifact usage: %w", WrapDBError(err))
 	}
-
-	return artifacts, nil
+	return files, bytes, nil
 }
 
 // ListOlderThan returns artifacts older than a timestamp.
@@ -293,6 +438,50 @@ func (r *artifactRepo) ListOlderThan(ctx context.Context, before time.Time, limi
 	if err != nil {
 		return nil, fmt.Errorf("failed to list artifacts by age: %w", err)
 	}
+	return scanArtifacts(rows)
+}
+
+// ListForArchival returns STANDARD storage class artifacts older than a timestamp.
+func (r *artifactRepo) ListForArchival(ctx context.Context, before time.Time, limit int) ([]Artifact, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := r.db.pool.Query(ctx, ArtifactListForArchival, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts for archival: %w", WrapDBError(err))
+	}
+	return scanArtifacts(rows)
+}
+
+// MarkArchived records that an artifact moved to another storage class.
+func (r *artifactRepo) MarkArchived(ctx context.Context, id uuid.UUID, storageClass string, at time.Time) error {
+	return r.update(ctx, "mark artifact archived", ArtifactMarkArchived, id, storageClass, at)
+}
+
+// MarkRestoreRequested records that a restore of an archived artifact was requested.
+func (r *artifactRepo) MarkRestoreRequested(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.update(ctx, "mark artifact restore requested", ArtifactMarkRestoreRequested, id, at)
+}
+
+// MarkRestored records until when the restored copy of an archived artifact is available.
+func (r *artifactRepo) MarkRestored(ctx context.Context, id uuid.UUID, until time.Time) error {
+	return r.update(ctx, "mark artifact restored", ArtifactMarkRestored, id, until)
+}
+
+func (r *artifactRepo) update(ctx context.Context, action, query string, args ...any) error {
+	result, err := r.db.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", action, WrapDBError(err))
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// scanArtifacts scans artifact rows and closes them.
+func scanArtifacts(rows pgx.Rows) ([]Artifact, error) {
 	defer rows.Close()
 
 	var artifacts []Artifact
@@ -306,6 +495,14 @@ func (r *artifactRepo) ListOlderThan(ctx context.Context, before time.Time, limi
 			&artifact.ContentType,
 			&artifact.SizeBytes,
 			&artifact.CreatedAt,
+			&artifact.StorageClass,
+			&artifact.ArchivedAt,
+			&artifact.RestoreRequestedAt,
+			&artifact.RestoredUntil,
+			&artifact.TestDefinitionID,
+			&artifact.Type,
+			&artifact.Checksum,
+			&artifact.BlobDigest,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan artifact: %w", err)
@@ -21,6 +21,9 @@ func NewRunRepo(db *DB) TestRunRepository {
 
 // Create creates a new test run.
 func (r *runRepo) Create(ctx context.Context, run *TestRun) error {
+	if run.Attempt == 0 {
+		run.Attempt = 1
+	}
 	err := r.db.pool.QueryRow(ctx, RunInsert,
 		run.ServiceID,
 		run.Status,
@@ -29,6 +32,16 @@ func (r *runRepo) Create(ctx context.Context, run *TestRun) error {
 		run.TriggerType,
 		run.TriggeredBy,
 		run.Priority,
+		run.PullRequestNumber,
+		run.Attempt,
+		run.RetryOfRunID,
+		run.NotBefore,
+		run.LabelSelector,
+		run.TraceParent,
+		run.ConcurrencyGroup,
+		run.ApprovalReason,
+		run.Metadata,
+		runParameters(run.Parameters),
 	).Scan(&run.ID, &run.CreatedAt)
 
 	if err != nil {
@@ -40,7 +53,7 @@ func (r *runRepo) Create(ctx context.Context, run *TestRun) error {
 // Get retrieves a test run by ID.
 func (r *runRepo) Get(ctx context.Context, id uuid.UUID) (*TestRun, error) {
 	run := &TestRun{}
-	err := r.db.pool.QueryRow(ctx, RunGetByID, id).Scan(
+	err := r.db.reader(ctx).QueryRow(ctx, RunGetByID, id, projectScopeArg(ctx)).Scan(
 		&run.ID,
 		&run.ServiceID,
 		&run.AgentID,
@@ -63,6 +76,23 @@ func (r *runRepo) Get(ctx context.Context, id uuid.UUID) (*TestRun, error) {
 		&run.MaxParallel,
 		&run.DurationMs,
 		&run.ErrorMessage,
+		&run.PullRequestNumber,
+		&run.ResultsDropped,
+		&run.ArtifactsDropped,
+		&run.Attempt,
+		&run.RetryOfRunID,
+		&run.NotBefore,
+		&run.LabelSelector,
+		&run.AgentName,
+		&run.AgentNetworkZones,
+		&run.TraceParent,
+		&run.ConcurrencyGroup,
+		&run.ApprovalReason,
+		&run.ApprovedBy,
+		&run.ApprovedAt,
+		&run.HasMediaArtifacts,
+		&run.Metadata,
+		&run.Parameters,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -129,6 +159,29 @@ func (r *runRepo) UpdateShardStats(ctx context.Context, id uuid.UUID, completed
 	return nil
 }
 
+// ReserveResults accepts up to n results for the run without exceeding limit.
+func (r *runRepo) ReserveResults(ctx context.Context, id uuid.UUID, n, limit int) (int, int64, error) {
+	return r.reserve(ctx, RunReserveResults, id, n, limit)
+}
+
+// ReserveArtifacts accepts up to n artifacts for the run without exceeding limit.
+func (r *runRepo) ReserveArtifacts(ctx context.Context, id uuid.UUID, n, limit int) (int, int64, error) {
+	return r.reserve(ctx, RunReserveArtifacts, id, n, limit)
+}
+
+func (r *runRepo) reserve(ctx context.Context, query string, id uuid.UUID, n, limit int) (int, int64, error) {
+	var accepted int
+	var dropped int64
+	err := r.db.pool.QueryRow(ctx, query, id, n, limit).Scan(&accepted, &dropped)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, 0, ErrNotFound
+		}
+		return 0, 0, fmt.Errorf("failed to reserve run ingestion: %w", err)
+	}
+	return accepted, dropped, nil
+}
+
 // UpdateStatus updates only the run's status.
 func (r *runRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status RunStatus) error {
 	result, err := r.db.pool.Exec(ctx, RunUpdateStatus, id, status)
@@ -141,6 +194,66 @@ func (r *runRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status RunStat
 	return nil
 }
 
+// Approve releases a run waiting for approval to the scheduler.
+func (r *runRepo) Approve(ctx context.Context, id uuid.UUID, approvedBy string) error {
+	result, err := r.db.pool.Exec(ctx, RunApprove, id, approvedBy)
+	if err != nil {
+		return fmt.Errorf("failed to approve test run: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Annotate merges annotations and links into the metadata of a run.
+func (r *runRepo) Annotate(ctx context.Context, id uuid.UUID, metadata RunMetadata) (*RunMetadata, error) {
+	var result RunMetadata
+	err := r.db.pool.QueryRow(ctx, RunAnnotate, id, metadataPatch(metadata.Annotations), metadataPatch(metadata.Links)).Scan(&result)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to annotate test run: %w", err)
+	}
+	return &result, nil
+}
+
+// runParameters returns the parameters of a run to store, which are never
+// null.
+func runParameters(params map[string]string) map[string]string {
+	if params == nil {
+		return map[string]string{}
+	}
+	return params
+}
+
+// metadataPatch converts values to merge into run metadata to JSON values,
+// with empty values as null so they are removed.
+func metadataPatch(values map[string]string) map[string]*string {
+	patch := make(map[string]*string, len(values))
+	for key, value := range values {
+		if value != "" {
+			patch[key] = &value
+		} else {
+			patch[key] = nil
+		}
+	}
+	return patch
+}
+
+// Retarget moves a pending run to another commit.
+func (r *runRepo) Retarget(ctx context.Context, id uuid.UUID, gitSHA *string) error {
+	result, err := r.db.pool.Exec(ctx, RunRetarget, id, gitSHA)
+	if err != nil {
+		return fmt.Errorf("failed to retarget test run: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // Start marks a run as started with the given agent.
 func (r *runRepo) Start(ctx context.Context, id uuid.UUID, agentID uuid.UUID) error {
 	result, err := r.db.pool.Exec(ctx, RunStart, id, agentID)
@@ -155,12 +268,21 @@ func (r *runRepo) Start(ctx context.Context, id uuid.UUID, agentID uuid.UUID) er
 
 // Finish marks a run as finished with results.
 func (r *runRepo) Finish(ctx context.Context, id uuid.UUID, status RunStatus, results RunResults) error {
+	return r.finish(ctx, RunFinish, id, status, results)
+}
+
+// FinishActive marks a pending or running run as finished with results.
+func (r *runRepo) FinishActive(ctx context.Context, id uuid.UUID, status RunStatus, results RunResults) error {
+	return r.finish(ctx, RunFinishActive, id, status, results)
+}
+
+func (r *runRepo) finish(ctx context.Context, query string, id uuid.UUID, status RunStatus, results RunResults) error {
 	var errorMsg *string
 	if results.ErrorMessage != "" {
 		errorMsg = &results.ErrorMessage
 	}
 
-	result, err := r.db.pool.Exec(ctx, RunFinish,
+	result, err := r.db.pool.Exec(ctx, query,
 		id,
 		status,
 		results.TotalTests,
@@ -181,7 +303,8 @@ func (r *runRepo) Finish(ctx context.Context, id uuid.UUID, status RunStatus, re
 
 // List returns test runs with pagination.
 func (r *runRepo) List(ctx context.Context, page Pagination) ([]TestRun, error) {
-	rows, err := r.db.pool.Query(ctx, RunList, page.Limit, page.Offset)
+	afterCreated, afterID := page.afterCreated()
+	rows, err := r.db.reader(ctx).Query(ctx, RunList, page.Limit, page.Offset, projectScopeArg(ctx), afterCreated, afterID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list test runs: %w", err)
 	}
@@ -192,7 +315,8 @@ func (r *runRepo) List(ctx context.Context, page Pagination) ([]TestRun, error)
 
 // ListByService returns test runs for a service.
 func (r *runRepo) ListByService(ctx context.Context, serviceID uuid.UUID, page Pagination) ([]TestRun, error) {
-	rows, err := r.db.pool.Query(ctx, RunListByService, serviceID, page.Limit, page.Offset)
+	afterCreated, afterID := page.afterCreated()
+	rows, err := r.db.reader(ctx).Query(ctx, RunListByService, serviceID, page.Limit, page.Offset, projectScopeArg(ctx), afterCreated, afterID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list test runs by service: %w", err)
 	}
@@ -203,7 +327,12 @@ func (r *runRepo) ListByService(ctx context.Context, serviceID uuid.UUID, page P
 
 // ListByStatus returns test runs with a specific status.
 func (r *runRepo) ListByStatus(ctx context.Context, status RunStatus, page Pagination) ([]TestRun, error) {
-	rows, err := r.db.pool.Query(ctx, RunListByStatus, status, page.Limit, page.Offset)
+	var afterCreated, afterPriority, afterID any
+	if page.After != nil {
+		afterCreated, afterPriority, afterID = page.After.CreatedAt, page.After.Priority, page.After.ID
+	}
+	rows, err := r.db.reader(ctx).Query(ctx, RunListByStatus, status, page.Limit, page.Offset, projectScopeArg(ctx),
+		afterCreated, afterPriority, afterID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list test runs by status: %w", err)
 	}
@@ -214,7 +343,8 @@ func (r *runRepo) ListByStatus(ctx context.Context, status RunStatus, page Pagin
 
 // ListByServiceAndStatus returns test runs for a service with a specific status.
 func (r *runRepo) ListByServiceAndStatus(ctx context.Context, serviceID uuid.UUID, status RunStatus, page Pagination) ([]TestRun, error) {
-	rows, err := r.db.pool.Query(ctx, RunListByServiceAndStatus, serviceID, status, page.Limit, page.Offset)
+	afterCreated, afterID := page.afterCreated()
+	rows, err := r.db.reader(ctx).Query(ctx, RunListByServiceAndStatus, serviceID, status, page.Limit, page.Offset, projectScopeArg(ctx), afterCreated, afterID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list test runs by service and status: %w", err)
 	}
@@ -225,7 +355,8 @@ func (r *runRepo) ListByServiceAndStatus(ctx context.Context, serviceID uuid.UUI
 
 // ListByDateRange returns test runs within a date range.
 func (r *runRepo) ListByDateRange(ctx context.Context, start, end time.Time, page Pagination) ([]TestRun, error) {
-	rows, err := r.db.pool.Query(ctx, RunListByDateRange, start, end, page.Limit, page.Offset)
+	afterCreated, afterID := page.afterCreated()
+	rows, err := r.db.reader(ctx).Query(ctx, RunListByDateRange, start, end, page.Limit, page.Offset, projectScopeArg(ctx), afterCreated, afterID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list test runs by date range: %w", err)
 	}
@@ -234,9 +365,63 @@ func (r *runRepo) ListByDateRange(ctx context.Context, start, end time.Time, pag
 	return scanTestRuns(rows)
 }
 
+// ListByAgent returns runs an agent executed, or executed a shard of, that
+// started within a time range, most recent first. Runs stay attributed to
+// agents that have since been deleted.
+func (r *runRepo) ListByAgent(ctx context.Context, agentID uuid.UUID, start, end time.Time, page Pagination) ([]TestRun, error) {
+	var afterStarted, afterID any
+	if page.After != nil {
+		afterStarted, afterID = page.After.StartedAt, page.After.ID
+	}
+	rows, err := r.db.reader(ctx).Query(ctx, RunListByAgent, agentID, start, end, page.Limit, page.Offset, projectScopeArg(ctx),
+		afterStarted, afterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list test runs by agent: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTestRuns(rows)
+}
+
+// Search returns the runs matching the filter, newest first, with the total
+// number of matches.
+func (r *runRepo) Search(ctx context.Context, filter RunSearchFilter, page Pagination) ([]TestRun, int, error) {
+	var statuses []string
+	for _, status := range filter.Statuses {
+		statuses = append(statuses, string(status))
+	}
+	args := []any{
+		filter.ServiceID,
+		statuses,
+		filter.Branch,
+		filter.Text,
+		filter.Since,
+		filter.Until,
+		projectScopeArg(ctx),
+	}
+
+	var total int
+	if err := r.db.reader(ctx).QueryRow(ctx, RunSearchCount, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count test runs: %w", err)
+	}
+
+	afterCreated, afterID := page.afterCreated()
+	rows, err := r.db.reader(ctx).Query(ctx, RunSearch, append(args, page.Limit, page.Offset, afterCreated, afterID)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search test runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs, err := scanTestRuns(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return runs, total, nil
+}
+
 // GetPending returns pending runs ordered by priority.
 func (r *runRepo) GetPending(ctx context.Context, limit int) ([]TestRun, error) {
-	rows, err := r.db.pool.Query(ctx, RunGetPending, limit)
+	rows, err := r.db.pool.Query(ctx, RunGetPending, limit, projectScopeArg(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pending runs: %w", err)
 	}
@@ -247,7 +432,7 @@ func (r *runRepo) GetPending(ctx context.Context, limit int) ([]TestRun, error)
 
 // GetRunning returns currently running tests.
 func (r *runRepo) GetRunning(ctx context.Context) ([]TestRun, error) {
-	rows, err := r.db.pool.Query(ctx, RunGetRunning)
+	rows, err := r.db.pool.Query(ctx, RunGetRunning, projectScopeArg(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get running tests: %w", err)
 	}
@@ -259,7 +444,7 @@ func (r *runRepo) GetRunning(ctx context.Context) ([]TestRun, error) {
 // Count returns the total number of test runs.
 func (r *runRepo) Count(ctx context.Context) (int64, error) {
 	var count int64
-	err := r.db.pool.QueryRow(ctx, RunCount).Scan(&count)
+	err := r.db.reader(ctx).QueryRow(ctx, RunCount, projectScopeArg(ctx)).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count test runs: %w", err)
 	}
@@ -268,7 +453,7 @@ func (r *runRepo) Count(ctx context.Context) (int64, error) {
 
 // CountByStatus returns the count of runs grouped by status.
 func (r *runRepo) CountByStatus(ctx context.Context) (map[RunStatus]int64, error) {
-	rows, err := r.db.pool.Query(ctx, RunCountByStatus)
+	rows, err := r.db.reader(ctx).Query(ctx, RunCountByStatus)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count runs by status: %w", err)
 	}
@@ -319,6 +504,23 @@ func scanTestRuns(rows pgx.Rows) ([]TestRun, error) {
 			&run.MaxParallel,
 			&run.DurationMs,
 			&run.ErrorMessage,
+			&run.PullRequestNumber,
+			&run.ResultsDropped,
+			&run.ArtifactsDropped,
+			&run.Attempt,
+			&run.RetryOfRunID,
+			&run.NotBefore,
+			&run.LabelSelector,
+			&run.AgentName,
+			&run.AgentNetworkZones,
+			&run.TraceParent,
+			&run.ConcurrencyGroup,
+			&run.ApprovalReason,
+			&run.ApprovedBy,
+			&run.ApprovedAt,
+			&run.HasMediaArtifacts,
+			&run.Metadata,
+			&run.Parameters,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan test run: %w", err)
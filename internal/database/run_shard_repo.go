@@ -31,6 +31,9 @@ func (r *runShardRepo) Create(ctx context.Context, shard *RunShard) error {
 		shard.ShardCount,
 		status,
 		shard.TotalTests,
+		shard.Arch,
+		shard.Matrix,
+		shard.Stage,
 	).Scan(&shard.ID, &shard.CreatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create run shard: %w", WrapDBError(err))
@@ -43,7 +46,7 @@ func (r *runShardRepo) Create(ctx context.Context, shard *RunShard) error {
 // Get retrieves a shard by ID.
 func (r *runShardRepo) Get(ctx context.Context, id uuid.UUID) (*RunShard, error) {
 	shard := &RunShard{}
-	err := r.db.pool.QueryRow(ctx, RunShardGetByID, id).Scan(
+	err := r.db.reader(ctx).QueryRow(ctx, RunShardGetByID, id).Scan(
 		&shard.ID,
 		&shard.RunID,
 		&shard.ShardIndex,
@@ -58,6 +61,11 @@ func (r *runShardRepo) Get(ctx context.Context, id uuid.UUID) (*RunShard, error)
 		&shard.StartedAt,
 		&shard.FinishedAt,
 		&shard.CreatedAt,
+		&shard.Arch,
+		&shard.AgentName,
+		&shard.AgentNetworkZones,
+		&shard.Matrix,
+		&shard.Stage,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -70,42 +78,13 @@ func (r *runShardRepo) Get(ctx context.Context, id uuid.UUID) (*RunShard, error)
 
 // ListByRun lists shards for a run.
 func (r *runShardRepo) ListByRun(ctx context.Context, runID uuid.UUID) ([]RunShard, error) {
-	rows, err := r.db.pool.Query(ctx, RunShardListByRun, runID)
+	rows, err := r.db.reader(ctx).Query(ctx, RunShardListByRun, runID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list run shards: %w", err)
 	}
 	defer rows.Close()
 
-	var shards []RunShard
-	for rows.Next() {
-		var shard RunShard
-		err := rows.Scan(
-			&shard.ID,
-			&shard.RunID,
-			&shard.ShardIndex,
-			&shard.ShardCount,
-			&shard.Status,
-			&shard.AgentID,
-			&shard.TotalTests,
-			&shard.PassedTests,
-			&shard.FailedTests,
-			&shard.SkippedTests,
-			&shard.ErrorMessage,
-			&shard.StartedAt,
-			&shard.FinishedAt,
-			&shard.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan run shard: %w", err)
-		}
-		shards = append(shards, shard)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating run shards: %w", err)
-	}
-
-	return shards, nil
+	return scanRunShards(rows)
 }
 
 // UpdateStatus updates a shard status.
@@ -134,12 +113,21 @@ func (r *runShardRepo) Start(ctx context.Context, id uuid.UUID, agentID uuid.UUI
 
 // Finish marks a shard as finished with results.
 func (r *runShardRepo) Finish(ctx context.Context, id uuid.UUID, status ShardStatus, results RunResults) error {
+	return r.finish(ctx, RunShardFinish, id, status, results)
+}
+
+// FinishRunning marks a shard running on an agent as finished with results.
+func (r *runShardRepo) FinishRunning(ctx context.Context, id uuid.UUID, agentID uuid.UUID, status ShardStatus, results RunResults) error {
+	return r.finish(ctx, RunShardFinishRunning, id, status, results, agentID)
+}
+
+func (r *runShardRepo) finish(ctx context.Context, query string, id uuid.UUID, status ShardStatus, results RunResults, args ...any) error {
 	var errorMsg *string
 	if results.ErrorMessage != "" {
 		errorMsg = &results.ErrorMessage
 	}
 
-	result, err := r.db.pool.Exec(ctx, RunShardFinish,
+	result, err := r.db.pool.Exec(ctx, query, append([]any{
 		id,
 		status,
 		results.TotalTests,
@@ -147,7 +135,7 @@ func (r *runShardRepo) Finish(ctx context.Context, id uuid.UUID, status ShardSta
 		results.FailedTests,
 		results.SkippedTests,
 		errorMsg,
-	)
+	}, args...)...)
 	if err != nil {
 		return fmt.Errorf("failed to finish shard: %w", err)
 	}
@@ -177,3 +165,52 @@ func (r *runShardRepo) DeleteByRun(ctx context.Context, runID uuid.UUID) error {
 	}
 	return nil
 }
+
+// RequeueOrphaned resets the running shards of offline agents to pending.
+func (r *runShardRepo) RequeueOrphaned(ctx context.Context) ([]RunShard, error) {
+	rows, err := r.db.pool.Query(ctx, RunShardRequeueOrphaned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to requeue orphaned run shards: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRunShards(rows)
+}
+
+func scanRunShards(rows pgx.Rows) ([]RunShard, error) {
+	var shards []RunShard
+	for rows.Next() {
+		var shard RunShard
+		err := rows.Scan(
+			&shard.ID,
+			&shard.RunID,
+			&shard.ShardIndex,
+			&shard.ShardCount,
+			&shard.Status,
+			&shard.AgentID,
+			&shard.TotalTests,
+			&shard.PassedTests,
+			&shard.FailedTests,
+			&shard.SkippedTests,
+			&shard.ErrorMessage,
+			&shard.StartedAt,
+			&shard.FinishedAt,
+			&shard.CreatedAt,
+			&shard.Arch,
+			&shard.AgentName,
+			&shard.AgentNetworkZones,
+			&shard.Matrix,
+			&shard.Stage,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan run shard: %w", err)
+		}
+		shards = append(shards, shard)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating run shards: %w", err)
+	}
+
+	return shards, nil
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// schedulingPauseRepo implements SchedulingPauseRepository.
+type schedulingPauseRepo struct {
+	db *DB
+}
+
+// NewSchedulingPauseRepo creates a new scheduling pause repository.
+func NewSchedulingPauseRepo(db *DB) SchedulingPauseRepository {
+	return &schedulingPauseRepo{db: db}
+}
+
+// Pause pauses scheduling globally or for a service.
+func (r *schedulingPauseRepo) Pause(ctx context.Context, pause *SchedulingPause) error {
+	var err error
+	if pause.ServiceID == nil {
+		err = r.db.pool.QueryRow(ctx, SchedulingPauseUpsertGlobal,
+			pause.Reason, pause.PausedBy,
+		).Scan(&pause.ID, &pause.PausedAt)
+	} else {
+		err = r.db.pool.QueryRow(ctx, SchedulingPauseUpsertService,
+			*pause.ServiceID, pause.Reason, pause.PausedBy,
+		).Scan(&pause.ID, &pause.PausedAt)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to pause scheduling: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// Resume resumes scheduling globally or for a service.
+func (r *schedulingPauseRepo) Resume(ctx context.Context, serviceID *uuid.UUID) error {
+	query, args := SchedulingPauseDeleteGlobal, []any{}
+	if serviceID != nil {
+		query, args = SchedulingPauseDeleteService, []any{*serviceID}
+	}
+	result, err := r.db.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to resume scheduling: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List lists the pauses, the global pause first.
+func (r *schedulingPauseRepo) List(ctx context.Context) ([]SchedulingPause, error) {
+	rows, err := r.db.pool.Query(ctx, SchedulingPauseList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduling pauses: %w", err)
+	}
+	defer rows.Close()
+
+	var pauses []SchedulingPause
+	for rows.Next() {
+		var p SchedulingPause
+		if err := rows.Scan(&p.ID, &p.ServiceID, &p.Reason, &p.PausedBy, &p.PausedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan scheduling pause: %w", err)
+		}
+		pauses = append(pauses, p)
+	}
+	return pauses, rows.Err()
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+type projectScopeKey struct{}
+
+// WithProjectScope returns a context that limits the repository queries made
+// with it to the resources of the given projects: services, agents and
+// notification channels, and the runs of the services. Queries made with a
+// context without a scope, such as those of background jobs, see every
+// project. An empty scope sees nothing.
+func WithProjectScope(ctx context.Context, projectIDs []uuid.UUID) context.Context {
+	if projectIDs == nil {
+		projectIDs = []uuid.UUID{}
+	}
+	return context.WithValue(ctx, projectScopeKey{}, projectIDs)
+}
+
+// ProjectScope returns the projects queries made with ctx are limited to,
+// and false if they are not limited.
+func ProjectScope(ctx context.Context) ([]uuid.UUID, bool) {
+	ids, ok := ctx.Value(projectScopeKey{}).([]uuid.UUID)
+	return ids, ok
+}
+
+// InProjectScope reports whether the resources of a project are visible to
+// queries made with ctx.
+func InProjectScope(ctx context.Context, projectID uuid.UUID) bool {
+	ids, ok := ProjectScope(ctx)
+	return !ok || slices.Contains(ids, projectID)
+}
+
+// projectScopeArg returns the scope of ctx as a uuid[] query argument, or nil
+// for NULL if ctx is not scoped. Scoped queries filter with
+// ($n::uuid[] IS NULL OR project_id = ANY($n)).
+func projectScopeArg(ctx context.Context) any {
+	ids, ok := ProjectScope(ctx)
+	if !ok {
+		return nil
+	}
+	return ids
+}
+
+// projectIDOrDefault returns the project a new resource is stored in.
+func projectIDOrDefault(id uuid.UUID) uuid.UUID {
+	if id == uuid.Nil {
+		return DefaultProjectID
+	}
+	return id
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -30,6 +31,11 @@ func (r *serviceRepo) Create(ctx context.Context, svc *Service) error {
 		svc.Owner,
 		svc.ContactSlack,
 		svc.ContactEmail,
+		svc.RootPath,
+		svc.SyncIntervalSeconds,
+		svc.AgentPool,
+		projectIDOrDefault(svc.ProjectID),
+		svc.BranchRunPolicy,
 	).Scan(&svc.ID, &svc.CreatedAt, &svc.UpdatedAt)
 
 	if err != nil {
@@ -41,7 +47,7 @@ func (r *serviceRepo) Create(ctx context.Context, svc *Service) error {
 // Get retrieves a service by ID.
 func (r *serviceRepo) Get(ctx context.Context, id uuid.UUID) (*Service, error) {
 	svc := &Service{}
-	err := r.db.pool.QueryRow(ctx, ServiceGetByID, id).Scan(
+	err := r.db.reader(ctx).QueryRow(ctx, ServiceGetByID, id, projectScopeArg(ctx)).Scan(
 		&svc.ID,
 		&svc.Name,
 		&svc.DisplayName,
@@ -52,8 +58,14 @@ func (r *serviceRepo) Get(ctx context.Context, id uuid.UUID) (*Service, error) {
 		&svc.Owner,
 		&svc.ContactSlack,
 		&svc.ContactEmail,
+		&svc.RootPath,
+		&svc.SyncIntervalSeconds,
+		&svc.AgentPool,
+		&svc.ArchivedAt,
 		&svc.CreatedAt,
 		&svc.UpdatedAt,
+		&svc.ProjectID,
+		&svc.BranchRunPolicy,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -67,7 +79,7 @@ func (r *serviceRepo) Get(ctx context.Context, id uuid.UUID) (*Service, error) {
 // GetByName retrieves a service by name.
 func (r *serviceRepo) GetByName(ctx context.Context, name string) (*Service, error) {
 	svc := &Service{}
-	err := r.db.pool.QueryRow(ctx, ServiceGetByName, name).Scan(
+	err := r.db.reader(ctx).QueryRow(ctx, ServiceGetByName, name, projectScopeArg(ctx)).Scan(
 		&svc.ID,
 		&svc.Name,
 		&svc.DisplayName,
@@ -78,8 +90,14 @@ func (r *serviceRepo) GetByName(ctx context.Context, name string) (*Service, err
 		&svc.Owner,
 		&svc.ContactSlack,
 		&svc.ContactEmail,
+		&svc.RootPath,
+		&svc.SyncIntervalSeconds,
+		&svc.AgentPool,
+		&svc.ArchivedAt,
 		&svc.CreatedAt,
 		&svc.UpdatedAt,
+		&svc.ProjectID,
+		&svc.BranchRunPolicy,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -103,6 +121,12 @@ func (r *serviceRepo) Update(ctx context.Context, svc *Service) error {
 		svc.Owner,
 		svc.ContactSlack,
 		svc.ContactEmail,
+		svc.RootPath,
+		svc.SyncIntervalSeconds,
+		svc.AgentPool,
+		svc.ArchivedAt,
+		projectScopeArg(ctx),
+		svc.BranchRunPolicy,
 	).Scan(&svc.UpdatedAt)
 
 	if err != nil {
@@ -116,7 +140,7 @@ func (r *serviceRepo) Update(ctx context.Context, svc *Service) error {
 
 // Delete deletes a service by ID.
 func (r *serviceRepo) Delete(ctx context.Context, id uuid.UUID) error {
-	result, err := r.db.pool.Exec(ctx, ServiceDelete, id)
+	result, err := r.db.pool.Exec(ctx, ServiceDelete, id, projectScopeArg(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to delete service: %w", err)
 	}
@@ -128,7 +152,8 @@ func (r *serviceRepo) Delete(ctx context.Context, id uuid.UUID) error {
 
 // List returns services with pagination.
 func (r *serviceRepo) List(ctx context.Context, page Pagination) ([]Service, error) {
-	rows, err := r.db.pool.Query(ctx, ServiceList, page.Limit, page.Offset)
+	afterName, afterID := page.afterName()
+	rows, err := r.db.reader(ctx).Query(ctx, ServiceList, page.Limit, page.Offset, projectScopeArg(ctx), afterName, afterID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list services: %w", err)
 	}
@@ -140,7 +165,7 @@ func (r *serviceRepo) List(ctx context.Context, page Pagination) ([]Service, err
 // Count returns the total number of services.
 func (r *serviceRepo) Count(ctx context.Context) (int64, error) {
 	var count int64
-	err := r.db.pool.QueryRow(ctx, ServiceCount).Scan(&count)
+	err := r.db.reader(ctx).QueryRow(ctx, ServiceCount, projectScopeArg(ctx)).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count services: %w", err)
 	}
@@ -149,7 +174,8 @@ func (r *serviceRepo) Count(ctx context.Context) (int64, error) {
 
 // ListByOwner returns services owned by a specific owner.
 func (r *serviceRepo) ListByOwner(ctx context.Context, owner string, page Pagination) ([]Service, error) {
-	rows, err := r.db.pool.Query(ctx, ServiceListByOwner, owner, page.Limit, page.Offset)
+	afterName, afterID := page.afterName()
+	rows, err := r.db.reader(ctx).Query(ctx, ServiceListByOwner, owner, page.Limit, page.Offset, projectScopeArg(ctx), afterName, afterID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list services by owner: %w", err)
 	}
@@ -162,7 +188,8 @@ func (r *serviceRepo) ListByOwner(ctx context.Context, owner string, page Pagina
 func (r *serviceRepo) Search(ctx context.Context, query string, page Pagination) ([]Service, error) {
 	// Add wildcards for ILIKE pattern matching
 	pattern := "%" + query + "%"
-	rows, err := r.db.pool.Query(ctx, ServiceSearch, pattern, page.Limit, page.Offset)
+	afterName, afterID := page.afterName()
+	rows, err := r.db.reader(ctx).Query(ctx, ServiceSearch, pattern, page.Limit, page.Offset, projectScopeArg(ctx), afterName, afterID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search services: %w", err)
 	}
@@ -187,8 +214,14 @@ func scanServices(rows pgx.Rows) ([]Service, error) {
 			&svc.Owner,
 			&svc.ContactSlack,
 			&svc.ContactEmail,
+			&svc.RootPath,
+			&svc.SyncIntervalSeconds,
+			&svc.AgentPool,
+			&svc.ArchivedAt,
 			&svc.CreatedAt,
 			&svc.UpdatedAt,
+			&svc.ProjectID,
+			&svc.BranchRunPolicy,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan service: %w", err)
@@ -215,7 +248,12 @@ func NewTestDefinitionRepo(db *DB) TestDefinitionRepository {
 
 // Create creates a new test definition.
 func (r *testDefinitionRepo) Create(ctx context.Context, def *TestDefinition) error {
-	err := r.db.pool.QueryRow(ctx, TestDefInsert,
+	return createTestDefinition(ctx, r.db.pool, def)
+}
+
+// createTestDefinition inserts a test definition with q.
+func createTestDefinition(ctx context.Context, q Querier, def *TestDefinition) error {
+	err := q.QueryRow(ctx, TestDefInsert,
 		def.ServiceID,
 		def.Name,
 		def.Description,
@@ -230,6 +268,20 @@ func (r *testDefinitionRepo) Create(ctx context.Context, def *TestDefinition) er
 		def.DependsOn,
 		def.Retries,
 		def.AllowFailure,
+		def.CacheKey,
+		def.CachePaths,
+		def.Environment,
+		def.Secrets,
+		def.LabelSelector,
+		def.ContainerImage,
+		def.Architectures,
+		def.ArtifactMaxBytes,
+		def.ArtifactMaxFiles,
+		def.Owner,
+		def.Matrix,
+		def.RequiresApproval,
+		def.CapturePatterns,
+		def.Parameters,
 	).Scan(&def.ID, &def.CreatedAt, &def.UpdatedAt)
 
 	if err != nil {
@@ -241,7 +293,7 @@ func (r *testDefinitionRepo) Create(ctx context.Context, def *TestDefinition) er
 // Get retrieves a test definition by ID.
 func (r *testDefinitionRepo) Get(ctx context.Context, id uuid.UUID) (*TestDefinition, error) {
 	def := &TestDefinition{}
-	err := r.db.pool.QueryRow(ctx, TestDefGetByID, id).Scan(
+	err := r.db.reader(ctx).QueryRow(ctx, TestDefGetByID, id).Scan(
 		&def.ID,
 		&def.ServiceID,
 		&def.Name,
@@ -257,6 +309,20 @@ func (r *testDefinitionRepo) Get(ctx context.Context, id uuid.UUID) (*TestDefini
 		&def.DependsOn,
 		&def.Retries,
 		&def.AllowFailure,
+		&def.CacheKey,
+		&def.CachePaths,
+		&def.Environment,
+		&def.Secrets,
+		&def.LabelSelector,
+		&def.ContainerImage,
+		&def.Architectures,
+		&def.ArtifactMaxBytes,
+		&def.ArtifactMaxFiles,
+		&def.Owner,
+		&def.Matrix,
+		&def.RequiresApproval,
+		&def.CapturePatterns,
+		&def.Parameters,
 		&def.CreatedAt,
 		&def.UpdatedAt,
 	)
@@ -271,7 +337,12 @@ func (r *testDefinitionRepo) Get(ctx context.Context, id uuid.UUID) (*TestDefini
 
 // Update updates a test definition.
 func (r *testDefinitionRepo) Update(ctx context.Context, def *TestDefinition) error {
-	err := r.db.pool.QueryRow(ctx, TestDefUpdate,
+	return updateTestDefinition(ctx, r.db.pool, def)
+}
+
+// updateTestDefinition updates a test definition with q.
+func updateTestDefinition(ctx context.Context, q Querier, def *TestDefinition) error {
+	err := q.QueryRow(ctx, TestDefUpdate,
 		def.ID,
 		def.Name,
 		def.Description,
@@ -286,6 +357,20 @@ func (r *testDefinitionRepo) Update(ctx context.Context, def *TestDefinition) er
 		def.DependsOn,
 		def.Retries,
 		def.AllowFailure,
+		def.CacheKey,
+		def.CachePaths,
+		def.Environment,
+		def.Secrets,
+		def.LabelSelector,
+		def.ContainerImage,
+		def.Architectures,
+		def.ArtifactMaxBytes,
+		def.ArtifactMaxFiles,
+		def.Owner,
+		def.Matrix,
+		def.RequiresApproval,
+		def.CapturePatterns,
+		def.Parameters,
 	).Scan(&def.UpdatedAt)
 
 	if err != nil {
@@ -297,6 +382,34 @@ func (r *testDefinitionRepo) Update(ctx context.Context, def *TestDefinition) er
 	return nil
 }
 
+// ApplySync applies the changes of a manifest sync in a single
+// transaction, so that a failure leaves no change applied.
+func (r *testDefinitionRepo) ApplySync(ctx context.Context, sync *TestDefinitionSync) error {
+	return r.db.WithTx(ctx, func(tx pgx.Tx) error {
+		for _, def := range sync.Create {
+			if err := createTestDefinition(ctx, tx, def); err != nil {
+				return fmt.Errorf("test %s: %w", def.Name, err)
+			}
+		}
+		for _, def := range sync.Update {
+			if err := updateTestDefinition(ctx, tx, def); err != nil {
+				return fmt.Errorf("test %s: %w", def.Name, err)
+			}
+		}
+		if sync.Environment != nil {
+			if err := upsertServiceEnvironment(ctx, tx, sync.Environment); err != nil {
+				return err
+			}
+		}
+		if sync.Concurrency != nil {
+			if err := saveServiceConcurrency(ctx, tx, sync.Concurrency); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // Delete deletes a test definition.
 func (r *testDefinitionRepo) Delete(ctx context.Context, id uuid.UUID) error {
 	result, err := r.db.pool.Exec(ctx, TestDefDelete, id)
@@ -311,7 +424,7 @@ func (r *testDefinitionRepo) Delete(ctx context.Context, id uuid.UUID) error {
 
 // ListByService returns test definitions for a service.
 func (r *testDefinitionRepo) ListByService(ctx context.Context, serviceID uuid.UUID, page Pagination) ([]TestDefinition, error) {
-	rows, err := r.db.pool.Query(ctx, TestDefListByService, serviceID, page.Limit, page.Offset)
+	rows, err := r.db.reader(ctx).Query(ctx, TestDefListByService, serviceID, page.Limit, page.Offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list test definitions: %w", err)
 	}
@@ -322,7 +435,7 @@ func (r *testDefinitionRepo) ListByService(ctx context.Context, serviceID uuid.U
 
 // ListByTags returns test definitions matching any of the given tags.
 func (r *testDefinitionRepo) ListByTags(ctx context.Context, serviceID uuid.UUID, tags []string, page Pagination) ([]TestDefinition, error) {
-	rows, err := r.db.pool.Query(ctx, TestDefListByTags, serviceID, tags, page.Limit, page.Offset)
+	rows, err := r.db.reader(ctx).Query(ctx, TestDefListByTags, serviceID, tags, page.Limit, page.Offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list test definitions by tags: %w", err)
 	}
@@ -352,6 +465,20 @@ func scanTestDefinitions(rows pgx.Rows) ([]TestDefinition, error) {
 			&def.DependsOn,
 			&def.Retries,
 			&def.AllowFailure,
+			&def.CacheKey,
+			&def.CachePaths,
+			&def.Environment,
+			&def.Secrets,
+			&def.LabelSelector,
+			&def.ContainerImage,
+			&def.Architectures,
+			&def.ArtifactMaxBytes,
+			&def.ArtifactMaxFiles,
+			&def.Owner,
+			&def.Matrix,
+			&def.RequiresApproval,
+			&def.CapturePatterns,
+			&def.Parameters,
 			&def.CreatedAt,
 			&def.UpdatedAt,
 		)
@@ -367,3 +494,449 @@ func scanTestDefinitions(rows pgx.Rows) ([]TestDefinition, error) {
 
 	return defs, nil
 }
+
+// deployKeyRepo implements DeployKeyRepository.
+type deployKeyRepo struct {
+	db *DB
+}
+
+// NewDeployKeyRepo creates a new deploy key repository.
+func NewDeployKeyRepo(db *DB) DeployKeyRepository {
+	return &deployKeyRepo{db: db}
+}
+
+// Upsert creates or replaces the deploy key for a service.
+func (r *deployKeyRepo) Upsert(ctx context.Context, key *ServiceDeployKey) error {
+	err := r.db.pool.QueryRow(ctx, DeployKeyUpsert,
+		key.ServiceID,
+		key.EncryptedPrivateKey,
+		key.PublicKey,
+		key.Fingerprint,
+		key.KnownHosts,
+	).Scan(&key.CreatedAt, &key.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save deploy key: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// GetByService retrieves the deploy key for a service.
+func (r *deployKeyRepo) GetByService(ctx context.Context, serviceID uuid.UUID) (*ServiceDeployKey, error) {
+	key := &ServiceDeployKey{}
+	err := r.db.pool.QueryRow(ctx, DeployKeyGetByService, serviceID).Scan(
+		&key.ServiceID,
+		&key.EncryptedPrivateKey,
+		&key.PublicKey,
+		&key.Fingerprint,
+		&key.KnownHosts,
+		&key.CreatedAt,
+		&key.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get deploy key: %w", err)
+	}
+	return key, nil
+}
+
+// Delete removes the deploy key for a service.
+func (r *deployKeyRepo) Delete(ctx context.Context, serviceID uuid.UUID) error {
+	result, err := r.db.pool.Exec(ctx, DeployKeyDelete, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to delete deploy key: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// gitCredentialRepo implements GitCredentialRepository.
+type gitCredentialRepo struct {
+	db *DB
+}
+
+// NewGitCredentialRepo creates a new git credential repository.
+func NewGitCredentialRepo(db *DB) GitCredentialRepository {
+	return &gitCredentialRepo{db: db}
+}
+
+// Upsert creates or replaces the git credential for a service.
+func (r *gitCredentialRepo) Upsert(ctx context.Context, cred *ServiceGitCredential) error {
+	err := r.db.pool.QueryRow(ctx, GitCredentialUpsert,
+		cred.ServiceID,
+		cred.Provider,
+		cred.BaseURL,
+		cred.EncryptedToken,
+	).Scan(&cred.CreatedAt, &cred.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save git credential: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// GetByService retrieves the git credential for a service.
+func (r *gitCredentialRepo) GetByService(ctx context.Context, serviceID uuid.UUID) (*ServiceGitCredential, error) {
+	cred := &ServiceGitCredential{}
+	err := r.db.pool.QueryRow(ctx, GitCredentialGetByService, serviceID).Scan(
+		&cred.ServiceID,
+		&cred.Provider,
+		&cred.BaseURL,
+		&cred.EncryptedToken,
+		&cred.CreatedAt,
+		&cred.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get git credential: %w", err)
+	}
+	return cred, nil
+}
+
+// Delete removes the git credential for a service.
+func (r *gitCredentialRepo) Delete(ctx context.Context, serviceID uuid.UUID) error {
+	result, err := r.db.pool.Exec(ctx, GitCredentialDelete, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to delete git credential: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// serviceEnvironmentRepo implements ServiceEnvironmentRepository.
+type serviceEnvironmentRepo struct {
+	db *DB
+}
+
+// NewServiceEnvironmentRepo creates a new service environment repository.
+func NewServiceEnvironmentRepo(db *DB) ServiceEnvironmentRepository {
+	return &serviceEnvironmentRepo{db: db}
+}
+
+// Upsert creates or replaces the environment set for a service.
+func (r *serviceEnvironmentRepo) Upsert(ctx context.Context, env *ServiceEnvironment) error {
+	return upsertServiceEnvironment(ctx, r.db.pool, env)
+}
+
+// upsertServiceEnvironment saves the environment set of a service with q.
+func upsertServiceEnvironment(ctx context.Context, q Querier, env *ServiceEnvironment) error {
+	// The columns are NOT NULL; store empty collections rather than NULL.
+	if env.Environment == nil {
+		env.Environment = map[string]string{}
+	}
+	if env.Secrets == nil {
+		env.Secrets = []SecretRef{}
+	}
+
+	err := q.QueryRow(ctx, ServiceEnvironmentUpsert,
+		env.ServiceID,
+		env.Environment,
+		env.Secrets,
+	).Scan(&env.CreatedAt, &env.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save service environment: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// GetByService retrieves the environment set for a service.
+func (r *serviceEnvironmentRepo) GetByService(ctx context.Context, serviceID uuid.UUID) (*ServiceEnvironment, error) {
+	env := &ServiceEnvironment{}
+	err := r.db.pool.QueryRow(ctx, ServiceEnvironmentGetByService, serviceID).Scan(
+		&env.ServiceID,
+		&env.Environment,
+		&env.Secrets,
+		&env.CreatedAt,
+		&env.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get service environment: %w", err)
+	}
+	return env, nil
+}
+
+// Delete removes the environment set for a service.
+func (r *serviceEnvironmentRepo) Delete(ctx context.Context, serviceID uuid.UUID) error {
+	result, err := r.db.pool.Exec(ctx, ServiceEnvironmentDelete, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to delete service environment: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// serviceTriggerRulesRepo implements ServiceTriggerRulesRepository.
+type serviceTriggerRulesRepo struct {
+	db *DB
+}
+
+// NewServiceTriggerRulesRepo creates a new service trigger rules repository.
+func NewServiceTriggerRulesRepo(db *DB) ServiceTriggerRulesRepository {
+	return &serviceTriggerRulesRepo{db: db}
+}
+
+// Upsert creates or replaces the trigger rules for a service.
+func (r *serviceTriggerRulesRepo) Upsert(ctx context.Context, rules *ServiceTriggerRules) error {
+	// The columns are NOT NULL; store empty arrays rather than NULL.
+	for _, patterns := range []*[]string{
+		&rules.Branches, &rules.IgnoreBranches, &rules.Paths,
+		&rules.IgnorePaths, &rules.Tags, &rules.SkipMarkers,
+	} {
+		if *patterns == nil {
+			*patterns = []string{}
+		}
+	}
+
+	err := r.db.pool.QueryRow(ctx, ServiceTriggerRulesUpsert,
+		rules.ServiceID,
+		rules.Branches,
+		rules.IgnoreBranches,
+		rules.Paths,
+		rules.IgnorePaths,
+		rules.Tags,
+		rules.SkipMarkers,
+	).Scan(&rules.CreatedAt, &rules.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save service trigger rules: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// GetByService retrieves the trigger rules for a service.
+func (r *serviceTriggerRulesRepo) GetByService(ctx context.Context, serviceID uuid.UUID) (*ServiceTriggerRules, error) {
+	rules := &ServiceTriggerRules{}
+	err := r.db.pool.QueryRow(ctx, ServiceTriggerRulesGetByService, serviceID).Scan(
+		&rules.ServiceID,
+		&rules.Branches,
+		&rules.IgnoreBranches,
+		&rules.Paths,
+		&rules.IgnorePaths,
+		&rules.Tags,
+		&rules.SkipMarkers,
+		&rules.CreatedAt,
+		&rules.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get service trigger rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Delete removes the trigger rules for a service.
+func (r *serviceTriggerRulesRepo) Delete(ctx context.Context, serviceID uuid.UUID) error {
+	result, err := r.db.pool.Exec(ctx, ServiceTriggerRulesDelete, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to delete service trigger rules: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// retryPolicyRepo implements RetryPolicyRepository.
+type retryPolicyRepo struct {
+	db *DB
+}
+
+// NewRetryPolicyRepo creates a new retry policy repository.
+func NewRetryPolicyRepo(db *DB) RetryPolicyRepository {
+	return &retryPolicyRepo{db: db}
+}
+
+// Upsert creates or replaces the policy of a service or test definition.
+func (r *retryPolicyRepo) Upsert(ctx context.Context, policy *RetryPolicy) error {
+	var row pgx.Row
+	if policy.TestDefinitionID == nil {
+		row = r.db.pool.QueryRow(ctx, RetryPolicyUpsertService,
+			policy.ServiceID,
+			policy.MaxAttempts,
+			policy.BackoffSeconds,
+			policy.MaxBackoffSeconds,
+			policy.RetryOn,
+		)
+	} else {
+		row = r.db.pool.QueryRow(ctx, RetryPolicyUpsertTestDefinition,
+			policy.ServiceID,
+			policy.TestDefinitionID,
+			policy.MaxAttempts,
+			policy.BackoffSeconds,
+			policy.MaxBackoffSeconds,
+			policy.RetryOn,
+		)
+	}
+
+	if err := row.Scan(&policy.ID, &policy.CreatedAt, &policy.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save retry policy: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// ListByService retrieves the policies of a service, the service policy first.
+func (r *retryPolicyRepo) ListByService(ctx context.Context, serviceID uuid.UUID) ([]RetryPolicy, error) {
+	rows, err := r.db.reader(ctx).Query(ctx, RetryPolicyListByService, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retry policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []RetryPolicy
+	for rows.Next() {
+		var p RetryPolicy
+		if err := rows.Scan(
+			&p.ID,
+			&p.ServiceID,
+			&p.TestDefinitionID,
+			&p.MaxAttempts,
+			&p.BackoffSeconds,
+			&p.MaxBackoffSeconds,
+			&p.RetryOn,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan retry policy: %w", err)
+		}
+		policies = append(policies, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating retry policies: %w", err)
+	}
+	return policies, nil
+}
+
+// Delete removes the policy of a service or test definition.
+func (r *retryPolicyRepo) Delete(ctx context.Context, serviceID uuid.UUID, testDefID *uuid.UUID) error {
+	result, err := r.db.pool.Exec(ctx, RetryPolicyDelete, serviceID, testDefID)
+	if err != nil {
+		return fmt.Errorf("failed to delete retry policy: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// serviceSyncRepo implements ServiceSyncRepository.
+type serviceSyncRepo struct {
+	db *DB
+}
+
+// NewServiceSyncRepo creates a new service sync repository.
+func NewServiceSyncRepo(db *DB) ServiceSyncRepository {
+	return &serviceSyncRepo{db: db}
+}
+
+// Record stores a finished sync.
+func (r *serviceSyncRepo) Record(ctx context.Context, sync *ServiceSync) error {
+	// The column is NOT NULL; store an empty array rather than NULL.
+	if sync.Errors == nil {
+		sync.Errors = []string{}
+	}
+
+	err := r.db.pool.QueryRow(ctx, ServiceSyncInsert,
+		sync.ServiceID,
+		sync.Branch,
+		sync.Trigger,
+		sync.Status,
+		sync.TestsAdded,
+		sync.TestsUpdated,
+		sync.TestsRemoved,
+		sync.Errors,
+		sync.StartedAt,
+		sync.FinishedAt,
+	).Scan(&sync.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to record service sync: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// Latest retrieves the most recent sync of a service.
+func (r *serviceSyncRepo) Latest(ctx context.Context, serviceID uuid.UUID) (*ServiceSync, error) {
+	sync, err := scanServiceSync(r.db.reader(ctx).QueryRow(ctx, ServiceSyncLatest, serviceID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get latest service sync: %w", err)
+	}
+	return sync, nil
+}
+
+// ListByService lists a service's syncs, most recent first.
+func (r *serviceSyncRepo) ListByService(ctx context.Context, serviceID uuid.UUID, page Pagination) ([]ServiceSync, int, error) {
+	var total int
+	if err := r.db.reader(ctx).QueryRow(ctx, ServiceSyncCountByService, serviceID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count service syncs: %w", err)
+	}
+
+	rows, err := r.db.reader(ctx).Query(ctx, ServiceSyncListByService, serviceID, page.Limit, page.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list service syncs: %w", err)
+	}
+	defer rows.Close()
+
+	var syncs []ServiceSync
+	for rows.Next() {
+		sync, err := scanServiceSync(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan service sync: %w", err)
+		}
+		syncs = append(syncs, *sync)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating service syncs: %w", err)
+	}
+	return syncs, total, nil
+}
+
+// ListDue lists up to limit services whose periodic sync is due.
+func (r *serviceSyncRepo) ListDue(ctx context.Context, defaultInterval time.Duration, limit int) ([]Service, error) {
+	rows, err := r.db.pool.Query(ctx, ServiceSyncListDue, int(defaultInterval.Seconds()), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services due for sync: %w", err)
+	}
+	defer rows.Close()
+
+	return scanServices(rows)
+}
+
+func scanServiceSync(row pgx.Row) (*ServiceSync, error) {
+	sync := &ServiceSync{}
+	err := row.Scan(
+		&sync.ID,
+		&sync.ServiceID,
+		&sync.Branch,
+		&sync.Trigger,
+		&sync.Status,
+		&sync.TestsAdded,
+		&sync.TestsUpdated,
+		&sync.TestsRemoved,
+		&sync.Errors,
+		&sync.StartedAt,
+		&sync.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return sync, nil
+}
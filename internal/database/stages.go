@@ -0,0 +1,111 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TestStages returns the pipeline stage of each test by name. Tests without
+// dependencies run in stage 0, every other test in the stage after the
+// latest of its dependencies. Dependencies on tests not in tests and
+// dependency cycles are ignored, so every test gets a stage.
+func TestStages(tests []TestDefinition) map[string]int {
+	deps := make(map[string][]string, len(tests))
+	for _, test := range tests {
+		deps[test.Name] = test.DependsOn
+	}
+
+	stages := make(map[string]int, len(tests))
+	visiting := make(map[string]bool)
+	var visit func(name string) int
+	visit = func(name string) int {
+		if stage, ok := stages[name]; ok {
+			return stage
+		}
+		visiting[name] = true
+		stage := 0
+		for _, dep := range deps[name] {
+			if _, ok := deps[dep]; !ok || visiting[dep] {
+				continue
+			}
+			stage = max(stage, visit(dep)+1)
+		}
+		visiting[name] = false
+		stages[name] = stage
+		return stage
+	}
+
+	for _, test := range tests {
+		visit(test.Name)
+	}
+	return stages
+}
+
+// ValidateTestDependencies checks that the tests of a service only depend
+// on each other and that their dependencies have no cycles.
+func ValidateTestDependencies(tests []TestDefinition) error {
+	deps := make(map[string][]string, len(tests))
+	for _, test := range tests {
+		deps[test.Name] = test.DependsOn
+	}
+	for _, test := range tests {
+		for _, dep := range test.DependsOn {
+			if _, ok := deps[dep]; !ok {
+				return fmt.Errorf("test %s depends on unknown test %q", test.Name, dep)
+			}
+		}
+	}
+
+	// 1 while a test's dependencies are visited, 2 once they are
+	state := make(map[string]int, len(tests))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("circular dependency: %s", strings.Join(append(path, name), " -> "))
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, dep := range deps[name] {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		return nil
+	}
+
+	for _, test := range tests {
+		if err := visit(test.Name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BlockedShards returns the pending shards of a run that can no longer
+// run because a shard of an earlier stage failed, errored or was
+// cancelled.
+func BlockedShards(shards []RunShard) []RunShard {
+	failedStage := -1
+	for _, shard := range shards {
+		switch shard.Status {
+		case ShardStatusFailed, ShardStatusError, ShardStatusCancelled:
+			if failedStage < 0 || shard.Stage < failedStage {
+				failedStage = shard.Stage
+			}
+		}
+	}
+	if failedStage < 0 {
+		return nil
+	}
+
+	var blocked []RunShard
+	for _, shard := range shards {
+		if shard.Status == ShardStatusPending && shard.Stage > failedStage {
+			blocked = append(blocked, shard)
+		}
+	}
+	return blocked
+}
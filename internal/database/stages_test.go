@@ -0,0 +1,60 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestStages(t *testing.T) {
+	tests := []TestDefinition{
+		{Name: "e2e", DependsOn: []string{"integration", "lint"}},
+		{Name: "unit"},
+		{Name: "lint"},
+		{Name: "integration", DependsOn: []string{"unit"}},
+		{Name: "smoke", DependsOn: []string{"removed"}},
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"a"}},
+	}
+
+	stages := TestStages(tests)
+	assert.Equal(t, 0, stages["unit"])
+	assert.Equal(t, 0, stages["lint"])
+	assert.Equal(t, 1, stages["integration"])
+	assert.Equal(t, 2, stages["e2e"])
+	assert.Equal(t, 0, stages["smoke"], "unknown dependencies are ignored")
+	assert.Len(t, stages, len(tests), "cycles still get a stage")
+}
+
+func TestValidateTestDependencies(t *testing.T) {
+	assert.NoError(t, ValidateTestDependencies([]TestDefinition{
+		{Name: "unit"},
+		{Name: "integration", DependsOn: []string{"unit"}},
+	}))
+
+	err := ValidateTestDependencies([]TestDefinition{
+		{Name: "integration", DependsOn: []string{"unit"}},
+	})
+	assert.ErrorContains(t, err, `unknown test "unit"`)
+
+	err = ValidateTestDependencies([]TestDefinition{
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"a"}},
+	})
+	assert.ErrorContains(t, err, "circular dependency: a -> b -> a")
+}
+
+func TestBlockedShards(t *testing.T) {
+	shards := []RunShard{
+		{ShardIndex: 0, Stage: 0, Status: ShardStatusPassed},
+		{ShardIndex: 1, Stage: 0, Status: ShardStatusRunning},
+		{ShardIndex: 0, Stage: 1, Status: ShardStatusPending},
+	}
+	assert.Empty(t, BlockedShards(shards))
+
+	shards[1].Status = ShardStatusFailed
+	blocked := BlockedShards(shards)
+	if assert.Len(t, blocked, 1) {
+		assert.Equal(t, 1, blocked[0].Stage)
+	}
+}
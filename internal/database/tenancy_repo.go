@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// organizationRepo implements OrganizationRepository.
+type organizationRepo struct {
+	db *DB
+}
+
+// NewOrganizationRepo creates a new organization repository.
+func NewOrganizationRepo(db *DB) OrganizationRepository {
+	return &organizationRepo{db: db}
+}
+
+// Create creates a new organization.
+func (r *organizationRepo) Create(ctx context.Context, org *Organization) error {
+	err := r.db.pool.QueryRow(ctx, OrganizationInsert,
+		org.Name,
+		org.DisplayName,
+	).Scan(&org.ID, &org.CreatedAt, &org.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create organization: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// Get retrieves an organization by ID.
+func (r *organizationRepo) Get(ctx context.Context, id uuid.UUID) (*Organization, error) {
+	org := &Organization{}
+	err := r.db.reader(ctx).QueryRow(ctx, OrganizationGetByID, id).Scan(
+		&org.ID,
+		&org.Name,
+		&org.DisplayName,
+		&org.CreatedAt,
+		&org.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return org, nil
+}
+
+// List returns organizations by name with the total count.
+func (r *organizationRepo) List(ctx context.Context, page Pagination) ([]Organization, int, error) {
+	var total int
+	if err := r.db.reader(ctx).QueryRow(ctx, OrganizationCount).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count organizations: %w", err)
+	}
+
+	rows, err := r.db.reader(ctx).Query(ctx, OrganizationList, page.Limit, page.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	defer rows.Close()
+
+	var orgs []Organization
+	for rows.Next() {
+		var org Organization
+		if err := rows.Scan(&org.ID, &org.Name, &org.DisplayName, &org.CreatedAt, &org.UpdatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		orgs = append(orgs, org)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate organizations: %w", err)
+	}
+	return orgs, total, nil
+}
+
+// projectRepo implements ProjectRepository.
+type projectRepo struct {
+	db *DB
+}
+
+// NewProjectRepo creates a new project repository.
+func NewProjectRepo(db *DB) ProjectRepository {
+	return &projectRepo{db: db}
+}
+
+// Create creates a new project.
+func (r *projectRepo) Create(ctx context.Context, project *Project) error {
+	err := r.db.pool.QueryRow(ctx, ProjectInsert,
+		project.OrganizationID,
+		project.Name,
+		project.DisplayName,
+	).Scan(&project.ID, &project.CreatedAt, &project.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create project: %w", WrapDBError(err))
+	}
+	return nil
+}
+
+// Get retrieves a project by ID.
+func (r *projectRepo) Get(ctx context.Context, id uuid.UUID) (*Project, error) {
+	project := &Project{}
+	err := r.db.reader(ctx).QueryRow(ctx, ProjectGetByID, id).Scan(
+		&project.ID,
+		&project.OrganizationID,
+		&project.Name,
+		&project.DisplayName,
+		&project.CreatedAt,
+		&project.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	return project, nil
+}
+
+// ListByOrganization returns the projects of an organization by name with
+// the total count. Only the projects in the scope of ctx are returned.
+func (r *projectRepo) ListByOrganization(ctx context.Context, orgID uuid.UUID, page Pagination) ([]Project, int, error) {
+	scope := projectScopeArg(ctx)
+
+	var total int
+	if err := r.db.reader(ctx).QueryRow(ctx, ProjectCountByOrganization, orgID, scope).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count projects: %w", err)
+	}
+
+	rows, err := r.db.reader(ctx).Query(ctx, ProjectListByOrganization, orgID, page.Limit, page.Offset, scope)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list projects: %w", err)
+	}
+	defer rows.Close()
+
+	var projects []Project
+	for rows.Next() {
+		var project Project
+		err := rows.Scan(
+			&project.ID,
+			&project.OrganizationID,
+			&project.Name,
+			&project.DisplayName,
+			&project.CreatedAt,
+			&project.UpdatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan project: %w", err)
+		}
+		projects = append(projects, project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate projects: %w", err)
+	}
+	return projects, total, nil
+}
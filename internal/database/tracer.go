@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// maxLoggedQueryLength bounds the SQL text included in slow query logs.
+const maxLoggedQueryLength = 1000
+
+// slowQueryTracer logs queries and batches that take at least threshold.
+type slowQueryTracer struct {
+	threshold time.Duration
+	logger    *slog.Logger
+	now       func() time.Time
+}
+
+type slowQueryKey struct{}
+
+// tracedQuery is stored in the query context between start and end.
+type tracedQuery struct {
+	sql     string
+	started time.Time
+	// queries counts the statements sent in a batch.
+	queries int
+}
+
+func newSlowQueryTracer(threshold time.Duration, logger *slog.Logger) *slowQueryTracer {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &slowQueryTracer{
+		threshold: threshold,
+		logger:    logger.With("component", "database"),
+		now:       time.Now,
+	}
+}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (t *slowQueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, slowQueryKey{}, &tracedQuery{sql: data.SQL, started: t.now()})
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (t *slowQueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	q, ok := ctx.Value(slowQueryKey{}).(*tracedQuery)
+	if !ok {
+		return
+	}
+	elapsed := t.now().Sub(q.started)
+	if elapsed < t.threshold {
+		return
+	}
+
+	attrs := []any{
+		"duration_ms", elapsed.Milliseconds(),
+		"threshold_ms", t.threshold.Milliseconds(),
+		"sql", compactSQL(q.sql),
+		"rows", data.CommandTag.RowsAffected(),
+	}
+	if data.Err != nil {
+		attrs = append(attrs, "error", data.Err)
+	}
+	t.logger.WarnContext(ctx, "slow query", attrs...)
+}
+
+// TraceBatchStart implements pgx.BatchTracer.
+func (t *slowQueryTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceBatchStartData) context.Context {
+	return context.WithValue(ctx, slowQueryKey{}, &tracedQuery{started: t.now()})
+}
+
+// TraceBatchQuery implements pgx.BatchTracer. The first statement stands in
+// for the batch in the log.
+func (t *slowQueryTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	if q, ok := ctx.Value(slowQueryKey{}).(*tracedQuery); ok {
+		if q.queries == 0 {
+			q.sql = data.SQL
+		}
+		q.queries++
+	}
+}
+
+// TraceBatchEnd implements pgx.BatchTracer.
+func (t *slowQueryTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	q, ok := ctx.Value(slowQueryKey{}).(*tracedQuery)
+	if !ok {
+		return
+	}
+	elapsed := t.now().Sub(q.started)
+	if elapsed < t.threshold {
+		return
+	}
+
+	attrs := []any{
+		"duration_ms", elapsed.Milliseconds(),
+		"threshold_ms", t.threshold.Milliseconds(),
+		"sql", compactSQL(q.sql),
+		"queries", q.queries,
+	}
+	if data.Err != nil {
+		attrs = append(attrs, "error", data.Err)
+	}
+	t.logger.WarnContext(ctx, "slow batch", attrs...)
+}
+
+// compactSQL collapses whitespace in a query and truncates it for logging.
+func compactSQL(sql string) string {
+	sql = strings.Join(strings.Fields(sql), " ")
+	if len(sql) > maxLoggedQueryLength {
+		sql = sql[:maxLoggedQueryLength] + "..."
+	}
+	return sql
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTracer(threshold time.Duration) (*slowQueryTracer, *bytes.Buffer, *time.Time) {
+	var buf bytes.Buffer
+	tracer := newSlowQueryTracer(threshold, slog.New(slog.NewJSONHandler(&buf, nil)))
+	now := time.Now()
+	tracer.now = func() time.Time { return now }
+	return tracer, &buf, &now
+}
+
+func TestSlowQueryTracer_Query(t *testing.T) {
+	tracer, buf, now := newTestTracer(100 * time.Millisecond)
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	*now = now.Add(50 * time.Millisecond)
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+	assert.Empty(t, buf.String(), "fast queries are not logged")
+
+	ctx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{
+		SQL: "SELECT id\n\t\tFROM test_runs\n\t\tWHERE id = $1",
+	})
+	*now = now.Add(250 * time.Millisecond)
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{
+		CommandTag: pgconn.NewCommandTag("SELECT 1"),
+		Err:        errors.New("boom"),
+	})
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "slow query", entry["msg"])
+	assert.Equal(t, "SELECT id FROM test_runs WHERE id = $1", entry["sql"])
+	assert.EqualValues(t, 250, entry["duration_ms"])
+	assert.EqualValues(t, 1, entry["rows"])
+	assert.Equal(t, "boom", entry["error"])
+}
+
+func TestSlowQueryTracer_Batch(t *testing.T) {
+	tracer, buf, now := newTestTracer(100 * time.Millisecond)
+
+	ctx := tracer.TraceBatchStart(context.Background(), nil, pgx.TraceBatchStartData{})
+	for i := 0; i < 3; i++ {
+		tracer.TraceBatchQuery(ctx, nil, pgx.TraceBatchQueryData{SQL: "INSERT INTO test_results VALUES ($1)"})
+	}
+	*now = now.Add(time.Second)
+	tracer.TraceBatchEnd(ctx, nil, pgx.TraceBatchEndData{})
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "slow batch", entry["msg"])
+	assert.Equal(t, "INSERT INTO test_results VALUES ($1)", entry["sql"])
+	assert.EqualValues(t, 3, entry["queries"])
+}
+
+func TestCompactSQL(t *testing.T) {
+	assert.Equal(t, "SELECT 1", compactSQL("  SELECT\n\t1  "))
+
+	long := compactSQL("SELECT " + strings.Repeat("x", 2*maxLoggedQueryLength))
+	assert.Len(t, long, maxLoggedQueryLength+3)
+	assert.True(t, strings.HasSuffix(long, "..."))
+}
@@ -0,0 +1,77 @@
+// Package energy estimates the energy used by test runs from agent resource
+// usage and converts it to carbon emissions using per-zone grid carbon
+// intensity factors.
+package energy
+
+import "time"
+
+const bytesPerGB = 1 << 30
+
+// Model estimates the power draw of an agent from its resource usage. The
+// estimate is linear in CPU utilization and memory in use; idle and
+// embodied power are not included.
+type Model struct {
+	// WattsPerCore is the power of a fully utilized CPU core.
+	WattsPerCore float64
+	// WattsPerMemoryGB is the power of one GiB of memory in use.
+	WattsPerMemoryGB float64
+}
+
+// Watts returns the estimated power draw for a CPU utilization (0-100)
+// across cores and the memory in use.
+func (m Model) Watts(cpuPercent float64, cpuCores int, memoryBytes int64) float64 {
+	if cpuCores < 1 {
+		cpuCores = 1
+	}
+	cpuPercent = min(max(cpuPercent, 0), 100)
+
+	cpu := float64(cpuCores) * m.WattsPerCore * cpuPercent / 100
+	memory := float64(max(memoryBytes, 0)) / bytesPerGB * m.WattsPerMemoryGB
+	return cpu + memory
+}
+
+// EnergyWh returns the estimated energy in watt-hours used at the given
+// resource usage over a duration.
+func (m Model) EnergyWh(cpuPercent float64, cpuCores int, memoryBytes int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return m.Watts(cpuPercent, cpuCores, memoryBytes) * d.Hours()
+}
+
+// CarbonIntensity holds grid carbon intensity factors in grams of CO2
+// equivalent per kWh.
+type CarbonIntensity struct {
+	// Default applies to zones without a factor of their own.
+	Default float64
+	// Zones maps network zones to their factor.
+	Zones map[string]float64
+}
+
+// ForZone returns the carbon intensity of a zone.
+func (c CarbonIntensity) ForZone(zone string) float64 {
+	if intensity, ok := c.Zones[zone]; ok {
+		return intensity
+	}
+	return c.Default
+}
+
+// Zone picks the zone an agent's energy is attributed to: the first of its
+// network zones with a configured factor, otherwise its first zone.
+func (c CarbonIntensity) Zone(zones []string) string {
+	for _, zone := range zones {
+		if _, ok := c.Zones[zone]; ok {
+			return zone
+		}
+	}
+	if len(zones) > 0 {
+		return zones[0]
+	}
+	return ""
+}
+
+// CarbonGrams returns the grams of CO2 equivalent emitted for energy used
+// in a zone.
+func (c CarbonIntensity) CarbonGrams(zone string, energyWh float64) float64 {
+	return energyWh / 1000 * c.ForZone(zone)
+}
@@ -0,0 +1,39 @@
+package energy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModel_Watts(t *testing.T) {
+	m := Model{WattsPerCore: 10, WattsPerMemoryGB: 0.5}
+
+	assert.InDelta(t, 20.0, m.Watts(50, 4, 0), 1e-9)
+	assert.InDelta(t, 42.0, m.Watts(100, 4, 4<<30), 1e-9)
+	assert.InDelta(t, 10.0, m.Watts(100, 0, 0), 1e-9, "at least one core")
+	assert.InDelta(t, 40.0, m.Watts(250, 4, -1), 1e-9, "utilization is clamped")
+}
+
+func TestModel_EnergyWh(t *testing.T) {
+	m := Model{WattsPerCore: 10}
+
+	assert.InDelta(t, 5.0, m.EnergyWh(50, 2, 0, 30*time.Minute), 1e-9)
+	assert.Zero(t, m.EnergyWh(50, 2, 0, 0))
+}
+
+func TestCarbonIntensity(t *testing.T) {
+	c := CarbonIntensity{Default: 400, Zones: map[string]float64{"eu-north": 40}}
+
+	assert.Equal(t, 40.0, c.ForZone("eu-north"))
+	assert.Equal(t, 400.0, c.ForZone("us-east"))
+	assert.Equal(t, 400.0, c.ForZone(""))
+
+	assert.Equal(t, "eu-north", c.Zone([]string{"internal", "eu-north"}))
+	assert.Equal(t, "internal", c.Zone([]string{"internal", "staging"}))
+	assert.Equal(t, "", c.Zone(nil))
+
+	assert.InDelta(t, 4.0, c.CarbonGrams("eu-north", 100), 1e-9)
+	assert.InDelta(t, 0.4, c.CarbonGrams("us-east", 1), 1e-9)
+}
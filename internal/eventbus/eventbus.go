@@ -0,0 +1,274 @@
+// Package eventbus publishes domain events to an event bus, NATS JetStream
+// or Kafka, for downstream data pipelines.
+//
+// Every event is wrapped in an Envelope that names its type and the version
+// of its payload schema, and is sent to the subject or topic
+// <prefix>.<type>, keyed by the run, agent or service it is about so that the
+// events of one entity stay in order. Events are queued in memory and retried
+// until the broker acknowledges them, so they are delivered at least once
+// while the control plane runs; consumers deduplicate by the envelope ID.
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types.
+const (
+	TypeRunUpdated     = "run.updated"
+	TypeAgentUpdated   = "agent.updated"
+	TypeTestCompleted  = "test.completed"
+	TypeServiceUpdated = "service.updated"
+	TypeServiceSynced  = "service.synced"
+)
+
+// SchemaVersion is the version of the payload schemas in this package. It is
+// incremented when a payload changes incompatibly.
+const SchemaVersion = 1
+
+// Headers sent with every message, next to the broker's own message ID.
+const (
+	HeaderEventType     = "Conductor-Event-Type"
+	HeaderSchemaVersion = "Conductor-Schema-Version"
+)
+
+const (
+	// batchSize is the maximum number of messages sent to the broker at
+	// once.
+	batchSize = 100
+	// maxRetryBackoff caps the wait between attempts to send a batch.
+	maxRetryBackoff = 30 * time.Second
+)
+
+// ErrBufferFull is returned when an event is published while the queue of
+// events waiting for the broker is full. The event is dropped.
+var ErrBufferFull = errors.New("event bus buffer is full")
+
+// ErrClosed is returned when an event is published after the publisher was
+// closed.
+var ErrClosed = errors.New("event bus publisher is closed")
+
+// Envelope is the JSON body of every message.
+type Envelope struct {
+	// ID identifies the event; redelivered events keep their ID.
+	ID   uuid.UUID `json:"id"`
+	Type string    `json:"type"`
+	// SchemaVersion is the version of the schema of Data.
+	SchemaVersion int             `json:"schemaVersion"`
+	Source        string          `json:"source"`
+	Time          time.Time       `json:"time"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// Message is an event ready to be sent to the broker.
+type Message struct {
+	// ID identifies the event; brokers that deduplicate use it as the
+	// message ID.
+	ID string
+	// Type is the event type, which the subject or topic is named after.
+	Type string
+	// Key is the ID of the entity the event is about. Events with the same
+	// key are kept in order.
+	Key  string
+	Body []byte
+}
+
+// Sink sends messages to a broker.
+type Sink interface {
+	// Publish sends messages and returns once the broker acknowledged all
+	// of them. On error the messages may be sent again.
+	Publish(ctx context.Context, msgs []Message) error
+	// Close releases the connection to the broker.
+	Close() error
+}
+
+// Config holds the settings of a Publisher.
+type Config struct {
+	// BufferSize is how many events may wait for the broker before new
+	// events are dropped.
+	BufferSize int
+	// PublishTimeout bounds each attempt to send a batch of events.
+	PublishTimeout time.Duration
+}
+
+// Publisher queues events and sends them to a Sink in the background.
+type Publisher struct {
+	sink   Sink
+	config Config
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan Message
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewPublisher creates a new Publisher. Call Start to begin sending events.
+func NewPublisher(sink Sink, config Config, logger *slog.Logger) *Publisher {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if config.BufferSize < 1 {
+		config.BufferSize = 10000
+	}
+	if config.PublishTimeout <= 0 {
+		config.PublishTimeout = 10 * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Publisher{
+		sink:   sink,
+		config: config,
+		logger: logger.With("component", "event_bus"),
+		now:    time.Now,
+		queue:  make(chan Message, config.BufferSize),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+}
+
+// Start sends queued events to the sink until Close is called.
+func (p *Publisher) Start() {
+	p.logger.Info("starting event bus publisher", "buffer_size", p.config.BufferSize)
+
+	go func() {
+		defer close(p.done)
+
+		batch := make([]Message, 0, batchSize)
+		for msg := range p.queue {
+			batch = append(batch[:0], msg)
+		fill:
+			for len(batch) < batchSize {
+				select {
+				case msg, ok := <-p.queue:
+					if !ok {
+						break fill
+					}
+					batch = append(batch, msg)
+				default:
+					break fill
+				}
+			}
+			p.send(batch)
+		}
+	}()
+}
+
+// Close stops accepting events and waits until the queued events are sent
+// or ctx is done, then closes the sink. Events still queued when ctx is done
+// are lost.
+func (p *Publisher) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.queue)
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		p.logger.Warn("event bus publisher closed with events queued", "queued", len(p.queue))
+		p.cancel()
+		<-p.done
+	}
+	p.cancel()
+	return p.sink.Close()
+}
+
+// send sends a batch to the sink, retrying with backoff until the broker
+// acknowledges it or the publisher is closed.
+func (p *Publisher) send(batch []Message) {
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(p.ctx, p.config.PublishTimeout)
+		err := p.sink.Publish(ctx, batch)
+		cancel()
+		if err == nil {
+			return
+		}
+		if p.ctx.Err() != nil {
+			return
+		}
+
+		wait := backoff(attempt)
+		p.logger.Warn("failed to publish events, retrying",
+			"events", len(batch),
+			"attempt", attempt,
+			"retry_in", wait,
+			"error", err,
+		)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-p.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the wait after attempt n (starting at 1) to send a batch.
+func backoff(n int) time.Duration {
+	wait := 100 * time.Millisecond
+	for i := 1; i < n && wait < maxRetryBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxRetryBackoff)
+}
+
+// publish wraps data in an envelope and queues it.
+func (p *Publisher) publish(eventType, key string, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+	}
+
+	envelope := Envelope{
+		ID:            uuid.New(),
+		Type:          eventType,
+		SchemaVersion: SchemaVersion,
+		Source:        "conductor",
+		Time:          p.now().UTC(),
+		Data:          body,
+	}
+	body, err = json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+	}
+
+	msg := Message{
+		ID:   envelope.ID.String(),
+		Type: eventType,
+		Key:  key,
+		Body: body,
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	select {
+	case p.queue <- msg:
+		return nil
+	default:
+		p.logger.Error("dropped event, buffer is full", "type", eventType, "key", key)
+		return ErrBufferFull
+	}
+}
@@ -0,0 +1,160 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/websocket"
+)
+
+// fakeSink records published batches and fails the first failures calls.
+type fakeSink struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	batches  [][]Message
+	closed   bool
+	block    chan struct{}
+}
+
+func (s *fakeSink) Publish(ctx context.Context, msgs []Message) error {
+	if s.block != nil {
+		select {
+		case <-s.block:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.calls <= s.failures {
+		return errors.New("broker unavailable")
+	}
+	s.batches = append(s.batches, append([]Message(nil), msgs...))
+	return nil
+}
+
+func (s *fakeSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *fakeSink) messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var msgs []Message
+	for _, b := range s.batches {
+		msgs = append(msgs, b...)
+	}
+	return msgs
+}
+
+func TestPublishEnvelope(t *testing.T) {
+	now := time.Date(2026, 1, 25, 12, 0, 0, 0, time.UTC)
+	sink := &fakeSink{}
+	p := NewPublisher(sink, Config{}, nil)
+	p.now = func() time.Time { return now }
+	p.Start()
+
+	runID := uuid.New()
+	require.NoError(t, p.PublishRunUpdate(websocket.RunEvent{
+		RunID:       runID,
+		Status:      "passed",
+		TotalTests:  3,
+		PassedTests: 3,
+	}))
+	require.NoError(t, p.PublishLogChunk(runID, websocket.LogChunk{}))
+	require.NoError(t, p.Close(context.Background()))
+
+	msgs := sink.messages()
+	require.Len(t, msgs, 1, "log chunks are not published")
+	assert.Equal(t, TypeRunUpdated, msgs[0].Type)
+	assert.Equal(t, runID.String(), msgs[0].Key)
+
+	var envelope struct {
+		Envelope
+		Data RunUpdated `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(msgs[0].Body, &envelope))
+	assert.Equal(t, msgs[0].ID, envelope.ID.String())
+	assert.Equal(t, TypeRunUpdated, envelope.Type)
+	assert.Equal(t, SchemaVersion, envelope.SchemaVersion)
+	assert.Equal(t, "conductor", envelope.Source)
+	assert.Equal(t, now, envelope.Time)
+	assert.Equal(t, runID, envelope.Data.RunID)
+	assert.Equal(t, "passed", envelope.Data.Status)
+	assert.Equal(t, 3, envelope.Data.PassedTests)
+	assert.True(t, sink.closed)
+}
+
+func TestPublishRetriesUntilAcknowledged(t *testing.T) {
+	sink := &fakeSink{failures: 2}
+	p := NewPublisher(sink, Config{}, nil)
+	p.Start()
+
+	serviceID := uuid.New()
+	require.NoError(t, p.PublishServiceSync(websocket.ServiceSyncEvent{ServiceID: serviceID, Applied: true}))
+	require.NoError(t, p.Close(context.Background()))
+
+	assert.Equal(t, 3, sink.calls)
+	msgs := sink.messages()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, TypeServiceSynced, msgs[0].Type)
+	assert.Equal(t, serviceID.String(), msgs[0].Key)
+}
+
+func TestPublishBatches(t *testing.T) {
+	sink := &fakeSink{block: make(chan struct{})}
+	p := NewPublisher(sink, Config{BufferSize: 1000}, nil)
+	p.Start()
+
+	// The first event is taken by the worker, which blocks in the sink; the
+	// rest queue up and are sent in batches once it is unblocked.
+	for range 2*batchSize + 1 {
+		require.NoError(t, p.PublishAgentUpdate(websocket.AgentEvent{AgentID: uuid.New()}))
+	}
+	close(sink.block)
+	require.NoError(t, p.Close(context.Background()))
+
+	assert.Len(t, sink.messages(), 2*batchSize+1)
+	for _, b := range sink.batches {
+		assert.LessOrEqual(t, len(b), batchSize)
+	}
+	assert.Len(t, sink.batches, 3)
+}
+
+func TestPublishBufferFullAndClosed(t *testing.T) {
+	sink := &fakeSink{block: make(chan struct{})}
+	p := NewPublisher(sink, Config{BufferSize: 1}, nil)
+
+	// Not started, so nothing drains the queue.
+	require.NoError(t, p.PublishTestResult(uuid.New(), websocket.TestResultEvent{TestName: "a"}))
+	assert.ErrorIs(t, p.PublishTestResult(uuid.New(), websocket.TestResultEvent{TestName: "b"}), ErrBufferFull)
+
+	p.Start()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, p.Close(ctx), "close gives up on queued events when ctx is done")
+	assert.Empty(t, sink.messages())
+
+	assert.ErrorIs(t, p.PublishServiceUpdate(websocket.ServiceEvent{ServiceID: uuid.New()}), ErrClosed)
+	require.NoError(t, p.Close(context.Background()))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 100*time.Millisecond, backoff(1))
+	assert.Equal(t, 400*time.Millisecond, backoff(3))
+	assert.Equal(t, maxRetryBackoff, backoff(20))
+}
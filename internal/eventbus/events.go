@@ -0,0 +1,144 @@
+package eventbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/internal/websocket"
+)
+
+// RunUpdated is the payload of run.updated events, published when a run
+// starts, progresses, finishes or is cancelled.
+type RunUpdated struct {
+	RunID        uuid.UUID  `json:"runId"`
+	ServiceID    uuid.UUID  `json:"serviceId"`
+	Status       string     `json:"status"`
+	TotalTests   int        `json:"totalTests"`
+	PassedTests  int        `json:"passedTests"`
+	FailedTests  int        `json:"failedTests"`
+	SkippedTests int        `json:"skippedTests"`
+	DurationMs   *int64     `json:"durationMs,omitempty"`
+	ErrorMessage *string    `json:"errorMessage,omitempty"`
+	StartedAt    *time.Time `json:"startedAt,omitempty"`
+	FinishedAt   *time.Time `json:"finishedAt,omitempty"`
+}
+
+// AgentUpdated is the payload of agent.updated events, published when an
+// agent registers, disconnects or times out.
+type AgentUpdated struct {
+	AgentID         uuid.UUID  `json:"agentId"`
+	Name            string     `json:"name"`
+	Status          string     `json:"status"`
+	LastHeartbeat   *time.Time `json:"lastHeartbeat,omitempty"`
+	ActiveJobs      int        `json:"activeJobs"`
+	Version         *string    `json:"version,omitempty"`
+	DockerAvailable bool       `json:"dockerAvailable"`
+}
+
+// TestCompleted is the payload of test.completed events, published for each
+// test result of a run.
+type TestCompleted struct {
+	RunID        uuid.UUID `json:"runId"`
+	TestName     string    `json:"testName"`
+	SuiteName    *string   `json:"suiteName,omitempty"`
+	Status       string    `json:"status"`
+	DurationMs   *int64    `json:"durationMs,omitempty"`
+	ErrorMessage *string   `json:"errorMessage,omitempty"`
+}
+
+// ServiceUpdated is the payload of service.updated events.
+type ServiceUpdated struct {
+	ServiceID     uuid.UUID  `json:"serviceId"`
+	Name          string     `json:"name"`
+	LastRunStatus *string    `json:"lastRunStatus,omitempty"`
+	LastRunAt     *time.Time `json:"lastRunAt,omitempty"`
+}
+
+// ServiceSynced is the payload of service.synced events, published when the
+// test definitions of a service were synced from its repository. Applied is
+// false when the changes were rolled back.
+type ServiceSynced struct {
+	ServiceID uuid.UUID `json:"serviceId"`
+	Branch    string    `json:"branch"`
+	Added     []string  `json:"added"`
+	Updated   []string  `json:"updated"`
+	Removed   []string  `json:"removed"`
+	Errors    []string  `json:"errors"`
+	Applied   bool      `json:"applied"`
+	SyncedAt  time.Time `json:"syncedAt"`
+}
+
+var _ websocket.EventPublisher = (*Publisher)(nil)
+
+// PublishRunUpdate publishes a run.updated event.
+func (p *Publisher) PublishRunUpdate(run websocket.RunEvent) error {
+	return p.publish(TypeRunUpdated, run.RunID.String(), RunUpdated{
+		RunID:        run.RunID,
+		ServiceID:    run.ServiceID,
+		Status:       run.Status,
+		TotalTests:   run.TotalTests,
+		PassedTests:  run.PassedTests,
+		FailedTests:  run.FailedTests,
+		SkippedTests: run.SkippedTests,
+		DurationMs:   run.DurationMs,
+		ErrorMessage: run.ErrorMessage,
+		StartedAt:    run.StartedAt,
+		FinishedAt:   run.FinishedAt,
+	})
+}
+
+// PublishAgentUpdate publishes an agent.updated event.
+func (p *Publisher) PublishAgentUpdate(agent websocket.AgentEvent) error {
+	return p.publish(TypeAgentUpdated, agent.AgentID.String(), AgentUpdated{
+		AgentID:         agent.AgentID,
+		Name:            agent.Name,
+		Status:          agent.Status,
+		LastHeartbeat:   agent.LastHeartbeat,
+		ActiveJobs:      agent.ActiveJobs,
+		Version:         agent.Version,
+		DockerAvailable: agent.DockerAvailable,
+	})
+}
+
+// PublishLogChunk does nothing: run output is streamed to clients, not
+// published as events.
+func (p *Publisher) PublishLogChunk(uuid.UUID, websocket.LogChunk) error {
+	return nil
+}
+
+// PublishTestResult publishes a test.completed event.
+func (p *Publisher) PublishTestResult(runID uuid.UUID, result websocket.TestResultEvent) error {
+	return p.publish(TypeTestCompleted, runID.String(), TestCompleted{
+		RunID:        runID,
+		TestName:     result.TestName,
+		SuiteName:    result.SuiteName,
+		Status:       result.Status,
+		DurationMs:   result.DurationMs,
+		ErrorMessage: result.ErrorMessage,
+	})
+}
+
+// PublishServiceUpdate publishes a service.updated event.
+func (p *Publisher) PublishServiceUpdate(service websocket.ServiceEvent) error {
+	return p.publish(TypeServiceUpdated, service.ServiceID.String(), ServiceUpdated{
+		ServiceID:     service.ServiceID,
+		Name:          service.Name,
+		LastRunStatus: service.LastRunStatus,
+		LastRunAt:     service.LastRunAt,
+	})
+}
+
+// PublishServiceSync publishes a service.synced event.
+func (p *Publisher) PublishServiceSync(sync websocket.ServiceSyncEvent) error {
+	return p.publish(TypeServiceSynced, sync.ServiceID.String(), ServiceSynced{
+		ServiceID: sync.ServiceID,
+		Branch:    sync.Branch,
+		Added:     sync.Added,
+		Updated:   sync.Updated,
+		Removed:   sync.Removed,
+		Errors:    sync.Errors,
+		Applied:   sync.Applied,
+		SyncedAt:  sync.SyncedAt,
+	})
+}
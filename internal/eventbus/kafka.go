@@ -0,0 +1,90 @@
+package eventbus
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+// KafkaConfig holds the settings of a Kafka sink.
+type KafkaConfig struct {
+	// Brokers are the addresses of the Kafka brokers to bootstrap from.
+	Brokers []string
+	// Username and Password authenticate with SASL/PLAIN, if set.
+	Username string
+	Password string
+	// TLS requires TLS connections.
+	TLS bool
+	// Prefix is prepended to every topic.
+	Prefix string
+}
+
+// kafkaSink produces messages to Kafka.
+type kafkaSink struct {
+	writer *kafka.Writer
+	prefix string
+}
+
+// NewKafkaSink creates a Kafka producer. Topics are created on first use if
+// the brokers allow it.
+func NewKafkaSink(cfg KafkaConfig) (Sink, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("at least one Kafka broker is required")
+	}
+
+	transport := &kafka.Transport{ClientID: "conductor-control-plane"}
+	if cfg.Username != "" {
+		transport.SASL = plain.Mechanism{Username: cfg.Username, Password: cfg.Password}
+	}
+	if cfg.TLS {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	return &kafkaSink{
+		writer: &kafka.Writer{
+			Addr: kafka.TCP(cfg.Brokers...),
+			// Hashing the key keeps the events of a run, agent or service
+			// on one partition, in order
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			BatchSize:              batchSize,
+			BatchTimeout:           10 * time.Millisecond,
+			AllowAutoTopicCreation: true,
+			Transport:              transport,
+		},
+		prefix: cfg.Prefix,
+	}, nil
+}
+
+// Publish produces each message to <prefix>.<type> and waits until all
+// in-sync replicas stored it.
+func (s *kafkaSink) Publish(ctx context.Context, msgs []Message) error {
+	version := []byte(strconv.Itoa(SchemaVersion))
+	records := make([]kafka.Message, len(msgs))
+	for i, m := range msgs {
+		records[i] = kafka.Message{
+			Topic: s.prefix + "." + m.Type,
+			Key:   []byte(m.Key),
+			Value: m.Body,
+			Headers: []kafka.Header{
+				{Key: HeaderEventType, Value: []byte(m.Type)},
+				{Key: HeaderSchemaVersion, Value: version},
+			},
+		}
+	}
+
+	if err := s.writer.WriteMessages(ctx, records...); err != nil {
+		return fmt.Errorf("failed to produce to Kafka: %w", err)
+	}
+	return nil
+}
+
+// Close flushes and closes the producer.
+func (s *kafkaSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,103 @@
+package eventbus
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSConfig holds the settings of a NATS JetStream sink.
+type NATSConfig struct {
+	// URLs are the NATS servers to connect to.
+	URLs []string
+	// Username and Password authenticate the connection, if set.
+	Username string
+	Password string
+	// TLS requires a TLS connection.
+	TLS bool
+	// Prefix is the first token of every subject.
+	Prefix string
+	// Stream is the JetStream stream the subjects are stored in. It is
+	// created for <prefix>.> if it does not exist.
+	Stream string
+}
+
+// natsSink publishes messages to NATS JetStream.
+type natsSink struct {
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	prefix string
+}
+
+// NewNATSSink connects to NATS and makes sure the stream exists. An
+// existing stream is left as it is, so it must capture <prefix>.>.
+func NewNATSSink(ctx context.Context, cfg NATSConfig) (Sink, error) {
+	opts := []nats.Option{
+		nats.Name("conductor-control-plane"),
+		nats.MaxReconnects(-1),
+	}
+	if cfg.Username != "" {
+		opts = append(opts, nats.UserInfo(cfg.Username, cfg.Password))
+	}
+	if cfg.TLS {
+		opts = append(opts, nats.Secure(&tls.Config{MinVersion: tls.VersionTLS12}))
+	}
+
+	conn, err := nats.Connect(strings.Join(cfg.URLs, ","), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	if _, err := js.Stream(ctx, cfg.Stream); errors.Is(err, jetstream.ErrStreamNotFound) {
+		_, err = js.CreateStream(ctx, jetstream.StreamConfig{
+			Name:     cfg.Stream,
+			Subjects: []string{cfg.Prefix + ".>"},
+		})
+		if errors.Is(err, jetstream.ErrStreamNameAlreadyInUse) {
+			err = nil
+		}
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to create JetStream stream %s: %w", cfg.Stream, err)
+		}
+	} else if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to look up JetStream stream %s: %w", cfg.Stream, err)
+	}
+
+	return &natsSink{conn: conn, js: js, prefix: cfg.Prefix}, nil
+}
+
+// Publish publishes each message to <prefix>.<type> and waits for the
+// stream to store it. Messages carry their event ID as the JetStream message
+// ID, so the stream drops duplicates sent again within its duplicate window.
+func (s *natsSink) Publish(ctx context.Context, msgs []Message) error {
+	for _, m := range msgs {
+		msg := nats.NewMsg(s.prefix + "." + m.Type)
+		msg.Data = m.Body
+		msg.Header.Set(HeaderEventType, m.Type)
+		msg.Header.Set(HeaderSchemaVersion, strconv.Itoa(SchemaVersion))
+
+		if _, err := s.js.PublishMsg(ctx, msg, jetstream.WithMsgID(m.ID)); err != nil {
+			return fmt.Errorf("failed to publish to %s: %w", msg.Subject, err)
+		}
+	}
+	return nil
+}
+
+// Close drains and closes the connection.
+func (s *natsSink) Close() error {
+	return s.conn.Drain()
+}
@@ -0,0 +1,365 @@
+// Package eventhook delivers run lifecycle events to external systems over
+// signed HTTP webhooks.
+//
+// Events are recorded by the database whenever a run is created, starts or
+// finishes. The Dispatcher fans each event out to the endpoints subscribed to
+// its type as deliveries, and attempts deliveries until the endpoint accepts
+// them or they run out of attempts. Because deliveries are stored, they
+// survive restarts and are shared between control plane replicas.
+package eventhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+const (
+	// batchSize is the maximum number of events fanned out, or deliveries
+	// attempted, per query.
+	batchSize = 100
+	// maxRetryBackoff caps the wait between attempts of a delivery.
+	maxRetryBackoff = time.Hour
+	// pruneInterval is how often events older than the retention are
+	// deleted.
+	pruneInterval = time.Hour
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Conductor-Event"
+	HeaderDelivery  = "X-Conductor-Delivery"
+	HeaderTimestamp = "X-Conductor-Timestamp"
+	HeaderSignature = "X-Conductor-Signature-256"
+)
+
+// Endpoint is an HTTP endpoint that receives run events.
+type Endpoint struct {
+	// Name identifies the endpoint in deliveries and logs.
+	Name string
+	// URL receives events as JSON POST requests.
+	URL string
+	// Secret signs requests with HMAC-SHA256.
+	Secret string
+	// Events are the event types sent to the endpoint; empty sends all.
+	Events []database.RunEventType
+}
+
+// subscribed reports whether events of the type are sent to the endpoint.
+func (e Endpoint) subscribed(eventType database.RunEventType) bool {
+	return len(e.Events) == 0 || slices.Contains(e.Events, eventType)
+}
+
+// Config holds the endpoints and delivery settings of a Dispatcher.
+type Config struct {
+	Endpoints []Endpoint
+	// Interval is how often new events and due retries are delivered.
+	Interval time.Duration
+	// Timeout bounds each delivery attempt.
+	Timeout time.Duration
+	// MaxAttempts is how many times a delivery is attempted before it fails.
+	MaxAttempts int
+	// RetryBackoff is the wait before the first retry. It doubles with every
+	// retry, up to an hour.
+	RetryBackoff time.Duration
+	// Retention is how long events are kept once their deliveries are
+	// done; 0 keeps them.
+	Retention time.Duration
+}
+
+// Dispatcher delivers run events to the configured endpoints.
+type Dispatcher struct {
+	repo      database.EventWebhookRepository
+	config    Config
+	endpoints map[string]Endpoint
+	client    *http.Client
+	logger    *slog.Logger
+	now       func() time.Time
+	lastPrune time.Time
+}
+
+// NewDispatcher creates a new Dispatcher.
+func NewDispatcher(repo database.EventWebhookRepository, config Config, logger *slog.Logger) *Dispatcher {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+
+	endpoints := make(map[string]Endpoint, len(config.Endpoints))
+	for _, endpoint := range config.Endpoints {
+		endpoints[endpoint.Name] = endpoint
+	}
+
+	return &Dispatcher{
+		repo:      repo,
+		config:    config,
+		endpoints: endpoints,
+		client:    &http.Client{Timeout: config.Timeout},
+		logger:    logger.With("component", "event_webhooks"),
+		now:       time.Now,
+	}
+}
+
+// Start delivers events every interval until the context is canceled,
+// starting right away.
+func (d *Dispatcher) Start(ctx context.Context) {
+	d.logger.Info("starting event webhook dispatcher",
+		"endpoints", len(d.config.Endpoints),
+		"interval", d.config.Interval,
+	)
+
+	go func() {
+		ticker := time.NewTicker(d.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			d.run(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// run fans out new events, attempts the deliveries that are due and prunes
+// old events.
+func (d *Dispatcher) run(ctx context.Context) {
+	d.dispatch(ctx)
+	d.deliver(ctx)
+	d.prune(ctx)
+}
+
+// dispatch creates deliveries of the new events to the endpoints subscribed
+// to them. Events no endpoint subscribes to are only marked dispatched.
+func (d *Dispatcher) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		events, err := d.repo.ListUndispatchedEvents(ctx, batchSize)
+		if err != nil {
+			d.logger.Error("failed to list run events", "error", err)
+			return
+		}
+
+		for _, event := range events {
+			var names []string
+			for _, endpoint := range d.config.Endpoints {
+				if endpoint.subscribed(event.Type) {
+					names = append(names, endpoint.Name)
+				}
+			}
+			if err := d.repo.DispatchEvent(ctx, event.ID, names); err != nil {
+				d.logger.Error("failed to dispatch run event",
+					"event_id", event.EventID,
+					"error", err,
+				)
+				return
+			}
+		}
+
+		if len(events) < batchSize {
+			return
+		}
+	}
+}
+
+// deliver attempts the deliveries that are due, concurrently so that a slow
+// endpoint does not hold up the others.
+func (d *Dispatcher) deliver(ctx context.Context) {
+	for ctx.Err() == nil {
+		deliveries, err := d.repo.ClaimDueDeliveries(ctx, d.now(), 2*d.config.Timeout, batchSize)
+		if err != nil {
+			d.logger.Error("failed to claim event webhook deliveries", "error", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		for i := range deliveries {
+			wg.Add(1)
+			go func(delivery *database.EventWebhookDelivery) {
+				defer wg.Done()
+				d.attempt(ctx, delivery)
+			}(&deliveries[i])
+		}
+		wg.Wait()
+
+		if len(deliveries) < batchSize {
+			return
+		}
+	}
+}
+
+// attempt sends a delivery and records the outcome. Failed deliveries are
+// retried with backoff unless the endpoint rejected the event or the
+// delivery ran out of attempts.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *database.EventWebhookDelivery) {
+	delivery.Attempts++
+	delivery.ResponseStatus = nil
+
+	var statusCode int
+	var err error
+	endpoint, ok := d.endpoints[delivery.Endpoint]
+	if ok {
+		statusCode, err = d.send(ctx, endpoint, delivery)
+	} else {
+		err = permanent(errors.New("endpoint is no longer configured"))
+	}
+	if statusCode != 0 {
+		delivery.ResponseStatus = &statusCode
+	}
+
+	now := d.now()
+	switch {
+	case err == nil:
+		delivery.Status = database.EventWebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.Error = ""
+	case isPermanent(err) || delivery.Attempts >= d.config.MaxAttempts:
+		delivery.Status = database.EventWebhookDeliveryFailed
+		delivery.Error = err.Error()
+		d.logger.Warn("event webhook delivery failed",
+			"endpoint", delivery.Endpoint,
+			"delivery_id", delivery.ID,
+			"attempts", delivery.Attempts,
+			"error", err,
+		)
+	default:
+		delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+		delivery.Error = err.Error()
+		d.logger.Debug("event webhook delivery failed, retrying",
+			"endpoint", delivery.Endpoint,
+			"delivery_id", delivery.ID,
+			"attempts", delivery.Attempts,
+			"error", err,
+		)
+	}
+
+	if err := d.repo.UpdateDelivery(ctx, delivery); err != nil {
+		d.logger.Error("failed to update event webhook delivery",
+			"delivery_id", delivery.ID,
+			"error", err,
+		)
+	}
+}
+
+// backoff returns the wait after attempt n (starting at 1) of a delivery.
+func (d *Dispatcher) backoff(n int) time.Duration {
+	wait := d.config.RetryBackoff
+	for i := 1; i < n && wait < maxRetryBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxRetryBackoff)
+}
+
+// send posts a delivery's event to an endpoint and returns the response
+// status, or 0 when no response was received.
+func (d *Dispatcher) send(ctx context.Context, endpoint Endpoint, delivery *database.EventWebhookDelivery) (int, error) {
+	body, err := marshalPayload(delivery.Event)
+	if err != nil {
+		return 0, permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, permanent(fmt.Errorf("failed to create request: %w", err))
+	}
+
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Conductor/1.0")
+	req.Header.Set(HeaderEvent, string(delivery.Event.Type))
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, "sha256="+sign(endpoint.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		return resp.StatusCode, nil
+	}
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("endpoint returned status %d: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+
+	// Client errors other than timeouts and rate limits will not go away
+	// when retried
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return resp.StatusCode, permanent(err)
+	}
+	return resp.StatusCode, err
+}
+
+// prune deletes events older than the retention whose deliveries are done,
+// at most once per pruneInterval.
+func (d *Dispatcher) prune(ctx context.Context) {
+	now := d.now()
+	if d.config.Retention <= 0 || now.Sub(d.lastPrune) < pruneInterval {
+		return
+	}
+	d.lastPrune = now
+
+	deleted, err := d.repo.DeleteEventsBefore(ctx, now.Add(-d.config.Retention))
+	if err != nil {
+		d.logger.Error("failed to delete old run events", "error", err)
+		return
+	}
+	if deleted > 0 {
+		d.logger.Debug("deleted old run events", "count", deleted)
+	}
+}
+
+// sign returns the hex-encoded HMAC-SHA256 of the timestamp and body, joined
+// by a dot. Signing the timestamp lets receivers reject replayed requests.
+func sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// permanentError marks a delivery failure that retrying will not fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// permanent marks err as not worth retrying.
+func permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// isPermanent reports whether err was marked as not worth retrying.
+func isPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,260 @@
+package eventhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// fakeRepo keeps events and deliveries in memory.
+type fakeRepo struct {
+	events     []database.RunEvent
+	dispatched map[int64][]string
+	deliveries []*database.EventWebhookDelivery
+	updates    []database.EventWebhookDelivery
+	pruned     []time.Time
+}
+
+func (r *fakeRepo) ListUndispatchedEvents(ctx context.Context, limit int) ([]database.RunEvent, error) {
+	var events []database.RunEvent
+	for _, e := range r.events {
+		if _, ok := r.dispatched[e.ID]; !ok && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (r *fakeRepo) DispatchEvent(ctx context.Context, eventID int64, endpoints []string) error {
+	if r.dispatched == nil {
+		r.dispatched = make(map[int64][]string)
+	}
+	r.dispatched[eventID] = endpoints
+	return nil
+}
+
+func (r *fakeRepo) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]database.EventWebhookDelivery, error) {
+	var due []database.EventWebhookDelivery
+	for _, d := range r.deliveries {
+		if d.Status == database.EventWebhookDeliveryPending && !d.NextAttemptAt.After(now) && len(due) < limit {
+			d.NextAttemptAt = now.Add(lease)
+			due = append(due, *d)
+		}
+	}
+	return due, nil
+}
+
+func (r *fakeRepo) UpdateDelivery(ctx context.Context, delivery *database.EventWebhookDelivery) error {
+	for _, d := range r.deliveries {
+		if d.ID == delivery.ID {
+			*d = *delivery
+		}
+	}
+	r.updates = append(r.updates, *delivery)
+	return nil
+}
+
+func (r *fakeRepo) DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.pruned = append(r.pruned, before)
+	return 0, nil
+}
+
+func testEvent(t *testing.T, eventType database.RunEventType) database.RunEvent {
+	t.Helper()
+	run, err := json.Marshal(map[string]any{
+		"id":            "0b7f5f0e-3f8e-4a53-9d5c-5f0c2a7c1e01",
+		"service_id":    "6a1d3c2b-8f4e-4b7a-a1c2-9e8d7f6a5b4c",
+		"status":        "failed",
+		"git_ref":       "main",
+		"git_sha":       "abc123",
+		"trigger_type":  "webhook",
+		"triggered_by":  nil,
+		"created_at":    "2026-01-25T12:00:00.123456+00:00",
+		"started_at":    "2026-01-25T12:00:05+00:00",
+		"finished_at":   "2026-01-25T12:01:05+00:00",
+		"total_tests":   10,
+		"passed_tests":  9,
+		"failed_tests":  1,
+		"skipped_tests": 0,
+		"duration_ms":   60000,
+		"error_message": nil,
+		"priority":      0,
+	})
+	require.NoError(t, err)
+	return database.RunEvent{
+		ID:        1,
+		EventID:   uuid.New(),
+		Type:      eventType,
+		RunID:     uuid.MustParse("0b7f5f0e-3f8e-4a53-9d5c-5f0c2a7c1e01"),
+		Run:       run,
+		CreatedAt: time.Date(2026, 1, 25, 12, 1, 5, 0, time.UTC),
+	}
+}
+
+func TestDispatchFiltersEndpoints(t *testing.T) {
+	repo := &fakeRepo{}
+	created := testEvent(t, database.RunEventCreated)
+	finished := testEvent(t, database.RunEventFinished)
+	finished.ID = 2
+	repo.events = []database.RunEvent{created, finished}
+
+	d := NewDispatcher(repo, Config{Endpoints: []Endpoint{
+		{Name: "all", URL: "http://all.example.com"},
+		{Name: "finished", URL: "http://finished.example.com", Events: []database.RunEventType{database.RunEventFinished}},
+	}}, nil)
+	d.dispatch(context.Background())
+
+	assert.Equal(t, map[int64][]string{
+		1: {"all"},
+		2: {"all", "finished"},
+	}, repo.dispatched)
+
+	repo = &fakeRepo{events: []database.RunEvent{created}}
+	NewDispatcher(repo, Config{}, nil).dispatch(context.Background())
+	assert.Contains(t, repo.dispatched, int64(1), "events without subscribers are marked dispatched")
+	assert.Empty(t, repo.dispatched[1])
+}
+
+func TestDeliverSignsEvents(t *testing.T) {
+	now := time.Date(2026, 1, 25, 12, 2, 0, 0, time.UTC)
+	var req *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	event := testEvent(t, database.RunEventFinished)
+	delivery := &database.EventWebhookDelivery{
+		ID:       uuid.New(),
+		EventID:  event.ID,
+		Endpoint: "ci",
+		Status:   database.EventWebhookDeliveryPending,
+		Event:    event,
+	}
+	repo := &fakeRepo{deliveries: []*database.EventWebhookDelivery{delivery}}
+
+	d := NewDispatcher(repo, Config{Endpoints: []Endpoint{{Name: "ci", URL: server.URL, Secret: "s3cret"}}}, nil)
+	d.now = func() time.Time { return now }
+	d.deliver(context.Background())
+
+	require.NotNil(t, req)
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "run.finished", req.Header.Get(HeaderEvent))
+	assert.Equal(t, delivery.ID.String(), req.Header.Get(HeaderDelivery))
+	assert.Equal(t, strconv.FormatInt(now.Unix(), 10), req.Header.Get(HeaderTimestamp))
+	assert.Equal(t, "sha256="+sign("s3cret", now.Unix(), body), req.Header.Get(HeaderSignature))
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(body, &got))
+	assert.Equal(t, event.EventID.String(), got["id"])
+	assert.Equal(t, "run.finished", got["type"])
+	run := got["run"].(map[string]any)
+	assert.Equal(t, "failed", run["status"])
+	assert.Equal(t, "main", run["branch"])
+	assert.Equal(t, "abc123", run["commitSha"])
+	assert.Equal(t, "2026-01-25T12:00:00.123456Z", run["createdAt"])
+	assert.Equal(t, float64(60000), run["durationMs"])
+	assert.NotContains(t, run, "triggeredBy")
+	assert.Equal(t, map[string]any{"total": 10.0, "passed": 9.0, "failed": 1.0, "skipped": 0.0}, run["summary"])
+
+	assert.Equal(t, database.EventWebhookDeliveryDelivered, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	require.NotNil(t, delivery.ResponseStatus)
+	assert.Equal(t, http.StatusNoContent, *delivery.ResponseStatus)
+	assert.Equal(t, &now, delivery.DeliveredAt)
+}
+
+func TestDeliverRetries(t *testing.T) {
+	now := time.Date(2026, 1, 25, 12, 0, 0, 0, time.UTC)
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("try later"))
+	}))
+	defer server.Close()
+
+	delivery := &database.EventWebhookDelivery{
+		ID:       uuid.New(),
+		Endpoint: "ci",
+		Status:   database.EventWebhookDeliveryPending,
+		Event:    testEvent(t, database.RunEventStarted),
+	}
+	repo := &fakeRepo{deliveries: []*database.EventWebhookDelivery{delivery}}
+
+	d := NewDispatcher(repo, Config{
+		Endpoints:    []Endpoint{{Name: "ci", URL: server.URL, Secret: "s3cret"}},
+		MaxAttempts:  3,
+		RetryBackoff: time.Minute,
+	}, nil)
+	d.now = func() time.Time { return now }
+
+	d.deliver(context.Background())
+	assert.Equal(t, database.EventWebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, now.Add(time.Minute), delivery.NextAttemptAt)
+	assert.Contains(t, delivery.Error, "endpoint returned status 503: try later")
+
+	d.deliver(context.Background())
+	assert.Len(t, repo.updates, 1, "deliveries are not attempted before they are due")
+
+	now = now.Add(time.Minute)
+	d.deliver(context.Background())
+	assert.Equal(t, now.Add(2*time.Minute), delivery.NextAttemptAt)
+
+	now = now.Add(2 * time.Minute)
+	d.deliver(context.Background())
+	assert.Equal(t, database.EventWebhookDeliveryFailed, delivery.Status, "gives up after MaxAttempts")
+	assert.Equal(t, 3, delivery.Attempts)
+
+	delivery.Status = database.EventWebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	status = http.StatusGone
+	d.deliver(context.Background())
+	assert.Equal(t, database.EventWebhookDeliveryFailed, delivery.Status, "client errors are not retried")
+	assert.Equal(t, 1, delivery.Attempts)
+
+	delivery.Status = database.EventWebhookDeliveryPending
+	delivery.NextAttemptAt = now
+	delivery.Endpoint = "removed"
+	d.deliver(context.Background())
+	assert.Equal(t, database.EventWebhookDeliveryFailed, delivery.Status)
+	assert.Equal(t, "endpoint is no longer configured", delivery.Error)
+	assert.Nil(t, delivery.ResponseStatus)
+}
+
+func TestBackoff(t *testing.T) {
+	d := NewDispatcher(&fakeRepo{}, Config{RetryBackoff: 30 * time.Second}, nil)
+
+	assert.Equal(t, 30*time.Second, d.backoff(1))
+	assert.Equal(t, 2*time.Minute, d.backoff(3))
+	assert.Equal(t, time.Hour, d.backoff(10))
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Date(2026, 1, 25, 12, 0, 0, 0, time.UTC)
+	repo := &fakeRepo{}
+	d := NewDispatcher(repo, Config{Retention: 24 * time.Hour}, nil)
+	d.now = func() time.Time { return now }
+
+	d.prune(context.Background())
+	d.prune(context.Background())
+	assert.Equal(t, []time.Time{now.Add(-24 * time.Hour)}, repo.pruned, "prunes at most once per interval")
+
+	repo = &fakeRepo{}
+	NewDispatcher(repo, Config{}, nil).prune(context.Background())
+	assert.Empty(t, repo.pruned, "a retention of 0 keeps events")
+}
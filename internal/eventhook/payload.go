@@ -0,0 +1,107 @@
+package eventhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// payload is the JSON body of a delivery.
+type payload struct {
+	ID        uuid.UUID             `json:"id"`
+	Type      database.RunEventType `json:"type"`
+	CreatedAt time.Time             `json:"createdAt"`
+	Run       runPayload            `json:"run"`
+}
+
+// runPayload is the state of the run at the time of the event.
+type runPayload struct {
+	ID           uuid.UUID  `json:"id"`
+	ServiceID    uuid.UUID  `json:"serviceId"`
+	Status       string     `json:"status"`
+	Branch       string     `json:"branch,omitempty"`
+	CommitSHA    string     `json:"commitSha,omitempty"`
+	Trigger      string     `json:"trigger,omitempty"`
+	TriggeredBy  string     `json:"triggeredBy,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	StartedAt    *time.Time `json:"startedAt,omitempty"`
+	FinishedAt   *time.Time `json:"finishedAt,omitempty"`
+	DurationMs   *int64     `json:"durationMs,omitempty"`
+	ErrorMessage string     `json:"errorMessage,omitempty"`
+	Summary      summary    `json:"summary"`
+}
+
+// summary counts the results of the run so far.
+type summary struct {
+	Total   int `json:"total"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+}
+
+// runSnapshot decodes the test_runs row recorded with an event.
+type runSnapshot struct {
+	ID           uuid.UUID  `json:"id"`
+	ServiceID    uuid.UUID  `json:"service_id"`
+	Status       string     `json:"status"`
+	GitRef       string     `json:"git_ref"`
+	GitSHA       string     `json:"git_sha"`
+	TriggerType  string     `json:"trigger_type"`
+	TriggeredBy  string     `json:"triggered_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	StartedAt    *time.Time `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	TotalTests   int        `json:"total_tests"`
+	PassedTests  int        `json:"passed_tests"`
+	FailedTests  int        `json:"failed_tests"`
+	SkippedTests int        `json:"skipped_tests"`
+	DurationMs   *int64     `json:"duration_ms"`
+	ErrorMessage string     `json:"error_message"`
+}
+
+// marshalPayload returns the body delivered for an event.
+func marshalPayload(event database.RunEvent) ([]byte, error) {
+	var run runSnapshot
+	if err := json.Unmarshal(event.Run, &run); err != nil {
+		return nil, fmt.Errorf("failed to decode run of event %s: %w", event.EventID, err)
+	}
+
+	return json.Marshal(payload{
+		ID:        event.EventID,
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt.UTC(),
+		Run: runPayload{
+			ID:           run.ID,
+			ServiceID:    run.ServiceID,
+			Status:       run.Status,
+			Branch:       run.GitRef,
+			CommitSHA:    run.GitSHA,
+			Trigger:      run.TriggerType,
+			TriggeredBy:  run.TriggeredBy,
+			CreatedAt:    run.CreatedAt.UTC(),
+			StartedAt:    utc(run.StartedAt),
+			FinishedAt:   utc(run.FinishedAt),
+			DurationMs:   run.DurationMs,
+			ErrorMessage: run.ErrorMessage,
+			Summary: summary{
+				Total:   run.TotalTests,
+				Passed:  run.PassedTests,
+				Failed:  run.FailedTests,
+				Skipped: run.SkippedTests,
+			},
+		},
+	})
+}
+
+// utc converts an optional time to UTC.
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
@@ -0,0 +1,283 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+const (
+	// maxCheckRunAnnotations caps the annotations attached to one check run.
+	maxCheckRunAnnotations = 250
+	// maxCheckRunText is GitHub's limit for check run summary and text fields.
+	maxCheckRunText = 65535
+	// maxAnnotationMessage keeps annotation messages readable in the diff view.
+	maxAnnotationMessage = 4000
+)
+
+// Stack frame patterns, tried in order against each line of a failure.
+var (
+	// Python: File "tests/test_auth.py", line 12, in test_login
+	pythonFramePattern = regexp.MustCompile(`File "([^"]+)", line (\d+)`)
+	// Java/Kotlin: at com.example.AuthTest.testLogin(AuthTest.java:42)
+	javaFramePattern = regexp.MustCompile(`at ([\w$.]+)\.[\w$<>]+\(([\w$]+\.(?:java|kt|scala|groovy)):(\d+)\)`)
+	// JavaScript/TypeScript: at Object.<anonymous> (src/auth.test.ts:12:5)
+	jsFramePattern = regexp.MustCompile(`at (?:.*?\()?((?:[A-Za-z]:)?[^\s():]+\.[cm]?[jt]sx?):(\d+):\d+\)?`)
+	// Go test output: "    auth_test.go:42: expected 200"
+	goFramePattern = regexp.MustCompile(`^\s*([\w.-]+_test\.go):(\d+):`)
+	// Generic path:line, e.g. pytest "tests/test_auth.py:12: AssertionError"
+	genericFramePattern = regexp.MustCompile(`((?:[\w.-]+/)*[\w.-]+\.\w+):(\d+)`)
+)
+
+// failureLocator resolves test failures to repository file locations.
+type failureLocator struct {
+	// runID identifies the agent workspace directory in absolute paths.
+	runID string
+	// repoPath is the "owner/repo" path used to map Go package suites to directories.
+	repoPath string
+}
+
+// locate returns the repository-relative file and line a failed test points
+// to, preferring frames in test files over library frames.
+func (l failureLocator) locate(result database.TestResult) (string, int, bool) {
+	var fallbackPath string
+	var fallbackLine int
+
+	for _, line := range failureLines(result) {
+		file, lineNo, ok := l.parseFrame(line, result)
+		if !ok {
+			continue
+		}
+		if isTestFile(file) {
+			return file, lineNo, true
+		}
+		if fallbackPath == "" {
+			fallbackPath, fallbackLine = file, lineNo
+		}
+	}
+
+	if fallbackPath == "" {
+		return "", 0, false
+	}
+	return fallbackPath, fallbackLine, true
+}
+
+// parseFrame extracts a repository-relative location from one output line.
+func (l failureLocator) parseFrame(line string, result database.TestResult) (string, int, bool) {
+	if m := goFramePattern.FindStringSubmatch(line); m != nil {
+		dir := l.goPackageDir(result)
+		if dir == "" && result.SuiteName != nil {
+			return "", 0, false
+		}
+		return l.finish(path.Join(dir, m[1]), m[2])
+	}
+
+	if m := pythonFramePattern.FindStringSubmatch(line); m != nil {
+		return l.finish(m[1], m[2])
+	}
+
+	if m := javaFramePattern.FindStringSubmatch(line); m != nil {
+		// Only frames of the failing class are mapped, using the Maven and
+		// Gradle source layout since JVM traces carry no directory.
+		class := m[1]
+		if !strings.HasPrefix(result.TestName, class+".") {
+			return "", 0, false
+		}
+		dir := strings.ReplaceAll(class[:max(strings.LastIndex(class, "."), 0)], ".", "/")
+		return l.finish(path.Join("src/test/java", dir, m[2]), m[3])
+	}
+
+	if m := jsFramePattern.FindStringSubmatch(line); m != nil {
+		return l.finish(m[1], m[2])
+	}
+
+	if m := genericFramePattern.FindStringSubmatch(line); m != nil {
+		return l.finish(m[1], m[2])
+	}
+
+	return "", 0, false
+}
+
+// finish normalizes a frame path and rejects locations outside the repository.
+func (l failureLocator) finish(file, line string) (string, int, bool) {
+	lineNo, err := strconv.Atoi(line)
+	if err != nil || lineNo <= 0 {
+		return "", 0, false
+	}
+
+	file = strings.ReplaceAll(file, "\\", "/")
+	if path.IsAbs(file) || (len(file) > 1 && file[1] == ':') {
+		// Agents clone into a workspace named after the run ID; anything
+		// outside it (toolchains, system libraries) cannot be annotated.
+		rel, ok := l.stripWorkspace(file)
+		if !ok {
+			return "", 0, false
+		}
+		file = rel
+	}
+
+	file = strings.TrimPrefix(path.Clean(file), "./")
+	if file == "." || strings.HasPrefix(file, "../") || isDependencyPath(file) {
+		return "", 0, false
+	}
+	return file, lineNo, true
+}
+
+// stripWorkspace removes the agent workspace prefix from an absolute path.
+func (l failureLocator) stripWorkspace(file string) (string, bool) {
+	if l.runID == "" {
+		return "", false
+	}
+	parts := strings.Split(file, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, l.runID) && i+1 < len(parts) {
+			return strings.Join(parts[i+1:], "/"), true
+		}
+	}
+	return "", false
+}
+
+// goPackageDir maps a Go package import path suite to its repository directory.
+func (l failureLocator) goPackageDir(result database.TestResult) string {
+	if result.SuiteName == nil || l.repoPath == "" {
+		return ""
+	}
+	pkg := *result.SuiteName
+	idx := strings.Index(pkg+"/", l.repoPath+"/")
+	if idx < 0 {
+		return ""
+	}
+	dir := strings.TrimPrefix(pkg[idx+len(l.repoPath):], "/")
+	if dir == "" {
+		return "."
+	}
+	return dir
+}
+
+// failureLines returns the lines of a failure to search for stack frames.
+func failureLines(result database.TestResult) []string {
+	var text strings.Builder
+	for _, s := range []*string{result.StackTrace, result.ErrorMessage, result.Stdout, result.Stderr} {
+		if s != nil {
+			text.WriteString(*s)
+			text.WriteString("\n")
+		}
+	}
+	return strings.Split(text.String(), "\n")
+}
+
+func isTestFile(file string) bool {
+	base := strings.ToLower(path.Base(file))
+	dir := "/" + strings.ToLower(path.Dir(file)) + "/"
+	return strings.Contains(base, "test") || strings.Contains(base, "spec") ||
+		strings.Contains(dir, "/test/") || strings.Contains(dir, "/tests/") ||
+		strings.Contains(dir, "/__tests__/") || strings.Contains(dir, "/e2e/")
+}
+
+func isDependencyPath(file string) bool {
+	for _, dir := range []string{"node_modules/", "vendor/", "site-packages/", ".venv/"} {
+		if strings.HasPrefix(file, dir) || strings.Contains(file, "/"+dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildCheckRunOutput renders a finished run and its failed tests as check
+// run output: a markdown summary with failure details and one annotation
+// per failure whose location could be found. repoPath is the repository's
+// "owner/repo" path.
+func BuildCheckRunOutput(run *database.TestRun, failures []database.TestResult, repoPath string) *CheckRunOutput {
+	locator := failureLocator{runID: run.ID.String(), repoPath: repoPath}
+	output := &CheckRunOutput{
+		Title: RunStatusDescription(run),
+	}
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "| Total | Passed | Failed | Skipped |\n|---|---|---|---|\n| %d | %d | %d | %d |\n",
+		run.TotalTests, run.PassedTests, run.FailedTests, run.SkippedTests)
+	if run.ErrorMessage != nil && *run.ErrorMessage != "" {
+		fmt.Fprintf(&summary, "\n**Error:** %s\n", *run.ErrorMessage)
+	}
+
+	var text strings.Builder
+	if len(failures) > 0 {
+		summary.WriteString("\n### Failed tests\n\n")
+	}
+	for _, result := range failures {
+		name := testDisplayName(result)
+		message := firstLine(result.ErrorMessage)
+
+		file, line, located := locator.locate(result)
+		if located {
+			fmt.Fprintf(&summary, "- `%s` (`%s:%d`)", name, file, line)
+		} else {
+			fmt.Fprintf(&summary, "- `%s`", name)
+		}
+		if message != "" {
+			fmt.Fprintf(&summary, ": %s", message)
+		}
+		summary.WriteString("\n")
+
+		if result.StackTrace != nil && *result.StackTrace != "" {
+			fmt.Fprintf(&text, "#### %s\n\n```\n%s\n```\n\n", name, strings.TrimSpace(*result.StackTrace))
+		}
+
+		if located && len(output.Annotations) < maxCheckRunAnnotations {
+			output.Annotations = append(output.Annotations, CheckRunAnnotation{
+				Path:       file,
+				StartLine:  line,
+				EndLine:    line,
+				Level:      AnnotationLevelFailure,
+				Title:      name,
+				Message:    truncateText(coalesceMessage(result), maxAnnotationMessage),
+				RawDetails: truncateText(stringValue(result.StackTrace), maxAnnotationMessage),
+			})
+		}
+	}
+
+	output.Summary = truncateText(summary.String(), maxCheckRunText)
+	output.Text = truncateText(text.String(), maxCheckRunText)
+	return output
+}
+
+func testDisplayName(result database.TestResult) string {
+	if result.SuiteName != nil && *result.SuiteName != "" && !strings.HasPrefix(result.TestName, *result.SuiteName) {
+		return *result.SuiteName + " / " + result.TestName
+	}
+	return result.TestName
+}
+
+func coalesceMessage(result database.TestResult) string {
+	if result.ErrorMessage != nil && *result.ErrorMessage != "" {
+		return *result.ErrorMessage
+	}
+	return "Test failed"
+}
+
+func firstLine(s *string) string {
+	if s == nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(*s), "\n")
+	return truncateText(line, 200)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// truncateText shortens text to at most limit bytes.
+func truncateText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return text[:limit-3] + "..."
+}
@@ -0,0 +1,214 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestFailureLocator(t *testing.T) {
+	runID := uuid.MustParse("6f1c2a9e-3d4b-4c5d-8e7f-0a1b2c3d4e5f")
+	locator := failureLocator{runID: runID.String(), repoPath: "acme/shop"}
+
+	tests := []struct {
+		name     string
+		result   database.TestResult
+		wantPath string
+		wantLine int
+		wantOK   bool
+	}{
+		{
+			name: "go test output mapped through package suite",
+			result: database.TestResult{
+				TestName:  "TestCheckout",
+				SuiteName: strPtr("github.com/acme/shop/internal/cart"),
+				Stdout:    strPtr("=== RUN   TestCheckout\n    checkout_test.go:42: expected 200, got 500\n--- FAIL: TestCheckout"),
+			},
+			wantPath: "internal/cart/checkout_test.go",
+			wantLine: 42,
+			wantOK:   true,
+		},
+		{
+			name: "go package outside the repository",
+			result: database.TestResult{
+				TestName:  "TestCheckout",
+				SuiteName: strPtr("github.com/other/lib"),
+				Stdout:    strPtr("    checkout_test.go:42: boom"),
+			},
+		},
+		{
+			name: "python traceback prefers the test frame",
+			result: database.TestResult{
+				TestName:   "test_login",
+				SuiteName:  strPtr("tests.test_auth"),
+				StackTrace: strPtr("Traceback (most recent call last):\n  File \"app/auth.py\", line 80, in login\n  File \"tests/test_auth.py\", line 12, in test_login\nAssertionError"),
+			},
+			wantPath: "tests/test_auth.py",
+			wantLine: 12,
+			wantOK:   true,
+		},
+		{
+			name: "pytest short traceback",
+			result: database.TestResult{
+				TestName:     "test_login",
+				ErrorMessage: strPtr("tests/test_auth.py:12: AssertionError"),
+			},
+			wantPath: "tests/test_auth.py",
+			wantLine: 12,
+			wantOK:   true,
+		},
+		{
+			name: "jest frame with absolute workspace path",
+			result: database.TestResult{
+				TestName:     "renders cart",
+				ErrorMessage: strPtr("expect(received).toBe(expected)\n    at Object.<anonymous> (/tmp/conductor/" + runID.String() + "-a1b2c3d4/src/cart.test.ts:17:21)\n    at node_modules/jest-circus/build/run.js:10:3"),
+			},
+			wantPath: "src/cart.test.ts",
+			wantLine: 17,
+			wantOK:   true,
+		},
+		{
+			name: "java frame of the failing class",
+			result: database.TestResult{
+				TestName:   "com.acme.shop.CartTest.testTotal",
+				StackTrace: strPtr("org.opentest4j.AssertionFailedError: expected 3\n\tat org.junit.Assert.fail(Assert.java:89)\n\tat com.acme.shop.CartTest.testTotal(CartTest.java:31)"),
+			},
+			wantPath: "src/test/java/com/acme/shop/CartTest.java",
+			wantLine: 31,
+			wantOK:   true,
+		},
+		{
+			name: "absolute path outside the workspace",
+			result: database.TestResult{
+				TestName:   "test_thing",
+				StackTrace: strPtr("  File \"/usr/lib/python3.12/unittest/case.py\", line 58, in testPartExecutor"),
+			},
+		},
+		{
+			name:   "no location",
+			result: database.TestResult{TestName: "test_thing", ErrorMessage: strPtr("connection refused")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, line, ok := locator.locate(tt.result)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantPath, path)
+			assert.Equal(t, tt.wantLine, line)
+		})
+	}
+}
+
+func TestBuildCheckRunOutput(t *testing.T) {
+	run := newPullRequestRun(database.RunStatusFailed)
+	run.TotalTests = 46
+	failures := []database.TestResult{
+		{
+			TestName:     "test_login",
+			SuiteName:    strPtr("tests.test_auth"),
+			ErrorMessage: strPtr("AssertionError: 401 != 200\nmore detail"),
+			StackTrace:   strPtr("  File \"tests/test_auth.py\", line 12, in test_login"),
+		},
+		{
+			TestName:     "test_flaky_network",
+			ErrorMessage: strPtr("connection refused"),
+		},
+	}
+
+	output := BuildCheckRunOutput(run, failures, "acme/shop")
+
+	assert.Equal(t, "42 passed, 1 failed, 3 skipped in 2m5s", output.Title)
+	assert.Contains(t, output.Summary, "| 46 | 42 | 1 | 3 |")
+	assert.Contains(t, output.Summary, "- `tests.test_auth / test_login` (`tests/test_auth.py:12`): AssertionError: 401 != 200\n")
+	assert.Contains(t, output.Summary, "- `test_flaky_network`: connection refused\n")
+	assert.Contains(t, output.Text, "#### tests.test_auth / test_login")
+
+	require.Len(t, output.Annotations, 1)
+	assert.Equal(t, CheckRunAnnotation{
+		Path:       "tests/test_auth.py",
+		StartLine:  12,
+		EndLine:    12,
+		Level:      AnnotationLevelFailure,
+		Title:      "tests.test_auth / test_login",
+		Message:    "AssertionError: 401 != 200\nmore detail",
+		RawDetails: "  File \"tests/test_auth.py\", line 12, in test_login",
+	}, output.Annotations[0])
+}
+
+func TestBuildCheckRunOutput_Limits(t *testing.T) {
+	run := newPullRequestRun(database.RunStatusFailed)
+	trace := "  File \"tests/test_big.py\", line 3, in test\n" + strings.Repeat("x", 1000)
+
+	failures := make([]database.TestResult, 400)
+	for i := range failures {
+		failures[i] = database.TestResult{TestName: "test_big", StackTrace: &trace}
+	}
+
+	output := BuildCheckRunOutput(run, failures, "acme/shop")
+
+	assert.Len(t, output.Annotations, maxCheckRunAnnotations)
+	assert.LessOrEqual(t, len(output.Text), maxCheckRunText)
+	assert.True(t, strings.HasSuffix(output.Text, "..."))
+}
+
+func TestCreateCompletedCheckRun_BatchesAnnotations(t *testing.T) {
+	var requests []githubCheckRunRequest
+	var updates []githubCheckRunUpdateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/repos/acme/shop/check-runs":
+			var req githubCheckRunRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			requests = append(requests, req)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": 99, "name": req.Name, "status": "completed"})
+		case r.Method == "PATCH" && r.URL.Path == "/repos/acme/shop/check-runs/99":
+			var req githubCheckRunUpdateRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			updates = append(updates, req)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": 99})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewGitHubProvider(Config{Token: "token", BaseURL: server.URL})
+	require.NoError(t, err)
+
+	output := &CheckRunOutput{Title: "1 failed", Summary: "summary"}
+	for i := 0; i < 120; i++ {
+		output.Annotations = append(output.Annotations, CheckRunAnnotation{
+			Path: "tests/test_big.py", StartLine: i + 1, EndLine: i + 1, Level: AnnotationLevelFailure, Message: "failed",
+		})
+	}
+
+	checkRun, err := provider.CreateCompletedCheckRun(context.Background(), "acme", "shop", "abc123", "conductor", "failure", "https://conductor.example.com/runs/1", output)
+	require.NoError(t, err)
+	assert.Equal(t, int64(99), checkRun.ID)
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, "completed", requests[0].Status)
+	assert.Equal(t, "failure", requests[0].Conclusion)
+	assert.Equal(t, "https://conductor.example.com/runs/1", requests[0].DetailsURL)
+	assert.Len(t, requests[0].Output.Annotations, 50)
+
+	require.Len(t, updates, 2)
+	assert.Len(t, updates[0].Output.Annotations, 50)
+	assert.Len(t, updates[1].Output.Annotations, 20)
+	assert.Equal(t, 101, updates[1].Output.Annotations[0].StartLine)
+}
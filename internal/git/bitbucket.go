@@ -269,6 +269,41 @@ func (b *BitbucketProvider) GetPullRequest(ctx context.Context, owner, repo stri
 	return pr, nil
 }
 
+// CompareCommits returns the paths changed on head since its merge base with base.
+func (b *BitbucketProvider) CompareCommits(ctx context.Context, owner, repo, base, head string) ([]string, error) {
+	apiURL := fmt.Sprintf("%s/repositories/%s/%s/diffstat/%s..%s?pagelen=500", b.baseURL, owner, repo, head, base)
+
+	var paths []string
+	for page := 0; apiURL != "" && page < maxComparePages; page++ {
+		var diffstat bitbucketDiffstatResponse
+		if err := b.doRequestWithRetry(ctx, "GET", apiURL, nil, &diffstat); err != nil {
+			return nil, fmt.Errorf("failed to compare commits: %w", err)
+		}
+
+		for _, v := range diffstat.Values {
+			if v.New != nil {
+				paths = append(paths, v.New.Path)
+			}
+			if v.Old != nil && (v.New == nil || v.Old.Path != v.New.Path) {
+				paths = append(paths, v.Old.Path)
+			}
+		}
+		apiURL = diffstat.Next
+	}
+	return paths, nil
+}
+
+// ResolveCommit returns the SHA of the commit a SHA, branch or tag refers to.
+func (b *BitbucketProvider) ResolveCommit(ctx context.Context, owner, repo, ref string) (string, error) {
+	apiURL := fmt.Sprintf("%s/repositories/%s/%s/commit/%s", b.baseURL, owner, repo, url.PathEscape(ref))
+
+	var commit bitbucketCommit
+	if err := b.doRequestWithRetry(ctx, "GET", apiURL, nil, &commit); err != nil {
+		return "", fmt.Errorf("failed to resolve commit: %w", err)
+	}
+	return commit.Hash, nil
+}
+
 // CreateComment posts a comment on a pull request.
 func (b *BitbucketProvider) CreateComment(ctx context.Context, owner, repo string, prNumber int, body string) error {
 	apiURL := fmt.Sprintf("%s/repositories/%s/%s/pullrequests/%d/comments", b.baseURL, owner, repo, prNumber)
@@ -538,6 +573,22 @@ type bitbucketBuildStatus struct {
 	URL         string `json:"url,omitempty"`
 }
 
+type bitbucketDiffstatResponse struct {
+	Values []struct {
+		Old *struct {
+			Path string `json:"path"`
+		} `json:"old"`
+		New *struct {
+			Path string `json:"path"`
+		} `json:"new"`
+	} `json:"values"`
+	Next string `json:"next"`
+}
+
+type bitbucketCommit struct {
+	Hash string `json:"hash"`
+}
+
 type bitbucketPullRequest struct {
 	ID          int       `json:"id"`
 	Title       string    `json:"title"`
@@ -0,0 +1,78 @@
+package git
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/secrets"
+)
+
+// maxComparePages bounds how many pages of changed files are fetched for
+// a single comparison.
+const maxComparePages = 30
+
+// Comparer is implemented by providers that can list the files changed
+// between two commits.
+type Comparer interface {
+	// CompareCommits returns the paths changed on head since its merge base
+	// with base. Base may be a commit SHA or a branch name.
+	CompareCommits(ctx context.Context, owner, repo, base, head string) ([]string, error)
+}
+
+// ChangeResolver lists the files changed by a push or pull request through
+// the git provider API, so webhook events in a monorepo can be mapped to the
+// services whose paths changed.
+type ChangeResolver struct {
+	providers *ProviderRegistry
+
+	credentialRepo   database.GitCredentialRepository
+	credentialCipher *secrets.Cipher
+}
+
+// NewChangeResolver creates a change resolver without providers.
+func NewChangeResolver() *ChangeResolver {
+	return &ChangeResolver{providers: NewProviderRegistry()}
+}
+
+// RegisterProvider registers the shared provider for a provider type.
+func (r *ChangeResolver) RegisterProvider(name string, provider Provider) {
+	r.providers.Register(name, provider)
+}
+
+// SetServiceCredentials enables per-service provider tokens, preferred over
+// the shared provider for services with a stored credential.
+func (r *ChangeResolver) SetServiceCredentials(repo database.GitCredentialRepository, cipher *secrets.Cipher) {
+	r.credentialRepo = repo
+	r.credentialCipher = cipher
+}
+
+// ChangedPaths returns the paths changed between base and head in the
+// service's repository.
+func (r *ChangeResolver) ChangedPaths(ctx context.Context, service *database.Service, base, head string) ([]string, error) {
+	provider, err := serviceProvider(ctx, r.providers, r.credentialRepo, r.credentialCipher, service)
+	if err != nil {
+		return nil, err
+	}
+
+	comparer, ok := provider.(Comparer)
+	if !ok {
+		return nil, fmt.Errorf("provider for %s cannot compare commits", service.GitURL)
+	}
+
+	owner, repo, err := ParseOwnerRepo(service.GitURL)
+	if err != nil {
+		return nil, err
+	}
+	return comparer.CompareCommits(ctx, owner, repo, base, head)
+}
+
+// serviceProvider returns the provider for a service's repository, preferring
+// the service's own credential over the shared provider for its host.
+func serviceProvider(ctx context.Context, providers *ProviderRegistry, repo database.GitCredentialRepository, cipher *secrets.Cipher, service *database.Service) (Provider, error) {
+	provider, err := credentialProvider(ctx, repo, cipher, service)
+	if err != nil || provider != nil {
+		return provider, err
+	}
+	return providers.GetForURL(service.GitURL)
+}
@@ -0,0 +1,129 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+func TestGitHubCompareCommits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/repos/acme/mono/compare/main...abc123", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+
+		var files []map[string]string
+		switch r.URL.Query().Get("page") {
+		case "1":
+			for i := 0; i < 100; i++ {
+				files = append(files, map[string]string{"filename": fmt.Sprintf("services/api/file%d.go", i)})
+			}
+		case "2":
+			files = append(files, map[string]string{"filename": "services/web/new.ts", "previous_filename": "web/old.ts"})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"files": files})
+	}))
+	defer server.Close()
+
+	provider, err := NewGitHubProvider(Config{Token: "token", BaseURL: server.URL})
+	require.NoError(t, err)
+
+	paths, err := provider.CompareCommits(context.Background(), "acme", "mono", "main", "abc123")
+	require.NoError(t, err)
+	require.Len(t, paths, 102)
+	assert.Equal(t, "services/web/new.ts", paths[100])
+	assert.Equal(t, "web/old.ts", paths[101])
+}
+
+func TestGitLabCompareCommits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "main", r.URL.Query().Get("from"))
+		assert.Equal(t, "abc123", r.URL.Query().Get("to"))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"diffs": []map[string]string{
+				{"old_path": "services/api/main.go", "new_path": "services/api/main.go"},
+				{"old_path": "old/name.md", "new_path": "docs/name.md"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	provider, err := NewGitLabProvider(Config{Provider: "gitlab", Token: "token", BaseURL: server.URL})
+	require.NoError(t, err)
+
+	paths, err := provider.CompareCommits(context.Background(), "acme", "mono", "main", "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"services/api/main.go", "docs/name.md", "old/name.md"}, paths)
+}
+
+func TestBitbucketCompareCommits(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "2" {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"values": []map[string]interface{}{
+					{"old": map[string]string{"path": "removed.txt"}, "new": nil},
+				},
+			})
+			return
+		}
+		require.Equal(t, "/repositories/acme/mono/diffstat/abc123..main", r.URL.Path)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"values": []map[string]interface{}{
+				{"old": nil, "new": map[string]string{"path": "services/api/new.go"}},
+			},
+			"next": server.URL + "/repositories/acme/mono/diffstat/abc123..main?page=2",
+		})
+	}))
+	defer server.Close()
+
+	provider, err := NewBitbucketProvider(Config{Provider: "bitbucket", Token: "token", BaseURL: server.URL})
+	require.NoError(t, err)
+
+	paths, err := provider.CompareCommits(context.Background(), "acme", "mono", "main", "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"services/api/new.go", "removed.txt"}, paths)
+}
+
+type fakeComparer struct {
+	Provider
+
+	owner, repo, base, head string
+}
+
+func (p *fakeComparer) CompareCommits(ctx context.Context, owner, repo, base, head string) ([]string, error) {
+	p.owner, p.repo, p.base, p.head = owner, repo, base, head
+	return []string{"services/api/main.go"}, nil
+}
+
+func TestChangeResolver_ChangedPaths(t *testing.T) {
+	service := &database.Service{ID: uuid.New(), GitURL: "https://github.com/acme/mono.git"}
+
+	resolver := NewChangeResolver()
+	_, err := resolver.ChangedPaths(context.Background(), service, "main", "abc123")
+	require.Error(t, err, "no provider registered")
+
+	resolver.RegisterProvider("github", &fakeStatusProvider{})
+	_, err = resolver.ChangedPaths(context.Background(), service, "main", "abc123")
+	require.Error(t, err, "provider cannot compare")
+
+	comparer := &fakeComparer{}
+	resolver.RegisterProvider("github", comparer)
+	paths, err := resolver.ChangedPaths(context.Background(), service, "main", "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"services/api/main.go"}, paths)
+	assert.Equal(t, "acme", comparer.owner)
+	assert.Equal(t, "mono", comparer.repo)
+	assert.Equal(t, "main", comparer.base)
+	assert.Equal(t, "abc123", comparer.head)
+}
@@ -0,0 +1,63 @@
+package git
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/secrets"
+)
+
+// CommitLookup is implemented by providers that can resolve refs to commits.
+type CommitLookup interface {
+	// ResolveCommit returns the SHA of the commit a SHA, branch or tag
+	// refers to.
+	ResolveCommit(ctx context.Context, owner, repo, ref string) (string, error)
+}
+
+// CommitResolver resolves refs in service repositories to commits through
+// the git provider API, so runs of unknown commits can be rejected before
+// they are queued.
+type CommitResolver struct {
+	providers *ProviderRegistry
+
+	credentialRepo   database.GitCredentialRepository
+	credentialCipher *secrets.Cipher
+}
+
+// NewCommitResolver creates a commit resolver without providers.
+func NewCommitResolver() *CommitResolver {
+	return &CommitResolver{providers: NewProviderRegistry()}
+}
+
+// RegisterProvider registers the shared provider for a provider type.
+func (r *CommitResolver) RegisterProvider(name string, provider Provider) {
+	r.providers.Register(name, provider)
+}
+
+// SetServiceCredentials enables per-service provider tokens, preferred over
+// the shared provider for services with a stored credential.
+func (r *CommitResolver) SetServiceCredentials(repo database.GitCredentialRepository, cipher *secrets.Cipher) {
+	r.credentialRepo = repo
+	r.credentialCipher = cipher
+}
+
+// ResolveCommit returns the SHA of the commit a ref refers to in the
+// service's repository.
+func (r *CommitResolver) ResolveCommit(ctx context.Context, service *database.Service, ref string) (string, error) {
+	provider, err := serviceProvider(ctx, r.providers, r.credentialRepo, r.credentialCipher, service)
+	if err != nil {
+		return "", err
+	}
+
+	lookup, ok := provider.(CommitLookup)
+	if !ok {
+		return "", fmt.Errorf("provider for %s cannot resolve commits", service.GitURL)
+	}
+
+	owner, repo, err := ParseOwnerRepo(service.GitURL)
+	if err != nil {
+		return "", err
+	}
+	return lookup.ResolveCommit(ctx, owner, repo, ref)
+}
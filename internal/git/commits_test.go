@@ -0,0 +1,101 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+func TestGitHubResolveCommit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/api/commits/main" {
+			http.Error(w, `{"message":"No commit found for SHA: missing"}`, http.StatusUnprocessableEntity)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"sha": "abc123"})
+	}))
+	defer server.Close()
+
+	provider, err := NewGitHubProvider(Config{Token: "token", BaseURL: server.URL})
+	require.NoError(t, err)
+
+	sha, err := provider.ResolveCommit(context.Background(), "acme", "api", "main")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", sha)
+
+	_, err = provider.ResolveCommit(context.Background(), "acme", "api", "missing")
+	assert.ErrorContains(t, err, "No commit found")
+}
+
+func TestGitLabResolveCommit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/projects/acme%2Fapi/repository/commits/feature%2Flogin", r.URL.EscapedPath())
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"id": "def456"})
+	}))
+	defer server.Close()
+
+	provider, err := NewGitLabProvider(Config{Provider: "gitlab", Token: "token", BaseURL: server.URL})
+	require.NoError(t, err)
+
+	sha, err := provider.ResolveCommit(context.Background(), "acme", "api", "feature/login")
+	require.NoError(t, err)
+	assert.Equal(t, "def456", sha)
+}
+
+func TestBitbucketResolveCommit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repositories/acme/api/commit/main", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"hash": "789abc"})
+	}))
+	defer server.Close()
+
+	provider, err := NewBitbucketProvider(Config{Provider: "bitbucket", Token: "token", BaseURL: server.URL})
+	require.NoError(t, err)
+
+	sha, err := provider.ResolveCommit(context.Background(), "acme", "api", "main")
+	require.NoError(t, err)
+	assert.Equal(t, "789abc", sha)
+}
+
+type fakeCommitLookup struct {
+	Provider
+
+	owner, repo, ref string
+}
+
+func (p *fakeCommitLookup) ResolveCommit(ctx context.Context, owner, repo, ref string) (string, error) {
+	p.owner, p.repo, p.ref = owner, repo, ref
+	return "abc123", nil
+}
+
+func TestCommitResolver_ResolveCommit(t *testing.T) {
+	service := &database.Service{ID: uuid.New(), GitURL: "https://github.com/acme/api.git"}
+
+	resolver := NewCommitResolver()
+	_, err := resolver.ResolveCommit(context.Background(), service, "main")
+	require.Error(t, err, "no provider registered")
+
+	resolver.RegisterProvider("github", &fakeStatusProvider{})
+	_, err = resolver.ResolveCommit(context.Background(), service, "main")
+	require.Error(t, err, "provider cannot resolve commits")
+
+	lookup := &fakeCommitLookup{}
+	resolver.RegisterProvider("github", lookup)
+	sha, err := resolver.ResolveCommit(context.Background(), service, "main")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", sha)
+	assert.Equal(t, "acme", lookup.owner)
+	assert.Equal(t, "api", lookup.repo)
+	assert.Equal(t, "main", lookup.ref)
+}
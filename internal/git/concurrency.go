@@ -0,0 +1,59 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// DefaultConcurrencyGroup is the group key of services that declare a
+// concurrency group without a key: runs of the same service and branch.
+const DefaultConcurrencyGroup = "${SERVICE_NAME}/${GIT_REF}"
+
+// maxConcurrencyGroupLength is the maximum length of a group key template.
+const maxConcurrencyGroupLength = 255
+
+// concurrencyGroupVariable matches the variables of a group key template.
+var concurrencyGroupVariable = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// concurrencyFromConfig returns the concurrency group declared in config,
+// with an empty Group when it declares none.
+func concurrencyFromConfig(serviceID uuid.UUID, config *TestConfig) (*database.ServiceConcurrency, error) {
+	concurrency := &database.ServiceConcurrency{ServiceID: serviceID}
+	if config.Concurrency == nil {
+		return concurrency, nil
+	}
+
+	group := strings.TrimSpace(config.Concurrency.Group)
+	if group == "" {
+		group = DefaultConcurrencyGroup
+	}
+	if len(group) > maxConcurrencyGroupLength {
+		return nil, fmt.Errorf("group must be at most %d characters", maxConcurrencyGroupLength)
+	}
+	for _, match := range concurrencyGroupVariable.FindAllStringSubmatch(group, -1) {
+		switch match[1] {
+		case "SERVICE_NAME", "GIT_REF", "GIT_BRANCH":
+		default:
+			return nil, fmt.Errorf("unknown variable %s in group (use SERVICE_NAME, GIT_REF or GIT_BRANCH)", match[0])
+		}
+	}
+
+	concurrency.Group = group
+	concurrency.CancelInProgress = config.Concurrency.CancelInProgress
+	return concurrency, nil
+}
+
+// ExpandConcurrencyGroup returns the group key of a run of a service on a
+// branch, replacing the variables of the template.
+func ExpandConcurrencyGroup(template, serviceName, branch string) string {
+	return strings.NewReplacer(
+		"${SERVICE_NAME}", serviceName,
+		"${GIT_REF}", branch,
+		"${GIT_BRANCH}", branch,
+	).Replace(template)
+}
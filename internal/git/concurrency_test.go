@@ -0,0 +1,13 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandConcurrencyGroup(t *testing.T) {
+	assert.Equal(t, "billing/main", ExpandConcurrencyGroup(DefaultConcurrencyGroup, "billing", "main"))
+	assert.Equal(t, "deploy-release", ExpandConcurrencyGroup("deploy-${GIT_BRANCH}", "billing", "release"))
+	assert.Equal(t, "staging", ExpandConcurrencyGroup("staging", "billing", "main"))
+}
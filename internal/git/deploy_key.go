@@ -0,0 +1,65 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// DeployKeyInfo holds the public details derived from a deploy key.
+type DeployKeyInfo struct {
+	// PublicKey is the key in authorized_keys format.
+	PublicKey string
+	// Fingerprint is the SHA256 fingerprint of the public key.
+	Fingerprint string
+}
+
+// ParseDeployKey validates an unencrypted SSH private key and derives its
+// public key and fingerprint.
+func ParseDeployKey(privateKey string) (*DeployKeyInfo, error) {
+	if strings.TrimSpace(privateKey) == "" {
+		return nil, errors.New("private key is required")
+	}
+
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return nil, errors.New("passphrase-protected keys are not supported")
+		}
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+
+	pub := signer.PublicKey()
+	return &DeployKeyInfo{
+		PublicKey:   strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub))),
+		Fingerprint: ssh.FingerprintSHA256(pub),
+	}, nil
+}
+
+// SSHCloneURL converts an HTTP(S) repository URL to its SSH equivalent so a
+// deploy key can be used, e.g. https://github.com/org/repo becomes
+// git@github.com:org/repo.git. URLs that already use SSH are returned as-is.
+func SSHCloneURL(repoURL string) string {
+	if !strings.HasPrefix(repoURL, "https://") && !strings.HasPrefix(repoURL, "http://") {
+		return repoURL
+	}
+
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Host == "" {
+		return repoURL
+	}
+
+	path := strings.Trim(u.Path, "/")
+	if path == "" {
+		return repoURL
+	}
+	if !strings.HasSuffix(path, ".git") {
+		path += ".git"
+	}
+
+	return fmt.Sprintf("git@%s:%s", u.Hostname(), path)
+}
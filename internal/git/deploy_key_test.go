@@ -0,0 +1,57 @@
+package git
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestParseDeployKey(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	block, err := ssh.MarshalPrivateKey(priv, "deploy")
+	require.NoError(t, err)
+
+	info, err := ParseDeployKey(string(pem.EncodeToMemory(block)))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(info.PublicKey, "ssh-ed25519 "))
+	assert.True(t, strings.HasPrefix(info.Fingerprint, "SHA256:"))
+
+	_, err = ParseDeployKey("")
+	assert.Error(t, err)
+
+	_, err = ParseDeployKey("not a key")
+	assert.Error(t, err)
+
+	protected, err := ssh.MarshalPrivateKeyWithPassphrase(priv, "deploy", []byte("secret"))
+	require.NoError(t, err)
+	_, err = ParseDeployKey(string(pem.EncodeToMemory(protected)))
+	assert.ErrorContains(t, err, "passphrase")
+}
+
+func TestSSHCloneURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/owner/repo", "git@github.com:owner/repo.git"},
+		{"https://github.com/owner/repo.git", "git@github.com:owner/repo.git"},
+		{"https://gitlab.example.com:8443/group/sub/repo", "git@gitlab.example.com:group/sub/repo.git"},
+		{"git@github.com:owner/repo.git", "git@github.com:owner/repo.git"},
+		{"ssh://git@github.com/owner/repo.git", "ssh://git@github.com/owner/repo.git"},
+		{"https://github.com", "https://github.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.want, SSHCloneURL(tt.url))
+		})
+	}
+}
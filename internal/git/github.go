@@ -269,6 +269,64 @@ func (g *GitHubProvider) CreateCheckRun(ctx context.Context, owner, repo, sha, n
 	}, nil
 }
 
+// CreateCompletedCheckRun creates a finished check run with its output.
+// GitHub accepts at most 50 annotations per request, so any beyond the first
+// batch are appended with follow-up updates.
+func (g *GitHubProvider) CreateCompletedCheckRun(ctx context.Context, owner, repo, sha, name, conclusion, detailsURL string, output *CheckRunOutput) (*CheckRun, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/check-runs", g.baseURL, owner, repo)
+
+	var annotations []CheckRunAnnotation
+	if output != nil {
+		annotations = output.Annotations
+	}
+	first := annotations[:min(len(annotations), maxAnnotationsPerRequest)]
+
+	payload := githubCheckRunRequest{
+		Name:        name,
+		HeadSHA:     sha,
+		Status:      "completed",
+		Conclusion:  conclusion,
+		DetailsURL:  detailsURL,
+		CompletedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if output != nil {
+		payload.Output = toGitHubCheckRunOutput(output, first)
+	}
+
+	var result githubCheckRun
+	if err := g.doRequestWithRetry(ctx, "POST", url, payload, &result); err != nil {
+		return nil, fmt.Errorf("failed to create check run: %w", err)
+	}
+
+	for start := len(first); start < len(annotations); start += maxAnnotationsPerRequest {
+		batch := annotations[start:min(start+maxAnnotationsPerRequest, len(annotations))]
+		updateURL := fmt.Sprintf("%s/repos/%s/%s/check-runs/%d", g.baseURL, owner, repo, result.ID)
+		update := githubCheckRunUpdateRequest{Output: toGitHubCheckRunOutput(output, batch)}
+		if err := g.doRequestWithRetry(ctx, "PATCH", updateURL, update, nil); err != nil {
+			return nil, fmt.Errorf("failed to add check run annotations: %w", err)
+		}
+	}
+
+	g.logger.Debug("created completed check run",
+		"owner", owner,
+		"repo", repo,
+		"sha", sha,
+		"name", name,
+		"conclusion", conclusion,
+		"annotations", len(annotations),
+		"check_run_id", result.ID,
+	)
+
+	return &CheckRun{
+		ID:         result.ID,
+		Name:       result.Name,
+		HeadSHA:    result.HeadSHA,
+		Status:     result.Status,
+		Conclusion: result.Conclusion,
+		HTMLURL:    result.HTMLURL,
+	}, nil
+}
+
 // UpdateCheckRun updates an existing check run.
 func (g *GitHubProvider) UpdateCheckRun(ctx context.Context, owner, repo string, checkRunID int64, status string, conclusion *string, output *CheckRunOutput) error {
 	url := fmt.Sprintf("%s/repos/%s/%s/check-runs/%d", g.baseURL, owner, repo, checkRunID)
@@ -282,11 +340,7 @@ func (g *GitHubProvider) UpdateCheckRun(ctx context.Context, owner, repo string,
 		payload.CompletedAt = now
 	}
 	if output != nil {
-		payload.Output = &githubCheckRunOutput{
-			Title:   output.Title,
-			Summary: output.Summary,
-			Text:    output.Text,
-		}
+		payload.Output = toGitHubCheckRunOutput(output, output.Annotations)
 	}
 
 	if err := g.doRequestWithRetry(ctx, "PATCH", url, payload, nil); err != nil {
@@ -339,6 +393,41 @@ func (g *GitHubProvider) GetPullRequest(ctx context.Context, owner, repo string,
 	return pr, nil
 }
 
+// CompareCommits returns the paths changed on head since its merge base with base.
+func (g *GitHubProvider) CompareCommits(ctx context.Context, owner, repo, base, head string) ([]string, error) {
+	var paths []string
+	for page := 1; page <= maxComparePages; page++ {
+		url := fmt.Sprintf("%s/repos/%s/%s/compare/%s...%s?per_page=100&page=%d", g.baseURL, owner, repo, base, head, page)
+
+		var comparison githubComparison
+		if err := g.doRequestWithRetry(ctx, "GET", url, nil, &comparison); err != nil {
+			return nil, fmt.Errorf("failed to compare commits: %w", err)
+		}
+
+		for _, f := range comparison.Files {
+			paths = append(paths, f.Filename)
+			if f.PreviousFilename != "" {
+				paths = append(paths, f.PreviousFilename)
+			}
+		}
+		if len(comparison.Files) < 100 {
+			break
+		}
+	}
+	return paths, nil
+}
+
+// ResolveCommit returns the SHA of the commit a SHA, branch or tag refers to.
+func (g *GitHubProvider) ResolveCommit(ctx context.Context, owner, repo, ref string) (string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/commits/%s", g.baseURL, owner, repo, ref)
+
+	var commit githubCommit
+	if err := g.doRequestWithRetry(ctx, "GET", url, nil, &commit); err != nil {
+		return "", fmt.Errorf("failed to resolve commit: %w", err)
+	}
+	return commit.SHA, nil
+}
+
 // CreateComment posts a comment on a pull request.
 func (g *GitHubProvider) CreateComment(ctx context.Context, owner, repo string, prNumber int, body string) error {
 	url := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", g.baseURL, owner, repo, prNumber)
@@ -620,6 +709,17 @@ type githubCommentRequest struct {
 	Body string `json:"body"`
 }
 
+type githubComparison struct {
+	Files []struct {
+		Filename         string `json:"filename"`
+		PreviousFilename string `json:"previous_filename"`
+	} `json:"files"`
+}
+
+type githubCommit struct {
+	SHA string `json:"sha"`
+}
+
 type githubPullRequest struct {
 	Number         int                  `json:"number"`
 	State          string               `json:"state"`
@@ -649,11 +749,14 @@ type githubUser struct {
 }
 
 type githubCheckRunRequest struct {
-	Name       string `json:"name"`
-	HeadSHA    string `json:"head_sha"`
-	Status     string `json:"status,omitempty"`
-	Conclusion string `json:"conclusion,omitempty"`
-	StartedAt  string `json:"started_at,omitempty"`
+	Name        string                `json:"name"`
+	HeadSHA     string                `json:"head_sha"`
+	Status      string                `json:"status,omitempty"`
+	Conclusion  string                `json:"conclusion,omitempty"`
+	DetailsURL  string                `json:"details_url,omitempty"`
+	StartedAt   string                `json:"started_at,omitempty"`
+	CompletedAt string                `json:"completed_at,omitempty"`
+	Output      *githubCheckRunOutput `json:"output,omitempty"`
 }
 
 type githubCheckRunUpdateRequest struct {
@@ -664,9 +767,43 @@ type githubCheckRunUpdateRequest struct {
 }
 
 type githubCheckRunOutput struct {
-	Title   string `json:"title"`
-	Summary string `json:"summary"`
-	Text    string `json:"text,omitempty"`
+	Title       string                     `json:"title"`
+	Summary     string                     `json:"summary"`
+	Text        string                     `json:"text,omitempty"`
+	Annotations []githubCheckRunAnnotation `json:"annotations,omitempty"`
+}
+
+type githubCheckRunAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title,omitempty"`
+	Message         string `json:"message"`
+	RawDetails      string `json:"raw_details,omitempty"`
+}
+
+// maxAnnotationsPerRequest is GitHub's limit on annotations per check run request.
+const maxAnnotationsPerRequest = 50
+
+func toGitHubCheckRunOutput(output *CheckRunOutput, annotations []CheckRunAnnotation) *githubCheckRunOutput {
+	out := &githubCheckRunOutput{
+		Title:   output.Title,
+		Summary: output.Summary,
+		Text:    output.Text,
+	}
+	for _, a := range annotations {
+		out.Annotations = append(out.Annotations, githubCheckRunAnnotation{
+			Path:            a.Path,
+			StartLine:       a.StartLine,
+			EndLine:         a.EndLine,
+			AnnotationLevel: string(a.Level),
+			Title:           a.Title,
+			Message:         a.Message,
+			RawDetails:      a.RawDetails,
+		})
+	}
+	return out
 }
 
 type githubCheckRun struct {
@@ -712,9 +849,30 @@ type CheckRun struct {
 
 // CheckRunOutput contains output for a check run.
 type CheckRunOutput struct {
-	Title   string
-	Summary string
-	Text    string
+	Title       string
+	Summary     string // Markdown
+	Text        string // Markdown
+	Annotations []CheckRunAnnotation
+}
+
+// AnnotationLevel is the severity of a check run annotation.
+type AnnotationLevel string
+
+const (
+	AnnotationLevelNotice  AnnotationLevel = "notice"
+	AnnotationLevelWarning AnnotationLevel = "warning"
+	AnnotationLevelFailure AnnotationLevel = "failure"
+)
+
+// CheckRunAnnotation marks a line range of a file in the pull request diff.
+type CheckRunAnnotation struct {
+	Path       string
+	StartLine  int
+	EndLine    int
+	Level      AnnotationLevel
+	Title      string
+	Message    string
+	RawDetails string
 }
 
 // decodeBase64Content decodes base64-encoded file content from GitHub.
@@ -264,6 +264,39 @@ func (g *GitLabProvider) GetPullRequest(ctx context.Context, owner, repo string,
 	return pr, nil
 }
 
+// CompareCommits returns the paths changed on head since its merge base with base.
+func (g *GitLabProvider) CompareCommits(ctx context.Context, owner, repo, base, head string) ([]string, error) {
+	projectPath := g.projectPath(owner, repo)
+	apiURL := fmt.Sprintf("%s/projects/%s/repository/compare?from=%s&to=%s",
+		g.baseURL, projectPath, url.QueryEscape(base), url.QueryEscape(head))
+
+	var comparison gitlabComparison
+	if err := g.doRequestWithRetry(ctx, "GET", apiURL, nil, &comparison); err != nil {
+		return nil, fmt.Errorf("failed to compare commits: %w", err)
+	}
+
+	paths := make([]string, 0, len(comparison.Diffs))
+	for _, d := range comparison.Diffs {
+		paths = append(paths, d.NewPath)
+		if d.OldPath != d.NewPath {
+			paths = append(paths, d.OldPath)
+		}
+	}
+	return paths, nil
+}
+
+// ResolveCommit returns the SHA of the commit a SHA, branch or tag refers to.
+func (g *GitLabProvider) ResolveCommit(ctx context.Context, owner, repo, ref string) (string, error) {
+	projectPath := g.projectPath(owner, repo)
+	apiURL := fmt.Sprintf("%s/projects/%s/repository/commits/%s", g.baseURL, projectPath, url.PathEscape(ref))
+
+	var commit gitlabCommit
+	if err := g.doRequestWithRetry(ctx, "GET", apiURL, nil, &commit); err != nil {
+		return "", fmt.Errorf("failed to resolve commit: %w", err)
+	}
+	return commit.ID, nil
+}
+
 // CreateComment posts a comment on a merge request.
 func (g *GitLabProvider) CreateComment(ctx context.Context, owner, repo string, mrNumber int, body string) error {
 	projectPath := g.projectPath(owner, repo)
@@ -503,6 +536,17 @@ type gitlabStatusRequest struct {
 	TargetURL   string `json:"target_url,omitempty"`
 }
 
+type gitlabComparison struct {
+	Diffs []struct {
+		OldPath string `json:"old_path"`
+		NewPath string `json:"new_path"`
+	} `json:"diffs"`
+}
+
+type gitlabCommit struct {
+	ID string `json:"id"`
+}
+
 type gitlabMergeRequest struct {
 	ID             int64      `json:"id"`
 	IID            int        `json:"iid"`
@@ -0,0 +1,57 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// healthCheckTimeout bounds a reachability check of a provider's API.
+const healthCheckTimeout = 5 * time.Second
+
+// HealthChecker checks that the API of a git provider is reachable.
+type HealthChecker struct {
+	url    string
+	client *http.Client
+}
+
+// NewHealthChecker creates a health checker for the provider's API.
+func NewHealthChecker(cfg Config) (*HealthChecker, error) {
+	url := cfg.BaseURL
+	if url == "" {
+		switch cfg.Provider {
+		case "github", "":
+			url = DefaultGitHubBaseURL
+		case "gitlab":
+			url = DefaultGitLabBaseURL
+		case "bitbucket":
+			url = DefaultBitbucketBaseURL
+		default:
+			return nil, fmt.Errorf("unsupported git provider: %s", cfg.Provider)
+		}
+	}
+	return &HealthChecker{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: healthCheckTimeout},
+	}, nil
+}
+
+// HealthCheck requests the root of the API. Any answer below 500 counts as
+// reachable, as providers may refuse unauthenticated requests to it.
+func (c *HealthChecker) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("git provider unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("git provider unavailable: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package git
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthChecker(t *testing.T) {
+	status := http.StatusUnauthorized
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	checker, err := NewHealthChecker(Config{Provider: "gitlab", BaseURL: srv.URL + "/"})
+	require.NoError(t, err)
+
+	assert.NoError(t, checker.HealthCheck(context.Background()), "refused requests reach the provider")
+
+	status = http.StatusBadGateway
+	assert.Error(t, checker.HealthCheck(context.Background()))
+
+	srv.Close()
+	assert.Error(t, checker.HealthCheck(context.Background()))
+}
+
+func TestNewHealthChecker(t *testing.T) {
+	checker, err := NewHealthChecker(Config{Provider: "bitbucket"})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBitbucketBaseURL, checker.url)
+
+	_, err = NewHealthChecker(Config{Provider: "svn"})
+	assert.Error(t, err)
+}
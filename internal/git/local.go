@@ -0,0 +1,138 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// LocalConfig is the test configuration of a working copy, read and
+// validated the same way a sync reads a repository.
+type LocalConfig struct {
+	// Environment is the service environment declared by the root config
+	// file, or nil if it declares none.
+	Environment *database.ServiceEnvironment
+	// Tests are the valid tests in declaration order.
+	Tests []LocalTest
+	// Errors describe the files and tests a sync would skip.
+	Errors []string
+}
+
+// LocalTest is a test declared in a working copy.
+type LocalTest struct {
+	// Definition is the test definition a sync would store.
+	Definition *database.TestDefinition
+	// ConfigPath is the config file declaring the test, relative to the
+	// working copy.
+	ConfigPath string
+	// Disabled reports whether the test is marked as disabled.
+	Disabled bool
+}
+
+// LoadLocalConfig reads the root config file and the config directory of a
+// working copy. Tests get random IDs. An error is only returned when no
+// configuration exists at all.
+func LoadLocalConfig(ctx context.Context, dir string) (*LocalConfig, error) {
+	s := &Syncer{
+		provider: dirProvider{dir: dir},
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	files, err := s.loadConfigs(ctx, "", "", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	local := &LocalConfig{}
+	declaredIn := make(map[string]string)
+	for _, file := range files {
+		if file.err != nil {
+			local.Errors = append(local.Errors, file.err.Error())
+			continue
+		}
+
+		if file.config.Env != nil || file.config.Secrets != nil {
+			if !file.root {
+				local.Errors = append(local.Errors, fmt.Sprintf("%s: env and secrets are only read from the root config file", file.path))
+			} else if env, err := environmentFromConfig(uuid.Nil, file.config); err != nil {
+				local.Errors = append(local.Errors, fmt.Sprintf("invalid environment: %v", err))
+			} else {
+				local.Environment = env
+			}
+		}
+
+		if file.config.Concurrency != nil {
+			if !file.root {
+				local.Errors = append(local.Errors, fmt.Sprintf("%s: concurrency is only read from the root config file", file.path))
+			} else if _, err := concurrencyFromConfig(uuid.Nil, file.config); err != nil {
+				local.Errors = append(local.Errors, fmt.Sprintf("invalid concurrency: %v", err))
+			}
+		}
+
+		for _, testCfg := range file.config.Tests {
+			if prev, ok := declaredIn[testCfg.Name]; ok && testCfg.Name != "" {
+				local.Errors = append(local.Errors, fmt.Sprintf("%s: duplicate test '%s' (already defined in %s)", file.path, testCfg.Name, prev))
+				continue
+			}
+			declaredIn[testCfg.Name] = file.path
+
+			test, err := s.configToTestDefinition(testCfg, uuid.Nil, file.path, "")
+			if err != nil {
+				local.Errors = append(local.Errors, fmt.Sprintf("%s: invalid test config '%s': %v", file.path, testCfg.Name, err))
+				continue
+			}
+			test.ID = uuid.New()
+
+			local.Tests = append(local.Tests, LocalTest{
+				Definition: test,
+				ConfigPath: file.path,
+				Disabled:   testCfg.Disabled,
+			})
+		}
+	}
+
+	tests := make([]database.TestDefinition, 0, len(local.Tests))
+	for _, test := range local.Tests {
+		tests = append(tests, *test.Definition)
+	}
+	if err := database.ValidateTestDependencies(tests); err != nil {
+		local.Errors = append(local.Errors, fmt.Sprintf("invalid depends_on: %v", err))
+	}
+	return local, nil
+}
+
+// dirProvider reads configuration files from a local directory. Only the
+// file operations used by syncs are implemented.
+type dirProvider struct {
+	Provider
+	dir string
+}
+
+func (p dirProvider) GetFile(ctx context.Context, owner, repo, name, ref string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(p.dir, filepath.FromSlash(name)))
+}
+
+func (p dirProvider) ListFiles(ctx context.Context, owner, repo, dir, ref string) ([]FileInfo, error) {
+	entries, err := os.ReadDir(filepath.Join(p.dir, filepath.FromSlash(dir)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info := FileInfo{Name: entry.Name(), Path: path.Join(dir, entry.Name()), Type: "file"}
+		if entry.IsDir() {
+			info.Type = "dir"
+		}
+		files = append(files, info)
+	}
+	return files, nil
+}
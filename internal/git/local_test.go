@@ -0,0 +1,189 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+}
+
+func TestLoadLocalConfig(t *testing.T) {
+	t.Run("reads root file and config directory", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"conductor.yaml": `
+env:
+  LOG_LEVEL: debug
+secrets:
+  - name: API_TOKEN
+    provider: vault
+    path: secret/api
+tests:
+  - name: unit
+    command: go test ./...
+  - name: slow
+    command: make slow
+    disabled: true
+`,
+			".conductor/e2e.yaml": `
+tests:
+  - name: e2e
+    command: npx
+    args: [playwright, test]
+    timeout: 10m
+`,
+			".conductor/notes.txt": "ignored",
+		})
+
+		local, err := LoadLocalConfig(context.Background(), dir)
+		require.NoError(t, err)
+		assert.Empty(t, local.Errors)
+
+		require.NotNil(t, local.Environment)
+		assert.Equal(t, map[string]string{"LOG_LEVEL": "debug"}, local.Environment.Environment)
+		require.Len(t, local.Environment.Secrets, 1)
+		assert.Equal(t, "API_TOKEN", local.Environment.Secrets[0].Name)
+
+		require.Len(t, local.Tests, 3)
+		assert.Equal(t, "unit", local.Tests[0].Definition.Name)
+		assert.Equal(t, "conductor.yaml", local.Tests[0].ConfigPath)
+		assert.True(t, local.Tests[1].Disabled)
+		assert.Equal(t, ".conductor/e2e.yaml", local.Tests[2].ConfigPath)
+		assert.Equal(t, 600, local.Tests[2].Definition.TimeoutSeconds)
+		assert.Equal(t, []string{"playwright", "test"}, local.Tests[2].Definition.Args)
+	})
+
+	t.Run("reports invalid tests like a sync", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			".conductor.yaml": `
+tests:
+  - name: unit
+    command: make test
+  - name: broken
+    execution_mode: vm
+    command: make
+`,
+			".conductor/a.yaml": `
+env:
+  FOO: bar
+tests:
+  - name: unit
+    command: make other
+`,
+		})
+
+		local, err := LoadLocalConfig(context.Background(), dir)
+		require.NoError(t, err)
+		require.Len(t, local.Tests, 1)
+		require.Len(t, local.Errors, 3)
+		assert.Contains(t, local.Errors[0], "invalid test config 'broken': invalid execution_mode")
+		assert.Contains(t, local.Errors[1], ".conductor/a.yaml: env and secrets are only read from the root config file")
+		assert.Contains(t, local.Errors[2], "duplicate test 'unit'")
+	})
+
+	t.Run("reads test matrices", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"conductor.yaml": `
+tests:
+  - name: unit
+    command: go test ./...
+    matrix:
+      env:
+        GO_VERSION: ["1.22", "1.23"]
+      labels:
+        os: [linux, windows]
+  - name: broken
+    command: make
+    matrix:
+      env:
+        GO_VERSION: []
+`,
+		})
+
+		local, err := LoadLocalConfig(context.Background(), dir)
+		require.NoError(t, err)
+		require.Len(t, local.Tests, 1)
+		require.NotNil(t, local.Tests[0].Definition.Matrix)
+		assert.Len(t, local.Tests[0].Definition.Matrix.Cells(), 4)
+		require.Len(t, local.Errors, 1)
+		assert.Contains(t, local.Errors[0], "invalid test config 'broken': invalid matrix: env axis GO_VERSION has no values")
+	})
+
+	t.Run("reads test parameters", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"conductor.yaml": `
+tests:
+  - name: e2e
+    command: npm run e2e -- --env ${params.TARGET_ENV}
+    parameters:
+      - name: TARGET_ENV
+        default: staging
+        choices: [staging, production]
+      - name: WORKERS
+        type: integer
+        default: 4
+  - name: broken
+    command: make
+    parameters:
+      - name: WORKERS
+        type: integer
+        default: many
+`,
+		})
+
+		local, err := LoadLocalConfig(context.Background(), dir)
+		require.NoError(t, err)
+		require.Len(t, local.Tests, 1)
+		params := local.Tests[0].Definition.Parameters
+		require.Len(t, params, 2)
+		assert.Equal(t, "staging", *params[0].Default)
+		assert.Equal(t, database.TestParameterInteger, params[1].Type)
+		assert.Equal(t, "4", *params[1].Default)
+		require.Len(t, local.Errors, 1)
+		assert.Contains(t, local.Errors[0], "invalid test config 'broken': invalid parameters: default of parameter WORKERS must be an integer")
+	})
+
+	t.Run("reads test dependencies", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"conductor.yaml": `
+tests:
+  - name: unit
+    command: go test ./...
+  - name: e2e
+    command: make e2e
+    depends_on: [unit, smoke]
+`,
+		})
+
+		local, err := LoadLocalConfig(context.Background(), dir)
+		require.NoError(t, err)
+		require.Len(t, local.Tests, 2)
+		assert.Equal(t, []string{"unit", "smoke"}, local.Tests[1].Definition.DependsOn)
+		require.Len(t, local.Errors, 1)
+		assert.Contains(t, local.Errors[0], `invalid depends_on: test e2e depends on unknown test "smoke"`)
+	})
+
+	t.Run("missing configuration", func(t *testing.T) {
+		_, err := LoadLocalConfig(context.Background(), t.TempDir())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "config file not found")
+	})
+}
@@ -0,0 +1,172 @@
+package git
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// ServiceSyncer syncs a service's test definitions from its repository.
+// Syncer implements it.
+type ServiceSyncer interface {
+	SyncService(ctx context.Context, service *database.Service, branch string) (*SyncResult, error)
+}
+
+// PeriodicSyncConfig defines periodic re-sync settings.
+type PeriodicSyncConfig struct {
+	// Interval is the default time between syncs of a service; services can
+	// override it. Zero only syncs services with their own interval.
+	Interval time.Duration
+	// CheckInterval is how often services are checked for a due sync.
+	CheckInterval time.Duration
+	// BatchSize is the maximum number of services synced per check.
+	BatchSize int
+}
+
+// PeriodicSyncer re-syncs test definitions of services on an interval so
+// that definitions changed in a repository are picked up without a manual
+// sync, and records every sync in the sync history.
+type PeriodicSyncer struct {
+	syncer        ServiceSyncer
+	repo          database.ServiceSyncRepository
+	logger        *slog.Logger
+	interval      time.Duration
+	checkInterval time.Duration
+	batchSize     int
+	now           func() time.Time
+}
+
+// NewPeriodicSyncer creates a new PeriodicSyncer.
+func NewPeriodicSyncer(
+	syncer ServiceSyncer,
+	repo database.ServiceSyncRepository,
+	config PeriodicSyncConfig,
+	logger *slog.Logger,
+) *PeriodicSyncer {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	checkInterval := config.CheckInterval
+	if checkInterval <= 0 {
+		checkInterval = time.Minute
+	}
+
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 20
+	}
+
+	return &PeriodicSyncer{
+		syncer:        syncer,
+		repo:          repo,
+		logger:        logger.With("component", "git_periodic_sync"),
+		interval:      config.Interval,
+		checkInterval: checkInterval,
+		batchSize:     batchSize,
+		now:           time.Now,
+	}
+}
+
+// Start begins the sync loop until the context is canceled.
+func (p *PeriodicSyncer) Start(ctx context.Context) {
+	p.logger.Info("starting periodic sync",
+		"interval", p.interval,
+		"check_interval", p.checkInterval,
+	)
+
+	go func() {
+		ticker := time.NewTicker(p.checkInterval)
+		defer ticker.Stop()
+
+		for {
+			p.run(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (p *PeriodicSyncer) run(ctx context.Context) {
+	services, err := p.repo.ListDue(ctx, p.interval, p.batchSize)
+	if err != nil {
+		p.logger.Error("failed to list services due for sync", "error", err)
+		return
+	}
+
+	for i := range services {
+		if ctx.Err() != nil {
+			return
+		}
+		p.sync(ctx, &services[i])
+	}
+}
+
+func (p *PeriodicSyncer) sync(ctx context.Context, service *database.Service) {
+	startedAt := p.now()
+	result, err := p.syncer.SyncService(ctx, service, "")
+	if err != nil {
+		p.logger.Warn("periodic sync failed",
+			"service_id", service.ID,
+			"service_name", service.Name,
+			"error", err,
+		)
+	}
+
+	record := NewSyncRecord(service, "", database.SyncTriggerScheduled, startedAt, p.now(), result, err)
+	if err := p.repo.Record(ctx, record); err != nil {
+		p.logger.Error("failed to record sync",
+			"service_id", service.ID,
+			"error", err,
+		)
+	}
+}
+
+// NewSyncRecord builds the sync history record of a finished sync. An empty
+// branch means the service's default branch was synced.
+func NewSyncRecord(
+	service *database.Service,
+	branch string,
+	trigger database.SyncTrigger,
+	startedAt, finishedAt time.Time,
+	result *SyncResult,
+	syncErr error,
+) *database.ServiceSync {
+	if branch == "" {
+		branch = service.DefaultBranch
+	}
+
+	record := &database.ServiceSync{
+		ServiceID:  service.ID,
+		Branch:     branch,
+		Trigger:    trigger,
+		Status:     database.SyncStatusSuccess,
+		StartedAt:  startedAt.UTC(),
+		FinishedAt: finishedAt.UTC(),
+	}
+	if result != nil {
+		record.TestsAdded = result.TestsAdded
+		record.TestsUpdated = result.TestsUpdated
+		record.TestsRemoved = result.TestsRemoved
+		record.Errors = result.Errors
+	}
+
+	switch {
+	case syncErr != nil:
+		record.Status = database.SyncStatusFailed
+		if len(record.Errors) == 0 {
+			record.Errors = []string{syncErr.Error()}
+		}
+	case len(record.Errors) > 0 && record.TestsAdded+record.TestsUpdated == 0:
+		// Nothing could be synced, e.g. the config file is missing or invalid
+		record.Status = database.SyncStatusFailed
+	case len(record.Errors) > 0:
+		record.Status = database.SyncStatusPartial
+	}
+	return record
+}
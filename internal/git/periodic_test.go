@@ -0,0 +1,78 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+type fakeSyncRepo struct {
+	database.ServiceSyncRepository
+
+	due      []database.Service
+	interval time.Duration
+	recorded []*database.ServiceSync
+}
+
+func (r *fakeSyncRepo) ListDue(ctx context.Context, defaultInterval time.Duration, limit int) ([]database.Service, error) {
+	r.interval = defaultInterval
+	return r.due, nil
+}
+
+func (r *fakeSyncRepo) Record(ctx context.Context, sync *database.ServiceSync) error {
+	r.recorded = append(r.recorded, sync)
+	return nil
+}
+
+type fakeServiceSyncer struct {
+	results map[uuid.UUID]*SyncResult
+	errs    map[uuid.UUID]error
+}
+
+func (s *fakeServiceSyncer) SyncService(ctx context.Context, service *database.Service, branch string) (*SyncResult, error) {
+	return s.results[service.ID], s.errs[service.ID]
+}
+
+func TestPeriodicSyncerRecordsSyncs(t *testing.T) {
+	ok := database.Service{ID: uuid.New(), Name: "ok", DefaultBranch: "main"}
+	partial := database.Service{ID: uuid.New(), Name: "partial", DefaultBranch: "main"}
+	missing := database.Service{ID: uuid.New(), Name: "missing", DefaultBranch: "main"}
+	broken := database.Service{ID: uuid.New(), Name: "broken", DefaultBranch: "develop"}
+
+	syncer := &fakeServiceSyncer{
+		results: map[uuid.UUID]*SyncResult{
+			ok.ID:      {TestsAdded: 2},
+			partial.ID: {TestsUpdated: 1, Errors: []string{"invalid test config 'e2e': test command is required"}},
+			missing.ID: {Errors: []string{"failed to load config: config file not found"}},
+		},
+		errs: map[uuid.UUID]error{broken.ID: errors.New("invalid repository URL")},
+	}
+	repo := &fakeSyncRepo{due: []database.Service{ok, partial, missing, broken}}
+
+	p := NewPeriodicSyncer(syncer, repo, PeriodicSyncConfig{Interval: time.Hour}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	p.run(context.Background())
+
+	assert.Equal(t, time.Hour, repo.interval)
+	require.Len(t, repo.recorded, 4)
+	for _, record := range repo.recorded {
+		assert.Equal(t, database.SyncTriggerScheduled, record.Trigger)
+	}
+
+	assert.Equal(t, database.SyncStatusSuccess, repo.recorded[0].Status)
+	assert.Equal(t, 2, repo.recorded[0].TestsAdded)
+	assert.Equal(t, "main", repo.recorded[0].Branch)
+	assert.Equal(t, database.SyncStatusPartial, repo.recorded[1].Status)
+	assert.Equal(t, database.SyncStatusFailed, repo.recorded[2].Status)
+	assert.Equal(t, database.SyncStatusFailed, repo.recorded[3].Status)
+	assert.Equal(t, []string{"invalid repository URL"}, repo.recorded[3].Errors)
+	assert.Equal(t, "develop", repo.recorded[3].Branch)
+}
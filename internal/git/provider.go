@@ -147,6 +147,58 @@ type TestConfig struct {
 	Service ServiceConfig `yaml:"service" json:"service"`
 	// Tests defines the test suites
 	Tests []TestSuiteConfig `yaml:"tests" json:"tests"`
+	// Env is the service environment inherited by every test
+	Env map[string]string `yaml:"env" json:"env"`
+	// Secrets are secret references inherited by every test
+	Secrets []SecretConfig `yaml:"secrets" json:"secrets"`
+	// Concurrency is the concurrency group of the service's runs
+	Concurrency *ConcurrencyConfig `yaml:"concurrency" json:"concurrency"`
+}
+
+// ConcurrencyConfig puts the runs of a service in a concurrency group, whose
+// runs execute one at a time.
+type ConcurrencyConfig struct {
+	// Group is the group key; empty uses DefaultConcurrencyGroup.
+	Group string `yaml:"group" json:"group"`
+	// CancelInProgress cancels the runs of the group in progress when a run
+	// is created, instead of queueing it behind them.
+	CancelInProgress bool `yaml:"cancel_in_progress" json:"cancel_in_progress"`
+}
+
+// SecretConfig references a secret resolved by agents into an environment variable.
+type SecretConfig struct {
+	Name     string `yaml:"name" json:"name"`
+	Provider string `yaml:"provider" json:"provider"` // vault, aws, envfile; empty uses the agent default
+	Path     string `yaml:"path" json:"path"`
+	Key      string `yaml:"key" json:"key"`
+	Version  int    `yaml:"version" json:"version"`
+}
+
+// ArtifactBudgetConfig caps the artifacts kept per run of a test. Zero
+// values are unlimited.
+type ArtifactBudgetConfig struct {
+	MaxBytes int64 `yaml:"max_bytes" json:"max_bytes"`
+	MaxFiles int   `yaml:"max_files" json:"max_files"`
+}
+
+// MatrixConfig fans a test out over every combination of the values of its
+// axes. Env axes set an environment variable and labels axes select agents
+// carrying a label.
+type MatrixConfig struct {
+	Env    map[string][]string `yaml:"env" json:"env"`
+	Labels map[string][]string `yaml:"labels" json:"labels"`
+}
+
+// ParameterConfig declares a parameter runs of a test can set, passed to
+// the test as an environment variable and as ${params.NAME} in its command.
+// Type is string (the default), integer or boolean.
+type ParameterConfig struct {
+	Name        string   `yaml:"name" json:"name"`
+	Description string   `yaml:"description" json:"description"`
+	Type        string   `yaml:"type" json:"type"`
+	Required    bool     `yaml:"required" json:"required"`
+	Default     *string  `yaml:"default" json:"default"`
+	Choices     []string `yaml:"choices" json:"choices"`
 }
 
 // ServiceConfig holds service-level configuration.
@@ -161,26 +213,35 @@ type ServiceConfig struct {
 
 // TestSuiteConfig defines a test suite configuration.
 type TestSuiteConfig struct {
-	Name             string            `yaml:"name" json:"name"`
-	Description      string            `yaml:"description" json:"description"`
-	Command          string            `yaml:"command" json:"command"`
-	Args             []string          `yaml:"args" json:"args"`
-	WorkDir          string            `yaml:"workdir" json:"workdir"`
-	Env              map[string]string `yaml:"env" json:"env"`
-	Timeout          string            `yaml:"timeout" json:"timeout"`
-	ExecutionMode    string            `yaml:"execution_mode" json:"execution_mode"` // subprocess, container
-	DockerImage      string            `yaml:"docker_image" json:"docker_image"`
-	ResultFormat     string            `yaml:"result_format" json:"result_format"` // junit, jest, go_test, etc.
-	ResultPath       string            `yaml:"result_path" json:"result_path"`
-	Tags             []string          `yaml:"tags" json:"tags"`
-	RequiredLabels   map[string]string `yaml:"required_labels" json:"required_labels"`
-	Disabled         bool              `yaml:"disabled" json:"disabled"`
-	Priority         int               `yaml:"priority" json:"priority"`
-	MaxRetries       int               `yaml:"max_retries" json:"max_retries"`
-	Parallelizable   bool              `yaml:"parallelizable" json:"parallelizable"`
-	ArtifactPaths    []string          `yaml:"artifact_paths" json:"artifact_paths"`
-	SetupCommands    []string          `yaml:"setup_commands" json:"setup_commands"`
-	TeardownCommands []string          `yaml:"teardown_commands" json:"teardown_commands"`
+	Name             string                `yaml:"name" json:"name"`
+	Description      string                `yaml:"description" json:"description"`
+	Owner            string                `yaml:"owner" json:"owner"` // team owning the test, defaults to the service owner
+	Command          string                `yaml:"command" json:"command"`
+	Args             []string              `yaml:"args" json:"args"`
+	WorkDir          string                `yaml:"workdir" json:"workdir"`
+	Env              map[string]string     `yaml:"env" json:"env"`
+	Secrets          []SecretConfig        `yaml:"secrets" json:"secrets"`
+	Timeout          string                `yaml:"timeout" json:"timeout"`
+	ExecutionMode    string                `yaml:"execution_mode" json:"execution_mode"` // subprocess, container
+	DockerImage      string                `yaml:"docker_image" json:"docker_image"`
+	ResultFormat     string                `yaml:"result_format" json:"result_format"` // junit, jest, go_test, etc.
+	ResultPath       string                `yaml:"result_path" json:"result_path"`
+	Tags             []string              `yaml:"tags" json:"tags"`
+	RequiredLabels   map[string]string     `yaml:"required_labels" json:"required_labels"`
+	Architectures    []string              `yaml:"architectures" json:"architectures"`
+	Matrix           *MatrixConfig         `yaml:"matrix" json:"matrix"`
+	Parameters       []ParameterConfig     `yaml:"parameters" json:"parameters"`               // values runs can set, e.g. TARGET_ENV
+	DependsOn        []string              `yaml:"depends_on" json:"depends_on"`               // tests that must pass first
+	RequiresApproval bool                  `yaml:"requires_approval" json:"requires_approval"` // runs wait for approval
+	Disabled         bool                  `yaml:"disabled" json:"disabled"`
+	Priority         int                   `yaml:"priority" json:"priority"`
+	MaxRetries       int                   `yaml:"max_retries" json:"max_retries"`
+	Parallelizable   bool                  `yaml:"parallelizable" json:"parallelizable"`
+	ArtifactPaths    []string              `yaml:"artifact_paths" json:"artifact_paths"`
+	CapturePaths     []string              `yaml:"capture_paths" json:"capture_paths"` // screenshots, videos and reports
+	ArtifactBudget   *ArtifactBudgetConfig `yaml:"artifact_budget" json:"artifact_budget"`
+	SetupCommands    []string              `yaml:"setup_commands" json:"setup_commands"`
+	TeardownCommands []string              `yaml:"teardown_commands" json:"teardown_commands"`
 }
 
 // DiscoveredTest represents a test discovered from a repository.
@@ -0,0 +1,296 @@
+package git
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// Stack trace languages.
+const (
+	LanguageGo         = "go"
+	LanguageJava       = "java"
+	LanguagePython     = "python"
+	LanguageJavaScript = "javascript"
+)
+
+// StackFrame is one frame of a parsed stack trace.
+type StackFrame struct {
+	// Language is the language whose trace format the frame matched.
+	Language string
+	// Function is the function, method or class.method of the frame, if printed.
+	Function string
+	// File is the file as printed in the trace.
+	File string
+	// Line is the 1-based line number.
+	Line int
+	// Column is the 1-based column, if printed.
+	Column int
+	// Path is the repository-relative file, empty when the frame lies outside
+	// the repository (toolchains, dependencies).
+	Path string
+	// URL links to the line on the git provider at the run's commit.
+	URL string
+}
+
+// Stack trace frame patterns, matched against whole lines.
+var (
+	// Go panic: "github.com/acme/shop/auth.(*Service).Login(0xc000012345)"
+	// followed by "\t/work/<run>/auth/service.go:42 +0x1d"
+	goTraceFuncPattern = regexp.MustCompile(`^([\w.\-/]+\.[\w.*()\[\]]+?)\(.*\)$`)
+	goTraceFilePattern = regexp.MustCompile(`^\s+(\S+\.go):(\d+)(?: \+0x[0-9a-f]+)?$`)
+	// Go test: "    auth_test.go:42: expected 200"
+	goTestLinePattern = regexp.MustCompile(`^\s*([\w.-]+_test\.go):(\d+):`)
+	// Java/Kotlin: "at com.example.AuthTest.testLogin(AuthTest.java:42)"
+	javaTracePattern = regexp.MustCompile(`^\s*at (?:[\w.\-]+/)?([\w$.]+)\.([\w$<>]+)\(([\w$-]+\.(?:java|kt|scala|groovy)):(\d+)\)`)
+	// Python: `File "tests/test_auth.py", line 12, in test_login`
+	pythonTracePattern = regexp.MustCompile(`^\s*File "([^"]+)", line (\d+)(?:, in (.+))?$`)
+	// JavaScript/TypeScript: "at Object.<anonymous> (src/auth.test.ts:12:5)"
+	// or "at src/auth.ts:10:3"
+	jsTracePattern = regexp.MustCompile(`^\s*at (?:(.+?) \()?((?:file://)?(?:[A-Za-z]:)?[^\s():]+\.[cm]?[jt]sx?):(\d+):(\d+)\)?$`)
+)
+
+// ParseStackTrace extracts the frames of Go, Java, Python and JavaScript
+// stack traces from text. Lines that are not frames are ignored, so error
+// messages and test output can be passed as is.
+func ParseStackTrace(text string) []StackFrame {
+	var frames []StackFrame
+	var goFunc string
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if m := goTraceFilePattern.FindStringSubmatch(line); m != nil {
+			frames = appendFrame(frames, LanguageGo, goFunc, m[1], m[2], "")
+			goFunc = ""
+			continue
+		}
+		goFunc = ""
+
+		if m := goTestLinePattern.FindStringSubmatch(line); m != nil {
+			frames = appendFrame(frames, LanguageGo, "", m[1], m[2], "")
+			continue
+		}
+		if m := javaTracePattern.FindStringSubmatch(line); m != nil {
+			frames = appendFrame(frames, LanguageJava, m[1]+"."+m[2], m[3], m[4], "")
+			continue
+		}
+		if m := pythonTracePattern.FindStringSubmatch(line); m != nil {
+			frames = appendFrame(frames, LanguagePython, m[3], m[1], m[2], "")
+			continue
+		}
+		if m := jsTracePattern.FindStringSubmatch(line); m != nil {
+			frames = appendFrame(frames, LanguageJavaScript, m[1], strings.TrimPrefix(m[2], "file://"), m[3], m[4])
+			continue
+		}
+		if m := goTraceFuncPattern.FindStringSubmatch(line); m != nil {
+			goFunc = m[1]
+		}
+	}
+	return frames
+}
+
+func appendFrame(frames []StackFrame, language, function, file, line, column string) []StackFrame {
+	lineNo, err := strconv.Atoi(line)
+	if err != nil || lineNo <= 0 {
+		return frames
+	}
+	columnNo, _ := strconv.Atoi(column)
+	return append(frames, StackFrame{
+		Language: language,
+		Function: function,
+		File:     file,
+		Line:     lineNo,
+		Column:   columnNo,
+	})
+}
+
+// SourceLinker resolves stack frames to repository files at a run's commit
+// and links them on the service's git provider.
+type SourceLinker struct {
+	locator  failureLocator
+	provider string
+	webURL   string
+	sha      string
+}
+
+// NewSourceLinker creates a linker for the frames of a run's test results.
+// Frames are still resolved to repository paths when the repository URL or
+// commit is unknown, but carry no URL.
+func NewSourceLinker(service *database.Service, run *database.TestRun) *SourceLinker {
+	linker := &SourceLinker{
+		locator: failureLocator{runID: run.ID.String()},
+	}
+	if run.GitSHA != nil {
+		linker.sha = *run.GitSHA
+	}
+	if service != nil {
+		if owner, repo, err := parseRepositoryURL(service.GitURL); err == nil {
+			linker.locator.repoPath = owner + "/" + repo
+		}
+		linker.webURL = repositoryWebURL(service.GitURL)
+		linker.provider = providerForURL(service.GitProvider, linker.webURL)
+	}
+	return linker
+}
+
+// Link parses the stack trace of a test result, falling back to its error
+// message, and resolves every frame against the repository.
+func (l *SourceLinker) Link(result database.TestResult) []StackFrame {
+	text := stringValue(result.StackTrace)
+	if strings.TrimSpace(text) == "" {
+		text = stringValue(result.ErrorMessage)
+	}
+
+	frames := ParseStackTrace(text)
+	for i := range frames {
+		frames[i].Path = l.resolve(frames[i], result)
+		if frames[i].Path != "" {
+			frames[i].URL = l.SourceURL(frames[i].Path, frames[i].Line)
+		}
+	}
+	return frames
+}
+
+// SourceURL links to a line of a repository file at the linker's commit. It
+// returns an empty string when the repository URL or commit is unknown.
+func (l *SourceLinker) SourceURL(file string, line int) string {
+	if l.webURL == "" || l.sha == "" {
+		return ""
+	}
+
+	segments := strings.Split(file, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	escaped := strings.Join(segments, "/")
+
+	switch l.provider {
+	case "gitlab":
+		return fmt.Sprintf("%s/-/blob/%s/%s#L%d", l.webURL, l.sha, escaped, line)
+	case "bitbucket":
+		return fmt.Sprintf("%s/src/%s/%s#lines-%d", l.webURL, l.sha, escaped, line)
+	default:
+		return fmt.Sprintf("%s/blob/%s/%s#L%d", l.webURL, l.sha, escaped, line)
+	}
+}
+
+// resolve returns the repository-relative file of a frame, or an empty
+// string when the frame lies outside the repository.
+func (l *SourceLinker) resolve(frame StackFrame, result database.TestResult) string {
+	file := frame.File
+	line := strconv.Itoa(frame.Line)
+
+	switch frame.Language {
+	case LanguageGo:
+		// Test log lines print the file name only, relative to the package.
+		if !strings.Contains(file, "/") && !strings.Contains(file, "\\") {
+			dir := l.locator.goPackageDir(result)
+			if dir == "" {
+				return ""
+			}
+			file = path.Join(dir, file)
+		}
+	case LanguageJava:
+		// JVM traces carry no directory; only classes in the test's package
+		// tree are mapped, using the Maven and Gradle source layout.
+		var ok bool
+		if file, ok = javaSourcePath(frame.Function, frame.File, result); !ok {
+			return ""
+		}
+	}
+
+	resolved, _, ok := l.locator.finish(file, line)
+	if !ok {
+		return ""
+	}
+	return resolved
+}
+
+// javaSourcePath maps a JVM frame of the failing test's package tree to its
+// source file. Test classes live under src/test, everything else under src/main.
+func javaSourcePath(function, file string, result database.TestResult) (string, bool) {
+	class := function[:max(strings.LastIndex(function, "."), 0)]
+	testClass := result.TestName[:max(strings.LastIndex(result.TestName, "."), 0)]
+	if testClass == "" && result.SuiteName != nil {
+		testClass = *result.SuiteName
+	}
+	if !strings.Contains(testClass, ".") || javaPackageRoot(class) != javaPackageRoot(testClass) {
+		return "", false
+	}
+
+	// Nested and anonymous classes ("Outer$Inner") share the outer class's file.
+	class, _, _ = strings.Cut(class, "$")
+	dir := strings.ReplaceAll(class[:max(strings.LastIndex(class, "."), 0)], ".", "/")
+
+	lang := strings.TrimPrefix(path.Ext(file), ".")
+	root := "src/main/"
+	if class == testClass || strings.HasSuffix(class, "Test") || strings.HasSuffix(class, "Tests") || strings.HasSuffix(class, "IT") {
+		root = "src/test/"
+	}
+	return path.Join(root+lang, dir, file), true
+}
+
+// javaPackageRoot returns the first two segments of a package or class name,
+// e.g. "com.example" for "com.example.auth.AuthTest".
+func javaPackageRoot(name string) string {
+	parts := strings.SplitN(name, ".", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// repositoryWebURL derives the web URL of a repository from its clone URL,
+// e.g. "https://github.com/acme/shop" for "git@github.com:acme/shop.git".
+// It returns an empty string for URLs without a host.
+func repositoryWebURL(gitURL string) string {
+	gitURL = strings.TrimSuffix(strings.TrimSpace(gitURL), "/")
+	gitURL = strings.TrimSuffix(gitURL, ".git")
+
+	// scp-like syntax: git@host:owner/repo
+	if !strings.Contains(gitURL, "://") {
+		userHost, repoPath, ok := strings.Cut(gitURL, ":")
+		if !ok || repoPath == "" {
+			return ""
+		}
+		host := userHost[strings.LastIndex(userHost, "@")+1:]
+		return "https://" + host + "/" + strings.TrimPrefix(repoPath, "/")
+	}
+
+	u, err := url.Parse(gitURL)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	scheme := u.Scheme
+	if scheme != "http" {
+		// SSH and git URLs are served over HTTPS, without the SSH port.
+		scheme = "https"
+	}
+	host := u.Host
+	if u.Scheme != "http" && u.Scheme != "https" {
+		host = u.Hostname()
+	}
+	return scheme + "://" + host + u.Path
+}
+
+// providerForURL returns the configured provider of a service, or infers it
+// from the repository host.
+func providerForURL(configured *string, webURL string) string {
+	if configured != nil && *configured != "" {
+		return strings.ToLower(*configured)
+	}
+	switch {
+	case strings.Contains(webURL, "gitlab"):
+		return "gitlab"
+	case strings.Contains(webURL, "bitbucket"):
+		return "bitbucket"
+	default:
+		return "github"
+	}
+}
@@ -0,0 +1,163 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+func TestParseStackTrace(t *testing.T) {
+	tests := []struct {
+		name  string
+		trace string
+		want  []StackFrame
+	}{
+		{
+			name: "go panic",
+			trace: "panic: runtime error: invalid memory address\n\ngoroutine 7 [running]:\n" +
+				"github.com/acme/shop/auth.(*Service).Login(0x0, {0x1, 0x2})\n" +
+				"\t/work/run/auth/service.go:42 +0x1d\n" +
+				"testing.tRunner(0xc000102340, 0x6b8f10)\n" +
+				"\t/usr/local/go/src/testing/testing.go:1595 +0xff\n",
+			want: []StackFrame{
+				{Language: LanguageGo, Function: "github.com/acme/shop/auth.(*Service).Login", File: "/work/run/auth/service.go", Line: 42},
+				{Language: LanguageGo, Function: "testing.tRunner", File: "/usr/local/go/src/testing/testing.go", Line: 1595},
+			},
+		},
+		{
+			name:  "go test log",
+			trace: "=== RUN   TestLogin\n    auth_test.go:17: expected 200, got 500\n--- FAIL: TestLogin",
+			want: []StackFrame{
+				{Language: LanguageGo, File: "auth_test.go", Line: 17},
+			},
+		},
+		{
+			name: "java",
+			trace: "java.lang.AssertionError: expected 200\n" +
+				"\tat com.example.auth.AuthTest.testLogin(AuthTest.java:42)\n" +
+				"\tat java.base/jdk.internal.reflect.NativeMethodAccessorImpl.invoke0(Native Method)\n" +
+				"\tat com.example.auth.Session$Builder.build(Session.kt:7)\n",
+			want: []StackFrame{
+				{Language: LanguageJava, Function: "com.example.auth.AuthTest.testLogin", File: "AuthTest.java", Line: 42},
+				{Language: LanguageJava, Function: "com.example.auth.Session$Builder.build", File: "Session.kt", Line: 7},
+			},
+		},
+		{
+			name: "python",
+			trace: "Traceback (most recent call last):\n" +
+				"  File \"tests/test_auth.py\", line 12, in test_login\n" +
+				"    assert resp.status == 200\n" +
+				"AssertionError",
+			want: []StackFrame{
+				{Language: LanguagePython, Function: "test_login", File: "tests/test_auth.py", Line: 12},
+			},
+		},
+		{
+			name: "javascript",
+			trace: "Error: expected 200\n" +
+				"    at Object.<anonymous> (src/auth.test.ts:12:5)\n" +
+				"    at file:///work/run/src/auth.js:3:14\n" +
+				"    at async Promise.all (index 0)\n",
+			want: []StackFrame{
+				{Language: LanguageJavaScript, Function: "Object.<anonymous>", File: "src/auth.test.ts", Line: 12, Column: 5},
+				{Language: LanguageJavaScript, File: "/work/run/src/auth.js", Line: 3, Column: 14},
+			},
+		},
+		{
+			name:  "no frames",
+			trace: "expected 200, got 500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseStackTrace(tt.trace))
+		})
+	}
+}
+
+func TestSourceLinker(t *testing.T) {
+	runID := uuid.MustParse("6f1c2a9e-3d4b-4c5d-8e7f-0a1b2c3d4e5f")
+	sha := "abc123"
+	run := &database.TestRun{ID: runID, GitSHA: &sha}
+	service := &database.Service{GitURL: "git@github.com:acme/shop.git"}
+
+	t.Run("go frames in and outside the workspace", func(t *testing.T) {
+		linker := NewSourceLinker(service, run)
+		frames := linker.Link(database.TestResult{
+			TestName:  "TestLogin",
+			SuiteName: strPtr("github.com/acme/shop/auth"),
+			StackTrace: strPtr("github.com/acme/shop/auth.Login()\n" +
+				"\t/home/agent/work/" + runID.String() + "/auth/login.go:9 +0x1d\n" +
+				"testing.tRunner()\n\t/usr/local/go/src/testing/testing.go:1595 +0xff\n" +
+				"    login_test.go:21: boom"),
+		})
+		require.Len(t, frames, 3)
+		assert.Equal(t, "auth/login.go", frames[0].Path)
+		assert.Equal(t, "https://github.com/acme/shop/blob/abc123/auth/login.go#L9", frames[0].URL)
+		assert.Empty(t, frames[1].Path)
+		assert.Empty(t, frames[1].URL)
+		assert.Equal(t, "auth/login_test.go", frames[2].Path)
+	})
+
+	t.Run("java frames of the test's package tree", func(t *testing.T) {
+		linker := NewSourceLinker(service, run)
+		frames := linker.Link(database.TestResult{
+			TestName: "com.example.auth.AuthTest.testLogin",
+			StackTrace: strPtr("\tat com.example.auth.Session.open(Session.java:30)\n" +
+				"\tat com.example.auth.AuthTest.testLogin(AuthTest.java:42)\n" +
+				"\tat org.junit.runners.ParentRunner.run(ParentRunner.java:413)"),
+		})
+		require.Len(t, frames, 3)
+		assert.Equal(t, "src/main/java/com/example/auth/Session.java", frames[0].Path)
+		assert.Equal(t, "src/test/java/com/example/auth/AuthTest.java", frames[1].Path)
+		assert.Empty(t, frames[2].Path)
+	})
+
+	t.Run("falls back to the error message and skips dependencies", func(t *testing.T) {
+		linker := NewSourceLinker(service, run)
+		frames := linker.Link(database.TestResult{
+			TestName:     "logs in",
+			ErrorMessage: strPtr("Error: boom\n    at login (src/auth.ts:4:2)\n    at run (node_modules/jest/run.js:1:1)"),
+		})
+		require.Len(t, frames, 2)
+		assert.Equal(t, "src/auth.ts", frames[0].Path)
+		assert.Empty(t, frames[1].Path)
+	})
+
+	t.Run("no links without a commit", func(t *testing.T) {
+		linker := NewSourceLinker(service, &database.TestRun{ID: runID})
+		frames := linker.Link(database.TestResult{StackTrace: strPtr(`  File "tests/test_auth.py", line 12, in test_login`)})
+		require.Len(t, frames, 1)
+		assert.Equal(t, "tests/test_auth.py", frames[0].Path)
+		assert.Empty(t, frames[0].URL)
+	})
+}
+
+func TestSourceURL(t *testing.T) {
+	sha := "abc123"
+	run := &database.TestRun{ID: uuid.New(), GitSHA: &sha}
+	gitlab := "gitlab"
+
+	tests := []struct {
+		name    string
+		service *database.Service
+		want    string
+	}{
+		{"github ssh", &database.Service{GitURL: "git@github.com:acme/shop.git"}, "https://github.com/acme/shop/blob/abc123/src/my%20app.go#L7"},
+		{"gitlab https", &database.Service{GitURL: "https://gitlab.com/acme/team/shop.git"}, "https://gitlab.com/acme/team/shop/-/blob/abc123/src/my%20app.go#L7"},
+		{"bitbucket", &database.Service{GitURL: "https://bitbucket.org/acme/shop"}, "https://bitbucket.org/acme/shop/src/abc123/src/my%20app.go#lines-7"},
+		{"self-hosted with configured provider", &database.Service{GitURL: "ssh://git@git.example.com:2222/acme/shop.git", GitProvider: &gitlab}, "https://git.example.com/acme/shop/-/blob/abc123/src/my%20app.go#L7"},
+		{"no host", &database.Service{GitURL: "acme/shop"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewSourceLinker(tt.service, run).SourceURL("src/my app.go", 7))
+		})
+	}
+}
@@ -7,6 +7,10 @@ import (
 	"net/url"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/secrets"
 )
 
 // StatusState represents the state of a commit status.
@@ -22,16 +26,39 @@ const (
 
 // StatusReporter reports test run status to git providers.
 type StatusReporter struct {
-	providers map[string]Provider
-	logger    *slog.Logger
-	baseURL   string // Base URL for constructing target URLs
-	mu        sync.RWMutex
+	providers     map[string]Provider
+	logger        *slog.Logger
+	baseURL       string // Base URL for constructing target URLs
+	context       string
+	retryAttempts int
+	retryDelay    time.Duration
+	checkRuns     bool
+	mu            sync.RWMutex
+
+	credentialRepo   database.GitCredentialRepository
+	credentialCipher *secrets.Cipher
+	resultRepo       database.ResultRepository
+}
+
+// checkRunProvider is implemented by providers that support the GitHub
+// Checks API.
+type checkRunProvider interface {
+	CreateCompletedCheckRun(ctx context.Context, owner, repo, sha, name, conclusion, detailsURL string, output *CheckRunOutput) (*CheckRun, error)
 }
 
 // StatusReporterConfig holds configuration for the status reporter.
 type StatusReporterConfig struct {
 	BaseURL string
-	Logger  *slog.Logger
+	// Context is the status check name shown on the commit (default: conductor).
+	Context string
+	// RetryAttempts is how many times a run status report is attempted (default: 5).
+	RetryAttempts int
+	// RetryDelay is the initial delay between attempts, doubled each retry (default: 10s).
+	RetryDelay time.Duration
+	// CheckRuns reports runs as check runs with failure annotations on
+	// providers that support them, instead of plain commit statuses.
+	CheckRuns bool
+	Logger    *slog.Logger
 }
 
 // NewStatusReporter creates a new status reporter.
@@ -41,13 +68,45 @@ func NewStatusReporter(cfg StatusReporterConfig) *StatusReporter {
 		logger = slog.Default()
 	}
 
+	statusContext := cfg.Context
+	if statusContext == "" {
+		statusContext = "conductor"
+	}
+
+	retryAttempts := cfg.RetryAttempts
+	if retryAttempts <= 0 {
+		retryAttempts = 5
+	}
+
+	retryDelay := cfg.RetryDelay
+	if retryDelay <= 0 {
+		retryDelay = 10 * time.Second
+	}
+
 	return &StatusReporter{
-		providers: make(map[string]Provider),
-		logger:    logger.With("component", "status_reporter"),
-		baseURL:   strings.TrimSuffix(cfg.BaseURL, "/"),
+		providers:     make(map[string]Provider),
+		logger:        logger.With("component", "status_reporter"),
+		baseURL:       strings.TrimSuffix(cfg.BaseURL, "/"),
+		context:       statusContext,
+		retryAttempts: retryAttempts,
+		retryDelay:    retryDelay,
+		checkRuns:     cfg.CheckRuns,
 	}
 }
 
+// SetServiceCredentials enables per-service provider tokens. Services with a
+// stored credential report with their own token instead of the provider
+// registered for their host.
+func (s *StatusReporter) SetServiceCredentials(repo database.GitCredentialRepository, cipher *secrets.Cipher) {
+	s.credentialRepo = repo
+	s.credentialCipher = cipher
+}
+
+// SetResults provides the test results used to annotate check runs.
+func (s *StatusReporter) SetResults(repo database.ResultRepository) {
+	s.resultRepo = repo
+}
+
 // RegisterProvider registers a provider for a specific git host.
 func (s *StatusReporter) RegisterProvider(host string, provider Provider) {
 	s.mu.Lock()
@@ -95,15 +154,10 @@ func (s *StatusReporter) report(ctx context.Context, gitURL, sha, runID string,
 	// Map internal state to provider state
 	providerState := mapState(state)
 
-	// Truncate description if too long (GitHub has 140 char limit)
-	if len(description) > 140 {
-		description = description[:137] + "..."
-	}
-
 	status := CommitStatus{
 		State:       providerState,
-		Context:     "conductor",
-		Description: description,
+		Context:     s.context,
+		Description: truncateDescription(description),
 		TargetURL:   s.buildTargetURL(runID),
 	}
 
@@ -128,6 +182,244 @@ func (s *StatusReporter) report(ctx context.Context, gitURL, sha, runID string,
 	return nil
 }
 
+// ReportRun reports the outcome of a finished run on its commit. Only runs
+// triggered by a pull/merge request are reported. When check runs are
+// enabled and the provider supports them, failed tests are annotated on the
+// pull request. Transient provider failures are retried with exponential
+// backoff.
+func (s *StatusReporter) ReportRun(ctx context.Context, service *database.Service, run *database.TestRun) error {
+	if run.PullRequestNumber == nil || run.GitSHA == nil || *run.GitSHA == "" {
+		return nil
+	}
+
+	state, ok := runStatusState(run.Status)
+	if !ok {
+		return nil
+	}
+
+	owner, repo, err := parseRepositoryURL(service.GitURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse git URL: %w", err)
+	}
+
+	provider, err := s.providerForService(ctx, service)
+	if err != nil {
+		return err
+	}
+
+	sha := *run.GitSHA
+	if checks, ok := provider.(checkRunProvider); ok && s.checkRuns && s.resultRepo != nil {
+		err := s.reportCheckRun(ctx, checks, owner, repo, sha, run)
+		if err == nil {
+			return nil
+		}
+		// Check runs need GitHub App credentials; keep reporting the
+		// outcome as a commit status when they are rejected.
+		s.logger.Warn("check run report failed, falling back to commit status",
+			"run_id", run.ID,
+			"error", err,
+		)
+	}
+
+	status := CommitStatus{
+		State:       mapState(state),
+		Context:     s.context,
+		Description: truncateDescription(RunStatusDescription(run)),
+		TargetURL:   s.buildTargetURL(run.ID.String()),
+	}
+
+	err = s.withRetry(ctx, run, func() error {
+		return provider.CreateCommitStatus(ctx, owner, repo, sha, status)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to report run status: %w", err)
+	}
+
+	s.logger.Info("reported run status",
+		"run_id", run.ID,
+		"owner", owner,
+		"repo", repo,
+		"sha", sha,
+		"pull_request", *run.PullRequestNumber,
+		"state", status.State,
+	)
+
+	return nil
+}
+
+// reportCheckRun reports a finished run as a completed check run whose
+// output summarizes the failed tests and annotates their source locations.
+func (s *StatusReporter) reportCheckRun(ctx context.Context, provider checkRunProvider, owner, repo, sha string, run *database.TestRun) error {
+	var failures []database.TestResult
+	for _, status := range []database.ResultStatus{database.ResultStatusFail, database.ResultStatusError} {
+		results, err := s.resultRepo.ListByRunAndStatus(ctx, run.ID, status)
+		if err != nil {
+			return fmt.Errorf("failed to list %s results: %w", status, err)
+		}
+		failures = append(failures, results...)
+	}
+
+	output := BuildCheckRunOutput(run, failures, owner+"/"+repo)
+	conclusion := checkRunConclusion(run.Status)
+
+	var checkRun *CheckRun
+	err := s.withRetry(ctx, run, func() error {
+		var err error
+		checkRun, err = provider.CreateCompletedCheckRun(ctx, owner, repo, sha, s.context, conclusion, s.buildTargetURL(run.ID.String()), output)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to report check run: %w", err)
+	}
+
+	s.logger.Info("reported run check run",
+		"run_id", run.ID,
+		"owner", owner,
+		"repo", repo,
+		"sha", sha,
+		"pull_request", *run.PullRequestNumber,
+		"conclusion", conclusion,
+		"annotations", len(output.Annotations),
+		"check_run_id", checkRun.ID,
+	)
+	return nil
+}
+
+// withRetry calls fn until it succeeds, retrying transient failures with
+// exponential backoff up to the configured number of attempts.
+func (s *StatusReporter) withRetry(ctx context.Context, run *database.TestRun, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if attempt >= s.retryAttempts || !isTransientReportError(err) {
+			return fmt.Errorf("gave up after %d attempt(s): %w", attempt, err)
+		}
+
+		delay := s.retryDelay * time.Duration(1<<(attempt-1))
+		s.logger.Warn("status report failed, retrying",
+			"run_id", run.ID,
+			"attempt", attempt,
+			"delay", delay,
+			"error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// checkRunConclusion maps a terminal run status to a check run conclusion.
+func checkRunConclusion(status database.RunStatus) string {
+	switch status {
+	case database.RunStatusPassed:
+		return "success"
+	case database.RunStatusTimeout:
+		return "timed_out"
+	case database.RunStatusCancelled:
+		return "cancelled"
+	default:
+		return "failure"
+	}
+}
+
+// RunStatusDescription summarizes a finished run for a commit status, e.g.
+// "42 passed, 1 failed, 3 skipped in 2m5s".
+func RunStatusDescription(run *database.TestRun) string {
+	switch run.Status {
+	case database.RunStatusTimeout:
+		return "Test run timed out"
+	case database.RunStatusCancelled:
+		return "Test run was cancelled"
+	case database.RunStatusError:
+		if run.ErrorMessage != nil && *run.ErrorMessage != "" {
+			return "Test run errored: " + *run.ErrorMessage
+		}
+		return "Test run errored"
+	}
+
+	description := fmt.Sprintf("%d passed, %d failed, %d skipped",
+		run.PassedTests, run.FailedTests, run.SkippedTests)
+	if run.DurationMs != nil {
+		description += " in " + (time.Duration(*run.DurationMs) * time.Millisecond).Round(time.Second).String()
+	}
+	return description
+}
+
+// runStatusState maps a terminal run status to a status state.
+func runStatusState(status database.RunStatus) (StatusState, bool) {
+	switch status {
+	case database.RunStatusPassed:
+		return StatusStateSuccess, true
+	case database.RunStatusFailed:
+		return StatusStateFailure, true
+	case database.RunStatusError, database.RunStatusTimeout, database.RunStatusCancelled:
+		return StatusStateError, true
+	default:
+		return "", false
+	}
+}
+
+// providerForService returns the provider to report a service's statuses
+// with, preferring the service's own credential over the shared provider.
+func (s *StatusReporter) providerForService(ctx context.Context, service *database.Service) (Provider, error) {
+	provider, err := credentialProvider(ctx, s.credentialRepo, s.credentialCipher, service)
+	if err != nil || provider != nil {
+		return provider, err
+	}
+
+	provider, err = s.getProviderForURL(service.GitURL)
+	if err != nil {
+		return nil, fmt.Errorf("no provider for URL: %w", err)
+	}
+	return provider, nil
+}
+
+// credentialProvider returns a provider using the service's own stored
+// credential, or nil if the service has none or credentials are disabled.
+func credentialProvider(ctx context.Context, repo database.GitCredentialRepository, cipher *secrets.Cipher, service *database.Service) (Provider, error) {
+	if repo == nil || cipher == nil {
+		return nil, nil
+	}
+
+	cred, err := repo.GetByService(ctx, service.ID)
+	if err != nil && !database.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get git credential: %w", err)
+	}
+	if cred == nil {
+		return nil, nil
+	}
+
+	token, err := cipher.Decrypt(cred.EncryptedToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt git credential for service %s: %w", service.ID, err)
+	}
+
+	cfg := Config{Provider: cred.Provider, Token: string(token)}
+	if cred.BaseURL != nil {
+		cfg.BaseURL = *cred.BaseURL
+	}
+	return NewProvider(cfg)
+}
+
+// isTransientReportError reports whether a failed status report may succeed
+// later. Client errors such as bad credentials or unknown commits are final.
+func isTransientReportError(err error) bool {
+	return isRetryableError(err) || strings.Contains(err.Error(), "max retries exceeded")
+}
+
+// truncateDescription shortens a description to GitHub's 140 character limit.
+func truncateDescription(description string) string {
+	if len(description) > 140 {
+		return description[:137] + "..."
+	}
+	return description
+}
+
 // ReportWithCheckRun creates/updates a GitHub check run (more detailed than commit status).
 func (s *StatusReporter) ReportWithCheckRun(ctx context.Context, gitURL, sha, runID, name string, state StatusState, summary, details string) error {
 	owner, repo, err := parseRepositoryURL(gitURL)
@@ -0,0 +1,255 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+type fakeStatusProvider struct {
+	Provider
+
+	errs     []error
+	statuses []CommitStatus
+	shas     []string
+}
+
+func (p *fakeStatusProvider) CreateCommitStatus(ctx context.Context, owner, repo, sha string, status CommitStatus) error {
+	p.shas = append(p.shas, sha)
+	p.statuses = append(p.statuses, status)
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		return err
+	}
+	return nil
+}
+
+type fakeCheckRunProvider struct {
+	fakeStatusProvider
+
+	err        error
+	conclusion string
+	detailsURL string
+	output     *CheckRunOutput
+}
+
+func (p *fakeCheckRunProvider) CreateCompletedCheckRun(ctx context.Context, owner, repo, sha, name, conclusion, detailsURL string, output *CheckRunOutput) (*CheckRun, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.conclusion = conclusion
+	p.detailsURL = detailsURL
+	p.output = output
+	return &CheckRun{ID: 1, Name: name, HeadSHA: sha, Status: "completed", Conclusion: conclusion}, nil
+}
+
+type fakeResultRepo struct {
+	database.ResultRepository
+
+	results []database.TestResult
+}
+
+func (r *fakeResultRepo) ListByRunAndStatus(ctx context.Context, runID uuid.UUID, status database.ResultStatus) ([]database.TestResult, error) {
+	var matched []database.TestResult
+	for _, result := range r.results {
+		if result.Status == status {
+			matched = append(matched, result)
+		}
+	}
+	return matched, nil
+}
+
+func newTestStatusReporter(provider Provider) *StatusReporter {
+	s := NewStatusReporter(StatusReporterConfig{
+		BaseURL:       "https://conductor.example.com/ui/#",
+		RetryAttempts: 3,
+		RetryDelay:    time.Millisecond,
+		Logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	s.RegisterProvider("github", provider)
+	return s
+}
+
+func newPullRequestRun(status database.RunStatus) *database.TestRun {
+	sha := "abc123"
+	pr := int64(7)
+	duration := int64(125000)
+	return &database.TestRun{
+		ID:                uuid.New(),
+		Status:            status,
+		GitSHA:            &sha,
+		PullRequestNumber: &pr,
+		PassedTests:       42,
+		FailedTests:       1,
+		SkippedTests:      3,
+		DurationMs:        &duration,
+	}
+}
+
+func TestReportRun(t *testing.T) {
+	service := &database.Service{ID: uuid.New(), GitURL: "https://github.com/owner/repo"}
+
+	t.Run("reports failed pull request run", func(t *testing.T) {
+		provider := &fakeStatusProvider{}
+		run := newPullRequestRun(database.RunStatusFailed)
+
+		err := newTestStatusReporter(provider).ReportRun(context.Background(), service, run)
+		require.NoError(t, err)
+
+		require.Len(t, provider.statuses, 1)
+		assert.Equal(t, "abc123", provider.shas[0])
+		assert.Equal(t, CommitStatus{
+			State:       "failure",
+			Context:     "conductor",
+			Description: "42 passed, 1 failed, 3 skipped in 2m5s",
+			TargetURL:   "https://conductor.example.com/ui/#/runs/" + run.ID.String(),
+		}, provider.statuses[0])
+	})
+
+	t.Run("skips runs without a pull request", func(t *testing.T) {
+		provider := &fakeStatusProvider{}
+		run := newPullRequestRun(database.RunStatusPassed)
+		run.PullRequestNumber = nil
+
+		err := newTestStatusReporter(provider).ReportRun(context.Background(), service, run)
+		require.NoError(t, err)
+		assert.Empty(t, provider.statuses)
+	})
+
+	t.Run("skips unfinished runs", func(t *testing.T) {
+		provider := &fakeStatusProvider{}
+
+		err := newTestStatusReporter(provider).ReportRun(context.Background(), service, newPullRequestRun(database.RunStatusRunning))
+		require.NoError(t, err)
+		assert.Empty(t, provider.statuses)
+	})
+
+	t.Run("retries transient failures", func(t *testing.T) {
+		provider := &fakeStatusProvider{errs: []error{errors.New("connection reset by peer")}}
+
+		err := newTestStatusReporter(provider).ReportRun(context.Background(), service, newPullRequestRun(database.RunStatusPassed))
+		require.NoError(t, err)
+		require.Len(t, provider.statuses, 2)
+		assert.Equal(t, "success", provider.statuses[1].State)
+	})
+
+	t.Run("gives up after retry attempts", func(t *testing.T) {
+		transient := errors.New("request timeout")
+		provider := &fakeStatusProvider{errs: []error{transient, transient, transient, transient}}
+
+		err := newTestStatusReporter(provider).ReportRun(context.Background(), service, newPullRequestRun(database.RunStatusPassed))
+		require.Error(t, err)
+		assert.Len(t, provider.statuses, 3)
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		provider := &fakeStatusProvider{errs: []error{errors.New("GitHub API error (status 422): No commit found")}}
+
+		err := newTestStatusReporter(provider).ReportRun(context.Background(), service, newPullRequestRun(database.RunStatusPassed))
+		require.Error(t, err)
+		assert.Len(t, provider.statuses, 1)
+	})
+}
+
+func TestReportRun_CheckRuns(t *testing.T) {
+	service := &database.Service{ID: uuid.New(), GitURL: "https://github.com/owner/repo"}
+	results := &fakeResultRepo{results: []database.TestResult{
+		{TestName: "test_ok", Status: database.ResultStatusPass},
+		{
+			TestName:     "test_login",
+			Status:       database.ResultStatusFail,
+			ErrorMessage: strPtr("AssertionError"),
+			StackTrace:   strPtr("  File \"tests/test_auth.py\", line 12, in test_login"),
+		},
+	}}
+
+	newReporter := func(provider Provider) *StatusReporter {
+		s := NewStatusReporter(StatusReporterConfig{
+			BaseURL:       "https://conductor.example.com/ui/#",
+			RetryAttempts: 1,
+			CheckRuns:     true,
+			Logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+		})
+		s.RegisterProvider("github", provider)
+		s.SetResults(results)
+		return s
+	}
+
+	t.Run("creates annotated check run", func(t *testing.T) {
+		provider := &fakeCheckRunProvider{}
+		run := newPullRequestRun(database.RunStatusFailed)
+
+		err := newReporter(provider).ReportRun(context.Background(), service, run)
+		require.NoError(t, err)
+
+		assert.Empty(t, provider.statuses)
+		assert.Equal(t, "failure", provider.conclusion)
+		assert.Equal(t, "https://conductor.example.com/ui/#/runs/"+run.ID.String(), provider.detailsURL)
+		require.NotNil(t, provider.output)
+		require.Len(t, provider.output.Annotations, 1)
+		assert.Equal(t, "tests/test_auth.py", provider.output.Annotations[0].Path)
+	})
+
+	t.Run("falls back to commit status", func(t *testing.T) {
+		provider := &fakeCheckRunProvider{err: errors.New("GitHub API error (status 403): Resource not accessible by integration")}
+
+		err := newReporter(provider).ReportRun(context.Background(), service, newPullRequestRun(database.RunStatusFailed))
+		require.NoError(t, err)
+		require.Len(t, provider.statuses, 1)
+		assert.Equal(t, "failure", provider.statuses[0].State)
+	})
+
+	t.Run("maps timeouts to timed out", func(t *testing.T) {
+		provider := &fakeCheckRunProvider{}
+
+		err := newReporter(provider).ReportRun(context.Background(), service, newPullRequestRun(database.RunStatusTimeout))
+		require.NoError(t, err)
+		assert.Equal(t, "timed_out", provider.conclusion)
+	})
+}
+
+func TestRunStatusDescription(t *testing.T) {
+	message := "agent lost"
+	tests := []struct {
+		name string
+		run  *database.TestRun
+		want string
+	}{
+		{
+			name: "passed without duration",
+			run:  &database.TestRun{Status: database.RunStatusPassed, PassedTests: 10},
+			want: "10 passed, 0 failed, 0 skipped",
+		},
+		{
+			name: "timed out",
+			run:  &database.TestRun{Status: database.RunStatusTimeout},
+			want: "Test run timed out",
+		},
+		{
+			name: "cancelled",
+			run:  &database.TestRun{Status: database.RunStatusCancelled},
+			want: "Test run was cancelled",
+		},
+		{
+			name: "errored with message",
+			run:  &database.TestRun{Status: database.RunStatusError, ErrorMessage: &message},
+			want: "Test run errored: agent lost",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RunStatusDescription(tt.run))
+		})
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"path"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,6 +13,8 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/placement"
+	"github.com/conductor/conductor/internal/websocket"
 )
 
 // SyncResult contains the results of a git sync operation.
@@ -20,6 +24,18 @@ type SyncResult struct {
 	TestsRemoved int
 	Errors       []string
 	SyncedAt     time.Time
+
+	// Added, Updated and Removed name the tests of each change. Removed
+	// tests are no longer declared by the configuration but are kept.
+	Added   []string
+	Updated []string
+	Removed []string
+}
+
+// SyncEventPublisher publishes the change set of each sync.
+// websocket.Publisher implements it.
+type SyncEventPublisher interface {
+	PublishServiceSync(sync websocket.ServiceSyncEvent) error
 }
 
 const (
@@ -27,15 +43,23 @@ const (
 	ConfigFileName = ".conductor.yaml"
 	// AlternateConfigFileName is an alternate config file name.
 	AlternateConfigFileName = ".conductor.yml"
+	// ConfigFileNameVisible is the config file name without the leading dot.
+	ConfigFileNameVisible = "conductor.yaml"
+	// AlternateConfigFileNameVisible is the alternate name without the leading dot.
+	AlternateConfigFileNameVisible = "conductor.yml"
+	// ConfigDirName is the directory whose YAML files each add test definitions.
+	ConfigDirName = ".conductor"
 	// DefaultTimeout is the default test timeout if not specified.
 	DefaultTimeout = "30m"
 )
 
 // Syncer handles synchronization of test definitions from git repositories.
 type Syncer struct {
-	provider Provider
-	testRepo database.TestDefinitionRepository
-	logger   *slog.Logger
+	provider     Provider
+	testRepo     database.TestDefinitionRepository
+	environments bool
+	events       SyncEventPublisher
+	logger       *slog.Logger
 }
 
 // NewSyncer creates a new git syncer.
@@ -50,6 +74,17 @@ func NewSyncer(provider Provider, testRepo database.TestDefinitionRepository, lo
 	}
 }
 
+// EnableEnvironments enables syncing the service environment set declared
+// by the top-level env and secrets of the configuration file.
+func (s *Syncer) EnableEnvironments() {
+	s.environments = true
+}
+
+// SetEvents enables publishing the change set of each sync.
+func (s *Syncer) SetEvents(events SyncEventPublisher) {
+	s.events = events
+}
+
 // SyncService synchronizes test definitions from a service's git repository.
 // It implements the server.GitSyncer interface.
 func (s *Syncer) SyncService(ctx context.Context, service *database.Service, branch string) (*SyncResult, error) {
@@ -83,8 +118,8 @@ func (s *Syncer) SyncService(ctx context.Context, service *database.Service, bra
 		}
 	}
 
-	// Try to load configuration file
-	config, configPath, err := s.loadConfig(ctx, owner, repo, branch)
+	// Load the root configuration file and the files of the config directory
+	files, err := s.loadConfigs(ctx, owner, repo, branch, serviceRootPath(service))
 	if err != nil {
 		s.logger.Warn("failed to load config file",
 			"error", err,
@@ -95,10 +130,49 @@ func (s *Syncer) SyncService(ctx context.Context, service *database.Service, bra
 		return result, nil // Return without error - service can still work without config
 	}
 
-	s.logger.Debug("loaded configuration",
-		"config_path", configPath,
-		"test_count", len(config.Tests),
-	)
+	var config *TestConfig
+	for _, file := range files {
+		if file.err != nil {
+			result.Errors = append(result.Errors, file.err.Error())
+			continue
+		}
+		s.logger.Debug("loaded configuration",
+			"config_path", file.path,
+			"test_count", len(file.config.Tests),
+		)
+		if file.root {
+			config = file.config
+		} else if file.config.Env != nil || file.config.Secrets != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: env and secrets are only read from the root config file", file.path))
+		}
+		if !file.root && file.config.Concurrency != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: concurrency is only read from the root config file", file.path))
+		}
+	}
+
+	changes := &database.TestDefinitionSync{}
+	var added, updated []string
+
+	// The configuration manages the service environment only when it declares one
+	if s.environments && config != nil && (config.Env != nil || config.Secrets != nil) {
+		env, err := environmentFromConfig(service.ID, config)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("invalid environment: %v", err))
+		} else {
+			changes.Environment = env
+		}
+	}
+
+	// The root config file manages the concurrency group; one without a
+	// group removes the service's
+	if config != nil {
+		concurrency, err := concurrencyFromConfig(service.ID, config)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("invalid concurrency: %v", err))
+		} else {
+			changes.Concurrency = concurrency
+		}
+	}
 
 	// Get existing test definitions
 	existingTests, err := s.testRepo.ListByService(ctx, service.ID, database.Pagination{Limit: 1000})
@@ -113,54 +187,86 @@ func (s *Syncer) SyncService(ctx context.Context, service *database.Service, bra
 		existingByName[t.Name] = t
 	}
 
-	// Track which tests are in config
+	// Track which tests are in config, and the file declaring each
 	configTestNames := make(map[string]bool)
+	declaredIn := make(map[string]string)
 
-	// Process each test from config
-	for _, testCfg := range config.Tests {
-		configTestNames[testCfg.Name] = true
-
-		test, err := s.configToTestDefinition(testCfg, service.ID, configPath, branch)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("invalid test config '%s': %v", testCfg.Name, err))
+	// Compute the changes of each test from config; invalid tests are
+	// reported and skipped
+	for _, file := range files {
+		if file.err != nil {
 			continue
 		}
+		for _, testCfg := range file.config.Tests {
+			configTestNames[testCfg.Name] = true
 
-		if existing, ok := existingByName[testCfg.Name]; ok {
-			// Update existing test
-			test.ID = existing.ID
-			test.CreatedAt = existing.CreatedAt
-			test.UpdatedAt = time.Now().UTC()
-
-			if err := s.testRepo.Update(ctx, test); err != nil {
-				result.Errors = append(result.Errors, fmt.Sprintf("failed to update test '%s': %v", testCfg.Name, err))
+			if prev, ok := declaredIn[testCfg.Name]; ok && testCfg.Name != "" {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: duplicate test '%s' (already defined in %s)", file.path, testCfg.Name, prev))
 				continue
 			}
-			result.TestsUpdated++
-		} else {
-			// Create new test
-			test.ID = uuid.New()
-			test.CreatedAt = time.Now().UTC()
-			test.UpdatedAt = time.Now().UTC()
+			declaredIn[testCfg.Name] = file.path
 
-			if err := s.testRepo.Create(ctx, test); err != nil {
-				result.Errors = append(result.Errors, fmt.Sprintf("failed to create test '%s': %v", testCfg.Name, err))
+			test, err := s.configToTestDefinition(testCfg, service.ID, file.path, branch)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: invalid test config '%s': %v", file.path, testCfg.Name, err))
 				continue
 			}
-			result.TestsAdded++
+
+			now := time.Now().UTC()
+			if existing, ok := existingByName[testCfg.Name]; ok {
+				test.ID = existing.ID
+				test.CreatedAt = existing.CreatedAt
+				test.UpdatedAt = now
+				changes.Update = append(changes.Update, test)
+				updated = append(updated, test.Name)
+			} else {
+				test.ID = uuid.New()
+				test.CreatedAt = now
+				test.UpdatedAt = now
+				changes.Create = append(changes.Create, test)
+				added = append(added, test.Name)
+			}
 		}
 	}
 
-	// Optionally mark tests not in config as disabled (using AllowFailure as a proxy)
-	// Note: The actual database model doesn't have a Disabled field,
-	// so we can't disable tests - just track what's been updated
+	// Runs ignore dependencies on unknown tests and cycles, so they are
+	// reported without rejecting the tests
+	var configured []database.TestDefinition
+	for _, test := range append(changes.Create, changes.Update...) {
+		configured = append(configured, *test)
+	}
+	if err := database.ValidateTestDependencies(configured); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("invalid depends_on: %v", err))
+	}
+
+	// Tests removed from config remain in the database but are no longer
+	// updated. The model has no disabled state to record them with.
 	for name := range existingByName {
 		if !configTestNames[name] {
-			// Test was removed from config - it remains in DB but won't be updated
-			result.TestsRemoved++
+			result.Removed = append(result.Removed, name)
+		}
+	}
+	sort.Strings(result.Removed)
+	result.TestsRemoved = len(result.Removed)
+
+	// Apply every change in one transaction, so that a failure part way
+	// through leaves the previous definitions in place
+	if len(changes.Create) > 0 || len(changes.Update) > 0 || changes.Environment != nil || changes.Concurrency != nil {
+		if err := s.testRepo.ApplySync(ctx, changes); err != nil {
+			s.logger.Error("failed to apply sync",
+				"service_id", service.ID,
+				"error", err,
+			)
+			result.Errors = append(result.Errors, fmt.Sprintf("failed to apply sync: %v", err))
+			s.publish(service.ID, branch, result, added, updated, false)
+			return result, fmt.Errorf("failed to apply sync: %w", err)
 		}
 	}
 
+	result.Added, result.TestsAdded = added, len(added)
+	result.Updated, result.TestsUpdated = updated, len(updated)
+	s.publish(service.ID, branch, result, added, updated, true)
+
 	s.logger.Info("sync completed",
 		"service_id", service.ID,
 		"added", result.TestsAdded,
@@ -172,29 +278,118 @@ func (s *Syncer) SyncService(ctx context.Context, service *database.Service, bra
 	return result, nil
 }
 
-// loadConfig attempts to load the conductor configuration file from the repository.
-func (s *Syncer) loadConfig(ctx context.Context, owner, repo, ref string) (*TestConfig, string, error) {
-	// Try primary config file name
-	content, err := s.provider.GetFile(ctx, owner, repo, ConfigFileName, ref)
-	if err == nil {
-		var config TestConfig
-		if err := yaml.Unmarshal(content, &config); err != nil {
-			return nil, ConfigFileName, fmt.Errorf("failed to parse %s: %w", ConfigFileName, err)
+// publish emits the change set of a sync, if events are enabled. Added and
+// updated are the computed changes, which are not applied when applied is
+// false.
+func (s *Syncer) publish(serviceID uuid.UUID, branch string, result *SyncResult, added, updated []string, applied bool) {
+	if s.events == nil {
+		return
+	}
+	err := s.events.PublishServiceSync(websocket.ServiceSyncEvent{
+		ServiceID: serviceID,
+		Branch:    branch,
+		Added:     added,
+		Updated:   updated,
+		Removed:   result.Removed,
+		Errors:    result.Errors,
+		Applied:   applied,
+		SyncedAt:  result.SyncedAt,
+	})
+	if err != nil {
+		s.logger.Warn("failed to publish sync event", "service_id", serviceID, "error", err)
+	}
+}
+
+// environmentFromConfig returns the service environment set declared in config.
+func environmentFromConfig(serviceID uuid.UUID, config *TestConfig) (*database.ServiceEnvironment, error) {
+	refs, err := secretRefsFromConfig(config.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &database.ServiceEnvironment{
+		ServiceID:   serviceID,
+		Environment: config.Env,
+		Secrets:     refs,
+	}, nil
+}
+
+// configFile is a configuration file read from the repository. err is set
+// when the file exists but cannot be parsed.
+type configFile struct {
+	path   string
+	root   bool
+	config *TestConfig
+	err    error
+}
+
+// loadConfigs loads the root configuration file and every YAML file of the
+// config directory, in that order. Paths are relative to root, the service
+// root path of a monorepo. Files that fail to parse are returned with their
+// error so that the others still sync; an error is only returned when no
+// configuration exists at all.
+func (s *Syncer) loadConfigs(ctx context.Context, owner, repo, ref, root string) ([]configFile, error) {
+	var files []configFile
+
+	for _, name := range []string{ConfigFileName, AlternateConfigFileName, ConfigFileNameVisible, AlternateConfigFileNameVisible} {
+		p := path.Join(root, name)
+		content, err := s.provider.GetFile(ctx, owner, repo, p, ref)
+		if err != nil {
+			continue
 		}
-		return &config, ConfigFileName, nil
+		files = append(files, parseConfigFile(p, content, true))
+		break
 	}
 
-	// Try alternate config file name
-	content, err = s.provider.GetFile(ctx, owner, repo, AlternateConfigFileName, ref)
-	if err == nil {
-		var config TestConfig
-		if err := yaml.Unmarshal(content, &config); err != nil {
-			return nil, AlternateConfigFileName, fmt.Errorf("failed to parse %s: %w", AlternateConfigFileName, err)
+	dir := path.Join(root, ConfigDirName)
+	entries, err := s.provider.ListFiles(ctx, owner, repo, dir, ref)
+	if err != nil {
+		s.logger.Debug("no config directory", "path", dir, "error", err)
+		entries = nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	for _, entry := range entries {
+		if entry.Type == "dir" || !isYAMLFile(entry.Name) {
+			continue
 		}
-		return &config, AlternateConfigFileName, nil
+		p := path.Join(dir, entry.Name)
+		content, err := s.provider.GetFile(ctx, owner, repo, p, ref)
+		if err != nil {
+			files = append(files, configFile{path: p, err: fmt.Errorf("failed to read %s: %w", p, err)})
+			continue
+		}
+		files = append(files, parseConfigFile(p, content, false))
 	}
 
-	return nil, "", fmt.Errorf("config file not found (tried %s and %s)", ConfigFileName, AlternateConfigFileName)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("config file not found (tried %s, %s, %s, %s and %s/)",
+			ConfigFileName, AlternateConfigFileName, ConfigFileNameVisible, AlternateConfigFileNameVisible, ConfigDirName)
+	}
+	return files, nil
+}
+
+// parseConfigFile unmarshals a configuration file.
+func parseConfigFile(p string, content []byte, root bool) configFile {
+	var config TestConfig
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return configFile{path: p, root: root, err: fmt.Errorf("failed to parse %s: %w", p, err)}
+	}
+	return configFile{path: p, root: root, config: &config}
+}
+
+// isYAMLFile reports whether name has a YAML extension.
+func isYAMLFile(name string) bool {
+	ext := path.Ext(name)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// serviceRootPath returns the directory of a monorepo service that its
+// configuration is read from, or "" for the repository root.
+func serviceRootPath(service *database.Service) string {
+	if service.RootPath == nil {
+		return ""
+	}
+	return strings.Trim(*service.RootPath, "/")
 }
 
 // configToTestDefinition converts a TestSuiteConfig to a database.TestDefinition.
@@ -215,6 +410,17 @@ func (s *Syncer) configToTestDefinition(cfg TestSuiteConfig, serviceID uuid.UUID
 	if err != nil {
 		return nil, fmt.Errorf("invalid timeout: %w", err)
 	}
+	if timeoutDuration < time.Second {
+		return nil, fmt.Errorf("invalid timeout: %s (must be at least 1s)", timeout)
+	}
+	if cfg.MaxRetries < 0 {
+		return nil, fmt.Errorf("invalid max_retries: %d (must not be negative)", cfg.MaxRetries)
+	}
+	for _, pattern := range cfg.ArtifactPaths {
+		if strings.TrimSpace(pattern) == "" {
+			return nil, fmt.Errorf("artifact_paths must not contain empty patterns")
+		}
+	}
 
 	// Determine execution mode
 	execType := cfg.ExecutionMode
@@ -225,11 +431,36 @@ func (s *Syncer) configToTestDefinition(cfg TestSuiteConfig, serviceID uuid.UUID
 		return nil, fmt.Errorf("invalid execution_mode: %s (must be subprocess or container)", execType)
 	}
 
-	// Container mode requires docker image (note: we store this info elsewhere if needed)
+	// Container mode requires docker image
 	if execType == "container" && cfg.DockerImage == "" {
 		return nil, fmt.Errorf("docker_image is required for container execution mode")
 	}
 
+	secrets, err := secretRefsFromConfig(cfg.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	archs, err := placement.NormalizeArchitectures(cfg.Architectures)
+	if err != nil {
+		return nil, fmt.Errorf("invalid architectures: %w", err)
+	}
+
+	maxBytes, maxFiles, err := artifactBudgetFromConfig(cfg.ArtifactBudget)
+	if err != nil {
+		return nil, err
+	}
+
+	matrix, err := matrixFromConfig(cfg.Matrix)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := parametersFromConfig(cfg.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
 	test := &database.TestDefinition{
 		ServiceID:        serviceID,
 		Name:             cfg.Name,
@@ -244,12 +475,123 @@ func (s *Syncer) configToTestDefinition(cfg TestSuiteConfig, serviceID uuid.UUID
 		Retries:          cfg.MaxRetries,
 		AllowFailure:     cfg.Disabled, // Use AllowFailure to indicate disabled tests
 		ArtifactPatterns: cfg.ArtifactPaths,
-		DependsOn:        nil, // Could be derived from config if needed
+		DependsOn:        cfg.DependsOn,
+		RequiresApproval: cfg.RequiresApproval,
+		CapturePatterns:  cfg.CapturePaths,
+		Environment:      cfg.Env,
+		Secrets:          secrets,
+		LabelSelector:    cfg.RequiredLabels,
+		Architectures:    archs,
+		ArtifactMaxBytes: maxBytes,
+		ArtifactMaxFiles: maxFiles,
+		Owner:            database.NullString(cfg.Owner),
+		ContainerImage:   database.NullString(cfg.DockerImage),
+		Matrix:           matrix,
+		Parameters:       params,
 	}
 
 	return test, nil
 }
 
+// matrixFromConfig validates a test matrix from the configuration file. A
+// matrix without axes is returned as nil.
+func matrixFromConfig(cfg *MatrixConfig) (*database.TestMatrix, error) {
+	if cfg == nil || len(cfg.Env) == 0 && len(cfg.Labels) == 0 {
+		return nil, nil
+	}
+
+	matrix := &database.TestMatrix{Env: cfg.Env, Labels: cfg.Labels}
+	if err := matrix.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid matrix: %w", err)
+	}
+	return matrix, nil
+}
+
+// parametersFromConfig validates the parameters of a test from the
+// configuration file.
+func parametersFromConfig(cfg []ParameterConfig) (database.TestParameters, error) {
+	if len(cfg) == 0 {
+		return nil, nil
+	}
+
+	params := make(database.TestParameters, len(cfg))
+	for i, param := range cfg {
+		params[i] = database.TestParameter{
+			Name:        param.Name,
+			Description: param.Description,
+			Type:        database.TestParameterType(param.Type),
+			Required:    param.Required,
+			Default:     param.Default,
+			Choices:     param.Choices,
+		}
+	}
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	return params, nil
+}
+
+// artifactBudgetFromConfig validates an artifact budget from the
+// configuration file. Unset and zero limits are returned as nil.
+func artifactBudgetFromConfig(cfg *ArtifactBudgetConfig) (*int64, *int, error) {
+	if cfg == nil {
+		return nil, nil, nil
+	}
+	if cfg.MaxBytes < 0 {
+		return nil, nil, fmt.Errorf("invalid artifact_budget.max_bytes: %d (must not be negative)", cfg.MaxBytes)
+	}
+	if cfg.MaxFiles < 0 {
+		return nil, nil, fmt.Errorf("invalid artifact_budget.max_files: %d (must not be negative)", cfg.MaxFiles)
+	}
+
+	var maxBytes *int64
+	if cfg.MaxBytes > 0 {
+		maxBytes = &cfg.MaxBytes
+	}
+	var maxFiles *int
+	if cfg.MaxFiles > 0 {
+		maxFiles = &cfg.MaxFiles
+	}
+	return maxBytes, maxFiles, nil
+}
+
+// secretRefsFromConfig validates secret references from the configuration file.
+func secretRefsFromConfig(cfgs []SecretConfig) ([]database.SecretRef, error) {
+	if len(cfgs) == 0 {
+		return nil, nil
+	}
+
+	refs := make([]database.SecretRef, 0, len(cfgs))
+	seen := make(map[string]bool, len(cfgs))
+	for _, cfg := range cfgs {
+		if cfg.Name == "" {
+			return nil, fmt.Errorf("secret name is required")
+		}
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("secret %s: path is required", cfg.Name)
+		}
+		if seen[cfg.Name] {
+			return nil, fmt.Errorf("duplicate secret: %s", cfg.Name)
+		}
+		seen[cfg.Name] = true
+
+		switch cfg.Provider {
+		case "", "vault", "aws", "envfile":
+		default:
+			return nil, fmt.Errorf("secret %s: invalid provider %q (must be vault, aws, or envfile)", cfg.Name, cfg.Provider)
+		}
+
+		refs = append(refs, database.SecretRef{
+			Name:     cfg.Name,
+			Provider: cfg.Provider,
+			Path:     cfg.Path,
+			Key:      cfg.Key,
+			Version:  cfg.Version,
+		})
+	}
+	return refs, nil
+}
+
 // parseRepositoryURL extracts owner and repo from a git repository URL.
 func parseRepositoryURL(url string) (owner, repo string, err error) {
 	// Handle various URL formats:
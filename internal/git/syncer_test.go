@@ -0,0 +1,421 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/websocket"
+)
+
+type fakeConfigProvider struct {
+	Provider
+
+	files map[string]string
+}
+
+func (p *fakeConfigProvider) GetFile(ctx context.Context, owner, repo, path, ref string) ([]byte, error) {
+	content, ok := p.files[path]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return []byte(content), nil
+}
+
+func (p *fakeConfigProvider) ListFiles(ctx context.Context, owner, repo, dir, ref string) ([]FileInfo, error) {
+	var entries []FileInfo
+	for name := range p.files {
+		rest, ok := strings.CutPrefix(name, dir+"/")
+		if !ok || strings.Contains(rest, "/") {
+			continue
+		}
+		entries = append(entries, FileInfo{Name: rest, Path: name, Type: "file"})
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("not found")
+	}
+	return entries, nil
+}
+
+type fakeTestDefinitionRepo struct {
+	database.TestDefinitionRepository
+
+	existing []database.TestDefinition
+	applyErr error
+	created  []*database.TestDefinition
+	updated  []*database.TestDefinition
+	saved    *database.ServiceEnvironment
+	// concurrency is the concurrency group of the last sync.
+	concurrency *database.ServiceConcurrency
+}
+
+func (r *fakeTestDefinitionRepo) ListByService(ctx context.Context, serviceID uuid.UUID, page database.Pagination) ([]database.TestDefinition, error) {
+	return r.existing, nil
+}
+
+func (r *fakeTestDefinitionRepo) ApplySync(ctx context.Context, sync *database.TestDefinitionSync) error {
+	if r.applyErr != nil {
+		return r.applyErr
+	}
+	r.created = append(r.created, sync.Create...)
+	r.updated = append(r.updated, sync.Update...)
+	r.saved = sync.Environment
+	r.concurrency = sync.Concurrency
+	return nil
+}
+
+type fakeSyncEvents struct {
+	events []websocket.ServiceSyncEvent
+}
+
+func (p *fakeSyncEvents) PublishServiceSync(sync websocket.ServiceSyncEvent) error {
+	p.events = append(p.events, sync)
+	return nil
+}
+
+func newTestSyncer(config string) (*Syncer, *fakeTestDefinitionRepo) {
+	provider := &fakeConfigProvider{files: map[string]string{ConfigFileName: config}}
+	testRepo := &fakeTestDefinitionRepo{}
+
+	syncer := NewSyncer(provider, testRepo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	syncer.EnableEnvironments()
+	return syncer, testRepo
+}
+
+func TestSyncService_Environment(t *testing.T) {
+	service := &database.Service{ID: uuid.New(), GitURL: "https://github.com/owner/repo", DefaultBranch: "main"}
+
+	t.Run("stores service set and definition overrides", func(t *testing.T) {
+		syncer, testRepo := newTestSyncer(`
+version: "1"
+env:
+  REGISTRY_URL: registry.example.com
+secrets:
+  - name: NPM_TOKEN
+    provider: vault
+    path: secret/data/ci
+    key: npm
+tests:
+  - name: unit
+    command: make test
+    env:
+      REGISTRY_URL: mirror.example.com
+    secrets:
+      - name: DB_PASSWORD
+        provider: aws
+        path: ci/db
+`)
+
+		result, err := syncer.SyncService(context.Background(), service, "")
+		require.NoError(t, err)
+		assert.Empty(t, result.Errors)
+
+		require.NotNil(t, testRepo.saved)
+		assert.Equal(t, service.ID, testRepo.saved.ServiceID)
+		assert.Equal(t, map[string]string{"REGISTRY_URL": "registry.example.com"}, testRepo.saved.Environment)
+		assert.Equal(t, []database.SecretRef{
+			{Name: "NPM_TOKEN", Provider: "vault", Path: "secret/data/ci", Key: "npm"},
+		}, testRepo.saved.Secrets)
+
+		require.Len(t, testRepo.created, 1)
+		assert.Equal(t, map[string]string{"REGISTRY_URL": "mirror.example.com"}, testRepo.created[0].Environment)
+		assert.Equal(t, []database.SecretRef{
+			{Name: "DB_PASSWORD", Provider: "aws", Path: "ci/db"},
+		}, testRepo.created[0].Secrets)
+	})
+
+	t.Run("leaves service set alone when not declared", func(t *testing.T) {
+		syncer, testRepo := newTestSyncer(`
+version: "1"
+tests:
+  - name: unit
+    command: make test
+`)
+
+		_, err := syncer.SyncService(context.Background(), service, "")
+		require.NoError(t, err)
+		assert.Nil(t, testRepo.saved)
+		require.Len(t, testRepo.created, 1)
+		assert.Nil(t, testRepo.created[0].Secrets)
+	})
+
+	t.Run("rejects invalid secret references", func(t *testing.T) {
+		syncer, testRepo := newTestSyncer(`
+version: "1"
+secrets:
+  - name: TOKEN
+    provider: keychain
+    path: ci/token
+tests:
+  - name: unit
+    command: make test
+    secrets:
+      - name: DB_PASSWORD
+`)
+
+		result, err := syncer.SyncService(context.Background(), service, "")
+		require.NoError(t, err)
+		assert.Nil(t, testRepo.saved)
+		assert.Empty(t, testRepo.created)
+		require.Len(t, result.Errors, 2)
+		assert.Contains(t, result.Errors[0], `invalid provider "keychain"`)
+		assert.Contains(t, result.Errors[1], "path is required")
+	})
+}
+
+func TestSyncService_Concurrency(t *testing.T) {
+	service := &database.Service{ID: uuid.New(), GitURL: "https://github.com/owner/repo", DefaultBranch: "main"}
+
+	t.Run("stores the declared group", func(t *testing.T) {
+		syncer, testRepo := newTestSyncer(`
+version: "1"
+concurrency:
+  group: deploy-${GIT_BRANCH}
+  cancel_in_progress: true
+tests:
+  - name: unit
+    command: make test
+`)
+
+		result, err := syncer.SyncService(context.Background(), service, "")
+		require.NoError(t, err)
+		assert.Empty(t, result.Errors)
+		require.NotNil(t, testRepo.concurrency)
+		assert.Equal(t, service.ID, testRepo.concurrency.ServiceID)
+		assert.Equal(t, "deploy-${GIT_BRANCH}", testRepo.concurrency.Group)
+		assert.True(t, testRepo.concurrency.CancelInProgress)
+	})
+
+	t.Run("defaults to the service and branch", func(t *testing.T) {
+		syncer, testRepo := newTestSyncer(`
+version: "1"
+concurrency: {}
+`)
+
+		_, err := syncer.SyncService(context.Background(), service, "")
+		require.NoError(t, err)
+		require.NotNil(t, testRepo.concurrency)
+		assert.Equal(t, DefaultConcurrencyGroup, testRepo.concurrency.Group)
+		assert.False(t, testRepo.concurrency.CancelInProgress)
+	})
+
+	t.Run("removes the group when not declared", func(t *testing.T) {
+		syncer, testRepo := newTestSyncer(`
+version: "1"
+tests:
+  - name: unit
+    command: make test
+`)
+
+		_, err := syncer.SyncService(context.Background(), service, "")
+		require.NoError(t, err)
+		require.NotNil(t, testRepo.concurrency)
+		assert.Empty(t, testRepo.concurrency.Group)
+	})
+
+	t.Run("rejects unknown variables", func(t *testing.T) {
+		syncer, testRepo := newTestSyncer(`
+version: "1"
+concurrency:
+  group: deploy-${GIT_SHA}
+`)
+
+		result, err := syncer.SyncService(context.Background(), service, "")
+		require.NoError(t, err)
+		assert.Nil(t, testRepo.concurrency, "the current group is kept")
+		require.Len(t, result.Errors, 1)
+		assert.Contains(t, result.Errors[0], "unknown variable ${GIT_SHA}")
+	})
+}
+
+func TestSyncService_ConfigDiscovery(t *testing.T) {
+	service := &database.Service{ID: uuid.New(), GitURL: "https://github.com/owner/repo", DefaultBranch: "main"}
+	sync := func(t *testing.T, service *database.Service, files map[string]string) (*SyncResult, *fakeTestDefinitionRepo) {
+		testRepo := &fakeTestDefinitionRepo{}
+		syncer := NewSyncer(&fakeConfigProvider{files: files}, testRepo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		result, err := syncer.SyncService(context.Background(), service, "")
+		require.NoError(t, err)
+		return result, testRepo
+	}
+	names := func(defs []*database.TestDefinition) []string {
+		var names []string
+		for _, def := range defs {
+			names = append(names, def.Name)
+		}
+		return names
+	}
+
+	t.Run("merges root file and config directory", func(t *testing.T) {
+		result, testRepo := sync(t, service, map[string]string{
+			"conductor.yaml": `
+tests:
+  - name: unit
+    command: make test
+`,
+			".conductor/e2e.yml": `
+tests:
+  - name: e2e
+    owner: team-web
+    command: npx
+    args: [playwright, test]
+    tags: [browser]
+    timeout: 10m
+    execution_mode: container
+    docker_image: mcr.microsoft.com/playwright:v1.48.0
+    artifact_paths: [test-results/**]
+    artifact_budget:
+      max_bytes: 104857600
+`,
+			".conductor/README.md": "not a config file",
+		})
+
+		assert.Empty(t, result.Errors)
+		assert.Equal(t, 2, result.TestsAdded)
+		assert.Equal(t, []string{"unit", "e2e"}, names(testRepo.created))
+
+		e2e := testRepo.created[1]
+		assert.Equal(t, []string{"playwright", "test"}, e2e.Args)
+		assert.Equal(t, []string{"browser"}, e2e.Tags)
+		assert.Equal(t, 600, e2e.TimeoutSeconds)
+		assert.Equal(t, "container", e2e.ExecutionType)
+		assert.Equal(t, []string{"test-results/**"}, e2e.ArtifactPatterns)
+		require.NotNil(t, e2e.ArtifactMaxBytes)
+		assert.Equal(t, int64(104857600), *e2e.ArtifactMaxBytes)
+		assert.Nil(t, e2e.ArtifactMaxFiles)
+		require.NotNil(t, e2e.Owner)
+		assert.Equal(t, "team-web", *e2e.Owner)
+		assert.Nil(t, testRepo.created[0].Owner)
+	})
+
+	t.Run("reads from the service root path", func(t *testing.T) {
+		root := "services/api/"
+		monorepo := &database.Service{ID: uuid.New(), GitURL: service.GitURL, DefaultBranch: "main", RootPath: &root}
+		result, testRepo := sync(t, monorepo, map[string]string{
+			ConfigFileName:                      "tests:\n  - name: repo-wide\n    command: make\n",
+			"services/api/.conductor/unit.yaml": "tests:\n  - name: api-unit\n    command: go test ./...\n",
+		})
+
+		assert.Empty(t, result.Errors)
+		assert.Equal(t, []string{"api-unit"}, names(testRepo.created))
+	})
+
+	t.Run("surfaces validation errors per file", func(t *testing.T) {
+		result, testRepo := sync(t, service, map[string]string{
+			ConfigFileName: `
+tests:
+  - name: unit
+    command: make test
+  - name: slow
+    command: make slow
+    timeout: 500ms
+`,
+			".conductor/a.yaml": `
+env:
+  FOO: bar
+tests:
+  - name: unit
+    command: make other
+  - name: flaky
+    command: make flaky
+    max_retries: -1
+`,
+			".conductor/b.yaml": "tests: [",
+		})
+
+		assert.Equal(t, []string{"unit"}, names(testRepo.created))
+		require.Len(t, result.Errors, 5)
+		assert.Contains(t, result.Errors[0], ".conductor/a.yaml: env and secrets are only read from the root config file")
+		assert.Contains(t, result.Errors[1], "failed to parse .conductor/b.yaml")
+		assert.Contains(t, result.Errors[2], ".conductor.yaml: invalid test config 'slow': invalid timeout")
+		assert.Contains(t, result.Errors[3], ".conductor/a.yaml: duplicate test 'unit' (already defined in .conductor.yaml)")
+		assert.Contains(t, result.Errors[4], ".conductor/a.yaml: invalid test config 'flaky': invalid max_retries")
+	})
+
+	t.Run("reports missing configuration", func(t *testing.T) {
+		result, testRepo := sync(t, service, map[string]string{})
+
+		assert.Empty(t, testRepo.created)
+		require.Len(t, result.Errors, 1)
+		assert.Contains(t, result.Errors[0], "config file not found")
+	})
+}
+
+func TestSyncService_ChangeSet(t *testing.T) {
+	service := &database.Service{ID: uuid.New(), GitURL: "https://github.com/owner/repo", DefaultBranch: "main"}
+	config := `
+tests:
+  - name: unit
+    command: make test
+  - name: lint
+    command: make lint
+  - name: slow
+    command: make slow
+    timeout: 500ms
+`
+	existing := []database.TestDefinition{
+		{ID: uuid.New(), ServiceID: service.ID, Name: "unit"},
+		{ID: uuid.New(), ServiceID: service.ID, Name: "legacy"},
+	}
+
+	t.Run("applies and publishes the change set", func(t *testing.T) {
+		syncer, testRepo := newTestSyncer(config)
+		testRepo.existing = existing
+		events := &fakeSyncEvents{}
+		syncer.SetEvents(events)
+
+		result, err := syncer.SyncService(context.Background(), service, "")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"lint"}, result.Added)
+		assert.Equal(t, []string{"unit"}, result.Updated)
+		assert.Equal(t, []string{"legacy"}, result.Removed)
+		assert.Equal(t, 1, result.TestsAdded)
+		assert.Equal(t, 1, result.TestsUpdated)
+		assert.Equal(t, 1, result.TestsRemoved)
+		require.Len(t, result.Errors, 1)
+		assert.Contains(t, result.Errors[0], "invalid test config 'slow'")
+
+		require.Len(t, testRepo.updated, 1)
+		assert.Equal(t, existing[0].ID, testRepo.updated[0].ID)
+
+		require.Len(t, events.events, 1)
+		event := events.events[0]
+		assert.True(t, event.Applied)
+		assert.Equal(t, service.ID, event.ServiceID)
+		assert.Equal(t, "main", event.Branch)
+		assert.Equal(t, []string{"lint"}, event.Added)
+		assert.Equal(t, []string{"unit"}, event.Updated)
+		assert.Equal(t, []string{"legacy"}, event.Removed)
+		assert.Equal(t, result.Errors, event.Errors)
+	})
+
+	t.Run("applies nothing when the transaction fails", func(t *testing.T) {
+		syncer, testRepo := newTestSyncer(config)
+		testRepo.existing = existing
+		testRepo.applyErr = errors.New("connection reset")
+		events := &fakeSyncEvents{}
+		syncer.SetEvents(events)
+
+		result, err := syncer.SyncService(context.Background(), service, "")
+		require.Error(t, err)
+
+		assert.Empty(t, testRepo.created)
+		assert.Empty(t, testRepo.updated)
+		assert.Zero(t, result.TestsAdded)
+		assert.Zero(t, result.TestsUpdated)
+		assert.Empty(t, result.Added)
+		assert.Contains(t, result.Errors[len(result.Errors)-1], "failed to apply sync: connection reset")
+
+		require.Len(t, events.events, 1)
+		assert.False(t, events.events[0].Applied)
+		assert.Equal(t, []string{"lint"}, events.events[0].Added)
+	})
+}
@@ -0,0 +1,136 @@
+// Package idempotency applies requests carrying an idempotency key at most
+// once: the first request with a key is applied and its response stored,
+// and retries with the same key are answered with the stored response until
+// the key expires.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// DefaultTTL is how long responses are kept for retries by default.
+const DefaultTTL = 24 * time.Hour
+
+// MaxKeyLength is the maximum length of an idempotency key.
+const MaxKeyLength = 255
+
+// lockTimeout is how long a key is held by a request in progress. A key
+// whose request did not complete, because its replica stopped, can be
+// claimed again after it.
+const lockTimeout = time.Minute
+
+var (
+	// ErrKeyReused is returned when a key is sent again with a different
+	// request.
+	ErrKeyReused = errors.New("idempotency key was used with a different request")
+
+	// ErrInProgress is returned when the request that claimed a key has not
+	// completed yet.
+	ErrInProgress = errors.New("a request with the idempotency key is in progress")
+)
+
+// Store keeps the idempotency keys of requests and the responses to them.
+type Store struct {
+	repo   database.IdempotencyKeyRepository
+	ttl    time.Duration
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// NewStore creates a store keeping responses for ttl, or DefaultTTL if it
+// is 0.
+func NewStore(repo database.IdempotencyKeyRepository, ttl time.Duration, logger *slog.Logger) *Store {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Store{
+		repo:   repo,
+		ttl:    ttl,
+		logger: logger.With("component", "idempotency"),
+		now:    time.Now,
+	}
+}
+
+// Do applies a request once per scope and key. The first call with a key
+// calls apply and stores its response; later calls with the same request
+// return the stored response and report it as replayed, and calls with
+// another request fail with ErrKeyReused. Failed requests are not stored,
+// so that they can be retried with the same key.
+//
+// Do calls apply directly when the store is nil or the key is empty.
+func Do[T any](ctx context.Context, s *Store, scope, key string, request any, apply func() (T, error)) (response T, replayed bool, err error) {
+	if s == nil || key == "" {
+		response, err = apply()
+		return response, false, err
+	}
+
+	hash, err := requestHash(request)
+	if err != nil {
+		return response, false, err
+	}
+
+	existing, err := s.repo.Claim(ctx, &database.IdempotencyKey{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: hash,
+		ExpiresAt:   s.now().Add(lockTimeout),
+	})
+	if err != nil {
+		return response, false, err
+	}
+	if existing != nil {
+		switch {
+		case existing.RequestHash != hash:
+			return response, false, ErrKeyReused
+		case existing.Response == nil:
+			return response, false, ErrInProgress
+		}
+		if err := json.Unmarshal(existing.Response, &response); err != nil {
+			return response, false, fmt.Errorf("failed to decode stored response: %w", err)
+		}
+		return response, true, nil
+	}
+
+	response, err = apply()
+	if err != nil {
+		// A detached context releases the key even when the request was
+		// cancelled
+		if releaseErr := s.repo.Release(context.WithoutCancel(ctx), scope, key); releaseErr != nil {
+			s.logger.Warn("failed to release idempotency key", "scope", scope, "key", key, "error", releaseErr)
+		}
+		return response, false, err
+	}
+
+	// The request was applied, so it succeeds even when its response
+	// cannot be stored; retries after the lock timeout apply it again
+	snapshot, err := json.Marshal(response)
+	if err == nil {
+		err = s.repo.Complete(context.WithoutCancel(ctx), scope, key, snapshot, s.now().Add(s.ttl))
+	}
+	if err != nil {
+		s.logger.Warn("failed to store idempotent response", "scope", scope, "key", key, "error", err)
+	}
+	return response, false, nil
+}
+
+// requestHash returns the SHA-256 of the JSON encoding of a request.
+func requestHash(request any) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
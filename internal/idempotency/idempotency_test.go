@@ -0,0 +1,135 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// fakeRepo keeps idempotency keys in memory.
+type fakeRepo struct {
+	keys map[string]*database.IdempotencyKey
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{keys: make(map[string]*database.IdempotencyKey)}
+}
+
+func (r *fakeRepo) Claim(ctx context.Context, key *database.IdempotencyKey) (*database.IdempotencyKey, error) {
+	if existing, ok := r.keys[key.Scope+"/"+key.Key]; ok {
+		return existing, nil
+	}
+	claimed := *key
+	r.keys[key.Scope+"/"+key.Key] = &claimed
+	return nil, nil
+}
+
+func (r *fakeRepo) Complete(ctx context.Context, scope, key string, response []byte, expiresAt time.Time) error {
+	k, ok := r.keys[scope+"/"+key]
+	if !ok {
+		return database.ErrNotFound
+	}
+	k.Response = response
+	k.ExpiresAt = expiresAt
+	return nil
+}
+
+func (r *fakeRepo) Release(ctx context.Context, scope, key string) error {
+	if k, ok := r.keys[scope+"/"+key]; ok && k.Response == nil {
+		delete(r.keys, scope+"/"+key)
+	}
+	return nil
+}
+
+func (r *fakeRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+type response struct {
+	RunID string `json:"run_id"`
+}
+
+func TestDo(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepo()
+	store := NewStore(repo, time.Hour, nil)
+
+	calls := 0
+	apply := func() (*response, error) {
+		calls++
+		return &response{RunID: "run-1"}, nil
+	}
+	request := map[string]string{"service_id": "svc-1"}
+
+	got, replayed, err := Do(ctx, store, "runs.create", "key-1", request, apply)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, "run-1", got.RunID)
+
+	got, replayed, err = Do(ctx, store, "runs.create", "key-1", request, apply)
+	require.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, "run-1", got.RunID)
+	assert.Equal(t, 1, calls, "retries are answered with the stored response")
+
+	_, _, err = Do(ctx, store, "runs.create", "key-1", map[string]string{"service_id": "svc-2"}, apply)
+	assert.ErrorIs(t, err, ErrKeyReused)
+
+	_, replayed, err = Do(ctx, store, "runs.schedule", "key-1", request, apply)
+	require.NoError(t, err)
+	assert.False(t, replayed, "keys are scoped")
+	assert.Equal(t, 2, calls)
+}
+
+func TestDo_InProgress(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(newFakeRepo(), time.Hour, nil)
+
+	_, _, err := Do(ctx, store, "runs.create", "key-1", "request", func() (*response, error) {
+		_, _, err := Do(ctx, store, "runs.create", "key-1", "request", func() (*response, error) {
+			t.Fatal("a concurrent request must not be applied")
+			return nil, nil
+		})
+		assert.ErrorIs(t, err, ErrInProgress)
+		return &response{RunID: "run-1"}, nil
+	})
+	require.NoError(t, err)
+}
+
+func TestDo_FailedRequest(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(newFakeRepo(), time.Hour, nil)
+	failure := errors.New("service is archived")
+
+	_, _, err := Do(ctx, store, "runs.create", "key-1", "request", func() (*response, error) {
+		return nil, failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	got, replayed, err := Do(ctx, store, "runs.create", "key-1", "request", func() (*response, error) {
+		return &response{RunID: "run-1"}, nil
+	})
+	require.NoError(t, err)
+	assert.False(t, replayed, "failed requests can be retried with the same key")
+	assert.Equal(t, "run-1", got.RunID)
+}
+
+func TestDo_WithoutKey(t *testing.T) {
+	calls := 0
+	apply := func() (*response, error) {
+		calls++
+		return &response{}, nil
+	}
+
+	_, _, err := Do(context.Background(), nil, "runs.create", "key-1", "request", apply)
+	require.NoError(t, err)
+	_, _, err = Do(context.Background(), NewStore(newFakeRepo(), 0, nil), "runs.create", "", "request", apply)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,62 @@
+package idempotency
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// pruneInterval is how often expired idempotency keys are deleted.
+const pruneInterval = time.Hour
+
+// Pruner deletes expired idempotency keys. Expired keys are already ignored
+// by Do; pruning only keeps the table small.
+type Pruner struct {
+	repo   database.IdempotencyKeyRepository
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// NewPruner creates a new Pruner.
+func NewPruner(repo database.IdempotencyKeyRepository, logger *slog.Logger) *Pruner {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Pruner{
+		repo:   repo,
+		logger: logger.With("component", "idempotency_pruner"),
+		now:    time.Now,
+	}
+}
+
+// Start deletes expired idempotency keys now and then every hour until the
+// context is cancelled.
+func (p *Pruner) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(pruneInterval)
+		defer ticker.Stop()
+
+		for {
+			p.prune(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// prune deletes the keys that have expired.
+func (p *Pruner) prune(ctx context.Context) {
+	deleted, err := p.repo.DeleteExpired(ctx, p.now())
+	if err != nil {
+		p.logger.Error("failed to delete expired idempotency keys", "error", err)
+		return
+	}
+	if deleted > 0 {
+		p.logger.Info("deleted expired idempotency keys", "count", deleted)
+	}
+}
@@ -0,0 +1,165 @@
+// Package leader elects the control plane replica that runs the background
+// jobs which must not run on several replicas at once, such as the schedule
+// runner, git sync and cleanup. API serving is not affected and scales
+// across replicas.
+package leader
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// Lock is a lock that at most one replica holds.
+type Lock interface {
+	// TryLock takes the lock without waiting. It returns nil if another
+	// replica holds it.
+	TryLock(ctx context.Context) (HeldLock, error)
+}
+
+// HeldLock is a lock held by this replica.
+type HeldLock interface {
+	// Ping returns an error if the lock may have been lost.
+	Ping(ctx context.Context) error
+	// Unlock releases the lock.
+	Unlock(ctx context.Context) error
+}
+
+// advisoryLock is a Lock backed by a Postgres advisory lock.
+type advisoryLock struct {
+	db *database.DB
+	id int64
+}
+
+// NewAdvisoryLock returns a Lock backed by the Postgres session-level
+// advisory lock with the given ID. The lock is released when the session
+// ends, so a crashed leader is replaced once Postgres closes its session.
+func NewAdvisoryLock(db *database.DB, id int64) Lock {
+	return &advisoryLock{db: db, id: id}
+}
+
+// TryLock takes the advisory lock without waiting.
+func (l *advisoryLock) TryLock(ctx context.Context) (HeldLock, error) {
+	lock, err := l.db.TryAdvisoryLock(ctx, l.id)
+	if err != nil || lock == nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// Elector runs the background jobs of the control plane while this replica
+// holds the leader lock.
+type Elector struct {
+	lock     Lock
+	interval time.Duration
+	logger   *slog.Logger
+	metrics  Metrics
+	leader   atomic.Bool
+}
+
+// Metrics reports whether this replica is the leader.
+// metrics.ControlPlaneMetrics implements it.
+type Metrics interface {
+	SetLeader(leader bool)
+}
+
+// NewElector creates an elector that tries to take the lock every interval
+// and, while it holds the lock, checks every interval that it still does.
+func NewElector(lock Lock, interval time.Duration, logger *slog.Logger) *Elector {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Elector{
+		lock:     lock,
+		interval: interval,
+		logger:   logger.With("component", "leader_election"),
+	}
+}
+
+// SetMetrics sets where leadership changes are reported.
+func (e *Elector) SetMetrics(m Metrics) {
+	e.metrics = m
+}
+
+// IsLeader reports whether this replica is the leader.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run campaigns for leadership until the context is cancelled. Each time
+// this replica becomes the leader it calls lead with a context that is
+// cancelled when it stops being the leader, so lead should start the
+// background jobs with that context and return. When the lock is lost the
+// jobs are stopped within one interval; another replica may take over as
+// soon as Postgres releases the lock, so both may briefly run the jobs.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	e.logger.Info("campaigning for leadership", "interval", e.interval)
+
+	for {
+		held, err := e.lock.TryLock(ctx)
+		if err != nil && ctx.Err() == nil {
+			e.logger.Error("failed to take leader lock", "error", err)
+		}
+		if held != nil {
+			e.lead(ctx, held, lead)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.interval):
+		}
+	}
+}
+
+// lead runs the background jobs until the context is cancelled or the lock
+// is lost.
+func (e *Elector) lead(ctx context.Context, held HeldLock, lead func(ctx context.Context)) {
+	leaderCtx, stop := context.WithCancel(ctx)
+	defer e.stepDown(ctx, held, stop)
+
+	e.setLeader(true)
+	e.logger.Info("became leader, starting background jobs")
+	lead(leaderCtx)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := held.Ping(ctx); err != nil {
+				e.logger.Error("lost leader lock, stopping background jobs", "error", err)
+				return
+			}
+		}
+	}
+}
+
+// stepDown stops the background jobs and releases the lock. The lock is
+// released even when ctx is cancelled, so that another replica takes over
+// without waiting for Postgres to close the session.
+func (e *Elector) stepDown(ctx context.Context, held HeldLock, stop context.CancelFunc) {
+	stop()
+	e.setLeader(false)
+
+	unlockCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), e.interval)
+	defer cancel()
+	if err := held.Unlock(unlockCtx); err != nil {
+		e.logger.Warn("failed to release leader lock", "error", err)
+	}
+	e.logger.Info("stepped down as leader")
+}
+
+// setLeader records whether this replica is the leader.
+func (e *Elector) setLeader(leader bool) {
+	e.leader.Store(leader)
+	if e.metrics != nil {
+		e.metrics.SetLeader(leader)
+	}
+}
@@ -0,0 +1,140 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryLock is a Lock shared by the electors of a test.
+type memoryLock struct {
+	mu     sync.Mutex
+	holder *memoryHeldLock
+}
+
+func (l *memoryLock) TryLock(ctx context.Context) (HeldLock, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holder != nil {
+		return nil, nil
+	}
+	l.holder = &memoryHeldLock{lock: l}
+	return l.holder, nil
+}
+
+// memoryHeldLock is a held memoryLock. Breaking it makes Ping fail and
+// frees the lock, like Postgres does when the session ends.
+type memoryHeldLock struct {
+	lock   *memoryLock
+	broken atomic.Bool
+}
+
+func (h *memoryHeldLock) Ping(ctx context.Context) error {
+	if h.broken.Load() {
+		return errors.New("connection reset")
+	}
+	return nil
+}
+
+func (h *memoryHeldLock) Unlock(ctx context.Context) error {
+	h.release()
+	return nil
+}
+
+func (h *memoryHeldLock) release() {
+	h.lock.mu.Lock()
+	defer h.lock.mu.Unlock()
+	if h.lock.holder == h {
+		h.lock.holder = nil
+	}
+}
+
+func (h *memoryHeldLock) breakSession() {
+	h.broken.Store(true)
+	h.release()
+}
+
+type leaderMetrics struct {
+	leader atomic.Bool
+}
+
+func (m *leaderMetrics) SetLeader(leader bool) {
+	m.leader.Store(leader)
+}
+
+// startElector runs an elector whose background job records whether it is
+// running.
+func startElector(t *testing.T, lock Lock, metrics Metrics) (*Elector, *atomic.Bool, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	elector := NewElector(lock, 10*time.Millisecond, nil)
+	if metrics != nil {
+		elector.SetMetrics(metrics)
+	}
+	var running atomic.Bool
+	go func() {
+		defer close(done)
+		elector.Run(ctx, func(ctx context.Context) {
+			running.Store(true)
+			go func() {
+				<-ctx.Done()
+				running.Store(false)
+			}()
+		})
+	}()
+	return elector, &running, cancel
+}
+
+func TestElector_OneLeader(t *testing.T) {
+	lock := &memoryLock{}
+	first, firstRunning, stopFirst := startElector(t, lock, nil)
+	require.Eventually(t, first.IsLeader, time.Second, time.Millisecond)
+
+	second, secondRunning, _ := startElector(t, lock, nil)
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, second.IsLeader())
+	assert.False(t, secondRunning.Load(), "followers do not run the background jobs")
+	assert.True(t, firstRunning.Load())
+
+	stopFirst()
+	require.Eventually(t, second.IsLeader, time.Second, time.Millisecond, "a follower takes over when the leader steps down")
+	assert.Eventually(t, secondRunning.Load, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return !firstRunning.Load() }, time.Second, time.Millisecond)
+}
+
+func TestElector_LostLock(t *testing.T) {
+	lock := &memoryLock{}
+	metrics := &leaderMetrics{}
+
+	elector, running, _ := startElector(t, lock, metrics)
+	require.Eventually(t, running.Load, time.Second, time.Millisecond)
+	require.Eventually(t, metrics.leader.Load, time.Second, time.Millisecond)
+
+	lock.mu.Lock()
+	held := lock.holder
+	lock.mu.Unlock()
+
+	// Another replica takes the lock while the session of this one is gone
+	held.breakSession()
+	other, err := lock.TryLock(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, other)
+
+	require.Eventually(t, func() bool { return !running.Load() }, time.Second, time.Millisecond, "the background jobs stop when the lock is lost")
+	assert.False(t, elector.IsLeader())
+	assert.False(t, metrics.leader.Load())
+
+	require.NoError(t, other.Unlock(context.Background()))
+	assert.Eventually(t, elector.IsLeader, time.Second, time.Millisecond, "the replica campaigns again")
+}
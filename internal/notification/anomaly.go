@@ -0,0 +1,184 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// DurationAnomalyConfig controls when a run's duration is an anomaly.
+type DurationAnomalyConfig struct {
+	// Sigma is how many standard deviations from the mean a duration must
+	// be to be an anomaly.
+	Sigma float64
+	// Window is how many previous passed runs form the baseline.
+	Window int
+	// MinRuns is how many previous passed runs are needed before runs are
+	// checked.
+	MinRuns int
+	// MinDelta is the smallest difference from the mean that is reported,
+	// so services with very steady durations do not alert on seconds.
+	MinDelta time.Duration
+}
+
+// DefaultDurationAnomalyConfig returns the default anomaly thresholds.
+func DefaultDurationAnomalyConfig() DurationAnomalyConfig {
+	return DurationAnomalyConfig{
+		Sigma:    3,
+		Window:   20,
+		MinRuns:  5,
+		MinDelta: 30 * time.Second,
+	}
+}
+
+// DurationAnomaly describes a run whose duration deviates from the recent
+// passed runs of its service.
+type DurationAnomaly struct {
+	// DurationMs is the duration of the run.
+	DurationMs int64
+	// MeanMs and StdDevMs describe the baseline durations.
+	MeanMs   float64
+	StdDevMs float64
+	// Sigma is how many standard deviations the run is from the mean;
+	// negative for runs faster than usual.
+	Sigma float64
+	// Runs is how many runs formed the baseline.
+	Runs int
+}
+
+// Slower reports whether the run took longer than usual.
+func (a *DurationAnomaly) Slower() bool {
+	return a.Sigma > 0
+}
+
+// RunHistoryRepository lists the previous runs of a service.
+// database.TestRunRepository implements it.
+type RunHistoryRepository interface {
+	ListByServiceAndStatus(ctx context.Context, serviceID uuid.UUID, status database.RunStatus, page database.Pagination) ([]database.TestRun, error)
+}
+
+// DurationAnomalyDetector compares the durations of passed runs with the
+// previous passed runs of their service, catching slowdowns that do not
+// fail any test.
+type DurationAnomalyDetector struct {
+	runs   RunHistoryRepository
+	config DurationAnomalyConfig
+	logger *slog.Logger
+}
+
+// NewDurationAnomalyDetector creates a new DurationAnomalyDetector. Unset
+// thresholds take their defaults.
+func NewDurationAnomalyDetector(runs RunHistoryRepository, config DurationAnomalyConfig, logger *slog.Logger) *DurationAnomalyDetector {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	defaults := DefaultDurationAnomalyConfig()
+	if config.Sigma <= 0 {
+		config.Sigma = defaults.Sigma
+	}
+	if config.Window <= 0 {
+		config.Window = defaults.Window
+	}
+	if config.MinRuns <= 0 {
+		config.MinRuns = defaults.MinRuns
+	}
+	if config.MinRuns > config.Window {
+		config.MinRuns = config.Window
+	}
+
+	return &DurationAnomalyDetector{
+		runs:   runs,
+		config: config,
+		logger: logger.With("component", "duration_anomaly_detector"),
+	}
+}
+
+// Detect returns the anomaly of a finished run, or nil if its duration is
+// within the thresholds. Only passed runs are checked: failed runs already
+// notify and often stop early.
+func (d *DurationAnomalyDetector) Detect(ctx context.Context, run *database.TestRun) (*DurationAnomaly, error) {
+	if run == nil || run.Status != database.RunStatusPassed || run.DurationMs == nil {
+		return nil, nil
+	}
+
+	// One extra run as the list may include the run itself.
+	previous, err := d.runs.ListByServiceAndStatus(ctx, run.ServiceID, database.RunStatusPassed, database.Pagination{Limit: d.config.Window + 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list previous runs: %w", err)
+	}
+
+	durations := make([]int64, 0, len(previous))
+	for _, prev := range previous {
+		if prev.ID == run.ID || prev.DurationMs == nil || !prev.CreatedAt.Before(run.CreatedAt) {
+			continue
+		}
+		durations = append(durations, *prev.DurationMs)
+		if len(durations) == d.config.Window {
+			break
+		}
+	}
+
+	anomaly := detectDurationAnomaly(*run.DurationMs, durations, d.config)
+	if anomaly != nil {
+		d.logger.Info("run duration anomaly detected",
+			"run_id", run.ID,
+			"service_id", run.ServiceID,
+			"duration_ms", anomaly.DurationMs,
+			"mean_ms", int64(anomaly.MeanMs),
+			"sigma", anomaly.Sigma,
+		)
+	}
+	return anomaly, nil
+}
+
+// detectDurationAnomaly compares a duration with the mean and standard
+// deviation of the baseline durations.
+func detectDurationAnomaly(durationMs int64, baseline []int64, config DurationAnomalyConfig) *DurationAnomaly {
+	if len(baseline) == 0 || len(baseline) < config.MinRuns {
+		return nil
+	}
+
+	var sum float64
+	for _, ms := range baseline {
+		sum += float64(ms)
+	}
+	mean := sum / float64(len(baseline))
+
+	var variance float64
+	for _, ms := range baseline {
+		variance += (float64(ms) - mean) * (float64(ms) - mean)
+	}
+	stdDev := math.Sqrt(variance / float64(len(baseline)))
+
+	delta := float64(durationMs) - mean
+	if math.Abs(delta) < float64(config.MinDelta.Milliseconds()) {
+		return nil
+	}
+
+	// Identical baselines have no spread; any change beyond MinDelta counts.
+	sigma := math.Inf(1)
+	if stdDev > 0 {
+		sigma = math.Abs(delta) / stdDev
+	}
+	if sigma < config.Sigma {
+		return nil
+	}
+	if delta < 0 {
+		sigma = -sigma
+	}
+
+	return &DurationAnomaly{
+		DurationMs: durationMs,
+		MeanMs:     mean,
+		StdDevMs:   stdDev,
+		Sigma:      sigma,
+		Runs:       len(baseline),
+	}
+}
@@ -0,0 +1,158 @@
+package notification
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+type fakeRunHistory struct {
+	runs  []database.TestRun
+	limit int
+}
+
+func (f *fakeRunHistory) ListByServiceAndStatus(ctx context.Context, serviceID uuid.UUID, status database.RunStatus, page database.Pagination) ([]database.TestRun, error) {
+	f.limit = page.Limit
+	var runs []database.TestRun
+	for _, run := range f.runs {
+		if run.ServiceID == serviceID && run.Status == status {
+			runs = append(runs, run)
+		}
+	}
+	return runs, nil
+}
+
+func TestDetectDurationAnomaly(t *testing.T) {
+	config := DurationAnomalyConfig{Sigma: 3, MinRuns: 5, MinDelta: 30 * time.Second}
+	// Mean 10m, standard deviation 20s
+	baseline := []int64{580_000, 620_000, 580_000, 620_000, 580_000, 620_000}
+
+	t.Run("within threshold", func(t *testing.T) {
+		assert.Nil(t, detectDurationAnomaly(650_000, baseline, config))
+	})
+
+	t.Run("slower", func(t *testing.T) {
+		anomaly := detectDurationAnomaly(900_000, baseline, config)
+		require.NotNil(t, anomaly)
+		assert.True(t, anomaly.Slower())
+		assert.Equal(t, 600_000.0, anomaly.MeanMs)
+		assert.Equal(t, 20_000.0, anomaly.StdDevMs)
+		assert.Equal(t, 15.0, anomaly.Sigma)
+		assert.Equal(t, 6, anomaly.Runs)
+	})
+
+	t.Run("faster", func(t *testing.T) {
+		anomaly := detectDurationAnomaly(60_000, baseline, config)
+		require.NotNil(t, anomaly)
+		assert.False(t, anomaly.Slower())
+		assert.Equal(t, -27.0, anomaly.Sigma)
+	})
+
+	t.Run("below minimum delta", func(t *testing.T) {
+		// 20s is 10 sigma from a steady 2s baseline, but not worth an alert
+		steady := []int64{1_900, 2_100, 1_900, 2_100, 1_900}
+		assert.Nil(t, detectDurationAnomaly(22_000, steady, config))
+	})
+
+	t.Run("identical baseline", func(t *testing.T) {
+		same := []int64{60_000, 60_000, 60_000, 60_000, 60_000}
+		anomaly := detectDurationAnomaly(120_000, same, config)
+		require.NotNil(t, anomaly)
+		assert.True(t, math.IsInf(anomaly.Sigma, 1))
+	})
+
+	t.Run("too few runs", func(t *testing.T) {
+		assert.Nil(t, detectDurationAnomaly(900_000, baseline[:4], config))
+	})
+}
+
+func TestDurationAnomalyDetectorDetect(t *testing.T) {
+	serviceID := uuid.New()
+	now := time.Now()
+	ms := func(v int64) *int64 { return &v }
+
+	history := &fakeRunHistory{}
+	for i := 0; i < 6; i++ {
+		history.runs = append(history.runs, database.TestRun{
+			ID:         uuid.New(),
+			ServiceID:  serviceID,
+			Status:     database.RunStatusPassed,
+			CreatedAt:  now.Add(-time.Duration(i+1) * time.Hour),
+			DurationMs: ms(int64(580_000 + (i%2)*40_000)),
+		})
+	}
+	// Failed runs and runs of other services are not part of the baseline
+	history.runs = append(history.runs,
+		database.TestRun{ID: uuid.New(), ServiceID: serviceID, Status: database.RunStatusFailed, CreatedAt: now.Add(-time.Minute), DurationMs: ms(5_000)},
+		database.TestRun{ID: uuid.New(), ServiceID: uuid.New(), Status: database.RunStatusPassed, CreatedAt: now.Add(-time.Minute), DurationMs: ms(5_000)},
+	)
+
+	run := database.TestRun{ID: uuid.New(), ServiceID: serviceID, Status: database.RunStatusPassed, CreatedAt: now, DurationMs: ms(900_000)}
+	history.runs = append([]database.TestRun{run}, history.runs...)
+
+	detector := NewDurationAnomalyDetector(history, DurationAnomalyConfig{Window: 10}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	anomaly, err := detector.Detect(context.Background(), &run)
+	require.NoError(t, err)
+	require.NotNil(t, anomaly)
+	assert.Equal(t, 11, history.limit)
+	assert.Equal(t, 6, anomaly.Runs, "the run itself is not part of its baseline")
+	assert.Equal(t, 600_000.0, anomaly.MeanMs)
+
+	failed := run
+	failed.Status = database.RunStatusFailed
+	anomaly, err = detector.Detect(context.Background(), &failed)
+	require.NoError(t, err)
+	assert.Nil(t, anomaly, "only passed runs are checked")
+}
+
+func TestDurationAnomalyTemplate(t *testing.T) {
+	title, message := GetTemplateForType(NotificationTypeDurationAnomaly, TemplateVars{
+		ServiceName:    "payments",
+		DurationMs:     900_000,
+		MeanDurationMs: 600_000,
+		DurationSigma:  15,
+		BaselineRuns:   20,
+		Branch:         "main",
+	})
+
+	assert.Equal(t, "Run Duration Anomaly - payments", title)
+	assert.Contains(t, message, "took 15m0s, much longer than usual")
+	assert.Contains(t, message, "*Usual duration:* 10m0s (mean of the last 20 passed runs)")
+	assert.Contains(t, message, "*Deviation:* 15.0σ")
+	assert.Equal(t, database.TriggerEventDurationAnomaly, mapTriggerEvent(NotificationTypeDurationAnomaly))
+}
+
+func TestDurationRegressionTemplate(t *testing.T) {
+	checkout := "TestCheckout"
+	s := &Service{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	vars := s.templateVars(&Event{
+		Type:        NotificationTypeDurationRegression,
+		ServiceName: "payments",
+		DurationRegressions: []database.DurationRegression{
+			{DurationMs: 900_000, BaselineMeanMs: 600_000, Sigma: 15, BaselineRuns: 20},
+			{TestName: &checkout, DurationMs: 9_500, BaselineMeanMs: 2_000, Sigma: math.Inf(1), BaselineRuns: 5},
+		},
+	})
+	assert.Equal(t, []RegressedTest{{Name: "TestCheckout", DurationMs: 9_500, MeanDurationMs: 2_000}}, vars.RegressedTests)
+
+	title, message := GetTemplateForType(NotificationTypeDurationRegression, vars)
+	assert.Equal(t, "Duration Regression - payments", title)
+	assert.Contains(t, message, "took 15m0s, much longer than usual")
+	assert.Contains(t, message, "*Usual duration:* 10m0s (mean of the last 20 passed runs)")
+	assert.Contains(t, message, "- `TestCheckout`: 9.5s (usually 2s)")
+
+	vars.BaselineRuns = 0
+	_, message = GetTemplateForType(NotificationTypeDurationRegression, vars)
+	assert.Contains(t, message, "Tests of *payments* took much longer than usual.")
+	assert.Equal(t, database.TriggerEventDurationRegression, mapTriggerEvent(NotificationTypeDurationRegression))
+}
@@ -31,6 +31,11 @@ const (
 	NotificationTypeRunTimeout NotificationType = "run_timeout"
 	// NotificationTypeRunError indicates a test run encountered an error.
 	NotificationTypeRunError NotificationType = "run_error"
+	// NotificationTypeDurationAnomaly indicates a run took unusually long or short.
+	NotificationTypeDurationAnomaly NotificationType = "duration_anomaly"
+	// NotificationTypeDurationRegression indicates a run or its tests took
+	// significantly longer than on recent runs of the branch.
+	NotificationTypeDurationRegression NotificationType = "duration_regression"
 	// NotificationTypeAgentOffline indicates an agent went offline.
 	NotificationTypeAgentOffline NotificationType = "agent_offline"
 	// NotificationTypeAgentOnline indicates an agent came online.
@@ -63,6 +68,9 @@ type Notification struct {
 	CreatedAt time.Time
 	// Metadata contains additional key-value data.
 	Metadata map[string]string
+	// MatchedRules describes every rule that matched when several rules
+	// were collapsed into this notification.
+	MatchedRules []string
 }
 
 // RunSummary contains summary information about a test run.
@@ -115,12 +123,27 @@ type Event struct {
 	RunID *uuid.UUID
 	// Run contains the test run data (if applicable).
 	Run *database.TestRun
+	// TestDefinitionIDs are the test definitions the event concerns, e.g. the
+	// failed tests of a run. Rules of these definitions take precedence.
+	TestDefinitionIDs []uuid.UUID
+	// TestOwners maps test definitions of TestDefinitionIDs to their owners.
+	// Definitions without an owner are absent.
+	TestOwners map[uuid.UUID]string
+	// FailedTestNames are the names of the failed tests of a run, for
+	// rule templates.
+	FailedTestNames []string
 	// PreviousRun contains the previous run for comparison (for recovery detection).
 	PreviousRun *database.TestRun
 	// Agent contains agent data (for agent events).
 	Agent *database.Agent
 	// FlakyTest contains flaky test data (for flaky detection).
 	FlakyTest *database.FlakyTest
+	// DurationAnomaly describes the deviation of the run's duration (for
+	// duration anomalies).
+	DurationAnomaly *DurationAnomaly
+	// DurationRegressions are the regressed run and tests (for duration
+	// regressions), the run itself first.
+	DurationRegressions []database.DurationRegression
 	// Timestamp is when the event occurred.
 	Timestamp time.Time
 	// Metadata contains additional event data.
@@ -138,6 +161,10 @@ func mapTriggerEvent(nt NotificationType) database.TriggerEvent {
 		return database.TriggerEventFlaky
 	case NotificationTypeTestQuarantined:
 		return database.TriggerEventFlaky
+	case NotificationTypeDurationAnomaly:
+		return database.TriggerEventDurationAnomaly
+	case NotificationTypeDurationRegression:
+		return database.TriggerEventDurationRegression
 	default:
 		return database.TriggerEventAlways
 	}
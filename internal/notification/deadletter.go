@@ -0,0 +1,188 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// maxDeadLetterDelay caps the backoff between automatic redeliveries of a
+// dead letter.
+const maxDeadLetterDelay = time.Hour
+
+// deadLetterBatchSize is the maximum number of dead letters redelivered per
+// interval.
+const deadLetterBatchSize = 100
+
+// DeadLetterMetrics records the depth of the dead-letter queue.
+// metrics.ControlPlaneMetrics implements it.
+type DeadLetterMetrics interface {
+	SetNotificationDeadLetters(pending, exhausted float64)
+}
+
+// SetMetrics sets where the depth of the dead-letter queue is reported.
+func (s *Service) SetMetrics(m DeadLetterMetrics) {
+	s.metrics = m
+}
+
+// deadLetter updates the dead-letter queue after a delivery. A failed
+// delivery is dead-lettered; a failed redelivery moves the dead letter of the
+// delivery it repeats, and a successful one removes it. Failing to update the
+// queue does not fail the delivery.
+func (s *Service) deadLetter(ctx context.Context, delivery *database.NotificationDelivery, sendErr error) {
+	var existing *database.NotificationDeadLetter
+	if delivery.RedeliveryOf != nil {
+		deadLetter, err := s.repo.GetDeadLetterByDelivery(ctx, *delivery.RedeliveryOf)
+		if err != nil && !database.IsNotFound(err) {
+			s.logger.Warn("failed to get notification dead letter",
+				"delivery_id", *delivery.RedeliveryOf,
+				"error", err,
+			)
+			return
+		}
+		existing = deadLetter
+	}
+
+	if sendErr == nil {
+		if existing != nil {
+			if err := s.repo.DeleteDeadLetter(ctx, existing.ID); err != nil && !database.IsNotFound(err) {
+				s.logger.Warn("failed to delete notification dead letter",
+					"dead_letter_id", existing.ID,
+					"error", err,
+				)
+			}
+		}
+		return
+	}
+
+	// Redeliveries of deliveries that are not dead-lettered are not queued,
+	// nor are deliveries that cannot be redelivered.
+	if (delivery.RedeliveryOf != nil && existing == nil) || delivery.ChannelID == nil || len(delivery.Payload) == 0 {
+		return
+	}
+
+	deadLetter := existing
+	if deadLetter == nil {
+		deadLetter = &database.NotificationDeadLetter{ChannelID: *delivery.ChannelID}
+	} else {
+		deadLetter.Attempts++
+	}
+	deadLetter.DeliveryID = delivery.ID
+	deadLetter.Error = sendErr.Error()
+	deadLetter.Transient = !isPermanent(sendErr)
+	deadLetter.NextAttemptAt = s.nextDeadLetterAttempt(deadLetter, time.Now())
+
+	var err error
+	if existing == nil {
+		err = s.repo.CreateDeadLetter(ctx, deadLetter)
+	} else {
+		err = s.repo.UpdateDeadLetter(ctx, deadLetter)
+	}
+	if err != nil {
+		s.logger.Warn("failed to dead-letter notification",
+			"delivery_id", delivery.ID,
+			"error", err,
+		)
+	}
+}
+
+// nextDeadLetterAttempt returns when a dead letter is redelivered next, or
+// nil when it is left for manual redelivery because its failure is
+// permanent, it ran out of attempts or automatic redelivery is disabled.
+// The delay doubles with every attempt.
+func (s *Service) nextDeadLetterAttempt(deadLetter *database.NotificationDeadLetter, now time.Time) *time.Time {
+	if !deadLetter.Transient || deadLetter.Attempts >= s.config.DeadLetterMaxAttempts || s.config.DeadLetterInterval <= 0 {
+		return nil
+	}
+
+	delay := maxDeadLetterDelay
+	if deadLetter.Attempts < 32 {
+		if d := s.config.DeadLetterInterval << deadLetter.Attempts; d > 0 && d < maxDeadLetterDelay {
+			delay = d
+		}
+	}
+	next := now.Add(delay)
+	return &next
+}
+
+// StartDeadLetterDrainer starts redelivering the dead letters that are due
+// every DeadLetterInterval, until ctx is cancelled. It is separate from
+// Start because dead letters are not claimed while they are redelivered,
+// so the drainer must run on one control plane replica at a time.
+func (s *Service) StartDeadLetterDrainer(ctx context.Context) {
+	if s.config.DeadLetterInterval <= 0 {
+		return
+	}
+	s.wg.Add(1)
+	go s.deadLetterDrainer(ctx)
+}
+
+// deadLetterDrainer periodically redelivers the dead letters that are due
+// and reports the depth of the queue.
+func (s *Service) deadLetterDrainer(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.DeadLetterInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.drainDeadLetters(ctx)
+			s.reportDeadLetters(ctx)
+		}
+	}
+}
+
+// drainDeadLetters redelivers the dead letters that are due. Redeliveries
+// update the queue themselves; dead letters that cannot be redelivered,
+// for example because their channel is disabled, are rescheduled.
+func (s *Service) drainDeadLetters(ctx context.Context) {
+	deadLetters, err := s.repo.ListDueDeadLetters(ctx, time.Now(), deadLetterBatchSize)
+	if err != nil {
+		s.logger.Error("failed to list notification dead letters", "error", err)
+		return
+	}
+
+	for i := range deadLetters {
+		if ctx.Err() != nil {
+			return
+		}
+
+		deadLetter := &deadLetters[i]
+		if _, err := s.Redeliver(ctx, deadLetter.DeliveryID); err != nil {
+			s.logger.Warn("failed to redeliver notification dead letter",
+				"dead_letter_id", deadLetter.ID,
+				"delivery_id", deadLetter.DeliveryID,
+				"error", err,
+			)
+
+			deadLetter.Attempts++
+			deadLetter.Error = err.Error()
+			deadLetter.NextAttemptAt = s.nextDeadLetterAttempt(deadLetter, time.Now())
+			if err := s.repo.UpdateDeadLetter(ctx, deadLetter); err != nil {
+				s.logger.Warn("failed to reschedule notification dead letter",
+					"dead_letter_id", deadLetter.ID,
+					"error", err,
+				)
+			}
+		}
+	}
+}
+
+// reportDeadLetters reports the depth of the dead-letter queue.
+func (s *Service) reportDeadLetters(ctx context.Context) {
+	if s.metrics == nil {
+		return
+	}
+
+	counts, err := s.repo.CountDeadLetters(ctx)
+	if err != nil {
+		s.logger.Warn("failed to count notification dead letters", "error", err)
+		return
+	}
+	s.metrics.SetNotificationDeadLetters(float64(counts.Pending), float64(counts.Exhausted))
+}
@@ -0,0 +1,148 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// deadLetterMetrics records the last reported queue depth.
+type deadLetterMetrics struct {
+	pending, exhausted float64
+}
+
+func (m *deadLetterMetrics) SetNotificationDeadLetters(pending, exhausted float64) {
+	m.pending, m.exhausted = pending, exhausted
+}
+
+func TestDeadLetter(t *testing.T) {
+	repo := &deliveryRepo{}
+	s := NewService(DefaultConfig(), repo, nil)
+	ctx := context.Background()
+	channelID := uuid.New()
+	failed := func(redeliveryOf *uuid.UUID) *database.NotificationDelivery {
+		return &database.NotificationDelivery{ID: uuid.New(), ChannelID: &channelID, Payload: []byte(`{}`), RedeliveryOf: redeliveryOf}
+	}
+
+	first := failed(nil)
+	s.deadLetter(ctx, first, errors.New("connection refused"))
+	require.Len(t, repo.deadLetters, 1)
+	deadLetter := repo.deadLetters[0]
+	assert.Equal(t, first.ID, deadLetter.DeliveryID)
+	assert.Equal(t, channelID, deadLetter.ChannelID)
+	assert.Equal(t, "connection refused", deadLetter.Error)
+	assert.True(t, deadLetter.Transient)
+	assert.Equal(t, 0, deadLetter.Attempts)
+	require.NotNil(t, deadLetter.NextAttemptAt)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), *deadLetter.NextAttemptAt, time.Second)
+
+	// A failed redelivery moves the dead letter to the new delivery
+	second := failed(&first.ID)
+	s.deadLetter(ctx, second, permanent(errors.New("webhook returned status 410")))
+	require.Len(t, repo.deadLetters, 1)
+	assert.Equal(t, second.ID, deadLetter.DeliveryID)
+	assert.Equal(t, 1, deadLetter.Attempts)
+	assert.False(t, deadLetter.Transient)
+	assert.Nil(t, deadLetter.NextAttemptAt, "permanent failures are not retried automatically")
+
+	// Redeliveries of deliveries that are not dead-lettered are not queued
+	s.deadLetter(ctx, failed(&first.ID), errors.New("timeout"))
+	assert.Len(t, repo.deadLetters, 1)
+
+	// Deliveries that cannot be redelivered are not queued
+	s.deadLetter(ctx, &database.NotificationDelivery{ID: uuid.New(), ChannelID: &channelID}, errors.New("timeout"))
+	assert.Len(t, repo.deadLetters, 1)
+
+	// A successful redelivery removes the dead letter
+	s.deadLetter(ctx, &database.NotificationDelivery{ID: uuid.New(), RedeliveryOf: &second.ID}, nil)
+	assert.Empty(t, repo.deadLetters)
+}
+
+func TestNextDeadLetterAttempt(t *testing.T) {
+	s := NewService(DefaultConfig(), &deliveryRepo{}, nil)
+	now := time.Now()
+	next := func(attempts int, transient bool) *time.Time {
+		return s.nextDeadLetterAttempt(&database.NotificationDeadLetter{Attempts: attempts, Transient: transient}, now)
+	}
+
+	assert.Equal(t, now.Add(time.Minute), *next(0, true))
+	assert.Equal(t, now.Add(4*time.Minute), *next(2, true), "the delay doubles with every attempt")
+	assert.Nil(t, next(5, true), "attempts are exhausted")
+	assert.Nil(t, next(0, false), "permanent failures are not retried")
+
+	s.config.DeadLetterMaxAttempts = 100
+	assert.Equal(t, now.Add(maxDeadLetterDelay), *next(10, true))
+	assert.Equal(t, now.Add(maxDeadLetterDelay), *next(70, true))
+
+	s.config.DeadLetterInterval = 0
+	assert.Nil(t, next(0, true), "automatic redelivery is disabled")
+}
+
+func TestDrainDeadLetters(t *testing.T) {
+	status := http.StatusBadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	config, err := json.Marshal(database.WebhookChannelConfig{URL: server.URL})
+	require.NoError(t, err)
+	channelID := uuid.New()
+	repo := &deliveryRepo{channel: &database.NotificationChannel{
+		ID:      channelID,
+		Type:    database.ChannelTypeWebhook,
+		Config:  config,
+		Enabled: true,
+	}}
+	metrics := &deadLetterMetrics{}
+	s := NewService(DefaultConfig(), repo, nil)
+	s.SetMetrics(metrics)
+	ctx := context.Background()
+
+	s.processJob(ctx, &notificationJob{
+		notification: &Notification{ID: uuid.New(), Type: NotificationTypeRunFailed, CreatedAt: time.Now()},
+		channel:      NewWebhookChannel(WebhookConfig{URL: server.URL}, nil),
+		channelID:    channelID,
+	})
+	require.Len(t, repo.deadLetters, 1)
+	deadLetter := repo.deadLetters[0]
+	assert.False(t, deadLetter.Transient, "rejected requests fail permanently")
+	assert.Nil(t, deadLetter.NextAttemptAt)
+
+	s.reportDeadLetters(ctx)
+	assert.Equal(t, deadLetterMetrics{pending: 0, exhausted: 1}, *metrics)
+
+	// Dead letters of disabled channels are rescheduled
+	past := time.Now().Add(-time.Second)
+	deadLetter.Transient, deadLetter.NextAttemptAt = true, &past
+	repo.channel.Enabled = false
+	s.drainDeadLetters(ctx)
+	require.Len(t, repo.deadLetters, 1)
+	assert.Equal(t, 1, deadLetter.Attempts)
+	assert.Contains(t, deadLetter.Error, "disabled")
+	require.NotNil(t, deadLetter.NextAttemptAt)
+	assert.True(t, deadLetter.NextAttemptAt.After(time.Now()))
+
+	s.reportDeadLetters(ctx)
+	assert.Equal(t, deadLetterMetrics{pending: 1, exhausted: 0}, *metrics)
+
+	// Due dead letters are redelivered and removed once delivered
+	repo.channel.Enabled = true
+	deadLetter.NextAttemptAt = &past
+	status = http.StatusOK
+	s.drainDeadLetters(ctx)
+	assert.Empty(t, repo.deadLetters)
+	require.Len(t, repo.deliveries, 2)
+	assert.Equal(t, database.DeliveryStatusSent, repo.deliveries[1].Status)
+	assert.Equal(t, &repo.deliveries[0].ID, repo.deliveries[1].RedeliveryOf)
+}
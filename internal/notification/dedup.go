@@ -0,0 +1,135 @@
+package notification
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// channelMatch groups all rules that matched an event for a single channel.
+type channelMatch struct {
+	Channel *database.NotificationChannel
+	Rules   []*database.NotificationRule
+}
+
+// groupMatchesByChannel collapses rule matches that target the same channel,
+// preserving the order in which channels were first matched.
+func groupMatchesByChannel(matches []RuleMatch) []channelMatch {
+	index := make(map[uuid.UUID]int)
+	var groups []channelMatch
+
+	for _, match := range matches {
+		i, ok := index[match.Channel.ID]
+		if !ok {
+			i = len(groups)
+			index[match.Channel.ID] = i
+			groups = append(groups, channelMatch{Channel: match.Channel})
+		}
+		groups[i].Rules = append(groups[i].Rules, match.Rule)
+	}
+
+	return groups
+}
+
+// matchReason describes why a rule matched, for collapsed notifications.
+func matchReason(rule *database.NotificationRule) string {
+	scope := strings.ReplaceAll(RuleScope(rule), "_", " ")
+
+	triggers := make([]string, len(rule.TriggerOn))
+	for i, t := range rule.TriggerOn {
+		triggers[i] = string(t)
+	}
+	sort.Strings(triggers)
+
+	return fmt.Sprintf("%s rule %s on %s", scope, rule.ID.String()[:8], strings.Join(triggers, ", "))
+}
+
+// eventKey identifies a single occurrence of an event so that repeated
+// deliveries of the same event can be deduplicated.
+func eventKey(event *Event) string {
+	key := string(event.Type) + ":" + event.ServiceID.String()
+
+	switch {
+	case event.RunID != nil:
+		return key + ":run:" + event.RunID.String()
+	case event.Run != nil:
+		return key + ":run:" + event.Run.ID.String()
+	case event.FlakyTest != nil:
+		return key + ":test:" + event.FlakyTest.ID.String()
+	case event.Agent != nil:
+		return key + ":agent:" + event.Agent.ID.String()
+	default:
+		return key + ":at:" + strconv.FormatInt(event.Timestamp.UnixNano(), 10)
+	}
+}
+
+// dedupCache remembers which (event, channel) pairs have already been
+// notified within a time window.
+type dedupCache struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[string]time.Time
+}
+
+// newDedupCache creates a dedup cache. A zero window disables deduplication
+// across calls; duplicates within a single event are still collapsed.
+func newDedupCache(window time.Duration) *dedupCache {
+	return &dedupCache{
+		window: window,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// claim records the (event, channel) pair and reports whether it had not
+// been seen within the window.
+func (d *dedupCache) claim(event *Event, channelID uuid.UUID) bool {
+	key := eventKey(event) + ":" + channelID.String()
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.window <= 0 {
+		return true
+	}
+	if sent, ok := d.seen[key]; ok && now.Sub(sent) < d.window {
+		return false
+	}
+	d.seen[key] = now
+	return true
+}
+
+// release forgets a pair, e.g. when the notification could not be queued.
+func (d *dedupCache) release(event *Event, channelID uuid.UUID) {
+	d.mu.Lock()
+	delete(d.seen, eventKey(event)+":"+channelID.String())
+	d.mu.Unlock()
+}
+
+// setWindow changes the window. Pairs seen within the new window keep
+// suppressing repeats.
+func (d *dedupCache) setWindow(window time.Duration) {
+	d.mu.Lock()
+	d.window = window
+	d.mu.Unlock()
+}
+
+// cleanup removes expired entries.
+func (d *dedupCache) cleanup() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for key, sent := range d.seen {
+		if now.Sub(sent) > d.window {
+			delete(d.seen, key)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package notification
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+func TestGroupMatchesByChannel(t *testing.T) {
+	chA := &database.NotificationChannel{ID: uuid.New()}
+	chB := &database.NotificationChannel{ID: uuid.New()}
+	r1 := &database.NotificationRule{ID: uuid.New()}
+	r2 := &database.NotificationRule{ID: uuid.New()}
+	r3 := &database.NotificationRule{ID: uuid.New()}
+
+	groups := groupMatchesByChannel([]RuleMatch{
+		{Rule: r1, Channel: chA},
+		{Rule: r2, Channel: chB},
+		{Rule: r3, Channel: chA},
+	})
+
+	require.Len(t, groups, 2)
+	assert.Equal(t, chA.ID, groups[0].Channel.ID)
+	assert.Equal(t, []*database.NotificationRule{r1, r3}, groups[0].Rules)
+	assert.Equal(t, chB.ID, groups[1].Channel.ID)
+	assert.Len(t, groups[1].Rules, 1)
+}
+
+func TestDedupCacheClaim(t *testing.T) {
+	runID := uuid.New()
+	channelID := uuid.New()
+	event := &Event{Type: NotificationTypeRunFailed, ServiceID: uuid.New(), RunID: &runID}
+
+	cache := newDedupCache(time.Minute)
+	assert.True(t, cache.claim(event, channelID))
+	assert.False(t, cache.claim(event, channelID), "same event and channel should be deduplicated")
+	assert.True(t, cache.claim(event, uuid.New()), "other channels should still be notified")
+
+	other := *event
+	other.Type = NotificationTypeRunRecovered
+	assert.True(t, cache.claim(&other, channelID), "other event types should still be notified")
+
+	cache.release(event, channelID)
+	assert.True(t, cache.claim(event, channelID), "released pairs can be claimed again")
+
+	disabled := newDedupCache(0)
+	assert.True(t, disabled.claim(event, channelID))
+	assert.True(t, disabled.claim(event, channelID))
+}
+
+func TestDedupCacheSetWindow(t *testing.T) {
+	runID := uuid.New()
+	channelID := uuid.New()
+	event := &Event{Type: NotificationTypeRunFailed, ServiceID: uuid.New(), RunID: &runID}
+
+	cache := newDedupCache(0)
+	assert.True(t, cache.claim(event, channelID))
+
+	cache.setWindow(time.Minute)
+	assert.True(t, cache.claim(event, channelID))
+	assert.False(t, cache.claim(event, channelID), "pairs should be deduplicated once a window is set")
+
+	cache.setWindow(0)
+	assert.True(t, cache.claim(event, channelID), "pairs should not be deduplicated once the window is removed")
+}
+
+func TestNotificationForGroupCollapsesRules(t *testing.T) {
+	serviceID := uuid.New()
+	group := channelMatch{
+		Channel: &database.NotificationChannel{ID: uuid.New()},
+		Rules: []*database.NotificationRule{
+			{ID: uuid.New(), TriggerOn: []database.TriggerEvent{database.TriggerEventFailure}},
+			{ID: uuid.New(), ServiceID: &serviceID, TriggerOn: []database.TriggerEvent{database.TriggerEventAlways}},
+		},
+	}
+	base := &Notification{Title: "Run failed", Message: "3 tests failed"}
+
+	s := &Service{config: Config{CollapseRules: false}}
+	assert.Same(t, base, s.notificationForGroup(base, group))
+
+	s.config.CollapseRules = true
+	collapsed := s.notificationForGroup(base, group)
+	require.Len(t, collapsed.MatchedRules, 2)
+	assert.True(t, strings.HasPrefix(collapsed.MatchedRules[0], "global rule"))
+	assert.True(t, strings.HasPrefix(collapsed.MatchedRules[1], "service rule"))
+	assert.Contains(t, collapsed.Message, "Matched rules:")
+	assert.Equal(t, "3 tests failed", base.Message, "original notification must not be modified")
+}
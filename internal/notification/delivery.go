@@ -0,0 +1,106 @@
+package notification
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrNotRedeliverable is returned when a delivery cannot be redelivered.
+var ErrNotRedeliverable = errors.New("delivery cannot be redelivered")
+
+// permanentError is a failure that retrying cannot fix, such as a request
+// the channel rejected.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// permanent marks err as a failure that retrying cannot fix.
+func permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// isPermanent reports whether err is a failure that retrying cannot fix.
+func isPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// maxResponseExcerpt is the maximum length of the response body kept with a
+// delivery.
+const maxResponseExcerpt = 512
+
+// deliveryTrace collects the requests a channel made to deliver one
+// notification.
+type deliveryTrace struct {
+	mu       sync.Mutex
+	attempts int
+	response string
+}
+
+type deliveryTraceKey struct{}
+
+// withDeliveryTrace returns a context that records the attempts of the
+// channel sending with it.
+func withDeliveryTrace(ctx context.Context) (context.Context, *deliveryTrace) {
+	trace := &deliveryTrace{}
+	return context.WithValue(ctx, deliveryTraceKey{}, trace), trace
+}
+
+// traceAttempt records a request made by a channel and the response body it
+// received, if any. It does nothing when the context carries no trace.
+func traceAttempt(ctx context.Context, body []byte) {
+	trace, ok := ctx.Value(deliveryTraceKey{}).(*deliveryTrace)
+	if !ok {
+		return
+	}
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	trace.attempts++
+	if body != nil {
+		trace.response = responseExcerpt(body)
+	}
+}
+
+// result returns the number of attempts and the last response excerpt.
+// Channels that do not record attempts count as a single attempt.
+func (t *deliveryTrace) result() (int, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.attempts == 0 {
+		return 1, t.response
+	}
+	return t.attempts, t.response
+}
+
+// responseExcerpt truncates a response body to maxResponseExcerpt bytes
+// without splitting a UTF-8 sequence.
+func responseExcerpt(body []byte) string {
+	if len(body) <= maxResponseExcerpt {
+		return string(body)
+	}
+	cut := maxResponseExcerpt
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return string(body[:cut]) + "..."
+}
+
+// encodePayload returns the notification as stored with a delivery and the
+// hex SHA-256 of the encoding.
+func encodePayload(notification *Notification) ([]byte, string, error) {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(payload)
+	return payload, hex.EncodeToString(sum[:]), nil
+}
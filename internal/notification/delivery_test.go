@@ -0,0 +1,242 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// deliveryRepo records the deliveries and dead letters of a service and
+// serves a single channel.
+type deliveryRepo struct {
+	database.NotificationRepository
+	deliveries  []*database.NotificationDelivery
+	deadLetters []*database.NotificationDeadLetter
+	channel     *database.NotificationChannel
+}
+
+func (r *deliveryRepo) CreateDeadLetter(ctx context.Context, deadLetter *database.NotificationDeadLetter) error {
+	deadLetter.ID = uuid.New()
+	r.deadLetters = append(r.deadLetters, deadLetter)
+	return nil
+}
+
+func (r *deliveryRepo) GetDeadLetterByDelivery(ctx context.Context, deliveryID uuid.UUID) (*database.NotificationDeadLetter, error) {
+	for _, d := range r.deadLetters {
+		if d.DeliveryID == deliveryID {
+			return d, nil
+		}
+	}
+	return nil, database.ErrNotFound
+}
+
+func (r *deliveryRepo) UpdateDeadLetter(ctx context.Context, deadLetter *database.NotificationDeadLetter) error {
+	for _, d := range r.deadLetters {
+		if d.ID == deadLetter.ID {
+			*d = *deadLetter
+			return nil
+		}
+	}
+	return database.ErrNotFound
+}
+
+func (r *deliveryRepo) DeleteDeadLetter(ctx context.Context, id uuid.UUID) error {
+	for i, d := range r.deadLetters {
+		if d.ID == id {
+			r.deadLetters = append(r.deadLetters[:i], r.deadLetters[i+1:]...)
+			return nil
+		}
+	}
+	return database.ErrNotFound
+}
+
+func (r *deliveryRepo) CountDeadLetters(ctx context.Context) (*database.NotificationDeadLetterCounts, error) {
+	var counts database.NotificationDeadLetterCounts
+	for _, d := range r.deadLetters {
+		if d.NextAttemptAt != nil {
+			counts.Pending++
+		} else {
+			counts.Exhausted++
+		}
+	}
+	return &counts, nil
+}
+
+func (r *deliveryRepo) ListDueDeadLetters(ctx context.Context, now time.Time, limit int) ([]database.NotificationDeadLetter, error) {
+	var due []database.NotificationDeadLetter
+	for _, d := range r.deadLetters {
+		if d.NextAttemptAt != nil && !d.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, *d)
+		}
+	}
+	return due, nil
+}
+
+func (r *deliveryRepo) RecordDelivery(ctx context.Context, delivery *database.NotificationDelivery) error {
+	delivery.ID = uuid.New()
+	r.deliveries = append(r.deliveries, delivery)
+	return nil
+}
+
+func (r *deliveryRepo) GetDelivery(ctx context.Context, id uuid.UUID) (*database.NotificationDelivery, error) {
+	for _, d := range r.deliveries {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+	return nil, database.ErrNotFound
+}
+
+func (r *deliveryRepo) GetChannel(ctx context.Context, id uuid.UUID) (*database.NotificationChannel, error) {
+	if r.channel == nil || r.channel.ID != id {
+		return nil, database.ErrNotFound
+	}
+	return r.channel, nil
+}
+
+func TestProcessJobRecordsDelivery(t *testing.T) {
+	status, body := http.StatusOK, "accepted"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	repo := &deliveryRepo{}
+	s := NewService(DefaultConfig(), repo, nil)
+
+	serviceID, runID, ruleID := uuid.New(), uuid.New(), uuid.New()
+	job := &notificationJob{
+		notification: &Notification{Type: NotificationTypeRunFailed, ServiceID: &serviceID, RunID: &runID, CreatedAt: time.Now()},
+		channel:      NewWebhookChannel(WebhookConfig{URL: server.URL}, nil),
+		channelID:    uuid.New(),
+		ruleIDs:      []uuid.UUID{ruleID},
+	}
+
+	s.processJob(context.Background(), job)
+	require.Len(t, repo.deliveries, 1)
+	sent := repo.deliveries[0]
+	assert.Equal(t, database.DeliveryStatusSent, sent.Status)
+	assert.Equal(t, "run_failed", sent.EventType)
+	assert.Equal(t, &serviceID, sent.ServiceID)
+	assert.Equal(t, &runID, sent.RunID)
+	assert.Equal(t, job.channelID, *sent.ChannelID)
+	assert.Equal(t, database.ChannelTypeWebhook, sent.ChannelType)
+	assert.Equal(t, []uuid.UUID{ruleID}, sent.RuleIDs)
+	assert.Equal(t, 1, sent.Attempts)
+	assert.Equal(t, "accepted", sent.ResponseExcerpt)
+	assert.Empty(t, sent.Error)
+	assert.NotEmpty(t, sent.Payload)
+	assert.Len(t, sent.PayloadHash, 64)
+	assert.Nil(t, sent.RedeliveryOf)
+
+	// Client errors are not retried
+	status, body = http.StatusBadRequest, "invalid payload"
+	s.processJob(context.Background(), job)
+	require.Len(t, repo.deliveries, 2)
+	failed := repo.deliveries[1]
+	assert.Equal(t, database.DeliveryStatusFailed, failed.Status)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Equal(t, "invalid payload", failed.ResponseExcerpt)
+	assert.Contains(t, failed.Error, "status 400")
+}
+
+func TestRedeliver(t *testing.T) {
+	status := http.StatusBadRequest
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	config, err := json.Marshal(database.WebhookChannelConfig{URL: server.URL})
+	require.NoError(t, err)
+	channelID := uuid.New()
+	repo := &deliveryRepo{channel: &database.NotificationChannel{
+		ID:      channelID,
+		Type:    database.ChannelTypeWebhook,
+		Config:  config,
+		Enabled: true,
+	}}
+	s := NewService(DefaultConfig(), repo, nil)
+
+	serviceID, ruleID := uuid.New(), uuid.New()
+	s.processJob(context.Background(), &notificationJob{
+		notification: &Notification{ID: uuid.New(), Type: NotificationTypeRunFailed, ServiceID: &serviceID, Title: "Tests Failed - checkout", CreatedAt: time.Now()},
+		channel:      NewWebhookChannel(WebhookConfig{URL: server.URL}, nil),
+		channelID:    channelID,
+		ruleIDs:      []uuid.UUID{ruleID},
+	})
+	require.Len(t, repo.deliveries, 1)
+	failed := repo.deliveries[0]
+	require.Equal(t, database.DeliveryStatusFailed, failed.Status)
+
+	status = http.StatusOK
+	redelivery, err := s.Redeliver(context.Background(), failed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, database.DeliveryStatusSent, redelivery.Status)
+	assert.Equal(t, &failed.ID, redelivery.RedeliveryOf)
+	assert.Equal(t, failed.PayloadHash, redelivery.PayloadHash, "the same notification is sent again")
+	assert.Equal(t, []uuid.UUID{ruleID}, redelivery.RuleIDs)
+	assert.Equal(t, &serviceID, redelivery.ServiceID)
+	require.Len(t, received, 2)
+	assert.Equal(t, received[0], received[1])
+
+	// Only failed deliveries are redelivered
+	_, err = s.Redeliver(context.Background(), redelivery.ID)
+	assert.ErrorIs(t, err, ErrNotRedeliverable)
+
+	repo.channel.Enabled = false
+	_, err = s.Redeliver(context.Background(), failed.ID)
+	assert.ErrorIs(t, err, ErrNotRedeliverable, "disabled channels are not redelivered to")
+
+	failed.ChannelID = nil
+	_, err = s.Redeliver(context.Background(), failed.ID)
+	assert.ErrorIs(t, err, ErrNotRedeliverable, "deleted channels are not redelivered to")
+
+	_, err = s.Redeliver(context.Background(), uuid.New())
+	assert.True(t, database.IsNotFound(err))
+}
+
+func TestDeliveryTrace(t *testing.T) {
+	// Channels that do not trace count as one attempt
+	_, trace := withDeliveryTrace(context.Background())
+	attempts, excerpt := trace.result()
+	assert.Equal(t, 1, attempts)
+	assert.Empty(t, excerpt)
+
+	ctx, trace := withDeliveryTrace(context.Background())
+	traceAttempt(ctx, nil)
+	traceAttempt(ctx, []byte("rate limited"))
+	traceAttempt(ctx, []byte("ok"))
+	attempts, excerpt = trace.result()
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, "ok", excerpt)
+
+	// Untraced contexts are ignored
+	traceAttempt(context.Background(), []byte("ok"))
+}
+
+func TestResponseExcerpt(t *testing.T) {
+	assert.Equal(t, "short", responseExcerpt([]byte("short")))
+
+	long := responseExcerpt([]byte(strings.Repeat("a", maxResponseExcerpt+10)))
+	assert.Equal(t, strings.Repeat("a", maxResponseExcerpt)+"...", long)
+
+	// Multi-byte characters are not split
+	multi := responseExcerpt([]byte(strings.Repeat("a", maxResponseExcerpt-1) + "é"))
+	assert.Equal(t, strings.Repeat("a", maxResponseExcerpt-1)+"...", multi)
+}
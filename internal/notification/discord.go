@@ -0,0 +1,232 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// DiscordChannel implements the Channel interface for Discord webhook notifications.
+type DiscordChannel struct {
+	webhookURL string
+	username   string
+	avatarURL  string
+	client     *http.Client
+	logger     *slog.Logger
+}
+
+// DiscordConfig contains configuration for a Discord channel.
+type DiscordConfig struct {
+	WebhookURL string
+	Username   string
+	AvatarURL  string
+}
+
+// NewDiscordChannel creates a new Discord notification channel.
+func NewDiscordChannel(cfg DiscordConfig, logger *slog.Logger) *DiscordChannel {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &DiscordChannel{
+		webhookURL: cfg.WebhookURL,
+		username:   cfg.Username,
+		avatarURL:  cfg.AvatarURL,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		logger: logger.With("channel", "discord"),
+	}
+}
+
+// Type returns the channel type.
+func (c *DiscordChannel) Type() database.ChannelType {
+	return database.ChannelTypeDiscord
+}
+
+// Validate validates the Discord configuration.
+func (c *DiscordChannel) Validate() error {
+	if c.webhookURL == "" {
+		return fmt.Errorf("Discord webhook URL is required")
+	}
+	return nil
+}
+
+// Send sends a notification to Discord.
+func (c *DiscordChannel) Send(ctx context.Context, notification *Notification) error {
+	payload := c.formatMessage(notification)
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Discord payload: %w", err)
+	}
+
+	// Send with retry
+	var lastErr error
+	maxRetries := 3
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.webhookURL, bytes.NewReader(jsonPayload))
+		if err != nil {
+			return fmt.Errorf("failed to create Discord request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			traceAttempt(ctx, nil)
+			lastErr = fmt.Errorf("Discord request failed: %w", err)
+			c.logger.Warn("Discord request failed, retrying",
+				"attempt", attempt+1,
+				"error", err,
+			)
+			continue
+		}
+
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		traceAttempt(ctx, body)
+		resp.Body.Close()
+
+		// Discord webhooks return 204 No Content, or 200 with ?wait=true
+		if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusOK {
+			c.logger.Debug("Discord notification sent",
+				"notification_type", notification.Type,
+			)
+			return nil
+		}
+
+		lastErr = fmt.Errorf("Discord returned status %d: %s", resp.StatusCode, string(body))
+
+		// Don't retry on client errors except rate limits
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != 429 {
+			return permanent(lastErr)
+		}
+	}
+
+	return lastErr
+}
+
+// formatMessage formats the notification as a Discord embed, the
+// equivalent of a Slack attachment: a status color bar, the message, the
+// run summary as fields and a link to the details.
+func (c *DiscordChannel) formatMessage(notification *Notification) map[string]interface{} {
+	embed := map[string]interface{}{
+		"title":       truncate(notification.Title, 256),
+		"description": truncate(notification.Message, 4096),
+		"color":       c.getColor(notification.Type),
+		"footer": map[string]interface{}{
+			"text": fmt.Sprintf("Service: %s", notification.ServiceName),
+		},
+	}
+	if notification.URL != "" {
+		embed["url"] = notification.URL
+	}
+	if !notification.CreatedAt.IsZero() {
+		embed["timestamp"] = notification.CreatedAt.Format(time.RFC3339)
+	}
+
+	if notification.Summary != nil {
+		fields := []map[string]interface{}{
+			{
+				"name": "Tests",
+				"value": fmt.Sprintf("%d total, %d passed, %d failed, %d skipped",
+					notification.Summary.TotalTests,
+					notification.Summary.PassedTests,
+					notification.Summary.FailedTests,
+					notification.Summary.SkippedTests),
+				"inline": false,
+			},
+		}
+
+		if notification.Summary.DurationMs > 0 {
+			duration := time.Duration(notification.Summary.DurationMs) * time.Millisecond
+			fields = append(fields, map[string]interface{}{
+				"name":   "Duration",
+				"value":  duration.Round(time.Second).String(),
+				"inline": true,
+			})
+		}
+
+		if notification.Summary.Branch != "" {
+			fields = append(fields, map[string]interface{}{
+				"name":   "Branch",
+				"value":  fmt.Sprintf("`%s`", notification.Summary.Branch),
+				"inline": true,
+			})
+		}
+
+		if notification.Summary.CommitSHA != "" {
+			shortSHA := notification.Summary.CommitSHA
+			if len(shortSHA) > 7 {
+				shortSHA = shortSHA[:7]
+			}
+			fields = append(fields, map[string]interface{}{
+				"name":   "Commit",
+				"value":  fmt.Sprintf("`%s`", shortSHA),
+				"inline": true,
+			})
+		}
+
+		if notification.Summary.ErrorMessage != "" {
+			fields = append(fields, map[string]interface{}{
+				"name":   "Error",
+				"value":  fmt.Sprintf("```%s```", truncate(notification.Summary.ErrorMessage, 500)),
+				"inline": false,
+			})
+		}
+
+		embed["fields"] = fields
+	}
+
+	payload := map[string]interface{}{
+		"embeds": []map[string]interface{}{embed},
+		// Mentions in test output must not ping anyone.
+		"allowed_mentions": map[string]interface{}{
+			"parse": []string{},
+		},
+	}
+	if c.username != "" {
+		payload["username"] = c.username
+	}
+	if c.avatarURL != "" {
+		payload["avatar_url"] = c.avatarURL
+	}
+
+	return payload
+}
+
+// getColor returns the embed color for the notification type.
+func (c *DiscordChannel) getColor(notificationType NotificationType) int {
+	switch notificationType {
+	case NotificationTypeRunPassed, NotificationTypeRunRecovered:
+		return 0x36a64f // Green
+	case NotificationTypeRunFailed, NotificationTypeRunError, NotificationTypeRunTimeout:
+		return 0xdc3545 // Red
+	case NotificationTypeFlakyDetected, NotificationTypeTestQuarantined, NotificationTypeDurationAnomaly, NotificationTypeDurationRegression:
+		return 0xffc107 // Yellow
+	case NotificationTypeRunStarted:
+		return 0x17a2b8 // Blue
+	case NotificationTypeAgentOffline:
+		return 0xdc3545 // Red
+	case NotificationTypeAgentOnline:
+		return 0x36a64f // Green
+	default:
+		return 0x6c757d // Gray
+	}
+}
@@ -0,0 +1,81 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+func TestDiscordChannelSend(t *testing.T) {
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	channel := NewDiscordChannel(DiscordConfig{WebhookURL: server.URL, Username: "Conductor"}, nil)
+	require.NoError(t, channel.Validate())
+	assert.Equal(t, database.ChannelTypeDiscord, channel.Type())
+
+	err := channel.Send(context.Background(), &Notification{
+		Type:        NotificationTypeRunFailed,
+		Title:       "Run failed",
+		Message:     "3 tests failed",
+		ServiceName: "payments",
+		URL:         "https://conductor.test/runs/1",
+		CreatedAt:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Summary: &RunSummary{
+			TotalTests:   10,
+			PassedTests:  7,
+			FailedTests:  3,
+			Branch:       "main",
+			CommitSHA:    "0123456789abcdef",
+			ErrorMessage: "boom",
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Conductor", payload["username"])
+	embeds := payload["embeds"].([]interface{})
+	require.Len(t, embeds, 1)
+	embed := embeds[0].(map[string]interface{})
+	assert.Equal(t, "Run failed", embed["title"])
+	assert.Equal(t, "3 tests failed", embed["description"])
+	assert.Equal(t, float64(0xdc3545), embed["color"])
+	assert.Equal(t, "https://conductor.test/runs/1", embed["url"])
+	assert.Equal(t, "2026-01-02T03:04:05Z", embed["timestamp"])
+	assert.Equal(t, "Service: payments", embed["footer"].(map[string]interface{})["text"])
+
+	fields := embed["fields"].([]interface{})
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.(map[string]interface{})["name"].(string)
+	}
+	assert.Equal(t, []string{"Tests", "Branch", "Commit", "Error"}, names)
+	assert.Equal(t, "`0123456`", fields[2].(map[string]interface{})["value"])
+}
+
+func TestDiscordChannelSendClientError(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		http.Error(w, `{"message": "Invalid Webhook Token"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := NewDiscordChannel(DiscordConfig{WebhookURL: server.URL}, nil).Send(context.Background(), &Notification{Title: "Run passed"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Discord returned status 401")
+	assert.Equal(t, 1, attempts, "client errors are not retried")
+
+	assert.Error(t, NewDiscordChannel(DiscordConfig{}, nil).Validate())
+}
@@ -328,6 +328,10 @@ func (c *EmailChannel) getSubjectPrefix(notificationType NotificationType) strin
 		return "[RECOVERED]"
 	case NotificationTypeFlakyDetected:
 		return "[FLAKY]"
+	case NotificationTypeDurationAnomaly:
+		return "[DURATION]"
+	case NotificationTypeDurationRegression:
+		return "[REGRESSION]"
 	case NotificationTypeRunStarted:
 		return "[STARTED]"
 	default:
@@ -401,7 +405,7 @@ func (c *EmailChannel) getStatusColor(notificationType NotificationType) string
 		return "#36a64f"
 	case NotificationTypeRunFailed, NotificationTypeRunError, NotificationTypeRunTimeout:
 		return "#dc3545"
-	case NotificationTypeFlakyDetected, NotificationTypeTestQuarantined:
+	case NotificationTypeFlakyDetected, NotificationTypeTestQuarantined, NotificationTypeDurationAnomaly, NotificationTypeDurationRegression:
 		return "#ffc107"
 	default:
 		return "#17a2b8"
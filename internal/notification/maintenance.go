@@ -0,0 +1,60 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/schedule"
+)
+
+// maintenanceTitlePrefix marks the title of notifications sent during a
+// maintenance window that tags them.
+const maintenanceTitlePrefix = "[Maintenance] "
+
+// SetMaintenanceWindows enables maintenance windows. Events of a service,
+// or of an agent of a pool, in maintenance are not notified, or are sent
+// tagged if the window tags notifications.
+func (s *Service) SetMaintenanceWindows(repo database.MaintenanceWindowRepository) {
+	s.maintenanceRepo = repo
+}
+
+// maintenanceWindow returns the maintenance window active for an event, or
+// nil if there is none. Windows that cannot be loaded are logged and
+// ignored, so that notifications are not lost.
+func (s *Service) maintenanceWindow(ctx context.Context, event *Event) *schedule.Window {
+	if s.maintenanceRepo == nil {
+		return nil
+	}
+
+	at := event.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	windows, err := schedule.ActiveMaintenance(ctx, s.maintenanceRepo, at)
+	if err != nil {
+		s.logger.Warn("failed to load maintenance windows", "error", err)
+		return nil
+	}
+
+	if window := windows.ForService(event.ServiceID); window != nil {
+		return window
+	}
+	if event.Agent != nil && event.Agent.Pool != nil {
+		return windows.ForPool(*event.Agent.Pool)
+	}
+	return nil
+}
+
+// tagMaintenance returns a copy of a notification marked as sent during a
+// maintenance window.
+func tagMaintenance(notification *Notification, window *schedule.Window) *Notification {
+	tagged := *notification
+	tagged.Title = maintenanceTitlePrefix + notification.Title
+	tagged.Metadata = make(map[string]string, len(notification.Metadata)+1)
+	for k, v := range notification.Metadata {
+		tagged.Metadata[k] = v
+	}
+	tagged.Metadata["maintenance_window"] = window.Name
+	return &tagged
+}
@@ -0,0 +1,87 @@
+package notification
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// maintenanceRepo serves fixed maintenance windows.
+type maintenanceRepo struct {
+	database.MaintenanceWindowRepository
+	windows []database.MaintenanceWindow
+}
+
+func (r *maintenanceRepo) ListEnabled(ctx context.Context) ([]database.MaintenanceWindow, error) {
+	return r.windows, nil
+}
+
+func TestMaintenanceWindows(t *testing.T) {
+	serviceID := uuid.New()
+	pool := "gpu"
+	startsAt := time.Now().Add(-time.Hour)
+	endsAt := time.Now().Add(time.Hour)
+
+	newService := func(windows ...database.MaintenanceWindow) *Service {
+		// The notification repository is left nil: suppressed events must
+		// not load any rules
+		s := NewService(DefaultConfig(), nil, nil)
+		s.SetMaintenanceWindows(&maintenanceRepo{windows: windows})
+		return s
+	}
+
+	t.Run("suppresses events of services in maintenance", func(t *testing.T) {
+		s := newService(database.MaintenanceWindow{
+			Name:      "upgrade",
+			ServiceID: &serviceID,
+			StartsAt:  &startsAt,
+			EndsAt:    &endsAt,
+		})
+
+		results, err := s.ProcessRules(context.Background(), &Event{Type: NotificationTypeRunFailed, ServiceID: serviceID})
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("finds windows of the agent's pool", func(t *testing.T) {
+		s := newService(database.MaintenanceWindow{
+			Name:      "reimage",
+			AgentPool: &pool,
+			StartsAt:  &startsAt,
+			EndsAt:    &endsAt,
+		})
+
+		window := s.maintenanceWindow(context.Background(), &Event{
+			ServiceID: uuid.New(),
+			Agent:     &database.Agent{Pool: &pool},
+		})
+		require.NotNil(t, window)
+		assert.Equal(t, "reimage", window.Name)
+
+		assert.Nil(t, s.maintenanceWindow(context.Background(), &Event{ServiceID: uuid.New()}))
+	})
+
+	t.Run("tags notifications", func(t *testing.T) {
+		s := newService(database.MaintenanceWindow{
+			Name:          "upgrade",
+			StartsAt:      &startsAt,
+			EndsAt:        &endsAt,
+			Notifications: database.MaintenanceNotificationsTag,
+		})
+		window := s.maintenanceWindow(context.Background(), &Event{ServiceID: serviceID})
+		require.NotNil(t, window)
+
+		notification := &Notification{Title: "api failed", Metadata: map[string]string{"branch": "main"}}
+		tagged := tagMaintenance(notification, window)
+		assert.Equal(t, "[Maintenance] api failed", tagged.Title)
+		assert.Equal(t, map[string]string{"branch": "main", "maintenance_window": "upgrade"}, tagged.Metadata)
+		assert.Equal(t, "api failed", notification.Title, "the original is unchanged")
+		assert.Len(t, notification.Metadata, 1)
+	})
+}
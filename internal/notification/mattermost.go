@@ -0,0 +1,232 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// MattermostChannel implements the Channel interface for Mattermost incoming webhook notifications.
+type MattermostChannel struct {
+	webhookURL string
+	channel    string
+	username   string
+	iconURL    string
+	client     *http.Client
+	logger     *slog.Logger
+}
+
+// MattermostConfig contains configuration for a Mattermost channel.
+type MattermostConfig struct {
+	WebhookURL string
+	Channel    string
+	Username   string
+	IconURL    string
+}
+
+// NewMattermostChannel creates a new Mattermost notification channel.
+func NewMattermostChannel(cfg MattermostConfig, logger *slog.Logger) *MattermostChannel {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &MattermostChannel{
+		webhookURL: cfg.WebhookURL,
+		channel:    cfg.Channel,
+		username:   cfg.Username,
+		iconURL:    cfg.IconURL,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		logger: logger.With("channel", "mattermost"),
+	}
+}
+
+// Type returns the channel type.
+func (c *MattermostChannel) Type() database.ChannelType {
+	return database.ChannelTypeMattermost
+}
+
+// Validate validates the Mattermost configuration.
+func (c *MattermostChannel) Validate() error {
+	if c.webhookURL == "" {
+		return fmt.Errorf("Mattermost webhook URL is required")
+	}
+	return nil
+}
+
+// Send sends a notification to Mattermost.
+func (c *MattermostChannel) Send(ctx context.Context, notification *Notification) error {
+	payload := c.formatMessage(notification)
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Mattermost payload: %w", err)
+	}
+
+	// Send with retry
+	var lastErr error
+	maxRetries := 3
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.webhookURL, bytes.NewReader(jsonPayload))
+		if err != nil {
+			return fmt.Errorf("failed to create Mattermost request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			traceAttempt(ctx, nil)
+			lastErr = fmt.Errorf("Mattermost request failed: %w", err)
+			c.logger.Warn("Mattermost request failed, retrying",
+				"attempt", attempt+1,
+				"error", err,
+			)
+			continue
+		}
+
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		traceAttempt(ctx, body)
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusOK {
+			c.logger.Debug("Mattermost notification sent",
+				"notification_type", notification.Type,
+			)
+			return nil
+		}
+
+		lastErr = fmt.Errorf("Mattermost returned status %d: %s", resp.StatusCode, string(body))
+
+		// Don't retry on client errors except rate limits
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != 429 {
+			return permanent(lastErr)
+		}
+	}
+
+	return lastErr
+}
+
+// formatMessage formats the notification as a Mattermost message
+// attachment, which follows the layout of Slack's legacy attachments.
+func (c *MattermostChannel) formatMessage(notification *Notification) map[string]interface{} {
+	attachment := map[string]interface{}{
+		// Shown in push notifications, which do not render attachments.
+		"fallback": notification.Title,
+		"color":    c.getColor(notification.Type),
+		"title":    notification.Title,
+		"text":     notification.Message,
+		"footer":   fmt.Sprintf("Service: %s", notification.ServiceName),
+	}
+	if notification.URL != "" {
+		attachment["title_link"] = notification.URL
+	}
+	if !notification.CreatedAt.IsZero() {
+		attachment["ts"] = notification.CreatedAt.Unix()
+	}
+
+	if notification.Summary != nil {
+		fields := []map[string]interface{}{
+			{
+				"title": "Tests",
+				"value": fmt.Sprintf("%d total, %d passed, %d failed, %d skipped",
+					notification.Summary.TotalTests,
+					notification.Summary.PassedTests,
+					notification.Summary.FailedTests,
+					notification.Summary.SkippedTests),
+				"short": false,
+			},
+		}
+
+		if notification.Summary.DurationMs > 0 {
+			duration := time.Duration(notification.Summary.DurationMs) * time.Millisecond
+			fields = append(fields, map[string]interface{}{
+				"title": "Duration",
+				"value": duration.Round(time.Second).String(),
+				"short": true,
+			})
+		}
+
+		if notification.Summary.Branch != "" {
+			fields = append(fields, map[string]interface{}{
+				"title": "Branch",
+				"value": fmt.Sprintf("`%s`", notification.Summary.Branch),
+				"short": true,
+			})
+		}
+
+		if notification.Summary.CommitSHA != "" {
+			shortSHA := notification.Summary.CommitSHA
+			if len(shortSHA) > 7 {
+				shortSHA = shortSHA[:7]
+			}
+			fields = append(fields, map[string]interface{}{
+				"title": "Commit",
+				"value": fmt.Sprintf("`%s`", shortSHA),
+				"short": true,
+			})
+		}
+
+		if notification.Summary.ErrorMessage != "" {
+			fields = append(fields, map[string]interface{}{
+				"title": "Error",
+				"value": fmt.Sprintf("```\n%s\n```", truncate(notification.Summary.ErrorMessage, 500)),
+				"short": false,
+			})
+		}
+
+		attachment["fields"] = fields
+	}
+
+	payload := map[string]interface{}{
+		"attachments": []map[string]interface{}{attachment},
+	}
+	if c.channel != "" {
+		payload["channel"] = c.channel
+	}
+	if c.username != "" {
+		payload["username"] = c.username
+	}
+	if c.iconURL != "" {
+		payload["icon_url"] = c.iconURL
+	}
+
+	return payload
+}
+
+// getColor returns the attachment color for the notification type.
+func (c *MattermostChannel) getColor(notificationType NotificationType) string {
+	switch notificationType {
+	case NotificationTypeRunPassed, NotificationTypeRunRecovered:
+		return "#36a64f" // Green
+	case NotificationTypeRunFailed, NotificationTypeRunError, NotificationTypeRunTimeout:
+		return "#dc3545" // Red
+	case NotificationTypeFlakyDetected, NotificationTypeTestQuarantined, NotificationTypeDurationAnomaly, NotificationTypeDurationRegression:
+		return "#ffc107" // Yellow
+	case NotificationTypeRunStarted:
+		return "#17a2b8" // Blue
+	case NotificationTypeAgentOffline:
+		return "#dc3545" // Red
+	case NotificationTypeAgentOnline:
+		return "#36a64f" // Green
+	default:
+		return "#6c757d" // Gray
+	}
+}
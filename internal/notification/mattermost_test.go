@@ -0,0 +1,58 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+func TestMattermostChannelSend(t *testing.T) {
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	channel := NewMattermostChannel(MattermostConfig{WebhookURL: server.URL, Channel: "town-square", IconURL: "https://conductor.test/icon.png"}, nil)
+	require.NoError(t, channel.Validate())
+	assert.Equal(t, database.ChannelTypeMattermost, channel.Type())
+
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := channel.Send(context.Background(), &Notification{
+		Type:        NotificationTypeRunRecovered,
+		Title:       "Run recovered",
+		Message:     "All tests pass again",
+		ServiceName: "payments",
+		URL:         "https://conductor.test/runs/1",
+		CreatedAt:   createdAt,
+		Summary:     &RunSummary{TotalTests: 10, PassedTests: 10, DurationMs: 90_000},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "town-square", payload["channel"])
+	assert.Equal(t, "https://conductor.test/icon.png", payload["icon_url"])
+	attachments := payload["attachments"].([]interface{})
+	require.Len(t, attachments, 1)
+	attachment := attachments[0].(map[string]interface{})
+	assert.Equal(t, "#36a64f", attachment["color"])
+	assert.Equal(t, "Run recovered", attachment["title"])
+	assert.Equal(t, "https://conductor.test/runs/1", attachment["title_link"])
+	assert.Equal(t, "All tests pass again", attachment["text"])
+	assert.Equal(t, "Service: payments", attachment["footer"])
+	assert.Equal(t, float64(createdAt.Unix()), attachment["ts"])
+
+	fields := attachment["fields"].([]interface{})
+	require.Len(t, fields, 2)
+	assert.Equal(t, "10 total, 10 passed, 0 failed, 0 skipped", fields[0].(map[string]interface{})["value"])
+	assert.Equal(t, "1m30s", fields[1].(map[string]interface{})["value"])
+	assert.Equal(t, true, fields[1].(map[string]interface{})["short"])
+}
@@ -1,6 +1,8 @@
 package notification
 
 import (
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -35,43 +37,144 @@ type RuleMatch struct {
 	Channel *database.NotificationChannel
 }
 
+// Rule scopes, from most to least specific.
+const (
+	// RuleScopeTestDefinition rules notify for events of one test definition.
+	RuleScopeTestDefinition = "test_definition"
+	// RuleScopeOwner rules notify for events of the tests of one owner.
+	RuleScopeOwner = "owner"
+	// RuleScopeService rules notify for events of one service.
+	RuleScopeService = "service"
+	// RuleScopeGlobal rules notify for events of every service.
+	RuleScopeGlobal = "global"
+)
+
+// RuleScope returns the scope of a rule.
+func RuleScope(rule *database.NotificationRule) string {
+	switch {
+	case rule.TestDefinitionID != nil:
+		return RuleScopeTestDefinition
+	case rule.Owner != nil:
+		return RuleScopeOwner
+	case rule.ServiceID != nil:
+		return RuleScopeService
+	default:
+		return RuleScopeGlobal
+	}
+}
+
+// RuleDecision explains whether a rule notifies for an event and why.
+type RuleDecision struct {
+	Rule *database.NotificationRule
+	// Channel is the rule's channel, nil when it is disabled or unavailable.
+	Channel *database.NotificationChannel
+	Scope   string
+	Matched bool
+	Reason  string
+}
+
 // Evaluate evaluates all rules against an event and returns matching rules.
 func (e *RuleEngine) Evaluate(rules []database.NotificationRule, channels map[uuid.UUID]*database.NotificationChannel, event *Event) []RuleMatch {
 	var matches []RuleMatch
+	for _, decision := range e.Explain(rules, channels, event) {
+		if decision.Matched {
+			matches = append(matches, RuleMatch{
+				Rule:    decision.Rule,
+				Channel: decision.Channel,
+			})
+		}
+	}
+	return matches
+}
 
+// Explain evaluates every rule against an event and reports for each whether
+// it notifies and why. Rules of a test definition or of its owner take
+// precedence: when every test definition the event concerns has such an
+// applicable rule, service and global rules are overridden and do not
+// notify. Otherwise, e.g. when a failed test has no known owner, they notify
+// in addition to them.
+func (e *RuleEngine) Explain(rules []database.NotificationRule, channels map[uuid.UUID]*database.NotificationChannel, event *Event) []RuleDecision {
 	triggerEvent := mapTriggerEvent(event.Type)
 
+	decisions := make([]RuleDecision, len(rules))
+	covered := make(map[uuid.UUID]bool)
 	for i := range rules {
 		rule := &rules[i]
+		decisions[i] = RuleDecision{Rule: rule, Scope: RuleScope(rule)}
+		if reason := e.ruleMismatch(rule, triggerEvent, event); reason != "" {
+			decisions[i].Reason = reason
+			continue
+		}
+		decisions[i].Matched = true
+		if rule.TestDefinitionID != nil {
+			covered[*rule.TestDefinitionID] = true
+		}
+		if rule.Owner != nil {
+			for id, owner := range event.TestOwners {
+				if owner == *rule.Owner {
+					covered[id] = true
+				}
+			}
+		}
+	}
+
+	overridden := len(event.TestDefinitionIDs) > 0
+	for _, id := range event.TestDefinitionIDs {
+		if !covered[id] {
+			overridden = false
+			break
+		}
+	}
 
-		// Skip disabled rules
-		if !rule.Enabled {
+	for i := range decisions {
+		d := &decisions[i]
+		if !d.Matched {
 			continue
 		}
+		d.Matched = false
 
-		// Check if rule matches the event
-		if !e.ruleMatchesEvent(rule, triggerEvent, event) {
+		if overridden && d.Scope != RuleScopeTestDefinition && d.Scope != RuleScopeOwner {
+			d.Reason = "overridden by the rules of the event's test definitions or their owners"
 			continue
 		}
 
-		// Get the channel for this rule
-		channel, ok := channels[rule.ChannelID]
+		channel, ok := channels[d.Rule.ChannelID]
 		if !ok || !channel.Enabled {
+			d.Reason = "channel is disabled or unavailable"
 			continue
 		}
+		d.Channel = channel
 
-		// Check throttling
-		if e.isThrottled(rule.ID, event) {
+		if e.isThrottled(d.Rule.ID, event) {
+			d.Reason = fmt.Sprintf("throttled: the rule already notified for this service and event type within %s", e.throttleDuration)
 			continue
 		}
 
-		matches = append(matches, RuleMatch{
-			Rule:    rule,
-			Channel: channel,
-		})
+		d.Matched = true
+		d.Reason = "matched"
 	}
 
-	return matches
+	return decisions
+}
+
+// ruleMismatch returns why a rule does not apply to the event, or "" when it does.
+func (e *RuleEngine) ruleMismatch(rule *database.NotificationRule, triggerEvent database.TriggerEvent, event *Event) string {
+	if !rule.Enabled {
+		return "rule is disabled"
+	}
+	if !e.ruleMatchesEvent(rule, triggerEvent, event) {
+		if rule.ServiceID != nil && *rule.ServiceID != event.ServiceID {
+			return "rule is for another service"
+		}
+		return fmt.Sprintf("rule triggers on %s, event is %s", joinTriggers(rule.TriggerOn), triggerEvent)
+	}
+	if rule.TestDefinitionID != nil && !containsID(event.TestDefinitionIDs, *rule.TestDefinitionID) {
+		return "rule's test definition is not part of the event"
+	}
+	if rule.Owner != nil && !ownsTest(event, *rule.Owner) {
+		return "rule's owner owns none of the event's tests"
+	}
+	return ""
 }
 
 // ruleMatchesEvent checks if a rule matches the given event.
@@ -98,6 +201,34 @@ func (e *RuleEngine) ruleMatchesEvent(rule *database.NotificationRule, triggerEv
 	return true
 }
 
+// joinTriggers formats a rule's triggers for explanations.
+func joinTriggers(triggers []database.TriggerEvent) string {
+	names := make([]string, len(triggers))
+	for i, t := range triggers {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
+
+// ownsTest reports whether owner owns any test definition of the event.
+func ownsTest(event *Event, owner string) bool {
+	for _, id := range event.TestDefinitionIDs {
+		if event.TestOwners[id] == owner {
+			return true
+		}
+	}
+	return false
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
 // isThrottled checks if a notification should be throttled.
 func (e *RuleEngine) isThrottled(ruleID uuid.UUID, event *Event) bool {
 	// Create a unique key for this rule+event combination
@@ -0,0 +1,149 @@
+package notification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+func TestRuleEngineTestDefinitionPrecedence(t *testing.T) {
+	serviceID := uuid.New()
+	smokeID := uuid.New()
+	unitID := uuid.New()
+	channel := &database.NotificationChannel{ID: uuid.New(), Enabled: true}
+	channels := map[uuid.UUID]*database.NotificationChannel{channel.ID: channel}
+	failure := []database.TriggerEvent{database.TriggerEventFailure}
+
+	smokeRule := database.NotificationRule{ID: uuid.New(), ChannelID: channel.ID, ServiceID: &serviceID, TestDefinitionID: &smokeID, TriggerOn: failure, Enabled: true}
+	serviceRule := database.NotificationRule{ID: uuid.New(), ChannelID: channel.ID, ServiceID: &serviceID, TriggerOn: failure, Enabled: true}
+	globalRule := database.NotificationRule{ID: uuid.New(), ChannelID: channel.ID, TriggerOn: failure, Enabled: true}
+	rules := []database.NotificationRule{smokeRule, serviceRule, globalRule}
+
+	t.Run("definition rules override when every definition is covered", func(t *testing.T) {
+		engine := NewRuleEngine(time.Minute)
+		event := &Event{Type: NotificationTypeRunFailed, ServiceID: serviceID, TestDefinitionIDs: []uuid.UUID{smokeID}}
+
+		decisions := engine.Explain(rules, channels, event)
+		require.Len(t, decisions, 3)
+		assert.Equal(t, RuleScopeTestDefinition, decisions[0].Scope)
+		assert.True(t, decisions[0].Matched)
+		assert.Equal(t, "matched", decisions[0].Reason)
+		assert.Equal(t, RuleScopeService, decisions[1].Scope)
+		assert.False(t, decisions[1].Matched)
+		assert.Contains(t, decisions[1].Reason, "overridden")
+		assert.Equal(t, RuleScopeGlobal, decisions[2].Scope)
+		assert.False(t, decisions[2].Matched)
+
+		matches := engine.Evaluate(rules, channels, event)
+		require.Len(t, matches, 1)
+		assert.Equal(t, smokeRule.ID, matches[0].Rule.ID)
+	})
+
+	t.Run("uncovered definitions keep service rules", func(t *testing.T) {
+		engine := NewRuleEngine(time.Minute)
+		event := &Event{Type: NotificationTypeRunFailed, ServiceID: serviceID, TestDefinitionIDs: []uuid.UUID{smokeID, unitID}}
+
+		matches := engine.Evaluate(rules, channels, event)
+		assert.Len(t, matches, 3)
+	})
+
+	t.Run("definition rules of other definitions do not apply", func(t *testing.T) {
+		engine := NewRuleEngine(time.Minute)
+		event := &Event{Type: NotificationTypeRunFailed, ServiceID: serviceID, TestDefinitionIDs: []uuid.UUID{unitID}}
+
+		decisions := engine.Explain(rules, channels, event)
+		assert.False(t, decisions[0].Matched)
+		assert.Equal(t, "rule's test definition is not part of the event", decisions[0].Reason)
+		assert.True(t, decisions[1].Matched)
+		assert.True(t, decisions[2].Matched)
+	})
+
+	t.Run("trigger mismatch does not override", func(t *testing.T) {
+		engine := NewRuleEngine(time.Minute)
+		event := &Event{Type: NotificationTypeRunRecovered, ServiceID: serviceID, TestDefinitionIDs: []uuid.UUID{smokeID}}
+
+		decisions := engine.Explain(rules, channels, event)
+		for _, d := range decisions {
+			assert.False(t, d.Matched)
+			assert.Equal(t, "rule triggers on failure, event is recovery", d.Reason)
+		}
+	})
+
+	t.Run("explains disabled channels and throttling", func(t *testing.T) {
+		engine := NewRuleEngine(time.Minute)
+		event := &Event{Type: NotificationTypeRunFailed, ServiceID: serviceID}
+		otherChannel := database.NotificationRule{ID: uuid.New(), ChannelID: uuid.New(), TriggerOn: failure, Enabled: true}
+		engine.MarkSent(serviceRule.ID, event)
+
+		decisions := engine.Explain([]database.NotificationRule{serviceRule, otherChannel}, channels, event)
+		assert.False(t, decisions[0].Matched)
+		assert.Contains(t, decisions[0].Reason, "throttled")
+		assert.False(t, decisions[1].Matched)
+		assert.Equal(t, "channel is disabled or unavailable", decisions[1].Reason)
+	})
+}
+
+func TestRuleEngineOwnerRules(t *testing.T) {
+	serviceID := uuid.New()
+	checkoutID := uuid.New()
+	searchID := uuid.New()
+	unownedID := uuid.New()
+	channel := &database.NotificationChannel{ID: uuid.New(), Enabled: true}
+	channels := map[uuid.UUID]*database.NotificationChannel{channel.ID: channel}
+	failure := []database.TriggerEvent{database.TriggerEventFailure}
+	owners := map[uuid.UUID]string{checkoutID: "team-payments", searchID: "team-search"}
+
+	paymentsRule := database.NotificationRule{ID: uuid.New(), ChannelID: channel.ID, Owner: database.NullString("team-payments"), TriggerOn: failure, Enabled: true}
+	searchRule := database.NotificationRule{ID: uuid.New(), ChannelID: channel.ID, ServiceID: &serviceID, Owner: database.NullString("team-search"), TriggerOn: failure, Enabled: true}
+	serviceRule := database.NotificationRule{ID: uuid.New(), ChannelID: channel.ID, ServiceID: &serviceID, TriggerOn: failure, Enabled: true}
+	rules := []database.NotificationRule{paymentsRule, searchRule, serviceRule}
+
+	t.Run("notifies only the owners of the failed tests", func(t *testing.T) {
+		engine := NewRuleEngine(time.Minute)
+		event := &Event{Type: NotificationTypeRunFailed, ServiceID: serviceID, TestDefinitionIDs: []uuid.UUID{checkoutID}, TestOwners: owners}
+
+		decisions := engine.Explain(rules, channels, event)
+		require.Len(t, decisions, 3)
+		assert.Equal(t, RuleScopeOwner, decisions[0].Scope)
+		assert.True(t, decisions[0].Matched)
+		assert.Equal(t, RuleScopeOwner, decisions[1].Scope)
+		assert.False(t, decisions[1].Matched)
+		assert.Equal(t, "rule's owner owns none of the event's tests", decisions[1].Reason)
+		assert.False(t, decisions[2].Matched)
+		assert.Contains(t, decisions[2].Reason, "overridden")
+	})
+
+	t.Run("notifies every owner of the failed tests", func(t *testing.T) {
+		engine := NewRuleEngine(time.Minute)
+		event := &Event{Type: NotificationTypeRunFailed, ServiceID: serviceID, TestDefinitionIDs: []uuid.UUID{checkoutID, searchID}, TestOwners: owners}
+
+		matches := engine.Evaluate(rules, channels, event)
+		require.Len(t, matches, 2)
+		assert.Equal(t, paymentsRule.ID, matches[0].Rule.ID)
+		assert.Equal(t, searchRule.ID, matches[1].Rule.ID)
+	})
+
+	t.Run("unknown ownership falls back to service rules", func(t *testing.T) {
+		engine := NewRuleEngine(time.Minute)
+		event := &Event{Type: NotificationTypeRunFailed, ServiceID: serviceID, TestDefinitionIDs: []uuid.UUID{checkoutID, unownedID}, TestOwners: owners}
+
+		decisions := engine.Explain(rules, channels, event)
+		assert.True(t, decisions[0].Matched)
+		assert.False(t, decisions[1].Matched)
+		assert.True(t, decisions[2].Matched)
+	})
+
+	t.Run("owners without rules fall back to service rules", func(t *testing.T) {
+		engine := NewRuleEngine(time.Minute)
+		event := &Event{Type: NotificationTypeRunFailed, ServiceID: serviceID, TestDefinitionIDs: []uuid.UUID{searchID}, TestOwners: owners}
+
+		matches := engine.Evaluate([]database.NotificationRule{paymentsRule, serviceRule}, channels, event)
+		require.Len(t, matches, 1)
+		assert.Equal(t, serviceRule.ID, matches[0].Rule.ID)
+	})
+}
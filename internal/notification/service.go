@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,12 +20,18 @@ type NotificationService interface {
 	SendNotification(ctx context.Context, event *Event) error
 	// ProcessRules evaluates notification rules and sends matching notifications.
 	ProcessRules(ctx context.Context, event *Event) ([]SendResult, error)
+	// Explain reports for every rule of the event's service whether it
+	// notifies for the event and why, without sending anything.
+	Explain(ctx context.Context, event *Event) ([]RuleDecision, error)
 	// Start starts the notification service background workers.
 	Start(ctx context.Context) error
 	// Stop gracefully stops the notification service.
 	Stop(ctx context.Context) error
 	// TestChannel sends a test notification to a specific channel.
 	TestChannel(ctx context.Context, channelID uuid.UUID, message string) (*SendResult, error)
+	// Redeliver sends the notification of a failed delivery to its channel
+	// again and returns the new delivery.
+	Redeliver(ctx context.Context, deliveryID uuid.UUID) (*database.NotificationDelivery, error)
 }
 
 // Config holds configuration for the notification service.
@@ -39,6 +46,18 @@ type Config struct {
 	ThrottleDuration time.Duration
 	// RetryAttempts is the number of retry attempts for failed sends.
 	RetryAttempts int
+	// DedupWindow is how long an (event, channel) pair is remembered so the
+	// same event is not delivered to a channel twice. Zero disables it.
+	DedupWindow time.Duration
+	// CollapseRules sends a single message per channel listing every rule
+	// that matched, instead of only the first matching rule.
+	CollapseRules bool
+	// DeadLetterInterval is how often dead-lettered notifications that are
+	// due are redelivered. Zero disables automatic redelivery.
+	DeadLetterInterval time.Duration
+	// DeadLetterMaxAttempts is how many times a dead-lettered notification
+	// is redelivered automatically before it is left for manual redelivery.
+	DeadLetterMaxAttempts int
 	// BaseURL is the base URL for notification links.
 	BaseURL string
 	// Email contains SMTP configuration for email notifications.
@@ -66,26 +85,36 @@ func DefaultConfig() Config {
 		DefaultTimeout:   30 * time.Second,
 		ThrottleDuration: 5 * time.Minute,
 		RetryAttempts:    3,
+		DedupWindow:      10 * time.Minute,
 		BaseURL:          "",
 		Email: EmailSettings{
 			ConnTimeout: 30 * time.Second,
 		},
+		DeadLetterInterval:    time.Minute,
+		DeadLetterMaxAttempts: 5,
 	}
 }
 
 // Service implements the NotificationService interface.
 type Service struct {
 	config     Config
+	configMu   sync.RWMutex
 	repo       database.NotificationRepository
 	ruleEngine *RuleEngine
+	dedup      *dedupCache
 	channels   map[uuid.UUID]Channel
 	channelsMu sync.RWMutex
 	queue      chan *notificationJob
+	metrics    DeadLetterMetrics
 	logger     *slog.Logger
 	wg         sync.WaitGroup
 	cancel     context.CancelFunc
 	started    bool
 	startMu    sync.Mutex
+
+	// maintenanceRepo holds the maintenance windows that suppress or tag
+	// notifications (optional).
+	maintenanceRepo database.MaintenanceWindowRepository
 }
 
 // notificationJob represents a notification job in the queue.
@@ -93,7 +122,10 @@ type notificationJob struct {
 	notification *Notification
 	channel      Channel
 	channelID    uuid.UUID
+	ruleIDs      []uuid.UUID
 	resultCh     chan<- SendResult
+	// redeliveryOf is the failed delivery a redelivery repeats.
+	redeliveryOf *uuid.UUID
 }
 
 // NewService creates a new notification service.
@@ -116,12 +148,53 @@ func NewService(config Config, repo database.NotificationRepository, logger *slo
 		config:     config,
 		repo:       repo,
 		ruleEngine: NewRuleEngine(config.ThrottleDuration),
+		dedup:      newDedupCache(config.DedupWindow),
 		channels:   make(map[uuid.UUID]Channel),
 		queue:      make(chan *notificationJob, config.QueueSize),
 		logger:     logger.With("component", "notification_service"),
 	}
 }
 
+// SetDedupWindow changes how long a delivered (event, channel) pair
+// suppresses repeats, also while the service runs.
+func (s *Service) SetDedupWindow(window time.Duration) {
+	s.configMu.Lock()
+	s.config.DedupWindow = window
+	s.configMu.Unlock()
+	s.dedup.setWindow(window)
+}
+
+// SetCollapseRules changes whether a single message per channel lists every
+// matched rule, also while the service runs.
+func (s *Service) SetCollapseRules(collapse bool) {
+	s.configMu.Lock()
+	s.config.CollapseRules = collapse
+	s.configMu.Unlock()
+}
+
+// QueueStats describes the notification queue, for diagnostics.
+type QueueStats struct {
+	// Queued is the number of notifications waiting for a worker.
+	Queued   int `json:"queued"`
+	Capacity int `json:"capacity"`
+	Workers  int `json:"workers"`
+	Channels int `json:"channels"`
+}
+
+// QueueStats returns statistics of the notification queue.
+func (s *Service) QueueStats() QueueStats {
+	s.channelsMu.RLock()
+	channels := len(s.channels)
+	s.channelsMu.RUnlock()
+
+	return QueueStats{
+		Queued:   len(s.queue),
+		Capacity: cap(s.queue),
+		Workers:  s.config.WorkerCount,
+		Channels: channels,
+	}
+}
+
 // Start starts the notification service background workers.
 func (s *Service) Start(ctx context.Context) error {
 	s.startMu.Lock()
@@ -215,11 +288,29 @@ func (s *Service) worker(ctx context.Context, id int) {
 
 // processJob processes a single notification job.
 func (s *Service) processJob(ctx context.Context, job *notificationJob) {
+	result, trace, err := s.send(ctx, job)
+	if delivery, recordErr := s.recordDelivery(ctx, job, result, trace); recordErr == nil {
+		s.deadLetter(ctx, delivery, err)
+	}
+
+	// Send result if channel provided
+	if job.resultCh != nil {
+		select {
+		case job.resultCh <- result:
+		default:
+			// Result channel full, skip
+		}
+	}
+}
+
+// send sends the notification of a job to its channel.
+func (s *Service) send(ctx context.Context, job *notificationJob) (SendResult, *deliveryTrace, error) {
 	start := time.Now()
 
 	// Create timeout context
 	sendCtx, cancel := context.WithTimeout(ctx, s.config.DefaultTimeout)
 	defer cancel()
+	sendCtx, trace := withDeliveryTrace(sendCtx)
 
 	result := SendResult{
 		ChannelID:   job.channelID,
@@ -249,14 +340,59 @@ func (s *Service) processJob(ctx context.Context, job *notificationJob) {
 		)
 	}
 
-	// Send result if channel provided
-	if job.resultCh != nil {
-		select {
-		case job.resultCh <- result:
-		default:
-			// Result channel full, skip
-		}
+	return result, trace, err
+}
+
+// recordDelivery persists the outcome of a job and returns the recorded
+// delivery. Failing to record it does not fail the delivery; the error is
+// logged and returned.
+func (s *Service) recordDelivery(ctx context.Context, job *notificationJob, result SendResult, trace *deliveryTrace) (*database.NotificationDelivery, error) {
+	attempts, excerpt := trace.result()
+	channelID := job.channelID
+	delivery := &database.NotificationDelivery{
+		EventType:       string(job.notification.Type),
+		ServiceID:       job.notification.ServiceID,
+		RunID:           job.notification.RunID,
+		ChannelID:       &channelID,
+		ChannelType:     result.ChannelType,
+		RuleIDs:         job.ruleIDs,
+		Status:          database.DeliveryStatusSent,
+		Attempts:        attempts,
+		LatencyMs:       result.LatencyMs,
+		Error:           result.Error,
+		ResponseExcerpt: excerpt,
+		SentAt:          result.SentAt,
+		RedeliveryOf:    job.redeliveryOf,
+	}
+	if !result.Success {
+		delivery.Status = database.DeliveryStatusFailed
+	}
+	if payload, hash, err := encodePayload(job.notification); err == nil {
+		delivery.Payload, delivery.PayloadHash = payload, hash
+	} else {
+		s.logger.Warn("failed to encode notification payload",
+			"channel_id", job.channelID,
+			"error", err,
+		)
+	}
+
+	if err := s.repo.RecordDelivery(ctx, delivery); err != nil {
+		s.logger.Warn("failed to record notification delivery",
+			"channel_id", job.channelID,
+			"error", err,
+		)
+		return nil, err
 	}
+	return delivery, nil
+}
+
+// ruleIDs returns the IDs of rules.
+func ruleIDs(rules []*database.NotificationRule) []uuid.UUID {
+	ids := make([]uuid.UUID, len(rules))
+	for i, rule := range rules {
+		ids[i] = rule.ID
+	}
+	return ids
 }
 
 // throttleCleaner periodically cleans up the throttle cache.
@@ -272,6 +408,7 @@ func (s *Service) throttleCleaner(ctx context.Context) {
 			return
 		case <-ticker.C:
 			s.ruleEngine.CleanupThrottleCache()
+			s.dedup.cleanup()
 		}
 	}
 }
@@ -312,11 +449,14 @@ func (s *Service) createChannelFromDB(dbChannel *database.NotificationChannel) (
 			return nil, fmt.Errorf("failed to parse slack config: %w", err)
 		}
 		return NewSlackChannel(SlackConfig{
-			WebhookURL: cfg.WebhookURL,
-			Channel:    cfg.Channel,
-			Username:   cfg.Username,
-			IconEmoji:  cfg.IconEmoji,
-			Token:      cfg.Token,
+			WebhookURL:    cfg.WebhookURL,
+			Channel:       cfg.Channel,
+			Username:      cfg.Username,
+			IconEmoji:     cfg.IconEmoji,
+			Token:         cfg.Token,
+			Format:        cfg.MessageFormat,
+			ThreadReplies: cfg.ThreadReplies,
+			UpdateInPlace: cfg.UpdateInPlace,
 		}, s.logger), nil
 
 	case database.ChannelTypeEmail:
@@ -366,6 +506,29 @@ func (s *Service) createChannelFromDB(dbChannel *database.NotificationChannel) (
 			WebhookURL: cfg.WebhookURL,
 		}, s.logger), nil
 
+	case database.ChannelTypeDiscord:
+		var cfg database.DiscordChannelConfig
+		if err := json.Unmarshal(dbChannel.Config, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse discord config: %w", err)
+		}
+		return NewDiscordChannel(DiscordConfig{
+			WebhookURL: cfg.WebhookURL,
+			Username:   cfg.Username,
+			AvatarURL:  cfg.AvatarURL,
+		}, s.logger), nil
+
+	case database.ChannelTypeMattermost:
+		var cfg database.MattermostChannelConfig
+		if err := json.Unmarshal(dbChannel.Config, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse mattermost config: %w", err)
+		}
+		return NewMattermostChannel(MattermostConfig{
+			WebhookURL: cfg.WebhookURL,
+			Channel:    cfg.Channel,
+			Username:   cfg.Username,
+			IconURL:    cfg.IconURL,
+		}, s.logger), nil
+
 	default:
 		return nil, fmt.Errorf("unsupported channel type: %s", dbChannel.Type)
 	}
@@ -392,31 +555,27 @@ func (s *Service) SendNotification(ctx context.Context, event *Event) error {
 }
 
 // ProcessRules evaluates notification rules and sends matching notifications.
+// During a maintenance window of the event's service or agent pool nothing
+// is sent, unless the window tags notifications instead.
 func (s *Service) ProcessRules(ctx context.Context, event *Event) ([]SendResult, error) {
-	// Get rules for this service
-	rules, err := s.repo.ListRulesByService(ctx, event.ServiceID)
+	window := s.maintenanceWindow(ctx, event)
+	if window != nil && window.Notifications != database.MaintenanceNotificationsTag {
+		s.logger.Debug("suppressing notification during maintenance",
+			"maintenance_window", window.Name,
+			"notification_type", event.Type,
+		)
+		return nil, nil
+	}
+
+	rules, channelMap, err := s.loadRules(ctx, event)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list rules: %w", err)
+		return nil, err
 	}
 
 	if len(rules) == 0 {
 		return nil, nil
 	}
 
-	// Build channel map
-	s.channelsMu.RLock()
-	channelMap := make(map[uuid.UUID]*database.NotificationChannel)
-	for _, rule := range rules {
-		if _, exists := s.channels[rule.ChannelID]; exists {
-			// Get DB channel for rule matching
-			dbChannel, err := s.repo.GetChannel(ctx, rule.ChannelID)
-			if err == nil && dbChannel != nil {
-				channelMap[rule.ChannelID] = dbChannel
-			}
-		}
-	}
-	s.channelsMu.RUnlock()
-
 	// Evaluate rules
 	matches := s.ruleEngine.Evaluate(rules, channelMap, event)
 	if len(matches) == 0 {
@@ -425,43 +584,68 @@ func (s *Service) ProcessRules(ctx context.Context, event *Event) ([]SendResult,
 
 	// Create notification from event
 	notification := s.createNotificationFromEvent(event)
+	vars := s.templateVars(event)
+	if window != nil {
+		vars.MaintenanceWindow = window.Name
+	}
 
-	// Send to all matched channels
-	resultCh := make(chan SendResult, len(matches))
-	for _, match := range matches {
+	// Send once per channel, even when several rules route the event to it
+	groups := groupMatchesByChannel(matches)
+	resultCh := make(chan SendResult, len(groups))
+	queued := 0
+	for _, group := range groups {
 		s.channelsMu.RLock()
-		channel, exists := s.channels[match.Channel.ID]
+		channel, exists := s.channels[group.Channel.ID]
 		s.channelsMu.RUnlock()
 
 		if !exists {
 			continue
 		}
 
+		if !s.dedup.claim(event, group.Channel.ID) {
+			s.logger.Debug("skipping duplicate notification",
+				"channel_id", group.Channel.ID,
+				"notification_type", event.Type,
+			)
+			continue
+		}
+
+		grouped := s.notificationForGroup(s.templatedNotification(notification, group, vars), group)
+		if window != nil {
+			grouped = tagMaintenance(grouped, window)
+		}
+
 		job := &notificationJob{
-			notification: notification,
+			notification: grouped,
 			channel:      channel,
-			channelID:    match.Channel.ID,
+			channelID:    group.Channel.ID,
+			ruleIDs:      ruleIDs(group.Rules),
 			resultCh:     resultCh,
 		}
 
 		select {
 		case s.queue <- job:
+			queued++
 			// Mark as sent for throttling
-			s.ruleEngine.MarkSent(match.Rule.ID, event)
+			for _, rule := range group.Rules {
+				s.ruleEngine.MarkSent(rule.ID, event)
+			}
 		case <-ctx.Done():
+			s.dedup.release(event, group.Channel.ID)
 			return nil, ctx.Err()
 		default:
+			s.dedup.release(event, group.Channel.ID)
 			s.logger.Warn("notification queue full, dropping notification",
-				"channel_id", match.Channel.ID,
+				"channel_id", group.Channel.ID,
 			)
 		}
 	}
 
 	// Collect results
-	results := make([]SendResult, 0, len(matches))
+	results := make([]SendResult, 0, queued)
 	timeout := time.After(s.config.DefaultTimeout)
 
-	for i := 0; i < len(matches); i++ {
+	for i := 0; i < queued; i++ {
 		select {
 		case result := <-resultCh:
 			results = append(results, result)
@@ -476,16 +660,108 @@ func (s *Service) ProcessRules(ctx context.Context, event *Event) ([]SendResult,
 	return results, nil
 }
 
-// createNotificationFromEvent creates a Notification from an Event.
-func (s *Service) createNotificationFromEvent(event *Event) *Notification {
+// Explain reports for every rule of the event's service whether it notifies
+// for the event and why. Nothing is sent and throttling state is unchanged.
+func (s *Service) Explain(ctx context.Context, event *Event) ([]RuleDecision, error) {
+	rules, channelMap, err := s.loadRules(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	return s.ruleEngine.Explain(rules, channelMap, event), nil
+}
+
+// loadRules returns the rules of the event's service with the channels they
+// route to. Channels that are not loaded by the service are left out.
+func (s *Service) loadRules(ctx context.Context, event *Event) ([]database.NotificationRule, map[uuid.UUID]*database.NotificationChannel, error) {
+	rules, err := s.repo.ListRulesByService(ctx, event.ServiceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list rules: %w", err)
+	}
+
+	s.channelsMu.RLock()
+	defer s.channelsMu.RUnlock()
+
+	channelMap := make(map[uuid.UUID]*database.NotificationChannel)
+	for _, rule := range rules {
+		if _, exists := s.channels[rule.ChannelID]; exists {
+			// Get DB channel for rule matching
+			dbChannel, err := s.repo.GetChannel(ctx, rule.ChannelID)
+			if err == nil && dbChannel != nil {
+				channelMap[rule.ChannelID] = dbChannel
+			}
+		}
+	}
+	return rules, channelMap, nil
+}
+
+// templatedNotification returns the notification with the title and
+// message rendered from the templates of the first rule of the group that
+// has any. Templates that fail to render keep the built-in text.
+func (s *Service) templatedNotification(notification *Notification, group channelMatch, vars TemplateVars) *Notification {
+	for _, rule := range group.Rules {
+		if rule.TitleTemplate == nil && rule.MessageTemplate == nil {
+			continue
+		}
+
+		templated := *notification
+		if rule.TitleTemplate != nil {
+			title, err := RenderRuleTemplate("title", *rule.TitleTemplate, vars)
+			if err != nil {
+				s.logger.Warn("failed to render rule title template", "rule_id", rule.ID, "error", err)
+			} else {
+				templated.Title = title
+			}
+		}
+		if rule.MessageTemplate != nil {
+			message, err := RenderRuleTemplate("message", *rule.MessageTemplate, vars)
+			if err != nil {
+				s.logger.Warn("failed to render rule message template", "rule_id", rule.ID, "error", err)
+			} else {
+				templated.Message = message
+			}
+		}
+		return &templated
+	}
+	return notification
+}
+
+// notificationForGroup returns the notification to send to a channel. When
+// rule collapsing is enabled and several rules matched, a copy listing every
+// matched rule is returned.
+func (s *Service) notificationForGroup(notification *Notification, group channelMatch) *Notification {
+	s.configMu.RLock()
+	collapse := s.config.CollapseRules
+	s.configMu.RUnlock()
+	if !collapse || len(group.Rules) < 2 {
+		return notification
+	}
+
+	collapsed := *notification
+	collapsed.MatchedRules = make([]string, len(group.Rules))
+	for i, rule := range group.Rules {
+		collapsed.MatchedRules[i] = matchReason(rule)
+	}
+	collapsed.Message = notification.Message + "\n\nMatched rules:\n- " + strings.Join(collapsed.MatchedRules, "\n- ")
+
+	return &collapsed
+}
+
+// templateVars returns the template variables of an event.
+func (s *Service) templateVars(event *Event) TemplateVars {
 	vars := TemplateVars{
-		ServiceName: event.ServiceName,
-		ServiceID:   event.ServiceID.String(),
-		Timestamp:   event.Timestamp,
+		ServiceName:     event.ServiceName,
+		ServiceID:       event.ServiceID.String(),
+		Timestamp:       event.Timestamp,
+		FailedTestNames: event.FailedTestNames,
+	}
+
+	if s.config.BaseURL != "" && event.RunID != nil {
+		vars.URL = fmt.Sprintf("%s/runs/%s", s.config.BaseURL, event.RunID.String())
 	}
 
 	if event.Run != nil {
 		vars.RunID = event.Run.ID.String()
+		vars.Status = string(event.Run.Status)
 		vars.TotalTests = event.Run.TotalTests
 		vars.PassedTests = event.Run.PassedTests
 		vars.FailedTests = event.Run.FailedTests
@@ -514,6 +790,38 @@ func (s *Service) createNotificationFromEvent(event *Event) *Notification {
 		}
 	}
 
+	if event.DurationAnomaly != nil {
+		vars.DurationMs = event.DurationAnomaly.DurationMs
+		vars.MeanDurationMs = int64(event.DurationAnomaly.MeanMs)
+		vars.DurationSigma = event.DurationAnomaly.Sigma
+		vars.BaselineRuns = event.DurationAnomaly.Runs
+	}
+
+	for _, regression := range event.DurationRegressions {
+		if regression.TestName == nil {
+			vars.DurationMs = regression.DurationMs
+			vars.MeanDurationMs = regression.BaselineMeanMs
+			vars.DurationSigma = regression.Sigma
+			vars.BaselineRuns = regression.BaselineRuns
+			continue
+		}
+		vars.RegressedTests = append(vars.RegressedTests, RegressedTest{
+			Name:           *regression.TestName,
+			DurationMs:     regression.DurationMs,
+			MeanDurationMs: regression.BaselineMeanMs,
+		})
+	}
+
+	if event.Agent != nil {
+		vars.AgentName = event.Agent.Name
+	}
+
+	return vars
+}
+
+// createNotificationFromEvent creates a Notification from an Event.
+func (s *Service) createNotificationFromEvent(event *Event) *Notification {
+	vars := s.templateVars(event)
 	title, message := GetTemplateForType(event.Type, vars)
 
 	notification := &Notification{
@@ -550,10 +858,7 @@ func (s *Service) createNotificationFromEvent(event *Event) *Notification {
 		}
 	}
 
-	// Generate URL if base URL is configured
-	if s.config.BaseURL != "" && event.RunID != nil {
-		notification.URL = fmt.Sprintf("%s/runs/%s", s.config.BaseURL, event.RunID.String())
-	}
+	notification.URL = vars.URL
 
 	return notification
 }
@@ -613,6 +918,60 @@ func (s *Service) TestChannel(ctx context.Context, channelID uuid.UUID, message
 	return result, nil
 }
 
+// Redeliver sends the notification of a failed delivery to its channel again,
+// bypassing rules, throttling and deduplication, and records the attempt as
+// a new delivery that refers to the failed one. Deliveries that were sent,
+// whose channel was deleted or disabled, or that have no stored payload
+// cannot be redelivered. A successful redelivery removes the notification
+// from the dead-letter queue.
+func (s *Service) Redeliver(ctx context.Context, deliveryID uuid.UUID) (*database.NotificationDelivery, error) {
+	original, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery: %w", err)
+	}
+
+	switch {
+	case original.Status != database.DeliveryStatusFailed:
+		return nil, fmt.Errorf("%w: only failed deliveries can be redelivered", ErrNotRedeliverable)
+	case original.ChannelID == nil:
+		return nil, fmt.Errorf("%w: the channel was deleted", ErrNotRedeliverable)
+	case len(original.Payload) == 0:
+		return nil, fmt.Errorf("%w: the delivery has no stored notification", ErrNotRedeliverable)
+	}
+
+	var notification Notification
+	if err := json.Unmarshal(original.Payload, &notification); err != nil {
+		return nil, fmt.Errorf("failed to decode notification payload: %w", err)
+	}
+
+	dbChannel, err := s.repo.GetChannel(ctx, *original.ChannelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel: %w", err)
+	}
+	if !dbChannel.Enabled {
+		return nil, fmt.Errorf("%w: the channel is disabled", ErrNotRedeliverable)
+	}
+	channel, err := s.createChannelFromDB(dbChannel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create channel: %w", err)
+	}
+
+	job := &notificationJob{
+		notification: &notification,
+		channel:      channel,
+		channelID:    dbChannel.ID,
+		ruleIDs:      original.RuleIDs,
+		redeliveryOf: &original.ID,
+	}
+	result, trace, sendErr := s.send(ctx, job)
+	delivery, err := s.recordDelivery(ctx, job, result, trace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record redelivery: %w", err)
+	}
+	s.deadLetter(ctx, delivery, sendErr)
+	return delivery, nil
+}
+
 // RefreshChannel reloads a channel from the database.
 func (s *Service) RefreshChannel(ctx context.Context, channelID uuid.UUID) error {
 	dbChannel, err := s.repo.GetChannel(ctx, channelID)
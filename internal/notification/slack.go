@@ -8,6 +8,7 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/conductor/conductor/internal/database"
@@ -21,8 +22,16 @@ type SlackChannel struct {
 	iconEmoji  string
 	token      string // Optional Bot token for API calls
 	apiBaseURL string
+	format     database.SlackMessageFormat
+	threads    bool
+	updates    bool
 	client     *http.Client
 	logger     *slog.Logger
+
+	// messages remembers posted messages that later events reply to or
+	// update, keyed by slackRunKey and slackFailureKey.
+	messagesMu sync.Mutex
+	messages   map[string]slackMessage
 }
 
 // SlackConfig contains configuration for a Slack channel.
@@ -33,9 +42,30 @@ type SlackConfig struct {
 	IconEmoji  string
 	Token      string
 	APIBaseURL string
+	// Format selects the message layout; defaults to attachments.
+	Format database.SlackMessageFormat
+	// ThreadReplies posts recoveries as replies to the failure they
+	// recover from. Requires Token.
+	ThreadReplies bool
+	// UpdateInPlace updates the message of a run as it progresses.
+	// Requires Token.
+	UpdateInPlace bool
+}
+
+// slackMessage is a message posted through the Web API.
+type slackMessage struct {
+	channel  string
+	ts       string
+	postedAt time.Time
 }
 
-const defaultSlackAPIBaseURL = "https://slack.com/api"
+const (
+	defaultSlackAPIBaseURL = "https://slack.com/api"
+
+	// slackMessageTTL is how long posted messages are remembered for
+	// replies and updates.
+	slackMessageTTL = 7 * 24 * time.Hour
+)
 
 // NewSlackChannel creates a new Slack notification channel.
 func NewSlackChannel(cfg SlackConfig, logger *slog.Logger) *SlackChannel {
@@ -48,6 +78,11 @@ func NewSlackChannel(cfg SlackConfig, logger *slog.Logger) *SlackChannel {
 		apiBaseURL = defaultSlackAPIBaseURL
 	}
 
+	format := cfg.Format
+	if format == "" {
+		format = database.SlackMessageFormatAttachments
+	}
+
 	return &SlackChannel{
 		webhookURL: cfg.WebhookURL,
 		channel:    cfg.Channel,
@@ -55,10 +90,14 @@ func NewSlackChannel(cfg SlackConfig, logger *slog.Logger) *SlackChannel {
 		iconEmoji:  cfg.IconEmoji,
 		token:      cfg.Token,
 		apiBaseURL: apiBaseURL,
+		format:     format,
+		threads:    cfg.ThreadReplies,
+		updates:    cfg.UpdateInPlace,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		logger: logger.With("channel", "slack"),
+		logger:   logger.With("channel", "slack"),
+		messages: make(map[string]slackMessage),
 	}
 }
 
@@ -75,12 +114,20 @@ func (c *SlackChannel) Validate() error {
 	if c.webhookURL == "" && c.token != "" && c.channel == "" {
 		return fmt.Errorf("channel is required when using a Slack token")
 	}
+	if c.format != database.SlackMessageFormatAttachments && c.format != database.SlackMessageFormatBlocks {
+		return fmt.Errorf("unsupported Slack message format: %s", c.format)
+	}
+	if (c.threads || c.updates) && (c.token == "" || c.channel == "") {
+		return fmt.Errorf("thread replies and updates in place require a Slack token and channel")
+	}
 	return nil
 }
 
-// Send sends a notification to Slack.
+// Send sends a notification to Slack. Channels that reply in threads or
+// update messages post through the Web API, since webhooks do not return
+// the posted message.
 func (c *SlackChannel) Send(ctx context.Context, notification *Notification) error {
-	if c.webhookURL != "" {
+	if c.webhookURL != "" && !c.threads && !c.updates {
 		return c.sendWebhook(ctx, notification)
 	}
 
@@ -118,6 +165,7 @@ func (c *SlackChannel) sendWebhook(ctx context.Context, notification *Notificati
 
 		resp, err := c.client.Do(req)
 		if err != nil {
+			traceAttempt(ctx, nil)
 			lastErr = fmt.Errorf("Slack request failed: %w", err)
 			c.logger.Warn("Slack request failed, retrying",
 				"attempt", attempt+1,
@@ -127,6 +175,7 @@ func (c *SlackChannel) sendWebhook(ctx context.Context, notification *Notificati
 		}
 
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		traceAttempt(ctx, body)
 		resp.Body.Close()
 
 		if resp.StatusCode == http.StatusOK {
@@ -143,7 +192,7 @@ func (c *SlackChannel) sendWebhook(ctx context.Context, notification *Notificati
 
 		// Don't retry on client errors except rate limits
 		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != 429 {
-			return lastErr
+			return permanent(lastErr)
 		}
 
 		// Check for rate limiting
@@ -172,9 +221,50 @@ func (c *SlackChannel) sendAPI(ctx context.Context, notification *Notification)
 		payload["text"] = fmt.Sprintf("%s\n%s", notification.Title, notification.Message)
 	}
 
+	runKey, failureKey := slackRunKey(notification), slackFailureKey(notification)
+
+	if c.updates && runKey != "" {
+		if posted, ok := c.message(runKey); ok {
+			update := make(map[string]interface{}, len(payload)+1)
+			for k, v := range payload {
+				update[k] = v
+			}
+			update["channel"] = posted.channel
+			update["ts"] = posted.ts
+
+			_, err := c.callAPI(ctx, "chat.update", update, notification)
+			if err == nil {
+				c.remember(notification, posted)
+				return nil
+			}
+			c.logger.Warn("failed to update Slack message, posting a new one",
+				"notification_type", notification.Type,
+				"error", err,
+			)
+		}
+	}
+
+	if c.threads && notification.Type == NotificationTypeRunRecovered && failureKey != "" {
+		if failure, ok := c.message(failureKey); ok {
+			payload["thread_ts"] = failure.ts
+			payload["reply_broadcast"] = true
+		}
+	}
+
+	posted, err := c.callAPI(ctx, "chat.postMessage", payload, notification)
+	if err != nil {
+		return err
+	}
+	c.remember(notification, posted)
+	return nil
+}
+
+// callAPI calls a Slack Web API method with retries and returns the
+// message it posted or updated.
+func (c *SlackChannel) callAPI(ctx context.Context, method string, payload map[string]interface{}, notification *Notification) (slackMessage, error) {
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal Slack payload: %w", err)
+		return slackMessage{}, fmt.Errorf("failed to marshal Slack payload: %w", err)
 	}
 
 	// Send with retry
@@ -185,20 +275,21 @@ func (c *SlackChannel) sendAPI(ctx context.Context, notification *Notification)
 			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
 			select {
 			case <-ctx.Done():
-				return ctx.Err()
+				return slackMessage{}, ctx.Err()
 			case <-time.After(backoff):
 			}
 		}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiBaseURL+"/chat.postMessage", bytes.NewReader(jsonPayload))
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiBaseURL+"/"+method, bytes.NewReader(jsonPayload))
 		if err != nil {
-			return fmt.Errorf("failed to create Slack API request: %w", err)
+			return slackMessage{}, fmt.Errorf("failed to create Slack API request: %w", err)
 		}
 		req.Header.Set("Authorization", "Bearer "+c.token)
 		req.Header.Set("Content-Type", "application/json; charset=utf-8")
 
 		resp, err := c.client.Do(req)
 		if err != nil {
+			traceAttempt(ctx, nil)
 			lastErr = fmt.Errorf("Slack API request failed: %w", err)
 			c.logger.Warn("Slack API request failed, retrying",
 				"attempt", attempt+1,
@@ -208,30 +299,34 @@ func (c *SlackChannel) sendAPI(ctx context.Context, notification *Notification)
 		}
 
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		traceAttempt(ctx, body)
 		resp.Body.Close()
 
 		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			var apiResp struct {
-				OK    bool   `json:"ok"`
-				Error string `json:"error"`
+				OK      bool   `json:"ok"`
+				Error   string `json:"error"`
+				Channel string `json:"channel"`
+				TS      string `json:"ts"`
 			}
 			if err := json.Unmarshal(body, &apiResp); err == nil {
 				if apiResp.OK {
 					c.logger.Debug("Slack API notification sent",
+						"method", method,
 						"notification_type", notification.Type,
 					)
-					return nil
+					return slackMessage{channel: apiResp.Channel, ts: apiResp.TS, postedAt: time.Now()}, nil
 				}
 				lastErr = fmt.Errorf("Slack API error: %s", apiResp.Error)
 			} else {
-				return nil
+				return slackMessage{}, nil
 			}
 		} else {
 			lastErr = fmt.Errorf("Slack API returned status %d: %s", resp.StatusCode, string(body))
 		}
 
 		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != 429 {
-			return lastErr
+			return slackMessage{}, permanent(lastErr)
 		}
 
 		if resp.StatusCode == 429 {
@@ -242,37 +337,130 @@ func (c *SlackChannel) sendAPI(ctx context.Context, notification *Notification)
 		}
 	}
 
-	return lastErr
+	return slackMessage{}, lastErr
 }
 
-// formatMessage formats the notification as Slack blocks.
+// slackRunKey identifies the message of a run, which later events of the
+// run update.
+func slackRunKey(notification *Notification) string {
+	if notification.RunID == nil {
+		return ""
+	}
+	return "run:" + notification.RunID.String()
+}
+
+// slackFailureKey identifies the failing state of a service branch, which
+// its recovery replies to.
+func slackFailureKey(notification *Notification) string {
+	if notification.ServiceID == nil {
+		return ""
+	}
+	key := "failure:" + notification.ServiceID.String()
+	if notification.Summary != nil {
+		key += ":" + notification.Summary.Branch
+	}
+	return key
+}
+
+// message returns a remembered message.
+func (c *SlackChannel) message(key string) (slackMessage, bool) {
+	c.messagesMu.Lock()
+	defer c.messagesMu.Unlock()
+
+	msg, ok := c.messages[key]
+	if !ok || time.Since(msg.postedAt) > slackMessageTTL {
+		return slackMessage{}, false
+	}
+	return msg, true
+}
+
+// remember records a message posted or updated for a notification, so that
+// later events of its run update it and the recovery of a failure replies
+// to it. Expired messages are pruned.
+func (c *SlackChannel) remember(notification *Notification, msg slackMessage) {
+	if msg.ts == "" || (!c.threads && !c.updates) {
+		return
+	}
+
+	c.messagesMu.Lock()
+	defer c.messagesMu.Unlock()
+
+	for key, m := range c.messages {
+		if time.Since(m.postedAt) > slackMessageTTL {
+			delete(c.messages, key)
+		}
+	}
+
+	if key := slackRunKey(notification); c.updates && key != "" {
+		c.messages[key] = msg
+	}
+	if key := slackFailureKey(notification); c.threads && key != "" {
+		switch notification.Type {
+		case NotificationTypeRunFailed, NotificationTypeRunError, NotificationTypeRunTimeout:
+			if _, ok := c.messages[key]; !ok {
+				c.messages[key] = msg
+			}
+		case NotificationTypeRunRecovered:
+			delete(c.messages, key)
+		}
+	}
+}
+
+// formatMessage formats the notification in the channel's message
+// format: Block Kit blocks, either at the top level or inside a legacy
+// attachment that adds a status color bar.
 func (c *SlackChannel) formatMessage(notification *Notification) map[string]interface{} {
-	color := c.getColor(notification.Type)
-
-	// Build the main attachment
-	attachment := map[string]interface{}{
-		"color": color,
-		"blocks": []map[string]interface{}{
-			{
-				"type": "header",
-				"text": map[string]interface{}{
-					"type":  "plain_text",
-					"text":  notification.Title,
-					"emoji": true,
-				},
+	blocks := c.formatBlocks(notification)
+
+	var payload map[string]interface{}
+	if c.format == database.SlackMessageFormatBlocks {
+		payload = map[string]interface{}{
+			"blocks": blocks,
+			// Shown in push notifications, which do not render blocks.
+			"text": notification.Title,
+		}
+	} else {
+		payload = map[string]interface{}{
+			"attachments": []map[string]interface{}{{
+				"color":  c.getColor(notification.Type),
+				"blocks": blocks,
+			}},
+		}
+	}
+
+	if c.channel != "" {
+		payload["channel"] = c.channel
+	}
+	if c.username != "" {
+		payload["username"] = c.username
+	}
+	if c.iconEmoji != "" {
+		payload["icon_emoji"] = c.iconEmoji
+	}
+
+	return payload
+}
+
+// formatBlocks formats the notification as Slack blocks.
+func (c *SlackChannel) formatBlocks(notification *Notification) []map[string]interface{} {
+	blocks := []map[string]interface{}{
+		{
+			"type": "header",
+			"text": map[string]interface{}{
+				"type":  "plain_text",
+				"text":  notification.Title,
+				"emoji": true,
 			},
-			{
-				"type": "section",
-				"text": map[string]interface{}{
-					"type": "mrkdwn",
-					"text": notification.Message,
-				},
+		},
+		{
+			"type": "section",
+			"text": map[string]interface{}{
+				"type": "mrkdwn",
+				"text": notification.Message,
 			},
 		},
 	}
 
-	blocks := attachment["blocks"].([]map[string]interface{})
-
 	// Add summary fields if available
 	if notification.Summary != nil {
 		fields := []map[string]interface{}{}
@@ -371,28 +559,10 @@ func (c *SlackChannel) formatMessage(notification *Notification) map[string]inte
 		},
 	}
 
-	blocks = append(blocks, map[string]interface{}{
+	return append(blocks, map[string]interface{}{
 		"type":     "context",
 		"elements": contextElements,
 	})
-
-	attachment["blocks"] = blocks
-
-	payload := map[string]interface{}{
-		"attachments": []map[string]interface{}{attachment},
-	}
-
-	if c.channel != "" {
-		payload["channel"] = c.channel
-	}
-	if c.username != "" {
-		payload["username"] = c.username
-	}
-	if c.iconEmoji != "" {
-		payload["icon_emoji"] = c.iconEmoji
-	}
-
-	return payload
 }
 
 // getColor returns the appropriate color for the notification type.
@@ -402,7 +572,7 @@ func (c *SlackChannel) getColor(notificationType NotificationType) string {
 		return "#36a64f" // Green
 	case NotificationTypeRunFailed, NotificationTypeRunError, NotificationTypeRunTimeout:
 		return "#dc3545" // Red
-	case NotificationTypeFlakyDetected, NotificationTypeTestQuarantined:
+	case NotificationTypeFlakyDetected, NotificationTypeTestQuarantined, NotificationTypeDurationAnomaly, NotificationTypeDurationRegression:
 		return "#ffc107" // Yellow/Warning
 	case NotificationTypeRunStarted:
 		return "#17a2b8" // Blue/Info
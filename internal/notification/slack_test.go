@@ -0,0 +1,113 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// slackCall is a Web API call received by fakeSlackAPI.
+type slackCall struct {
+	method  string
+	payload map[string]interface{}
+}
+
+// fakeSlackAPI serves chat.postMessage and chat.update, numbering posted
+// messages.
+type fakeSlackAPI struct {
+	mu    sync.Mutex
+	calls []slackCall
+}
+
+func (f *fakeSlackAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var payload map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&payload)
+
+	f.mu.Lock()
+	method := strings.TrimPrefix(r.URL.Path, "/")
+	f.calls = append(f.calls, slackCall{method: method, payload: payload})
+	ts := strconv.Itoa(len(f.calls))
+	f.mu.Unlock()
+
+	if method == "chat.update" {
+		ts, _ = payload["ts"].(string)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "channel": "C123", "ts": ts})
+}
+
+func TestSlackChannelFormats(t *testing.T) {
+	n := &Notification{Type: NotificationTypeRunFailed, Title: "Run failed", Message: "3 tests failed", CreatedAt: time.Now()}
+
+	attachments := NewSlackChannel(SlackConfig{WebhookURL: "https://hooks.slack.test"}, nil).formatMessage(n)
+	require.Contains(t, attachments, "attachments")
+	assert.NotContains(t, attachments, "blocks")
+
+	blocks := NewSlackChannel(SlackConfig{WebhookURL: "https://hooks.slack.test", Format: database.SlackMessageFormatBlocks}, nil).formatMessage(n)
+	require.Contains(t, blocks, "blocks")
+	assert.NotContains(t, blocks, "attachments")
+	assert.Equal(t, "Run failed", blocks["text"])
+
+	assert.Error(t, NewSlackChannel(SlackConfig{WebhookURL: "https://hooks.slack.test", Format: "cards"}, nil).Validate())
+	assert.Error(t, NewSlackChannel(SlackConfig{WebhookURL: "https://hooks.slack.test", ThreadReplies: true}, nil).Validate())
+	assert.NoError(t, NewSlackChannel(SlackConfig{Token: "xoxb", Channel: "#ci", ThreadReplies: true}, nil).Validate())
+}
+
+func TestSlackChannelThreadsAndUpdates(t *testing.T) {
+	api := &fakeSlackAPI{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	channel := NewSlackChannel(SlackConfig{
+		Token:         "xoxb-test",
+		Channel:       "#ci",
+		APIBaseURL:    server.URL,
+		ThreadReplies: true,
+		UpdateInPlace: true,
+	}, nil)
+
+	serviceID := uuid.New()
+	failedRun, recoveredRun := uuid.New(), uuid.New()
+	notify := func(notificationType NotificationType, runID uuid.UUID) {
+		t.Helper()
+		require.NoError(t, channel.Send(t.Context(), &Notification{
+			Type:      notificationType,
+			ServiceID: &serviceID,
+			RunID:     &runID,
+			Title:     string(notificationType),
+			Summary:   &RunSummary{Branch: "main"},
+			CreatedAt: time.Now(),
+		}))
+	}
+
+	notify(NotificationTypeRunStarted, failedRun)
+	notify(NotificationTypeRunFailed, failedRun)
+	notify(NotificationTypeRunRecovered, recoveredRun)
+	notify(NotificationTypeRunPassed, uuid.New())
+
+	require.Len(t, api.calls, 4)
+
+	assert.Equal(t, "chat.postMessage", api.calls[0].method)
+
+	// The failure updates the message of its run.
+	assert.Equal(t, "chat.update", api.calls[1].method)
+	assert.Equal(t, "1", api.calls[1].payload["ts"])
+
+	// The recovery replies to the failure.
+	assert.Equal(t, "chat.postMessage", api.calls[2].method)
+	assert.Equal(t, "1", api.calls[2].payload["thread_ts"])
+
+	// Other runs post new messages outside the thread.
+	assert.Equal(t, "chat.postMessage", api.calls[3].method)
+	assert.NotContains(t, api.calls[3].payload, "thread_ts")
+}
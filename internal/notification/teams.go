@@ -84,6 +84,7 @@ func (c *TeamsChannel) Send(ctx context.Context, notification *Notification) err
 
 		resp, err := c.client.Do(req)
 		if err != nil {
+			traceAttempt(ctx, nil)
 			lastErr = fmt.Errorf("Teams request failed: %w", err)
 			c.logger.Warn("Teams request failed, retrying",
 				"attempt", attempt+1,
@@ -95,6 +96,7 @@ func (c *TeamsChannel) Send(ctx context.Context, notification *Notification) err
 		defer resp.Body.Close()
 
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		traceAttempt(ctx, body)
 
 		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusAccepted {
 			c.logger.Debug("Teams notification sent",
@@ -107,7 +109,7 @@ func (c *TeamsChannel) Send(ctx context.Context, notification *Notification) err
 
 		// Don't retry on client errors except rate limits
 		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != 429 {
-			return lastErr
+			return permanent(lastErr)
 		}
 	}
 
@@ -282,7 +284,7 @@ func (c *TeamsChannel) getThemeColor(notificationType NotificationType) string {
 		return "28a745" // Green
 	case NotificationTypeRunFailed, NotificationTypeRunError, NotificationTypeRunTimeout:
 		return "dc3545" // Red
-	case NotificationTypeFlakyDetected, NotificationTypeTestQuarantined:
+	case NotificationTypeFlakyDetected, NotificationTypeTestQuarantined, NotificationTypeDurationAnomaly, NotificationTypeDurationRegression:
 		return "ffc107" // Yellow
 	case NotificationTypeRunStarted:
 		return "17a2b8" // Blue
@@ -2,7 +2,10 @@ package notification
 
 import (
 	"fmt"
+	"io"
+	"math"
 	"strings"
+	"text/template"
 	"time"
 )
 
@@ -33,8 +36,72 @@ type TemplateVars struct {
 	FlakyRuns      int
 	TotalRuns      int
 	QuarantinedBy  string
+	AgentName      string
+	MeanDurationMs int64
+	DurationSigma  float64
+	BaselineRuns   int
 	URL            string
 	Timestamp      time.Time
+	// FailedTestNames are the names of the run's failed tests, when the
+	// event carries them.
+	FailedTestNames []string
+	// RegressedTests are the tests of a duration regression that took
+	// significantly longer than usual.
+	RegressedTests []RegressedTest
+	// MaintenanceWindow is the name of the maintenance window the event
+	// occurred in, if the window tags notifications.
+	MaintenanceWindow string
+}
+
+// RegressedTest is a test whose duration regressed.
+type RegressedTest struct {
+	Name           string
+	DurationMs     int64
+	MeanDurationMs int64
+}
+
+// templateFuncs are the functions available to rule templates.
+var templateFuncs = template.FuncMap{
+	"join":     strings.Join,
+	"truncate": truncateString,
+	"shortSHA": func(sha string) string {
+		if len(sha) > 7 {
+			return sha[:7]
+		}
+		return sha
+	},
+	"duration": func(ms int64) string {
+		return (time.Duration(ms) * time.Millisecond).Round(time.Second).String()
+	},
+}
+
+// ParseRuleTemplate parses a title or message template of a notification
+// rule. Templates are Go text/templates executed with TemplateVars.
+func ParseRuleTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// ValidateRuleTemplate checks that a rule template parses and only refers to
+// known template variables.
+func ValidateRuleTemplate(name, text string) error {
+	tmpl, err := ParseRuleTemplate(name, text)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(io.Discard, TemplateVars{})
+}
+
+// RenderRuleTemplate renders a rule template with the variables of an event.
+func RenderRuleTemplate(name, text string, vars TemplateVars) (string, error) {
+	tmpl, err := ParseRuleTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, vars); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
 }
 
 // RunStartedTemplate returns a notification for run started events.
@@ -199,6 +266,79 @@ func RunErrorTemplate(vars TemplateVars) (title, message string) {
 	return
 }
 
+// DurationAnomalyTemplate returns a notification for run duration anomalies.
+func DurationAnomalyTemplate(vars TemplateVars) (title, message string) {
+	title = fmt.Sprintf("Run Duration Anomaly - %s", vars.ServiceName)
+
+	duration := (time.Duration(vars.DurationMs) * time.Millisecond).Round(time.Second)
+	mean := (time.Duration(vars.MeanDurationMs) * time.Millisecond).Round(time.Second)
+	comparison := "longer"
+	if vars.DurationMs < vars.MeanDurationMs {
+		comparison = "shorter"
+	}
+
+	var parts []string
+	parts = append(parts, fmt.Sprintf("A passing test run of *%s* took %s, much %s than usual.", vars.ServiceName, duration, comparison))
+	parts = append(parts, "")
+	parts = append(parts, fmt.Sprintf("*Usual duration:* %s (mean of the last %d passed runs)", mean, vars.BaselineRuns))
+	if !math.IsInf(vars.DurationSigma, 0) {
+		parts = append(parts, fmt.Sprintf("*Deviation:* %.1fσ", math.Abs(vars.DurationSigma)))
+	}
+	if vars.Branch != "" {
+		parts = append(parts, fmt.Sprintf("*Branch:* `%s`", vars.Branch))
+	}
+
+	message = strings.Join(parts, "\n")
+	return
+}
+
+// maxRegressedTestsListed bounds the regressed tests listed in a duration
+// regression notification.
+const maxRegressedTestsListed = 10
+
+// DurationRegressionTemplate returns a notification for run duration
+// regressions. The run's own regression is described when BaselineRuns is
+// set.
+func DurationRegressionTemplate(vars TemplateVars) (title, message string) {
+	title = fmt.Sprintf("Duration Regression - %s", vars.ServiceName)
+
+	round := func(ms int64) time.Duration {
+		return (time.Duration(ms) * time.Millisecond).Round(time.Second)
+	}
+
+	var parts []string
+	if vars.BaselineRuns > 0 {
+		parts = append(parts, fmt.Sprintf("A test run of *%s* took %s, much longer than usual.", vars.ServiceName, round(vars.DurationMs)))
+		parts = append(parts, "")
+		parts = append(parts, fmt.Sprintf("*Usual duration:* %s (mean of the last %d passed runs)", round(vars.MeanDurationMs), vars.BaselineRuns))
+		if !math.IsInf(vars.DurationSigma, 0) {
+			parts = append(parts, fmt.Sprintf("*Deviation:* %.1fσ", vars.DurationSigma))
+		}
+	} else {
+		parts = append(parts, fmt.Sprintf("Tests of *%s* took much longer than usual.", vars.ServiceName))
+	}
+	if vars.Branch != "" {
+		parts = append(parts, fmt.Sprintf("*Branch:* `%s`", vars.Branch))
+	}
+
+	if len(vars.RegressedTests) > 0 {
+		parts = append(parts, "")
+		parts = append(parts, "*Slower tests:*")
+		for i, test := range vars.RegressedTests {
+			if i == maxRegressedTestsListed {
+				parts = append(parts, fmt.Sprintf("...and %d more", len(vars.RegressedTests)-i))
+				break
+			}
+			// Tests often take well under a second.
+			parts = append(parts, fmt.Sprintf("- `%s`: %s (usually %s)", test.Name,
+				time.Duration(test.DurationMs)*time.Millisecond, time.Duration(test.MeanDurationMs)*time.Millisecond))
+		}
+	}
+
+	message = strings.Join(parts, "\n")
+	return
+}
+
 // AgentOfflineTemplate returns a notification for agent offline events.
 func AgentOfflineTemplate(agentName string) (title, message string) {
 	title = fmt.Sprintf("Agent Offline - %s", agentName)
@@ -254,6 +394,14 @@ func GetTemplateForType(notificationType NotificationType, vars TemplateVars) (t
 		return RunTimeoutTemplate(vars)
 	case NotificationTypeRunError:
 		return RunErrorTemplate(vars)
+	case NotificationTypeDurationAnomaly:
+		return DurationAnomalyTemplate(vars)
+	case NotificationTypeDurationRegression:
+		return DurationRegressionTemplate(vars)
+	case NotificationTypeAgentOffline:
+		return AgentOfflineTemplate(vars.AgentName)
+	case NotificationTypeAgentOnline:
+		return AgentOnlineTemplate(vars.AgentName)
 	default:
 		return "Notification", "A notification event occurred."
 	}
@@ -0,0 +1,57 @@
+package notification
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+func TestRenderRuleTemplate(t *testing.T) {
+	vars := TemplateVars{
+		ServiceName:     "checkout",
+		FailedTests:     2,
+		TotalTests:      40,
+		CommitSHA:       "0123456789abcdef",
+		DurationMs:      95400,
+		URL:             "https://conductor.example.com/runs/1",
+		FailedTestNames: []string{"TestPay", "TestRefund"},
+	}
+
+	out, err := RenderRuleTemplate("message", `{{.FailedTests}}/{{.TotalTests}} failed in {{duration .DurationMs}} at {{shortSHA .CommitSHA}}: {{join .FailedTestNames ", "}} {{.URL}}`, vars)
+	require.NoError(t, err)
+	assert.Equal(t, "2/40 failed in 1m35s at 0123456: TestPay, TestRefund https://conductor.example.com/runs/1", out)
+
+	_, err = RenderRuleTemplate("title", "{{.ServiceName", vars)
+	assert.Error(t, err)
+}
+
+func TestValidateRuleTemplate(t *testing.T) {
+	assert.NoError(t, ValidateRuleTemplate("title", "{{.ServiceName}} failed"))
+	assert.NoError(t, ValidateRuleTemplate("message", "{{range .FailedTestNames}}- {{.}}\n{{end}}"))
+	assert.Error(t, ValidateRuleTemplate("title", "{{.ServiceName"), "unterminated action")
+	assert.Error(t, ValidateRuleTemplate("title", "{{.Nope}}"), "unknown variable")
+	assert.Error(t, ValidateRuleTemplate("title", "{{nope .ServiceName}}"), "unknown function")
+}
+
+func TestTemplatedNotification(t *testing.T) {
+	title := "{{.ServiceName}} is red"
+	message := "{{.Broken"
+	plain := &database.NotificationRule{ID: uuid.New()}
+	templated := &database.NotificationRule{ID: uuid.New(), TitleTemplate: &title, MessageTemplate: &message}
+	base := &Notification{Title: "Tests Failed - checkout", Message: "2 tests failed"}
+	vars := TemplateVars{ServiceName: "checkout"}
+
+	s := &Service{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	assert.Same(t, base, s.templatedNotification(base, channelMatch{Rules: []*database.NotificationRule{plain}}, vars))
+
+	got := s.templatedNotification(base, channelMatch{Rules: []*database.NotificationRule{plain, templated}}, vars)
+	assert.Equal(t, "checkout is red", got.Title)
+	assert.Equal(t, "2 tests failed", got.Message, "templates that fail to render keep the built-in text")
+	assert.Equal(t, "Tests Failed - checkout", base.Title, "original notification must not be modified")
+}
@@ -119,6 +119,7 @@ func (c *WebhookChannel) Send(ctx context.Context, notification *Notification) e
 
 		resp, err := c.client.Do(req)
 		if err != nil {
+			traceAttempt(ctx, nil)
 			lastErr = fmt.Errorf("webhook request failed: %w", err)
 			c.logger.Warn("webhook request failed, retrying",
 				"attempt", attempt+1,
@@ -131,6 +132,7 @@ func (c *WebhookChannel) Send(ctx context.Context, notification *Notification) e
 
 		// Read response body for error reporting
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		traceAttempt(ctx, body)
 
 		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			c.logger.Debug("webhook notification sent",
@@ -144,7 +146,7 @@ func (c *WebhookChannel) Send(ctx context.Context, notification *Notification) e
 
 		// Don't retry on client errors (4xx) except 429 (rate limit)
 		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != 429 {
-			return lastErr
+			return permanent(lastErr)
 		}
 
 		c.logger.Warn("webhook returned error, retrying",
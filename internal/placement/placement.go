@@ -0,0 +1,132 @@
+// Package placement decides which agents may run a piece of work. Work is
+// constrained by the network zones of its service and by label selectors
+// declared on test definitions and runs; agents offer their network zones
+// and labels.
+package placement
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Built-in labels every agent carries, taken from its reported capabilities
+// unless the agent sets them explicitly.
+const (
+	LabelOS   = "os"
+	LabelArch = "arch"
+)
+
+// AgentLabels returns the labels an agent is matched on: its configured
+// labels plus the built-in os and arch labels.
+func AgentLabels(labels map[string]string, os, arch string) map[string]string {
+	result := make(map[string]string, len(labels)+2)
+	if os != "" {
+		result[LabelOS] = os
+	}
+	if arch != "" {
+		result[LabelArch] = arch
+	}
+	for key, value := range labels {
+		result[key] = value
+	}
+	return result
+}
+
+// archAliases maps common names of CPU architectures to the Go names
+// agents report.
+var archAliases = map[string]string{
+	"x86_64":  "amd64",
+	"x64":     "amd64",
+	"aarch64": "arm64",
+	"armv7l":  "arm",
+	"i386":    "386",
+	"i686":    "386",
+}
+
+// NormalizeArchitectures lowercases architectures, maps aliases such as
+// x86_64 and aarch64 to the names agents report and removes duplicates,
+// keeping the first occurrence.
+func NormalizeArchitectures(archs []string) ([]string, error) {
+	if len(archs) == 0 {
+		return nil, nil
+	}
+
+	result := make([]string, 0, len(archs))
+	seen := make(map[string]bool, len(archs))
+	for _, arch := range archs {
+		arch = strings.ToLower(strings.TrimSpace(arch))
+		if arch == "" || strings.ContainsAny(arch, " \t,=") {
+			return nil, fmt.Errorf("invalid architecture %q", arch)
+		}
+		if alias, ok := archAliases[arch]; ok {
+			arch = alias
+		}
+		if !seen[arch] {
+			seen[arch] = true
+			result = append(result, arch)
+		}
+	}
+	return result, nil
+}
+
+// ZonesMatch reports whether an agent in the available zones can reach a
+// service in the required zones. Either side being empty means unrestricted.
+func ZonesMatch(required, available []string) bool {
+	if len(required) == 0 || len(available) == 0 {
+		return true
+	}
+	set := make(map[string]struct{}, len(available))
+	for _, zone := range available {
+		set[zone] = struct{}{}
+	}
+	for _, zone := range required {
+		if _, ok := set[zone]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// LabelsMatch reports whether labels carry every key=value pair of a
+// selector. An empty selector matches any labels.
+func LabelsMatch(selector, labels map[string]string) bool {
+	for key, value := range selector {
+		if got, ok := labels[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
+
+// MergeSelectors combines selectors into one that requires all of their
+// labels. Selectors requiring different values for the same key can never
+// be satisfied together and return an error.
+func MergeSelectors(selectors ...map[string]string) (map[string]string, error) {
+	merged := make(map[string]string)
+	for _, selector := range selectors {
+		for key, value := range selector {
+			if existing, ok := merged[key]; ok && existing != value {
+				return nil, fmt.Errorf("conflicting label selectors: %s=%s and %s=%s", key, existing, key, value)
+			}
+			merged[key] = value
+		}
+	}
+	return merged, nil
+}
+
+// FormatSelector renders a selector as comma-separated key=value pairs in
+// key order.
+func FormatSelector(selector map[string]string) string {
+	keys := make([]string, 0, len(selector))
+	for key := range selector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+selector[key])
+	}
+	return strings.Join(pairs, ",")
+}
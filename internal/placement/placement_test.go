@@ -0,0 +1,62 @@
+package placement
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentLabels(t *testing.T) {
+	labels := AgentLabels(map[string]string{"gpu": "true", "os": "windows-server"}, "windows", "amd64")
+
+	assert.Equal(t, map[string]string{
+		"gpu":  "true",
+		"os":   "windows-server",
+		"arch": "amd64",
+	}, labels)
+	assert.Empty(t, AgentLabels(nil, "", ""))
+}
+
+func TestNormalizeArchitectures(t *testing.T) {
+	archs, err := NormalizeArchitectures([]string{"AMD64", "aarch64", "x86_64", "arm64"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"amd64", "arm64"}, archs)
+
+	archs, err = NormalizeArchitectures(nil)
+	require.NoError(t, err)
+	assert.Nil(t, archs)
+
+	_, err = NormalizeArchitectures([]string{"amd64", " "})
+	assert.ErrorContains(t, err, "invalid architecture")
+}
+
+func TestZonesMatch(t *testing.T) {
+	assert.True(t, ZonesMatch(nil, []string{"prod"}))
+	assert.True(t, ZonesMatch([]string{"prod"}, nil))
+	assert.True(t, ZonesMatch([]string{"prod", "staging"}, []string{"staging"}))
+	assert.False(t, ZonesMatch([]string{"prod"}, []string{"staging"}))
+}
+
+func TestLabelsMatch(t *testing.T) {
+	labels := map[string]string{"gpu": "true", "os": "linux"}
+
+	assert.True(t, LabelsMatch(nil, labels))
+	assert.True(t, LabelsMatch(map[string]string{"gpu": "true"}, labels))
+	assert.False(t, LabelsMatch(map[string]string{"gpu": "false"}, labels))
+	assert.False(t, LabelsMatch(map[string]string{"os": "windows"}, nil))
+}
+
+func TestMergeSelectors(t *testing.T) {
+	merged, err := MergeSelectors(map[string]string{"gpu": "true"}, nil, map[string]string{"os": "linux", "gpu": "true"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"gpu": "true", "os": "linux"}, merged)
+
+	_, err = MergeSelectors(map[string]string{"os": "linux"}, map[string]string{"os": "windows"})
+	assert.ErrorContains(t, err, "os=linux and os=windows")
+}
+
+func TestFormatSelector(t *testing.T) {
+	assert.Equal(t, "gpu=true,os=windows", FormatSelector(map[string]string{"os": "windows", "gpu": "true"}))
+	assert.Equal(t, "", FormatSelector(nil))
+}
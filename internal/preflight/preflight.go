@@ -0,0 +1,184 @@
+// Package preflight verifies that a run can start before it is queued: its
+// commit exists, the container images of its tests can be pulled, and the
+// secrets it references resolve. Misconfiguration is reported when the run
+// is created instead of after an agent has cloned the repository.
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/secrets"
+)
+
+// Names of the checks problems are reported for.
+const (
+	CheckCommit = "commit"
+	CheckImage  = "image"
+	CheckSecret = "secret"
+)
+
+// CommitResolver resolves refs in a service's repository to commits.
+type CommitResolver interface {
+	ResolveCommit(ctx context.Context, service *database.Service, ref string) (string, error)
+}
+
+// ImageChecker verifies that a container image can be pulled.
+type ImageChecker interface {
+	CheckImage(ctx context.Context, image string) error
+}
+
+// SecretResolver resolves secret references. References it does not serve
+// are left to the agents' own secret providers.
+type SecretResolver interface {
+	Serves(ref secrets.Reference) bool
+	Resolve(ctx context.Context, ref secrets.Reference) (string, error)
+}
+
+// Config holds pre-flight check settings.
+type Config struct {
+	// Timeout bounds all checks of a run; 0 means no limit.
+	Timeout time.Duration
+}
+
+// Target describes the run being checked.
+type Target struct {
+	Service *database.Service
+	// Ref is the commit SHA or branch the run checks out. Empty skips the
+	// commit check.
+	Ref string
+	// Tests are the test definitions the run executes.
+	Tests []*database.TestDefinition
+	// Environment is the service environment set, if any.
+	Environment *database.ServiceEnvironment
+}
+
+// Problem is a failed check.
+type Problem struct {
+	Check   string
+	Message string
+}
+
+// String formats the problem as "check: message".
+func (p Problem) String() string {
+	return p.Check + ": " + p.Message
+}
+
+// Checker runs pre-flight checks. Checks whose dependency is nil are
+// skipped.
+type Checker struct {
+	commits CommitResolver
+	images  ImageChecker
+	secrets SecretResolver
+	cfg     Config
+}
+
+// NewChecker creates a checker over the given dependencies, any of which
+// may be nil.
+func NewChecker(commits CommitResolver, images ImageChecker, secrets SecretResolver, cfg Config) *Checker {
+	return &Checker{
+		commits: commits,
+		images:  images,
+		secrets: secrets,
+		cfg:     cfg,
+	}
+}
+
+// Check runs every check against a run and returns the problems found.
+func (c *Checker) Check(ctx context.Context, target Target) []Problem {
+	if c.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.cfg.Timeout)
+		defer cancel()
+	}
+
+	var problems []Problem
+	problems = append(problems, c.checkCommit(ctx, target)...)
+	problems = append(problems, c.checkImages(ctx, target)...)
+	problems = append(problems, c.checkSecrets(ctx, target)...)
+	return problems
+}
+
+// checkCommit verifies that the run's ref resolves to a commit.
+func (c *Checker) checkCommit(ctx context.Context, target Target) []Problem {
+	if c.commits == nil || target.Ref == "" {
+		return nil
+	}
+
+	if _, err := c.commits.ResolveCommit(ctx, target.Service, target.Ref); err != nil {
+		return []Problem{{
+			Check:   CheckCommit,
+			Message: fmt.Sprintf("%s does not resolve to a commit in %s: %v", target.Ref, target.Service.GitURL, err),
+		}}
+	}
+	return nil
+}
+
+// checkImages verifies that the image of every container test can be
+// pulled. Images shared by several tests are checked once.
+func (c *Checker) checkImages(ctx context.Context, target Target) []Problem {
+	if c.images == nil {
+		return nil
+	}
+
+	var problems []Problem
+	checked := make(map[string]bool)
+	for _, test := range target.Tests {
+		if test.ExecutionType != "container" || test.ContainerImage == nil || checked[*test.ContainerImage] {
+			continue
+		}
+		image := *test.ContainerImage
+		checked[image] = true
+
+		if err := c.images.CheckImage(ctx, image); err != nil {
+			problems = append(problems, Problem{
+				Check:   CheckImage,
+				Message: fmt.Sprintf("image %s of test %s cannot be pulled: %v", image, test.Name, err),
+			})
+		}
+	}
+	return problems
+}
+
+// checkSecrets verifies that the secrets of the service environment and of
+// every test resolve. Only references the resolver serves are checked.
+func (c *Checker) checkSecrets(ctx context.Context, target Target) []Problem {
+	if c.secrets == nil {
+		return nil
+	}
+
+	var problems []Problem
+	checked := make(map[secrets.Reference]bool)
+	check := func(owner string, refs []database.SecretRef) {
+		for _, ref := range refs {
+			reference := secrets.Reference{
+				Provider: secrets.Provider(ref.Provider),
+				Path:     ref.Path,
+				Key:      ref.Key,
+				Version:  ref.Version,
+			}
+			if checked[reference] || !c.secrets.Serves(reference) {
+				continue
+			}
+			checked[reference] = true
+
+			reference.Name = ref.Name
+			if _, err := c.secrets.Resolve(ctx, reference); err != nil {
+				problems = append(problems, Problem{
+					Check:   CheckSecret,
+					Message: fmt.Sprintf("secret %s of %s does not resolve: %v", ref.Name, owner, err),
+				})
+			}
+		}
+	}
+
+	if target.Environment != nil {
+		check("the service environment", target.Environment.Secrets)
+	}
+	for _, test := range target.Tests {
+		check("test "+test.Name, test.Secrets)
+	}
+	return problems
+}
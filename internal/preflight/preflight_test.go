@@ -0,0 +1,102 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/secrets"
+)
+
+type fakeCommits struct {
+	known map[string]bool
+}
+
+func (f *fakeCommits) ResolveCommit(ctx context.Context, service *database.Service, ref string) (string, error) {
+	if !f.known[ref] {
+		return "", errors.New("GitHub API error (status 404)")
+	}
+	return "abc123", nil
+}
+
+type fakeImages struct {
+	missing map[string]bool
+	checked []string
+}
+
+func (f *fakeImages) CheckImage(ctx context.Context, image string) error {
+	f.checked = append(f.checked, image)
+	if f.missing[image] {
+		return ErrImageNotFound
+	}
+	return nil
+}
+
+type fakeSecrets struct {
+	provider secrets.Provider
+	missing  map[string]bool
+	resolved []string
+}
+
+func (f *fakeSecrets) Serves(ref secrets.Reference) bool {
+	return ref.Provider == "" || ref.Provider == f.provider
+}
+
+func (f *fakeSecrets) Resolve(ctx context.Context, ref secrets.Reference) (string, error) {
+	f.resolved = append(f.resolved, ref.Path)
+	if f.missing[ref.Path] {
+		return "", errors.New("secret not found")
+	}
+	return "value", nil
+}
+
+func TestChecker_Check(t *testing.T) {
+	service := &database.Service{Name: "api", GitURL: "https://github.com/acme/api.git"}
+	tests := []*database.TestDefinition{
+		{Name: "e2e", ExecutionType: "container", ContainerImage: database.NullString("acme/e2e:missing"),
+			Secrets: []database.SecretRef{{Name: "TOKEN", Path: "ci/token"}}},
+		{Name: "smoke", ExecutionType: "container", ContainerImage: database.NullString("acme/e2e:missing")},
+		{Name: "unit", ExecutionType: "subprocess", ContainerImage: database.NullString("ignored:latest"),
+			Secrets: []database.SecretRef{{Name: "AWS_KEY", Provider: "aws", Path: "ci/aws"}}},
+	}
+	env := &database.ServiceEnvironment{Secrets: []database.SecretRef{
+		{Name: "DB_PASSWORD", Path: "ci/db"},
+		{Name: "TOKEN", Path: "ci/token"},
+	}}
+
+	commits := &fakeCommits{known: map[string]bool{"main": true}}
+	images := &fakeImages{missing: map[string]bool{"acme/e2e:missing": true}}
+	resolver := &fakeSecrets{provider: secrets.ProviderVault, missing: map[string]bool{"ci/db": true}}
+	checker := NewChecker(commits, images, resolver, Config{})
+
+	problems := checker.Check(context.Background(), Target{Service: service, Ref: "feature", Tests: tests, Environment: env})
+	require.Len(t, problems, 3)
+	assert.Equal(t, CheckCommit, problems[0].Check)
+	assert.Contains(t, problems[0].Message, "feature does not resolve to a commit in https://github.com/acme/api.git")
+	assert.Equal(t, "image: image acme/e2e:missing of test e2e cannot be pulled: image not found", problems[1].String())
+	assert.Equal(t, "secret: secret DB_PASSWORD of the service environment does not resolve: secret not found", problems[2].String())
+
+	assert.Equal(t, []string{"acme/e2e:missing"}, images.checked, "images are checked once, container tests only")
+	assert.Equal(t, []string{"ci/db", "ci/token"}, resolver.resolved, "references are resolved once, unserved providers skipped")
+
+	problems = checker.Check(context.Background(), Target{Service: service, Ref: "main"})
+	assert.Empty(t, problems)
+}
+
+func TestChecker_SkipsWithoutDependencies(t *testing.T) {
+	checker := NewChecker(nil, nil, nil, Config{})
+
+	problems := checker.Check(context.Background(), Target{
+		Service: &database.Service{Name: "api"},
+		Ref:     "main",
+		Tests: []*database.TestDefinition{
+			{Name: "e2e", ExecutionType: "container", ContainerImage: database.NullString("acme/e2e:1.0"),
+				Secrets: []database.SecretRef{{Name: "TOKEN", Path: "ci/token"}}},
+		},
+	})
+	assert.Empty(t, problems)
+}
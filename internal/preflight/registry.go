@@ -0,0 +1,192 @@
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrImageNotFound is returned when a registry reports that an image or
+// tag does not exist.
+var ErrImageNotFound = errors.New("image not found")
+
+const (
+	// dockerHub is the registry of image names without a registry host.
+	dockerHub = "docker.io"
+	// dockerHubAPI serves the registry API of Docker Hub.
+	dockerHubAPI = "registry-1.docker.io"
+)
+
+// manifestMediaTypes are the manifest formats accepted from registries,
+// including multi-architecture indexes.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// challengeParam matches the key="value" parameters of a WWW-Authenticate
+// header.
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// RegistryClient checks container images against the registry HTTP API,
+// requesting anonymous pull tokens where registries ask for them.
+type RegistryClient struct {
+	client *http.Client
+	scheme string
+}
+
+// NewRegistryClient creates a registry client with the given request
+// timeout.
+func NewRegistryClient(timeout time.Duration) *RegistryClient {
+	return &RegistryClient{
+		client: &http.Client{Timeout: timeout},
+		scheme: "https",
+	}
+}
+
+// CheckImage returns an error if the image reference is malformed or the
+// registry reports that its manifest does not exist. Registries that deny
+// anonymous access or cannot be reached from the control plane do not fail
+// the check: agents may pull with their own credentials and networks.
+func (c *RegistryClient) CheckImage(ctx context.Context, image string) error {
+	ref, err := parseImage(image)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.headManifest(ctx, ref, "")
+	if err != nil {
+		return nil
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := c.anonymousToken(ctx, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return nil
+		}
+		if resp, err = c.headManifest(ctx, ref, token); err != nil {
+			return nil
+		}
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s has no manifest %s", ErrImageNotFound, ref.repository, ref.reference)
+	}
+	return nil
+}
+
+// headManifest requests the manifest of an image without downloading it.
+func (c *RegistryClient) headManifest(ctx context.Context, ref imageRef, token string) (*http.Response, error) {
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", c.scheme, ref.registry, ref.repository, ref.reference)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// anonymousToken requests a pull token from the realm of a Bearer
+// challenge.
+func (c *RegistryClient) anonymousToken(ctx context.Context, challenge string) (string, error) {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return "", fmt.Errorf("unsupported auth challenge: %s", challenge)
+	}
+
+	params := make(map[string]string)
+	for _, match := range challengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid auth realm: %q", params["realm"])
+	}
+
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request failed (status %d)", resp.StatusCode)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode token: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// imageRef is a parsed container image reference.
+type imageRef struct {
+	registry   string
+	repository string
+	// reference is a tag or digest.
+	reference string
+}
+
+// parseImage splits an image such as "ghcr.io/acme/app:1.2" or
+// "ubuntu@sha256:..." into registry, repository and tag or digest. Images
+// without a registry host are pulled from Docker Hub.
+func parseImage(image string) (imageRef, error) {
+	name, reference := image, "latest"
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, reference = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, reference = name[:i], name[i+1:]
+	}
+
+	registry := dockerHub
+	if i := strings.Index(name, "/"); i >= 0 {
+		host := name[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			registry, name = host, name[i+1:]
+		}
+	}
+	if name == "" || reference == "" || strings.ContainsAny(image, " \t") {
+		return imageRef{}, fmt.Errorf("invalid image reference %q", image)
+	}
+
+	if registry == dockerHub {
+		registry = dockerHubAPI
+		if !strings.Contains(name, "/") {
+			name = "library/" + name
+		}
+	}
+	return imageRef{registry: registry, repository: name, reference: reference}, nil
+}
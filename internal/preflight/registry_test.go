@@ -0,0 +1,91 @@
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImage(t *testing.T) {
+	tests := []struct {
+		image string
+		want  imageRef
+	}{
+		{"ubuntu", imageRef{"registry-1.docker.io", "library/ubuntu", "latest"}},
+		{"acme/app:1.2", imageRef{"registry-1.docker.io", "acme/app", "1.2"}},
+		{"ghcr.io/acme/app:1.2", imageRef{"ghcr.io", "acme/app", "1.2"}},
+		{"localhost:5000/app", imageRef{"localhost:5000", "app", "latest"}},
+		{"mcr.microsoft.com/playwright@sha256:abc", imageRef{"mcr.microsoft.com", "playwright", "sha256:abc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			got, err := parseImage(tt.image)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := parseImage("acme/app:")
+	assert.Error(t, err)
+	_, err = parseImage("acme app")
+	assert.Error(t, err)
+}
+
+func TestRegistryClient_CheckImage(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			assert.Equal(t, "registry.test", r.URL.Query().Get("service"))
+			assert.Equal(t, "repository:acme/app:pull", r.URL.Query().Get("scope"))
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": "anonymous"})
+			return
+		}
+
+		assert.Equal(t, http.MethodHead, r.Method)
+		assert.Contains(t, r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json")
+		if r.Header.Get("Authorization") != "Bearer anonymous" {
+			w.Header().Set("WWW-Authenticate",
+				`Bearer realm="`+server.URL+`/token",service="registry.test",scope="repository:acme/app:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/manifests/1.0") {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewRegistryClient(5 * time.Second)
+	client.scheme = "http"
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	assert.NoError(t, client.CheckImage(context.Background(), host+"/acme/app:1.0"))
+
+	err := client.CheckImage(context.Background(), host+"/acme/app:2.0")
+	assert.ErrorIs(t, err, ErrImageNotFound)
+	assert.ErrorContains(t, err, "acme/app has no manifest 2.0")
+}
+
+func TestRegistryClient_CheckImageInconclusive(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	client := NewRegistryClient(time.Second)
+	client.scheme = "http"
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	assert.NoError(t, client.CheckImage(context.Background(), host+"/private/app:1.0"), "access denied")
+
+	server.Close()
+	assert.NoError(t, client.CheckImage(context.Background(), host+"/private/app:1.0"), "registry unreachable")
+}
@@ -3,9 +3,12 @@ package registry
 import (
 	"fmt"
 	"io"
+	"path"
 	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/conductor/conductor/internal/placement"
 )
 
 // Manifest represents the .testharness.yaml configuration file.
@@ -40,12 +43,59 @@ type DefaultConfig struct {
 	ContainerImage   string            `yaml:"container_image,omitempty"`
 	WorkingDirectory string            `yaml:"working_directory,omitempty"`
 	Environment      map[string]string `yaml:"environment,omitempty"`
+	LabelSelector    map[string]string `yaml:"label_selector,omitempty"`
+	Architectures    []string          `yaml:"architectures,omitempty"`
+	Matrix           *MatrixConfig     `yaml:"matrix,omitempty"`
+	Cache            *CacheConfig      `yaml:"cache,omitempty"`
+	ArtifactBudget   *ArtifactBudget   `yaml:"artifact_budget,omitempty"`
+}
+
+// MatrixConfig fans a test out over every combination of the values of its
+// axes.
+type MatrixConfig struct {
+	// Env axes set an environment variable, e.g. GO_VERSION: ["1.22", "1.23"].
+	Env map[string][]string `yaml:"env,omitempty"`
+	// Labels axes select agents carrying a label, e.g. os: [linux, windows].
+	Labels map[string][]string `yaml:"labels,omitempty"`
+}
+
+// ParameterConfig declares a parameter runs of a test can set. Its value is
+// passed to the test as the environment variable Name and replaces
+// ${params.Name} in the test's command.
+type ParameterConfig struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	// Type is string (the default), integer or boolean.
+	Type     string `yaml:"type,omitempty"`
+	Required bool   `yaml:"required,omitempty"`
+	// Default is the value in runs that do not set the parameter.
+	Default *string  `yaml:"default,omitempty"`
+	Choices []string `yaml:"choices,omitempty"`
+}
+
+// ArtifactBudget caps the artifacts kept per run of a test. Zero values are
+// unlimited.
+type ArtifactBudget struct {
+	// MaxBytes caps the total size of the test's artifacts.
+	MaxBytes int64 `yaml:"max_bytes,omitempty"`
+	// MaxFiles caps the number of the test's artifacts.
+	MaxFiles int `yaml:"max_files,omitempty"`
+}
+
+// CacheConfig configures a keyed dependency cache persisted on agents.
+type CacheConfig struct {
+	// Key identifies the cache entry. It may reference lockfiles with
+	// {{ checksum "path" }} so the cache is invalidated when they change.
+	Key string `yaml:"key"`
+	// Paths are workspace-relative directories stored under the key.
+	Paths []string `yaml:"paths"`
 }
 
 // TestDefinition defines a single test or test suite.
 type TestDefinition struct {
 	Name             string            `yaml:"name"`
 	Description      string            `yaml:"description,omitempty"`
+	Owner            string            `yaml:"owner,omitempty"`          // team owning the test, defaults to the service owner
 	ExecutionType    string            `yaml:"execution_type,omitempty"` // subprocess, container
 	Command          string            `yaml:"command"`
 	Args             []string          `yaml:"args,omitempty"`
@@ -53,15 +103,23 @@ type TestDefinition struct {
 	ResultFile       string            `yaml:"result_file,omitempty"`
 	ResultFormat     string            `yaml:"result_format,omitempty"` // junit, jest, playwright, go_test, tap, json
 	ArtifactPatterns []string          `yaml:"artifact_patterns,omitempty"`
+	CapturePatterns  []string          `yaml:"capture_patterns,omitempty"` // screenshot and video dirs, e.g. cypress/videos/
 	Tags             []string          `yaml:"tags,omitempty"`
 	DependsOn        []string          `yaml:"depends_on,omitempty"`
 	Retries          int               `yaml:"retries,omitempty"`
 	AllowFailure     bool              `yaml:"allow_failure,omitempty"`
+	RequiresApproval bool              `yaml:"requires_approval,omitempty"` // runs wait until approved
 	ContainerImage   string            `yaml:"container_image,omitempty"`
 	WorkingDirectory string            `yaml:"working_directory,omitempty"`
 	Environment      map[string]string `yaml:"environment,omitempty"`
+	LabelSelector    map[string]string `yaml:"label_selector,omitempty"` // labels an agent must carry, e.g. gpu: "true"
+	Architectures    []string          `yaml:"architectures,omitempty"`  // run once per architecture, e.g. [amd64, arm64]
+	Matrix           *MatrixConfig     `yaml:"matrix,omitempty"`         // run once per combination of values
+	Parameters       []ParameterConfig `yaml:"parameters,omitempty"`     // values runs can set, e.g. TARGET_ENV
 	Setup            []string          `yaml:"setup,omitempty"`
 	Teardown         []string          `yaml:"teardown,omitempty"`
+	Cache            *CacheConfig      `yaml:"cache,omitempty"`
+	ArtifactBudget   *ArtifactBudget   `yaml:"artifact_budget,omitempty"`
 }
 
 // HooksConfig contains lifecycle hook commands.
@@ -141,6 +199,31 @@ func ValidateManifest(m *Manifest) error {
 			errors = append(errors, fmt.Sprintf("%s.retries cannot be negative", prefix))
 		}
 
+		if test.Cache != nil {
+			errors = append(errors, validateCacheConfig(prefix+".cache", test.Cache)...)
+		}
+
+		if _, err := placement.NormalizeArchitectures(test.Architectures); err != nil {
+			errors = append(errors, fmt.Sprintf("%s.architectures: %v", prefix, err))
+		}
+
+		if err := test.Matrix.toDB().Validate(); err != nil {
+			errors = append(errors, fmt.Sprintf("%s.matrix: %v", prefix, err))
+		}
+
+		if err := parametersToDB(test.Parameters).Validate(); err != nil {
+			errors = append(errors, fmt.Sprintf("%s.parameters: %v", prefix, err))
+		}
+
+		if test.ArtifactBudget != nil {
+			if test.ArtifactBudget.MaxBytes < 0 {
+				errors = append(errors, fmt.Sprintf("%s.artifact_budget.max_bytes cannot be negative", prefix))
+			}
+			if test.ArtifactBudget.MaxFiles < 0 {
+				errors = append(errors, fmt.Sprintf("%s.artifact_budget.max_files cannot be negative", prefix))
+			}
+		}
+
 		// Validate dependencies exist
 		for _, dep := range test.DependsOn {
 			if !testNames[dep] && !containsTestNamed(m.Tests, dep) {
@@ -211,6 +294,29 @@ func applyDefaults(m *Manifest) {
 			test.WorkingDirectory = m.Defaults.WorkingDirectory
 		}
 
+		// Apply architectures default
+		if len(test.Architectures) == 0 && len(m.Defaults.Architectures) > 0 {
+			test.Architectures = m.Defaults.Architectures
+		}
+
+		// Apply matrix default
+		if test.Matrix == nil && m.Defaults.Matrix != nil {
+			matrix := *m.Defaults.Matrix
+			test.Matrix = &matrix
+		}
+
+		// Apply cache default
+		if test.Cache == nil && m.Defaults.Cache != nil {
+			cache := *m.Defaults.Cache
+			test.Cache = &cache
+		}
+
+		// Apply artifact budget default
+		if test.ArtifactBudget == nil && m.Defaults.ArtifactBudget != nil {
+			budget := *m.Defaults.ArtifactBudget
+			test.ArtifactBudget = &budget
+		}
+
 		// Merge environment variables (test overrides defaults)
 		if len(m.Defaults.Environment) > 0 {
 			if test.Environment == nil {
@@ -222,7 +328,39 @@ func applyDefaults(m *Manifest) {
 				}
 			}
 		}
+
+		// Merge label selectors (test overrides defaults)
+		if len(m.Defaults.LabelSelector) > 0 {
+			if test.LabelSelector == nil {
+				test.LabelSelector = make(map[string]string)
+			}
+			for k, v := range m.Defaults.LabelSelector {
+				if _, exists := test.LabelSelector[k]; !exists {
+					test.LabelSelector[k] = v
+				}
+			}
+		}
+	}
+}
+
+// validateCacheConfig validates a dependency cache configuration.
+func validateCacheConfig(prefix string, c *CacheConfig) []string {
+	var errors []string
+
+	if strings.TrimSpace(c.Key) == "" {
+		errors = append(errors, fmt.Sprintf("%s.key is required", prefix))
+	}
+	if len(c.Paths) == 0 {
+		errors = append(errors, fmt.Sprintf("%s.paths requires at least one path", prefix))
 	}
+	for _, p := range c.Paths {
+		clean := path.Clean(p)
+		if p == "" || path.IsAbs(p) || clean == ".." || strings.HasPrefix(clean, "../") {
+			errors = append(errors, fmt.Sprintf("%s.paths entry '%s' must be relative to the repository", prefix, p))
+		}
+	}
+
+	return errors
 }
 
 // isValidVersion checks if the manifest version is supported.
@@ -365,9 +503,14 @@ tests:
     timeout_seconds: 1800
     artifact_patterns:
       - "test-results/**"
-      - "playwright-report/**"
+    capture_patterns:
+      - "playwright-report/"
+    artifact_budget:
+      max_bytes: 524288000
+      max_files: 200
     tags: ["e2e", "slow"]
     depends_on: ["integration-tests"]
+    requires_approval: true
 
 hooks:
   before_all:
@@ -11,6 +11,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/placement"
 )
 
 // RegistryService defines the interface for the test registry service.
@@ -274,10 +275,11 @@ func (r *Registry) DeleteService(ctx context.Context, id uuid.UUID) error {
 
 // manifestTestToDBTest converts a manifest test definition to a database model.
 func manifestTestToDBTest(serviceID uuid.UUID, test TestDefinition) database.TestDefinition {
-	return database.TestDefinition{
+	def := database.TestDefinition{
 		ServiceID:        serviceID,
 		Name:             test.Name,
 		Description:      database.NullString(test.Description),
+		Owner:            database.NullString(test.Owner),
 		ExecutionType:    test.ExecutionType,
 		Command:          test.Command,
 		Args:             test.Args,
@@ -289,6 +291,61 @@ func manifestTestToDBTest(serviceID uuid.UUID, test TestDefinition) database.Tes
 		DependsOn:        test.DependsOn,
 		Retries:          test.Retries,
 		AllowFailure:     test.AllowFailure,
+		RequiresApproval: test.RequiresApproval,
+		CapturePatterns:  test.CapturePatterns,
+		LabelSelector:    test.LabelSelector,
+		ContainerImage:   database.NullString(test.ContainerImage),
+		Matrix:           test.Matrix.toDB(),
+		Parameters:       parametersToDB(test.Parameters),
 		UpdatedAt:        time.Now().UTC(),
 	}
+
+	// Architectures were validated with the manifest.
+	def.Architectures, _ = placement.NormalizeArchitectures(test.Architectures)
+
+	if test.Cache != nil {
+		def.CacheKey = database.NullString(test.Cache.Key)
+		def.CachePaths = test.Cache.Paths
+	}
+
+	if budget := test.ArtifactBudget; budget != nil {
+		if budget.MaxBytes > 0 {
+			maxBytes := budget.MaxBytes
+			def.ArtifactMaxBytes = &maxBytes
+		}
+		if budget.MaxFiles > 0 {
+			maxFiles := budget.MaxFiles
+			def.ArtifactMaxFiles = &maxFiles
+		}
+	}
+
+	return def
+}
+
+// toDB converts the matrix to its stored form; a matrix without axes
+// converts to nil.
+func (m *MatrixConfig) toDB() *database.TestMatrix {
+	if m == nil || len(m.Env) == 0 && len(m.Labels) == 0 {
+		return nil
+	}
+	return &database.TestMatrix{Env: m.Env, Labels: m.Labels}
+}
+
+// parametersToDB converts parameter declarations to their stored form.
+func parametersToDB(params []ParameterConfig) database.TestParameters {
+	if len(params) == 0 {
+		return nil
+	}
+	result := make(database.TestParameters, len(params))
+	for i, param := range params {
+		result[i] = database.TestParameter{
+			Name:        param.Name,
+			Description: param.Description,
+			Type:        database.TestParameterType(param.Type),
+			Required:    param.Required,
+			Default:     param.Default,
+			Choices:     param.Choices,
+		}
+	}
+	return result
 }
@@ -0,0 +1,177 @@
+package result
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// ArchiveStore stores exports of test results past their retention.
+type ArchiveStore interface {
+	// UploadResultArchive uploads a gzipped export and returns its storage
+	// path.
+	UploadResultArchive(ctx context.Context, name string, reader io.Reader) (string, error)
+}
+
+// ArchiverConfig configures the Archiver.
+type ArchiverConfig struct {
+	// Interval is how often partitions are maintained.
+	Interval time.Duration
+
+	// Retention is how long results are kept. Months that ended longer ago
+	// are archived and dropped. 0 keeps results forever.
+	Retention time.Duration
+
+	// PartitionsAhead is how many months ahead of the current one
+	// partitions are created for.
+	PartitionsAhead int
+
+	// Export uploads the results of a month to the ArchiveStore before it is
+	// dropped. Without it, months past their retention are dropped without
+	// export.
+	Export bool
+}
+
+// Archiver maintains the monthly partitions of test results: it creates the
+// partitions of the coming months, and archives and drops the months past
+// the retention.
+type Archiver struct {
+	repo   database.ResultPartitionRepository
+	store  ArchiveStore
+	config ArchiverConfig
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// NewArchiver creates a new Archiver. The store may be nil if results are
+// not exported.
+func NewArchiver(repo database.ResultPartitionRepository, store ArchiveStore, cfg ArchiverConfig, logger *slog.Logger) *Archiver {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 6 * time.Hour
+	}
+	if cfg.PartitionsAhead < 0 {
+		cfg.PartitionsAhead = 0
+	}
+	return &Archiver{
+		repo:   repo,
+		store:  store,
+		config: cfg,
+		logger: logger.With("component", "result_archiver"),
+		now:    time.Now,
+	}
+}
+
+// Start maintains the partitions now and then at every interval until the
+// context is cancelled.
+func (a *Archiver) Start(ctx context.Context) {
+	a.logger.Info("starting result partition maintenance",
+		"interval", a.config.Interval,
+		"retention", a.config.Retention,
+		"export", a.config.Export,
+	)
+
+	go func() {
+		ticker := time.NewTicker(a.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			a.maintain(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// maintain creates the partitions of the current and coming months, then
+// archives the months past the retention.
+func (a *Archiver) maintain(ctx context.Context) {
+	now := a.now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i <= a.config.PartitionsAhead; i++ {
+		if err := a.repo.EnsurePartition(ctx, month.AddDate(0, i, 0)); err != nil {
+			a.logger.Error("failed to create result partition", "error", err)
+			return
+		}
+	}
+
+	if a.config.Retention <= 0 {
+		return
+	}
+
+	partitions, err := a.repo.ListPartitions(ctx)
+	if err != nil {
+		a.logger.Error("failed to list result partitions", "error", err)
+		return
+	}
+	cutoff := now.Add(-a.config.Retention)
+	for _, partition := range partitions {
+		// A month is expired once its last result is older than the cutoff
+		if partition.Month.AddDate(0, 1, 0).After(cutoff) {
+			break
+		}
+		if err := a.archive(ctx, partition); err != nil {
+			a.logger.Error("failed to archive result partition", "partition", partition.Name, "error", err)
+			return
+		}
+	}
+}
+
+// archive exports a partition, if enabled, and drops it.
+func (a *Archiver) archive(ctx context.Context, partition database.ResultPartition) error {
+	var objectPath *string
+	if a.config.Export {
+		path, err := a.export(ctx, partition)
+		if err != nil {
+			return err
+		}
+		objectPath = &path
+	}
+
+	count, err := a.repo.DropPartition(ctx, partition, objectPath)
+	if err != nil {
+		return err
+	}
+	if objectPath != nil {
+		a.logger.Info("archived expired test results", "month", partition.Month.Format("2006-01"), "count", count, "path", *objectPath)
+	} else {
+		a.logger.Info("dropped expired test results", "month", partition.Month.Format("2006-01"), "count", count)
+	}
+	return nil
+}
+
+// export uploads the results of a partition as gzipped CSV, streaming them
+// from the database to the store.
+func (a *Archiver) export(ctx context.Context, partition database.ResultPartition) (string, error) {
+	if a.store == nil {
+		return "", fmt.Errorf("no archive store configured")
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		gz := gzip.NewWriter(pw)
+		_, err := a.repo.ExportPartition(ctx, partition, gz)
+		if err == nil {
+			err = gz.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	path, err := a.store.UploadResultArchive(ctx, partition.Name+".csv.gz", pr)
+	// Unblock the export if the upload stopped reading
+	pr.CloseWithError(err)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload results of %s: %w", partition.Name, err)
+	}
+	return path, nil
+}
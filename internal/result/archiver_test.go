@@ -0,0 +1,137 @@
+package result
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// fakePartitionRepo keeps partitions in memory; each holds one CSV line.
+type fakePartitionRepo struct {
+	partitions []database.ResultPartition
+	archived   map[string]*string
+}
+
+func (r *fakePartitionRepo) EnsurePartition(ctx context.Context, t time.Time) error {
+	month := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for _, p := range r.partitions {
+		if p.Month.Equal(month) {
+			return nil
+		}
+	}
+	r.partitions = append(r.partitions, database.ResultPartition{Name: "test_results_" + month.Format("2006_01"), Month: month})
+	return nil
+}
+
+func (r *fakePartitionRepo) ListPartitions(ctx context.Context) ([]database.ResultPartition, error) {
+	return append([]database.ResultPartition(nil), r.partitions...), nil
+}
+
+func (r *fakePartitionRepo) ExportPartition(ctx context.Context, partition database.ResultPartition, w io.Writer) (int64, error) {
+	_, err := io.WriteString(w, "id,test_name\n1,"+partition.Name+"\n")
+	return 1, err
+}
+
+func (r *fakePartitionRepo) DropPartition(ctx context.Context, partition database.ResultPartition, objectPath *string) (int64, error) {
+	for i, p := range r.partitions {
+		if p.Name == partition.Name {
+			r.partitions = append(r.partitions[:i], r.partitions[i+1:]...)
+			r.archived[partition.Name] = objectPath
+			return 1, nil
+		}
+	}
+	return 0, errors.New("no such partition")
+}
+
+type fakeArchiveStore struct {
+	objects map[string][]byte
+	err     error
+}
+
+func (s *fakeArchiveStore) UploadResultArchive(ctx context.Context, name string, reader io.Reader) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	s.objects[name] = data
+	return "result-archives/" + name, nil
+}
+
+func newTestArchiver(t *testing.T, cfg ArchiverConfig, store ArchiveStore) (*Archiver, *fakePartitionRepo) {
+	repo := &fakePartitionRepo{archived: map[string]*string{}}
+	for _, month := range []time.Month{time.January, time.February, time.March} {
+		require.NoError(t, repo.EnsurePartition(context.Background(), time.Date(2026, month, 1, 0, 0, 0, 0, time.UTC)))
+	}
+	a := NewArchiver(repo, store, cfg, nil)
+	a.now = func() time.Time { return time.Date(2026, time.April, 20, 12, 0, 0, 0, time.UTC) }
+	return a, repo
+}
+
+func partitionNames(partitions []database.ResultPartition) []string {
+	names := make([]string, len(partitions))
+	for i, p := range partitions {
+		names[i] = p.Name
+	}
+	return names
+}
+
+func TestArchiver(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates partitions ahead", func(t *testing.T) {
+		a, repo := newTestArchiver(t, ArchiverConfig{PartitionsAhead: 2}, nil)
+		a.maintain(ctx)
+		assert.Equal(t, []string{
+			"test_results_2026_01", "test_results_2026_02", "test_results_2026_03",
+			"test_results_2026_04", "test_results_2026_05", "test_results_2026_06",
+		}, partitionNames(repo.partitions))
+		assert.Empty(t, repo.archived, "results are kept without retention")
+	})
+
+	t.Run("archives months past the retention", func(t *testing.T) {
+		store := &fakeArchiveStore{objects: map[string][]byte{}}
+		// The cutoff is March 21; only January and February ended before it
+		a, repo := newTestArchiver(t, ArchiverConfig{Retention: 30 * 24 * time.Hour, Export: true}, store)
+		a.maintain(ctx)
+
+		assert.Equal(t, []string{"test_results_2026_03", "test_results_2026_04"}, partitionNames(repo.partitions))
+		require.Len(t, repo.archived, 2)
+		require.NotNil(t, repo.archived["test_results_2026_01"])
+		assert.Equal(t, "result-archives/test_results_2026_01.csv.gz", *repo.archived["test_results_2026_01"])
+
+		gz, err := gzip.NewReader(bytes.NewReader(store.objects["test_results_2026_02.csv.gz"]))
+		require.NoError(t, err)
+		csv, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		assert.Equal(t, "id,test_name\n1,test_results_2026_02\n", string(csv))
+	})
+
+	t.Run("drops without export", func(t *testing.T) {
+		a, repo := newTestArchiver(t, ArchiverConfig{Retention: 30 * 24 * time.Hour}, nil)
+		a.maintain(ctx)
+
+		require.Len(t, repo.archived, 2)
+		assert.Nil(t, repo.archived["test_results_2026_01"])
+	})
+
+	t.Run("keeps months whose upload failed", func(t *testing.T) {
+		store := &fakeArchiveStore{err: errors.New("bucket unavailable")}
+		a, repo := newTestArchiver(t, ArchiverConfig{Retention: 30 * 24 * time.Hour, Export: true}, store)
+		a.maintain(ctx)
+
+		assert.Empty(t, repo.archived)
+		assert.Len(t, repo.partitions, 4)
+	})
+}
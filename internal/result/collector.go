@@ -56,6 +56,9 @@ type ArtifactInfo struct {
 	ContentType string
 	SizeBytes   int64
 	Checksum    string
+	// BlobDigest is the digest of the shared blob holding the content, if
+	// it was stored deduplicated; Path is the blob's path then.
+	BlobDigest string
 }
 
 // ArtifactStorage defines the interface for artifact storage operations.
@@ -77,6 +80,10 @@ type Collector struct {
 	artifactRepo database.ArtifactRepository
 	storage      ArtifactStorage
 	logger       *slog.Logger
+
+	// maxResults and maxArtifacts cap what a single run may store; 0 disables.
+	maxResults   int
+	maxArtifacts int
 }
 
 // NewCollector creates a new Collector instance.
@@ -100,6 +107,13 @@ func NewCollector(
 	}
 }
 
+// SetIngestionLimits caps the results and artifacts stored per run. Records
+// beyond a cap are dropped and counted on the run. 0 disables a cap.
+func (c *Collector) SetIngestionLimits(maxResults, maxArtifacts int) {
+	c.maxResults = maxResults
+	c.maxArtifacts = maxArtifacts
+}
+
 // ProcessResults stores test results for a run.
 func (c *Collector) ProcessResults(ctx context.Context, runID uuid.UUID, results []TestResult) error {
 	if len(results) == 0 {
@@ -111,6 +125,25 @@ func (c *Collector) ProcessResults(ctx context.Context, runID uuid.UUID, results
 		"count", len(results),
 	)
 
+	if c.maxResults > 0 {
+		accepted, dropped, err := c.runRepo.ReserveResults(ctx, runID, len(results), c.maxResults)
+		if err != nil {
+			return fmt.Errorf("failed to check result cap: %w", err)
+		}
+		if accepted < len(results) {
+			c.logger.Warn("run reached its result cap, dropping results",
+				"run_id", runID,
+				"limit", c.maxResults,
+				"dropped", len(results)-accepted,
+				"total_dropped", dropped,
+			)
+			results = results[:accepted]
+		}
+		if len(results) == 0 {
+			return nil
+		}
+	}
+
 	dbResults := make([]database.TestResult, 0, len(results))
 	for _, r := range results {
 		dbResult := database.TestResult{
@@ -158,6 +191,21 @@ func (c *Collector) ProcessArtifact(ctx context.Context, runID uuid.UUID, artifa
 		"size", artifact.SizeBytes,
 	)
 
+	if c.maxArtifacts > 0 {
+		accepted, _, err := c.runRepo.ReserveArtifacts(ctx, runID, 1, c.maxArtifacts)
+		if err != nil {
+			return fmt.Errorf("failed to check artifact cap: %w", err)
+		}
+		if accepted == 0 {
+			c.logger.Warn("run reached its artifact cap, dropping artifact",
+				"run_id", runID,
+				"limit", c.maxArtifacts,
+				"name", artifact.Name,
+			)
+			return nil
+		}
+	}
+
 	dbArtifact := &database.Artifact{
 		ID:          uuid.New(),
 		RunID:       runID,
@@ -165,6 +213,8 @@ func (c *Collector) ProcessArtifact(ctx context.Context, runID uuid.UUID, artifa
 		Path:        artifact.Path,
 		ContentType: database.NullString(artifact.ContentType),
 		SizeBytes:   database.NullInt64(artifact.SizeBytes),
+		Checksum:    database.NullString(artifact.Checksum),
+		BlobDigest:  database.NullString(artifact.BlobDigest),
 		CreatedAt:   time.Now().UTC(),
 	}
 
@@ -0,0 +1,96 @@
+package result
+
+import (
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// DiffOptions bounds the size of output diffs.
+type DiffOptions struct {
+	// MaxInputBytes is the maximum size of each compared output. Longer
+	// outputs are cut at a line boundary before diffing.
+	MaxInputBytes int
+	// MaxDiffBytes is the maximum size of the returned diff.
+	MaxDiffBytes int
+	// Context is the number of unchanged lines around each change.
+	Context int
+}
+
+// DefaultDiffOptions returns the default diff bounds.
+func DefaultDiffOptions() DiffOptions {
+	return DiffOptions{
+		MaxInputBytes: 1 << 20,
+		MaxDiffBytes:  64 << 10,
+		Context:       3,
+	}
+}
+
+// OutputDiff is a unified diff of one captured output of a test between a
+// passing and a failing run.
+type OutputDiff struct {
+	// Name identifies the output, e.g. stdout or an artifact name.
+	Name string
+	// Diff is the unified diff from the passing to the failing output.
+	Diff string
+	// Identical reports whether the outputs are the same.
+	Identical bool
+	// Truncated reports whether an input or the diff was cut to fit the bounds.
+	Truncated bool
+}
+
+// DiffOutput diffs the output of a passing run against the output of a
+// failing run.
+func DiffOutput(name, passing, failing string, opts DiffOptions) OutputDiff {
+	defaults := DefaultDiffOptions()
+	if opts.MaxInputBytes <= 0 {
+		opts.MaxInputBytes = defaults.MaxInputBytes
+	}
+	if opts.MaxDiffBytes <= 0 {
+		opts.MaxDiffBytes = defaults.MaxDiffBytes
+	}
+	if opts.Context <= 0 {
+		opts.Context = defaults.Context
+	}
+
+	result := OutputDiff{Name: name}
+
+	passing, cutPassing := truncateLines(passing, opts.MaxInputBytes)
+	failing, cutFailing := truncateLines(failing, opts.MaxInputBytes)
+	result.Truncated = cutPassing || cutFailing
+
+	if passing == failing {
+		result.Identical = true
+		return result
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(passing),
+		B:        difflib.SplitLines(failing),
+		FromFile: "passing/" + name,
+		ToFile:   "failing/" + name,
+		Context:  opts.Context,
+	})
+	if err != nil {
+		// Writing to a string builder does not fail
+		return result
+	}
+
+	diff, cutDiff := truncateLines(diff, opts.MaxDiffBytes)
+	result.Diff = diff
+	result.Truncated = result.Truncated || cutDiff
+	return result
+}
+
+// truncateLines cuts s to at most max bytes, ending at a line boundary when
+// possible, and reports whether it was cut.
+func truncateLines(s string, max int) (string, bool) {
+	if len(s) <= max {
+		return s, false
+	}
+	s = s[:max]
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		s = s[:i+1]
+	}
+	return s, true
+}
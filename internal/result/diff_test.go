@@ -0,0 +1,40 @@
+package result
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffOutput(t *testing.T) {
+	t.Run("identical", func(t *testing.T) {
+		diff := DiffOutput("stdout", "ok\n", "ok\n", DefaultDiffOptions())
+		assert.True(t, diff.Identical)
+		assert.Empty(t, diff.Diff)
+		assert.False(t, diff.Truncated)
+	})
+
+	t.Run("unified diff", func(t *testing.T) {
+		passing := "connecting to db\nDB_HOST=db.internal\nready\n"
+		failing := "connecting to db\nDB_HOST=localhost\nconnection refused\n"
+
+		diff := DiffOutput("stdout", passing, failing, DefaultDiffOptions())
+		assert.False(t, diff.Identical)
+		assert.Contains(t, diff.Diff, "--- passing/stdout")
+		assert.Contains(t, diff.Diff, "+++ failing/stdout")
+		assert.Contains(t, diff.Diff, "-DB_HOST=db.internal\n")
+		assert.Contains(t, diff.Diff, "+DB_HOST=localhost\n")
+		assert.Contains(t, diff.Diff, " connecting to db\n")
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		passing := strings.Repeat("same line\n", 100)
+		failing := strings.Repeat("other line\n", 100)
+
+		diff := DiffOutput("stdout", passing, failing, DiffOptions{MaxInputBytes: 200, MaxDiffBytes: 120})
+		assert.True(t, diff.Truncated)
+		assert.LessOrEqual(t, len(diff.Diff), 120)
+		assert.True(t, strings.HasSuffix(diff.Diff, "\n"), "diff should be cut at a line boundary")
+	})
+}
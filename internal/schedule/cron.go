@@ -0,0 +1,293 @@
+// Package schedule evaluates the cron expressions of scheduled runs in their
+// time zones and triggers the runs when they are due.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchDays bounds the search for the next fire time. Eight years cover
+// expressions that only match on February 29.
+const maxSearchDays = 8 * 366
+
+// Cron is a parsed five-field cron expression (minute hour day-of-month
+// month day-of-week) evaluated in a time zone.
+//
+// Fire times are wall-clock times of the time zone. A time skipped when
+// clocks go forward fires at the end of the gap, e.g. 02:30 fires at 03:00
+// on the day daylight saving time starts. A time repeated when clocks go
+// back fires once, at its first occurrence.
+type Cron struct {
+	expr    string
+	loc     *time.Location
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool
+	dowStar bool
+}
+
+// field describes the range and names of a cron field.
+type field struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Day of week 7 is Sunday, like 0.
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// macros are the supported cron shorthands.
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// LoadLocation returns the time zone of a schedule. An empty name is UTC.
+// "Local" is rejected because it depends on the server's configuration.
+func LoadLocation(name string) (*time.Location, error) {
+	switch name {
+	case "", "UTC":
+		return time.UTC, nil
+	case "Local":
+		return nil, fmt.Errorf("time zone must be an IANA name such as Europe/Stockholm, not Local")
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// Parse parses a cron expression evaluated in the named time zone.
+// Expressions that can never fire, such as "0 0 30 2 *", are rejected.
+func Parse(expr, timezone string) (*Cron, error) {
+	loc, err := LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := strings.TrimSpace(expr)
+	if macro, ok := macros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields (minute hour day month weekday), got %d", expr, len(fields))
+	}
+
+	c := &Cron{expr: expr, loc: loc}
+	for i, target := range []struct {
+		field field
+		bits  *uint64
+	}{
+		{minuteField, &c.minute},
+		{hourField, &c.hour},
+		{domField, &c.dom},
+		{monthField, &c.month},
+		{dowField, &c.dow},
+	} {
+		bits, err := parseField(fields[i], target.field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		*target.bits = bits
+	}
+	c.domStar = strings.HasPrefix(fields[2], "*")
+	c.dowStar = strings.HasPrefix(fields[4], "*")
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+
+	if c.Next(time.Date(2000, 1, 1, 0, 0, 0, 0, loc)).IsZero() {
+		return nil, fmt.Errorf("cron expression %q never fires", expr)
+	}
+	return c, nil
+}
+
+// parseField parses a comma-separated list of values, ranges and steps into
+// a bit set.
+func parseField(spec string, f field) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(spec, ",") {
+		rangeSpec, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangeSpec = part[:i]
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %s field %q", f.name, part)
+			}
+			step = n
+		}
+
+		var lo, hi int
+		switch {
+		case rangeSpec == "*":
+			lo, hi = f.min, f.max
+		case strings.Contains(rangeSpec, "-"):
+			bounds := strings.SplitN(rangeSpec, "-", 2)
+			var err error
+			if lo, err = parseValue(bounds[0], f); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(bounds[1], f); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range in %s field %q", f.name, part)
+			}
+		default:
+			v, err := parseValue(rangeSpec, f)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			if step > 1 {
+				// "5/15" means every 15 starting at 5
+				hi = f.max
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, f field) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", f.name, s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s %d out of range %d-%d", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String returns the expression the schedule was parsed from.
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// Location returns the time zone the expression is evaluated in.
+func (c *Cron) Location() *time.Location {
+	return c.loc
+}
+
+// Next returns the first fire time after t, or the zero time if the
+// expression does not fire in the next eight years.
+func (c *Cron) Next(t time.Time) time.Time {
+	local := t.In(c.loc)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < maxSearchDays; i++ {
+		day := start.AddDate(0, 0, i)
+		if !c.matchDay(day) {
+			continue
+		}
+		for h := 0; h < 24; h++ {
+			if c.hour&(1<<uint(h)) == 0 {
+				continue
+			}
+			for m := 0; m < 60; m++ {
+				if c.minute&(1<<uint(m)) == 0 {
+					continue
+				}
+				if at := c.resolve(day, h, m); at.After(t) {
+					return at
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// Upcoming returns the next n fire times after t.
+func (c *Cron) Upcoming(t time.Time, n int) []time.Time {
+	times := make([]time.Time, 0, n)
+	for len(times) < n {
+		t = c.Next(t)
+		if t.IsZero() {
+			break
+		}
+		times = append(times, t)
+	}
+	return times
+}
+
+// matchDay reports whether the expression fires on a date, carried as
+// midnight UTC. Like cron, a restricted day of month and day of week match
+// if either matches.
+func (c *Cron) matchDay(day time.Time) bool {
+	if c.month&(1<<uint(day.Month())) == 0 {
+		return false
+	}
+	domMatch := c.dom&(1<<uint(day.Day())) != 0
+	dowMatch := c.dow&(1<<uint(day.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// resolve returns the instant a wall-clock time of a date fires at in the
+// schedule's time zone.
+func (c *Cron) resolve(day time.Time, hour, minute int) time.Time {
+	wall := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, time.UTC)
+	at := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, c.loc)
+
+	if got := wallClock(at); !got.Equal(wall) {
+		// The time does not exist: fire when the gap ends
+		start, end := at.ZoneBounds()
+		if got.After(wall) {
+			return start
+		}
+		return end
+	}
+
+	// Fire repeated times at their first occurrence
+	start, _ := at.ZoneBounds()
+	if !start.IsZero() {
+		_, offset := at.Zone()
+		_, prevOffset := start.Add(-time.Second).Zone()
+		if prevOffset > offset {
+			if earlier := at.Add(-time.Duration(prevOffset-offset) * time.Second); earlier.Before(start) {
+				return earlier
+			}
+		}
+	}
+	return at
+}
+
+// wallClock returns the wall-clock time of t in its location, carried as UTC.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
@@ -0,0 +1,154 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParse(t *testing.T, expr, timezone string) *Cron {
+	t.Helper()
+	c, err := Parse(expr, timezone)
+	require.NoError(t, err)
+	return c
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		timezone string
+		errMsg   string
+	}{
+		{"too few fields", "0 2 * *", "UTC", "must have 5 fields"},
+		{"minute out of range", "60 2 * * *", "UTC", "minute 60 out of range"},
+		{"hour out of range", "0 24 * * *", "UTC", "hour 24 out of range"},
+		{"bad step", "*/0 * * * *", "UTC", "invalid step"},
+		{"reversed range", "0 5-2 * * *", "UTC", "invalid range"},
+		{"unknown name", "0 0 * foo *", "UTC", "invalid month"},
+		{"never fires", "0 0 30 2 *", "UTC", "never fires"},
+		{"unknown time zone", "0 2 * * *", "Mars/Olympus_Mons", "unknown time zone"},
+		{"local time zone", "0 2 * * *", "Local", "not Local"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expr, tt.timezone)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	from := time.Date(2026, 6, 10, 12, 0, 0, 0, time.UTC) // a Wednesday
+
+	tests := []struct {
+		name     string
+		expr     string
+		timezone string
+		want     time.Time
+	}{
+		{"every 15 minutes", "*/15 * * * *", "", time.Date(2026, 6, 10, 12, 15, 0, 0, time.UTC)},
+		{"daily macro", "@daily", "UTC", time.Date(2026, 6, 11, 0, 0, 0, 0, time.UTC)},
+		{"weekday names", "0 9 * * mon-fri", "UTC", time.Date(2026, 6, 11, 9, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 9 * * 7", "UTC", time.Date(2026, 6, 14, 9, 0, 0, 0, time.UTC)},
+		{"month names", "0 0 1 jan,jul *", "UTC", time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"step from value", "5/20 12 * * *", "UTC", time.Date(2026, 6, 10, 12, 5, 0, 0, time.UTC)},
+		{"day of month or weekday", "0 0 13 * fri", "UTC", time.Date(2026, 6, 12, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", "UTC", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"nightly in time zone", "30 2 * * *", "Europe/Stockholm", time.Date(2026, 6, 11, 0, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := mustParse(t, tt.expr, tt.timezone)
+			assert.Equal(t, tt.want, c.Next(from).UTC())
+		})
+	}
+}
+
+func TestNextKeepsWallClockAcrossDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	c := mustParse(t, "0 9 * * *", "America/New_York")
+
+	// 09:00 in New York is 14:00 UTC in winter and 13:00 UTC in summer
+	runs := c.Upcoming(time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC), 4)
+	require.Len(t, runs, 4)
+	for _, run := range runs {
+		assert.Equal(t, 9, run.In(ny).Hour())
+	}
+	assert.Equal(t, 14, runs[0].UTC().Hour())
+	assert.Equal(t, 13, runs[3].UTC().Hour())
+}
+
+func TestNextSpringForward(t *testing.T) {
+	c := mustParse(t, "30 2 * * *", "America/New_York")
+
+	// 02:30 does not exist on 2026-03-08 and fires at 03:00 EDT
+	runs := c.Upcoming(time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC), 2)
+	require.Len(t, runs, 2)
+	assert.Equal(t, time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC), runs[0].UTC())
+	assert.Equal(t, time.Date(2026, 3, 9, 6, 30, 0, 0, time.UTC), runs[1].UTC())
+}
+
+func TestNextFallBack(t *testing.T) {
+	c := mustParse(t, "30 1 * * *", "America/New_York")
+
+	// 01:30 occurs twice on 2026-11-01 and fires once, at 01:30 EDT
+	runs := c.Upcoming(time.Date(2026, 10, 31, 12, 0, 0, 0, time.UTC), 2)
+	require.Len(t, runs, 2)
+	assert.Equal(t, time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC), runs[0].UTC())
+	assert.Equal(t, time.Date(2026, 11, 2, 6, 30, 0, 0, time.UTC), runs[1].UTC())
+
+	// Every 15 minutes does not repeat the hour that occurs twice
+	c = mustParse(t, "*/15 1 * * *", "America/New_York")
+	runs = c.Upcoming(time.Date(2026, 11, 1, 4, 0, 0, 0, time.UTC), 5)
+	require.Len(t, runs, 5)
+	assert.Equal(t, time.Date(2026, 11, 1, 5, 45, 0, 0, time.UTC), runs[3].UTC())
+	assert.Equal(t, time.Date(2026, 11, 2, 6, 0, 0, 0, time.UTC), runs[4].UTC())
+}
+
+func TestNextHalfHourDST(t *testing.T) {
+	// Lord Howe Island moves its clocks by 30 minutes
+	c := mustParse(t, "15 2 * * *", "Australia/Lord_Howe")
+
+	runs := c.Upcoming(time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC), 1)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "2026-10-04T02:30:00+11:00", runs[0].In(c.Location()).Format(time.RFC3339))
+}
+
+func TestDSTNotes(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := from.AddDate(1, 0, 0)
+
+	t.Run("skipped time", func(t *testing.T) {
+		c := mustParse(t, "30 2 * * *", "America/New_York")
+		assert.Equal(t, []string{
+			"02:30 on 2026-03-08 does not exist in America/New_York (clocks go forward); it fires at 03:00 EDT",
+		}, c.DSTNotes(from, until))
+	})
+
+	t.Run("repeated time", func(t *testing.T) {
+		c := mustParse(t, "30 1 * * *", "America/New_York")
+		assert.Equal(t, []string{
+			"01:30 on 2026-11-01 occurs twice in America/New_York (clocks go back); it fires once, at 01:30 EDT",
+		}, c.DSTNotes(from, until))
+	})
+
+	t.Run("several fire times", func(t *testing.T) {
+		c := mustParse(t, "*/15 * * * *", "Europe/Stockholm")
+		assert.Equal(t, []string{
+			"4 fire times between 02:00 and 02:45 on 2026-03-29 do not exist in Europe/Stockholm (clocks go forward); they fire once at 03:00 CEST",
+			"4 fire times between 02:00 and 02:45 on 2026-10-25 occur twice in Europe/Stockholm (clocks go back); each fires once, in CEST",
+		}, c.DSTNotes(from, until))
+	})
+
+	t.Run("unaffected", func(t *testing.T) {
+		assert.Empty(t, mustParse(t, "0 9 * * *", "America/New_York").DSTNotes(from, until))
+		assert.Empty(t, mustParse(t, "30 2 * * *", "UTC").DSTNotes(from, until))
+	})
+}
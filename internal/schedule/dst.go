@@ -0,0 +1,78 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+)
+
+// DSTNotes describes the fire times between from and until that a daylight
+// saving time change of the schedule's time zone skips or repeats, one note
+// per change. Changes that affect no fire time are left out.
+func (c *Cron) DSTNotes(from, until time.Time) []string {
+	var notes []string
+	at := from.In(c.loc)
+	for {
+		_, end := at.ZoneBounds()
+		if end.IsZero() || end.After(until) {
+			return notes
+		}
+		if note := c.dstNote(end); note != "" {
+			notes = append(notes, note)
+		}
+		at = end.In(c.loc)
+	}
+}
+
+// dstNote describes the fire times affected by the zone change at instant
+// change, or returns "" if none is affected.
+func (c *Cron) dstNote(change time.Time) string {
+	before := change.Add(-time.Second).In(c.loc)
+	after := change.In(c.loc)
+	_, beforeOffset := before.Zone()
+	_, afterOffset := after.Zone()
+	if beforeOffset == afterOffset {
+		return ""
+	}
+
+	// Wall-clock times in [lo, hi) are skipped or repeated
+	utc := change.UTC()
+	lo := utc.Add(time.Duration(min(beforeOffset, afterOffset)) * time.Second)
+	hi := utc.Add(time.Duration(max(beforeOffset, afterOffset)) * time.Second)
+
+	var first, last time.Time
+	count := 0
+	for wall := lo; wall.Before(hi); wall = wall.Add(time.Minute) {
+		day := time.Date(wall.Year(), wall.Month(), wall.Day(), 0, 0, 0, 0, time.UTC)
+		if !c.matchDay(day) || c.hour&(1<<uint(wall.Hour())) == 0 || c.minute&(1<<uint(wall.Minute())) == 0 {
+			continue
+		}
+		if count == 0 {
+			first = wall
+		}
+		last = wall
+		count++
+	}
+	if count == 0 {
+		return ""
+	}
+
+	times := first.Format("15:04")
+	if count > 1 {
+		times = fmt.Sprintf("%d fire times between %s and %s", count, first.Format("15:04"), last.Format("15:04"))
+	}
+	date := first.Format("2006-01-02")
+
+	if afterOffset > beforeOffset {
+		fires := after.Format("15:04 MST")
+		if count == 1 {
+			return fmt.Sprintf("%s on %s does not exist in %s (clocks go forward); it fires at %s", times, date, c.loc, fires)
+		}
+		return fmt.Sprintf("%s on %s do not exist in %s (clocks go forward); they fire once at %s", times, date, c.loc, fires)
+	}
+
+	zone, _ := before.Zone()
+	if count == 1 {
+		return fmt.Sprintf("%s on %s occurs twice in %s (clocks go back); it fires once, at %s %s", times, date, c.loc, first.Format("15:04"), zone)
+	}
+	return fmt.Sprintf("%s on %s occur twice in %s (clocks go back); each fires once, in %s", times, date, c.loc, zone)
+}
@@ -0,0 +1,163 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// Window is a parsed maintenance window.
+type Window struct {
+	*database.MaintenanceWindow
+	cron *Cron
+}
+
+// ParseWindow validates a maintenance window and parses its recurrence. A
+// recurring window needs a positive duration; a one-off window needs a
+// start and an end.
+func ParseWindow(w *database.MaintenanceWindow) (*Window, error) {
+	if w.StartsAt != nil && w.EndsAt != nil && !w.EndsAt.After(*w.StartsAt) {
+		return nil, fmt.Errorf("ends_at must be after starts_at")
+	}
+	if w.ServiceID != nil && w.AgentPool != nil {
+		return nil, fmt.Errorf("a maintenance window applies to a service or an agent pool, not both")
+	}
+	switch w.Notifications {
+	case "", database.MaintenanceNotificationsSuppress, database.MaintenanceNotificationsTag:
+	default:
+		return nil, fmt.Errorf("notifications must be %q or %q, got %q",
+			database.MaintenanceNotificationsSuppress, database.MaintenanceNotificationsTag, w.Notifications)
+	}
+
+	if w.CronExpression == nil {
+		if w.StartsAt == nil || w.EndsAt == nil {
+			return nil, fmt.Errorf("a one-off maintenance window needs starts_at and ends_at")
+		}
+		return &Window{MaintenanceWindow: w}, nil
+	}
+
+	if w.DurationSeconds <= 0 {
+		return nil, fmt.Errorf("a recurring maintenance window needs a positive duration")
+	}
+	cron, err := Parse(*w.CronExpression, w.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	return &Window{MaintenanceWindow: w, cron: cron}, nil
+}
+
+// duration returns how long each occurrence of a recurring window lasts.
+func (w *Window) duration() time.Duration {
+	return time.Duration(w.DurationSeconds) * time.Second
+}
+
+// Active returns the start and end of the occurrence of the window that is
+// active at t. Occurrences of a recurring window start at its cron fire
+// times, but not before StartsAt, and are cut off at EndsAt.
+func (w *Window) Active(t time.Time) (time.Time, time.Time, bool) {
+	if w.StartsAt != nil && t.Before(*w.StartsAt) {
+		return time.Time{}, time.Time{}, false
+	}
+	if w.EndsAt != nil && !t.Before(*w.EndsAt) {
+		return time.Time{}, time.Time{}, false
+	}
+	if w.cron == nil {
+		return *w.StartsAt, *w.EndsAt, true
+	}
+
+	// The active occurrence is the first one to start after t - duration
+	after := t.Add(-w.duration())
+	if w.StartsAt != nil && w.StartsAt.Add(-time.Nanosecond).After(after) {
+		after = w.StartsAt.Add(-time.Nanosecond)
+	}
+	start := w.cron.Next(after)
+	if start.IsZero() || start.After(t) {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, w.end(start), true
+}
+
+// Next returns the start of the first occurrence of the window after t, or
+// the zero time if there is none.
+func (w *Window) Next(t time.Time) time.Time {
+	if w.cron == nil {
+		if w.StartsAt.After(t) {
+			return *w.StartsAt
+		}
+		return time.Time{}
+	}
+
+	if w.StartsAt != nil && w.StartsAt.After(t) {
+		t = w.StartsAt.Add(-time.Nanosecond)
+	}
+	start := w.cron.Next(t)
+	if start.IsZero() || (w.EndsAt != nil && !start.Before(*w.EndsAt)) {
+		return time.Time{}
+	}
+	return start
+}
+
+// end returns the end of the occurrence starting at start.
+func (w *Window) end(start time.Time) time.Time {
+	end := start.Add(w.duration())
+	if w.EndsAt != nil && w.EndsAt.Before(end) {
+		return *w.EndsAt
+	}
+	return end
+}
+
+// ActiveWindows are the maintenance windows active at a time.
+type ActiveWindows []*Window
+
+// ActiveMaintenance loads the enabled maintenance windows active at t.
+// Stored windows that do not parse are ignored.
+func ActiveMaintenance(ctx context.Context, repo database.MaintenanceWindowRepository, t time.Time) (ActiveWindows, error) {
+	windows, err := repo.ListEnabled(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance windows: %w", err)
+	}
+
+	var active ActiveWindows
+	for i := range windows {
+		window, err := ParseWindow(&windows[i])
+		if err != nil {
+			continue
+		}
+		if _, _, ok := window.Active(t); ok {
+			active = append(active, window)
+		}
+	}
+	return active, nil
+}
+
+// ForService returns an active window of a service, or of every service,
+// or nil if there is none.
+func (a ActiveWindows) ForService(serviceID uuid.UUID) *Window {
+	for _, w := range a {
+		if w.AgentPool != nil {
+			continue
+		}
+		if w.ServiceID == nil || *w.ServiceID == serviceID {
+			return w
+		}
+	}
+	return nil
+}
+
+// ForPool returns an active window of an agent pool, or nil if there is
+// none.
+func (a ActiveWindows) ForPool(pool string) *Window {
+	if pool == "" {
+		return nil
+	}
+	for _, w := range a {
+		if w.AgentPool != nil && *w.AgentPool == pool {
+			return w
+		}
+	}
+	return nil
+}
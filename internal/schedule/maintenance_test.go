@@ -0,0 +1,136 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+func TestParseWindowErrors(t *testing.T) {
+	cron := "0 2 * * *"
+	pool := "gpu"
+	serviceID := uuid.New()
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		window database.MaintenanceWindow
+		errMsg string
+	}{
+		{"one-off without end", database.MaintenanceWindow{StartsAt: &start}, "needs starts_at and ends_at"},
+		{"end before start", database.MaintenanceWindow{StartsAt: &end, EndsAt: &start}, "ends_at must be after starts_at"},
+		{"recurring without duration", database.MaintenanceWindow{CronExpression: &cron}, "positive duration"},
+		{"invalid cron", database.MaintenanceWindow{CronExpression: &pool, DurationSeconds: 60}, "must have 5 fields"},
+		{"service and pool", database.MaintenanceWindow{ServiceID: &serviceID, AgentPool: &pool, StartsAt: &start, EndsAt: &end}, "not both"},
+		{"unknown notifications", database.MaintenanceWindow{StartsAt: &start, EndsAt: &end, Notifications: "drop"}, "notifications must be"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseWindow(&tt.window)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestWindowActive(t *testing.T) {
+	t.Run("recurring", func(t *testing.T) {
+		cron := "0 2 * * sat"
+		w, err := ParseWindow(&database.MaintenanceWindow{
+			CronExpression:  &cron,
+			Timezone:        "Europe/Stockholm",
+			DurationSeconds: 2 * 60 * 60,
+		})
+		require.NoError(t, err)
+
+		// Saturday 2026-03-07 02:00 in Stockholm is 01:00 UTC
+		start := time.Date(2026, 3, 7, 1, 0, 0, 0, time.UTC)
+		for _, tt := range []struct {
+			at     time.Time
+			active bool
+		}{
+			{start.Add(-time.Minute), false},
+			{start, true},
+			{start.Add(119 * time.Minute), true},
+			{start.Add(2 * time.Hour), false},
+		} {
+			got, end, ok := w.Active(tt.at)
+			assert.Equal(t, tt.active, ok, "at %s", tt.at)
+			if ok {
+				assert.Equal(t, start, got.UTC())
+				assert.Equal(t, start.Add(2*time.Hour), end.UTC())
+			}
+		}
+
+		assert.Equal(t, start.AddDate(0, 0, 7), w.Next(start).UTC())
+	})
+
+	t.Run("recurring within bounds", func(t *testing.T) {
+		cron := "0 * * * *"
+		startsAt := time.Date(2026, 3, 7, 10, 30, 0, 0, time.UTC)
+		endsAt := time.Date(2026, 3, 7, 12, 15, 0, 0, time.UTC)
+		w, err := ParseWindow(&database.MaintenanceWindow{
+			CronExpression:  &cron,
+			DurationSeconds: 30 * 60,
+			StartsAt:        &startsAt,
+			EndsAt:          &endsAt,
+		})
+		require.NoError(t, err)
+
+		// The 10:00 occurrence started before the window's start
+		_, _, ok := w.Active(startsAt.Add(time.Minute))
+		assert.False(t, ok)
+
+		// The 12:00 occurrence is cut off at the window's end
+		start, end, ok := w.Active(endsAt.Add(-time.Minute))
+		require.True(t, ok)
+		assert.Equal(t, time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC), start.UTC())
+		assert.Equal(t, endsAt, end.UTC())
+
+		assert.Equal(t, time.Date(2026, 3, 7, 11, 0, 0, 0, time.UTC), w.Next(time.Date(2026, 3, 7, 9, 0, 0, 0, time.UTC)).UTC())
+		assert.True(t, w.Next(endsAt.Add(-time.Minute)).IsZero())
+	})
+
+	t.Run("one-off", func(t *testing.T) {
+		startsAt := time.Date(2026, 3, 7, 10, 0, 0, 0, time.UTC)
+		endsAt := startsAt.Add(time.Hour)
+		w, err := ParseWindow(&database.MaintenanceWindow{StartsAt: &startsAt, EndsAt: &endsAt})
+		require.NoError(t, err)
+
+		_, _, ok := w.Active(startsAt.Add(-time.Second))
+		assert.False(t, ok)
+		_, _, ok = w.Active(startsAt)
+		assert.True(t, ok)
+		_, _, ok = w.Active(endsAt)
+		assert.False(t, ok)
+
+		assert.Equal(t, startsAt, w.Next(startsAt.Add(-time.Hour)))
+		assert.True(t, w.Next(startsAt).IsZero())
+	})
+}
+
+func TestActiveWindowsScope(t *testing.T) {
+	serviceID := uuid.New()
+	pool := "gpu"
+
+	global := &Window{MaintenanceWindow: &database.MaintenanceWindow{Name: "global"}}
+	service := &Window{MaintenanceWindow: &database.MaintenanceWindow{Name: "service", ServiceID: &serviceID}}
+	poolWindow := &Window{MaintenanceWindow: &database.MaintenanceWindow{Name: "pool", AgentPool: &pool}}
+
+	active := ActiveWindows{service, poolWindow}
+	assert.Equal(t, service, active.ForService(serviceID))
+	assert.Nil(t, active.ForService(uuid.New()))
+	assert.Equal(t, poolWindow, active.ForPool("gpu"))
+	assert.Nil(t, active.ForPool(""))
+
+	active = ActiveWindows{poolWindow, global}
+	assert.Equal(t, global, active.ForService(uuid.New()))
+	assert.Nil(t, active.ForPool("arm"))
+}
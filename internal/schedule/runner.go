@@ -0,0 +1,121 @@
+package schedule
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// RunTrigger starts the test run of a due schedule.
+type RunTrigger interface {
+	TriggerScheduledRun(ctx context.Context, schedule *database.ScheduledRun) error
+}
+
+// Runner triggers scheduled runs when they are due and advances each
+// schedule to its next fire time in the schedule's time zone.
+type Runner struct {
+	repo          database.ScheduleRepository
+	trigger       RunTrigger
+	logger        *slog.Logger
+	checkInterval time.Duration
+	now           func() time.Time
+}
+
+// NewRunner creates a new Runner that checks for due schedules every
+// checkInterval, or every 30 seconds if it is not positive.
+func NewRunner(
+	repo database.ScheduleRepository,
+	trigger RunTrigger,
+	checkInterval time.Duration,
+	logger *slog.Logger,
+) *Runner {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if checkInterval <= 0 {
+		checkInterval = 30 * time.Second
+	}
+
+	return &Runner{
+		repo:          repo,
+		trigger:       trigger,
+		logger:        logger.With("component", "schedule_runner"),
+		checkInterval: checkInterval,
+		now:           time.Now,
+	}
+}
+
+// Start begins the schedule loop until the context is canceled.
+func (r *Runner) Start(ctx context.Context) {
+	r.logger.Info("starting schedule runner", "check_interval", r.checkInterval)
+
+	go func() {
+		ticker := time.NewTicker(r.checkInterval)
+		defer ticker.Stop()
+
+		for {
+			r.run(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (r *Runner) run(ctx context.Context) {
+	schedules, err := r.repo.ListDue(ctx)
+	if err != nil {
+		r.logger.Error("failed to list due schedules", "error", err)
+		return
+	}
+
+	for i := range schedules {
+		if ctx.Err() != nil {
+			return
+		}
+		r.fire(ctx, &schedules[i])
+	}
+}
+
+func (r *Runner) fire(ctx context.Context, schedule *database.ScheduledRun) {
+	cron, err := Parse(schedule.CronExpression, schedule.Timezone)
+	if err != nil {
+		r.logger.Error("invalid schedule",
+			"schedule_id", schedule.ID,
+			"cron", schedule.CronExpression,
+			"timezone", schedule.Timezone,
+			"error", err,
+		)
+		return
+	}
+
+	if err := r.trigger.TriggerScheduledRun(ctx, schedule); err != nil {
+		r.logger.Warn("scheduled run failed to start",
+			"schedule_id", schedule.ID,
+			"service_id", schedule.ServiceID,
+			"error", err,
+		)
+	}
+
+	// Advance even if the run failed to start so a broken schedule does not
+	// retrigger on every check. Missed fire times are not caught up.
+	next := cron.Next(r.now())
+	if err := r.repo.UpdateAfterRun(ctx, schedule.ID, next.UTC()); err != nil {
+		r.logger.Error("failed to update schedule after run",
+			"schedule_id", schedule.ID,
+			"error", err,
+		)
+		return
+	}
+
+	r.logger.Info("scheduled run triggered",
+		"schedule_id", schedule.ID,
+		"service_id", schedule.ServiceID,
+		"next_run_at", next.UTC(),
+		"next_run_local", next.Format(time.RFC3339),
+	)
+}
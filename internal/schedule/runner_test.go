@@ -0,0 +1,69 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+type fakeScheduleRepo struct {
+	database.ScheduleRepository
+
+	due  []database.ScheduledRun
+	next map[uuid.UUID]time.Time
+}
+
+func (r *fakeScheduleRepo) ListDue(ctx context.Context) ([]database.ScheduledRun, error) {
+	return r.due, nil
+}
+
+func (r *fakeScheduleRepo) UpdateAfterRun(ctx context.Context, id uuid.UUID, nextRunAt time.Time) error {
+	r.next[id] = nextRunAt
+	return nil
+}
+
+type fakeRunTrigger struct {
+	triggered []uuid.UUID
+	errs      map[uuid.UUID]error
+}
+
+func (f *fakeRunTrigger) TriggerScheduledRun(ctx context.Context, schedule *database.ScheduledRun) error {
+	f.triggered = append(f.triggered, schedule.ID)
+	return f.errs[schedule.ID]
+}
+
+func TestRunnerTriggersDueSchedules(t *testing.T) {
+	nightly := database.ScheduledRun{ID: uuid.New(), CronExpression: "30 2 * * *", Timezone: "America/New_York"}
+	hourly := database.ScheduledRun{ID: uuid.New(), CronExpression: "@hourly", Timezone: "UTC"}
+	failing := database.ScheduledRun{ID: uuid.New(), CronExpression: "0 9 * * *", Timezone: "Europe/Stockholm"}
+	invalid := database.ScheduledRun{ID: uuid.New(), CronExpression: "0 9 * * *", Timezone: "Nowhere/Special"}
+
+	repo := &fakeScheduleRepo{
+		due:  []database.ScheduledRun{nightly, hourly, failing, invalid},
+		next: make(map[uuid.UUID]time.Time),
+	}
+	trigger := &fakeRunTrigger{errs: map[uuid.UUID]error{failing.ID: errors.New("no agents")}}
+
+	runner := NewRunner(repo, trigger, time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	runner.now = func() time.Time { return time.Date(2026, 3, 7, 8, 0, 0, 0, time.UTC) }
+	runner.run(context.Background())
+
+	assert.Equal(t, []uuid.UUID{nightly.ID, hourly.ID, failing.ID}, trigger.triggered)
+
+	require.Len(t, repo.next, 3)
+	// 02:30 does not exist on 2026-03-08 in New York and fires at 03:00 EDT
+	assert.Equal(t, time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC), repo.next[nightly.ID])
+	assert.Equal(t, time.Date(2026, 3, 7, 9, 0, 0, 0, time.UTC), repo.next[hourly.ID])
+	// A run that failed to start still advances the schedule
+	assert.Equal(t, time.Date(2026, 3, 8, 8, 0, 0, 0, time.UTC), repo.next[failing.ID])
+	assert.NotContains(t, repo.next, invalid.ID)
+}
@@ -0,0 +1,30 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// SetConcurrencyGroups enables concurrency groups. Runs of a group are only
+// assigned work while no other pending or running run of the group holds
+// it, so that the group's runs execute one at a time.
+func (w *WorkScheduler) SetConcurrencyGroups(repo database.ConcurrencyGroupRepository) {
+	w.concurrencyRepo = repo
+}
+
+// claimConcurrencyGroup reports whether a run may be assigned work, making
+// it the holder of its concurrency group. Runs without a group, or with
+// concurrency groups not enabled, may always be assigned work.
+func (w *WorkScheduler) claimConcurrencyGroup(ctx context.Context, run *database.TestRun) (bool, error) {
+	if w.concurrencyRepo == nil || run.ConcurrencyGroup == nil {
+		return true, nil
+	}
+
+	claimed, err := w.concurrencyRepo.Claim(ctx, *run.ConcurrencyGroup, run.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim concurrency group: %w", err)
+	}
+	return claimed, nil
+}
@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+)
+
+// MockConcurrencyGroupRepo is a mock implementation of
+// database.ConcurrencyGroupRepository.
+type MockConcurrencyGroupRepo struct {
+	mock.Mock
+}
+
+func (m *MockConcurrencyGroupRepo) GetByService(ctx context.Context, serviceID uuid.UUID) (*database.ServiceConcurrency, error) {
+	args := m.Called(ctx, serviceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*database.ServiceConcurrency), args.Error(1)
+}
+
+func (m *MockConcurrencyGroupRepo) Claim(ctx context.Context, group string, runID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, group, runID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockConcurrencyGroupRepo) ListActive(ctx context.Context, group string) ([]uuid.UUID, error) {
+	args := m.Called(ctx, group)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func TestWorkScheduler_AssignWorkConcurrencyGroups(t *testing.T) {
+	ctx := context.Background()
+	capabilities := &conductorv1.Capabilities{}
+	service := &database.Service{ID: uuid.New(), GitURL: "https://github.com/acme/api.git"}
+	held, free := "project/api/main", "project/api/feature"
+
+	runs := []database.TestRun{
+		{ID: uuid.New(), ServiceID: service.ID, ConcurrencyGroup: &held},
+		{ID: uuid.New(), ServiceID: service.ID, ConcurrencyGroup: &free},
+		{ID: uuid.New(), ServiceID: service.ID},
+	}
+
+	newScheduler := func(pending []database.TestRun, claimErr error) *WorkScheduler {
+		runRepo := new(MockRunRepo)
+		serviceRepo := new(MockServiceRepo)
+		testRepo := new(MockTestRepo)
+		shardRepo := new(MockRunShardRepo)
+		concurrencyRepo := new(MockConcurrencyGroupRepo)
+
+		runRepo.On("GetPending", ctx, mock.Anything).Return(pending, nil)
+		runRepo.On("GetRunning", ctx).Return([]database.TestRun{}, nil)
+		serviceRepo.On("Get", ctx, service.ID).Return(service, nil)
+		testRepo.On("ListByService", ctx, service.ID, mock.Anything).Return([]database.TestDefinition{{Name: "unit"}}, nil)
+		for _, run := range runs {
+			shard := database.RunShard{ID: uuid.New(), RunID: run.ID, ShardCount: 1, Status: database.ShardStatusPending}
+			shardRepo.On("ListByRun", ctx, run.ID).Return([]database.RunShard{shard}, nil)
+		}
+		concurrencyRepo.On("Claim", ctx, held, runs[0].ID).Return(false, claimErr)
+		concurrencyRepo.On("Claim", ctx, free, runs[1].ID).Return(true, nil)
+
+		w := NewWorkScheduler(runRepo, serviceRepo, testRepo, shardRepo, nil)
+		w.SetConcurrencyGroups(concurrencyRepo)
+		return w
+	}
+
+	t.Run("skips runs whose group another run holds", func(t *testing.T) {
+		w := newScheduler(runs, nil)
+
+		work, err := w.AssignWork(ctx, uuid.New(), capabilities, nil, "")
+		require.NoError(t, err)
+		require.NotNil(t, work)
+		assert.Equal(t, runs[1].ID.String(), work.RunId)
+	})
+
+	t.Run("fails when the group cannot be claimed", func(t *testing.T) {
+		w := newScheduler(runs, errors.New("connection refused"))
+
+		_, err := w.AssignWork(ctx, uuid.New(), capabilities, nil, "")
+		assert.ErrorContains(t, err, "failed to claim concurrency group")
+	})
+
+	t.Run("runs without a group are not claimed", func(t *testing.T) {
+		w := newScheduler(runs[2:], nil)
+
+		work, err := w.AssignWork(ctx, uuid.New(), capabilities, nil, "")
+		require.NoError(t, err)
+		require.NotNil(t, work)
+		assert.Equal(t, runs[2].ID.String(), work.RunId)
+		w.concurrencyRepo.(*MockConcurrencyGroupRepo).AssertNotCalled(t, "Claim", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
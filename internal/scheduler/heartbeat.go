@@ -0,0 +1,164 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/notification"
+	"github.com/conductor/conductor/internal/websocket"
+)
+
+// AgentEventPublisher publishes agent status changes to WebSocket clients.
+// websocket.Publisher implements it.
+type AgentEventPublisher interface {
+	PublishAgentUpdate(agent websocket.AgentEvent) error
+}
+
+// Notifier sends notifications for events. notification.Service implements it.
+type Notifier interface {
+	SendNotification(ctx context.Context, event *notification.Event) error
+}
+
+// HeartbeatSweepConfig defines heartbeat sweep settings.
+type HeartbeatSweepConfig struct {
+	// HeartbeatTimeout is how long after its last heartbeat an agent is
+	// considered offline.
+	HeartbeatTimeout time.Duration
+	// Interval is how often heartbeats are checked. Defaults to a third of
+	// the heartbeat timeout.
+	Interval time.Duration
+}
+
+// HeartbeatSweeper marks agents offline when their heartbeats time out and
+// requeues the shards they were running, so that work of crashed or
+// partitioned agents is picked up by other agents.
+type HeartbeatSweeper struct {
+	agentRepo database.AgentRepository
+	shardRepo database.RunShardRepository
+	publisher AgentEventPublisher
+	notifier  Notifier
+	logger    *slog.Logger
+	timeout   time.Duration
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// NewHeartbeatSweeper creates a new HeartbeatSweeper. The publisher and
+// notifier are optional.
+func NewHeartbeatSweeper(
+	agentRepo database.AgentRepository,
+	shardRepo database.RunShardRepository,
+	publisher AgentEventPublisher,
+	notifier Notifier,
+	config HeartbeatSweepConfig,
+	logger *slog.Logger,
+) *HeartbeatSweeper {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	timeout := config.HeartbeatTimeout
+	if timeout <= 0 {
+		timeout = 90 * time.Second
+	}
+
+	interval := config.Interval
+	if interval <= 0 {
+		interval = timeout / 3
+	}
+
+	return &HeartbeatSweeper{
+		agentRepo: agentRepo,
+		shardRepo: shardRepo,
+		publisher: publisher,
+		notifier:  notifier,
+		logger:    logger.With("component", "heartbeat_sweeper"),
+		timeout:   timeout,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// Start begins the sweep loop until the context is canceled.
+func (s *HeartbeatSweeper) Start(ctx context.Context) {
+	s.logger.Info("starting heartbeat sweeper",
+		"heartbeat_timeout", s.timeout,
+		"interval", s.interval,
+	)
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.sweep(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// sweep marks timed out agents offline and requeues the running shards of
+// every offline agent. Shards of agents whose stream disconnected are
+// requeued here as well, since the disconnect only marks the agent offline.
+func (s *HeartbeatSweeper) sweep(ctx context.Context) {
+	agents, err := s.agentRepo.MarkOfflineAgents(ctx, s.timeout)
+	if err != nil {
+		s.logger.Error("failed to mark offline agents", "error", err)
+	} else {
+		for i := range agents {
+			s.agentOffline(ctx, &agents[i])
+		}
+	}
+
+	shards, err := s.shardRepo.RequeueOrphaned(ctx)
+	if err != nil {
+		s.logger.Error("failed to requeue shards of offline agents", "error", err)
+		return
+	}
+	for _, shard := range shards {
+		s.logger.Info("requeued shard of offline agent",
+			"run_id", shard.RunID,
+			"shard_index", shard.ShardIndex,
+			"agent_id", shard.AgentID,
+		)
+	}
+}
+
+func (s *HeartbeatSweeper) agentOffline(ctx context.Context, agent *database.Agent) {
+	s.logger.Warn("agent heartbeat timed out",
+		"agent_id", agent.ID,
+		"agent_name", agent.Name,
+		"last_heartbeat", agent.LastHeartbeat,
+	)
+
+	if s.publisher != nil {
+		event := websocket.AgentEvent{
+			AgentID:         agent.ID,
+			Name:            agent.Name,
+			Status:          string(database.AgentStatusOffline),
+			LastHeartbeat:   agent.LastHeartbeat,
+			Version:         agent.Version,
+			DockerAvailable: agent.DockerAvailable,
+		}
+		if err := s.publisher.PublishAgentUpdate(event); err != nil {
+			s.logger.Warn("failed to publish agent update", "agent_id", agent.ID, "error", err)
+		}
+	}
+
+	if s.notifier != nil {
+		event := &notification.Event{
+			Type:      notification.NotificationTypeAgentOffline,
+			Agent:     agent,
+			Timestamp: s.now(),
+		}
+		if err := s.notifier.SendNotification(ctx, event); err != nil {
+			s.logger.Warn("failed to send agent offline notification", "agent_id", agent.ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/notification"
+	"github.com/conductor/conductor/internal/websocket"
+)
+
+type MockAgentEventPublisher struct {
+	mock.Mock
+}
+
+func (m *MockAgentEventPublisher) PublishAgentUpdate(agent websocket.AgentEvent) error {
+	args := m.Called(agent)
+	return args.Error(0)
+}
+
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) SendNotification(ctx context.Context, event *notification.Event) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func TestNewHeartbeatSweeper_Defaults(t *testing.T) {
+	s := NewHeartbeatSweeper(nil, nil, nil, nil, HeartbeatSweepConfig{}, nil)
+	assert.Equal(t, 90*time.Second, s.timeout)
+	assert.Equal(t, 30*time.Second, s.interval)
+
+	s = NewHeartbeatSweeper(nil, nil, nil, nil, HeartbeatSweepConfig{HeartbeatTimeout: time.Minute, Interval: 5 * time.Second}, nil)
+	assert.Equal(t, time.Minute, s.timeout)
+	assert.Equal(t, 5*time.Second, s.interval)
+}
+
+func TestHeartbeatSweeper_Sweep(t *testing.T) {
+	ctx := context.Background()
+	timeout := 90 * time.Second
+
+	t.Run("marks agents offline and requeues their shards", func(t *testing.T) {
+		agentRepo := new(MockAgentRepo)
+		shardRepo := new(MockRunShardRepo)
+		publisher := new(MockAgentEventPublisher)
+		notifier := new(MockNotifier)
+
+		agent := database.Agent{ID: uuid.New(), Name: "agent-1", Status: database.AgentStatusOffline}
+		agentRepo.On("MarkOfflineAgents", ctx, timeout).Return([]database.Agent{agent}, nil)
+		shardRepo.On("RequeueOrphaned", ctx).Return([]database.RunShard{{RunID: uuid.New(), AgentID: &agent.ID}}, nil)
+
+		var published websocket.AgentEvent
+		publisher.On("PublishAgentUpdate", mock.Anything).Run(func(args mock.Arguments) {
+			published = args.Get(0).(websocket.AgentEvent)
+		}).Return(nil)
+
+		var sent *notification.Event
+		notifier.On("SendNotification", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			sent = args.Get(1).(*notification.Event)
+		}).Return(nil)
+
+		s := NewHeartbeatSweeper(agentRepo, shardRepo, publisher, notifier, HeartbeatSweepConfig{HeartbeatTimeout: timeout}, nil)
+		s.sweep(ctx)
+
+		assert.Equal(t, agent.ID, published.AgentID)
+		assert.Equal(t, "offline", published.Status)
+		require.NotNil(t, sent)
+		assert.Equal(t, notification.NotificationTypeAgentOffline, sent.Type)
+		assert.Equal(t, agent.ID, sent.Agent.ID)
+		shardRepo.AssertExpectations(t)
+	})
+
+	t.Run("requeues shards of disconnected agents without timeouts", func(t *testing.T) {
+		agentRepo := new(MockAgentRepo)
+		shardRepo := new(MockRunShardRepo)
+
+		agentRepo.On("MarkOfflineAgents", ctx, timeout).Return([]database.Agent(nil), nil)
+		shardRepo.On("RequeueOrphaned", ctx).Return([]database.RunShard{{RunID: uuid.New()}}, nil)
+
+		s := NewHeartbeatSweeper(agentRepo, shardRepo, nil, nil, HeartbeatSweepConfig{HeartbeatTimeout: timeout}, nil)
+		s.sweep(ctx)
+
+		shardRepo.AssertExpectations(t)
+	})
+
+	t.Run("requeues even when marking agents offline fails", func(t *testing.T) {
+		agentRepo := new(MockAgentRepo)
+		shardRepo := new(MockRunShardRepo)
+
+		agentRepo.On("MarkOfflineAgents", ctx, timeout).Return([]database.Agent(nil), errors.New("connection refused"))
+		shardRepo.On("RequeueOrphaned", ctx).Return([]database.RunShard(nil), nil)
+
+		s := NewHeartbeatSweeper(agentRepo, shardRepo, nil, nil, HeartbeatSweepConfig{HeartbeatTimeout: timeout}, nil)
+		s.sweep(ctx)
+
+		shardRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,25 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/schedule"
+)
+
+// SetMaintenanceWindows enables maintenance windows. During a window of a
+// service its runs keep queueing but are not assigned work, and during a
+// window of an agent pool the pool's agents are assigned no work.
+func (w *WorkScheduler) SetMaintenanceWindows(repo database.MaintenanceWindowRepository) {
+	w.maintenanceRepo = repo
+}
+
+// activeMaintenance loads the maintenance windows active now. There are
+// none if maintenance windows are not enabled.
+func (w *WorkScheduler) activeMaintenance(ctx context.Context) (schedule.ActiveWindows, error) {
+	if w.maintenanceRepo == nil {
+		return nil, nil
+	}
+	return schedule.ActiveMaintenance(ctx, w.maintenanceRepo, time.Now())
+}
@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+)
+
+// MockMaintenanceWindowRepo is a mock implementation of
+// database.MaintenanceWindowRepository.
+type MockMaintenanceWindowRepo struct {
+	mock.Mock
+}
+
+func (m *MockMaintenanceWindowRepo) Create(ctx context.Context, window *database.MaintenanceWindow) error {
+	args := m.Called(ctx, window)
+	return args.Error(0)
+}
+
+func (m *MockMaintenanceWindowRepo) Get(ctx context.Context, id uuid.UUID) (*database.MaintenanceWindow, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*database.MaintenanceWindow), args.Error(1)
+}
+
+func (m *MockMaintenanceWindowRepo) Update(ctx context.Context, window *database.MaintenanceWindow) error {
+	args := m.Called(ctx, window)
+	return args.Error(0)
+}
+
+func (m *MockMaintenanceWindowRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockMaintenanceWindowRepo) List(ctx context.Context) ([]database.MaintenanceWindow, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]database.MaintenanceWindow), args.Error(1)
+}
+
+func (m *MockMaintenanceWindowRepo) ListEnabled(ctx context.Context) ([]database.MaintenanceWindow, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]database.MaintenanceWindow), args.Error(1)
+}
+
+func TestWorkScheduler_AssignWorkMaintenanceWindows(t *testing.T) {
+	ctx := context.Background()
+	capabilities := &conductorv1.Capabilities{}
+	maintained := &database.Service{ID: uuid.New(), GitURL: "https://github.com/acme/api.git"}
+	active := &database.Service{ID: uuid.New(), GitURL: "https://github.com/acme/web.git"}
+
+	runs := []database.TestRun{
+		{ID: uuid.New(), ServiceID: maintained.ID},
+		{ID: uuid.New(), ServiceID: active.ID},
+	}
+
+	startsAt := time.Now().Add(-time.Hour)
+	endsAt := time.Now().Add(time.Hour)
+	pool := "gpu"
+
+	newScheduler := func(windows []database.MaintenanceWindow) *WorkScheduler {
+		runRepo := new(MockRunRepo)
+		serviceRepo := new(MockServiceRepo)
+		testRepo := new(MockTestRepo)
+		shardRepo := new(MockRunShardRepo)
+		maintenanceRepo := new(MockMaintenanceWindowRepo)
+
+		runRepo.On("GetPending", ctx, mock.Anything).Return(runs, nil)
+		runRepo.On("GetRunning", ctx).Return([]database.TestRun{}, nil)
+		for _, service := range []*database.Service{maintained, active} {
+			serviceRepo.On("Get", ctx, service.ID).Return(service, nil)
+			testRepo.On("ListByService", ctx, service.ID, mock.Anything).Return([]database.TestDefinition{{Name: "unit"}}, nil)
+		}
+		for _, run := range runs {
+			shard := database.RunShard{ID: uuid.New(), RunID: run.ID, ShardCount: 1, Status: database.ShardStatusPending}
+			shardRepo.On("ListByRun", ctx, run.ID).Return([]database.RunShard{shard}, nil)
+		}
+		maintenanceRepo.On("ListEnabled", ctx).Return(windows, nil)
+
+		w := NewWorkScheduler(runRepo, serviceRepo, testRepo, shardRepo, nil)
+		w.SetMaintenanceWindows(maintenanceRepo)
+		return w
+	}
+
+	t.Run("defers runs of services in maintenance", func(t *testing.T) {
+		w := newScheduler([]database.MaintenanceWindow{
+			{ServiceID: &maintained.ID, StartsAt: &startsAt, EndsAt: &endsAt},
+		})
+
+		work, err := w.AssignWork(ctx, uuid.New(), capabilities, nil, "")
+		require.NoError(t, err)
+		require.NotNil(t, work)
+		assert.Equal(t, runs[1].ID.String(), work.RunId)
+	})
+
+	t.Run("assigns nothing to agents of a pool in maintenance", func(t *testing.T) {
+		w := newScheduler([]database.MaintenanceWindow{
+			{AgentPool: &pool, StartsAt: &startsAt, EndsAt: &endsAt},
+		})
+
+		work, err := w.AssignWork(ctx, uuid.New(), capabilities, nil, pool)
+		require.NoError(t, err)
+		assert.Nil(t, work)
+	})
+
+	t.Run("ignores windows that are not active", func(t *testing.T) {
+		later := endsAt.Add(time.Hour)
+		w := newScheduler([]database.MaintenanceWindow{
+			{ServiceID: &maintained.ID, StartsAt: &endsAt, EndsAt: &later},
+		})
+
+		work, err := w.AssignWork(ctx, uuid.New(), capabilities, nil, "")
+		require.NoError(t, err)
+		require.NotNil(t, work)
+		assert.Equal(t, runs[0].ID.String(), work.RunId)
+	})
+}
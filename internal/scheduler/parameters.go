@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"maps"
+	"regexp"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+)
+
+// parameterReference matches references to parameters in test commands.
+var parameterReference = regexp.MustCompile(`\$\{params\.([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// resolveParameters returns the values of the parameters a test declares
+// for a run: the run's value where it is valid, the default otherwise.
+// Parameters are validated when runs are created through the API, so
+// invalid values only remain if the test's schema changed since; runs
+// created by schedules and webhooks set none.
+func resolveParameters(params database.TestParameters, values map[string]string) map[string]string {
+	resolved := make(map[string]string, len(params))
+	for _, param := range params {
+		value, ok := values[param.Name]
+		if !ok || param.Check(value) != nil {
+			if param.Default == nil {
+				continue
+			}
+			value = *param.Default
+		}
+		resolved[param.Name] = value
+	}
+	return resolved
+}
+
+// applyParameters passes the parameters of a run to a test: as environment
+// variables, which override the test's own, and in place of
+// ${params.NAME} in its command. References to declared parameters without
+// a value are removed; other references are left as is.
+func applyParameters(test *conductorv1.TestToRun, def database.TestDefinition, values map[string]string) {
+	if len(def.Parameters) == 0 {
+		return
+	}
+
+	resolved := resolveParameters(def.Parameters, values)
+	test.Command = parameterReference.ReplaceAllStringFunc(test.Command, func(ref string) string {
+		name := parameterReference.FindStringSubmatch(ref)[1]
+		if !def.Parameters.Declares(name) {
+			return ref
+		}
+		return resolved[name]
+	})
+
+	if len(resolved) > 0 {
+		env := maps.Clone(test.Environment)
+		if env == nil {
+			env = make(map[string]string, len(resolved))
+		}
+		maps.Copy(env, resolved)
+		test.Environment = env
+	}
+}
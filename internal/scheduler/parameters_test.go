@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+func TestBuildAssignWork_Parameters(t *testing.T) {
+	staging := "staging"
+	params := database.TestParameters{
+		{Name: "TARGET_ENV", Default: &staging, Choices: []string{"staging", "production"}},
+		{Name: "WORKERS", Type: database.TestParameterInteger},
+		{Name: "TOKEN"},
+	}
+	tests := []database.TestDefinition{
+		{
+			Name:        "e2e",
+			Command:     "npm test -- --env ${params.TARGET_ENV} --workers=${params.WORKERS} ${params.OTHER}",
+			Environment: map[string]string{"TARGET_ENV": "dev", "CI": "true"},
+			Parameters:  params,
+		},
+		{Name: "lint", Command: "make lint ${params.TARGET_ENV}"},
+	}
+	shard := &database.RunShard{ID: uuid.New()}
+
+	t.Run("injects run values", func(t *testing.T) {
+		run := &database.TestRun{ID: uuid.New(), Parameters: map[string]string{"TARGET_ENV": "production", "WORKERS": "4"}}
+		assignment := buildAssignWork(&database.Service{}, run, shard, tests)
+		require.Len(t, assignment.Tests, 2)
+
+		assert.Equal(t, "npm test -- --env production --workers=4 ${params.OTHER}", assignment.Tests[0].Command)
+		assert.Equal(t, map[string]string{"TARGET_ENV": "production", "WORKERS": "4", "CI": "true"}, assignment.Tests[0].Environment)
+		assert.Equal(t, "dev", tests[0].Environment["TARGET_ENV"])
+
+		// Tests that do not declare parameters run unchanged
+		assert.Equal(t, "make lint ${params.TARGET_ENV}", assignment.Tests[1].Command)
+		assert.Nil(t, assignment.Tests[1].Environment)
+	})
+
+	t.Run("falls back to defaults", func(t *testing.T) {
+		run := &database.TestRun{ID: uuid.New(), Parameters: map[string]string{"WORKERS": "many"}}
+		assignment := buildAssignWork(&database.Service{}, run, shard, tests)
+
+		assert.Equal(t, "npm test -- --env staging --workers= ${params.OTHER}", assignment.Tests[0].Command)
+		assert.Equal(t, map[string]string{"TARGET_ENV": "staging", "CI": "true"}, assignment.Tests[0].Environment)
+	})
+}
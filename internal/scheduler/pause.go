@@ -0,0 +1,51 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// SetSchedulingPauses enables pausing scheduling. While scheduling is paused
+// globally no work is assigned, and while a service is paused its runs keep
+// queueing but are not assigned work.
+func (w *WorkScheduler) SetSchedulingPauses(repo database.SchedulingPauseRepository) {
+	w.pauseRepo = repo
+}
+
+// schedulingPauses is the paused state of scheduling.
+type schedulingPauses struct {
+	global   bool
+	services map[uuid.UUID]bool
+}
+
+// paused reports whether a service's runs may not be assigned work.
+func (p schedulingPauses) paused(serviceID uuid.UUID) bool {
+	return p.global || p.services[serviceID]
+}
+
+// schedulingPauses loads the paused state of scheduling. Nothing is paused
+// if pausing scheduling is not enabled.
+func (w *WorkScheduler) schedulingPauses(ctx context.Context) (schedulingPauses, error) {
+	if w.pauseRepo == nil {
+		return schedulingPauses{}, nil
+	}
+
+	list, err := w.pauseRepo.List(ctx)
+	if err != nil {
+		return schedulingPauses{}, fmt.Errorf("failed to list scheduling pauses: %w", err)
+	}
+
+	pauses := schedulingPauses{services: make(map[uuid.UUID]bool)}
+	for _, pause := range list {
+		if pause.ServiceID == nil {
+			pauses.global = true
+		} else {
+			pauses.services[*pause.ServiceID] = true
+		}
+	}
+	return pauses, nil
+}
@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+)
+
+// MockSchedulingPauseRepo is a mock implementation of
+// database.SchedulingPauseRepository.
+type MockSchedulingPauseRepo struct {
+	mock.Mock
+}
+
+func (m *MockSchedulingPauseRepo) Pause(ctx context.Context, pause *database.SchedulingPause) error {
+	args := m.Called(ctx, pause)
+	return args.Error(0)
+}
+
+func (m *MockSchedulingPauseRepo) Resume(ctx context.Context, serviceID *uuid.UUID) error {
+	args := m.Called(ctx, serviceID)
+	return args.Error(0)
+}
+
+func (m *MockSchedulingPauseRepo) List(ctx context.Context) ([]database.SchedulingPause, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]database.SchedulingPause), args.Error(1)
+}
+
+func TestWorkScheduler_AssignWorkSchedulingPauses(t *testing.T) {
+	ctx := context.Background()
+	capabilities := &conductorv1.Capabilities{}
+	paused := &database.Service{ID: uuid.New(), GitURL: "https://github.com/acme/api.git"}
+	active := &database.Service{ID: uuid.New(), GitURL: "https://github.com/acme/web.git"}
+
+	runs := []database.TestRun{
+		{ID: uuid.New(), ServiceID: paused.ID},
+		{ID: uuid.New(), ServiceID: active.ID},
+	}
+
+	newScheduler := func(pauses []database.SchedulingPause, listErr error) *WorkScheduler {
+		runRepo := new(MockRunRepo)
+		serviceRepo := new(MockServiceRepo)
+		testRepo := new(MockTestRepo)
+		shardRepo := new(MockRunShardRepo)
+		pauseRepo := new(MockSchedulingPauseRepo)
+
+		runRepo.On("GetPending", ctx, mock.Anything).Return(runs, nil)
+		runRepo.On("GetRunning", ctx).Return([]database.TestRun{}, nil)
+		for _, service := range []*database.Service{paused, active} {
+			serviceRepo.On("Get", ctx, service.ID).Return(service, nil)
+			testRepo.On("ListByService", ctx, service.ID, mock.Anything).Return([]database.TestDefinition{{Name: "unit"}}, nil)
+		}
+		for _, run := range runs {
+			shard := database.RunShard{ID: uuid.New(), RunID: run.ID, ShardCount: 1, Status: database.ShardStatusPending}
+			shardRepo.On("ListByRun", ctx, run.ID).Return([]database.RunShard{shard}, nil)
+		}
+		pauseRepo.On("List", ctx).Return(pauses, listErr)
+
+		w := NewWorkScheduler(runRepo, serviceRepo, testRepo, shardRepo, nil)
+		w.SetSchedulingPauses(pauseRepo)
+		return w
+	}
+
+	t.Run("skips runs of paused services", func(t *testing.T) {
+		w := newScheduler([]database.SchedulingPause{{ServiceID: &paused.ID}}, nil)
+
+		work, err := w.AssignWork(ctx, uuid.New(), capabilities, nil, "")
+		require.NoError(t, err)
+		require.NotNil(t, work)
+		assert.Equal(t, runs[1].ID.String(), work.RunId)
+	})
+
+	t.Run("assigns nothing while paused globally", func(t *testing.T) {
+		w := newScheduler([]database.SchedulingPause{{}}, nil)
+
+		work, err := w.AssignWork(ctx, uuid.New(), capabilities, nil, "")
+		require.NoError(t, err)
+		assert.Nil(t, work)
+		w.runRepo.(*MockRunRepo).AssertNotCalled(t, "GetPending", mock.Anything, mock.Anything)
+	})
+
+	t.Run("fails when the pauses cannot be listed", func(t *testing.T) {
+		w := newScheduler(nil, errors.New("connection refused"))
+
+		_, err := w.AssignWork(ctx, uuid.New(), capabilities, nil, "")
+		assert.ErrorContains(t, err, "failed to list scheduling pauses")
+	})
+}
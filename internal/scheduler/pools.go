@@ -0,0 +1,162 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// SetPools enables agent pool quotas. Agents of a pool with quotas are only
+// assigned work while the shards running on the pool's agents, in total and
+// per service, stay below the pool's limits.
+func (w *WorkScheduler) SetPools(repo database.AgentPoolRepository) {
+	w.poolRepo = repo
+}
+
+// poolMatches reports whether an agent in pool may run the work of a
+// service. Services pinned to a pool only run on its agents; other services
+// run on agents of any pool.
+func poolMatches(service *database.Service, pool string) bool {
+	return service.AgentPool == nil || *service.AgentPool == pool
+}
+
+// poolUsage is the running work of an agent pool measured against its
+// quotas.
+type poolUsage struct {
+	pool      *database.AgentPool
+	total     int
+	byService map[uuid.UUID]int
+}
+
+// full reports whether the pool has reached its total quota.
+func (u *poolUsage) full() bool {
+	return u != nil && u.pool.MaxConcurrency > 0 && u.total >= u.pool.MaxConcurrency
+}
+
+// serviceFull reports whether the pool has reached its per-service quota
+// for a service.
+func (u *poolUsage) serviceFull(serviceID uuid.UUID) bool {
+	return u != nil && u.pool.MaxConcurrencyPerService > 0 && u.byService[serviceID] >= u.pool.MaxConcurrencyPerService
+}
+
+// poolUsage loads the running work of an agent's pool. It returns nil when
+// the agent is in no pool, pools are not enabled, or the pool is not
+// defined or has no quotas.
+func (w *WorkScheduler) poolUsage(ctx context.Context, pool string) (*poolUsage, error) {
+	if pool == "" || w.poolRepo == nil {
+		return nil, nil
+	}
+
+	p, err := w.poolRepo.GetByName(ctx, pool)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get agent pool: %w", err)
+	}
+	if p.MaxConcurrency <= 0 && p.MaxConcurrencyPerService <= 0 {
+		return nil, nil
+	}
+
+	byService, err := w.poolRepo.RunningShardsByService(ctx, pool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count running shards of agent pool: %w", err)
+	}
+
+	usage := &poolUsage{pool: p, byService: byService}
+	for _, count := range byService {
+		usage.total += count
+	}
+	return usage, nil
+}
+
+// PoolMetrics records the usage of agent pools. metrics.ControlPlaneMetrics
+// implements it.
+type PoolMetrics interface {
+	SetAgentPool(pool string, agents, onlineAgents, runningShards, maxConcurrency float64)
+	DeleteAgentPool(pool string)
+}
+
+// PoolMetricsReporter periodically exports the agent count, running shards
+// and quota of every agent pool as metrics.
+type PoolMetricsReporter struct {
+	poolRepo database.AgentPoolRepository
+	metrics  PoolMetrics
+	logger   *slog.Logger
+	interval time.Duration
+	reported map[string]bool
+}
+
+// NewPoolMetricsReporter creates a new PoolMetricsReporter. The interval
+// defaults to 30 seconds.
+func NewPoolMetricsReporter(poolRepo database.AgentPoolRepository, metrics PoolMetrics, interval time.Duration, logger *slog.Logger) *PoolMetricsReporter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	return &PoolMetricsReporter{
+		poolRepo: poolRepo,
+		metrics:  metrics,
+		logger:   logger.With("component", "pool_metrics"),
+		interval: interval,
+		reported: make(map[string]bool),
+	}
+}
+
+// Start begins reporting until the context is canceled.
+func (r *PoolMetricsReporter) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			r.report(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// report exports the usage of every pool and removes the metrics of
+// deleted pools.
+func (r *PoolMetricsReporter) report(ctx context.Context) {
+	pools, err := r.poolRepo.List(ctx)
+	if err != nil {
+		r.logger.Error("failed to list agent pools", "error", err)
+		return
+	}
+	stats, err := r.poolRepo.Stats(ctx)
+	if err != nil {
+		r.logger.Error("failed to get agent pool stats", "error", err)
+		return
+	}
+
+	usage := make(map[string]database.AgentPoolStats, len(stats))
+	for _, s := range stats {
+		usage[s.Pool] = s
+	}
+
+	current := make(map[string]bool, len(pools))
+	for _, pool := range pools {
+		s := usage[pool.Name]
+		r.metrics.SetAgentPool(pool.Name, float64(s.Agents), float64(s.OnlineAgents), float64(s.RunningShards), float64(pool.MaxConcurrency))
+		current[pool.Name] = true
+	}
+	for name := range r.reported {
+		if !current[name] {
+			r.metrics.DeleteAgentPool(name)
+		}
+	}
+	r.reported = current
+}
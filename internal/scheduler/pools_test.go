@@ -0,0 +1,233 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+)
+
+// MockAgentPoolRepo is a mock implementation of database.AgentPoolRepository.
+type MockAgentPoolRepo struct {
+	mock.Mock
+}
+
+func (m *MockAgentPoolRepo) Create(ctx context.Context, pool *database.AgentPool) error {
+	args := m.Called(ctx, pool)
+	return args.Error(0)
+}
+
+func (m *MockAgentPoolRepo) GetByName(ctx context.Context, name string) (*database.AgentPool, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*database.AgentPool), args.Error(1)
+}
+
+func (m *MockAgentPoolRepo) List(ctx context.Context) ([]database.AgentPool, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]database.AgentPool), args.Error(1)
+}
+
+func (m *MockAgentPoolRepo) Update(ctx context.Context, pool *database.AgentPool) error {
+	args := m.Called(ctx, pool)
+	return args.Error(0)
+}
+
+func (m *MockAgentPoolRepo) Delete(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func (m *MockAgentPoolRepo) Stats(ctx context.Context) ([]database.AgentPoolStats, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]database.AgentPoolStats), args.Error(1)
+}
+
+func (m *MockAgentPoolRepo) RunningShardsByService(ctx context.Context, name string) (map[uuid.UUID]int, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(map[uuid.UUID]int), args.Error(1)
+}
+
+// MockPoolMetrics records the pools metrics were set and deleted for.
+type MockPoolMetrics struct {
+	set     map[string][4]float64
+	deleted []string
+}
+
+func (m *MockPoolMetrics) SetAgentPool(pool string, agents, onlineAgents, runningShards, maxConcurrency float64) {
+	m.set[pool] = [4]float64{agents, onlineAgents, runningShards, maxConcurrency}
+}
+
+func (m *MockPoolMetrics) DeleteAgentPool(pool string) {
+	m.deleted = append(m.deleted, pool)
+}
+
+func TestPoolMatches(t *testing.T) {
+	gpu := "gpu"
+
+	assert.True(t, poolMatches(&database.Service{}, ""))
+	assert.True(t, poolMatches(&database.Service{}, "gpu"))
+	assert.True(t, poolMatches(&database.Service{AgentPool: &gpu}, "gpu"))
+	assert.False(t, poolMatches(&database.Service{AgentPool: &gpu}, "arm"))
+	assert.False(t, poolMatches(&database.Service{AgentPool: &gpu}, ""))
+}
+
+func TestPoolUsage(t *testing.T) {
+	ctx := context.Background()
+	serviceA, serviceB := uuid.New(), uuid.New()
+
+	newScheduler := func(pool *database.AgentPool, running map[uuid.UUID]int) *WorkScheduler {
+		poolRepo := new(MockAgentPoolRepo)
+		if pool == nil {
+			poolRepo.On("GetByName", ctx, mock.Anything).Return(nil, database.ErrNotFound)
+		} else {
+			poolRepo.On("GetByName", ctx, pool.Name).Return(pool, nil)
+		}
+		poolRepo.On("RunningShardsByService", ctx, mock.Anything).Return(running, nil)
+
+		w := NewWorkScheduler(nil, nil, nil, nil, nil)
+		w.SetPools(poolRepo)
+		return w
+	}
+
+	t.Run("total quota", func(t *testing.T) {
+		w := newScheduler(&database.AgentPool{Name: "gpu", MaxConcurrency: 3}, map[uuid.UUID]int{serviceA: 2, serviceB: 1})
+
+		usage, err := w.poolUsage(ctx, "gpu")
+		require.NoError(t, err)
+		assert.True(t, usage.full())
+		assert.False(t, usage.serviceFull(serviceA))
+	})
+
+	t.Run("per-service quota", func(t *testing.T) {
+		w := newScheduler(&database.AgentPool{Name: "gpu", MaxConcurrencyPerService: 2}, map[uuid.UUID]int{serviceA: 2, serviceB: 1})
+
+		usage, err := w.poolUsage(ctx, "gpu")
+		require.NoError(t, err)
+		assert.False(t, usage.full())
+		assert.True(t, usage.serviceFull(serviceA))
+		assert.False(t, usage.serviceFull(serviceB))
+	})
+
+	t.Run("pools without quotas are unlimited", func(t *testing.T) {
+		w := newScheduler(&database.AgentPool{Name: "gpu"}, nil)
+
+		usage, err := w.poolUsage(ctx, "gpu")
+		require.NoError(t, err)
+		assert.Nil(t, usage)
+		assert.False(t, usage.full())
+		assert.False(t, usage.serviceFull(serviceA))
+	})
+
+	t.Run("undefined pools are unlimited", func(t *testing.T) {
+		w := newScheduler(nil, nil)
+
+		usage, err := w.poolUsage(ctx, "adhoc")
+		require.NoError(t, err)
+		assert.Nil(t, usage)
+	})
+
+	t.Run("agents without a pool are unlimited", func(t *testing.T) {
+		usage, err := NewWorkScheduler(nil, nil, nil, nil, nil).poolUsage(ctx, "gpu")
+		require.NoError(t, err)
+		assert.Nil(t, usage)
+	})
+}
+
+func TestWorkScheduler_AssignWorkPools(t *testing.T) {
+	ctx := context.Background()
+	gpu, arm := "gpu", "arm"
+	capabilities := &conductorv1.Capabilities{}
+
+	throttled := &database.Service{ID: uuid.New(), GitURL: "https://github.com/acme/throttled.git"}
+	pinnedElsewhere := &database.Service{ID: uuid.New(), GitURL: "https://github.com/acme/arm.git", AgentPool: &arm}
+	pinned := &database.Service{ID: uuid.New(), GitURL: "https://github.com/acme/gpu.git", AgentPool: &gpu}
+
+	runs := []database.TestRun{
+		{ID: uuid.New(), ServiceID: throttled.ID},
+		{ID: uuid.New(), ServiceID: pinnedElsewhere.ID},
+		{ID: uuid.New(), ServiceID: pinned.ID},
+	}
+
+	newScheduler := func(pool *database.AgentPool, running map[uuid.UUID]int) *WorkScheduler {
+		runRepo := new(MockRunRepo)
+		serviceRepo := new(MockServiceRepo)
+		testRepo := new(MockTestRepo)
+		shardRepo := new(MockRunShardRepo)
+		poolRepo := new(MockAgentPoolRepo)
+
+		runRepo.On("GetPending", ctx, mock.Anything).Return(runs, nil)
+		runRepo.On("GetRunning", ctx).Return([]database.TestRun{}, nil)
+		for _, svc := range []*database.Service{throttled, pinnedElsewhere, pinned} {
+			serviceRepo.On("Get", ctx, svc.ID).Return(svc, nil)
+		}
+		for _, run := range runs {
+			shard := database.RunShard{ID: uuid.New(), RunID: run.ID, ShardCount: 1, Status: database.ShardStatusPending}
+			testRepo.On("ListByService", ctx, run.ServiceID, mock.Anything).Return([]database.TestDefinition{{Name: "unit"}}, nil)
+			shardRepo.On("ListByRun", ctx, run.ID).Return([]database.RunShard{shard}, nil)
+		}
+		poolRepo.On("GetByName", ctx, "gpu").Return(pool, nil)
+		poolRepo.On("RunningShardsByService", ctx, "gpu").Return(running, nil)
+
+		w := NewWorkScheduler(runRepo, serviceRepo, testRepo, shardRepo, nil)
+		w.SetPools(poolRepo)
+		return w
+	}
+
+	t.Run("skips services pinned elsewhere and at their quota", func(t *testing.T) {
+		w := newScheduler(&database.AgentPool{Name: "gpu", MaxConcurrencyPerService: 1}, map[uuid.UUID]int{throttled.ID: 1})
+
+		work, err := w.AssignWork(ctx, uuid.New(), capabilities, nil, "gpu")
+		require.NoError(t, err)
+		require.NotNil(t, work)
+		assert.Equal(t, runs[2].ID.String(), work.RunId)
+	})
+
+	t.Run("assigns nothing when the pool is full", func(t *testing.T) {
+		w := newScheduler(&database.AgentPool{Name: "gpu", MaxConcurrency: 1}, map[uuid.UUID]int{throttled.ID: 1})
+
+		work, err := w.AssignWork(ctx, uuid.New(), capabilities, nil, "gpu")
+		require.NoError(t, err)
+		assert.Nil(t, work)
+	})
+
+	t.Run("agents without a pool run unpinned services", func(t *testing.T) {
+		w := newScheduler(&database.AgentPool{Name: "gpu"}, nil)
+
+		work, err := w.AssignWork(ctx, uuid.New(), capabilities, nil, "")
+		require.NoError(t, err)
+		require.NotNil(t, work)
+		assert.Equal(t, runs[0].ID.String(), work.RunId)
+	})
+}
+
+func TestPoolMetricsReporter_Report(t *testing.T) {
+	ctx := context.Background()
+	poolRepo := new(MockAgentPoolRepo)
+	poolRepo.On("List", ctx).Return([]database.AgentPool{
+		{Name: "gpu", MaxConcurrency: 8},
+		{Name: "arm"},
+	}, nil)
+	poolRepo.On("Stats", ctx).Return([]database.AgentPoolStats{
+		{Pool: "gpu", Agents: 4, OnlineAgents: 3, RunningShards: 2},
+	}, nil)
+
+	metrics := &MockPoolMetrics{set: make(map[string][4]float64)}
+	r := NewPoolMetricsReporter(poolRepo, metrics, 0, nil)
+	r.reported["retired"] = true
+
+	r.report(ctx)
+
+	assert.Equal(t, [4]float64{4, 3, 2, 8}, metrics.set["gpu"])
+	assert.Equal(t, [4]float64{0, 0, 0, 0}, metrics.set["arm"])
+	assert.Equal(t, []string{"retired"}, metrics.deleted)
+	assert.Equal(t, map[string]bool{"gpu": true, "arm": true}, r.reported)
+}
@@ -0,0 +1,201 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// SetRetryPolicies enables automatic retries. Runs that finish with an error,
+// or a failure if the policy allows it, are rescheduled as a new attempt
+// after the policy's backoff.
+func (w *WorkScheduler) SetRetryPolicies(repo database.RetryPolicyRepository) {
+	w.retryPolicyRepo = repo
+}
+
+// retryRun schedules another attempt of a finished run if a retry policy
+// applies. Retries are best effort; errors are logged, not returned, so they
+// never fail the completion that triggered them.
+func (w *WorkScheduler) retryRun(ctx context.Context, runID uuid.UUID, status database.RunStatus, shards []database.RunShard) {
+	if w.retryPolicyRepo == nil || !retryableStatus(status) {
+		return
+	}
+
+	retry, err := w.scheduleRetry(ctx, runID, status, shards)
+	if err != nil {
+		w.logger.Error("failed to schedule retry", "run_id", runID, "error", err)
+		return
+	}
+	if retry != nil {
+		w.logger.Info("scheduled retry",
+			"run_id", runID,
+			"retry_run_id", retry.ID,
+			"attempt", retry.Attempt,
+			"not_before", retry.NotBefore,
+		)
+	}
+}
+
+func (w *WorkScheduler) scheduleRetry(ctx context.Context, runID uuid.UUID, status database.RunStatus, shards []database.RunShard) (*database.TestRun, error) {
+	run, err := w.runRepo.Get(ctx, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run: %w", err)
+	}
+
+	policies, err := w.retryPolicyRepo.ListByService(ctx, run.ServiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retry policies: %w", err)
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	tests, err := w.testRepo.ListByService(ctx, run.ServiceID, database.Pagination{Limit: 1000})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tests: %w", err)
+	}
+
+	policy := selectRetryPolicy(policies, status, failedTests(tests, status, shards))
+	if policy == nil {
+		return nil, nil
+	}
+
+	attempt := run.Attempt
+	if attempt <= 0 {
+		attempt = 1
+	}
+	if attempt >= policy.MaxAttempts {
+		return nil, nil
+	}
+
+	notBefore := time.Now().Add(retryBackoff(policy, attempt))
+	retry := &database.TestRun{
+		ServiceID:         run.ServiceID,
+		Status:            database.RunStatusPending,
+		GitRef:            run.GitRef,
+		GitSHA:            run.GitSHA,
+		TriggerType:       run.TriggerType,
+		TriggeredBy:       run.TriggeredBy,
+		Priority:          run.Priority,
+		PullRequestNumber: run.PullRequestNumber,
+		Attempt:           attempt + 1,
+		RetryOfRunID:      &run.ID,
+		NotBefore:         &notBefore,
+		LabelSelector:     run.LabelSelector,
+		TraceParent:       run.TraceParent,
+		ConcurrencyGroup:  run.ConcurrencyGroup,
+		Metadata:          run.Metadata,
+		Parameters:        run.Parameters,
+	}
+	if err := w.runRepo.Create(ctx, retry); err != nil {
+		return nil, fmt.Errorf("failed to create retry run: %w", err)
+	}
+	return retry, nil
+}
+
+// retryableStatus reports whether any retry policy can retry a run status.
+func retryableStatus(status database.RunStatus) bool {
+	switch status {
+	case database.RunStatusError, database.RunStatusFailed, database.RunStatusTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// policyRetries reports whether a policy retries a run status.
+func policyRetries(policy *database.RetryPolicy, status database.RunStatus) bool {
+	if status == database.RunStatusError {
+		return true
+	}
+	return policy.RetryOn == database.RetryOnFailure && retryableStatus(status)
+}
+
+// failedTests maps the test definitions that errored or failed to the status
+// of the shard they ran in. Without shards, every test shares the run status.
+func failedTests(tests []database.TestDefinition, status database.RunStatus, shards []database.RunShard) map[uuid.UUID]database.RunStatus {
+	failed := make(map[uuid.UUID]database.RunStatus)
+	if len(shards) == 0 {
+		for _, test := range tests {
+			failed[test.ID] = status
+		}
+		return failed
+	}
+
+	for _, shard := range shards {
+		var shardStatus database.RunStatus
+		switch shard.Status {
+		case database.ShardStatusError:
+			shardStatus = database.RunStatusError
+		case database.ShardStatusFailed:
+			shardStatus = database.RunStatusFailed
+		default:
+			continue
+		}
+		for _, test := range testsForShard(tests, &shard) {
+			failed[test.ID] = shardStatus
+		}
+	}
+	return failed
+}
+
+// selectRetryPolicy picks the policy that applies to a finished run. Test
+// definition policies take precedence when every failed test definition has
+// one; the policy allowing the most attempts wins. Otherwise the service
+// policy applies. It returns nil when no policy retries the run.
+func selectRetryPolicy(policies []database.RetryPolicy, status database.RunStatus, failed map[uuid.UUID]database.RunStatus) *database.RetryPolicy {
+	var servicePolicy *database.RetryPolicy
+	definitionPolicies := make(map[uuid.UUID]*database.RetryPolicy)
+	for i := range policies {
+		if policies[i].TestDefinitionID == nil {
+			servicePolicy = &policies[i]
+			continue
+		}
+		definitionPolicies[*policies[i].TestDefinitionID] = &policies[i]
+	}
+
+	covered := len(failed) > 0
+	for testID := range failed {
+		if _, ok := definitionPolicies[testID]; !ok {
+			covered = false
+			break
+		}
+	}
+
+	if covered {
+		var selected *database.RetryPolicy
+		for testID, testStatus := range failed {
+			policy := definitionPolicies[testID]
+			if !policyRetries(policy, testStatus) {
+				continue
+			}
+			if selected == nil || policy.MaxAttempts > selected.MaxAttempts {
+				selected = policy
+			}
+		}
+		return selected
+	}
+
+	if servicePolicy != nil && policyRetries(servicePolicy, status) {
+		return servicePolicy
+	}
+	return nil
+}
+
+// retryBackoff returns the delay before the attempt after the given one. The
+// delay doubles with every attempt, up to the policy's maximum.
+func retryBackoff(policy *database.RetryPolicy, attempt int) time.Duration {
+	backoff := time.Duration(policy.BackoffSeconds) * time.Second
+	maxBackoff := time.Duration(policy.MaxBackoffSeconds) * time.Second
+	for i := 1; i < attempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if maxBackoff > 0 && backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
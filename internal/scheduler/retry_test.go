@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+)
+
+// MockRetryPolicyRepo is a mock implementation of database.RetryPolicyRepository.
+type MockRetryPolicyRepo struct {
+	mock.Mock
+}
+
+func (m *MockRetryPolicyRepo) Upsert(ctx context.Context, policy *database.RetryPolicy) error {
+	args := m.Called(ctx, policy)
+	return args.Error(0)
+}
+
+func (m *MockRetryPolicyRepo) ListByService(ctx context.Context, serviceID uuid.UUID) ([]database.RetryPolicy, error) {
+	args := m.Called(ctx, serviceID)
+	return args.Get(0).([]database.RetryPolicy), args.Error(1)
+}
+
+func (m *MockRetryPolicyRepo) Delete(ctx context.Context, serviceID uuid.UUID, testDefID *uuid.UUID) error {
+	args := m.Called(ctx, serviceID, testDefID)
+	return args.Error(0)
+}
+
+func TestSelectRetryPolicy(t *testing.T) {
+	serviceID := uuid.New()
+	unitID := uuid.New()
+	e2eID := uuid.New()
+
+	servicePolicy := database.RetryPolicy{ServiceID: serviceID, MaxAttempts: 2, RetryOn: database.RetryOnError}
+	e2ePolicy := database.RetryPolicy{ServiceID: serviceID, TestDefinitionID: &e2eID, MaxAttempts: 4, RetryOn: database.RetryOnFailure}
+	policies := []database.RetryPolicy{servicePolicy, e2ePolicy}
+
+	t.Run("service policy retries errors", func(t *testing.T) {
+		failed := map[uuid.UUID]database.RunStatus{unitID: database.RunStatusError, e2eID: database.RunStatusError}
+		policy := selectRetryPolicy(policies, database.RunStatusError, failed)
+		require.NotNil(t, policy)
+		assert.Nil(t, policy.TestDefinitionID)
+	})
+
+	t.Run("service policy does not retry failures", func(t *testing.T) {
+		failed := map[uuid.UUID]database.RunStatus{unitID: database.RunStatusFailed}
+		assert.Nil(t, selectRetryPolicy(policies, database.RunStatusFailed, failed))
+	})
+
+	t.Run("definition policy takes precedence", func(t *testing.T) {
+		failed := map[uuid.UUID]database.RunStatus{e2eID: database.RunStatusFailed}
+		policy := selectRetryPolicy(policies, database.RunStatusFailed, failed)
+		require.NotNil(t, policy)
+		assert.Equal(t, e2eID, *policy.TestDefinitionID)
+	})
+
+	t.Run("no policy", func(t *testing.T) {
+		assert.Nil(t, selectRetryPolicy(nil, database.RunStatusError, nil))
+	})
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := &database.RetryPolicy{BackoffSeconds: 30, MaxBackoffSeconds: 100}
+
+	assert.Equal(t, 30*time.Second, retryBackoff(policy, 1))
+	assert.Equal(t, 60*time.Second, retryBackoff(policy, 2))
+	assert.Equal(t, 100*time.Second, retryBackoff(policy, 3))
+	assert.Equal(t, 100*time.Second, retryBackoff(policy, 10))
+}
+
+func TestWorkScheduler_RetryOnError(t *testing.T) {
+	ctx := context.Background()
+	serviceID := uuid.New()
+	gitSHA := "abc123"
+
+	newScheduler := func(run *database.TestRun, policies []database.RetryPolicy) (*WorkScheduler, *MockRunRepo) {
+		runRepo := new(MockRunRepo)
+		testRepo := new(MockTestRepo)
+		policyRepo := new(MockRetryPolicyRepo)
+
+		runRepo.On("FinishActive", ctx, run.ID, mock.Anything, mock.Anything).Return(nil)
+		runRepo.On("Get", ctx, run.ID).Return(run, nil)
+		policyRepo.On("ListByService", ctx, serviceID).Return(policies, nil)
+		testRepo.On("ListByService", ctx, serviceID, mock.Anything).Return([]database.TestDefinition{{ID: uuid.New()}}, nil)
+
+		w := NewWorkScheduler(runRepo, nil, testRepo, nil, nil)
+		w.SetRetryPolicies(policyRepo)
+		return w, runRepo
+	}
+
+	policy := database.RetryPolicy{ServiceID: serviceID, MaxAttempts: 3, BackoffSeconds: 60, MaxBackoffSeconds: 600, RetryOn: database.RetryOnError}
+	errored := &conductorv1.RunComplete{Status: conductorv1.RunStatus_RUN_STATUS_ERROR}
+
+	t.Run("schedules the next attempt", func(t *testing.T) {
+		run := &database.TestRun{ID: uuid.New(), ServiceID: serviceID, GitSHA: &gitSHA, Attempt: 1, Priority: 5}
+		w, runRepo := newScheduler(run, []database.RetryPolicy{policy})
+
+		var retry *database.TestRun
+		runRepo.On("Create", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			retry = args.Get(1).(*database.TestRun)
+		}).Return(nil)
+
+		require.NoError(t, w.HandleRunComplete(ctx, uuid.New(), run.ID, nil, errored))
+		require.NotNil(t, retry)
+		assert.Equal(t, 2, retry.Attempt)
+		assert.Equal(t, run.ID, *retry.RetryOfRunID)
+		assert.Equal(t, database.RunStatusPending, retry.Status)
+		assert.Equal(t, &gitSHA, retry.GitSHA)
+		assert.Equal(t, 5, retry.Priority)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), *retry.NotBefore, 5*time.Second)
+	})
+
+	t.Run("stops at max attempts", func(t *testing.T) {
+		run := &database.TestRun{ID: uuid.New(), ServiceID: serviceID, Attempt: 3}
+		w, runRepo := newScheduler(run, []database.RetryPolicy{policy})
+
+		require.NoError(t, w.HandleRunComplete(ctx, uuid.New(), run.ID, nil, errored))
+		runRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("passed runs are not retried", func(t *testing.T) {
+		run := &database.TestRun{ID: uuid.New(), ServiceID: serviceID, Attempt: 1}
+		w, runRepo := newScheduler(run, []database.RetryPolicy{policy})
+
+		passed := &conductorv1.RunComplete{Status: conductorv1.RunStatus_RUN_STATUS_PASSED}
+		require.NoError(t, w.HandleRunComplete(ctx, uuid.New(), run.ID, nil, passed))
+		runRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	})
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/idempotency"
+	"github.com/conductor/conductor/pkg/tracing"
 )
 
 // ScheduleRequest contains parameters for scheduling a new test run.
@@ -23,6 +25,17 @@ type ScheduleRequest struct {
 	Priority    int
 	TestIDs     []uuid.UUID // Optional: specific tests to run
 	Tags        []string    // Optional: filter tests by tags
+
+	// PullRequestNumber is the PR/MR that triggered the run (optional).
+	PullRequestNumber int64
+
+	// LabelSelector lists the labels an agent must carry to run the run (optional).
+	LabelSelector map[string]string
+
+	// IdempotencyKey identifies the request across retries, such as the
+	// webhook delivery that triggered the run (optional). Requests with
+	// the same key for the service return the run the first one created.
+	IdempotencyKey string
 }
 
 // AgentManager defines the interface for agent management operations.
@@ -41,6 +54,7 @@ type AgentInfo struct {
 	ActiveRuns      int
 	DockerAvailable bool
 	LastHeartbeat   time.Time
+	Labels          map[string]string
 }
 
 // AvailableSlots returns the number of additional runs this agent can accept.
@@ -79,6 +93,7 @@ type Scheduler struct {
 	agentMgr    AgentManager
 	queue       *Queue
 	logger      *slog.Logger
+	idempotency *idempotency.Store
 
 	mu           sync.RWMutex
 	running      bool
@@ -144,6 +159,12 @@ func NewScheduler(
 	}
 }
 
+// SetIdempotency makes requests with an idempotency key schedule at most
+// one run.
+func (s *Scheduler) SetIdempotency(store *idempotency.Store) {
+	s.idempotency = store
+}
+
 // ScheduleRun creates a new test run and queues it for execution.
 func (s *Scheduler) ScheduleRun(ctx context.Context, req ScheduleRequest) (*database.TestRun, error) {
 	// Validate service exists
@@ -155,22 +176,48 @@ func (s *Scheduler) ScheduleRun(ctx context.Context, req ScheduleRequest) (*data
 		return nil, fmt.Errorf("service not found: %s", req.ServiceID)
 	}
 
+	// Retries with the key of a request return the run it created
+	fingerprint := req
+	fingerprint.IdempotencyKey = ""
+	run, replayed, err := idempotency.Do(ctx, s.idempotency, "runs.schedule:"+req.ServiceID.String(), req.IdempotencyKey, fingerprint,
+		func() (*database.TestRun, error) {
+			return s.createRun(ctx, service, req)
+		})
+	if err != nil {
+		return nil, err
+	}
+	if replayed {
+		s.logger.Info("test run already scheduled",
+			"run_id", run.ID,
+			"service_id", run.ServiceID,
+			"idempotency_key", req.IdempotencyKey,
+		)
+	}
+	return run, nil
+}
+
+// createRun creates a test run of an existing service and queues it.
+func (s *Scheduler) createRun(ctx context.Context, service *database.Service, req ScheduleRequest) (*database.TestRun, error) {
 	// Create the test run record
 	run := &database.TestRun{
-		ID:          uuid.New(),
-		ServiceID:   req.ServiceID,
-		Status:      database.RunStatusPending,
-		GitRef:      database.NullString(req.GitRef),
-		GitSHA:      database.NullString(req.GitSHA),
-		TriggerType: &req.TriggerType,
-		TriggeredBy: database.NullString(req.TriggeredBy),
-		Priority:    req.Priority,
-		CreatedAt:   time.Now().UTC(),
+		ID:                uuid.New(),
+		ServiceID:         req.ServiceID,
+		Status:            database.RunStatusPending,
+		GitRef:            database.NullString(req.GitRef),
+		GitSHA:            database.NullString(req.GitSHA),
+		TriggerType:       &req.TriggerType,
+		TriggeredBy:       database.NullString(req.TriggeredBy),
+		Priority:          req.Priority,
+		CreatedAt:         time.Now().UTC(),
+		PullRequestNumber: database.NullInt64(req.PullRequestNumber),
+		LabelSelector:     req.LabelSelector,
+		TraceParent:       database.NullString(tracing.TraceParent(ctx)),
 	}
 
 	if err := s.runRepo.Create(ctx, run); err != nil {
 		return nil, fmt.Errorf("failed to create test run: %w", err)
 	}
+	tracing.AddSpanAttributes(ctx, tracing.AttrRunID.String(run.ID.String()))
 
 	s.logger.Info("created test run",
 		"run_id", run.ID,
@@ -265,9 +312,10 @@ func (s *Scheduler) RetryRun(ctx context.Context, runID uuid.UUID) (*database.Te
 	}
 
 	req := ScheduleRequest{
-		ServiceID:   original.ServiceID,
-		TriggerType: triggerType,
-		Priority:    original.Priority,
+		ServiceID:     original.ServiceID,
+		TriggerType:   triggerType,
+		Priority:      original.Priority,
+		LabelSelector: original.LabelSelector,
 	}
 
 	if original.GitRef != nil {
@@ -445,13 +493,23 @@ func (s *Scheduler) processWorkItem(ctx context.Context, item *WorkItem) error {
 	}
 
 	shard, shardTestList := nextPendingShard(shards, shardTests)
+	if shard == nil && hasPendingShards(shards) {
+		s.logger.Debug("pending shards wait for an earlier stage", "run_id", run.ID)
+		return nil
+	}
 	if shard == nil {
 		s.logger.Debug("no pending shards, removing from queue", "run_id", run.ID)
 		return s.queue.Remove(ctx, item.RunID)
 	}
 
-	// Match run to best agent
-	agent := s.matchAgent(run, agents)
+	// Match run to best agent among those satisfying the shard's label selector
+	var candidates []*AgentInfo
+	for _, agent := range agents {
+		if shardMatchesArch(shard, agent.Labels) && shardMatchesMatrix(shard, agent.Labels) && shardMatchesLabels(run, shardTestList, agent.Labels) {
+			candidates = append(candidates, agent)
+		}
+	}
+	agent := s.matchAgent(run, candidates)
 	if agent == nil {
 		s.logger.Debug("no suitable agent found", "run_id", item.RunID)
 		return nil
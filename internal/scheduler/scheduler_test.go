@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/idempotency"
 )
 
 // MockRunRepo is a mock implementation of database.TestRunRepository.
@@ -41,6 +42,24 @@ func (m *MockRunRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status dat
 	return args.Error(0)
 }
 
+func (m *MockRunRepo) Approve(ctx context.Context, id uuid.UUID, approvedBy string) error {
+	args := m.Called(ctx, id, approvedBy)
+	return args.Error(0)
+}
+
+func (m *MockRunRepo) Retarget(ctx context.Context, id uuid.UUID, gitSHA *string) error {
+	args := m.Called(ctx, id, gitSHA)
+	return args.Error(0)
+}
+
+func (m *MockRunRepo) Annotate(ctx context.Context, id uuid.UUID, metadata database.RunMetadata) (*database.RunMetadata, error) {
+	args := m.Called(ctx, id, metadata)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*database.RunMetadata), args.Error(1)
+}
+
 func (m *MockRunRepo) Start(ctx context.Context, id uuid.UUID, agentID uuid.UUID) error {
 	args := m.Called(ctx, id, agentID)
 	return args.Error(0)
@@ -51,6 +70,11 @@ func (m *MockRunRepo) Finish(ctx context.Context, id uuid.UUID, status database.
 	return args.Error(0)
 }
 
+func (m *MockRunRepo) FinishActive(ctx context.Context, id uuid.UUID, status database.RunStatus, results database.RunResults) error {
+	args := m.Called(ctx, id, status, results)
+	return args.Error(0)
+}
+
 func (m *MockRunRepo) UpdateShardStats(ctx context.Context, id uuid.UUID, completed int, failed int, results database.RunResults) error {
 	args := m.Called(ctx, id, completed, failed, results)
 	return args.Error(0)
@@ -81,6 +105,16 @@ func (m *MockRunRepo) ListByDateRange(ctx context.Context, start, end time.Time,
 	return args.Get(0).([]database.TestRun), args.Error(1)
 }
 
+func (m *MockRunRepo) ListByAgent(ctx context.Context, agentID uuid.UUID, start, end time.Time, page database.Pagination) ([]database.TestRun, error) {
+	args := m.Called(ctx, agentID, start, end, page)
+	return args.Get(0).([]database.TestRun), args.Error(1)
+}
+
+func (m *MockRunRepo) Search(ctx context.Context, filter database.RunSearchFilter, page database.Pagination) ([]database.TestRun, int, error) {
+	args := m.Called(ctx, filter, page)
+	return args.Get(0).([]database.TestRun), args.Int(1), args.Error(2)
+}
+
 func (m *MockRunRepo) GetPending(ctx context.Context, limit int) ([]database.TestRun, error) {
 	args := m.Called(ctx, limit)
 	return args.Get(0).([]database.TestRun), args.Error(1)
@@ -101,6 +135,16 @@ func (m *MockRunRepo) CountByStatus(ctx context.Context) (map[database.RunStatus
 	return args.Get(0).(map[database.RunStatus]int64), args.Error(1)
 }
 
+func (m *MockRunRepo) ReserveResults(ctx context.Context, id uuid.UUID, n, limit int) (int, int64, error) {
+	args := m.Called(ctx, id, n, limit)
+	return args.Int(0), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRunRepo) ReserveArtifacts(ctx context.Context, id uuid.UUID, n, limit int) (int, int64, error) {
+	args := m.Called(ctx, id, n, limit)
+	return args.Int(0), args.Get(1).(int64), args.Error(2)
+}
+
 // MockServiceRepo is a mock implementation of database.ServiceRepository.
 type MockServiceRepo struct {
 	mock.Mock
@@ -218,9 +262,9 @@ func (m *MockAgentRepo) GetAvailable(ctx context.Context, zones []string, limit
 	return args.Get(0).([]database.Agent), args.Error(1)
 }
 
-func (m *MockAgentRepo) MarkOfflineAgents(ctx context.Context) (int64, error) {
-	args := m.Called(ctx)
-	return args.Get(0).(int64), args.Error(1)
+func (m *MockAgentRepo) MarkOfflineAgents(ctx context.Context, timeout time.Duration) ([]database.Agent, error) {
+	args := m.Called(ctx, timeout)
+	return args.Get(0).([]database.Agent), args.Error(1)
 }
 
 func (m *MockAgentRepo) CountByStatus(ctx context.Context) (map[database.AgentStatus]int64, error) {
@@ -289,6 +333,11 @@ func (m *MockTestRepo) ListByTags(ctx context.Context, serviceID uuid.UUID, tags
 	return args.Get(0).([]database.TestDefinition), args.Error(1)
 }
 
+func (m *MockTestRepo) ApplySync(ctx context.Context, sync *database.TestDefinitionSync) error {
+	args := m.Called(ctx, sync)
+	return args.Error(0)
+}
+
 // MockRunShardRepo is a mock implementation of RunShardRepository.
 type MockRunShardRepo struct {
 	mock.Mock
@@ -327,6 +376,11 @@ func (m *MockRunShardRepo) Finish(ctx context.Context, id uuid.UUID, status data
 	return args.Error(0)
 }
 
+func (m *MockRunShardRepo) FinishRunning(ctx context.Context, id uuid.UUID, agentID uuid.UUID, status database.ShardStatus, results database.RunResults) error {
+	args := m.Called(ctx, id, agentID, status, results)
+	return args.Error(0)
+}
+
 func (m *MockRunShardRepo) Reset(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -337,6 +391,31 @@ func (m *MockRunShardRepo) DeleteByRun(ctx context.Context, runID uuid.UUID) err
 	return args.Error(0)
 }
 
+func (m *MockRunShardRepo) RequeueOrphaned(ctx context.Context) ([]database.RunShard, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]database.RunShard), args.Error(1)
+}
+
+// memoryIdempotencyRepo keeps idempotency keys in memory.
+type memoryIdempotencyRepo struct {
+	database.IdempotencyKeyRepository
+	keys map[string]*database.IdempotencyKey
+}
+
+func (r *memoryIdempotencyRepo) Claim(ctx context.Context, key *database.IdempotencyKey) (*database.IdempotencyKey, error) {
+	if existing, ok := r.keys[key.Scope+"/"+key.Key]; ok {
+		return existing, nil
+	}
+	claimed := *key
+	r.keys[key.Scope+"/"+key.Key] = &claimed
+	return nil, nil
+}
+
+func (r *memoryIdempotencyRepo) Complete(ctx context.Context, scope, key string, response []byte, expiresAt time.Time) error {
+	r.keys[scope+"/"+key].Response = response
+	return nil
+}
+
 func TestScheduler_ScheduleRun(t *testing.T) {
 	ctx := context.Background()
 	serviceID := uuid.New()
@@ -395,6 +474,43 @@ func TestScheduler_ScheduleRun(t *testing.T) {
 		mockRunRepo.AssertExpectations(t)
 	})
 
+	t.Run("returns the run of a retried request", func(t *testing.T) {
+		mockRunRepo := new(MockRunRepo)
+		mockServiceRepo := new(MockServiceRepo)
+		queue := NewQueue(mockRunRepo)
+
+		scheduler := NewScheduler(
+			mockRunRepo,
+			mockServiceRepo,
+			new(MockAgentRepo),
+			new(MockTestRepo),
+			new(MockRunShardRepo),
+			new(MockAgentManager),
+			queue,
+			nil,
+			DefaultConfig(),
+		)
+		scheduler.SetIdempotency(idempotency.NewStore(&memoryIdempotencyRepo{keys: make(map[string]*database.IdempotencyKey)}, time.Hour, nil))
+
+		mockServiceRepo.On("Get", ctx, serviceID).Return(service, nil)
+		mockRunRepo.On("Create", ctx, mock.AnythingOfType("*database.TestRun")).Return(nil).Once()
+
+		req := ScheduleRequest{
+			ServiceID:      serviceID,
+			GitRef:         "main",
+			TriggerType:    triggerType,
+			IdempotencyKey: "github:delivery-1",
+		}
+		first, err := scheduler.ScheduleRun(ctx, req)
+		require.NoError(t, err)
+		retry, err := scheduler.ScheduleRun(ctx, req)
+		require.NoError(t, err)
+
+		assert.Equal(t, first.ID, retry.ID)
+		assert.Equal(t, 1, queue.Len(), "a retry must not queue the run again")
+		mockRunRepo.AssertExpectations(t)
+	})
+
 	t.Run("returns error when service not found", func(t *testing.T) {
 		mockRunRepo := new(MockRunRepo)
 		mockServiceRepo := new(MockServiceRepo)
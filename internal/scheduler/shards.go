@@ -3,10 +3,19 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/placement"
 )
 
+// ensureShards creates the shards of a run on first use and returns them
+// with the tests each one runs, aligned by position. Tests requesting
+// architectures or declaring a matrix fan out into one set of shards per
+// architecture and matrix combination; the other tests run once on any
+// architecture. Tests depending on other tests get shards of a later
+// pipeline stage.
 func ensureShards(ctx context.Context, run *database.TestRun, tests []database.TestDefinition, shardRepo database.RunShardRepository) ([]database.RunShard, [][]database.TestDefinition, error) {
 	if shardRepo == nil {
 		return nil, nil, fmt.Errorf("shard repository not configured")
@@ -17,32 +26,192 @@ func ensureShards(ctx context.Context, run *database.TestRun, tests []database.T
 		shardCount = 1
 	}
 
-	shardTests := splitTests(tests, shardCount)
-
 	shards, err := shardRepo.ListByRun(ctx, run.ID)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to list shards: %w", err)
 	}
-	if len(shards) > 0 {
-		return shards, shardTests, nil
+	if len(shards) == 0 {
+		for _, variant := range shardVariants(tests) {
+			variantTests := splitTests(testsForVariant(tests, variant), shardCount)
+			for i := 0; i < shardCount; i++ {
+				shard := database.RunShard{
+					RunID:      run.ID,
+					ShardIndex: i,
+					ShardCount: shardCount,
+					Status:     database.ShardStatusPending,
+					TotalTests: len(variantTests[i]),
+					Arch:       database.NullString(variant.arch),
+					Matrix:     variant.cell,
+					Stage:      variant.stage,
+				}
+				if err := shardRepo.Create(ctx, &shard); err != nil {
+					return nil, nil, fmt.Errorf("failed to create shard %d: %w", i, err)
+				}
+				shards = append(shards, shard)
+			}
+		}
+	}
+
+	shardTests := make([][]database.TestDefinition, len(shards))
+	for i := range shards {
+		shardTests[i] = testsForShard(tests, &shards[i])
 	}
+	return shards, shardTests, nil
+}
 
-	shards = make([]database.RunShard, 0, shardCount)
-	for i := 0; i < shardCount; i++ {
-		shard := database.RunShard{
-			RunID:      run.ID,
-			ShardIndex: i,
-			ShardCount: shardCount,
-			Status:     database.ShardStatusPending,
-			TotalTests: len(shardTests[i]),
+// shardArchitectures returns the architectures a run's shards fan out
+// over: "" for tests that run on any architecture, then every requested
+// architecture in order of first request.
+func shardArchitectures(tests []database.TestDefinition) []string {
+	var archs []string
+	anyArch := len(tests) == 0
+	seen := make(map[string]bool)
+	for _, test := range tests {
+		if len(test.Architectures) == 0 {
+			anyArch = true
+			continue
 		}
-		if err := shardRepo.Create(ctx, &shard); err != nil {
-			return nil, nil, fmt.Errorf("failed to create shard %d: %w", i, err)
+		for _, arch := range test.Architectures {
+			if !seen[arch] {
+				seen[arch] = true
+				archs = append(archs, arch)
+			}
 		}
-		shards = append(shards, shard)
 	}
 
-	return shards, shardTests, nil
+	if anyArch {
+		archs = append([]string{""}, archs...)
+	}
+	return archs
+}
+
+// testsForArch returns the tests that run on an architecture; "" selects
+// the tests that run on any architecture.
+func testsForArch(tests []database.TestDefinition, arch string) []database.TestDefinition {
+	var result []database.TestDefinition
+	for _, test := range tests {
+		if arch == "" && len(test.Architectures) == 0 || arch != "" && slices.Contains(test.Architectures, arch) {
+			result = append(result, test)
+		}
+	}
+	return result
+}
+
+// testsForStage returns the tests of a pipeline stage of database.TestStages.
+func testsForStage(tests []database.TestDefinition, stage int) []database.TestDefinition {
+	stages := database.TestStages(tests)
+	var result []database.TestDefinition
+	for _, test := range tests {
+		if stages[test.Name] == stage {
+			result = append(result, test)
+		}
+	}
+	return result
+}
+
+// shardStages returns the pipeline stages a run's shards fan out over, in
+// order; runs without dependencies between their tests have stage 0 only.
+func shardStages(tests []database.TestDefinition) []int {
+	stages := []int{0}
+	for _, stage := range database.TestStages(tests) {
+		for len(stages) <= stage {
+			stages = append(stages, len(stages))
+		}
+	}
+	return stages
+}
+
+// shardVariant is a pipeline stage, architecture and matrix combination a
+// run's shards fan out over.
+type shardVariant struct {
+	stage int
+	arch  string
+	cell  *database.MatrixCell
+}
+
+// shardVariants returns the variants a run's shards fan out over: per
+// pipeline stage and architecture of shardArchitectures, the tests without
+// a matrix, then every matrix combination in order of first declaration.
+func shardVariants(tests []database.TestDefinition) []shardVariant {
+	var variants []shardVariant
+	for _, stage := range shardStages(tests) {
+		stageTests := testsForStage(tests, stage)
+		for _, arch := range shardArchitectures(stageTests) {
+			archTests := testsForArch(stageTests, arch)
+			if len(archTests) == 0 || slices.ContainsFunc(archTests, func(test database.TestDefinition) bool {
+				return len(test.Matrix.Cells()) == 0
+			}) {
+				variants = append(variants, shardVariant{stage: stage, arch: arch})
+			}
+
+			seen := make(map[string]bool)
+			for _, test := range archTests {
+				for _, cell := range test.Matrix.Cells() {
+					if name := cell.Name(); !seen[name] {
+						seen[name] = true
+						variants = append(variants, shardVariant{stage: stage, arch: arch, cell: &cell})
+					}
+				}
+			}
+		}
+	}
+	return variants
+}
+
+// testsForVariant returns the tests of a pipeline stage that run on an
+// architecture with a matrix combination; a nil combination selects the
+// tests without a matrix.
+func testsForVariant(tests []database.TestDefinition, variant shardVariant) []database.TestDefinition {
+	var result []database.TestDefinition
+	for _, test := range testsForArch(testsForStage(tests, variant.stage), variant.arch) {
+		cells := test.Matrix.Cells()
+		if variant.cell == nil && len(cells) == 0 || variant.cell != nil && slices.ContainsFunc(cells, func(c database.MatrixCell) bool {
+			return c.Name() == variant.cell.Name()
+		}) {
+			result = append(result, test)
+		}
+	}
+	return result
+}
+
+// testsForShard returns the tests a shard runs.
+func testsForShard(tests []database.TestDefinition, shard *database.RunShard) []database.TestDefinition {
+	variant := shardVariant{stage: shard.Stage, cell: shard.Matrix}
+	if shard.Arch != nil {
+		variant.arch = *shard.Arch
+	}
+
+	shardTests := splitTests(testsForVariant(tests, variant), shard.ShardCount)
+	if shard.ShardIndex < 0 || shard.ShardIndex >= len(shardTests) {
+		return nil
+	}
+	return shardTests[shard.ShardIndex]
+}
+
+// shardMatchesArch reports whether an agent's labels satisfy the
+// architecture of a shard.
+func shardMatchesArch(shard *database.RunShard, labels map[string]string) bool {
+	return shard.Arch == nil || labels[placement.LabelArch] == *shard.Arch
+}
+
+// shardMatchesMatrix reports whether an agent's labels satisfy the labels
+// of the matrix combination of a shard.
+func shardMatchesMatrix(shard *database.RunShard, labels map[string]string) bool {
+	return shard.Matrix == nil || placement.LabelsMatch(shard.Matrix.Labels, labels)
+}
+
+// matrixEnvironment returns the environment of a test run with a matrix
+// combination, whose values override the test's own.
+func matrixEnvironment(env map[string]string, cell *database.MatrixCell) map[string]string {
+	if cell == nil || len(cell.Env) == 0 {
+		return env
+	}
+	merged := maps.Clone(env)
+	if merged == nil {
+		merged = make(map[string]string, len(cell.Env))
+	}
+	maps.Copy(merged, cell.Env)
+	return merged
 }
 
 func splitTests(tests []database.TestDefinition, shardCount int) [][]database.TestDefinition {
@@ -59,18 +228,40 @@ func splitTests(tests []database.TestDefinition, shardCount int) [][]database.Te
 }
 
 func nextPendingShard(shards []database.RunShard, shardTests [][]database.TestDefinition) (*database.RunShard, []database.TestDefinition) {
+	return nextMatchingShard(shards, shardTests, nil)
+}
+
+// nextMatchingShard returns the first pending shard whose stage can start
+// that, with its tests, satisfies match; a nil match accepts any shard.
+// shardTests holds the tests of each shard by position.
+func nextMatchingShard(shards []database.RunShard, shardTests [][]database.TestDefinition, match func(*database.RunShard, []database.TestDefinition) bool) (*database.RunShard, []database.TestDefinition) {
 	for i := range shards {
-		if shards[i].Status == database.ShardStatusPending {
-			var tests []database.TestDefinition
-			if shards[i].ShardIndex >= 0 && shards[i].ShardIndex < len(shardTests) {
-				tests = shardTests[shards[i].ShardIndex]
-			}
-			return &shards[i], tests
+		if shards[i].Status != database.ShardStatusPending || !stageReady(shards, shards[i].Stage) {
+			continue
 		}
+		var tests []database.TestDefinition
+		if i < len(shardTests) {
+			tests = shardTests[i]
+		}
+		if match != nil && !match(&shards[i], tests) {
+			continue
+		}
+		return &shards[i], tests
 	}
 	return nil, nil
 }
 
+// stageReady reports whether the shards of a pipeline stage can start,
+// that is whether every shard of an earlier stage passed.
+func stageReady(shards []database.RunShard, stage int) bool {
+	for _, shard := range shards {
+		if shard.Stage < stage && shard.Status != database.ShardStatusPassed {
+			return false
+		}
+	}
+	return true
+}
+
 func hasPendingShards(shards []database.RunShard) bool {
 	for _, shard := range shards {
 		if shard.Status == database.ShardStatusPending {
@@ -2,16 +2,29 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 
 	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
 	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/git"
+	"github.com/conductor/conductor/internal/placement"
+	"github.com/conductor/conductor/internal/secrets"
+	"github.com/conductor/conductor/internal/websocket"
+	"github.com/conductor/conductor/pkg/tracing"
 )
 
+// RunEventPublisher publishes run status changes to WebSocket clients.
+// websocket.Publisher implements it.
+type RunEventPublisher interface {
+	PublishRunUpdate(run websocket.RunEvent) error
+}
+
 // WorkScheduler assigns pending shards to agents.
 type WorkScheduler struct {
 	runRepo     database.TestRunRepository
@@ -19,6 +32,23 @@ type WorkScheduler struct {
 	testRepo    database.TestDefinitionRepository
 	shardRepo   database.RunShardRepository
 	logger      *slog.Logger
+
+	deployKeyRepo   database.DeployKeyRepository
+	deployKeyCipher *secrets.Cipher
+
+	environmentRepo database.ServiceEnvironmentRepository
+
+	retryPolicyRepo database.RetryPolicyRepository
+
+	poolRepo database.AgentPoolRepository
+
+	concurrencyRepo database.ConcurrencyGroupRepository
+
+	pauseRepo database.SchedulingPauseRepository
+
+	maintenanceRepo database.MaintenanceWindowRepository
+
+	events RunEventPublisher
 }
 
 // NewWorkScheduler creates a new WorkScheduler.
@@ -42,12 +72,64 @@ func NewWorkScheduler(
 	}
 }
 
-// AssignWork finds and assigns pending work to an agent.
-func (w *WorkScheduler) AssignWork(ctx context.Context, agentID uuid.UUID, capabilities *conductorv1.Capabilities) (*conductorv1.AssignWork, error) {
+// SetDeployKeys enables per-service SSH deploy keys. Assignments for services
+// with a stored key carry the decrypted key and an SSH clone URL.
+func (w *WorkScheduler) SetDeployKeys(repo database.DeployKeyRepository, cipher *secrets.Cipher) {
+	w.deployKeyRepo = repo
+	w.deployKeyCipher = cipher
+}
+
+// SetEnvironments enables per-service environment sets. Assignments carry
+// the service's variables and secret references; test definitions override
+// them per test.
+func (w *WorkScheduler) SetEnvironments(repo database.ServiceEnvironmentRepository) {
+	w.environmentRepo = repo
+}
+
+// SetEvents publishes run updates when runs start, progress, finish or are
+// cancelled.
+func (w *WorkScheduler) SetEvents(events RunEventPublisher) {
+	w.events = events
+}
+
+// AssignWork finds and assigns pending work to an agent. Only shards whose
+// service network zones the agent can reach, whose label selectors,
+// architecture and matrix labels its labels satisfy and whose service is
+// not pinned to another agent pool are assigned, and only while the agent's
+// pool is within its quotas and no other run holds the run's concurrency
+// group. Shards of a pipeline stage are assigned once every earlier stage
+// passed. Nothing is assigned while scheduling is paused, globally or for
+// the run's service, or during a maintenance window of the run's service or
+// the agent's pool. Runs are limited to the projects ctx is scoped to.
+func (w *WorkScheduler) AssignWork(ctx context.Context, agentID uuid.UUID, capabilities *conductorv1.Capabilities, labels map[string]string, pool string) (*conductorv1.AssignWork, error) {
 	if capabilities == nil {
 		return nil, nil
 	}
 
+	pauses, err := w.schedulingPauses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if pauses.global {
+		return nil, nil
+	}
+
+	windows, err := w.activeMaintenance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if windows.ForPool(pool) != nil {
+		return nil, nil
+	}
+
+	usage, err := w.poolUsage(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	if usage.full() {
+		return nil, nil
+	}
+
 	pendingRuns, err := w.runRepo.GetPending(ctx, 100)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list pending runs: %w", err)
@@ -61,6 +143,9 @@ func (w *WorkScheduler) AssignWork(ctx context.Context, agentID uuid.UUID, capab
 	runs := append(pendingRuns, runningRuns...)
 
 	for _, run := range runs {
+		if pauses.paused(run.ServiceID) || windows.ForService(run.ServiceID) != nil {
+			continue
+		}
 		service, err := w.serviceRepo.Get(ctx, run.ServiceID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get service: %w", err)
@@ -68,7 +153,17 @@ func (w *WorkScheduler) AssignWork(ctx context.Context, agentID uuid.UUID, capab
 		if service == nil {
 			continue
 		}
-		if !zonesMatch(service.NetworkZones, capabilities.NetworkZones) {
+		if !placement.ZonesMatch(service.NetworkZones, capabilities.NetworkZones) {
+			continue
+		}
+		if !poolMatches(service, pool) || usage.serviceFull(service.ID) {
+			continue
+		}
+		claimed, err := w.claimConcurrencyGroup(ctx, &run)
+		if err != nil {
+			return nil, err
+		}
+		if !claimed {
 			continue
 		}
 
@@ -82,12 +177,21 @@ func (w *WorkScheduler) AssignWork(ctx context.Context, agentID uuid.UUID, capab
 			return nil, fmt.Errorf("failed to ensure shards: %w", err)
 		}
 
-		shard, testsForShard := nextPendingShard(shards, shardTests)
+		shard, testsForShard := nextMatchingShard(shards, shardTests, func(shard *database.RunShard, tests []database.TestDefinition) bool {
+			return shardMatchesArch(shard, labels) && shardMatchesMatrix(shard, labels) && shardMatchesLabels(&run, tests, labels)
+		})
 		if shard == nil {
 			continue
 		}
 
 		assignment := buildAssignWork(service, &run, shard, testsForShard)
+		traceAssignment(ctx, agentID, &run, assignment)
+		if err := w.attachGitCredentials(ctx, service, assignment); err != nil {
+			return nil, err
+		}
+		if err := w.attachEnvironment(ctx, service, assignment); err != nil {
+			return nil, err
+		}
 		return assignment, nil
 	}
 
@@ -99,6 +203,7 @@ func (w *WorkScheduler) CancelWork(ctx context.Context, runID uuid.UUID, reason
 	if err := w.runRepo.UpdateStatus(ctx, runID, database.RunStatusCancelled); err != nil {
 		return fmt.Errorf("failed to cancel run: %w", err)
 	}
+	w.publishRun(ctx, runID)
 	return nil
 }
 
@@ -113,6 +218,7 @@ func (w *WorkScheduler) HandleWorkAccepted(ctx context.Context, agentID uuid.UUI
 	if err := w.runRepo.Start(ctx, runID, agentID); err != nil {
 		return fmt.Errorf("failed to start run: %w", err)
 	}
+	w.publishRun(ctx, runID)
 	return nil
 }
 
@@ -126,22 +232,40 @@ func (w *WorkScheduler) HandleWorkRejected(ctx context.Context, agentID uuid.UUI
 	return nil
 }
 
-// HandleRunComplete handles run or shard completion.
+// HandleRunComplete handles run or shard completion. Agents replay the
+// completions they could not deliver, so a completion of a run or shard that
+// has finished, or of a shard requeued since, changes nothing.
 func (w *WorkScheduler) HandleRunComplete(ctx context.Context, agentID uuid.UUID, runID uuid.UUID, shardID *uuid.UUID, result *conductorv1.RunComplete) error {
 	if result == nil {
 		return nil
 	}
 
 	if shardID != nil {
-		return w.finishShard(ctx, runID, *shardID, result)
+		return w.finishShard(ctx, agentID, runID, *shardID, result)
+	}
+
+	status := runStatusFromProto(result.Status)
+	if err := w.runRepo.FinishActive(ctx, runID, status, runResultsFromProto(result)); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			w.logger.Info("ignoring completion of finished run", "run_id", runID, "agent_id", agentID)
+			return nil
+		}
+		return err
 	}
+	w.publishRun(ctx, runID)
 
-	return w.runRepo.Finish(ctx, runID, runStatusFromProto(result.Status), runResultsFromProto(result))
+	w.retryRun(ctx, runID, status, nil)
+	return nil
 }
 
-func (w *WorkScheduler) finishShard(ctx context.Context, runID uuid.UUID, shardID uuid.UUID, result *conductorv1.RunComplete) error {
+func (w *WorkScheduler) finishShard(ctx context.Context, agentID uuid.UUID, runID uuid.UUID, shardID uuid.UUID, result *conductorv1.RunComplete) error {
 	status := shardStatusFromProto(result.Status)
-	if err := w.shardRepo.Finish(ctx, shardID, status, runResultsFromProto(result)); err != nil {
+	if err := w.shardRepo.FinishRunning(ctx, shardID, agentID, status, runResultsFromProto(result)); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			w.logger.Info("ignoring completion of shard not running on the agent",
+				"run_id", runID, "shard_id", shardID, "agent_id", agentID)
+			return nil
+		}
 		return fmt.Errorf("failed to finish shard: %w", err)
 	}
 
@@ -149,6 +273,9 @@ func (w *WorkScheduler) finishShard(ctx context.Context, runID uuid.UUID, shardI
 	if err != nil {
 		return fmt.Errorf("failed to list shards: %w", err)
 	}
+	if err := w.skipBlockedShards(ctx, shards); err != nil {
+		return err
+	}
 
 	completed, failed, results, finished := aggregateShardResults(shards)
 	if err := w.runRepo.UpdateShardStats(ctx, runID, completed, failed, results); err != nil {
@@ -157,34 +284,167 @@ func (w *WorkScheduler) finishShard(ctx context.Context, runID uuid.UUID, shardI
 
 	if finished {
 		status := runStatusFromShardStatus(shards)
-		if err := w.runRepo.Finish(ctx, runID, status, results); err != nil {
+		// A run that finished meanwhile, such as a cancelled one, keeps its
+		// status and is not retried again
+		err := w.runRepo.FinishActive(ctx, runID, status, results)
+		if err != nil && !errors.Is(err, database.ErrNotFound) {
 			return fmt.Errorf("failed to finish run: %w", err)
 		}
+		w.publishRun(ctx, runID)
+		if err == nil {
+			w.retryRun(ctx, runID, status, shards)
+		}
+		return nil
 	}
 
+	w.publishRun(ctx, runID)
+	return nil
+}
+
+// skipBlockedShards cancels the pending shards of later stages once a shard
+// of an earlier stage failed, updating shards in place.
+func (w *WorkScheduler) skipBlockedShards(ctx context.Context, shards []database.RunShard) error {
+	for _, blocked := range database.BlockedShards(shards) {
+		results := database.RunResults{ErrorMessage: "skipped after an earlier stage failed"}
+		if err := w.shardRepo.Finish(ctx, blocked.ID, database.ShardStatusCancelled, results); err != nil {
+			return fmt.Errorf("failed to skip shard: %w", err)
+		}
+		for i := range shards {
+			if shards[i].ID == blocked.ID {
+				shards[i].Status = database.ShardStatusCancelled
+				shards[i].ErrorMessage = &results.ErrorMessage
+			}
+		}
+	}
 	return nil
 }
 
-func zonesMatch(serviceZones, agentZones []string) bool {
-	if len(serviceZones) == 0 || len(agentZones) == 0 {
-		return true
+// publishRun publishes the current state of a run, if events are enabled.
+// Publishing is best effort and never fails the transition.
+func (w *WorkScheduler) publishRun(ctx context.Context, runID uuid.UUID) {
+	if w.events == nil {
+		return
 	}
-	set := make(map[string]struct{}, len(agentZones))
-	for _, zone := range agentZones {
-		set[zone] = struct{}{}
+
+	run, err := w.runRepo.Get(ctx, runID)
+	if err != nil {
+		w.logger.Warn("failed to load run for update", "run_id", runID, "error", err)
+		return
+	}
+
+	event := websocket.RunEvent{
+		RunID:        run.ID,
+		ServiceID:    run.ServiceID,
+		Status:       string(run.Status),
+		TotalTests:   run.TotalTests,
+		PassedTests:  run.PassedTests,
+		FailedTests:  run.FailedTests,
+		SkippedTests: run.SkippedTests,
+		DurationMs:   run.DurationMs,
+		ErrorMessage: run.ErrorMessage,
+		StartedAt:    run.StartedAt,
+		FinishedAt:   run.FinishedAt,
+	}
+	if err := w.events.PublishRunUpdate(event); err != nil {
+		w.logger.Warn("failed to publish run update", "run_id", runID, "error", err)
 	}
-	for _, zone := range serviceZones {
-		if _, ok := set[zone]; ok {
-			return true
+}
+
+// traceAssignment records the assignment of work as a span of the trace the
+// run was created in, linked to the span of the agent's work stream, and
+// passes the span on to the agent, which continues the trace.
+func traceAssignment(ctx context.Context, agentID uuid.UUID, run *database.TestRun, assignment *conductorv1.AssignWork) {
+	opts := []trace.SpanStartOption{tracing.WithAttributes(
+		tracing.AttrRunID.String(assignment.RunId),
+		tracing.AttrShardID.String(assignment.ShardId),
+		tracing.AttrServiceID.String(run.ServiceID.String()),
+		tracing.AttrAgentID.String(agentID.String()),
+	)}
+	parent := ctx
+	if run.TraceParent != nil {
+		parent = tracing.ContextWithTraceParent(ctx, *run.TraceParent)
+		opts = append(opts, trace.WithLinks(trace.LinkFromContext(ctx)))
+	}
+
+	spanCtx, span := tracing.StartSpan(parent, "scheduler.AssignWork", opts...)
+	defer span.End()
+	assignment.TraceParent = tracing.TraceParent(spanCtx)
+}
+
+// attachGitCredentials adds the service deploy key to an assignment, if any.
+func (w *WorkScheduler) attachGitCredentials(ctx context.Context, service *database.Service, assignment *conductorv1.AssignWork) error {
+	if w.deployKeyRepo == nil || w.deployKeyCipher == nil {
+		return nil
+	}
+
+	key, err := w.deployKeyRepo.GetByService(ctx, service.ID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil
 		}
+		return fmt.Errorf("failed to get deploy key: %w", err)
+	}
+
+	privateKey, err := w.deployKeyCipher.Decrypt(key.EncryptedPrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt deploy key for service %s: %w", service.ID, err)
+	}
+
+	assignment.GitCredentials = &conductorv1.GitCredentials{
+		SshPrivateKey: string(privateKey),
+	}
+	if key.KnownHosts != nil {
+		assignment.GitCredentials.KnownHosts = *key.KnownHosts
+	}
+	if assignment.GitRef != nil {
+		assignment.GitRef.RepositoryUrl = git.SSHCloneURL(assignment.GitRef.RepositoryUrl)
 	}
-	return false
+
+	return nil
+}
+
+// attachEnvironment adds the service environment set to an assignment, if any.
+func (w *WorkScheduler) attachEnvironment(ctx context.Context, service *database.Service, assignment *conductorv1.AssignWork) error {
+	if w.environmentRepo == nil {
+		return nil
+	}
+
+	env, err := w.environmentRepo.GetByService(ctx, service.ID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get service environment: %w", err)
+	}
+
+	assignment.Environment = env.Environment
+	assignment.Secrets = secretRefsToProto(env.Secrets)
+	return nil
+}
+
+// shardMatchesLabels reports whether agent labels satisfy the label selector
+// of a run combined with the selectors of the tests in one of its shards.
+func shardMatchesLabels(run *database.TestRun, tests []database.TestDefinition, labels map[string]string) bool {
+	selectors := make([]map[string]string, 0, len(tests)+1)
+	selectors = append(selectors, run.LabelSelector)
+	for _, test := range tests {
+		selectors = append(selectors, test.LabelSelector)
+	}
+
+	selector, err := placement.MergeSelectors(selectors...)
+	if err != nil {
+		return false
+	}
+	return placement.LabelsMatch(selector, labels)
 }
 
 func buildAssignWork(service *database.Service, run *database.TestRun, shard *database.RunShard, tests []database.TestDefinition) *conductorv1.AssignWork {
 	protoTests := make([]*conductorv1.TestToRun, 0, len(tests))
 	for _, test := range tests {
-		protoTests = append(protoTests, testDefinitionToProto(test))
+		protoTest := TestDefinitionToProto(test)
+		protoTest.Environment = matrixEnvironment(protoTest.Environment, shard.Matrix)
+		applyParameters(protoTest, test, run.Parameters)
+		protoTests = append(protoTests, protoTest)
 	}
 
 	execType := determineExecutionType(tests)
@@ -193,6 +453,7 @@ func buildAssignWork(service *database.Service, run *database.TestRun, shard *da
 		GitRef:           gitRefFromRun(service, run),
 		Tests:            protoTests,
 		ExecutionType:    execType,
+		ContainerImage:   determineContainerImage(tests),
 		Priority:         int32(run.Priority),
 		ShardId:          shard.ID.String(),
 		ShardIndex:       int32(shard.ShardIndex),
@@ -211,22 +472,36 @@ func gitRefFromRun(service *database.Service, run *database.TestRun) *conductorv
 	if run.GitSHA != nil {
 		ref.CommitSha = *run.GitSHA
 	}
+	if run.PullRequestNumber != nil {
+		ref.PullRequestNumber = *run.PullRequestNumber
+	}
 	return ref
 }
 
-func testDefinitionToProto(def database.TestDefinition) *conductorv1.TestToRun {
+// TestDefinitionToProto converts a test definition to the test an agent
+// runs.
+func TestDefinitionToProto(def database.TestDefinition) *conductorv1.TestToRun {
 	command := def.Command
 	if len(def.Args) > 0 {
 		command = strings.TrimSpace(command + " " + strings.Join(def.Args, " "))
 	}
 
 	proto := &conductorv1.TestToRun{
-		TestId:        def.ID.String(),
-		Name:          def.Name,
-		Command:       command,
-		ResultFormat:  resultFormatToProto(def.ResultFormat),
-		ArtifactPaths: def.ArtifactPatterns,
-		RetryCount:    int32(def.Retries),
+		TestId:         def.ID.String(),
+		Name:           def.Name,
+		Command:        command,
+		ResultFormat:   resultFormatToProto(def.ResultFormat),
+		ArtifactPaths:  def.ArtifactPatterns,
+		RetryCount:     int32(def.Retries),
+		CachePaths:     def.CachePaths,
+		Environment:    def.Environment,
+		Secrets:        secretRefsToProto(def.Secrets),
+		ArtifactBudget: artifactBudgetToProto(def),
+		CapturePaths:   def.CapturePatterns,
+	}
+
+	if def.CacheKey != nil {
+		proto.CacheKey = *def.CacheKey
 	}
 
 	if def.TimeoutSeconds > 0 {
@@ -236,6 +511,53 @@ func testDefinitionToProto(def database.TestDefinition) *conductorv1.TestToRun {
 	return proto
 }
 
+// artifactBudgetToProto returns the artifact budget of a test definition,
+// or nil if its artifacts are unlimited.
+func artifactBudgetToProto(def database.TestDefinition) *conductorv1.ArtifactBudget {
+	if def.ArtifactMaxBytes == nil && def.ArtifactMaxFiles == nil {
+		return nil
+	}
+
+	budget := &conductorv1.ArtifactBudget{}
+	if def.ArtifactMaxBytes != nil {
+		budget.MaxBytes = *def.ArtifactMaxBytes
+	}
+	if def.ArtifactMaxFiles != nil {
+		budget.MaxFiles = int32(*def.ArtifactMaxFiles)
+	}
+	return budget
+}
+
+func secretRefsToProto(refs []database.SecretRef) []*conductorv1.Secret {
+	if len(refs) == 0 {
+		return nil
+	}
+	result := make([]*conductorv1.Secret, 0, len(refs))
+	for _, ref := range refs {
+		result = append(result, &conductorv1.Secret{
+			Name:     ref.Name,
+			Provider: secretProviderToProto(ref.Provider),
+			Path:     ref.Path,
+			Key:      ref.Key,
+			Version:  int32(ref.Version),
+		})
+	}
+	return result
+}
+
+func secretProviderToProto(provider string) conductorv1.SecretProvider {
+	switch provider {
+	case string(secrets.ProviderVault):
+		return conductorv1.SecretProvider_SECRET_PROVIDER_VAULT
+	case string(secrets.ProviderAWS):
+		return conductorv1.SecretProvider_SECRET_PROVIDER_AWS_SECRETS_MANAGER
+	case string(secrets.ProviderEnvFile):
+		return conductorv1.SecretProvider_SECRET_PROVIDER_ENV_FILE
+	default:
+		return conductorv1.SecretProvider_SECRET_PROVIDER_UNSPECIFIED
+	}
+}
+
 func resultFormatToProto(format *string) conductorv1.ResultFormat {
 	if format == nil {
 		return conductorv1.ResultFormat_RESULT_FORMAT_UNSPECIFIED
@@ -267,6 +589,17 @@ func determineExecutionType(tests []database.TestDefinition) conductorv1.Executi
 	return conductorv1.ExecutionType_EXECUTION_TYPE_SUBPROCESS
 }
 
+// determineContainerImage returns the image of the first container test;
+// a shard runs all of its tests in one container.
+func determineContainerImage(tests []database.TestDefinition) string {
+	for _, test := range tests {
+		if strings.EqualFold(test.ExecutionType, "container") && test.ContainerImage != nil {
+			return *test.ContainerImage
+		}
+	}
+	return ""
+}
+
 func runStatusFromProto(status conductorv1.RunStatus) database.RunStatus {
 	switch status {
 	case conductorv1.RunStatus_RUN_STATUS_PASSED:
@@ -335,7 +668,7 @@ func aggregateShardResults(shards []database.RunShard) (completed int, failed in
 		if shard.Status == database.ShardStatusFailed || shard.Status == database.ShardStatusError || shard.Status == database.ShardStatusCancelled {
 			failed++
 			if results.ErrorMessage == "" && shard.ErrorMessage != nil {
-				results.ErrorMessage = *shard.ErrorMessage
+				results.ErrorMessage = shard.FailureMessage()
 			}
 		}
 
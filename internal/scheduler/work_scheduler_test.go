@@ -0,0 +1,392 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/websocket"
+	"github.com/conductor/conductor/pkg/tracing"
+)
+
+func TestShardMatchesLabels(t *testing.T) {
+	run := &database.TestRun{LabelSelector: map[string]string{"os": "windows"}}
+	gpuTest := database.TestDefinition{Name: "render", LabelSelector: map[string]string{"gpu": "true"}}
+	linuxTest := database.TestDefinition{Name: "unit", LabelSelector: map[string]string{"os": "linux"}}
+
+	assert.True(t, shardMatchesLabels(run, []database.TestDefinition{gpuTest}, map[string]string{"os": "windows", "gpu": "true"}))
+	assert.False(t, shardMatchesLabels(run, []database.TestDefinition{gpuTest}, map[string]string{"os": "windows"}))
+	assert.False(t, shardMatchesLabels(run, []database.TestDefinition{linuxTest}, map[string]string{"os": "linux"}), "conflicting selectors")
+	assert.True(t, shardMatchesLabels(&database.TestRun{}, nil, nil))
+}
+
+func TestNextMatchingShard(t *testing.T) {
+	shards := []database.RunShard{
+		{ShardIndex: 0, Status: database.ShardStatusRunning},
+		{ShardIndex: 1, Status: database.ShardStatusPending},
+		{ShardIndex: 2, Status: database.ShardStatusPending},
+	}
+	shardTests := [][]database.TestDefinition{
+		{{Name: "a"}},
+		{{Name: "b", LabelSelector: map[string]string{"gpu": "true"}}},
+		{{Name: "c"}},
+	}
+	run := &database.TestRun{}
+
+	shard, tests := nextMatchingShard(shards, shardTests, func(_ *database.RunShard, tests []database.TestDefinition) bool {
+		return shardMatchesLabels(run, tests, map[string]string{"os": "linux"})
+	})
+	require.NotNil(t, shard)
+	assert.Equal(t, 2, shard.ShardIndex)
+	assert.Equal(t, "c", tests[0].Name)
+
+	shard, _ = nextPendingShard(shards, shardTests)
+	require.NotNil(t, shard)
+	assert.Equal(t, 1, shard.ShardIndex)
+}
+
+func TestEnsureShards_Architectures(t *testing.T) {
+	ctx := context.Background()
+	run := &database.TestRun{ID: uuid.New(), ShardCount: 2}
+	tests := []database.TestDefinition{
+		{Name: "lint"},
+		{Name: "unit", Architectures: []string{"amd64", "arm64"}},
+		{Name: "simd", Architectures: []string{"arm64"}},
+	}
+
+	shardRepo := new(MockRunShardRepo)
+	shardRepo.On("ListByRun", ctx, run.ID).Return([]database.RunShard{}, nil)
+	shardRepo.On("Create", ctx, mock.Anything).Return(nil)
+
+	shards, shardTests, err := ensureShards(ctx, run, tests, shardRepo)
+	require.NoError(t, err)
+	require.Len(t, shards, 6)
+	require.Len(t, shardTests, 6)
+
+	names := func(tests []database.TestDefinition) []string {
+		var result []string
+		for _, test := range tests {
+			result = append(result, test.Name)
+		}
+		return result
+	}
+
+	assert.Nil(t, shards[0].Arch)
+	assert.Equal(t, []string{"lint"}, names(shardTests[0]))
+	assert.Empty(t, shardTests[1])
+	assert.Equal(t, "amd64", *shards[2].Arch)
+	assert.Equal(t, []string{"unit"}, names(shardTests[2]))
+	assert.Equal(t, "arm64", *shards[4].Arch)
+	assert.Equal(t, 0, shards[4].ShardIndex)
+	assert.Equal(t, []string{"unit"}, names(shardTests[4]))
+	assert.Equal(t, []string{"simd"}, names(shardTests[5]))
+	assert.Equal(t, 1, shards[5].ShardIndex)
+	assert.Equal(t, 1, shards[5].TotalTests)
+
+	// Existing shards map back to the same tests.
+	assert.Equal(t, shardTests[5], testsForShard(tests, &shards[5]))
+}
+
+func TestShardArchitectures(t *testing.T) {
+	assert.Equal(t, []string{""}, shardArchitectures(nil))
+	assert.Equal(t, []string{""}, shardArchitectures([]database.TestDefinition{{Name: "unit"}}))
+	assert.Equal(t, []string{"arm64", "amd64"}, shardArchitectures([]database.TestDefinition{
+		{Name: "simd", Architectures: []string{"arm64"}},
+		{Name: "unit", Architectures: []string{"amd64", "arm64"}},
+	}))
+}
+
+func TestShardMatchesArch(t *testing.T) {
+	arm := "arm64"
+
+	assert.True(t, shardMatchesArch(&database.RunShard{}, nil))
+	assert.True(t, shardMatchesArch(&database.RunShard{Arch: &arm}, map[string]string{"arch": "arm64"}))
+	assert.False(t, shardMatchesArch(&database.RunShard{Arch: &arm}, map[string]string{"arch": "amd64"}))
+	assert.False(t, shardMatchesArch(&database.RunShard{Arch: &arm}, nil))
+}
+
+func TestAggregateShardResults_ArchFailure(t *testing.T) {
+	arm := "arm64"
+	shards := []database.RunShard{
+		{Status: database.ShardStatusPassed, PassedTests: 3, TotalTests: 3},
+		{Status: database.ShardStatusFailed, FailedTests: 1, TotalTests: 3, Arch: &arm, ErrorMessage: database.NullString("illegal instruction")},
+	}
+
+	completed, failed, results, finished := aggregateShardResults(shards)
+	assert.True(t, finished)
+	assert.Equal(t, 2, completed)
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, "arm64: illegal instruction", results.ErrorMessage)
+	assert.Equal(t, database.RunStatusFailed, runStatusFromShardStatus(shards))
+}
+
+func TestEnsureShards_Matrix(t *testing.T) {
+	ctx := context.Background()
+	run := &database.TestRun{ID: uuid.New(), ShardCount: 1}
+	matrix := &database.TestMatrix{
+		Env:    map[string][]string{"GO_VERSION": {"1.22", "1.23"}},
+		Labels: map[string][]string{"os": {"linux"}},
+	}
+	tests := []database.TestDefinition{
+		{Name: "lint"},
+		{Name: "unit", Matrix: matrix, Environment: map[string]string{"CGO_ENABLED": "0", "GO_VERSION": "1.21"}},
+		{Name: "race", Matrix: matrix, Architectures: []string{"arm64"}},
+	}
+
+	shardRepo := new(MockRunShardRepo)
+	shardRepo.On("ListByRun", ctx, run.ID).Return([]database.RunShard{}, nil)
+	shardRepo.On("Create", ctx, mock.Anything).Return(nil)
+
+	shards, shardTests, err := ensureShards(ctx, run, tests, shardRepo)
+	require.NoError(t, err)
+	require.Len(t, shards, 5)
+
+	assert.Nil(t, shards[0].Matrix)
+	assert.Len(t, shardTests[0], 1)
+	assert.Equal(t, "lint", shardTests[0][0].Name)
+	assert.Equal(t, "GO_VERSION=1.22, os=linux", shards[1].Matrix.Name())
+	assert.Equal(t, "unit", shardTests[1][0].Name)
+	assert.Equal(t, "GO_VERSION=1.23, os=linux", shards[2].Matrix.Name())
+	assert.Equal(t, "arm64", *shards[3].Arch)
+	assert.Equal(t, "GO_VERSION=1.22, os=linux", shards[3].Matrix.Name())
+	assert.Equal(t, "race", shardTests[3][0].Name)
+	assert.Equal(t, shardTests[4], testsForShard(tests, &shards[4]))
+
+	assert.False(t, shardMatchesMatrix(&shards[1], map[string]string{"os": "windows"}))
+	assert.True(t, shardMatchesMatrix(&shards[1], map[string]string{"os": "linux"}))
+	assert.True(t, shardMatchesMatrix(&shards[0], nil))
+
+	// Matrix values override the test's environment without changing it
+	assignment := buildAssignWork(&database.Service{}, run, &shards[2], shardTests[2])
+	require.Len(t, assignment.Tests, 1)
+	assert.Equal(t, map[string]string{"CGO_ENABLED": "0", "GO_VERSION": "1.23"}, assignment.Tests[0].Environment)
+	assert.Equal(t, "1.21", tests[1].Environment["GO_VERSION"])
+}
+
+func TestEnsureShards_Stages(t *testing.T) {
+	ctx := context.Background()
+	run := &database.TestRun{ID: uuid.New(), ShardCount: 1}
+	tests := []database.TestDefinition{
+		{Name: "e2e", DependsOn: []string{"integration"}},
+		{Name: "unit"},
+		{Name: "lint"},
+		{Name: "integration", DependsOn: []string{"unit"}},
+	}
+
+	shardRepo := new(MockRunShardRepo)
+	shardRepo.On("ListByRun", ctx, run.ID).Return([]database.RunShard{}, nil)
+	shardRepo.On("Create", ctx, mock.Anything).Return(nil)
+
+	shards, shardTests, err := ensureShards(ctx, run, tests, shardRepo)
+	require.NoError(t, err)
+	require.Len(t, shards, 3)
+
+	for stage, names := range [][]string{{"unit", "lint"}, {"integration"}, {"e2e"}} {
+		assert.Equal(t, stage, shards[stage].Stage)
+		var got []string
+		for _, test := range shardTests[stage] {
+			got = append(got, test.Name)
+		}
+		assert.ElementsMatch(t, names, got)
+	}
+
+	// Later stages wait for the earlier ones to pass
+	shard, _ := nextPendingShard(shards, shardTests)
+	require.NotNil(t, shard)
+	assert.Equal(t, 0, shard.Stage)
+	shards[0].Status = database.ShardStatusRunning
+	shard, _ = nextPendingShard(shards, shardTests)
+	assert.Nil(t, shard)
+	shards[0].Status = database.ShardStatusPassed
+	shard, _ = nextPendingShard(shards, shardTests)
+	require.NotNil(t, shard)
+	assert.Equal(t, 1, shard.Stage)
+}
+
+func TestFinishShard_SkipsLaterStages(t *testing.T) {
+	ctx := context.Background()
+	runID := uuid.New()
+	shards := []database.RunShard{
+		{ID: uuid.New(), RunID: runID, Stage: 0, Status: database.ShardStatusFailed, ErrorMessage: database.NullString("1 test failed")},
+		{ID: uuid.New(), RunID: runID, Stage: 1, Status: database.ShardStatusPending},
+		{ID: uuid.New(), RunID: runID, Stage: 2, Status: database.ShardStatusPending},
+	}
+
+	agentID := uuid.New()
+	shardRepo := new(MockRunShardRepo)
+	shardRepo.On("FinishRunning", ctx, shards[0].ID, agentID, database.ShardStatusFailed, mock.Anything).Return(nil)
+	shardRepo.On("Finish", ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	shardRepo.On("ListByRun", ctx, runID).Return(shards, nil)
+	runRepo := new(MockRunRepo)
+	runRepo.On("UpdateShardStats", ctx, runID, 3, 3, mock.Anything).Return(nil)
+	runRepo.On("FinishActive", ctx, runID, database.RunStatusFailed, mock.MatchedBy(func(results database.RunResults) bool {
+		return results.ErrorMessage == "1 test failed"
+	})).Return(nil)
+
+	w := NewWorkScheduler(runRepo, nil, nil, shardRepo, nil)
+	failed := &conductorv1.RunComplete{Status: conductorv1.RunStatus_RUN_STATUS_FAILED}
+	require.NoError(t, w.HandleRunComplete(ctx, agentID, runID, &shards[0].ID, failed))
+
+	shardRepo.AssertCalled(t, "Finish", ctx, shards[1].ID, database.ShardStatusCancelled, mock.Anything)
+	shardRepo.AssertCalled(t, "Finish", ctx, shards[2].ID, database.ShardStatusCancelled, mock.Anything)
+	runRepo.AssertExpectations(t)
+}
+
+func TestHandleRunComplete_IgnoresReplayedCompletions(t *testing.T) {
+	ctx := context.Background()
+	runID := uuid.New()
+	agentID := uuid.New()
+	passed := &conductorv1.RunComplete{Status: conductorv1.RunStatus_RUN_STATUS_PASSED}
+
+	t.Run("shard not running on the agent", func(t *testing.T) {
+		shardID := uuid.New()
+		shardRepo := new(MockRunShardRepo)
+		shardRepo.On("FinishRunning", ctx, shardID, agentID, database.ShardStatusPassed, mock.Anything).Return(database.ErrNotFound)
+		runRepo := new(MockRunRepo)
+
+		w := NewWorkScheduler(runRepo, nil, nil, shardRepo, nil)
+		require.NoError(t, w.HandleRunComplete(ctx, agentID, runID, &shardID, passed))
+		shardRepo.AssertNotCalled(t, "ListByRun", mock.Anything, mock.Anything)
+		runRepo.AssertNotCalled(t, "FinishActive", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("finished run", func(t *testing.T) {
+		runRepo := new(MockRunRepo)
+		runRepo.On("FinishActive", ctx, runID, database.RunStatusPassed, mock.Anything).Return(database.ErrNotFound)
+
+		w := NewWorkScheduler(runRepo, nil, nil, nil, nil)
+		require.NoError(t, w.HandleRunComplete(ctx, agentID, runID, nil, passed))
+		runRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	})
+
+	t.Run("run finished while its last shard ran", func(t *testing.T) {
+		shardID := uuid.New()
+		shards := []database.RunShard{{ID: shardID, RunID: runID, Status: database.ShardStatusPassed}}
+		shardRepo := new(MockRunShardRepo)
+		shardRepo.On("FinishRunning", ctx, shardID, agentID, database.ShardStatusPassed, mock.Anything).Return(nil)
+		shardRepo.On("ListByRun", ctx, runID).Return(shards, nil)
+		runRepo := new(MockRunRepo)
+		runRepo.On("UpdateShardStats", ctx, runID, 1, 0, mock.Anything).Return(nil)
+		runRepo.On("FinishActive", ctx, runID, database.RunStatusPassed, mock.Anything).Return(database.ErrNotFound)
+
+		w := NewWorkScheduler(runRepo, nil, nil, shardRepo, nil)
+		require.NoError(t, w.HandleRunComplete(ctx, agentID, runID, &shardID, passed))
+		runRepo.AssertExpectations(t)
+		runRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAggregateShardResults_MatrixFailure(t *testing.T) {
+	arm := "arm64"
+	shards := []database.RunShard{
+		{Status: database.ShardStatusPassed, PassedTests: 3, TotalTests: 3},
+		{
+			Status:       database.ShardStatusFailed,
+			FailedTests:  1,
+			TotalTests:   3,
+			Arch:         &arm,
+			Matrix:       &database.MatrixCell{Env: map[string]string{"GO_VERSION": "1.23"}},
+			ErrorMessage: database.NullString("data race"),
+		},
+	}
+
+	_, _, results, _ := aggregateShardResults(shards)
+	assert.Equal(t, "arm64, GO_VERSION=1.23: data race", results.ErrorMessage)
+}
+
+func TestDetermineContainerImage(t *testing.T) {
+	image := "mcr.microsoft.com/playwright:v1.40.0"
+	tests := []database.TestDefinition{
+		{Name: "unit", ExecutionType: "subprocess", ContainerImage: database.NullString("ignored:latest")},
+		{Name: "e2e", ExecutionType: "container", ContainerImage: &image},
+	}
+
+	assert.Equal(t, image, determineContainerImage(tests))
+	assert.Equal(t, "", determineContainerImage(tests[:1]))
+}
+
+type MockRunEventPublisher struct {
+	mock.Mock
+}
+
+func (m *MockRunEventPublisher) PublishRunUpdate(run websocket.RunEvent) error {
+	args := m.Called(run)
+	return args.Error(0)
+}
+
+func TestWorkScheduler_PublishesRunUpdates(t *testing.T) {
+	ctx := context.Background()
+	agentID := uuid.New()
+	run := &database.TestRun{ID: uuid.New(), ServiceID: uuid.New(), Status: database.RunStatusRunning, TotalTests: 3, PassedTests: 2}
+
+	runRepo := new(MockRunRepo)
+	runRepo.On("Start", ctx, run.ID, agentID).Return(nil)
+	runRepo.On("UpdateStatus", ctx, run.ID, database.RunStatusCancelled).Return(nil)
+	runRepo.On("Get", ctx, run.ID).Return(run, nil)
+
+	events := new(MockRunEventPublisher)
+	events.On("PublishRunUpdate", mock.Anything).Return(nil)
+
+	w := NewWorkScheduler(runRepo, nil, nil, nil, nil)
+	w.SetEvents(events)
+
+	require.NoError(t, w.HandleWorkAccepted(ctx, agentID, run.ID, nil))
+	require.NoError(t, w.CancelWork(ctx, run.ID, "no longer needed"))
+
+	events.AssertNumberOfCalls(t, "PublishRunUpdate", 2)
+	events.AssertCalled(t, "PublishRunUpdate", websocket.RunEvent{
+		RunID:       run.ID,
+		ServiceID:   run.ServiceID,
+		Status:      "running",
+		TotalTests:  3,
+		PassedTests: 2,
+	})
+}
+
+func TestTraceAssignment(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	// The run was created in the span of a webhook delivery
+	webhookCtx, webhookSpan := tracing.StartSpan(context.Background(), "webhook")
+	webhookSpan.End()
+	traceParent := tracing.TraceParent(webhookCtx)
+	streamCtx, streamSpan := tracing.StartSpan(context.Background(), "work stream")
+	defer streamSpan.End()
+
+	run := &database.TestRun{ID: uuid.New(), ServiceID: uuid.New(), TraceParent: &traceParent}
+	assignment := &conductorv1.AssignWork{RunId: run.ID.String(), ShardId: uuid.New().String()}
+	traceAssignment(streamCtx, uuid.New(), run, assignment)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	span := spans[1]
+	assert.Equal(t, "scheduler.AssignWork", span.Name())
+	assert.Equal(t, webhookSpan.SpanContext().TraceID(), span.SpanContext().TraceID())
+	assert.Equal(t, webhookSpan.SpanContext().SpanID(), span.Parent().SpanID())
+	require.Len(t, span.Links(), 1)
+	assert.Equal(t, streamSpan.SpanContext().TraceID(), span.Links()[0].SpanContext.TraceID())
+	assert.Contains(t, span.Attributes(), tracing.AttrRunID.String(run.ID.String()))
+
+	continued := tracing.ContextWithTraceParent(context.Background(), assignment.TraceParent)
+	assert.Equal(t, span.SpanContext().SpanID().String(), tracing.SpanID(continued))
+
+	// Runs created without a trace are traced from the work stream
+	assignment = &conductorv1.AssignWork{RunId: uuid.New().String()}
+	traceAssignment(streamCtx, uuid.New(), &database.TestRun{}, assignment)
+	spans = recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, streamSpan.SpanContext().TraceID(), spans[2].SpanContext().TraceID())
+	assert.Empty(t, spans[2].Links())
+}
@@ -0,0 +1,213 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// AWSConfig configures an AWS Secrets Manager-backed secret store.
+// Empty credentials fall back to the standard AWS_* environment variables.
+type AWSConfig struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Endpoint overrides the regional endpoint, e.g. for LocalStack.
+	Endpoint string
+	Timeout  time.Duration
+}
+
+// AWSStore resolves secrets from AWS Secrets Manager.
+type AWSStore struct {
+	region          string
+	endpoint        string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	client          *http.Client
+	now             func() time.Time
+}
+
+// NewAWSStore creates a new AWS Secrets Manager store.
+func NewAWSStore(cfg AWSConfig) (*AWSStore, error) {
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_REGION")
+	}
+	if cfg.Region == "" {
+		return nil, errors.New("aws region is required")
+	}
+	if cfg.AccessKeyID == "" {
+		cfg.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		cfg.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		cfg.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("aws access key id and secret access key are required")
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", cfg.Region)
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	return &AWSStore{
+		region:          cfg.Region,
+		endpoint:        strings.TrimRight(endpoint, "/"),
+		accessKeyID:     cfg.AccessKeyID,
+		secretAccessKey: cfg.SecretAccessKey,
+		sessionToken:    cfg.SessionToken,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+		},
+		now: time.Now,
+	}, nil
+}
+
+// Resolve fetches a secret value from AWS Secrets Manager. Path is the
+// secret ID or ARN. When Key is set, the secret string is parsed as a JSON
+// object and the key's value is returned; otherwise the whole string is.
+func (s *AWSStore) Resolve(ctx context.Context, ref Reference) (string, error) {
+	if ref.Path == "" {
+		return "", errors.New("secret path is required")
+	}
+
+	if ref.Version > 0 {
+		return "", errors.New("aws secrets manager does not support numeric versions")
+	}
+
+	body, err := json.Marshal(map[string]string{"SecretId": ref.Path})
+	if err != nil {
+		return "", fmt.Errorf("encode aws request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("create aws request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	s.sign(req, body)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("aws request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var awsErr struct {
+			Type string `json:"__type"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&awsErr)
+		if awsErr.Type != "" {
+			return "", fmt.Errorf("aws returned status %d: %s", resp.StatusCode, awsErr.Type)
+		}
+		return "", fmt.Errorf("aws returned status %d", resp.StatusCode)
+	}
+
+	var payload struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", fmt.Errorf("decode aws response: %w", err)
+	}
+	if payload.SecretString == nil {
+		return "", fmt.Errorf("aws secret %s has no string value", ref.Path)
+	}
+
+	if ref.Key == "" {
+		return *payload.SecretString, nil
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal([]byte(*payload.SecretString), &data); err != nil {
+		return "", fmt.Errorf("aws secret %s is not a JSON object", ref.Path)
+	}
+	value, ok := data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("aws key not found: %s", ref.Key)
+	}
+	stringValue, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("aws key %s is not a string", ref.Key)
+	}
+	return stringValue, nil
+}
+
+// sign adds AWS Signature Version 4 headers to the request.
+func (s *AWSStore) sign(req *http.Request, body []byte) {
+	const service = "secretsmanager"
+
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	// Headers must be listed in sorted order
+	names := []string{"content-type", "host", "x-amz-date", "x-amz-target"}
+	if s.sessionToken != "" {
+		names = []string{"content-type", "host", "x-amz-date", "x-amz-security-token", "x-amz-target"}
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = io.WriteString(mac, data)
+	return mac.Sum(nil)
+}
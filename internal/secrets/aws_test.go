@@ -0,0 +1,62 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAWSStoreResolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			t.Errorf("unexpected target %q", r.Header.Get("X-Amz-Target"))
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			t.Errorf("missing signature: %q", r.Header.Get("Authorization"))
+		}
+
+		var input struct {
+			SecretId string
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+
+		switch input.SecretId {
+		case "prod/db":
+			_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"password":"hunter2"}`})
+		case "prod/token":
+			_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": "plain-token"})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException"}`))
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewAWSStore(AWSConfig{
+		Region:          "eu-north-1",
+		Endpoint:        server.URL,
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewAWSStore: %v", err)
+	}
+
+	got, err := store.Resolve(context.Background(), Reference{Path: "prod/db", Key: "password"})
+	if err != nil || got != "hunter2" {
+		t.Fatalf("json key: got %q (%v)", got, err)
+	}
+
+	got, err = store.Resolve(context.Background(), Reference{Path: "prod/token"})
+	if err != nil || got != "plain-token" {
+		t.Fatalf("plain string: got %q (%v)", got, err)
+	}
+
+	_, err = store.Resolve(context.Background(), Reference{Path: "missing"})
+	if err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnvFileConfig configures a sealed env-file secret store.
+type EnvFileConfig struct {
+	// Dir is the directory containing sealed env files.
+	Dir string
+	// Key is the base64-encoded 32-byte key the files are sealed with.
+	Key string
+}
+
+// EnvFileStore resolves secrets from sealed env files: KEY=VALUE files
+// encrypted with a Cipher and base64-encoded, see SealEnvFile.
+type EnvFileStore struct {
+	dir    string
+	cipher *Cipher
+}
+
+// NewEnvFileStore creates a new sealed env-file store.
+func NewEnvFileStore(cfg EnvFileConfig) (*EnvFileStore, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("env file directory is required")
+	}
+	c, err := NewCipherFromBase64(cfg.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid env file key: %w", err)
+	}
+
+	return &EnvFileStore{
+		dir:    cfg.Dir,
+		cipher: c,
+	}, nil
+}
+
+// Resolve reads a variable from a sealed env file. Path is the file name
+// relative to the store directory and Key is the variable name.
+func (s *EnvFileStore) Resolve(ctx context.Context, ref Reference) (string, error) {
+	if ref.Path == "" {
+		return "", errors.New("secret path is required")
+	}
+	if ref.Key == "" {
+		return "", errors.New("secret key is required")
+	}
+
+	clean := filepath.Clean(ref.Path)
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("env file path must be relative to the secrets directory: %s", ref.Path)
+	}
+
+	sealed, err := os.ReadFile(filepath.Join(s.dir, clean))
+	if err != nil {
+		return "", fmt.Errorf("read env file: %w", err)
+	}
+
+	vars, err := OpenEnvFile(s.cipher, sealed)
+	if err != nil {
+		return "", fmt.Errorf("open env file %s: %w", ref.Path, err)
+	}
+
+	value, ok := vars[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("env file key not found: %s", ref.Key)
+	}
+	return value, nil
+}
+
+// SealEnvFile encrypts env file content for use with EnvFileStore.
+func SealEnvFile(c *Cipher, plaintext []byte) ([]byte, error) {
+	if _, err := ParseEnvFile(plaintext); err != nil {
+		return nil, err
+	}
+	sealed, err := c.Encrypt(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(sealed) + "\n"), nil
+}
+
+// OpenEnvFile decrypts and parses a sealed env file.
+func OpenEnvFile(c *Cipher, sealed []byte) (map[string]string, error) {
+	raw, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sealed)))
+	if err != nil {
+		return nil, fmt.Errorf("decode sealed env file: %w", err)
+	}
+	plaintext, err := c.Decrypt(raw)
+	if err != nil {
+		return nil, err
+	}
+	return ParseEnvFile(plaintext)
+}
+
+// ParseEnvFile parses KEY=VALUE lines. Blank lines and lines starting with
+// # are ignored, an optional "export " prefix is allowed, and values may be
+// wrapped in single or double quotes.
+func ParseEnvFile(content []byte) (map[string]string, error) {
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNo)
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
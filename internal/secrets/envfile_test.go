@@ -0,0 +1,60 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvFileStoreResolve(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{3}, KeySize))
+	c, err := NewCipherFromBase64(key)
+	if err != nil {
+		t.Fatalf("NewCipherFromBase64: %v", err)
+	}
+
+	sealed, err := SealEnvFile(c, []byte("# staging\nexport DB_PASSWORD=\"s3cret\"\nAPI_KEY=abc=123\n"))
+	if err != nil {
+		t.Fatalf("SealEnvFile: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("s3cret")) {
+		t.Fatal("sealed file contains plaintext")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "staging.env"), sealed, 0600); err != nil {
+		t.Fatalf("write env file: %v", err)
+	}
+
+	store, err := NewEnvFileStore(EnvFileConfig{Dir: dir, Key: key})
+	if err != nil {
+		t.Fatalf("NewEnvFileStore: %v", err)
+	}
+
+	tests := map[string]string{"DB_PASSWORD": "s3cret", "API_KEY": "abc=123"}
+	for name, want := range tests {
+		got, err := store.Resolve(context.Background(), Reference{Path: "staging.env", Key: name})
+		if err != nil {
+			t.Fatalf("Resolve %s: %v", name, err)
+		}
+		if got != want {
+			t.Errorf("Resolve %s = %q, want %q", name, got, want)
+		}
+	}
+
+	if _, err := store.Resolve(context.Background(), Reference{Path: "staging.env", Key: "MISSING"}); err == nil {
+		t.Error("expected error for missing key")
+	}
+	if _, err := store.Resolve(context.Background(), Reference{Path: "../staging.env", Key: "API_KEY"}); err == nil {
+		t.Error("expected error for path outside the secrets directory")
+	}
+}
+
+func TestParseEnvFileRejectsMalformedLines(t *testing.T) {
+	if _, err := ParseEnvFile([]byte("VALID=1\nnot a pair\n")); err == nil {
+		t.Fatal("expected error for malformed line")
+	}
+}
@@ -0,0 +1,133 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Resolver routes references to the store registered for their provider,
+// caches resolved values, and writes an audit log entry for every
+// resolution. Secret values are never logged.
+type Resolver struct {
+	stores          map[Provider]Store
+	defaultProvider Provider
+	ttl             time.Duration
+	logger          zerolog.Logger
+
+	mu    sync.Mutex
+	cache map[string]cachedSecret
+	now   func() time.Time
+}
+
+// cachedSecret is a resolved value and when it expires.
+type cachedSecret struct {
+	value     string
+	expiresAt time.Time
+}
+
+// NewResolver creates a resolver over the given stores. References without
+// a provider use defaultProvider. A zero ttl disables caching.
+func NewResolver(stores map[Provider]Store, defaultProvider Provider, ttl time.Duration, logger zerolog.Logger) *Resolver {
+	return &Resolver{
+		stores:          stores,
+		defaultProvider: defaultProvider,
+		ttl:             ttl,
+		logger:          logger.With().Str("component", "secrets").Logger(),
+		cache:           make(map[string]cachedSecret),
+		now:             time.Now,
+	}
+}
+
+// Providers returns the providers the resolver can serve.
+func (r *Resolver) Providers() []Provider {
+	providers := make([]Provider, 0, len(r.stores))
+	for p := range r.stores {
+		providers = append(providers, p)
+	}
+	return providers
+}
+
+// Serves reports whether a store is registered for the reference's
+// provider, or for the default provider if the reference names none.
+func (r *Resolver) Serves(ref Reference) bool {
+	if ref.Provider == "" {
+		ref.Provider = r.defaultProvider
+	}
+	_, ok := r.stores[ref.Provider]
+	return ok
+}
+
+// Resolve resolves a reference using the store for its provider.
+func (r *Resolver) Resolve(ctx context.Context, ref Reference) (string, error) {
+	if ref.Provider == "" {
+		ref.Provider = r.defaultProvider
+	}
+
+	start := r.now()
+	value, cached, err := r.resolve(ctx, ref)
+
+	event := r.logger.Info()
+	if err != nil {
+		event = r.logger.Warn().Err(err)
+	}
+	event.
+		Str("audit", "secret_resolved").
+		Str("secret_name", ref.Name).
+		Str("provider", string(ref.Provider)).
+		Str("path", ref.Path).
+		Str("key", ref.Key).
+		Int("version", ref.Version).
+		Bool("cached", cached).
+		Bool("success", err == nil).
+		Dur("duration", r.now().Sub(start)).
+		Msg("Secret resolution")
+
+	return value, err
+}
+
+func (r *Resolver) resolve(ctx context.Context, ref Reference) (string, bool, error) {
+	store, ok := r.stores[ref.Provider]
+	if !ok {
+		return "", false, fmt.Errorf("secret provider %q is not configured", ref.Provider)
+	}
+
+	key := cacheKey(ref)
+	if r.ttl > 0 {
+		r.mu.Lock()
+		entry, hit := r.cache[key]
+		r.mu.Unlock()
+		if hit && r.now().Before(entry.expiresAt) {
+			return entry.value, true, nil
+		}
+	}
+
+	value, err := store.Resolve(ctx, ref)
+	if err != nil {
+		return "", false, err
+	}
+
+	if r.ttl > 0 {
+		r.mu.Lock()
+		r.cache[key] = cachedSecret{value: value, expiresAt: r.now().Add(r.ttl)}
+		r.mu.Unlock()
+	}
+
+	return value, false, nil
+}
+
+// Purge drops all cached values, including unexpired ones.
+func (r *Resolver) Purge() {
+	r.mu.Lock()
+	r.cache = make(map[string]cachedSecret)
+	r.mu.Unlock()
+}
+
+// cacheKey identifies a secret value independently of the env var it is
+// bound to.
+func cacheKey(ref Reference) string {
+	return fmt.Sprintf("%s|%s|%s|%d", ref.Provider, ref.Path, ref.Key, ref.Version)
+}
@@ -0,0 +1,97 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// countingStore returns a fixed value and counts resolutions.
+type countingStore struct {
+	value string
+	err   error
+	calls int
+}
+
+func (s *countingStore) Resolve(ctx context.Context, ref Reference) (string, error) {
+	s.calls++
+	return s.value, s.err
+}
+
+func TestResolverRoutesByProvider(t *testing.T) {
+	vault := &countingStore{value: "from-vault"}
+	aws := &countingStore{value: "from-aws"}
+	r := NewResolver(map[Provider]Store{ProviderVault: vault, ProviderAWS: aws}, ProviderVault, 0, zerolog.New(io.Discard))
+
+	got, err := r.Resolve(context.Background(), Reference{Name: "A", Path: "p"})
+	if err != nil || got != "from-vault" {
+		t.Fatalf("default provider: got %q (%v)", got, err)
+	}
+
+	got, err = r.Resolve(context.Background(), Reference{Name: "B", Provider: ProviderAWS, Path: "p"})
+	if err != nil || got != "from-aws" {
+		t.Fatalf("aws provider: got %q (%v)", got, err)
+	}
+
+	if _, err := r.Resolve(context.Background(), Reference{Provider: ProviderEnvFile, Path: "p"}); err == nil {
+		t.Fatal("expected error for unconfigured provider")
+	}
+}
+
+func TestResolverServes(t *testing.T) {
+	r := NewResolver(map[Provider]Store{ProviderVault: &countingStore{}}, ProviderVault, 0, zerolog.New(io.Discard))
+
+	if !r.Serves(Reference{Path: "p"}) {
+		t.Fatal("expected default provider to be served")
+	}
+	if !r.Serves(Reference{Provider: ProviderVault, Path: "p"}) {
+		t.Fatal("expected vault to be served")
+	}
+	if r.Serves(Reference{Provider: ProviderAWS, Path: "p"}) {
+		t.Fatal("expected aws not to be served")
+	}
+}
+
+func TestResolverCaches(t *testing.T) {
+	store := &countingStore{value: "v"}
+	r := NewResolver(map[Provider]Store{ProviderVault: store}, ProviderVault, time.Minute, zerolog.New(io.Discard))
+
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	ref := Reference{Path: "app/db", Key: "password"}
+	for i := 0; i < 3; i++ {
+		if _, err := r.Resolve(context.Background(), ref); err != nil {
+			t.Fatalf("Resolve: %v", err)
+		}
+	}
+	if store.calls != 1 {
+		t.Fatalf("expected 1 store call, got %d", store.calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := r.Resolve(context.Background(), ref); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if store.calls != 2 {
+		t.Fatalf("expected expired entry to be refreshed, got %d calls", store.calls)
+	}
+}
+
+func TestResolverDoesNotCacheErrors(t *testing.T) {
+	store := &countingStore{err: errors.New("boom")}
+	r := NewResolver(map[Provider]Store{ProviderVault: store}, ProviderVault, time.Minute, zerolog.New(io.Discard))
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.Resolve(context.Background(), Reference{Path: "p"}); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if store.calls != 2 {
+		t.Fatalf("expected errors not to be cached, got %d calls", store.calls)
+	}
+}
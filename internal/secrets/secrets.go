@@ -6,7 +6,9 @@ import "context"
 type Provider string
 
 const (
-	ProviderVault Provider = "vault"
+	ProviderVault   Provider = "vault"
+	ProviderAWS     Provider = "aws"
+	ProviderEnvFile Provider = "envfile"
 )
 
 // Reference identifies a single secret value in a store.
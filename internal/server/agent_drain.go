@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+// drainPollInterval is how often a draining replica checks whether its
+// agents have closed their work streams.
+const drainPollInterval = 100 * time.Millisecond
+
+// Drain moves the agents connected to this replica to the other replicas
+// before it shuts down. It stops accepting registrations and assigning work,
+// asks each agent to reconnect after a random delay of up to jitter, and
+// waits until the agents have closed their work streams, so that the results
+// they sent before are processed. It returns an error if agents are still
+// connected when ctx is done.
+func (s *AgentServiceServer) Drain(ctx context.Context, jitter time.Duration) error {
+	s.draining.Store(true)
+
+	agents := s.GetConnectedAgents()
+	s.logger.Info().Int("agents", len(agents)).Dur("jitter", jitter).Msg("draining agent work streams")
+
+	for _, agentID := range agents {
+		var delay time.Duration
+		if jitter > 0 {
+			delay = rand.N(jitter)
+		}
+		msg := &conductorv1.ControlMessage{
+			Message: &conductorv1.ControlMessage_Reconnect{
+				Reconnect: &conductorv1.Reconnect{
+					Reason: "control plane replica is shutting down",
+					Delay: &conductorv1.Duration{
+						Seconds: int64(delay.Seconds()),
+						Nanos:   int32(delay.Nanoseconds() % 1e9),
+					},
+				},
+			},
+		}
+		agent, ok := s.localAgent(agentID)
+		if !ok {
+			continue
+		}
+		agent.sendMu.Lock()
+		err := agent.stream.Send(msg)
+		agent.sendMu.Unlock()
+		if err != nil {
+			s.logger.Warn().Err(err).Str("agent_id", agentID.String()).Msg("failed to ask agent to reconnect")
+		}
+	}
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for {
+		remaining := len(s.GetConnectedAgents())
+		if remaining == 0 {
+			s.logger.Info().Msg("agent work streams drained")
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d agents still connected: %w", remaining, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
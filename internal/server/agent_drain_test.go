@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+func TestAgentServiceDrain(t *testing.T) {
+	agent := &database.Agent{ID: uuid.New(), Name: "agent-1", Status: database.AgentStatusIdle}
+	server := NewAgentServiceServer(AgentServiceDeps{
+		AgentRepo: &routingAgentRepo{detailAgentRepo{agent: agent}},
+		RunRepo:   &detailRunRepo{},
+	}, zerolog.Nop())
+
+	stream := &recordingStream{}
+	server.agents[agent.ID] = &connectedAgent{id: agent.ID, stream: stream, connectedAt: time.Now(), cancel: func() {}}
+
+	drained := make(chan error, 1)
+	go func() {
+		drained <- server.Drain(context.Background(), time.Second)
+	}()
+
+	// The agent is asked to reconnect within the jitter
+	var reconnect *conductorv1.Reconnect
+	require.Eventually(t, func() bool {
+		stream.mu.Lock()
+		defer stream.mu.Unlock()
+		if len(stream.sent) == 0 {
+			return false
+		}
+		reconnect = stream.sent[0].GetReconnect()
+		return true
+	}, time.Second, time.Millisecond)
+	require.NotNil(t, reconnect)
+	delay := time.Duration(reconnect.Delay.Seconds)*time.Second + time.Duration(reconnect.Delay.Nanos)
+	assert.Less(t, delay, time.Second)
+
+	t.Run("registrations are rejected while draining", func(t *testing.T) {
+		other := &recordingStream{}
+		_, err := server.handleRegister(context.Background(), other, &conductorv1.RegisterRequest{AgentId: uuid.NewString()})
+		assert.True(t, errcode.Is(err, errcode.Unavailable))
+		require.Len(t, other.sent, 1)
+		assert.False(t, other.sent[0].GetRegisterResponse().GetSuccess())
+		assert.Len(t, server.GetConnectedAgents(), 1)
+	})
+
+	select {
+	case err := <-drained:
+		t.Fatalf("drain returned before the agent disconnected: %v", err)
+	default:
+	}
+
+	// The agent closes its stream once it has flushed its results
+	server.disconnectAgent(agent.ID)
+	select {
+	case err := <-drained:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("drain did not return after the agent disconnected")
+	}
+}
+
+func TestAgentServiceDrain_Timeout(t *testing.T) {
+	agentID := uuid.New()
+	server := NewAgentServiceServer(AgentServiceDeps{}, zerolog.Nop())
+	server.agents[agentID] = &connectedAgent{id: agentID, stream: &recordingStream{}, connectedAt: time.Now(), cancel: func() {}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := server.Drain(ctx, 0)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "1 agents still connected")
+}
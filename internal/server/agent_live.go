@@ -0,0 +1,198 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+// maxQueuedWork bounds the unaccepted assignments tracked per agent, so
+// assignments an agent never answers cannot grow without limit.
+const maxQueuedWork = 100
+
+// queuedWork is work assigned to an agent that it has not accepted or
+// rejected yet.
+type queuedWork struct {
+	runID      string
+	shardID    string
+	shardIndex int32
+	shardCount int32
+	assignedAt time.Time
+}
+
+// agentLiveState is a snapshot of a connected agent's connection, last
+// heartbeat and unaccepted work.
+type agentLiveState struct {
+	connectedAt  time.Time
+	lastSeen     time.Time
+	status       conductorv1.AgentStatus
+	activeRunIDs []string
+	usage        *conductorv1.ResourceUsage
+	queued       []queuedWork
+}
+
+// liveAgentSource looks up the live state of connected agents and requests
+// their logs. AgentServiceServer implements it.
+type liveAgentSource interface {
+	liveAgent(ctx context.Context, agentID uuid.UUID) (*agentLiveState, bool)
+	requestLogs(ctx context.Context, agentID uuid.UUID, limit int32, minLevel string) (*conductorv1.AgentLogs, error)
+}
+
+// recordHeartbeat stores the state reported by a heartbeat and returns the
+// time since the previous one.
+func (a *connectedAgent) recordHeartbeat(hb *conductorv1.Heartbeat, now time.Time) time.Duration {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+
+	elapsed := now.Sub(a.lastSeen)
+	a.lastSeen = now
+	a.status = hb.Status
+	a.activeRunIDs = hb.ActiveRunIds
+	a.usage = hb.ResourceUsage
+	return elapsed
+}
+
+// queueWork records work sent to the agent until it is accepted or
+// rejected. The oldest assignment is dropped once maxQueuedWork is reached.
+func (a *connectedAgent) queueWork(work *conductorv1.AssignWork, now time.Time) {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+
+	if len(a.queued) >= maxQueuedWork {
+		a.queued = a.queued[1:]
+	}
+	a.queued = append(a.queued, queuedWork{
+		runID:      work.RunId,
+		shardID:    work.ShardId,
+		shardIndex: work.ShardIndex,
+		shardCount: work.ShardCount,
+		assignedAt: now,
+	})
+}
+
+// dequeueWork forgets an assignment the agent answered.
+func (a *connectedAgent) dequeueWork(runID, shardID string) {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+
+	for i, work := range a.queued {
+		if work.runID == runID && work.shardID == shardID {
+			a.queued = append(a.queued[:i:i], a.queued[i+1:]...)
+			return
+		}
+	}
+}
+
+// snapshot copies the agent's live state.
+func (a *connectedAgent) snapshot() *agentLiveState {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+
+	// Heartbeats are never modified after they are received, so the
+	// resource usage is shared rather than copied.
+	return &agentLiveState{
+		connectedAt:  a.connectedAt,
+		lastSeen:     a.lastSeen,
+		status:       a.status,
+		activeRunIDs: append([]string(nil), a.activeRunIDs...),
+		usage:        a.usage,
+		queued:       append([]queuedWork(nil), a.queued...),
+	}
+}
+
+// liveAgent returns the live state of a connected agent. The state of
+// agents connected to another replica is looked up on that replica.
+func (s *AgentServiceServer) liveAgent(ctx context.Context, agentID uuid.UUID) (*agentLiveState, bool) {
+	if agent, ok := s.localAgent(agentID); ok {
+		return agent.snapshot(), true
+	}
+
+	replica, err := s.replicaOf(ctx, agentID)
+	if err != nil {
+		if !errors.Is(err, errAgentNotConnected) {
+			s.logger.Warn().Err(err).Str("agent_id", agentID.String()).Msg("failed to find the replica of agent")
+		}
+		return nil, false
+	}
+	resp, err := replica.GetLiveAgent(ctx, &conductorv1.GetLiveAgentRequest{AgentId: agentID.String()})
+	if err != nil {
+		if !errors.Is(routedError(err), errAgentNotConnected) {
+			s.logger.Warn().Err(err).Str("agent_id", agentID.String()).Msg("failed to get live state of agent from its replica")
+		}
+		return nil, false
+	}
+	return liveStateFromProto(resp), true
+}
+
+// agentConnectionToProto converts the live state of an agent to its
+// connection state. Agents without live state are disconnected.
+func agentConnectionToProto(state *agentLiveState) *conductorv1.AgentConnection {
+	if state == nil {
+		return &conductorv1.AgentConnection{}
+	}
+	return &conductorv1.AgentConnection{
+		Connected:      true,
+		ConnectedAt:    timestamppb.New(state.connectedAt),
+		LastHeartbeat:  timestamppb.New(state.lastSeen),
+		ReportedStatus: state.status,
+	}
+}
+
+// queuedWorkToProto converts unaccepted assignments to their API form.
+func queuedWorkToProto(queued []queuedWork) []*conductorv1.QueuedWork {
+	protoQueued := make([]*conductorv1.QueuedWork, len(queued))
+	for i, work := range queued {
+		protoQueued[i] = &conductorv1.QueuedWork{
+			RunId:      work.runID,
+			ShardId:    work.shardID,
+			ShardIndex: work.shardIndex,
+			ShardCount: work.shardCount,
+			AssignedAt: timestamppb.New(work.assignedAt),
+		}
+	}
+	return protoQueued
+}
+
+// queuedWorkFromProto converts unaccepted assignments from their API form.
+func queuedWorkFromProto(protoQueued []*conductorv1.QueuedWork) []queuedWork {
+	queued := make([]queuedWork, len(protoQueued))
+	for i, work := range protoQueued {
+		queued[i] = queuedWork{
+			runID:      work.RunId,
+			shardID:    work.ShardId,
+			shardIndex: work.ShardIndex,
+			shardCount: work.ShardCount,
+			assignedAt: work.AssignedAt.AsTime(),
+		}
+	}
+	return queued
+}
+
+// liveStateToProto converts the live state of an agent to the reply of the
+// replica it is connected to.
+func liveStateToProto(state *agentLiveState) *conductorv1.GetLiveAgentResponse {
+	return &conductorv1.GetLiveAgentResponse{
+		Connection:   agentConnectionToProto(state),
+		Utilization:  state.usage,
+		ActiveRunIds: state.activeRunIDs,
+		QueuedWork:   queuedWorkToProto(state.queued),
+	}
+}
+
+// liveStateFromProto converts the reply of the replica an agent is
+// connected to back to its live state.
+func liveStateFromProto(resp *conductorv1.GetLiveAgentResponse) *agentLiveState {
+	return &agentLiveState{
+		connectedAt:  resp.GetConnection().GetConnectedAt().AsTime(),
+		lastSeen:     resp.GetConnection().GetLastHeartbeat().AsTime(),
+		status:       resp.GetConnection().GetReportedStatus(),
+		activeRunIDs: resp.ActiveRunIds,
+		usage:        resp.Utilization,
+		queued:       queuedWorkFromProto(resp.QueuedWork),
+	}
+}
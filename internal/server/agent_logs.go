@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+// errAgentNotConnected is returned for agents without a stream to any
+// control plane replica.
+var errAgentNotConnected = errors.New("agent not connected")
+
+// deliverLogs hands an agent's log lines to the request waiting for them.
+// Replies nobody waits for anymore are dropped.
+func (a *connectedAgent) deliverLogs(logs *conductorv1.AgentLogs) {
+	a.logsMu.Lock()
+	reply, ok := a.logRequests[logs.RequestId]
+	delete(a.logRequests, logs.RequestId)
+	a.logsMu.Unlock()
+
+	if ok {
+		reply <- logs
+	}
+}
+
+// requestLogs asks a connected agent for the most recent lines of its log
+// buffer and waits for the reply until ctx is done. Agents connected to
+// another replica are asked through that replica.
+func (s *AgentServiceServer) requestLogs(ctx context.Context, agentID uuid.UUID, limit int32, minLevel string) (*conductorv1.AgentLogs, error) {
+	if agent, ok := s.localAgent(agentID); ok {
+		return agent.requestLogs(ctx, limit, minLevel)
+	}
+
+	replica, err := s.replicaOf(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := replica.FetchAgentLogs(ctx, &conductorv1.FetchAgentLogsRequest{
+		AgentId:  agentID.String(),
+		Limit:    limit,
+		MinLevel: minLevel,
+	})
+	if err != nil {
+		return nil, routedError(err)
+	}
+	return logs, nil
+}
+
+// requestLogs asks the agent for the most recent lines of its log buffer
+// and waits for the reply until ctx is done.
+func (agent *connectedAgent) requestLogs(ctx context.Context, limit int32, minLevel string) (*conductorv1.AgentLogs, error) {
+	requestID := uuid.NewString()
+	reply := make(chan *conductorv1.AgentLogs, 1)
+
+	agent.logsMu.Lock()
+	if agent.logRequests == nil {
+		agent.logRequests = make(map[string]chan *conductorv1.AgentLogs)
+	}
+	agent.logRequests[requestID] = reply
+	agent.logsMu.Unlock()
+
+	defer func() {
+		agent.logsMu.Lock()
+		delete(agent.logRequests, requestID)
+		agent.logsMu.Unlock()
+	}()
+
+	msg := &conductorv1.ControlMessage{
+		Message: &conductorv1.ControlMessage_LogRequest{
+			LogRequest: &conductorv1.LogRequest{
+				RequestId: requestID,
+				Limit:     limit,
+				MinLevel:  minLevel,
+			},
+		},
+	}
+	agent.sendMu.Lock()
+	err := agent.stream.Send(msg)
+	agent.sendMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to send log request: %w", err)
+	}
+
+	select {
+	case logs := <-reply:
+		return logs, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,266 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// replicaRole is the role of the tokens control plane replicas issue to
+// each other. Only it may call the AgentRoutingService.
+const replicaRole = "control-plane"
+
+// replicaTokenTTL is how long a token issued to call another replica is
+// valid.
+const replicaTokenTTL = time.Minute
+
+// routeTimeout bounds a control message routed to another replica.
+const routeTimeout = 10 * time.Second
+
+// replicaDialer returns a client of the control plane replica at an
+// address.
+type replicaDialer interface {
+	dial(address string) (conductorv1.AgentRoutingServiceClient, error)
+}
+
+// replicaPeers dials the other control plane replicas and keeps one
+// connection per replica. Calls are authenticated with short-lived tokens
+// signed with the JWT secret the replicas share.
+type replicaPeers struct {
+	tokens    *JWTValidator
+	replicaID string
+
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+// newReplicaPeers creates the dialer of the replicas the replica with the
+// given ID routes messages through.
+func newReplicaPeers(tokens *JWTValidator, replicaID string) *replicaPeers {
+	return &replicaPeers{
+		tokens:    tokens,
+		replicaID: replicaID,
+		conns:     make(map[string]*grpc.ClientConn),
+	}
+}
+
+// dial returns a client of the replica at address.
+func (p *replicaPeers) dial(address string) (conductorv1.AgentRoutingServiceClient, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conn, ok := p.conns[address]
+	if !ok {
+		var err error
+		conn, err = grpc.NewClient(
+			address,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithPerRPCCredentials(p),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial replica %s: %w", address, err)
+		}
+		p.conns[address] = conn
+	}
+	return conductorv1.NewAgentRoutingServiceClient(conn), nil
+}
+
+// GetRequestMetadata issues the token of a call to another replica.
+func (p *replicaPeers) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	now := time.Now()
+	token, err := p.tokens.GenerateToken(&UserClaims{
+		UserID:    "replica:" + p.replicaID,
+		Roles:     []string{replicaRole},
+		IssuedAt:  now,
+		ExpiresAt: now.Add(replicaTokenTTL),
+		Issuer:    "conductor-control-plane",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+// RequireTransportSecurity reports that replica tokens may be sent over
+// the plaintext connections between replicas.
+func (p *replicaPeers) RequireTransportSecurity() bool {
+	return false
+}
+
+// close closes the connections to the other replicas.
+func (p *replicaPeers) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for address, conn := range p.conns {
+		_ = conn.Close()
+		delete(p.conns, address)
+	}
+}
+
+// localAgent returns an agent connected to this replica.
+func (s *AgentServiceServer) localAgent(agentID uuid.UUID) (*connectedAgent, bool) {
+	s.agentsMu.RLock()
+	defer s.agentsMu.RUnlock()
+	agent, ok := s.agents[agentID]
+	return agent, ok
+}
+
+// replicaOf returns a client of the replica another agent is connected to.
+// It returns errAgentNotConnected if the agent is not connected to another
+// replica, or if agent connections are not recorded.
+func (s *AgentServiceServer) replicaOf(ctx context.Context, agentID uuid.UUID) (conductorv1.AgentRoutingServiceClient, error) {
+	if s.deps.Connections == nil || s.peers == nil {
+		return nil, errAgentNotConnected
+	}
+
+	conn, err := s.deps.Connections.Get(ctx, agentID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errAgentNotConnected
+		}
+		return nil, fmt.Errorf("failed to get agent connection: %w", err)
+	}
+	// The agent was connected to this replica and its stream is gone
+	if conn.ReplicaID == s.deps.ReplicaID {
+		return nil, errAgentNotConnected
+	}
+	return s.peers.dial(conn.ReplicaAddress)
+}
+
+// routedError maps the error of a call to the replica an agent is
+// connected to. Agents the replica no longer holds a stream with are not
+// connected.
+func routedError(err error) error {
+	switch {
+	case errcode.Is(err, errcode.AgentOffline):
+		return errAgentNotConnected
+	case status.Code(err) == codes.DeadlineExceeded:
+		return context.DeadlineExceeded
+	default:
+		return err
+	}
+}
+
+// registerConnection records that an agent connected to this replica.
+// Recording is best effort: without it, the agent is only reachable from
+// this replica.
+func (s *AgentServiceServer) registerConnection(ctx context.Context, agentID uuid.UUID) {
+	if s.deps.Connections == nil {
+		return
+	}
+	err := s.deps.Connections.Register(ctx, &database.AgentConnection{
+		AgentID:        agentID,
+		ReplicaID:      s.deps.ReplicaID,
+		ReplicaAddress: s.deps.ReplicaAddress,
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Str("agent_id", agentID.String()).Msg("failed to record agent connection")
+	}
+}
+
+// unregisterConnection forgets that an agent is connected to this replica.
+func (s *AgentServiceServer) unregisterConnection(ctx context.Context, agentID uuid.UUID) {
+	if s.deps.Connections == nil {
+		return
+	}
+	if err := s.deps.Connections.Unregister(ctx, agentID, s.deps.ReplicaID); err != nil {
+		s.logger.Error().Err(err).Str("agent_id", agentID.String()).Msg("failed to remove agent connection")
+	}
+}
+
+// AgentRoutingServer implements the AgentRoutingService gRPC service. It
+// serves the other replicas for the agents connected to this one.
+type AgentRoutingServer struct {
+	conductorv1.UnimplementedAgentRoutingServiceServer
+
+	agents *AgentServiceServer
+	logger zerolog.Logger
+}
+
+// NewAgentRoutingServer creates a new agent routing server for the agents
+// connected to the given agent service.
+func NewAgentRoutingServer(agents *AgentServiceServer, logger zerolog.Logger) *AgentRoutingServer {
+	return &AgentRoutingServer{
+		agents: agents,
+		logger: logger.With().Str("service", "AgentRoutingService").Logger(),
+	}
+}
+
+// agent authorizes a call from another replica and returns the agent it
+// names, which must be connected to this replica.
+func (s *AgentRoutingServer) agent(ctx context.Context, id string) (*connectedAgent, error) {
+	claims := GetUserFromContext(ctx)
+	if claims == nil || !claims.HasRole(replicaRole) {
+		return nil, errcode.New(errcode.PermissionDenied, "agent routing is reserved to control plane replicas")
+	}
+	agentID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid agent ID: %v", err)
+	}
+	agent, ok := s.agents.localAgent(agentID)
+	if !ok {
+		return nil, errcode.New(errcode.AgentOffline, "agent %s is not connected to this replica", id)
+	}
+	return agent, nil
+}
+
+// SendControlMessage sends a control message to an agent connected to this
+// replica.
+func (s *AgentRoutingServer) SendControlMessage(ctx context.Context, req *conductorv1.SendControlMessageRequest) (*conductorv1.SendControlMessageResponse, error) {
+	agent, err := s.agent(ctx, req.AgentId)
+	if err != nil {
+		return nil, err
+	}
+	if req.Message == nil {
+		return nil, errcode.New(errcode.InvalidArgument, "message is required")
+	}
+
+	agent.sendMu.Lock()
+	err = agent.stream.Send(req.Message)
+	agent.sendMu.Unlock()
+	if err != nil {
+		return nil, errcode.New(errcode.Unavailable, "failed to send message to agent: %v", err)
+	}
+	return &conductorv1.SendControlMessageResponse{}, nil
+}
+
+// GetLiveAgent returns the live state of an agent connected to this
+// replica.
+func (s *AgentRoutingServer) GetLiveAgent(ctx context.Context, req *conductorv1.GetLiveAgentRequest) (*conductorv1.GetLiveAgentResponse, error) {
+	agent, err := s.agent(ctx, req.AgentId)
+	if err != nil {
+		return nil, err
+	}
+	return liveStateToProto(agent.snapshot()), nil
+}
+
+// FetchAgentLogs asks an agent connected to this replica for its logs.
+func (s *AgentRoutingServer) FetchAgentLogs(ctx context.Context, req *conductorv1.FetchAgentLogsRequest) (*conductorv1.AgentLogs, error) {
+	agent, err := s.agent(ctx, req.AgentId)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := agent.requestLogs(ctx, req.Limit, req.MinLevel)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return nil, status.FromContextError(err).Err()
+	case err != nil:
+		return nil, errcode.New(errcode.Internal, "failed to request agent logs: %v", err)
+	}
+	return logs, nil
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// memoryConnectionRepo is an agent connection registry shared by the
+// replicas of a test.
+type memoryConnectionRepo struct {
+	mu    sync.Mutex
+	conns map[uuid.UUID]database.AgentConnection
+}
+
+func (r *memoryConnectionRepo) Register(ctx context.Context, conn *database.AgentConnection) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conn.ConnectedAt = time.Now()
+	r.conns[conn.AgentID] = *conn
+	return nil
+}
+
+func (r *memoryConnectionRepo) Get(ctx context.Context, agentID uuid.UUID) (*database.AgentConnection, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conn, ok := r.conns[agentID]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	return &conn, nil
+}
+
+func (r *memoryConnectionRepo) Unregister(ctx context.Context, agentID uuid.UUID, replicaID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conns[agentID].ReplicaID == replicaID {
+		delete(r.conns, agentID)
+	}
+	return nil
+}
+
+func (r *memoryConnectionRepo) UnregisterReplica(ctx context.Context, replicaID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for id, conn := range r.conns {
+		if conn.ReplicaID == replicaID {
+			delete(r.conns, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// routingAgentRepo serves an agent and accepts its status changes.
+type routingAgentRepo struct {
+	detailAgentRepo
+}
+
+func (r *routingAgentRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status database.AgentStatus) error {
+	return nil
+}
+
+// memoryReplicas dials the routing servers of the replicas of a test
+// in process, calling them like another replica would.
+type memoryReplicas map[string]*AgentRoutingServer
+
+func (r memoryReplicas) dial(address string) (conductorv1.AgentRoutingServiceClient, error) {
+	return &memoryReplicaClient{server: r[address]}, nil
+}
+
+type memoryReplicaClient struct {
+	server *AgentRoutingServer
+}
+
+func (c *memoryReplicaClient) ctx(ctx context.Context) context.Context {
+	return withUser(ctx, &UserClaims{UserID: "replica:a", Roles: []string{replicaRole}})
+}
+
+func (c *memoryReplicaClient) SendControlMessage(ctx context.Context, req *conductorv1.SendControlMessageRequest, opts ...grpc.CallOption) (*conductorv1.SendControlMessageResponse, error) {
+	return c.server.SendControlMessage(c.ctx(ctx), req)
+}
+
+func (c *memoryReplicaClient) GetLiveAgent(ctx context.Context, req *conductorv1.GetLiveAgentRequest, opts ...grpc.CallOption) (*conductorv1.GetLiveAgentResponse, error) {
+	return c.server.GetLiveAgent(c.ctx(ctx), req)
+}
+
+func (c *memoryReplicaClient) FetchAgentLogs(ctx context.Context, req *conductorv1.FetchAgentLogsRequest, opts ...grpc.CallOption) (*conductorv1.AgentLogs, error) {
+	return c.server.FetchAgentLogs(c.ctx(ctx), req)
+}
+
+// recordingStream records the messages sent to an agent and answers its
+// log requests.
+type recordingStream struct {
+	conductorv1.AgentService_WorkStreamServer
+	agent *connectedAgent
+
+	mu   sync.Mutex
+	sent []*conductorv1.ControlMessage
+}
+
+func (s *recordingStream) Send(msg *conductorv1.ControlMessage) error {
+	s.mu.Lock()
+	s.sent = append(s.sent, msg)
+	s.mu.Unlock()
+	if req := msg.GetLogRequest(); req != nil {
+		go s.agent.deliverLogs(&conductorv1.AgentLogs{
+			RequestId: req.RequestId,
+			Lines:     []*conductorv1.AgentLogLine{{Level: "info", Line: "ready"}},
+		})
+	}
+	return nil
+}
+
+func TestAgentRouting(t *testing.T) {
+	ctx := context.Background()
+	agent := &database.Agent{ID: uuid.New(), Name: "agent-1", Status: database.AgentStatusIdle}
+	connections := &memoryConnectionRepo{conns: map[uuid.UUID]database.AgentConnection{}}
+	replicas := memoryReplicas{}
+
+	newReplica := func(id string) (*AgentServiceServer, *AgentManagementServer) {
+		deps := AgentServiceDeps{
+			AgentRepo:      &routingAgentRepo{detailAgentRepo{agent: agent}},
+			RunRepo:        &detailRunRepo{},
+			Connections:    connections,
+			ReplicaID:      id,
+			ReplicaAddress: id + ":9090",
+		}
+		agentService := NewAgentServiceServer(deps, zerolog.Nop())
+		agentService.peers = replicas
+		mgmt := NewAgentManagementServer(deps, zerolog.Nop())
+		mgmt.live = agentService
+		replicas[deps.ReplicaAddress] = NewAgentRoutingServer(agentService, zerolog.Nop())
+		return agentService, mgmt
+	}
+	replicaA, mgmtA := newReplica("a")
+	replicaB, _ := newReplica("b")
+
+	// The agent holds its work stream with replica b
+	conn := &connectedAgent{id: agent.ID, connectedAt: time.Now(), cancel: func() {}}
+	stream := &recordingStream{agent: conn}
+	conn.stream = stream
+	conn.recordHeartbeat(&conductorv1.Heartbeat{
+		Status:        conductorv1.AgentStatus_AGENT_STATUS_BUSY,
+		ResourceUsage: &conductorv1.ResourceUsage{CpuPercent: 40},
+	}, time.Now())
+	conn.queueWork(&conductorv1.AssignWork{RunId: "run-1", ShardId: "shard-1", ShardCount: 2}, time.Now())
+	replicaB.agents[agent.ID] = conn
+	replicaB.registerConnection(ctx, agent.ID)
+
+	t.Run("live state comes from the agent's replica", func(t *testing.T) {
+		resp, err := mgmtA.GetAgentDetail(ctx, &conductorv1.GetAgentDetailRequest{AgentId: agent.ID.String()})
+		require.NoError(t, err)
+		assert.True(t, resp.Connection.Connected)
+		assert.Equal(t, conductorv1.AgentStatus_AGENT_STATUS_BUSY, resp.Connection.ReportedStatus)
+		assert.Equal(t, 40.0, resp.Utilization.CpuPercent)
+		require.Len(t, resp.QueuedWork, 1)
+		assert.Equal(t, "shard-1", resp.QueuedWork[0].ShardId)
+	})
+
+	t.Run("logs are requested through the agent's replica", func(t *testing.T) {
+		resp, err := mgmtA.GetAgentLogs(ctx, &conductorv1.GetAgentLogsRequest{AgentId: agent.ID.String()})
+		require.NoError(t, err)
+		require.Len(t, resp.Lines, 1)
+		assert.Equal(t, "ready", resp.Lines[0].Line)
+	})
+
+	t.Run("control messages are sent through the agent's replica", func(t *testing.T) {
+		require.NoError(t, replicaA.DrainAgent(agent.ID, "maintenance", false, time.Now()))
+
+		stream.mu.Lock()
+		defer stream.mu.Unlock()
+		last := stream.sent[len(stream.sent)-1]
+		assert.Equal(t, "maintenance", last.GetDrain().GetReason())
+	})
+
+	t.Run("only replicas may route", func(t *testing.T) {
+		_, err := replicas["b:9090"].GetLiveAgent(withUser(ctx, &UserClaims{UserID: "alice", Roles: []string{"admin"}}),
+			&conductorv1.GetLiveAgentRequest{AgentId: agent.ID.String()})
+		assert.True(t, errcode.Is(err, errcode.PermissionDenied))
+	})
+
+	t.Run("agents are offline once their replica loses the stream", func(t *testing.T) {
+		replicaB.disconnectAgent(agent.ID)
+
+		_, err := mgmtA.GetAgentLogs(ctx, &conductorv1.GetAgentLogsRequest{AgentId: agent.ID.String()})
+		assert.True(t, errcode.Is(err, errcode.AgentOffline))
+		assert.Error(t, replicaA.SendToAgent(agent.ID, &conductorv1.ControlMessage{}))
+
+		resp, err := mgmtA.GetAgentDetail(ctx, &conductorv1.GetAgentDetailRequest{AgentId: agent.ID.String()})
+		require.NoError(t, err)
+		assert.False(t, resp.Connection.Connected)
+	})
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+)
+
+// maxAnnotationMessageLength bounds the message of a run annotation.
+const maxAnnotationMessageLength = 1024
+
+// handleAnnotation records a warning an agent attached to a run. Annotations
+// are timestamped on receipt: the agent's clock may be the one that is off.
+func (s *AgentServiceServer) handleAnnotation(ctx context.Context, agent *connectedAgent, rs *conductorv1.ResultStream, annotation *conductorv1.RunAnnotation) error {
+	if annotation == nil || s.deps.AnnotationRepo == nil {
+		return nil
+	}
+	if annotation.Kind == "" {
+		return fmt.Errorf("annotation has no kind")
+	}
+
+	runID, err := uuid.Parse(rs.RunId)
+	if err != nil {
+		return fmt.Errorf("invalid run ID: %w", err)
+	}
+	shardID, err := parseOptionalUUID(rs.ShardId)
+	if err != nil {
+		return fmt.Errorf("invalid shard ID: %w", err)
+	}
+
+	agentID := agent.id
+	record := &database.RunAnnotation{
+		RunID:    runID,
+		ShardID:  shardID,
+		AgentID:  &agentID,
+		Kind:     annotation.Kind,
+		Severity: annotationSeverityFromProto(annotation.Severity),
+		Message:  annotation.Message,
+		Metadata: annotation.Metadata,
+	}
+	if len(record.Message) > maxAnnotationMessageLength {
+		record.Message = strings.ToValidUTF8(record.Message[:maxAnnotationMessageLength], "")
+	}
+
+	return s.deps.AnnotationRepo.Create(ctx, record)
+}
+
+// runAnnotationsToProto converts run annotations to their API form.
+func runAnnotationsToProto(annotations []database.RunAnnotation) []*conductorv1.RunAnnotation {
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	result := make([]*conductorv1.RunAnnotation, len(annotations))
+	for i, a := range annotations {
+		result[i] = &conductorv1.RunAnnotation{
+			Id:        a.ID.String(),
+			Kind:      a.Kind,
+			Severity:  annotationSeverityToProto(a.Severity),
+			Message:   a.Message,
+			Metadata:  a.Metadata,
+			Timestamp: timestamppb.New(a.CreatedAt),
+		}
+		if a.ShardID != nil {
+			result[i].ShardId = a.ShardID.String()
+		}
+		if a.AgentID != nil {
+			result[i].AgentId = a.AgentID.String()
+		}
+	}
+	return result
+}
+
+func annotationSeverityFromProto(severity conductorv1.AnnotationSeverity) database.AnnotationSeverity {
+	if severity == conductorv1.AnnotationSeverity_ANNOTATION_SEVERITY_INFO {
+		return database.AnnotationSeverityInfo
+	}
+	return database.AnnotationSeverityWarning
+}
+
+func annotationSeverityToProto(severity database.AnnotationSeverity) conductorv1.AnnotationSeverity {
+	if severity == database.AnnotationSeverityInfo {
+		return conductorv1.AnnotationSeverity_ANNOTATION_SEVERITY_INFO
+	}
+	return conductorv1.AnnotationSeverity_ANNOTATION_SEVERITY_WARNING
+}
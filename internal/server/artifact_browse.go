@@ -0,0 +1,253 @@
+package server
+
+import (
+	"context"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// artifactTypeOrder is the order artifact types are summarized in.
+var artifactTypeOrder = []database.ArtifactType{
+	database.ArtifactTypeLog,
+	database.ArtifactTypeJUnit,
+	database.ArtifactTypeCoverage,
+	database.ArtifactTypeScreenshot,
+	database.ArtifactTypeVideo,
+	database.ArtifactTypeBinary,
+}
+
+// inlineMediaTypes are the media types screenshots and videos are displayed
+// with, by file extension.
+var inlineMediaTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".bmp":  "image/bmp",
+	".mp4":  "video/mp4",
+	".webm": "video/webm",
+	".mov":  "video/quicktime",
+	".avi":  "video/x-msvideo",
+	".mkv":  "video/x-matroska",
+}
+
+// artifactFilter selects the artifacts of a run to list.
+type artifactFilter struct {
+	testID            *uuid.UUID
+	contentTypePrefix string
+	artifactType      database.ArtifactType
+}
+
+func newArtifactFilter(testID, contentTypePrefix string, artifactType conductorv1.ArtifactType) (artifactFilter, error) {
+	id, err := parseOptionalUUID(testID)
+	if err != nil {
+		return artifactFilter{}, errcode.New(errcode.InvalidArgument, "invalid test ID: %v", err)
+	}
+	return artifactFilter{
+		testID:            id,
+		contentTypePrefix: contentTypePrefix,
+		artifactType:      artifactTypeFromProto(artifactType),
+	}, nil
+}
+
+func (f artifactFilter) matches(artifact *database.Artifact) bool {
+	if f.testID != nil && (artifact.TestDefinitionID == nil || *artifact.TestDefinitionID != *f.testID) {
+		return false
+	}
+	if f.contentTypePrefix != "" && !strings.HasPrefix(stringValue(artifact.ContentType), f.contentTypePrefix) {
+		return false
+	}
+	return f.artifactType == "" || artifact.Type == f.artifactType
+}
+
+// BrowseArtifacts returns the artifacts of a run as a directory tree built
+// from their names, with the count and size of the artifacts of each type.
+func (s *ResultServiceServer) BrowseArtifacts(ctx context.Context, req *conductorv1.BrowseArtifactsRequest) (*conductorv1.BrowseArtifactsResponse, error) {
+	runID, err := uuid.Parse(req.RunId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid run ID: %v", err)
+	}
+	filter, err := newArtifactFilter(req.TestId, "", req.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts, _, err := s.deps.ArtifactRepo.ListByRunID(ctx, runID, database.Pagination{})
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list artifacts: %v", err)
+	}
+	artifacts = slices.DeleteFunc(artifacts, func(artifact *database.Artifact) bool {
+		return !filter.matches(artifact)
+	})
+
+	return &conductorv1.BrowseArtifactsResponse{
+		Root:  artifactTree(artifacts),
+		Types: artifactTypeSummaries(artifacts),
+	}, nil
+}
+
+// artifactDir is a directory of an artifact tree being built.
+type artifactDir struct {
+	node  *conductorv1.ArtifactTreeNode
+	dirs  map[string]*artifactDir
+	files []*conductorv1.ArtifactTreeNode
+}
+
+func newArtifactDir(name, dirPath string) *artifactDir {
+	return &artifactDir{
+		node: &conductorv1.ArtifactTreeNode{Name: name, Path: dirPath},
+		dirs: make(map[string]*artifactDir),
+	}
+}
+
+// artifactTree returns the directory tree of artifacts, splitting their
+// names on "/". Every directory counts the artifacts below it.
+func artifactTree(artifacts []*database.Artifact) *conductorv1.ArtifactTreeNode {
+	root := newArtifactDir("", "")
+	for _, artifact := range artifacts {
+		name := strings.TrimPrefix(path.Clean("/"+artifact.Name), "/")
+		if name == "" {
+			name = artifact.ID.String()
+		}
+		var size int64
+		if artifact.SizeBytes != nil {
+			size = *artifact.SizeBytes
+		}
+
+		dir := root
+		parts := strings.Split(name, "/")
+		for i, part := range parts[:len(parts)-1] {
+			dir.node.Count++
+			dir.node.SizeBytes += size
+			sub, ok := dir.dirs[part]
+			if !ok {
+				sub = newArtifactDir(part, strings.Join(parts[:i+1], "/"))
+				dir.dirs[part] = sub
+			}
+			dir = sub
+		}
+		dir.node.Count++
+		dir.node.SizeBytes += size
+		dir.files = append(dir.files, &conductorv1.ArtifactTreeNode{
+			Name:      parts[len(parts)-1],
+			Path:      name,
+			Count:     1,
+			SizeBytes: size,
+			Artifact:  artifactToProto(artifact),
+		})
+	}
+	return root.build()
+}
+
+// build sets the children of the directory: subdirectories first, then
+// files, each sorted by name.
+func (d *artifactDir) build() *conductorv1.ArtifactTreeNode {
+	names := make([]string, 0, len(d.dirs))
+	for name := range d.dirs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		d.node.Children = append(d.node.Children, d.dirs[name].build())
+	}
+
+	slices.SortStableFunc(d.files, func(a, b *conductorv1.ArtifactTreeNode) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	d.node.Children = append(d.node.Children, d.files...)
+	return d.node
+}
+
+// artifactTypeSummaries counts the artifacts of each type present.
+func artifactTypeSummaries(artifacts []*database.Artifact) []*conductorv1.ArtifactTypeSummary {
+	summaries := make(map[database.ArtifactType]*conductorv1.ArtifactTypeSummary)
+	for _, artifact := range artifacts {
+		summary, ok := summaries[artifact.Type]
+		if !ok {
+			summary = &conductorv1.ArtifactTypeSummary{Type: artifactTypeToProto(artifact.Type)}
+			summaries[artifact.Type] = summary
+		}
+		summary.Count++
+		if artifact.SizeBytes != nil {
+			summary.SizeBytes += *artifact.SizeBytes
+		}
+	}
+
+	var result []*conductorv1.ArtifactTypeSummary
+	for _, artifactType := range artifactTypeOrder {
+		if summary, ok := summaries[artifactType]; ok {
+			result = append(result, summary)
+		}
+	}
+	return result
+}
+
+// inlineContentType returns the content type an artifact is displayed
+// inline with, and false if it cannot be displayed. Logs and reports are
+// shown as plain text, so HTML reports cannot run scripts; screenshots and
+// videos keep their media type, except SVG images, which can carry scripts.
+func inlineContentType(artifact *database.Artifact) (string, bool) {
+	switch artifact.Type {
+	case database.ArtifactTypeLog, database.ArtifactTypeJUnit, database.ArtifactTypeCoverage:
+		return "text/plain; charset=utf-8", true
+	case database.ArtifactTypeScreenshot, database.ArtifactTypeVideo:
+		prefix := "image/"
+		if artifact.Type == database.ArtifactTypeVideo {
+			prefix = "video/"
+		}
+		if mediaType, ok := inlineMediaTypes[strings.ToLower(path.Ext(artifact.Name))]; ok && strings.HasPrefix(mediaType, prefix) {
+			return mediaType, true
+		}
+		contentType := strings.ToLower(stringValue(artifact.ContentType))
+		if strings.HasPrefix(contentType, prefix) && !strings.HasPrefix(contentType, "image/svg") {
+			return contentType, true
+		}
+	}
+	return "", false
+}
+
+func artifactTypeFromProto(t conductorv1.ArtifactType) database.ArtifactType {
+	switch t {
+	case conductorv1.ArtifactType_ARTIFACT_TYPE_LOG:
+		return database.ArtifactTypeLog
+	case conductorv1.ArtifactType_ARTIFACT_TYPE_JUNIT:
+		return database.ArtifactTypeJUnit
+	case conductorv1.ArtifactType_ARTIFACT_TYPE_COVERAGE:
+		return database.ArtifactTypeCoverage
+	case conductorv1.ArtifactType_ARTIFACT_TYPE_SCREENSHOT:
+		return database.ArtifactTypeScreenshot
+	case conductorv1.ArtifactType_ARTIFACT_TYPE_VIDEO:
+		return database.ArtifactTypeVideo
+	case conductorv1.ArtifactType_ARTIFACT_TYPE_BINARY:
+		return database.ArtifactTypeBinary
+	default:
+		return ""
+	}
+}
+
+func artifactTypeToProto(t database.ArtifactType) conductorv1.ArtifactType {
+	switch t {
+	case database.ArtifactTypeLog:
+		return conductorv1.ArtifactType_ARTIFACT_TYPE_LOG
+	case database.ArtifactTypeJUnit:
+		return conductorv1.ArtifactType_ARTIFACT_TYPE_JUNIT
+	case database.ArtifactTypeCoverage:
+		return conductorv1.ArtifactType_ARTIFACT_TYPE_COVERAGE
+	case database.ArtifactTypeScreenshot:
+		return conductorv1.ArtifactType_ARTIFACT_TYPE_SCREENSHOT
+	case database.ArtifactTypeVideo:
+		return conductorv1.ArtifactType_ARTIFACT_TYPE_VIDEO
+	case database.ArtifactTypeBinary:
+		return conductorv1.ArtifactType_ARTIFACT_TYPE_BINARY
+	default:
+		return conductorv1.ArtifactType_ARTIFACT_TYPE_UNSPECIFIED
+	}
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// browseArtifactRepo serves a fixed list of artifacts.
+type browseArtifactRepo struct {
+	ArtifactRepository
+	artifacts []*database.Artifact
+}
+
+func (r *browseArtifactRepo) GetByID(ctx context.Context, id uuid.UUID) (*database.Artifact, error) {
+	for _, artifact := range r.artifacts {
+		if artifact.ID == id {
+			return artifact, nil
+		}
+	}
+	return nil, database.ErrNotFound
+}
+
+func (r *browseArtifactRepo) ListByRunID(ctx context.Context, runID uuid.UUID, pagination database.Pagination) ([]*database.Artifact, int, error) {
+	return append([]*database.Artifact(nil), r.artifacts...), len(r.artifacts), nil
+}
+
+// urlRecordingStorage records the options of the download URLs it signs.
+type urlRecordingStorage struct {
+	opts DownloadURLOptions
+}
+
+func (s *urlRecordingStorage) GenerateDownloadURL(ctx context.Context, path string, expirationSeconds int, opts DownloadURLOptions) (string, time.Time, error) {
+	s.opts = opts
+	return "https://storage.example.com/" + path, time.Now().Add(time.Duration(expirationSeconds) * time.Second), nil
+}
+
+func testArtifact(name string, artifactType database.ArtifactType, contentType string, size int64) *database.Artifact {
+	return &database.Artifact{
+		ID:          uuid.New(),
+		Name:        name,
+		Path:        "runs/r/" + name,
+		ContentType: database.NullString(contentType),
+		SizeBytes:   &size,
+		Type:        artifactType,
+	}
+}
+
+func TestBrowseArtifacts(t *testing.T) {
+	testID := uuid.New()
+	junit := testArtifact("reports/junit.xml", database.ArtifactTypeJUnit, "application/xml", 100)
+	junit.TestDefinitionID = &testID
+	repo := &browseArtifactRepo{artifacts: []*database.Artifact{
+		testArtifact("server.log", database.ArtifactTypeLog, "text/plain", 10),
+		testArtifact("screenshots/login/failed.png", database.ArtifactTypeScreenshot, "image/png", 1000),
+		junit,
+		testArtifact("reports/coverage.xml", database.ArtifactTypeCoverage, "application/xml", 50),
+		testArtifact("screenshots/home.png", database.ArtifactTypeScreenshot, "image/png", 2000),
+	}}
+	server := NewResultServiceServer(ResultServiceDeps{ArtifactRepo: repo}, zerolog.Nop())
+	runID := uuid.NewString()
+
+	t.Run("builds the directory tree", func(t *testing.T) {
+		resp, err := server.BrowseArtifacts(context.Background(), &conductorv1.BrowseArtifactsRequest{RunId: runID})
+		require.NoError(t, err)
+
+		root := resp.Root
+		assert.Equal(t, int32(5), root.Count)
+		assert.Equal(t, int64(3160), root.SizeBytes)
+		require.Len(t, root.Children, 3)
+		assert.Equal(t, "reports", root.Children[0].Name)
+		assert.Equal(t, "screenshots", root.Children[1].Name)
+		assert.Equal(t, "server.log", root.Children[2].Name, "files follow directories")
+		assert.NotNil(t, root.Children[2].Artifact)
+
+		screenshots := root.Children[1]
+		assert.Equal(t, int32(2), screenshots.Count)
+		assert.Equal(t, int64(3000), screenshots.SizeBytes)
+		require.Len(t, screenshots.Children, 2)
+		assert.Equal(t, "screenshots/login", screenshots.Children[0].Path)
+		assert.Equal(t, "screenshots/home.png", screenshots.Children[1].Path)
+
+		require.Len(t, resp.Types, 4)
+		assert.Equal(t, conductorv1.ArtifactType_ARTIFACT_TYPE_LOG, resp.Types[0].Type)
+		assert.Equal(t, conductorv1.ArtifactType_ARTIFACT_TYPE_SCREENSHOT, resp.Types[3].Type)
+		assert.Equal(t, int32(2), resp.Types[3].Count)
+		assert.Equal(t, int64(3000), resp.Types[3].SizeBytes)
+	})
+
+	t.Run("filters by type and test", func(t *testing.T) {
+		resp, err := server.BrowseArtifacts(context.Background(), &conductorv1.BrowseArtifactsRequest{
+			RunId: runID,
+			Type:  conductorv1.ArtifactType_ARTIFACT_TYPE_SCREENSHOT,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), resp.Root.Count)
+		require.Len(t, resp.Types, 1)
+
+		resp, err = server.BrowseArtifacts(context.Background(), &conductorv1.BrowseArtifactsRequest{
+			RunId:  runID,
+			TestId: testID.String(),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), resp.Root.Count)
+		assert.Equal(t, testID.String(), resp.Root.Children[0].Children[0].Artifact.TestId)
+	})
+}
+
+func TestListArtifacts_Filters(t *testing.T) {
+	repo := &browseArtifactRepo{artifacts: []*database.Artifact{
+		testArtifact("server.log", database.ArtifactTypeLog, "text/plain", 10),
+		testArtifact("home.png", database.ArtifactTypeScreenshot, "image/png", 2000),
+	}}
+	server := NewResultServiceServer(ResultServiceDeps{ArtifactRepo: repo}, zerolog.Nop())
+
+	resp, err := server.ListArtifacts(context.Background(), &conductorv1.ListArtifactsRequest{
+		RunId: uuid.NewString(),
+		Type:  conductorv1.ArtifactType_ARTIFACT_TYPE_SCREENSHOT,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Artifacts, 1)
+	assert.Equal(t, "home.png", resp.Artifacts[0].Name)
+
+	resp, err = server.ListArtifacts(context.Background(), &conductorv1.ListArtifactsRequest{
+		RunId:             uuid.NewString(),
+		ContentTypePrefix: "text/",
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Artifacts, 1)
+	assert.Equal(t, conductorv1.ArtifactType_ARTIFACT_TYPE_LOG, resp.Artifacts[0].Type)
+}
+
+func TestGetArtifactDownloadURL_Inline(t *testing.T) {
+	log := testArtifact("logs/server.log", database.ArtifactTypeLog, "application/octet-stream", 10)
+	screenshot := testArtifact("failed.png", database.ArtifactTypeScreenshot, "application/octet-stream", 10)
+	svg := testArtifact("chart.svg", database.ArtifactTypeScreenshot, "image/svg+xml", 10)
+	binary := testArtifact("bin/api", database.ArtifactTypeBinary, "application/octet-stream", 10)
+	storage := &urlRecordingStorage{}
+	server := NewResultServiceServer(ResultServiceDeps{
+		ArtifactRepo:    &browseArtifactRepo{artifacts: []*database.Artifact{log, screenshot, svg, binary}},
+		ArtifactStorage: storage,
+	}, zerolog.Nop())
+	ctx := context.Background()
+
+	t.Run("downloads keep the file name", func(t *testing.T) {
+		resp, err := server.GetArtifactDownloadURL(ctx, &conductorv1.GetArtifactDownloadURLRequest{ArtifactId: log.ID.String()})
+		require.NoError(t, err)
+		assert.Equal(t, DownloadURLOptions{FileName: "server.log"}, storage.opts)
+		assert.Equal(t, "application/octet-stream", resp.ContentType)
+	})
+
+	t.Run("logs are displayed as text", func(t *testing.T) {
+		resp, err := server.GetArtifactDownloadURL(ctx, &conductorv1.GetArtifactDownloadURLRequest{ArtifactId: log.ID.String(), Inline: true})
+		require.NoError(t, err)
+		assert.True(t, storage.opts.Inline)
+		assert.Equal(t, "text/plain; charset=utf-8", resp.ContentType)
+	})
+
+	t.Run("screenshots keep their media type", func(t *testing.T) {
+		resp, err := server.GetArtifactDownloadURL(ctx, &conductorv1.GetArtifactDownloadURLRequest{ArtifactId: screenshot.ID.String(), Inline: true})
+		require.NoError(t, err)
+		assert.Equal(t, "image/png", resp.ContentType)
+	})
+
+	t.Run("rejects artifacts that cannot be displayed", func(t *testing.T) {
+		for _, artifact := range []*database.Artifact{svg, binary} {
+			_, err := server.GetArtifactDownloadURL(ctx, &conductorv1.GetArtifactDownloadURLRequest{ArtifactId: artifact.ID.String(), Inline: true})
+			assert.True(t, errcode.Is(err, errcode.FailedPrecondition), artifact.Name)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// checkArtifactBudget rejects an artifact that would take its test over the
+// test's artifact budget for the run. Agents skip such artifacts already;
+// this guards against agents that do not. Artifacts without a test, of tests
+// without a budget, and artifacts whose budget cannot be checked are
+// accepted.
+func (s *AgentServiceServer) checkArtifactBudget(ctx context.Context, runID uuid.UUID, testID *uuid.UUID, event *conductorv1.ArtifactUploaded) error {
+	if testID == nil || s.deps.TestRepo == nil || s.deps.RunRepo == nil {
+		return nil
+	}
+
+	logger := s.logger.With().Str("run_id", runID.String()).Str("test_id", testID.String()).Logger()
+
+	run, err := s.deps.RunRepo.GetByID(ctx, runID)
+	if err != nil || run == nil {
+		logger.Warn().Err(err).Msg("failed to load run for artifact budget")
+		return nil
+	}
+	test, err := s.deps.TestRepo.GetByID(ctx, run.ServiceID, *testID)
+	if err != nil || test == nil {
+		logger.Warn().Err(err).Msg("failed to load test for artifact budget")
+		return nil
+	}
+	if test.ArtifactMaxBytes == nil && test.ArtifactMaxFiles == nil {
+		return nil
+	}
+
+	files, bytes, err := s.deps.ArtifactRepo.UsageByTest(ctx, runID, *testID)
+	if err != nil {
+		logger.Warn().Err(err).Msg("failed to check artifact budget")
+		return nil
+	}
+
+	if test.ArtifactMaxFiles != nil && files >= *test.ArtifactMaxFiles {
+		return errcode.New(errcode.ArtifactBudgetExceeded,
+			"artifact %q of test %q rejected: the test already has %d artifacts in this run, its budget is %d",
+			event.Name, test.Name, files, *test.ArtifactMaxFiles)
+	}
+	if test.ArtifactMaxBytes != nil && bytes+event.Size > *test.ArtifactMaxBytes {
+		return errcode.New(errcode.ArtifactBudgetExceeded,
+			"artifact %q of test %q rejected: its %d bytes would take the test's artifacts in this run to %d bytes, over its budget of %d bytes",
+			event.Name, test.Name, event.Size, bytes+event.Size, *test.ArtifactMaxBytes)
+	}
+	return nil
+}
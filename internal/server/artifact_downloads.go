@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// artifactDownloadPath is the control-plane path proxied artifact downloads
+// are served from.
+const artifactDownloadPath = "/api/v1/artifact-downloads"
+
+// ArtifactDownloadProxy serves artifact downloads through the control plane
+// instead of presigned storage URLs, for deployments where the object store
+// is not reachable from user networks. It implements ArtifactStorage by
+// signing control-plane URLs, and streams the artifacts those URLs name
+// from storage. The signature authenticates the download: only callers
+// allowed to get a download URL can create one.
+type ArtifactDownloadProxy struct {
+	baseURL string
+	key     []byte
+	reader  ArtifactReader
+	logger  zerolog.Logger
+	now     func() time.Time
+}
+
+// NewArtifactDownloadProxy creates a proxy that streams artifacts from reader.
+// Download URLs are signed with a key derived from secret, so the secret
+// itself, such as the JWT secret, is not used for both. They are relative to
+// baseURL, the external URL of the control plane, or to the host serving the
+// API if it is empty.
+func NewArtifactDownloadProxy(baseURL, secret string, reader ArtifactReader, logger zerolog.Logger) *ArtifactDownloadProxy {
+	derive := hmac.New(sha256.New, []byte(secret))
+	derive.Write([]byte("conductor artifact downloads"))
+
+	return &ArtifactDownloadProxy{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		key:     derive.Sum(nil),
+		reader:  reader,
+		logger:  logger.With().Str("component", "artifact_downloads").Logger(),
+		now:     time.Now,
+	}
+}
+
+// GenerateDownloadURL returns a signed control-plane URL for an artifact.
+func (p *ArtifactDownloadProxy) GenerateDownloadURL(ctx context.Context, path string, expirationSeconds int, opts DownloadURLOptions) (string, time.Time, error) {
+	expiration := time.Duration(expirationSeconds) * time.Second
+	if expirationSeconds <= 0 {
+		expiration = time.Hour // Default 1 hour
+	}
+	expiresAt := p.now().Add(expiration).Truncate(time.Second)
+
+	query := downloadQuery(path, expiresAt, opts)
+	query.Set("signature", p.sign(query))
+	return p.baseURL + artifactDownloadPath + "?" + query.Encode(), expiresAt, nil
+}
+
+// ServeHTTP streams the artifact a signed download URL names.
+func (p *ArtifactDownloadProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	path := query.Get("path")
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if path == "" || err != nil {
+		p.writeError(w, r, errcode.New(errcode.InvalidArgument, "download URL is incomplete"))
+		return
+	}
+	expiresAt := time.Unix(expires, 0)
+	opts := DownloadURLOptions{
+		ContentType: query.Get("type"),
+		Inline:      query.Get("inline") == "true",
+		FileName:    query.Get("name"),
+	}
+
+	// Only the signed parameters are checked, so the signature is computed
+	// over the values they are read as.
+	signature, err := hex.DecodeString(query.Get("signature"))
+	if err != nil || !hmac.Equal(signature, p.mac(downloadQuery(path, expiresAt, opts))) {
+		p.writeError(w, r, errcode.New(errcode.PermissionDenied, "download URL signature is invalid"))
+		return
+	}
+	if !p.now().Before(expiresAt) {
+		p.writeError(w, r, errcode.New(errcode.PermissionDenied, "download URL expired at %s", expiresAt.UTC().Format(time.RFC3339)))
+		return
+	}
+
+	content, err := p.reader.Download(r.Context(), path)
+	if err != nil {
+		p.logger.Warn().Err(err).Str("path", path).Msg("failed to open artifact for download")
+		p.writeError(w, r, errcode.New(errcode.ArtifactNotFound, "artifact not found"))
+		return
+	}
+	defer content.Close()
+
+	if opts.ContentType != "" {
+		w.Header().Set("Content-Type", opts.ContentType)
+	}
+	if opts.Inline || opts.FileName != "" {
+		disposition := "attachment"
+		if opts.Inline {
+			disposition = "inline"
+		}
+		var params map[string]string
+		if opts.FileName != "" {
+			params = map[string]string{"filename": opts.FileName}
+		}
+		w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, params))
+	}
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	// Large artifacts take longer to stream than the server's write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	// Seekable content supports range requests, which video players use.
+	if seeker, ok := content.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, opts.FileName, time.Time{}, seeker)
+		return
+	}
+	if _, err := io.Copy(w, content); err != nil && !errors.Is(err, context.Canceled) {
+		p.logger.Warn().Err(err).Str("path", path).Msg("failed to stream artifact")
+	}
+}
+
+// sign returns the hex-encoded signature of the download parameters.
+func (p *ArtifactDownloadProxy) sign(query url.Values) string {
+	return hex.EncodeToString(p.mac(query))
+}
+
+func (p *ArtifactDownloadProxy) mac(query url.Values) []byte {
+	h := hmac.New(sha256.New, p.key)
+	h.Write([]byte(query.Encode()))
+	return h.Sum(nil)
+}
+
+func (p *ArtifactDownloadProxy) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	customErrorHandler(r.Context(), nil, nil, w, r, err)
+}
+
+// downloadQuery returns the signed parameters of a download URL.
+func downloadQuery(path string, expiresAt time.Time, opts DownloadURLOptions) url.Values {
+	query := url.Values{}
+	query.Set("path", path)
+	query.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	if opts.ContentType != "" {
+		query.Set("type", opts.ContentType)
+	}
+	if opts.Inline {
+		query.Set("inline", "true")
+	}
+	if opts.FileName != "" {
+		query.Set("name", opts.FileName)
+	}
+	return query
+}
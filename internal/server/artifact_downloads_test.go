@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seekableReader serves artifacts as seekable content, as object storage
+// does.
+type seekableReader map[string]string
+
+func (r seekableReader) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	content, ok := r[path]
+	if !ok {
+		return nil, errors.New("no such object")
+	}
+	return struct {
+		io.ReadSeeker
+		io.Closer
+	}{strings.NewReader(content), io.NopCloser(nil)}, nil
+}
+
+func TestArtifactDownloadProxy(t *testing.T) {
+	reader := seekableReader{"artifacts/run/login.mp4": "0123456789"}
+	proxy := NewArtifactDownloadProxy("https://conductor.example.com/", "this-is-a-secret-key-at-least-32-chars", reader, zerolog.Nop())
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	proxy.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	downloadURL, expiresAt, err := proxy.GenerateDownloadURL(ctx, "artifacts/run/login.mp4", 300, DownloadURLOptions{
+		ContentType: "video/mp4",
+		Inline:      true,
+		FileName:    "login.mp4",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(5*time.Minute), expiresAt)
+	require.True(t, strings.HasPrefix(downloadURL, "https://conductor.example.com/api/v1/artifact-downloads?"), downloadURL)
+
+	get := func(rawURL string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, rawURL, nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		rec := httptest.NewRecorder()
+		proxy.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("streams the artifact", func(t *testing.T) {
+		rec := get(downloadURL, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "0123456789", rec.Body.String())
+		assert.Equal(t, "video/mp4", rec.Header().Get("Content-Type"))
+		assert.Equal(t, `inline; filename=login.mp4`, rec.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("serves ranges", func(t *testing.T) {
+		rec := get(downloadURL, http.Header{"Range": {"bytes=2-5"}})
+		require.Equal(t, http.StatusPartialContent, rec.Code)
+		assert.Equal(t, "2345", rec.Body.String())
+	})
+
+	t.Run("rejects tampered URLs", func(t *testing.T) {
+		parsed, err := url.Parse(downloadURL)
+		require.NoError(t, err)
+		query := parsed.Query()
+		query.Set("inline", "false")
+		query.Set("type", "text/html")
+		parsed.RawQuery = query.Encode()
+
+		rec := get(parsed.String(), nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("rejects expired URLs", func(t *testing.T) {
+		proxy.now = func() time.Time { return expiresAt }
+		defer func() { proxy.now = func() time.Time { return now } }()
+
+		rec := get(downloadURL, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), "expired")
+	})
+
+	t.Run("reports missing artifacts", func(t *testing.T) {
+		missingURL, _, err := proxy.GenerateDownloadURL(ctx, "artifacts/run/gone.mp4", 300, DownloadURLOptions{})
+		require.NoError(t, err)
+
+		rec := get(missingURL, nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
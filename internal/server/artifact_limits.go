@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// artifactLimits returns the effective artifact limits of a registering
+// agent: the smallest of the limit the agent advertises, the control plane's
+// limit and the limit of the agent's pool. Agents skip artifacts over the
+// limit instead of failing mid-upload. Pools that are not defined have no
+// limit, and a pool that cannot be loaded does not prevent registration.
+func (s *AgentServiceServer) artifactLimits(ctx context.Context, req *conductorv1.RegisterRequest) *conductorv1.ArtifactLimits {
+	var poolLimit int64
+	if req.Pool != "" && s.deps.PoolRepo != nil {
+		pool, err := s.deps.PoolRepo.GetByName(ctx, req.Pool)
+		if err != nil && !database.IsNotFound(err) {
+			s.logger.Warn().Err(err).Str("pool", req.Pool).Msg("failed to load agent pool for artifact limits")
+		} else if err == nil && pool != nil {
+			poolLimit = pool.MaxArtifactBytes
+		}
+	}
+
+	return &conductorv1.ArtifactLimits{
+		MaxArtifactBytes: minArtifactLimit(req.Capabilities.GetMaxArtifactBytes(), s.deps.MaxArtifactBytes, poolLimit),
+	}
+}
+
+// minArtifactLimit returns the smallest of the limits, ignoring limits of 0
+// that mean no limit. It returns 0 if no limit is set.
+func minArtifactLimit(limits ...int64) int64 {
+	var limit int64
+	for _, l := range limits {
+		if l > 0 && (limit == 0 || l < limit) {
+			limit = l
+		}
+	}
+	return limit
+}
+
+// checkArtifactSize rejects an artifact over the limit the agent was given
+// when it registered. Agents skip such artifacts already; this guards
+// against agents that do not.
+func checkArtifactSize(agent *connectedAgent, event *conductorv1.ArtifactUploaded) error {
+	limit := agent.artifactLimits.GetMaxArtifactBytes()
+	if event == nil || limit <= 0 || event.Size <= limit {
+		return nil
+	}
+	return errcode.New(errcode.ArtifactTooLarge,
+		"artifact %q rejected: its %d bytes are over the agent's artifact size limit of %d bytes",
+		event.Name, event.Size, limit)
+}
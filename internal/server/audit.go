@@ -0,0 +1,385 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/audit"
+	"github.com/conductor/conductor/internal/database"
+)
+
+// auditWriteTimeout bounds how long recording an audit log may take. It is
+// applied after the call has completed, so it does not delay the caller.
+const auditWriteTimeout = 5 * time.Second
+
+// anonymousActor is recorded for calls made without a token.
+const anonymousActor = "anonymous"
+
+// AuditLogWriter records audit logs. database.AuditLogRepository implements it.
+type AuditLogWriter interface {
+	Create(ctx context.Context, log *database.AuditLog) error
+}
+
+// readOnlyPrefixes are the method name prefixes of calls that do not change
+// anything and are not audited.
+var readOnlyPrefixes = []string{"Get", "List", "Search", "Check", "Diff", "Explain", "Stream", "Watch"}
+
+// isMutatingMethod reports whether a full gRPC method changes state. Calls
+// replicas route to each other are audited on the replica they come from.
+func isMutatingMethod(fullMethod string) bool {
+	service, method := path.Split(fullMethod)
+	if strings.HasPrefix(service, "/grpc.") || service == "/conductor.v1.HealthService/" || service == "/conductor.v1.AgentRoutingService/" {
+		return false
+	}
+	for _, prefix := range readOnlyPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// auditSnapshot loads the state of the resource a call is about to change.
+type auditSnapshot func(ctx context.Context, req any) (any, error)
+
+// AuditInterceptor records mutating gRPC calls in the audit log. REST calls
+// are recorded too, as the gateway translates them to gRPC calls.
+type AuditInterceptor struct {
+	writer AuditLogWriter
+	logger zerolog.Logger
+
+	// snapshots load the before state of the calls that update or delete an
+	// existing resource, keyed by full method.
+	snapshots map[string]auditSnapshot
+	marshal   func(v any) ([]byte, error)
+}
+
+// NewAuditInterceptor creates a new audit interceptor.
+func NewAuditInterceptor(writer AuditLogWriter, logger zerolog.Logger) *AuditInterceptor {
+	return &AuditInterceptor{
+		writer:  writer,
+		logger:  logger.With().Str("component", "grpc_audit").Logger(),
+		marshal: marshalAuditPayload,
+	}
+}
+
+// Unary returns a unary server interceptor that records mutating calls. It
+// must run after the auth interceptor so the caller is known.
+func (a *AuditInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if !isMutatingMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		resourceType, resourceID := auditResource(info.FullMethod, req)
+		entry := &database.AuditLog{
+			Action:       info.FullMethod,
+			Protocol:     database.AuditProtocolGRPC,
+			ResourceType: resourceType,
+			ResourceID:   resourceID,
+			Request:      a.encode(req),
+			RequestID:    GetRequestID(ctx),
+			SourceIP:     grpcSourceIP(ctx),
+			UserAgent:    grpcUserAgent(ctx),
+		}
+		entry.Actor, entry.ActorEmail = auditActor(ctx)
+
+		if snapshot := a.snapshots[info.FullMethod]; snapshot != nil {
+			if before, err := snapshot(ctx, req); err == nil && before != nil {
+				entry.Before = a.encode(before)
+			}
+		}
+
+		resp, err := handler(ctx, req)
+
+		entry.Status = status.Code(err).String()
+		if err != nil {
+			entry.Error = status.Convert(err).Message()
+		} else {
+			entry.After = a.encode(resp)
+		}
+		recordAuditLog(ctx, a.writer, entry, a.logger)
+
+		return resp, err
+	}
+}
+
+// encode returns the JSON encoding of a payload with secrets redacted.
+func (a *AuditInterceptor) encode(v any) json.RawMessage {
+	data, err := a.marshal(v)
+	if err != nil {
+		a.logger.Warn().Err(err).Msg("failed to encode audit payload")
+		return nil
+	}
+	return audit.Redact(data)
+}
+
+// marshalAuditPayload encodes proto messages with their JSON field names.
+func marshalAuditPayload(v any) ([]byte, error) {
+	if m, ok := v.(proto.Message); ok {
+		return protojson.Marshal(m)
+	}
+	return json.Marshal(v)
+}
+
+// recordAuditLog writes an audit log. A failed write is logged but does not
+// fail the call, which has already been made.
+func recordAuditLog(ctx context.Context, writer AuditLogWriter, entry *database.AuditLog, logger zerolog.Logger) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditWriteTimeout)
+	defer cancel()
+
+	if err := writer.Create(ctx, entry); err != nil {
+		logger.Error().
+			Err(err).
+			Str("request_id", entry.RequestID).
+			Str("action", entry.Action).
+			Str("actor", entry.Actor).
+			Msg("failed to record audit log")
+	}
+}
+
+// auditActor returns the subject and email of the caller.
+func auditActor(ctx context.Context) (string, string) {
+	claims := GetUserFromContext(ctx)
+	if claims == nil || claims.UserID == "" {
+		return anonymousActor, ""
+	}
+	return claims.UserID, claims.Email
+}
+
+// auditResource returns the type and ID of the resource a call acts on, taken
+// from the most specific ID in the request.
+func auditResource(fullMethod string, req any) (string, string) {
+	if strings.Contains(fullMethod, "AgentPool") {
+		if r, ok := req.(interface{ GetName() string }); ok {
+			return "agent_pool", r.GetName()
+		}
+	}
+
+	switch r := req.(type) {
+	case interface{ GetTestId() string }:
+		return "test_definition", r.GetTestId()
+	case interface{ GetScheduleId() string }:
+		return "schedule", r.GetScheduleId()
+	case interface{ GetRunId() string }:
+		return "run", r.GetRunId()
+	case interface{ GetChannelId() string }:
+		return "channel", r.GetChannelId()
+	case interface{ GetRuleId() string }:
+		return "rule", r.GetRuleId()
+	case interface{ GetDeliveryId() string }:
+		return "notification_delivery", r.GetDeliveryId()
+	case interface{ GetAgentId() string }:
+		return "agent", r.GetAgentId()
+	case interface{ GetViewId() string }:
+		return "saved_view", r.GetViewId()
+	case interface{ GetWindowId() string }:
+		return "maintenance_window", r.GetWindowId()
+	case interface{ GetServiceId() string }:
+		return "service", r.GetServiceId()
+	}
+	return "", ""
+}
+
+// auditSnapshots returns the before state loaders of the calls that update or
+// delete an existing resource. They go through the servers' getters, so the
+// recorded state matches what the API returns.
+func auditSnapshots(services *ServiceRegistryServer, runs *RunServiceServer, agents *AgentManagementServer, notifications *NotificationServiceServer, admin *AdminServiceServer) map[string]auditSnapshot {
+	service := func(ctx context.Context, req any) (any, error) {
+		r, _ := req.(interface{ GetServiceId() string })
+		if r == nil {
+			return nil, nil
+		}
+		return services.GetService(ctx, &conductorv1.GetServiceRequest{ServiceId: r.GetServiceId()})
+	}
+	testDefinition := func(ctx context.Context, req any) (any, error) {
+		r, _ := req.(interface {
+			GetServiceId() string
+			GetTestId() string
+		})
+		if r == nil {
+			return nil, nil
+		}
+		return services.GetTestDefinition(ctx, &conductorv1.GetTestDefinitionRequest{ServiceId: r.GetServiceId(), TestId: r.GetTestId()})
+	}
+	schedule := func(ctx context.Context, req any) (any, error) {
+		r, _ := req.(interface {
+			GetServiceId() string
+			GetScheduleId() string
+		})
+		if r == nil {
+			return nil, nil
+		}
+		return services.GetSchedule(ctx, &conductorv1.GetScheduleRequest{ServiceId: r.GetServiceId(), ScheduleId: r.GetScheduleId()})
+	}
+	quota := func(ctx context.Context, req any) (any, error) {
+		r, _ := req.(interface{ GetServiceId() string })
+		if r == nil {
+			return nil, nil
+		}
+		return services.GetServiceQuota(ctx, &conductorv1.GetServiceQuotaRequest{ServiceId: r.GetServiceId()})
+	}
+	run := func(ctx context.Context, req any) (any, error) {
+		r, _ := req.(interface{ GetRunId() string })
+		if r == nil {
+			return nil, nil
+		}
+		return runs.GetRun(ctx, &conductorv1.GetRunRequest{RunId: r.GetRunId()})
+	}
+	agent := func(ctx context.Context, req any) (any, error) {
+		r, _ := req.(interface{ GetAgentId() string })
+		if r == nil {
+			return nil, nil
+		}
+		return agents.GetAgent(ctx, &conductorv1.GetAgentRequest{AgentId: r.GetAgentId()})
+	}
+	agentPool := func(ctx context.Context, req any) (any, error) {
+		r, _ := req.(interface{ GetName() string })
+		if r == nil {
+			return nil, nil
+		}
+		return agents.GetAgentPool(ctx, &conductorv1.GetAgentPoolRequest{Name: r.GetName()})
+	}
+	channel := func(ctx context.Context, req any) (any, error) {
+		r, _ := req.(interface{ GetChannelId() string })
+		if r == nil {
+			return nil, nil
+		}
+		return notifications.GetChannel(ctx, &conductorv1.GetChannelRequest{ChannelId: r.GetChannelId()})
+	}
+	rule := func(ctx context.Context, req any) (any, error) {
+		r, _ := req.(interface{ GetRuleId() string })
+		if r == nil {
+			return nil, nil
+		}
+		return notifications.GetRule(ctx, &conductorv1.GetRuleRequest{RuleId: r.GetRuleId()})
+	}
+	window := func(ctx context.Context, req any) (any, error) {
+		r, _ := req.(interface{ GetWindowId() string })
+		if r == nil {
+			return nil, nil
+		}
+		return admin.GetMaintenanceWindow(ctx, &conductorv1.GetMaintenanceWindowRequest{WindowId: r.GetWindowId()})
+	}
+
+	return map[string]auditSnapshot{
+		"/conductor.v1.ServiceRegistryService/UpdateService":        service,
+		"/conductor.v1.ServiceRegistryService/DeleteService":        service,
+		"/conductor.v1.ServiceRegistryService/ArchiveService":       service,
+		"/conductor.v1.ServiceRegistryService/UnarchiveService":     service,
+		"/conductor.v1.ServiceRegistryService/UpdateTestDefinition": testDefinition,
+		"/conductor.v1.ServiceRegistryService/UpdateSchedule":       schedule,
+		"/conductor.v1.ServiceRegistryService/DeleteSchedule":       schedule,
+		"/conductor.v1.ServiceRegistryService/SetServiceQuota":      quota,
+		"/conductor.v1.ServiceRegistryService/DeleteServiceQuota":   quota,
+		"/conductor.v1.RunService/CancelRun":                        run,
+		"/conductor.v1.RunService/ApproveRun":                       run,
+		"/conductor.v1.RunService/AnnotateRun":                      run,
+		"/conductor.v1.RunService/RetryRun":                         run,
+		"/conductor.v1.AgentManagementService/DrainAgent":           agent,
+		"/conductor.v1.AgentManagementService/UndrainAgent":         agent,
+		"/conductor.v1.AgentManagementService/DeleteAgent":          agent,
+		"/conductor.v1.AgentManagementService/UpdateAgentPool":      agentPool,
+		"/conductor.v1.AgentManagementService/DeleteAgentPool":      agentPool,
+		"/conductor.v1.NotificationService/UpdateChannel":           channel,
+		"/conductor.v1.NotificationService/DeleteChannel":           channel,
+		"/conductor.v1.NotificationService/UpdateRule":              rule,
+		"/conductor.v1.NotificationService/DeleteRule":              rule,
+		"/conductor.v1.AdminService/UpdateMaintenanceWindow":        window,
+		"/conductor.v1.AdminService/DeleteMaintenanceWindow":        window,
+	}
+}
+
+// grpcSourceIP returns the address of the client. Calls proxied by the
+// gateway come from loopback, so the client address the gateway forwards is
+// used for them instead.
+func grpcSourceIP(ctx context.Context) string {
+	var addr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+
+	var forwarded string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-forwarded-for"); len(values) > 0 {
+			forwarded = values[len(values)-1]
+		}
+	}
+	return sourceIP(addr, forwarded)
+}
+
+// sourceIP returns the host of a remote address. If the address is loopback,
+// the last, i.e. the proxy-appended, X-Forwarded-For entry is used instead.
+func sourceIP(remoteAddr, forwarded string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() && forwarded != "" {
+		entries := strings.Split(forwarded, ",")
+		return strings.TrimSpace(entries[len(entries)-1])
+	}
+	return host
+}
+
+// grpcUserAgent returns the user agent of the client, preferring the one the
+// gateway forwards for REST calls.
+func grpcUserAgent(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, key := range []string{"grpcgateway-user-agent", "user-agent"} {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// auditMiddleware records the HTTP calls handled outside the gateway, such as
+// incoming VCS webhooks. Their payloads are not recorded: they come from the
+// VCS provider and can be large.
+func (s *HTTPServer) auditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		next.ServeHTTP(wrapped, r)
+
+		entry := &database.AuditLog{
+			Action:       r.Method + " " + r.URL.Path,
+			Protocol:     database.AuditProtocolHTTP,
+			ResourceType: "webhook",
+			ResourceID:   path.Base(r.URL.Path),
+			Status:       strconv.Itoa(wrapped.statusCode),
+			SourceIP:     sourceIP(r.RemoteAddr, ""),
+			RequestID:    GetRequestID(r.Context()),
+			UserAgent:    r.UserAgent(),
+		}
+		if wrapped.statusCode >= 400 {
+			entry.Error = http.StatusText(wrapped.statusCode)
+		}
+		entry.Actor, entry.ActorEmail = auditActor(r.Context())
+		recordAuditLog(r.Context(), s.auditLog, entry, s.logger)
+	})
+}
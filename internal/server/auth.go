@@ -9,6 +9,10 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/internal/database"
 )
 
 // JWTValidator validates JWT tokens.
@@ -39,6 +43,10 @@ type UserClaims struct {
 	ExpiresAt time.Time `json:"exp"`
 	// Issuer is who issued the token.
 	Issuer string `json:"iss"`
+	// Organization is the ID of the organization the user belongs to.
+	Organization string `json:"org,omitempty"`
+	// Projects are the IDs of the projects the user is a member of.
+	Projects []string `json:"projects,omitempty"`
 }
 
 // jwtHeader represents the JWT header.
@@ -56,6 +64,8 @@ type jwtClaims struct {
 	IssuedAt  int64    `json:"iat"`
 	ExpiresAt int64    `json:"exp"`
 	Issuer    string   `json:"iss"`
+	Org       string   `json:"org,omitempty"`
+	Projects  []string `json:"projects,omitempty"`
 }
 
 // Validate validates a JWT token and returns the claims.
@@ -114,13 +124,15 @@ func (v *JWTValidator) Validate(token string) (*UserClaims, error) {
 	}
 
 	return &UserClaims{
-		UserID:    claims.Subject,
-		Email:     claims.Email,
-		Name:      claims.Name,
-		Roles:     claims.Roles,
-		IssuedAt:  time.Unix(claims.IssuedAt, 0),
-		ExpiresAt: expiresAt,
-		Issuer:    claims.Issuer,
+		UserID:       claims.Subject,
+		Email:        claims.Email,
+		Name:         claims.Name,
+		Roles:        claims.Roles,
+		IssuedAt:     time.Unix(claims.IssuedAt, 0),
+		ExpiresAt:    expiresAt,
+		Issuer:       claims.Issuer,
+		Organization: claims.Org,
+		Projects:     claims.Projects,
 	}, nil
 }
 
@@ -144,6 +156,8 @@ func (v *JWTValidator) GenerateToken(claims *UserClaims) (string, error) {
 		IssuedAt:  claims.IssuedAt.Unix(),
 		ExpiresAt: claims.ExpiresAt.Unix(),
 		Issuer:    claims.Issuer,
+		Org:       claims.Organization,
+		Projects:  claims.Projects,
 	}
 
 	claimsBytes, err := json.Marshal(rawClaims)
@@ -199,3 +213,32 @@ func (c *UserClaims) HasRole(role string) bool {
 func (c *UserClaims) IsAdmin() bool {
 	return c.HasRole("admin")
 }
+
+// ProjectScope returns the projects the user may access, and false if the
+// user may access every project. Admins and control plane replicas access
+// every project, and users whose token names no project are members of the
+// default project. Project IDs that are not UUIDs are ignored.
+func (c *UserClaims) ProjectScope() ([]uuid.UUID, bool) {
+	if c.IsAdmin() || c.HasRole(replicaRole) {
+		return nil, false
+	}
+	if len(c.Projects) == 0 {
+		return []uuid.UUID{database.DefaultProjectID}, true
+	}
+	ids := make([]uuid.UUID, 0, len(c.Projects))
+	for _, p := range c.Projects {
+		if id, err := uuid.Parse(p); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, true
+}
+
+// OrganizationID returns the organization the user belongs to: the one the
+// token names, or the default organization.
+func (c *UserClaims) OrganizationID() (uuid.UUID, error) {
+	if c.Organization == "" {
+		return database.DefaultOrganizationID, nil
+	}
+	return uuid.Parse(c.Organization)
+}
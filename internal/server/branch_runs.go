@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/google/uuid"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+)
+
+// maxBranchRuns bounds the active runs of a branch a branch run policy
+// looks at.
+const maxBranchRuns = 100
+
+// BranchRunRepository finds the active runs of a branch and moves pending
+// runs to newer commits, for services with a branch run policy.
+type BranchRunRepository interface {
+	Search(ctx context.Context, filter database.RunSearchFilter, page database.Pagination) ([]database.TestRun, int, error)
+	Retarget(ctx context.Context, id uuid.UUID, gitSHA *string) error
+}
+
+// activeBranchRuns returns the waiting, pending and running runs of the
+// branch and pull request of a new run, newest first. Runs without a branch have no
+// branch runs.
+func (s *RunServiceServer) activeBranchRuns(ctx context.Context, serviceID uuid.UUID, ref *conductorv1.GitRef) ([]database.TestRun, error) {
+	branch := ref.GetBranch()
+	if s.deps.BranchRuns == nil || branch == "" {
+		return nil, nil
+	}
+
+	runs, _, err := s.deps.BranchRuns.Search(ctx, database.RunSearchFilter{
+		ServiceID: &serviceID,
+		Statuses:  []database.RunStatus{database.RunStatusWaitingApproval, database.RunStatusPending, database.RunStatusRunning},
+		Branch:    &branch,
+	}, database.Pagination{Limit: maxBranchRuns})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs of branch %s: %w", branch, err)
+	}
+
+	// Pull request runs are kept apart from pushes to their source branch
+	active := runs[:0]
+	for _, run := range runs {
+		var pullRequest int64
+		if run.PullRequestNumber != nil {
+			pullRequest = *run.PullRequestNumber
+		}
+		if pullRequest == ref.GetPullRequestNumber() {
+			active = append(active, run)
+		}
+	}
+	return active, nil
+}
+
+// coalesceRun moves the newest pending run of the branch of a new run to
+// the new commit and returns it, or returns nil if the new run has to be
+// created. Only runs with the same label selector are coalesced, since they
+// are placed on the same agents. Approved runs are not moved to a commit
+// nobody approved, while runs waiting for approval are.
+func (s *RunServiceServer) coalesceRun(ctx context.Context, service *database.Service, req *conductorv1.CreateRunRequest) (*database.TestRun, error) {
+	if service.BranchRunPolicy != database.BranchRunPolicyCoalesce {
+		return nil, nil
+	}
+
+	runs, err := s.activeBranchRuns(ctx, service.ID, req.GetGitRef())
+	if err != nil {
+		return nil, err
+	}
+
+	sha := database.NullString(req.GetGitRef().GetCommitSha())
+	for i := range runs {
+		run := &runs[i]
+		if !coalescable(run) || !maps.Equal(run.LabelSelector, req.LabelSelector) ||
+			!maps.Equal(run.Parameters, req.Parameters) {
+			continue
+		}
+		// The run may have been scheduled since it was listed
+		if err := s.deps.BranchRuns.Retarget(ctx, run.ID, sha); err != nil {
+			if database.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to coalesce into run %s: %w", run.ID, err)
+		}
+		run.GitSHA = sha
+
+		s.logger.Info().
+			Str("run_id", run.ID.String()).
+			Str("service_id", service.ID.String()).
+			Str("branch", req.GetGitRef().GetBranch()).
+			Str("commit_sha", req.GetGitRef().GetCommitSha()).
+			Msg("run coalesced into pending run")
+		return run, nil
+	}
+	return nil, nil
+}
+
+// coalescable reports whether a run of a branch can be moved to a newer
+// commit.
+func coalescable(run *database.TestRun) bool {
+	switch run.Status {
+	case database.RunStatusWaitingApproval:
+		return true
+	case database.RunStatusPending:
+		return run.ApprovedBy == nil
+	}
+	return false
+}
+
+// supersedeRuns cancels the waiting, pending and running runs of the branch of a new
+// run.
+func (s *RunServiceServer) supersedeRuns(ctx context.Context, service *database.Service, run *database.TestRun, ref *conductorv1.GitRef) {
+	if service.BranchRunPolicy != database.BranchRunPolicySupersede {
+		return
+	}
+
+	runs, err := s.activeBranchRuns(ctx, service.ID, ref)
+	if err != nil {
+		s.logger.Error().Err(err).Str("run_id", run.ID.String()).Msg("failed to supersede runs")
+		return
+	}
+
+	reason := fmt.Sprintf("superseded by run %s", run.ID)
+	for _, older := range runs {
+		if older.ID != run.ID {
+			s.cancelOlderRun(ctx, older.ID, run.ID, reason)
+		}
+	}
+}
+
+// cancelOlderRun cancels an active run made obsolete by a newer run. Runs
+// that cannot be cancelled are logged and left to finish.
+func (s *RunServiceServer) cancelOlderRun(ctx context.Context, id, newer uuid.UUID, reason string) {
+	if err := s.deps.Scheduler.CancelWork(ctx, id, reason); err != nil {
+		s.logger.Error().Err(err).Str("run_id", id.String()).Msg("failed to cancel work via scheduler")
+	}
+	if err := s.deps.RunRepo.UpdateStatus(ctx, id, database.RunStatusCancelled, &reason); err != nil {
+		s.logger.Error().Err(err).Str("run_id", id.String()).Msg("failed to cancel superseded run")
+		return
+	}
+	s.logger.Info().
+		Str("run_id", id.String()).
+		Str("superseded_by", newer.String()).
+		Str("reason", reason).
+		Msg("run superseded")
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+)
+
+// branchRunRepo keeps runs in memory and finds them by branch.
+type branchRunRepo struct {
+	RunRepository
+	runs []*database.TestRun
+}
+
+func (r *branchRunRepo) Create(ctx context.Context, run *database.TestRun) error {
+	r.runs = append(r.runs, run)
+	return nil
+}
+
+func (r *branchRunRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status database.RunStatus, errorMsg *string) error {
+	run := r.get(id)
+	if run == nil {
+		return database.ErrNotFound
+	}
+	run.Status = status
+	run.ErrorMessage = errorMsg
+	return nil
+}
+
+func (r *branchRunRepo) Search(ctx context.Context, filter database.RunSearchFilter, page database.Pagination) ([]database.TestRun, int, error) {
+	var found []database.TestRun
+	for i := len(r.runs) - 1; i >= 0; i-- {
+		run := r.runs[i]
+		if run.ServiceID != *filter.ServiceID || stringValue(run.GitRef) != *filter.Branch {
+			continue
+		}
+		for _, status := range filter.Statuses {
+			if run.Status == status {
+				found = append(found, *run)
+			}
+		}
+	}
+	return found, len(found), nil
+}
+
+func (r *branchRunRepo) Retarget(ctx context.Context, id uuid.UUID, gitSHA *string) error {
+	run := r.get(id)
+	if run == nil || (run.Status != database.RunStatusPending && run.Status != database.RunStatusWaitingApproval) {
+		return database.ErrNotFound
+	}
+	run.GitSHA = gitSHA
+	return nil
+}
+
+func (r *branchRunRepo) get(id uuid.UUID) *database.TestRun {
+	for _, run := range r.runs {
+		if run.ID == id {
+			return run
+		}
+	}
+	return nil
+}
+
+// cancellingScheduler records the runs it cancels.
+type cancellingScheduler struct {
+	WorkScheduler
+	cancelled []uuid.UUID
+}
+
+func (s *cancellingScheduler) CancelWork(ctx context.Context, runID uuid.UUID, reason string) error {
+	s.cancelled = append(s.cancelled, runID)
+	return nil
+}
+
+func TestCreateRun_BranchRunPolicy(t *testing.T) {
+	newServer := func(policy database.BranchRunPolicy) (*RunServiceServer, *branchRunRepo, *cancellingScheduler, *database.Service) {
+		service := &database.Service{ID: uuid.New(), Name: "api", BranchRunPolicy: policy}
+		runs := &branchRunRepo{}
+		scheduler := &cancellingScheduler{}
+		return NewRunServiceServer(RunServiceDeps{
+			RunRepo:     runs,
+			ServiceRepo: &placementServiceRepo{service: service},
+			TestRepo:    &placementTestRepo{},
+			Scheduler:   scheduler,
+			BranchRuns:  runs,
+		}, zerolog.Nop()), runs, scheduler, service
+	}
+	push := func(service *database.Service, branch, sha string) *conductorv1.CreateRunRequest {
+		return &conductorv1.CreateRunRequest{
+			ServiceId: service.ID.String(),
+			GitRef:    &conductorv1.GitRef{Branch: branch, CommitSha: sha},
+		}
+	}
+
+	t.Run("allow keeps every run", func(t *testing.T) {
+		server, runs, scheduler, service := newServer(database.BranchRunPolicyAllow)
+
+		_, err := server.CreateRun(context.Background(), push(service, "main", "aaa"))
+		require.NoError(t, err)
+		_, err = server.CreateRun(context.Background(), push(service, "main", "bbb"))
+		require.NoError(t, err)
+
+		require.Len(t, runs.runs, 2)
+		assert.Equal(t, database.RunStatusPending, runs.runs[0].Status)
+		assert.Empty(t, scheduler.cancelled)
+	})
+
+	t.Run("supersede cancels the active runs of the branch", func(t *testing.T) {
+		server, runs, scheduler, service := newServer(database.BranchRunPolicySupersede)
+
+		_, err := server.CreateRun(context.Background(), push(service, "main", "aaa"))
+		require.NoError(t, err)
+		runs.runs[0].Status = database.RunStatusRunning
+		_, err = server.CreateRun(context.Background(), push(service, "feature", "ccc"))
+		require.NoError(t, err)
+		latest, err := server.CreateRun(context.Background(), push(service, "main", "bbb"))
+		require.NoError(t, err)
+
+		require.Len(t, runs.runs, 3)
+		older := runs.runs[0]
+		assert.Equal(t, database.RunStatusCancelled, older.Status)
+		require.NotNil(t, older.ErrorMessage)
+		assert.Contains(t, *older.ErrorMessage, latest.Run.Id)
+		assert.Equal(t, []uuid.UUID{older.ID}, scheduler.cancelled)
+		assert.Equal(t, database.RunStatusPending, runs.runs[1].Status, "other branches are not superseded")
+		assert.Equal(t, database.RunStatusPending, runs.runs[2].Status)
+	})
+
+	t.Run("coalesce moves the pending run to the new commit", func(t *testing.T) {
+		server, runs, _, service := newServer(database.BranchRunPolicyCoalesce)
+
+		first, err := server.CreateRun(context.Background(), push(service, "main", "aaa"))
+		require.NoError(t, err)
+		second, err := server.CreateRun(context.Background(), push(service, "main", "bbb"))
+		require.NoError(t, err)
+
+		require.Len(t, runs.runs, 1)
+		assert.Equal(t, first.Run.Id, second.Run.Id)
+		assert.Equal(t, "bbb", second.Run.GitRef.CommitSha)
+		assert.Equal(t, "bbb", *runs.runs[0].GitSHA)
+
+		// A pull request of the branch is coalesced apart from its pushes
+		pr := push(service, "main", "ccc")
+		pr.GitRef.PullRequestNumber = 7
+		_, err = server.CreateRun(context.Background(), pr)
+		require.NoError(t, err)
+		assert.Len(t, runs.runs, 2)
+	})
+
+	t.Run("coalesce creates a run once the pending run started", func(t *testing.T) {
+		server, runs, _, service := newServer(database.BranchRunPolicyCoalesce)
+
+		_, err := server.CreateRun(context.Background(), push(service, "main", "aaa"))
+		require.NoError(t, err)
+		runs.runs[0].Status = database.RunStatusRunning
+		_, err = server.CreateRun(context.Background(), push(service, "main", "bbb"))
+		require.NoError(t, err)
+
+		require.Len(t, runs.runs, 2)
+		assert.Equal(t, "aaa", *runs.runs[0].GitSHA)
+		assert.Equal(t, "bbb", *runs.runs[1].GitSHA)
+	})
+}
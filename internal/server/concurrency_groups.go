@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/git"
+)
+
+// runConcurrencyGroup returns the concurrency group of a new run of a
+// service on a branch, or nil if the service declares none, and whether the
+// run cancels the runs of the group in progress. Group keys are scoped to
+// the service's project.
+func (s *RunServiceServer) runConcurrencyGroup(ctx context.Context, service *database.Service, branch string) (*string, bool, error) {
+	if s.deps.ConcurrencyRepo == nil {
+		return nil, false, nil
+	}
+
+	concurrency, err := s.deps.ConcurrencyRepo.GetByService(ctx, service.ID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to get concurrency group: %w", err)
+	}
+
+	if branch == "" {
+		branch = service.DefaultBranch
+	}
+	group := service.ProjectID.String() + "/" + git.ExpandConcurrencyGroup(concurrency.Group, service.Name, branch)
+	return &group, concurrency.CancelInProgress, nil
+}
+
+// cancelConcurrencyGroup cancels the pending and running runs of the
+// concurrency group of a new run.
+func (s *RunServiceServer) cancelConcurrencyGroup(ctx context.Context, run *database.TestRun) {
+	runs, err := s.deps.ConcurrencyRepo.ListActive(ctx, *run.ConcurrencyGroup)
+	if err != nil {
+		s.logger.Error().Err(err).Str("run_id", run.ID.String()).Msg("failed to list runs of concurrency group")
+		return
+	}
+
+	reason := fmt.Sprintf("cancelled by run %s of concurrency group %s", run.ID, *run.ConcurrencyGroup)
+	for _, id := range runs {
+		if id != run.ID {
+			s.cancelOlderRun(ctx, id, run.ID, reason)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+)
+
+// concurrencyGroupRepo serves one service's concurrency group and lists the
+// active runs of a run repository.
+type concurrencyGroupRepo struct {
+	database.ConcurrencyGroupRepository
+	concurrency *database.ServiceConcurrency
+	runs        *branchRunRepo
+}
+
+func (r *concurrencyGroupRepo) GetByService(ctx context.Context, serviceID uuid.UUID) (*database.ServiceConcurrency, error) {
+	if r.concurrency == nil || r.concurrency.ServiceID != serviceID {
+		return nil, database.ErrNotFound
+	}
+	return r.concurrency, nil
+}
+
+func (r *concurrencyGroupRepo) ListActive(ctx context.Context, group string) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, run := range r.runs.runs {
+		active := run.Status == database.RunStatusPending || run.Status == database.RunStatusRunning
+		if active && run.ConcurrencyGroup != nil && *run.ConcurrencyGroup == group {
+			ids = append(ids, run.ID)
+		}
+	}
+	return ids, nil
+}
+
+func TestCreateRun_ConcurrencyGroup(t *testing.T) {
+	newServer := func(concurrency *database.ServiceConcurrency) (*RunServiceServer, *branchRunRepo, *cancellingScheduler, *database.Service) {
+		service := &database.Service{ID: uuid.New(), ProjectID: uuid.New(), Name: "api", DefaultBranch: "main"}
+		if concurrency != nil {
+			concurrency.ServiceID = service.ID
+		}
+		runs := &branchRunRepo{}
+		scheduler := &cancellingScheduler{}
+		return NewRunServiceServer(RunServiceDeps{
+			RunRepo:         runs,
+			ServiceRepo:     &placementServiceRepo{service: service},
+			TestRepo:        &placementTestRepo{},
+			Scheduler:       scheduler,
+			ConcurrencyRepo: &concurrencyGroupRepo{concurrency: concurrency, runs: runs},
+		}, zerolog.Nop()), runs, scheduler, service
+	}
+	push := func(service *database.Service, branch string) *conductorv1.CreateRunRequest {
+		return &conductorv1.CreateRunRequest{
+			ServiceId: service.ID.String(),
+			GitRef:    &conductorv1.GitRef{Branch: branch},
+		}
+	}
+
+	t.Run("services without a group create runs without one", func(t *testing.T) {
+		server, runs, _, service := newServer(nil)
+
+		resp, err := server.CreateRun(context.Background(), push(service, "main"))
+		require.NoError(t, err)
+
+		assert.Nil(t, runs.runs[0].ConcurrencyGroup)
+		assert.Empty(t, resp.Run.ConcurrencyGroup)
+	})
+
+	t.Run("groups are expanded and scoped to the project", func(t *testing.T) {
+		server, runs, scheduler, service := newServer(&database.ServiceConcurrency{Group: "deploy-${GIT_BRANCH}"})
+
+		_, err := server.CreateRun(context.Background(), push(service, "release"))
+		require.NoError(t, err)
+		resp, err := server.CreateRun(context.Background(), &conductorv1.CreateRunRequest{ServiceId: service.ID.String()})
+		require.NoError(t, err)
+
+		require.Len(t, runs.runs, 2)
+		assert.Equal(t, service.ProjectID.String()+"/deploy-release", *runs.runs[0].ConcurrencyGroup)
+		assert.Equal(t, service.ProjectID.String()+"/deploy-main", resp.Run.ConcurrencyGroup, "runs without a branch use the default branch")
+		assert.Empty(t, scheduler.cancelled, "runs of a group wait for each other")
+	})
+
+	t.Run("cancel in progress cancels the active runs of the group", func(t *testing.T) {
+		server, runs, scheduler, service := newServer(&database.ServiceConcurrency{Group: "${SERVICE_NAME}", CancelInProgress: true})
+
+		_, err := server.CreateRun(context.Background(), push(service, "main"))
+		require.NoError(t, err)
+		runs.runs[0].Status = database.RunStatusRunning
+		latest, err := server.CreateRun(context.Background(), push(service, "feature"))
+		require.NoError(t, err)
+
+		require.Len(t, runs.runs, 2)
+		older := runs.runs[0]
+		assert.Equal(t, database.RunStatusCancelled, older.Status)
+		require.NotNil(t, older.ErrorMessage)
+		assert.Contains(t, *older.ErrorMessage, latest.Run.Id)
+		assert.Equal(t, []uuid.UUID{older.ID}, scheduler.cancelled)
+		assert.Equal(t, database.RunStatusPending, runs.runs[1].Status)
+	})
+}
@@ -15,6 +15,10 @@ import (
 	"google.golang.org/grpc/reflection"
 
 	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	// Registers the compressors agents may send with; responses use the
+	// agent's compressor.
+	_ "github.com/conductor/conductor/pkg/compression"
 	"github.com/conductor/conductor/pkg/metrics"
 	"github.com/conductor/conductor/pkg/tracing"
 )
@@ -33,6 +37,10 @@ type GRPCConfig struct {
 	EnableTracing bool
 	// Metrics is the control plane metrics instance for recording gRPC metrics.
 	Metrics *metrics.ControlPlaneMetrics
+	// AuditLog records mutating calls in the audit log. Nil disables auditing.
+	AuditLog AuditLogWriter
+	// RateLimiter limits the calls of each client. Nil disables rate limiting.
+	RateLimiter *RateLimiter
 }
 
 // DefaultGRPCConfig returns sensible defaults for gRPC server configuration.
@@ -55,6 +63,11 @@ type Services struct {
 	ResultService       ResultServiceDeps
 	HealthService       HealthServiceDeps
 	NotificationService NotificationServiceDeps
+	ReportService       ReportServiceDeps
+	AuditService        AuditServiceDeps
+	AdminService        AdminServiceDeps
+	TenancyService      TenancyServiceDeps
+	PreferencesService  PreferencesServiceDeps
 }
 
 // GRPCServer wraps a gRPC server with Conductor services.
@@ -67,14 +80,23 @@ type GRPCServer struct {
 	// Service implementations
 	agentService          *AgentServiceServer
 	agentManagementServer *AgentManagementServer
+	agentRoutingServer    *AgentRoutingServer
 	runServer             *RunServiceServer
 	serviceRegistryServer *ServiceRegistryServer
 	resultServer          *ResultServiceServer
 	healthServer          *HealthServiceServer
 	notificationServer    *NotificationServiceServer
+	reportServer          *ReportServiceServer
+	auditServer           *AuditServiceServer
+	adminServer           *AdminServiceServer
+	tenancyServer         *TenancyServiceServer
+	preferencesServer     *PreferencesServiceServer
 
 	// gRPC health server
 	grpcHealth *health.Server
+
+	// peers are the connections to the other replicas (optional).
+	peers *replicaPeers
 }
 
 // NewGRPCServer creates a new gRPC server with the provided configuration and services.
@@ -83,9 +105,10 @@ func NewGRPCServer(cfg GRPCConfig, services Services, jwtValidator *JWTValidator
 	loggingInterceptor := NewLoggingInterceptor(logger)
 	recoveryInterceptor := NewRecoveryInterceptor(logger)
 	authInterceptor := NewAuthInterceptor(jwtValidator, logger)
+	validationInterceptor := NewValidationInterceptor()
 
 	// Build unary interceptor chain
-	// Order: recovery -> tracing -> metrics -> logging -> auth
+	// Order: recovery -> tracing -> metrics -> logging -> rate limit -> auth -> validation -> audit -> replica reads -> tenancy
 	unaryInterceptors := []grpc.UnaryServerInterceptor{
 		recoveryInterceptor.Unary(),
 	}
@@ -100,13 +123,34 @@ func NewGRPCServer(cfg GRPCConfig, services Services, jwtValidator *JWTValidator
 		unaryInterceptors = append(unaryInterceptors, newMetricsUnaryInterceptor(cfg.Metrics))
 	}
 
+	unaryInterceptors = append(unaryInterceptors, loggingInterceptor.Unary())
+
+	// Add rate limit interceptor if a rate limiter is configured
+	if cfg.RateLimiter != nil {
+		unaryInterceptors = append(unaryInterceptors, newRateLimitUnaryInterceptor(cfg.RateLimiter, cfg.Metrics))
+	}
+
 	unaryInterceptors = append(unaryInterceptors,
-		loggingInterceptor.Unary(),
 		authInterceptor.Unary(),
+		validationInterceptor.Unary(),
 	)
 
+	// Add audit interceptor if an audit log is configured
+	var auditInterceptor *AuditInterceptor
+	if cfg.AuditLog != nil {
+		auditInterceptor = NewAuditInterceptor(cfg.AuditLog, logger)
+		unaryInterceptors = append(unaryInterceptors, auditInterceptor.Unary())
+	}
+
+	// Let read-only calls read from the database read replica, if any
+	unaryInterceptors = append(unaryInterceptors, newReplicaReadsUnaryInterceptor())
+
+	// Limit calls naming a resource to the caller's projects
+	tenancyInterceptor := NewTenancyInterceptor()
+	unaryInterceptors = append(unaryInterceptors, tenancyInterceptor.Unary())
+
 	// Build stream interceptor chain
-	// Order: recovery -> tracing -> metrics -> logging -> auth
+	// Order: recovery -> tracing -> metrics -> logging -> rate limit -> auth -> validation
 	streamInterceptors := []grpc.StreamServerInterceptor{
 		recoveryInterceptor.Stream(),
 	}
@@ -121,9 +165,16 @@ func NewGRPCServer(cfg GRPCConfig, services Services, jwtValidator *JWTValidator
 		streamInterceptors = append(streamInterceptors, newMetricsStreamInterceptor(cfg.Metrics))
 	}
 
+	streamInterceptors = append(streamInterceptors, loggingInterceptor.Stream())
+
+	// Add rate limit interceptor if a rate limiter is configured
+	if cfg.RateLimiter != nil {
+		streamInterceptors = append(streamInterceptors, newRateLimitStreamInterceptor(cfg.RateLimiter, cfg.Metrics))
+	}
+
 	streamInterceptors = append(streamInterceptors,
-		loggingInterceptor.Stream(),
 		authInterceptor.Stream(),
+		validationInterceptor.Stream(),
 	)
 
 	// Build server options
@@ -150,20 +201,43 @@ func NewGRPCServer(cfg GRPCConfig, services Services, jwtValidator *JWTValidator
 	// Create service implementations
 	agentService := NewAgentServiceServer(services.AgentService, logger)
 	agentMgmtServer := NewAgentManagementServer(services.AgentService, logger)
+	agentMgmtServer.live = agentService
+	agentRoutingServer := NewAgentRoutingServer(agentService, logger)
+	var peers *replicaPeers
+	if services.AgentService.Connections != nil {
+		peers = newReplicaPeers(jwtValidator, services.AgentService.ReplicaID)
+		agentService.peers = peers
+	}
 	runServer := NewRunServiceServer(services.RunService, logger)
 	serviceRegistryServer := NewServiceRegistryServer(services.ServiceService, logger)
 	resultServer := NewResultServiceServer(services.ResultService, logger)
 	healthServer := NewHealthServiceServer(services.HealthService, logger)
 	notificationServer := NewNotificationServiceServer(services.NotificationService, logger)
+	reportServer := NewReportServiceServer(services.ReportService, logger)
+	auditServer := NewAuditServiceServer(services.AuditService, logger)
+	adminServer := NewAdminServiceServer(services.AdminService, logger)
+	tenancyServer := NewTenancyServiceServer(services.TenancyService, logger)
+	preferencesServer := NewPreferencesServiceServer(services.PreferencesService, logger)
+
+	if auditInterceptor != nil {
+		auditInterceptor.snapshots = auditSnapshots(serviceRegistryServer, runServer, agentMgmtServer, notificationServer, adminServer)
+	}
+	tenancyInterceptor.checks = tenancyChecks(serviceRegistryServer, runServer, agentMgmtServer, notificationServer)
 
 	// Register services
 	conductorv1.RegisterAgentServiceServer(server, agentService)
 	conductorv1.RegisterAgentManagementServiceServer(server, agentMgmtServer)
+	conductorv1.RegisterAgentRoutingServiceServer(server, agentRoutingServer)
 	conductorv1.RegisterRunServiceServer(server, runServer)
 	conductorv1.RegisterServiceRegistryServiceServer(server, serviceRegistryServer)
 	conductorv1.RegisterResultServiceServer(server, resultServer)
 	conductorv1.RegisterHealthServiceServer(server, healthServer)
 	conductorv1.RegisterNotificationServiceServer(server, notificationServer)
+	conductorv1.RegisterReportServiceServer(server, reportServer)
+	conductorv1.RegisterAuditServiceServer(server, auditServer)
+	conductorv1.RegisterAdminServiceServer(server, adminServer)
+	conductorv1.RegisterTenancyServiceServer(server, tenancyServer)
+	conductorv1.RegisterPreferencesServiceServer(server, preferencesServer)
 
 	// Register gRPC health service
 	grpcHealth := health.NewServer()
@@ -180,12 +254,19 @@ func NewGRPCServer(cfg GRPCConfig, services Services, jwtValidator *JWTValidator
 		logger:                logger.With().Str("component", "grpc_server").Logger(),
 		agentService:          agentService,
 		agentManagementServer: agentMgmtServer,
+		agentRoutingServer:    agentRoutingServer,
 		runServer:             runServer,
 		serviceRegistryServer: serviceRegistryServer,
 		resultServer:          resultServer,
 		healthServer:          healthServer,
 		notificationServer:    notificationServer,
+		reportServer:          reportServer,
+		auditServer:           auditServer,
+		adminServer:           adminServer,
+		tenancyServer:         tenancyServer,
+		preferencesServer:     preferencesServer,
 		grpcHealth:            grpcHealth,
+		peers:                 peers,
 	}
 }
 
@@ -202,17 +283,26 @@ func (s *GRPCServer) Start(ctx context.Context) error {
 	s.grpcHealth.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
 	s.grpcHealth.SetServingStatus("conductor.v1.AgentService", healthpb.HealthCheckResponse_SERVING)
 	s.grpcHealth.SetServingStatus("conductor.v1.AgentManagementService", healthpb.HealthCheckResponse_SERVING)
+	s.grpcHealth.SetServingStatus("conductor.v1.AgentRoutingService", healthpb.HealthCheckResponse_SERVING)
 	s.grpcHealth.SetServingStatus("conductor.v1.RunService", healthpb.HealthCheckResponse_SERVING)
 	s.grpcHealth.SetServingStatus("conductor.v1.ServiceRegistryService", healthpb.HealthCheckResponse_SERVING)
 	s.grpcHealth.SetServingStatus("conductor.v1.ResultService", healthpb.HealthCheckResponse_SERVING)
 	s.grpcHealth.SetServingStatus("conductor.v1.HealthService", healthpb.HealthCheckResponse_SERVING)
 	s.grpcHealth.SetServingStatus("conductor.v1.NotificationService", healthpb.HealthCheckResponse_SERVING)
+	s.grpcHealth.SetServingStatus("conductor.v1.ReportService", healthpb.HealthCheckResponse_SERVING)
+	s.grpcHealth.SetServingStatus("conductor.v1.AuditService", healthpb.HealthCheckResponse_SERVING)
+	s.grpcHealth.SetServingStatus("conductor.v1.AdminService", healthpb.HealthCheckResponse_SERVING)
+	s.grpcHealth.SetServingStatus("conductor.v1.TenancyService", healthpb.HealthCheckResponse_SERVING)
+	s.grpcHealth.SetServingStatus("conductor.v1.PreferencesService", healthpb.HealthCheckResponse_SERVING)
 
 	s.logger.Info().
 		Str("address", addr).
 		Bool("reflection", s.config.EnableReflection).
 		Msg("starting gRPC server")
 
+	// Stop serving through the gRPC health protocol while not ready
+	go s.healthServer.watchReadiness(ctx, s.grpcHealth, readinessCheckInterval)
+
 	// Start server in a goroutine
 	errCh := make(chan error, 1)
 	go func() {
@@ -242,6 +332,10 @@ func (s *GRPCServer) Stop(ctx context.Context) error {
 	// Set all services as not serving
 	s.grpcHealth.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
 
+	if s.peers != nil {
+		defer s.peers.close()
+	}
+
 	// Create a channel to signal when graceful stop is complete
 	done := make(chan struct{})
 	go func() {
@@ -261,6 +355,25 @@ func (s *GRPCServer) Stop(ctx context.Context) error {
 	}
 }
 
+// Drain moves the agents connected to this replica to the other replicas
+// before Stop: the replica reports itself as not serving, asks its agents to
+// reconnect after a random delay of up to jitter, and waits until they have
+// closed their work streams or ctx is done.
+func (s *GRPCServer) Drain(ctx context.Context, jitter time.Duration) error {
+	s.logger.Info().Msg("draining gRPC server")
+
+	s.grpcHealth.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	s.grpcHealth.SetServingStatus("conductor.v1.AgentService", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	return s.agentService.Drain(ctx, jitter)
+}
+
+// AgentStreams returns statistics of the work streams of the agents
+// connected to this replica.
+func (s *GRPCServer) AgentStreams() AgentStreamStats {
+	return s.agentService.StreamStats()
+}
+
 // Address returns the address the server is listening on.
 // Returns empty string if server is not started.
 func (s *GRPCServer) Address() string {
@@ -275,6 +388,24 @@ func (s *GRPCServer) Server() *grpc.Server {
 	return s.server
 }
 
+// newReplicaReadsUnaryInterceptor creates a unary interceptor that lets the
+// read-only calls, the same ones the audit log skips, read from the database
+// read replica. Calls that write read from the primary, so they see their
+// own writes.
+func newReplicaReadsUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if !isMutatingMethod(info.FullMethod) {
+			ctx = database.WithReplicaReads(ctx)
+		}
+		return handler(ctx, req)
+	}
+}
+
 // newMetricsUnaryInterceptor creates a unary interceptor that records gRPC metrics.
 func newMetricsUnaryInterceptor(m *metrics.ControlPlaneMetrics) grpc.UnaryServerInterceptor {
 	return func(
@@ -0,0 +1,465 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/schedule"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// AdminServiceDeps defines the dependencies for the admin service.
+type AdminServiceDeps struct {
+	// Pauses stores the pauses of scheduling.
+	Pauses database.SchedulingPauseRepository
+	// Maintenance stores the maintenance windows (optional).
+	Maintenance database.MaintenanceWindowRepository
+}
+
+// AdminServiceServer implements the AdminService gRPC service.
+type AdminServiceServer struct {
+	conductorv1.UnimplementedAdminServiceServer
+
+	deps   AdminServiceDeps
+	logger zerolog.Logger
+}
+
+// NewAdminServiceServer creates a new admin service server.
+func NewAdminServiceServer(deps AdminServiceDeps, logger zerolog.Logger) *AdminServiceServer {
+	return &AdminServiceServer{
+		deps:   deps,
+		logger: logger.With().Str("service", "AdminService").Logger(),
+	}
+}
+
+// requireAdmin checks that the caller has the admin role.
+func (s *AdminServiceServer) requireAdmin(ctx context.Context) (*UserClaims, error) {
+	claims := GetUserFromContext(ctx)
+	if claims == nil || !claims.IsAdmin() {
+		return nil, errcode.New(errcode.PermissionDenied, "operating the control plane requires the admin role")
+	}
+	return claims, nil
+}
+
+// requirePauses checks that the caller has the admin role and that pausing
+// scheduling is configured.
+func (s *AdminServiceServer) requirePauses(ctx context.Context) (*UserClaims, error) {
+	claims, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.deps.Pauses == nil {
+		return nil, errcode.New(errcode.NotConfigured, "pausing scheduling is not configured")
+	}
+	return claims, nil
+}
+
+// requireMaintenance checks that the caller has the admin role and that
+// maintenance windows are configured.
+func (s *AdminServiceServer) requireMaintenance(ctx context.Context) (*UserClaims, error) {
+	claims, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.deps.Maintenance == nil {
+		return nil, errcode.New(errcode.NotConfigured, "maintenance windows are not configured")
+	}
+	return claims, nil
+}
+
+// GetSchedulingStatus returns whether scheduling is paused, and the
+// maintenance windows active now.
+func (s *AdminServiceServer) GetSchedulingStatus(ctx context.Context, req *conductorv1.GetSchedulingStatusRequest) (*conductorv1.GetSchedulingStatusResponse, error) {
+	if _, err := s.requirePauses(ctx); err != nil {
+		return nil, err
+	}
+
+	pauses, err := s.deps.Pauses.List(ctx)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list scheduling pauses: %v", err)
+	}
+
+	resp := &conductorv1.GetSchedulingStatusResponse{}
+	for i := range pauses {
+		pause := schedulingPauseToProto(&pauses[i])
+		if pauses[i].ServiceID == nil {
+			resp.Paused = true
+			resp.GlobalPause = pause
+			continue
+		}
+		resp.ServicePauses = append(resp.ServicePauses, pause)
+	}
+
+	if s.deps.Maintenance != nil {
+		now := time.Now()
+		windows, err := schedule.ActiveMaintenance(ctx, s.deps.Maintenance, now)
+		if err != nil {
+			return nil, errcode.New(errcode.Internal, "%v", err)
+		}
+		for _, window := range windows {
+			resp.ActiveWindows = append(resp.ActiveWindows, maintenanceWindowToProto(window, now))
+		}
+	}
+	return resp, nil
+}
+
+// PauseScheduling pauses scheduling globally or for a service.
+func (s *AdminServiceServer) PauseScheduling(ctx context.Context, req *conductorv1.PauseSchedulingRequest) (*conductorv1.PauseSchedulingResponse, error) {
+	claims, err := s.requirePauses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	serviceID, err := optionalServiceID(req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+
+	pause := &database.SchedulingPause{
+		ServiceID: serviceID,
+		Reason:    req.Reason,
+		PausedBy:  claims.UserID,
+	}
+	if err := s.deps.Pauses.Pause(ctx, pause); err != nil {
+		if database.IsForeignKeyViolation(err) {
+			return nil, errcode.New(errcode.ServiceNotFound, "service not found: %s", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to pause scheduling: %v", err)
+	}
+
+	s.logger.Info().
+		Str("service_id", req.ServiceId).
+		Str("reason", req.Reason).
+		Str("paused_by", claims.UserID).
+		Msg("scheduling paused")
+
+	return &conductorv1.PauseSchedulingResponse{Pause: schedulingPauseToProto(pause)}, nil
+}
+
+// ResumeScheduling resumes scheduling globally or for a service.
+func (s *AdminServiceServer) ResumeScheduling(ctx context.Context, req *conductorv1.ResumeSchedulingRequest) (*conductorv1.ResumeSchedulingResponse, error) {
+	claims, err := s.requirePauses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	serviceID, err := optionalServiceID(req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.deps.Pauses.Resume(ctx, serviceID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.FailedPrecondition, "scheduling is not paused")
+		}
+		return nil, errcode.New(errcode.Internal, "failed to resume scheduling: %v", err)
+	}
+
+	s.logger.Info().
+		Str("service_id", req.ServiceId).
+		Str("resumed_by", claims.UserID).
+		Msg("scheduling resumed")
+
+	return &conductorv1.ResumeSchedulingResponse{}, nil
+}
+
+// optionalServiceID parses a service ID, returning nil if it is empty.
+func optionalServiceID(id string) (*uuid.UUID, error) {
+	if id == "" {
+		return nil, nil
+	}
+	serviceID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+	return &serviceID, nil
+}
+
+// schedulingPauseToProto converts a scheduling pause to its proto form.
+func schedulingPauseToProto(p *database.SchedulingPause) *conductorv1.SchedulingPause {
+	pause := &conductorv1.SchedulingPause{
+		Reason:   p.Reason,
+		PausedBy: p.PausedBy,
+		PausedAt: timestamppb.New(p.PausedAt),
+	}
+	if p.ServiceID != nil {
+		pause.ServiceId = p.ServiceID.String()
+	}
+	return pause
+}
+
+// ListMaintenanceWindows returns all maintenance windows by name.
+func (s *AdminServiceServer) ListMaintenanceWindows(ctx context.Context, req *conductorv1.ListMaintenanceWindowsRequest) (*conductorv1.ListMaintenanceWindowsResponse, error) {
+	if _, err := s.requireMaintenance(ctx); err != nil {
+		return nil, err
+	}
+
+	windows, err := s.deps.Maintenance.List(ctx)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list maintenance windows: %v", err)
+	}
+
+	now := time.Now()
+	resp := &conductorv1.ListMaintenanceWindowsResponse{}
+	for i := range windows {
+		resp.Windows = append(resp.Windows, s.storedWindowToProto(&windows[i], now))
+	}
+	return resp, nil
+}
+
+// GetMaintenanceWindow retrieves a maintenance window.
+func (s *AdminServiceServer) GetMaintenanceWindow(ctx context.Context, req *conductorv1.GetMaintenanceWindowRequest) (*conductorv1.GetMaintenanceWindowResponse, error) {
+	if _, err := s.requireMaintenance(ctx); err != nil {
+		return nil, err
+	}
+
+	window, err := s.getWindow(ctx, req.WindowId)
+	if err != nil {
+		return nil, err
+	}
+	return &conductorv1.GetMaintenanceWindowResponse{Window: s.storedWindowToProto(window, time.Now())}, nil
+}
+
+// CreateMaintenanceWindow creates a maintenance window.
+func (s *AdminServiceServer) CreateMaintenanceWindow(ctx context.Context, req *conductorv1.CreateMaintenanceWindowRequest) (*conductorv1.CreateMaintenanceWindowResponse, error) {
+	claims, err := s.requireMaintenance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	serviceID, err := optionalServiceID(req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+
+	window := &database.MaintenanceWindow{
+		Name:            req.Name,
+		Description:     database.NullString(req.Description),
+		ServiceID:       serviceID,
+		AgentPool:       database.NullString(req.AgentPool),
+		CronExpression:  database.NullString(req.CronExpression),
+		Timezone:        req.Timezone,
+		DurationSeconds: int(req.DurationSeconds),
+		StartsAt:        optionalTime(req.StartsAt),
+		EndsAt:          optionalTime(req.EndsAt),
+		Notifications:   maintenanceNotificationsFromProto(req.Notifications),
+		Enabled:         !req.Disabled,
+		CreatedBy:       claims.UserID,
+	}
+	parsed, err := parseMaintenanceWindow(window)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.deps.Maintenance.Create(ctx, window); err != nil {
+		if database.IsForeignKeyViolation(err) {
+			return nil, errcode.New(errcode.ServiceNotFound, "service not found: %s", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to create maintenance window: %v", err)
+	}
+
+	s.logger.Info().
+		Str("window_id", window.ID.String()).
+		Str("name", window.Name).
+		Str("created_by", claims.UserID).
+		Msg("maintenance window created")
+
+	return &conductorv1.CreateMaintenanceWindowResponse{Window: maintenanceWindowToProto(parsed, time.Now())}, nil
+}
+
+// UpdateMaintenanceWindow updates a maintenance window.
+func (s *AdminServiceServer) UpdateMaintenanceWindow(ctx context.Context, req *conductorv1.UpdateMaintenanceWindowRequest) (*conductorv1.UpdateMaintenanceWindowResponse, error) {
+	if _, err := s.requireMaintenance(ctx); err != nil {
+		return nil, err
+	}
+
+	window, err := s.getWindow(ctx, req.WindowId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			return nil, errcode.New(errcode.InvalidArgument, "name must not be empty")
+		}
+		window.Name = *req.Name
+	}
+	if req.Description != nil {
+		window.Description = database.NullString(*req.Description)
+	}
+	if req.CronExpression != nil {
+		window.CronExpression = database.NullString(*req.CronExpression)
+	}
+	if req.Timezone != nil {
+		window.Timezone = *req.Timezone
+	}
+	if req.DurationSeconds != nil {
+		window.DurationSeconds = int(*req.DurationSeconds)
+	}
+	if req.StartsAt != nil {
+		window.StartsAt = optionalTime(req.StartsAt)
+	}
+	if req.EndsAt != nil {
+		window.EndsAt = optionalTime(req.EndsAt)
+	}
+	if req.Notifications != conductorv1.MaintenanceNotifications_MAINTENANCE_NOTIFICATIONS_UNSPECIFIED {
+		window.Notifications = maintenanceNotificationsFromProto(req.Notifications)
+	}
+	if req.Enabled != nil {
+		window.Enabled = *req.Enabled
+	}
+	parsed, err := parseMaintenanceWindow(window)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.deps.Maintenance.Update(ctx, window); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.NotFound, "maintenance window not found: %s", req.WindowId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to update maintenance window: %v", err)
+	}
+
+	s.logger.Info().
+		Str("window_id", window.ID.String()).
+		Bool("enabled", window.Enabled).
+		Msg("maintenance window updated")
+
+	return &conductorv1.UpdateMaintenanceWindowResponse{Window: maintenanceWindowToProto(parsed, time.Now())}, nil
+}
+
+// DeleteMaintenanceWindow deletes a maintenance window.
+func (s *AdminServiceServer) DeleteMaintenanceWindow(ctx context.Context, req *conductorv1.DeleteMaintenanceWindowRequest) (*conductorv1.DeleteMaintenanceWindowResponse, error) {
+	if _, err := s.requireMaintenance(ctx); err != nil {
+		return nil, err
+	}
+	windowID, err := uuid.Parse(req.WindowId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid window ID: %v", err)
+	}
+
+	if err := s.deps.Maintenance.Delete(ctx, windowID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.NotFound, "maintenance window not found: %s", req.WindowId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to delete maintenance window: %v", err)
+	}
+
+	s.logger.Info().Str("window_id", req.WindowId).Msg("maintenance window deleted")
+
+	return &conductorv1.DeleteMaintenanceWindowResponse{}, nil
+}
+
+// getWindow looks up a maintenance window by its ID string.
+func (s *AdminServiceServer) getWindow(ctx context.Context, id string) (*database.MaintenanceWindow, error) {
+	windowID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid window ID: %v", err)
+	}
+
+	window, err := s.deps.Maintenance.Get(ctx, windowID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.NotFound, "maintenance window not found: %s", id)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get maintenance window: %v", err)
+	}
+	return window, nil
+}
+
+// storedWindowToProto converts a stored maintenance window. Windows stored
+// before their fields were validated may not parse; they are returned
+// without their occurrences.
+func (s *AdminServiceServer) storedWindowToProto(w *database.MaintenanceWindow, now time.Time) *conductorv1.MaintenanceWindow {
+	parsed, err := schedule.ParseWindow(w)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("window_id", w.ID.String()).Msg("invalid stored maintenance window")
+		return storedMaintenanceWindowToProto(w)
+	}
+	return maintenanceWindowToProto(parsed, now)
+}
+
+// parseMaintenanceWindow validates a maintenance window.
+func parseMaintenanceWindow(w *database.MaintenanceWindow) (*schedule.Window, error) {
+	parsed, err := schedule.ParseWindow(w)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "%v", err)
+	}
+	return parsed, nil
+}
+
+// optionalTime converts a timestamp, returning nil if it is not set.
+func optionalTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+// maintenanceNotificationsFromProto converts the notification handling of a
+// maintenance window; unspecified suppresses notifications.
+func maintenanceNotificationsFromProto(n conductorv1.MaintenanceNotifications) database.MaintenanceNotifications {
+	if n == conductorv1.MaintenanceNotifications_MAINTENANCE_NOTIFICATIONS_TAG {
+		return database.MaintenanceNotificationsTag
+	}
+	return database.MaintenanceNotificationsSuppress
+}
+
+// maintenanceWindowToProto converts a maintenance window to its proto form,
+// with its occurrence at now.
+func maintenanceWindowToProto(w *schedule.Window, now time.Time) *conductorv1.MaintenanceWindow {
+	window := storedMaintenanceWindowToProto(w.MaintenanceWindow)
+	if !w.Enabled {
+		return window
+	}
+	if _, end, ok := w.Active(now); ok {
+		window.Active = true
+		window.ActiveUntil = timestamppb.New(end)
+	}
+	if next := w.Next(now); !next.IsZero() {
+		window.NextStart = timestamppb.New(next)
+	}
+	return window
+}
+
+// storedMaintenanceWindowToProto converts the stored fields of a maintenance
+// window to its proto form.
+func storedMaintenanceWindowToProto(w *database.MaintenanceWindow) *conductorv1.MaintenanceWindow {
+	window := &conductorv1.MaintenanceWindow{
+		Id:              w.ID.String(),
+		Name:            w.Name,
+		Timezone:        w.Timezone,
+		DurationSeconds: int32(w.DurationSeconds),
+		Notifications:   conductorv1.MaintenanceNotifications_MAINTENANCE_NOTIFICATIONS_SUPPRESS,
+		Enabled:         w.Enabled,
+		CreatedBy:       w.CreatedBy,
+		CreatedAt:       timestamppb.New(w.CreatedAt),
+		UpdatedAt:       timestamppb.New(w.UpdatedAt),
+	}
+	if w.Description != nil {
+		window.Description = *w.Description
+	}
+	if w.ServiceID != nil {
+		window.ServiceId = w.ServiceID.String()
+	}
+	if w.AgentPool != nil {
+		window.AgentPool = *w.AgentPool
+	}
+	if w.CronExpression != nil {
+		window.CronExpression = *w.CronExpression
+	}
+	if w.StartsAt != nil {
+		window.StartsAt = timestamppb.New(*w.StartsAt)
+	}
+	if w.EndsAt != nil {
+		window.EndsAt = timestamppb.New(*w.EndsAt)
+	}
+	if w.Notifications == database.MaintenanceNotificationsTag {
+		window.Notifications = conductorv1.MaintenanceNotifications_MAINTENANCE_NOTIFICATIONS_TAG
+	}
+	return window
+}
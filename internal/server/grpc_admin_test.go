@@ -0,0 +1,241 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// fakeSchedulingPauses stores pauses in memory, keyed by service ID with
+// uuid.Nil for the global pause.
+type fakeSchedulingPauses struct {
+	pauses map[uuid.UUID]database.SchedulingPause
+}
+
+func (f *fakeSchedulingPauses) Pause(ctx context.Context, pause *database.SchedulingPause) error {
+	key := uuid.Nil
+	if pause.ServiceID != nil {
+		key = *pause.ServiceID
+	}
+	pause.ID = uuid.New()
+	pause.PausedAt = time.Now()
+	f.pauses[key] = *pause
+	return nil
+}
+
+func (f *fakeSchedulingPauses) Resume(ctx context.Context, serviceID *uuid.UUID) error {
+	key := uuid.Nil
+	if serviceID != nil {
+		key = *serviceID
+	}
+	if _, ok := f.pauses[key]; !ok {
+		return database.ErrNotFound
+	}
+	delete(f.pauses, key)
+	return nil
+}
+
+func (f *fakeSchedulingPauses) List(ctx context.Context) ([]database.SchedulingPause, error) {
+	var pauses []database.SchedulingPause
+	for _, pause := range f.pauses {
+		pauses = append(pauses, pause)
+	}
+	return pauses, nil
+}
+
+func TestAdminSchedulingPauses(t *testing.T) {
+	pauses := &fakeSchedulingPauses{pauses: make(map[uuid.UUID]database.SchedulingPause)}
+	server := NewAdminServiceServer(AdminServiceDeps{Pauses: pauses}, zerolog.Nop())
+	admin := withUser(context.Background(), &UserClaims{UserID: "admin", Roles: []string{"admin"}})
+	serviceID := uuid.NewString()
+
+	t.Run("requires admin", func(t *testing.T) {
+		viewer := withUser(context.Background(), &UserClaims{UserID: "u-2", Roles: []string{"viewer"}})
+		_, err := server.PauseScheduling(viewer, &conductorv1.PauseSchedulingRequest{})
+		assert.Equal(t, errcode.PermissionDenied, errcode.FromError(err))
+
+		_, err = server.GetSchedulingStatus(context.Background(), &conductorv1.GetSchedulingStatusRequest{})
+		assert.Equal(t, errcode.PermissionDenied, errcode.FromError(err))
+	})
+
+	t.Run("pauses globally and per service", func(t *testing.T) {
+		resp, err := server.PauseScheduling(admin, &conductorv1.PauseSchedulingRequest{Reason: "database upgrade"})
+		require.NoError(t, err)
+		assert.Equal(t, "admin", resp.Pause.PausedBy)
+		assert.Empty(t, resp.Pause.ServiceId)
+
+		_, err = server.PauseScheduling(admin, &conductorv1.PauseSchedulingRequest{ServiceId: serviceID})
+		require.NoError(t, err)
+
+		status, err := server.GetSchedulingStatus(admin, &conductorv1.GetSchedulingStatusRequest{})
+		require.NoError(t, err)
+		assert.True(t, status.Paused)
+		assert.Equal(t, "database upgrade", status.GlobalPause.GetReason())
+		require.Len(t, status.ServicePauses, 1)
+		assert.Equal(t, serviceID, status.ServicePauses[0].ServiceId)
+	})
+
+	t.Run("resumes globally without resuming services", func(t *testing.T) {
+		_, err := server.ResumeScheduling(admin, &conductorv1.ResumeSchedulingRequest{})
+		require.NoError(t, err)
+
+		status, err := server.GetSchedulingStatus(admin, &conductorv1.GetSchedulingStatusRequest{})
+		require.NoError(t, err)
+		assert.False(t, status.Paused)
+		assert.Nil(t, status.GlobalPause)
+		assert.Len(t, status.ServicePauses, 1)
+
+		_, err = server.ResumeScheduling(admin, &conductorv1.ResumeSchedulingRequest{})
+		assert.Equal(t, errcode.FailedPrecondition, errcode.FromError(err))
+	})
+
+	t.Run("rejects invalid service IDs", func(t *testing.T) {
+		_, err := server.PauseScheduling(admin, &conductorv1.PauseSchedulingRequest{ServiceId: "api"})
+		assert.Equal(t, errcode.InvalidArgument, errcode.FromError(err))
+	})
+}
+
+// fakeMaintenanceWindows stores maintenance windows in memory.
+type fakeMaintenanceWindows struct {
+	windows map[uuid.UUID]database.MaintenanceWindow
+}
+
+func (f *fakeMaintenanceWindows) Create(ctx context.Context, window *database.MaintenanceWindow) error {
+	window.ID = uuid.New()
+	window.CreatedAt = time.Now()
+	window.UpdatedAt = window.CreatedAt
+	f.windows[window.ID] = *window
+	return nil
+}
+
+func (f *fakeMaintenanceWindows) Get(ctx context.Context, id uuid.UUID) (*database.MaintenanceWindow, error) {
+	window, ok := f.windows[id]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	return &window, nil
+}
+
+func (f *fakeMaintenanceWindows) Update(ctx context.Context, window *database.MaintenanceWindow) error {
+	if _, ok := f.windows[window.ID]; !ok {
+		return database.ErrNotFound
+	}
+	f.windows[window.ID] = *window
+	return nil
+}
+
+func (f *fakeMaintenanceWindows) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, ok := f.windows[id]; !ok {
+		return database.ErrNotFound
+	}
+	delete(f.windows, id)
+	return nil
+}
+
+func (f *fakeMaintenanceWindows) List(ctx context.Context) ([]database.MaintenanceWindow, error) {
+	var windows []database.MaintenanceWindow
+	for _, window := range f.windows {
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+func (f *fakeMaintenanceWindows) ListEnabled(ctx context.Context) ([]database.MaintenanceWindow, error) {
+	var windows []database.MaintenanceWindow
+	for _, window := range f.windows {
+		if window.Enabled {
+			windows = append(windows, window)
+		}
+	}
+	return windows, nil
+}
+
+func TestAdminMaintenanceWindows(t *testing.T) {
+	pauses := &fakeSchedulingPauses{pauses: make(map[uuid.UUID]database.SchedulingPause)}
+	windows := &fakeMaintenanceWindows{windows: make(map[uuid.UUID]database.MaintenanceWindow)}
+	server := NewAdminServiceServer(AdminServiceDeps{Pauses: pauses, Maintenance: windows}, zerolog.Nop())
+	admin := withUser(context.Background(), &UserClaims{UserID: "admin", Roles: []string{"admin"}})
+	serviceID := uuid.NewString()
+
+	t.Run("requires admin", func(t *testing.T) {
+		viewer := withUser(context.Background(), &UserClaims{UserID: "u-2", Roles: []string{"viewer"}})
+		_, err := server.ListMaintenanceWindows(viewer, &conductorv1.ListMaintenanceWindowsRequest{})
+		assert.Equal(t, errcode.PermissionDenied, errcode.FromError(err))
+	})
+
+	t.Run("rejects invalid windows", func(t *testing.T) {
+		_, err := server.CreateMaintenanceWindow(admin, &conductorv1.CreateMaintenanceWindowRequest{
+			Name:           "nightly",
+			CronExpression: "0 2 * * *",
+		})
+		assert.Equal(t, errcode.InvalidArgument, errcode.FromError(err))
+
+		_, err = server.CreateMaintenanceWindow(admin, &conductorv1.CreateMaintenanceWindowRequest{
+			Name:      "both",
+			ServiceId: serviceID,
+			AgentPool: "gpu",
+			StartsAt:  timestamppb.Now(),
+			EndsAt:    timestamppb.New(time.Now().Add(time.Hour)),
+		})
+		assert.Equal(t, errcode.InvalidArgument, errcode.FromError(err))
+	})
+
+	var windowID string
+	t.Run("creates an active window", func(t *testing.T) {
+		resp, err := server.CreateMaintenanceWindow(admin, &conductorv1.CreateMaintenanceWindowRequest{
+			Name:          "database upgrade",
+			ServiceId:     serviceID,
+			StartsAt:      timestamppb.New(time.Now().Add(-time.Hour)),
+			EndsAt:        timestamppb.New(time.Now().Add(time.Hour)),
+			Notifications: conductorv1.MaintenanceNotifications_MAINTENANCE_NOTIFICATIONS_TAG,
+		})
+		require.NoError(t, err)
+		windowID = resp.Window.Id
+		assert.Equal(t, "admin", resp.Window.CreatedBy)
+		assert.Equal(t, serviceID, resp.Window.ServiceId)
+		assert.True(t, resp.Window.Enabled)
+		assert.True(t, resp.Window.Active)
+
+		status, err := server.GetSchedulingStatus(admin, &conductorv1.GetSchedulingStatusRequest{})
+		require.NoError(t, err)
+		require.Len(t, status.ActiveWindows, 1)
+		assert.Equal(t, windowID, status.ActiveWindows[0].Id)
+	})
+
+	t.Run("disables a window", func(t *testing.T) {
+		enabled := false
+		resp, err := server.UpdateMaintenanceWindow(admin, &conductorv1.UpdateMaintenanceWindowRequest{
+			WindowId: windowID,
+			Enabled:  &enabled,
+		})
+		require.NoError(t, err)
+		assert.False(t, resp.Window.Enabled)
+		assert.False(t, resp.Window.Active)
+		assert.Equal(t, conductorv1.MaintenanceNotifications_MAINTENANCE_NOTIFICATIONS_TAG, resp.Window.Notifications)
+
+		status, err := server.GetSchedulingStatus(admin, &conductorv1.GetSchedulingStatusRequest{})
+		require.NoError(t, err)
+		assert.Empty(t, status.ActiveWindows)
+	})
+
+	t.Run("deletes a window", func(t *testing.T) {
+		_, err := server.DeleteMaintenanceWindow(admin, &conductorv1.DeleteMaintenanceWindowRequest{WindowId: windowID})
+		require.NoError(t, err)
+
+		_, err = server.GetMaintenanceWindow(admin, &conductorv1.GetMaintenanceWindowRequest{WindowId: windowID})
+		assert.Equal(t, errcode.NotFound, errcode.FromError(err))
+
+		_, err = server.DeleteMaintenanceWindow(admin, &conductorv1.DeleteMaintenanceWindowRequest{WindowId: windowID})
+		assert.Equal(t, errcode.NotFound, errcode.FromError(err))
+	})
+}
@@ -2,20 +2,27 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
 	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/energy"
 	"github.com/conductor/conductor/internal/notification"
+	"github.com/conductor/conductor/internal/placement"
+	"github.com/conductor/conductor/internal/websocket"
+	"github.com/conductor/conductor/pkg/errcode"
+	"github.com/conductor/conductor/pkg/metrics"
 )
 
 // AgentServiceDeps defines the dependencies for the agent service.
@@ -26,18 +33,63 @@ type AgentServiceDeps struct {
 	RunRepo RunRepository
 	// ResultRepo handles test result persistence.
 	ResultRepo AgentResultRepository
+	// ResultBatchSize is how many streamed results are stored at once; 1 or
+	// less stores each result as it arrives.
+	ResultBatchSize int
+	// ResultFlushInterval is how long a streamed result waits for its batch
+	// to fill up before it is stored (default: 1s).
+	ResultFlushInterval time.Duration
+	// ArtifactRepo records artifacts uploaded by agents (optional).
+	ArtifactRepo ArtifactRepository
+	// AnnotationRepo records the warnings agents attach to runs (optional).
+	AnnotationRepo database.RunAnnotationRepository
+	// TestRepo looks up the artifact budgets of test definitions (optional).
+	TestRepo TestDefinitionRepository
+	// IngestionRepo counts results and artifacts against the per-run caps (optional).
+	IngestionRepo RunIngestionRepository
+	// MaxResultsPerRun caps the results stored per run; 0 disables the cap.
+	MaxResultsPerRun int
+	// MaxArtifactsPerRun caps the artifacts recorded per run; 0 disables the cap.
+	MaxArtifactsPerRun int
+	// MaxArtifactBytes caps the size of a single artifact; 0 disables the cap.
+	MaxArtifactBytes int64
+	// Metrics records dropped results and artifacts (optional).
+	Metrics *metrics.ControlPlaneMetrics
 	// AnalyticsRepo handles test history and flakiness.
 	AnalyticsRepo AgentAnalyticsRepository
 	// ServiceRepo handles service lookups.
 	ServiceRepo ServiceRepository
+	// PoolRepo handles agent pool persistence (optional).
+	PoolRepo database.AgentPoolRepository
 	// NotificationService handles outbound notifications.
 	NotificationService notification.NotificationService
 	// Scheduler handles work assignment.
 	Scheduler WorkScheduler
+	// StatusReporter posts finished pull request runs to the git provider (optional).
+	StatusReporter RunStatusReporter
+	// DurationAnomalies flags finished runs with unusual durations (optional).
+	DurationAnomalies DurationAnomalyDetector
+	// DurationRegressions records runs and tests slower than the recent runs of their branch (optional).
+	DurationRegressions DurationRegressionDetector
+	// Events publishes agent status changes when agents register and disconnect (optional).
+	Events AgentEventPublisher
 	// HeartbeatTimeout is the duration after which an agent is considered offline.
 	HeartbeatTimeout time.Duration
+	// EnergyRepo records the estimated energy of runs from heartbeats (optional).
+	EnergyRepo AgentEnergyRepository
+	// EnergyModel estimates the power draw of agents from their resource usage.
+	EnergyModel energy.Model
+	// CarbonIntensity selects the zone an agent's energy is attributed to.
+	CarbonIntensity energy.CarbonIntensity
 	// ServerVersion is the version of the control plane server.
 	ServerVersion string
+	// Connections records the replica each agent is connected to, so that
+	// any replica reaches the agents connected to the others (optional).
+	Connections database.AgentConnectionRepository
+	// ReplicaID identifies this control plane replica to the others.
+	ReplicaID string
+	// ReplicaAddress is the gRPC address other replicas reach this one at.
+	ReplicaAddress string
 }
 
 // AgentRepository defines the interface for agent persistence.
@@ -51,9 +103,20 @@ type AgentRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
-// ResultRepository defines the interface for storing test results.
+// AgentResultRepository defines the interface for storing test results.
 type AgentResultRepository interface {
-	Create(ctx context.Context, result *database.TestResult) error
+	BatchCreate(ctx context.Context, results []database.TestResult) error
+}
+
+// RunIngestionRepository defines the interface for per-run ingestion caps.
+type RunIngestionRepository interface {
+	ReserveResults(ctx context.Context, id uuid.UUID, n, limit int) (accepted int, dropped int64, err error)
+	ReserveArtifacts(ctx context.Context, id uuid.UUID, n, limit int) (accepted int, dropped int64, err error)
+}
+
+// AgentEnergyRepository defines the interface for recording run energy samples.
+type AgentEnergyRepository interface {
+	RecordSample(ctx context.Context, sample *database.EnergySample) error
 }
 
 // AgentAnalyticsRepository defines the interface for flaky test tracking.
@@ -69,13 +132,14 @@ type AgentFilter struct {
 	Statuses    []database.AgentStatus
 	NetworkZone string
 	Labels      map[string]string
+	Pool        string
 	Query       string
 }
 
 // WorkScheduler handles work assignment to agents.
 type WorkScheduler interface {
 	// AssignWork finds and assigns pending work to an agent.
-	AssignWork(ctx context.Context, agentID uuid.UUID, capabilities *conductorv1.Capabilities) (*conductorv1.AssignWork, error)
+	AssignWork(ctx context.Context, agentID uuid.UUID, capabilities *conductorv1.Capabilities, labels map[string]string, pool string) (*conductorv1.AssignWork, error)
 	// CancelWork cancels an assigned work item.
 	CancelWork(ctx context.Context, runID uuid.UUID, reason string) error
 	// HandleWorkAccepted processes a work acceptance from an agent.
@@ -86,16 +150,75 @@ type WorkScheduler interface {
 	HandleRunComplete(ctx context.Context, agentID uuid.UUID, runID uuid.UUID, shardID *uuid.UUID, result *conductorv1.RunComplete) error
 }
 
+// RunStatusReporter reports the outcome of finished runs back to git providers.
+type RunStatusReporter interface {
+	// ReportRun posts a commit status for a finished run, retrying transient failures.
+	ReportRun(ctx context.Context, service *database.Service, run *database.TestRun) error
+}
+
+// statusReportTimeout bounds a single run's status report, including retries.
+const statusReportTimeout = 10 * time.Minute
+
+// DurationAnomalyDetector detects runs whose duration deviates from the
+// recent runs of their service.
+type DurationAnomalyDetector interface {
+	// Detect returns the anomaly of a finished run, or nil if there is none.
+	Detect(ctx context.Context, run *database.TestRun) (*notification.DurationAnomaly, error)
+}
+
+// DurationRegressionDetector records runs and tests whose durations regressed
+// against the recent passed runs of their service branch.
+type DurationRegressionDetector interface {
+	// Detect records and returns the regressions of a finished run.
+	Detect(ctx context.Context, run *database.TestRun) ([]database.DurationRegression, error)
+}
+
+// AgentEventPublisher publishes agent status changes. websocket.Publisher
+// implements it.
+type AgentEventPublisher interface {
+	PublishAgentUpdate(agent websocket.AgentEvent) error
+}
+
+// anomalyCheckTimeout bounds a single run's duration anomaly check.
+const anomalyCheckTimeout = 30 * time.Second
+
 // connectedAgent represents an agent with an active stream connection.
 type connectedAgent struct {
 	id           uuid.UUID
 	name         string
 	capabilities *conductorv1.Capabilities
 	labels       map[string]string
+	pool         string
+	projectID    uuid.UUID
 	stream       conductorv1.AgentService_WorkStreamServer
 	sendMu       sync.Mutex
-	lastSeen     time.Time
+	connectedAt  time.Time
 	cancel       context.CancelFunc
+
+	// artifactLimits are the limits the agent was given at registration.
+	artifactLimits *conductorv1.ArtifactLimits
+
+	// stateMu guards the state reported by heartbeats and the work assigned
+	// to the agent that it has not accepted yet.
+	stateMu      sync.Mutex
+	lastSeen     time.Time
+	status       conductorv1.AgentStatus
+	activeRunIDs []string
+	usage        *conductorv1.ResourceUsage
+	queued       []queuedWork
+
+	// logsMu guards the log requests waiting for the agent's reply.
+	logsMu      sync.Mutex
+	logRequests map[string]chan *conductorv1.AgentLogs
+
+	// resultParts assembles the results the agent sends in parts.
+	resultParts resultParts
+
+	// replaysResults is set for agents that keep result stream messages
+	// until acknowledged; unackedResults counts the messages processed
+	// since the previous acknowledgement.
+	replaysResults bool
+	unackedResults int
 }
 
 // AgentServiceServer implements the AgentService gRPC service.
@@ -108,15 +231,34 @@ type AgentServiceServer struct {
 	// Connected agents indexed by agent ID
 	agents   map[uuid.UUID]*connectedAgent
 	agentsMu sync.RWMutex
+
+	// peers reaches the agents connected to other replicas (optional).
+	peers replicaDialer
+
+	// draining is set once the replica moves its agents to the other
+	// replicas before shutting down.
+	draining atomic.Bool
+
+	// results buffers the streamed test results until they are stored
+	// (nil without a ResultRepo).
+	results *resultBatcher
+
+	// resultSequences tracks the result stream messages processed for
+	// agents that replay results.
+	resultSequences resultSequences
 }
 
 // NewAgentServiceServer creates a new agent service server.
 func NewAgentServiceServer(deps AgentServiceDeps, logger zerolog.Logger) *AgentServiceServer {
-	return &AgentServiceServer{
+	s := &AgentServiceServer{
 		deps:   deps,
 		logger: logger.With().Str("service", "AgentService").Logger(),
 		agents: make(map[uuid.UUID]*connectedAgent),
 	}
+	if deps.ResultRepo != nil {
+		s.results = newResultBatcher(deps.ResultRepo, deps.ResultBatchSize, deps.ResultFlushInterval, s.logger)
+	}
+	return s
 }
 
 // WorkStream handles the bidirectional streaming RPC for agent communication.
@@ -146,7 +288,7 @@ func (s *AgentServiceServer) WorkStream(stream conductorv1.AgentService_WorkStre
 			if agent != nil {
 				s.disconnectAgent(agent.id)
 			}
-			return status.Errorf(codes.Internal, "failed to receive message: %v", err)
+			return errcode.New(errcode.Internal, "failed to receive message: %v", err)
 		}
 
 		switch m := msg.Message.(type) {
@@ -159,7 +301,7 @@ func (s *AgentServiceServer) WorkStream(stream conductorv1.AgentService_WorkStre
 
 		case *conductorv1.AgentMessage_Heartbeat:
 			if agent == nil {
-				return status.Error(codes.FailedPrecondition, "agent not registered")
+				return errcode.New(errcode.AgentNotRegistered, "agent not registered")
 			}
 			if err := s.handleHeartbeat(ctx, agent, m.Heartbeat); err != nil {
 				s.logger.Error().Err(err).Str("agent_id", agent.id.String()).Msg("failed to handle heartbeat")
@@ -167,7 +309,7 @@ func (s *AgentServiceServer) WorkStream(stream conductorv1.AgentService_WorkStre
 
 		case *conductorv1.AgentMessage_WorkAccepted:
 			if agent == nil {
-				return status.Error(codes.FailedPrecondition, "agent not registered")
+				return errcode.New(errcode.AgentNotRegistered, "agent not registered")
 			}
 			if err := s.handleWorkAccepted(ctx, agent, m.WorkAccepted); err != nil {
 				s.logger.Error().Err(err).Str("agent_id", agent.id.String()).Msg("failed to handle work accepted")
@@ -175,7 +317,7 @@ func (s *AgentServiceServer) WorkStream(stream conductorv1.AgentService_WorkStre
 
 		case *conductorv1.AgentMessage_WorkRejected:
 			if agent == nil {
-				return status.Error(codes.FailedPrecondition, "agent not registered")
+				return errcode.New(errcode.AgentNotRegistered, "agent not registered")
 			}
 			if err := s.handleWorkRejected(ctx, agent, m.WorkRejected); err != nil {
 				s.logger.Error().Err(err).Str("agent_id", agent.id.String()).Msg("failed to handle work rejected")
@@ -183,12 +325,18 @@ func (s *AgentServiceServer) WorkStream(stream conductorv1.AgentService_WorkStre
 
 		case *conductorv1.AgentMessage_ResultStream:
 			if agent == nil {
-				return status.Error(codes.FailedPrecondition, "agent not registered")
+				return errcode.New(errcode.AgentNotRegistered, "agent not registered")
 			}
 			if err := s.handleResultStream(ctx, agent, m.ResultStream); err != nil {
 				s.logger.Error().Err(err).Str("agent_id", agent.id.String()).Msg("failed to handle result stream")
 			}
 
+		case *conductorv1.AgentMessage_AgentLogs:
+			if agent == nil {
+				return errcode.New(errcode.AgentNotRegistered, "agent not registered")
+			}
+			agent.deliverLogs(m.AgentLogs)
+
 		default:
 			s.logger.Warn().Type("message_type", m).Msg("unknown message type")
 		}
@@ -201,6 +349,22 @@ func (s *AgentServiceServer) handleRegister(
 	stream conductorv1.AgentService_WorkStreamServer,
 	req *conductorv1.RegisterRequest,
 ) (*connectedAgent, error) {
+	// Agents reconnecting while the replica drains must find another one
+	if s.draining.Load() {
+		resp := &conductorv1.ControlMessage{
+			Message: &conductorv1.ControlMessage_RegisterResponse{
+				RegisterResponse: &conductorv1.RegisterResponse{
+					Success:      false,
+					ErrorMessage: "control plane replica is shutting down",
+				},
+			},
+		}
+		if sendErr := stream.Send(resp); sendErr != nil {
+			return nil, errcode.New(errcode.Internal, "failed to send register response: %v", sendErr)
+		}
+		return nil, errcode.New(errcode.Unavailable, "control plane replica is shutting down")
+	}
+
 	agentID, err := uuid.Parse(req.AgentId)
 	if err != nil {
 		resp := &conductorv1.ControlMessage{
@@ -212,14 +376,35 @@ func (s *AgentServiceServer) handleRegister(
 			},
 		}
 		if sendErr := stream.Send(resp); sendErr != nil {
-			return nil, status.Errorf(codes.Internal, "failed to send register response: %v", sendErr)
+			return nil, errcode.New(errcode.Internal, "failed to send register response: %v", sendErr)
+		}
+		return nil, errcode.New(errcode.InvalidArgument, "invalid agent ID: %v", err)
+	}
+
+	projectID := database.DefaultProjectID
+	if req.ProjectId != "" {
+		projectID, err = uuid.Parse(req.ProjectId)
+		if err != nil {
+			resp := &conductorv1.ControlMessage{
+				Message: &conductorv1.ControlMessage_RegisterResponse{
+					RegisterResponse: &conductorv1.RegisterResponse{
+						Success:      false,
+						ErrorMessage: "invalid project ID format",
+					},
+				},
+			}
+			if sendErr := stream.Send(resp); sendErr != nil {
+				return nil, errcode.New(errcode.Internal, "failed to send register response: %v", sendErr)
+			}
+			return nil, errcode.New(errcode.InvalidArgument, "invalid project ID: %v", err)
 		}
-		return nil, status.Errorf(codes.InvalidArgument, "invalid agent ID: %v", err)
 	}
 
 	logger := s.logger.With().Str("agent_id", agentID.String()).Str("agent_name", req.Name).Logger()
 	logger.Info().Msg("agent registering")
 
+	labels := placement.AgentLabels(req.Labels, req.Capabilities.GetOs(), req.Capabilities.GetArch())
+
 	// Create or update agent in database
 	agent := &database.Agent{
 		ID:              agentID,
@@ -230,45 +415,58 @@ func (s *AgentServiceServer) handleRegister(
 		MaxParallel:     int(req.Capabilities.GetMaxParallel()),
 		DockerAvailable: req.Capabilities.GetDockerAvailable(),
 		RegisteredAt:    time.Now(),
+		Labels:          labels,
+		Pool:            database.NullString(req.Pool),
+		ProjectID:       projectID,
 	}
 
 	// Try to get existing agent
 	existing, err := s.deps.AgentRepo.GetByID(ctx, agentID)
 	if err != nil && !database.IsNotFound(err) {
 		logger.Error().Err(err).Msg("failed to check existing agent")
-		return nil, status.Errorf(codes.Internal, "failed to check agent: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to check agent: %v", err)
 	}
 
 	if existing != nil {
 		// Update existing agent
 		if err := s.deps.AgentRepo.Update(ctx, agent); err != nil {
 			logger.Error().Err(err).Msg("failed to update agent")
-			return nil, status.Errorf(codes.Internal, "failed to update agent: %v", err)
+			return nil, errcode.New(errcode.Internal, "failed to update agent: %v", err)
 		}
 	} else {
 		// Create new agent
 		if err := s.deps.AgentRepo.Create(ctx, agent); err != nil {
 			logger.Error().Err(err).Msg("failed to create agent")
-			return nil, status.Errorf(codes.Internal, "failed to create agent: %v", err)
+			return nil, errcode.New(errcode.Internal, "failed to create agent: %v", err)
 		}
 	}
 
 	// Create connected agent
 	streamCtx, cancel := context.WithCancel(ctx)
+	now := time.Now()
 	connAgent := &connectedAgent{
-		id:           agentID,
-		name:         req.Name,
-		capabilities: req.Capabilities,
-		labels:       req.Labels,
-		stream:       stream,
-		lastSeen:     time.Now(),
-		cancel:       cancel,
+		id:             agentID,
+		name:           req.Name,
+		capabilities:   req.Capabilities,
+		labels:         labels,
+		pool:           req.Pool,
+		projectID:      projectID,
+		stream:         stream,
+		artifactLimits: s.artifactLimits(ctx, req),
+		connectedAt:    now,
+		lastSeen:       now,
+		cancel:         cancel,
+		replaysResults: req.ReplaysResults,
+	}
+	if !req.ReplaysResults {
+		s.resultSequences.reset(agentID)
 	}
 
 	// Register connected agent
 	s.agentsMu.Lock()
 	s.agents[agentID] = connAgent
 	s.agentsMu.Unlock()
+	s.registerConnection(ctx, agentID)
 
 	// Send register response
 	resp := &conductorv1.ControlMessage{
@@ -277,15 +475,23 @@ func (s *AgentServiceServer) handleRegister(
 				Success:                  true,
 				HeartbeatIntervalSeconds: int32(s.deps.HeartbeatTimeout.Seconds() / 3), // Heartbeat at 1/3 of timeout
 				ServerVersion:            s.deps.ServerVersion,
+				ServerTime:               timestamppb.Now(),
+				ArtifactLimits:           connAgent.artifactLimits,
+				AcksResults:              req.ReplaysResults,
+				ResultSequence:           s.resultSequences.processed(agentID),
 			},
 		},
 	}
 	if err := stream.Send(resp); err != nil {
 		s.disconnectAgent(agentID)
-		return nil, status.Errorf(codes.Internal, "failed to send register response: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to send register response: %v", err)
 	}
 
-	logger.Info().Msg("agent registered successfully")
+	logger.Info().
+		Int64("max_artifact_bytes", connAgent.artifactLimits.GetMaxArtifactBytes()).
+		Msg("agent registered successfully")
+
+	s.publishAgent(agent)
 
 	// Start work assignment goroutine
 	go s.workAssignmentLoop(streamCtx, connAgent)
@@ -294,7 +500,9 @@ func (s *AgentServiceServer) handleRegister(
 }
 
 // workAssignmentLoop periodically checks for work to assign to an agent.
+// Only runs of services in the agent's project are assigned.
 func (s *AgentServiceServer) workAssignmentLoop(ctx context.Context, agent *connectedAgent) {
+	ctx = database.WithProjectScope(ctx, []uuid.UUID{agent.projectID})
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
@@ -303,7 +511,11 @@ func (s *AgentServiceServer) workAssignmentLoop(ctx context.Context, agent *conn
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			work, err := s.deps.Scheduler.AssignWork(ctx, agent.id, agent.capabilities)
+			// The agent is about to move to another replica
+			if s.draining.Load() {
+				continue
+			}
+			work, err := s.deps.Scheduler.AssignWork(ctx, agent.id, agent.capabilities, agent.labels, agent.pool)
 			if err != nil {
 				s.logger.Error().Err(err).Str("agent_id", agent.id.String()).Msg("failed to get work assignment")
 				continue
@@ -319,11 +531,15 @@ func (s *AgentServiceServer) workAssignmentLoop(ctx context.Context, agent *conn
 				},
 			}
 
+			// Queue the work before sending it, since the agent may accept
+			// it before Send returns.
+			agent.queueWork(work, time.Now())
 			agent.sendMu.Lock()
 			err = agent.stream.Send(msg)
 			agent.sendMu.Unlock()
 
 			if err != nil {
+				agent.dequeueWork(work.RunId, work.ShardId)
 				s.logger.Error().Err(err).
 					Str("agent_id", agent.id.String()).
 					Str("run_id", work.RunId).
@@ -340,7 +556,8 @@ func (s *AgentServiceServer) workAssignmentLoop(ctx context.Context, agent *conn
 
 // handleHeartbeat processes an agent heartbeat.
 func (s *AgentServiceServer) handleHeartbeat(ctx context.Context, agent *connectedAgent, hb *conductorv1.Heartbeat) error {
-	agent.lastSeen = time.Now()
+	now := time.Now()
+	elapsed := agent.recordHeartbeat(hb, now)
 
 	// Map proto status to database status
 	var dbStatus database.AgentStatus
@@ -364,9 +581,62 @@ func (s *AgentServiceServer) handleHeartbeat(ctx context.Context, agent *connect
 		Strs("active_runs", hb.ActiveRunIds).
 		Msg("heartbeat received")
 
+	s.recordEnergy(ctx, agent, hb, elapsed, now)
+	s.ackResults(agent)
+
 	return nil
 }
 
+// recordEnergy records the estimated energy the agent's active runs used
+// since its previous heartbeat, split evenly between them. Gaps longer than
+// the heartbeat timeout are capped, since the agent may not have been
+// running. Failures are logged and never fail the heartbeat.
+func (s *AgentServiceServer) recordEnergy(ctx context.Context, agent *connectedAgent, hb *conductorv1.Heartbeat, elapsed time.Duration, sampledAt time.Time) {
+	if s.deps.EnergyRepo == nil || hb.ResourceUsage == nil || len(hb.ActiveRunIds) == 0 {
+		return
+	}
+	if s.deps.HeartbeatTimeout > 0 && elapsed > s.deps.HeartbeatTimeout {
+		elapsed = s.deps.HeartbeatTimeout
+	}
+
+	var zones []string
+	cpuCores := 1
+	if agent.capabilities != nil {
+		zones = agent.capabilities.NetworkZones
+		if agent.capabilities.Resources != nil && agent.capabilities.Resources.CpuCores > 0 {
+			cpuCores = int(agent.capabilities.Resources.CpuCores)
+		}
+	}
+
+	usage := hb.ResourceUsage
+	energyWh := s.deps.EnergyModel.EnergyWh(usage.CpuPercent, cpuCores, usage.MemoryBytes, elapsed)
+	share := energyWh / float64(len(hb.ActiveRunIds))
+	zone := s.deps.CarbonIntensity.Zone(zones)
+
+	for _, id := range hb.ActiveRunIds {
+		runID, err := uuid.Parse(id)
+		if err != nil {
+			continue
+		}
+		sample := &database.EnergySample{
+			RunID:       runID,
+			AgentID:     &agent.id,
+			Zone:        zone,
+			CPUPercent:  usage.CpuPercent,
+			CPUCores:    cpuCores,
+			MemoryBytes: usage.MemoryBytes,
+			EnergyWh:    share,
+			SampledAt:   sampledAt,
+		}
+		if err := s.deps.EnergyRepo.RecordSample(ctx, sample); err != nil {
+			s.logger.Warn().Err(err).
+				Str("agent_id", agent.id.String()).
+				Str("run_id", id).
+				Msg("failed to record energy sample")
+		}
+	}
+}
+
 // handleWorkAccepted processes a work acceptance from an agent.
 func (s *AgentServiceServer) handleWorkAccepted(ctx context.Context, agent *connectedAgent, wa *conductorv1.WorkAccepted) error {
 	runID, err := uuid.Parse(wa.RunId)
@@ -379,6 +649,7 @@ func (s *AgentServiceServer) handleWorkAccepted(ctx context.Context, agent *conn
 		return fmt.Errorf("invalid shard ID: %w", err)
 	}
 
+	agent.dequeueWork(wa.RunId, wa.ShardId)
 	if err := s.deps.Scheduler.HandleWorkAccepted(ctx, agent.id, runID, shardID); err != nil {
 		return fmt.Errorf("failed to handle work accepted: %w", err)
 	}
@@ -418,6 +689,7 @@ func (s *AgentServiceServer) handleWorkRejected(ctx context.Context, agent *conn
 		return fmt.Errorf("invalid shard ID: %w", err)
 	}
 
+	agent.dequeueWork(wr.RunId, wr.ShardId)
 	if err := s.deps.Scheduler.HandleWorkRejected(ctx, agent.id, runID, shardID, wr.Reason); err != nil {
 		return fmt.Errorf("failed to handle work rejected: %w", err)
 	}
@@ -441,6 +713,19 @@ func (s *AgentServiceServer) handleResultStream(ctx context.Context, agent *conn
 		Int64("sequence", rs.Sequence).
 		Logger()
 
+	// Agents that replay results resend the messages sent before a broken
+	// stream that were not acknowledged; those processed already are skipped
+	if agent.replaysResults {
+		agent.unackedResults++
+		if agent.unackedResults >= resultAckInterval || rs.GetRunComplete() != nil {
+			defer s.ackResults(agent)
+		}
+		if !s.resultSequences.advance(agent.id, rs.Sequence) {
+			logger.Debug().Msg("replayed result stream message skipped")
+			return nil
+		}
+	}
+
 	switch p := rs.Payload.(type) {
 	case *conductorv1.ResultStream_LogChunk:
 		logger.Debug().
@@ -450,11 +735,22 @@ func (s *AgentServiceServer) handleResultStream(ctx context.Context, agent *conn
 		// TODO: Store log chunk
 
 	case *conductorv1.ResultStream_TestResult:
+		result := agent.resultParts.add(rs, p.TestResult)
+		if result == nil {
+			logger.Debug().
+				Str("test_name", p.TestResult.TestName).
+				Msg("test result part received")
+			break
+		}
 		logger.Info().
-			Str("test_name", p.TestResult.TestName).
-			Str("status", p.TestResult.Status.String()).
+			Str("test_name", result.TestName).
+			Str("status", result.Status.String()).
 			Msg("test result received")
-		if err := s.handleTestResult(ctx, rs, p.TestResult); err != nil {
+		var sequence int64
+		if agent.replaysResults {
+			sequence = rs.Sequence
+		}
+		if err := s.handleTestResult(ctx, rs, result, sequence); err != nil {
 			logger.Error().Err(err).Msg("failed to handle test result")
 		}
 
@@ -463,7 +759,17 @@ func (s *AgentServiceServer) handleResultStream(ctx context.Context, agent *conn
 			Str("artifact_name", p.Artifact.Name).
 			Int64("size", p.Artifact.Size).
 			Msg("artifact uploaded")
-		// TODO: Record artifact metadata
+		err := checkArtifactSize(agent, p.Artifact)
+		if err == nil {
+			err = s.handleArtifact(ctx, rs, p.Artifact)
+		}
+		if err != nil {
+			if errcode.Is(err, errcode.ArtifactBudgetExceeded) || errcode.Is(err, errcode.ArtifactTooLarge) {
+				logger.Warn().Err(err).Str("test_id", p.Artifact.TestId).Msg("artifact rejected")
+			} else {
+				logger.Error().Err(err).Msg("failed to handle artifact")
+			}
+		}
 
 	case *conductorv1.ResultStream_RunComplete:
 		runID, err := uuid.Parse(rs.RunId)
@@ -476,6 +782,11 @@ func (s *AgentServiceServer) handleResultStream(ctx context.Context, agent *conn
 			return fmt.Errorf("invalid shard ID: %w", err)
 		}
 
+		// The run's summary and checks read the results it streamed
+		if s.results != nil {
+			s.results.flush(ctx)
+		}
+
 		if err := s.deps.Scheduler.HandleRunComplete(ctx, agent.id, runID, shardID, p.RunComplete); err != nil {
 			return fmt.Errorf("failed to handle run complete: %w", err)
 		}
@@ -487,6 +798,20 @@ func (s *AgentServiceServer) handleResultStream(ctx context.Context, agent *conn
 			Int32("failed", p.RunComplete.Summary.GetFailed()).
 			Msg("run completed")
 
+		s.reportRunStatus(runID)
+		s.checkDurationAnomaly(runID)
+		s.checkDurationRegressions(runID)
+
+	case *conductorv1.ResultStream_Annotation:
+		logger.Warn().
+			Str("shard_id", rs.ShardId).
+			Str("kind", p.Annotation.Kind).
+			Str("message", p.Annotation.Message).
+			Msg("run annotated by agent")
+		if err := s.handleAnnotation(ctx, agent, rs, p.Annotation); err != nil {
+			logger.Error().Err(err).Msg("failed to handle annotation")
+		}
+
 	case *conductorv1.ResultStream_Progress:
 		logger.Debug().
 			Str("phase", p.Progress.Phase).
@@ -497,6 +822,170 @@ func (s *AgentServiceServer) handleResultStream(ctx context.Context, agent *conn
 	return nil
 }
 
+// reportRunStatus posts the outcome of a finished pull request run to its git
+// provider. Reports retry with backoff, so they run in the background.
+func (s *AgentServiceServer) reportRunStatus(runID uuid.UUID) {
+	if s.deps.StatusReporter == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), statusReportTimeout)
+		defer cancel()
+
+		logger := s.logger.With().Str("run_id", runID.String()).Logger()
+
+		run, err := s.deps.RunRepo.GetByID(ctx, runID)
+		if err != nil {
+			logger.Warn().Err(err).Msg("failed to load run for status report")
+			return
+		}
+		// Sharded runs finish when their last shard completes.
+		if !run.IsTerminal() || run.PullRequestNumber == nil {
+			return
+		}
+
+		service, err := s.deps.ServiceRepo.GetByID(ctx, run.ServiceID)
+		if err != nil {
+			logger.Warn().Err(err).Msg("failed to load service for status report")
+			return
+		}
+
+		if err := s.deps.StatusReporter.ReportRun(ctx, service, run); err != nil {
+			logger.Warn().Err(err).Msg("failed to report run status")
+		}
+	}()
+}
+
+// checkDurationAnomaly notifies when a finished run took much longer or
+// shorter than the recent runs of its service. The check queries run
+// history, so it runs in the background.
+func (s *AgentServiceServer) checkDurationAnomaly(runID uuid.UUID) {
+	if s.deps.DurationAnomalies == nil || s.deps.NotificationService == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), anomalyCheckTimeout)
+		defer cancel()
+
+		logger := s.logger.With().Str("run_id", runID.String()).Logger()
+
+		run, err := s.deps.RunRepo.GetByID(ctx, runID)
+		if err != nil {
+			logger.Warn().Err(err).Msg("failed to load run for duration anomaly check")
+			return
+		}
+		// Sharded runs finish when their last shard completes.
+		if !run.IsTerminal() {
+			return
+		}
+
+		anomaly, err := s.deps.DurationAnomalies.Detect(ctx, run)
+		if err != nil {
+			logger.Warn().Err(err).Msg("failed to check run duration")
+			return
+		}
+		if anomaly == nil {
+			return
+		}
+
+		serviceName := "Unknown Service"
+		if s.deps.ServiceRepo != nil {
+			service, err := s.deps.ServiceRepo.GetByID(ctx, run.ServiceID)
+			if err != nil {
+				logger.Warn().Err(err).Msg("failed to load service for duration anomaly notification")
+			} else if service != nil {
+				serviceName = service.Name
+			}
+		}
+
+		event := &notification.Event{
+			Type:            notification.NotificationTypeDurationAnomaly,
+			ServiceID:       run.ServiceID,
+			ServiceName:     serviceName,
+			RunID:           &run.ID,
+			Run:             run,
+			DurationAnomaly: anomaly,
+			Timestamp:       time.Now(),
+			Metadata: map[string]string{
+				"duration_ms":      strconv.FormatInt(anomaly.DurationMs, 10),
+				"mean_duration_ms": strconv.FormatInt(int64(anomaly.MeanMs), 10),
+				"baseline_runs":    strconv.Itoa(anomaly.Runs),
+			},
+		}
+		if !math.IsInf(anomaly.Sigma, 0) {
+			event.Metadata["sigma"] = strconv.FormatFloat(anomaly.Sigma, 'f', 2, 64)
+		}
+
+		if err := s.deps.NotificationService.SendNotification(ctx, event); err != nil {
+			logger.Warn().Err(err).Msg("failed to send duration anomaly notification")
+		}
+	}()
+}
+
+// checkDurationRegressions records the regressions of a finished run and its
+// tests, and notifies about them. Like the anomaly check it queries run
+// history, so it runs in the background.
+func (s *AgentServiceServer) checkDurationRegressions(runID uuid.UUID) {
+	if s.deps.DurationRegressions == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), anomalyCheckTimeout)
+		defer cancel()
+
+		logger := s.logger.With().Str("run_id", runID.String()).Logger()
+
+		run, err := s.deps.RunRepo.GetByID(ctx, runID)
+		if err != nil {
+			logger.Warn().Err(err).Msg("failed to load run for duration regression check")
+			return
+		}
+		// Sharded runs finish when their last shard completes.
+		if !run.IsTerminal() {
+			return
+		}
+
+		regressions, err := s.deps.DurationRegressions.Detect(ctx, run)
+		if err != nil {
+			logger.Warn().Err(err).Msg("failed to check duration regressions")
+			return
+		}
+		if len(regressions) == 0 || s.deps.NotificationService == nil {
+			return
+		}
+
+		serviceName := "Unknown Service"
+		if s.deps.ServiceRepo != nil {
+			service, err := s.deps.ServiceRepo.GetByID(ctx, run.ServiceID)
+			if err != nil {
+				logger.Warn().Err(err).Msg("failed to load service for duration regression notification")
+			} else if service != nil {
+				serviceName = service.Name
+			}
+		}
+
+		event := &notification.Event{
+			Type:                notification.NotificationTypeDurationRegression,
+			ServiceID:           run.ServiceID,
+			ServiceName:         serviceName,
+			RunID:               &run.ID,
+			Run:                 run,
+			DurationRegressions: regressions,
+			Timestamp:           time.Now(),
+			Metadata: map[string]string{
+				"regressions": strconv.Itoa(len(regressions)),
+			},
+		}
+
+		if err := s.deps.NotificationService.SendNotification(ctx, event); err != nil {
+			logger.Warn().Err(err).Msg("failed to send duration regression notification")
+		}
+	}()
+}
+
 // disconnectAgent removes an agent from the connected agents map and updates its status.
 func (s *AgentServiceServer) disconnectAgent(agentID uuid.UUID) {
 	s.agentsMu.Lock()
@@ -511,11 +1000,38 @@ func (s *AgentServiceServer) disconnectAgent(agentID uuid.UUID) {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
+		// Store the results the agent streamed before it disconnected
+		if s.results != nil {
+			s.results.flush(ctx)
+		}
+		s.unregisterConnection(ctx, agentID)
 		if err := s.deps.AgentRepo.UpdateStatus(ctx, agentID, database.AgentStatusOffline); err != nil {
 			s.logger.Error().Err(err).Str("agent_id", agentID.String()).Msg("failed to update agent status to offline")
 		}
 
 		s.logger.Info().Str("agent_id", agentID.String()).Msg("agent disconnected")
+
+		s.publishAgent(&database.Agent{ID: agentID, Name: agent.name, Status: database.AgentStatusOffline})
+	}
+}
+
+// publishAgent publishes the status of an agent, if events are enabled.
+// Publishing is best effort and never fails the caller.
+func (s *AgentServiceServer) publishAgent(agent *database.Agent) {
+	if s.deps.Events == nil {
+		return
+	}
+
+	event := websocket.AgentEvent{
+		AgentID:         agent.ID,
+		Name:            agent.Name,
+		Status:          string(agent.Status),
+		LastHeartbeat:   agent.LastHeartbeat,
+		Version:         agent.Version,
+		DockerAvailable: agent.DockerAvailable,
+	}
+	if err := s.deps.Events.PublishAgentUpdate(event); err != nil {
+		s.logger.Warn().Err(err).Str("agent_id", agent.ID.String()).Msg("failed to publish agent update")
 	}
 }
 
@@ -530,7 +1046,10 @@ func parseOptionalUUID(value string) (*uuid.UUID, error) {
 	return &parsed, nil
 }
 
-func (s *AgentServiceServer) handleTestResult(ctx context.Context, rs *conductorv1.ResultStream, event *conductorv1.TestResultEvent) error {
+// handleTestResult stores a test result. A non-zero sequence is the result
+// stream sequence of an agent that replays results, which the result is
+// stored once by.
+func (s *AgentServiceServer) handleTestResult(ctx context.Context, rs *conductorv1.ResultStream, event *conductorv1.TestResultEvent, sequence int64) error {
 	if event == nil {
 		return nil
 	}
@@ -553,17 +1072,22 @@ func (s *AgentServiceServer) handleTestResult(ctx context.Context, rs *conductor
 		}
 	}
 
+	if !s.reserveIngestion(ctx, runID, ingestionResult) {
+		return nil
+	}
+
 	status := resultStatusFromProto(event.Status)
 	durationMs := durationToMillis(event.Duration)
 
-	if s.deps.ResultRepo != nil {
-		result := &database.TestResult{
+	if s.results != nil {
+		result := database.TestResult{
 			RunID:            runID,
 			ShardID:          shardID,
 			TestDefinitionID: testDefID,
 			TestName:         event.TestName,
 			Status:           status,
 			DurationMs:       durationMs,
+			StreamSequence:   sequence,
 		}
 		if event.ErrorMessage != "" {
 			result.ErrorMessage = &event.ErrorMessage
@@ -571,9 +1095,7 @@ func (s *AgentServiceServer) handleTestResult(ctx context.Context, rs *conductor
 		if event.StackTrace != "" {
 			result.StackTrace = &event.StackTrace
 		}
-		if err := s.deps.ResultRepo.Create(ctx, result); err != nil {
-			s.logger.Warn().Err(err).Str("run_id", rs.RunId).Msg("failed to store test result")
-		}
+		s.results.add(ctx, result)
 	}
 
 	if s.deps.AnalyticsRepo == nil || s.deps.RunRepo == nil {
@@ -619,14 +1141,103 @@ func (s *AgentServiceServer) handleTestResult(ctx context.Context, rs *conductor
 		if err := s.deps.AnalyticsRepo.QuarantineTestByName(ctx, run.ServiceID, event.TestName, "system"); err != nil {
 			s.logger.Warn().Err(err).Msg("failed to quarantine flaky test")
 		} else {
-			s.notifyTestQuarantined(ctx, run, event.TestName, flakiness, flakyRuns, totalRuns)
+			s.notifyTestQuarantined(ctx, run, testDefID, event.TestName, flakiness, flakyRuns, totalRuns)
 		}
 	}
 
 	return nil
 }
 
-func (s *AgentServiceServer) notifyTestQuarantined(ctx context.Context, run *database.TestRun, testName string, flakinessScore float64, flakyRuns int, totalRuns int) {
+func (s *AgentServiceServer) handleArtifact(ctx context.Context, rs *conductorv1.ResultStream, event *conductorv1.ArtifactUploaded) error {
+	if event == nil || s.deps.ArtifactRepo == nil {
+		return nil
+	}
+
+	runID, err := uuid.Parse(rs.RunId)
+	if err != nil {
+		return fmt.Errorf("invalid run ID: %w", err)
+	}
+
+	testID, err := parseOptionalUUID(event.TestId)
+	if err != nil {
+		return fmt.Errorf("invalid test ID: %w", err)
+	}
+
+	if err := s.checkArtifactBudget(ctx, runID, testID, event); err != nil {
+		return err
+	}
+
+	if !s.reserveIngestion(ctx, runID, ingestionArtifact) {
+		return nil
+	}
+
+	path := event.StorageUrl
+	if path == "" {
+		path = event.Path
+	}
+	artifact := &database.Artifact{
+		RunID:            runID,
+		Name:             event.Name,
+		Path:             path,
+		ContentType:      database.NullString(event.ContentType),
+		SizeBytes:        database.NullInt64(event.Size),
+		TestDefinitionID: testID,
+		Type:             artifactTypeFromProto(event.Type),
+		Checksum:         database.NullString(event.Checksum),
+	}
+	if err := s.deps.ArtifactRepo.Create(ctx, artifact); err != nil {
+		return fmt.Errorf("failed to store artifact: %w", err)
+	}
+	return nil
+}
+
+// Kinds of ingested records, used as the metric label.
+const (
+	ingestionResult   = "result"
+	ingestionArtifact = "artifact"
+)
+
+// reserveIngestion reports whether another record of the kind may be stored
+// for the run. Records beyond the run's cap are counted on the run as
+// dropped, so clients can tell its results were truncated. Ingestion fails
+// open if the counters cannot be updated.
+func (s *AgentServiceServer) reserveIngestion(ctx context.Context, runID uuid.UUID, kind string) bool {
+	if s.deps.IngestionRepo == nil {
+		return true
+	}
+
+	reserve, limit := s.deps.IngestionRepo.ReserveResults, s.deps.MaxResultsPerRun
+	if kind == ingestionArtifact {
+		reserve, limit = s.deps.IngestionRepo.ReserveArtifacts, s.deps.MaxArtifactsPerRun
+	}
+	if limit <= 0 {
+		return true
+	}
+
+	accepted, dropped, err := reserve(ctx, runID, 1, limit)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("run_id", runID.String()).Str("kind", kind).Msg("failed to check ingestion cap")
+		return true
+	}
+	if accepted > 0 {
+		return true
+	}
+
+	if s.deps.Metrics != nil {
+		s.deps.Metrics.RecordIngestionDropped(kind, 1)
+	}
+	// Log once per run rather than for each of possibly millions of records.
+	if dropped == 1 {
+		s.logger.Warn().
+			Str("run_id", runID.String()).
+			Str("kind", kind).
+			Int("limit", limit).
+			Msg("run reached its ingestion cap, dropping further records")
+	}
+	return false
+}
+
+func (s *AgentServiceServer) notifyTestQuarantined(ctx context.Context, run *database.TestRun, testDefID *uuid.UUID, testName string, flakinessScore float64, flakyRuns int, totalRuns int) {
 	if s.deps.NotificationService == nil {
 		return
 	}
@@ -658,6 +1269,17 @@ func (s *AgentServiceServer) notifyTestQuarantined(ctx context.Context, run *dat
 		},
 		Timestamp: time.Now(),
 	}
+	if testDefID != nil {
+		quarantinedEvent.TestDefinitionIDs = []uuid.UUID{*testDefID}
+		if s.deps.TestRepo != nil {
+			def, err := s.deps.TestRepo.GetByID(ctx, run.ServiceID, *testDefID)
+			if err != nil {
+				s.logger.Warn().Err(err).Msg("failed to load test definition for quarantine notification")
+			} else if def.Owner != nil {
+				quarantinedEvent.TestOwners = map[uuid.UUID]string{*testDefID: *def.Owner}
+			}
+		}
+	}
 
 	if err := s.deps.NotificationService.SendNotification(ctx, quarantinedEvent); err != nil {
 		s.logger.Warn().Err(err).Msg("failed to send quarantine notification")
@@ -698,20 +1320,34 @@ func boolToInt(value bool) int {
 	return 0
 }
 
-// SendToAgent sends a control message to a connected agent.
+// SendToAgent sends a control message to a connected agent. Messages for
+// agents connected to another replica are sent through that replica.
 func (s *AgentServiceServer) SendToAgent(agentID uuid.UUID, msg *conductorv1.ControlMessage) error {
-	s.agentsMu.RLock()
-	agent, ok := s.agents[agentID]
-	s.agentsMu.RUnlock()
+	if agent, ok := s.localAgent(agentID); ok {
+		agent.sendMu.Lock()
+		defer agent.sendMu.Unlock()
 
-	if !ok {
-		return fmt.Errorf("agent %s not connected", agentID)
+		return agent.stream.Send(msg)
 	}
 
-	agent.sendMu.Lock()
-	defer agent.sendMu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), routeTimeout)
+	defer cancel()
 
-	return agent.stream.Send(msg)
+	replica, err := s.replicaOf(ctx, agentID)
+	if err == nil {
+		_, err = replica.SendControlMessage(ctx, &conductorv1.SendControlMessageRequest{
+			AgentId: agentID.String(),
+			Message: msg,
+		})
+		err = routedError(err)
+	}
+	if errors.Is(err, errAgentNotConnected) {
+		return fmt.Errorf("agent %s not connected", agentID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to route message to agent %s: %w", agentID, err)
+	}
+	return nil
 }
 
 // CancelWork sends a cancel work message to an agent.
@@ -744,7 +1380,8 @@ func (s *AgentServiceServer) DrainAgent(agentID uuid.UUID, reason string, cancel
 	return s.SendToAgent(agentID, msg)
 }
 
-// GetConnectedAgents returns a list of connected agent IDs.
+// GetConnectedAgents returns a list of the IDs of the agents connected to
+// this replica.
 func (s *AgentServiceServer) GetConnectedAgents() []uuid.UUID {
 	s.agentsMu.RLock()
 	defer s.agentsMu.RUnlock()
@@ -756,10 +1393,55 @@ func (s *AgentServiceServer) GetConnectedAgents() []uuid.UUID {
 	return ids
 }
 
-// IsAgentConnected returns true if the agent is currently connected.
+// IsAgentConnected returns true if the agent is currently connected to this
+// replica.
 func (s *AgentServiceServer) IsAgentConnected(agentID uuid.UUID) bool {
 	s.agentsMu.RLock()
 	defer s.agentsMu.RUnlock()
 	_, ok := s.agents[agentID]
 	return ok
 }
+
+// AgentStreamStats describes the work streams of the agents connected to a
+// replica, for diagnostics.
+type AgentStreamStats struct {
+	// Streams is the number of open work streams.
+	Streams int `json:"streams"`
+	// QueuedWork is the work assigned to the agents that they have not
+	// accepted yet.
+	QueuedWork int `json:"queued_work"`
+	// PendingLogRequests are the log requests waiting for an agent's reply.
+	PendingLogRequests int `json:"pending_log_requests"`
+	// PendingResults are the streamed test results waiting to be stored.
+	PendingResults int  `json:"pending_results"`
+	Draining       bool `json:"draining"`
+}
+
+// StreamStats returns statistics of the work streams of the connected
+// agents.
+func (s *AgentServiceServer) StreamStats() AgentStreamStats {
+	s.agentsMu.RLock()
+	agents := make([]*connectedAgent, 0, len(s.agents))
+	for _, agent := range s.agents {
+		agents = append(agents, agent)
+	}
+	s.agentsMu.RUnlock()
+
+	stats := AgentStreamStats{
+		Streams:  len(agents),
+		Draining: s.draining.Load(),
+	}
+	for _, agent := range agents {
+		agent.stateMu.Lock()
+		stats.QueuedWork += len(agent.queued)
+		agent.stateMu.Unlock()
+
+		agent.logsMu.Lock()
+		stats.PendingLogRequests += len(agent.logRequests)
+		agent.logsMu.Unlock()
+	}
+	if s.results != nil {
+		stats.PendingResults = s.results.len()
+	}
+	return stats
+}
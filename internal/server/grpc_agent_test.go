@@ -0,0 +1,691 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/energy"
+	"github.com/conductor/conductor/internal/websocket"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// capResultRepo records stored results.
+type capResultRepo struct {
+	results []*database.TestResult
+}
+
+func (r *capResultRepo) BatchCreate(ctx context.Context, results []database.TestResult) error {
+	for i := range results {
+		r.results = append(r.results, &results[i])
+	}
+	return nil
+}
+
+// capArtifactRepo records stored artifacts.
+type capArtifactRepo struct {
+	ArtifactRepository
+	artifacts []*database.Artifact
+}
+
+func (r *capArtifactRepo) Create(ctx context.Context, artifact *database.Artifact) error {
+	r.artifacts = append(r.artifacts, artifact)
+	return nil
+}
+
+func (r *capArtifactRepo) UsageByTest(ctx context.Context, runID, testID uuid.UUID) (int, int64, error) {
+	var files int
+	var bytes int64
+	for _, artifact := range r.artifacts {
+		if artifact.RunID == runID && artifact.TestDefinitionID != nil && *artifact.TestDefinitionID == testID {
+			files++
+			bytes += *artifact.SizeBytes
+		}
+	}
+	return files, bytes, nil
+}
+
+// budgetTestRepo serves a fixed test definition.
+type budgetTestRepo struct {
+	TestDefinitionRepository
+	test *database.TestDefinition
+}
+
+func (r *budgetTestRepo) GetByID(ctx context.Context, serviceID, testID uuid.UUID) (*database.TestDefinition, error) {
+	if r.test.ServiceID != serviceID || r.test.ID != testID {
+		return nil, database.ErrNotFound
+	}
+	return r.test, nil
+}
+
+// capIngestionRepo counts ingestion like the database does.
+type capIngestionRepo struct {
+	results, artifacts int
+	dropped            int64
+	err                error
+}
+
+func (r *capIngestionRepo) reserve(stored *int, n, limit int) (int, int64, error) {
+	if r.err != nil {
+		return 0, 0, r.err
+	}
+	accepted := min(n, max(limit-*stored, 0))
+	*stored += accepted
+	r.dropped += int64(n - accepted)
+	return accepted, r.dropped, nil
+}
+
+func (r *capIngestionRepo) ReserveResults(ctx context.Context, id uuid.UUID, n, limit int) (int, int64, error) {
+	return r.reserve(&r.results, n, limit)
+}
+
+func (r *capIngestionRepo) ReserveArtifacts(ctx context.Context, id uuid.UUID, n, limit int) (int, int64, error) {
+	return r.reserve(&r.artifacts, n, limit)
+}
+
+func TestHandleTestResult_ResultCap(t *testing.T) {
+	resultRepo := &capResultRepo{}
+	ingestion := &capIngestionRepo{}
+	server := NewAgentServiceServer(AgentServiceDeps{
+		ResultRepo:       resultRepo,
+		IngestionRepo:    ingestion,
+		MaxResultsPerRun: 2,
+	}, zerolog.Nop())
+
+	rs := &conductorv1.ResultStream{RunId: uuid.New().String()}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		err := server.handleTestResult(context.Background(), rs, &conductorv1.TestResultEvent{
+			TestName: name,
+			Status:   conductorv1.TestStatus_TEST_STATUS_PASS,
+		}, 0)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, resultRepo.results, 2)
+	assert.Equal(t, "b", resultRepo.results[1].TestName)
+	assert.EqualValues(t, 2, ingestion.dropped)
+}
+
+func TestHandleTestResult_CapFailsOpen(t *testing.T) {
+	resultRepo := &capResultRepo{}
+	server := NewAgentServiceServer(AgentServiceDeps{
+		ResultRepo:       resultRepo,
+		IngestionRepo:    &capIngestionRepo{err: errors.New("db down")},
+		MaxResultsPerRun: 1,
+	}, zerolog.Nop())
+
+	rs := &conductorv1.ResultStream{RunId: uuid.New().String()}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, server.handleTestResult(context.Background(), rs, &conductorv1.TestResultEvent{TestName: "a"}, 0))
+	}
+	assert.Len(t, resultRepo.results, 3)
+}
+
+func TestHandleArtifact_ArtifactCap(t *testing.T) {
+	artifactRepo := &capArtifactRepo{}
+	ingestion := &capIngestionRepo{}
+	server := NewAgentServiceServer(AgentServiceDeps{
+		ArtifactRepo:       artifactRepo,
+		IngestionRepo:      ingestion,
+		MaxArtifactsPerRun: 1,
+	}, zerolog.Nop())
+
+	runID := uuid.New()
+	rs := &conductorv1.ResultStream{RunId: runID.String()}
+	require.NoError(t, server.handleArtifact(context.Background(), rs, &conductorv1.ArtifactUploaded{
+		Name:        "report.html",
+		Path:        "out/report.html",
+		StorageUrl:  "runs/report.html",
+		ContentType: "text/html",
+		Size:        42,
+	}))
+	require.NoError(t, server.handleArtifact(context.Background(), rs, &conductorv1.ArtifactUploaded{Name: "trace.zip"}))
+
+	require.Len(t, artifactRepo.artifacts, 1)
+	artifact := artifactRepo.artifacts[0]
+	assert.Equal(t, runID, artifact.RunID)
+	assert.Equal(t, "runs/report.html", artifact.Path)
+	assert.Equal(t, int64(42), *artifact.SizeBytes)
+	assert.EqualValues(t, 1, ingestion.dropped)
+}
+
+func TestHandleArtifact_Budget(t *testing.T) {
+	maxBytes, maxFiles := int64(100), 2
+	test := &database.TestDefinition{ID: uuid.New(), ServiceID: uuid.New(), Name: "e2e", ArtifactMaxBytes: &maxBytes, ArtifactMaxFiles: &maxFiles}
+	run := &database.TestRun{ID: uuid.New(), ServiceID: test.ServiceID}
+
+	artifactRepo := &capArtifactRepo{}
+	server := NewAgentServiceServer(AgentServiceDeps{
+		RunRepo:      &detailRunRepo{runs: map[uuid.UUID]*database.TestRun{run.ID: run}},
+		ArtifactRepo: artifactRepo,
+		TestRepo:     &budgetTestRepo{test: test},
+	}, zerolog.Nop())
+
+	rs := &conductorv1.ResultStream{RunId: run.ID.String()}
+	upload := func(name string, size int64, testID string) error {
+		return server.handleArtifact(context.Background(), rs, &conductorv1.ArtifactUploaded{Name: name, Size: size, TestId: testID})
+	}
+
+	require.NoError(t, upload("trace.zip", 60, test.ID.String()))
+
+	err := upload("video.webm", 50, test.ID.String())
+	assert.True(t, errcode.Is(err, errcode.ArtifactBudgetExceeded))
+	assert.Contains(t, err.Error(), "over its budget of 100 bytes")
+
+	require.NoError(t, upload("screenshot.png", 40, test.ID.String()))
+
+	err = upload("log.txt", 0, test.ID.String())
+	assert.True(t, errcode.Is(err, errcode.ArtifactBudgetExceeded))
+	assert.Contains(t, err.Error(), "its budget is 2")
+
+	// Artifacts of other tests are not charged to the budget.
+	require.NoError(t, upload("report.html", 500, ""))
+
+	require.Len(t, artifactRepo.artifacts, 3)
+	assert.Equal(t, &test.ID, artifactRepo.artifacts[0].TestDefinitionID)
+	assert.Nil(t, artifactRepo.artifacts[2].TestDefinitionID)
+}
+
+func TestArtifactLimits(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryPoolRepo{pools: map[string]*database.AgentPool{
+		"gpu":   {Name: "gpu", MaxArtifactBytes: 64 << 20},
+		"batch": {Name: "batch"},
+	}}
+	server := NewAgentServiceServer(AgentServiceDeps{PoolRepo: repo, MaxArtifactBytes: 256 << 20}, zerolog.Nop())
+
+	register := func(pool string, agentLimit int64) int64 {
+		return server.artifactLimits(ctx, &conductorv1.RegisterRequest{
+			Pool:         pool,
+			Capabilities: &conductorv1.Capabilities{MaxArtifactBytes: agentLimit},
+		}).GetMaxArtifactBytes()
+	}
+
+	assert.Equal(t, int64(256<<20), register("", 0), "server limit")
+	assert.Equal(t, int64(128<<20), register("", 128<<20), "agent limit below server limit")
+	assert.Equal(t, int64(64<<20), register("gpu", 128<<20), "pool limit below agent limit")
+	assert.Equal(t, int64(32<<20), register("gpu", 32<<20), "agent limit below pool limit")
+	assert.Equal(t, int64(256<<20), register("batch", 0), "pool without limit")
+	assert.Equal(t, int64(256<<20), register("undefined", 0), "undefined pool")
+
+	unlimited := NewAgentServiceServer(AgentServiceDeps{}, zerolog.Nop())
+	assert.Zero(t, unlimited.artifactLimits(ctx, &conductorv1.RegisterRequest{}).GetMaxArtifactBytes())
+}
+
+func TestCheckArtifactSize(t *testing.T) {
+	agent := &connectedAgent{artifactLimits: &conductorv1.ArtifactLimits{MaxArtifactBytes: 100}}
+
+	require.NoError(t, checkArtifactSize(agent, &conductorv1.ArtifactUploaded{Name: "report.html", Size: 100}))
+
+	err := checkArtifactSize(agent, &conductorv1.ArtifactUploaded{Name: "video.webm", Size: 101})
+	assert.True(t, errcode.Is(err, errcode.ArtifactTooLarge))
+	assert.Contains(t, err.Error(), "over the agent's artifact size limit of 100 bytes")
+
+	require.NoError(t, checkArtifactSize(&connectedAgent{}, &conductorv1.ArtifactUploaded{Name: "video.webm", Size: 1 << 40}))
+}
+
+func TestHandleTestResult_NoCap(t *testing.T) {
+	resultRepo := &capResultRepo{}
+	ingestion := &capIngestionRepo{}
+	server := NewAgentServiceServer(AgentServiceDeps{
+		ResultRepo:    resultRepo,
+		IngestionRepo: ingestion,
+	}, zerolog.Nop())
+
+	rs := &conductorv1.ResultStream{RunId: uuid.New().String()}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, server.handleTestResult(context.Background(), rs, &conductorv1.TestResultEvent{TestName: "a"}, 0))
+	}
+	assert.Len(t, resultRepo.results, 3)
+	assert.Zero(t, ingestion.results, "counters are not touched without a cap")
+}
+
+// memoryAnnotationRepo keeps run annotations in memory.
+type memoryAnnotationRepo struct {
+	annotations []database.RunAnnotation
+}
+
+func (r *memoryAnnotationRepo) Create(ctx context.Context, annotation *database.RunAnnotation) error {
+	annotation.ID = uuid.New()
+	annotation.CreatedAt = time.Now()
+	r.annotations = append(r.annotations, *annotation)
+	return nil
+}
+
+func (r *memoryAnnotationRepo) ListByRun(ctx context.Context, runID uuid.UUID) ([]database.RunAnnotation, error) {
+	var annotations []database.RunAnnotation
+	for _, a := range r.annotations {
+		if a.RunID == runID {
+			annotations = append(annotations, a)
+		}
+	}
+	return annotations, nil
+}
+
+func TestHandleAnnotation(t *testing.T) {
+	repo := &memoryAnnotationRepo{}
+	server := NewAgentServiceServer(AgentServiceDeps{AnnotationRepo: repo}, zerolog.Nop())
+	agent := &connectedAgent{id: uuid.New()}
+	runID, shardID := uuid.New(), uuid.New()
+	rs := &conductorv1.ResultStream{RunId: runID.String(), ShardId: shardID.String()}
+
+	err := server.handleAnnotation(context.Background(), agent, rs, &conductorv1.RunAnnotation{
+		Kind:     "disk_pressure",
+		Severity: conductorv1.AnnotationSeverity_ANNOTATION_SEVERITY_WARNING,
+		Message:  "workspace disk is 85% full",
+		Metadata: map[string]string{"usage_percent": "85.0"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, server.handleAnnotation(context.Background(), agent, rs, &conductorv1.RunAnnotation{
+		Kind:    "note",
+		Message: strings.Repeat("x", maxAnnotationMessageLength+10),
+	}))
+	assert.Error(t, server.handleAnnotation(context.Background(), agent, rs, &conductorv1.RunAnnotation{Message: "no kind"}))
+
+	annotations, err := repo.ListByRun(context.Background(), runID)
+	require.NoError(t, err)
+	require.Len(t, annotations, 2)
+	assert.Equal(t, agent.id, *annotations[0].AgentID)
+	assert.Equal(t, shardID, *annotations[0].ShardID)
+	assert.Equal(t, database.AnnotationSeverityWarning, annotations[0].Severity)
+	assert.Len(t, annotations[1].Message, maxAnnotationMessageLength)
+
+	protos := runAnnotationsToProto(annotations)
+	require.Len(t, protos, 2)
+	assert.Equal(t, "disk_pressure", protos[0].Kind)
+	assert.Equal(t, shardID.String(), protos[0].ShardId)
+	assert.Equal(t, agent.id.String(), protos[0].AgentId)
+	assert.Equal(t, "85.0", protos[0].Metadata["usage_percent"])
+}
+
+// energySampleRepo records energy samples.
+type energySampleRepo struct {
+	samples []*database.EnergySample
+}
+
+func (r *energySampleRepo) RecordSample(ctx context.Context, sample *database.EnergySample) error {
+	r.samples = append(r.samples, sample)
+	return nil
+}
+
+func TestRecordEnergy(t *testing.T) {
+	repo := &energySampleRepo{}
+	server := NewAgentServiceServer(AgentServiceDeps{
+		EnergyRepo:       repo,
+		EnergyModel:      energy.Model{WattsPerCore: 10},
+		CarbonIntensity:  energy.CarbonIntensity{Zones: map[string]float64{"eu-north": 40}},
+		HeartbeatTimeout: time.Hour,
+	}, zerolog.Nop())
+
+	agent := &connectedAgent{
+		id: uuid.New(),
+		capabilities: &conductorv1.Capabilities{
+			NetworkZones: []string{"internal", "eu-north"},
+			Resources:    &conductorv1.Resources{CpuCores: 4},
+		},
+	}
+	runA, runB := uuid.New(), uuid.New()
+	hb := &conductorv1.Heartbeat{
+		ActiveRunIds:  []string{runA.String(), "not-a-run", runB.String()},
+		ResourceUsage: &conductorv1.ResourceUsage{CpuPercent: 50},
+	}
+
+	// 4 cores at 50% draw 20W; 20Wh over an hour split between three runs.
+	server.recordEnergy(context.Background(), agent, hb, time.Hour, time.Now())
+
+	require.Len(t, repo.samples, 2)
+	assert.Equal(t, runA, repo.samples[0].RunID)
+	assert.Equal(t, runB, repo.samples[1].RunID)
+	assert.InDelta(t, 20.0/3, repo.samples[0].EnergyWh, 1e-9)
+	assert.Equal(t, "eu-north", repo.samples[0].Zone)
+	assert.Equal(t, 4, repo.samples[0].CPUCores)
+
+	t.Run("caps gaps at the heartbeat timeout", func(t *testing.T) {
+		repo.samples = nil
+		server.recordEnergy(context.Background(), agent, hb, 5*time.Hour, time.Now())
+		require.Len(t, repo.samples, 2)
+		assert.InDelta(t, 20.0/3, repo.samples[0].EnergyWh, 1e-9)
+	})
+
+	t.Run("skips heartbeats without usage or runs", func(t *testing.T) {
+		repo.samples = nil
+		server.recordEnergy(context.Background(), agent, &conductorv1.Heartbeat{ActiveRunIds: []string{runA.String()}}, time.Minute, time.Now())
+		server.recordEnergy(context.Background(), agent, &conductorv1.Heartbeat{ResourceUsage: hb.ResourceUsage}, time.Minute, time.Now())
+		assert.Empty(t, repo.samples)
+	})
+}
+
+// memoryPoolRepo stores agent pools in memory.
+type memoryPoolRepo struct {
+	database.AgentPoolRepository
+	pools map[string]*database.AgentPool
+	stats []database.AgentPoolStats
+}
+
+func (r *memoryPoolRepo) Create(ctx context.Context, pool *database.AgentPool) error {
+	if _, ok := r.pools[pool.Name]; ok {
+		return database.ErrDuplicate
+	}
+	pool.ID = uuid.New()
+	r.pools[pool.Name] = pool
+	return nil
+}
+
+func (r *memoryPoolRepo) GetByName(ctx context.Context, name string) (*database.AgentPool, error) {
+	pool, ok := r.pools[name]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	copied := *pool
+	return &copied, nil
+}
+
+func (r *memoryPoolRepo) Update(ctx context.Context, pool *database.AgentPool) error {
+	if _, ok := r.pools[pool.Name]; !ok {
+		return database.ErrNotFound
+	}
+	r.pools[pool.Name] = pool
+	return nil
+}
+
+func (r *memoryPoolRepo) Delete(ctx context.Context, name string) error {
+	if _, ok := r.pools[name]; !ok {
+		return database.ErrNotFound
+	}
+	delete(r.pools, name)
+	return nil
+}
+
+func (r *memoryPoolRepo) Stats(ctx context.Context) ([]database.AgentPoolStats, error) {
+	return r.stats, nil
+}
+
+func TestAgentPools(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryPoolRepo{
+		pools: make(map[string]*database.AgentPool),
+		stats: []database.AgentPoolStats{{Pool: "gpu", Agents: 3, OnlineAgents: 2, RunningShards: 1}},
+	}
+	s := NewAgentManagementServer(AgentServiceDeps{PoolRepo: repo}, zerolog.Nop())
+
+	created, err := s.CreateAgentPool(ctx, &conductorv1.CreateAgentPoolRequest{
+		Name:                     "gpu",
+		Description:              "GPU runners",
+		MaxConcurrency:           4,
+		MaxConcurrencyPerService: 2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "gpu", created.Pool.Name)
+	assert.Equal(t, int32(4), created.Pool.MaxConcurrency)
+	assert.Zero(t, created.Pool.MaxArtifactBytes)
+
+	_, err = s.CreateAgentPool(ctx, &conductorv1.CreateAgentPoolRequest{Name: "gpu"})
+	assert.True(t, errcode.Is(err, errcode.AgentPoolAlreadyExists))
+
+	_, err = s.CreateAgentPool(ctx, &conductorv1.CreateAgentPoolRequest{Name: "arm", MaxConcurrency: -1})
+	assert.True(t, errcode.Is(err, errcode.InvalidArgument))
+
+	got, err := s.GetAgentPool(ctx, &conductorv1.GetAgentPoolRequest{Name: "gpu"})
+	require.NoError(t, err)
+	assert.Equal(t, "GPU runners", got.Pool.Description)
+	assert.Equal(t, int32(3), got.Pool.AgentCount)
+	assert.Equal(t, int32(2), got.Pool.OnlineAgentCount)
+	assert.Equal(t, int32(1), got.Pool.RunningShards)
+
+	unlimited := int32(0)
+	updated, err := s.UpdateAgentPool(ctx, &conductorv1.UpdateAgentPoolRequest{Name: "gpu", MaxConcurrency: &unlimited})
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), updated.Pool.MaxConcurrency)
+	assert.Equal(t, int32(2), updated.Pool.MaxConcurrencyPerService)
+
+	maxArtifactBytes := int64(64 << 20)
+	updated, err = s.UpdateAgentPool(ctx, &conductorv1.UpdateAgentPoolRequest{Name: "gpu", MaxArtifactBytes: &maxArtifactBytes})
+	require.NoError(t, err)
+	assert.Equal(t, maxArtifactBytes, updated.Pool.MaxArtifactBytes)
+
+	negative := int64(-1)
+	_, err = s.UpdateAgentPool(ctx, &conductorv1.UpdateAgentPoolRequest{Name: "gpu", MaxArtifactBytes: &negative})
+	assert.True(t, errcode.Is(err, errcode.InvalidArgument))
+
+	_, err = s.DeleteAgentPool(ctx, &conductorv1.DeleteAgentPoolRequest{Name: "gpu"})
+	require.NoError(t, err)
+	_, err = s.GetAgentPool(ctx, &conductorv1.GetAgentPoolRequest{Name: "gpu"})
+	assert.True(t, errcode.Is(err, errcode.AgentPoolNotFound))
+
+	_, err = NewAgentManagementServer(AgentServiceDeps{}, zerolog.Nop()).ListAgentPools(ctx, &conductorv1.ListAgentPoolsRequest{})
+	assert.True(t, errcode.Is(err, errcode.NotConfigured))
+}
+
+// detailAgentRepo returns a fixed agent.
+type detailAgentRepo struct {
+	AgentRepository
+	agent *database.Agent
+}
+
+func (r *detailAgentRepo) GetByID(ctx context.Context, id uuid.UUID) (*database.Agent, error) {
+	if id != r.agent.ID {
+		return nil, database.ErrNotFound
+	}
+	return r.agent, nil
+}
+
+// detailRunRepo serves runs by ID and lists fixed recent runs.
+type detailRunRepo struct {
+	RunRepository
+	runs       map[uuid.UUID]*database.TestRun
+	recent     []*database.TestRun
+	pagination database.Pagination
+}
+
+func (r *detailRunRepo) GetByID(ctx context.Context, id uuid.UUID) (*database.TestRun, error) {
+	run, ok := r.runs[id]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	return run, nil
+}
+
+func (r *detailRunRepo) List(ctx context.Context, filter RunFilter, pagination database.Pagination) ([]*database.TestRun, int, error) {
+	r.pagination = pagination
+	return r.recent, len(r.recent), nil
+}
+
+func TestGetAgentDetail(t *testing.T) {
+	ctx := context.Background()
+	agent := &database.Agent{ID: uuid.New(), Name: "agent-1", Status: database.AgentStatusBusy}
+	startedAt := time.Now().Add(-time.Minute)
+	active := &database.TestRun{ID: uuid.New(), ShardCount: 4, ShardsDone: 1, StartedAt: &startedAt}
+	finished := &database.TestRun{ID: uuid.New(), Status: database.RunStatusPassed}
+	runs := &detailRunRepo{
+		runs:   map[uuid.UUID]*database.TestRun{active.ID: active},
+		recent: []*database.TestRun{finished},
+	}
+	deps := AgentServiceDeps{
+		AgentRepo:   &detailAgentRepo{agent: agent},
+		RunRepo:     runs,
+		ServiceRepo: &placementServiceRepo{service: &database.Service{Name: "checkout"}},
+	}
+	agentService := NewAgentServiceServer(deps, zerolog.Nop())
+	mgmt := NewAgentManagementServer(deps, zerolog.Nop())
+	mgmt.live = agentService
+
+	t.Run("disconnected agents have only recent runs", func(t *testing.T) {
+		resp, err := mgmt.GetAgentDetail(ctx, &conductorv1.GetAgentDetailRequest{AgentId: agent.ID.String()})
+		require.NoError(t, err)
+		assert.False(t, resp.Connection.Connected)
+		assert.Nil(t, resp.Utilization)
+		assert.Empty(t, resp.CurrentRuns)
+		require.Len(t, resp.RecentRuns, 1)
+		assert.Equal(t, finished.ID.String(), resp.RecentRuns[0].Id)
+		assert.Equal(t, "checkout", resp.RecentRuns[0].ServiceName)
+		assert.Equal(t, defaultRecentAgentRuns, runs.pagination.Limit)
+	})
+
+	conn := &connectedAgent{id: agent.ID, connectedAt: time.Now()}
+	agentService.agents[agent.ID] = conn
+	conn.recordHeartbeat(&conductorv1.Heartbeat{
+		Status:        conductorv1.AgentStatus_AGENT_STATUS_BUSY,
+		ActiveRunIds:  []string{active.ID.String(), uuid.NewString()},
+		ResourceUsage: &conductorv1.ResourceUsage{CpuPercent: 75},
+	}, time.Now())
+	queued := uuid.NewString()
+	conn.queueWork(&conductorv1.AssignWork{RunId: queued, ShardId: "shard-1", ShardIndex: 1, ShardCount: 2}, time.Now())
+	conn.queueWork(&conductorv1.AssignWork{RunId: active.ID.String()}, time.Now())
+	conn.dequeueWork(active.ID.String(), "")
+
+	t.Run("connected agents report live state", func(t *testing.T) {
+		resp, err := mgmt.GetAgentDetail(ctx, &conductorv1.GetAgentDetailRequest{AgentId: agent.ID.String(), RecentRunsLimit: 500})
+		require.NoError(t, err)
+		assert.True(t, resp.Connection.Connected)
+		assert.Equal(t, conductorv1.AgentStatus_AGENT_STATUS_BUSY, resp.Connection.ReportedStatus)
+		assert.Equal(t, 75.0, resp.Utilization.CpuPercent)
+		assert.Equal(t, maxRecentAgentRuns, runs.pagination.Limit)
+
+		require.Len(t, resp.CurrentRuns, 1)
+		assert.Equal(t, active.ID.String(), resp.CurrentRuns[0].RunId)
+		assert.Equal(t, "checkout", resp.CurrentRuns[0].ServiceName)
+		assert.Equal(t, int32(25), resp.CurrentRuns[0].ProgressPercent)
+
+		require.Len(t, resp.QueuedWork, 1)
+		assert.Equal(t, queued, resp.QueuedWork[0].RunId)
+		assert.Equal(t, "shard-1", resp.QueuedWork[0].ShardId)
+		assert.Equal(t, int32(2), resp.QueuedWork[0].ShardCount)
+	})
+
+	t.Run("GetAgent includes current runs when requested", func(t *testing.T) {
+		resp, err := mgmt.GetAgent(ctx, &conductorv1.GetAgentRequest{AgentId: agent.ID.String(), IncludeCurrentRuns: true})
+		require.NoError(t, err)
+		require.Len(t, resp.CurrentRuns, 1)
+		assert.Equal(t, active.ID.String(), resp.CurrentRuns[0].RunId)
+	})
+
+	t.Run("unknown agents are not found", func(t *testing.T) {
+		_, err := mgmt.GetAgentDetail(ctx, &conductorv1.GetAgentDetailRequest{AgentId: uuid.NewString()})
+		assert.True(t, errcode.Is(err, errcode.AgentNotFound))
+	})
+}
+
+// logStream answers log requests like an agent with a log buffer.
+type logStream struct {
+	conductorv1.AgentService_WorkStreamServer
+	agent    *connectedAgent
+	lines    []*conductorv1.AgentLogLine
+	err      string
+	silent   bool
+	requests []*conductorv1.LogRequest
+}
+
+func (s *logStream) Send(msg *conductorv1.ControlMessage) error {
+	req := msg.GetLogRequest()
+	s.requests = append(s.requests, req)
+	if !s.silent {
+		go s.agent.deliverLogs(&conductorv1.AgentLogs{RequestId: req.RequestId, Lines: s.lines, Error: s.err})
+	}
+	return nil
+}
+
+func TestGetAgentLogs(t *testing.T) {
+	ctx := context.Background()
+	agent := &database.Agent{ID: uuid.New(), Name: "agent-1"}
+	deps := AgentServiceDeps{AgentRepo: &detailAgentRepo{agent: agent}}
+	agentService := NewAgentServiceServer(deps, zerolog.Nop())
+	mgmt := NewAgentManagementServer(deps, zerolog.Nop())
+	mgmt.live = agentService
+
+	t.Run("disconnected agents are offline", func(t *testing.T) {
+		_, err := mgmt.GetAgentLogs(ctx, &conductorv1.GetAgentLogsRequest{AgentId: agent.ID.String()})
+		assert.True(t, errcode.Is(err, errcode.AgentOffline))
+	})
+
+	conn := &connectedAgent{id: agent.ID}
+	stream := &logStream{agent: conn}
+	conn.stream = stream
+	agentService.agents[agent.ID] = conn
+
+	t.Run("returns the agent's lines", func(t *testing.T) {
+		stream.lines = []*conductorv1.AgentLogLine{{Level: "error", Line: `{"level":"error","message":"clone failed"}`}}
+
+		resp, err := mgmt.GetAgentLogs(ctx, &conductorv1.GetAgentLogsRequest{AgentId: agent.ID.String(), MinLevel: "warn"})
+		require.NoError(t, err)
+		require.Len(t, resp.Lines, 1)
+		assert.Equal(t, "error", resp.Lines[0].Level)
+		assert.NotNil(t, resp.CollectedAt)
+
+		req := stream.requests[len(stream.requests)-1]
+		assert.Equal(t, int32(defaultAgentLogLines), req.Limit)
+		assert.Equal(t, "warn", req.MinLevel)
+		assert.Empty(t, conn.logRequests)
+	})
+
+	t.Run("surfaces agent errors", func(t *testing.T) {
+		stream.err = "log buffer is disabled"
+		defer func() { stream.err = "" }()
+
+		_, err := mgmt.GetAgentLogs(ctx, &conductorv1.GetAgentLogsRequest{AgentId: agent.ID.String()})
+		assert.True(t, errcode.Is(err, errcode.FailedPrecondition))
+	})
+
+	t.Run("times out when the agent does not reply", func(t *testing.T) {
+		stream.silent = true
+		defer func() { stream.silent = false }()
+
+		ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err := mgmt.GetAgentLogs(ctx, &conductorv1.GetAgentLogsRequest{AgentId: agent.ID.String()})
+		assert.True(t, errcode.Is(err, errcode.Unavailable))
+		assert.Empty(t, conn.logRequests)
+	})
+
+	t.Run("rejects unknown levels", func(t *testing.T) {
+		_, err := mgmt.GetAgentLogs(ctx, &conductorv1.GetAgentLogsRequest{AgentId: agent.ID.String(), MinLevel: "verbose"})
+		assert.True(t, errcode.Is(err, errcode.InvalidArgument))
+	})
+}
+
+// statusAgentRepo records agent status updates.
+type statusAgentRepo struct {
+	AgentRepository
+	statuses map[uuid.UUID]database.AgentStatus
+}
+
+func (r *statusAgentRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status database.AgentStatus) error {
+	r.statuses[id] = status
+	return nil
+}
+
+// agentEvents records published agent updates.
+type agentEvents struct {
+	events []websocket.AgentEvent
+}
+
+func (p *agentEvents) PublishAgentUpdate(agent websocket.AgentEvent) error {
+	p.events = append(p.events, agent)
+	return nil
+}
+
+func TestDisconnectAgent_PublishesOffline(t *testing.T) {
+	repo := &statusAgentRepo{statuses: make(map[uuid.UUID]database.AgentStatus)}
+	events := &agentEvents{}
+	s := NewAgentServiceServer(AgentServiceDeps{AgentRepo: repo, Events: events}, zerolog.Nop())
+
+	agentID := uuid.New()
+	s.agents[agentID] = &connectedAgent{id: agentID, name: "agent-1", cancel: func() {}}
+
+	s.disconnectAgent(agentID)
+	s.disconnectAgent(agentID)
+
+	assert.Equal(t, database.AgentStatusOffline, repo.statuses[agentID])
+	require.Len(t, events.events, 1, "only connected agents are published")
+	assert.Equal(t, agentID, events.events[0].AgentID)
+	assert.Equal(t, "agent-1", events.events[0].Name)
+	assert.Equal(t, string(database.AgentStatusOffline), events.events[0].Status)
+}
@@ -2,15 +2,16 @@ package server
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
 	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
 )
 
 // AgentManagementServer implements the AgentManagementService gRPC service.
@@ -19,8 +20,25 @@ type AgentManagementServer struct {
 
 	deps   AgentServiceDeps
 	logger zerolog.Logger
+
+	// live reports the state of connected agents (optional).
+	live liveAgentSource
 }
 
+// Bounds of the recent runs returned with an agent's detail.
+const (
+	defaultRecentAgentRuns = 10
+	maxRecentAgentRuns     = 100
+)
+
+// Bounds of the log lines retrieved from an agent, and how long to wait
+// for the agent to upload them.
+const (
+	defaultAgentLogLines = 200
+	maxAgentLogLines     = 5000
+	agentLogsTimeout     = 15 * time.Second
+)
+
 // NewAgentManagementServer creates a new agent management server.
 func NewAgentManagementServer(deps AgentServiceDeps, logger zerolog.Logger) *AgentManagementServer {
 	return &AgentManagementServer{
@@ -34,6 +52,7 @@ func (s *AgentManagementServer) ListAgents(ctx context.Context, req *conductorv1
 	filter := AgentFilter{
 		NetworkZone: req.NetworkZone,
 		Labels:      req.Labels,
+		Pool:        req.Pool,
 		Query:       req.Query,
 	}
 
@@ -45,10 +64,11 @@ func (s *AgentManagementServer) ListAgents(ctx context.Context, req *conductorv1
 	}
 
 	pagination := paginationFromProto(req.Pagination)
-	agents, total, err := s.deps.AgentRepo.List(ctx, filter, pagination)
+	agents, total, err := s.deps.AgentRepo.List(ctx, filter, peekPagination(pagination))
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to list agents: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to list agents: %v", err)
 	}
+	agents, page := keysetPage(pagination, agents, total, agentCursor)
 
 	protoAgents := make([]*conductorv1.Agent, len(agents))
 	for i, agent := range agents {
@@ -57,7 +77,7 @@ func (s *AgentManagementServer) ListAgents(ctx context.Context, req *conductorv1
 
 	return &conductorv1.ListAgentsResponse{
 		Agents:     protoAgents,
-		Pagination: paginationResponseToProto(pagination, total),
+		Pagination: page,
 	}, nil
 }
 
@@ -65,48 +85,217 @@ func (s *AgentManagementServer) ListAgents(ctx context.Context, req *conductorv1
 func (s *AgentManagementServer) GetAgent(ctx context.Context, req *conductorv1.GetAgentRequest) (*conductorv1.GetAgentResponse, error) {
 	agentID, err := uuid.Parse(req.AgentId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid agent ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid agent ID: %v", err)
 	}
 
 	agent, err := s.deps.AgentRepo.GetByID(ctx, agentID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "agent not found: %s", req.AgentId)
+			return nil, errcode.New(errcode.AgentNotFound, "agent not found: %s", req.AgentId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get agent: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get agent: %v", err)
 	}
 
 	resp := &conductorv1.GetAgentResponse{
 		Agent: agentToProto(agent),
 	}
 
-	// TODO: Include current runs if requested
+	if req.IncludeCurrentRuns {
+		if state, ok := s.liveAgent(ctx, agentID); ok {
+			resp.CurrentRuns = s.currentRuns(ctx, state.activeRunIDs)
+		}
+	}
 
 	return resp, nil
 }
 
+// GetAgentDetail returns an agent with its live connection state,
+// utilization and work, and the runs it executed most recently.
+func (s *AgentManagementServer) GetAgentDetail(ctx context.Context, req *conductorv1.GetAgentDetailRequest) (*conductorv1.GetAgentDetailResponse, error) {
+	agentID, err := uuid.Parse(req.AgentId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid agent ID: %v", err)
+	}
+
+	limit := int(req.RecentRunsLimit)
+	if limit <= 0 {
+		limit = defaultRecentAgentRuns
+	}
+	if limit > maxRecentAgentRuns {
+		limit = maxRecentAgentRuns
+	}
+
+	agent, err := s.deps.AgentRepo.GetByID(ctx, agentID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.AgentNotFound, "agent not found: %s", req.AgentId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get agent: %v", err)
+	}
+
+	resp := &conductorv1.GetAgentDetailResponse{
+		Agent:      agentToProto(agent),
+		Connection: agentConnectionToProto(nil),
+	}
+
+	if state, ok := s.liveAgent(ctx, agentID); ok {
+		resp.Connection = agentConnectionToProto(state)
+		resp.Utilization = state.usage
+		resp.CurrentRuns = s.currentRuns(ctx, state.activeRunIDs)
+		resp.QueuedWork = queuedWorkToProto(state.queued)
+	}
+
+	end := time.Now()
+	start := end.Add(-defaultAgentRunsWindow)
+	filter := RunFilter{AgentID: &agentID, StartTime: &start, EndTime: &end}
+	runs, _, err := s.deps.RunRepo.List(ctx, filter, database.Pagination{Limit: limit})
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list runs: %v", err)
+	}
+
+	services := make(map[uuid.UUID]*database.Service)
+	resp.RecentRuns = make([]*conductorv1.Run, len(runs))
+	for i, run := range runs {
+		resp.RecentRuns[i] = runToProto(run, s.service(ctx, services, run.ServiceID))
+	}
+
+	return resp, nil
+}
+
+// GetAgentLogs asks a connected agent for the most recent lines of its log
+// buffer over its work stream.
+func (s *AgentManagementServer) GetAgentLogs(ctx context.Context, req *conductorv1.GetAgentLogsRequest) (*conductorv1.GetAgentLogsResponse, error) {
+	agentID, err := uuid.Parse(req.AgentId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid agent ID: %v", err)
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultAgentLogLines
+	}
+	if limit > maxAgentLogLines {
+		limit = maxAgentLogLines
+	}
+	switch req.MinLevel {
+	case "", "debug", "info", "warn", "error":
+	default:
+		return nil, errcode.New(errcode.InvalidArgument, "invalid min_level: %s (must be debug, info, warn or error)", req.MinLevel)
+	}
+
+	if _, err := s.deps.AgentRepo.GetByID(ctx, agentID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.AgentNotFound, "agent not found: %s", req.AgentId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get agent: %v", err)
+	}
+	if s.live == nil {
+		return nil, errcode.New(errcode.AgentOffline, "agent %s is not connected to this control plane", req.AgentId)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, agentLogsTimeout)
+	defer cancel()
+
+	logs, err := s.live.requestLogs(ctx, agentID, limit, req.MinLevel)
+	switch {
+	case errors.Is(err, errAgentNotConnected):
+		return nil, errcode.New(errcode.AgentOffline, "agent %s is not connected to the control plane", req.AgentId)
+	case errors.Is(err, context.DeadlineExceeded):
+		return nil, errcode.New(errcode.Unavailable, "agent %s did not return its logs within %s", req.AgentId, agentLogsTimeout)
+	case err != nil:
+		return nil, errcode.New(errcode.Internal, "failed to request agent logs: %v", err)
+	case logs.Error != "":
+		return nil, errcode.New(errcode.FailedPrecondition, "agent cannot return its logs: %s", logs.Error)
+	}
+
+	return &conductorv1.GetAgentLogsResponse{
+		Lines:       logs.Lines,
+		Truncated:   logs.Truncated,
+		CollectedAt: timestamppb.Now(),
+	}, nil
+}
+
+// liveAgent returns the live state of a connected agent.
+func (s *AgentManagementServer) liveAgent(ctx context.Context, agentID uuid.UUID) (*agentLiveState, bool) {
+	if s.live == nil {
+		return nil, false
+	}
+	return s.live.liveAgent(ctx, agentID)
+}
+
+// currentRuns loads the runs an agent reported as active. Runs that cannot
+// be loaded are skipped.
+func (s *AgentManagementServer) currentRuns(ctx context.Context, runIDs []string) []*conductorv1.AgentRun {
+	services := make(map[uuid.UUID]*database.Service)
+	current := make([]*conductorv1.AgentRun, 0, len(runIDs))
+	for _, id := range runIDs {
+		runID, err := uuid.Parse(id)
+		if err != nil {
+			continue
+		}
+		run, err := s.deps.RunRepo.GetByID(ctx, runID)
+		if err != nil {
+			s.logger.Debug().Err(err).Str("run_id", id).Msg("failed to get active run")
+			continue
+		}
+
+		agentRun := &conductorv1.AgentRun{
+			RunId:           id,
+			ProgressPercent: runProgress(run),
+		}
+		if svc := s.service(ctx, services, run.ServiceID); svc != nil {
+			agentRun.ServiceName = svc.Name
+		}
+		if run.StartedAt != nil {
+			agentRun.StartedAt = timestamppb.New(*run.StartedAt)
+		}
+		current = append(current, agentRun)
+	}
+	return current
+}
+
+// service looks up a service through a cache shared by one request.
+// Services that cannot be loaded are cached as nil.
+func (s *AgentManagementServer) service(ctx context.Context, cache map[uuid.UUID]*database.Service, id uuid.UUID) *database.Service {
+	svc, ok := cache[id]
+	if !ok {
+		svc, _ = s.deps.ServiceRepo.GetByID(ctx, id)
+		cache[id] = svc
+	}
+	return svc
+}
+
+// runProgress estimates the percentage of a run that has finished from its
+// completed shards.
+func runProgress(run *database.TestRun) int32 {
+	if run.ShardCount <= 0 {
+		return 0
+	}
+	return int32(run.ShardsDone * 100 / run.ShardCount)
+}
+
 // DrainAgent puts an agent into draining mode.
 func (s *AgentManagementServer) DrainAgent(ctx context.Context, req *conductorv1.DrainAgentRequest) (*conductorv1.DrainAgentResponse, error) {
 	agentID, err := uuid.Parse(req.AgentId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid agent ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid agent ID: %v", err)
 	}
 
 	agent, err := s.deps.AgentRepo.GetByID(ctx, agentID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "agent not found: %s", req.AgentId)
+			return nil, errcode.New(errcode.AgentNotFound, "agent not found: %s", req.AgentId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get agent: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get agent: %v", err)
 	}
 
 	if agent.Status == database.AgentStatusOffline {
-		return nil, status.Error(codes.FailedPrecondition, "cannot drain offline agent")
+		return nil, errcode.New(errcode.AgentOffline, "cannot drain offline agent")
 	}
 
 	// Update status to draining
 	if err := s.deps.AgentRepo.UpdateStatus(ctx, agentID, database.AgentStatusDraining); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to update agent status: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to update agent status: %v", err)
 	}
 
 	// Fetch updated agent
@@ -128,24 +317,24 @@ func (s *AgentManagementServer) DrainAgent(ctx context.Context, req *conductorv1
 func (s *AgentManagementServer) UndrainAgent(ctx context.Context, req *conductorv1.UndrainAgentRequest) (*conductorv1.UndrainAgentResponse, error) {
 	agentID, err := uuid.Parse(req.AgentId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid agent ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid agent ID: %v", err)
 	}
 
 	agent, err := s.deps.AgentRepo.GetByID(ctx, agentID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "agent not found: %s", req.AgentId)
+			return nil, errcode.New(errcode.AgentNotFound, "agent not found: %s", req.AgentId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get agent: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get agent: %v", err)
 	}
 
 	if agent.Status != database.AgentStatusDraining {
-		return nil, status.Errorf(codes.FailedPrecondition, "agent is not draining, current status: %s", agent.Status)
+		return nil, errcode.New(errcode.AgentNotDraining, "agent is not draining, current status: %s", agent.Status)
 	}
 
 	// Update status to idle
 	if err := s.deps.AgentRepo.UpdateStatus(ctx, agentID, database.AgentStatusIdle); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to update agent status: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to update agent status: %v", err)
 	}
 
 	// Fetch updated agent
@@ -164,23 +353,23 @@ func (s *AgentManagementServer) UndrainAgent(ctx context.Context, req *conductor
 func (s *AgentManagementServer) DeleteAgent(ctx context.Context, req *conductorv1.DeleteAgentRequest) (*conductorv1.DeleteAgentResponse, error) {
 	agentID, err := uuid.Parse(req.AgentId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid agent ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid agent ID: %v", err)
 	}
 
 	agent, err := s.deps.AgentRepo.GetByID(ctx, agentID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "agent not found: %s", req.AgentId)
+			return nil, errcode.New(errcode.AgentNotFound, "agent not found: %s", req.AgentId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get agent: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get agent: %v", err)
 	}
 
 	if agent.Status != database.AgentStatusOffline && !req.Force {
-		return nil, status.Error(codes.FailedPrecondition, "cannot delete online agent without force flag")
+		return nil, errcode.New(errcode.AgentOnline, "cannot delete online agent without force flag")
 	}
 
 	if err := s.deps.AgentRepo.Delete(ctx, agentID); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to delete agent: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to delete agent: %v", err)
 	}
 
 	s.logger.Info().
@@ -198,16 +387,16 @@ func (s *AgentManagementServer) DeleteAgent(ctx context.Context, req *conductorv
 func (s *AgentManagementServer) GetAgentStats(ctx context.Context, req *conductorv1.GetAgentStatsRequest) (*conductorv1.GetAgentStatsResponse, error) {
 	agentID, err := uuid.Parse(req.AgentId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid agent ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid agent ID: %v", err)
 	}
 
 	// Verify agent exists
 	_, err = s.deps.AgentRepo.GetByID(ctx, agentID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "agent not found: %s", req.AgentId)
+			return nil, errcode.New(errcode.AgentNotFound, "agent not found: %s", req.AgentId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get agent: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get agent: %v", err)
 	}
 
 	// TODO: Implement actual stats collection
@@ -222,8 +411,230 @@ func (s *AgentManagementServer) GetAgentStats(ctx context.Context, req *conducto
 	}, nil
 }
 
+// ListAgentPools returns all agent pools with their current usage.
+func (s *AgentManagementServer) ListAgentPools(ctx context.Context, req *conductorv1.ListAgentPoolsRequest) (*conductorv1.ListAgentPoolsResponse, error) {
+	if err := s.requirePools(); err != nil {
+		return nil, err
+	}
+
+	pools, err := s.deps.PoolRepo.List(ctx)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list agent pools: %v", err)
+	}
+	stats, err := s.poolStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	protoPools := make([]*conductorv1.AgentPool, len(pools))
+	for i := range pools {
+		protoPools[i] = agentPoolToProto(&pools[i], stats[pools[i].Name])
+	}
+
+	return &conductorv1.ListAgentPoolsResponse{
+		Pools: protoPools,
+	}, nil
+}
+
+// GetAgentPool retrieves an agent pool by name.
+func (s *AgentManagementServer) GetAgentPool(ctx context.Context, req *conductorv1.GetAgentPoolRequest) (*conductorv1.GetAgentPoolResponse, error) {
+	if err := s.requirePools(); err != nil {
+		return nil, err
+	}
+
+	pool, err := s.getPool(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.poolStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conductorv1.GetAgentPoolResponse{
+		Pool: agentPoolToProto(pool, stats[pool.Name]),
+	}, nil
+}
+
+// CreateAgentPool creates an agent pool.
+func (s *AgentManagementServer) CreateAgentPool(ctx context.Context, req *conductorv1.CreateAgentPoolRequest) (*conductorv1.CreateAgentPoolResponse, error) {
+	if err := s.requirePools(); err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		return nil, errcode.New(errcode.InvalidArgument, "name is required")
+	}
+	if err := validatePoolQuotas(req.MaxConcurrency, req.MaxConcurrencyPerService); err != nil {
+		return nil, err
+	}
+	if req.MaxArtifactBytes < 0 {
+		return nil, errcode.New(errcode.InvalidArgument, "max_artifact_bytes must not be negative")
+	}
+
+	pool := &database.AgentPool{
+		Name:                     req.Name,
+		Description:              database.NullString(req.Description),
+		MaxConcurrency:           int(req.MaxConcurrency),
+		MaxConcurrencyPerService: int(req.MaxConcurrencyPerService),
+		MaxArtifactBytes:         req.MaxArtifactBytes,
+	}
+	if err := s.deps.PoolRepo.Create(ctx, pool); err != nil {
+		if database.IsDuplicate(err) {
+			return nil, errcode.New(errcode.AgentPoolAlreadyExists, "agent pool %q already exists", req.Name)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to create agent pool: %v", err)
+	}
+
+	s.logger.Info().
+		Str("pool", pool.Name).
+		Int("max_concurrency", pool.MaxConcurrency).
+		Int("max_concurrency_per_service", pool.MaxConcurrencyPerService).
+		Int64("max_artifact_bytes", pool.MaxArtifactBytes).
+		Msg("agent pool created")
+
+	return &conductorv1.CreateAgentPoolResponse{
+		Pool: agentPoolToProto(pool, database.AgentPoolStats{}),
+	}, nil
+}
+
+// UpdateAgentPool updates the description and quotas of an agent pool.
+func (s *AgentManagementServer) UpdateAgentPool(ctx context.Context, req *conductorv1.UpdateAgentPoolRequest) (*conductorv1.UpdateAgentPoolResponse, error) {
+	if err := s.requirePools(); err != nil {
+		return nil, err
+	}
+
+	pool, err := s.getPool(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Description != nil {
+		pool.Description = database.NullString(*req.Description)
+	}
+	if req.MaxConcurrency != nil {
+		pool.MaxConcurrency = int(*req.MaxConcurrency)
+	}
+	if req.MaxConcurrencyPerService != nil {
+		pool.MaxConcurrencyPerService = int(*req.MaxConcurrencyPerService)
+	}
+	if req.MaxArtifactBytes != nil {
+		if *req.MaxArtifactBytes < 0 {
+			return nil, errcode.New(errcode.InvalidArgument, "max_artifact_bytes must not be negative")
+		}
+		pool.MaxArtifactBytes = *req.MaxArtifactBytes
+	}
+	if err := validatePoolQuotas(int32(pool.MaxConcurrency), int32(pool.MaxConcurrencyPerService)); err != nil {
+		return nil, err
+	}
+
+	if err := s.deps.PoolRepo.Update(ctx, pool); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.AgentPoolNotFound, "agent pool not found: %s", req.Name)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to update agent pool: %v", err)
+	}
+
+	s.logger.Info().
+		Str("pool", pool.Name).
+		Int("max_concurrency", pool.MaxConcurrency).
+		Int("max_concurrency_per_service", pool.MaxConcurrencyPerService).
+		Int64("max_artifact_bytes", pool.MaxArtifactBytes).
+		Msg("agent pool updated")
+
+	stats, err := s.poolStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conductorv1.UpdateAgentPoolResponse{
+		Pool: agentPoolToProto(pool, stats[pool.Name]),
+	}, nil
+}
+
+// DeleteAgentPool deletes an agent pool.
+func (s *AgentManagementServer) DeleteAgentPool(ctx context.Context, req *conductorv1.DeleteAgentPoolRequest) (*conductorv1.DeleteAgentPoolResponse, error) {
+	if err := s.requirePools(); err != nil {
+		return nil, err
+	}
+
+	if err := s.deps.PoolRepo.Delete(ctx, req.Name); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.AgentPoolNotFound, "agent pool not found: %s", req.Name)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to delete agent pool: %v", err)
+	}
+
+	s.logger.Info().
+		Str("pool", req.Name).
+		Msg("agent pool deleted")
+
+	return &conductorv1.DeleteAgentPoolResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *AgentManagementServer) requirePools() error {
+	if s.deps.PoolRepo == nil {
+		return errcode.New(errcode.NotConfigured, "agent pools are not configured")
+	}
+	return nil
+}
+
+func (s *AgentManagementServer) getPool(ctx context.Context, name string) (*database.AgentPool, error) {
+	pool, err := s.deps.PoolRepo.GetByName(ctx, name)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.AgentPoolNotFound, "agent pool not found: %s", name)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get agent pool: %v", err)
+	}
+	return pool, nil
+}
+
+// poolStats returns the current usage of every pool by name.
+func (s *AgentManagementServer) poolStats(ctx context.Context) (map[string]database.AgentPoolStats, error) {
+	stats, err := s.deps.PoolRepo.Stats(ctx)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to get agent pool stats: %v", err)
+	}
+
+	byName := make(map[string]database.AgentPoolStats, len(stats))
+	for _, st := range stats {
+		byName[st.Pool] = st
+	}
+	return byName, nil
+}
+
+func validatePoolQuotas(maxConcurrency, maxConcurrencyPerService int32) error {
+	if maxConcurrency < 0 {
+		return errcode.New(errcode.InvalidArgument, "max_concurrency must not be negative")
+	}
+	if maxConcurrencyPerService < 0 {
+		return errcode.New(errcode.InvalidArgument, "max_concurrency_per_service must not be negative")
+	}
+	return nil
+}
+
 // Helper functions
 
+func agentPoolToProto(pool *database.AgentPool, stats database.AgentPoolStats) *conductorv1.AgentPool {
+	protoPool := &conductorv1.AgentPool{
+		Id:                       pool.ID.String(),
+		Name:                     pool.Name,
+		MaxConcurrency:           int32(pool.MaxConcurrency),
+		MaxConcurrencyPerService: int32(pool.MaxConcurrencyPerService),
+		AgentCount:               int32(stats.Agents),
+		OnlineAgentCount:         int32(stats.OnlineAgents),
+		RunningShards:            int32(stats.RunningShards),
+		MaxArtifactBytes:         pool.MaxArtifactBytes,
+		CreatedAt:                timestamppb.New(pool.CreatedAt),
+		UpdatedAt:                timestamppb.New(pool.UpdatedAt),
+	}
+	if pool.Description != nil {
+		protoPool.Description = *pool.Description
+	}
+	return protoPool
+}
+
 func agentToProto(agent *database.Agent) *conductorv1.Agent {
 	if agent == nil {
 		return nil
@@ -236,6 +647,7 @@ func agentToProto(agent *database.Agent) *conductorv1.Agent {
 		NetworkZones: agent.NetworkZones,
 		MaxParallel:  int32(agent.MaxParallel),
 		RegisteredAt: timestamppb.New(agent.RegisteredAt),
+		Labels:       agent.Labels,
 		Capabilities: &conductorv1.AgentCapabilities{
 			DockerAvailable: agent.DockerAvailable,
 		},
@@ -244,6 +656,12 @@ func agentToProto(agent *database.Agent) *conductorv1.Agent {
 	if agent.Version != nil {
 		protoAgent.Version = *agent.Version
 	}
+	if agent.Pool != nil {
+		protoAgent.Pool = *agent.Pool
+	}
+	if agent.ProjectID != uuid.Nil {
+		protoAgent.ProjectId = agent.ProjectID.String()
+	}
 
 	if agent.LastHeartbeat != nil {
 		protoAgent.LastHeartbeat = timestamppb.New(*agent.LastHeartbeat)
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// AuditServiceDeps defines the dependencies for the audit service.
+type AuditServiceDeps struct {
+	// Repo queries the audit log.
+	Repo AuditLogReader
+}
+
+// AuditLogReader lists audit logs. database.AuditLogRepository implements it.
+type AuditLogReader interface {
+	List(ctx context.Context, filter database.AuditLogFilter, page database.Pagination) ([]database.AuditLog, int, error)
+}
+
+// AuditServiceServer implements the AuditService gRPC service.
+type AuditServiceServer struct {
+	conductorv1.UnimplementedAuditServiceServer
+
+	deps   AuditServiceDeps
+	logger zerolog.Logger
+}
+
+// NewAuditServiceServer creates a new audit service server.
+func NewAuditServiceServer(deps AuditServiceDeps, logger zerolog.Logger) *AuditServiceServer {
+	return &AuditServiceServer{
+		deps:   deps,
+		logger: logger.With().Str("service", "AuditService").Logger(),
+	}
+}
+
+// ListAuditLogs returns audit logs, newest first. Only admins may read them.
+func (s *AuditServiceServer) ListAuditLogs(ctx context.Context, req *conductorv1.ListAuditLogsRequest) (*conductorv1.ListAuditLogsResponse, error) {
+	if claims := GetUserFromContext(ctx); claims == nil || !claims.IsAdmin() {
+		return nil, errcode.New(errcode.PermissionDenied, "the audit log requires the admin role")
+	}
+	if s.deps.Repo == nil {
+		return nil, errcode.New(errcode.NotConfigured, "the audit log is not configured")
+	}
+
+	var filter database.AuditLogFilter
+	for _, f := range []struct {
+		value string
+		dst   **string
+	}{
+		{req.Actor, &filter.Actor},
+		{req.Action, &filter.Action},
+		{req.ResourceType, &filter.ResourceType},
+		{req.ResourceId, &filter.ResourceID},
+	} {
+		if f.value != "" {
+			value := f.value
+			*f.dst = &value
+		}
+	}
+	if req.TimeRange != nil {
+		if req.TimeRange.Start != nil {
+			t := req.TimeRange.Start.AsTime()
+			filter.Since = &t
+		}
+		if req.TimeRange.End != nil {
+			t := req.TimeRange.End.AsTime()
+			filter.Until = &t
+		}
+	}
+
+	pagination := paginationFromProto(req.Pagination)
+	logs, total, err := s.deps.Repo.List(ctx, filter, pagination)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list audit logs: %v", err)
+	}
+
+	resp := &conductorv1.ListAuditLogsResponse{
+		Logs:       make([]*conductorv1.AuditLog, len(logs)),
+		Pagination: paginationResponseToProto(pagination, total),
+	}
+	for i := range logs {
+		resp.Logs[i] = auditLogToProto(&logs[i])
+	}
+	return resp, nil
+}
+
+// auditLogToProto converts an audit log to its proto representation.
+func auditLogToProto(l *database.AuditLog) *conductorv1.AuditLog {
+	return &conductorv1.AuditLog{
+		Id:           l.ID.String(),
+		Actor:        l.Actor,
+		ActorEmail:   l.ActorEmail,
+		Action:       l.Action,
+		Protocol:     string(l.Protocol),
+		ResourceType: l.ResourceType,
+		ResourceId:   l.ResourceID,
+		Request:      string(l.Request),
+		Before:       string(l.Before),
+		After:        string(l.After),
+		Status:       l.Status,
+		Error:        l.Error,
+		SourceIp:     l.SourceIP,
+		RequestId:    l.RequestID,
+		UserAgent:    l.UserAgent,
+		CreatedAt:    timestamppb.New(l.CreatedAt),
+	}
+}
@@ -2,19 +2,40 @@ package server
 
 import (
 	"context"
+	"net/http"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
 	"github.com/conductor/conductor/internal/database"
 )
 
+const (
+	// dependencyCheckTimeout bounds the health check of a dependency.
+	dependencyCheckTimeout = 5 * time.Second
+	// slowDependencyLatency is the latency from which a dependency is degraded.
+	slowDependencyLatency = time.Second
+	// readinessCheckInterval is how often readiness is reported through the
+	// gRPC health protocol.
+	readinessCheckInterval = 10 * time.Second
+)
+
 // HealthServiceDeps defines the dependencies for the health service.
 type HealthServiceDeps struct {
 	// DB is the database connection for health checks.
 	DB HealthChecker
+	// ArtifactStorage is the artifact storage checked for readiness (optional).
+	ArtifactStorage DependencyChecker
+	// GitProvider is the git provider checked when all dependencies are
+	// checked (optional). It is not critical: while it is unreachable the
+	// service is degraded but ready.
+	GitProvider DependencyChecker
 	// StartTime is when the server started.
 	StartTime time.Time
 	// Version is the server version info.
@@ -27,6 +48,11 @@ type HealthChecker interface {
 	Stats() database.HealthStats
 }
 
+// DependencyChecker checks that a dependency is reachable.
+type DependencyChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
 // VersionInfo contains version information about the server.
 type VersionInfo struct {
 	Version   string
@@ -73,7 +99,7 @@ func (s *HealthServiceServer) Check(ctx context.Context, req *conductorv1.Health
 
 	// Check components if requested
 	if req.IncludeComponents || len(req.Components) > 0 {
-		components := s.checkComponents(ctx, req.Components)
+		components := s.checkComponents(ctx, s.dependencies(true), req.Components)
 		resp.Components = components
 
 		// Update overall status based on component health
@@ -104,58 +130,150 @@ func (s *HealthServiceServer) CheckLiveness(ctx context.Context, req *conductorv
 
 // CheckReadiness returns whether the service is ready to accept traffic.
 func (s *HealthServiceServer) CheckReadiness(ctx context.Context, req *conductorv1.ReadinessRequest) (*conductorv1.ReadinessResponse, error) {
-	notReady := []string{}
+	resp := s.readiness(ctx, req.CheckAll)
+	if !resp.Ready {
+		setHTTPStatus(ctx, http.StatusServiceUnavailable)
+	}
+	return resp, nil
+}
 
-	// Check database connection
-	if err := s.deps.DB.Health(ctx); err != nil {
-		notReady = append(notReady, "database")
+// readiness checks the critical dependencies, and the others too if all is
+// set. The service is ready unless a critical dependency is unhealthy.
+func (s *HealthServiceServer) readiness(ctx context.Context, all bool) *conductorv1.ReadinessResponse {
+	resp := &conductorv1.ReadinessResponse{
+		Ready:      true,
+		Status:     conductorv1.HealthStatus_HEALTH_STATUS_HEALTHY,
+		Components: s.checkComponents(ctx, s.dependencies(all), nil),
+		Timestamp:  timestamppb.Now(),
 	}
 
-	if len(notReady) > 0 {
-		return &conductorv1.ReadinessResponse{
-			Ready:              false,
-			Reason:             "required components not ready",
-			NotReadyComponents: notReady,
-			Timestamp:          timestamppb.Now(),
-		}, nil
+	for _, comp := range resp.Components {
+		switch {
+		case comp.Critical && comp.Status == conductorv1.HealthStatus_HEALTH_STATUS_UNHEALTHY:
+			resp.NotReadyComponents = append(resp.NotReadyComponents, comp.Name)
+		case comp.Status != conductorv1.HealthStatus_HEALTH_STATUS_HEALTHY:
+			resp.Status = conductorv1.HealthStatus_HEALTH_STATUS_DEGRADED
+		}
 	}
 
-	return &conductorv1.ReadinessResponse{
-		Ready:     true,
-		Timestamp: timestamppb.Now(),
-	}, nil
+	if len(resp.NotReadyComponents) > 0 {
+		resp.Ready = false
+		resp.Status = conductorv1.HealthStatus_HEALTH_STATUS_UNHEALTHY
+		resp.Reason = "required components not ready"
+	}
+	return resp
 }
 
-// checkComponents checks the health of individual components.
-func (s *HealthServiceServer) checkComponents(ctx context.Context, requestedComponents []string) []*conductorv1.ComponentHealth {
-	// Define all components
-	allComponents := map[string]func(context.Context) *conductorv1.ComponentHealth{
-		"database": s.checkDatabase,
-		// TODO: Add more components: redis, artifact_storage, etc.
+// watchReadiness reports readiness through the gRPC health protocol until
+// ctx is done: the server as a whole is not serving while a critical
+// dependency is unhealthy.
+func (s *HealthServiceServer) watchReadiness(ctx context.Context, grpcHealth *health.Server, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ready := s.readiness(ctx, false).Ready
+		if ctx.Err() != nil {
+			return
+		}
+		servingStatus := healthpb.HealthCheckResponse_SERVING
+		if !ready {
+			servingStatus = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		grpcHealth.SetServingStatus("", servingStatus)
 	}
+}
+
+// dependency is a dependency of the control plane whose health is checked.
+type dependency struct {
+	name     string
+	critical bool
+	check    func(context.Context) *conductorv1.ComponentHealth
+}
 
+// dependencies returns the configured dependencies: the critical ones, and
+// the others too if all is set.
+func (s *HealthServiceServer) dependencies(all bool) []dependency {
+	var deps []dependency
+	if s.deps.DB != nil {
+		deps = append(deps, dependency{name: "database", critical: true, check: s.checkDatabase})
+	}
+	if s.deps.ArtifactStorage != nil {
+		deps = append(deps, dependency{name: "artifact_storage", critical: true, check: func(ctx context.Context) *conductorv1.ComponentHealth {
+			return checkDependency(ctx, s.deps.ArtifactStorage)
+		}})
+	}
+	if all && s.deps.GitProvider != nil {
+		deps = append(deps, dependency{name: "git_provider", check: func(ctx context.Context) *conductorv1.ComponentHealth {
+			return checkDependency(ctx, s.deps.GitProvider)
+		}})
+	}
+	return deps
+}
+
+// checkComponents checks the health of dependencies concurrently, only of
+// those named in requestedComponents if any are.
+func (s *HealthServiceServer) checkComponents(ctx context.Context, deps []dependency, requestedComponents []string) []*conductorv1.ComponentHealth {
 	// Filter components if specific ones were requested
-	componentsToCheck := allComponents
 	if len(requestedComponents) > 0 {
-		componentsToCheck = make(map[string]func(context.Context) *conductorv1.ComponentHealth)
-		for _, name := range requestedComponents {
-			if checkFn, ok := allComponents[name]; ok {
-				componentsToCheck[name] = checkFn
+		filtered := deps[:0:0]
+		for _, dep := range deps {
+			if slices.Contains(requestedComponents, dep.name) {
+				filtered = append(filtered, dep)
 			}
 		}
+		deps = filtered
 	}
 
-	results := make([]*conductorv1.ComponentHealth, 0, len(componentsToCheck))
-	for name, checkFn := range componentsToCheck {
-		result := checkFn(ctx)
-		result.Name = name
-		result.LastChecked = timestamppb.Now()
-		results = append(results, result)
+	results := make([]*conductorv1.ComponentHealth, len(deps))
+	var wg sync.WaitGroup
+	for i, dep := range deps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, dependencyCheckTimeout)
+			defer cancel()
+
+			result := dep.check(checkCtx)
+			result.Name = dep.name
+			result.Critical = dep.critical
+			result.LastChecked = timestamppb.Now()
+			results[i] = result
+		}()
 	}
+	wg.Wait()
 
 	return results
 }
 
+// checkDependency checks a dependency that only reports whether it is
+// reachable. It is degraded if it answers slowly.
+func checkDependency(ctx context.Context, checker DependencyChecker) *conductorv1.ComponentHealth {
+	start := time.Now()
+	err := checker.HealthCheck(ctx)
+	latency := time.Since(start)
+
+	component := &conductorv1.ComponentHealth{LatencyMs: latency.Milliseconds()}
+	switch {
+	case err != nil:
+		component.Status = conductorv1.HealthStatus_HEALTH_STATUS_UNHEALTHY
+		component.Message = err.Error()
+	case latency >= slowDependencyLatency:
+		component.Status = conductorv1.HealthStatus_HEALTH_STATUS_DEGRADED
+		component.Message = "responding slowly"
+	default:
+		component.Status = conductorv1.HealthStatus_HEALTH_STATUS_HEALTHY
+		component.Message = "responding normally"
+	}
+	return component
+}
+
 // checkDatabase performs a health check on the database.
 func (s *HealthServiceServer) checkDatabase(ctx context.Context) *conductorv1.ComponentHealth {
 	start := time.Now()
@@ -182,12 +300,23 @@ func (s *HealthServiceServer) checkDatabase(ctx context.Context) *conductorv1.Co
 	component.Details["idle_conns"] = itoa(int(stats.IdleConns))
 	component.Details["acquired_conns"] = itoa(int(stats.AcquiredConns))
 	component.Details["max_conns"] = itoa(int(stats.MaxConns))
+	if stats.Replica {
+		component.Details["replica_lag_ms"] = itoa(int(stats.ReplicaLag.Milliseconds()))
+		component.Details["replica_in_use"] = "false"
+		if stats.ReplicaInUse {
+			component.Details["replica_in_use"] = "true"
+		}
+	}
 
-	// Check if connection pool is saturated
-	if stats.AcquiredConns >= stats.MaxConns-1 {
+	// Check if connection pool is saturated or the database slow
+	switch {
+	case stats.AcquiredConns >= stats.MaxConns-1:
 		component.Status = conductorv1.HealthStatus_HEALTH_STATUS_DEGRADED
 		component.Message = "connection pool near capacity"
-	} else {
+	case latency >= slowDependencyLatency:
+		component.Status = conductorv1.HealthStatus_HEALTH_STATUS_DEGRADED
+		component.Message = "database responding slowly"
+	default:
 		component.Status = conductorv1.HealthStatus_HEALTH_STATUS_HEALTHY
 		component.Message = "database responding normally"
 	}
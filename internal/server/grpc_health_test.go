@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+)
+
+type fakeHealthDB struct {
+	err error
+}
+
+func (db *fakeHealthDB) Health(ctx context.Context) error { return db.err }
+
+func (db *fakeHealthDB) Stats() database.HealthStats {
+	return database.HealthStats{TotalConns: 2, IdleConns: 2, MaxConns: 10}
+}
+
+type fakeDependency struct {
+	err error
+}
+
+func (d *fakeDependency) HealthCheck(ctx context.Context) error { return d.err }
+
+func TestHealthService_CheckReadiness(t *testing.T) {
+	unreachable := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		deps       HealthServiceDeps
+		checkAll   bool
+		ready      bool
+		status     conductorv1.HealthStatus
+		notReady   []string
+		components []string
+	}{
+		{
+			name:       "ready",
+			deps:       HealthServiceDeps{DB: &fakeHealthDB{}, ArtifactStorage: &fakeDependency{}, GitProvider: &fakeDependency{}},
+			ready:      true,
+			status:     conductorv1.HealthStatus_HEALTH_STATUS_HEALTHY,
+			components: []string{"database", "artifact_storage"},
+		},
+		{
+			name:       "critical dependency unhealthy",
+			deps:       HealthServiceDeps{DB: &fakeHealthDB{}, ArtifactStorage: &fakeDependency{err: unreachable}},
+			status:     conductorv1.HealthStatus_HEALTH_STATUS_UNHEALTHY,
+			notReady:   []string{"artifact_storage"},
+			components: []string{"database", "artifact_storage"},
+		},
+		{
+			name:       "non-critical dependency unhealthy",
+			deps:       HealthServiceDeps{DB: &fakeHealthDB{}, ArtifactStorage: &fakeDependency{}, GitProvider: &fakeDependency{err: unreachable}},
+			checkAll:   true,
+			ready:      true,
+			status:     conductorv1.HealthStatus_HEALTH_STATUS_DEGRADED,
+			components: []string{"database", "artifact_storage", "git_provider"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewHealthServiceServer(tt.deps, zerolog.Nop())
+
+			resp, err := s.CheckReadiness(context.Background(), &conductorv1.ReadinessRequest{CheckAll: tt.checkAll})
+			require.NoError(t, err)
+			assert.Equal(t, tt.ready, resp.Ready)
+			assert.Equal(t, tt.status, resp.Status)
+			assert.Equal(t, tt.notReady, resp.NotReadyComponents)
+
+			var names []string
+			for _, comp := range resp.Components {
+				names = append(names, comp.Name)
+				assert.NotNil(t, comp.LastChecked)
+			}
+			assert.Equal(t, tt.components, names)
+		})
+	}
+}
+
+func TestHealthService_CheckComponents(t *testing.T) {
+	s := NewHealthServiceServer(HealthServiceDeps{
+		DB:              &fakeHealthDB{},
+		ArtifactStorage: &fakeDependency{err: errors.New("bucket missing")},
+		GitProvider:     &fakeDependency{},
+	}, zerolog.Nop())
+
+	resp, err := s.Check(context.Background(), &conductorv1.HealthCheckRequest{Components: []string{"artifact_storage", "git_provider"}})
+	require.NoError(t, err)
+	require.Len(t, resp.Components, 2)
+	assert.Equal(t, "artifact_storage", resp.Components[0].Name)
+	assert.True(t, resp.Components[0].Critical)
+	assert.Equal(t, "bucket missing", resp.Components[0].Message)
+	assert.Equal(t, "git_provider", resp.Components[1].Name)
+	assert.False(t, resp.Components[1].Critical)
+	assert.Equal(t, conductorv1.HealthStatus_HEALTH_STATUS_UNHEALTHY, resp.Status)
+}
+
+func TestHealthService_WatchReadiness(t *testing.T) {
+	db := &fakeHealthDB{err: errors.New("connection refused")}
+	s := NewHealthServiceServer(HealthServiceDeps{DB: db}, zerolog.Nop())
+	grpcHealth := health.NewServer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.watchReadiness(ctx, grpcHealth, time.Millisecond)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		resp, err := grpcHealth.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		return err == nil && resp.Status == healthpb.HealthCheckResponse_NOT_SERVING
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestProbeHandler(t *testing.T) {
+	var path, query string
+	gateway := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.RawQuery
+	})
+
+	rec := httptest.NewRecorder()
+	probeHandler(gateway, "/api/v1/health/ready").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz?check_all=true", nil))
+
+	assert.Equal(t, "/api/v1/health/ready", path)
+	assert.Equal(t, "check_all=true", query)
+}
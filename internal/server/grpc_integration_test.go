@@ -1113,7 +1113,7 @@ func (m *grpcMockRunRepository) UpdateStatus(ctx context.Context, id uuid.UUID,
 // grpcMockWorkScheduler implements WorkScheduler for testing.
 type grpcMockWorkScheduler struct{}
 
-func (m *grpcMockWorkScheduler) AssignWork(ctx context.Context, agentID uuid.UUID, capabilities *conductorv1.Capabilities) (*conductorv1.AssignWork, error) {
+func (m *grpcMockWorkScheduler) AssignWork(ctx context.Context, agentID uuid.UUID, capabilities *conductorv1.Capabilities, labels map[string]string, pool string) (*conductorv1.AssignWork, error) {
 	return nil, nil
 }
 
@@ -3,17 +3,18 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
 	"github.com/conductor/conductor/internal/database"
 	"github.com/conductor/conductor/internal/notification"
+	"github.com/conductor/conductor/pkg/errcode"
 )
 
 // NotificationServiceDeps defines the dependencies for the notification service.
@@ -22,6 +23,12 @@ type NotificationServiceDeps struct {
 	Repo database.NotificationRepository
 	// NotificationService handles sending notifications.
 	NotificationService notification.NotificationService
+	// TestRepo resolves test definitions of definition rules (optional).
+	TestRepo database.TestDefinitionRepository
+	// RunRepo loads runs to explain notifications for (optional).
+	RunRepo RunRepository
+	// ResultRepo loads the results of explained runs (optional).
+	ResultRepo ResultRepository
 }
 
 // NotificationServiceServer implements the NotificationService gRPC service.
@@ -43,16 +50,20 @@ func NewNotificationServiceServer(deps NotificationServiceDeps, logger zerolog.L
 // CreateChannel creates a new notification channel.
 func (s *NotificationServiceServer) CreateChannel(ctx context.Context, req *conductorv1.CreateChannelRequest) (*conductorv1.CreateChannelResponse, error) {
 	if req.Name == "" {
-		return nil, status.Error(codes.InvalidArgument, "name is required")
+		return nil, errcode.New(errcode.InvalidArgument, "name is required")
 	}
 	if req.Type == conductorv1.ChannelType_CHANNEL_TYPE_UNSPECIFIED {
-		return nil, status.Error(codes.InvalidArgument, "type is required")
+		return nil, errcode.New(errcode.InvalidArgument, "type is required")
 	}
 
 	// Validate and convert config
 	config, err := channelConfigToJSON(req.Type, req.Config)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid config: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid config: %v", err)
+	}
+	projectID, err := projectForCreate(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
 	}
 
 	channel := &database.NotificationChannel{
@@ -62,11 +73,15 @@ func (s *NotificationServiceServer) CreateChannel(ctx context.Context, req *cond
 		Enabled:   req.Enabled,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
+		ProjectID: projectID,
 	}
 
 	if err := s.deps.Repo.CreateChannel(ctx, channel); err != nil {
+		if database.IsForeignKeyViolation(err) {
+			return nil, errcode.New(errcode.NotFound, "project not found: %s", projectID)
+		}
 		s.logger.Error().Err(err).Str("name", req.Name).Msg("failed to create channel")
-		return nil, status.Errorf(codes.Internal, "failed to create channel: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to create channel: %v", err)
 	}
 
 	s.logger.Info().
@@ -84,15 +99,15 @@ func (s *NotificationServiceServer) CreateChannel(ctx context.Context, req *cond
 func (s *NotificationServiceServer) GetChannel(ctx context.Context, req *conductorv1.GetChannelRequest) (*conductorv1.GetChannelResponse, error) {
 	channelID, err := uuid.Parse(req.ChannelId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid channel ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid channel ID: %v", err)
 	}
 
 	channel, err := s.deps.Repo.GetChannel(ctx, channelID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "channel not found: %s", req.ChannelId)
+			return nil, errcode.New(errcode.ChannelNotFound, "channel not found: %s", req.ChannelId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get channel: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get channel: %v", err)
 	}
 
 	return &conductorv1.GetChannelResponse{
@@ -106,7 +121,7 @@ func (s *NotificationServiceServer) ListChannels(ctx context.Context, req *condu
 
 	channels, err := s.deps.Repo.ListChannels(ctx, pagination)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to list channels: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to list channels: %v", err)
 	}
 
 	// Filter by type if specified
@@ -148,15 +163,15 @@ func (s *NotificationServiceServer) ListChannels(ctx context.Context, req *condu
 func (s *NotificationServiceServer) UpdateChannel(ctx context.Context, req *conductorv1.UpdateChannelRequest) (*conductorv1.UpdateChannelResponse, error) {
 	channelID, err := uuid.Parse(req.ChannelId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid channel ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid channel ID: %v", err)
 	}
 
 	channel, err := s.deps.Repo.GetChannel(ctx, channelID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "channel not found: %s", req.ChannelId)
+			return nil, errcode.New(errcode.ChannelNotFound, "channel not found: %s", req.ChannelId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get channel: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get channel: %v", err)
 	}
 
 	// Apply updates
@@ -166,7 +181,7 @@ func (s *NotificationServiceServer) UpdateChannel(ctx context.Context, req *cond
 	if req.Config != nil {
 		config, err := channelConfigToJSON(channelTypeToProto(channel.Type), req.Config)
 		if err != nil {
-			return nil, status.Errorf(codes.InvalidArgument, "invalid config: %v", err)
+			return nil, errcode.New(errcode.InvalidArgument, "invalid config: %v", err)
 		}
 		channel.Config = config
 	}
@@ -177,7 +192,7 @@ func (s *NotificationServiceServer) UpdateChannel(ctx context.Context, req *cond
 	channel.UpdatedAt = time.Now()
 
 	if err := s.deps.Repo.UpdateChannel(ctx, channel); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to update channel: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to update channel: %v", err)
 	}
 
 	// Refresh channel in notification service
@@ -202,14 +217,14 @@ func (s *NotificationServiceServer) UpdateChannel(ctx context.Context, req *cond
 func (s *NotificationServiceServer) DeleteChannel(ctx context.Context, req *conductorv1.DeleteChannelRequest) (*conductorv1.DeleteChannelResponse, error) {
 	channelID, err := uuid.Parse(req.ChannelId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid channel ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid channel ID: %v", err)
 	}
 
 	if err := s.deps.Repo.DeleteChannel(ctx, channelID); err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "channel not found: %s", req.ChannelId)
+			return nil, errcode.New(errcode.ChannelNotFound, "channel not found: %s", req.ChannelId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to delete channel: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to delete channel: %v", err)
 	}
 
 	// Remove channel from notification service
@@ -232,16 +247,16 @@ func (s *NotificationServiceServer) DeleteChannel(ctx context.Context, req *cond
 func (s *NotificationServiceServer) TestChannel(ctx context.Context, req *conductorv1.TestChannelRequest) (*conductorv1.TestChannelResponse, error) {
 	channelID, err := uuid.Parse(req.ChannelId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid channel ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid channel ID: %v", err)
 	}
 
 	if s.deps.NotificationService == nil {
-		return nil, status.Error(codes.Unavailable, "notification service not available")
+		return nil, errcode.New(errcode.Unavailable, "notification service not available")
 	}
 
 	result, err := s.deps.NotificationService.TestChannel(ctx, channelID, req.Message)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to test channel: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to test channel: %v", err)
 	}
 
 	return &conductorv1.TestChannelResponse{
@@ -254,37 +269,40 @@ func (s *NotificationServiceServer) TestChannel(ctx context.Context, req *conduc
 // CreateRule creates a new notification rule.
 func (s *NotificationServiceServer) CreateRule(ctx context.Context, req *conductorv1.CreateRuleRequest) (*conductorv1.CreateRuleResponse, error) {
 	if req.Name == "" {
-		return nil, status.Error(codes.InvalidArgument, "name is required")
+		return nil, errcode.New(errcode.InvalidArgument, "name is required")
 	}
 	if len(req.ChannelIds) == 0 {
-		return nil, status.Error(codes.InvalidArgument, "at least one channel ID is required")
+		return nil, errcode.New(errcode.InvalidArgument, "at least one channel ID is required")
 	}
 	if len(req.Events) == 0 {
-		return nil, status.Error(codes.InvalidArgument, "at least one event is required")
+		return nil, errcode.New(errcode.InvalidArgument, "at least one event is required")
 	}
 
 	// Verify channel exists
 	channelID, err := uuid.Parse(req.ChannelIds[0])
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid channel ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid channel ID: %v", err)
 	}
 
 	_, err = s.deps.Repo.GetChannel(ctx, channelID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "channel not found: %s", req.ChannelIds[0])
+			return nil, errcode.New(errcode.ChannelNotFound, "channel not found: %s", req.ChannelIds[0])
 		}
-		return nil, status.Errorf(codes.Internal, "failed to verify channel: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to verify channel: %v", err)
 	}
 
-	// Parse service ID filter if provided
-	var serviceID *uuid.UUID
-	if req.Filter != nil && len(req.Filter.ServiceIds) > 0 {
-		id, err := uuid.Parse(req.Filter.ServiceIds[0])
-		if err != nil {
-			return nil, status.Errorf(codes.InvalidArgument, "invalid service ID: %v", err)
-		}
-		serviceID = &id
+	serviceID, testDefID, err := s.ruleTarget(ctx, req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	owner := ruleOwner(req.Filter)
+	if owner != nil && testDefID != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "a rule cannot be limited to both a test definition and an owner")
+	}
+	titleTemplate, messageTemplate, err := ruleTemplates(req.Template)
+	if err != nil {
+		return nil, err
 	}
 
 	// Convert events to trigger events
@@ -294,17 +312,21 @@ func (s *NotificationServiceServer) CreateRule(ctx context.Context, req *conduct
 	}
 
 	rule := &database.NotificationRule{
-		ChannelID: channelID,
-		ServiceID: serviceID,
-		TriggerOn: triggerOn,
-		Enabled:   req.Enabled,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ChannelID:        channelID,
+		ServiceID:        serviceID,
+		TestDefinitionID: testDefID,
+		Owner:            owner,
+		TriggerOn:        triggerOn,
+		Enabled:          req.Enabled,
+		TitleTemplate:    titleTemplate,
+		MessageTemplate:  messageTemplate,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
 	}
 
 	if err := s.deps.Repo.CreateRule(ctx, rule); err != nil {
 		s.logger.Error().Err(err).Str("name", req.Name).Msg("failed to create rule")
-		return nil, status.Errorf(codes.Internal, "failed to create rule: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to create rule: %v", err)
 	}
 
 	s.logger.Info().
@@ -321,15 +343,15 @@ func (s *NotificationServiceServer) CreateRule(ctx context.Context, req *conduct
 func (s *NotificationServiceServer) GetRule(ctx context.Context, req *conductorv1.GetRuleRequest) (*conductorv1.GetRuleResponse, error) {
 	ruleID, err := uuid.Parse(req.RuleId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid rule ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid rule ID: %v", err)
 	}
 
 	rule, err := s.deps.Repo.GetRule(ctx, ruleID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "rule not found: %s", req.RuleId)
+			return nil, errcode.New(errcode.RuleNotFound, "rule not found: %s", req.RuleId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get rule: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get rule: %v", err)
 	}
 
 	return &conductorv1.GetRuleResponse{
@@ -345,7 +367,7 @@ func (s *NotificationServiceServer) ListRules(ctx context.Context, req *conducto
 	if req.ServiceId != "" {
 		serviceID, parseErr := uuid.Parse(req.ServiceId)
 		if parseErr != nil {
-			return nil, status.Errorf(codes.InvalidArgument, "invalid service ID: %v", parseErr)
+			return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", parseErr)
 		}
 		rules, err = s.deps.Repo.ListRulesByService(ctx, serviceID)
 	} else {
@@ -355,7 +377,7 @@ func (s *NotificationServiceServer) ListRules(ctx context.Context, req *conducto
 		pagination.Limit = 100
 		channels, chErr := s.deps.Repo.ListChannels(ctx, pagination)
 		if chErr != nil {
-			return nil, status.Errorf(codes.Internal, "failed to list channels: %v", chErr)
+			return nil, errcode.New(errcode.Internal, "failed to list channels: %v", chErr)
 		}
 
 		ruleSet := make(map[uuid.UUID]database.NotificationRule)
@@ -375,7 +397,7 @@ func (s *NotificationServiceServer) ListRules(ctx context.Context, req *conducto
 	}
 
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to list rules: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to list rules: %v", err)
 	}
 
 	// Filter by enabled status if specified
@@ -404,22 +426,22 @@ func (s *NotificationServiceServer) ListRules(ctx context.Context, req *conducto
 func (s *NotificationServiceServer) UpdateRule(ctx context.Context, req *conductorv1.UpdateRuleRequest) (*conductorv1.UpdateRuleResponse, error) {
 	ruleID, err := uuid.Parse(req.RuleId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid rule ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid rule ID: %v", err)
 	}
 
 	rule, err := s.deps.Repo.GetRule(ctx, ruleID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "rule not found: %s", req.RuleId)
+			return nil, errcode.New(errcode.RuleNotFound, "rule not found: %s", req.RuleId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get rule: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get rule: %v", err)
 	}
 
 	// Apply updates
 	if len(req.ChannelIds) > 0 {
 		channelID, err := uuid.Parse(req.ChannelIds[0])
 		if err != nil {
-			return nil, status.Errorf(codes.InvalidArgument, "invalid channel ID: %v", err)
+			return nil, errcode.New(errcode.InvalidArgument, "invalid channel ID: %v", err)
 		}
 		rule.ChannelID = channelID
 	}
@@ -432,22 +454,36 @@ func (s *NotificationServiceServer) UpdateRule(ctx context.Context, req *conduct
 		rule.TriggerOn = triggerOn
 	}
 
-	if req.Filter != nil && len(req.Filter.ServiceIds) > 0 {
-		serviceID, err := uuid.Parse(req.Filter.ServiceIds[0])
+	if req.Filter != nil && (len(req.Filter.ServiceIds) > 0 || len(req.Filter.TestDefinitionIds) > 0) {
+		serviceID, testDefID, err := s.ruleTarget(ctx, req.Filter)
 		if err != nil {
-			return nil, status.Errorf(codes.InvalidArgument, "invalid service ID: %v", err)
+			return nil, err
 		}
-		rule.ServiceID = &serviceID
+		rule.ServiceID = serviceID
+		rule.TestDefinitionID = testDefID
+	}
+	if req.Filter != nil && len(req.Filter.Owners) > 0 {
+		rule.Owner = ruleOwner(req.Filter)
+	}
+	if rule.Owner != nil && rule.TestDefinitionID != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "a rule cannot be limited to both a test definition and an owner")
 	}
 
 	if req.Enabled != nil {
 		rule.Enabled = *req.Enabled
 	}
 
+	if req.Template != nil {
+		rule.TitleTemplate, rule.MessageTemplate, err = ruleTemplates(req.Template)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	rule.UpdatedAt = time.Now()
 
 	if err := s.deps.Repo.UpdateRule(ctx, rule); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to update rule: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to update rule: %v", err)
 	}
 
 	s.logger.Info().
@@ -468,14 +504,14 @@ func (s *NotificationServiceServer) UpdateRule(ctx context.Context, req *conduct
 func (s *NotificationServiceServer) DeleteRule(ctx context.Context, req *conductorv1.DeleteRuleRequest) (*conductorv1.DeleteRuleResponse, error) {
 	ruleID, err := uuid.Parse(req.RuleId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid rule ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid rule ID: %v", err)
 	}
 
 	if err := s.deps.Repo.DeleteRule(ctx, ruleID); err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "rule not found: %s", req.RuleId)
+			return nil, errcode.New(errcode.RuleNotFound, "rule not found: %s", req.RuleId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to delete rule: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to delete rule: %v", err)
 	}
 
 	s.logger.Info().
@@ -487,23 +523,309 @@ func (s *NotificationServiceServer) DeleteRule(ctx context.Context, req *conduct
 	}, nil
 }
 
-// ListNotificationHistory returns recent notifications sent.
+// ListNotificationHistory returns the delivery log of notifications sent to
+// channels, newest first.
 func (s *NotificationServiceServer) ListNotificationHistory(ctx context.Context, req *conductorv1.ListNotificationHistoryRequest) (*conductorv1.ListNotificationHistoryResponse, error) {
-	// TODO: Implement notification history tracking
+	var filter database.NotificationDeliveryFilter
+	for _, f := range []struct {
+		name  string
+		value string
+		dst   **uuid.UUID
+	}{
+		{"service", req.ServiceId, &filter.ServiceID},
+		{"run", req.RunId, &filter.RunID},
+		{"channel", req.ChannelId, &filter.ChannelID},
+		{"rule", req.RuleId, &filter.RuleID},
+	} {
+		if f.value == "" {
+			continue
+		}
+		id, err := uuid.Parse(f.value)
+		if err != nil {
+			return nil, errcode.New(errcode.InvalidArgument, "invalid %s ID: %v", f.name, err)
+		}
+		*f.dst = &id
+	}
+
+	if req.Success != nil {
+		status := database.DeliveryStatusFailed
+		if *req.Success {
+			status = database.DeliveryStatusSent
+		}
+		filter.Status = &status
+	}
+	if req.EventType != "" {
+		filter.EventType = &req.EventType
+	}
+	if req.TimeRange != nil {
+		if req.TimeRange.Start != nil {
+			t := req.TimeRange.Start.AsTime()
+			filter.Since = &t
+		}
+		if req.TimeRange.End != nil {
+			t := req.TimeRange.End.AsTime()
+			filter.Until = &t
+		}
+	}
+
+	pagination := paginationFromProto(req.Pagination)
+	deliveries, total, err := s.deps.Repo.ListDeliveries(ctx, filter, pagination)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list notification history: %v", err)
+	}
+
+	records := make([]*conductorv1.NotificationRecord, len(deliveries))
+	for i := range deliveries {
+		records[i] = deliveryToProto(&deliveries[i])
+	}
+
 	return &conductorv1.ListNotificationHistoryResponse{
-		Records:    []*conductorv1.NotificationRecord{},
-		Pagination: paginationResponseToProto(database.DefaultPagination(), 0),
+		Records:    records,
+		Pagination: paginationResponseToProto(pagination, total),
+	}, nil
+}
+
+// RedeliverNotification sends the notification of a failed delivery to its
+// channel again.
+func (s *NotificationServiceServer) RedeliverNotification(ctx context.Context, req *conductorv1.RedeliverNotificationRequest) (*conductorv1.RedeliverNotificationResponse, error) {
+	deliveryID, err := uuid.Parse(req.DeliveryId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid delivery ID: %v", err)
+	}
+
+	if s.deps.NotificationService == nil {
+		return nil, errcode.New(errcode.Unavailable, "notification service not available")
+	}
+
+	delivery, err := s.deps.NotificationService.Redeliver(ctx, deliveryID)
+	if err != nil {
+		switch {
+		case database.IsNotFound(err):
+			return nil, errcode.New(errcode.DeliveryNotFound, "delivery not found: %s", req.DeliveryId)
+		case errors.Is(err, notification.ErrNotRedeliverable):
+			return nil, errcode.New(errcode.FailedPrecondition, "%v", err)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to redeliver notification: %v", err)
+	}
+
+	s.logger.Info().
+		Str("delivery_id", delivery.ID.String()).
+		Str("redelivery_of", deliveryID.String()).
+		Str("status", string(delivery.Status)).
+		Msg("notification redelivered")
+
+	return &conductorv1.RedeliverNotificationResponse{
+		Record: deliveryToProto(delivery),
 	}, nil
 }
 
+// ExplainNotification explains which rules notify for a run and why.
+func (s *NotificationServiceServer) ExplainNotification(ctx context.Context, req *conductorv1.ExplainNotificationRequest) (*conductorv1.ExplainNotificationResponse, error) {
+	if s.deps.NotificationService == nil || s.deps.RunRepo == nil {
+		return nil, errcode.New(errcode.NotConfigured, "notification explanations are not configured")
+	}
+
+	runID, err := uuid.Parse(req.RunId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid run ID: %v", err)
+	}
+
+	run, err := s.deps.RunRepo.GetByID(ctx, runID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.RunNotFound, "run not found: %s", req.RunId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get run: %v", err)
+	}
+
+	event := &notification.Event{
+		Type:      notificationTypeForRun(run, req.Event),
+		ServiceID: run.ServiceID,
+		RunID:     &run.ID,
+		Run:       run,
+		Timestamp: time.Now(),
+	}
+
+	if s.deps.ResultRepo != nil {
+		results, err := s.deps.ResultRepo.GetByRunID(ctx, run.ID)
+		if err != nil {
+			return nil, errcode.New(errcode.Internal, "failed to get results: %v", err)
+		}
+		event.TestDefinitionIDs = eventTestDefinitions(event.Type, results)
+	}
+	if s.deps.TestRepo != nil {
+		owners, err := s.testOwners(ctx, event.TestDefinitionIDs)
+		if err != nil {
+			return nil, err
+		}
+		event.TestOwners = owners
+	}
+
+	decisions, err := s.deps.NotificationService.Explain(ctx, event)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to explain notification: %v", err)
+	}
+
+	resp := &conductorv1.ExplainNotificationResponse{
+		EventType:         string(event.Type),
+		ServiceId:         run.ServiceID.String(),
+		TestDefinitionIds: make([]string, len(event.TestDefinitionIDs)),
+		Decisions:         make([]*conductorv1.RuleDecision, len(decisions)),
+	}
+	for i, id := range event.TestDefinitionIDs {
+		resp.TestDefinitionIds[i] = id.String()
+	}
+	for i, d := range decisions {
+		resp.Decisions[i] = &conductorv1.RuleDecision{
+			RuleId:    d.Rule.ID.String(),
+			ChannelId: d.Rule.ChannelID.String(),
+			Scope:     d.Scope,
+			Matched:   d.Matched,
+			Reason:    d.Reason,
+		}
+	}
+	return resp, nil
+}
+
+// ruleTarget resolves the service and test definition a rule is limited to.
+// A test definition implies its service.
+func (s *NotificationServiceServer) ruleTarget(ctx context.Context, filter *conductorv1.NotificationFilter) (*uuid.UUID, *uuid.UUID, error) {
+	if filter == nil {
+		return nil, nil, nil
+	}
+
+	var serviceID *uuid.UUID
+	if len(filter.ServiceIds) > 0 {
+		id, err := uuid.Parse(filter.ServiceIds[0])
+		if err != nil {
+			return nil, nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+		}
+		serviceID = &id
+	}
+
+	if len(filter.TestDefinitionIds) == 0 {
+		return serviceID, nil, nil
+	}
+	if s.deps.TestRepo == nil {
+		return nil, nil, errcode.New(errcode.NotConfigured, "test definition rules are not configured")
+	}
+
+	testDefID, err := uuid.Parse(filter.TestDefinitionIds[0])
+	if err != nil {
+		return nil, nil, errcode.New(errcode.InvalidArgument, "invalid test definition ID: %v", err)
+	}
+	def, err := s.deps.TestRepo.Get(ctx, testDefID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, nil, errcode.New(errcode.TestNotFound, "test definition not found: %s", testDefID)
+		}
+		return nil, nil, errcode.New(errcode.Internal, "failed to get test definition: %v", err)
+	}
+	if serviceID != nil && *serviceID != def.ServiceID {
+		return nil, nil, errcode.New(errcode.InvalidArgument, "test definition %s does not belong to service %s", testDefID, *serviceID)
+	}
+	return &def.ServiceID, &testDefID, nil
+}
+
+// testOwners returns the owners of test definitions. Definitions without an
+// owner, or since deleted, are left out.
+func (s *NotificationServiceServer) testOwners(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	owners := make(map[uuid.UUID]string)
+	for _, id := range ids {
+		def, err := s.deps.TestRepo.Get(ctx, id)
+		if err != nil {
+			if database.IsNotFound(err) {
+				continue
+			}
+			return nil, errcode.New(errcode.Internal, "failed to get test definition: %v", err)
+		}
+		if def.Owner != nil {
+			owners[id] = *def.Owner
+		}
+	}
+	return owners, nil
+}
+
+// ruleTemplates validates the templates of a rule. Empty templates are
+// returned as nil.
+func ruleTemplates(tmpl *conductorv1.NotificationTemplate) (*string, *string, error) {
+	if tmpl == nil {
+		return nil, nil, nil
+	}
+	if tmpl.Title != "" {
+		if err := notification.ValidateRuleTemplate("title", tmpl.Title); err != nil {
+			return nil, nil, errcode.New(errcode.InvalidArgument, "invalid title template: %v", err)
+		}
+	}
+	if tmpl.Message != "" {
+		if err := notification.ValidateRuleTemplate("message", tmpl.Message); err != nil {
+			return nil, nil, errcode.New(errcode.InvalidArgument, "invalid message template: %v", err)
+		}
+	}
+	return database.NullString(tmpl.Title), database.NullString(tmpl.Message), nil
+}
+
+// ruleOwner returns the owner a rule is limited to, if any.
+func ruleOwner(filter *conductorv1.NotificationFilter) *string {
+	if filter == nil || len(filter.Owners) == 0 {
+		return nil
+	}
+	return database.NullString(strings.TrimSpace(filter.Owners[0]))
+}
+
 // Helper functions
 
+// notificationTypeForRun returns the notification type to explain for a run,
+// defaulting to the type of the run's status.
+func notificationTypeForRun(run *database.TestRun, event conductorv1.NotificationEvent) notification.NotificationType {
+	switch event {
+	case conductorv1.NotificationEvent_NOTIFICATION_EVENT_RUN_FAILED:
+		return notification.NotificationTypeRunFailed
+	case conductorv1.NotificationEvent_NOTIFICATION_EVENT_RUN_TIMEOUT:
+		return notification.NotificationTypeRunTimeout
+	case conductorv1.NotificationEvent_NOTIFICATION_EVENT_RUN_ERROR:
+		return notification.NotificationTypeRunError
+	case conductorv1.NotificationEvent_NOTIFICATION_EVENT_RUN_PASSED:
+		return notification.NotificationTypeRunRecovered
+	case conductorv1.NotificationEvent_NOTIFICATION_EVENT_FLAKY_TEST:
+		return notification.NotificationTypeFlakyDetected
+	case conductorv1.NotificationEvent_NOTIFICATION_EVENT_DURATION_ANOMALY:
+		return notification.NotificationTypeDurationAnomaly
+	case conductorv1.NotificationEvent_NOTIFICATION_EVENT_DURATION_REGRESSION:
+		return notification.NotificationTypeDurationRegression
+	default:
+		return notification.DetermineNotificationType(run, nil)
+	}
+}
+
+// eventTestDefinitions returns the test definitions a run event concerns:
+// those of the failed results for failure events, otherwise all of them.
+func eventTestDefinitions(eventType notification.NotificationType, results []*database.TestResult) []uuid.UUID {
+	failuresOnly := eventType == notification.NotificationTypeRunFailed ||
+		eventType == notification.NotificationTypeRunError ||
+		eventType == notification.NotificationTypeRunTimeout
+
+	var ids []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, result := range results {
+		if result.TestDefinitionID == nil || seen[*result.TestDefinitionID] {
+			continue
+		}
+		if failuresOnly && result.Status != database.ResultStatusFail && result.Status != database.ResultStatusError {
+			continue
+		}
+		seen[*result.TestDefinitionID] = true
+		ids = append(ids, *result.TestDefinitionID)
+	}
+	return ids
+}
+
 func channelToProto(channel *database.NotificationChannel) *conductorv1.NotificationChannel {
 	if channel == nil {
 		return nil
 	}
 
-	return &conductorv1.NotificationChannel{
+	protoChannel := &conductorv1.NotificationChannel{
 		Id:        channel.ID.String(),
 		Name:      channel.Name,
 		Type:      channelTypeToProto(channel.Type),
@@ -512,6 +834,72 @@ func channelToProto(channel *database.NotificationChannel) *conductorv1.Notifica
 		CreatedAt: timestamppb.New(channel.CreatedAt),
 		UpdatedAt: timestamppb.New(channel.UpdatedAt),
 	}
+	if channel.ProjectID != uuid.Nil {
+		protoChannel.ProjectId = channel.ProjectID.String()
+	}
+	return protoChannel
+}
+
+func deliveryToProto(d *database.NotificationDelivery) *conductorv1.NotificationRecord {
+	record := &conductorv1.NotificationRecord{
+		Id:              d.ID.String(),
+		Event:           notificationTypeToEvent(notification.NotificationType(d.EventType)),
+		EventType:       d.EventType,
+		Success:         d.Status == database.DeliveryStatusSent,
+		ErrorMessage:    d.Error,
+		SentAt:          timestamppb.New(d.SentAt),
+		LatencyMs:       d.LatencyMs,
+		ChannelType:     channelTypeToProto(d.ChannelType),
+		RuleIds:         make([]string, len(d.RuleIDs)),
+		Attempts:        int32(d.Attempts),
+		ResponseExcerpt: d.ResponseExcerpt,
+		PayloadHash:     d.PayloadHash,
+	}
+	if d.ChannelID != nil {
+		record.ChannelId = d.ChannelID.String()
+	}
+	if d.ServiceID != nil {
+		record.ServiceId = d.ServiceID.String()
+	}
+	if d.RunID != nil {
+		record.RunId = d.RunID.String()
+	}
+	if d.RedeliveryOf != nil {
+		record.RedeliveryOf = d.RedeliveryOf.String()
+	}
+	for i, id := range d.RuleIDs {
+		record.RuleIds[i] = id.String()
+	}
+	if len(record.RuleIds) > 0 {
+		record.RuleId = record.RuleIds[0]
+	}
+	return record
+}
+
+// notificationTypeToEvent maps a notification type to the closest event.
+func notificationTypeToEvent(t notification.NotificationType) conductorv1.NotificationEvent {
+	switch t {
+	case notification.NotificationTypeRunFailed:
+		return conductorv1.NotificationEvent_NOTIFICATION_EVENT_RUN_FAILED
+	case notification.NotificationTypeRunPassed, notification.NotificationTypeRunRecovered:
+		return conductorv1.NotificationEvent_NOTIFICATION_EVENT_RUN_PASSED
+	case notification.NotificationTypeRunTimeout:
+		return conductorv1.NotificationEvent_NOTIFICATION_EVENT_RUN_TIMEOUT
+	case notification.NotificationTypeRunError:
+		return conductorv1.NotificationEvent_NOTIFICATION_EVENT_RUN_ERROR
+	case notification.NotificationTypeAgentOffline:
+		return conductorv1.NotificationEvent_NOTIFICATION_EVENT_AGENT_OFFLINE
+	case notification.NotificationTypeAgentOnline:
+		return conductorv1.NotificationEvent_NOTIFICATION_EVENT_AGENT_ONLINE
+	case notification.NotificationTypeFlakyDetected:
+		return conductorv1.NotificationEvent_NOTIFICATION_EVENT_FLAKY_TEST
+	case notification.NotificationTypeDurationAnomaly:
+		return conductorv1.NotificationEvent_NOTIFICATION_EVENT_DURATION_ANOMALY
+	case notification.NotificationTypeDurationRegression:
+		return conductorv1.NotificationEvent_NOTIFICATION_EVENT_DURATION_REGRESSION
+	default:
+		return conductorv1.NotificationEvent_NOTIFICATION_EVENT_UNSPECIFIED
+	}
 }
 
 func channelTypeFromProto(t conductorv1.ChannelType) database.ChannelType {
@@ -524,6 +912,10 @@ func channelTypeFromProto(t conductorv1.ChannelType) database.ChannelType {
 		return database.ChannelTypeWebhook
 	case conductorv1.ChannelType_CHANNEL_TYPE_TEAMS:
 		return database.ChannelTypeTeams
+	case conductorv1.ChannelType_CHANNEL_TYPE_DISCORD:
+		return database.ChannelTypeDiscord
+	case conductorv1.ChannelType_CHANNEL_TYPE_MATTERMOST:
+		return database.ChannelTypeMattermost
 	default:
 		return database.ChannelTypeWebhook
 	}
@@ -539,11 +931,35 @@ func channelTypeToProto(t database.ChannelType) conductorv1.ChannelType {
 		return conductorv1.ChannelType_CHANNEL_TYPE_WEBHOOK
 	case database.ChannelTypeTeams:
 		return conductorv1.ChannelType_CHANNEL_TYPE_TEAMS
+	case database.ChannelTypeDiscord:
+		return conductorv1.ChannelType_CHANNEL_TYPE_DISCORD
+	case database.ChannelTypeMattermost:
+		return conductorv1.ChannelType_CHANNEL_TYPE_MATTERMOST
 	default:
 		return conductorv1.ChannelType_CHANNEL_TYPE_UNSPECIFIED
 	}
 }
 
+func slackMessageFormatFromProto(f conductorv1.SlackMessageFormat) database.SlackMessageFormat {
+	switch f {
+	case conductorv1.SlackMessageFormat_SLACK_MESSAGE_FORMAT_ATTACHMENTS:
+		return database.SlackMessageFormatAttachments
+	case conductorv1.SlackMessageFormat_SLACK_MESSAGE_FORMAT_BLOCKS:
+		return database.SlackMessageFormatBlocks
+	default:
+		return ""
+	}
+}
+
+func slackMessageFormatToProto(f database.SlackMessageFormat) conductorv1.SlackMessageFormat {
+	switch f {
+	case database.SlackMessageFormatBlocks:
+		return conductorv1.SlackMessageFormat_SLACK_MESSAGE_FORMAT_BLOCKS
+	default:
+		return conductorv1.SlackMessageFormat_SLACK_MESSAGE_FORMAT_ATTACHMENTS
+	}
+}
+
 func channelConfigToJSON(channelType conductorv1.ChannelType, config *conductorv1.ChannelConfig) (json.RawMessage, error) {
 	if config == nil {
 		return json.RawMessage("{}"), nil
@@ -553,12 +969,18 @@ func channelConfigToJSON(channelType conductorv1.ChannelType, config *conductorv
 	switch channelType {
 	case conductorv1.ChannelType_CHANNEL_TYPE_SLACK:
 		if config.Slack != nil {
+			if (config.Slack.ThreadReplies || config.Slack.UpdateInPlace) && (config.Slack.Token == "" || config.Slack.Channel == "") {
+				return nil, errors.New("slack thread replies and updates in place require a bot token and channel")
+			}
 			data = database.SlackChannelConfig{
-				WebhookURL: config.Slack.WebhookUrl,
-				Channel:    config.Slack.Channel,
-				Username:   config.Slack.Username,
-				IconEmoji:  config.Slack.Icon,
-				Token:      config.Slack.Token,
+				WebhookURL:    config.Slack.WebhookUrl,
+				Channel:       config.Slack.Channel,
+				Username:      config.Slack.Username,
+				IconEmoji:     config.Slack.Icon,
+				Token:         config.Slack.Token,
+				MessageFormat: slackMessageFormatFromProto(config.Slack.MessageFormat),
+				ThreadReplies: config.Slack.ThreadReplies,
+				UpdateInPlace: config.Slack.UpdateInPlace,
 			}
 		}
 	case conductorv1.ChannelType_CHANNEL_TYPE_EMAIL:
@@ -581,6 +1003,23 @@ func channelConfigToJSON(channelType conductorv1.ChannelType, config *conductorv
 				"webhook_url": config.Teams.WebhookUrl,
 			}
 		}
+	case conductorv1.ChannelType_CHANNEL_TYPE_DISCORD:
+		if config.Discord != nil {
+			data = database.DiscordChannelConfig{
+				WebhookURL: config.Discord.WebhookUrl,
+				Username:   config.Discord.Username,
+				AvatarURL:  config.Discord.AvatarUrl,
+			}
+		}
+	case conductorv1.ChannelType_CHANNEL_TYPE_MATTERMOST:
+		if config.Mattermost != nil {
+			data = database.MattermostChannelConfig{
+				WebhookURL: config.Mattermost.WebhookUrl,
+				Channel:    config.Mattermost.Channel,
+				Username:   config.Mattermost.Username,
+				IconURL:    config.Mattermost.IconUrl,
+			}
+		}
 	}
 
 	if data == nil {
@@ -598,11 +1037,14 @@ func channelConfigFromJSON(channelType database.ChannelType, raw json.RawMessage
 		var cfg database.SlackChannelConfig
 		if err := json.Unmarshal(raw, &cfg); err == nil {
 			config.Slack = &conductorv1.SlackConfig{
-				WebhookUrl: cfg.WebhookURL,
-				Channel:    cfg.Channel,
-				Username:   cfg.Username,
-				Icon:       cfg.IconEmoji,
-				Token:      cfg.Token,
+				WebhookUrl:    cfg.WebhookURL,
+				Channel:       cfg.Channel,
+				Username:      cfg.Username,
+				Icon:          cfg.IconEmoji,
+				Token:         cfg.Token,
+				MessageFormat: slackMessageFormatToProto(cfg.MessageFormat),
+				ThreadReplies: cfg.ThreadReplies,
+				UpdateInPlace: cfg.UpdateInPlace,
 			}
 		}
 	case database.ChannelTypeEmail:
@@ -628,6 +1070,25 @@ func channelConfigFromJSON(channelType database.ChannelType, raw json.RawMessage
 				WebhookUrl: cfg["webhook_url"],
 			}
 		}
+	case database.ChannelTypeDiscord:
+		var cfg database.DiscordChannelConfig
+		if err := json.Unmarshal(raw, &cfg); err == nil {
+			config.Discord = &conductorv1.DiscordConfig{
+				WebhookUrl: cfg.WebhookURL,
+				Username:   cfg.Username,
+				AvatarUrl:  cfg.AvatarURL,
+			}
+		}
+	case database.ChannelTypeMattermost:
+		var cfg database.MattermostChannelConfig
+		if err := json.Unmarshal(raw, &cfg); err == nil {
+			config.Mattermost = &conductorv1.MattermostConfig{
+				WebhookUrl: cfg.WebhookURL,
+				Channel:    cfg.Channel,
+				Username:   cfg.Username,
+				IconUrl:    cfg.IconURL,
+			}
+		}
 	}
 
 	return config
@@ -652,9 +1113,26 @@ func ruleToProto(rule *database.NotificationRule, name string) *conductorv1.Noti
 		protoRule.Events = append(protoRule.Events, triggerToNotificationEvent(trigger))
 	}
 
+	if rule.ServiceID != nil || rule.Owner != nil {
+		protoRule.Filter = &conductorv1.NotificationFilter{}
+	}
 	if rule.ServiceID != nil {
-		protoRule.Filter = &conductorv1.NotificationFilter{
-			ServiceIds: []string{rule.ServiceID.String()},
+		protoRule.Filter.ServiceIds = []string{rule.ServiceID.String()}
+		if rule.TestDefinitionID != nil {
+			protoRule.Filter.TestDefinitionIds = []string{rule.TestDefinitionID.String()}
+		}
+	}
+	if rule.Owner != nil {
+		protoRule.Filter.Owners = []string{*rule.Owner}
+	}
+
+	if rule.TitleTemplate != nil || rule.MessageTemplate != nil {
+		protoRule.Template = &conductorv1.NotificationTemplate{}
+		if rule.TitleTemplate != nil {
+			protoRule.Template.Title = *rule.TitleTemplate
+		}
+		if rule.MessageTemplate != nil {
+			protoRule.Template.Message = *rule.MessageTemplate
 		}
 	}
 
@@ -671,6 +1149,10 @@ func notificationEventToTrigger(event conductorv1.NotificationEvent) database.Tr
 		return database.TriggerEventRecovery
 	case conductorv1.NotificationEvent_NOTIFICATION_EVENT_FLAKY_TEST:
 		return database.TriggerEventFlaky
+	case conductorv1.NotificationEvent_NOTIFICATION_EVENT_DURATION_ANOMALY:
+		return database.TriggerEventDurationAnomaly
+	case conductorv1.NotificationEvent_NOTIFICATION_EVENT_DURATION_REGRESSION:
+		return database.TriggerEventDurationRegression
 	default:
 		return database.TriggerEventAlways
 	}
@@ -684,6 +1166,10 @@ func triggerToNotificationEvent(trigger database.TriggerEvent) conductorv1.Notif
 		return conductorv1.NotificationEvent_NOTIFICATION_EVENT_RUN_PASSED
 	case database.TriggerEventFlaky:
 		return conductorv1.NotificationEvent_NOTIFICATION_EVENT_FLAKY_TEST
+	case database.TriggerEventDurationAnomaly:
+		return conductorv1.NotificationEvent_NOTIFICATION_EVENT_DURATION_ANOMALY
+	case database.TriggerEventDurationRegression:
+		return conductorv1.NotificationEvent_NOTIFICATION_EVENT_DURATION_REGRESSION
 	case database.TriggerEventAlways:
 		return conductorv1.NotificationEvent_NOTIFICATION_EVENT_RUN_COMPLETED
 	default:
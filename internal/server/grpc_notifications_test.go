@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/notification"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// deliveryHistoryRepo returns fixed deliveries and records the last query.
+type deliveryHistoryRepo struct {
+	database.NotificationRepository
+	deliveries []database.NotificationDelivery
+	total      int
+	filter     database.NotificationDeliveryFilter
+	page       database.Pagination
+}
+
+func (r *deliveryHistoryRepo) ListDeliveries(ctx context.Context, filter database.NotificationDeliveryFilter, page database.Pagination) ([]database.NotificationDelivery, int, error) {
+	r.filter = filter
+	r.page = page
+	return r.deliveries, r.total, nil
+}
+
+func (r *deliveryHistoryRepo) GetDelivery(ctx context.Context, id uuid.UUID) (*database.NotificationDelivery, error) {
+	for i := range r.deliveries {
+		if r.deliveries[i].ID == id {
+			return &r.deliveries[i], nil
+		}
+	}
+	return nil, database.ErrNotFound
+}
+
+func TestListNotificationHistory(t *testing.T) {
+	serviceID, channelID := uuid.New(), uuid.New()
+	ruleA, ruleB := uuid.New(), uuid.New()
+	sentAt := time.Date(2026, 1, 20, 10, 0, 0, 0, time.UTC)
+
+	repo := &deliveryHistoryRepo{
+		deliveries: []database.NotificationDelivery{{
+			ID:              uuid.New(),
+			EventType:       "run_failed",
+			ServiceID:       &serviceID,
+			ChannelID:       &channelID,
+			ChannelType:     database.ChannelTypeSlack,
+			RuleIDs:         []uuid.UUID{ruleA, ruleB},
+			Status:          database.DeliveryStatusFailed,
+			Attempts:        3,
+			LatencyMs:       1200,
+			Error:           "Slack returned status 500",
+			ResponseExcerpt: "internal error",
+			SentAt:          sentAt,
+		}},
+		total: 30,
+	}
+	server := NewNotificationServiceServer(NotificationServiceDeps{Repo: repo}, zerolog.Nop())
+
+	success := false
+	resp, err := server.ListNotificationHistory(context.Background(), &conductorv1.ListNotificationHistoryRequest{
+		ServiceId:  serviceID.String(),
+		RuleId:     ruleB.String(),
+		Success:    &success,
+		EventType:  "run_failed",
+		TimeRange:  &conductorv1.TimeRange{Start: timestamppb.New(sentAt.Add(-time.Hour))},
+		Pagination: &conductorv1.Pagination{PageSize: 10},
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, repo.filter.ServiceID)
+	assert.Equal(t, serviceID, *repo.filter.ServiceID)
+	require.NotNil(t, repo.filter.RuleID)
+	assert.Equal(t, ruleB, *repo.filter.RuleID)
+	assert.Nil(t, repo.filter.ChannelID)
+	require.NotNil(t, repo.filter.Status)
+	assert.Equal(t, database.DeliveryStatusFailed, *repo.filter.Status)
+	require.NotNil(t, repo.filter.EventType)
+	assert.Equal(t, "run_failed", *repo.filter.EventType)
+	require.NotNil(t, repo.filter.Since)
+	assert.Nil(t, repo.filter.Until)
+
+	require.Len(t, resp.Records, 1)
+	record := resp.Records[0]
+	assert.Equal(t, conductorv1.NotificationEvent_NOTIFICATION_EVENT_RUN_FAILED, record.Event)
+	assert.Equal(t, "run_failed", record.EventType)
+	assert.Equal(t, channelID.String(), record.ChannelId)
+	assert.Equal(t, conductorv1.ChannelType_CHANNEL_TYPE_SLACK, record.ChannelType)
+	assert.Equal(t, ruleA.String(), record.RuleId)
+	assert.Equal(t, []string{ruleA.String(), ruleB.String()}, record.RuleIds)
+	assert.False(t, record.Success)
+	assert.EqualValues(t, 3, record.Attempts)
+	assert.Equal(t, "internal error", record.ResponseExcerpt)
+	assert.Empty(t, record.RunId)
+
+	// The next page starts after the first ten deliveries
+	assert.True(t, resp.Pagination.HasMore)
+	assert.EqualValues(t, 30, resp.Pagination.TotalCount)
+	_, err = server.ListNotificationHistory(context.Background(), &conductorv1.ListNotificationHistoryRequest{
+		Pagination: &conductorv1.Pagination{PageSize: 10, PageToken: resp.Pagination.NextPageToken},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, database.Pagination{Limit: 10, Offset: 10}, repo.page)
+	assert.Nil(t, repo.filter.ServiceID)
+
+	_, err = server.ListNotificationHistory(context.Background(), &conductorv1.ListNotificationHistoryRequest{ChannelId: "slack"})
+	assert.True(t, errcode.Is(err, errcode.InvalidArgument))
+}
+
+func TestPageTokens(t *testing.T) {
+	page := paginationFromProto(&conductorv1.Pagination{PageSize: 20})
+	assert.Equal(t, 0, page.Offset)
+
+	resp := paginationResponseToProto(page, 45)
+	require.True(t, resp.HasMore)
+	page = paginationFromProto(&conductorv1.Pagination{PageSize: 20, PageToken: resp.NextPageToken})
+	assert.Equal(t, 20, page.Offset)
+
+	page = paginationFromProto(&conductorv1.Pagination{PageSize: 20, PageToken: paginationResponseToProto(page, 45).NextPageToken})
+	assert.Equal(t, 40, page.Offset)
+	last := paginationResponseToProto(page, 45)
+	assert.False(t, last.HasMore)
+	assert.Empty(t, last.NextPageToken)
+
+	// Invalid tokens start from the first page
+	assert.Equal(t, 0, paginationFromProto(&conductorv1.Pagination{PageToken: "not a token"}).Offset)
+	assert.Equal(t, 0, paginationFromProto(&conductorv1.Pagination{PageToken: encodePageToken(-5)}).Offset)
+}
+
+func TestRedeliverNotificationErrors(t *testing.T) {
+	channelID := uuid.New()
+	sent := database.NotificationDelivery{ID: uuid.New(), ChannelID: &channelID, Status: database.DeliveryStatusSent}
+	deleted := database.NotificationDelivery{ID: uuid.New(), Status: database.DeliveryStatusFailed}
+	repo := &deliveryHistoryRepo{deliveries: []database.NotificationDelivery{sent, deleted}}
+	server := NewNotificationServiceServer(NotificationServiceDeps{
+		Repo:                repo,
+		NotificationService: notification.NewService(notification.DefaultConfig(), repo, nil),
+	}, zerolog.Nop())
+	redeliver := func(id string) error {
+		_, err := server.RedeliverNotification(context.Background(), &conductorv1.RedeliverNotificationRequest{DeliveryId: id})
+		return err
+	}
+
+	assert.True(t, errcode.Is(redeliver("not-a-uuid"), errcode.InvalidArgument))
+	assert.True(t, errcode.Is(redeliver(uuid.New().String()), errcode.DeliveryNotFound))
+	assert.True(t, errcode.Is(redeliver(sent.ID.String()), errcode.FailedPrecondition), "sent deliveries")
+	assert.True(t, errcode.Is(redeliver(deleted.ID.String()), errcode.FailedPrecondition), "deleted channels")
+}
+
+func TestDeliveryToProtoRedelivery(t *testing.T) {
+	failedID := uuid.New()
+	record := deliveryToProto(&database.NotificationDelivery{
+		ID:           uuid.New(),
+		Status:       database.DeliveryStatusSent,
+		PayloadHash:  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		RedeliveryOf: &failedID,
+	})
+	assert.Equal(t, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", record.PayloadHash)
+	assert.Equal(t, failedID.String(), record.RedeliveryOf)
+}
+
+// ruleRepo accepts rules for any channel and records the created rule.
+type ruleRepo struct {
+	database.NotificationRepository
+	created *database.NotificationRule
+}
+
+func (r *ruleRepo) GetChannel(ctx context.Context, id uuid.UUID) (*database.NotificationChannel, error) {
+	return &database.NotificationChannel{ID: id, Enabled: true}, nil
+}
+
+func (r *ruleRepo) CreateRule(ctx context.Context, rule *database.NotificationRule) error {
+	rule.ID = uuid.New()
+	r.created = rule
+	return nil
+}
+
+func TestCreateRuleTemplates(t *testing.T) {
+	repo := &ruleRepo{}
+	server := NewNotificationServiceServer(NotificationServiceDeps{Repo: repo}, zerolog.Nop())
+	req := func(tmpl *conductorv1.NotificationTemplate) *conductorv1.CreateRuleRequest {
+		return &conductorv1.CreateRuleRequest{
+			Name:       "failures",
+			ChannelIds: []string{uuid.New().String()},
+			Events:     []conductorv1.NotificationEvent{conductorv1.NotificationEvent_NOTIFICATION_EVENT_RUN_FAILED},
+			Enabled:    true,
+			Template:   tmpl,
+		}
+	}
+
+	resp, err := server.CreateRule(context.Background(), req(&conductorv1.NotificationTemplate{Message: "{{.FailedTests}} failed: {{.URL}}"}))
+	require.NoError(t, err)
+	assert.Nil(t, repo.created.TitleTemplate)
+	require.NotNil(t, repo.created.MessageTemplate)
+	assert.Equal(t, "{{.FailedTests}} failed: {{.URL}}", *repo.created.MessageTemplate)
+	assert.Equal(t, "{{.FailedTests}} failed: {{.URL}}", resp.Rule.Template.GetMessage())
+
+	_, err = server.CreateRule(context.Background(), req(&conductorv1.NotificationTemplate{Title: "{{.Unknown}}"}))
+	assert.True(t, errcode.Is(err, errcode.InvalidArgument))
+}
@@ -0,0 +1,284 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// maxViewPageLength is the longest page name a view may be saved for.
+const maxViewPageLength = 50
+
+// PreferencesServiceDeps defines the dependencies for the preferences service.
+type PreferencesServiceDeps struct {
+	// Repo handles preference persistence.
+	Repo database.PreferenceRepository
+}
+
+// PreferencesServiceServer implements the PreferencesService gRPC service.
+type PreferencesServiceServer struct {
+	conductorv1.UnimplementedPreferencesServiceServer
+
+	deps   PreferencesServiceDeps
+	logger zerolog.Logger
+}
+
+// NewPreferencesServiceServer creates a new preferences service server.
+func NewPreferencesServiceServer(deps PreferencesServiceDeps, logger zerolog.Logger) *PreferencesServiceServer {
+	return &PreferencesServiceServer{
+		deps:   deps,
+		logger: logger.With().Str("service", "PreferencesService").Logger(),
+	}
+}
+
+// caller returns the ID of the user whose preferences a call reads or
+// changes.
+func (s *PreferencesServiceServer) caller(ctx context.Context) (string, error) {
+	if s.deps.Repo == nil {
+		return "", errcode.New(errcode.NotConfigured, "preferences are not configured")
+	}
+	claims := GetUserFromContext(ctx)
+	if claims == nil || claims.UserID == "" {
+		return "", errcode.New(errcode.Unauthenticated, "preferences are stored per user and require a token")
+	}
+	return claims.UserID, nil
+}
+
+// ListPinnedServices lists the services the caller pinned.
+func (s *PreferencesServiceServer) ListPinnedServices(ctx context.Context, req *conductorv1.ListPinnedServicesRequest) (*conductorv1.ListPinnedServicesResponse, error) {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := s.deps.Repo.ListPinnedServices(ctx, userID)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list pinned services: %v", err)
+	}
+
+	resp := &conductorv1.ListPinnedServicesResponse{
+		Services: make([]*conductorv1.Service, len(services)),
+	}
+	for i := range services {
+		resp.Services[i] = serviceToProto(&services[i])
+	}
+	return resp, nil
+}
+
+// PinService pins a service to the caller's dashboard.
+func (s *PreferencesServiceServer) PinService(ctx context.Context, req *conductorv1.PinServiceRequest) (*conductorv1.PinServiceResponse, error) {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	if err := s.deps.Repo.PinService(ctx, userID, serviceID); err != nil {
+		if database.IsForeignKeyViolation(err) {
+			return nil, errcode.New(errcode.ServiceNotFound, "service not found: %s", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to pin service: %v", err)
+	}
+	return &conductorv1.PinServiceResponse{}, nil
+}
+
+// UnpinService removes a service from the caller's dashboard.
+func (s *PreferencesServiceServer) UnpinService(ctx context.Context, req *conductorv1.UnpinServiceRequest) (*conductorv1.UnpinServiceResponse, error) {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	if err := s.deps.Repo.UnpinService(ctx, userID, serviceID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.NotFound, "service %s is not pinned", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to unpin service: %v", err)
+	}
+	return &conductorv1.UnpinServiceResponse{}, nil
+}
+
+// ListSavedViews lists the views the caller saved.
+func (s *PreferencesServiceServer) ListSavedViews(ctx context.Context, req *conductorv1.ListSavedViewsRequest) (*conductorv1.ListSavedViewsResponse, error) {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var filter database.SavedViewFilter
+	if req.Page != "" {
+		filter.Page = &req.Page
+	}
+	if filter.ServiceID, err = parseOptionalUUID(req.ServiceId); err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	views, err := s.deps.Repo.ListViews(ctx, userID, filter)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list saved views: %v", err)
+	}
+
+	resp := &conductorv1.ListSavedViewsResponse{
+		Views: make([]*conductorv1.SavedView, len(views)),
+	}
+	for i := range views {
+		resp.Views[i] = savedViewToProto(&views[i])
+	}
+	return resp, nil
+}
+
+// CreateSavedView saves a view for the caller.
+func (s *PreferencesServiceServer) CreateSavedView(ctx context.Context, req *conductorv1.CreateSavedViewRequest) (*conductorv1.CreateSavedViewResponse, error) {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		return nil, errcode.New(errcode.InvalidArgument, "name is required")
+	}
+	if req.Page == "" || len(req.Page) > maxViewPageLength {
+		return nil, errcode.New(errcode.InvalidArgument, "page is required and must be at most %d characters", maxViewPageLength)
+	}
+	serviceID, err := parseOptionalUUID(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+	filters, err := viewFilters(req.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &database.SavedView{
+		UserID:    userID,
+		Name:      req.Name,
+		Page:      req.Page,
+		ServiceID: serviceID,
+		Filters:   filters,
+		IsDefault: req.IsDefault,
+	}
+	if err := s.deps.Repo.CreateView(ctx, view); err != nil {
+		return nil, savedViewError(err, req.ServiceId)
+	}
+
+	return &conductorv1.CreateSavedViewResponse{View: savedViewToProto(view)}, nil
+}
+
+// UpdateSavedView updates a view of the caller.
+func (s *PreferencesServiceServer) UpdateSavedView(ctx context.Context, req *conductorv1.UpdateSavedViewRequest) (*conductorv1.UpdateSavedViewResponse, error) {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	viewID, err := uuid.Parse(req.ViewId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid view ID: %v", err)
+	}
+
+	view, err := s.deps.Repo.GetView(ctx, userID, viewID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.ViewNotFound, "view not found: %s", req.ViewId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get saved view: %v", err)
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			return nil, errcode.New(errcode.InvalidArgument, "name must not be empty")
+		}
+		view.Name = *req.Name
+	}
+	if req.Filters != nil {
+		if view.Filters, err = viewFilters(*req.Filters); err != nil {
+			return nil, err
+		}
+	}
+	if req.IsDefault != nil {
+		view.IsDefault = *req.IsDefault
+	}
+
+	if err := s.deps.Repo.UpdateView(ctx, view); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.ViewNotFound, "view not found: %s", req.ViewId)
+		}
+		return nil, savedViewError(err, "")
+	}
+
+	return &conductorv1.UpdateSavedViewResponse{View: savedViewToProto(view)}, nil
+}
+
+// DeleteSavedView deletes a view of the caller.
+func (s *PreferencesServiceServer) DeleteSavedView(ctx context.Context, req *conductorv1.DeleteSavedViewRequest) (*conductorv1.DeleteSavedViewResponse, error) {
+	userID, err := s.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	viewID, err := uuid.Parse(req.ViewId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid view ID: %v", err)
+	}
+
+	if err := s.deps.Repo.DeleteView(ctx, userID, viewID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.ViewNotFound, "view not found: %s", req.ViewId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to delete saved view: %v", err)
+	}
+	return &conductorv1.DeleteSavedViewResponse{}, nil
+}
+
+// viewError maps an error saving a view to its error code.
+func savedViewError(err error, serviceID string) error {
+	switch {
+	case database.IsDuplicate(err):
+		return errcode.New(errcode.ViewAlreadyExists, "a view with the same name already exists for the page")
+	case database.IsForeignKeyViolation(err):
+		return errcode.New(errcode.ServiceNotFound, "service not found: %s", serviceID)
+	default:
+		return errcode.New(errcode.Internal, "failed to save view: %v", err)
+	}
+}
+
+// viewFilters validates the filters of a view, which must be a JSON
+// object. Empty filters are saved as an empty object.
+func viewFilters(filters string) (json.RawMessage, error) {
+	if filters == "" {
+		return json.RawMessage("{}"), nil
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(filters), &object); err != nil || object == nil {
+		return nil, errcode.New(errcode.InvalidArgument, "filters must be a JSON object")
+	}
+	return json.RawMessage(filters), nil
+}
+
+// savedViewToProto converts a saved view to its proto representation.
+func savedViewToProto(view *database.SavedView) *conductorv1.SavedView {
+	pb := &conductorv1.SavedView{
+		Id:        view.ID.String(),
+		Name:      view.Name,
+		Page:      view.Page,
+		Filters:   string(view.Filters),
+		IsDefault: view.IsDefault,
+		CreatedAt: timestamppb.New(view.CreatedAt),
+		UpdatedAt: timestamppb.New(view.UpdatedAt),
+	}
+	if view.ServiceID != nil {
+		pb.ServiceId = view.ServiceID.String()
+	}
+	return pb
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// memoryPreferenceRepo stores preferences in memory.
+type memoryPreferenceRepo struct {
+	services map[uuid.UUID]database.Service
+	pinned   map[string][]uuid.UUID
+	views    []*database.SavedView
+}
+
+func newMemoryPreferenceRepo(services ...database.Service) *memoryPreferenceRepo {
+	r := &memoryPreferenceRepo{services: map[uuid.UUID]database.Service{}, pinned: map[string][]uuid.UUID{}}
+	for _, svc := range services {
+		r.services[svc.ID] = svc
+	}
+	return r
+}
+
+func (r *memoryPreferenceRepo) PinService(ctx context.Context, userID string, serviceID uuid.UUID) error {
+	if _, ok := r.services[serviceID]; !ok {
+		return database.ErrForeignKey
+	}
+	for _, id := range r.pinned[userID] {
+		if id == serviceID {
+			return nil
+		}
+	}
+	r.pinned[userID] = append(r.pinned[userID], serviceID)
+	return nil
+}
+
+func (r *memoryPreferenceRepo) UnpinService(ctx context.Context, userID string, serviceID uuid.UUID) error {
+	for i, id := range r.pinned[userID] {
+		if id == serviceID {
+			r.pinned[userID] = append(r.pinned[userID][:i], r.pinned[userID][i+1:]...)
+			return nil
+		}
+	}
+	return database.ErrNotFound
+}
+
+func (r *memoryPreferenceRepo) ListPinnedServices(ctx context.Context, userID string) ([]database.Service, error) {
+	var services []database.Service
+	for _, id := range r.pinned[userID] {
+		services = append(services, r.services[id])
+	}
+	return services, nil
+}
+
+func (r *memoryPreferenceRepo) clearDefault(view *database.SavedView) {
+	for _, v := range r.views {
+		if v.ID != view.ID && v.UserID == view.UserID && v.Page == view.Page && v.ServiceID == view.ServiceID {
+			v.IsDefault = false
+		}
+	}
+}
+
+func (r *memoryPreferenceRepo) CreateView(ctx context.Context, view *database.SavedView) error {
+	for _, v := range r.views {
+		if v.UserID == view.UserID && v.Page == view.Page && v.Name == view.Name {
+			return database.ErrDuplicate
+		}
+	}
+	if view.IsDefault {
+		r.clearDefault(view)
+	}
+	view.ID = uuid.New()
+	saved := *view
+	r.views = append(r.views, &saved)
+	return nil
+}
+
+func (r *memoryPreferenceRepo) GetView(ctx context.Context, userID string, id uuid.UUID) (*database.SavedView, error) {
+	for _, v := range r.views {
+		if v.ID == id && v.UserID == userID {
+			view := *v
+			return &view, nil
+		}
+	}
+	return nil, database.ErrNotFound
+}
+
+func (r *memoryPreferenceRepo) UpdateView(ctx context.Context, view *database.SavedView) error {
+	for _, v := range r.views {
+		if v.ID == view.ID && v.UserID == view.UserID {
+			if view.IsDefault {
+				r.clearDefault(view)
+			}
+			*v = *view
+			return nil
+		}
+	}
+	return database.ErrNotFound
+}
+
+func (r *memoryPreferenceRepo) DeleteView(ctx context.Context, userID string, id uuid.UUID) error {
+	for i, v := range r.views {
+		if v.ID == id && v.UserID == userID {
+			r.views = append(r.views[:i], r.views[i+1:]...)
+			return nil
+		}
+	}
+	return database.ErrNotFound
+}
+
+func (r *memoryPreferenceRepo) ListViews(ctx context.Context, userID string, filter database.SavedViewFilter) ([]database.SavedView, error) {
+	var views []database.SavedView
+	for _, v := range r.views {
+		if v.UserID == userID && (filter.Page == nil || v.Page == *filter.Page) {
+			views = append(views, *v)
+		}
+	}
+	return views, nil
+}
+
+func TestPreferencesService_PinnedServices(t *testing.T) {
+	svc := database.Service{ID: uuid.New(), Name: "checkout", ProjectID: database.DefaultProjectID}
+	server := NewPreferencesServiceServer(PreferencesServiceDeps{Repo: newMemoryPreferenceRepo(svc)}, zerolog.Nop())
+	alice := withUser(context.Background(), &UserClaims{UserID: "alice"})
+	bob := withUser(context.Background(), &UserClaims{UserID: "bob"})
+
+	_, err := server.ListPinnedServices(context.Background(), &conductorv1.ListPinnedServicesRequest{})
+	assert.Equal(t, errcode.Unauthenticated, errcode.FromError(err), "preferences require a user")
+
+	for i := 0; i < 2; i++ {
+		_, err = server.PinService(alice, &conductorv1.PinServiceRequest{ServiceId: svc.ID.String()})
+		require.NoError(t, err, "pinning is idempotent")
+	}
+	_, err = server.PinService(alice, &conductorv1.PinServiceRequest{ServiceId: uuid.NewString()})
+	assert.Equal(t, errcode.ServiceNotFound, errcode.FromError(err))
+
+	resp, err := server.ListPinnedServices(alice, &conductorv1.ListPinnedServicesRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Services, 1)
+	assert.Equal(t, "checkout", resp.Services[0].Name)
+
+	resp, err = server.ListPinnedServices(bob, &conductorv1.ListPinnedServicesRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Services, "pins are per user")
+
+	_, err = server.UnpinService(alice, &conductorv1.UnpinServiceRequest{ServiceId: svc.ID.String()})
+	require.NoError(t, err)
+	_, err = server.UnpinService(alice, &conductorv1.UnpinServiceRequest{ServiceId: svc.ID.String()})
+	assert.Equal(t, errcode.NotFound, errcode.FromError(err))
+}
+
+func TestPreferencesService_SavedViews(t *testing.T) {
+	server := NewPreferencesServiceServer(PreferencesServiceDeps{Repo: newMemoryPreferenceRepo()}, zerolog.Nop())
+	alice := withUser(context.Background(), &UserClaims{UserID: "alice"})
+	bob := withUser(context.Background(), &UserClaims{UserID: "bob"})
+
+	failing, err := server.CreateSavedView(alice, &conductorv1.CreateSavedViewRequest{
+		Name:      "failing on main",
+		Page:      "runs",
+		Filters:   `{"status":["failed"],"branch":"main"}`,
+		IsDefault: true,
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status":["failed"],"branch":"main"}`, failing.View.Filters)
+	assert.True(t, failing.View.IsDefault)
+
+	mine, err := server.CreateSavedView(alice, &conductorv1.CreateSavedViewRequest{Name: "mine", Page: "runs"})
+	require.NoError(t, err)
+	assert.Equal(t, "{}", mine.View.Filters)
+
+	t.Run("validation", func(t *testing.T) {
+		_, err := server.CreateSavedView(alice, &conductorv1.CreateSavedViewRequest{Name: "mine", Page: "runs"})
+		assert.Equal(t, errcode.ViewAlreadyExists, errcode.FromError(err))
+
+		_, err = server.CreateSavedView(alice, &conductorv1.CreateSavedViewRequest{Name: "bad", Page: "runs", Filters: `["failed"]`})
+		assert.Equal(t, errcode.InvalidArgument, errcode.FromError(err), "filters must be an object")
+
+		_, err = server.CreateSavedView(alice, &conductorv1.CreateSavedViewRequest{Name: "bad"})
+		assert.Equal(t, errcode.InvalidArgument, errcode.FromError(err), "page is required")
+	})
+
+	t.Run("default moves to the updated view", func(t *testing.T) {
+		isDefault := true
+		resp, err := server.UpdateSavedView(alice, &conductorv1.UpdateSavedViewRequest{ViewId: mine.View.Id, IsDefault: &isDefault})
+		require.NoError(t, err)
+		assert.True(t, resp.View.IsDefault)
+
+		views, err := server.ListSavedViews(alice, &conductorv1.ListSavedViewsRequest{Page: "runs"})
+		require.NoError(t, err)
+		require.Len(t, views.Views, 2)
+		for _, v := range views.Views {
+			assert.Equal(t, v.Id == mine.View.Id, v.IsDefault, v.Name)
+		}
+	})
+
+	t.Run("views are per user", func(t *testing.T) {
+		views, err := server.ListSavedViews(bob, &conductorv1.ListSavedViewsRequest{})
+		require.NoError(t, err)
+		assert.Empty(t, views.Views)
+
+		_, err = server.DeleteSavedView(bob, &conductorv1.DeleteSavedViewRequest{ViewId: failing.View.Id})
+		assert.Equal(t, errcode.ViewNotFound, errcode.FromError(err))
+
+		_, err = server.DeleteSavedView(alice, &conductorv1.DeleteSavedViewRequest{ViewId: failing.View.Id})
+		require.NoError(t, err)
+	})
+}
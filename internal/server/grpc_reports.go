@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/analytics"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// ReportServiceDeps defines the dependencies for the report service.
+type ReportServiceDeps struct {
+	// Reporter computes service reports.
+	Reporter ServiceReporter
+}
+
+// ServiceReporter computes service reports. analytics.Reporter implements it.
+type ServiceReporter interface {
+	ServiceReports(ctx context.Context, query analytics.Query) (*analytics.Reports, error)
+	ServiceReport(ctx context.Context, serviceID uuid.UUID, query analytics.Query) (*analytics.Reports, error)
+}
+
+// ReportServiceServer implements the ReportService gRPC service.
+type ReportServiceServer struct {
+	conductorv1.UnimplementedReportServiceServer
+
+	deps   ReportServiceDeps
+	logger zerolog.Logger
+}
+
+// NewReportServiceServer creates a new report service server.
+func NewReportServiceServer(deps ReportServiceDeps, logger zerolog.Logger) *ReportServiceServer {
+	return &ReportServiceServer{
+		deps:   deps,
+		logger: logger.With().Str("service", "ReportService").Logger(),
+	}
+}
+
+// ListServiceReports returns the reports of all services.
+func (s *ReportServiceServer) ListServiceReports(ctx context.Context, req *conductorv1.ListServiceReportsRequest) (*conductorv1.ListServiceReportsResponse, error) {
+	if s.deps.Reporter == nil {
+		return nil, errcode.New(errcode.NotConfigured, "reports are not configured")
+	}
+
+	reports, err := s.deps.Reporter.ServiceReports(ctx, analytics.Query{
+		WindowDays: int(req.WindowDays),
+		Branch:     req.Branch,
+	})
+	if err != nil {
+		return nil, reportError(err)
+	}
+
+	resp := &conductorv1.ListServiceReportsResponse{
+		Reports:     make([]*conductorv1.ServiceReport, len(reports.Services)),
+		WindowDays:  int32(reports.WindowDays),
+		WindowStart: timestamppb.New(reports.Since),
+	}
+	for i := range reports.Services {
+		resp.Reports[i] = serviceReportToProto(&reports.Services[i])
+	}
+	return resp, nil
+}
+
+// GetServiceReport returns the report of a service.
+func (s *ReportServiceServer) GetServiceReport(ctx context.Context, req *conductorv1.GetServiceReportRequest) (*conductorv1.GetServiceReportResponse, error) {
+	if s.deps.Reporter == nil {
+		return nil, errcode.New(errcode.NotConfigured, "reports are not configured")
+	}
+
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	reports, err := s.deps.Reporter.ServiceReport(ctx, serviceID, analytics.Query{
+		WindowDays: int(req.WindowDays),
+		Branch:     req.Branch,
+	})
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.ServiceNotFound, "service not found: %s", req.ServiceId)
+		}
+		return nil, reportError(err)
+	}
+
+	return &conductorv1.GetServiceReportResponse{
+		Report:      serviceReportToProto(&reports.Services[0]),
+		WindowDays:  int32(reports.WindowDays),
+		WindowStart: timestamppb.New(reports.Since),
+	}, nil
+}
+
+// reportError maps an error computing reports to a gRPC error.
+func reportError(err error) error {
+	if errors.Is(err, analytics.ErrInvalidWindow) {
+		return errcode.New(errcode.InvalidArgument, "%v", err)
+	}
+	return errcode.New(errcode.Internal, "failed to compute service reports: %v", err)
+}
+
+// serviceReportToProto converts a service report to its proto representation.
+func serviceReportToProto(report *analytics.Report) *conductorv1.ServiceReport {
+	return &conductorv1.ServiceReport{
+		ServiceId:     report.ServiceID.String(),
+		ServiceName:   report.ServiceName,
+		TotalRuns:     int64(report.TotalRuns),
+		PassedRuns:    int64(report.PassedRuns),
+		FailedRuns:    int64(report.FailedRuns),
+		SuccessRate:   report.SuccessRate,
+		AvgDurationMs: report.AvgDurationMs,
+		P95DurationMs: report.P95DurationMs,
+		Recoveries:    int64(report.Recoveries),
+		MttrMs:        report.MTTRMs,
+	}
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/analytics"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// serviceReportRepo returns fixed service reports.
+type serviceReportRepo struct {
+	reports []database.ServiceReport
+}
+
+func (r *serviceReportRepo) GetServiceReports(ctx context.Context, filter database.ServiceReportFilter) ([]database.ServiceReport, error) {
+	if filter.ServiceID == nil {
+		return r.reports, nil
+	}
+	for _, report := range r.reports {
+		if report.ServiceID == *filter.ServiceID {
+			return []database.ServiceReport{report}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *serviceReportRepo) RefreshServiceReports(ctx context.Context) error {
+	return nil
+}
+
+func TestServiceReports(t *testing.T) {
+	checkout := database.ServiceReport{
+		ServiceID:     uuid.New(),
+		ServiceName:   "checkout",
+		TotalRuns:     4,
+		PassedRuns:    3,
+		FailedRuns:    1,
+		AvgDurationMs: 1200,
+		P95DurationMs: 2500,
+		Recoveries:    1,
+		MTTRMs:        60000,
+	}
+	s := NewReportServiceServer(ReportServiceDeps{
+		Reporter: analytics.NewReporter(&serviceReportRepo{reports: []database.ServiceReport{checkout}}, nil),
+	}, zerolog.Nop())
+
+	list, err := s.ListServiceReports(context.Background(), &conductorv1.ListServiceReportsRequest{WindowDays: 7})
+	require.NoError(t, err)
+	assert.Equal(t, int32(7), list.WindowDays)
+	assert.NotNil(t, list.WindowStart)
+	require.Len(t, list.Reports, 1)
+	assert.Equal(t, &conductorv1.ServiceReport{
+		ServiceId:     checkout.ServiceID.String(),
+		ServiceName:   "checkout",
+		TotalRuns:     4,
+		PassedRuns:    3,
+		FailedRuns:    1,
+		SuccessRate:   0.75,
+		AvgDurationMs: 1200,
+		P95DurationMs: 2500,
+		Recoveries:    1,
+		MttrMs:        60000,
+	}, list.Reports[0])
+
+	get, err := s.GetServiceReport(context.Background(), &conductorv1.GetServiceReportRequest{ServiceId: checkout.ServiceID.String()})
+	require.NoError(t, err)
+	assert.Equal(t, int32(analytics.DefaultWindowDays), get.WindowDays)
+	assert.Equal(t, "checkout", get.Report.ServiceName)
+
+	getReport := func(req *conductorv1.GetServiceReportRequest) error {
+		_, err := s.GetServiceReport(context.Background(), req)
+		return err
+	}
+	assert.True(t, errcode.Is(getReport(&conductorv1.GetServiceReportRequest{ServiceId: "not-a-uuid"}), errcode.InvalidArgument))
+	assert.True(t, errcode.Is(getReport(&conductorv1.GetServiceReportRequest{ServiceId: uuid.New().String()}), errcode.ServiceNotFound))
+	assert.True(t, errcode.Is(getReport(&conductorv1.GetServiceReportRequest{ServiceId: checkout.ServiceID.String(), WindowDays: 400}), errcode.InvalidArgument))
+
+	_, err = NewReportServiceServer(ReportServiceDeps{}, zerolog.Nop()).ListServiceReports(context.Background(), &conductorv1.ListServiceReportsRequest{})
+	assert.True(t, errcode.Is(err, errcode.NotConfigured))
+}
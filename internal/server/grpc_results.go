@@ -2,16 +2,22 @@ package server
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
 	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/git"
+	"github.com/conductor/conductor/internal/result"
+	"github.com/conductor/conductor/pkg/errcode"
 )
 
 // ResultServiceDeps defines the dependencies for the result service.
@@ -24,6 +30,12 @@ type ResultServiceDeps struct {
 	RunRepo RunRepository
 	// ArtifactStorage handles artifact storage operations.
 	ArtifactStorage ArtifactStorage
+	// ArtifactRestorer restores archived artifacts on download (optional).
+	ArtifactRestorer ArtifactRestorer
+	// ArtifactReader reads artifact content for output diffs (optional).
+	ArtifactReader ArtifactReader
+	// ServiceRepo looks up services to link stack frames to their source (optional).
+	ServiceRepo ServiceRepository
 }
 
 // ResultRepository defines the interface for result persistence.
@@ -31,6 +43,7 @@ type ResultRepository interface {
 	GetByRunID(ctx context.Context, runID uuid.UUID) ([]*database.TestResult, error)
 	List(ctx context.Context, runID uuid.UUID, filter ResultFilter, pagination database.Pagination) ([]*database.TestResult, int, error)
 	Create(ctx context.Context, result *database.TestResult) error
+	GetLastPassing(ctx context.Context, serviceID uuid.UUID, testName string, suiteName *string, before time.Time) (*database.TestResult, error)
 }
 
 // ResultFilter defines filtering options for listing results.
@@ -45,12 +58,36 @@ type ArtifactRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*database.Artifact, error)
 	ListByRunID(ctx context.Context, runID uuid.UUID, pagination database.Pagination) ([]*database.Artifact, int, error)
 	Create(ctx context.Context, artifact *database.Artifact) error
+	UsageByTest(ctx context.Context, runID, testID uuid.UUID) (files int, bytes int64, err error)
 }
 
 // ArtifactStorage handles artifact storage operations.
 type ArtifactStorage interface {
 	// GenerateDownloadURL generates a signed download URL for an artifact.
-	GenerateDownloadURL(ctx context.Context, path string, expirationSeconds int) (string, time.Time, error)
+	GenerateDownloadURL(ctx context.Context, path string, expirationSeconds int, opts DownloadURLOptions) (string, time.Time, error)
+}
+
+// DownloadURLOptions sets the response headers a download URL serves an
+// artifact with.
+type DownloadURLOptions struct {
+	// ContentType overrides the content type the artifact was stored with.
+	ContentType string
+	// Inline asks browsers to display the artifact instead of saving it.
+	Inline bool
+	// FileName is the name browsers save or display the artifact as.
+	FileName string
+}
+
+// ArtifactRestorer makes archived artifacts available for download.
+type ArtifactRestorer interface {
+	// PrepareDownload reports whether an artifact can be downloaded now. If
+	// not, it starts a restore and returns when it is expected to finish.
+	PrepareDownload(ctx context.Context, artifact *database.Artifact) (bool, time.Time, error)
+}
+
+// ArtifactReader reads stored artifact content.
+type ArtifactReader interface {
+	Download(ctx context.Context, path string) (io.ReadCloser, error)
 }
 
 // ResultServiceServer implements the ResultService gRPC service.
@@ -73,15 +110,15 @@ func NewResultServiceServer(deps ResultServiceDeps, logger zerolog.Logger) *Resu
 func (s *ResultServiceServer) GetRunResults(ctx context.Context, req *conductorv1.GetRunResultsRequest) (*conductorv1.GetRunResultsResponse, error) {
 	runID, err := uuid.Parse(req.RunId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid run ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid run ID: %v", err)
 	}
 
 	run, err := s.deps.RunRepo.GetByID(ctx, runID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "run not found: %s", req.RunId)
+			return nil, errcode.New(errcode.RunNotFound, "run not found: %s", req.RunId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get run: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get run: %v", err)
 	}
 
 	// Calculate pass rate
@@ -122,7 +159,7 @@ func (s *ResultServiceServer) GetRunResults(ctx context.Context, req *conductorv
 func (s *ResultServiceServer) ListTestResults(ctx context.Context, req *conductorv1.ListTestResultsRequest) (*conductorv1.ListTestResultsResponse, error) {
 	runID, err := uuid.Parse(req.RunId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid run ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid run ID: %v", err)
 	}
 
 	filter := ResultFilter{
@@ -138,10 +175,11 @@ func (s *ResultServiceServer) ListTestResults(ctx context.Context, req *conducto
 	}
 
 	pagination := paginationFromProto(req.Pagination)
-	results, total, err := s.deps.ResultRepo.List(ctx, runID, filter, pagination)
+	results, total, err := s.deps.ResultRepo.List(ctx, runID, filter, peekPagination(pagination))
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to list results: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to list results: %v", err)
 	}
+	results, page := keysetPage(pagination, results, total, resultCursor)
 
 	protoResults := make([]*conductorv1.TestResult, len(results))
 	for i, result := range results {
@@ -150,7 +188,7 @@ func (s *ResultServiceServer) ListTestResults(ctx context.Context, req *conducto
 
 	return &conductorv1.ListTestResultsResponse{
 		Results:    protoResults,
-		Pagination: paginationResponseToProto(pagination, total),
+		Pagination: page,
 	}, nil
 }
 
@@ -158,15 +196,15 @@ func (s *ResultServiceServer) ListTestResults(ctx context.Context, req *conducto
 func (s *ResultServiceServer) GetArtifact(ctx context.Context, req *conductorv1.GetArtifactRequest) (*conductorv1.GetArtifactResponse, error) {
 	artifactID, err := uuid.Parse(req.ArtifactId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid artifact ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid artifact ID: %v", err)
 	}
 
 	artifact, err := s.deps.ArtifactRepo.GetByID(ctx, artifactID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "artifact not found: %s", req.ArtifactId)
+			return nil, errcode.New(errcode.ArtifactNotFound, "artifact not found: %s", req.ArtifactId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get artifact: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get artifact: %v", err)
 	}
 
 	return &conductorv1.GetArtifactResponse{
@@ -178,15 +216,33 @@ func (s *ResultServiceServer) GetArtifact(ctx context.Context, req *conductorv1.
 func (s *ResultServiceServer) GetArtifactDownloadURL(ctx context.Context, req *conductorv1.GetArtifactDownloadURLRequest) (*conductorv1.GetArtifactDownloadURLResponse, error) {
 	artifactID, err := uuid.Parse(req.ArtifactId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid artifact ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid artifact ID: %v", err)
 	}
 
 	artifact, err := s.deps.ArtifactRepo.GetByID(ctx, artifactID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "artifact not found: %s", req.ArtifactId)
+			return nil, errcode.New(errcode.ArtifactNotFound, "artifact not found: %s", req.ArtifactId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get artifact: %v", err)
+	}
+
+	if s.deps.ArtifactRestorer != nil {
+		ready, eta, err := s.deps.ArtifactRestorer.PrepareDownload(ctx, artifact)
+		if err != nil {
+			s.logger.Error().Err(err).
+				Str("artifact_id", artifactID.String()).
+				Str("storage_class", artifact.StorageClass).
+				Msg("failed to restore archived artifact")
+			return nil, errcode.New(errcode.Unavailable, "failed to restore archived artifact: %v", err)
+		}
+		if !ready {
+			setHTTPStatus(ctx, http.StatusAccepted)
+			return &conductorv1.GetArtifactDownloadURLResponse{
+				RestorePending: true,
+				RestoreEta:     timestamppb.New(eta),
+			}, nil
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get artifact: %v", err)
 	}
 
 	// Default expiration is 5 minutes, max is 1 hour
@@ -198,18 +254,33 @@ func (s *ResultServiceServer) GetArtifactDownloadURL(ctx context.Context, req *c
 		expirationSeconds = 3600
 	}
 
-	downloadURL, expiresAt, err := s.deps.ArtifactStorage.GenerateDownloadURL(ctx, artifact.Path, expirationSeconds)
+	opts := DownloadURLOptions{FileName: path.Base(artifact.Name)}
+	if req.Inline {
+		contentType, ok := inlineContentType(artifact)
+		if !ok {
+			return nil, errcode.New(errcode.FailedPrecondition, "%s artifact %s cannot be displayed inline", artifact.Type, artifact.Name)
+		}
+		opts.ContentType = contentType
+		opts.Inline = true
+	}
+
+	downloadURL, expiresAt, err := s.deps.ArtifactStorage.GenerateDownloadURL(ctx, artifact.Path, expirationSeconds, opts)
 	if err != nil {
 		s.logger.Error().Err(err).
 			Str("artifact_id", artifactID.String()).
 			Str("path", artifact.Path).
 			Msg("failed to generate download URL")
-		return nil, status.Errorf(codes.Internal, "failed to generate download URL: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to generate download URL: %v", err)
 	}
 
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = stringValue(artifact.ContentType)
+	}
 	return &conductorv1.GetArtifactDownloadURLResponse{
 		DownloadUrl: downloadURL,
 		ExpiresAt:   timestamppb.New(expiresAt),
+		ContentType: contentType,
 	}, nil
 }
 
@@ -217,19 +288,27 @@ func (s *ResultServiceServer) GetArtifactDownloadURL(ctx context.Context, req *c
 func (s *ResultServiceServer) ListArtifacts(ctx context.Context, req *conductorv1.ListArtifactsRequest) (*conductorv1.ListArtifactsResponse, error) {
 	runID, err := uuid.Parse(req.RunId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid run ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid run ID: %v", err)
+	}
+
+	filter, err := newArtifactFilter(req.TestId, req.ContentTypePrefix, req.Type)
+	if err != nil {
+		return nil, err
 	}
 
 	pagination := paginationFromProto(req.Pagination)
 	artifacts, total, err := s.deps.ArtifactRepo.ListByRunID(ctx, runID, pagination)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to list artifacts: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to list artifacts: %v", err)
 	}
 
-	protoArtifacts := make([]*conductorv1.Artifact, len(artifacts))
-	for i, artifact := range artifacts {
-		protoArtifacts[i] = artifactToProto(artifact)
+	protoArtifacts := make([]*conductorv1.Artifact, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		if filter.matches(artifact) {
+			protoArtifacts = append(protoArtifacts, artifactToProto(artifact))
+		}
 	}
+	total -= len(artifacts) - len(protoArtifacts)
 
 	return &conductorv1.ListArtifactsResponse{
 		Artifacts:  protoArtifacts,
@@ -237,8 +316,261 @@ func (s *ResultServiceServer) ListArtifacts(ctx context.Context, req *conductorv
 	}, nil
 }
 
+// maxOutputDiffArtifacts is the maximum number of log artifacts diffed per test.
+const maxOutputDiffArtifacts = 5
+
+// DiffTestOutput diffs the output of a failing test against the output of
+// its last passing run.
+func (s *ResultServiceServer) DiffTestOutput(ctx context.Context, req *conductorv1.DiffTestOutputRequest) (*conductorv1.DiffTestOutputResponse, error) {
+	runID, err := uuid.Parse(req.RunId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid run ID: %v", err)
+	}
+	if req.TestName == "" {
+		return nil, errcode.New(errcode.InvalidArgument, "test_name is required")
+	}
+	if req.MaxBytes < 0 {
+		return nil, errcode.New(errcode.InvalidArgument, "max_bytes must not be negative")
+	}
+
+	run, err := s.deps.RunRepo.GetByID(ctx, runID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.RunNotFound, "run not found: %s", req.RunId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get run: %v", err)
+	}
+
+	results, err := s.deps.ResultRepo.GetByRunID(ctx, runID)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to get results: %v", err)
+	}
+
+	failing := findTestResult(results, req.TestName, req.SuiteName)
+	if failing == nil {
+		return nil, errcode.New(errcode.NotFound, "test %q has no result in run %s", req.TestName, req.RunId)
+	}
+	if failing.Status != database.ResultStatusFail && failing.Status != database.ResultStatusError {
+		return nil, errcode.New(errcode.FailedPrecondition, "test %q did not fail in run %s", req.TestName, req.RunId)
+	}
+
+	baseline, err := s.deps.ResultRepo.GetLastPassing(ctx, run.ServiceID, failing.TestName, failing.SuiteName, failing.CreatedAt)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.NotFound, "test %q has not passed before run %s", req.TestName, req.RunId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get last passing result: %v", err)
+	}
+
+	opts := result.DefaultDiffOptions()
+	if req.MaxBytes > 0 {
+		opts.MaxDiffBytes = min(int(req.MaxBytes), 1<<20)
+	}
+
+	resp := &conductorv1.DiffTestOutputResponse{
+		ResultId:         failing.ID.String(),
+		BaselineRunId:    baseline.RunID.String(),
+		BaselineResultId: baseline.ID.String(),
+	}
+	resp.Diffs = append(resp.Diffs,
+		outputDiffToProto(result.DiffOutput("stdout", stringValue(baseline.Stdout), stringValue(failing.Stdout), opts)),
+		outputDiffToProto(result.DiffOutput("stderr", stringValue(baseline.Stderr), stringValue(failing.Stderr), opts)),
+	)
+
+	if req.IncludeArtifacts {
+		diffs, skipped, err := s.diffLogArtifacts(ctx, runID, baseline.RunID, opts)
+		if err != nil {
+			return nil, err
+		}
+		resp.Diffs = append(resp.Diffs, diffs...)
+		resp.Skipped = skipped
+	}
+
+	return resp, nil
+}
+
+// GetTestStackTrace parses the stack trace of a test result into frames
+// linked to the source at the run's commit.
+func (s *ResultServiceServer) GetTestStackTrace(ctx context.Context, req *conductorv1.GetTestStackTraceRequest) (*conductorv1.GetTestStackTraceResponse, error) {
+	runID, err := uuid.Parse(req.RunId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid run ID: %v", err)
+	}
+	if req.TestName == "" {
+		return nil, errcode.New(errcode.InvalidArgument, "test_name is required")
+	}
+
+	run, err := s.deps.RunRepo.GetByID(ctx, runID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.RunNotFound, "run not found: %s", req.RunId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get run: %v", err)
+	}
+
+	results, err := s.deps.ResultRepo.GetByRunID(ctx, runID)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to get results: %v", err)
+	}
+
+	testResult := findTestResult(results, req.TestName, req.SuiteName)
+	if testResult == nil {
+		return nil, errcode.New(errcode.NotFound, "test %q has no result in run %s", req.TestName, req.RunId)
+	}
+
+	// Without the service, frames are still resolved but carry no links
+	var service *database.Service
+	if s.deps.ServiceRepo != nil {
+		service, err = s.deps.ServiceRepo.GetByID(ctx, run.ServiceID)
+		if err != nil && !database.IsNotFound(err) {
+			return nil, errcode.New(errcode.Internal, "failed to get service: %v", err)
+		}
+	}
+
+	frames := git.NewSourceLinker(service, run).Link(*testResult)
+	protoFrames := make([]*conductorv1.StackFrame, len(frames))
+	for i, frame := range frames {
+		protoFrames[i] = &conductorv1.StackFrame{
+			Language: frame.Language,
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     int32(frame.Line),
+			Column:   int32(frame.Column),
+			Path:     frame.Path,
+			Url:      frame.URL,
+		}
+	}
+
+	return &conductorv1.GetTestStackTraceResponse{
+		ResultId:  testResult.ID.String(),
+		CommitSha: stringValue(run.GitSHA),
+		Frames:    protoFrames,
+	}, nil
+}
+
+// findTestResult returns the result of a test in a run, preferring a failed
+// or errored result when the test was reported more than once.
+func findTestResult(results []*database.TestResult, testName, suiteName string) *database.TestResult {
+	var found *database.TestResult
+	for _, r := range results {
+		if r.TestName == testName && (suiteName == "" || (r.SuiteName != nil && *r.SuiteName == suiteName)) {
+			found = r
+			if r.Status == database.ResultStatusFail || r.Status == database.ResultStatusError {
+				break
+			}
+		}
+	}
+	return found
+}
+
+// diffLogArtifacts diffs the text log artifacts that both runs stored under
+// the same name.
+func (s *ResultServiceServer) diffLogArtifacts(ctx context.Context, runID, baselineRunID uuid.UUID, opts result.DiffOptions) ([]*conductorv1.OutputDiff, []string, error) {
+	if s.deps.ArtifactReader == nil {
+		return nil, nil, errcode.New(errcode.NotConfigured, "artifact storage is not configured")
+	}
+
+	pagination := database.DefaultPagination()
+	pagination.Limit = 1000
+	failingArtifacts, _, err := s.deps.ArtifactRepo.ListByRunID(ctx, runID, pagination)
+	if err != nil {
+		return nil, nil, errcode.New(errcode.Internal, "failed to list artifacts: %v", err)
+	}
+	baselineArtifacts, _, err := s.deps.ArtifactRepo.ListByRunID(ctx, baselineRunID, pagination)
+	if err != nil {
+		return nil, nil, errcode.New(errcode.Internal, "failed to list artifacts: %v", err)
+	}
+
+	baselineByName := make(map[string]*database.Artifact, len(baselineArtifacts))
+	for _, a := range baselineArtifacts {
+		baselineByName[a.Name] = a
+	}
+
+	var diffs []*conductorv1.OutputDiff
+	var skipped []string
+	for _, a := range failingArtifacts {
+		if !isLogArtifact(a) {
+			continue
+		}
+		base, ok := baselineByName[a.Name]
+		if !ok {
+			skipped = append(skipped, fmt.Sprintf("%s: not stored by the passing run", a.Name))
+			continue
+		}
+		if len(diffs) == maxOutputDiffArtifacts {
+			skipped = append(skipped, fmt.Sprintf("%s: more than %d log artifacts", a.Name, maxOutputDiffArtifacts))
+			continue
+		}
+		if isArchived(a) || isArchived(base) {
+			skipped = append(skipped, fmt.Sprintf("%s: archived, restore it to diff", a.Name))
+			continue
+		}
+
+		failingContent, err := s.readArtifact(ctx, a, opts.MaxInputBytes)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", a.Name, err))
+			continue
+		}
+		baselineContent, err := s.readArtifact(ctx, base, opts.MaxInputBytes)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", a.Name, err))
+			continue
+		}
+		diffs = append(diffs, outputDiffToProto(result.DiffOutput(a.Name, baselineContent, failingContent, opts)))
+	}
+	return diffs, skipped, nil
+}
+
+// readArtifact reads up to one byte more than max so that diffs can report
+// truncation.
+func (s *ResultServiceServer) readArtifact(ctx context.Context, artifact *database.Artifact, max int) (string, error) {
+	reader, err := s.deps.ArtifactReader.Download(ctx, artifact.Path)
+	if err != nil {
+		s.logger.Warn().Err(err).
+			Str("artifact_id", artifact.ID.String()).
+			Msg("failed to read artifact for output diff")
+		return "", fmt.Errorf("failed to read artifact")
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(io.LimitReader(reader, int64(max)+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read artifact")
+	}
+	return string(content), nil
+}
+
+// isLogArtifact reports whether an artifact is a text log worth diffing.
+func isLogArtifact(artifact *database.Artifact) bool {
+	if artifact.ContentType != nil && strings.HasPrefix(*artifact.ContentType, "text/") {
+		return true
+	}
+	switch strings.ToLower(path.Ext(artifact.Name)) {
+	case ".log", ".txt", ".out":
+		return true
+	}
+	return false
+}
+
+// isArchived reports whether an artifact is in an archive tier and not restored.
+func isArchived(artifact *database.Artifact) bool {
+	if artifact.ArchivedAt == nil {
+		return false
+	}
+	return artifact.RestoredUntil == nil || artifact.RestoredUntil.Before(time.Now())
+}
+
 // Helper functions
 
+func outputDiffToProto(diff result.OutputDiff) *conductorv1.OutputDiff {
+	return &conductorv1.OutputDiff{
+		Name:      diff.Name,
+		Diff:      diff.Diff,
+		Identical: diff.Identical,
+		Truncated: diff.Truncated,
+	}
+}
+
 func testResultToProto(result *database.TestResult) *conductorv1.TestResult {
 	if result == nil {
 		return nil
@@ -315,14 +647,28 @@ func artifactToProto(artifact *database.Artifact) *conductorv1.Artifact {
 		Name:      artifact.Name,
 		Path:      artifact.Path,
 		CreatedAt: timestamppb.New(artifact.CreatedAt),
+
+		StorageClass: artifact.StorageClass,
+		Type:         artifactTypeToProto(artifact.Type),
+		Checksum:     stringValue(artifact.Checksum),
 	}
 
 	if artifact.ContentType != nil {
 		protoArtifact.ContentType = *artifact.ContentType
 	}
+	if artifact.TestDefinitionID != nil {
+		protoArtifact.TestId = artifact.TestDefinitionID.String()
+	}
 	if artifact.SizeBytes != nil {
 		protoArtifact.SizeBytes = *artifact.SizeBytes
 	}
 
 	return protoArtifact
 }
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -2,16 +2,24 @@ package server
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
 	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/energy"
+	"github.com/conductor/conductor/internal/idempotency"
+	"github.com/conductor/conductor/internal/preflight"
+	"github.com/conductor/conductor/pkg/errcode"
+	"github.com/conductor/conductor/pkg/tracing"
 )
 
 // RunServiceDeps defines the dependencies for the run service.
@@ -20,10 +28,67 @@ type RunServiceDeps struct {
 	RunRepo RunRepository
 	// RunShardRepo handles shard persistence.
 	RunShardRepo RunShardRepository
+	// AnnotationRepo provides the warnings agents attached to runs (optional).
+	AnnotationRepo database.RunAnnotationRepository
+	// RegressionRepo provides the duration regressions detected for runs (optional).
+	RegressionRepo database.DurationRegressionRepository
 	// ServiceRepo handles service persistence.
 	ServiceRepo ServiceRepository
 	// Scheduler handles work scheduling.
 	Scheduler WorkScheduler
+	// Throttle limits how often runs are created per service and branch (optional).
+	Throttle *TriggerThrottle
+	// EnergyRepo provides the estimated energy of runs (optional).
+	EnergyRepo RunEnergyRepository
+	// CarbonIntensity converts estimated energy to carbon emissions.
+	CarbonIntensity energy.CarbonIntensity
+	// AgentRepo lists the registered agents label selectors are validated
+	// against (optional).
+	AgentRepo AgentRepository
+	// TestRepo provides the test definition label selectors runs combine
+	// with their own (optional).
+	TestRepo TestDefinitionRepository
+	// Preflight verifies the commit, images and secrets of new runs before
+	// they are queued (optional).
+	Preflight RunPreflight
+	// EnvironmentRepo provides the service secrets checked before runs are
+	// queued (optional).
+	EnvironmentRepo database.ServiceEnvironmentRepository
+	// SearchRepo searches runs (optional).
+	SearchRepo RunSearchRepository
+	// ResultSearchRepo finds the test results matching a run search
+	// (optional).
+	ResultSearchRepo ResultSearchRepository
+	// Idempotency answers retried run creation requests with the run they
+	// created (optional).
+	Idempotency *idempotency.Store
+	// BranchRuns applies the branch run policies of services to new runs
+	// (optional).
+	BranchRuns BranchRunRepository
+	// ConcurrencyRepo provides the concurrency groups of services
+	// (optional).
+	ConcurrencyRepo database.ConcurrencyGroupRepository
+	// BranchRepo finds the protected branches whose runs wait for approval
+	// (optional).
+	BranchRepo ProtectedBranchRepository
+	// Approvals releases runs waiting for approval (optional).
+	Approvals RunApprovalRepository
+	// Metadata attaches annotations and links to existing runs (optional).
+	Metadata RunMetadataRepository
+	// QuotaRepo reads the run quotas that new runs are checked against
+	// (optional).
+	QuotaRepo RunQuotaRepository
+}
+
+// RunPreflight verifies that a run can start before it is queued.
+type RunPreflight interface {
+	Check(ctx context.Context, target preflight.Target) []preflight.Problem
+}
+
+// RunEnergyRepository defines the interface for run energy estimates.
+type RunEnergyRepository interface {
+	GetRunEnergy(ctx context.Context, runID uuid.UUID) ([]database.ZoneEnergy, error)
+	GetMonthlyEnergy(ctx context.Context, serviceID *uuid.UUID, start, end time.Time) ([]database.MonthlyEnergy, error)
 }
 
 // RunRepository defines the interface for run persistence.
@@ -55,6 +120,8 @@ type RunFilter struct {
 	Labels      map[string]string
 	StartTime   *time.Time
 	EndTime     *time.Time
+	// AgentID selects runs the agent executed, or executed a shard of.
+	AgentID *uuid.UUID
 }
 
 // ServiceRepository defines the interface for service persistence.
@@ -91,60 +158,253 @@ func NewRunServiceServer(deps RunServiceDeps, logger zerolog.Logger) *RunService
 func (s *RunServiceServer) CreateRun(ctx context.Context, req *conductorv1.CreateRunRequest) (*conductorv1.CreateRunResponse, error) {
 	serviceID, err := uuid.Parse(req.ServiceId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid service ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	key := req.GetIdempotencyKey()
+	if len(key) > idempotency.MaxKeyLength {
+		return nil, errcode.NewInvalidFields(errcode.FieldViolation{
+			Field:       "idempotency_key",
+			Description: fmt.Sprintf("must be at most %d characters", idempotency.MaxKeyLength),
+		})
+	}
+	if err := validateRunMetadata(database.RunMetadata{Annotations: req.Annotations, Links: req.Links}, false); err != nil {
+		return nil, err
 	}
 
 	// Verify service exists
 	service, err := s.deps.ServiceRepo.GetByID(ctx, serviceID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "service not found: %s", req.ServiceId)
+			return nil, errcode.New(errcode.ServiceNotFound, "service not found: %s", req.ServiceId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get service: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get service: %v", err)
+	}
+
+	// Retries of a request with an idempotency key are answered with the
+	// run the first one created
+	run, replayed, err := idempotency.Do(ctx, s.deps.Idempotency, createRunScope(ctx), key, createRunFingerprint(req),
+		func() (*database.TestRun, error) {
+			return s.createRun(ctx, service, req)
+		})
+	if err != nil {
+		return nil, idempotencyError(key, err)
+	}
+	if replayed {
+		tracing.AddSpanAttributes(ctx, tracing.AttrRunID.String(run.ID.String()))
+		s.logger.Info().
+			Str("run_id", run.ID.String()).
+			Str("service_id", serviceID.String()).
+			Str("idempotency_key", key).
+			Msg("run creation replayed")
+	}
+
+	return &conductorv1.CreateRunResponse{
+		Run: runToProto(run, service),
+	}, nil
+}
+
+// createRun creates a run of an existing service.
+func (s *RunServiceServer) createRun(ctx context.Context, service *database.Service, req *conductorv1.CreateRunRequest) (*database.TestRun, error) {
+	if err := requireActive(service); err != nil {
+		return nil, err
+	}
+
+	if s.deps.Throttle != nil {
+		key := throttleKey(service.ID, req.GetGitRef().GetBranch(), int(req.GetGitRef().GetPullRequestNumber()))
+		if wait, ok := s.deps.Throttle.Allow(key); !ok {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			return nil, errcode.NewWithMetadata(errcode.RunThrottled,
+				map[string]string{"retry_after_seconds": strconv.Itoa(retryAfter)},
+				"runs for %s are throttled; retry in %ds", service.Name, retryAfter)
+		}
+	}
+
+	tests, err := s.listRunTests(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkRunParameters(tests, req.Parameters); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkRunPlacement(ctx, service, tests, req.LabelSelector); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkRunPreflight(ctx, service, tests, req.GetGitRef()); err != nil {
+		return nil, err
+	}
+
+	coalesced, err := s.coalesceRun(ctx, service, req)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to coalesce run: %v", err)
+	}
+	if coalesced != nil {
+		return coalesced, nil
+	}
+
+	// Coalesced runs are not new runs, so they pass regardless of the quota
+	if err := s.checkRunQuota(ctx, service); err != nil {
+		return nil, err
+	}
+
+	group, cancelInProgress, err := s.runConcurrencyGroup(ctx, service, req.GetGitRef().GetBranch())
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to create run: %v", err)
+	}
+
+	approvalReason, err := s.runApprovalReason(ctx, service, req.GetGitRef().GetBranch(), tests)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to create run: %v", err)
 	}
 
 	// Create the run
 	run := &database.TestRun{
-		ID:          uuid.New(),
-		ServiceID:   serviceID,
-		Status:      database.RunStatusPending,
-		GitRef:      database.NullString(req.GetGitRef().GetBranch()),
-		GitSHA:      database.NullString(req.GetGitRef().GetCommitSha()),
-		TriggerType: triggerTypeFromProto(req.GetTrigger().GetType()),
-		TriggeredBy: database.NullString(req.GetTrigger().GetUser()),
-		Priority:    int(req.Priority),
-		CreatedAt:   time.Now(),
+		ID:                uuid.New(),
+		ServiceID:         service.ID,
+		Status:            initialRunStatus(approvalReason),
+		GitRef:            database.NullString(req.GetGitRef().GetBranch()),
+		GitSHA:            database.NullString(req.GetGitRef().GetCommitSha()),
+		TriggerType:       triggerTypeFromProto(req.GetTrigger().GetType()),
+		TriggeredBy:       database.NullString(req.GetTrigger().GetUser()),
+		Priority:          int(req.Priority),
+		CreatedAt:         time.Now(),
+		PullRequestNumber: database.NullInt64(req.GetGitRef().GetPullRequestNumber()),
+		LabelSelector:     req.LabelSelector,
+		TraceParent:       database.NullString(tracing.TraceParent(ctx)),
+		ConcurrencyGroup:  group,
+		ApprovalReason:    approvalReason,
+		Metadata:          database.RunMetadata{Annotations: req.Annotations, Links: req.Links},
+		Parameters:        req.Parameters,
 	}
 
 	if err := s.deps.RunRepo.Create(ctx, run); err != nil {
-		s.logger.Error().Err(err).Str("service_id", serviceID.String()).Msg("failed to create run")
-		return nil, status.Errorf(codes.Internal, "failed to create run: %v", err)
+		s.logger.Error().Err(err).Str("service_id", service.ID.String()).Msg("failed to create run")
+		return nil, errcode.New(errcode.Internal, "failed to create run: %v", err)
 	}
+	tracing.AddSpanAttributes(ctx, tracing.AttrRunID.String(run.ID.String()))
 
 	s.logger.Info().
 		Str("run_id", run.ID.String()).
-		Str("service_id", serviceID.String()).
+		Str("service_id", service.ID.String()).
 		Str("service_name", service.Name).
 		Msg("run created")
 
-	return &conductorv1.CreateRunResponse{
-		Run: runToProto(run, service),
-	}, nil
+	s.supersedeRuns(ctx, service, run, req.GetGitRef())
+	if cancelInProgress {
+		s.cancelConcurrencyGroup(ctx, run)
+	}
+
+	return run, nil
+}
+
+// listRunTests returns the test definitions a new run of a service
+// executes, or nil without a test repository.
+func (s *RunServiceServer) listRunTests(ctx context.Context, service *database.Service) ([]*database.TestDefinition, error) {
+	if s.deps.TestRepo == nil {
+		return nil, nil
+	}
+
+	tests, _, err := s.deps.TestRepo.ListByService(ctx, service.ID, TestDefinitionFilter{}, database.Pagination{Limit: 1000})
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list tests: %v", err)
+	}
+	return tests, nil
+}
+
+// checkRunPlacement verifies that every test of a service can be placed on
+// a registered agent, on each of its architectures, under the run's label
+// selector.
+func (s *RunServiceServer) checkRunPlacement(ctx context.Context, service *database.Service, tests []*database.TestDefinition, selector map[string]string) error {
+	checked := false
+	for _, test := range tests {
+		cells := test.Matrix.Cells()
+		if len(test.LabelSelector) == 0 && len(test.Architectures) == 0 && len(cells) == 0 {
+			continue
+		}
+		if err := checkTestPlacement(ctx, s.deps.AgentRepo, test.Name, service.NetworkZones, test.Architectures, cells, selector, test.LabelSelector); err != nil {
+			return err
+		}
+		checked = true
+	}
+	if checked {
+		return nil
+	}
+	return checkPlacement(ctx, s.deps.AgentRepo, "run", service.NetworkZones, selector)
+}
+
+// checkRunPreflight runs the pre-flight checks of a new run and rejects it
+// with every problem found.
+func (s *RunServiceServer) checkRunPreflight(ctx context.Context, service *database.Service, tests []*database.TestDefinition, ref *conductorv1.GitRef) error {
+	if s.deps.Preflight == nil {
+		return nil
+	}
+
+	target := preflight.Target{
+		Service: service,
+		Ref:     preflightRef(service, ref),
+		Tests:   tests,
+	}
+	if s.deps.EnvironmentRepo != nil {
+		env, err := s.deps.EnvironmentRepo.GetByService(ctx, service.ID)
+		if err != nil && !database.IsNotFound(err) {
+			return errcode.New(errcode.Internal, "failed to get service environment: %v", err)
+		}
+		target.Environment = env
+	}
+
+	problems := s.deps.Preflight.Check(ctx, target)
+	if len(problems) == 0 {
+		return nil
+	}
+
+	checks := make([]string, 0, len(problems))
+	messages := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		checks = append(checks, problem.Check)
+		messages = append(messages, problem.String())
+	}
+	s.logger.Info().
+		Str("service_id", service.ID.String()).
+		Strs("problems", messages).
+		Msg("run rejected by pre-flight checks")
+
+	return errcode.NewWithMetadata(errcode.PreflightFailed,
+		map[string]string{"checks": strings.Join(checks, ",")},
+		"pre-flight checks failed for %s: %s", service.Name, strings.Join(messages, "; "))
+}
+
+// preflightRef returns the ref a new run checks out: its commit, branch, or
+// the service's default branch. Pull request runs are only checked by
+// commit, since their branch may live in a fork.
+func preflightRef(service *database.Service, ref *conductorv1.GitRef) string {
+	switch {
+	case ref.GetCommitSha() != "":
+		return ref.GetCommitSha()
+	case ref.GetPullRequestNumber() != 0:
+		return ""
+	case ref.GetBranch() != "":
+		return ref.GetBranch()
+	default:
+		return service.DefaultBranch
+	}
 }
 
 // GetRun retrieves details of a specific test run.
 func (s *RunServiceServer) GetRun(ctx context.Context, req *conductorv1.GetRunRequest) (*conductorv1.GetRunResponse, error) {
 	runID, err := uuid.Parse(req.RunId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid run ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid run ID: %v", err)
 	}
 
 	run, err := s.deps.RunRepo.GetByID(ctx, runID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "run not found: %s", req.RunId)
+			return nil, errcode.New(errcode.RunNotFound, "run not found: %s", req.RunId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get run: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get run: %v", err)
 	}
 
 	service, err := s.deps.ServiceRepo.GetByID(ctx, run.ServiceID)
@@ -157,12 +417,33 @@ func (s *RunServiceServer) GetRun(ctx context.Context, req *conductorv1.GetRunRe
 		Run: runToProto(run, service),
 	}
 
-	if req.IncludeShards && s.deps.RunShardRepo != nil {
+	if s.deps.RunShardRepo != nil {
 		shards, err := s.deps.RunShardRepo.ListByRun(ctx, runID)
 		if err != nil {
-			return nil, status.Errorf(codes.Internal, "failed to list shards: %v", err)
+			return nil, errcode.New(errcode.Internal, "failed to list shards: %v", err)
+		}
+		if req.IncludeShards {
+			resp.Shards = runShardsToProto(shards)
 		}
-		resp.Shards = runShardsToProto(shards)
+		resp.ArchResults = runArchResults(shards)
+		resp.MatrixResults = runMatrixResults(shards)
+		resp.StageResults = runStageResults(shards)
+	}
+
+	if s.deps.AnnotationRepo != nil {
+		annotations, err := s.deps.AnnotationRepo.ListByRun(ctx, runID)
+		if err != nil {
+			return nil, errcode.New(errcode.Internal, "failed to list annotations: %v", err)
+		}
+		resp.Annotations = runAnnotationsToProto(annotations)
+	}
+
+	if s.deps.RegressionRepo != nil {
+		regressions, err := s.deps.RegressionRepo.ListByRun(ctx, runID)
+		if err != nil {
+			return nil, errcode.New(errcode.Internal, "failed to list duration regressions: %v", err)
+		}
+		resp.DurationRegressions = durationRegressionsToProto(regressions)
 	}
 
 	// TODO: Include results and artifacts if requested
@@ -181,7 +462,7 @@ func (s *RunServiceServer) ListRuns(ctx context.Context, req *conductorv1.ListRu
 	if req.ServiceId != "" {
 		serviceID, err := uuid.Parse(req.ServiceId)
 		if err != nil {
-			return nil, status.Errorf(codes.InvalidArgument, "invalid service ID: %v", err)
+			return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
 		}
 		filter.ServiceID = &serviceID
 	}
@@ -210,10 +491,11 @@ func (s *RunServiceServer) ListRuns(ctx context.Context, req *conductorv1.ListRu
 	}
 
 	pagination := paginationFromProto(req.Pagination)
-	runs, total, err := s.deps.RunRepo.List(ctx, filter, pagination)
+	runs, total, err := s.deps.RunRepo.List(ctx, filter, peekPagination(pagination))
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to list runs: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to list runs: %v", err)
 	}
+	runs, page := keysetPage(pagination, runs, total, runCursor)
 
 	// Get services for runs
 	serviceIDs := make(map[uuid.UUID]bool)
@@ -236,7 +518,57 @@ func (s *RunServiceServer) ListRuns(ctx context.Context, req *conductorv1.ListRu
 
 	return &conductorv1.ListRunsResponse{
 		Runs:       protoRuns,
-		Pagination: paginationResponseToProto(pagination, total),
+		Pagination: page,
+	}, nil
+}
+
+// defaultAgentRunsWindow is how far back ListRunsByAgent looks without a
+// time range.
+const defaultAgentRunsWindow = 30 * 24 * time.Hour
+
+// ListRunsByAgent returns the runs an agent executed in a time range. Runs
+// keep the agent's ID, name and zones after the agent is deleted, so
+// deleted agents can still be audited.
+func (s *RunServiceServer) ListRunsByAgent(ctx context.Context, req *conductorv1.ListRunsByAgentRequest) (*conductorv1.ListRunsByAgentResponse, error) {
+	agentID, err := uuid.Parse(req.AgentId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid agent ID: %v", err)
+	}
+
+	end := time.Now()
+	if req.TimeRange != nil && req.TimeRange.End != nil {
+		end = req.TimeRange.End.AsTime()
+	}
+	start := end.Add(-defaultAgentRunsWindow)
+	if req.TimeRange != nil && req.TimeRange.Start != nil {
+		start = req.TimeRange.Start.AsTime()
+	}
+	if !start.Before(end) {
+		return nil, errcode.New(errcode.InvalidArgument, "time range start must be before its end")
+	}
+
+	filter := RunFilter{AgentID: &agentID, StartTime: &start, EndTime: &end}
+	pagination := paginationFromProto(req.Pagination)
+	runs, total, err := s.deps.RunRepo.List(ctx, filter, peekPagination(pagination))
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list runs: %v", err)
+	}
+	runs, page := keysetPage(pagination, runs, total, runCursor)
+
+	services := make(map[uuid.UUID]*database.Service)
+	protoRuns := make([]*conductorv1.Run, len(runs))
+	for i, run := range runs {
+		svc, ok := services[run.ServiceID]
+		if !ok {
+			svc, _ = s.deps.ServiceRepo.GetByID(ctx, run.ServiceID)
+			services[run.ServiceID] = svc
+		}
+		protoRuns[i] = runToProto(run, svc)
+	}
+
+	return &conductorv1.ListRunsByAgentResponse{
+		Runs:       protoRuns,
+		Pagination: page,
 	}, nil
 }
 
@@ -244,7 +576,7 @@ func (s *RunServiceServer) ListRuns(ctx context.Context, req *conductorv1.ListRu
 func (s *RunServiceServer) CancelRun(ctx context.Context, req *conductorv1.CancelRunRequest) (*conductorv1.CancelRunResponse, error) {
 	runID, err := uuid.Parse(req.RunId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid run ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid run ID: %v", err)
 	}
 
 	if req.ShardId != "" {
@@ -254,13 +586,13 @@ func (s *RunServiceServer) CancelRun(ctx context.Context, req *conductorv1.Cance
 	run, err := s.deps.RunRepo.GetByID(ctx, runID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "run not found: %s", req.RunId)
+			return nil, errcode.New(errcode.RunNotFound, "run not found: %s", req.RunId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get run: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get run: %v", err)
 	}
 
 	if run.IsTerminal() {
-		return nil, status.Errorf(codes.FailedPrecondition, "run is already in terminal state: %s", run.Status)
+		return nil, errcode.New(errcode.RunTerminal, "run is already in terminal state: %s", run.Status)
 	}
 
 	// Cancel via scheduler (handles agent notification)
@@ -271,7 +603,7 @@ func (s *RunServiceServer) CancelRun(ctx context.Context, req *conductorv1.Cance
 	// Update run status
 	reason := req.Reason
 	if err := s.deps.RunRepo.UpdateStatus(ctx, runID, database.RunStatusCancelled, &reason); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to update run status: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to update run status: %v", err)
 	}
 
 	// Fetch updated run
@@ -292,7 +624,7 @@ func (s *RunServiceServer) CancelRun(ctx context.Context, req *conductorv1.Cance
 func (s *RunServiceServer) RetryRun(ctx context.Context, req *conductorv1.RetryRunRequest) (*conductorv1.RetryRunResponse, error) {
 	originalRunID, err := uuid.Parse(req.RunId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid run ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid run ID: %v", err)
 	}
 
 	if req.ShardId != "" {
@@ -302,29 +634,54 @@ func (s *RunServiceServer) RetryRun(ctx context.Context, req *conductorv1.RetryR
 	originalRun, err := s.deps.RunRepo.GetByID(ctx, originalRunID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "run not found: %s", req.RunId)
+			return nil, errcode.New(errcode.RunNotFound, "run not found: %s", req.RunId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get run: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get run: %v", err)
+	}
+
+	service, err := s.deps.ServiceRepo.GetByID(ctx, originalRun.ServiceID)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to get service: %v", err)
+	}
+
+	if err := s.checkRunQuota(ctx, service); err != nil {
+		return nil, err
+	}
+
+	// Retries wait for approval like any other run of the branch
+	tests, err := s.listRunTests(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+	approvalReason, err := s.runApprovalReason(ctx, service, stringValue(originalRun.GitRef), tests)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to create retry run: %v", err)
 	}
 
 	// Create new run based on original
 	triggerRetry := database.TriggerTypeManual // Default to manual for retries
 	newRun := &database.TestRun{
-		ID:          uuid.New(),
-		ServiceID:   originalRun.ServiceID,
-		Status:      database.RunStatusPending,
-		GitRef:      originalRun.GitRef,
-		GitSHA:      originalRun.GitSHA,
-		TriggerType: &triggerRetry,
-		Priority:    originalRun.Priority,
-		CreatedAt:   time.Now(),
+		ID:                uuid.New(),
+		ServiceID:         originalRun.ServiceID,
+		Status:            initialRunStatus(approvalReason),
+		GitRef:            originalRun.GitRef,
+		GitSHA:            originalRun.GitSHA,
+		TriggerType:       &triggerRetry,
+		Priority:          originalRun.Priority,
+		CreatedAt:         time.Now(),
+		PullRequestNumber: originalRun.PullRequestNumber,
+		LabelSelector:     originalRun.LabelSelector,
+		TraceParent:       database.NullString(tracing.TraceParent(ctx)),
+		ConcurrencyGroup:  originalRun.ConcurrencyGroup,
+		ApprovalReason:    approvalReason,
+		Metadata:          originalRun.Metadata,
+		Parameters:        originalRun.Parameters,
 	}
 
 	if err := s.deps.RunRepo.Create(ctx, newRun); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create retry run: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to create retry run: %v", err)
 	}
-
-	service, _ := s.deps.ServiceRepo.GetByID(ctx, newRun.ServiceID)
+	tracing.AddSpanAttributes(ctx, tracing.AttrRunID.String(newRun.ID.String()))
 
 	s.logger.Info().
 		Str("run_id", newRun.ID.String()).
@@ -340,13 +697,115 @@ func (s *RunServiceServer) RetryRun(ctx context.Context, req *conductorv1.RetryR
 // StreamRunLogs streams live logs from a running test.
 func (s *RunServiceServer) StreamRunLogs(req *conductorv1.StreamRunLogsRequest, stream conductorv1.RunService_StreamRunLogsServer) error {
 	// TODO: Implement log streaming
-	return status.Error(codes.Unimplemented, "log streaming not yet implemented")
+	return errcode.New(errcode.Unimplemented, "log streaming not yet implemented")
 }
 
 // GetRunLogs retrieves stored logs for a completed run.
 func (s *RunServiceServer) GetRunLogs(ctx context.Context, req *conductorv1.GetRunLogsRequest) (*conductorv1.GetRunLogsResponse, error) {
 	// TODO: Implement log retrieval
-	return nil, status.Error(codes.Unimplemented, "log retrieval not yet implemented")
+	return nil, errcode.New(errcode.Unimplemented, "log retrieval not yet implemented")
+}
+
+// GetRunEnergy estimates the energy and carbon emissions of a run.
+func (s *RunServiceServer) GetRunEnergy(ctx context.Context, req *conductorv1.GetRunEnergyRequest) (*conductorv1.GetRunEnergyResponse, error) {
+	if s.deps.EnergyRepo == nil {
+		return nil, errcode.New(errcode.NotConfigured, "energy estimation is not configured")
+	}
+
+	runID, err := uuid.Parse(req.RunId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid run ID: %v", err)
+	}
+
+	if _, err := s.deps.RunRepo.GetByID(ctx, runID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.RunNotFound, "run not found: %s", req.RunId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get run: %v", err)
+	}
+
+	zones, err := s.deps.EnergyRepo.GetRunEnergy(ctx, runID)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to get run energy: %v", err)
+	}
+
+	resp := &conductorv1.GetRunEnergyResponse{RunId: req.RunId}
+	for _, zone := range zones {
+		z := s.zoneEnergyToProto(zone.Zone, zone.EnergyWh)
+		resp.Zones = append(resp.Zones, z)
+		resp.EnergyWh += z.EnergyWh
+		resp.CarbonGrams += z.CarbonGrams
+	}
+	return resp, nil
+}
+
+// defaultEnergyStatsRange is the period energy stats cover without a time range.
+const defaultEnergyStatsRange = 12 * 30 * 24 * time.Hour
+
+// GetEnergyStats aggregates the estimated energy and carbon emissions of
+// runs per service and month.
+func (s *RunServiceServer) GetEnergyStats(ctx context.Context, req *conductorv1.GetEnergyStatsRequest) (*conductorv1.GetEnergyStatsResponse, error) {
+	if s.deps.EnergyRepo == nil {
+		return nil, errcode.New(errcode.NotConfigured, "energy estimation is not configured")
+	}
+
+	var serviceID *uuid.UUID
+	if req.ServiceId != "" {
+		id, err := uuid.Parse(req.ServiceId)
+		if err != nil {
+			return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+		}
+		serviceID = &id
+	}
+
+	end := time.Now()
+	if req.GetTimeRange().GetEnd() != nil {
+		end = req.TimeRange.End.AsTime()
+	}
+	start := end.Add(-defaultEnergyStatsRange)
+	if req.GetTimeRange().GetStart() != nil {
+		start = req.TimeRange.Start.AsTime()
+	}
+	if !start.Before(end) {
+		return nil, errcode.New(errcode.InvalidArgument, "time range start must be before its end")
+	}
+
+	rows, err := s.deps.EnergyRepo.GetMonthlyEnergy(ctx, serviceID, start, end)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to get energy stats: %v", err)
+	}
+
+	resp := &conductorv1.GetEnergyStatsResponse{}
+	var month *conductorv1.MonthlyEnergy
+	for _, row := range rows {
+		// Rows are ordered by month and service, one per zone.
+		if month == nil || month.ServiceId != row.ServiceID.String() || !month.Month.AsTime().Equal(row.Month) {
+			month = &conductorv1.MonthlyEnergy{
+				ServiceId:   row.ServiceID.String(),
+				ServiceName: row.ServiceName,
+				Month:       timestamppb.New(row.Month),
+				RunCount:    int32(row.RunCount),
+			}
+			resp.Months = append(resp.Months, month)
+		}
+
+		z := s.zoneEnergyToProto(row.Zone, row.EnergyWh)
+		month.Zones = append(month.Zones, z)
+		month.EnergyWh += z.EnergyWh
+		month.CarbonGrams += z.CarbonGrams
+		resp.TotalEnergyWh += z.EnergyWh
+		resp.TotalCarbonGrams += z.CarbonGrams
+	}
+	return resp, nil
+}
+
+func (s *RunServiceServer) zoneEnergyToProto(zone string, energyWh float64) *conductorv1.ZoneEnergy {
+	return &conductorv1.ZoneEnergy{
+		Zone:            zone,
+		EnergyWh:        energyWh,
+		CarbonIntensity: s.deps.CarbonIntensity.ForZone(zone),
+		CarbonGrams:     s.deps.CarbonIntensity.CarbonGrams(zone, energyWh),
+	}
 }
 
 // Helper functions for type conversion
@@ -357,11 +816,16 @@ func runToProto(run *database.TestRun, service *database.Service) *conductorv1.R
 	}
 
 	protoRun := &conductorv1.Run{
-		Id:        run.ID.String(),
-		ServiceId: run.ServiceID.String(),
-		Status:    runStatusToProto(run.Status),
-		Priority:  int32(run.Priority),
-		CreatedAt: timestamppb.New(run.CreatedAt),
+		Id:                run.ID.String(),
+		ServiceId:         run.ServiceID.String(),
+		Status:            runStatusToProto(run.Status),
+		Priority:          int32(run.Priority),
+		CreatedAt:         timestamppb.New(run.CreatedAt),
+		LabelSelector:     run.LabelSelector,
+		HasMediaArtifacts: run.HasMediaArtifacts,
+		Annotations:       run.Metadata.Annotations,
+		Links:             run.Metadata.Links,
+		Parameters:        run.Parameters,
 	}
 
 	if service != nil {
@@ -376,6 +840,9 @@ func runToProto(run *database.TestRun, service *database.Service) *conductorv1.R
 		if run.GitSHA != nil {
 			protoRun.GitRef.CommitSha = *run.GitSHA
 		}
+		if run.PullRequestNumber != nil {
+			protoRun.GitRef.PullRequestNumber = *run.PullRequestNumber
+		}
 	}
 
 	if run.TriggerType != nil {
@@ -390,6 +857,22 @@ func runToProto(run *database.TestRun, service *database.Service) *conductorv1.R
 	if run.AgentID != nil {
 		protoRun.AgentId = run.AgentID.String()
 	}
+	if run.AgentName != nil {
+		protoRun.AgentName = *run.AgentName
+	}
+	protoRun.AgentNetworkZones = run.AgentNetworkZones
+	if run.ConcurrencyGroup != nil {
+		protoRun.ConcurrencyGroup = *run.ConcurrencyGroup
+	}
+	if run.ApprovalReason != nil {
+		protoRun.ApprovalReason = *run.ApprovalReason
+	}
+	if run.ApprovedBy != nil {
+		protoRun.ApprovedBy = *run.ApprovedBy
+	}
+	if run.ApprovedAt != nil {
+		protoRun.ApprovedAt = timestamppb.New(*run.ApprovedAt)
+	}
 
 	if run.StartedAt != nil {
 		protoRun.StartedAt = timestamppb.New(*run.StartedAt)
@@ -414,6 +897,19 @@ func runToProto(run *database.TestRun, service *database.Service) *conductorv1.R
 	protoRun.ShardsCompleted = int32(run.ShardsDone)
 	protoRun.ShardsFailed = int32(run.ShardsFailed)
 	protoRun.MaxParallelTests = int32(run.MaxParallel)
+	protoRun.ResultsDropped = run.ResultsDropped
+	protoRun.ArtifactsDropped = int32(run.ArtifactsDropped)
+
+	if run.Attempt > 0 {
+		protoRun.Attempt = int32(run.Attempt)
+		protoRun.RetryCount = int32(run.Attempt - 1)
+	}
+	if run.RetryOfRunID != nil {
+		protoRun.RetryOfRunId = run.RetryOfRunID.String()
+	}
+	if run.NotBefore != nil {
+		protoRun.NotBefore = timestamppb.New(*run.NotBefore)
+	}
 
 	return protoRun
 }
@@ -434,6 +930,8 @@ func runStatusToProto(status database.RunStatus) conductorv1.RunStatus {
 		return conductorv1.RunStatus_RUN_STATUS_TIMEOUT
 	case database.RunStatusCancelled:
 		return conductorv1.RunStatus_RUN_STATUS_CANCELLED
+	case database.RunStatusWaitingApproval:
+		return conductorv1.RunStatus_RUN_STATUS_WAITING_APPROVAL
 	default:
 		return conductorv1.RunStatus_RUN_STATUS_UNSPECIFIED
 	}
@@ -455,6 +953,8 @@ func runStatusFromProto(status conductorv1.RunStatus) database.RunStatus {
 		return database.RunStatusTimeout
 	case conductorv1.RunStatus_RUN_STATUS_CANCELLED:
 		return database.RunStatusCancelled
+	case conductorv1.RunStatus_RUN_STATUS_WAITING_APPROVAL:
+		return database.RunStatusWaitingApproval
 	default:
 		return database.RunStatusPending
 	}
@@ -462,17 +962,17 @@ func runStatusFromProto(status conductorv1.RunStatus) database.RunStatus {
 
 func (s *RunServiceServer) cancelShard(ctx context.Context, runID uuid.UUID, shardID string, reason string) (*conductorv1.CancelRunResponse, error) {
 	if s.deps.RunShardRepo == nil {
-		return nil, status.Error(codes.FailedPrecondition, "shard repository not configured")
+		return nil, errcode.New(errcode.NotConfigured, "shard repository not configured")
 	}
 
 	parsed, err := uuid.Parse(shardID)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid shard ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid shard ID: %v", err)
 	}
 
 	results := database.RunResults{ErrorMessage: reason}
 	if err := s.deps.RunShardRepo.Finish(ctx, parsed, database.ShardStatusCancelled, results); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to cancel shard: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to cancel shard: %v", err)
 	}
 
 	if err := s.updateRunFromShards(ctx, runID); err != nil {
@@ -487,16 +987,16 @@ func (s *RunServiceServer) cancelShard(ctx context.Context, runID uuid.UUID, sha
 
 func (s *RunServiceServer) retryShard(ctx context.Context, runID uuid.UUID, shardID string) (*conductorv1.RetryRunResponse, error) {
 	if s.deps.RunShardRepo == nil {
-		return nil, status.Error(codes.FailedPrecondition, "shard repository not configured")
+		return nil, errcode.New(errcode.NotConfigured, "shard repository not configured")
 	}
 
 	parsed, err := uuid.Parse(shardID)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid shard ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid shard ID: %v", err)
 	}
 
 	if err := s.deps.RunShardRepo.Reset(ctx, parsed); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to reset shard: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to reset shard: %v", err)
 	}
 
 	run, _ := s.deps.RunRepo.GetByID(ctx, runID)
@@ -515,18 +1015,31 @@ func (s *RunServiceServer) updateRunFromShards(ctx context.Context, runID uuid.U
 
 	shards, err := s.deps.RunShardRepo.ListByRun(ctx, runID)
 	if err != nil {
-		return status.Errorf(codes.Internal, "failed to list shards: %v", err)
+		return errcode.New(errcode.Internal, "failed to list shards: %v", err)
+	}
+
+	// Later stages do not start once a shard of an earlier stage is cancelled
+	if blocked := database.BlockedShards(shards); len(blocked) > 0 {
+		results := database.RunResults{ErrorMessage: "skipped after an earlier stage failed"}
+		for _, shard := range blocked {
+			if err := s.deps.RunShardRepo.Finish(ctx, shard.ID, database.ShardStatusCancelled, results); err != nil {
+				return errcode.New(errcode.Internal, "failed to skip shard: %v", err)
+			}
+		}
+		if shards, err = s.deps.RunShardRepo.ListByRun(ctx, runID); err != nil {
+			return errcode.New(errcode.Internal, "failed to list shards: %v", err)
+		}
 	}
 
 	completed, failed, results, finished := aggregateShardResults(shards)
 	if err := s.deps.RunRepo.UpdateShardStats(ctx, runID, completed, failed, results); err != nil {
-		return status.Errorf(codes.Internal, "failed to update shard stats: %v", err)
+		return errcode.New(errcode.Internal, "failed to update shard stats: %v", err)
 	}
 
 	if finished {
 		statusVal := runStatusFromShardStatus(shards)
 		if err := s.deps.RunRepo.Finish(ctx, runID, statusVal, results); err != nil {
-			return status.Errorf(codes.Internal, "failed to finish run: %v", err)
+			return errcode.New(errcode.Internal, "failed to finish run: %v", err)
 		}
 	}
 
@@ -559,6 +1072,10 @@ func runShardToProto(shard database.RunShard) *conductorv1.RunShard {
 	if shard.AgentID != nil {
 		protoShard.AgentId = shard.AgentID.String()
 	}
+	if shard.AgentName != nil {
+		protoShard.AgentName = *shard.AgentName
+	}
+	protoShard.AgentNetworkZones = shard.AgentNetworkZones
 	if shard.ErrorMessage != nil {
 		protoShard.ErrorMessage = *shard.ErrorMessage
 	}
@@ -568,10 +1085,192 @@ func runShardToProto(shard database.RunShard) *conductorv1.RunShard {
 	if shard.FinishedAt != nil {
 		protoShard.FinishedAt = timestamppb.New(*shard.FinishedAt)
 	}
+	if shard.Arch != nil {
+		protoShard.Arch = *shard.Arch
+	}
+	if shard.Matrix != nil {
+		protoShard.MatrixEnv = shard.Matrix.Env
+		protoShard.MatrixLabels = shard.Matrix.Labels
+	}
+	protoShard.Stage = int32(shard.Stage)
 
 	return protoShard
 }
 
+// runArchResults groups the shards of a run by CPU architecture into the
+// run's architecture matrix, ordered by architecture. It returns nil unless
+// a shard runs on a specific architecture.
+func runArchResults(shards []database.RunShard) []*conductorv1.RunArchResult {
+	var archs []string
+	byArch := make(map[string][]database.RunShard)
+	for _, shard := range shards {
+		var arch string
+		if shard.Arch != nil {
+			arch = *shard.Arch
+		}
+		if _, ok := byArch[arch]; !ok {
+			archs = append(archs, arch)
+		}
+		byArch[arch] = append(byArch[arch], shard)
+	}
+	if len(archs) == 0 || len(archs) == 1 && archs[0] == "" {
+		return nil
+	}
+	sort.Strings(archs)
+
+	results := make([]*conductorv1.RunArchResult, 0, len(archs))
+	for _, arch := range archs {
+		group := aggregateShardGroup(byArch[arch])
+		results = append(results, &conductorv1.RunArchResult{
+			Arch:            arch,
+			Status:          runStatusToProto(group.status),
+			Summary:         group.summary,
+			ShardCount:      group.shards,
+			ShardsCompleted: group.completed,
+			ShardsFailed:    group.failed,
+			ErrorMessage:    group.errorMessage,
+		})
+	}
+	return results
+}
+
+// runMatrixResults groups the shards of a run by matrix combination,
+// ordered by combination name. It returns nil unless a shard runs a matrix
+// combination.
+func runMatrixResults(shards []database.RunShard) []*conductorv1.RunMatrixResult {
+	var names []string
+	cells := make(map[string]*database.MatrixCell)
+	byCell := make(map[string][]database.RunShard)
+	for _, shard := range shards {
+		name := shard.Matrix.Name()
+		if _, ok := byCell[name]; !ok {
+			names = append(names, name)
+			cells[name] = shard.Matrix
+		}
+		byCell[name] = append(byCell[name], shard)
+	}
+	if len(names) == 0 || len(names) == 1 && names[0] == "" {
+		return nil
+	}
+	sort.Strings(names)
+
+	results := make([]*conductorv1.RunMatrixResult, 0, len(names))
+	for _, name := range names {
+		group := aggregateShardGroup(byCell[name])
+		result := &conductorv1.RunMatrixResult{
+			Status:          runStatusToProto(group.status),
+			Summary:         group.summary,
+			ShardCount:      group.shards,
+			ShardsCompleted: group.completed,
+			ShardsFailed:    group.failed,
+			ErrorMessage:    group.errorMessage,
+		}
+		if cell := cells[name]; cell != nil {
+			result.Env, result.Labels = cell.Env, cell.Labels
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// runStageResults groups the shards of a run by pipeline stage, in stage
+// order. It returns nil unless the run has several stages.
+func runStageResults(shards []database.RunShard) []*conductorv1.RunStageResult {
+	var stages []int
+	byStage := make(map[int][]database.RunShard)
+	for _, shard := range shards {
+		if _, ok := byStage[shard.Stage]; !ok {
+			stages = append(stages, shard.Stage)
+		}
+		byStage[shard.Stage] = append(byStage[shard.Stage], shard)
+	}
+	if len(stages) < 2 {
+		return nil
+	}
+	sort.Ints(stages)
+
+	results := make([]*conductorv1.RunStageResult, 0, len(stages))
+	for _, stage := range stages {
+		group := aggregateShardGroup(byStage[stage])
+		results = append(results, &conductorv1.RunStageResult{
+			Stage:           int32(stage),
+			Status:          runStatusToProto(group.status),
+			Summary:         group.summary,
+			ShardCount:      group.shards,
+			ShardsCompleted: group.completed,
+			ShardsFailed:    group.failed,
+			ErrorMessage:    group.errorMessage,
+		})
+	}
+	return results
+}
+
+// shardGroup is the aggregated status and results of a group of the shards
+// of a run.
+type shardGroup struct {
+	status       database.RunStatus
+	summary      *conductorv1.RunSummary
+	shards       int32
+	completed    int32
+	failed       int32
+	errorMessage string
+}
+
+// aggregateShardGroup aggregates a group of the shards of a run. A group
+// none of whose shards started is pending.
+func aggregateShardGroup(shards []database.RunShard) shardGroup {
+	completed, failed, summary, _ := aggregateShardResults(shards)
+
+	status := runStatusFromShardStatus(shards)
+	if status == database.RunStatusRunning && !shardsStarted(shards) {
+		status = database.RunStatusPending
+	}
+
+	return shardGroup{
+		status: status,
+		summary: &conductorv1.RunSummary{
+			Total:   int32(summary.TotalTests),
+			Passed:  int32(summary.PassedTests),
+			Failed:  int32(summary.FailedTests),
+			Skipped: int32(summary.SkippedTests),
+		},
+		shards:       int32(len(shards)),
+		completed:    int32(completed),
+		failed:       int32(failed),
+		errorMessage: summary.ErrorMessage,
+	}
+}
+
+// durationRegressionsToProto converts the duration regressions of a run to
+// their API form.
+func durationRegressionsToProto(regressions []database.DurationRegression) []*conductorv1.DurationRegression {
+	result := make([]*conductorv1.DurationRegression, len(regressions))
+	for i, r := range regressions {
+		result[i] = &conductorv1.DurationRegression{
+			DurationMs:       r.DurationMs,
+			BaselineMeanMs:   r.BaselineMeanMs,
+			BaselineStddevMs: r.BaselineStdDevMs,
+			Sigma:            r.Sigma,
+			BaselineRuns:     int32(r.BaselineRuns),
+			DetectedAt:       timestamppb.New(r.CreatedAt),
+		}
+		if r.TestName != nil {
+			result[i].TestName = *r.TestName
+		}
+	}
+	return result
+}
+
+// shardsStarted reports whether any shard left the pending state.
+func shardsStarted(shards []database.RunShard) bool {
+	for _, shard := range shards {
+		if shard.Status != database.ShardStatusPending {
+			return true
+		}
+	}
+	return false
+}
+
 func aggregateShardResults(shards []database.RunShard) (completed int, failed int, results database.RunResults, finished bool) {
 	finished = true
 
@@ -587,7 +1286,7 @@ func aggregateShardResults(shards []database.RunShard) (completed int, failed in
 		if shard.Status == database.ShardStatusFailed || shard.Status == database.ShardStatusError || shard.Status == database.ShardStatusCancelled {
 			failed++
 			if results.ErrorMessage == "" && shard.ErrorMessage != nil {
-				results.ErrorMessage = *shard.ErrorMessage
+				results.ErrorMessage = shard.FailureMessage()
 			}
 		}
 
@@ -680,30 +1379,3 @@ func triggerTypeToProto(tt database.TriggerType) conductorv1.TriggerType {
 		return conductorv1.TriggerType_TRIGGER_TYPE_UNSPECIFIED
 	}
 }
-
-func paginationFromProto(p *conductorv1.Pagination) database.Pagination {
-	if p == nil {
-		return database.DefaultPagination()
-	}
-	pagination := database.Pagination{
-		Limit:  int(p.PageSize),
-		Offset: 0,
-	}
-	if pagination.Limit <= 0 {
-		pagination.Limit = 50
-	}
-	if pagination.Limit > 100 {
-		pagination.Limit = 100
-	}
-	// TODO: Handle page token for cursor-based pagination
-	return pagination
-}
-
-func paginationResponseToProto(p database.Pagination, total int) *conductorv1.PaginationResponse {
-	hasMore := p.Offset+p.Limit < total
-	return &conductorv1.PaginationResponse{
-		TotalCount: int64(total),
-		HasMore:    hasMore,
-		// TODO: Generate next page token
-	}
-}
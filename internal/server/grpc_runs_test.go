@@ -0,0 +1,531 @@
+package server
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/energy"
+	"github.com/conductor/conductor/internal/idempotency"
+	"github.com/conductor/conductor/internal/preflight"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// energyStatsRepo returns fixed energy estimates.
+type energyStatsRepo struct {
+	zones     []database.ZoneEnergy
+	months    []database.MonthlyEnergy
+	serviceID *uuid.UUID
+}
+
+func (r *energyStatsRepo) GetRunEnergy(ctx context.Context, runID uuid.UUID) ([]database.ZoneEnergy, error) {
+	return r.zones, nil
+}
+
+func (r *energyStatsRepo) GetMonthlyEnergy(ctx context.Context, serviceID *uuid.UUID, start, end time.Time) ([]database.MonthlyEnergy, error) {
+	r.serviceID = serviceID
+	return r.months, nil
+}
+
+// energyRunRepo returns a fixed run.
+type energyRunRepo struct {
+	RunRepository
+}
+
+func (r *energyRunRepo) GetByID(ctx context.Context, id uuid.UUID) (*database.TestRun, error) {
+	return &database.TestRun{ID: id}, nil
+}
+
+func TestGetRunEnergy(t *testing.T) {
+	server := NewRunServiceServer(RunServiceDeps{
+		RunRepo: &energyRunRepo{},
+		EnergyRepo: &energyStatsRepo{zones: []database.ZoneEnergy{
+			{Zone: "", EnergyWh: 10},
+			{Zone: "eu-north", EnergyWh: 100},
+		}},
+		CarbonIntensity: energy.CarbonIntensity{Default: 500, Zones: map[string]float64{"eu-north": 40}},
+	}, zerolog.Nop())
+
+	resp, err := server.GetRunEnergy(context.Background(), &conductorv1.GetRunEnergyRequest{RunId: uuid.New().String()})
+	require.NoError(t, err)
+	assert.InDelta(t, 110.0, resp.EnergyWh, 1e-9)
+	assert.InDelta(t, 9.0, resp.CarbonGrams, 1e-9)
+	require.Len(t, resp.Zones, 2)
+	assert.Equal(t, 500.0, resp.Zones[0].CarbonIntensity)
+	assert.InDelta(t, 4.0, resp.Zones[1].CarbonGrams, 1e-9)
+}
+
+// regressionListRepo returns fixed duration regressions.
+type regressionListRepo struct {
+	database.DurationRegressionRepository
+	regressions []database.DurationRegression
+}
+
+func (r *regressionListRepo) ListByRun(ctx context.Context, runID uuid.UUID) ([]database.DurationRegression, error) {
+	return r.regressions, nil
+}
+
+func TestGetRunDurationRegressions(t *testing.T) {
+	testName := "TestCheckout"
+	detected := time.Date(2026, 1, 25, 12, 0, 0, 0, time.UTC)
+	server := NewRunServiceServer(RunServiceDeps{
+		RunRepo:     &energyRunRepo{},
+		ServiceRepo: &placementServiceRepo{service: &database.Service{Name: "checkout"}},
+		RegressionRepo: &regressionListRepo{regressions: []database.DurationRegression{
+			{DurationMs: 900_000, BaselineMeanMs: 600_000, BaselineStdDevMs: 20_000, Sigma: 15, BaselineRuns: 20, CreatedAt: detected},
+			{TestName: &testName, DurationMs: 9_000, BaselineMeanMs: 2_000, Sigma: math.Inf(1), BaselineRuns: 5, CreatedAt: detected},
+		}},
+	}, zerolog.Nop())
+
+	resp, err := server.GetRun(context.Background(), &conductorv1.GetRunRequest{RunId: uuid.New().String()})
+	require.NoError(t, err)
+	require.Len(t, resp.DurationRegressions, 2)
+	assert.Equal(t, &conductorv1.DurationRegression{
+		DurationMs:       900_000,
+		BaselineMeanMs:   600_000,
+		BaselineStddevMs: 20_000,
+		Sigma:            15,
+		BaselineRuns:     20,
+		DetectedAt:       timestamppb.New(detected),
+	}, resp.DurationRegressions[0])
+	assert.Equal(t, "TestCheckout", resp.DurationRegressions[1].TestName)
+	assert.True(t, math.IsInf(resp.DurationRegressions[1].Sigma, 1))
+}
+
+func TestGetEnergyStats(t *testing.T) {
+	checkout, payments := uuid.New(), uuid.New()
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	repo := &energyStatsRepo{months: []database.MonthlyEnergy{
+		{ServiceID: checkout, ServiceName: "checkout", Month: jan, Zone: "eu-north", EnergyWh: 1000, RunCount: 3},
+		{ServiceID: checkout, ServiceName: "checkout", Month: jan, Zone: "us-east", EnergyWh: 500, RunCount: 3},
+		{ServiceID: payments, ServiceName: "payments", Month: jan, Zone: "us-east", EnergyWh: 200, RunCount: 1},
+		{ServiceID: checkout, ServiceName: "checkout", Month: feb, Zone: "eu-north", EnergyWh: 100, RunCount: 1},
+	}}
+	server := NewRunServiceServer(RunServiceDeps{
+		EnergyRepo:      repo,
+		CarbonIntensity: energy.CarbonIntensity{Default: 400, Zones: map[string]float64{"eu-north": 40}},
+	}, zerolog.Nop())
+
+	resp, err := server.GetEnergyStats(context.Background(), &conductorv1.GetEnergyStatsRequest{})
+	require.NoError(t, err)
+	assert.Nil(t, repo.serviceID)
+
+	require.Len(t, resp.Months, 3)
+	assert.Equal(t, "checkout", resp.Months[0].ServiceName)
+	assert.Equal(t, int32(3), resp.Months[0].RunCount)
+	assert.InDelta(t, 1500.0, resp.Months[0].EnergyWh, 1e-9)
+	assert.InDelta(t, 240.0, resp.Months[0].CarbonGrams, 1e-9)
+	assert.Len(t, resp.Months[0].Zones, 2)
+	assert.Equal(t, "payments", resp.Months[1].ServiceName)
+	assert.Equal(t, feb, resp.Months[2].Month.AsTime())
+	assert.InDelta(t, 1800.0, resp.TotalEnergyWh, 1e-9)
+	assert.InDelta(t, 324.0, resp.TotalCarbonGrams, 1e-9)
+
+	t.Run("filters by service", func(t *testing.T) {
+		_, err := server.GetEnergyStats(context.Background(), &conductorv1.GetEnergyStatsRequest{ServiceId: checkout.String()})
+		require.NoError(t, err)
+		require.NotNil(t, repo.serviceID)
+		assert.Equal(t, checkout, *repo.serviceID)
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		server := NewRunServiceServer(RunServiceDeps{}, zerolog.Nop())
+		_, err := server.GetEnergyStats(context.Background(), &conductorv1.GetEnergyStatsRequest{})
+		assert.True(t, errcode.Is(err, errcode.NotConfigured))
+	})
+}
+
+// placementServiceRepo returns a fixed service.
+type placementServiceRepo struct {
+	ServiceRepository
+	service *database.Service
+}
+
+func (r *placementServiceRepo) GetByID(ctx context.Context, id uuid.UUID) (*database.Service, error) {
+	return r.service, nil
+}
+
+// placementRunRepo records created runs.
+type placementRunRepo struct {
+	RunRepository
+	created *database.TestRun
+}
+
+func (r *placementRunRepo) Create(ctx context.Context, run *database.TestRun) error {
+	r.created = run
+	return nil
+}
+
+// placementAgentRepo lists fixed agents.
+type placementAgentRepo struct {
+	AgentRepository
+	agents []*database.Agent
+}
+
+func (r *placementAgentRepo) List(ctx context.Context, filter AgentFilter, pagination database.Pagination) ([]*database.Agent, int, error) {
+	return r.agents, len(r.agents), nil
+}
+
+// placementTestRepo lists fixed test definitions.
+type placementTestRepo struct {
+	TestDefinitionRepository
+	tests []*database.TestDefinition
+}
+
+func (r *placementTestRepo) ListByService(ctx context.Context, serviceID uuid.UUID, filter TestDefinitionFilter, pagination database.Pagination) ([]*database.TestDefinition, int, error) {
+	return r.tests, len(r.tests), nil
+}
+
+func TestCreateRun_LabelSelector(t *testing.T) {
+	service := &database.Service{ID: uuid.New(), Name: "api", NetworkZones: []string{"internal"}}
+	agents := &placementAgentRepo{agents: []*database.Agent{
+		{Name: "linux", NetworkZones: []string{"internal"}, Labels: map[string]string{"os": "linux"}},
+		{Name: "gpu", NetworkZones: []string{"dmz"}, Labels: map[string]string{"os": "linux", "gpu": "true"}},
+		{Name: "windows", NetworkZones: []string{"internal"}, Labels: map[string]string{"os": "windows"}},
+	}}
+
+	newServer := func(tests ...*database.TestDefinition) (*RunServiceServer, *placementRunRepo) {
+		runs := &placementRunRepo{}
+		return NewRunServiceServer(RunServiceDeps{
+			RunRepo:     runs,
+			ServiceRepo: &placementServiceRepo{service: service},
+			AgentRepo:   agents,
+			TestRepo:    &placementTestRepo{tests: tests},
+		}, zerolog.Nop()), runs
+	}
+
+	t.Run("satisfiable selector is stored", func(t *testing.T) {
+		server, runs := newServer()
+		_, err := server.CreateRun(context.Background(), &conductorv1.CreateRunRequest{
+			ServiceId:     service.ID.String(),
+			LabelSelector: map[string]string{"os": "windows"},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, runs.created)
+		assert.Equal(t, map[string]string{"os": "windows"}, runs.created.LabelSelector)
+	})
+
+	t.Run("agent outside the service zones", func(t *testing.T) {
+		server, runs := newServer()
+		_, err := server.CreateRun(context.Background(), &conductorv1.CreateRunRequest{
+			ServiceId:     service.ID.String(),
+			LabelSelector: map[string]string{"gpu": "true"},
+		})
+		assert.True(t, errcode.Is(err, errcode.NoMatchingAgent))
+		assert.ErrorContains(t, err, "zones internal matches label selector gpu=true")
+		assert.Nil(t, runs.created)
+	})
+
+	t.Run("test selector combined with run selector", func(t *testing.T) {
+		server, _ := newServer(&database.TestDefinition{Name: "e2e", LabelSelector: map[string]string{"os": "linux"}})
+		_, err := server.CreateRun(context.Background(), &conductorv1.CreateRunRequest{
+			ServiceId:     service.ID.String(),
+			LabelSelector: map[string]string{"os": "windows"},
+		})
+		assert.True(t, errcode.Is(err, errcode.NoMatchingAgent))
+		assert.ErrorContains(t, err, "test e2e: conflicting label selectors")
+	})
+
+	t.Run("no selector", func(t *testing.T) {
+		server, runs := newServer()
+		_, err := server.CreateRun(context.Background(), &conductorv1.CreateRunRequest{ServiceId: service.ID.String()})
+		require.NoError(t, err)
+		assert.NotNil(t, runs.created)
+	})
+}
+
+func TestCreateRun_ArchivedService(t *testing.T) {
+	archivedAt := time.Now()
+	service := &database.Service{ID: uuid.New(), Name: "legacy", ArchivedAt: &archivedAt}
+	runs := &placementRunRepo{}
+	server := NewRunServiceServer(RunServiceDeps{
+		RunRepo:     runs,
+		ServiceRepo: &placementServiceRepo{service: service},
+		TestRepo:    &placementTestRepo{},
+	}, zerolog.Nop())
+
+	_, err := server.CreateRun(context.Background(), &conductorv1.CreateRunRequest{ServiceId: service.ID.String()})
+	assert.True(t, errcode.Is(err, errcode.ServiceArchived))
+	assert.Nil(t, runs.created)
+}
+
+// memoryIdempotencyRepo keeps idempotency keys in memory.
+type memoryIdempotencyRepo struct {
+	database.IdempotencyKeyRepository
+	keys map[string]*database.IdempotencyKey
+}
+
+func (r *memoryIdempotencyRepo) Claim(ctx context.Context, key *database.IdempotencyKey) (*database.IdempotencyKey, error) {
+	if existing, ok := r.keys[key.Scope+"/"+key.Key]; ok {
+		return existing, nil
+	}
+	claimed := *key
+	r.keys[key.Scope+"/"+key.Key] = &claimed
+	return nil, nil
+}
+
+func (r *memoryIdempotencyRepo) Complete(ctx context.Context, scope, key string, response []byte, expiresAt time.Time) error {
+	r.keys[scope+"/"+key].Response = response
+	return nil
+}
+
+func (r *memoryIdempotencyRepo) Release(ctx context.Context, scope, key string) error {
+	delete(r.keys, scope+"/"+key)
+	return nil
+}
+
+func TestCreateRun_IdempotencyKey(t *testing.T) {
+	service := &database.Service{ID: uuid.New(), Name: "api"}
+	runs := &placementRunRepo{}
+	server := NewRunServiceServer(RunServiceDeps{
+		RunRepo:     runs,
+		ServiceRepo: &placementServiceRepo{service: service},
+		TestRepo:    &placementTestRepo{},
+		Idempotency: idempotency.NewStore(&memoryIdempotencyRepo{keys: make(map[string]*database.IdempotencyKey)}, time.Hour, nil),
+	}, zerolog.Nop())
+	ctx := withUser(context.Background(), &UserClaims{UserID: "alice"})
+	req := &conductorv1.CreateRunRequest{
+		ServiceId:      service.ID.String(),
+		GitRef:         &conductorv1.GitRef{Branch: "main"},
+		IdempotencyKey: "deploy-42",
+	}
+
+	first, err := server.CreateRun(ctx, req)
+	require.NoError(t, err)
+	created := runs.created
+	require.NotNil(t, created)
+
+	runs.created = nil
+	retry, err := server.CreateRun(ctx, req)
+	require.NoError(t, err)
+	assert.Nil(t, runs.created, "a retry must not create another run")
+	assert.Equal(t, first.Run.Id, retry.Run.Id)
+	assert.Equal(t, created.ID.String(), retry.Run.Id)
+
+	// Another user's key is another key
+	_, err = server.CreateRun(withUser(context.Background(), &UserClaims{UserID: "bob"}), req)
+	require.NoError(t, err)
+	assert.NotNil(t, runs.created)
+
+	_, err = server.CreateRun(ctx, &conductorv1.CreateRunRequest{
+		ServiceId:      service.ID.String(),
+		GitRef:         &conductorv1.GitRef{Branch: "release"},
+		IdempotencyKey: "deploy-42",
+	})
+	assert.True(t, errcode.Is(err, errcode.IdempotencyKeyReused))
+
+	_, err = server.CreateRun(ctx, &conductorv1.CreateRunRequest{
+		ServiceId:      service.ID.String(),
+		IdempotencyKey: strings.Repeat("k", idempotency.MaxKeyLength+1),
+	})
+	assert.True(t, errcode.Is(err, errcode.InvalidArgument))
+}
+
+// fakePreflight records checked targets and reports fixed problems.
+type fakePreflight struct {
+	target   preflight.Target
+	problems []preflight.Problem
+}
+
+func (p *fakePreflight) Check(ctx context.Context, target preflight.Target) []preflight.Problem {
+	p.target = target
+	return p.problems
+}
+
+func TestCreateRun_Preflight(t *testing.T) {
+	service := &database.Service{ID: uuid.New(), Name: "api", DefaultBranch: "main"}
+	tests := []*database.TestDefinition{{Name: "e2e"}}
+
+	newServer := func(checks *fakePreflight) (*RunServiceServer, *placementRunRepo) {
+		runs := &placementRunRepo{}
+		return NewRunServiceServer(RunServiceDeps{
+			RunRepo:     runs,
+			ServiceRepo: &placementServiceRepo{service: service},
+			TestRepo:    &placementTestRepo{tests: tests},
+			Preflight:   checks,
+		}, zerolog.Nop()), runs
+	}
+
+	t.Run("problems reject the run", func(t *testing.T) {
+		checks := &fakePreflight{problems: []preflight.Problem{
+			{Check: preflight.CheckCommit, Message: "deadbeef does not resolve to a commit"},
+			{Check: preflight.CheckSecret, Message: "secret TOKEN of test e2e does not resolve"},
+		}}
+		server, runs := newServer(checks)
+
+		_, err := server.CreateRun(context.Background(), &conductorv1.CreateRunRequest{
+			ServiceId: service.ID.String(),
+			GitRef:    &conductorv1.GitRef{Branch: "main", CommitSha: "deadbeef"},
+		})
+		assert.True(t, errcode.Is(err, errcode.PreflightFailed))
+		assert.ErrorContains(t, err, "pre-flight checks failed for api: commit: deadbeef does not resolve to a commit; secret: secret TOKEN")
+		assert.Equal(t, "commit,secret", errcode.Metadata(err)["checks"])
+		assert.Nil(t, runs.created)
+		assert.Equal(t, "deadbeef", checks.target.Ref)
+		assert.Equal(t, tests, checks.target.Tests)
+	})
+
+	t.Run("passing checks create the run", func(t *testing.T) {
+		checks := &fakePreflight{}
+		server, runs := newServer(checks)
+
+		_, err := server.CreateRun(context.Background(), &conductorv1.CreateRunRequest{ServiceId: service.ID.String()})
+		require.NoError(t, err)
+		assert.NotNil(t, runs.created)
+		assert.Equal(t, "main", checks.target.Ref, "runs without a ref use the default branch")
+	})
+}
+
+func TestPreflightRef(t *testing.T) {
+	service := &database.Service{DefaultBranch: "main"}
+
+	assert.Equal(t, "abc123", preflightRef(service, &conductorv1.GitRef{Branch: "feature", CommitSha: "abc123"}))
+	assert.Equal(t, "feature", preflightRef(service, &conductorv1.GitRef{Branch: "feature"}))
+	assert.Equal(t, "", preflightRef(service, &conductorv1.GitRef{Branch: "fork-branch", PullRequestNumber: 7}))
+	assert.Equal(t, "main", preflightRef(service, nil))
+}
+
+func TestRunArchResults(t *testing.T) {
+	amd, arm := "amd64", "arm64"
+
+	assert.Nil(t, runArchResults(nil))
+	assert.Nil(t, runArchResults([]database.RunShard{{Status: database.ShardStatusPassed}}))
+
+	results := runArchResults([]database.RunShard{
+		{Status: database.ShardStatusPassed, TotalTests: 2, PassedTests: 2},
+		{Status: database.ShardStatusFailed, TotalTests: 4, PassedTests: 3, FailedTests: 1, Arch: &arm, ErrorMessage: database.NullString("illegal instruction")},
+		{Status: database.ShardStatusPassed, TotalTests: 4, PassedTests: 4, Arch: &amd},
+		{Status: database.ShardStatusPending, Arch: &arm},
+	})
+	require.Len(t, results, 3)
+
+	assert.Equal(t, "", results[0].Arch)
+	assert.Equal(t, conductorv1.RunStatus_RUN_STATUS_PASSED, results[0].Status)
+
+	assert.Equal(t, "amd64", results[1].Arch)
+	assert.Equal(t, conductorv1.RunStatus_RUN_STATUS_PASSED, results[1].Status)
+	assert.Equal(t, int32(4), results[1].Summary.Passed)
+
+	assert.Equal(t, "arm64", results[2].Arch)
+	assert.Equal(t, conductorv1.RunStatus_RUN_STATUS_RUNNING, results[2].Status)
+	assert.Equal(t, int32(2), results[2].ShardCount)
+	assert.Equal(t, int32(1), results[2].ShardsCompleted)
+	assert.Equal(t, int32(1), results[2].ShardsFailed)
+	assert.Equal(t, "arm64: illegal instruction", results[2].ErrorMessage)
+
+	pending := runArchResults([]database.RunShard{{Status: database.ShardStatusPending, Arch: &arm}})
+	require.Len(t, pending, 1)
+	assert.Equal(t, conductorv1.RunStatus_RUN_STATUS_PENDING, pending[0].Status)
+}
+
+func TestRunMatrixResults(t *testing.T) {
+	go122 := &database.MatrixCell{Env: map[string]string{"GO_VERSION": "1.22"}, Labels: map[string]string{"os": "linux"}}
+	go123 := &database.MatrixCell{Env: map[string]string{"GO_VERSION": "1.23"}, Labels: map[string]string{"os": "linux"}}
+
+	assert.Nil(t, runMatrixResults(nil))
+	assert.Nil(t, runMatrixResults([]database.RunShard{{Status: database.ShardStatusPassed}}))
+
+	results := runMatrixResults([]database.RunShard{
+		{Status: database.ShardStatusPassed, TotalTests: 1, PassedTests: 1},
+		{Status: database.ShardStatusFailed, TotalTests: 3, FailedTests: 1, Matrix: go123, ErrorMessage: database.NullString("exit status 1")},
+		{Status: database.ShardStatusPassed, TotalTests: 3, PassedTests: 3, Matrix: go122},
+	})
+	require.Len(t, results, 3)
+
+	assert.Empty(t, results[0].Env)
+	assert.Equal(t, conductorv1.RunStatus_RUN_STATUS_PASSED, results[0].Status)
+
+	assert.Equal(t, map[string]string{"GO_VERSION": "1.22"}, results[1].Env)
+	assert.Equal(t, map[string]string{"os": "linux"}, results[1].Labels)
+	assert.Equal(t, int32(3), results[1].Summary.Passed)
+
+	assert.Equal(t, map[string]string{"GO_VERSION": "1.23"}, results[2].Env)
+	assert.Equal(t, conductorv1.RunStatus_RUN_STATUS_FAILED, results[2].Status)
+	assert.Equal(t, int32(1), results[2].ShardsFailed)
+	assert.Equal(t, "GO_VERSION=1.23, os=linux: exit status 1", results[2].ErrorMessage)
+}
+
+func TestRunStageResults(t *testing.T) {
+	assert.Nil(t, runStageResults([]database.RunShard{{Status: database.ShardStatusPassed}}))
+
+	results := runStageResults([]database.RunShard{
+		{Stage: 0, Status: database.ShardStatusFailed, TotalTests: 2, FailedTests: 1, ErrorMessage: database.NullString("1 test failed")},
+		{Stage: 0, Status: database.ShardStatusPassed, TotalTests: 2, PassedTests: 2},
+		{Stage: 1, Status: database.ShardStatusCancelled, ErrorMessage: database.NullString("skipped after an earlier stage failed")},
+	})
+	require.Len(t, results, 2)
+
+	assert.Equal(t, int32(0), results[0].Stage)
+	assert.Equal(t, conductorv1.RunStatus_RUN_STATUS_FAILED, results[0].Status)
+	assert.Equal(t, int32(2), results[0].ShardCount)
+	assert.Equal(t, "1 test failed", results[0].ErrorMessage)
+
+	assert.Equal(t, int32(1), results[1].Stage)
+	assert.Equal(t, conductorv1.RunStatus_RUN_STATUS_CANCELLED, results[1].Status)
+}
+
+// agentRunRepo returns fixed runs and records the filter they were listed
+// with.
+type agentRunRepo struct {
+	RunRepository
+	runs   []*database.TestRun
+	filter RunFilter
+}
+
+func (r *agentRunRepo) List(ctx context.Context, filter RunFilter, pagination database.Pagination) ([]*database.TestRun, int, error) {
+	r.filter = filter
+	return r.runs, len(r.runs), nil
+}
+
+func TestListRunsByAgent(t *testing.T) {
+	agentID := uuid.New()
+	name := "agent-deleted"
+	runs := &agentRunRepo{runs: []*database.TestRun{{
+		ID:                uuid.New(),
+		AgentID:           &agentID,
+		AgentName:         &name,
+		AgentNetworkZones: []string{"eu-north"},
+	}}}
+	server := NewRunServiceServer(RunServiceDeps{
+		RunRepo:     runs,
+		ServiceRepo: &placementServiceRepo{service: &database.Service{Name: "checkout"}},
+	}, zerolog.Nop())
+
+	t.Run("defaults to the last 30 days", func(t *testing.T) {
+		resp, err := server.ListRunsByAgent(context.Background(), &conductorv1.ListRunsByAgentRequest{AgentId: agentID.String()})
+		require.NoError(t, err)
+		require.Len(t, resp.Runs, 1)
+		assert.Equal(t, name, resp.Runs[0].AgentName)
+		assert.Equal(t, []string{"eu-north"}, resp.Runs[0].AgentNetworkZones)
+		assert.Equal(t, "checkout", resp.Runs[0].ServiceName)
+
+		assert.Equal(t, agentID, *runs.filter.AgentID)
+		assert.Equal(t, defaultAgentRunsWindow, runs.filter.EndTime.Sub(*runs.filter.StartTime))
+	})
+
+	t.Run("rejects invalid agent IDs", func(t *testing.T) {
+		_, err := server.ListRunsByAgent(context.Background(), &conductorv1.ListRunsByAgentRequest{AgentId: "agent-1"})
+		assert.True(t, errcode.Is(err, errcode.InvalidArgument))
+	})
+
+	t.Run("rejects empty time ranges", func(t *testing.T) {
+		now := timestamppb.Now()
+		_, err := server.ListRunsByAgent(context.Background(), &conductorv1.ListRunsByAgentRequest{
+			AgentId:   agentID.String(),
+			TimeRange: &conductorv1.TimeRange{Start: now, End: now},
+		})
+		assert.True(t, errcode.Is(err, errcode.InvalidArgument))
+	})
+}
@@ -2,17 +2,22 @@ package server
 
 import (
 	"context"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
 	"github.com/conductor/conductor/internal/database"
 	"github.com/conductor/conductor/internal/git"
+	"github.com/conductor/conductor/internal/placement"
+	"github.com/conductor/conductor/internal/schedule"
+	"github.com/conductor/conductor/internal/secrets"
+	"github.com/conductor/conductor/pkg/errcode"
 )
 
 // SyncResult is an alias for git.SyncResult for backward compatibility.
@@ -24,8 +29,38 @@ type ServiceRegistryDeps struct {
 	ServiceRepo FullServiceRepository
 	// TestRepo handles test definition persistence.
 	TestRepo TestDefinitionRepository
+	// AgentRepo lists the registered agents label selectors are validated
+	// against (optional).
+	AgentRepo AgentRepository
 	// GitSyncer handles git repository synchronization.
 	GitSyncer GitSyncer
+	// DeployKeyRepo handles deploy key persistence (optional).
+	DeployKeyRepo database.DeployKeyRepository
+	// DeployKeyCipher encrypts deploy keys and git credentials at rest (optional).
+	DeployKeyCipher *secrets.Cipher
+	// GitCredentialRepo handles git credential persistence (optional).
+	GitCredentialRepo database.GitCredentialRepository
+	// EnvironmentRepo handles service environment set persistence (optional).
+	EnvironmentRepo database.ServiceEnvironmentRepository
+	// TriggerRuleRepo handles service trigger rule persistence (optional).
+	TriggerRuleRepo database.ServiceTriggerRulesRepository
+	// QuotaRepo handles service quota persistence (optional).
+	QuotaRepo database.ServiceQuotaRepository
+	// RetryPolicyRepo handles run retry policy persistence (optional).
+	RetryPolicyRepo database.RetryPolicyRepository
+	// PoolRepo validates the agent pools services are pinned to (optional).
+	PoolRepo database.AgentPoolRepository
+	// BranchRepo handles branch persistence (optional).
+	BranchRepo database.BranchRepository
+	// RunRepo lists branch run history (optional).
+	RunRepo RunRepository
+	// SyncRepo records sync history (optional).
+	SyncRepo database.ServiceSyncRepository
+	// ScheduleRepo handles scheduled run persistence (optional).
+	ScheduleRepo database.ScheduleRepository
+	// SyncInterval is the default periodic sync interval; 0 disables it for
+	// services without their own interval.
+	SyncInterval time.Duration
 }
 
 // FullServiceRepository extends ServiceRepository with write operations.
@@ -77,10 +112,21 @@ func NewServiceRegistryServer(deps ServiceRegistryDeps, logger zerolog.Logger) *
 // CreateService registers a new service in the registry.
 func (s *ServiceRegistryServer) CreateService(ctx context.Context, req *conductorv1.CreateServiceRequest) (*conductorv1.CreateServiceResponse, error) {
 	if req.Name == "" {
-		return nil, status.Error(codes.InvalidArgument, "name is required")
+		return nil, errcode.New(errcode.InvalidArgument, "name is required")
 	}
 	if req.GitUrl == "" {
-		return nil, status.Error(codes.InvalidArgument, "git_url is required")
+		return nil, errcode.New(errcode.InvalidArgument, "git_url is required")
+	}
+	rootPath, err := normalizeRootPath(req.RootPath)
+	if err != nil {
+		return nil, err
+	}
+	if req.SyncIntervalSeconds != nil && *req.SyncIntervalSeconds < 0 {
+		return nil, errcode.New(errcode.InvalidArgument, "sync_interval_seconds must not be negative")
+	}
+	projectID, err := projectForCreate(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
 	}
 
 	service := &database.Service{
@@ -90,8 +136,14 @@ func (s *ServiceRegistryServer) CreateService(ctx context.Context, req *conducto
 		DefaultBranch: req.DefaultBranch,
 		NetworkZones:  req.NetworkZones,
 		Owner:         database.NullString(req.Owner),
+		RootPath:      database.NullString(rootPath),
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
+		ProjectID:     projectID,
+	}
+	if req.SyncIntervalSeconds != nil {
+		interval := int(*req.SyncIntervalSeconds)
+		service.SyncIntervalSeconds = &interval
 	}
 
 	if service.DefaultBranch == "" {
@@ -102,13 +154,24 @@ func (s *ServiceRegistryServer) CreateService(ctx context.Context, req *conducto
 		service.ContactSlack = database.NullString(req.Contact.Slack)
 		service.ContactEmail = database.NullString(req.Contact.Email)
 	}
+	if err := s.checkAgentPool(ctx, req.AgentPool); err != nil {
+		return nil, err
+	}
+	service.AgentPool = database.NullString(req.AgentPool)
+	service.BranchRunPolicy = branchRunPolicyFromProto(req.BranchRunPolicy)
+	if service.BranchRunPolicy == "" {
+		service.BranchRunPolicy = database.BranchRunPolicyAllow
+	}
 
 	if err := s.deps.ServiceRepo.Create(ctx, service); err != nil {
 		if database.IsDuplicate(err) {
-			return nil, status.Errorf(codes.AlreadyExists, "service with name %q already exists", req.Name)
+			return nil, errcode.New(errcode.ServiceAlreadyExists, "service with name %q already exists", req.Name)
+		}
+		if database.IsForeignKeyViolation(err) {
+			return nil, errcode.New(errcode.NotFound, "project not found: %s", projectID)
 		}
 		s.logger.Error().Err(err).Str("name", req.Name).Msg("failed to create service")
-		return nil, status.Errorf(codes.Internal, "failed to create service: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to create service: %v", err)
 	}
 
 	s.logger.Info().
@@ -125,15 +188,15 @@ func (s *ServiceRegistryServer) CreateService(ctx context.Context, req *conducto
 func (s *ServiceRegistryServer) GetService(ctx context.Context, req *conductorv1.GetServiceRequest) (*conductorv1.GetServiceResponse, error) {
 	serviceID, err := uuid.Parse(req.ServiceId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid service ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
 	}
 
 	service, err := s.deps.ServiceRepo.GetByID(ctx, serviceID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "service not found: %s", req.ServiceId)
+			return nil, errcode.New(errcode.ServiceNotFound, "service not found: %s", req.ServiceId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get service: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get service: %v", err)
 	}
 
 	resp := &conductorv1.GetServiceResponse{
@@ -168,10 +231,11 @@ func (s *ServiceRegistryServer) ListServices(ctx context.Context, req *conductor
 	}
 
 	pagination := paginationFromProto(req.Pagination)
-	services, total, err := s.deps.ServiceRepo.List(ctx, filter, pagination)
+	services, total, err := s.deps.ServiceRepo.List(ctx, filter, peekPagination(pagination))
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to list services: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to list services: %v", err)
 	}
+	services, page := keysetPage(pagination, services, total, serviceCursor)
 
 	protoServices := make([]*conductorv1.Service, len(services))
 	for i, svc := range services {
@@ -180,7 +244,7 @@ func (s *ServiceRegistryServer) ListServices(ctx context.Context, req *conductor
 
 	return &conductorv1.ListServicesResponse{
 		Services:   protoServices,
-		Pagination: paginationResponseToProto(pagination, total),
+		Pagination: page,
 	}, nil
 }
 
@@ -188,15 +252,15 @@ func (s *ServiceRegistryServer) ListServices(ctx context.Context, req *conductor
 func (s *ServiceRegistryServer) UpdateService(ctx context.Context, req *conductorv1.UpdateServiceRequest) (*conductorv1.UpdateServiceResponse, error) {
 	serviceID, err := uuid.Parse(req.ServiceId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid service ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
 	}
 
 	service, err := s.deps.ServiceRepo.GetByID(ctx, serviceID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "service not found: %s", req.ServiceId)
+			return nil, errcode.New(errcode.ServiceNotFound, "service not found: %s", req.ServiceId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get service: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get service: %v", err)
 	}
 
 	// Apply updates
@@ -219,11 +283,37 @@ func (s *ServiceRegistryServer) UpdateService(ctx context.Context, req *conducto
 		service.ContactSlack = database.NullString(req.Contact.Slack)
 		service.ContactEmail = database.NullString(req.Contact.Email)
 	}
+	if req.RootPath != nil {
+		rootPath, err := normalizeRootPath(*req.RootPath)
+		if err != nil {
+			return nil, err
+		}
+		service.RootPath = database.NullString(rootPath)
+	}
+	if req.SyncIntervalSeconds != nil {
+		switch interval := int(*req.SyncIntervalSeconds); {
+		case interval == -1:
+			service.SyncIntervalSeconds = nil
+		case interval < 0:
+			return nil, errcode.New(errcode.InvalidArgument, "sync_interval_seconds must be -1 or not negative")
+		default:
+			service.SyncIntervalSeconds = &interval
+		}
+	}
+	if req.AgentPool != nil {
+		if err := s.checkAgentPool(ctx, *req.AgentPool); err != nil {
+			return nil, err
+		}
+		service.AgentPool = database.NullString(*req.AgentPool)
+	}
+	if policy := branchRunPolicyFromProto(req.BranchRunPolicy); policy != "" {
+		service.BranchRunPolicy = policy
+	}
 
 	service.UpdatedAt = time.Now()
 
 	if err := s.deps.ServiceRepo.Update(ctx, service); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to update service: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to update service: %v", err)
 	}
 
 	s.logger.Info().
@@ -239,16 +329,16 @@ func (s *ServiceRegistryServer) UpdateService(ctx context.Context, req *conducto
 func (s *ServiceRegistryServer) DeleteService(ctx context.Context, req *conductorv1.DeleteServiceRequest) (*conductorv1.DeleteServiceResponse, error) {
 	serviceID, err := uuid.Parse(req.ServiceId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid service ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
 	}
 
 	// Verify service exists
 	_, err = s.deps.ServiceRepo.GetByID(ctx, serviceID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "service not found: %s", req.ServiceId)
+			return nil, errcode.New(errcode.ServiceNotFound, "service not found: %s", req.ServiceId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get service: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get service: %v", err)
 	}
 
 	// Delete associated test definitions
@@ -257,7 +347,7 @@ func (s *ServiceRegistryServer) DeleteService(ctx context.Context, req *conducto
 	}
 
 	if err := s.deps.ServiceRepo.Delete(ctx, serviceID); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to delete service: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to delete service: %v", err)
 	}
 
 	s.logger.Info().
@@ -269,19 +359,77 @@ func (s *ServiceRegistryServer) DeleteService(ctx context.Context, req *conducto
 	}, nil
 }
 
+// ArchiveService archives a service. Archived services keep their history
+// but are no longer triggered or synced. Archiving an archived service
+// returns it unchanged.
+func (s *ServiceRegistryServer) ArchiveService(ctx context.Context, req *conductorv1.ArchiveServiceRequest) (*conductorv1.ArchiveServiceResponse, error) {
+	service, err := s.getService(ctx, req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+
+	if service.ArchivedAt == nil {
+		now := time.Now()
+		service.ArchivedAt = &now
+		service.UpdatedAt = now
+
+		if err := s.deps.ServiceRepo.Update(ctx, service); err != nil {
+			return nil, errcode.New(errcode.Internal, "failed to archive service: %v", err)
+		}
+
+		s.logger.Info().
+			Str("service_id", req.ServiceId).
+			Msg("service archived")
+	}
+
+	return &conductorv1.ArchiveServiceResponse{
+		Service: serviceToProto(service),
+	}, nil
+}
+
+// UnarchiveService makes an archived service active again. Unarchiving an
+// active service returns it unchanged.
+func (s *ServiceRegistryServer) UnarchiveService(ctx context.Context, req *conductorv1.UnarchiveServiceRequest) (*conductorv1.UnarchiveServiceResponse, error) {
+	service, err := s.getService(ctx, req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+
+	if service.ArchivedAt != nil {
+		service.ArchivedAt = nil
+		service.UpdatedAt = time.Now()
+
+		if err := s.deps.ServiceRepo.Update(ctx, service); err != nil {
+			return nil, errcode.New(errcode.Internal, "failed to unarchive service: %v", err)
+		}
+
+		s.logger.Info().
+			Str("service_id", req.ServiceId).
+			Msg("service unarchived")
+	}
+
+	return &conductorv1.UnarchiveServiceResponse{
+		Service: serviceToProto(service),
+	}, nil
+}
+
 // SyncService triggers discovery of test definitions from the repository.
 func (s *ServiceRegistryServer) SyncService(ctx context.Context, req *conductorv1.SyncServiceRequest) (*conductorv1.SyncServiceResponse, error) {
 	serviceID, err := uuid.Parse(req.ServiceId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid service ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
 	}
 
 	service, err := s.deps.ServiceRepo.GetByID(ctx, serviceID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "service not found: %s", req.ServiceId)
+			return nil, errcode.New(errcode.ServiceNotFound, "service not found: %s", req.ServiceId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get service: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get service: %v", err)
+	}
+
+	if err := requireActive(service); err != nil {
+		return nil, err
 	}
 
 	branch := req.Branch
@@ -289,10 +437,12 @@ func (s *ServiceRegistryServer) SyncService(ctx context.Context, req *conductorv
 		branch = service.DefaultBranch
 	}
 
+	startedAt := time.Now()
 	result, err := s.deps.GitSyncer.SyncService(ctx, service, branch)
+	s.recordSync(ctx, service, branch, startedAt, result, err)
 	if err != nil {
 		s.logger.Error().Err(err).Str("service_id", serviceID.String()).Msg("failed to sync service")
-		return nil, status.Errorf(codes.Internal, "failed to sync service: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to sync service: %v", err)
 	}
 
 	s.logger.Info().
@@ -308,27 +458,101 @@ func (s *ServiceRegistryServer) SyncService(ctx context.Context, req *conductorv
 		TestsRemoved: int32(result.TestsRemoved),
 		Errors:       result.Errors,
 		SyncedAt:     timestamppb.New(result.SyncedAt),
+		Changes: &conductorv1.SyncChangeSet{
+			Added:   result.Added,
+			Updated: result.Updated,
+			Removed: result.Removed,
+		},
 	}, nil
 }
 
+// recordSync adds a manual sync to the sync history, if configured.
+func (s *ServiceRegistryServer) recordSync(ctx context.Context, service *database.Service, branch string, startedAt time.Time, result *SyncResult, syncErr error) {
+	if s.deps.SyncRepo == nil {
+		return
+	}
+	record := git.NewSyncRecord(service, branch, database.SyncTriggerManual, startedAt, time.Now(), result, syncErr)
+	if err := s.deps.SyncRepo.Record(ctx, record); err != nil {
+		s.logger.Warn().Err(err).Str("service_id", service.ID.String()).Msg("failed to record sync")
+	}
+}
+
+// GetSyncStatus returns when test definitions were last synced, when the
+// next periodic sync is due, and the sync history.
+func (s *ServiceRegistryServer) GetSyncStatus(ctx context.Context, req *conductorv1.GetSyncStatusRequest) (*conductorv1.GetSyncStatusResponse, error) {
+	if s.deps.SyncRepo == nil {
+		return nil, errcode.New(errcode.NotConfigured, "sync history is not configured")
+	}
+
+	service, err := s.getService(ctx, req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+
+	pagination := paginationFromProto(req.Pagination)
+	history, total, err := s.deps.SyncRepo.ListByService(ctx, service.ID, pagination)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list syncs: %v", err)
+	}
+
+	resp := &conductorv1.GetSyncStatusResponse{
+		History:    make([]*conductorv1.SyncRecord, len(history)),
+		Pagination: paginationResponseToProto(pagination, total),
+	}
+	for i := range history {
+		resp.History[i] = syncRecordToProto(&history[i])
+	}
+
+	var lastStartedAt time.Time
+	if pagination.Offset == 0 && len(history) > 0 {
+		resp.LastSync = resp.History[0]
+		lastStartedAt = history[0].StartedAt
+	} else {
+		last, err := s.deps.SyncRepo.Latest(ctx, service.ID)
+		if err != nil && !database.IsNotFound(err) {
+			return nil, errcode.New(errcode.Internal, "failed to get latest sync: %v", err)
+		}
+		if last != nil {
+			resp.LastSync = syncRecordToProto(last)
+			lastStartedAt = last.StartedAt
+		}
+	}
+
+	interval := s.deps.SyncInterval
+	if service.SyncIntervalSeconds != nil {
+		interval = time.Duration(*service.SyncIntervalSeconds) * time.Second
+	}
+	if interval > 0 {
+		resp.SyncIntervalSeconds = int32(interval / time.Second)
+		// Never synced services are due immediately
+		next := time.Now()
+		if !lastStartedAt.IsZero() && lastStartedAt.Add(interval).After(next) {
+			next = lastStartedAt.Add(interval)
+		}
+		resp.NextSyncAt = timestamppb.New(next)
+	}
+
+	return resp, nil
+}
+
 // GetTestDefinition retrieves a specific test definition.
 func (s *ServiceRegistryServer) GetTestDefinition(ctx context.Context, req *conductorv1.GetTestDefinitionRequest) (*conductorv1.GetTestDefinitionResponse, error) {
 	serviceID, err := uuid.Parse(req.ServiceId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid service ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
 	}
 
 	testID, err := uuid.Parse(req.TestId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid test ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid test ID: %v", err)
 	}
 
 	test, err := s.deps.TestRepo.GetByID(ctx, serviceID, testID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "test not found: %s", req.TestId)
+			return nil, errcode.New(errcode.TestNotFound, "test not found: %s", req.TestId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get test: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get test: %v", err)
 	}
 
 	return &conductorv1.GetTestDefinitionResponse{
@@ -340,7 +564,7 @@ func (s *ServiceRegistryServer) GetTestDefinition(ctx context.Context, req *cond
 func (s *ServiceRegistryServer) ListTestDefinitions(ctx context.Context, req *conductorv1.ListTestDefinitionsRequest) (*conductorv1.ListTestDefinitionsResponse, error) {
 	serviceID, err := uuid.Parse(req.ServiceId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid service ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
 	}
 
 	filter := TestDefinitionFilter{
@@ -352,7 +576,7 @@ func (s *ServiceRegistryServer) ListTestDefinitions(ctx context.Context, req *co
 	pagination := paginationFromProto(req.Pagination)
 	tests, total, err := s.deps.TestRepo.ListByService(ctx, serviceID, filter, pagination)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to list tests: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to list tests: %v", err)
 	}
 
 	protoTests := make([]*conductorv1.TestDefinition, len(tests))
@@ -366,24 +590,125 @@ func (s *ServiceRegistryServer) ListTestDefinitions(ctx context.Context, req *co
 	}, nil
 }
 
+// CreateTestDefinition adds a test definition to a service.
+func (s *ServiceRegistryServer) CreateTestDefinition(ctx context.Context, req *conductorv1.CreateTestDefinitionRequest) (*conductorv1.CreateTestDefinitionResponse, error) {
+	if req.Name == "" {
+		return nil, errcode.New(errcode.InvalidArgument, "name is required")
+	}
+	if req.Command == "" {
+		return nil, errcode.New(errcode.InvalidArgument, "command is required")
+	}
+	if req.RetryCount < 0 {
+		return nil, errcode.New(errcode.InvalidArgument, "retry_count must not be negative")
+	}
+
+	service, err := s.getService(ctx, req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireActive(service); err != nil {
+		return nil, err
+	}
+
+	refs, err := validateEnvironment(req.Environment, req.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	archs, err := placement.NormalizeArchitectures(req.Architectures)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "%v", err)
+	}
+	maxBytes, maxFiles, err := artifactBudgetFromProto(req.ArtifactBudget)
+	if err != nil {
+		return nil, err
+	}
+	matrix, err := testMatrixFromProto(req.Matrix)
+	if err != nil {
+		return nil, err
+	}
+	params, err := testParametersFromProto(req.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTestPlacement(ctx, s.deps.AgentRepo, req.Name, service.NetworkZones, archs, matrix.Cells(), req.LabelSelector); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	test := &database.TestDefinition{
+		ID:               uuid.New(),
+		ServiceID:        service.ID,
+		Name:             req.Name,
+		ExecutionType:    "subprocess",
+		Command:          req.Command,
+		TimeoutSeconds:   int(req.Timeout.GetSeconds()),
+		ResultFormat:     resultFormatFromProto(req.ResultFormat),
+		ArtifactPatterns: req.ArtifactPaths,
+		Tags:             req.Tags,
+		Retries:          int(req.RetryCount),
+		Environment:      req.Environment,
+		Secrets:          refs,
+		LabelSelector:    req.LabelSelector,
+		Architectures:    archs,
+		ArtifactMaxBytes: maxBytes,
+		ArtifactMaxFiles: maxFiles,
+		Owner:            database.NullString(req.Owner),
+		Matrix:           matrix,
+		DependsOn:        req.DependsOn,
+		RequiresApproval: req.RequiresApproval,
+		CapturePatterns:  req.CapturePaths,
+		Parameters:       params,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if err := s.checkTestDependencies(ctx, test); err != nil {
+		return nil, err
+	}
+
+	if err := s.deps.TestRepo.Create(ctx, test); err != nil {
+		if database.IsDuplicate(err) {
+			return nil, errcode.New(errcode.TestAlreadyExists, "test with name %q already exists", req.Name)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to create test: %v", err)
+	}
+
+	s.logger.Info().
+		Str("service_id", service.ID.String()).
+		Str("test_id", test.ID.String()).
+		Str("name", test.Name).
+		Msg("test definition created")
+
+	return &conductorv1.CreateTestDefinitionResponse{
+		Test: testDefinitionToProto(test),
+	}, nil
+}
+
 // UpdateTestDefinition updates a test definition.
 func (s *ServiceRegistryServer) UpdateTestDefinition(ctx context.Context, req *conductorv1.UpdateTestDefinitionRequest) (*conductorv1.UpdateTestDefinitionResponse, error) {
 	serviceID, err := uuid.Parse(req.ServiceId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid service ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
 	}
 
 	testID, err := uuid.Parse(req.TestId)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid test ID: %v", err)
+		return nil, errcode.New(errcode.InvalidArgument, "invalid test ID: %v", err)
 	}
 
 	test, err := s.deps.TestRepo.GetByID(ctx, serviceID, testID)
 	if err != nil {
 		if database.IsNotFound(err) {
-			return nil, status.Errorf(codes.NotFound, "test not found: %s", req.TestId)
+			return nil, errcode.New(errcode.TestNotFound, "test not found: %s", req.TestId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get test: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to get test: %v", err)
+	}
+
+	service, err := s.getService(ctx, req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireActive(service); err != nil {
+		return nil, err
 	}
 
 	// Apply updates
@@ -402,11 +727,86 @@ func (s *ServiceRegistryServer) UpdateTestDefinition(ctx context.Context, req *c
 	if req.RetryCount != nil {
 		test.Retries = int(*req.RetryCount)
 	}
+	if len(req.Environment) > 0 || len(req.Secrets) > 0 {
+		refs, err := validateEnvironment(req.Environment, req.Secrets)
+		if err != nil {
+			return nil, err
+		}
+		if len(req.Environment) > 0 {
+			test.Environment = req.Environment
+		}
+		if len(req.Secrets) > 0 {
+			test.Secrets = refs
+		}
+	}
+	if len(req.Architectures) > 0 {
+		archs, err := placement.NormalizeArchitectures(req.Architectures)
+		if err != nil {
+			return nil, errcode.New(errcode.InvalidArgument, "%v", err)
+		}
+		test.Architectures = archs
+	}
+	if req.ResultFormat != nil {
+		test.ResultFormat = resultFormatFromProto(*req.ResultFormat)
+	}
+	if len(req.ArtifactPaths) > 0 {
+		test.ArtifactPatterns = req.ArtifactPaths
+	}
+	if len(req.CapturePaths) > 0 {
+		test.CapturePatterns = req.CapturePaths
+	}
+	if req.ArtifactBudget != nil {
+		maxBytes, maxFiles, err := artifactBudgetFromProto(req.ArtifactBudget)
+		if err != nil {
+			return nil, err
+		}
+		test.ArtifactMaxBytes, test.ArtifactMaxFiles = maxBytes, maxFiles
+	}
+	if req.Owner != nil {
+		test.Owner = database.NullString(*req.Owner)
+	}
+	if req.Matrix != nil {
+		matrix, err := testMatrixFromProto(req.Matrix)
+		if err != nil {
+			return nil, err
+		}
+		test.Matrix = matrix
+	}
+	if req.RequiresApproval != nil {
+		test.RequiresApproval = *req.RequiresApproval
+	}
+	if len(req.Parameters) > 0 {
+		params, err := testParametersFromProto(req.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		test.Parameters = params
+	}
+	if len(req.DependsOn) > 0 {
+		test.DependsOn = req.DependsOn
+		if err := s.checkTestDependencies(ctx, test); err != nil {
+			return nil, err
+		}
+	}
+	if len(req.LabelSelector) > 0 || len(req.Architectures) > 0 || req.Matrix != nil {
+		service, err := s.deps.ServiceRepo.GetByID(ctx, serviceID)
+		if err != nil {
+			return nil, errcode.New(errcode.Internal, "failed to get service: %v", err)
+		}
+		selector := test.LabelSelector
+		if len(req.LabelSelector) > 0 {
+			selector = req.LabelSelector
+		}
+		if err := checkTestPlacement(ctx, s.deps.AgentRepo, test.Name, service.NetworkZones, test.Architectures, test.Matrix.Cells(), selector); err != nil {
+			return nil, err
+		}
+		test.LabelSelector = selector
+	}
 
 	test.UpdatedAt = time.Now()
 
 	if err := s.deps.TestRepo.Update(ctx, test); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to update test: %v", err)
+		return nil, errcode.New(errcode.Internal, "failed to update test: %v", err)
 	}
 
 	return &conductorv1.UpdateTestDefinitionResponse{
@@ -414,91 +814,1634 @@ func (s *ServiceRegistryServer) UpdateTestDefinition(ctx context.Context, req *c
 	}, nil
 }
 
-// Helper functions
+// SetDeployKey stores an SSH deploy key for a service.
+func (s *ServiceRegistryServer) SetDeployKey(ctx context.Context, req *conductorv1.SetDeployKeyRequest) (*conductorv1.SetDeployKeyResponse, error) {
+	if err := s.requireDeployKeys(); err != nil {
+		return nil, err
+	}
 
-func serviceToProto(svc *database.Service) *conductorv1.Service {
-	if svc == nil {
-		return nil
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
 	}
 
-	protoSvc := &conductorv1.Service{
-		Id:            svc.ID.String(),
-		Name:          svc.Name,
-		GitUrl:        svc.GitURL,
-		DefaultBranch: svc.DefaultBranch,
-		NetworkZones:  svc.NetworkZones,
-		Active:        true,
-		CreatedAt:     timestamppb.New(svc.CreatedAt),
-		UpdatedAt:     timestamppb.New(svc.UpdatedAt),
+	info, err := git.ParseDeployKey(req.PrivateKey)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid deploy key: %v", err)
 	}
 
-	if svc.Owner != nil {
-		protoSvc.Owner = *svc.Owner
+	if _, err := s.deps.ServiceRepo.GetByID(ctx, serviceID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.ServiceNotFound, "service not found: %s", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get service: %v", err)
 	}
 
-	if svc.ContactSlack != nil || svc.ContactEmail != nil {
-		protoSvc.Contact = &conductorv1.Contact{}
-		if svc.ContactSlack != nil {
-			protoSvc.Contact.Slack = *svc.ContactSlack
+	encrypted, err := s.deps.DeployKeyCipher.Encrypt([]byte(req.PrivateKey))
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to encrypt deploy key: %v", err)
+	}
+
+	key := &database.ServiceDeployKey{
+		ServiceID:           serviceID,
+		EncryptedPrivateKey: encrypted,
+		PublicKey:           info.PublicKey,
+		Fingerprint:         info.Fingerprint,
+		KnownHosts:          database.NullString(req.KnownHosts),
+	}
+	if err := s.deps.DeployKeyRepo.Upsert(ctx, key); err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to save deploy key: %v", err)
+	}
+
+	s.logger.Info().
+		Str("service_id", serviceID.String()).
+		Str("fingerprint", key.Fingerprint).
+		Msg("deploy key set")
+
+	return &conductorv1.SetDeployKeyResponse{
+		DeployKey: deployKeyToProto(key),
+	}, nil
+}
+
+// GetDeployKey returns the public details of a service's deploy key.
+func (s *ServiceRegistryServer) GetDeployKey(ctx context.Context, req *conductorv1.GetDeployKeyRequest) (*conductorv1.GetDeployKeyResponse, error) {
+	if err := s.requireDeployKeys(); err != nil {
+		return nil, err
+	}
+
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	key, err := s.deps.DeployKeyRepo.GetByService(ctx, serviceID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.DeployKeyNotFound, "deploy key not found for service: %s", req.ServiceId)
 		}
-		if svc.ContactEmail != nil {
-			protoSvc.Contact.Email = *svc.ContactEmail
+		return nil, errcode.New(errcode.Internal, "failed to get deploy key: %v", err)
+	}
+
+	return &conductorv1.GetDeployKeyResponse{
+		DeployKey: deployKeyToProto(key),
+	}, nil
+}
+
+// DeleteDeployKey removes a service's deploy key.
+func (s *ServiceRegistryServer) DeleteDeployKey(ctx context.Context, req *conductorv1.DeleteDeployKeyRequest) (*conductorv1.DeleteDeployKeyResponse, error) {
+	if err := s.requireDeployKeys(); err != nil {
+		return nil, err
+	}
+
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	if err := s.deps.DeployKeyRepo.Delete(ctx, serviceID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.DeployKeyNotFound, "deploy key not found for service: %s", req.ServiceId)
 		}
+		return nil, errcode.New(errcode.Internal, "failed to delete deploy key: %v", err)
 	}
 
-	return protoSvc
+	s.logger.Info().
+		Str("service_id", serviceID.String()).
+		Msg("deploy key deleted")
+
+	return &conductorv1.DeleteDeployKeyResponse{
+		Success: true,
+	}, nil
 }
 
-func testDefinitionToProto(test *database.TestDefinition) *conductorv1.TestDefinition {
-	if test == nil {
-		return nil
+func (s *ServiceRegistryServer) requireDeployKeys() error {
+	if s.deps.DeployKeyRepo == nil || s.deps.DeployKeyCipher == nil {
+		return errcode.New(errcode.NotConfigured, "deploy keys are not configured")
 	}
+	return nil
+}
 
-	protoTest := &conductorv1.TestDefinition{
-		Id:        test.ID.String(),
-		ServiceId: test.ServiceID.String(),
-		Name:      test.Name,
-		Command:   test.Command,
-		Tags:      test.Tags,
-		Enabled:   !test.AllowFailure,
-		CreatedAt: timestamppb.New(test.CreatedAt),
-		UpdatedAt: timestamppb.New(test.UpdatedAt),
+// SetGitCredential stores a provider API token for reporting a service's commit statuses.
+func (s *ServiceRegistryServer) SetGitCredential(ctx context.Context, req *conductorv1.SetGitCredentialRequest) (*conductorv1.SetGitCredentialResponse, error) {
+	if err := s.requireGitCredentials(); err != nil {
+		return nil, err
 	}
 
-	if test.TimeoutSeconds > 0 {
-		protoTest.Timeout = &conductorv1.Duration{
-			Seconds: int64(test.TimeoutSeconds),
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	provider := strings.ToLower(req.Provider)
+	switch provider {
+	case "github", "gitlab", "bitbucket":
+	default:
+		return nil, errcode.New(errcode.InvalidArgument, "provider must be one of github, gitlab, bitbucket")
+	}
+	if req.Token == "" {
+		return nil, errcode.New(errcode.InvalidArgument, "token is required")
+	}
+
+	if _, err := s.deps.ServiceRepo.GetByID(ctx, serviceID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.ServiceNotFound, "service not found: %s", req.ServiceId)
 		}
+		return nil, errcode.New(errcode.Internal, "failed to get service: %v", err)
 	}
 
-	if test.Retries > 0 {
-		protoTest.RetryCount = int32(test.Retries)
+	encrypted, err := s.deps.DeployKeyCipher.Encrypt([]byte(req.Token))
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to encrypt git credential: %v", err)
 	}
 
-	if test.ResultFormat != nil {
-		protoTest.ResultFormat = resultFormatToProto(*test.ResultFormat)
+	cred := &database.ServiceGitCredential{
+		ServiceID:      serviceID,
+		Provider:       provider,
+		BaseURL:        database.NullString(req.BaseUrl),
+		EncryptedToken: encrypted,
+	}
+	if err := s.deps.GitCredentialRepo.Upsert(ctx, cred); err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to save git credential: %v", err)
 	}
 
-	return protoTest
+	s.logger.Info().
+		Str("service_id", serviceID.String()).
+		Str("provider", cred.Provider).
+		Msg("git credential set")
+
+	return &conductorv1.SetGitCredentialResponse{
+		GitCredential: gitCredentialToProto(cred),
+	}, nil
 }
 
-func testTypeFromProto(t conductorv1.TestType) string {
-	switch t {
-	case conductorv1.TestType_TEST_TYPE_UNIT:
-		return "unit"
-	case conductorv1.TestType_TEST_TYPE_INTEGRATION:
-		return "integration"
-	case conductorv1.TestType_TEST_TYPE_E2E:
-		return "e2e"
-	case conductorv1.TestType_TEST_TYPE_PERFORMANCE:
-		return "performance"
-	case conductorv1.TestType_TEST_TYPE_SECURITY:
-		return "security"
-	case conductorv1.TestType_TEST_TYPE_SMOKE:
-		return "smoke"
-	default:
-		return ""
+// GetGitCredential returns a service's git credential without the token.
+func (s *ServiceRegistryServer) GetGitCredential(ctx context.Context, req *conductorv1.GetGitCredentialRequest) (*conductorv1.GetGitCredentialResponse, error) {
+	if err := s.requireGitCredentials(); err != nil {
+		return nil, err
+	}
+
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	cred, err := s.deps.GitCredentialRepo.GetByService(ctx, serviceID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.GitCredentialNotFound, "git credential not found for service: %s", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get git credential: %v", err)
+	}
+
+	return &conductorv1.GetGitCredentialResponse{
+		GitCredential: gitCredentialToProto(cred),
+	}, nil
+}
+
+// DeleteGitCredential removes a service's git credential.
+func (s *ServiceRegistryServer) DeleteGitCredential(ctx context.Context, req *conductorv1.DeleteGitCredentialRequest) (*conductorv1.DeleteGitCredentialResponse, error) {
+	if err := s.requireGitCredentials(); err != nil {
+		return nil, err
+	}
+
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	if err := s.deps.GitCredentialRepo.Delete(ctx, serviceID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.GitCredentialNotFound, "git credential not found for service: %s", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to delete git credential: %v", err)
+	}
+
+	s.logger.Info().
+		Str("service_id", serviceID.String()).
+		Msg("git credential deleted")
+
+	return &conductorv1.DeleteGitCredentialResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *ServiceRegistryServer) requireGitCredentials() error {
+	if s.deps.GitCredentialRepo == nil || s.deps.DeployKeyCipher == nil {
+		return errcode.New(errcode.NotConfigured, "git credentials are not configured")
+	}
+	return nil
+}
+
+// SetServiceEnvironment replaces the environment set inherited by a service's tests.
+func (s *ServiceRegistryServer) SetServiceEnvironment(ctx context.Context, req *conductorv1.SetServiceEnvironmentRequest) (*conductorv1.SetServiceEnvironmentResponse, error) {
+	if err := s.requireEnvironments(); err != nil {
+		return nil, err
+	}
+
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	refs, err := validateEnvironment(req.Environment, req.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.deps.ServiceRepo.GetByID(ctx, serviceID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.ServiceNotFound, "service not found: %s", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get service: %v", err)
+	}
+
+	env := &database.ServiceEnvironment{
+		ServiceID:   serviceID,
+		Environment: req.Environment,
+		Secrets:     refs,
+	}
+	if err := s.deps.EnvironmentRepo.Upsert(ctx, env); err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to save service environment: %v", err)
+	}
+
+	s.logger.Info().
+		Str("service_id", serviceID.String()).
+		Int("variables", len(env.Environment)).
+		Int("secrets", len(env.Secrets)).
+		Msg("service environment set")
+
+	return &conductorv1.SetServiceEnvironmentResponse{
+		Environment: serviceEnvironmentToProto(env),
+	}, nil
+}
+
+// GetServiceEnvironment returns a service's environment set.
+func (s *ServiceRegistryServer) GetServiceEnvironment(ctx context.Context, req *conductorv1.GetServiceEnvironmentRequest) (*conductorv1.GetServiceEnvironmentResponse, error) {
+	if err := s.requireEnvironments(); err != nil {
+		return nil, err
+	}
+
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	env, err := s.deps.EnvironmentRepo.GetByService(ctx, serviceID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.EnvironmentNotFound, "environment not found for service: %s", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get service environment: %v", err)
+	}
+
+	return &conductorv1.GetServiceEnvironmentResponse{
+		Environment: serviceEnvironmentToProto(env),
+	}, nil
+}
+
+// DeleteServiceEnvironment removes a service's environment set.
+func (s *ServiceRegistryServer) DeleteServiceEnvironment(ctx context.Context, req *conductorv1.DeleteServiceEnvironmentRequest) (*conductorv1.DeleteServiceEnvironmentResponse, error) {
+	if err := s.requireEnvironments(); err != nil {
+		return nil, err
+	}
+
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	if err := s.deps.EnvironmentRepo.Delete(ctx, serviceID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.EnvironmentNotFound, "environment not found for service: %s", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to delete service environment: %v", err)
+	}
+
+	s.logger.Info().
+		Str("service_id", serviceID.String()).
+		Msg("service environment deleted")
+
+	return &conductorv1.DeleteServiceEnvironmentResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *ServiceRegistryServer) requireEnvironments() error {
+	if s.deps.EnvironmentRepo == nil {
+		return errcode.New(errcode.NotConfigured, "service environments are not configured")
+	}
+	return nil
+}
+
+// SetTriggerRules replaces the rules deciding which webhook events trigger runs.
+func (s *ServiceRegistryServer) SetTriggerRules(ctx context.Context, req *conductorv1.SetTriggerRulesRequest) (*conductorv1.SetTriggerRulesResponse, error) {
+	if err := s.requireTriggerRules(); err != nil {
+		return nil, err
+	}
+
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	rules := &database.ServiceTriggerRules{
+		ServiceID:      serviceID,
+		Branches:       req.Branches,
+		IgnoreBranches: req.IgnoreBranches,
+		Paths:          req.Paths,
+		IgnorePaths:    req.IgnorePaths,
+		Tags:           req.Tags,
+		SkipMarkers:    req.SkipMarkers,
+	}
+	if err := validateTriggerRules(rules); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.deps.ServiceRepo.GetByID(ctx, serviceID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.ServiceNotFound, "service not found: %s", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get service: %v", err)
+	}
+
+	if err := s.deps.TriggerRuleRepo.Upsert(ctx, rules); err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to save trigger rules: %v", err)
+	}
+
+	s.logger.Info().
+		Str("service_id", serviceID.String()).
+		Strs("branches", rules.Branches).
+		Strs("paths", rules.Paths).
+		Strs("tags", rules.Tags).
+		Msg("trigger rules set")
+
+	return &conductorv1.SetTriggerRulesResponse{
+		Rules: triggerRulesToProto(rules),
+	}, nil
+}
+
+// GetTriggerRules returns a service's trigger rules.
+func (s *ServiceRegistryServer) GetTriggerRules(ctx context.Context, req *conductorv1.GetTriggerRulesRequest) (*conductorv1.GetTriggerRulesResponse, error) {
+	if err := s.requireTriggerRules(); err != nil {
+		return nil, err
+	}
+
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	rules, err := s.deps.TriggerRuleRepo.GetByService(ctx, serviceID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.TriggerRulesNotFound, "trigger rules not found for service: %s", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get trigger rules: %v", err)
+	}
+
+	return &conductorv1.GetTriggerRulesResponse{
+		Rules: triggerRulesToProto(rules),
+	}, nil
+}
+
+// DeleteTriggerRules removes a service's trigger rules.
+func (s *ServiceRegistryServer) DeleteTriggerRules(ctx context.Context, req *conductorv1.DeleteTriggerRulesRequest) (*conductorv1.DeleteTriggerRulesResponse, error) {
+	if err := s.requireTriggerRules(); err != nil {
+		return nil, err
+	}
+
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	if err := s.deps.TriggerRuleRepo.Delete(ctx, serviceID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.TriggerRulesNotFound, "trigger rules not found for service: %s", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to delete trigger rules: %v", err)
+	}
+
+	s.logger.Info().
+		Str("service_id", serviceID.String()).
+		Msg("trigger rules deleted")
+
+	return &conductorv1.DeleteTriggerRulesResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *ServiceRegistryServer) requireTriggerRules() error {
+	if s.deps.TriggerRuleRepo == nil {
+		return errcode.New(errcode.NotConfigured, "trigger rules are not configured")
+	}
+	return nil
+}
+
+// SetRetryPolicy replaces the retry policy of a service or test definition.
+func (s *ServiceRegistryServer) SetRetryPolicy(ctx context.Context, req *conductorv1.SetRetryPolicyRequest) (*conductorv1.SetRetryPolicyResponse, error) {
+	if err := s.requireRetryPolicies(); err != nil {
+		return nil, err
+	}
+
+	service, err := s.getService(ctx, req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+
+	testDefID, err := s.retryPolicyTarget(ctx, service.ID, req.TestDefinitionId)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &database.RetryPolicy{
+		ServiceID:         service.ID,
+		TestDefinitionID:  testDefID,
+		MaxAttempts:       int(req.MaxAttempts),
+		BackoffSeconds:    defaultRetryBackoffSeconds,
+		MaxBackoffSeconds: defaultRetryMaxBackoffSeconds,
+		RetryOn:           database.RetryOnError,
+	}
+	if req.BackoffSeconds != nil {
+		policy.BackoffSeconds = int(*req.BackoffSeconds)
+	}
+	if req.MaxBackoffSeconds != nil {
+		policy.MaxBackoffSeconds = int(*req.MaxBackoffSeconds)
+	}
+	if req.RetryOn == conductorv1.RetryCondition_RETRY_CONDITION_FAILURE {
+		policy.RetryOn = database.RetryOnFailure
+	}
+	if err := validateRetryPolicy(policy); err != nil {
+		return nil, err
+	}
+
+	if err := s.deps.RetryPolicyRepo.Upsert(ctx, policy); err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to save retry policy: %v", err)
+	}
+
+	s.logger.Info().
+		Str("service_id", service.ID.String()).
+		Str("test_definition_id", req.TestDefinitionId).
+		Int("max_attempts", policy.MaxAttempts).
+		Str("retry_on", string(policy.RetryOn)).
+		Msg("retry policy set")
+
+	return &conductorv1.SetRetryPolicyResponse{
+		Policy: retryPolicyToProto(policy),
+	}, nil
+}
+
+// ListRetryPolicies returns the retry policies of a service and its test definitions.
+func (s *ServiceRegistryServer) ListRetryPolicies(ctx context.Context, req *conductorv1.ListRetryPoliciesRequest) (*conductorv1.ListRetryPoliciesResponse, error) {
+	if err := s.requireRetryPolicies(); err != nil {
+		return nil, err
+	}
+
+	service, err := s.getService(ctx, req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := s.deps.RetryPolicyRepo.ListByService(ctx, service.ID)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list retry policies: %v", err)
+	}
+
+	protoPolicies := make([]*conductorv1.RetryPolicy, len(policies))
+	for i := range policies {
+		protoPolicies[i] = retryPolicyToProto(&policies[i])
+	}
+
+	return &conductorv1.ListRetryPoliciesResponse{
+		Policies: protoPolicies,
+	}, nil
+}
+
+// DeleteRetryPolicy removes the retry policy of a service or test definition.
+func (s *ServiceRegistryServer) DeleteRetryPolicy(ctx context.Context, req *conductorv1.DeleteRetryPolicyRequest) (*conductorv1.DeleteRetryPolicyResponse, error) {
+	if err := s.requireRetryPolicies(); err != nil {
+		return nil, err
+	}
+
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	var testDefID *uuid.UUID
+	if req.TestDefinitionId != "" {
+		id, err := uuid.Parse(req.TestDefinitionId)
+		if err != nil {
+			return nil, errcode.New(errcode.InvalidArgument, "invalid test definition ID: %v", err)
+		}
+		testDefID = &id
+	}
+
+	if err := s.deps.RetryPolicyRepo.Delete(ctx, serviceID, testDefID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.RetryPolicyNotFound, "retry policy not found for service: %s", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to delete retry policy: %v", err)
+	}
+
+	s.logger.Info().
+		Str("service_id", serviceID.String()).
+		Str("test_definition_id", req.TestDefinitionId).
+		Msg("retry policy deleted")
+
+	return &conductorv1.DeleteRetryPolicyResponse{
+		Success: true,
+	}, nil
+}
+
+// checkAgentPool verifies that the agent pool a service is pinned to
+// exists. Pools are not checked without a pool repository.
+func (s *ServiceRegistryServer) checkAgentPool(ctx context.Context, pool string) error {
+	if pool == "" || s.deps.PoolRepo == nil {
+		return nil
+	}
+
+	if _, err := s.deps.PoolRepo.GetByName(ctx, pool); err != nil {
+		if database.IsNotFound(err) {
+			return errcode.New(errcode.AgentPoolNotFound, "agent pool not found: %s", pool)
+		}
+		return errcode.New(errcode.Internal, "failed to get agent pool: %v", err)
+	}
+	return nil
+}
+
+func (s *ServiceRegistryServer) requireRetryPolicies() error {
+	if s.deps.RetryPolicyRepo == nil {
+		return errcode.New(errcode.NotConfigured, "retry policies are not configured")
+	}
+	return nil
+}
+
+// retryPolicyTarget resolves the test definition a retry policy applies to;
+// nil means the service policy.
+func (s *ServiceRegistryServer) retryPolicyTarget(ctx context.Context, serviceID uuid.UUID, testDefinitionID string) (*uuid.UUID, error) {
+	if testDefinitionID == "" {
+		return nil, nil
+	}
+
+	testID, err := uuid.Parse(testDefinitionID)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid test definition ID: %v", err)
+	}
+
+	if _, err := s.deps.TestRepo.GetByID(ctx, serviceID, testID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.TestNotFound, "test not found: %s", testDefinitionID)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get test: %v", err)
+	}
+	return &testID, nil
+}
+
+// ListBranches lists a service's branches with their latest run and health.
+func (s *ServiceRegistryServer) ListBranches(ctx context.Context, req *conductorv1.ListBranchesRequest) (*conductorv1.ListBranchesResponse, error) {
+	if err := s.requireBranches(); err != nil {
+		return nil, err
+	}
+
+	service, err := s.getService(ctx, req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+
+	pagination := paginationFromProto(req.Pagination)
+	branches, total, err := s.deps.BranchRepo.ListByService(ctx, service.ID, pagination)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list branches: %v", err)
+	}
+
+	protoBranches := make([]*conductorv1.Branch, len(branches))
+	for i := range branches {
+		protoBranches[i] = branchToProto(&branches[i], service)
+	}
+
+	return &conductorv1.ListBranchesResponse{
+		Branches:   protoBranches,
+		Pagination: paginationResponseToProto(pagination, total),
+	}, nil
+}
+
+// GetBranch returns a branch and its run history.
+func (s *ServiceRegistryServer) GetBranch(ctx context.Context, req *conductorv1.GetBranchRequest) (*conductorv1.GetBranchResponse, error) {
+	if err := s.requireBranches(); err != nil {
+		return nil, err
+	}
+
+	service, err := s.getService(ctx, req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+
+	branch, err := s.getBranch(ctx, service.ID, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &conductorv1.GetBranchResponse{
+		Branch: branchToProto(branch, service),
+	}
+	if s.deps.RunRepo == nil {
+		return resp, nil
+	}
+
+	pagination := paginationFromProto(req.Pagination)
+	runs, total, err := s.deps.RunRepo.List(ctx, RunFilter{
+		ServiceID: &service.ID,
+		Branch:    branch.Name,
+	}, pagination)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list runs: %v", err)
+	}
+
+	resp.Runs = make([]*conductorv1.Run, len(runs))
+	for i, run := range runs {
+		resp.Runs[i] = runToProto(run, service)
+	}
+	resp.Pagination = paginationResponseToProto(pagination, total)
+	return resp, nil
+}
+
+// UpdateBranch updates a branch's protection flag.
+func (s *ServiceRegistryServer) UpdateBranch(ctx context.Context, req *conductorv1.UpdateBranchRequest) (*conductorv1.UpdateBranchResponse, error) {
+	if err := s.requireBranches(); err != nil {
+		return nil, err
+	}
+
+	service, err := s.getService(ctx, req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Protected != nil {
+		if err := s.deps.BranchRepo.SetProtected(ctx, service.ID, req.Name, *req.Protected); err != nil {
+			if database.IsNotFound(err) {
+				return nil, errcode.New(errcode.BranchNotFound, "branch not found: %s", req.Name)
+			}
+			return nil, errcode.New(errcode.Internal, "failed to update branch: %v", err)
+		}
+
+		s.logger.Info().
+			Str("service_id", service.ID.String()).
+			Str("branch", req.Name).
+			Bool("protected", *req.Protected).
+			Msg("branch updated")
+	}
+
+	branch, err := s.getBranch(ctx, service.ID, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conductorv1.UpdateBranchResponse{
+		Branch: branchToProto(branch, service),
+	}, nil
+}
+
+func (s *ServiceRegistryServer) requireBranches() error {
+	if s.deps.BranchRepo == nil {
+		return errcode.New(errcode.NotConfigured, "branches are not configured")
+	}
+	return nil
+}
+
+// CreateSchedule creates a recurring run of a service.
+func (s *ServiceRegistryServer) CreateSchedule(ctx context.Context, req *conductorv1.CreateScheduleRequest) (*conductorv1.CreateScheduleResponse, error) {
+	if err := s.requireSchedules(); err != nil {
+		return nil, err
+	}
+
+	service, err := s.getService(ctx, req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireActive(service); err != nil {
+		return nil, err
+	}
+
+	if req.Name == "" {
+		return nil, errcode.New(errcode.InvalidArgument, "name is required")
+	}
+	cron, err := parseSchedule(req.CronExpression, req.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	sched := &database.ScheduledRun{
+		ServiceID:      service.ID,
+		Name:           req.Name,
+		CronExpression: req.CronExpression,
+		Timezone:       cron.Location().String(),
+		GitRef:         req.GitRef,
+		TestFilter:     req.Tags,
+		Enabled:        true,
+	}
+	if sched.GitRef == "" {
+		sched.GitRef = service.DefaultBranch
+	}
+	if req.Enabled != nil {
+		sched.Enabled = *req.Enabled
+	}
+	now := time.Now()
+	setNextRun(sched, cron, now)
+
+	if err := s.deps.ScheduleRepo.Create(ctx, sched); err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to create schedule: %v", err)
+	}
+
+	s.logger.Info().
+		Str("service_id", service.ID.String()).
+		Str("schedule_id", sched.ID.String()).
+		Str("cron", sched.CronExpression).
+		Str("timezone", sched.Timezone).
+		Msg("schedule created")
+
+	return &conductorv1.CreateScheduleResponse{
+		Schedule: scheduleToProto(sched, cron, now, defaultUpcomingFireTimes),
+	}, nil
+}
+
+// ListSchedules lists the schedules of a service.
+func (s *ServiceRegistryServer) ListSchedules(ctx context.Context, req *conductorv1.ListSchedulesRequest) (*conductorv1.ListSchedulesResponse, error) {
+	if err := s.requireSchedules(); err != nil {
+		return nil, err
+	}
+
+	service, err := s.getService(ctx, req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules, err := s.deps.ScheduleRepo.ListByService(ctx, service.ID)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list schedules: %v", err)
+	}
+
+	now := time.Now()
+	protoSchedules := make([]*conductorv1.Schedule, len(schedules))
+	for i := range schedules {
+		protoSchedules[i] = scheduleToProto(&schedules[i], s.storedCron(&schedules[i]), now, defaultUpcomingFireTimes)
+	}
+
+	return &conductorv1.ListSchedulesResponse{
+		Schedules: protoSchedules,
+	}, nil
+}
+
+// GetSchedule returns a schedule with its upcoming fire times.
+func (s *ServiceRegistryServer) GetSchedule(ctx context.Context, req *conductorv1.GetScheduleRequest) (*conductorv1.GetScheduleResponse, error) {
+	if err := s.requireSchedules(); err != nil {
+		return nil, err
+	}
+
+	count := int(req.UpcomingCount)
+	if count < 0 || count > maxUpcomingFireTimes {
+		return nil, errcode.New(errcode.InvalidArgument, "upcoming_count must be between 0 and %d", maxUpcomingFireTimes)
+	}
+	if count == 0 {
+		count = defaultUpcomingFireTimes
+	}
+
+	sched, err := s.getSchedule(ctx, req.ServiceId, req.ScheduleId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conductorv1.GetScheduleResponse{
+		Schedule: scheduleToProto(sched, s.storedCron(sched), time.Now(), count),
+	}, nil
+}
+
+// UpdateSchedule updates a schedule. Changing the cron expression, time
+// zone or enabled flag recomputes the next fire time.
+func (s *ServiceRegistryServer) UpdateSchedule(ctx context.Context, req *conductorv1.UpdateScheduleRequest) (*conductorv1.UpdateScheduleResponse, error) {
+	if err := s.requireSchedules(); err != nil {
+		return nil, err
+	}
+
+	sched, err := s.getSchedule(ctx, req.ServiceId, req.ScheduleId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			return nil, errcode.New(errcode.InvalidArgument, "name must not be empty")
+		}
+		sched.Name = *req.Name
+	}
+	if req.CronExpression != nil {
+		sched.CronExpression = *req.CronExpression
+	}
+	if req.Timezone != nil {
+		sched.Timezone = *req.Timezone
+	}
+	if req.GitRef != nil {
+		if *req.GitRef == "" {
+			return nil, errcode.New(errcode.InvalidArgument, "git_ref must not be empty")
+		}
+		sched.GitRef = *req.GitRef
+	}
+	if len(req.Tags) > 0 {
+		sched.TestFilter = req.Tags
+	}
+	if req.Enabled != nil {
+		sched.Enabled = *req.Enabled
+	}
+
+	cron, err := parseSchedule(sched.CronExpression, sched.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	sched.Timezone = cron.Location().String()
+
+	now := time.Now()
+	if req.CronExpression != nil || req.Timezone != nil || req.Enabled != nil {
+		setNextRun(sched, cron, now)
+	}
+
+	if err := s.deps.ScheduleRepo.Update(ctx, sched); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.ScheduleNotFound, "schedule not found: %s", req.ScheduleId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to update schedule: %v", err)
+	}
+
+	s.logger.Info().
+		Str("service_id", sched.ServiceID.String()).
+		Str("schedule_id", sched.ID.String()).
+		Str("cron", sched.CronExpression).
+		Str("timezone", sched.Timezone).
+		Bool("enabled", sched.Enabled).
+		Msg("schedule updated")
+
+	return &conductorv1.UpdateScheduleResponse{
+		Schedule: scheduleToProto(sched, cron, now, defaultUpcomingFireTimes),
+	}, nil
+}
+
+// DeleteSchedule deletes a schedule.
+func (s *ServiceRegistryServer) DeleteSchedule(ctx context.Context, req *conductorv1.DeleteScheduleRequest) (*conductorv1.DeleteScheduleResponse, error) {
+	if err := s.requireSchedules(); err != nil {
+		return nil, err
+	}
+
+	sched, err := s.getSchedule(ctx, req.ServiceId, req.ScheduleId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.deps.ScheduleRepo.Delete(ctx, sched.ID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.ScheduleNotFound, "schedule not found: %s", req.ScheduleId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to delete schedule: %v", err)
+	}
+
+	s.logger.Info().
+		Str("service_id", sched.ServiceID.String()).
+		Str("schedule_id", sched.ID.String()).
+		Msg("schedule deleted")
+
+	return &conductorv1.DeleteScheduleResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *ServiceRegistryServer) requireSchedules() error {
+	if s.deps.ScheduleRepo == nil {
+		return errcode.New(errcode.NotConfigured, "schedules are not configured")
+	}
+	return nil
+}
+
+// getSchedule looks up a schedule of a service by its ID strings.
+func (s *ServiceRegistryServer) getSchedule(ctx context.Context, serviceID, scheduleID string) (*database.ScheduledRun, error) {
+	service, err := s.getService(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.Parse(scheduleID)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid schedule ID: %v", err)
+	}
+
+	sched, err := s.deps.ScheduleRepo.Get(ctx, id)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.ScheduleNotFound, "schedule not found: %s", scheduleID)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get schedule: %v", err)
+	}
+	if sched.ServiceID != service.ID {
+		return nil, errcode.New(errcode.ScheduleNotFound, "schedule not found: %s", scheduleID)
+	}
+	return sched, nil
+}
+
+// storedCron parses the cron expression of a stored schedule. Schedules
+// stored before time zones were validated may not parse; they are returned
+// without fire times.
+func (s *ServiceRegistryServer) storedCron(sched *database.ScheduledRun) *schedule.Cron {
+	cron, err := schedule.Parse(sched.CronExpression, sched.Timezone)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("schedule_id", sched.ID.String()).Msg("invalid stored schedule")
+		return nil
+	}
+	return cron
+}
+
+// getService looks up a service by its ID string.
+func (s *ServiceRegistryServer) getService(ctx context.Context, id string) (*database.Service, error) {
+	serviceID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	service, err := s.deps.ServiceRepo.GetByID(ctx, serviceID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.ServiceNotFound, "service not found: %s", id)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get service: %v", err)
+	}
+	return service, nil
+}
+
+// requireActive rejects changes to archived services.
+func requireActive(service *database.Service) error {
+	if service.ArchivedAt != nil {
+		return errcode.New(errcode.ServiceArchived, "service is archived: %s", service.Name)
+	}
+	return nil
+}
+
+// getBranch looks up a branch of a service by name.
+func (s *ServiceRegistryServer) getBranch(ctx context.Context, serviceID uuid.UUID, name string) (*database.Branch, error) {
+	if name == "" {
+		return nil, errcode.New(errcode.InvalidArgument, "name is required")
+	}
+
+	branch, err := s.deps.BranchRepo.Get(ctx, serviceID, name)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.BranchNotFound, "branch not found: %s", name)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get branch: %v", err)
+	}
+	return branch, nil
+}
+
+// validateEnvironment checks environment variable names and secret
+// references and converts the references for storage.
+func validateEnvironment(env map[string]string, refs []*conductorv1.Secret) ([]database.SecretRef, error) {
+	for name := range env {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return nil, errcode.New(errcode.InvalidArgument, "invalid environment variable name: %q", name)
+		}
+	}
+
+	result := make([]database.SecretRef, 0, len(refs))
+	seen := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		if ref.GetName() == "" || strings.ContainsAny(ref.GetName(), "=\x00") {
+			return nil, errcode.New(errcode.InvalidArgument, "invalid secret name: %q", ref.GetName())
+		}
+		if ref.GetPath() == "" {
+			return nil, errcode.New(errcode.InvalidArgument, "secret %s: path is required", ref.GetName())
+		}
+		if seen[ref.GetName()] {
+			return nil, errcode.New(errcode.InvalidArgument, "duplicate secret: %s", ref.GetName())
+		}
+		seen[ref.GetName()] = true
+
+		result = append(result, database.SecretRef{
+			Name:     ref.GetName(),
+			Provider: secretProviderFromProto(ref.GetProvider()),
+			Path:     ref.GetPath(),
+			Key:      ref.GetKey(),
+			Version:  int(ref.GetVersion()),
+		})
+	}
+	return result, nil
+}
+
+// Helper functions
+
+func deployKeyToProto(key *database.ServiceDeployKey) *conductorv1.DeployKey {
+	protoKey := &conductorv1.DeployKey{
+		ServiceId:   key.ServiceID.String(),
+		PublicKey:   key.PublicKey,
+		Fingerprint: key.Fingerprint,
+		CreatedAt:   timestamppb.New(key.CreatedAt),
+		UpdatedAt:   timestamppb.New(key.UpdatedAt),
+	}
+	if key.KnownHosts != nil {
+		protoKey.KnownHosts = *key.KnownHosts
+	}
+	return protoKey
+}
+
+func gitCredentialToProto(cred *database.ServiceGitCredential) *conductorv1.GitCredential {
+	protoCred := &conductorv1.GitCredential{
+		ServiceId: cred.ServiceID.String(),
+		Provider:  cred.Provider,
+		CreatedAt: timestamppb.New(cred.CreatedAt),
+		UpdatedAt: timestamppb.New(cred.UpdatedAt),
+	}
+	if cred.BaseURL != nil {
+		protoCred.BaseUrl = *cred.BaseURL
+	}
+	return protoCred
+}
+
+func serviceEnvironmentToProto(env *database.ServiceEnvironment) *conductorv1.ServiceEnvironment {
+	return &conductorv1.ServiceEnvironment{
+		ServiceId:   env.ServiceID.String(),
+		Environment: env.Environment,
+		Secrets:     secretRefsToProto(env.Secrets),
+		CreatedAt:   timestamppb.New(env.CreatedAt),
+		UpdatedAt:   timestamppb.New(env.UpdatedAt),
+	}
+}
+
+func triggerRulesToProto(rules *database.ServiceTriggerRules) *conductorv1.TriggerRules {
+	return &conductorv1.TriggerRules{
+		ServiceId:      rules.ServiceID.String(),
+		Branches:       rules.Branches,
+		IgnoreBranches: rules.IgnoreBranches,
+		Paths:          rules.Paths,
+		IgnorePaths:    rules.IgnorePaths,
+		Tags:           rules.Tags,
+		SkipMarkers:    rules.SkipMarkers,
+		CreatedAt:      timestamppb.New(rules.CreatedAt),
+		UpdatedAt:      timestamppb.New(rules.UpdatedAt),
+	}
+}
+
+// Number of upcoming fire times returned with a schedule.
+const (
+	defaultUpcomingFireTimes = 5
+	maxUpcomingFireTimes     = 50
+)
+
+// parseSchedule validates the cron expression and time zone of a schedule.
+func parseSchedule(expr, timezone string) (*schedule.Cron, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, errcode.New(errcode.InvalidArgument, "cron_expression is required")
+	}
+	cron, err := schedule.Parse(expr, timezone)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "%v", err)
+	}
+	return cron, nil
+}
+
+// setNextRun sets the next fire time of a schedule after now; disabled
+// schedules have none.
+func setNextRun(sched *database.ScheduledRun, cron *schedule.Cron, now time.Time) {
+	sched.NextRunAt = nil
+	if !sched.Enabled {
+		return
+	}
+	if next := cron.Next(now); !next.IsZero() {
+		next = next.UTC()
+		sched.NextRunAt = &next
+	}
+}
+
+// scheduleToProto converts a schedule, resolving its fire times in both UTC
+// and the schedule's time zone. A nil cron omits the fire times.
+func scheduleToProto(sched *database.ScheduledRun, cron *schedule.Cron, now time.Time, upcoming int) *conductorv1.Schedule {
+	protoSchedule := &conductorv1.Schedule{
+		Id:             sched.ID.String(),
+		ServiceId:      sched.ServiceID.String(),
+		Name:           sched.Name,
+		CronExpression: sched.CronExpression,
+		Timezone:       sched.Timezone,
+		GitRef:         sched.GitRef,
+		Tags:           sched.TestFilter,
+		Enabled:        sched.Enabled,
+		CreatedAt:      timestamppb.New(sched.CreatedAt),
+		UpdatedAt:      timestamppb.New(sched.UpdatedAt),
+	}
+	if sched.LastRunAt != nil {
+		protoSchedule.LastRunAt = timestamppb.New(*sched.LastRunAt)
+	}
+	if sched.NextRunAt != nil {
+		protoSchedule.NextRunAt = timestamppb.New(*sched.NextRunAt)
+	}
+	if cron == nil {
+		return protoSchedule
+	}
+
+	protoSchedule.DstNotes = cron.DSTNotes(now, now.AddDate(1, 0, 0))
+	if sched.NextRunAt != nil {
+		protoSchedule.NextRunLocal = sched.NextRunAt.In(cron.Location()).Format(time.RFC3339)
+	}
+	if !sched.Enabled {
+		return protoSchedule
+	}
+
+	// Start from the stored next run, which may be due but not yet triggered
+	var times []time.Time
+	if sched.NextRunAt != nil {
+		times = append([]time.Time{*sched.NextRunAt}, cron.Upcoming(*sched.NextRunAt, upcoming-1)...)
+	} else {
+		times = cron.Upcoming(now, upcoming)
+	}
+	for _, t := range times {
+		local := t.In(cron.Location())
+		zone, _ := local.Zone()
+		protoSchedule.Upcoming = append(protoSchedule.Upcoming, &conductorv1.ScheduleFireTime{
+			At:    timestamppb.New(t),
+			Local: local.Format(time.RFC3339),
+			Zone:  zone,
+		})
+	}
+	return protoSchedule
+}
+
+// Retry policy defaults, matching the column defaults of retry_policies.
+const (
+	defaultRetryBackoffSeconds    = 30
+	defaultRetryMaxBackoffSeconds = 3600
+)
+
+// validateRetryPolicy checks the attempt and backoff bounds of a policy.
+func validateRetryPolicy(policy *database.RetryPolicy) error {
+	if policy.MaxAttempts < 1 {
+		return errcode.New(errcode.InvalidArgument, "max_attempts must be at least 1")
+	}
+	if policy.BackoffSeconds < 0 {
+		return errcode.New(errcode.InvalidArgument, "backoff_seconds must not be negative")
+	}
+	if policy.MaxBackoffSeconds < policy.BackoffSeconds {
+		return errcode.New(errcode.InvalidArgument, "max_backoff_seconds must be at least backoff_seconds")
+	}
+	return nil
+}
+
+func retryPolicyToProto(policy *database.RetryPolicy) *conductorv1.RetryPolicy {
+	protoPolicy := &conductorv1.RetryPolicy{
+		Id:                policy.ID.String(),
+		ServiceId:         policy.ServiceID.String(),
+		MaxAttempts:       int32(policy.MaxAttempts),
+		BackoffSeconds:    int32(policy.BackoffSeconds),
+		MaxBackoffSeconds: int32(policy.MaxBackoffSeconds),
+		RetryOn:           conductorv1.RetryCondition_RETRY_CONDITION_ERROR,
+		CreatedAt:         timestamppb.New(policy.CreatedAt),
+		UpdatedAt:         timestamppb.New(policy.UpdatedAt),
+	}
+	if policy.TestDefinitionID != nil {
+		protoPolicy.TestDefinitionId = policy.TestDefinitionID.String()
+	}
+	if policy.RetryOn == database.RetryOnFailure {
+		protoPolicy.RetryOn = conductorv1.RetryCondition_RETRY_CONDITION_FAILURE
+	}
+	return protoPolicy
+}
+
+func syncRecordToProto(sync *database.ServiceSync) *conductorv1.SyncRecord {
+	return &conductorv1.SyncRecord{
+		Id:           sync.ID.String(),
+		Branch:       sync.Branch,
+		Trigger:      string(sync.Trigger),
+		Status:       string(sync.Status),
+		TestsAdded:   int32(sync.TestsAdded),
+		TestsUpdated: int32(sync.TestsUpdated),
+		TestsRemoved: int32(sync.TestsRemoved),
+		Errors:       sync.Errors,
+		StartedAt:    timestamppb.New(sync.StartedAt),
+		FinishedAt:   timestamppb.New(sync.FinishedAt),
+	}
+}
+
+func branchToProto(branch *database.Branch, service *database.Service) *conductorv1.Branch {
+	protoBranch := &conductorv1.Branch{
+		ServiceId:    branch.ServiceID.String(),
+		Name:         branch.Name,
+		Protected:    branch.Protected,
+		IsDefault:    service != nil && branch.Name == service.DefaultBranch,
+		RecentRuns:   int32(branch.RecentRuns),
+		RecentPassed: int32(branch.RecentPassed),
+		CreatedAt:    timestamppb.New(branch.CreatedAt),
+		UpdatedAt:    timestamppb.New(branch.UpdatedAt),
+	}
+	if branch.LastSHA != nil {
+		protoBranch.LastSha = *branch.LastSHA
+	}
+	if branch.LastRunID != nil {
+		protoBranch.LastRunId = branch.LastRunID.String()
+	}
+	if branch.LastRunStatus != nil {
+		protoBranch.LastRunStatus = runStatusToProto(*branch.LastRunStatus)
+	}
+	if branch.LastRunAt != nil {
+		protoBranch.LastRunAt = timestamppb.New(*branch.LastRunAt)
+	}
+	if branch.LastPushedAt != nil {
+		protoBranch.LastPushedAt = timestamppb.New(*branch.LastPushedAt)
+	}
+	if branch.RecentRuns > 0 {
+		protoBranch.PassRate = float64(branch.RecentPassed) / float64(branch.RecentRuns)
+	}
+	return protoBranch
+}
+
+func secretRefsToProto(refs []database.SecretRef) []*conductorv1.Secret {
+	if len(refs) == 0 {
+		return nil
+	}
+	result := make([]*conductorv1.Secret, 0, len(refs))
+	for _, ref := range refs {
+		result = append(result, &conductorv1.Secret{
+			Name:     ref.Name,
+			Provider: secretProviderToProto(ref.Provider),
+			Path:     ref.Path,
+			Key:      ref.Key,
+			Version:  int32(ref.Version),
+		})
+	}
+	return result
+}
+
+func secretProviderFromProto(p conductorv1.SecretProvider) string {
+	switch p {
+	case conductorv1.SecretProvider_SECRET_PROVIDER_VAULT:
+		return string(secrets.ProviderVault)
+	case conductorv1.SecretProvider_SECRET_PROVIDER_AWS_SECRETS_MANAGER:
+		return string(secrets.ProviderAWS)
+	case conductorv1.SecretProvider_SECRET_PROVIDER_ENV_FILE:
+		return string(secrets.ProviderEnvFile)
+	default:
+		return ""
+	}
+}
+
+func secretProviderToProto(p string) conductorv1.SecretProvider {
+	switch p {
+	case string(secrets.ProviderVault):
+		return conductorv1.SecretProvider_SECRET_PROVIDER_VAULT
+	case string(secrets.ProviderAWS):
+		return conductorv1.SecretProvider_SECRET_PROVIDER_AWS_SECRETS_MANAGER
+	case string(secrets.ProviderEnvFile):
+		return conductorv1.SecretProvider_SECRET_PROVIDER_ENV_FILE
+	default:
+		return conductorv1.SecretProvider_SECRET_PROVIDER_UNSPECIFIED
+	}
+}
+
+func branchRunPolicyFromProto(p conductorv1.BranchRunPolicy) database.BranchRunPolicy {
+	switch p {
+	case conductorv1.BranchRunPolicy_BRANCH_RUN_POLICY_ALLOW:
+		return database.BranchRunPolicyAllow
+	case conductorv1.BranchRunPolicy_BRANCH_RUN_POLICY_SUPERSEDE:
+		return database.BranchRunPolicySupersede
+	case conductorv1.BranchRunPolicy_BRANCH_RUN_POLICY_COALESCE:
+		return database.BranchRunPolicyCoalesce
+	default:
+		return ""
+	}
+}
+
+func branchRunPolicyToProto(p database.BranchRunPolicy) conductorv1.BranchRunPolicy {
+	switch p {
+	case database.BranchRunPolicySupersede:
+		return conductorv1.BranchRunPolicy_BRANCH_RUN_POLICY_SUPERSEDE
+	case database.BranchRunPolicyCoalesce:
+		return conductorv1.BranchRunPolicy_BRANCH_RUN_POLICY_COALESCE
+	default:
+		return conductorv1.BranchRunPolicy_BRANCH_RUN_POLICY_ALLOW
+	}
+}
+
+func serviceToProto(svc *database.Service) *conductorv1.Service {
+	if svc == nil {
+		return nil
+	}
+
+	protoSvc := &conductorv1.Service{
+		Id:              svc.ID.String(),
+		Name:            svc.Name,
+		GitUrl:          svc.GitURL,
+		DefaultBranch:   svc.DefaultBranch,
+		NetworkZones:    svc.NetworkZones,
+		Active:          svc.ArchivedAt == nil,
+		CreatedAt:       timestamppb.New(svc.CreatedAt),
+		UpdatedAt:       timestamppb.New(svc.UpdatedAt),
+		BranchRunPolicy: branchRunPolicyToProto(svc.BranchRunPolicy),
+	}
+
+	if svc.Owner != nil {
+		protoSvc.Owner = *svc.Owner
+	}
+	if svc.RootPath != nil {
+		protoSvc.RootPath = *svc.RootPath
+	}
+	if svc.SyncIntervalSeconds != nil {
+		interval := int32(*svc.SyncIntervalSeconds)
+		protoSvc.SyncIntervalSeconds = &interval
+	}
+	if svc.AgentPool != nil {
+		protoSvc.AgentPool = *svc.AgentPool
+	}
+	if svc.ArchivedAt != nil {
+		protoSvc.ArchivedAt = timestamppb.New(*svc.ArchivedAt)
+	}
+	if svc.ProjectID != uuid.Nil {
+		protoSvc.ProjectId = svc.ProjectID.String()
+	}
+
+	if svc.ContactSlack != nil || svc.ContactEmail != nil {
+		protoSvc.Contact = &conductorv1.Contact{}
+		if svc.ContactSlack != nil {
+			protoSvc.Contact.Slack = *svc.ContactSlack
+		}
+		if svc.ContactEmail != nil {
+			protoSvc.Contact.Email = *svc.ContactEmail
+		}
+	}
+
+	return protoSvc
+}
+
+// artifactBudgetToProto returns the artifact budget of a test definition,
+// or nil if its artifacts are unlimited.
+func artifactBudgetToProto(test *database.TestDefinition) *conductorv1.ArtifactBudget {
+	if test.ArtifactMaxBytes == nil && test.ArtifactMaxFiles == nil {
+		return nil
+	}
+
+	budget := &conductorv1.ArtifactBudget{}
+	if test.ArtifactMaxBytes != nil {
+		budget.MaxBytes = *test.ArtifactMaxBytes
+	}
+	if test.ArtifactMaxFiles != nil {
+		budget.MaxFiles = int32(*test.ArtifactMaxFiles)
+	}
+	return budget
+}
+
+// artifactBudgetFromProto converts an artifact budget to the limits stored
+// on a test definition. Unset limits are nil.
+func artifactBudgetFromProto(budget *conductorv1.ArtifactBudget) (*int64, *int, error) {
+	if budget == nil {
+		return nil, nil, nil
+	}
+	if budget.MaxBytes < 0 || budget.MaxFiles < 0 {
+		return nil, nil, errcode.New(errcode.InvalidArgument, "artifact budget limits cannot be negative")
+	}
+
+	var maxBytes *int64
+	var maxFiles *int
+	if budget.MaxBytes > 0 {
+		b := budget.MaxBytes
+		maxBytes = &b
+	}
+	if budget.MaxFiles > 0 {
+		f := int(budget.MaxFiles)
+		maxFiles = &f
+	}
+	return maxBytes, maxFiles, nil
+}
+
+// checkTestDependencies checks that a new or updated test only depends on
+// other tests of its service, without cycles.
+func (s *ServiceRegistryServer) checkTestDependencies(ctx context.Context, test *database.TestDefinition) error {
+	if len(test.DependsOn) == 0 {
+		return nil
+	}
+
+	existing, _, err := s.deps.TestRepo.ListByService(ctx, test.ServiceID, TestDefinitionFilter{}, database.Pagination{Limit: 1000})
+	if err != nil {
+		return errcode.New(errcode.Internal, "failed to list tests: %v", err)
+	}
+	tests := []database.TestDefinition{*test}
+	for _, other := range existing {
+		if other.ID != test.ID {
+			tests = append(tests, *other)
+		}
+	}
+
+	if err := database.ValidateTestDependencies(tests); err != nil {
+		return errcode.New(errcode.InvalidArgument, "invalid depends_on: %v", err)
+	}
+	return nil
+}
+
+// testMatrixFromProto converts and validates a test matrix. A matrix
+// without axes converts to nil.
+func testMatrixFromProto(matrix *conductorv1.TestMatrix) (*database.TestMatrix, error) {
+	if len(matrix.GetEnv()) == 0 && len(matrix.GetLabels()) == 0 {
+		return nil, nil
+	}
+
+	axes := func(kind string, axes []*conductorv1.MatrixAxis) (map[string][]string, error) {
+		if len(axes) == 0 {
+			return nil, nil
+		}
+		result := make(map[string][]string, len(axes))
+		for _, axis := range axes {
+			if _, ok := result[axis.Name]; ok {
+				return nil, errcode.New(errcode.InvalidArgument, "invalid matrix: %s axis %s is declared twice", kind, axis.Name)
+			}
+			result[axis.Name] = axis.Values
+		}
+		return result, nil
+	}
+
+	env, err := axes("env", matrix.GetEnv())
+	if err != nil {
+		return nil, err
+	}
+	labels, err := axes("label", matrix.GetLabels())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &database.TestMatrix{Env: env, Labels: labels}
+	if err := result.Validate(); err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid matrix: %v", err)
+	}
+	return result, nil
+}
+
+// testMatrixToProto converts a test matrix to its API form, with axes
+// ordered by name.
+func testMatrixToProto(matrix *database.TestMatrix) *conductorv1.TestMatrix {
+	if matrix == nil {
+		return nil
+	}
+
+	axes := func(values map[string][]string) []*conductorv1.MatrixAxis {
+		result := make([]*conductorv1.MatrixAxis, 0, len(values))
+		for _, name := range slices.Sorted(maps.Keys(values)) {
+			result = append(result, &conductorv1.MatrixAxis{Name: name, Values: values[name]})
+		}
+		return result
+	}
+	return &conductorv1.TestMatrix{Env: axes(matrix.Env), Labels: axes(matrix.Labels)}
+}
+
+// testParametersFromProto converts and validates the parameter schema of a
+// test.
+func testParametersFromProto(params []*conductorv1.TestParameter) (database.TestParameters, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+
+	result := make(database.TestParameters, len(params))
+	for i, param := range params {
+		result[i] = database.TestParameter{
+			Name:        param.Name,
+			Description: param.Description,
+			Type:        testParameterTypeFromProto(param.Type),
+			Required:    param.Required,
+			Default:     param.DefaultValue,
+			Choices:     param.Choices,
+		}
+	}
+	if err := result.Validate(); err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid parameters: %v", err)
+	}
+	return result, nil
+}
+
+func testParametersToProto(params database.TestParameters) []*conductorv1.TestParameter {
+	if len(params) == 0 {
+		return nil
+	}
+
+	result := make([]*conductorv1.TestParameter, len(params))
+	for i, param := range params {
+		result[i] = &conductorv1.TestParameter{
+			Name:         param.Name,
+			Description:  param.Description,
+			Type:         testParameterTypeToProto(param.Type),
+			Required:     param.Required,
+			DefaultValue: param.Default,
+			Choices:      param.Choices,
+		}
+	}
+	return result
+}
+
+func testParameterTypeFromProto(t conductorv1.TestParameterType) database.TestParameterType {
+	switch t {
+	case conductorv1.TestParameterType_TEST_PARAMETER_TYPE_INTEGER:
+		return database.TestParameterInteger
+	case conductorv1.TestParameterType_TEST_PARAMETER_TYPE_BOOLEAN:
+		return database.TestParameterBoolean
+	default:
+		return database.TestParameterString
+	}
+}
+
+func testParameterTypeToProto(t database.TestParameterType) conductorv1.TestParameterType {
+	switch t {
+	case database.TestParameterInteger:
+		return conductorv1.TestParameterType_TEST_PARAMETER_TYPE_INTEGER
+	case database.TestParameterBoolean:
+		return conductorv1.TestParameterType_TEST_PARAMETER_TYPE_BOOLEAN
+	default:
+		return conductorv1.TestParameterType_TEST_PARAMETER_TYPE_STRING
+	}
+}
+
+func testDefinitionToProto(test *database.TestDefinition) *conductorv1.TestDefinition {
+	if test == nil {
+		return nil
+	}
+
+	protoTest := &conductorv1.TestDefinition{
+		Id:               test.ID.String(),
+		ServiceId:        test.ServiceID.String(),
+		Name:             test.Name,
+		Command:          test.Command,
+		Tags:             test.Tags,
+		Environment:      test.Environment,
+		Secrets:          secretRefsToProto(test.Secrets),
+		Enabled:          !test.AllowFailure,
+		CreatedAt:        timestamppb.New(test.CreatedAt),
+		UpdatedAt:        timestamppb.New(test.UpdatedAt),
+		LabelSelector:    test.LabelSelector,
+		Architectures:    test.Architectures,
+		ArtifactPaths:    test.ArtifactPatterns,
+		ArtifactBudget:   artifactBudgetToProto(test),
+		Matrix:           testMatrixToProto(test.Matrix),
+		DependsOn:        test.DependsOn,
+		RequiresApproval: test.RequiresApproval,
+		CapturePaths:     test.CapturePatterns,
+		Parameters:       testParametersToProto(test.Parameters),
+	}
+
+	if test.TimeoutSeconds > 0 {
+		protoTest.Timeout = &conductorv1.Duration{
+			Seconds: int64(test.TimeoutSeconds),
+		}
+	}
+
+	if test.Retries > 0 {
+		protoTest.RetryCount = int32(test.Retries)
+	}
+
+	if test.ResultFormat != nil {
+		protoTest.ResultFormat = resultFormatToProto(*test.ResultFormat)
+	}
+
+	if test.Owner != nil {
+		protoTest.Owner = *test.Owner
+	}
+
+	return protoTest
+}
+
+func testTypeFromProto(t conductorv1.TestType) string {
+	switch t {
+	case conductorv1.TestType_TEST_TYPE_UNIT:
+		return "unit"
+	case conductorv1.TestType_TEST_TYPE_INTEGRATION:
+		return "integration"
+	case conductorv1.TestType_TEST_TYPE_E2E:
+		return "e2e"
+	case conductorv1.TestType_TEST_TYPE_PERFORMANCE:
+		return "performance"
+	case conductorv1.TestType_TEST_TYPE_SECURITY:
+		return "security"
+	case conductorv1.TestType_TEST_TYPE_SMOKE:
+		return "smoke"
+	default:
+		return ""
+	}
+}
+
+// resultFormatFromProto converts a result format to its stored name. The
+// unspecified format is nil.
+func resultFormatFromProto(format conductorv1.ResultFormat) *string {
+	var name string
+	switch format {
+	case conductorv1.ResultFormat_RESULT_FORMAT_JUNIT:
+		name = "junit"
+	case conductorv1.ResultFormat_RESULT_FORMAT_JEST:
+		name = "jest"
+	case conductorv1.ResultFormat_RESULT_FORMAT_PLAYWRIGHT:
+		name = "playwright"
+	case conductorv1.ResultFormat_RESULT_FORMAT_GO_TEST:
+		name = "go_test"
+	case conductorv1.ResultFormat_RESULT_FORMAT_TAP:
+		name = "tap"
+	case conductorv1.ResultFormat_RESULT_FORMAT_JSON:
+		name = "json"
+	default:
+		return nil
 	}
+	return &name
 }
 
 func resultFormatToProto(format string) conductorv1.ResultFormat {
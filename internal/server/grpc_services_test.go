@@ -0,0 +1,299 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// registryServiceRepo returns a fixed service.
+type registryServiceRepo struct {
+	FullServiceRepository
+	service *database.Service
+}
+
+func (r *registryServiceRepo) GetByID(ctx context.Context, id uuid.UUID) (*database.Service, error) {
+	return r.service, nil
+}
+
+// registryTestRepo records created test definitions and rejects duplicate
+// names like the unique constraint does.
+type registryTestRepo struct {
+	TestDefinitionRepository
+	created []*database.TestDefinition
+}
+
+func (r *registryTestRepo) Create(ctx context.Context, test *database.TestDefinition) error {
+	for _, existing := range r.created {
+		if existing.ServiceID == test.ServiceID && existing.Name == test.Name {
+			return fmt.Errorf("%w: name %s", database.ErrDuplicate, test.Name)
+		}
+	}
+	r.created = append(r.created, test)
+	return nil
+}
+
+func (r *registryTestRepo) ListByService(ctx context.Context, serviceID uuid.UUID, filter TestDefinitionFilter, pagination database.Pagination) ([]*database.TestDefinition, int, error) {
+	return r.created, len(r.created), nil
+}
+
+func TestCreateTestDefinition(t *testing.T) {
+	service := &database.Service{ID: uuid.New(), Name: "api"}
+
+	newServer := func() (*ServiceRegistryServer, *registryTestRepo) {
+		tests := &registryTestRepo{}
+		return NewServiceRegistryServer(ServiceRegistryDeps{
+			ServiceRepo: &registryServiceRepo{service: service},
+			TestRepo:    tests,
+		}, zerolog.Nop()), tests
+	}
+
+	t.Run("creates definition", func(t *testing.T) {
+		server, tests := newServer()
+		resp, err := server.CreateTestDefinition(context.Background(), &conductorv1.CreateTestDefinitionRequest{
+			ServiceId:      service.ID.String(),
+			Name:           "smoke",
+			Command:        "make smoke",
+			ResultFormat:   conductorv1.ResultFormat_RESULT_FORMAT_JUNIT,
+			Timeout:        &conductorv1.Duration{Seconds: 300},
+			Tags:           []string{"smoke"},
+			ArtifactPaths:  []string{"reports/*.xml"},
+			Architectures:  []string{"arm64"},
+			ArtifactBudget: &conductorv1.ArtifactBudget{MaxFiles: 5},
+			Matrix: &conductorv1.TestMatrix{
+				Env: []*conductorv1.MatrixAxis{{Name: "GO_VERSION", Values: []string{"1.22", "1.23"}}},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, tests.created, 1)
+
+		created := tests.created[0]
+		assert.Equal(t, service.ID, created.ServiceID)
+		assert.Equal(t, "subprocess", created.ExecutionType)
+		assert.Equal(t, 300, created.TimeoutSeconds)
+		require.NotNil(t, created.ResultFormat)
+		assert.Equal(t, "junit", *created.ResultFormat)
+		assert.Nil(t, created.ArtifactMaxBytes)
+		require.NotNil(t, created.ArtifactMaxFiles)
+		assert.Equal(t, 5, *created.ArtifactMaxFiles)
+		require.NotNil(t, created.Matrix)
+		assert.Len(t, created.Matrix.Cells(), 2)
+
+		assert.Equal(t, created.ID.String(), resp.Test.Id)
+		assert.Equal(t, []string{"reports/*.xml"}, resp.Test.ArtifactPaths)
+		assert.Equal(t, []string{"arm64"}, resp.Test.Architectures)
+		require.Len(t, resp.Test.Matrix.Env, 1)
+		assert.Equal(t, []string{"1.22", "1.23"}, resp.Test.Matrix.Env[0].Values)
+	})
+
+	t.Run("depends on tests of the service", func(t *testing.T) {
+		server, tests := newServer()
+		_, err := server.CreateTestDefinition(context.Background(), &conductorv1.CreateTestDefinitionRequest{
+			ServiceId: service.ID.String(), Name: "unit", Command: "make unit",
+		})
+		require.NoError(t, err)
+
+		resp, err := server.CreateTestDefinition(context.Background(), &conductorv1.CreateTestDefinitionRequest{
+			ServiceId: service.ID.String(), Name: "integration", Command: "make integration", DependsOn: []string{"unit"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"unit"}, resp.Test.DependsOn)
+		require.Len(t, tests.created, 2)
+		assert.Equal(t, []string{"unit"}, tests.created[1].DependsOn)
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		server, _ := newServer()
+		req := &conductorv1.CreateTestDefinitionRequest{ServiceId: service.ID.String(), Name: "smoke", Command: "make smoke"}
+		_, err := server.CreateTestDefinition(context.Background(), req)
+		require.NoError(t, err)
+
+		_, err = server.CreateTestDefinition(context.Background(), req)
+		assert.True(t, errcode.Is(err, errcode.TestAlreadyExists))
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		server, tests := newServer()
+		for name, req := range map[string]*conductorv1.CreateTestDefinitionRequest{
+			"missing name":    {ServiceId: service.ID.String(), Command: "make"},
+			"missing command": {ServiceId: service.ID.String(), Name: "smoke"},
+			"negative budget": {ServiceId: service.ID.String(), Name: "smoke", Command: "make", ArtifactBudget: &conductorv1.ArtifactBudget{MaxBytes: -1}},
+			"invalid arch":    {ServiceId: service.ID.String(), Name: "smoke", Command: "make", Architectures: []string{"arm 64"}},
+			"empty matrix axis": {ServiceId: service.ID.String(), Name: "smoke", Command: "make", Matrix: &conductorv1.TestMatrix{
+				Labels: []*conductorv1.MatrixAxis{{Name: "os"}},
+			}},
+			"invalid service":    {ServiceId: "not-a-uuid", Name: "smoke", Command: "make"},
+			"negative retries":   {ServiceId: service.ID.String(), Name: "smoke", Command: "make", RetryCount: -1},
+			"unknown dependency": {ServiceId: service.ID.String(), Name: "smoke", Command: "make", DependsOn: []string{"unit"}},
+			"self dependency":    {ServiceId: service.ID.String(), Name: "smoke", Command: "make", DependsOn: []string{"smoke"}},
+		} {
+			_, err := server.CreateTestDefinition(context.Background(), req)
+			assert.True(t, errcode.Is(err, errcode.InvalidArgument), name)
+		}
+		assert.Empty(t, tests.created)
+	})
+}
+
+// registryScheduleRepo stores schedules in memory.
+type registryScheduleRepo struct {
+	database.ScheduleRepository
+	schedules map[uuid.UUID]*database.ScheduledRun
+}
+
+func (r *registryScheduleRepo) Create(ctx context.Context, schedule *database.ScheduledRun) error {
+	schedule.ID = uuid.New()
+	r.schedules[schedule.ID] = schedule
+	return nil
+}
+
+func (r *registryScheduleRepo) Get(ctx context.Context, id uuid.UUID) (*database.ScheduledRun, error) {
+	schedule, ok := r.schedules[id]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	stored := *schedule
+	return &stored, nil
+}
+
+func (r *registryScheduleRepo) Update(ctx context.Context, schedule *database.ScheduledRun) error {
+	r.schedules[schedule.ID] = schedule
+	return nil
+}
+
+func TestSchedules(t *testing.T) {
+	service := &database.Service{ID: uuid.New(), Name: "api", DefaultBranch: "main"}
+
+	newServer := func() (*ServiceRegistryServer, *registryScheduleRepo) {
+		schedules := &registryScheduleRepo{schedules: make(map[uuid.UUID]*database.ScheduledRun)}
+		return NewServiceRegistryServer(ServiceRegistryDeps{
+			ServiceRepo:  &registryServiceRepo{service: service},
+			ScheduleRepo: schedules,
+		}, zerolog.Nop()), schedules
+	}
+
+	t.Run("resolves fire times in the time zone", func(t *testing.T) {
+		server, schedules := newServer()
+		resp, err := server.CreateSchedule(context.Background(), &conductorv1.CreateScheduleRequest{
+			ServiceId:      service.ID.String(),
+			Name:           "nightly",
+			CronExpression: "30 2 * * *",
+			Timezone:       "America/New_York",
+		})
+		require.NoError(t, err)
+		require.Len(t, schedules.schedules, 1)
+
+		stored := schedules.schedules[uuid.MustParse(resp.Schedule.Id)]
+		assert.Equal(t, "main", stored.GitRef)
+		assert.True(t, stored.Enabled)
+		require.NotNil(t, stored.NextRunAt)
+
+		s := resp.Schedule
+		assert.Equal(t, "America/New_York", s.Timezone)
+		assert.True(t, s.NextRunAt.AsTime().Equal(*stored.NextRunAt))
+		require.Len(t, s.Upcoming, 5)
+		for _, fire := range s.Upcoming {
+			local, err := time.Parse(time.RFC3339, fire.Local)
+			require.NoError(t, err)
+			assert.True(t, local.Equal(fire.At.AsTime()))
+			assert.Contains(t, []string{"EST", "EDT"}, fire.Zone)
+		}
+		assert.Equal(t, s.Upcoming[0].Local, s.NextRunLocal)
+		// Every year has a spring-forward gap that skips 02:30
+		require.NotEmpty(t, s.DstNotes)
+		assert.Contains(t, s.DstNotes[0], "02:30")
+	})
+
+	t.Run("defaults to UTC", func(t *testing.T) {
+		server, _ := newServer()
+		resp, err := server.CreateSchedule(context.Background(), &conductorv1.CreateScheduleRequest{
+			ServiceId:      service.ID.String(),
+			Name:           "hourly",
+			CronExpression: "@hourly",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "UTC", resp.Schedule.Timezone)
+		assert.Empty(t, resp.Schedule.DstNotes)
+	})
+
+	t.Run("update recomputes next run", func(t *testing.T) {
+		server, _ := newServer()
+		created, err := server.CreateSchedule(context.Background(), &conductorv1.CreateScheduleRequest{
+			ServiceId:      service.ID.String(),
+			Name:           "nightly",
+			CronExpression: "0 3 * * *",
+		})
+		require.NoError(t, err)
+
+		disabled := false
+		resp, err := server.UpdateSchedule(context.Background(), &conductorv1.UpdateScheduleRequest{
+			ServiceId:  service.ID.String(),
+			ScheduleId: created.Schedule.Id,
+			Enabled:    &disabled,
+		})
+		require.NoError(t, err)
+		assert.Nil(t, resp.Schedule.NextRunAt)
+		assert.Empty(t, resp.Schedule.Upcoming)
+
+		tz := "Europe/Stockholm"
+		enabled := true
+		resp, err = server.UpdateSchedule(context.Background(), &conductorv1.UpdateScheduleRequest{
+			ServiceId:  service.ID.String(),
+			ScheduleId: created.Schedule.Id,
+			Timezone:   &tz,
+			Enabled:    &enabled,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, resp.Schedule.NextRunAt)
+		local, err := time.Parse(time.RFC3339, resp.Schedule.NextRunLocal)
+		require.NoError(t, err)
+		assert.Equal(t, 3, local.Hour())
+
+		bad := "0 0 30 2 *"
+		_, err = server.UpdateSchedule(context.Background(), &conductorv1.UpdateScheduleRequest{
+			ServiceId:      service.ID.String(),
+			ScheduleId:     created.Schedule.Id,
+			CronExpression: &bad,
+		})
+		assert.True(t, errcode.Is(err, errcode.InvalidArgument))
+	})
+
+	t.Run("unknown schedule", func(t *testing.T) {
+		server, _ := newServer()
+		_, err := server.GetSchedule(context.Background(), &conductorv1.GetScheduleRequest{
+			ServiceId:  service.ID.String(),
+			ScheduleId: uuid.NewString(),
+		})
+		assert.True(t, errcode.Is(err, errcode.ScheduleNotFound))
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		server, schedules := newServer()
+		for name, req := range map[string]*conductorv1.CreateScheduleRequest{
+			"missing name":      {ServiceId: service.ID.String(), CronExpression: "@daily"},
+			"missing cron":      {ServiceId: service.ID.String(), Name: "nightly"},
+			"invalid cron":      {ServiceId: service.ID.String(), Name: "nightly", CronExpression: "0 25 * * *"},
+			"unknown time zone": {ServiceId: service.ID.String(), Name: "nightly", CronExpression: "@daily", Timezone: "EST5EDT6"},
+			"local time zone":   {ServiceId: service.ID.String(), Name: "nightly", CronExpression: "@daily", Timezone: "Local"},
+		} {
+			_, err := server.CreateSchedule(context.Background(), req)
+			assert.True(t, errcode.Is(err, errcode.InvalidArgument), name)
+		}
+		assert.Empty(t, schedules.schedules)
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		server := NewServiceRegistryServer(ServiceRegistryDeps{}, zerolog.Nop())
+		_, err := server.ListSchedules(context.Background(), &conductorv1.ListSchedulesRequest{ServiceId: service.ID.String()})
+		assert.True(t, errcode.Is(err, errcode.NotConfigured))
+	})
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// TenancyServiceDeps defines the dependencies for the tenancy service.
+type TenancyServiceDeps struct {
+	// OrganizationRepo handles organization persistence.
+	OrganizationRepo database.OrganizationRepository
+	// ProjectRepo handles project persistence.
+	ProjectRepo database.ProjectRepository
+}
+
+// TenancyServiceServer implements the TenancyService gRPC service.
+type TenancyServiceServer struct {
+	conductorv1.UnimplementedTenancyServiceServer
+
+	deps   TenancyServiceDeps
+	logger zerolog.Logger
+}
+
+// NewTenancyServiceServer creates a new tenancy service server.
+func NewTenancyServiceServer(deps TenancyServiceDeps, logger zerolog.Logger) *TenancyServiceServer {
+	return &TenancyServiceServer{
+		deps:   deps,
+		logger: logger.With().Str("service", "TenancyService").Logger(),
+	}
+}
+
+// requireRepos returns an error if tenancy is not configured.
+func (s *TenancyServiceServer) requireRepos() error {
+	if s.deps.OrganizationRepo == nil || s.deps.ProjectRepo == nil {
+		return errcode.New(errcode.NotConfigured, "organizations and projects are not configured")
+	}
+	return nil
+}
+
+// CreateOrganization creates an organization. Only admins may create them.
+func (s *TenancyServiceServer) CreateOrganization(ctx context.Context, req *conductorv1.CreateOrganizationRequest) (*conductorv1.CreateOrganizationResponse, error) {
+	if claims := GetUserFromContext(ctx); claims == nil || !claims.IsAdmin() {
+		return nil, errcode.New(errcode.PermissionDenied, "creating organizations requires the admin role")
+	}
+	if err := s.requireRepos(); err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		return nil, errcode.New(errcode.InvalidArgument, "name is required")
+	}
+
+	org := &database.Organization{
+		Name:        req.Name,
+		DisplayName: database.NullString(req.DisplayName),
+	}
+	if err := s.deps.OrganizationRepo.Create(ctx, org); err != nil {
+		if database.IsDuplicate(err) {
+			return nil, errcode.New(errcode.OrganizationAlreadyExists, "organization %q already exists", req.Name)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to create organization: %v", err)
+	}
+
+	s.logger.Info().Str("organization_id", org.ID.String()).Str("name", org.Name).Msg("organization created")
+
+	return &conductorv1.CreateOrganizationResponse{Organization: organizationToProto(org)}, nil
+}
+
+// ListOrganizations lists organizations. Callers other than admins only see
+// their own organization.
+func (s *TenancyServiceServer) ListOrganizations(ctx context.Context, req *conductorv1.ListOrganizationsRequest) (*conductorv1.ListOrganizationsResponse, error) {
+	if err := s.requireRepos(); err != nil {
+		return nil, err
+	}
+
+	if claims := GetUserFromContext(ctx); claims != nil && !claims.IsAdmin() {
+		orgID, err := claims.OrganizationID()
+		if err != nil {
+			return nil, errcode.New(errcode.PermissionDenied, "invalid organization in token: %v", err)
+		}
+		org, err := s.getOrganization(ctx, orgID.String())
+		if err != nil {
+			return nil, err
+		}
+		return &conductorv1.ListOrganizationsResponse{
+			Organizations: []*conductorv1.Organization{organizationToProto(org)},
+			Pagination:    paginationResponseToProto(paginationFromProto(req.Pagination), 1),
+		}, nil
+	}
+
+	pagination := paginationFromProto(req.Pagination)
+	orgs, total, err := s.deps.OrganizationRepo.List(ctx, pagination)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list organizations: %v", err)
+	}
+
+	resp := &conductorv1.ListOrganizationsResponse{
+		Organizations: make([]*conductorv1.Organization, len(orgs)),
+		Pagination:    paginationResponseToProto(pagination, total),
+	}
+	for i := range orgs {
+		resp.Organizations[i] = organizationToProto(&orgs[i])
+	}
+	return resp, nil
+}
+
+// CreateProject creates a project in an organization. Only admins may
+// create them.
+func (s *TenancyServiceServer) CreateProject(ctx context.Context, req *conductorv1.CreateProjectRequest) (*conductorv1.CreateProjectResponse, error) {
+	if claims := GetUserFromContext(ctx); claims == nil || !claims.IsAdmin() {
+		return nil, errcode.New(errcode.PermissionDenied, "creating projects requires the admin role")
+	}
+	if err := s.requireRepos(); err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		return nil, errcode.New(errcode.InvalidArgument, "name is required")
+	}
+
+	org, err := s.getOrganization(ctx, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	project := &database.Project{
+		OrganizationID: org.ID,
+		Name:           req.Name,
+		DisplayName:    database.NullString(req.DisplayName),
+	}
+	if err := s.deps.ProjectRepo.Create(ctx, project); err != nil {
+		if database.IsDuplicate(err) {
+			return nil, errcode.New(errcode.ProjectAlreadyExists, "project %q already exists in organization %q", req.Name, org.Name)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to create project: %v", err)
+	}
+
+	s.logger.Info().
+		Str("organization_id", org.ID.String()).
+		Str("project_id", project.ID.String()).
+		Str("name", project.Name).
+		Msg("project created")
+
+	return &conductorv1.CreateProjectResponse{Project: projectToProto(project)}, nil
+}
+
+// ListProjects lists the projects of an organization the caller is a member
+// of.
+func (s *TenancyServiceServer) ListProjects(ctx context.Context, req *conductorv1.ListProjectsRequest) (*conductorv1.ListProjectsResponse, error) {
+	if err := s.requireRepos(); err != nil {
+		return nil, err
+	}
+
+	org, err := s.getOrganization(ctx, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	pagination := paginationFromProto(req.Pagination)
+	projects, total, err := s.deps.ProjectRepo.ListByOrganization(ctx, org.ID, pagination)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to list projects: %v", err)
+	}
+
+	resp := &conductorv1.ListProjectsResponse{
+		Projects:   make([]*conductorv1.Project, len(projects)),
+		Pagination: paginationResponseToProto(pagination, total),
+	}
+	for i := range projects {
+		resp.Projects[i] = projectToProto(&projects[i])
+	}
+	return resp, nil
+}
+
+// getOrganization retrieves an organization by its string ID.
+func (s *TenancyServiceServer) getOrganization(ctx context.Context, id string) (*database.Organization, error) {
+	orgID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid organization ID: %v", err)
+	}
+
+	org, err := s.deps.OrganizationRepo.Get(ctx, orgID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.OrganizationNotFound, "organization not found: %s", id)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get organization: %v", err)
+	}
+	return org, nil
+}
+
+// organizationToProto converts an organization to its proto representation.
+func organizationToProto(org *database.Organization) *conductorv1.Organization {
+	return &conductorv1.Organization{
+		Id:          org.ID.String(),
+		Name:        org.Name,
+		DisplayName: stringValue(org.DisplayName),
+		CreatedAt:   timestamppb.New(org.CreatedAt),
+		UpdatedAt:   timestamppb.New(org.UpdatedAt),
+	}
+}
+
+// projectToProto converts a project to its proto representation.
+func projectToProto(project *database.Project) *conductorv1.Project {
+	return &conductorv1.Project{
+		Id:             project.ID.String(),
+		OrganizationId: project.OrganizationID.String(),
+		Name:           project.Name,
+		DisplayName:    stringValue(project.DisplayName),
+		CreatedAt:      timestamppb.New(project.CreatedAt),
+		UpdatedAt:      timestamppb.New(project.UpdatedAt),
+	}
+}
@@ -2,19 +2,29 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 
 	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/apidocs"
 	"github.com/conductor/conductor/internal/websocket"
+	"github.com/conductor/conductor/internal/webui"
+	"github.com/conductor/conductor/pkg/errcode"
+	conductorlog "github.com/conductor/conductor/pkg/log"
 	"github.com/conductor/conductor/pkg/metrics"
 	"github.com/conductor/conductor/pkg/tracing"
 )
@@ -41,8 +51,24 @@ type HTTPConfig struct {
 	EnableTracing bool
 	// Metrics is the control plane metrics instance for recording HTTP metrics.
 	Metrics *metrics.ControlPlaneMetrics
+	// EnableUI serves the embedded web UI.
+	EnableUI bool
+	// UIPath is the path the embedded web UI is served under (default: /ui).
+	UIPath string
+	// EnableAPIDocs serves the OpenAPI description and API explorer under /api/docs.
+	EnableAPIDocs bool
 }
 
+// apiDocsPath is the path the OpenAPI description and API explorer are served
+// under. It sits next to the versioned API paths rather than inside them.
+const apiDocsPath = "/api/docs"
+
+// Probe paths for liveness and readiness, served by the health API.
+const (
+	livenessProbePath  = "/healthz"
+	readinessProbePath = "/readyz"
+)
+
 // DefaultHTTPConfig returns sensible defaults for HTTP server configuration.
 func DefaultHTTPConfig() HTTPConfig {
 	return HTTPConfig{
@@ -54,6 +80,7 @@ func DefaultHTTPConfig() HTTPConfig {
 		WriteTimeout:   30 * time.Second,
 		IdleTimeout:    120 * time.Second,
 		WebSocketPath:  "/ws",
+		UIPath:         "/ui",
 		EnableTracing:  false,
 		Metrics:        nil,
 	}
@@ -66,7 +93,15 @@ type HTTPServer struct {
 	mux            *runtime.ServeMux
 	wsHandler      *websocket.Handler
 	webhookHandler *WebhookHandler
+	downloads      http.Handler
+	auditLog       AuditLogWriter
+	responseCache  ResponseCache
 	logger         zerolog.Logger
+
+	// rateLimiter and webhookRateLimiter limit the requests of each client
+	// to the API and of each webhook sender (optional).
+	rateLimiter        *RateLimiter
+	webhookRateLimiter *RateLimiter
 }
 
 // NewHTTPServer creates a new HTTP server with grpc-gateway.
@@ -75,6 +110,7 @@ func NewHTTPServer(cfg HTTPConfig, logger zerolog.Logger) (*HTTPServer, error) {
 	mux := runtime.NewServeMux(
 		runtime.WithIncomingHeaderMatcher(customHeaderMatcher),
 		runtime.WithErrorHandler(customErrorHandler),
+		runtime.WithForwardResponseOption(forwardHTTPStatus),
 	)
 
 	return &HTTPServer{
@@ -90,6 +126,7 @@ func NewHTTPServerWithWebSocket(cfg HTTPConfig, wsHub *websocket.Hub, wsAuth web
 	mux := runtime.NewServeMux(
 		runtime.WithIncomingHeaderMatcher(customHeaderMatcher),
 		runtime.WithErrorHandler(customErrorHandler),
+		runtime.WithForwardResponseOption(forwardHTTPStatus),
 	)
 
 	// Create WebSocket handler
@@ -115,6 +152,20 @@ func (s *HTTPServer) SetWebhookHandler(handler *WebhookHandler) {
 	s.webhookHandler = handler
 }
 
+// SetArtifactDownloads serves proxied artifact downloads, such as an
+// ArtifactDownloadProxy, from the control plane. This must be called before
+// Start().
+func (s *HTTPServer) SetArtifactDownloads(handler http.Handler) {
+	s.downloads = handler
+}
+
+// SetAuditLog records the calls handled outside the gateway, such as
+// webhooks, in the audit log. Gateway calls are recorded by the gRPC server.
+// This must be called before Start().
+func (s *HTTPServer) SetAuditLog(writer AuditLogWriter) {
+	s.auditLog = writer
+}
+
 // Start starts the HTTP server and blocks until the context is cancelled.
 func (s *HTTPServer) Start(ctx context.Context) error {
 	// Connect to gRPC server
@@ -199,6 +250,11 @@ func (s *HTTPServer) registerHandlers(ctx context.Context, conn *grpc.ClientConn
 		conductorv1.RegisterAgentManagementServiceHandler,
 		conductorv1.RegisterResultServiceHandler,
 		conductorv1.RegisterNotificationServiceHandler,
+		conductorv1.RegisterReportServiceHandler,
+		conductorv1.RegisterAuditServiceHandler,
+		conductorv1.RegisterAdminServiceHandler,
+		conductorv1.RegisterTenancyServiceHandler,
+		conductorv1.RegisterPreferencesServiceHandler,
 		conductorv1.RegisterHealthServiceHandler,
 	}
 
@@ -228,15 +284,68 @@ func (s *HTTPServer) buildHandler() http.Handler {
 
 	// Mount webhook handlers if configured
 	if s.webhookHandler != nil {
-		s.webhookHandler.RegisterRoutes(rootMux)
+		if s.auditLog != nil {
+			// Only the webhook routes themselves are audited; other paths
+			// under the prefixes fall through to the gateway as before.
+			webhookMux := http.NewServeMux()
+			s.webhookHandler.RegisterRoutes(webhookMux)
+			audited := s.auditMiddleware(webhookMux)
+			webhooks := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if _, pattern := webhookMux.Handler(r); pattern == "" {
+					s.mux.ServeHTTP(w, r)
+					return
+				}
+				audited.ServeHTTP(w, r)
+			})
+			rootMux.Handle("/api/v1/webhooks/", webhooks)
+			rootMux.Handle("/webhooks/", webhooks)
+		} else {
+			s.webhookHandler.RegisterRoutes(rootMux)
+		}
 		s.logger.Info().Msg("webhook handlers mounted")
 	}
 
+	// Mount proxied artifact downloads if configured
+	if s.downloads != nil {
+		rootMux.Handle("GET "+artifactDownloadPath, s.downloads)
+		s.logger.Info().Str("path", artifactDownloadPath).Msg("artifact downloads mounted")
+	}
+
+	// Mount the embedded web UI if enabled
+	if s.config.EnableUI {
+		uiPath := "/" + strings.Trim(s.config.UIPath, "/")
+		if uiPath == "/" {
+			uiPath = "/ui"
+		}
+		rootMux.Handle(uiPath+"/", webui.Handler(uiPath))
+		rootMux.Handle("/{$}", http.RedirectHandler(uiPath+"/", http.StatusFound))
+		s.logger.Info().Str("path", uiPath).Msg("web UI mounted")
+	}
+
+	// Mount the OpenAPI description and API explorer if enabled
+	if s.config.EnableAPIDocs {
+		rootMux.Handle(apiDocsPath+"/", apidocs.Handler(apiDocsPath))
+		s.logger.Info().Str("path", apiDocsPath).Msg("API docs mounted")
+	}
+
+	// Mount the probe paths orchestrators expect
+	rootMux.Handle("GET "+livenessProbePath, probeHandler(s.mux, "/api/v1/health/live"))
+	rootMux.Handle("GET "+readinessProbePath, probeHandler(s.mux, "/api/v1/health/ready"))
+
 	// Mount gRPC-Gateway handler for all other paths
-	rootMux.Handle("/", s.mux)
+	var gateway http.Handler = s.mux
+	if s.responseCache != nil {
+		gateway = s.responseCacheMiddleware(gateway)
+	}
+	rootMux.Handle("/", gateway)
 
 	var handler http.Handler = rootMux
 
+	// Add rate limiting middleware if configured
+	if s.rateLimiter != nil || s.webhookRateLimiter != nil {
+		handler = s.rateLimitMiddleware(handler)
+	}
+
 	// Add request ID middleware
 	handler = s.requestIDMiddleware(handler)
 
@@ -305,7 +414,7 @@ func (s *HTTPServer) requestIDMiddleware(next http.Handler) http.Handler {
 		}
 
 		w.Header().Set("X-Request-ID", requestID)
-		ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
+		ctx := conductorlog.ContextWithRequestID(r.Context(), requestID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -321,7 +430,6 @@ func (s *HTTPServer) loggingMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(wrapped, r)
 
 		duration := time.Since(start)
-		requestID := GetRequestID(r.Context())
 
 		logEvent := s.logger.Info()
 		if wrapped.statusCode >= 400 {
@@ -332,7 +440,7 @@ func (s *HTTPServer) loggingMiddleware(next http.Handler) http.Handler {
 		}
 
 		logEvent.
-			Str("request_id", requestID).
+			Ctx(r.Context()).
 			Str("method", r.Method).
 			Str("path", r.URL.Path).
 			Int("status", wrapped.statusCode).
@@ -411,10 +519,80 @@ func customHeaderMatcher(key string) (string, bool) {
 	}
 }
 
-// customErrorHandler handles errors from gRPC and formats them for HTTP.
-func customErrorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
-	// Use the default error handler for now
-	runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, w, r, err)
+// customErrorHandler handles errors from gRPC and formats them for HTTP, as
+// RFC 7807 problem details with the error code, the request ID and the
+// fields at fault. The error code is also exposed as a header so HTTP
+// clients can branch on it without parsing the body.
+func customErrorHandler(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	// Routing errors carry the HTTP status code to answer with
+	var routingErr *runtime.HTTPStatusError
+	if errors.As(err, &routingErr) {
+		err = routingErr.Err
+	}
+	httpStatus := runtime.HTTPStatusFromCode(status.Code(err))
+	if routingErr != nil {
+		httpStatus = routingErr.HTTPStatus
+	}
+
+	problem := errcode.NewProblem(err, httpStatus)
+	problem.Instance = r.URL.Path
+	problem.RequestID = conductorlog.RequestIDFromContext(ctx)
+	if problem.RequestID == "" {
+		problem.RequestID = w.Header().Get(conductorlog.RequestIDHeader)
+	}
+
+	w.Header().Del("Trailer")
+	w.Header().Del("Transfer-Encoding")
+	w.Header().Set("Content-Type", errcode.ProblemContentType)
+	w.Header().Set(errcode.HTTPHeader, problem.Code.String())
+	if problem.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+// probeHandler serves a probe path with the health API at path. Query
+// parameters, such as check_all, are passed on.
+func probeHandler(gateway http.Handler, path string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.Clone(r.Context())
+		r.URL.Path = path
+		r.URL.RawPath = ""
+		gateway.ServeHTTP(w, r)
+	})
+}
+
+// httpStatusMetadataKey is the gRPC header a handler sets to override the
+// HTTP status code of a successful response.
+const httpStatusMetadataKey = "x-http-code"
+
+// setHTTPStatus asks the gateway to answer with a non-200 success status.
+// It is a no-op for plain gRPC callers.
+func setHTTPStatus(ctx context.Context, code int) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(httpStatusMetadataKey, strconv.Itoa(code)))
+}
+
+// forwardHTTPStatus applies a status code set with setHTTPStatus.
+func forwardHTTPStatus(ctx context.Context, w http.ResponseWriter, _ proto.Message) error {
+	md, ok := runtime.ServerMetadataFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	values := md.HeaderMD.Get(httpStatusMetadataKey)
+	if len(values) == 0 {
+		return nil
+	}
+	delete(md.HeaderMD, httpStatusMetadataKey)
+	w.Header().Del("Grpc-Metadata-X-Http-Code")
+
+	code, err := strconv.Atoi(values[0])
+	if err != nil {
+		return nil
+	}
+	w.WriteHeader(code)
+	return nil
 }
 
 // generateRequestID generates a unique request ID.
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// maxCachedResponseSize caps the body of a response the gateway caches.
+const maxCachedResponseSize = 1 << 20
+
+// ResponseCache caches the responses of the HTTP gateway.
+type ResponseCache interface {
+	// Load returns the cached response of key, or calls fetch and caches
+	// the response it returns. Errors of fetch are not cached.
+	Load(ctx context.Context, key string, fetch func() ([]byte, error)) ([]byte, error)
+	// Invalidate drops every cached response.
+	Invalidate(ctx context.Context)
+}
+
+// errUncacheable is returned for responses the gateway does not cache.
+var errUncacheable = errors.New("response is not cacheable")
+
+// SetResponseCache caches the successful responses of GET requests to the
+// gateway, such as those of dashboards polling the API. Requests that may
+// write, such as POST requests, invalidate every cached response.
+// This must be called before Start().
+func (s *HTTPServer) SetResponseCache(c ResponseCache) {
+	s.responseCache = c
+}
+
+// cachedResponse is a response of the gateway as it is cached.
+type cachedResponse struct {
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// responseCacheMiddleware serves GET requests from the response cache, and
+// invalidates it after requests that may write. Health checks and requests
+// with a Cache-Control: no-cache header bypass the cache.
+func (s *HTTPServer) responseCacheMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions {
+			defer s.responseCache.Invalidate(r.Context())
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.Method != http.MethodGet || isHealthPath(r.URL.Path) || strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		served := false
+		data, _ := s.responseCache.Load(r.Context(), responseCacheKey(r), func() ([]byte, error) {
+			served = true
+			w.Header().Set("X-Cache", "MISS")
+			recorder := &cachingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			if recorder.statusCode != http.StatusOK || recorder.streamed || recorder.body.Len() > maxCachedResponseSize {
+				return nil, errUncacheable
+			}
+
+			// Other headers, such as CORS headers, are set per request
+			header := http.Header{}
+			for name, values := range w.Header() {
+				if name == "Content-Type" || strings.HasPrefix(name, runtime.MetadataHeaderPrefix) {
+					header[name] = values
+				}
+			}
+			return json.Marshal(cachedResponse{Header: header, Body: recorder.body.Bytes()})
+		})
+		if served {
+			return
+		}
+
+		var cached cachedResponse
+		if err := json.Unmarshal(data, &cached); err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		for name, values := range cached.Header {
+			w.Header()[name] = values
+		}
+		w.Header().Set("X-Cache", "HIT")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(cached.Body)
+	})
+}
+
+// responseKeyHeaders are the headers, besides the gRPC metadata headers,
+// responses depend on.
+var responseKeyHeaders = []string{"Authorization", "Cookie", "Accept", "Accept-Language"}
+
+// responseCacheKey identifies the response of a request: its URL, its
+// credentials and the other headers the gateway forwards to the API.
+func responseCacheKey(r *http.Request) string {
+	h := sha256.New()
+	h.Write([]byte(r.URL.RequestURI()))
+
+	names := make([]string, 0, len(responseKeyHeaders))
+	for name := range r.Header {
+		if slices.Contains(responseKeyHeaders, name) || strings.HasPrefix(name, runtime.MetadataHeaderPrefix) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		h.Write([]byte{0})
+		h.Write([]byte(name))
+		for _, value := range r.Header[name] {
+			h.Write([]byte{0})
+			h.Write([]byte(value))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachingResponseWriter writes a response through and keeps a copy of it.
+// Streamed responses, which are flushed, are not kept.
+type cachingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+	streamed   bool
+}
+
+// WriteHeader captures the status code.
+func (w *cachingResponseWriter) WriteHeader(code int) {
+	w.statusCode = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes through and keeps a copy of the body until it is too large
+// to cache.
+func (w *cachingResponseWriter) Write(p []byte) (int, error) {
+	if !w.streamed && w.body.Len() <= maxCachedResponseSize {
+		w.body.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush flushes a streamed response.
+func (w *cachingResponseWriter) Flush() {
+	w.streamed = true
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
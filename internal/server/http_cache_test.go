@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryResponseCache implements ResponseCache for testing.
+type memoryResponseCache struct {
+	responses map[string][]byte
+}
+
+func (c *memoryResponseCache) Load(ctx context.Context, key string, fetch func() ([]byte, error)) ([]byte, error) {
+	if data, ok := c.responses[key]; ok {
+		return data, nil
+	}
+	data, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	c.responses[key] = data
+	return data, nil
+}
+
+func (c *memoryResponseCache) Invalidate(ctx context.Context) {
+	clear(c.responses)
+}
+
+func TestResponseCacheMiddleware(t *testing.T) {
+	calls := 0
+	status := http.StatusOK
+	s := &HTTPServer{responseCache: &memoryResponseCache{responses: map[string][]byte{}}}
+	handler := s.responseCacheMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"runs":[]}`))
+	}))
+
+	serve := func(method, target string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/api/v1/runs", http.Header{"Origin": {"https://a.example"}})
+	assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+
+	rec = serve(http.MethodGet, "/api/v1/runs", http.Header{"Origin": {"https://b.example"}})
+	assert.Equal(t, "HIT", rec.Header().Get("X-Cache"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"runs":[]}`, rec.Body.String())
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"), "per request headers are not cached")
+	assert.Equal(t, 1, calls)
+
+	t.Run("credentials are cached separately", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/api/v1/runs", http.Header{"Authorization": {"Bearer other"}})
+		assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+	})
+
+	t.Run("no-cache bypasses the cache", func(t *testing.T) {
+		before := calls
+		rec := serve(http.MethodGet, "/api/v1/runs", http.Header{"Cache-Control": {"no-cache"}})
+		assert.Empty(t, rec.Header().Get("X-Cache"))
+		assert.Equal(t, before+1, calls)
+	})
+
+	t.Run("writes invalidate the cache", func(t *testing.T) {
+		serve(http.MethodPost, "/api/v1/runs", nil)
+		rec := serve(http.MethodGet, "/api/v1/runs", nil)
+		assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		status = http.StatusNotFound
+		defer func() { status = http.StatusOK }()
+
+		serve(http.MethodGet, "/api/v1/runs/missing", nil)
+		rec := serve(http.MethodGet, "/api/v1/runs/missing", nil)
+		assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/pkg/errcode"
+	conductorlog "github.com/conductor/conductor/pkg/log"
+)
+
+func TestCustomErrorHandler(t *testing.T) {
+	decode := func(t *testing.T, rec *httptest.ResponseRecorder) errcode.Problem {
+		t.Helper()
+		assert.Equal(t, errcode.ProblemContentType, rec.Header().Get("Content-Type"))
+		var problem errcode.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+		return problem
+	}
+
+	t.Run("handler error", func(t *testing.T) {
+		err := errcode.NewInvalidFields(errcode.FieldViolation{Field: "service_id", Description: "must be a UUID"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/runs", nil)
+		ctx := conductorlog.ContextWithRequestID(context.Background(), "req-1")
+		rec := httptest.NewRecorder()
+		customErrorHandler(ctx, nil, nil, rec, req, err)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, string(errcode.InvalidArgument), rec.Header().Get(errcode.HTTPHeader))
+		problem := decode(t, rec)
+		assert.Equal(t, errcode.ProblemTypePrefix+"CONDUCTOR_INVALID_ARGUMENT", problem.Type)
+		assert.Equal(t, http.StatusBadRequest, problem.Status)
+		assert.Equal(t, "/api/v1/runs", problem.Instance)
+		assert.Equal(t, "req-1", problem.RequestID)
+		assert.Equal(t, []errcode.FieldViolation{{Field: "service_id", Description: "must be a UUID"}}, problem.FieldViolations)
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/runs", nil)
+		rec := httptest.NewRecorder()
+		customErrorHandler(context.Background(), nil, nil, rec, req, errcode.New(errcode.Unauthenticated, "missing token"))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+		assert.Equal(t, "missing token", decode(t, rec).Detail)
+	})
+
+	t.Run("routing error", func(t *testing.T) {
+		s, err := NewHTTPServer(DefaultHTTPConfig(), zerolog.Nop())
+		require.NoError(t, err)
+		handler := s.requestIDMiddleware(s.mux)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/nothing-here", nil)
+		req.Header.Set(conductorlog.RequestIDHeader, "req-2")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		problem := decode(t, rec)
+		assert.Equal(t, errcode.NotFound, problem.Code)
+		assert.Equal(t, "NotFound", problem.GRPCStatus)
+		assert.Equal(t, "req-2", problem.RequestID)
+	})
+}
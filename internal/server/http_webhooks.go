@@ -4,17 +4,21 @@ import (
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 
 	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/metrics"
 )
 
 // WebhookHandler handles incoming webhooks from git providers.
@@ -22,14 +26,32 @@ type WebhookHandler struct {
 	logger      zerolog.Logger
 	serviceRepo WebhookServiceRepository
 	scheduler   RunScheduler
+	// triggerRules filters events per service (optional)
+	triggerRules TriggerRuleRepository
+	// throttle coalesces rapid triggers per branch (optional)
+	throttle *TriggerThrottle
+	// changedPaths lists files changed by an event for monorepos (optional)
+	changedPaths ChangedPathsResolver
+	// branches tracks pushed and deleted branches (optional)
+	branches WebhookBranchRepository
 
 	// Secrets for webhook validation
-	githubSecret    string
-	gitlabSecret    string
-	bitbucketSecret string
+	githubSecret      string
+	gitlabSecret      string
+	bitbucketSecret   string
+	giteaSecret       string
+	azureDevOpsSecret string
 
 	// Base URL for constructing callback URLs
 	baseURL string
+
+	// Replay protection
+	strict             bool
+	timestampTolerance time.Duration
+	deliveries         *deliveryCache
+	now                func() time.Time
+
+	metrics *metrics.ControlPlaneMetrics
 }
 
 // WebhookServiceRepository defines the interface for service lookup in webhooks.
@@ -37,6 +59,13 @@ type WebhookServiceRepository interface {
 	List(ctx context.Context, page database.Pagination) ([]database.Service, error)
 }
 
+// WebhookBranchRepository records branches pushed to and deleted through
+// webhooks.
+type WebhookBranchRepository interface {
+	RecordPush(ctx context.Context, serviceID uuid.UUID, name, sha string) error
+	Delete(ctx context.Context, serviceID uuid.UUID, name string) error
+}
+
 // RunScheduler defines the interface for scheduling test runs.
 type RunScheduler interface {
 	ScheduleRun(ctx context.Context, req ScheduleRunRequest) (*database.TestRun, error)
@@ -50,14 +79,34 @@ type ScheduleRunRequest struct {
 	TriggerType database.TriggerType
 	TriggeredBy string
 	Priority    int
+	// PullRequestNumber is the PR/MR that triggered the run, or 0 for pushes.
+	PullRequestNumber int
+	// IdempotencyKey identifies the webhook delivery that triggered the
+	// run, so that redeliveries return the run it scheduled. It is empty
+	// when the provider sent no delivery ID.
+	IdempotencyKey string
 }
 
 // WebhookConfig holds configuration for the webhook handler.
 type WebhookConfig struct {
-	GithubSecret    string
-	GitlabSecret    string
-	BitbucketSecret string
-	BaseURL         string
+	GithubSecret      string
+	GitlabSecret      string
+	BitbucketSecret   string
+	GiteaSecret       string
+	AzureDevOpsSecret string
+	BaseURL           string
+
+	// Strict rejects deliveries that cannot be authenticated or lack a
+	// delivery ID, and matches replays by payload digest as well as ID.
+	Strict bool
+	// TimestampTolerance is the maximum clock skew accepted for deliveries
+	// that carry a send time. Zero disables the check.
+	TimestampTolerance time.Duration
+	// ReplayWindow is how long accepted delivery IDs are remembered to
+	// reject replays. Zero disables the replay cache.
+	ReplayWindow time.Duration
+	// Metrics records rejected deliveries (optional).
+	Metrics *metrics.ControlPlaneMetrics
 }
 
 // NewWebhookHandler creates a new webhook handler.
@@ -67,15 +116,43 @@ func NewWebhookHandler(
 	scheduler RunScheduler,
 	logger zerolog.Logger,
 ) *WebhookHandler {
-	return &WebhookHandler{
-		logger:          logger.With().Str("component", "webhook_handler").Logger(),
-		serviceRepo:     serviceRepo,
-		scheduler:       scheduler,
-		githubSecret:    cfg.GithubSecret,
-		gitlabSecret:    cfg.GitlabSecret,
-		bitbucketSecret: cfg.BitbucketSecret,
-		baseURL:         cfg.BaseURL,
+	h := &WebhookHandler{
+		logger:             logger.With().Str("component", "webhook_handler").Logger(),
+		serviceRepo:        serviceRepo,
+		scheduler:          scheduler,
+		githubSecret:       cfg.GithubSecret,
+		gitlabSecret:       cfg.GitlabSecret,
+		bitbucketSecret:    cfg.BitbucketSecret,
+		giteaSecret:        cfg.GiteaSecret,
+		azureDevOpsSecret:  cfg.AzureDevOpsSecret,
+		baseURL:            cfg.BaseURL,
+		strict:             cfg.Strict,
+		timestampTolerance: cfg.TimestampTolerance,
+		now:                time.Now,
+		metrics:            cfg.Metrics,
 	}
+	if cfg.ReplayWindow > 0 {
+		h.deliveries = newDeliveryCache(cfg.ReplayWindow)
+	}
+	return h
+}
+
+// SetTriggerRules enables per-service trigger rules. Without them every
+// branch push and pull request triggers a run.
+func (h *WebhookHandler) SetTriggerRules(repo TriggerRuleRepository) {
+	h.triggerRules = repo
+}
+
+// SetThrottle limits how often runs are triggered per service and branch.
+// Triggers beyond the limit are coalesced into a run of the latest commit.
+func (h *WebhookHandler) SetThrottle(throttle *TriggerThrottle) {
+	h.throttle = throttle
+}
+
+// SetBranches enables branch tracking: pushes create or move a service's
+// branch and branch deletions remove it.
+func (h *WebhookHandler) SetBranches(repo WebhookBranchRepository) {
+	h.branches = repo
 }
 
 // RegisterRoutes registers webhook routes on the given mux.
@@ -83,11 +160,15 @@ func (h *WebhookHandler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("POST /api/v1/webhooks/github", h.HandleGitHubWebhook)
 	mux.HandleFunc("POST /api/v1/webhooks/gitlab", h.HandleGitLabWebhook)
 	mux.HandleFunc("POST /api/v1/webhooks/bitbucket", h.HandleBitbucketWebhook)
+	mux.HandleFunc("POST /api/v1/webhooks/gitea", h.HandleGiteaWebhook)
+	mux.HandleFunc("POST /api/v1/webhooks/azure-devops", h.HandleAzureDevOpsWebhook)
 
 	// Also register without /api/v1 prefix for compatibility
 	mux.HandleFunc("POST /webhooks/github", h.HandleGitHubWebhook)
 	mux.HandleFunc("POST /webhooks/gitlab", h.HandleGitLabWebhook)
 	mux.HandleFunc("POST /webhooks/bitbucket", h.HandleBitbucketWebhook)
+	mux.HandleFunc("POST /webhooks/gitea", h.HandleGiteaWebhook)
+	mux.HandleFunc("POST /webhooks/azure-devops", h.HandleAzureDevOpsWebhook)
 }
 
 // HandleGitHubWebhook handles GitHub webhook events.
@@ -109,18 +190,23 @@ func (h *WebhookHandler) HandleGitHubWebhook(w http.ResponseWriter, r *http.Requ
 	defer r.Body.Close()
 
 	// Validate signature
+	if !h.requireSecret(w, r, "github", h.githubSecret) {
+		return
+	}
 	signature := r.Header.Get("X-Hub-Signature-256")
 	if !validateGitHubSignature(payload, signature, h.githubSecret) {
-		h.logger.Warn().
-			Str("request_id", requestID).
-			Msg("invalid webhook signature")
-		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		h.rejectDelivery(w, r, "github", webhookRejectSignature, http.StatusUnauthorized, "invalid signature")
 		return
 	}
 
 	// Get event type and delivery ID
 	eventType := r.Header.Get("X-GitHub-Event")
-	deliveryID := r.Header.Get("X-GitHub-Delivery")
+	deliveryID := deliveryIDFrom(r, "X-GitHub-Delivery")
+
+	release, ok := h.admit(w, r, "github", eventType, deliveryID, payload)
+	if !ok {
+		return
+	}
 
 	h.logger.Debug().
 		Str("request_id", requestID).
@@ -129,7 +215,8 @@ func (h *WebhookHandler) HandleGitHubWebhook(w http.ResponseWriter, r *http.Requ
 		Msg("processing GitHub webhook")
 
 	// Parse and handle the event
-	if err := h.processGitHubEvent(ctx, eventType, payload); err != nil {
+	if err := h.processGitHubEvent(withDelivery(ctx, "github", deliveryID), eventType, payload); err != nil {
+		release()
 		h.logger.Error().Err(err).
 			Str("event_type", eventType).
 			Msg("failed to handle webhook event")
@@ -180,31 +267,43 @@ func (h *WebhookHandler) processGitHubEvent(ctx context.Context, eventType strin
 // handleGitHubPush handles a GitHub push event.
 func (h *WebhookHandler) handleGitHubPush(ctx context.Context, event *githubWebhookPushEvent) error {
 	if event.Deleted {
-		h.logger.Debug().
-			Str("ref", event.Ref).
-			Msg("ignoring branch deletion")
+		h.deleteBranch(ctx, event.Ref, webhookTrigger{
+			repoFullName: event.Repository.FullName,
+			owner:        event.Repository.Owner.Login,
+			repo:         event.Repository.Name,
+		})
 		return nil
 	}
 
-	// Only trigger on branch pushes, not tags
-	if !strings.HasPrefix(event.Ref, "refs/heads/") {
+	ref, tag, ok := parsePushRef(event.Ref)
+	if !ok {
 		h.logger.Debug().
 			Str("ref", event.Ref).
 			Msg("ignoring non-branch ref")
 		return nil
 	}
 
-	branch := strings.TrimPrefix(event.Ref, "refs/heads/")
-
 	h.logger.Info().
 		Str("repo", event.Repository.FullName).
-		Str("branch", branch).
+		Str("ref", ref).
 		Str("sha", event.After).
 		Str("pusher", event.Pusher.Name).
 		Msg("processing push event")
 
-	return h.triggerTestRun(ctx, event.Repository.FullName, event.Repository.Owner.Login,
-		event.Repository.Name, branch, event.After, event.Pusher.Name, 0)
+	paths, known := changedPaths(event.Commits, -1)
+	return h.triggerTestRun(ctx, webhookTrigger{
+		repoFullName: event.Repository.FullName,
+		owner:        event.Repository.Owner.Login,
+		repo:         event.Repository.Name,
+		ref:          ref,
+		tag:          tag,
+		sha:          event.After,
+		base:         event.Before,
+		triggeredBy:  event.Pusher.Name,
+		message:      event.HeadCommit.Message,
+		changedPaths: paths,
+		pathsKnown:   known,
+	})
 }
 
 // handleGitHubPR handles a GitHub pull request event.
@@ -226,9 +325,17 @@ func (h *WebhookHandler) handleGitHubPR(ctx context.Context, event *githubWebhoo
 		Str("head_sha", event.PullRequest.Head.SHA).
 		Msg("processing PR event")
 
-	return h.triggerTestRun(ctx, event.Repository.FullName, event.Repository.Owner.Login,
-		event.Repository.Name, event.PullRequest.Head.Ref, event.PullRequest.Head.SHA,
-		event.Sender.Login, 1) // Higher priority for PRs
+	return h.triggerTestRun(ctx, webhookTrigger{
+		repoFullName: event.Repository.FullName,
+		owner:        event.Repository.Owner.Login,
+		repo:         event.Repository.Name,
+		ref:          event.PullRequest.Head.Ref,
+		sha:          event.PullRequest.Head.SHA,
+		base:         event.PullRequest.Base.Ref,
+		triggeredBy:  event.Sender.Login,
+		pullRequest:  event.Number,
+		message:      event.PullRequest.Title,
+	})
 }
 
 // handleGitHubCheckSuite handles a GitHub check suite event.
@@ -248,9 +355,15 @@ func (h *WebhookHandler) handleGitHubCheckSuite(ctx context.Context, event *gith
 		Str("head_sha", event.CheckSuite.HeadSHA).
 		Msg("processing check suite event")
 
-	return h.triggerTestRun(ctx, event.Repository.FullName, event.Repository.Owner.Login,
-		event.Repository.Name, event.CheckSuite.HeadBranch, event.CheckSuite.HeadSHA,
-		event.Sender.Login, 0)
+	return h.triggerTestRun(ctx, webhookTrigger{
+		repoFullName: event.Repository.FullName,
+		owner:        event.Repository.Owner.Login,
+		repo:         event.Repository.Name,
+		ref:          event.CheckSuite.HeadBranch,
+		sha:          event.CheckSuite.HeadSHA,
+		triggeredBy:  event.Sender.Login,
+		message:      event.CheckSuite.HeadCommit.Message,
+	})
 }
 
 // HandleGitLabWebhook handles GitLab webhook events.
@@ -272,25 +385,33 @@ func (h *WebhookHandler) HandleGitLabWebhook(w http.ResponseWriter, r *http.Requ
 	defer r.Body.Close()
 
 	// Validate token
+	if !h.requireSecret(w, r, "gitlab", h.gitlabSecret) {
+		return
+	}
 	token := r.Header.Get("X-Gitlab-Token")
-	if h.gitlabSecret != "" && token != h.gitlabSecret {
-		h.logger.Warn().
-			Str("request_id", requestID).
-			Msg("invalid webhook token")
-		http.Error(w, "invalid token", http.StatusUnauthorized)
+	if h.gitlabSecret != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.gitlabSecret)) != 1 {
+		h.rejectDelivery(w, r, "gitlab", webhookRejectSignature, http.StatusUnauthorized, "invalid token")
 		return
 	}
 
-	// Get event type
+	// Get event type and delivery ID
 	eventType := r.Header.Get("X-Gitlab-Event")
+	deliveryID := deliveryIDFrom(r, "X-Gitlab-Event-UUID")
+
+	release, ok := h.admit(w, r, "gitlab", eventType, deliveryID, payload)
+	if !ok {
+		return
+	}
 
 	h.logger.Debug().
 		Str("request_id", requestID).
 		Str("event_type", eventType).
+		Str("delivery_id", deliveryID).
 		Msg("processing GitLab webhook")
 
 	// Parse and handle the event
-	if err := h.processGitLabEvent(ctx, eventType, payload); err != nil {
+	if err := h.processGitLabEvent(withDelivery(ctx, "gitlab", deliveryID), eventType, payload); err != nil {
+		release()
 		h.logger.Error().Err(err).
 			Str("event_type", eventType).
 			Msg("failed to handle webhook event")
@@ -305,7 +426,7 @@ func (h *WebhookHandler) HandleGitLabWebhook(w http.ResponseWriter, r *http.Requ
 // processGitLabEvent processes a GitLab webhook event.
 func (h *WebhookHandler) processGitLabEvent(ctx context.Context, eventType string, payload []byte) error {
 	switch eventType {
-	case "Push Hook":
+	case "Push Hook", "Tag Push Hook":
 		var event gitlabWebhookPushEvent
 		if err := json.Unmarshal(payload, &event); err != nil {
 			return fmt.Errorf("failed to parse push event: %w", err)
@@ -329,37 +450,51 @@ func (h *WebhookHandler) processGitLabEvent(ctx context.Context, eventType strin
 
 // handleGitLabPush handles a GitLab push event.
 func (h *WebhookHandler) handleGitLabPush(ctx context.Context, event *gitlabWebhookPushEvent) error {
+	parts := strings.SplitN(event.Project.PathWithNamespace, "/", 2)
+	owner, repo := "", event.Project.PathWithNamespace
+	if len(parts) == 2 {
+		owner, repo = parts[0], parts[1]
+	}
+
 	// Check for branch deletion
 	if event.After == "0000000000000000000000000000000000000000" {
-		h.logger.Debug().
-			Str("ref", event.Ref).
-			Msg("ignoring branch deletion")
+		h.deleteBranch(ctx, event.Ref, webhookTrigger{
+			repoFullName: event.Project.PathWithNamespace,
+			owner:        owner,
+			repo:         repo,
+		})
 		return nil
 	}
 
-	if !strings.HasPrefix(event.Ref, "refs/heads/") {
+	ref, tag, ok := parsePushRef(event.Ref)
+	if !ok {
 		h.logger.Debug().
 			Str("ref", event.Ref).
 			Msg("ignoring non-branch ref")
 		return nil
 	}
 
-	branch := strings.TrimPrefix(event.Ref, "refs/heads/")
-	parts := strings.SplitN(event.Project.PathWithNamespace, "/", 2)
-	owner, repo := "", event.Project.PathWithNamespace
-	if len(parts) == 2 {
-		owner, repo = parts[0], parts[1]
-	}
-
 	h.logger.Info().
 		Str("repo", event.Project.PathWithNamespace).
-		Str("branch", branch).
+		Str("ref", ref).
 		Str("sha", event.After).
 		Str("pusher", event.UserUsername).
 		Msg("processing push event")
 
-	return h.triggerTestRun(ctx, event.Project.PathWithNamespace, owner, repo, branch,
-		event.After, event.UserUsername, 0)
+	paths, known := changedPaths(event.Commits, event.TotalCommitsCount)
+	return h.triggerTestRun(ctx, webhookTrigger{
+		repoFullName: event.Project.PathWithNamespace,
+		owner:        owner,
+		repo:         repo,
+		ref:          ref,
+		tag:          tag,
+		sha:          event.After,
+		base:         event.Before,
+		triggeredBy:  event.UserUsername,
+		message:      headCommitMessage(event.Commits, event.After),
+		changedPaths: paths,
+		pathsKnown:   known,
+	})
 }
 
 // handleGitLabMR handles a GitLab merge request event.
@@ -386,9 +521,17 @@ func (h *WebhookHandler) handleGitLabMR(ctx context.Context, event *gitlabWebhoo
 		Str("action", event.ObjectAttributes.Action).
 		Msg("processing MR event")
 
-	return h.triggerTestRun(ctx, event.Project.PathWithNamespace, owner, repo,
-		event.ObjectAttributes.SourceBranch, event.ObjectAttributes.LastCommit.ID,
-		event.User.Username, 1)
+	return h.triggerTestRun(ctx, webhookTrigger{
+		repoFullName: event.Project.PathWithNamespace,
+		owner:        owner,
+		repo:         repo,
+		ref:          event.ObjectAttributes.SourceBranch,
+		sha:          event.ObjectAttributes.LastCommit.ID,
+		base:         event.ObjectAttributes.TargetBranch,
+		triggeredBy:  event.User.Username,
+		pullRequest:  event.ObjectAttributes.IID,
+		message:      event.ObjectAttributes.Title,
+	})
 }
 
 // HandleBitbucketWebhook handles Bitbucket webhook events.
@@ -410,27 +553,35 @@ func (h *WebhookHandler) HandleBitbucketWebhook(w http.ResponseWriter, r *http.R
 	defer r.Body.Close()
 
 	// Validate authorization if configured
+	if !h.requireSecret(w, r, "bitbucket", h.bitbucketSecret) {
+		return
+	}
 	if h.bitbucketSecret != "" {
 		authHeader := r.Header.Get("Authorization")
-		if authHeader != "Bearer "+h.bitbucketSecret {
-			h.logger.Warn().
-				Str("request_id", requestID).
-				Msg("invalid webhook authorization")
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		if subtle.ConstantTimeCompare([]byte(authHeader), []byte("Bearer "+h.bitbucketSecret)) != 1 {
+			h.rejectDelivery(w, r, "bitbucket", webhookRejectSignature, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 	}
 
-	// Get event type
+	// Get event type and delivery ID
 	eventType := r.Header.Get("X-Event-Key")
+	deliveryID := deliveryIDFrom(r, "X-Request-UUID")
+
+	release, ok := h.admit(w, r, "bitbucket", eventType, deliveryID, payload)
+	if !ok {
+		return
+	}
 
 	h.logger.Debug().
 		Str("request_id", requestID).
 		Str("event_type", eventType).
+		Str("delivery_id", deliveryID).
 		Msg("processing Bitbucket webhook")
 
 	// Parse and handle the event
-	if err := h.processBitbucketEvent(ctx, eventType, payload); err != nil {
+	if err := h.processBitbucketEvent(withDelivery(ctx, "bitbucket", deliveryID), eventType, payload); err != nil {
+		release()
 		h.logger.Error().Err(err).
 			Str("event_type", eventType).
 			Msg("failed to handle webhook event")
@@ -469,25 +620,41 @@ func (h *WebhookHandler) processBitbucketEvent(ctx context.Context, eventType st
 
 // handleBitbucketPush handles a Bitbucket push event.
 func (h *WebhookHandler) handleBitbucketPush(ctx context.Context, event *bitbucketWebhookPushEvent) error {
+	parts := strings.SplitN(event.Repository.FullName, "/", 2)
+	owner, repo := "", event.Repository.FullName
+	if len(parts) == 2 {
+		owner, repo = parts[0], parts[1]
+	}
+
 	for _, change := range event.Push.Changes {
 		if change.Closed {
+			if change.Old.Type == "branch" {
+				h.deleteBranch(ctx, "refs/heads/"+change.Old.Name, webhookTrigger{
+					repoFullName: event.Repository.FullName,
+					owner:        owner,
+					repo:         repo,
+				})
+			}
 			continue
 		}
 
-		parts := strings.SplitN(event.Repository.FullName, "/", 2)
-		owner, repo := "", event.Repository.FullName
-		if len(parts) == 2 {
-			owner, repo = parts[0], parts[1]
-		}
-
 		h.logger.Info().
 			Str("repo", event.Repository.FullName).
-			Str("branch", change.New.Name).
+			Str("ref", change.New.Name).
 			Str("sha", change.New.Target.Hash).
 			Msg("processing push event")
 
-		if err := h.triggerTestRun(ctx, event.Repository.FullName, owner, repo,
-			change.New.Name, change.New.Target.Hash, event.Actor.Username, 0); err != nil {
+		if err := h.triggerTestRun(ctx, webhookTrigger{
+			repoFullName: event.Repository.FullName,
+			owner:        owner,
+			repo:         repo,
+			ref:          change.New.Name,
+			tag:          change.New.Type == "tag",
+			sha:          change.New.Target.Hash,
+			base:         change.Old.Target.Hash,
+			triggeredBy:  event.Actor.Username,
+			message:      change.New.Target.Message,
+		}); err != nil {
 			return err
 		}
 	}
@@ -507,35 +674,551 @@ func (h *WebhookHandler) handleBitbucketPR(ctx context.Context, event *bitbucket
 		Int("pr_id", event.PullRequest.ID).
 		Msg("processing PR event")
 
-	return h.triggerTestRun(ctx, event.Repository.FullName, owner, repo,
-		event.PullRequest.Source.Branch.Name, event.PullRequest.Source.Commit.Hash,
-		event.Actor.Username, 1)
+	return h.triggerTestRun(ctx, webhookTrigger{
+		repoFullName: event.Repository.FullName,
+		owner:        owner,
+		repo:         repo,
+		ref:          event.PullRequest.Source.Branch.Name,
+		sha:          event.PullRequest.Source.Commit.Hash,
+		base:         event.PullRequest.Destination.Branch.Name,
+		triggeredBy:  event.Actor.Username,
+		pullRequest:  event.PullRequest.ID,
+		message:      event.PullRequest.Title,
+	})
 }
 
-// triggerTestRun schedules a test run for the given repository and commit.
-func (h *WebhookHandler) triggerTestRun(ctx context.Context, repoFullName, owner, repo, branch, sha, triggeredBy string, priority int) error {
-	// Find service by git URL
-	service, err := h.findServiceByRepo(ctx, owner, repo, repoFullName)
+// HandleGiteaWebhook handles Gitea (and Forgejo) webhook events.
+func (h *WebhookHandler) HandleGiteaWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	requestID := GetRequestID(ctx)
+
+	h.logger.Info().
+		Str("request_id", requestID).
+		Msg("received Gitea webhook")
+
+	// Read the payload
+	payload, err := io.ReadAll(r.Body)
 	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to read webhook payload")
+		http.Error(w, "failed to read payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	// Validate signature
+	signature := r.Header.Get("X-Gitea-Signature")
+	if signature == "" {
+		signature = r.Header.Get("X-Forgejo-Signature")
+	}
+	if !h.requireSecret(w, r, "gitea", h.giteaSecret) {
+		return
+	}
+	if !validateGiteaSignature(payload, signature, h.giteaSecret) {
+		h.rejectDelivery(w, r, "gitea", webhookRejectSignature, http.StatusUnauthorized, "invalid signature")
+		return
+	}
+
+	// Get event type and delivery ID
+	eventType := r.Header.Get("X-Gitea-Event")
+	if eventType == "" {
+		eventType = r.Header.Get("X-Forgejo-Event")
+	}
+	deliveryID := deliveryIDFrom(r, "X-Gitea-Delivery")
+	if deliveryID == "" {
+		deliveryID = r.Header.Get("X-Forgejo-Delivery")
+	}
+
+	release, ok := h.admit(w, r, "gitea", eventType, deliveryID, payload)
+	if !ok {
+		return
+	}
+
+	h.logger.Debug().
+		Str("request_id", requestID).
+		Str("event_type", eventType).
+		Str("delivery_id", deliveryID).
+		Msg("processing Gitea webhook")
+
+	// Parse and handle the event
+	if err := h.processGiteaEvent(withDelivery(ctx, "gitea", deliveryID), eventType, payload); err != nil {
+		release()
+		h.logger.Error().Err(err).
+			Str("event_type", eventType).
+			Msg("failed to handle webhook event")
+		http.Error(w, "failed to handle event", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// processGiteaEvent processes a Gitea webhook event.
+func (h *WebhookHandler) processGiteaEvent(ctx context.Context, eventType string, payload []byte) error {
+	switch eventType {
+	case "push":
+		var event giteaWebhookPushEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return fmt.Errorf("failed to parse push event: %w", err)
+		}
+		return h.handleGiteaPush(ctx, &event)
+
+	case "pull_request":
+		var event giteaWebhookPREvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return fmt.Errorf("failed to parse PR event: %w", err)
+		}
+		return h.handleGiteaPR(ctx, &event)
+
+	default:
 		h.logger.Debug().
-			Str("repo", repoFullName).
-			Err(err).
+			Str("event_type", eventType).
+			Msg("ignoring unsupported event type")
+		return nil
+	}
+}
+
+// handleGiteaPush handles a Gitea push event.
+func (h *WebhookHandler) handleGiteaPush(ctx context.Context, event *giteaWebhookPushEvent) error {
+	// Check for branch deletion
+	if event.After == "0000000000000000000000000000000000000000" {
+		h.deleteBranch(ctx, event.Ref, webhookTrigger{
+			repoFullName: event.Repository.FullName,
+			owner:        event.Repository.Owner.Login,
+			repo:         event.Repository.Name,
+		})
+		return nil
+	}
+
+	ref, tag, ok := parsePushRef(event.Ref)
+	if !ok {
+		h.logger.Debug().
+			Str("ref", event.Ref).
+			Msg("ignoring non-branch ref")
+		return nil
+	}
+
+	h.logger.Info().
+		Str("repo", event.Repository.FullName).
+		Str("ref", ref).
+		Str("sha", event.After).
+		Str("pusher", event.Pusher.Login).
+		Msg("processing push event")
+
+	paths, known := changedPaths(event.Commits, event.TotalCommits)
+	return h.triggerTestRun(ctx, webhookTrigger{
+		repoFullName: event.Repository.FullName,
+		owner:        event.Repository.Owner.Login,
+		repo:         event.Repository.Name,
+		ref:          ref,
+		tag:          tag,
+		sha:          event.After,
+		base:         event.Before,
+		triggeredBy:  event.Pusher.Login,
+		message:      event.HeadCommit.Message,
+		changedPaths: paths,
+		pathsKnown:   known,
+	})
+}
+
+// handleGiteaPR handles a Gitea pull request event.
+func (h *WebhookHandler) handleGiteaPR(ctx context.Context, event *giteaWebhookPREvent) error {
+	switch event.Action {
+	case "opened", "synchronized", "reopened":
+		// Continue processing
+	default:
+		h.logger.Debug().
+			Str("action", event.Action).
+			Msg("ignoring PR action")
+		return nil
+	}
+
+	h.logger.Info().
+		Str("repo", event.Repository.FullName).
+		Int("pr_number", event.Number).
+		Str("action", event.Action).
+		Str("head_sha", event.PullRequest.Head.SHA).
+		Msg("processing PR event")
+
+	return h.triggerTestRun(ctx, webhookTrigger{
+		repoFullName: event.Repository.FullName,
+		owner:        event.Repository.Owner.Login,
+		repo:         event.Repository.Name,
+		ref:          event.PullRequest.Head.Ref,
+		sha:          event.PullRequest.Head.SHA,
+		base:         event.PullRequest.Base.Ref,
+		triggeredBy:  event.Sender.Login,
+		pullRequest:  event.Number,
+		message:      event.PullRequest.Title,
+	})
+}
+
+// HandleAzureDevOpsWebhook handles Azure DevOps service hook events.
+func (h *WebhookHandler) HandleAzureDevOpsWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	requestID := GetRequestID(ctx)
+
+	h.logger.Info().
+		Str("request_id", requestID).
+		Msg("received Azure DevOps webhook")
+
+	// Read the payload
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to read webhook payload")
+		http.Error(w, "failed to read payload", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	// Validate authorization if configured
+	if !h.requireSecret(w, r, "azure_devops", h.azureDevOpsSecret) {
+		return
+	}
+	if !validateAzureDevOpsAuth(r, h.azureDevOpsSecret) {
+		h.rejectDelivery(w, r, "azure_devops", webhookRejectSignature, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// Azure DevOps sends the event type, ID, and send time in the payload
+	// rather than in headers
+	var envelope struct {
+		ID          string    `json:"id"`
+		EventType   string    `json:"eventType"`
+		CreatedDate time.Time `json:"createdDate"`
+	}
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		h.rejectDelivery(w, r, "azure_devops", webhookRejectMalformed, http.StatusBadRequest, "invalid payload")
+		return
+	}
+
+	delivery := webhookDelivery{
+		provider: "azure_devops",
+		id:       envelope.ID,
+		sentAt:   envelope.CreatedDate,
+		payload:  payload,
+	}
+	if delivery.id == "" {
+		delivery.id = deliveryIDFrom(r, "")
+	}
+	release, ok := h.admitDelivery(w, r, delivery)
+	if !ok {
+		return
+	}
+
+	h.logger.Debug().
+		Str("request_id", requestID).
+		Str("event_type", envelope.EventType).
+		Str("delivery_id", delivery.id).
+		Msg("processing Azure DevOps webhook")
+
+	// Parse and handle the event
+	if err := h.processAzureDevOpsEvent(withDelivery(ctx, delivery.provider, delivery.id), envelope.EventType, payload); err != nil {
+		release()
+		h.logger.Error().Err(err).
+			Str("event_type", envelope.EventType).
+			Msg("failed to handle webhook event")
+		http.Error(w, "failed to handle event", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// processAzureDevOpsEvent processes an Azure DevOps service hook event.
+func (h *WebhookHandler) processAzureDevOpsEvent(ctx context.Context, eventType string, payload []byte) error {
+	switch eventType {
+	case "git.push":
+		var event azureDevOpsPushEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return fmt.Errorf("failed to parse push event: %w", err)
+		}
+		return h.handleAzureDevOpsPush(ctx, &event)
+
+	case "git.pullrequest.created", "git.pullrequest.updated":
+		var event azureDevOpsPREvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return fmt.Errorf("failed to parse PR event: %w", err)
+		}
+		return h.handleAzureDevOpsPR(ctx, &event)
+
+	default:
+		h.logger.Debug().
+			Str("event_type", eventType).
+			Msg("ignoring unsupported event type")
+		return nil
+	}
+}
+
+// handleAzureDevOpsPush handles an Azure DevOps push event.
+func (h *WebhookHandler) handleAzureDevOpsPush(ctx context.Context, event *azureDevOpsPushEvent) error {
+	fullName, owner, repo := azureDevOpsRepoName(event.Resource.Repository.RemoteURL, event.Resource.Repository.Name)
+
+	for _, update := range event.Resource.RefUpdates {
+		// Check for branch deletion
+		if strings.Trim(update.NewObjectID, "0") == "" {
+			h.deleteBranch(ctx, update.Name, webhookTrigger{
+				repoFullName: fullName,
+				owner:        owner,
+				repo:         repo,
+			})
+			continue
+		}
+		ref, tag, ok := parsePushRef(update.Name)
+		if !ok {
+			continue
+		}
+
+		h.logger.Info().
+			Str("repo", fullName).
+			Str("ref", ref).
+			Str("sha", update.NewObjectID).
+			Str("pusher", event.Resource.PushedBy.UniqueName).
+			Msg("processing push event")
+
+		var message string
+		for _, commit := range event.Resource.Commits {
+			if commit.CommitID == update.NewObjectID {
+				message = commit.Comment
+			}
+		}
+
+		if err := h.triggerTestRun(ctx, webhookTrigger{
+			repoFullName: fullName,
+			owner:        owner,
+			repo:         repo,
+			ref:          ref,
+			tag:          tag,
+			sha:          update.NewObjectID,
+			base:         update.OldObjectID,
+			triggeredBy:  event.Resource.PushedBy.UniqueName,
+			message:      message,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleAzureDevOpsPR handles an Azure DevOps pull request event.
+func (h *WebhookHandler) handleAzureDevOpsPR(ctx context.Context, event *azureDevOpsPREvent) error {
+	if event.Resource.Status != "active" {
+		h.logger.Debug().
+			Str("status", event.Resource.Status).
+			Msg("ignoring inactive PR")
+		return nil
+	}
+
+	fullName, owner, repo := azureDevOpsRepoName(event.Resource.Repository.RemoteURL, event.Resource.Repository.Name)
+
+	h.logger.Info().
+		Str("repo", fullName).
+		Int("pr_id", event.Resource.PullRequestID).
+		Msg("processing PR event")
+
+	return h.triggerTestRun(ctx, webhookTrigger{
+		repoFullName: fullName,
+		owner:        owner,
+		repo:         repo,
+		ref:          strings.TrimPrefix(event.Resource.SourceRefName, "refs/heads/"),
+		sha:          event.Resource.LastMergeSourceCommit.CommitID,
+		base:         strings.TrimPrefix(event.Resource.TargetRefName, "refs/heads/"),
+		triggeredBy:  event.Resource.CreatedBy.UniqueName,
+		pullRequest:  event.Resource.PullRequestID,
+		message:      event.Resource.Title,
+	})
+}
+
+// azureDevOpsRepoName derives repository names from an Azure DevOps remote
+// URL such as https://dev.azure.com/org/project/_git/repo. The full name is
+// "org/project/_git/repo" and owner is "org/project", so services registered
+// with either the HTTPS or the SSH (v3/org/project/repo) URL match.
+func azureDevOpsRepoName(remoteURL, name string) (fullName, owner, repo string) {
+	path := remoteURL
+	if i := strings.Index(path, "://"); i >= 0 {
+		path = path[i+3:]
+	}
+	if i := strings.Index(path, "/"); i >= 0 {
+		path = path[i+1:]
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) == 4 && parts[2] == "_git" {
+		return path, parts[0] + "/" + parts[1], parts[3]
+	}
+	return name, "", name
+}
+
+// triggerTestRun schedules test runs for the event's repository and commit.
+// Archived services never run. Services that share the repository with a
+// root path only run when the event changed files under it, and trigger
+// rules may exclude each service.
+func (h *WebhookHandler) triggerTestRun(ctx context.Context, t webhookTrigger) error {
+	services, err := h.findServicesByRepo(ctx, t.owner, t.repo, t.repoFullName)
+	if err != nil {
+		return err
+	}
+	if len(services) == 0 {
+		h.logger.Debug().
+			Str("repo", t.repoFullName).
 			Msg("no service found for repository")
 		return nil // Not an error - repo might not be registered
 	}
+	h.recordBranchPush(ctx, services, t)
+
+	var (
+		changed      []string
+		changedKnown bool
+		resolved     bool
+		errs         []error
+	)
+	for i := range services {
+		service := &services[i]
+		if service.ArchivedAt != nil {
+			h.logger.Info().
+				Str("service", service.Name).
+				Str("sha", t.sha).
+				Msg("service is archived, skipping run")
+			continue
+		}
+		if service.RootPath != nil {
+			if !resolved {
+				changed, changedKnown = h.resolveChangedPaths(ctx, service, t)
+				resolved = true
+			}
+			if changedKnown && !touchesRootPath(*service.RootPath, changed) {
+				h.logger.Info().
+					Str("service", service.Name).
+					Str("root_path", *service.RootPath).
+					Str("sha", t.sha).
+					Msg("no changes under service root path, skipping run")
+				continue
+			}
+		}
+		if err := h.triggerServiceRun(ctx, service, t); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// recordBranchPush moves the pushed branch of each service to the pushed
+// commit. Branch tracking is best effort and never fails the webhook.
+func (h *WebhookHandler) recordBranchPush(ctx context.Context, services []database.Service, t webhookTrigger) {
+	if h.branches == nil || t.tag || t.pullRequest > 0 || t.ref == "" {
+		return
+	}
+	for _, service := range services {
+		if err := h.branches.RecordPush(ctx, service.ID, t.ref, t.sha); err != nil {
+			h.logger.Warn().
+				Err(err).
+				Str("service", service.Name).
+				Str("ref", t.ref).
+				Msg("failed to record branch push")
+		}
+	}
+}
+
+// deleteBranch removes a deleted branch from the services of the event's
+// repository. Tag deletions and other refs are ignored.
+func (h *WebhookHandler) deleteBranch(ctx context.Context, ref string, t webhookTrigger) {
+	name, tag, ok := parsePushRef(ref)
+	if !ok || tag || h.branches == nil {
+		h.logger.Debug().
+			Str("ref", ref).
+			Msg("ignoring ref deletion")
+		return
+	}
+
+	services, err := h.findServicesByRepo(ctx, t.owner, t.repo, t.repoFullName)
+	if err != nil {
+		h.logger.Warn().Err(err).Str("ref", ref).Msg("failed to look up services for branch deletion")
+		return
+	}
+	for _, service := range services {
+		if err := h.branches.Delete(ctx, service.ID, name); err != nil {
+			if !database.IsNotFound(err) {
+				h.logger.Warn().
+					Err(err).
+					Str("service", service.Name).
+					Str("ref", name).
+					Msg("failed to delete branch")
+			}
+			continue
+		}
+		h.logger.Info().
+			Str("service", service.Name).
+			Str("ref", name).
+			Msg("branch deleted")
+	}
+}
+
+// triggerServiceRun schedules a test run of one service unless its trigger
+// rules exclude the event or the throttle coalesces it.
+func (h *WebhookHandler) triggerServiceRun(ctx context.Context, service *database.Service, t webhookTrigger) error {
+	rules, err := h.loadTriggerRules(ctx, service.ID)
+	if err != nil {
+		return err
+	}
+	if reason := rules.skipReason(t); reason != "" {
+		h.logger.Info().
+			Str("service", service.Name).
+			Str("ref", t.ref).
+			Str("sha", t.sha).
+			Str("reason", reason).
+			Msg("webhook event does not trigger a run")
+		return nil
+	}
 
 	if h.scheduler == nil {
 		h.logger.Debug().Msg("no scheduler configured")
 		return nil
 	}
 
+	if h.throttle != nil {
+		// The coalesced run starts after this request has finished.
+		detached := context.WithoutCancel(ctx)
+		start := func() {
+			if err := h.scheduleRun(detached, service, t); err != nil {
+				h.logger.Error().
+					Err(err).
+					Str("service", service.Name).
+					Str("ref", t.ref).
+					Str("sha", t.sha).
+					Msg("failed to schedule coalesced run")
+			}
+		}
+		if !h.throttle.Coalesce(throttleKey(service.ID, t.ref, t.pullRequest), start) {
+			h.logger.Info().
+				Str("service", service.Name).
+				Str("ref", t.ref).
+				Str("sha", t.sha).
+				Msg("trigger throttled, coalescing into next run")
+			return nil
+		}
+	}
+
+	return h.scheduleRun(ctx, service, t)
+}
+
+// scheduleRun schedules a webhook-triggered run of the service.
+func (h *WebhookHandler) scheduleRun(ctx context.Context, service *database.Service, t webhookTrigger) error {
+	// Higher priority for PRs
+	priority := 0
+	if t.pullRequest > 0 {
+		priority = 1
+	}
+
 	run, err := h.scheduler.ScheduleRun(ctx, ScheduleRunRequest{
-		ServiceID:   service.ID,
-		GitRef:      branch,
-		GitSHA:      sha,
-		TriggerType: database.TriggerTypeWebhook,
-		TriggeredBy: triggeredBy,
-		Priority:    priority,
+		ServiceID:         service.ID,
+		GitRef:            t.ref,
+		GitSHA:            t.sha,
+		TriggerType:       database.TriggerTypeWebhook,
+		TriggeredBy:       t.triggeredBy,
+		Priority:          priority,
+		PullRequestNumber: t.pullRequest,
+		IdempotencyKey:    deliveryFromContext(ctx),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to schedule run: %w", err)
@@ -544,15 +1227,89 @@ func (h *WebhookHandler) triggerTestRun(ctx context.Context, repoFullName, owner
 	h.logger.Info().
 		Str("run_id", run.ID.String()).
 		Str("service", service.Name).
-		Str("branch", branch).
-		Str("sha", sha).
+		Str("ref", t.ref).
+		Str("sha", t.sha).
 		Msg("scheduled test run from webhook")
 
 	return nil
 }
 
-// findServiceByRepo finds a service by repository information.
-func (h *WebhookHandler) findServiceByRepo(ctx context.Context, owner, repo, fullName string) (*database.Service, error) {
+// loadTriggerRules returns the service's compiled trigger rules, or nil when
+// it has none. Rules that no longer compile are treated as absent so that a
+// bad pattern does not silently stop all runs.
+func (h *WebhookHandler) loadTriggerRules(ctx context.Context, serviceID uuid.UUID) (*triggerRules, error) {
+	if h.triggerRules == nil {
+		return nil, nil
+	}
+
+	stored, err := h.triggerRules.GetByService(ctx, serviceID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get trigger rules: %w", err)
+	}
+
+	rules, err := compileTriggerRules(stored)
+	if err != nil {
+		h.logger.Warn().
+			Str("service_id", serviceID.String()).
+			Err(err).
+			Msg("ignoring invalid trigger rules")
+		return nil, nil
+	}
+	return rules, nil
+}
+
+// parsePushRef splits a pushed ref into a branch or tag name. ok is false for
+// other refs, such as notes or pull request heads.
+func parsePushRef(ref string) (name string, tag bool, ok bool) {
+	if branch, found := strings.CutPrefix(ref, "refs/heads/"); found {
+		return branch, false, true
+	}
+	if tagName, found := strings.CutPrefix(ref, "refs/tags/"); found {
+		return tagName, true, true
+	}
+	return "", false, false
+}
+
+// changedPaths collects the files touched by a push. Providers truncate the
+// commit list of large pushes, so the paths are only known when every commit
+// is present; total is the pushed commit count, or -1 when the provider does
+// not report it.
+func changedPaths(commits []webhookCommit, total int) ([]string, bool) {
+	if len(commits) == 0 || (total >= 0 && len(commits) < total) {
+		return nil, false
+	}
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, commit := range commits {
+		for _, files := range [][]string{commit.Added, commit.Modified, commit.Removed} {
+			for _, file := range files {
+				if !seen[file] {
+					seen[file] = true
+					paths = append(paths, file)
+				}
+			}
+		}
+	}
+	return paths, true
+}
+
+// headCommitMessage returns the message of the pushed head commit.
+func headCommitMessage(commits []webhookCommit, sha string) string {
+	for _, commit := range commits {
+		if commit.ID == sha {
+			return commit.Message
+		}
+	}
+	return ""
+}
+
+// findServicesByRepo finds the services registered for a repository. A
+// monorepo may be registered as several services with distinct root paths.
+func (h *WebhookHandler) findServicesByRepo(ctx context.Context, owner, repo, fullName string) ([]database.Service, error) {
 	patterns := []string{
 		fmt.Sprintf("https://github.com/%s", fullName),
 		fmt.Sprintf("https://gitlab.com/%s", fullName),
@@ -569,15 +1326,16 @@ func (h *WebhookHandler) findServiceByRepo(ctx context.Context, owner, repo, ful
 		return nil, fmt.Errorf("failed to list services: %w", err)
 	}
 
+	var matched []database.Service
 	for _, service := range services {
 		for _, pattern := range patterns {
 			if strings.Contains(strings.ToLower(service.GitURL), strings.ToLower(pattern)) {
-				return &service, nil
+				matched = append(matched, service)
+				break
 			}
 		}
 	}
-
-	return nil, fmt.Errorf("service not found for repository: %s", fullName)
+	return matched, nil
 }
 
 // Signature validation
@@ -603,8 +1361,51 @@ func validateGitHubSignature(payload []byte, signature, secret string) bool {
 	return hmac.Equal([]byte(expectedSig), []byte(actualSig))
 }
 
+func validateGiteaSignature(payload []byte, signature, secret string) bool {
+	if secret == "" {
+		return true
+	}
+	if signature == "" {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	actualSig := hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(strings.ToLower(signature)), []byte(actualSig))
+}
+
+// validateAzureDevOpsAuth checks the secret configured on the service hook,
+// sent either as the basic auth password or as a bearer token.
+func validateAzureDevOpsAuth(r *http.Request, secret string) bool {
+	if secret == "" {
+		return true
+	}
+
+	if _, password, ok := r.BasicAuth(); ok {
+		return subtle.ConstantTimeCompare([]byte(password), []byte(secret)) == 1
+	}
+
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if strings.HasPrefix(auth, prefix) {
+		return subtle.ConstantTimeCompare([]byte(auth[len(prefix):]), []byte(secret)) == 1
+	}
+	return false
+}
+
 // Webhook event structures (local to avoid import cycles)
 
+// webhookCommit is a pushed commit as reported by GitHub, GitLab and Gitea.
+type webhookCommit struct {
+	ID       string   `json:"id"`
+	Message  string   `json:"message"`
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+}
+
 type githubWebhookPushEvent struct {
 	Ref     string `json:"ref"`
 	Before  string `json:"before"`
@@ -614,6 +1415,10 @@ type githubWebhookPushEvent struct {
 		Name  string `json:"name"`
 		Email string `json:"email"`
 	} `json:"pusher"`
+	HeadCommit struct {
+		Message string `json:"message"`
+	} `json:"head_commit"`
+	Commits    []webhookCommit `json:"commits"`
 	Repository struct {
 		Name     string `json:"name"`
 		FullName string `json:"full_name"`
@@ -630,10 +1435,14 @@ type githubWebhookPREvent struct {
 	Action      string `json:"action"`
 	Number      int    `json:"number"`
 	PullRequest struct {
-		Head struct {
+		Title string `json:"title"`
+		Head  struct {
 			Ref string `json:"ref"`
 			SHA string `json:"sha"`
 		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
 	} `json:"pull_request"`
 	Repository struct {
 		Name     string `json:"name"`
@@ -652,6 +1461,9 @@ type githubWebhookCheckSuiteEvent struct {
 	CheckSuite struct {
 		HeadBranch string `json:"head_branch"`
 		HeadSHA    string `json:"head_sha"`
+		HeadCommit struct {
+			Message string `json:"message"`
+		} `json:"head_commit"`
 	} `json:"check_suite"`
 	Repository struct {
 		Name     string `json:"name"`
@@ -673,13 +1485,17 @@ type gitlabWebhookPushEvent struct {
 	Project      struct {
 		PathWithNamespace string `json:"path_with_namespace"`
 	} `json:"project"`
+	Commits           []webhookCommit `json:"commits"`
+	TotalCommitsCount int             `json:"total_commits_count"`
 }
 
 type gitlabWebhookMREvent struct {
 	ObjectAttributes struct {
 		IID          int    `json:"iid"`
 		Action       string `json:"action"`
+		Title        string `json:"title"`
 		SourceBranch string `json:"source_branch"`
+		TargetBranch string `json:"target_branch"`
 		LastCommit   struct {
 			ID string `json:"id"`
 		} `json:"last_commit"`
@@ -697,10 +1513,19 @@ type bitbucketWebhookPushEvent struct {
 		Changes []struct {
 			New struct {
 				Name   string `json:"name"`
+				Type   string `json:"type"`
 				Target struct {
-					Hash string `json:"hash"`
+					Hash    string `json:"hash"`
+					Message string `json:"message"`
 				} `json:"target"`
 			} `json:"new"`
+			Old struct {
+				Name   string `json:"name"`
+				Type   string `json:"type"`
+				Target struct {
+					Hash string `json:"hash"`
+				} `json:"target"`
+			} `json:"old"`
 			Closed bool `json:"closed"`
 		} `json:"changes"`
 	} `json:"push"`
@@ -714,7 +1539,8 @@ type bitbucketWebhookPushEvent struct {
 
 type bitbucketWebhookPREvent struct {
 	PullRequest struct {
-		ID     int `json:"id"`
+		ID     int    `json:"id"`
+		Title  string `json:"title"`
 		Source struct {
 			Branch struct {
 				Name string `json:"name"`
@@ -723,6 +1549,11 @@ type bitbucketWebhookPREvent struct {
 				Hash string `json:"hash"`
 			} `json:"commit"`
 		} `json:"source"`
+		Destination struct {
+			Branch struct {
+				Name string `json:"name"`
+			} `json:"branch"`
+		} `json:"destination"`
 	} `json:"pullrequest"`
 	Repository struct {
 		FullName string `json:"full_name"`
@@ -731,3 +1562,89 @@ type bitbucketWebhookPREvent struct {
 		Username string `json:"username"`
 	} `json:"actor"`
 }
+
+type giteaWebhookPushEvent struct {
+	Ref    string `json:"ref"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+	Pusher struct {
+		Login string `json:"login"`
+	} `json:"pusher"`
+	HeadCommit struct {
+		Message string `json:"message"`
+	} `json:"head_commit"`
+	Commits      []webhookCommit `json:"commits"`
+	TotalCommits int             `json:"total_commits"`
+	Repository   struct {
+		Name     string `json:"name"`
+		FullName string `json:"full_name"`
+		Owner    struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repository"`
+}
+
+type giteaWebhookPREvent struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Title string `json:"title"`
+		Head  struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Repository struct {
+		Name     string `json:"name"`
+		FullName string `json:"full_name"`
+		Owner    struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repository"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+}
+
+type azureDevOpsRepository struct {
+	Name      string `json:"name"`
+	RemoteURL string `json:"remoteUrl"`
+}
+
+type azureDevOpsIdentity struct {
+	UniqueName string `json:"uniqueName"`
+}
+
+type azureDevOpsPushEvent struct {
+	Resource struct {
+		RefUpdates []struct {
+			Name        string `json:"name"`
+			OldObjectID string `json:"oldObjectId"`
+			NewObjectID string `json:"newObjectId"`
+		} `json:"refUpdates"`
+		Commits []struct {
+			CommitID string `json:"commitId"`
+			Comment  string `json:"comment"`
+		} `json:"commits"`
+		Repository azureDevOpsRepository `json:"repository"`
+		PushedBy   azureDevOpsIdentity   `json:"pushedBy"`
+	} `json:"resource"`
+}
+
+type azureDevOpsPREvent struct {
+	Resource struct {
+		PullRequestID         int    `json:"pullRequestId"`
+		Status                string `json:"status"`
+		Title                 string `json:"title"`
+		SourceRefName         string `json:"sourceRefName"`
+		TargetRefName         string `json:"targetRefName"`
+		LastMergeSourceCommit struct {
+			CommitID string `json:"commitId"`
+		} `json:"lastMergeSourceCommit"`
+		Repository azureDevOpsRepository `json:"repository"`
+		CreatedBy  azureDevOpsIdentity   `json:"createdBy"`
+	} `json:"resource"`
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -179,6 +180,28 @@ func TestHandleGitHubWebhook_Push(t *testing.T) {
 	assert.Equal(t, "main", scheduler.requests[0].GitRef)
 	assert.Equal(t, "abc123def456", scheduler.requests[0].GitSHA)
 	assert.Equal(t, "test-user", scheduler.requests[0].TriggeredBy)
+	assert.Zero(t, scheduler.requests[0].PullRequestNumber)
+	assert.Equal(t, "github:test-delivery-id", scheduler.requests[0].IdempotencyKey)
+}
+
+func TestHandleGitHubWebhook_SkipsArchivedServices(t *testing.T) {
+	archivedAt := time.Now()
+	active := database.Service{ID: uuid.New(), Name: "api", GitURL: "https://github.com/owner/repo"}
+	archived := database.Service{ID: uuid.New(), Name: "legacy", GitURL: "https://github.com/owner/repo", ArchivedAt: &archivedAt}
+	scheduler := &mockScheduler{}
+	handler := NewWebhookHandler(WebhookConfig{},
+		&mockServiceRepo{services: []database.Service{archived, active}}, scheduler, zerolog.Nop())
+
+	payload := `{"ref":"refs/heads/main","before":"0000000000000000000000000000000000000000","after":"abc123","repository":{"name":"repo","full_name":"owner/repo","owner":{"login":"owner"}}}`
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader([]byte(payload)))
+	req.Header.Set("X-GitHub-Event", "push")
+	rr := httptest.NewRecorder()
+	handler.HandleGitHubWebhook(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	require.Len(t, scheduler.requests, 1)
+	assert.Equal(t, active.ID, scheduler.requests[0].ServiceID)
+	assert.Empty(t, scheduler.requests[0].IdempotencyKey, "deliveries without an ID have no key")
 }
 
 func TestHandleGitHubWebhook_InvalidSignature(t *testing.T) {
@@ -262,6 +285,7 @@ func TestHandleGitHubWebhook_PullRequest(t *testing.T) {
 	assert.Equal(t, "pr-sha-123", scheduler.requests[0].GitSHA)
 	assert.Equal(t, "pr-author", scheduler.requests[0].TriggeredBy)
 	assert.Equal(t, 1, scheduler.requests[0].Priority) // PRs get higher priority
+	assert.Equal(t, 42, scheduler.requests[0].PullRequestNumber)
 }
 
 func TestHandleGitHubWebhook_Ping(t *testing.T) {
@@ -475,6 +499,286 @@ func TestHandleBitbucketWebhook_InvalidAuth(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, rr.Code)
 }
 
+func TestHandleGiteaWebhook_Push(t *testing.T) {
+	logger := zerolog.Nop()
+	serviceID := uuid.New()
+
+	serviceRepo := &mockServiceRepo{
+		services: []database.Service{
+			{
+				ID:     serviceID,
+				Name:   "test-service",
+				GitURL: "https://gitea.example.com/owner/repo.git",
+			},
+		},
+	}
+
+	scheduler := &mockScheduler{}
+	secret := "gitea-secret"
+
+	handler := NewWebhookHandler(
+		WebhookConfig{GiteaSecret: secret},
+		serviceRepo,
+		scheduler,
+		logger,
+	)
+
+	payload := map[string]interface{}{
+		"ref":   "refs/heads/main",
+		"after": "gitea-sha-789",
+		"pusher": map[string]string{
+			"login": "gitea-user",
+		},
+		"repository": map[string]interface{}{
+			"name":      "repo",
+			"full_name": "owner/repo",
+			"owner": map[string]string{
+				"login": "owner",
+			},
+		},
+	}
+	payloadBytes, _ := json.Marshal(payload)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payloadBytes)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/gitea", bytes.NewReader(payloadBytes))
+	req.Header.Set("X-Gitea-Event", "push")
+	req.Header.Set("X-Gitea-Signature", signature)
+
+	rr := httptest.NewRecorder()
+	handler.HandleGiteaWebhook(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	require.Len(t, scheduler.requests, 1)
+	assert.Equal(t, serviceID, scheduler.requests[0].ServiceID)
+	assert.Equal(t, "main", scheduler.requests[0].GitRef)
+	assert.Equal(t, "gitea-sha-789", scheduler.requests[0].GitSHA)
+}
+
+func TestHandleGiteaWebhook_PullRequest(t *testing.T) {
+	logger := zerolog.Nop()
+	serviceID := uuid.New()
+
+	serviceRepo := &mockServiceRepo{
+		services: []database.Service{
+			{
+				ID:     serviceID,
+				Name:   "test-service",
+				GitURL: "https://gitea.example.com/owner/repo.git",
+			},
+		},
+	}
+
+	scheduler := &mockScheduler{}
+
+	handler := NewWebhookHandler(
+		WebhookConfig{},
+		serviceRepo,
+		scheduler,
+		logger,
+	)
+
+	payload := map[string]interface{}{
+		"action": "synchronized",
+		"number": 7,
+		"pull_request": map[string]interface{}{
+			"head": map[string]string{
+				"ref": "feature",
+				"sha": "pr-sha-123",
+			},
+		},
+		"repository": map[string]interface{}{
+			"name":      "repo",
+			"full_name": "owner/repo",
+			"owner": map[string]string{
+				"login": "owner",
+			},
+		},
+		"sender": map[string]string{
+			"login": "gitea-user",
+		},
+	}
+	payloadBytes, _ := json.Marshal(payload)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/gitea", bytes.NewReader(payloadBytes))
+	req.Header.Set("X-Gitea-Event", "pull_request")
+
+	rr := httptest.NewRecorder()
+	handler.HandleGiteaWebhook(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	require.Len(t, scheduler.requests, 1)
+	assert.Equal(t, "feature", scheduler.requests[0].GitRef)
+	assert.Equal(t, "pr-sha-123", scheduler.requests[0].GitSHA)
+	assert.Equal(t, 1, scheduler.requests[0].Priority)
+	assert.Equal(t, 7, scheduler.requests[0].PullRequestNumber)
+}
+
+func TestHandleGiteaWebhook_InvalidSignature(t *testing.T) {
+	logger := zerolog.Nop()
+
+	handler := NewWebhookHandler(
+		WebhookConfig{GiteaSecret: "correct-secret"},
+		&mockServiceRepo{},
+		&mockScheduler{},
+		logger,
+	)
+
+	payload := []byte(`{"ref": "refs/heads/main"}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/gitea", bytes.NewReader(payload))
+	req.Header.Set("X-Gitea-Event", "push")
+	req.Header.Set("X-Gitea-Signature", "invalid")
+
+	rr := httptest.NewRecorder()
+	handler.HandleGiteaWebhook(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestHandleAzureDevOpsWebhook_Push(t *testing.T) {
+	logger := zerolog.Nop()
+	serviceID := uuid.New()
+
+	serviceRepo := &mockServiceRepo{
+		services: []database.Service{
+			{
+				ID:     serviceID,
+				Name:   "test-service",
+				GitURL: "git@ssh.dev.azure.com:v3/org/project/repo",
+			},
+		},
+	}
+
+	scheduler := &mockScheduler{}
+
+	handler := NewWebhookHandler(
+		WebhookConfig{AzureDevOpsSecret: "ado-secret"},
+		serviceRepo,
+		scheduler,
+		logger,
+	)
+
+	payload := map[string]interface{}{
+		"eventType": "git.push",
+		"resource": map[string]interface{}{
+			"refUpdates": []map[string]string{
+				{
+					"name":        "refs/heads/main",
+					"oldObjectId": "aaaa",
+					"newObjectId": "ado-sha-321",
+				},
+				{
+					"name":        "refs/heads/removed",
+					"oldObjectId": "bbbb",
+					"newObjectId": "0000000000000000000000000000000000000000",
+				},
+			},
+			"repository": map[string]string{
+				"name":      "repo",
+				"remoteUrl": "https://org@dev.azure.com/org/project/_git/repo",
+			},
+			"pushedBy": map[string]string{
+				"uniqueName": "user@example.com",
+			},
+		},
+	}
+	payloadBytes, _ := json.Marshal(payload)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/azure-devops", bytes.NewReader(payloadBytes))
+	req.SetBasicAuth("conductor", "ado-secret")
+
+	rr := httptest.NewRecorder()
+	handler.HandleAzureDevOpsWebhook(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	require.Len(t, scheduler.requests, 1)
+	assert.Equal(t, serviceID, scheduler.requests[0].ServiceID)
+	assert.Equal(t, "main", scheduler.requests[0].GitRef)
+	assert.Equal(t, "ado-sha-321", scheduler.requests[0].GitSHA)
+}
+
+func TestHandleAzureDevOpsWebhook_PullRequest(t *testing.T) {
+	logger := zerolog.Nop()
+	serviceID := uuid.New()
+
+	serviceRepo := &mockServiceRepo{
+		services: []database.Service{
+			{
+				ID:     serviceID,
+				Name:   "test-service",
+				GitURL: "https://dev.azure.com/org/project/_git/repo",
+			},
+		},
+	}
+
+	scheduler := &mockScheduler{}
+
+	handler := NewWebhookHandler(
+		WebhookConfig{AzureDevOpsSecret: "ado-secret"},
+		serviceRepo,
+		scheduler,
+		logger,
+	)
+
+	payload := map[string]interface{}{
+		"eventType": "git.pullrequest.updated",
+		"resource": map[string]interface{}{
+			"pullRequestId": 42,
+			"status":        "active",
+			"sourceRefName": "refs/heads/feature",
+			"lastMergeSourceCommit": map[string]string{
+				"commitId": "ado-pr-sha",
+			},
+			"repository": map[string]string{
+				"name":      "repo",
+				"remoteUrl": "https://dev.azure.com/org/project/_git/repo",
+			},
+			"createdBy": map[string]string{
+				"uniqueName": "user@example.com",
+			},
+		},
+	}
+	payloadBytes, _ := json.Marshal(payload)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/azure-devops", bytes.NewReader(payloadBytes))
+	req.Header.Set("Authorization", "Bearer ado-secret")
+
+	rr := httptest.NewRecorder()
+	handler.HandleAzureDevOpsWebhook(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	require.Len(t, scheduler.requests, 1)
+	assert.Equal(t, serviceID, scheduler.requests[0].ServiceID)
+	assert.Equal(t, "feature", scheduler.requests[0].GitRef)
+	assert.Equal(t, "ado-pr-sha", scheduler.requests[0].GitSHA)
+	assert.Equal(t, 1, scheduler.requests[0].Priority)
+	assert.Equal(t, 42, scheduler.requests[0].PullRequestNumber)
+}
+
+func TestHandleAzureDevOpsWebhook_InvalidAuth(t *testing.T) {
+	logger := zerolog.Nop()
+
+	handler := NewWebhookHandler(
+		WebhookConfig{AzureDevOpsSecret: "correct-secret"},
+		&mockServiceRepo{},
+		&mockScheduler{},
+		logger,
+	)
+
+	payload := []byte(`{"eventType": "git.push"}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/azure-devops", bytes.NewReader(payload))
+	req.SetBasicAuth("conductor", "wrong-secret")
+
+	rr := httptest.NewRecorder()
+	handler.HandleAzureDevOpsWebhook(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
 func TestFindServiceByRepo(t *testing.T) {
 	logger := zerolog.Nop()
 	serviceID := uuid.New()
@@ -542,12 +846,13 @@ func TestFindServiceByRepo(t *testing.T) {
 				logger,
 			)
 
-			svc, err := handler.findServiceByRepo(context.Background(), tt.owner, tt.repo, tt.fullName)
+			services, err := handler.findServicesByRepo(context.Background(), tt.owner, tt.repo, tt.fullName)
+			require.NoError(t, err)
 			if tt.wantFound {
-				require.NoError(t, err)
-				assert.Equal(t, tt.wantSvcID, svc.ID)
+				require.Len(t, services, 1)
+				assert.Equal(t, tt.wantSvcID, services[0].ID)
 			} else {
-				assert.Error(t, err)
+				assert.Empty(t, services)
 			}
 		})
 	}
@@ -571,9 +876,13 @@ func TestRegisterRoutes(t *testing.T) {
 		"/api/v1/webhooks/github",
 		"/api/v1/webhooks/gitlab",
 		"/api/v1/webhooks/bitbucket",
+		"/api/v1/webhooks/gitea",
+		"/api/v1/webhooks/azure-devops",
 		"/webhooks/github",
 		"/webhooks/gitlab",
 		"/webhooks/bitbucket",
+		"/webhooks/gitea",
+		"/webhooks/azure-devops",
 	}
 
 	for _, route := range routes {
@@ -587,3 +896,48 @@ func TestRegisterRoutes(t *testing.T) {
 		})
 	}
 }
+
+// mockBranchRepo implements WebhookBranchRepository for testing.
+type mockBranchRepo struct {
+	branches map[string]string
+}
+
+func (m *mockBranchRepo) RecordPush(ctx context.Context, serviceID uuid.UUID, name, sha string) error {
+	m.branches[name] = sha
+	return nil
+}
+
+func (m *mockBranchRepo) Delete(ctx context.Context, serviceID uuid.UUID, name string) error {
+	if _, ok := m.branches[name]; !ok {
+		return database.ErrNotFound
+	}
+	delete(m.branches, name)
+	return nil
+}
+
+func TestHandleGitHubWebhook_BranchTracking(t *testing.T) {
+	serviceRepo := &mockServiceRepo{services: []database.Service{
+		{ID: uuid.New(), Name: "test-service", GitURL: "https://github.com/owner/repo"},
+	}}
+	branches := &mockBranchRepo{branches: map[string]string{}}
+	handler := NewWebhookHandler(WebhookConfig{}, serviceRepo, &mockScheduler{}, zerolog.Nop())
+	handler.SetBranches(branches)
+
+	send := func(event, payload string) {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader([]byte(payload)))
+		req.Header.Set("X-GitHub-Event", event)
+		rr := httptest.NewRecorder()
+		handler.HandleGitHubWebhook(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+	repo := `"repository":{"name":"repo","full_name":"owner/repo","owner":{"login":"owner"}}`
+
+	send("push", `{"ref":"refs/heads/feature/x","after":"abc123",`+repo+`}`)
+	send("push", `{"ref":"refs/heads/feature/x","after":"def456",`+repo+`}`)
+	send("push", `{"ref":"refs/tags/v1.0.0","after":"abc123",`+repo+`}`)
+	send("pull_request", `{"action":"opened","number":1,"pull_request":{"head":{"ref":"fork-branch","sha":"abc123"}},`+repo+`}`)
+	assert.Equal(t, map[string]string{"feature/x": "def456"}, branches.branches)
+
+	send("push", `{"ref":"refs/heads/feature/x","deleted":true,"after":"0000000000000000000000000000000000000000",`+repo+`}`)
+	assert.Empty(t, branches.branches)
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/idempotency"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// createRunScope returns the scope of the idempotency keys of the caller's
+// run creation requests, so that keys of different users never collide.
+func createRunScope(ctx context.Context) string {
+	scope := "runs.create"
+	if claims := GetUserFromContext(ctx); claims != nil && claims.UserID != "" {
+		scope += ":" + claims.UserID
+	}
+	return scope
+}
+
+// createRunFingerprint returns the fields of a run creation request that a
+// retry must repeat, that is all but the idempotency key.
+func createRunFingerprint(req *conductorv1.CreateRunRequest) any {
+	return struct {
+		ServiceID     string
+		GitRef        *conductorv1.GitRef
+		TestIDs       []string
+		Tags          []string
+		Environment   map[string]string
+		Priority      int32
+		ExecutionType conductorv1.ExecutionType
+		Timeout       *conductorv1.Duration
+		Trigger       *conductorv1.RunTrigger
+		Labels        map[string]string
+		LabelSelector map[string]string
+		Annotations   map[string]string
+		Links         map[string]string
+		Parameters    map[string]string
+	}{
+		ServiceID:     req.GetServiceId(),
+		GitRef:        req.GetGitRef(),
+		TestIDs:       req.GetTestIds(),
+		Tags:          req.GetTags(),
+		Environment:   req.GetEnvironment(),
+		Priority:      req.GetPriority(),
+		ExecutionType: req.GetExecutionType(),
+		Timeout:       req.GetTimeout(),
+		Trigger:       req.GetTrigger(),
+		Labels:        req.GetLabels(),
+		LabelSelector: req.GetLabelSelector(),
+		Annotations:   req.GetAnnotations(),
+		Links:         req.GetLinks(),
+		Parameters:    req.GetParameters(),
+	}
+}
+
+// idempotencyError converts the errors of idempotency.Do to error codes.
+// Errors of the request itself already carry one.
+func idempotencyError(key string, err error) error {
+	metadata := map[string]string{"idempotency_key": key}
+	switch {
+	case errors.Is(err, idempotency.ErrKeyReused):
+		return errcode.NewWithMetadata(errcode.IdempotencyKeyReused, metadata,
+			"idempotency key %q was used with a different request", key)
+	case errors.Is(err, idempotency.ErrInProgress):
+		return errcode.NewWithMetadata(errcode.IdempotencyKeyInProgress, metadata,
+			"a request with idempotency key %q is in progress", key)
+	case errcode.FromError(err) == errcode.Unknown:
+		return errcode.New(errcode.Internal, "failed to check idempotency key: %v", err)
+	}
+	return err
+}
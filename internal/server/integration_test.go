@@ -990,7 +990,7 @@ func (m *mockRunRepository) UpdateShardStats(ctx context.Context, id uuid.UUID,
 // mockWorkScheduler implements WorkScheduler for testing.
 type mockWorkScheduler struct{}
 
-func (m *mockWorkScheduler) AssignWork(ctx context.Context, agentID uuid.UUID, capabilities *conductorv1.Capabilities) (*conductorv1.AssignWork, error) {
+func (m *mockWorkScheduler) AssignWork(ctx context.Context, agentID uuid.UUID, capabilities *conductorv1.Capabilities, labels map[string]string, pool string) (*conductorv1.AssignWork, error) {
 	return nil, nil // No work available
 }
 
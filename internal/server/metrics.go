@@ -10,6 +10,8 @@ import (
 
 	"github.com/rs/zerolog"
 
+	"github.com/conductor/conductor/pkg/diagnostics"
+	conductorlog "github.com/conductor/conductor/pkg/log"
 	"github.com/conductor/conductor/pkg/metrics"
 )
 
@@ -37,10 +39,12 @@ func DefaultMetricsServerConfig() MetricsServerConfig {
 
 // MetricsServer serves Prometheus metrics over HTTP.
 type MetricsServer struct {
-	config  MetricsServerConfig
-	metrics *metrics.Metrics
-	server  *http.Server
-	logger  zerolog.Logger
+	config      MetricsServerConfig
+	metrics     *metrics.Metrics
+	logLevels   *conductorlog.Levels
+	diagnostics *diagnostics.Diagnostics
+	server      *http.Server
+	logger      zerolog.Logger
 }
 
 // NewMetricsServer creates a new metrics server.
@@ -52,6 +56,21 @@ func NewMetricsServer(cfg MetricsServerConfig, m *metrics.Metrics, logger zerolo
 	}
 }
 
+// SetLogLevels serves the log levels under /log-level, to read and change
+// them at runtime. The metrics port should not be exposed publicly.
+// This must be called before Start().
+func (s *MetricsServer) SetLogLevels(levels *conductorlog.Levels) {
+	s.logLevels = levels
+}
+
+// SetDiagnostics serves pprof profiles and runtime diagnostics under
+// /debug/, to clients presenting the diagnostics token. The write timeout is
+// raised so that profiles of up to a minute complete.
+// This must be called before Start().
+func (s *MetricsServer) SetDiagnostics(d *diagnostics.Diagnostics) {
+	s.diagnostics = d
+}
+
 // Start starts the metrics HTTP server.
 func (s *MetricsServer) Start(ctx context.Context) error {
 	mux := http.NewServeMux()
@@ -69,12 +88,24 @@ func (s *MetricsServer) Start(ctx context.Context) error {
 		w.Write([]byte("ok"))
 	})
 
+	// Add log level endpoint if configured
+	if s.logLevels != nil {
+		mux.Handle("/log-level", s.logLevels.HTTPHandler())
+	}
+
+	// Add diagnostics endpoints if configured
+	writeTimeout := s.config.WriteTimeout
+	if s.diagnostics != nil {
+		s.diagnostics.Mount(mux)
+		writeTimeout = max(writeTimeout, diagnostics.WriteTimeout)
+	}
+
 	addr := fmt.Sprintf(":%d", s.config.Port)
 	s.server = &http.Server{
 		Addr:         addr,
 		Handler:      mux,
 		ReadTimeout:  s.config.ReadTimeout,
-		WriteTimeout: s.config.WriteTimeout,
+		WriteTimeout: writeTimeout,
 	}
 
 	s.logger.Info().
@@ -8,9 +8,12 @@ import (
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+	conductorlog "github.com/conductor/conductor/pkg/log"
 )
 
 // LoggingInterceptor provides logging for gRPC calls.
@@ -36,8 +39,9 @@ func (l *LoggingInterceptor) Unary() grpc.UnaryServerInterceptor {
 		start := time.Now()
 		requestID := getOrCreateRequestID(ctx)
 
-		// Add request ID to context
-		ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+		// Add the request ID, and the run ID of the request, to the context
+		ctx = conductorlog.ContextWithRequestID(ctx, requestID)
+		ctx = withRunID(ctx, req)
 
 		// Execute handler
 		resp, err := handler(ctx, req)
@@ -50,11 +54,12 @@ func (l *LoggingInterceptor) Unary() grpc.UnaryServerInterceptor {
 			st, _ := status.FromError(err)
 			logEvent = l.logger.Error().
 				Str("error", st.Message()).
-				Str("code", st.Code().String())
+				Str("code", st.Code().String()).
+				Str("error_code", errcode.FromError(err).String())
 		}
 
 		logEvent.
-			Str("request_id", requestID).
+			Ctx(ctx).
 			Str("method", info.FullMethod).
 			Dur("duration", duration).
 			Msg("unary request completed")
@@ -75,13 +80,14 @@ func (l *LoggingInterceptor) Stream() grpc.StreamServerInterceptor {
 		requestID := getOrCreateRequestID(ss.Context())
 
 		// Wrap stream with request ID in context
+		ctx := conductorlog.ContextWithRequestID(ss.Context(), requestID)
 		wrapped := &wrappedServerStream{
 			ServerStream: ss,
-			ctx:          context.WithValue(ss.Context(), requestIDKey{}, requestID),
+			ctx:          ctx,
 		}
 
 		l.logger.Info().
-			Str("request_id", requestID).
+			Ctx(ctx).
 			Str("method", info.FullMethod).
 			Msg("stream started")
 
@@ -96,11 +102,12 @@ func (l *LoggingInterceptor) Stream() grpc.StreamServerInterceptor {
 			st, _ := status.FromError(err)
 			logEvent = l.logger.Error().
 				Str("error", st.Message()).
-				Str("code", st.Code().String())
+				Str("code", st.Code().String()).
+				Str("error_code", errcode.FromError(err).String())
 		}
 
 		logEvent.
-			Str("request_id", requestID).
+			Ctx(ctx).
 			Str("method", info.FullMethod).
 			Dur("duration", duration).
 			Msg("stream completed")
@@ -137,7 +144,7 @@ func (r *RecoveryInterceptor) Unary() grpc.UnaryServerInterceptor {
 					Str("method", info.FullMethod).
 					Msg("recovered from panic")
 
-				err = status.Error(codes.Internal, "internal server error")
+				err = errcode.New(errcode.Internal, "internal server error")
 			}
 		}()
 
@@ -161,7 +168,7 @@ func (r *RecoveryInterceptor) Stream() grpc.StreamServerInterceptor {
 					Str("method", info.FullMethod).
 					Msg("recovered from panic")
 
-				err = status.Error(codes.Internal, "internal server error")
+				err = errcode.New(errcode.Internal, "internal server error")
 			}
 		}()
 
@@ -214,7 +221,7 @@ func (a *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
 		}
 
 		// Add claims to context
-		ctx = context.WithValue(ctx, userClaimsKey{}, claims)
+		ctx = withUser(ctx, claims)
 
 		return handler(ctx, req)
 	}
@@ -242,7 +249,7 @@ func (a *AuthInterceptor) Stream() grpc.StreamServerInterceptor {
 		// Wrap stream with authenticated context
 		wrapped := &wrappedServerStream{
 			ServerStream: ss,
-			ctx:          context.WithValue(ss.Context(), userClaimsKey{}, claims),
+			ctx:          withUser(ss.Context(), claims),
 		}
 
 		return handler(srv, wrapped)
@@ -253,13 +260,13 @@ func (a *AuthInterceptor) Stream() grpc.StreamServerInterceptor {
 func (a *AuthInterceptor) authenticate(ctx context.Context) (*UserClaims, error) {
 	token, err := extractToken(ctx)
 	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "missing or invalid authorization: %v", err)
+		return nil, errcode.New(errcode.Unauthenticated, "missing or invalid authorization: %v", err)
 	}
 
 	claims, err := a.validator.Validate(token)
 	if err != nil {
 		a.logger.Debug().Err(err).Msg("token validation failed")
-		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+		return nil, errcode.New(errcode.Unauthenticated, "invalid token: %v", err)
 	}
 
 	return claims, nil
@@ -277,7 +284,6 @@ func (w *wrappedServerStream) Context() context.Context {
 }
 
 // Context keys
-type requestIDKey struct{}
 type userClaimsKey struct{}
 
 // getOrCreateRequestID extracts the request ID from metadata or creates a new one.
@@ -295,19 +301,19 @@ func getOrCreateRequestID(ctx context.Context) string {
 func extractToken(ctx context.Context) (string, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return "", status.Error(codes.Unauthenticated, "no metadata in context")
+		return "", errcode.New(errcode.Unauthenticated, "no metadata in context")
 	}
 
 	values := md.Get("authorization")
 	if len(values) == 0 {
-		return "", status.Error(codes.Unauthenticated, "authorization header not provided")
+		return "", errcode.New(errcode.Unauthenticated, "authorization header not provided")
 	}
 
 	// Expect "Bearer <token>"
 	auth := values[0]
 	const prefix = "Bearer "
 	if len(auth) < len(prefix) || auth[:len(prefix)] != prefix {
-		return "", status.Error(codes.Unauthenticated, "invalid authorization header format")
+		return "", errcode.New(errcode.Unauthenticated, "invalid authorization header format")
 	}
 
 	return auth[len(prefix):], nil
@@ -315,10 +321,26 @@ func extractToken(ctx context.Context) (string, error) {
 
 // GetRequestID returns the request ID from the context.
 func GetRequestID(ctx context.Context) string {
-	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
-		return id
+	return conductorlog.RequestIDFromContext(ctx)
+}
+
+// withRunID returns a context carrying the ID of the run a request is about,
+// if any, which the logs of the context include.
+func withRunID(ctx context.Context, req any) context.Context {
+	if r, ok := req.(interface{ GetRunId() string }); ok && r.GetRunId() != "" {
+		return conductorlog.ContextWithRunID(ctx, r.GetRunId())
+	}
+	return ctx
+}
+
+// withUser returns a context carrying the user's claims whose repository
+// queries are limited to the user's projects.
+func withUser(ctx context.Context, claims *UserClaims) context.Context {
+	ctx = context.WithValue(ctx, userClaimsKey{}, claims)
+	if scope, ok := claims.ProjectScope(); ok {
+		ctx = database.WithProjectScope(ctx, scope)
 	}
-	return ""
+	return ctx
 }
 
 // GetUserFromContext returns the user claims from the context.
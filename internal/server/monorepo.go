@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// ChangedPathsResolver lists the files changed between two commits of a
+// service's repository through the git provider API.
+type ChangedPathsResolver interface {
+	ChangedPaths(ctx context.Context, service *database.Service, base, head string) ([]string, error)
+}
+
+// SetChangedPaths enables listing changed files through the git provider
+// API, used to decide which services of a monorepo a push affects. Without
+// it only the file lists included in push payloads are used.
+func (h *WebhookHandler) SetChangedPaths(resolver ChangedPathsResolver) {
+	h.changedPaths = resolver
+}
+
+// normalizeRootPath cleans a service root path so that it can be compared
+// against repository-relative file paths. An empty result means the service
+// covers the whole repository.
+func normalizeRootPath(p string) (string, error) {
+	p = strings.Trim(strings.TrimSpace(p), "/")
+	if p == "" {
+		return "", nil
+	}
+	if strings.ContainsAny(p, "*?[]\\") {
+		return "", errcode.New(errcode.InvalidArgument, "root_path must be a directory, not a pattern: %q", p)
+	}
+	cleaned := path.Clean(p)
+	if cleaned == "." {
+		return "", nil
+	}
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", errcode.New(errcode.InvalidArgument, "root_path must be inside the repository: %q", p)
+	}
+	return cleaned, nil
+}
+
+// underRootPath reports whether the repository-relative file p is inside
+// the root directory.
+func underRootPath(root, p string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(p, "/"), root+"/")
+}
+
+// touchesRootPath reports whether any of the changed files is inside root.
+func touchesRootPath(root string, changed []string) bool {
+	for _, p := range changed {
+		if underRootPath(root, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// isZeroSHA reports whether sha is the all-zero object ID providers send
+// for newly created branches.
+func isZeroSHA(sha string) bool {
+	return strings.Trim(sha, "0") == ""
+}
+
+// resolveChangedPaths lists the files changed by the event for services with
+// a root path. The provider API is preferred because push payloads truncate
+// large pushes and pull request events carry no file list at all. ok is
+// false when the changes cannot be determined, in which case every service
+// of the repository runs.
+func (h *WebhookHandler) resolveChangedPaths(ctx context.Context, service *database.Service, t webhookTrigger) ([]string, bool) {
+	if h.changedPaths != nil && !isZeroSHA(t.base) {
+		paths, err := h.changedPaths.ChangedPaths(ctx, service, t.base, t.sha)
+		if err == nil {
+			return paths, true
+		}
+		h.logger.Warn().
+			Err(err).
+			Str("repo", t.repoFullName).
+			Str("base", t.base).
+			Str("sha", t.sha).
+			Msg("failed to list changed files from git provider")
+	}
+	if t.pathsKnown {
+		return t.changedPaths, true
+	}
+	return nil, false
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// mockChangedPaths implements ChangedPathsResolver for testing.
+type mockChangedPaths struct {
+	paths []string
+	err   error
+	calls int
+	base  string
+}
+
+func (m *mockChangedPaths) ChangedPaths(ctx context.Context, service *database.Service, base, head string) ([]string, error) {
+	m.calls++
+	m.base = base
+	return m.paths, m.err
+}
+
+func TestNormalizeRootPath(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "", want: ""},
+		{in: "/", want: ""},
+		{in: ".", want: ""},
+		{in: "services/api", want: "services/api"},
+		{in: " /services/api/ ", want: "services/api"},
+		{in: "services//api/./", want: "services/api"},
+		{in: "services/../api", want: "api"},
+		{in: "../api", wantErr: true},
+		{in: "services/*", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := normalizeRootPath(tt.in)
+			if tt.wantErr {
+				assert.True(t, errcode.Is(err, errcode.InvalidArgument))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUnderRootPath(t *testing.T) {
+	assert.True(t, underRootPath("services/api", "services/api/main.go"))
+	assert.True(t, underRootPath("services/api", "/services/api/pkg/x.go"))
+	assert.False(t, underRootPath("services/api", "services/api-gateway/main.go"))
+	assert.False(t, underRootPath("services/api", "services/api"))
+}
+
+func monorepoServices() (api, web database.Service) {
+	apiRoot, webRoot := "services/api", "services/web"
+	api = database.Service{ID: uuid.New(), Name: "api", GitURL: "https://github.com/owner/mono", RootPath: &apiRoot}
+	web = database.Service{ID: uuid.New(), Name: "web", GitURL: "https://github.com/owner/mono", RootPath: &webRoot}
+	return api, web
+}
+
+func TestHandleGitHubWebhook_MonorepoPushPaths(t *testing.T) {
+	api, web := monorepoServices()
+	shared := database.Service{ID: uuid.New(), Name: "e2e", GitURL: "https://github.com/owner/mono"}
+	scheduler := &mockScheduler{}
+	handler := NewWebhookHandler(WebhookConfig{},
+		&mockServiceRepo{services: []database.Service{api, web, shared}}, scheduler, zerolog.Nop())
+
+	payload := `{"ref":"refs/heads/main","before":"0000000000000000000000000000000000000000","after":"abc123","commits":[{"id":"abc123","modified":["services/api/handler.go"]}],"repository":{"name":"mono","full_name":"owner/mono","owner":{"login":"owner"}}}`
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader([]byte(payload)))
+	req.Header.Set("X-GitHub-Event", "push")
+	rr := httptest.NewRecorder()
+	handler.HandleGitHubWebhook(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Len(t, scheduler.requests, 2)
+	assert.Equal(t, api.ID, scheduler.requests[0].ServiceID)
+	assert.Equal(t, shared.ID, scheduler.requests[1].ServiceID, "services without a root path always run")
+}
+
+func TestHandleGitHubWebhook_MonorepoPullRequest(t *testing.T) {
+	api, web := monorepoServices()
+	payload := `{"action":"opened","number":7,"pull_request":{"title":"Restyle","head":{"ref":"feature","sha":"abc123"},"base":{"ref":"main"}},"repository":{"name":"mono","full_name":"owner/mono","owner":{"login":"owner"}}}`
+
+	tests := []struct {
+		name     string
+		resolver *mockChangedPaths
+		want     []uuid.UUID
+	}{
+		{
+			name:     "provider lists changes",
+			resolver: &mockChangedPaths{paths: []string{"services/web/app.css"}},
+			want:     []uuid.UUID{web.ID},
+		},
+		{
+			name:     "provider fails",
+			resolver: &mockChangedPaths{err: errors.New("rate limited")},
+			want:     []uuid.UUID{api.ID, web.ID},
+		},
+		{
+			name: "no provider",
+			want: []uuid.UUID{api.ID, web.ID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler := &mockScheduler{}
+			handler := NewWebhookHandler(WebhookConfig{},
+				&mockServiceRepo{services: []database.Service{api, web}}, scheduler, zerolog.Nop())
+			if tt.resolver != nil {
+				handler.SetChangedPaths(tt.resolver)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader([]byte(payload)))
+			req.Header.Set("X-GitHub-Event", "pull_request")
+			rr := httptest.NewRecorder()
+			handler.HandleGitHubWebhook(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			var got []uuid.UUID
+			for _, r := range scheduler.requests {
+				got = append(got, r.ServiceID)
+			}
+			assert.Equal(t, tt.want, got)
+			if tt.resolver != nil {
+				assert.Equal(t, 1, tt.resolver.calls, "changes are resolved once per event")
+				assert.Equal(t, "main", tt.resolver.base)
+			}
+		})
+	}
+}
+
+func TestHandleGitHubWebhook_MonorepoNewBranchSkipsProvider(t *testing.T) {
+	api, web := monorepoServices()
+	resolver := &mockChangedPaths{}
+	scheduler := &mockScheduler{}
+	handler := NewWebhookHandler(WebhookConfig{},
+		&mockServiceRepo{services: []database.Service{api, web}}, scheduler, zerolog.Nop())
+	handler.SetChangedPaths(resolver)
+
+	payload := `{"ref":"refs/heads/feature","before":"0000000000000000000000000000000000000000","after":"abc123","commits":[{"id":"abc123","added":["services/web/index.ts"]}],"repository":{"name":"mono","full_name":"owner/mono","owner":{"login":"owner"}}}`
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader([]byte(payload)))
+	req.Header.Set("X-GitHub-Event", "push")
+	rr := httptest.NewRecorder()
+	handler.HandleGitHubWebhook(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Zero(t, resolver.calls, "a new branch has no base to compare against")
+	require.Len(t, scheduler.requests, 1)
+	assert.Equal(t, web.ID, scheduler.requests[0].ServiceID)
+}
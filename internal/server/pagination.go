@@ -0,0 +1,150 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+)
+
+// paginationFromProto converts a page request. The page token is the one
+// returned with the previous page and takes precedence over the offset; an
+// invalid token starts from the first page.
+func paginationFromProto(p *conductorv1.Pagination) database.Pagination {
+	if p == nil {
+		return database.DefaultPagination()
+	}
+	pagination := database.Pagination{
+		Limit: int(p.PageSize),
+	}
+	switch {
+	case p.PageToken != "":
+		if cursor, ok := decodeCursorToken(p.PageToken); ok {
+			pagination.After = cursor
+		} else {
+			pagination.Offset = decodePageToken(p.PageToken)
+		}
+	case p.Offset > 0:
+		pagination.Offset = int(p.Offset)
+	}
+	if pagination.Limit <= 0 {
+		pagination.Limit = 50
+	}
+	if pagination.Limit > 100 {
+		pagination.Limit = 100
+	}
+	return pagination
+}
+
+func paginationResponseToProto(p database.Pagination, total int) *conductorv1.PaginationResponse {
+	hasMore := p.Offset+p.Limit < total
+	resp := &conductorv1.PaginationResponse{
+		TotalCount: int64(total),
+		HasMore:    hasMore,
+	}
+	if hasMore {
+		resp.NextPageToken = encodePageToken(p.Offset + p.Limit)
+	}
+	return resp
+}
+
+// peekPagination returns the pagination fetching one item more than the
+// page holds, which tells keysetPage whether another page follows.
+func peekPagination(p database.Pagination) database.Pagination {
+	p.Limit++
+	return p
+}
+
+// keysetPage trims the items of a list that pages by cursor, fetched with
+// peekPagination, to the page and returns the pagination of the response.
+// The next page continues after the cursor of the last item, or by offset
+// if the page was requested by offset.
+func keysetPage[T any](p database.Pagination, items []T, total int, cursor func(T) database.Cursor) ([]T, *conductorv1.PaginationResponse) {
+	hasMore := len(items) > p.Limit
+	if hasMore {
+		items = items[:p.Limit]
+	}
+	resp := &conductorv1.PaginationResponse{
+		TotalCount: int64(total),
+		HasMore:    hasMore,
+	}
+	switch {
+	case !hasMore:
+	case p.After == nil && p.Offset > 0:
+		resp.NextPageToken = encodePageToken(p.Offset + p.Limit)
+	default:
+		resp.NextPageToken = encodeCursorToken(cursor(items[len(items)-1]))
+	}
+	return items, resp
+}
+
+// encodePageToken returns the opaque page token of an offset.
+func encodePageToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+// decodePageToken returns the offset of a page token, or 0 if the token is
+// empty or invalid.
+func decodePageToken(token string) int {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), "offset:"))
+	if err != nil || offset < 0 || !strings.HasPrefix(string(raw), "offset:") {
+		return 0
+	}
+	return offset
+}
+
+// encodeCursorToken returns the opaque page token of the page after a
+// cursor.
+func encodeCursorToken(cursor database.Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(append([]byte("cursor:"), data...))
+}
+
+// decodeCursorToken returns the cursor of a page token, if it is a cursor
+// token.
+func decodeCursorToken(token string) (*database.Cursor, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, false
+	}
+	data, ok := strings.CutPrefix(string(raw), "cursor:")
+	if !ok {
+		return nil, false
+	}
+	var cursor database.Cursor
+	if err := json.Unmarshal([]byte(data), &cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, false
+	}
+	return &cursor, true
+}
+
+// runCursor returns the cursor of a run, for any of the orders runs are
+// listed in.
+func runCursor(run *database.TestRun) database.Cursor {
+	cursor := database.Cursor{ID: run.ID, CreatedAt: run.CreatedAt, Priority: run.Priority}
+	if run.StartedAt != nil {
+		cursor.StartedAt = *run.StartedAt
+	}
+	return cursor
+}
+
+func serviceCursor(svc *database.Service) database.Cursor {
+	return database.Cursor{ID: svc.ID, Name: svc.Name}
+}
+
+func agentCursor(agent *database.Agent) database.Cursor {
+	return database.Cursor{ID: agent.ID, Name: agent.Name}
+}
+
+func resultCursor(result *database.TestResult) database.Cursor {
+	return database.Cursor{ID: result.ID, Name: result.TestName}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+)
+
+// keysetRunRepo lists runs newest first like the database does, by offset
+// or after a cursor.
+type keysetRunRepo struct {
+	RunRepository
+	runs []*database.TestRun
+}
+
+func (r *keysetRunRepo) List(ctx context.Context, filter RunFilter, pagination database.Pagination) ([]*database.TestRun, int, error) {
+	start := pagination.Offset
+	if after := pagination.After; after != nil {
+		start = len(r.runs)
+		for i, run := range r.runs {
+			if run.CreatedAt.Before(after.CreatedAt) || (run.CreatedAt.Equal(after.CreatedAt) && run.ID.String() < after.ID.String()) {
+				start = i
+				break
+			}
+		}
+	}
+	end := min(start+pagination.Limit, len(r.runs))
+	return r.runs[min(start, end):end], len(r.runs), nil
+}
+
+func TestCursorPageTokens(t *testing.T) {
+	cursor := database.Cursor{ID: uuid.New(), Name: "checkout", CreatedAt: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)}
+	page := paginationFromProto(&conductorv1.Pagination{PageSize: 20, PageToken: encodeCursorToken(cursor)})
+	require.NotNil(t, page.After)
+	assert.Equal(t, cursor, *page.After)
+	assert.Zero(t, page.Offset)
+
+	// Offset tokens and offsets still page by offset
+	page = paginationFromProto(&conductorv1.Pagination{PageSize: 20, PageToken: encodePageToken(40), Offset: 10})
+	assert.Nil(t, page.After)
+	assert.Equal(t, 40, page.Offset, "the page token takes precedence over the offset")
+	assert.Equal(t, 10, paginationFromProto(&conductorv1.Pagination{Offset: 10}).Offset)
+
+	_, ok := decodeCursorToken(encodePageToken(40))
+	assert.False(t, ok)
+	_, ok = decodeCursorToken(encodeCursorToken(database.Cursor{Name: "no ID"}))
+	assert.False(t, ok)
+}
+
+func TestListRuns_KeysetPagination(t *testing.T) {
+	start := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo := &keysetRunRepo{}
+	for i := range 5 {
+		repo.runs = append(repo.runs, &database.TestRun{ID: uuid.New(), CreatedAt: start.Add(-time.Duration(i) * time.Minute)})
+	}
+	server := NewRunServiceServer(RunServiceDeps{
+		RunRepo:     repo,
+		ServiceRepo: &placementServiceRepo{service: &database.Service{Name: "checkout"}},
+	}, zerolog.Nop())
+	list := func(p *conductorv1.Pagination) *conductorv1.ListRunsResponse {
+		resp, err := server.ListRuns(context.Background(), &conductorv1.ListRunsRequest{Pagination: p})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("pages by cursor", func(t *testing.T) {
+		var ids []string
+		token := ""
+		for range 3 {
+			resp := list(&conductorv1.Pagination{PageSize: 2, PageToken: token})
+			for _, run := range resp.Runs {
+				ids = append(ids, run.Id)
+			}
+			assert.EqualValues(t, 5, resp.Pagination.TotalCount)
+			token = resp.Pagination.NextPageToken
+			if !resp.Pagination.HasMore {
+				break
+			}
+			_, ok := decodeCursorToken(token)
+			assert.True(t, ok)
+		}
+		assert.Empty(t, token)
+		require.Len(t, ids, 5)
+		for i, run := range repo.runs {
+			assert.Equal(t, run.ID.String(), ids[i])
+		}
+	})
+
+	t.Run("continues offset pages by offset", func(t *testing.T) {
+		resp := list(&conductorv1.Pagination{PageSize: 2, Offset: 2})
+		require.Len(t, resp.Runs, 2)
+		assert.Equal(t, repo.runs[2].ID.String(), resp.Runs[0].Id)
+		assert.Equal(t, 4, decodePageToken(resp.Pagination.NextPageToken))
+
+		resp = list(&conductorv1.Pagination{PageSize: 2, PageToken: resp.Pagination.NextPageToken})
+		require.Len(t, resp.Runs, 1)
+		assert.False(t, resp.Pagination.HasMore)
+	})
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/placement"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// maxPlacementAgents bounds the registered agents label selectors are
+// validated against.
+const maxPlacementAgents = 1000
+
+// checkPlacement verifies that the combined label selectors can ever be
+// satisfied: they must not conflict, and at least one registered agent must
+// reach the service zones and carry every selected label. Agents are not
+// checked without a selector or an agent repository.
+func checkPlacement(ctx context.Context, agents AgentRepository, subject string, zones []string, selectors ...map[string]string) error {
+	selector, err := placement.MergeSelectors(selectors...)
+	if err != nil {
+		return errcode.New(errcode.NoMatchingAgent, "%s: %v", subject, err)
+	}
+	if len(selector) == 0 || agents == nil {
+		return nil
+	}
+
+	registered, _, err := agents.List(ctx, AgentFilter{}, database.Pagination{Limit: maxPlacementAgents})
+	if err != nil {
+		return errcode.New(errcode.Internal, "failed to list agents: %v", err)
+	}
+	for _, agent := range registered {
+		if placement.ZonesMatch(zones, agent.NetworkZones) && placement.LabelsMatch(selector, agent.Labels) {
+			return nil
+		}
+	}
+
+	if len(zones) > 0 {
+		return errcode.New(errcode.NoMatchingAgent, "%s: no registered agent in zones %s matches label selector %s",
+			subject, strings.Join(zones, ","), placement.FormatSelector(selector))
+	}
+	return errcode.New(errcode.NoMatchingAgent, "%s: no registered agent matches label selector %s",
+		subject, placement.FormatSelector(selector))
+}
+
+// checkTestPlacement verifies that a test can be placed under the combined
+// selectors on every architecture it requests, with the labels of every
+// combination of its matrix.
+func checkTestPlacement(ctx context.Context, agents AgentRepository, test string, zones, archs []string, cells []database.MatrixCell, selectors ...map[string]string) error {
+	if len(archs) == 0 {
+		archs = []string{""}
+	}
+	if len(cells) == 0 {
+		cells = []database.MatrixCell{{}}
+	}
+
+	for _, arch := range archs {
+		for _, cell := range cells {
+			subject := "test " + test
+			variantSelectors := selectors
+			if len(cell.Labels) > 0 {
+				subject += " with " + placement.FormatSelector(cell.Labels)
+				variantSelectors = append([]map[string]string{cell.Labels}, variantSelectors...)
+			}
+			if arch != "" {
+				subject += " on " + arch
+				variantSelectors = append([]map[string]string{{placement.LabelArch: arch}}, variantSelectors...)
+			}
+			if err := checkPlacement(ctx, agents, subject, zones, variantSelectors...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// RunQuotaRepository reads the quotas of services and what they used of
+// them.
+type RunQuotaRepository interface {
+	GetByService(ctx context.Context, serviceID uuid.UUID) (*database.ServiceQuota, error)
+	Usage(ctx context.Context, serviceID uuid.UUID, dayStart, monthStart time.Time) (*database.ServiceQuotaUsage, error)
+}
+
+// quotaPeriods returns the starts of the UTC day and month now falls in.
+func quotaPeriods(now time.Time) (dayStart, monthStart time.Time) {
+	now = now.UTC()
+	dayStart = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return dayStart, monthStart
+}
+
+// quotaUsage returns the quota of a service, nil if it has none, and what the
+// service used of it in the periods now falls in.
+func quotaUsage(ctx context.Context, repo RunQuotaRepository, serviceID uuid.UUID, now time.Time) (*database.ServiceQuota, *database.ServiceQuotaUsage, error) {
+	quota, err := repo.GetByService(ctx, serviceID)
+	if err != nil && !database.IsNotFound(err) {
+		return nil, nil, err
+	}
+	dayStart, monthStart := quotaPeriods(now)
+	usage, err := repo.Usage(ctx, serviceID, dayStart, monthStart)
+	if err != nil {
+		return nil, nil, err
+	}
+	return quota, usage, nil
+}
+
+// checkRunQuota rejects a new run of a service that used up its runs for the
+// day or its execution minutes for the month. The rejection names the
+// exhausted limit and when it resets.
+func (s *RunServiceServer) checkRunQuota(ctx context.Context, service *database.Service) error {
+	if s.deps.QuotaRepo == nil {
+		return nil
+	}
+
+	now := time.Now()
+	quota, usage, err := quotaUsage(ctx, s.deps.QuotaRepo, service.ID, now)
+	if err != nil {
+		return errcode.New(errcode.Internal, "failed to check service quota: %v", err)
+	}
+	if quota == nil {
+		return nil
+	}
+
+	dayStart, monthStart := quotaPeriods(now)
+	if quota.MaxRunsPerDay > 0 && usage.RunsToday >= quota.MaxRunsPerDay {
+		resetsAt := dayStart.AddDate(0, 0, 1)
+		return errcode.NewWithMetadata(errcode.QuotaExceeded,
+			map[string]string{
+				"quota":     "runs_per_day",
+				"limit":     strconv.Itoa(quota.MaxRunsPerDay),
+				"resets_at": resetsAt.Format(time.RFC3339),
+			},
+			"%s reached its quota of %d runs per day; it resets at %s",
+			service.Name, quota.MaxRunsPerDay, resetsAt.Format(time.RFC3339))
+	}
+	if quota.MaxMinutesPerMonth > 0 && usage.MinutesThisMonth >= quota.MaxMinutesPerMonth {
+		resetsAt := monthStart.AddDate(0, 1, 0)
+		return errcode.NewWithMetadata(errcode.QuotaExceeded,
+			map[string]string{
+				"quota":     "minutes_per_month",
+				"limit":     strconv.Itoa(quota.MaxMinutesPerMonth),
+				"resets_at": resetsAt.Format(time.RFC3339),
+			},
+			"%s used its quota of %d execution minutes per month; it resets at %s",
+			service.Name, quota.MaxMinutesPerMonth, resetsAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// SetServiceQuota replaces the quota of a service. Only admins may set
+// quotas, as they bound what services may use of a shared deployment.
+func (s *ServiceRegistryServer) SetServiceQuota(ctx context.Context, req *conductorv1.SetServiceQuotaRequest) (*conductorv1.SetServiceQuotaResponse, error) {
+	if claims := GetUserFromContext(ctx); claims == nil || !claims.IsAdmin() {
+		return nil, errcode.New(errcode.PermissionDenied, "setting service quotas requires the admin role")
+	}
+	if err := s.requireQuotas(); err != nil {
+		return nil, err
+	}
+
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	var fields []errcode.FieldViolation
+	if req.MaxRunsPerDay < 0 {
+		fields = append(fields, errcode.FieldViolation{Field: "max_runs_per_day", Description: "must not be negative"})
+	}
+	if req.MaxMinutesPerMonth < 0 {
+		fields = append(fields, errcode.FieldViolation{Field: "max_minutes_per_month", Description: "must not be negative"})
+	}
+	if len(fields) > 0 {
+		return nil, errcode.NewInvalidFields(fields...)
+	}
+
+	if _, err := s.deps.ServiceRepo.GetByID(ctx, serviceID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.ServiceNotFound, "service not found: %s", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get service: %v", err)
+	}
+
+	quota := &database.ServiceQuota{
+		ServiceID:          serviceID,
+		MaxRunsPerDay:      int(req.MaxRunsPerDay),
+		MaxMinutesPerMonth: int(req.MaxMinutesPerMonth),
+	}
+	if err := s.deps.QuotaRepo.Upsert(ctx, quota); err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to save service quota: %v", err)
+	}
+
+	s.logger.Info().
+		Str("service_id", serviceID.String()).
+		Int("max_runs_per_day", quota.MaxRunsPerDay).
+		Int("max_minutes_per_month", quota.MaxMinutesPerMonth).
+		Msg("service quota set")
+
+	return &conductorv1.SetServiceQuotaResponse{
+		Quota: serviceQuotaToProto(quota),
+	}, nil
+}
+
+// GetServiceQuota returns the quota of a service with what it used and has
+// left. Services without a quota report their usage alone.
+func (s *ServiceRegistryServer) GetServiceQuota(ctx context.Context, req *conductorv1.GetServiceQuotaRequest) (*conductorv1.GetServiceQuotaResponse, error) {
+	if err := s.requireQuotas(); err != nil {
+		return nil, err
+	}
+
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	if _, err := s.deps.ServiceRepo.GetByID(ctx, serviceID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.ServiceNotFound, "service not found: %s", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get service: %v", err)
+	}
+
+	now := time.Now()
+	quota, usage, err := quotaUsage(ctx, s.deps.QuotaRepo, serviceID, now)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to get service quota: %v", err)
+	}
+
+	resp := &conductorv1.GetServiceQuotaResponse{
+		Usage: quotaUsageToProto(quota, usage, now),
+	}
+	if quota != nil {
+		resp.Quota = serviceQuotaToProto(quota)
+	}
+	return resp, nil
+}
+
+// DeleteServiceQuota removes the quota of a service. Only admins may delete
+// quotas.
+func (s *ServiceRegistryServer) DeleteServiceQuota(ctx context.Context, req *conductorv1.DeleteServiceQuotaRequest) (*conductorv1.DeleteServiceQuotaResponse, error) {
+	if claims := GetUserFromContext(ctx); claims == nil || !claims.IsAdmin() {
+		return nil, errcode.New(errcode.PermissionDenied, "deleting service quotas requires the admin role")
+	}
+	if err := s.requireQuotas(); err != nil {
+		return nil, err
+	}
+
+	serviceID, err := uuid.Parse(req.ServiceId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+	}
+
+	if err := s.deps.QuotaRepo.Delete(ctx, serviceID); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.NotFound, "quota not found for service: %s", req.ServiceId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to delete service quota: %v", err)
+	}
+
+	s.logger.Info().
+		Str("service_id", serviceID.String()).
+		Msg("service quota deleted")
+
+	return &conductorv1.DeleteServiceQuotaResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *ServiceRegistryServer) requireQuotas() error {
+	if s.deps.QuotaRepo == nil {
+		return errcode.New(errcode.NotConfigured, "service quotas are not configured")
+	}
+	return nil
+}
+
+func serviceQuotaToProto(quota *database.ServiceQuota) *conductorv1.ServiceQuota {
+	return &conductorv1.ServiceQuota{
+		ServiceId:          quota.ServiceID.String(),
+		MaxRunsPerDay:      int32(quota.MaxRunsPerDay),
+		MaxMinutesPerMonth: int32(quota.MaxMinutesPerMonth),
+		CreatedAt:          timestamppb.New(quota.CreatedAt),
+		UpdatedAt:          timestamppb.New(quota.UpdatedAt),
+	}
+}
+
+// quotaUsageToProto converts what a service used of its quota, which may be
+// nil, in the periods now falls in.
+func quotaUsageToProto(quota *database.ServiceQuota, usage *database.ServiceQuotaUsage, now time.Time) *conductorv1.ServiceQuotaUsage {
+	dayStart, monthStart := quotaPeriods(now)
+	pb := &conductorv1.ServiceQuotaUsage{
+		RunsToday:        int32(usage.RunsToday),
+		MinutesThisMonth: int32(usage.MinutesThisMonth),
+		DayResetsAt:      timestamppb.New(dayStart.AddDate(0, 0, 1)),
+		MonthResetsAt:    timestamppb.New(monthStart.AddDate(0, 1, 0)),
+	}
+	if quota == nil {
+		return pb
+	}
+	if quota.MaxRunsPerDay > 0 {
+		remaining := int32(max(quota.MaxRunsPerDay-usage.RunsToday, 0))
+		pb.RunsRemaining = &remaining
+	}
+	if quota.MaxMinutesPerMonth > 0 {
+		remaining := int32(max(quota.MaxMinutesPerMonth-usage.MinutesThisMonth, 0))
+		pb.MinutesRemaining = &remaining
+	}
+	return pb
+}
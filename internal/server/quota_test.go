@@ -0,0 +1,214 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// quotaRepo keeps one service quota and reports a fixed usage.
+type quotaRepo struct {
+	database.ServiceQuotaRepository
+	quota *database.ServiceQuota
+	usage database.ServiceQuotaUsage
+}
+
+func (r *quotaRepo) Upsert(ctx context.Context, quota *database.ServiceQuota) error {
+	r.quota = quota
+	return nil
+}
+
+func (r *quotaRepo) GetByService(ctx context.Context, serviceID uuid.UUID) (*database.ServiceQuota, error) {
+	if r.quota == nil {
+		return nil, database.ErrNotFound
+	}
+	return r.quota, nil
+}
+
+func (r *quotaRepo) Delete(ctx context.Context, serviceID uuid.UUID) error {
+	if r.quota == nil {
+		return database.ErrNotFound
+	}
+	r.quota = nil
+	return nil
+}
+
+func (r *quotaRepo) Usage(ctx context.Context, serviceID uuid.UUID, dayStart, monthStart time.Time) (*database.ServiceQuotaUsage, error) {
+	usage := r.usage
+	return &usage, nil
+}
+
+func TestQuotaPeriods(t *testing.T) {
+	now := time.Date(2026, time.January, 31, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*60*60))
+
+	dayStart, monthStart := quotaPeriods(now)
+
+	assert.Equal(t, time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC), dayStart, "periods are UTC")
+	assert.Equal(t, time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC), monthStart)
+}
+
+func TestCreateRun_Quota(t *testing.T) {
+	ctx := context.Background()
+	newServer := func(policy database.BranchRunPolicy, quotas *quotaRepo) (*RunServiceServer, *approvalRunRepo, *database.Service) {
+		service := &database.Service{ID: uuid.New(), Name: "api", BranchRunPolicy: policy}
+		runs := &approvalRunRepo{}
+		return NewRunServiceServer(RunServiceDeps{
+			RunRepo:     runs,
+			ServiceRepo: &placementServiceRepo{service: service},
+			TestRepo:    &placementTestRepo{},
+			Scheduler:   &cancellingScheduler{},
+			BranchRuns:  runs,
+			QuotaRepo:   quotas,
+		}, zerolog.Nop()), runs, service
+	}
+	push := func(service *database.Service, sha string) *conductorv1.CreateRunRequest {
+		return &conductorv1.CreateRunRequest{
+			ServiceId: service.ID.String(),
+			GitRef:    &conductorv1.GitRef{Branch: "main", CommitSha: sha},
+		}
+	}
+
+	t.Run("rejects runs over the daily limit", func(t *testing.T) {
+		quotas := &quotaRepo{
+			quota: &database.ServiceQuota{MaxRunsPerDay: 10},
+			usage: database.ServiceQuotaUsage{RunsToday: 10},
+		}
+		server, runs, service := newServer(database.BranchRunPolicyAllow, quotas)
+
+		_, err := server.CreateRun(ctx, push(service, "aaa"))
+		require.True(t, errcode.Is(err, errcode.QuotaExceeded), "got %v", err)
+		assert.Contains(t, err.Error(), "api reached its quota of 10 runs per day")
+		metadata := errcode.Metadata(err)
+		assert.Equal(t, "runs_per_day", metadata["quota"])
+		assert.Equal(t, "10", metadata["limit"])
+		dayStart, _ := quotaPeriods(time.Now())
+		assert.Equal(t, dayStart.AddDate(0, 0, 1).Format(time.RFC3339), metadata["resets_at"])
+		assert.Empty(t, runs.runs)
+	})
+
+	t.Run("rejects runs over the monthly minutes", func(t *testing.T) {
+		quotas := &quotaRepo{
+			quota: &database.ServiceQuota{MaxRunsPerDay: 10, MaxMinutesPerMonth: 600},
+			usage: database.ServiceQuotaUsage{RunsToday: 3, MinutesThisMonth: 601},
+		}
+		server, _, service := newServer(database.BranchRunPolicyAllow, quotas)
+
+		_, err := server.CreateRun(ctx, push(service, "aaa"))
+		require.True(t, errcode.Is(err, errcode.QuotaExceeded), "got %v", err)
+		assert.Equal(t, "minutes_per_month", errcode.Metadata(err)["quota"])
+	})
+
+	t.Run("allows runs within or without a quota", func(t *testing.T) {
+		quotas := &quotaRepo{
+			quota: &database.ServiceQuota{MaxRunsPerDay: 10},
+			usage: database.ServiceQuotaUsage{RunsToday: 9, MinutesThisMonth: 10000},
+		}
+		server, runs, service := newServer(database.BranchRunPolicyAllow, quotas)
+
+		_, err := server.CreateRun(ctx, push(service, "aaa"))
+		require.NoError(t, err)
+
+		quotas.quota = nil
+		quotas.usage.RunsToday = 100
+		_, err = server.CreateRun(ctx, push(service, "bbb"))
+		require.NoError(t, err)
+		assert.Len(t, runs.runs, 2)
+	})
+
+	t.Run("coalesced runs pass", func(t *testing.T) {
+		quotas := &quotaRepo{quota: &database.ServiceQuota{MaxRunsPerDay: 1}}
+		server, runs, service := newServer(database.BranchRunPolicyCoalesce, quotas)
+
+		first, err := server.CreateRun(ctx, push(service, "aaa"))
+		require.NoError(t, err)
+		quotas.usage.RunsToday = 1
+		second, err := server.CreateRun(ctx, push(service, "bbb"))
+		require.NoError(t, err)
+
+		assert.Equal(t, first.Run.Id, second.Run.Id)
+		assert.Len(t, runs.runs, 1)
+	})
+
+	t.Run("rejects retries over the limit", func(t *testing.T) {
+		quotas := &quotaRepo{quota: &database.ServiceQuota{MaxRunsPerDay: 1}}
+		server, runs, service := newServer(database.BranchRunPolicyAllow, quotas)
+
+		created, err := server.CreateRun(ctx, push(service, "aaa"))
+		require.NoError(t, err)
+		quotas.usage.RunsToday = 1
+		_, err = server.RetryRun(ctx, &conductorv1.RetryRunRequest{RunId: created.Run.Id})
+
+		assert.True(t, errcode.Is(err, errcode.QuotaExceeded), "got %v", err)
+		assert.Len(t, runs.runs, 1)
+	})
+}
+
+func TestServiceQuota(t *testing.T) {
+	service := &database.Service{ID: uuid.New(), Name: "api"}
+	quotas := &quotaRepo{usage: database.ServiceQuotaUsage{RunsToday: 4, MinutesThisMonth: 700}}
+	server := NewServiceRegistryServer(ServiceRegistryDeps{
+		ServiceRepo: &registryServiceRepo{service: service},
+		QuotaRepo:   quotas,
+	}, zerolog.Nop())
+	admin := withUser(context.Background(), &UserClaims{UserID: "u-1", Roles: []string{"admin"}})
+
+	t.Run("reports usage without a quota", func(t *testing.T) {
+		resp, err := server.GetServiceQuota(context.Background(), &conductorv1.GetServiceQuotaRequest{ServiceId: service.ID.String()})
+		require.NoError(t, err)
+
+		assert.Nil(t, resp.Quota)
+		assert.Equal(t, int32(4), resp.Usage.RunsToday)
+		assert.Nil(t, resp.Usage.RunsRemaining)
+		assert.Nil(t, resp.Usage.MinutesRemaining)
+		assert.True(t, resp.Usage.DayResetsAt.AsTime().After(time.Now()))
+	})
+
+	t.Run("setting requires the admin role", func(t *testing.T) {
+		viewer := withUser(context.Background(), &UserClaims{UserID: "u-2", Roles: []string{"viewer"}})
+		_, err := server.SetServiceQuota(viewer, &conductorv1.SetServiceQuotaRequest{ServiceId: service.ID.String(), MaxRunsPerDay: 1})
+		assert.True(t, errcode.Is(err, errcode.PermissionDenied))
+		assert.Nil(t, quotas.quota)
+	})
+
+	t.Run("rejects negative limits", func(t *testing.T) {
+		_, err := server.SetServiceQuota(admin, &conductorv1.SetServiceQuotaRequest{ServiceId: service.ID.String(), MaxRunsPerDay: -1, MaxMinutesPerMonth: -1})
+		require.True(t, errcode.Is(err, errcode.InvalidArgument))
+		assert.Len(t, errcode.FieldViolations(err), 2)
+	})
+
+	t.Run("reports remaining quota", func(t *testing.T) {
+		_, err := server.SetServiceQuota(admin, &conductorv1.SetServiceQuotaRequest{
+			ServiceId:          service.ID.String(),
+			MaxRunsPerDay:      10,
+			MaxMinutesPerMonth: 600,
+		})
+		require.NoError(t, err)
+
+		resp, err := server.GetServiceQuota(context.Background(), &conductorv1.GetServiceQuotaRequest{ServiceId: service.ID.String()})
+		require.NoError(t, err)
+
+		assert.Equal(t, int32(10), resp.Quota.MaxRunsPerDay)
+		require.NotNil(t, resp.Usage.RunsRemaining)
+		assert.Equal(t, int32(6), *resp.Usage.RunsRemaining)
+		require.NotNil(t, resp.Usage.MinutesRemaining)
+		assert.Equal(t, int32(0), *resp.Usage.MinutesRemaining, "remaining minutes do not go negative")
+	})
+
+	t.Run("deletes the quota", func(t *testing.T) {
+		_, err := server.DeleteServiceQuota(admin, &conductorv1.DeleteServiceQuotaRequest{ServiceId: service.ID.String()})
+		require.NoError(t, err)
+		assert.Nil(t, quotas.quota)
+
+		_, err = server.DeleteServiceQuota(admin, &conductorv1.DeleteServiceQuotaRequest{ServiceId: service.ID.String()})
+		assert.True(t, errcode.Is(err, errcode.NotFound))
+	})
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/conductor/conductor/pkg/errcode"
+	"github.com/conductor/conductor/pkg/metrics"
+)
+
+// maxRateLimitedClients bounds the number of clients tracked by a rate limiter.
+const maxRateLimitedClients = 100000
+
+// APIs requests are rate limited in, used as the metric label.
+const (
+	rateLimitHTTP    = "http"
+	rateLimitGRPC    = "grpc"
+	rateLimitWebhook = "webhook"
+)
+
+// RateLimiter limits the requests of each client with a token bucket.
+type RateLimiter struct {
+	mu      sync.Mutex
+	limit   rate.Limit
+	burst   int
+	clients map[string]*rateLimitedClient
+	now     func() time.Time
+}
+
+type rateLimitedClient struct {
+	limiter *rate.Limiter
+	// seen is the time of the client's last request.
+	seen time.Time
+}
+
+// NewRateLimiter creates a rate limiter whose buckets hold burst requests
+// and refill at perSecond requests per second.
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		limit:   rate.Limit(perSecond),
+		burst:   burst,
+		clients: make(map[string]*rateLimitedClient),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a request from the bucket of the client identified by key if
+// it holds one. Otherwise it returns how long until it does.
+func (l *RateLimiter) Allow(key string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	c, ok := l.clients[key]
+	if !ok {
+		if len(l.clients) >= maxRateLimitedClients {
+			l.prune(now)
+		}
+		c = &rateLimitedClient{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[key] = c
+	}
+	c.seen = now
+
+	r := c.limiter.ReserveN(now, 1)
+	if wait := r.DelayFrom(now); wait > 0 {
+		r.CancelAt(now)
+		return wait, false
+	}
+	return 0, true
+}
+
+// prune forgets clients whose buckets refilled since their last request, as
+// they would start over with a full bucket anyway. Callers must hold the lock.
+func (l *RateLimiter) prune(now time.Time) {
+	refill := time.Duration(float64(l.burst) / float64(l.limit) * float64(time.Second))
+	for key, c := range l.clients {
+		if now.Sub(c.seen) >= refill {
+			delete(l.clients, key)
+		}
+	}
+}
+
+// rateLimitError is the error of a rate limited request.
+func rateLimitError(wait time.Duration) error {
+	retryAfter := retryAfterSeconds(wait)
+	return errcode.NewWithMetadata(errcode.QuotaExceeded,
+		map[string]string{"retry_after_seconds": strconv.Itoa(retryAfter)},
+		"rate limit exceeded; retry in %ds", retryAfter)
+}
+
+func retryAfterSeconds(wait time.Duration) int {
+	return int(math.Ceil(wait.Seconds()))
+}
+
+// tokenKey identifies a client by its bearer token. Tokens are hashed so
+// that the limiter does not hold credentials.
+func tokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:16])
+}
+
+// rateLimitExempt reports whether a gRPC method is exempt from rate limits,
+// so that health probes are answered under load.
+func rateLimitExempt(method string) bool {
+	return strings.HasPrefix(method, "/conductor.v1.HealthService/") ||
+		strings.HasPrefix(method, "/grpc.health.v1.Health/")
+}
+
+// grpcRateLimitKey identifies the client of a gRPC call: its bearer token,
+// or else its IP address. Calls proxied by the HTTP gateway come from the
+// gateway, so only those with a token are told apart.
+func grpcRateLimitKey(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 && values[0] != "" {
+			return tokenKey(values[0])
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return "ip:" + hostOf(p.Addr.String())
+	}
+	return "ip:unknown"
+}
+
+// newRateLimitUnaryInterceptor creates a unary interceptor that rejects the
+// calls of clients beyond their rate limit. Metrics are optional.
+func newRateLimitUnaryInterceptor(limiter *RateLimiter, m *metrics.ControlPlaneMetrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !rateLimitExempt(info.FullMethod) {
+			if wait, ok := limiter.Allow(grpcRateLimitKey(ctx)); !ok {
+				recordRateLimited(m, rateLimitGRPC)
+				return nil, rateLimitError(wait)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// newRateLimitStreamInterceptor is like newRateLimitUnaryInterceptor for
+// streams. Opening a stream counts as one request; its messages do not.
+func newRateLimitStreamInterceptor(limiter *RateLimiter, m *metrics.ControlPlaneMetrics) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !rateLimitExempt(info.FullMethod) {
+			if wait, ok := limiter.Allow(grpcRateLimitKey(ss.Context())); !ok {
+				recordRateLimited(m, rateLimitGRPC)
+				return rateLimitError(wait)
+			}
+		}
+		return handler(srv, ss)
+	}
+}
+
+func recordRateLimited(m *metrics.ControlPlaneMetrics, api string) {
+	if m != nil {
+		m.RecordRateLimited(api)
+	}
+}
+
+// SetRateLimiters limits the requests of each client to the HTTP API, and
+// separately those of each webhook sender. Health checks are not limited.
+// This must be called before Start().
+func (s *HTTPServer) SetRateLimiters(api, webhooks *RateLimiter) {
+	s.rateLimiter = api
+	s.webhookRateLimiter = webhooks
+}
+
+// rateLimitMiddleware rejects the requests of clients beyond their rate
+// limit with 429 Too Many Requests. Clients of the API are identified by
+// their bearer token, or else their IP address; webhook senders by their IP
+// address.
+func (s *HTTPServer) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter, api, key := s.rateLimiter, rateLimitHTTP, httpRateLimitKey(r)
+		switch {
+		case isHealthPath(r.URL.Path):
+			limiter = nil
+		case isWebhookPath(r.URL.Path):
+			limiter, api, key = s.webhookRateLimiter, rateLimitWebhook, "ip:"+hostOf(r.RemoteAddr)
+		}
+		if limiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		wait, ok := limiter.Allow(key)
+		if !ok {
+			recordRateLimited(s.config.Metrics, api)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(wait)))
+			_, outbound := runtime.MarshalerForRequest(s.mux, r)
+			runtime.HTTPError(r.Context(), s.mux, outbound, w, r, rateLimitError(wait))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// httpRateLimitKey identifies the client of an HTTP request: its bearer
+// token, or else its IP address.
+func httpRateLimitKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		return tokenKey(auth)
+	}
+	return "ip:" + hostOf(r.RemoteAddr)
+}
+
+// isHealthPath reports whether path is a health check of the HTTP API or a
+// probe path.
+func isHealthPath(path string) bool {
+	return path == "/api/v1/health" || strings.HasPrefix(path, "/api/v1/health/") ||
+		path == livenessProbePath || path == readinessProbePath
+}
+
+// isWebhookPath reports whether path is under a webhook prefix.
+func isWebhookPath(path string) bool {
+	return strings.HasPrefix(path, "/api/v1/webhooks/") || strings.HasPrefix(path, "/webhooks/")
+}
+
+// hostOf returns the host of a host:port address, or the address itself if
+// it has no port.
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
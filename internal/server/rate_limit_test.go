@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewRateLimiter(2, 3)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		_, ok := limiter.Allow("a")
+		require.True(t, ok, "request %d is within the burst", i)
+	}
+	wait, ok := limiter.Allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	_, ok = limiter.Allow("b")
+	assert.True(t, ok, "clients have buckets of their own")
+
+	// Rejected requests do not take from the bucket
+	now = now.Add(500 * time.Millisecond)
+	_, ok = limiter.Allow("a")
+	assert.True(t, ok)
+	_, ok = limiter.Allow("a")
+	assert.False(t, ok)
+
+	t.Run("prune forgets refilled clients", func(t *testing.T) {
+		now = now.Add(time.Second)
+		limiter.prune(now)
+		assert.Contains(t, limiter.clients, "a")
+		assert.NotContains(t, limiter.clients, "b")
+	})
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	s, err := NewHTTPServer(DefaultHTTPConfig(), zerolog.Nop())
+	require.NoError(t, err)
+	s.SetRateLimiters(NewRateLimiter(1, 1), NewRateLimiter(1, 2))
+	handler := s.rateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(method, target, remoteAddr, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.RemoteAddr = remoteAddr
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/v1/runs", "10.0.0.1:1234", "Bearer a").Code)
+	rec := serve(http.MethodGet, "/api/v1/runs", "10.0.0.2:1234", "Bearer a")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "clients with a token are limited by token")
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Equal(t, string(errcode.QuotaExceeded), rec.Header().Get(errcode.HTTPHeader))
+	assert.Contains(t, rec.Body.String(), "rate limit exceeded")
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/v1/runs", "10.0.0.2:1234", "Bearer b").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/v1/runs", "10.0.0.3:1234", "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(http.MethodGet, "/api/v1/runs", "10.0.0.3:5678", "").Code,
+		"clients without a token are limited by IP address")
+
+	t.Run("health checks are not limited", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/v1/health/ready", "10.0.0.3:1234", "").Code)
+		}
+	})
+
+	t.Run("webhooks have limits of their own", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			assert.Equal(t, http.StatusOK, serve(http.MethodPost, "/webhooks/github", "10.0.0.3:1234", "").Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, serve(http.MethodPost, "/api/v1/webhooks/github", "10.0.0.3:1234", "").Code)
+	})
+}
+
+func TestRateLimitInterceptors(t *testing.T) {
+	limiter := NewRateLimiter(1, 1)
+	unary := newRateLimitUnaryInterceptor(limiter, nil)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+	call := func(ctx context.Context, method string) error {
+		_, err := unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	require.NoError(t, call(ctx, "/conductor.v1.RunService/ListRuns"))
+	err := call(ctx, "/conductor.v1.RunService/ListRuns")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, "1", errcode.Metadata(err)["retry_after_seconds"])
+
+	assert.NoError(t, call(ctx, "/conductor.v1.HealthService/Check"), "health checks are not limited")
+
+	withToken := metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer a"))
+	assert.NoError(t, call(withToken, "/conductor.v1.RunService/ListRuns"), "clients with a token are limited by token")
+
+	t.Run("stream", func(t *testing.T) {
+		stream := newRateLimitStreamInterceptor(limiter, nil)
+		info := &grpc.StreamServerInfo{FullMethod: "/conductor.v1.AgentService/WorkStream"}
+		err := stream(nil, &wrappedServerStream{ctx: ctx}, info, func(srv interface{}, ss grpc.ServerStream) error { return nil })
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+}
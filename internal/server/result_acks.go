@@ -0,0 +1,80 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+// resultAckInterval is how many result stream messages of an agent that
+// replays results are processed before they are acknowledged. Heartbeats
+// and run completions acknowledge the rest.
+const resultAckInterval = 64
+
+// resultSequences remembers the highest result stream sequence processed
+// for each agent that replays results, across its reconnects to this
+// replica, so replayed messages are processed once.
+type resultSequences struct {
+	mu   sync.Mutex
+	last map[uuid.UUID]int64
+}
+
+// processed returns the highest sequence processed for an agent.
+func (r *resultSequences) processed(agentID uuid.UUID) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last[agentID]
+}
+
+// advance records that a message of an agent is processed. It returns
+// false if the message was processed already.
+func (r *resultSequences) advance(agentID uuid.UUID, sequence int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if sequence <= r.last[agentID] {
+		return false
+	}
+	if r.last == nil {
+		r.last = make(map[uuid.UUID]int64)
+	}
+	r.last[agentID] = sequence
+	return true
+}
+
+// reset forgets an agent's sequence, for agents that do not replay results
+// and number their messages from the start on every connection.
+func (r *resultSequences) reset(agentID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.last, agentID)
+}
+
+// ackResults acknowledges the result stream messages an agent that replays
+// results sent since the previous acknowledgement. It is called from the
+// agent's stream only.
+func (s *AgentServiceServer) ackResults(agent *connectedAgent) {
+	if !agent.replaysResults || agent.unackedResults == 0 {
+		return
+	}
+
+	ack := &conductorv1.ControlMessage{
+		Message: &conductorv1.ControlMessage_Ack{
+			Ack: &conductorv1.Ack{
+				Success:        true,
+				ResultSequence: s.resultSequences.processed(agent.id),
+			},
+		},
+	}
+
+	agent.sendMu.Lock()
+	err := agent.stream.Send(ack)
+	agent.sendMu.Unlock()
+	if err != nil {
+		s.logger.Warn().Err(err).Str("agent_id", agent.id.String()).Msg("failed to acknowledge results")
+		return
+	}
+	agent.unackedResults = 0
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+func TestResultSequences(t *testing.T) {
+	var sequences resultSequences
+	agentID := uuid.New()
+
+	assert.True(t, sequences.advance(agentID, 10))
+	assert.False(t, sequences.advance(agentID, 10), "repeated sequence")
+	assert.False(t, sequences.advance(agentID, 5), "older sequence")
+	assert.True(t, sequences.advance(agentID, 11))
+	assert.Equal(t, int64(11), sequences.processed(agentID))
+	assert.Zero(t, sequences.processed(uuid.New()))
+
+	sequences.reset(agentID)
+	assert.True(t, sequences.advance(agentID, 1))
+}
+
+func TestHandleResultStreamAcksReplayedResults(t *testing.T) {
+	s := NewAgentServiceServer(AgentServiceDeps{}, zerolog.Nop())
+	agent := &connectedAgent{id: uuid.New(), replaysResults: true}
+	stream := &recordingStream{agent: agent}
+	agent.stream = stream
+	ctx := context.Background()
+
+	progress := func(sequence int64) *conductorv1.ResultStream {
+		return &conductorv1.ResultStream{
+			RunId:    uuid.NewString(),
+			Sequence: sequence,
+			Payload: &conductorv1.ResultStream_Progress{
+				Progress: &conductorv1.ProgressUpdate{Phase: "running"},
+			},
+		}
+	}
+	acks := func() []int64 {
+		var sequences []int64
+		for _, msg := range stream.sent {
+			if ack := msg.GetAck(); ack != nil {
+				sequences = append(sequences, ack.ResultSequence)
+			}
+		}
+		return sequences
+	}
+
+	for seq := int64(1); seq < resultAckInterval; seq++ {
+		require.NoError(t, s.handleResultStream(ctx, agent, progress(seq)))
+	}
+	assert.Empty(t, acks(), "acknowledged before the interval")
+
+	require.NoError(t, s.handleResultStream(ctx, agent, progress(resultAckInterval)))
+	assert.Equal(t, []int64{resultAckInterval}, acks())
+
+	// After a reconnect the agent replays what it sent since the ack
+	require.NoError(t, s.handleResultStream(ctx, agent, progress(resultAckInterval)))
+	require.NoError(t, s.handleResultStream(ctx, agent, progress(resultAckInterval+1)))
+	assert.Equal(t, int64(resultAckInterval+1), s.resultSequences.processed(agent.id))
+
+	// Heartbeats acknowledge the rest
+	s.ackResults(agent)
+	assert.Equal(t, []int64{resultAckInterval, resultAckInterval + 1}, acks())
+	s.ackResults(agent)
+	assert.Len(t, acks(), 2, "nothing new to acknowledge")
+}
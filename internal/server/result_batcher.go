@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// defaultResultFlushInterval is how long a streamed result waits for its
+// batch to fill up when no flush interval is configured.
+const defaultResultFlushInterval = time.Second
+
+// resultFlushTimeout bounds the storing of a batch of results.
+const resultFlushTimeout = 30 * time.Second
+
+// resultBatcher buffers the test results streamed by the agents and stores
+// them in batches, so that a run with many results is not stored a row at a
+// time. A batch is stored once it is full or its first result waited for the
+// flush interval.
+type resultBatcher struct {
+	repo     AgentResultRepository
+	size     int
+	interval time.Duration
+	logger   zerolog.Logger
+
+	mu      sync.Mutex
+	pending []database.TestResult
+	timer   *time.Timer
+
+	// flushMu serializes flushes, so that a flush returns only once the
+	// results added before it are stored.
+	flushMu sync.Mutex
+}
+
+// newResultBatcher creates a batcher storing up to size results at once. A
+// size of 1 or less stores each result as it is added.
+func newResultBatcher(repo AgentResultRepository, size int, interval time.Duration, logger zerolog.Logger) *resultBatcher {
+	if interval <= 0 {
+		interval = defaultResultFlushInterval
+	}
+	return &resultBatcher{
+		repo:     repo,
+		size:     max(size, 1),
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// add buffers a result and stores the batch if it is full.
+func (b *resultBatcher) add(ctx context.Context, result database.TestResult) {
+	b.mu.Lock()
+	b.pending = append(b.pending, result)
+	full := len(b.pending) >= b.size
+	if !full && b.timer == nil {
+		b.timer = time.AfterFunc(b.interval, func() { b.flush(context.Background()) })
+	}
+	b.mu.Unlock()
+
+	if full {
+		b.flush(ctx)
+	}
+}
+
+// len returns the number of buffered results.
+func (b *resultBatcher) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+// flush stores the buffered results. Failures are logged; the results of a
+// failed batch are dropped, like the results that failed to be stored one
+// by one before.
+func (b *resultBatcher) flush(ctx context.Context) {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	// The results outlive the stream of the agent that sent them
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), resultFlushTimeout)
+	defer cancel()
+
+	if err := b.repo.BatchCreate(ctx, batch); err != nil {
+		b.logger.Warn().Err(err).Int("count", len(batch)).Msg("failed to store test results")
+		return
+	}
+	b.logger.Debug().Int("count", len(batch)).Msg("stored test results")
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/conductor/conductor/internal/database"
+)
+
+// batchResultRepo records the batches of results stored.
+type batchResultRepo struct {
+	mu      sync.Mutex
+	batches [][]database.TestResult
+}
+
+func (r *batchResultRepo) BatchCreate(ctx context.Context, results []database.TestResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, results)
+	return nil
+}
+
+func (r *batchResultRepo) batchSizes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sizes := make([]int, len(r.batches))
+	for i, batch := range r.batches {
+		sizes[i] = len(batch)
+	}
+	return sizes
+}
+
+func TestResultBatcher(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores full batches", func(t *testing.T) {
+		repo := &batchResultRepo{}
+		b := newResultBatcher(repo, 3, time.Hour, zerolog.Nop())
+		for _, name := range []string{"a", "b", "c", "d"} {
+			b.add(ctx, database.TestResult{TestName: name})
+		}
+		assert.Equal(t, []int{3}, repo.batchSizes())
+
+		b.flush(ctx)
+		assert.Equal(t, []int{3, 1}, repo.batchSizes())
+		assert.Equal(t, "d", repo.batches[1][0].TestName)
+
+		b.flush(ctx)
+		assert.Equal(t, []int{3, 1}, repo.batchSizes(), "empty batches are not stored")
+	})
+
+	t.Run("stores partial batches after the interval", func(t *testing.T) {
+		repo := &batchResultRepo{}
+		b := newResultBatcher(repo, 100, 10*time.Millisecond, zerolog.Nop())
+		b.add(ctx, database.TestResult{TestName: "a"})
+		b.add(ctx, database.TestResult{TestName: "b"})
+
+		assert.Eventually(t, func() bool {
+			sizes := repo.batchSizes()
+			return len(sizes) == 1 && sizes[0] == 2
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("stores each result without batching", func(t *testing.T) {
+		repo := &batchResultRepo{}
+		b := newResultBatcher(repo, 0, 0, zerolog.Nop())
+		b.add(ctx, database.TestResult{TestName: "a"})
+		b.add(ctx, database.TestResult{TestName: "b"})
+		assert.Equal(t, []int{1, 1}, repo.batchSizes())
+	})
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+// maxResultTextBytes bounds the error message and stack trace assembled for
+// one test result; parts beyond it are dropped.
+const maxResultTextBytes = 64 << 20
+
+// resultParts assembles the test results an agent sends in parts because
+// they are too large for one message. Parts of a result arrive in order on
+// the agent's stream, so only the stream's own results are kept.
+type resultParts struct {
+	mu      sync.Mutex
+	pending map[string]*conductorv1.TestResultEvent
+}
+
+// add records a part of a result and returns the assembled result once its
+// last part arrived, or nil while more parts are expected. Results sent in
+// one message are returned as they are.
+func (p *resultParts) add(rs *conductorv1.ResultStream, event *conductorv1.TestResultEvent) *conductorv1.TestResultEvent {
+	key := fmt.Sprintf("%s/%s/%s/%s/%d", rs.RunId, rs.ShardId, event.TestId, event.TestName, event.RetryAttempt)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	assembled, ok := p.pending[key]
+	if !ok {
+		if !event.Partial {
+			return event
+		}
+		if p.pending == nil {
+			p.pending = make(map[string]*conductorv1.TestResultEvent)
+		}
+		p.pending[key] = event
+		return nil
+	}
+
+	if len(assembled.ErrorMessage)+len(assembled.StackTrace)+len(event.ErrorMessage)+len(event.StackTrace) <= maxResultTextBytes {
+		assembled.ErrorMessage += event.ErrorMessage
+		assembled.StackTrace += event.StackTrace
+	}
+	if event.Partial {
+		return nil
+	}
+	delete(p.pending, key)
+	assembled.Partial = false
+	return assembled
+}
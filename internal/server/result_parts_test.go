@@ -0,0 +1,39 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+func TestResultParts(t *testing.T) {
+	var parts resultParts
+	rs := &conductorv1.ResultStream{RunId: "run-1"}
+
+	whole := &conductorv1.TestResultEvent{TestName: "TestLogin", ErrorMessage: "boom"}
+	assert.Same(t, whole, parts.add(rs, whole), "results sent whole are returned as they are")
+
+	first := &conductorv1.TestResultEvent{
+		TestName:     "TestCheckout",
+		Status:       conductorv1.TestStatus_TEST_STATUS_FAIL,
+		ErrorMessage: "expected 3 items, ",
+		Partial:      true,
+	}
+	assert.Nil(t, parts.add(rs, first))
+
+	// Parts of other results do not mix
+	other := &conductorv1.TestResultEvent{TestName: "TestCheckout", RetryAttempt: 1, ErrorMessage: "retry", Partial: true}
+	assert.Nil(t, parts.add(rs, other))
+
+	assert.Nil(t, parts.add(rs, &conductorv1.TestResultEvent{TestName: "TestCheckout", ErrorMessage: "got 2", StackTrace: "at checkout.go:12\n", Partial: true}))
+	result := parts.add(rs, &conductorv1.TestResultEvent{TestName: "TestCheckout", StackTrace: "at cart.go:40\n"})
+	require.NotNil(t, result)
+	assert.Equal(t, conductorv1.TestStatus_TEST_STATUS_FAIL, result.Status)
+	assert.Equal(t, "expected 3 items, got 2", result.ErrorMessage)
+	assert.Equal(t, "at checkout.go:12\nat cart.go:40\n", result.StackTrace)
+	assert.False(t, result.Partial)
+	assert.Len(t, parts.pending, 1, "the retry is still pending")
+}
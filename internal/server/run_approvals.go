@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// approverRole is the role that may approve runs besides admins.
+const approverRole = "approver"
+
+// RunApprovalRepository releases runs waiting for approval.
+type RunApprovalRepository interface {
+	Approve(ctx context.Context, id uuid.UUID, approvedBy string) error
+}
+
+// ProtectedBranchRepository finds the branches of a service, whose
+// protection holds their runs for approval.
+type ProtectedBranchRepository interface {
+	Get(ctx context.Context, serviceID uuid.UUID, name string) (*database.Branch, error)
+}
+
+// runApprovalReason returns why a new run of a service on a branch waits for
+// approval, or nil if it can be scheduled right away. Runs of protected
+// branches and runs including a test that requires approval wait. Runs
+// without a branch run on the service's default branch.
+func (s *RunServiceServer) runApprovalReason(ctx context.Context, service *database.Service, branch string, tests []*database.TestDefinition) (*string, error) {
+	if branch == "" {
+		branch = service.DefaultBranch
+	}
+	if s.deps.BranchRepo != nil && branch != "" {
+		b, err := s.deps.BranchRepo.Get(ctx, service.ID, branch)
+		if err != nil && !database.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get branch %s: %w", branch, err)
+		}
+		if err == nil && b.Protected {
+			reason := fmt.Sprintf("branch %s is protected", branch)
+			return &reason, nil
+		}
+	}
+
+	var names []string
+	for _, test := range tests {
+		if test.RequiresApproval {
+			names = append(names, test.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	reason := fmt.Sprintf("tests require approval: %s", strings.Join(names, ", "))
+	return &reason, nil
+}
+
+// initialRunStatus returns the status a new run starts in: waiting for
+// approval if it needs it, pending otherwise.
+func initialRunStatus(approvalReason *string) database.RunStatus {
+	if approvalReason != nil {
+		return database.RunStatusWaitingApproval
+	}
+	return database.RunStatusPending
+}
+
+// ApproveRun releases a run waiting for approval to the scheduler. Admins
+// and users with the approver role may approve runs; the audit log records
+// the approval with its comment.
+func (s *RunServiceServer) ApproveRun(ctx context.Context, req *conductorv1.ApproveRunRequest) (*conductorv1.ApproveRunResponse, error) {
+	claims := GetUserFromContext(ctx)
+	if claims == nil || (!claims.IsAdmin() && !claims.HasRole(approverRole)) {
+		return nil, errcode.New(errcode.PermissionDenied, "approving runs requires the admin or %s role", approverRole)
+	}
+	if s.deps.Approvals == nil {
+		return nil, errcode.New(errcode.NotConfigured, "run approvals are not configured")
+	}
+
+	runID, err := uuid.Parse(req.RunId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid run ID: %v", err)
+	}
+
+	run, err := s.deps.RunRepo.GetByID(ctx, runID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.RunNotFound, "run not found: %s", req.RunId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get run: %v", err)
+	}
+	if run.Status != database.RunStatusWaitingApproval {
+		return nil, errcode.New(errcode.FailedPrecondition, "run is not waiting for approval: %s", run.Status)
+	}
+
+	approvedBy := claims.UserID
+	if approvedBy == "" {
+		approvedBy = claims.Email
+	}
+	// The run may have been approved or cancelled since it was read
+	if err := s.deps.Approvals.Approve(ctx, runID, approvedBy); err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.FailedPrecondition, "run is no longer waiting for approval")
+		}
+		return nil, errcode.New(errcode.Internal, "failed to approve run: %v", err)
+	}
+
+	run, err = s.deps.RunRepo.GetByID(ctx, runID)
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to get run: %v", err)
+	}
+	service, _ := s.deps.ServiceRepo.GetByID(ctx, run.ServiceID)
+
+	s.logger.Info().
+		Str("run_id", runID.String()).
+		Str("approved_by", approvedBy).
+		Str("comment", req.Comment).
+		Msg("run approved")
+
+	return &conductorv1.ApproveRunResponse{
+		Run: runToProto(run, service),
+	}, nil
+}
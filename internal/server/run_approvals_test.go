@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// approvalRunRepo keeps runs in memory and approves the waiting ones.
+type approvalRunRepo struct {
+	branchRunRepo
+}
+
+func (r *approvalRunRepo) GetByID(ctx context.Context, id uuid.UUID) (*database.TestRun, error) {
+	run := r.get(id)
+	if run == nil {
+		return nil, database.ErrNotFound
+	}
+	return run, nil
+}
+
+func (r *approvalRunRepo) Approve(ctx context.Context, id uuid.UUID, approvedBy string) error {
+	run := r.get(id)
+	if run == nil || run.Status != database.RunStatusWaitingApproval {
+		return database.ErrNotFound
+	}
+	now := time.Now()
+	run.Status = database.RunStatusPending
+	run.ApprovedBy = &approvedBy
+	run.ApprovedAt = &now
+	return nil
+}
+
+// protectedBranchRepo serves branches, protected or not, by name.
+type protectedBranchRepo struct {
+	protected map[string]bool
+}
+
+func (r *protectedBranchRepo) Get(ctx context.Context, serviceID uuid.UUID, name string) (*database.Branch, error) {
+	protected, ok := r.protected[name]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	return &database.Branch{ServiceID: serviceID, Name: name, Protected: protected}, nil
+}
+
+func newApprovalServer(policy database.BranchRunPolicy, tests ...*database.TestDefinition) (*RunServiceServer, *approvalRunRepo, *database.Service) {
+	service := &database.Service{ID: uuid.New(), Name: "api", DefaultBranch: "main", BranchRunPolicy: policy}
+	runs := &approvalRunRepo{}
+	return NewRunServiceServer(RunServiceDeps{
+		RunRepo:     runs,
+		ServiceRepo: &placementServiceRepo{service: service},
+		TestRepo:    &placementTestRepo{tests: tests},
+		Scheduler:   &cancellingScheduler{},
+		BranchRuns:  runs,
+		BranchRepo:  &protectedBranchRepo{protected: map[string]bool{"main": true, "feature": false}},
+		Approvals:   runs,
+	}, zerolog.Nop()), runs, service
+}
+
+func TestCreateRun_Approval(t *testing.T) {
+	ctx := context.Background()
+	push := func(service *database.Service, branch, sha string) *conductorv1.CreateRunRequest {
+		return &conductorv1.CreateRunRequest{
+			ServiceId: service.ID.String(),
+			GitRef:    &conductorv1.GitRef{Branch: branch, CommitSha: sha},
+		}
+	}
+
+	t.Run("runs of protected branches wait", func(t *testing.T) {
+		server, runs, service := newApprovalServer(database.BranchRunPolicyAllow)
+
+		resp, err := server.CreateRun(ctx, push(service, "main", "aaa"))
+		require.NoError(t, err)
+		assert.Equal(t, conductorv1.RunStatus_RUN_STATUS_WAITING_APPROVAL, resp.Run.Status)
+		assert.Equal(t, "branch main is protected", resp.Run.ApprovalReason)
+
+		_, err = server.CreateRun(ctx, push(service, "feature", "bbb"))
+		require.NoError(t, err)
+		_, err = server.CreateRun(ctx, &conductorv1.CreateRunRequest{ServiceId: service.ID.String()})
+		require.NoError(t, err)
+
+		require.Len(t, runs.runs, 3)
+		assert.Equal(t, database.RunStatusPending, runs.runs[1].Status)
+		assert.Nil(t, runs.runs[1].ApprovalReason)
+		assert.Equal(t, database.RunStatusWaitingApproval, runs.runs[2].Status, "runs without a branch use the default branch")
+	})
+
+	t.Run("runs of tests requiring approval wait", func(t *testing.T) {
+		server, runs, service := newApprovalServer(database.BranchRunPolicyAllow,
+			&database.TestDefinition{Name: "unit"},
+			&database.TestDefinition{Name: "deploy-smoke", RequiresApproval: true},
+		)
+
+		_, err := server.CreateRun(ctx, push(service, "feature", "aaa"))
+		require.NoError(t, err)
+
+		require.Len(t, runs.runs, 1)
+		assert.Equal(t, database.RunStatusWaitingApproval, runs.runs[0].Status)
+		require.NotNil(t, runs.runs[0].ApprovalReason)
+		assert.Equal(t, "tests require approval: deploy-smoke", *runs.runs[0].ApprovalReason)
+	})
+
+	t.Run("retries wait again", func(t *testing.T) {
+		server, runs, service := newApprovalServer(database.BranchRunPolicyAllow)
+
+		created, err := server.CreateRun(ctx, push(service, "main", "aaa"))
+		require.NoError(t, err)
+		retry, err := server.RetryRun(ctx, &conductorv1.RetryRunRequest{RunId: created.Run.Id})
+		require.NoError(t, err)
+
+		assert.Equal(t, conductorv1.RunStatus_RUN_STATUS_WAITING_APPROVAL, retry.Run.Status)
+		assert.Len(t, runs.runs, 2)
+	})
+
+	t.Run("coalesce leaves approved runs alone", func(t *testing.T) {
+		server, runs, service := newApprovalServer(database.BranchRunPolicyCoalesce)
+		approver := withUser(ctx, &UserClaims{UserID: "u-1", Roles: []string{approverRole}})
+
+		first, err := server.CreateRun(ctx, push(service, "main", "aaa"))
+		require.NoError(t, err)
+		second, err := server.CreateRun(ctx, push(service, "main", "bbb"))
+		require.NoError(t, err)
+		assert.Equal(t, first.Run.Id, second.Run.Id, "waiting runs are coalesced")
+
+		_, err = server.ApproveRun(approver, &conductorv1.ApproveRunRequest{RunId: first.Run.Id})
+		require.NoError(t, err)
+		third, err := server.CreateRun(ctx, push(service, "main", "ccc"))
+		require.NoError(t, err)
+
+		assert.NotEqual(t, first.Run.Id, third.Run.Id)
+		require.Len(t, runs.runs, 2)
+		assert.Equal(t, "bbb", *runs.runs[0].GitSHA)
+		assert.Equal(t, database.RunStatusWaitingApproval, runs.runs[1].Status)
+	})
+}
+
+func TestApproveRun(t *testing.T) {
+	server, runs, service := newApprovalServer(database.BranchRunPolicyAllow)
+	created, err := server.CreateRun(context.Background(), &conductorv1.CreateRunRequest{
+		ServiceId: service.ID.String(),
+		GitRef:    &conductorv1.GitRef{Branch: "main"},
+	})
+	require.NoError(t, err)
+	req := &conductorv1.ApproveRunRequest{RunId: created.Run.Id, Comment: "release sign-off"}
+
+	t.Run("requires the approver or admin role", func(t *testing.T) {
+		_, err := server.ApproveRun(context.Background(), req)
+		assert.True(t, errcode.Is(err, errcode.PermissionDenied))
+
+		viewer := withUser(context.Background(), &UserClaims{UserID: "u-2", Roles: []string{"viewer"}})
+		_, err = server.ApproveRun(viewer, req)
+		assert.True(t, errcode.Is(err, errcode.PermissionDenied))
+		assert.Equal(t, database.RunStatusWaitingApproval, runs.runs[0].Status)
+	})
+
+	approver := withUser(context.Background(), &UserClaims{UserID: "u-1", Roles: []string{approverRole}})
+
+	t.Run("releases the run", func(t *testing.T) {
+		resp, err := server.ApproveRun(approver, req)
+		require.NoError(t, err)
+
+		assert.Equal(t, conductorv1.RunStatus_RUN_STATUS_PENDING, resp.Run.Status)
+		assert.Equal(t, "u-1", resp.Run.ApprovedBy)
+		assert.NotNil(t, resp.Run.ApprovedAt)
+		assert.Equal(t, "branch main is protected", resp.Run.ApprovalReason)
+	})
+
+	t.Run("fails unless the run is waiting", func(t *testing.T) {
+		_, err := server.ApproveRun(approver, req)
+		assert.True(t, errcode.Is(err, errcode.FailedPrecondition))
+
+		_, err = server.ApproveRun(approver, &conductorv1.ApproveRunRequest{RunId: uuid.NewString()})
+		assert.True(t, errcode.Is(err, errcode.RunNotFound))
+	})
+}
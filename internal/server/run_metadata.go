@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/google/uuid"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// Limits of the annotations and links of a run, which are returned with
+// every run.
+const (
+	maxRunMetadataEntries   = 64
+	maxRunMetadataKeyLength = 128
+	maxRunAnnotationLength  = 1024
+	maxRunLinkLength        = 2048
+)
+
+// RunMetadataRepository attaches annotations and links to runs.
+type RunMetadataRepository interface {
+	Annotate(ctx context.Context, id uuid.UUID, metadata database.RunMetadata) (*database.RunMetadata, error)
+}
+
+// AnnotateRun attaches annotations and external links to a run, at any time
+// during or after its execution.
+func (s *RunServiceServer) AnnotateRun(ctx context.Context, req *conductorv1.AnnotateRunRequest) (*conductorv1.AnnotateRunResponse, error) {
+	if s.deps.Metadata == nil {
+		return nil, errcode.New(errcode.NotConfigured, "run annotations are not configured")
+	}
+
+	runID, err := uuid.Parse(req.RunId)
+	if err != nil {
+		return nil, errcode.New(errcode.InvalidArgument, "invalid run ID: %v", err)
+	}
+
+	run, err := s.deps.RunRepo.GetByID(ctx, runID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.RunNotFound, "run not found: %s", req.RunId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to get run: %v", err)
+	}
+
+	patch := database.RunMetadata{Annotations: req.Annotations, Links: req.Links}
+	if err := validateRunMetadata(patch, true); err != nil {
+		return nil, err
+	}
+	merged := database.RunMetadata{
+		Annotations: mergeRunMetadata(run.Metadata.Annotations, req.Annotations),
+		Links:       mergeRunMetadata(run.Metadata.Links, req.Links),
+	}
+	if err := validateRunMetadata(merged, false); err != nil {
+		return nil, err
+	}
+
+	metadata, err := s.deps.Metadata.Annotate(ctx, runID, patch)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return nil, errcode.New(errcode.RunNotFound, "run not found: %s", req.RunId)
+		}
+		return nil, errcode.New(errcode.Internal, "failed to annotate run: %v", err)
+	}
+	run.Metadata = *metadata
+	service, _ := s.deps.ServiceRepo.GetByID(ctx, run.ServiceID)
+
+	s.logger.Debug().
+		Str("run_id", runID.String()).
+		Int("annotations", len(req.Annotations)).
+		Int("links", len(req.Links)).
+		Msg("run annotated")
+
+	return &conductorv1.AnnotateRunResponse{Run: runToProto(run, service)}, nil
+}
+
+// validateRunMetadata checks the annotations and links of a run. Empty
+// values are allowed in patches, where they remove their keys.
+func validateRunMetadata(metadata database.RunMetadata, patch bool) error {
+	if n := len(metadata.Annotations) + len(metadata.Links); n > maxRunMetadataEntries {
+		return errcode.New(errcode.InvalidArgument, "runs have at most %d annotations and links, got %d", maxRunMetadataEntries, n)
+	}
+	for key, value := range metadata.Annotations {
+		if err := validateRunMetadataKey("annotation", key); err != nil {
+			return err
+		}
+		if len(value) > maxRunAnnotationLength {
+			return errcode.New(errcode.InvalidArgument, "annotation %s exceeds %d characters", key, maxRunAnnotationLength)
+		}
+		if value == "" && !patch {
+			return errcode.New(errcode.InvalidArgument, "annotation %s is empty", key)
+		}
+	}
+	for name, link := range metadata.Links {
+		if err := validateRunMetadataKey("link", name); err != nil {
+			return err
+		}
+		if link == "" && patch {
+			continue
+		}
+		if err := validateRunLink(link); err != nil {
+			return errcode.New(errcode.InvalidArgument, "invalid link %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// validateRunMetadataKey checks the name of an annotation or link.
+func validateRunMetadataKey(kind, key string) error {
+	if key == "" {
+		return errcode.New(errcode.InvalidArgument, "%s names must not be empty", kind)
+	}
+	if len(key) > maxRunMetadataKeyLength {
+		return errcode.New(errcode.InvalidArgument, "%s name %s exceeds %d characters", kind, key, maxRunMetadataKeyLength)
+	}
+	return nil
+}
+
+// validateRunLink checks that a link is an absolute http(s) URL, so it can
+// be followed from the dashboard.
+func validateRunLink(link string) error {
+	if len(link) > maxRunLinkLength {
+		return fmt.Errorf("URL exceeds %d characters", maxRunLinkLength)
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// mergeRunMetadata merges a patch of annotations or links into the current
+// ones as the database does; empty values remove their keys.
+func mergeRunMetadata(current, patch map[string]string) map[string]string {
+	merged := make(map[string]string, len(current)+len(patch))
+	for key, value := range current {
+		merged[key] = value
+	}
+	for key, value := range patch {
+		if value == "" {
+			delete(merged, key)
+		} else {
+			merged[key] = value
+		}
+	}
+	return merged
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// metadataRunRepo keeps runs in memory and merges their metadata.
+type metadataRunRepo struct {
+	approvalRunRepo
+}
+
+func (r *metadataRunRepo) Annotate(ctx context.Context, id uuid.UUID, metadata database.RunMetadata) (*database.RunMetadata, error) {
+	run := r.get(id)
+	if run == nil {
+		return nil, database.ErrNotFound
+	}
+	run.Metadata = database.RunMetadata{
+		Annotations: mergeRunMetadata(run.Metadata.Annotations, metadata.Annotations),
+		Links:       mergeRunMetadata(run.Metadata.Links, metadata.Links),
+	}
+	return &run.Metadata, nil
+}
+
+func TestRunMetadata(t *testing.T) {
+	ctx := context.Background()
+	service := &database.Service{ID: uuid.New(), Name: "api"}
+	runs := &metadataRunRepo{}
+	server := NewRunServiceServer(RunServiceDeps{
+		RunRepo:     runs,
+		ServiceRepo: &placementServiceRepo{service: service},
+		TestRepo:    &placementTestRepo{},
+		Scheduler:   &cancellingScheduler{},
+		Metadata:    runs,
+	}, zerolog.Nop())
+
+	var runID string
+	t.Run("attaches annotations and links at creation", func(t *testing.T) {
+		resp, err := server.CreateRun(ctx, &conductorv1.CreateRunRequest{
+			ServiceId:   service.ID.String(),
+			Annotations: map[string]string{"deployment_id": "d-42"},
+			Links:       map[string]string{"build": "https://ci.example.com/builds/7"},
+		})
+		require.NoError(t, err)
+		runID = resp.Run.Id
+		assert.Equal(t, map[string]string{"deployment_id": "d-42"}, resp.Run.Annotations)
+		assert.Equal(t, map[string]string{"build": "https://ci.example.com/builds/7"}, resp.Run.Links)
+	})
+
+	t.Run("merges annotations and links afterwards", func(t *testing.T) {
+		resp, err := server.AnnotateRun(ctx, &conductorv1.AnnotateRunRequest{
+			RunId:       runID,
+			Annotations: map[string]string{"deployment_id": "", "release": "1.4.0"},
+			Links:       map[string]string{"ticket": "https://tracker.example.com/OPS-1"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"release": "1.4.0"}, resp.Run.Annotations)
+		assert.Equal(t, map[string]string{
+			"build":  "https://ci.example.com/builds/7",
+			"ticket": "https://tracker.example.com/OPS-1",
+		}, resp.Run.Links)
+	})
+
+	t.Run("rejects invalid metadata", func(t *testing.T) {
+		for name, req := range map[string]*conductorv1.AnnotateRunRequest{
+			"relative link":   {RunId: runID, Links: map[string]string{"build": "/builds/7"}},
+			"other scheme":    {RunId: runID, Links: map[string]string{"build": "javascript:alert(1)"}},
+			"empty name":      {RunId: runID, Annotations: map[string]string{"": "x"}},
+			"long annotation": {RunId: runID, Annotations: map[string]string{"log": strings.Repeat("x", maxRunAnnotationLength+1)}},
+		} {
+			_, err := server.AnnotateRun(ctx, req)
+			assert.Equal(t, errcode.InvalidArgument, errcode.FromError(err), name)
+		}
+
+		_, err := server.CreateRun(ctx, &conductorv1.CreateRunRequest{
+			ServiceId:   service.ID.String(),
+			Annotations: map[string]string{"deployment_id": ""},
+		})
+		assert.Equal(t, errcode.InvalidArgument, errcode.FromError(err))
+	})
+
+	t.Run("limits the entries of a run", func(t *testing.T) {
+		annotations := make(map[string]string)
+		for i := 0; len(annotations) < maxRunMetadataEntries-1; i++ {
+			annotations[strings.Repeat("k", i+1)] = "v"
+		}
+		_, err := server.AnnotateRun(ctx, &conductorv1.AnnotateRunRequest{RunId: runID, Annotations: annotations})
+		assert.Equal(t, errcode.InvalidArgument, errcode.FromError(err))
+	})
+
+	t.Run("unknown run", func(t *testing.T) {
+		_, err := server.AnnotateRun(ctx, &conductorv1.AnnotateRunRequest{RunId: uuid.NewString()})
+		assert.Equal(t, errcode.RunNotFound, errcode.FromError(err))
+	})
+}
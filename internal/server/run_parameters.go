@@ -0,0 +1,39 @@
+package server
+
+import (
+	"slices"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// Limits of the parameters of a run, which are passed to its tests as
+// environment variables.
+const (
+	maxRunParameters           = 64
+	maxRunParameterValueLength = 4096
+)
+
+// checkRunParameters checks the parameters of a new run against the
+// parameter schemas of the tests it executes. Every parameter must be
+// declared by a test, and every test must accept the parameters, including
+// the required ones it declares.
+func checkRunParameters(tests []*database.TestDefinition, params map[string]string) error {
+	if len(params) > maxRunParameters {
+		return errcode.New(errcode.InvalidArgument, "runs have at most %d parameters, got %d", maxRunParameters, len(params))
+	}
+	for name, value := range params {
+		if len(value) > maxRunParameterValueLength {
+			return errcode.New(errcode.InvalidArgument, "parameter %s exceeds %d characters", name, maxRunParameterValueLength)
+		}
+		if !slices.ContainsFunc(tests, func(test *database.TestDefinition) bool { return test.Parameters.Declares(name) }) {
+			return errcode.New(errcode.InvalidArgument, "unknown parameter %s: no test of the service declares it", name)
+		}
+	}
+	for _, test := range tests {
+		if _, err := test.Parameters.Resolve(params); err != nil {
+			return errcode.New(errcode.InvalidArgument, "invalid parameters for test %s: %v", test.Name, err)
+		}
+	}
+	return nil
+}
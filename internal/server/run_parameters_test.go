@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+func TestRunParameters(t *testing.T) {
+	ctx := context.Background()
+	staging := "staging"
+	service := &database.Service{ID: uuid.New(), Name: "api"}
+	tests := []*database.TestDefinition{
+		{Name: "e2e", Parameters: database.TestParameters{
+			{Name: "TARGET_ENV", Default: &staging, Choices: []string{"staging", "production"}},
+			{Name: "WORKERS", Type: database.TestParameterInteger},
+		}},
+		{Name: "smoke", Parameters: database.TestParameters{{Name: "TOKEN", Required: true}}},
+	}
+	runs := &approvalRunRepo{}
+	server := NewRunServiceServer(RunServiceDeps{
+		RunRepo:     runs,
+		ServiceRepo: &placementServiceRepo{service: service},
+		TestRepo:    &placementTestRepo{tests: tests},
+		Scheduler:   &cancellingScheduler{},
+	}, zerolog.Nop())
+
+	t.Run("records valid parameters", func(t *testing.T) {
+		params := map[string]string{"TARGET_ENV": "production", "TOKEN": "t"}
+		resp, err := server.CreateRun(ctx, &conductorv1.CreateRunRequest{ServiceId: service.ID.String(), Parameters: params})
+		require.NoError(t, err)
+		assert.Equal(t, params, resp.Run.Parameters)
+
+		retry, err := server.RetryRun(ctx, &conductorv1.RetryRunRequest{RunId: resp.Run.Id})
+		require.NoError(t, err)
+		assert.Equal(t, params, retry.Run.Parameters)
+	})
+
+	t.Run("rejects invalid parameters", func(t *testing.T) {
+		for name, params := range map[string]map[string]string{
+			"missing required": {"TARGET_ENV": "staging"},
+			"unknown":          {"TOKEN": "t", "REGION": "eu"},
+			"not a choice":     {"TOKEN": "t", "TARGET_ENV": "dev"},
+			"wrong type":       {"TOKEN": "t", "WORKERS": "four"},
+		} {
+			_, err := server.CreateRun(ctx, &conductorv1.CreateRunRequest{ServiceId: service.ID.String(), Parameters: params})
+			assert.Equal(t, errcode.InvalidArgument, errcode.FromError(err), name)
+		}
+	})
+}
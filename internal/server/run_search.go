@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+const (
+	// defaultSearchResultsPerRun is how many matching test results a run
+	// search returns per run by default.
+	defaultSearchResultsPerRun = 3
+	// maxSearchResultsPerRun caps the matching test results a run search
+	// returns per run.
+	maxSearchResultsPerRun = 20
+)
+
+// RunSearchRepository searches runs.
+type RunSearchRepository interface {
+	Search(ctx context.Context, filter database.RunSearchFilter, page database.Pagination) ([]database.TestRun, int, error)
+}
+
+// ResultSearchRepository finds the test results of a run matching a search.
+type ResultSearchRepository interface {
+	SearchByRun(ctx context.Context, runID uuid.UUID, text string, limit int) ([]database.TestResult, error)
+}
+
+// SearchRuns searches runs by the errors they and their test results failed
+// with, combined with filters such as branch, status and time.
+func (s *RunServiceServer) SearchRuns(ctx context.Context, req *conductorv1.SearchRunsRequest) (*conductorv1.SearchRunsResponse, error) {
+	if s.deps.SearchRepo == nil {
+		return nil, errcode.New(errcode.NotConfigured, "run search is not configured")
+	}
+
+	filter, err := runSearchFilterFromProto(req)
+	if err != nil {
+		return nil, err
+	}
+	perRun := int(req.MaxResultsPerRun)
+	switch {
+	case perRun < 0:
+		return nil, errcode.New(errcode.InvalidArgument, "max_results_per_run must not be negative")
+	case perRun == 0:
+		perRun = defaultSearchResultsPerRun
+	case perRun > maxSearchResultsPerRun:
+		perRun = maxSearchResultsPerRun
+	}
+
+	pagination := paginationFromProto(req.Pagination)
+	runs, total, err := s.deps.SearchRepo.Search(ctx, filter, peekPagination(pagination))
+	if err != nil {
+		return nil, errcode.New(errcode.Internal, "failed to search runs: %v", err)
+	}
+	runs, page := keysetPage(pagination, runs, total, func(run database.TestRun) database.Cursor {
+		return runCursor(&run)
+	})
+
+	services := make(map[uuid.UUID]*database.Service)
+	hits := make([]*conductorv1.RunSearchHit, len(runs))
+	for i := range runs {
+		run := &runs[i]
+		svc, ok := services[run.ServiceID]
+		if !ok {
+			svc, _ = s.deps.ServiceRepo.GetByID(ctx, run.ServiceID)
+			services[run.ServiceID] = svc
+		}
+		hits[i] = &conductorv1.RunSearchHit{Run: runToProto(run, svc)}
+
+		if filter.Text == nil || s.deps.ResultSearchRepo == nil {
+			continue
+		}
+		results, err := s.deps.ResultSearchRepo.SearchByRun(ctx, run.ID, *filter.Text, perRun)
+		if err != nil {
+			return nil, errcode.New(errcode.Internal, "failed to search test results: %v", err)
+		}
+		for j := range results {
+			result := testResultToProto(&results[j])
+			result.Stdout, result.Stderr = "", ""
+			hits[i].MatchingResults = append(hits[i].MatchingResults, result)
+		}
+	}
+
+	return &conductorv1.SearchRunsResponse{
+		Hits:       hits,
+		Pagination: page,
+	}, nil
+}
+
+// runSearchFilterFromProto converts the filters of a run search.
+func runSearchFilterFromProto(req *conductorv1.SearchRunsRequest) (database.RunSearchFilter, error) {
+	var filter database.RunSearchFilter
+	if query := strings.TrimSpace(req.Query); query != "" {
+		filter.Text = &query
+	}
+	if branch := strings.TrimSpace(req.Branch); branch != "" {
+		filter.Branch = &branch
+	}
+	if req.ServiceId != "" {
+		serviceID, err := uuid.Parse(req.ServiceId)
+		if err != nil {
+			return filter, errcode.New(errcode.InvalidArgument, "invalid service ID: %v", err)
+		}
+		filter.ServiceID = &serviceID
+	}
+	for _, status := range req.Statuses {
+		filter.Statuses = append(filter.Statuses, runStatusFromProto(status))
+	}
+	if req.TimeRange != nil {
+		if req.TimeRange.Start != nil {
+			t := req.TimeRange.Start.AsTime()
+			filter.Since = &t
+		}
+		if req.TimeRange.End != nil {
+			t := req.TimeRange.End.AsTime()
+			filter.Until = &t
+		}
+		if filter.Since != nil && filter.Until != nil && !filter.Since.Before(*filter.Until) {
+			return filter, errcode.New(errcode.InvalidArgument, "time range start must be before its end")
+		}
+	}
+	return filter, nil
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// searchRunRepo returns its runs for any search and records the filter.
+type searchRunRepo struct {
+	runs   []database.TestRun
+	filter database.RunSearchFilter
+}
+
+func (r *searchRunRepo) Search(ctx context.Context, filter database.RunSearchFilter, page database.Pagination) ([]database.TestRun, int, error) {
+	r.filter = filter
+	return r.runs[:min(page.Limit, len(r.runs))], len(r.runs), nil
+}
+
+// searchResultRepo returns the results of runs matching any text.
+type searchResultRepo struct {
+	results map[uuid.UUID][]database.TestResult
+}
+
+func (r *searchResultRepo) SearchByRun(ctx context.Context, runID uuid.UUID, text string, limit int) ([]database.TestResult, error) {
+	results := r.results[runID]
+	return results[:min(limit, len(results))], nil
+}
+
+func TestSearchRuns(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	runs := []database.TestRun{
+		{ID: uuid.New(), Status: database.RunStatusFailed, CreatedAt: now},
+		{ID: uuid.New(), Status: database.RunStatusFailed, CreatedAt: now.Add(-time.Hour)},
+	}
+	errMsg := "connection refused"
+	results := &searchResultRepo{results: map[uuid.UUID][]database.TestResult{
+		runs[0].ID: {
+			{ID: uuid.New(), RunID: runs[0].ID, TestName: "TestA", Status: database.ResultStatusFail, ErrorMessage: &errMsg, Stdout: &errMsg},
+			{ID: uuid.New(), RunID: runs[0].ID, TestName: "TestB", Status: database.ResultStatusFail, ErrorMessage: &errMsg},
+		},
+	}}
+	repo := &searchRunRepo{runs: runs}
+	server := NewRunServiceServer(RunServiceDeps{
+		ServiceRepo:      &placementServiceRepo{service: &database.Service{Name: "checkout"}},
+		SearchRepo:       repo,
+		ResultSearchRepo: results,
+	}, zerolog.Nop())
+
+	t.Run("returns runs with their matching results", func(t *testing.T) {
+		serviceID := uuid.New()
+		since := now.Add(-7 * 24 * time.Hour)
+		resp, err := server.SearchRuns(ctx, &conductorv1.SearchRunsRequest{
+			Query:            "  connection refused ",
+			ServiceId:        serviceID.String(),
+			Statuses:         []conductorv1.RunStatus{conductorv1.RunStatus_RUN_STATUS_FAILED},
+			Branch:           "main",
+			TimeRange:        &conductorv1.TimeRange{Start: timestamppb.New(since)},
+			MaxResultsPerRun: 1,
+			Pagination:       &conductorv1.Pagination{PageSize: 1},
+		})
+		require.NoError(t, err)
+
+		require.NotNil(t, repo.filter.Text)
+		assert.Equal(t, "connection refused", *repo.filter.Text)
+		assert.Equal(t, "main", *repo.filter.Branch)
+		assert.Equal(t, serviceID, *repo.filter.ServiceID)
+		assert.Equal(t, []database.RunStatus{database.RunStatusFailed}, repo.filter.Statuses)
+		assert.True(t, since.Equal(*repo.filter.Since))
+		assert.Nil(t, repo.filter.Until)
+
+		require.Len(t, resp.Hits, 1)
+		assert.Equal(t, runs[0].ID.String(), resp.Hits[0].Run.Id)
+		require.Len(t, resp.Hits[0].MatchingResults, 1)
+		assert.Equal(t, "TestA", resp.Hits[0].MatchingResults[0].TestName)
+		assert.Equal(t, errMsg, resp.Hits[0].MatchingResults[0].ErrorMessage)
+		assert.Empty(t, resp.Hits[0].MatchingResults[0].Stdout, "output is left out")
+
+		assert.True(t, resp.Pagination.HasMore)
+		assert.EqualValues(t, 2, resp.Pagination.TotalCount)
+		after, ok := decodeCursorToken(resp.Pagination.NextPageToken)
+		require.True(t, ok)
+		assert.Equal(t, runs[0].ID, after.ID)
+	})
+
+	t.Run("returns no results without a query", func(t *testing.T) {
+		resp, err := server.SearchRuns(ctx, &conductorv1.SearchRunsRequest{})
+		require.NoError(t, err)
+		assert.Nil(t, repo.filter.Text)
+		require.Len(t, resp.Hits, 2)
+		assert.Empty(t, resp.Hits[0].MatchingResults)
+	})
+
+	t.Run("rejects invalid filters", func(t *testing.T) {
+		for name, req := range map[string]*conductorv1.SearchRunsRequest{
+			"service ID":  {ServiceId: "nope"},
+			"time range":  {TimeRange: &conductorv1.TimeRange{Start: timestamppb.New(now), End: timestamppb.New(now.Add(-time.Hour))}},
+			"max results": {MaxResultsPerRun: -1},
+		} {
+			_, err := server.SearchRuns(ctx, req)
+			assert.True(t, errcode.Is(err, errcode.InvalidArgument), name)
+		}
+	})
+
+	t.Run("requires a search repository", func(t *testing.T) {
+		_, err := NewRunServiceServer(RunServiceDeps{}, zerolog.Nop()).SearchRuns(ctx, &conductorv1.SearchRunsRequest{})
+		assert.True(t, errcode.Is(err, errcode.NotConfigured))
+	})
+}
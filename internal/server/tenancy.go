@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// tenancyCheck fails if a resource a request names is outside the projects
+// the context is scoped to.
+type tenancyCheck func(ctx context.Context, req any) error
+
+// TenancyInterceptor rejects calls naming a service, run, agent,
+// notification channel or rule outside the caller's projects. Repositories
+// only scope the resources that belong to projects, so resources derived
+// from them, such as test definitions, schedules and deploy keys, are
+// protected by checking the resource they belong to before the call.
+type TenancyInterceptor struct {
+	checks []tenancyCheck
+}
+
+// NewTenancyInterceptor creates a tenancy interceptor. Its checks are set
+// once the servers whose repositories they query are created.
+func NewTenancyInterceptor() *TenancyInterceptor {
+	return &TenancyInterceptor{}
+}
+
+// Unary returns a unary server interceptor enforcing project membership.
+func (t *TenancyInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if _, scoped := database.ProjectScope(ctx); scoped {
+			for _, check := range t.checks {
+				if err := check(ctx, req); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// tenancyChecks looks up the service, run, agent, channels and rule a
+// request names with the repositories of the servers. Malformed IDs are
+// left to the handlers to reject.
+func tenancyChecks(services *ServiceRegistryServer, runs *RunServiceServer, agents *AgentManagementServer, notifications *NotificationServiceServer) []tenancyCheck {
+	resolve := func(ctx context.Context, id string, code errcode.Code, kind string, get func(context.Context, uuid.UUID) error) error {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil
+		}
+		if err := get(ctx, parsed); err != nil {
+			if database.IsNotFound(err) {
+				return errcode.New(code, "%s not found: %s", kind, id)
+			}
+			return errcode.New(errcode.Internal, "failed to get %s: %v", kind, err)
+		}
+		return nil
+	}
+
+	return []tenancyCheck{
+		func(ctx context.Context, req any) error {
+			r, _ := req.(interface{ GetServiceId() string })
+			if r == nil || r.GetServiceId() == "" || services.deps.ServiceRepo == nil {
+				return nil
+			}
+			return resolve(ctx, r.GetServiceId(), errcode.ServiceNotFound, "service", func(ctx context.Context, id uuid.UUID) error {
+				_, err := services.deps.ServiceRepo.GetByID(ctx, id)
+				return err
+			})
+		},
+		func(ctx context.Context, req any) error {
+			r, _ := req.(interface{ GetRunId() string })
+			if r == nil || r.GetRunId() == "" || runs.deps.RunRepo == nil {
+				return nil
+			}
+			return resolve(ctx, r.GetRunId(), errcode.RunNotFound, "run", func(ctx context.Context, id uuid.UUID) error {
+				_, err := runs.deps.RunRepo.GetByID(ctx, id)
+				return err
+			})
+		},
+		func(ctx context.Context, req any) error {
+			r, _ := req.(interface{ GetAgentId() string })
+			if r == nil || r.GetAgentId() == "" || agents.deps.AgentRepo == nil {
+				return nil
+			}
+			return resolve(ctx, r.GetAgentId(), errcode.AgentNotFound, "agent", func(ctx context.Context, id uuid.UUID) error {
+				_, err := agents.deps.AgentRepo.GetByID(ctx, id)
+				return err
+			})
+		},
+		func(ctx context.Context, req any) error {
+			if notifications.deps.Repo == nil {
+				return nil
+			}
+			var ids []string
+			if r, ok := req.(interface{ GetChannelId() string }); ok && r.GetChannelId() != "" {
+				ids = append(ids, r.GetChannelId())
+			}
+			if r, ok := req.(interface{ GetChannelIds() []string }); ok {
+				ids = append(ids, r.GetChannelIds()...)
+			}
+			for _, channelID := range ids {
+				err := resolve(ctx, channelID, errcode.ChannelNotFound, "channel", func(ctx context.Context, id uuid.UUID) error {
+					_, err := notifications.deps.Repo.GetChannel(ctx, id)
+					return err
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func(ctx context.Context, req any) error {
+			r, _ := req.(interface{ GetRuleId() string })
+			if r == nil || r.GetRuleId() == "" || notifications.deps.Repo == nil {
+				return nil
+			}
+			return resolve(ctx, r.GetRuleId(), errcode.RuleNotFound, "rule", func(ctx context.Context, id uuid.UUID) error {
+				_, err := notifications.deps.Repo.GetRule(ctx, id)
+				return err
+			})
+		},
+	}
+}
+
+// projectForCreate returns the project a resource created by the caller
+// belongs to: the requested one, or without a request the caller's first
+// project, or the default project for unscoped callers. The caller must be
+// a member of the project.
+func projectForCreate(ctx context.Context, requested string) (uuid.UUID, error) {
+	scope, scoped := database.ProjectScope(ctx)
+	if requested == "" {
+		if !scoped {
+			return database.DefaultProjectID, nil
+		}
+		if len(scope) == 0 {
+			return uuid.Nil, errcode.New(errcode.PermissionDenied, "the caller is not a member of any project")
+		}
+		return scope[0], nil
+	}
+
+	projectID, err := uuid.Parse(requested)
+	if err != nil {
+		return uuid.Nil, errcode.New(errcode.InvalidArgument, "invalid project ID: %v", err)
+	}
+	if !database.InProjectScope(ctx, projectID) {
+		return uuid.Nil, errcode.New(errcode.PermissionDenied, "the caller is not a member of project %s", requested)
+	}
+	return projectID, nil
+}
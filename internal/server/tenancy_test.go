@@ -0,0 +1,258 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// scopedServiceRepo stores services in memory and hides the services of
+// projects outside the scope of the context like the database does.
+type scopedServiceRepo struct {
+	FullServiceRepository
+	services map[uuid.UUID]*database.Service
+}
+
+func (r *scopedServiceRepo) GetByID(ctx context.Context, id uuid.UUID) (*database.Service, error) {
+	svc, ok := r.services[id]
+	if !ok || !database.InProjectScope(ctx, svc.ProjectID) {
+		return nil, database.ErrNotFound
+	}
+	return svc, nil
+}
+
+func (r *scopedServiceRepo) Create(ctx context.Context, svc *database.Service) error {
+	r.services[svc.ID] = svc
+	return nil
+}
+
+// memoryTenancyRepo stores organizations and projects in memory.
+type memoryTenancyRepo struct {
+	orgs     []database.Organization
+	projects []database.Project
+}
+
+func (r *memoryTenancyRepo) Create(ctx context.Context, org *database.Organization) error {
+	for _, existing := range r.orgs {
+		if existing.Name == org.Name {
+			return fmt.Errorf("%w: name %s", database.ErrDuplicate, org.Name)
+		}
+	}
+	org.ID = uuid.New()
+	r.orgs = append(r.orgs, *org)
+	return nil
+}
+
+func (r *memoryTenancyRepo) Get(ctx context.Context, id uuid.UUID) (*database.Organization, error) {
+	for _, org := range r.orgs {
+		if org.ID == id {
+			return &org, nil
+		}
+	}
+	return nil, database.ErrNotFound
+}
+
+func (r *memoryTenancyRepo) List(ctx context.Context, page database.Pagination) ([]database.Organization, int, error) {
+	return r.orgs, len(r.orgs), nil
+}
+
+// memoryProjectRepo adapts memoryTenancyRepo to database.ProjectRepository.
+type memoryProjectRepo struct {
+	*memoryTenancyRepo
+}
+
+func (r memoryProjectRepo) Create(ctx context.Context, project *database.Project) error {
+	project.ID = uuid.New()
+	r.projects = append(r.projects, *project)
+	return nil
+}
+
+func (r memoryProjectRepo) Get(ctx context.Context, id uuid.UUID) (*database.Project, error) {
+	return nil, database.ErrNotFound
+}
+
+func (r memoryProjectRepo) ListByOrganization(ctx context.Context, orgID uuid.UUID, page database.Pagination) ([]database.Project, int, error) {
+	var projects []database.Project
+	for _, p := range r.projects {
+		if p.OrganizationID == orgID && database.InProjectScope(ctx, p.ID) {
+			projects = append(projects, p)
+		}
+	}
+	return projects, len(projects), nil
+}
+
+func TestUserClaimsProjectScope(t *testing.T) {
+	project := uuid.New()
+
+	scope, scoped := (&UserClaims{Roles: []string{"admin"}, Projects: []string{project.String()}}).ProjectScope()
+	assert.False(t, scoped, "admins see every project")
+	assert.Nil(t, scope)
+
+	scope, scoped = (&UserClaims{}).ProjectScope()
+	assert.True(t, scoped)
+	assert.Equal(t, []uuid.UUID{database.DefaultProjectID}, scope, "tokens without projects belong to the default project")
+
+	scope, scoped = (&UserClaims{Projects: []string{project.String(), "not-a-uuid"}}).ProjectScope()
+	assert.True(t, scoped)
+	assert.Equal(t, []uuid.UUID{project}, scope)
+}
+
+func TestJWTValidator_ProjectClaims(t *testing.T) {
+	validator := NewJWTValidator("secret")
+	project := uuid.New().String()
+	token, err := validator.GenerateToken(&UserClaims{
+		UserID:       "u-1",
+		IssuedAt:     time.Now(),
+		ExpiresAt:    time.Now().Add(time.Hour),
+		Organization: database.DefaultOrganizationID.String(),
+		Projects:     []string{project},
+	})
+	require.NoError(t, err)
+
+	claims, err := validator.Validate(token)
+	require.NoError(t, err)
+	assert.Equal(t, database.DefaultOrganizationID.String(), claims.Organization)
+	assert.Equal(t, []string{project}, claims.Projects)
+
+	ctx := withUser(context.Background(), claims)
+	scope, scoped := database.ProjectScope(ctx)
+	assert.True(t, scoped)
+	assert.Equal(t, []uuid.UUID{uuid.MustParse(project)}, scope)
+}
+
+func TestTenancyInterceptor(t *testing.T) {
+	own, other := uuid.New(), uuid.New()
+	ownService := &database.Service{ID: uuid.New(), ProjectID: own}
+	otherService := &database.Service{ID: uuid.New(), ProjectID: other}
+	services := NewServiceRegistryServer(ServiceRegistryDeps{
+		ServiceRepo: &scopedServiceRepo{services: map[uuid.UUID]*database.Service{
+			ownService.ID:   ownService,
+			otherService.ID: otherService,
+		}},
+	}, zerolog.Nop())
+
+	interceptor := NewTenancyInterceptor()
+	interceptor.checks = tenancyChecks(services,
+		NewRunServiceServer(RunServiceDeps{}, zerolog.Nop()),
+		NewAgentManagementServer(AgentServiceDeps{}, zerolog.Nop()),
+		NewNotificationServiceServer(NotificationServiceDeps{}, zerolog.Nop()))
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/conductor.v1.ServiceRegistryService/ListSchedules"}
+	call := func(ctx context.Context, serviceID uuid.UUID) (bool, error) {
+		called := false
+		_, err := interceptor.Unary()(ctx, &conductorv1.ListSchedulesRequest{ServiceId: serviceID.String()}, info,
+			func(ctx context.Context, req any) (any, error) {
+				called = true
+				return nil, nil
+			})
+		return called, err
+	}
+
+	scoped := database.WithProjectScope(context.Background(), []uuid.UUID{own})
+
+	called, err := call(scoped, ownService.ID)
+	require.NoError(t, err)
+	assert.True(t, called)
+
+	called, err = call(scoped, otherService.ID)
+	assert.Equal(t, errcode.ServiceNotFound, errcode.FromError(err), "resources of other projects are reported as not found")
+	assert.False(t, called)
+
+	called, err = call(context.Background(), otherService.ID)
+	require.NoError(t, err, "unscoped calls are not checked")
+	assert.True(t, called)
+}
+
+func TestCreateService_Project(t *testing.T) {
+	own, other := uuid.New(), uuid.New()
+	repo := &scopedServiceRepo{services: map[uuid.UUID]*database.Service{}}
+	server := NewServiceRegistryServer(ServiceRegistryDeps{ServiceRepo: repo}, zerolog.Nop())
+	scoped := database.WithProjectScope(context.Background(), []uuid.UUID{own, other})
+
+	resp, err := server.CreateService(scoped, &conductorv1.CreateServiceRequest{Name: "api", GitUrl: "https://example.com/api.git"})
+	require.NoError(t, err)
+	assert.Equal(t, own.String(), resp.Service.ProjectId, "the caller's first project is the default")
+
+	resp, err = server.CreateService(scoped, &conductorv1.CreateServiceRequest{Name: "web", GitUrl: "https://example.com/web.git", ProjectId: other.String()})
+	require.NoError(t, err)
+	assert.Equal(t, other.String(), resp.Service.ProjectId)
+
+	_, err = server.CreateService(scoped, &conductorv1.CreateServiceRequest{Name: "cli", GitUrl: "https://example.com/cli.git", ProjectId: uuid.NewString()})
+	assert.Equal(t, errcode.PermissionDenied, errcode.FromError(err))
+
+	resp, err = server.CreateService(context.Background(), &conductorv1.CreateServiceRequest{Name: "batch", GitUrl: "https://example.com/batch.git"})
+	require.NoError(t, err)
+	assert.Equal(t, database.DefaultProjectID.String(), resp.Service.ProjectId)
+}
+
+func TestTenancyService(t *testing.T) {
+	repo := &memoryTenancyRepo{orgs: []database.Organization{{ID: database.DefaultOrganizationID, Name: "default"}}}
+	server := NewTenancyServiceServer(TenancyServiceDeps{
+		OrganizationRepo: repo,
+		ProjectRepo:      memoryProjectRepo{repo},
+	}, zerolog.Nop())
+
+	admin := withUser(context.Background(), &UserClaims{UserID: "admin", Roles: []string{"admin"}})
+
+	t.Run("creating requires admin", func(t *testing.T) {
+		user := withUser(context.Background(), &UserClaims{UserID: "u-1"})
+		_, err := server.CreateOrganization(user, &conductorv1.CreateOrganizationRequest{Name: "acme"})
+		assert.Equal(t, errcode.PermissionDenied, errcode.FromError(err))
+
+		_, err = server.CreateProject(user, &conductorv1.CreateProjectRequest{OrganizationId: database.DefaultOrganizationID.String(), Name: "payments"})
+		assert.Equal(t, errcode.PermissionDenied, errcode.FromError(err))
+	})
+
+	var acme *conductorv1.Organization
+	t.Run("creates organizations and projects", func(t *testing.T) {
+		resp, err := server.CreateOrganization(admin, &conductorv1.CreateOrganizationRequest{Name: "acme", DisplayName: "Acme Corp"})
+		require.NoError(t, err)
+		acme = resp.Organization
+		assert.Equal(t, "Acme Corp", acme.DisplayName)
+
+		_, err = server.CreateOrganization(admin, &conductorv1.CreateOrganizationRequest{Name: "acme"})
+		assert.Equal(t, errcode.OrganizationAlreadyExists, errcode.FromError(err))
+
+		_, err = server.CreateProject(admin, &conductorv1.CreateProjectRequest{OrganizationId: uuid.NewString(), Name: "payments"})
+		assert.Equal(t, errcode.OrganizationNotFound, errcode.FromError(err))
+
+		for _, name := range []string{"payments", "search"} {
+			_, err = server.CreateProject(admin, &conductorv1.CreateProjectRequest{OrganizationId: acme.Id, Name: name})
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("members see their organization and projects", func(t *testing.T) {
+		payments := repo.projects[0].ID.String()
+		member := withUser(context.Background(), &UserClaims{UserID: "u-1", Organization: acme.Id, Projects: []string{payments}})
+
+		orgs, err := server.ListOrganizations(member, &conductorv1.ListOrganizationsRequest{})
+		require.NoError(t, err)
+		require.Len(t, orgs.Organizations, 1)
+		assert.Equal(t, "acme", orgs.Organizations[0].Name)
+
+		projects, err := server.ListProjects(member, &conductorv1.ListProjectsRequest{OrganizationId: acme.Id})
+		require.NoError(t, err)
+		require.Len(t, projects.Projects, 1)
+		assert.Equal(t, "payments", projects.Projects[0].Name)
+
+		orgs, err = server.ListOrganizations(admin, &conductorv1.ListOrganizationsRequest{})
+		require.NoError(t, err)
+		assert.Len(t, orgs.Organizations, 2)
+
+		projects, err = server.ListProjects(admin, &conductorv1.ListProjectsRequest{OrganizationId: acme.Id})
+		require.NoError(t, err)
+		assert.Len(t, projects.Projects, 2)
+	})
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// TriggerRuleRepository defines the interface for looking up a service's
+// trigger rules in webhooks.
+type TriggerRuleRepository interface {
+	GetByService(ctx context.Context, serviceID uuid.UUID) (*database.ServiceTriggerRules, error)
+}
+
+// webhookTrigger describes a webhook event that may trigger a run.
+type webhookTrigger struct {
+	repoFullName string
+	owner        string
+	repo         string
+	// ref is the branch, or the tag name for tag pushes.
+	ref string
+	tag bool
+	sha string
+	// base is the previous head of a push or the target branch of a pull
+	// request, used to list changed files through the provider API. It is
+	// empty when unknown.
+	base        string
+	triggeredBy string
+	// pullRequest is the PR/MR number, or 0 for pushes.
+	pullRequest int
+	// message is the head commit message or the pull request title.
+	message string
+	// changedPaths lists the files changed by a push. It is only
+	// meaningful when pathsKnown is set, since not every provider
+	// reports them.
+	changedPaths []string
+	pathsKnown   bool
+}
+
+// triggerRules are a service's trigger rules with the patterns compiled.
+type triggerRules struct {
+	branches       []*regexp.Regexp
+	ignoreBranches []*regexp.Regexp
+	paths          []*regexp.Regexp
+	ignorePaths    []*regexp.Regexp
+	tags           []*regexp.Regexp
+	skipMarkers    []string
+}
+
+func compileTriggerRules(rules *database.ServiceTriggerRules) (*triggerRules, error) {
+	var err error
+	compiled := &triggerRules{}
+	if compiled.branches, err = compileGlobs(rules.Branches, false); err != nil {
+		return nil, err
+	}
+	if compiled.ignoreBranches, err = compileGlobs(rules.IgnoreBranches, false); err != nil {
+		return nil, err
+	}
+	if compiled.paths, err = compileGlobs(rules.Paths, true); err != nil {
+		return nil, err
+	}
+	if compiled.ignorePaths, err = compileGlobs(rules.IgnorePaths, true); err != nil {
+		return nil, err
+	}
+	if compiled.tags, err = compileGlobs(rules.Tags, false); err != nil {
+		return nil, err
+	}
+	for _, marker := range rules.SkipMarkers {
+		compiled.skipMarkers = append(compiled.skipMarkers, strings.ToLower(marker))
+	}
+	return compiled, nil
+}
+
+// skipReason returns why the trigger must not start a run, or "" if it may.
+// Nil rules keep the default behavior: every branch push and pull request
+// runs and tag pushes are ignored.
+func (r *triggerRules) skipReason(t webhookTrigger) string {
+	if r == nil {
+		if t.tag {
+			return "tag pushes are not enabled"
+		}
+		return ""
+	}
+
+	message := strings.ToLower(t.message)
+	for _, marker := range r.skipMarkers {
+		if strings.Contains(message, marker) {
+			return "skip marker in commit message"
+		}
+	}
+
+	if t.tag {
+		if !matchesAny(r.tags, t.ref) {
+			return "tag does not match trigger rules"
+		}
+		return ""
+	}
+
+	if len(r.branches) > 0 && !matchesAny(r.branches, t.ref) {
+		return "branch does not match trigger rules"
+	}
+	if matchesAny(r.ignoreBranches, t.ref) {
+		return "branch is ignored by trigger rules"
+	}
+
+	if !t.pathsKnown || (len(r.paths) == 0 && len(r.ignorePaths) == 0) {
+		return ""
+	}
+	for _, p := range t.changedPaths {
+		p = strings.TrimPrefix(p, "/")
+		if matchesAny(r.ignorePaths, p) {
+			continue
+		}
+		if len(r.paths) == 0 || matchesAny(r.paths, p) {
+			return ""
+		}
+	}
+	return "no changed paths match trigger rules"
+}
+
+func matchesAny(patterns []*regexp.Regexp, value string) bool {
+	for _, re := range patterns {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func compileGlobs(patterns []string, isPath bool) ([]*regexp.Regexp, error) {
+	result := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := compileGlob(pattern, isPath)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, re)
+	}
+	return result, nil
+}
+
+// compileGlob converts a glob into an anchored regular expression. "*" and
+// "?" match within a path segment, "**" matches across segments. For paths a
+// leading slash is dropped, a trailing slash matches everything below, and
+// patterns without a slash match the file name in any directory, as in
+// .gitignore.
+func compileGlob(pattern string, isPath bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	if isPath {
+		pattern = strings.TrimPrefix(pattern, "/")
+		if strings.HasSuffix(pattern, "/") {
+			pattern += "**"
+		}
+		if !strings.Contains(pattern, "/") {
+			b.WriteString("(?:.*/)?")
+		}
+	}
+
+	glob := []rune(pattern)
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*' && i+1 < len(glob) && glob[i+1] == '*':
+			i++
+			if i+1 < len(glob) && glob[i+1] == '/' {
+				// "**/" also matches no directories at all.
+				i++
+				b.WriteString("(?:.*/)?")
+			} else {
+				b.WriteString(".*")
+			}
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// validateTriggerRules checks that every pattern and marker is usable.
+func validateTriggerRules(rules *database.ServiceTriggerRules) error {
+	fields := []struct {
+		name     string
+		patterns []string
+	}{
+		{"branches", rules.Branches},
+		{"ignore_branches", rules.IgnoreBranches},
+		{"paths", rules.Paths},
+		{"ignore_paths", rules.IgnorePaths},
+		{"tags", rules.Tags},
+		{"skip_markers", rules.SkipMarkers},
+	}
+	for _, field := range fields {
+		for _, pattern := range field.patterns {
+			if strings.TrimSpace(pattern) == "" {
+				return errcode.New(errcode.InvalidArgument, "%s: empty pattern", field.name)
+			}
+		}
+	}
+
+	if _, err := compileTriggerRules(rules); err != nil {
+		return errcode.New(errcode.InvalidArgument, "invalid pattern: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// mockTriggerRuleRepo implements TriggerRuleRepository for testing.
+type mockTriggerRuleRepo struct {
+	rules map[uuid.UUID]*database.ServiceTriggerRules
+}
+
+func (m *mockTriggerRuleRepo) GetByService(ctx context.Context, serviceID uuid.UUID) (*database.ServiceTriggerRules, error) {
+	if rules, ok := m.rules[serviceID]; ok {
+		return rules, nil
+	}
+	return nil, database.ErrNotFound
+}
+
+func TestCompileGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		isPath  bool
+		value   string
+		want    bool
+	}{
+		{"main", false, "main", true},
+		{"main", false, "main2", false},
+		{"release/*", false, "release/1.0", true},
+		{"release/*", false, "release/1.0/hotfix", false},
+		{"feature/**", false, "feature/a/b", true},
+		{"v?.*", false, "v1.2", true},
+		{"v?.*", false, "v10.2", false},
+		{"docs/", true, "docs/guide/intro.md", true},
+		{"*.md", true, "README.md", true},
+		{"*.md", true, "docs/guide/intro.md", true},
+		{"/src/*.go", true, "src/main.go", true},
+		{"src/*.go", true, "src/pkg/main.go", false},
+		{"src/**/*.go", true, "src/main.go", true},
+		{"src/**/*.go", true, "src/pkg/sub/main.go", true},
+		{"**/testdata/**", true, "internal/git/testdata/a.json", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.value, func(t *testing.T) {
+			re, err := compileGlob(tt.pattern, tt.isPath)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, re.MatchString(tt.value))
+		})
+	}
+}
+
+func TestTriggerRulesSkipReason(t *testing.T) {
+	rules, err := compileTriggerRules(&database.ServiceTriggerRules{
+		Branches:       []string{"main", "release/*"},
+		IgnoreBranches: []string{"release/old"},
+		Paths:          []string{"src/**", "go.mod"},
+		IgnorePaths:    []string{"*.md"},
+		Tags:           []string{"v*"},
+		SkipMarkers:    []string{"[skip ci]"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		trigger webhookTrigger
+		skip    bool
+	}{
+		{
+			name:    "matching branch",
+			trigger: webhookTrigger{ref: "main"},
+		},
+		{
+			name:    "unmatched branch",
+			trigger: webhookTrigger{ref: "feature/x"},
+			skip:    true,
+		},
+		{
+			name:    "ignored branch",
+			trigger: webhookTrigger{ref: "release/old"},
+			skip:    true,
+		},
+		{
+			name:    "skip marker is case insensitive",
+			trigger: webhookTrigger{ref: "main", message: "Fix typo [SKIP CI]"},
+			skip:    true,
+		},
+		{
+			name:    "skip marker in pull request title",
+			trigger: webhookTrigger{ref: "main", pullRequest: 4, message: "WIP [skip ci]"},
+			skip:    true,
+		},
+		{
+			name:    "matching tag",
+			trigger: webhookTrigger{ref: "v1.2.0", tag: true},
+		},
+		{
+			name:    "unmatched tag",
+			trigger: webhookTrigger{ref: "nightly", tag: true},
+			skip:    true,
+		},
+		{
+			name:    "matching changed path",
+			trigger: webhookTrigger{ref: "main", changedPaths: []string{"README.md", "src/app.go"}, pathsKnown: true},
+		},
+		{
+			name:    "only ignored paths changed",
+			trigger: webhookTrigger{ref: "main", changedPaths: []string{"README.md", "src/README.md"}, pathsKnown: true},
+			skip:    true,
+		},
+		{
+			name:    "no changed path matches",
+			trigger: webhookTrigger{ref: "main", changedPaths: []string{"scripts/build.sh"}, pathsKnown: true},
+			skip:    true,
+		},
+		{
+			name:    "unknown changed paths run",
+			trigger: webhookTrigger{ref: "main"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := rules.skipReason(tt.trigger)
+			assert.Equal(t, tt.skip, reason != "", "reason: %q", reason)
+		})
+	}
+}
+
+func TestTriggerRulesSkipReason_NoRules(t *testing.T) {
+	var rules *triggerRules
+
+	assert.Empty(t, rules.skipReason(webhookTrigger{ref: "feature/x", message: "[skip ci]"}))
+	assert.NotEmpty(t, rules.skipReason(webhookTrigger{ref: "v1.0.0", tag: true}))
+}
+
+func TestValidateTriggerRules(t *testing.T) {
+	assert.NoError(t, validateTriggerRules(&database.ServiceTriggerRules{
+		Branches: []string{"main", "release/**"},
+		Paths:    []string{"src/"},
+	}))
+
+	err := validateTriggerRules(&database.ServiceTriggerRules{Paths: []string{"src/", " "}})
+	require.Error(t, err)
+	assert.True(t, errcode.Is(err, errcode.InvalidArgument))
+}
+
+func TestHandleGitHubWebhook_TriggerRules(t *testing.T) {
+	serviceID := uuid.New()
+	serviceRepo := &mockServiceRepo{services: []database.Service{
+		{ID: serviceID, Name: "test-service", GitURL: "https://github.com/owner/repo"},
+	}}
+	ruleRepo := &mockTriggerRuleRepo{rules: map[uuid.UUID]*database.ServiceTriggerRules{
+		serviceID: {
+			ServiceID:   serviceID,
+			Paths:       []string{"src/**"},
+			Tags:        []string{"v*"},
+			SkipMarkers: []string{"[skip ci]"},
+		},
+	}}
+
+	tests := []struct {
+		name    string
+		payload string
+		wantRef string
+	}{
+		{
+			name:    "matching path",
+			payload: `{"ref":"refs/heads/main","after":"abc123","head_commit":{"message":"Update app"},"commits":[{"id":"abc123","modified":["src/app.go"]}],"repository":{"name":"repo","full_name":"owner/repo","owner":{"login":"owner"}}}`,
+			wantRef: "main",
+		},
+		{
+			name:    "unmatched path",
+			payload: `{"ref":"refs/heads/main","after":"abc123","head_commit":{"message":"Update docs"},"commits":[{"id":"abc123","modified":["docs/index.md"]}],"repository":{"name":"repo","full_name":"owner/repo","owner":{"login":"owner"}}}`,
+		},
+		{
+			name:    "skip marker",
+			payload: `{"ref":"refs/heads/main","after":"abc123","head_commit":{"message":"Bump version [skip ci]"},"commits":[{"id":"abc123","modified":["src/app.go"]}],"repository":{"name":"repo","full_name":"owner/repo","owner":{"login":"owner"}}}`,
+		},
+		{
+			name:    "tag push",
+			payload: `{"ref":"refs/tags/v1.0.0","after":"abc123","repository":{"name":"repo","full_name":"owner/repo","owner":{"login":"owner"}}}`,
+			wantRef: "v1.0.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler := &mockScheduler{}
+			handler := NewWebhookHandler(WebhookConfig{GithubSecret: "test-secret"}, serviceRepo, scheduler, zerolog.Nop())
+			handler.SetTriggerRules(ruleRepo)
+
+			mac := hmac.New(sha256.New, []byte("test-secret"))
+			mac.Write([]byte(tt.payload))
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader([]byte(tt.payload)))
+			req.Header.Set("X-GitHub-Event", "push")
+			req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+			rr := httptest.NewRecorder()
+			handler.HandleGitHubWebhook(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			if tt.wantRef == "" {
+				assert.Empty(t, scheduler.requests)
+				return
+			}
+			require.Len(t, scheduler.requests, 1)
+			assert.Equal(t, tt.wantRef, scheduler.requests[0].GitRef)
+		})
+	}
+}
+
+func TestHandleGitHubWebhook_TagPushWithoutRules(t *testing.T) {
+	serviceRepo := &mockServiceRepo{services: []database.Service{
+		{ID: uuid.New(), Name: "test-service", GitURL: "https://github.com/owner/repo"},
+	}}
+	scheduler := &mockScheduler{}
+	handler := NewWebhookHandler(WebhookConfig{}, serviceRepo, scheduler, zerolog.Nop())
+	handler.SetTriggerRules(&mockTriggerRuleRepo{})
+
+	payload := `{"ref":"refs/tags/v1.0.0","after":"abc123","repository":{"name":"repo","full_name":"owner/repo","owner":{"login":"owner"}}}`
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader([]byte(payload)))
+	req.Header.Set("X-GitHub-Event", "push")
+	rr := httptest.NewRecorder()
+	handler.HandleGitHubWebhook(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, scheduler.requests)
+}
+
+func TestChangedPaths(t *testing.T) {
+	commits := []webhookCommit{
+		{ID: "a", Added: []string{"src/new.go"}, Modified: []string{"go.mod"}},
+		{ID: "b", Modified: []string{"go.mod"}, Removed: []string{"old.go"}},
+	}
+
+	paths, known := changedPaths(commits, 2)
+	assert.True(t, known)
+	assert.Equal(t, []string{"src/new.go", "go.mod", "old.go"}, paths)
+
+	_, known = changedPaths(commits, 30)
+	assert.False(t, known, "truncated commit list")
+
+	_, known = changedPaths(nil, -1)
+	assert.False(t, known)
+}
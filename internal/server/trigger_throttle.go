@@ -0,0 +1,194 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/conductor/conductor/pkg/metrics"
+)
+
+// maxThrottledBranches bounds the number of branches tracked by a throttle.
+const maxThrottledBranches = 10000
+
+// Outcomes of a throttled trigger, used as the metric label.
+const (
+	throttleCoalesced = "coalesced"
+	throttleRejected  = "rejected"
+)
+
+// TriggerThrottle limits how many runs are triggered per service and branch
+// within a window. Webhook triggers beyond the limit are coalesced: only the
+// latest one is kept and started once the window allows it, so a burst of
+// pushes results in one run of the newest commit.
+type TriggerThrottle struct {
+	mu       sync.Mutex
+	runs     int
+	window   time.Duration
+	branches map[string]*throttledBranch
+	now      func() time.Time
+	stopped  bool
+	metrics  *metrics.ControlPlaneMetrics
+}
+
+type throttledBranch struct {
+	// started holds the times of runs triggered within the window, oldest first.
+	started []time.Time
+	// pending is the latest coalesced trigger, started when timer fires.
+	pending func()
+	timer   *time.Timer
+}
+
+// NewTriggerThrottle creates a throttle allowing runs per window for each
+// service and branch. Metrics are optional.
+func NewTriggerThrottle(runs int, window time.Duration, m *metrics.ControlPlaneMetrics) *TriggerThrottle {
+	return &TriggerThrottle{
+		runs:     runs,
+		window:   window,
+		branches: make(map[string]*throttledBranch),
+		now:      time.Now,
+		metrics:  m,
+	}
+}
+
+// throttleKey identifies the branch a trigger counts against. Pull requests
+// are tracked apart from pushes to their source branch.
+func throttleKey(serviceID uuid.UUID, ref string, pullRequest int) string {
+	return fmt.Sprintf("%s/%d/%s", serviceID, pullRequest, ref)
+}
+
+// Allow reserves a run for the key if the window permits one. Otherwise it
+// returns how long until the next run is allowed.
+func (t *TriggerThrottle) Allow(key string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.branch(key)
+	// A coalesced trigger already waiting takes the next slot.
+	if wait := t.wait(b); wait > 0 || b.pending != nil {
+		t.record(throttleRejected)
+		return wait, false
+	}
+	b.started = append(b.started, t.now())
+	return 0, true
+}
+
+// Coalesce reserves a run for the key and reports true if start should be
+// called right away. Otherwise start replaces any trigger already waiting
+// for the key and is called in its own goroutine once the window allows.
+func (t *TriggerThrottle) Coalesce(key string, start func()) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.branch(key)
+	wait := t.wait(b)
+	if wait == 0 && b.pending == nil {
+		b.started = append(b.started, t.now())
+		return true
+	}
+
+	t.record(throttleCoalesced)
+	b.pending = start
+	if b.timer == nil && !t.stopped {
+		b.timer = time.AfterFunc(wait, func() { t.fire(key) })
+	}
+	return false
+}
+
+// Stop cancels all waiting triggers. It returns how many were dropped.
+func (t *TriggerThrottle) Stop() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stopped = true
+	dropped := 0
+	for _, b := range t.branches {
+		if b.timer != nil {
+			b.timer.Stop()
+			b.timer = nil
+		}
+		if b.pending != nil {
+			b.pending = nil
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// fire starts the coalesced trigger for the key, or re-arms the timer if the
+// window is still full.
+func (t *TriggerThrottle) fire(key string) {
+	t.mu.Lock()
+	b, ok := t.branches[key]
+	if !ok || b.pending == nil || t.stopped {
+		if ok {
+			b.timer = nil
+		}
+		t.mu.Unlock()
+		return
+	}
+
+	b = t.branch(key)
+	if wait := t.wait(b); wait > 0 {
+		b.timer = time.AfterFunc(wait, func() { t.fire(key) })
+		t.mu.Unlock()
+		return
+	}
+
+	start := b.pending
+	b.pending = nil
+	b.timer = nil
+	b.started = append(b.started, t.now())
+	t.mu.Unlock()
+
+	go start()
+}
+
+func (t *TriggerThrottle) record(outcome string) {
+	if t.metrics != nil {
+		t.metrics.RecordTriggerThrottled(outcome)
+	}
+}
+
+// branch returns the state for key, dropping runs that left the window.
+// Callers must hold the lock.
+func (t *TriggerThrottle) branch(key string) *throttledBranch {
+	b, ok := t.branches[key]
+	if !ok {
+		if len(t.branches) >= maxThrottledBranches {
+			t.prune()
+		}
+		b = &throttledBranch{}
+		t.branches[key] = b
+	}
+
+	cutoff := t.now().Add(-t.window)
+	i := 0
+	for i < len(b.started) && !b.started[i].After(cutoff) {
+		i++
+	}
+	b.started = b.started[i:]
+	return b
+}
+
+// wait returns how long until the branch may start another run. Callers
+// must hold the lock and have trimmed the branch.
+func (t *TriggerThrottle) wait(b *throttledBranch) time.Duration {
+	if len(b.started) < t.runs {
+		return 0
+	}
+	return b.started[len(b.started)-t.runs].Add(t.window).Sub(t.now())
+}
+
+// prune forgets branches without runs in the window or waiting triggers.
+// Callers must hold the lock.
+func (t *TriggerThrottle) prune() {
+	cutoff := t.now().Add(-t.window)
+	for key, b := range t.branches {
+		if b.pending == nil && (len(b.started) == 0 || !b.started[len(b.started)-1].After(cutoff)) {
+			delete(t.branches, key)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// syncScheduler is a RunScheduler safe for use by coalesced triggers.
+type syncScheduler struct {
+	mu       sync.Mutex
+	requests []ScheduleRunRequest
+}
+
+func (s *syncScheduler) ScheduleRun(ctx context.Context, req ScheduleRunRequest) (*database.TestRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	return &database.TestRun{ID: uuid.New(), Status: database.RunStatusPending}, nil
+}
+
+func (s *syncScheduler) scheduled() []ScheduleRunRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ScheduleRunRequest(nil), s.requests...)
+}
+
+func TestTriggerThrottle_Allow(t *testing.T) {
+	throttle := NewTriggerThrottle(2, time.Minute, nil)
+	now := time.Now()
+	throttle.now = func() time.Time { return now }
+
+	_, ok := throttle.Allow("svc/0/main")
+	assert.True(t, ok)
+	now = now.Add(10 * time.Second)
+	_, ok = throttle.Allow("svc/0/main")
+	assert.True(t, ok)
+
+	wait, ok := throttle.Allow("svc/0/main")
+	assert.False(t, ok)
+	assert.Equal(t, 50*time.Second, wait)
+
+	_, ok = throttle.Allow("svc/0/develop")
+	assert.True(t, ok, "branches are throttled independently")
+
+	now = now.Add(51 * time.Second)
+	_, ok = throttle.Allow("svc/0/main")
+	assert.True(t, ok)
+}
+
+func TestTriggerThrottle_Coalesce(t *testing.T) {
+	throttle := NewTriggerThrottle(1, 50*time.Millisecond, nil)
+	defer throttle.Stop()
+
+	var mu sync.Mutex
+	var started []string
+	start := func(sha string) func() {
+		return func() {
+			mu.Lock()
+			defer mu.Unlock()
+			started = append(started, sha)
+		}
+	}
+
+	assert.True(t, throttle.Coalesce("key", start("a")))
+	assert.False(t, throttle.Coalesce("key", start("b")))
+	assert.False(t, throttle.Coalesce("key", start("c")))
+
+	_, ok := throttle.Allow("key")
+	assert.False(t, ok, "waiting trigger takes the next slot")
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(started) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	mu.Lock()
+	assert.Equal(t, []string{"c"}, started)
+	mu.Unlock()
+}
+
+func TestTriggerThrottle_StopDropsPending(t *testing.T) {
+	throttle := NewTriggerThrottle(1, time.Hour, nil)
+
+	assert.True(t, throttle.Coalesce("key", func() {}))
+	assert.False(t, throttle.Coalesce("key", func() { t.Error("dropped trigger started") }))
+	assert.Equal(t, 1, throttle.Stop())
+}
+
+func TestHandleGitHubWebhook_ThrottleCoalescesPushes(t *testing.T) {
+	serviceRepo := &mockServiceRepo{services: []database.Service{
+		{ID: uuid.New(), Name: "test-service", GitURL: "https://github.com/owner/repo"},
+	}}
+	scheduler := &syncScheduler{}
+	throttle := NewTriggerThrottle(1, 50*time.Millisecond, nil)
+	defer throttle.Stop()
+
+	handler := NewWebhookHandler(WebhookConfig{}, serviceRepo, scheduler, zerolog.Nop())
+	handler.SetThrottle(throttle)
+
+	for _, sha := range []string{"sha1", "sha2", "sha3"} {
+		payload := `{"ref":"refs/heads/main","after":"` + sha + `","repository":{"name":"repo","full_name":"owner/repo","owner":{"login":"owner"}}}`
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader([]byte(payload)))
+		req.Header.Set("X-GitHub-Event", "push")
+		rr := httptest.NewRecorder()
+		handler.HandleGitHubWebhook(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	require.Len(t, scheduler.scheduled(), 1)
+	assert.Equal(t, "sha1", scheduler.scheduled()[0].GitSHA)
+
+	require.Eventually(t, func() bool { return len(scheduler.scheduled()) == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "sha3", scheduler.scheduled()[1].GitSHA)
+	assert.Equal(t, "main", scheduler.scheduled()[1].GitRef)
+}
+
+// throttleRunRepo records created runs.
+type throttleRunRepo struct {
+	RunRepository
+	created int
+}
+
+func (r *throttleRunRepo) Create(ctx context.Context, run *database.TestRun) error {
+	r.created++
+	return nil
+}
+
+// throttleServiceRepo returns a fixed service.
+type throttleServiceRepo struct {
+	ServiceRepository
+	service *database.Service
+}
+
+func (r *throttleServiceRepo) GetByID(ctx context.Context, id uuid.UUID) (*database.Service, error) {
+	return r.service, nil
+}
+
+func TestCreateRun_Throttled(t *testing.T) {
+	service := &database.Service{ID: uuid.New(), Name: "test-service"}
+	runRepo := &throttleRunRepo{}
+	server := NewRunServiceServer(RunServiceDeps{
+		RunRepo:     runRepo,
+		ServiceRepo: &throttleServiceRepo{service: service},
+		Throttle:    NewTriggerThrottle(1, time.Minute, nil),
+	}, zerolog.Nop())
+
+	req := &conductorv1.CreateRunRequest{
+		ServiceId: service.ID.String(),
+		GitRef:    &conductorv1.GitRef{Branch: "main"},
+	}
+
+	_, err := server.CreateRun(context.Background(), req)
+	require.NoError(t, err)
+
+	_, err = server.CreateRun(context.Background(), req)
+	require.Error(t, err)
+	assert.True(t, errcode.Is(err, errcode.RunThrottled))
+	assert.Equal(t, "60", errcode.Metadata(err)["retry_after_seconds"])
+	assert.Equal(t, 1, runRepo.created)
+
+	req.GitRef.Branch = "develop"
+	_, err = server.CreateRun(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, runRepo.created)
+}
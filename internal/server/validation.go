@@ -0,0 +1,413 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+// requestField reads a string or string list field of the requests that
+// have it.
+type requestField struct {
+	get  func(req any) ([]string, bool)
+	list bool
+}
+
+func stringField[R any](get func(R) string) requestField {
+	return requestField{get: func(req any) ([]string, bool) {
+		r, ok := req.(R)
+		if !ok {
+			return nil, false
+		}
+		return []string{get(r)}, true
+	}}
+}
+
+func stringsField[R any](get func(R) []string) requestField {
+	return requestField{list: true, get: func(req any) ([]string, bool) {
+		r, ok := req.(R)
+		if !ok {
+			return nil, false
+		}
+		return get(r), true
+	}}
+}
+
+// requestFields are the fields of requests that are validated, by name.
+var requestFields = map[string]requestField{
+	"agent_id":           stringField(interface{ GetAgentId() string }.GetAgentId),
+	"artifact_id":        stringField(interface{ GetArtifactId() string }.GetArtifactId),
+	"channel_id":         stringField(interface{ GetChannelId() string }.GetChannelId),
+	"channel_ids":        stringsField(interface{ GetChannelIds() []string }.GetChannelIds),
+	"delivery_id":        stringField(interface{ GetDeliveryId() string }.GetDeliveryId),
+	"organization_id":    stringField(interface{ GetOrganizationId() string }.GetOrganizationId),
+	"project_id":         stringField(interface{ GetProjectId() string }.GetProjectId),
+	"rule_id":            stringField(interface{ GetRuleId() string }.GetRuleId),
+	"run_id":             stringField(interface{ GetRunId() string }.GetRunId),
+	"schedule_id":        stringField(interface{ GetScheduleId() string }.GetScheduleId),
+	"service_id":         stringField(interface{ GetServiceId() string }.GetServiceId),
+	"shard_id":           stringField(interface{ GetShardId() string }.GetShardId),
+	"test_definition_id": stringField(interface{ GetTestDefinitionId() string }.GetTestDefinitionId),
+	"view_id":            stringField(interface{ GetViewId() string }.GetViewId),
+	"window_id":          stringField(interface{ GetWindowId() string }.GetWindowId),
+
+	"agent_pool":   stringField(interface{ GetAgentPool() string }.GetAgentPool),
+	"command":      stringField(interface{ GetCommand() string }.GetCommand),
+	"display_name": stringField(interface{ GetDisplayName() string }.GetDisplayName),
+	"git_url":      stringField(interface{ GetGitUrl() string }.GetGitUrl),
+	"name":         stringField(interface{ GetName() string }.GetName),
+	"owner":        stringField(interface{ GetOwner() string }.GetOwner),
+	"page":         stringField(interface{ GetPage() string }.GetPage),
+	"pool":         stringField(interface{ GetPool() string }.GetPool),
+	"test_id":      stringField(interface{ GetTestId() string }.GetTestId),
+	"test_name":    stringField(interface{ GetTestName() string }.GetTestName),
+	"timezone":     stringField(interface{ GetTimezone() string }.GetTimezone),
+	"token":        stringField(interface{ GetToken() string }.GetToken),
+}
+
+// uuidFields are the fields that hold the IDs of resources and must be
+// UUIDs when set.
+var uuidFields = []string{
+	"agent_id", "artifact_id", "channel_id", "channel_ids", "delivery_id",
+	"organization_id", "project_id", "rule_id", "run_id", "schedule_id",
+	"service_id", "shard_id", "test_definition_id", "view_id", "window_id",
+}
+
+// maxFieldLengths are the lengths in characters of the columns fields are
+// stored in.
+var maxFieldLengths = map[string]int{
+	"agent_pool":   255,
+	"display_name": 255,
+	"name":         255,
+	"owner":        255,
+	"pool":         255,
+	"test_name":    512,
+	"timezone":     64,
+}
+
+// requiredFields are the fields each request must set. Blank strings and
+// empty lists are not set.
+var requiredFields = map[reflect.Type][]string{
+	// Services
+	reflect.TypeFor[*conductorv1.CreateServiceRequest]():            {"name", "git_url"},
+	reflect.TypeFor[*conductorv1.GetServiceRequest]():               {"service_id"},
+	reflect.TypeFor[*conductorv1.UpdateServiceRequest]():            {"service_id"},
+	reflect.TypeFor[*conductorv1.DeleteServiceRequest]():            {"service_id"},
+	reflect.TypeFor[*conductorv1.ArchiveServiceRequest]():           {"service_id"},
+	reflect.TypeFor[*conductorv1.UnarchiveServiceRequest]():         {"service_id"},
+	reflect.TypeFor[*conductorv1.SyncServiceRequest]():              {"service_id"},
+	reflect.TypeFor[*conductorv1.GetSyncStatusRequest]():            {"service_id"},
+	reflect.TypeFor[*conductorv1.ListTestDefinitionsRequest]():      {"service_id"},
+	reflect.TypeFor[*conductorv1.CreateTestDefinitionRequest]():     {"service_id", "name", "command"},
+	reflect.TypeFor[*conductorv1.GetTestDefinitionRequest]():        {"service_id", "test_id"},
+	reflect.TypeFor[*conductorv1.UpdateTestDefinitionRequest]():     {"service_id", "test_id"},
+	reflect.TypeFor[*conductorv1.GetDeployKeyRequest]():             {"service_id"},
+	reflect.TypeFor[*conductorv1.SetDeployKeyRequest]():             {"service_id"},
+	reflect.TypeFor[*conductorv1.DeleteDeployKeyRequest]():          {"service_id"},
+	reflect.TypeFor[*conductorv1.GetGitCredentialRequest]():         {"service_id"},
+	reflect.TypeFor[*conductorv1.SetGitCredentialRequest]():         {"service_id", "token"},
+	reflect.TypeFor[*conductorv1.DeleteGitCredentialRequest]():      {"service_id"},
+	reflect.TypeFor[*conductorv1.GetServiceEnvironmentRequest]():    {"service_id"},
+	reflect.TypeFor[*conductorv1.SetServiceEnvironmentRequest]():    {"service_id"},
+	reflect.TypeFor[*conductorv1.DeleteServiceEnvironmentRequest](): {"service_id"},
+	reflect.TypeFor[*conductorv1.GetTriggerRulesRequest]():          {"service_id"},
+	reflect.TypeFor[*conductorv1.SetTriggerRulesRequest]():          {"service_id"},
+	reflect.TypeFor[*conductorv1.DeleteTriggerRulesRequest]():       {"service_id"},
+	reflect.TypeFor[*conductorv1.GetServiceQuotaRequest]():          {"service_id"},
+	reflect.TypeFor[*conductorv1.SetServiceQuotaRequest]():          {"service_id"},
+	reflect.TypeFor[*conductorv1.DeleteServiceQuotaRequest]():       {"service_id"},
+	reflect.TypeFor[*conductorv1.SetRetryPolicyRequest]():           {"service_id"},
+	reflect.TypeFor[*conductorv1.ListRetryPoliciesRequest]():        {"service_id"},
+	reflect.TypeFor[*conductorv1.DeleteRetryPolicyRequest]():        {"service_id"},
+	reflect.TypeFor[*conductorv1.ListBranchesRequest]():             {"service_id"},
+	reflect.TypeFor[*conductorv1.GetBranchRequest]():                {"service_id"},
+	reflect.TypeFor[*conductorv1.UpdateBranchRequest]():             {"service_id"},
+	reflect.TypeFor[*conductorv1.CreateScheduleRequest]():           {"service_id", "name"},
+	reflect.TypeFor[*conductorv1.ListSchedulesRequest]():            {"service_id"},
+	reflect.TypeFor[*conductorv1.GetScheduleRequest]():              {"service_id", "schedule_id"},
+	reflect.TypeFor[*conductorv1.UpdateScheduleRequest]():           {"service_id", "schedule_id"},
+	reflect.TypeFor[*conductorv1.DeleteScheduleRequest]():           {"service_id", "schedule_id"},
+	reflect.TypeFor[*conductorv1.GetServiceReportRequest]():         {"service_id"},
+
+	// Runs and results
+	reflect.TypeFor[*conductorv1.CreateRunRequest]():              {"service_id"},
+	reflect.TypeFor[*conductorv1.GetRunRequest]():                 {"run_id"},
+	reflect.TypeFor[*conductorv1.CancelRunRequest]():              {"run_id"},
+	reflect.TypeFor[*conductorv1.ApproveRunRequest]():             {"run_id"},
+	reflect.TypeFor[*conductorv1.AnnotateRunRequest]():            {"run_id"},
+	reflect.TypeFor[*conductorv1.RetryRunRequest]():               {"run_id"},
+	reflect.TypeFor[*conductorv1.ListRunsByAgentRequest]():        {"agent_id"},
+	reflect.TypeFor[*conductorv1.GetRunResultsRequest]():          {"run_id"},
+	reflect.TypeFor[*conductorv1.GetRunEnergyRequest]():           {"run_id"},
+	reflect.TypeFor[*conductorv1.ListTestResultsRequest]():        {"run_id"},
+	reflect.TypeFor[*conductorv1.GetTestStackTraceRequest]():      {"run_id", "test_name"},
+	reflect.TypeFor[*conductorv1.DiffTestOutputRequest]():         {"run_id", "test_name"},
+	reflect.TypeFor[*conductorv1.ListArtifactsRequest]():          {"run_id"},
+	reflect.TypeFor[*conductorv1.GetArtifactRequest]():            {"artifact_id"},
+	reflect.TypeFor[*conductorv1.GetArtifactDownloadURLRequest](): {"artifact_id"},
+
+	// Agents
+	reflect.TypeFor[*conductorv1.GetAgentRequest]():           {"agent_id"},
+	reflect.TypeFor[*conductorv1.GetAgentDetailRequest]():     {"agent_id"},
+	reflect.TypeFor[*conductorv1.GetAgentStatsRequest]():      {"agent_id"},
+	reflect.TypeFor[*conductorv1.GetAgentLogsRequest]():       {"agent_id"},
+	reflect.TypeFor[*conductorv1.DeleteAgentRequest]():        {"agent_id"},
+	reflect.TypeFor[*conductorv1.DrainAgentRequest]():         {"agent_id"},
+	reflect.TypeFor[*conductorv1.UndrainAgentRequest]():       {"agent_id"},
+	reflect.TypeFor[*conductorv1.GetLiveAgentRequest]():       {"agent_id"},
+	reflect.TypeFor[*conductorv1.FetchAgentLogsRequest]():     {"agent_id"},
+	reflect.TypeFor[*conductorv1.SendControlMessageRequest](): {"agent_id"},
+	reflect.TypeFor[*conductorv1.CreateAgentPoolRequest]():    {"name"},
+
+	// Notifications
+	reflect.TypeFor[*conductorv1.CreateChannelRequest]():         {"name"},
+	reflect.TypeFor[*conductorv1.GetChannelRequest]():            {"channel_id"},
+	reflect.TypeFor[*conductorv1.UpdateChannelRequest]():         {"channel_id"},
+	reflect.TypeFor[*conductorv1.DeleteChannelRequest]():         {"channel_id"},
+	reflect.TypeFor[*conductorv1.TestChannelRequest]():           {"channel_id"},
+	reflect.TypeFor[*conductorv1.CreateRuleRequest]():            {"name", "channel_ids"},
+	reflect.TypeFor[*conductorv1.GetRuleRequest]():               {"rule_id"},
+	reflect.TypeFor[*conductorv1.UpdateRuleRequest]():            {"rule_id"},
+	reflect.TypeFor[*conductorv1.DeleteRuleRequest]():            {"rule_id"},
+	reflect.TypeFor[*conductorv1.RedeliverNotificationRequest](): {"delivery_id"},
+	reflect.TypeFor[*conductorv1.ExplainNotificationRequest]():   {"run_id"},
+
+	// Tenancy and preferences
+	reflect.TypeFor[*conductorv1.CreateOrganizationRequest](): {"name"},
+	reflect.TypeFor[*conductorv1.CreateProjectRequest]():      {"organization_id", "name"},
+	reflect.TypeFor[*conductorv1.ListProjectsRequest]():       {"organization_id"},
+	reflect.TypeFor[*conductorv1.PinServiceRequest]():         {"service_id"},
+	reflect.TypeFor[*conductorv1.UnpinServiceRequest]():       {"service_id"},
+	reflect.TypeFor[*conductorv1.CreateSavedViewRequest]():    {"name", "page"},
+	reflect.TypeFor[*conductorv1.UpdateSavedViewRequest]():    {"view_id"},
+	reflect.TypeFor[*conductorv1.DeleteSavedViewRequest]():    {"view_id"},
+
+	// Administration
+	reflect.TypeFor[*conductorv1.GetMaintenanceWindowRequest]():    {"window_id"},
+	reflect.TypeFor[*conductorv1.CreateMaintenanceWindowRequest](): {"name"},
+	reflect.TypeFor[*conductorv1.UpdateMaintenanceWindowRequest](): {"window_id"},
+	reflect.TypeFor[*conductorv1.DeleteMaintenanceWindowRequest](): {"window_id"},
+}
+
+// enumField checks that the values of an enum field of the requests that
+// have it are defined.
+type enumField struct {
+	check func(req any) []errcode.FieldViolation
+}
+
+func enumValue[R any, E ~int32](name string, values map[int32]string, get func(R) E) enumField {
+	return enumField{check: func(req any) []errcode.FieldViolation {
+		r, ok := req.(R)
+		if !ok {
+			return nil
+		}
+		if v := get(r); !definedEnum(values, v) {
+			return []errcode.FieldViolation{undefinedEnum(name, v)}
+		}
+		return nil
+	}}
+}
+
+func enumValues[R any, E ~int32](name string, values map[int32]string, get func(R) []E) enumField {
+	return enumField{check: func(req any) []errcode.FieldViolation {
+		r, ok := req.(R)
+		if !ok {
+			return nil
+		}
+		var violations []errcode.FieldViolation
+		for i, v := range get(r) {
+			if !definedEnum(values, v) {
+				violations = append(violations, undefinedEnum(fmt.Sprintf("%s[%d]", name, i), v))
+			}
+		}
+		return violations
+	}}
+}
+
+func definedEnum[E ~int32](values map[int32]string, v E) bool {
+	_, ok := values[int32(v)]
+	return ok
+}
+
+func undefinedEnum[E ~int32](field string, v E) errcode.FieldViolation {
+	return errcode.FieldViolation{Field: field, Description: fmt.Sprintf("has no value %d", int32(v))}
+}
+
+// enumFields are the enum fields of requests.
+var enumFields = []enumField{
+	enumValues("statuses", conductorv1.AgentStatus_name, interface {
+		GetStatuses() []conductorv1.AgentStatus
+	}.GetStatuses),
+	enumValues("statuses", conductorv1.RunStatus_name, interface {
+		GetStatuses() []conductorv1.RunStatus
+	}.GetStatuses),
+	enumValues("statuses", conductorv1.TestStatus_name, interface {
+		GetStatuses() []conductorv1.TestStatus
+	}.GetStatuses),
+	enumValues("statuses", conductorv1.WebhookStatus_name, interface {
+		GetStatuses() []conductorv1.WebhookStatus
+	}.GetStatuses),
+	enumValues("events", conductorv1.NotificationEvent_name, interface {
+		GetEvents() []conductorv1.NotificationEvent
+	}.GetEvents),
+	enumValue("event", conductorv1.NotificationEvent_name, interface {
+		GetEvent() conductorv1.NotificationEvent
+	}.GetEvent),
+	enumValue("type", conductorv1.ChannelType_name, interface {
+		GetType() conductorv1.ChannelType
+	}.GetType),
+	enumValue("type", conductorv1.TestType_name, interface{ GetType() conductorv1.TestType }.GetType),
+	enumValue("sort_order", conductorv1.AgentSortOrder_name, interface {
+		GetSortOrder() conductorv1.AgentSortOrder
+	}.GetSortOrder),
+	enumValue("sort_order", conductorv1.RunSortOrder_name, interface {
+		GetSortOrder() conductorv1.RunSortOrder
+	}.GetSortOrder),
+	enumValue("sort_order", conductorv1.TestResultSortOrder_name, interface {
+		GetSortOrder() conductorv1.TestResultSortOrder
+	}.GetSortOrder),
+	enumValue("trigger_type", conductorv1.TriggerType_name, interface {
+		GetTriggerType() conductorv1.TriggerType
+	}.GetTriggerType),
+	enumValue("execution_type", conductorv1.ExecutionType_name, interface {
+		GetExecutionType() conductorv1.ExecutionType
+	}.GetExecutionType),
+	enumValue("default_execution_type", conductorv1.ExecutionType_name, interface {
+		GetDefaultExecutionType() conductorv1.ExecutionType
+	}.GetDefaultExecutionType),
+	enumValue("stream", conductorv1.LogStream_name, interface{ GetStream() conductorv1.LogStream }.GetStream),
+	enumValue("result_format", conductorv1.ResultFormat_name, interface {
+		GetResultFormat() conductorv1.ResultFormat
+	}.GetResultFormat),
+	enumValue("retry_on", conductorv1.RetryCondition_name, interface {
+		GetRetryOn() conductorv1.RetryCondition
+	}.GetRetryOn),
+	enumValue("notifications", conductorv1.MaintenanceNotifications_name, interface {
+		GetNotifications() conductorv1.MaintenanceNotifications
+	}.GetNotifications),
+}
+
+// ValidationInterceptor rejects requests with malformed IDs, missing
+// required fields, values too long to store or undefined enum values before
+// they reach the handlers, with the fields at fault in a BadRequest detail.
+// Handlers keep their own checks for what depends on other fields or on
+// stored state.
+type ValidationInterceptor struct{}
+
+// NewValidationInterceptor creates a validation interceptor.
+func NewValidationInterceptor() *ValidationInterceptor {
+	return &ValidationInterceptor{}
+}
+
+// Unary returns a unary server interceptor validating requests.
+func (v *ValidationInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if err := validateRequest(req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Stream returns a stream server interceptor validating the request of
+// server-streaming calls. The messages of client streams, such as those of
+// agents, are left to their handlers.
+func (v *ValidationInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if info.IsClientStream {
+			return handler(srv, ss)
+		}
+		return handler(srv, &validatingServerStream{ServerStream: ss})
+	}
+}
+
+// validatingServerStream validates the request of a server-streaming call
+// as the handler receives it.
+type validatingServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validateRequest(m)
+}
+
+// validateRequest returns an InvalidArgument error listing the fields of
+// req that are invalid, or nil.
+func validateRequest(req any) error {
+	var violations []errcode.FieldViolation
+
+	for _, name := range requiredFields[reflect.TypeOf(req)] {
+		values, _ := requestFields[name].get(req)
+		if !isSet(values, requestFields[name].list) {
+			violations = append(violations, errcode.FieldViolation{Field: name, Description: "is required"})
+		}
+	}
+
+	for _, name := range uuidFields {
+		field := requestFields[name]
+		values, ok := field.get(req)
+		if !ok {
+			continue
+		}
+		for i, value := range values {
+			if value == "" {
+				continue
+			}
+			if _, err := uuid.Parse(value); err != nil {
+				violations = append(violations, errcode.FieldViolation{Field: fieldPath(name, field.list, i), Description: "must be a UUID"})
+			}
+		}
+	}
+
+	for name, max := range maxFieldLengths {
+		values, ok := requestFields[name].get(req)
+		if ok && utf8.RuneCountInString(values[0]) > max {
+			violations = append(violations, errcode.FieldViolation{Field: name, Description: fmt.Sprintf("must be at most %d characters", max)})
+		}
+	}
+
+	for _, field := range enumFields {
+		violations = append(violations, field.check(req)...)
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return errcode.NewInvalidFields(violations...)
+}
+
+// isSet reports whether a required field is set.
+func isSet(values []string, list bool) bool {
+	if list {
+		return len(values) > 0
+	}
+	return len(values) == 1 && strings.TrimSpace(values[0]) != ""
+}
+
+func fieldPath(name string, list bool, i int) string {
+	if list {
+		return fmt.Sprintf("%s[%d]", name, i)
+	}
+	return name
+}
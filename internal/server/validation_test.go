@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+	"github.com/conductor/conductor/pkg/errcode"
+)
+
+func TestRequiredFieldsExist(t *testing.T) {
+	for typ, fields := range requiredFields {
+		req := reflect.New(typ.Elem()).Interface()
+		for _, name := range fields {
+			field, ok := requestFields[name]
+			require.True(t, ok, "field %s is known", name)
+			_, ok = field.get(req)
+			assert.True(t, ok, "%s has field %s", typ, name)
+		}
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	const serviceID = "7f9c1c1e-8d5a-4b0e-9f5e-2c1b3a4d5e6f"
+
+	tests := []struct {
+		name       string
+		req        any
+		violations []errcode.FieldViolation
+	}{
+		{
+			name: "valid",
+			req:  &conductorv1.GetServiceRequest{ServiceId: serviceID},
+		},
+		{
+			name:       "missing ID",
+			req:        &conductorv1.GetServiceRequest{},
+			violations: []errcode.FieldViolation{{Field: "service_id", Description: "is required"}},
+		},
+		{
+			name:       "malformed ID",
+			req:        &conductorv1.GetServiceRequest{ServiceId: "not-a-uuid"},
+			violations: []errcode.FieldViolation{{Field: "service_id", Description: "must be a UUID"}},
+		},
+		{
+			name: "blank and too long strings",
+			req:  &conductorv1.CreateServiceRequest{Name: strings.Repeat("a", 256), GitUrl: "  "},
+			violations: []errcode.FieldViolation{
+				{Field: "git_url", Description: "is required"},
+				{Field: "name", Description: "must be at most 255 characters"},
+			},
+		},
+		{
+			name: "malformed IDs in lists",
+			req:  &conductorv1.CreateRuleRequest{Name: "rule", ChannelIds: []string{serviceID, "x"}, Events: []conductorv1.NotificationEvent{1}},
+			violations: []errcode.FieldViolation{
+				{Field: "channel_ids[1]", Description: "must be a UUID"},
+			},
+		},
+		{
+			name: "undefined enum values",
+			req:  &conductorv1.ListRunsRequest{Statuses: []conductorv1.RunStatus{1, 999}},
+			violations: []errcode.FieldViolation{
+				{Field: "statuses[1]", Description: "has no value 999"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRequest(tt.req)
+			if tt.violations == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+			assert.True(t, errcode.Is(err, errcode.InvalidArgument))
+			assert.ElementsMatch(t, tt.violations, errcode.FieldViolations(err))
+		})
+	}
+}
+
+func TestValidationInterceptor(t *testing.T) {
+	interceptor := NewValidationInterceptor().Unary()
+	called := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/conductor.v1.RunService/GetRun"}
+
+	_, err := interceptor(context.Background(), &conductorv1.GetRunRequest{RunId: "abc"}, info, handler)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.False(t, called, "invalid requests do not reach the handler")
+
+	_, err = interceptor(context.Background(), &conductorv1.GetRunRequest{RunId: "7f9c1c1e-8d5a-4b0e-9f5e-2c1b3a4d5e6f"}, info, handler)
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Reasons a webhook delivery is rejected, used as the metric label.
+const (
+	webhookRejectSignature = "invalid_signature"
+	webhookRejectUnsigned  = "unsigned"
+	webhookRejectMalformed = "malformed"
+	webhookRejectMissingID = "missing_delivery_id"
+	webhookRejectStale     = "stale"
+	webhookRejectReplayed  = "replayed"
+)
+
+// Standard Webhooks headers, honored on every endpoint so that relays and
+// generic senders can supply a delivery ID and send time.
+const (
+	webhookIDHeader        = "Webhook-Id"
+	webhookTimestampHeader = "Webhook-Timestamp"
+)
+
+// maxRememberedDeliveries bounds the replay cache.
+const maxRememberedDeliveries = 100000
+
+// webhookDelivery identifies an authenticated delivery for replay checks.
+type webhookDelivery struct {
+	provider string
+	id       string
+	// sentAt is when the sender created the delivery, zero if unknown.
+	sentAt  time.Time
+	payload []byte
+}
+
+// deliveryCache remembers recently accepted deliveries until they expire.
+type deliveryCache struct {
+	mu      sync.Mutex
+	window  time.Duration
+	entries map[string]time.Time
+}
+
+func newDeliveryCache(window time.Duration) *deliveryCache {
+	return &deliveryCache{
+		window:  window,
+		entries: make(map[string]time.Time),
+	}
+}
+
+// add records the keys and reports whether none of them had been seen
+// within the window. Nothing is recorded for a replay.
+func (c *deliveryCache) add(now time.Time, keys ...string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if expires, ok := c.entries[key]; ok && now.Before(expires) {
+			return false
+		}
+	}
+
+	if len(c.entries)+len(keys) > maxRememberedDeliveries {
+		c.prune(now)
+	}
+	for _, key := range keys {
+		c.entries[key] = now.Add(c.window)
+	}
+	return true
+}
+
+// remove forgets the keys so a retried delivery is accepted.
+func (c *deliveryCache) remove(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+}
+
+// prune drops expired entries, then the soonest to expire if still full.
+// Callers must hold the lock.
+func (c *deliveryCache) prune(now time.Time) {
+	for key, expires := range c.entries {
+		if !now.Before(expires) {
+			delete(c.entries, key)
+		}
+	}
+
+	for len(c.entries) >= maxRememberedDeliveries {
+		var oldestKey string
+		var oldest time.Time
+		for key, expires := range c.entries {
+			if oldestKey == "" || expires.Before(oldest) {
+				oldestKey, oldest = key, expires
+			}
+		}
+		delete(c.entries, oldestKey)
+	}
+}
+
+// deliveryContextKey is the context key of the webhook delivery being
+// processed.
+type deliveryContextKey struct{}
+
+// withDelivery returns a context carrying the delivery being processed, so
+// that the runs it triggers take their idempotency keys from it.
+func withDelivery(ctx context.Context, provider, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, deliveryContextKey{}, provider+":"+id)
+}
+
+// deliveryFromContext returns the provider and ID of the delivery being
+// processed, as provider:id, or "" without one.
+func deliveryFromContext(ctx context.Context) string {
+	delivery, _ := ctx.Value(deliveryContextKey{}).(string)
+	return delivery
+}
+
+// deliveryIDFrom returns the provider's delivery ID header, falling back to the
+// Standard Webhooks ID.
+func deliveryIDFrom(r *http.Request, header string) string {
+	if header != "" {
+		if id := r.Header.Get(header); id != "" {
+			return id
+		}
+	}
+	return r.Header.Get(webhookIDHeader)
+}
+
+// deliverySentAt parses the Standard Webhooks timestamp (Unix seconds). It
+// returns the zero time when the header is absent.
+func deliverySentAt(r *http.Request) (time.Time, bool) {
+	value := r.Header.Get(webhookTimestampHeader)
+	if value == "" {
+		return time.Time{}, true
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0), true
+}
+
+// rejectDelivery logs, counts, and answers a rejected webhook delivery.
+func (h *WebhookHandler) rejectDelivery(w http.ResponseWriter, r *http.Request, provider, reason string, status int, message string) {
+	h.logger.Warn().
+		Str("request_id", GetRequestID(r.Context())).
+		Str("provider", provider).
+		Str("reason", reason).
+		Str("remote_addr", r.RemoteAddr).
+		Msg("rejected webhook delivery")
+
+	if h.metrics != nil {
+		h.metrics.RecordWebhookRejected(provider, reason)
+	}
+	http.Error(w, message, status)
+}
+
+// requireSecret rejects deliveries to a provider without a configured secret
+// in strict mode, since they cannot be authenticated.
+func (h *WebhookHandler) requireSecret(w http.ResponseWriter, r *http.Request, provider, secret string) bool {
+	if h.strict && secret == "" {
+		h.rejectDelivery(w, r, provider, webhookRejectUnsigned, http.StatusUnauthorized, "webhook secret not configured")
+		return false
+	}
+	return true
+}
+
+// admitDelivery applies the freshness and replay checks to an authenticated
+// delivery. It answers the request and returns false when the delivery must
+// not be processed. Otherwise the returned release func forgets the
+// delivery, for use when processing fails and the provider will retry.
+func (h *WebhookHandler) admitDelivery(w http.ResponseWriter, r *http.Request, d webhookDelivery) (func(), bool) {
+	noop := func() {}
+	now := h.now()
+
+	if h.strict && d.id == "" {
+		h.rejectDelivery(w, r, d.provider, webhookRejectMissingID, http.StatusBadRequest, "missing delivery ID")
+		return noop, false
+	}
+
+	if h.timestampTolerance > 0 && !d.sentAt.IsZero() {
+		skew := now.Sub(d.sentAt)
+		if skew > h.timestampTolerance || skew < -h.timestampTolerance {
+			h.rejectDelivery(w, r, d.provider, webhookRejectStale, http.StatusBadRequest, "delivery timestamp outside tolerance")
+			return noop, false
+		}
+	}
+
+	if h.deliveries == nil {
+		return noop, true
+	}
+
+	var keys []string
+	if d.id != "" {
+		keys = append(keys, d.provider+":id:"+d.id)
+	}
+	if h.strict {
+		// Delivery ID headers are not covered by provider signatures, so
+		// strict mode also matches the signed body itself.
+		digest := sha256.Sum256(d.payload)
+		keys = append(keys, d.provider+":sha256:"+hex.EncodeToString(digest[:]))
+	}
+	if len(keys) == 0 {
+		return noop, true
+	}
+
+	if !h.deliveries.add(now, keys...) {
+		h.rejectDelivery(w, r, d.provider, webhookRejectReplayed, http.StatusConflict, "delivery already processed")
+		return noop, false
+	}
+	return func() { h.deliveries.remove(keys...) }, true
+}
+
+// admit validates an authenticated header-based delivery and applies the
+// replay checks. In strict mode the event type header and a JSON body are
+// required.
+func (h *WebhookHandler) admit(w http.ResponseWriter, r *http.Request, provider, eventType, id string, payload []byte) (func(), bool) {
+	if h.strict && (eventType == "" || !json.Valid(payload)) {
+		h.rejectDelivery(w, r, provider, webhookRejectMalformed, http.StatusBadRequest, "invalid payload")
+		return func() {}, false
+	}
+
+	sentAt, ok := deliverySentAt(r)
+	if !ok {
+		h.rejectDelivery(w, r, provider, webhookRejectMalformed, http.StatusBadRequest, "invalid delivery timestamp")
+		return func() {}, false
+	}
+
+	return h.admitDelivery(w, r, webhookDelivery{
+		provider: provider,
+		id:       id,
+		sentAt:   sentAt,
+		payload:  payload,
+	})
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/pkg/metrics"
+)
+
+const replayTestPayload = `{"ref":"refs/heads/main","after":"abc123","repository":{"name":"repo","full_name":"owner/repo","owner":{"login":"owner"}},"sender":{"login":"test-user"}}`
+
+func newReplayTestHandler(cfg WebhookConfig, scheduler *mockScheduler) (*WebhookHandler, *metrics.ControlPlaneMetrics) {
+	m := metrics.NewControlPlaneMetrics().ControlPlane
+	cfg.GithubSecret = "test-secret"
+	cfg.Metrics = m
+
+	serviceRepo := &mockServiceRepo{services: []database.Service{
+		{ID: uuid.New(), Name: "test-service", GitURL: "https://github.com/owner/repo"},
+	}}
+	return NewWebhookHandler(cfg, serviceRepo, scheduler, zerolog.Nop()), m
+}
+
+func signedGitHubRequest(payload, deliveryID string) *http.Request {
+	mac := hmac.New(sha256.New, []byte("test-secret"))
+	mac.Write([]byte(payload))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader([]byte(payload)))
+	req.Header.Set("X-GitHub-Event", "push")
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	if deliveryID != "" {
+		req.Header.Set("X-GitHub-Delivery", deliveryID)
+	}
+	return req
+}
+
+func serveGitHub(handler *WebhookHandler, req *http.Request) int {
+	rr := httptest.NewRecorder()
+	handler.HandleGitHubWebhook(rr, req)
+	return rr.Code
+}
+
+func rejected(m *metrics.ControlPlaneMetrics, provider, reason string) float64 {
+	return testutil.ToFloat64(m.WebhookDeliveriesRejected.WithLabelValues(provider, reason))
+}
+
+func TestWebhookReplayProtection(t *testing.T) {
+	t.Run("rejects replayed delivery ID", func(t *testing.T) {
+		scheduler := &mockScheduler{}
+		handler, m := newReplayTestHandler(WebhookConfig{ReplayWindow: time.Hour}, scheduler)
+
+		assert.Equal(t, http.StatusOK, serveGitHub(handler, signedGitHubRequest(replayTestPayload, "delivery-1")))
+		assert.Equal(t, http.StatusConflict, serveGitHub(handler, signedGitHubRequest(replayTestPayload, "delivery-1")))
+		assert.Equal(t, http.StatusOK, serveGitHub(handler, signedGitHubRequest(replayTestPayload, "delivery-2")))
+
+		assert.Len(t, scheduler.requests, 2)
+		assert.Equal(t, 1.0, rejected(m, "github", webhookRejectReplayed))
+	})
+
+	t.Run("accepts delivery again after window", func(t *testing.T) {
+		handler, _ := newReplayTestHandler(WebhookConfig{ReplayWindow: time.Hour}, &mockScheduler{})
+		now := time.Now()
+		handler.now = func() time.Time { return now }
+
+		assert.Equal(t, http.StatusOK, serveGitHub(handler, signedGitHubRequest(replayTestPayload, "delivery-1")))
+		now = now.Add(2 * time.Hour)
+		assert.Equal(t, http.StatusOK, serveGitHub(handler, signedGitHubRequest(replayTestPayload, "delivery-1")))
+	})
+
+	t.Run("accepts retry after processing failure", func(t *testing.T) {
+		scheduler := &mockScheduler{err: errors.New("database unavailable")}
+		handler, _ := newReplayTestHandler(WebhookConfig{ReplayWindow: time.Hour}, scheduler)
+
+		assert.Equal(t, http.StatusInternalServerError, serveGitHub(handler, signedGitHubRequest(replayTestPayload, "delivery-1")))
+		scheduler.err = nil
+		assert.Equal(t, http.StatusOK, serveGitHub(handler, signedGitHubRequest(replayTestPayload, "delivery-1")))
+	})
+
+	t.Run("rejects stale and future timestamps", func(t *testing.T) {
+		handler, m := newReplayTestHandler(WebhookConfig{TimestampTolerance: 5 * time.Minute}, &mockScheduler{})
+
+		for _, sentAt := range []time.Time{time.Now().Add(-10 * time.Minute), time.Now().Add(10 * time.Minute)} {
+			req := signedGitHubRequest(replayTestPayload, "delivery-1")
+			req.Header.Set("Webhook-Timestamp", strconv.FormatInt(sentAt.Unix(), 10))
+			assert.Equal(t, http.StatusBadRequest, serveGitHub(handler, req))
+		}
+
+		req := signedGitHubRequest(replayTestPayload, "delivery-1")
+		req.Header.Set("Webhook-Timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+		assert.Equal(t, http.StatusOK, serveGitHub(handler, req))
+
+		assert.Equal(t, 2.0, rejected(m, "github", webhookRejectStale))
+	})
+
+	t.Run("counts invalid signatures", func(t *testing.T) {
+		handler, m := newReplayTestHandler(WebhookConfig{}, &mockScheduler{})
+
+		req := signedGitHubRequest(replayTestPayload, "delivery-1")
+		req.Header.Set("X-Hub-Signature-256", "sha256=invalid")
+		assert.Equal(t, http.StatusUnauthorized, serveGitHub(handler, req))
+		assert.Equal(t, 1.0, rejected(m, "github", webhookRejectSignature))
+	})
+}
+
+func TestWebhookStrictMode(t *testing.T) {
+	t.Run("requires delivery ID", func(t *testing.T) {
+		handler, m := newReplayTestHandler(WebhookConfig{Strict: true, ReplayWindow: time.Hour}, &mockScheduler{})
+
+		assert.Equal(t, http.StatusBadRequest, serveGitHub(handler, signedGitHubRequest(replayTestPayload, "")))
+		assert.Equal(t, 1.0, rejected(m, "github", webhookRejectMissingID))
+	})
+
+	t.Run("detects replays with a new delivery ID", func(t *testing.T) {
+		handler, _ := newReplayTestHandler(WebhookConfig{Strict: true, ReplayWindow: time.Hour}, &mockScheduler{})
+
+		assert.Equal(t, http.StatusOK, serveGitHub(handler, signedGitHubRequest(replayTestPayload, "delivery-1")))
+		assert.Equal(t, http.StatusConflict, serveGitHub(handler, signedGitHubRequest(replayTestPayload, "forged-delivery")))
+	})
+
+	t.Run("rejects malformed payloads", func(t *testing.T) {
+		handler, m := newReplayTestHandler(WebhookConfig{Strict: true}, &mockScheduler{})
+
+		assert.Equal(t, http.StatusBadRequest, serveGitHub(handler, signedGitHubRequest(`{"ref":`, "delivery-1")))
+		assert.Equal(t, 1.0, rejected(m, "github", webhookRejectMalformed))
+	})
+
+	t.Run("rejects providers without a secret", func(t *testing.T) {
+		handler, m := newReplayTestHandler(WebhookConfig{Strict: true}, &mockScheduler{})
+
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/gitlab", bytes.NewReader([]byte(replayTestPayload)))
+		req.Header.Set("X-Gitlab-Event", "Push Hook")
+		req.Header.Set("X-Gitlab-Event-UUID", "delivery-1")
+		rr := httptest.NewRecorder()
+		handler.HandleGitLabWebhook(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Equal(t, 1.0, rejected(m, "gitlab", webhookRejectUnsigned))
+	})
+}
+
+func TestHandleAzureDevOpsWebhook_StaleDelivery(t *testing.T) {
+	handler, m := newReplayTestHandler(WebhookConfig{TimestampTolerance: 5 * time.Minute}, &mockScheduler{})
+
+	payload := `{"id":"evt-1","eventType":"git.push","createdDate":"` + time.Now().Add(-time.Hour).UTC().Format(time.RFC3339) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/azure-devops", bytes.NewReader([]byte(payload)))
+	rr := httptest.NewRecorder()
+	handler.HandleAzureDevOpsWebhook(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, 1.0, rejected(m, "azure_devops", webhookRejectStale))
+}
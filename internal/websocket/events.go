@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -23,6 +24,9 @@ type EventPublisher interface {
 
 	// PublishServiceUpdate publishes a service update event.
 	PublishServiceUpdate(service ServiceEvent) error
+
+	// PublishServiceSync publishes the change set of a service sync.
+	PublishServiceSync(sync ServiceSyncEvent) error
 }
 
 // RunEvent represents a test run event for publishing.
@@ -76,6 +80,19 @@ type ServiceEvent struct {
 	LastRunAt     *time.Time
 }
 
+// ServiceSyncEvent represents the change set of a service sync for
+// publishing. Applied is false when the changes were rolled back.
+type ServiceSyncEvent struct {
+	ServiceID uuid.UUID
+	Branch    string
+	Added     []string
+	Updated   []string
+	Removed   []string
+	Errors    []string
+	Applied   bool
+	SyncedAt  time.Time
+}
+
 // Publisher implements EventPublisher using the WebSocket hub.
 type Publisher struct {
 	hub    *Hub
@@ -270,6 +287,48 @@ func (p *Publisher) PublishServiceUpdate(service ServiceEvent) error {
 	return nil
 }
 
+// PublishServiceSync publishes the change set of a service sync.
+func (p *Publisher) PublishServiceSync(sync ServiceSyncEvent) error {
+	payload := ServiceSyncPayload{
+		ServiceID: sync.ServiceID,
+		Branch:    sync.Branch,
+		Added:     sync.Added,
+		Updated:   sync.Updated,
+		Removed:   sync.Removed,
+		Errors:    sync.Errors,
+		Applied:   sync.Applied,
+		SyncedAt:  sync.SyncedAt,
+	}
+
+	msg, err := NewMessage(MessageTypeServiceSync, payload)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to create service sync message")
+		return err
+	}
+
+	// Publish to service-specific room
+	serviceRoom := RoomName(RoomTypeService, sync.ServiceID.String())
+	if err := p.hub.BroadcastMessage(serviceRoom, msg); err != nil {
+		p.logger.Error().Err(err).Str("room", serviceRoom).Msg("failed to broadcast to service room")
+	}
+
+	// Publish to global services room
+	globalRoom := RoomName(RoomTypeGlobal, "services")
+	if err := p.hub.BroadcastMessage(globalRoom, msg); err != nil {
+		p.logger.Error().Err(err).Str("room", globalRoom).Msg("failed to broadcast to global room")
+	}
+
+	p.logger.Debug().
+		Str("service_id", sync.ServiceID.String()).
+		Int("added", len(sync.Added)).
+		Int("updated", len(sync.Updated)).
+		Int("removed", len(sync.Removed)).
+		Bool("applied", sync.Applied).
+		Msg("published service sync")
+
+	return nil
+}
+
 // NoopPublisher is a no-op implementation of EventPublisher.
 type NoopPublisher struct{}
 
@@ -287,3 +346,50 @@ func (NoopPublisher) PublishTestResult(uuid.UUID, TestResultEvent) error { retur
 
 // PublishServiceUpdate does nothing.
 func (NoopPublisher) PublishServiceUpdate(ServiceEvent) error { return nil }
+
+// PublishServiceSync does nothing.
+func (NoopPublisher) PublishServiceSync(ServiceSyncEvent) error { return nil }
+
+// MultiPublisher publishes every event to each of its publishers. All
+// publishers are called even if one fails; their errors are joined.
+type MultiPublisher []EventPublisher
+
+// PublishRunUpdate publishes a run update to every publisher.
+func (m MultiPublisher) PublishRunUpdate(run RunEvent) error {
+	return m.each(func(p EventPublisher) error { return p.PublishRunUpdate(run) })
+}
+
+// PublishAgentUpdate publishes an agent update to every publisher.
+func (m MultiPublisher) PublishAgentUpdate(agent AgentEvent) error {
+	return m.each(func(p EventPublisher) error { return p.PublishAgentUpdate(agent) })
+}
+
+// PublishLogChunk publishes a log chunk to every publisher.
+func (m MultiPublisher) PublishLogChunk(runID uuid.UUID, chunk LogChunk) error {
+	return m.each(func(p EventPublisher) error { return p.PublishLogChunk(runID, chunk) })
+}
+
+// PublishTestResult publishes a test result to every publisher.
+func (m MultiPublisher) PublishTestResult(runID uuid.UUID, result TestResultEvent) error {
+	return m.each(func(p EventPublisher) error { return p.PublishTestResult(runID, result) })
+}
+
+// PublishServiceUpdate publishes a service update to every publisher.
+func (m MultiPublisher) PublishServiceUpdate(service ServiceEvent) error {
+	return m.each(func(p EventPublisher) error { return p.PublishServiceUpdate(service) })
+}
+
+// PublishServiceSync publishes a service sync result to every publisher.
+func (m MultiPublisher) PublishServiceSync(sync ServiceSyncEvent) error {
+	return m.each(func(p EventPublisher) error { return p.PublishServiceSync(sync) })
+}
+
+func (m MultiPublisher) each(publish func(EventPublisher) error) error {
+	var errs []error
+	for _, p := range m {
+		if err := publish(p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -29,6 +29,7 @@ const (
 	MessageTypeLogChunk      MessageType = "log_chunk"
 	MessageTypeTestResult    MessageType = "test_result"
 	MessageTypeServiceUpdate MessageType = "service_update"
+	MessageTypeServiceSync   MessageType = "service_sync"
 )
 
 // RoomType defines the type of subscription room.
@@ -171,6 +172,18 @@ type ServiceUpdatePayload struct {
 	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
 }
 
+// ServiceSyncPayload is the payload for service sync messages.
+type ServiceSyncPayload struct {
+	ServiceID uuid.UUID `json:"service_id"`
+	Branch    string    `json:"branch"`
+	Added     []string  `json:"added"`
+	Updated   []string  `json:"updated"`
+	Removed   []string  `json:"removed"`
+	Errors    []string  `json:"errors"`
+	Applied   bool      `json:"applied"`
+	SyncedAt  time.Time `json:"synced_at"`
+}
+
 // RoomName creates a standardized room name from type and ID.
 func RoomName(roomType RoomType, id string) string {
 	return string(roomType) + ":" + id
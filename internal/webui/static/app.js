@@ -0,0 +1,358 @@
+// Conductor embedded UI. Plain JavaScript with no build step: it renders the
+// REST API into a few tables and refreshes them on WebSocket events.
+(function () {
+  "use strict";
+
+  const API = "/api/v1";
+  const TOKEN_KEY = "conductor.token";
+  const view = document.getElementById("view");
+  const live = document.getElementById("live");
+  const signout = document.getElementById("signout");
+
+  // ---------------------------------------------------------------------------
+  // Helpers
+  // ---------------------------------------------------------------------------
+
+  function el(tag, attrs, ...children) {
+    const node = document.createElement(tag);
+    for (const [key, value] of Object.entries(attrs || {})) {
+      if (value === undefined || value === null || value === false) continue;
+      if (key.startsWith("on")) node.addEventListener(key.slice(2), value);
+      else node.setAttribute(key, value);
+    }
+    for (const child of children.flat()) {
+      if (child === undefined || child === null) continue;
+      node.append(child instanceof Node ? child : document.createTextNode(String(child)));
+    }
+    return node;
+  }
+
+  // enumName turns "RUN_STATUS_PASSED" or "passed" into "passed".
+  function enumName(value, prefix) {
+    if (!value) return "";
+    return String(value).replace(prefix, "").toLowerCase();
+  }
+
+  function statusBadge(value, prefix) {
+    const name = enumName(value, prefix) || "unknown";
+    return el("span", { class: "status " + name }, name);
+  }
+
+  function formatTime(value) {
+    if (!value) return "";
+    const date = new Date(value);
+    return isNaN(date) ? "" : date.toLocaleString();
+  }
+
+  function formatDuration(ms) {
+    ms = Number(ms || 0);
+    if (!ms) return "";
+    if (ms < 1000) return ms + "ms";
+    const seconds = Math.round(ms / 1000);
+    if (seconds < 60) return seconds + "s";
+    return Math.floor(seconds / 60) + "m " + (seconds % 60) + "s";
+  }
+
+  function formatBytes(bytes) {
+    bytes = Number(bytes || 0);
+    if (bytes < 1024) return bytes + " B";
+    if (bytes < 1024 * 1024) return (bytes / 1024).toFixed(1) + " KB";
+    return (bytes / (1024 * 1024)).toFixed(1) + " MB";
+  }
+
+  function table(headers, rows, emptyText) {
+    if (!rows.length) return el("div", { class: "empty" }, emptyText);
+    return el("table", {},
+      el("thead", {}, el("tr", {}, headers.map((h) => el("th", {}, h)))),
+      el("tbody", {}, rows.map((cells) => el("tr", {}, cells.map((c) => el("td", {}, c))))));
+  }
+
+  function render(...children) {
+    view.replaceChildren(...children.filter((child) => child !== null));
+  }
+
+  // ---------------------------------------------------------------------------
+  // API
+  // ---------------------------------------------------------------------------
+
+  class Unauthorized extends Error {}
+
+  function token() {
+    return localStorage.getItem(TOKEN_KEY) || "";
+  }
+
+  async function api(path) {
+    const headers = { Accept: "application/json" };
+    if (token()) headers.Authorization = "Bearer " + token();
+
+    const resp = await fetch(API + path, { headers });
+    if (resp.status === 401) throw new Unauthorized();
+
+    const body = await resp.json().catch(() => ({}));
+    if (!resp.ok) {
+      const code = resp.headers.get("X-Conductor-Error-Code");
+      throw new Error((body.message || resp.statusText) + (code ? " (" + code + ")" : ""));
+    }
+    body.httpStatus = resp.status;
+    return body;
+  }
+
+  // ---------------------------------------------------------------------------
+  // Views
+  // ---------------------------------------------------------------------------
+
+  function loginView() {
+    const input = el("textarea", { name: "token", placeholder: "Bearer token", required: true });
+    const form = el("form", {
+      class: "login",
+      onsubmit: (event) => {
+        event.preventDefault();
+        localStorage.setItem(TOKEN_KEY, input.value.trim().replace(/^Bearer\s+/i, ""));
+        connect();
+        route();
+      },
+    },
+    el("h1", {}, "Sign in"),
+    el("p", { class: "muted" }, "Paste an API token issued for this control plane."),
+    input,
+    el("p", {}, el("button", { type: "submit" }, "Continue")));
+    render(form);
+  }
+
+  async function servicesView() {
+    const data = await api("/services?pagination.page_size=100");
+    const services = data.services || [];
+    render(
+      el("h1", {}, "Services"),
+      table(
+        ["Name", "Owner", "Default branch", "Tests", "Last synced"],
+        services.map((s) => [
+          el("a", { href: "#/runs?service=" + encodeURIComponent(s.id) }, s.name),
+          s.owner || "",
+          el("code", {}, s.defaultBranch || ""),
+          String(s.testCount || 0),
+          formatTime(s.lastSyncedAt),
+        ]),
+        "No services registered."));
+  }
+
+  async function runsView(params) {
+    const query = new URLSearchParams({ "pagination.page_size": "50" });
+    if (params.get("service")) query.set("service_id", params.get("service"));
+
+    const data = await api("/runs?" + query);
+    const runs = data.runs || [];
+    render(
+      el("h1", {}, params.get("service") ? "Runs for service" : "Runs"),
+      table(
+        ["Run", "Service", "Status", "Branch", "Commit", "Tests", "Created"],
+        runs.map((r) => {
+          const git = r.gitRef || {};
+          const summary = r.summary || {};
+          return [
+            el("a", { href: "#/runs/" + r.id }, el("code", {}, r.id.slice(0, 8))),
+            r.serviceName || r.serviceId,
+            statusBadge(r.status, "RUN_STATUS_"),
+            git.branch || "",
+            el("code", {}, git.commitShaShort || (git.commitSha || "").slice(0, 7)),
+            summary.total ? summary.passed + "/" + summary.total : "",
+            formatTime(r.createdAt),
+          ];
+        }),
+        "No runs yet."));
+  }
+
+  async function runDetailView(runID) {
+    const [runData, resultData, artifactData] = await Promise.all([
+      api("/runs/" + runID),
+      api("/runs/" + runID + "/results/tests?pagination.page_size=100").catch(() => ({})),
+      api("/runs/" + runID + "/artifacts").catch(() => ({})),
+    ]);
+    const run = runData.run || {};
+    const git = run.gitRef || {};
+    const summary = run.summary || {};
+    const results = resultData.results || [];
+    const artifacts = artifactData.artifacts || [];
+
+    render(
+      el("h1", {}, (run.serviceName || "Run") + " ", statusBadge(run.status, "RUN_STATUS_")),
+      el("p", { class: "muted" },
+        "Run ", el("code", {}, run.id), " on ", git.branch || "?", " at ",
+        el("code", {}, git.commitShaShort || git.commitSha || "?"),
+        run.agentId ? [" by agent ", el("code", {}, run.agentId.slice(0, 8))] : null),
+      run.errorMessage ? el("div", { class: "error" }, run.errorMessage) : null,
+      el("div", { class: "summary" },
+        ["total", "passed", "failed", "skipped"].map((key) =>
+          el("div", {}, el("strong", {}, String(summary[key] || 0)), key))),
+      el("h2", {}, "Results"),
+      table(
+        ["Test", "Status", "Duration"],
+        results.map((t) => [
+          [t.suiteName ? el("span", { class: "muted" }, t.suiteName + " / ") : null, t.testName,
+            t.errorMessage ? el("pre", {}, t.errorMessage) : null],
+          statusBadge(t.status, "TEST_STATUS_"),
+          formatDuration(t.durationMs),
+        ]),
+        "No results reported."),
+      el("h2", {}, "Artifacts"),
+      table(
+        ["Name", "Type", "Size", ""],
+        artifacts.map((a) => [
+          a.name,
+          a.contentType || "",
+          formatBytes(a.sizeBytes),
+          el("button", { type: "button", onclick: (event) => download(a, event.target) }, "Download"),
+        ]),
+        "No artifacts."));
+
+    subscribe("run:" + runID);
+  }
+
+  async function download(artifact, button) {
+    try {
+      const data = await api("/artifacts/" + artifact.id + "/download");
+      if (data.restorePending) {
+        button.textContent = "Restoring until " + formatTime(data.restoreEta);
+        return;
+      }
+      window.open(data.downloadUrl, "_blank", "noopener");
+    } catch (err) {
+      button.textContent = err.message;
+    }
+  }
+
+  async function agentsView() {
+    const data = await api("/agents?pagination.page_size=100");
+    const agents = data.agents || [];
+    render(
+      el("h1", {}, "Agents"),
+      table(
+        ["Name", "Status", "Version", "Zones", "Active runs", "Last heartbeat"],
+        agents.map((a) => [
+          [a.name, a.hostname ? el("div", { class: "muted" }, a.hostname) : null],
+          statusBadge(a.status, "AGENT_STATUS_"),
+          a.version || "",
+          (a.networkZones || []).join(", "),
+          String(a.activeRunCount || 0) + " / " + String(a.maxParallel || 0),
+          formatTime(a.lastHeartbeat),
+        ]),
+        "No agents registered."));
+  }
+
+  // ---------------------------------------------------------------------------
+  // Routing
+  // ---------------------------------------------------------------------------
+
+  let currentRoute = "";
+
+  async function route() {
+    const hash = location.hash.replace(/^#/, "") || "/runs";
+    const [path, search] = hash.split("?");
+    const params = new URLSearchParams(search || "");
+    const parts = path.split("/").filter(Boolean);
+
+    if (hash !== currentRoute) unsubscribeAll();
+    currentRoute = hash;
+
+    for (const link of document.querySelectorAll("[data-nav]")) {
+      link.classList.toggle("active", link.dataset.nav === parts[0]);
+    }
+    signout.hidden = !token();
+
+    try {
+      if (parts[0] === "services") await servicesView();
+      else if (parts[0] === "agents") await agentsView();
+      else if (parts[0] === "runs" && parts[1]) await runDetailView(parts[1]);
+      else await runsView(params);
+    } catch (err) {
+      if (err instanceof Unauthorized) loginView();
+      else render(el("div", { class: "error" }, err.message));
+    }
+  }
+
+  // ---------------------------------------------------------------------------
+  // Live updates
+  // ---------------------------------------------------------------------------
+
+  const globalRooms = ["global:runs", "global:agents", "global:services"];
+  let socket = null;
+  let viewRooms = [];
+  let refreshTimer = null;
+  let retryDelay = 1000;
+
+  function send(type, room) {
+    if (socket && socket.readyState === WebSocket.OPEN) {
+      socket.send(JSON.stringify({ type, room, timestamp: new Date().toISOString() }));
+    }
+  }
+
+  function subscribe(room) {
+    if (viewRooms.includes(room)) return;
+    viewRooms.push(room);
+    send("subscribe", room);
+  }
+
+  function unsubscribeAll() {
+    viewRooms.forEach((room) => send("unsubscribe", room));
+    viewRooms = [];
+  }
+
+  function scheduleRefresh() {
+    clearTimeout(refreshTimer);
+    refreshTimer = setTimeout(route, 500);
+  }
+
+  function disconnect() {
+    const old = socket;
+    socket = null;
+    if (old) old.close();
+  }
+
+  function connect() {
+    disconnect();
+
+    const protocol = location.protocol === "https:" ? "wss:" : "ws:";
+    const query = token() ? "?token=" + encodeURIComponent(token()) : "";
+    socket = new WebSocket(protocol + "//" + location.host + "/ws" + query);
+
+    socket.onopen = () => {
+      retryDelay = 1000;
+      live.textContent = "live";
+      live.classList.add("connected");
+      globalRooms.concat(viewRooms).forEach((room) => send("subscribe", room));
+    };
+
+    socket.onmessage = (event) => {
+      let msg;
+      try {
+        msg = JSON.parse(event.data);
+      } catch {
+        return;
+      }
+      if (["run_update", "agent_update", "service_update", "test_result"].includes(msg.type)) {
+        scheduleRefresh();
+      }
+    };
+
+    socket.onclose = () => {
+      live.textContent = "offline";
+      live.classList.remove("connected");
+      const closed = socket;
+      setTimeout(() => {
+        if (socket === closed) connect();
+      }, retryDelay);
+      retryDelay = Math.min(retryDelay * 2, 30000);
+    };
+  }
+
+  signout.addEventListener("click", () => {
+    localStorage.removeItem(TOKEN_KEY);
+    disconnect();
+    loginView();
+    signout.hidden = true;
+  });
+
+  window.addEventListener("hashchange", route);
+  connect();
+  route();
+})();
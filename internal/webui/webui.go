@@ -0,0 +1,70 @@
+// Package webui serves a minimal embedded single-page UI for installs that
+// do not deploy the separate dashboard. It talks to the REST API and the
+// WebSocket hub on the same origin and needs no build step.
+package webui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+//go:embed static
+var staticFS embed.FS
+
+// contentSecurityPolicy restricts the UI to same-origin scripts, styles,
+// API calls, and WebSocket connections.
+const contentSecurityPolicy = "default-src 'self'; connect-src 'self' ws: wss:; img-src 'self' data:; frame-ancestors 'none'"
+
+// Handler returns an http.Handler serving the UI under prefix (e.g. "/ui").
+// Unknown paths below the prefix serve index.html so the page can be
+// reloaded on any client-side route.
+func Handler(prefix string) http.Handler {
+	prefix = "/" + strings.Trim(prefix, "/")
+
+	assets, err := fs.Sub(staticFS, "static")
+	if err != nil {
+		// The embedded directory is fixed at compile time.
+		panic(err)
+	}
+	files := http.FileServer(http.FS(assets))
+
+	return http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Security-Policy", contentSecurityPolicy)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" || name == "index.html" {
+			serveIndex(w, assets)
+			return
+		}
+		if _, err := fs.Stat(assets, name); err != nil {
+			serveIndex(w, assets)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-cache")
+		files.ServeHTTP(w, r)
+	}))
+}
+
+// serveIndex writes index.html. It is served directly rather than through
+// http.FileServer, which redirects requests for index.html to "./".
+func serveIndex(w http.ResponseWriter, assets fs.FS) {
+	data, err := fs.ReadFile(assets, "index.html")
+	if err != nil {
+		http.Error(w, "ui not available", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,47 @@
+package webui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serve(t *testing.T, method, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handler("/ui").ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestHandlerServesIndex(t *testing.T) {
+	for _, target := range []string{"/ui/", "/ui/index.html", "/ui/runs/123"} {
+		rec := serve(t, http.MethodGet, target)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", target, rec.Code, http.StatusOK)
+		}
+		if !strings.Contains(rec.Body.String(), `<script src="app.js">`) {
+			t.Fatalf("%s: expected index.html, got %q", target, rec.Body.String())
+		}
+		if rec.Header().Get("Content-Security-Policy") == "" {
+			t.Fatalf("%s: missing Content-Security-Policy header", target)
+		}
+	}
+}
+
+func TestHandlerServesAssets(t *testing.T) {
+	rec := serve(t, http.MethodGet, "/ui/app.js")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.Contains(ct, "javascript") {
+		t.Fatalf("Content-Type = %q, want javascript", ct)
+	}
+}
+
+func TestHandlerRejectsWrites(t *testing.T) {
+	rec := serve(t, http.MethodPost, "/ui/")
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -3,6 +3,7 @@ package wire
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,6 +11,7 @@ import (
 	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
 	"github.com/conductor/conductor/internal/artifact"
 	"github.com/conductor/conductor/internal/database"
+	"github.com/conductor/conductor/internal/placement"
 	"github.com/conductor/conductor/internal/server"
 )
 
@@ -62,10 +64,20 @@ func (a *AgentRepositoryAdapter) List(ctx context.Context, filter server.AgentFi
 		return nil, 0, err
 	}
 
-	// Convert to pointer slice
-	result := make([]*database.Agent, len(agents))
+	// Convert to pointer slice, keeping agents that carry the filter labels
+	// and joined the filter pool
+	result := make([]*database.Agent, 0, len(agents))
 	for i := range agents {
-		result[i] = &agents[i]
+		if !placement.LabelsMatch(filter.Labels, agents[i].Labels) {
+			continue
+		}
+		if filter.Pool != "" && (agents[i].Pool == nil || *agents[i].Pool != filter.Pool) {
+			continue
+		}
+		result = append(result, &agents[i])
+	}
+	if len(filter.Labels) > 0 || filter.Pool != "" {
+		return result, len(result), nil
 	}
 
 	// Get count
@@ -117,7 +129,9 @@ func (a *RunRepositoryAdapter) List(ctx context.Context, filter server.RunFilter
 	var err error
 
 	// Apply filters - database repo has limited filtering support
-	if filter.ServiceID != nil && len(filter.Statuses) > 0 {
+	if filter.AgentID != nil && filter.StartTime != nil && filter.EndTime != nil {
+		runs, err = a.repo.ListByAgent(ctx, *filter.AgentID, *filter.StartTime, *filter.EndTime, pagination)
+	} else if filter.ServiceID != nil && len(filter.Statuses) > 0 {
 		runs, err = a.repo.ListByServiceAndStatus(ctx, *filter.ServiceID, filter.Statuses[0], pagination)
 	} else if filter.ServiceID != nil {
 		runs, err = a.repo.ListByService(ctx, *filter.ServiceID, pagination)
@@ -324,16 +338,7 @@ func (a *ResultRepositoryAdapter) GetByRunID(ctx context.Context, runID uuid.UUI
 }
 
 func (a *ResultRepositoryAdapter) List(ctx context.Context, runID uuid.UUID, filter server.ResultFilter, pagination database.Pagination) ([]*database.TestResult, int, error) {
-	var results []database.TestResult
-	var err error
-
-	if len(filter.Statuses) > 0 {
-		// Use first status for now - database repo only supports single status
-		results, err = a.repo.ListByRunAndStatus(ctx, runID, filter.Statuses[0])
-	} else {
-		results, err = a.repo.ListByRun(ctx, runID)
-	}
-
+	results, err := a.repo.ListPage(ctx, runID, filter.Statuses, pagination)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -344,13 +349,29 @@ func (a *ResultRepositoryAdapter) List(ctx context.Context, runID uuid.UUID, fil
 		ptrs[i] = &results[i]
 	}
 
-	return ptrs, len(ptrs), nil
+	// Get the count of the results with the filter statuses
+	counts, err := a.repo.CountByRun(ctx, runID)
+	if err != nil {
+		return ptrs, len(ptrs), nil
+	}
+	var total int64
+	for status, count := range counts {
+		if len(filter.Statuses) == 0 || slices.Contains(filter.Statuses, status) {
+			total += count
+		}
+	}
+
+	return ptrs, int(total), nil
 }
 
 func (a *ResultRepositoryAdapter) Create(ctx context.Context, result *database.TestResult) error {
 	return a.repo.Create(ctx, result)
 }
 
+func (a *ResultRepositoryAdapter) GetLastPassing(ctx context.Context, serviceID uuid.UUID, testName string, suiteName *string, before time.Time) (*database.TestResult, error) {
+	return a.repo.GetLastPassing(ctx, serviceID, testName, suiteName, before)
+}
+
 // ArtifactRepositoryAdapter adapts database.ArtifactRepository to server.ArtifactRepository.
 type ArtifactRepositoryAdapter struct {
 	repo database.ArtifactRepository
@@ -386,11 +407,15 @@ func (a *ArtifactRepositoryAdapter) Create(ctx context.Context, artifact *databa
 	return a.repo.Create(ctx, artifact)
 }
 
+func (a *ArtifactRepositoryAdapter) UsageByTest(ctx context.Context, runID, testID uuid.UUID) (int, int64, error) {
+	return a.repo.UsageByTest(ctx, runID, testID)
+}
+
 // NoopScheduler implements server.WorkScheduler as a no-op for initial setup.
 // TODO: Replace with real scheduler integration.
 type NoopScheduler struct{}
 
-func (s *NoopScheduler) AssignWork(ctx context.Context, agentID uuid.UUID, capabilities *conductorv1.Capabilities) (*conductorv1.AssignWork, error) {
+func (s *NoopScheduler) AssignWork(ctx context.Context, agentID uuid.UUID, capabilities *conductorv1.Capabilities, labels map[string]string, pool string) (*conductorv1.AssignWork, error) {
 	return nil, nil
 }
 
@@ -448,7 +473,7 @@ func (a *GitSyncerAdapter) SyncService(ctx context.Context, service *database.Se
 // TODO: Replace with S3/MinIO implementation.
 type NoopArtifactStorage struct{}
 
-func (s *NoopArtifactStorage) GenerateDownloadURL(ctx context.Context, path string, expirationSeconds int) (string, time.Time, error) {
+func (s *NoopArtifactStorage) GenerateDownloadURL(ctx context.Context, path string, expirationSeconds int, opts server.DownloadURLOptions) (string, time.Time, error) {
 	return "", time.Now().Add(time.Hour), nil
 }
 
@@ -463,13 +488,17 @@ func NewArtifactStorageAdapter(storage *artifact.Storage) *ArtifactStorageAdapte
 }
 
 // GenerateDownloadURL generates a presigned download URL for an artifact.
-func (a *ArtifactStorageAdapter) GenerateDownloadURL(ctx context.Context, path string, expirationSeconds int) (string, time.Time, error) {
+func (a *ArtifactStorageAdapter) GenerateDownloadURL(ctx context.Context, path string, expirationSeconds int, opts server.DownloadURLOptions) (string, time.Time, error) {
 	expiration := time.Duration(expirationSeconds) * time.Second
 	if expirationSeconds <= 0 {
 		expiration = time.Hour // Default 1 hour
 	}
 
-	url, err := a.storage.GetPresignedURL(ctx, path, expiration)
+	url, err := a.storage.GetPresignedURLWithOptions(ctx, path, expiration, artifact.PresignOptions{
+		ContentType: opts.ContentType,
+		Inline:      opts.Inline,
+		FileName:    opts.FileName,
+	})
 	if err != nil {
 		return "", time.Time{}, err
 	}
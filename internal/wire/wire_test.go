@@ -127,8 +127,8 @@ func (m *mockAgentRepository) CountByStatus(ctx context.Context) (map[database.A
 	return m.countByStatus, nil
 }
 
-func (m *mockAgentRepository) MarkOfflineAgents(ctx context.Context) (int64, error) {
-	return 0, nil
+func (m *mockAgentRepository) MarkOfflineAgents(ctx context.Context, timeout time.Duration) ([]database.Agent, error) {
+	return nil, nil
 }
 
 // TestRunRepository mock
@@ -233,6 +233,23 @@ func (m *mockTestRunRepository) ListByDateRange(ctx context.Context, start, end
 	return m.List(ctx, pagination)
 }
 
+func (m *mockTestRunRepository) ListByAgent(ctx context.Context, agentID uuid.UUID, start, end time.Time, pagination database.Pagination) ([]database.TestRun, error) {
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
+	var runs []database.TestRun
+	for _, r := range m.runs {
+		if r.AgentID != nil && *r.AgentID == agentID && r.StartedAt != nil && !r.StartedAt.Before(start) && r.StartedAt.Before(end) {
+			runs = append(runs, *r)
+		}
+	}
+	return runs, nil
+}
+
+func (m *mockTestRunRepository) Search(ctx context.Context, filter database.RunSearchFilter, pagination database.Pagination) ([]database.TestRun, int, error) {
+	return nil, 0, nil
+}
+
 func (m *mockTestRunRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status database.RunStatus) error {
 	if r, ok := m.runs[id]; ok {
 		r.Status = status
@@ -240,6 +257,30 @@ func (m *mockTestRunRepository) UpdateStatus(ctx context.Context, id uuid.UUID,
 	return nil
 }
 
+func (m *mockTestRunRepository) Approve(ctx context.Context, id uuid.UUID, approvedBy string) error {
+	if r, ok := m.runs[id]; ok {
+		r.Status = database.RunStatusPending
+		r.ApprovedBy = &approvedBy
+	}
+	return nil
+}
+
+func (m *mockTestRunRepository) Retarget(ctx context.Context, id uuid.UUID, gitSHA *string) error {
+	if r, ok := m.runs[id]; ok {
+		r.GitSHA = gitSHA
+	}
+	return nil
+}
+
+func (m *mockTestRunRepository) Annotate(ctx context.Context, id uuid.UUID, metadata database.RunMetadata) (*database.RunMetadata, error) {
+	r, ok := m.runs[id]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	r.Metadata = metadata
+	return &r.Metadata, nil
+}
+
 func (m *mockTestRunRepository) Start(ctx context.Context, id uuid.UUID, agentID uuid.UUID) error {
 	if r, ok := m.runs[id]; ok {
 		r.AgentID = &agentID
@@ -263,6 +304,13 @@ func (m *mockTestRunRepository) Finish(ctx context.Context, id uuid.UUID, status
 	return nil
 }
 
+func (m *mockTestRunRepository) FinishActive(ctx context.Context, id uuid.UUID, status database.RunStatus, results database.RunResults) error {
+	if r, ok := m.runs[id]; !ok || r.IsTerminal() {
+		return database.ErrNotFound
+	}
+	return m.Finish(ctx, id, status, results)
+}
+
 func (m *mockTestRunRepository) UpdateShardStats(ctx context.Context, id uuid.UUID, shardCount int, shardsFailed int, results database.RunResults) error {
 	if r, ok := m.runs[id]; ok {
 		r.ShardCount = shardCount
@@ -301,6 +349,14 @@ func (m *mockTestRunRepository) CountByStatus(ctx context.Context) (map[database
 	return nil, nil
 }
 
+func (m *mockTestRunRepository) ReserveResults(ctx context.Context, id uuid.UUID, n, limit int) (int, int64, error) {
+	return n, 0, nil
+}
+
+func (m *mockTestRunRepository) ReserveArtifacts(ctx context.Context, id uuid.UUID, n, limit int) (int, int64, error) {
+	return n, 0, nil
+}
+
 // ServiceRepository mock
 
 type mockServiceRepository struct {
@@ -456,6 +512,20 @@ func (m *mockTestDefinitionRepository) ListByTags(ctx context.Context, serviceID
 	return m.ListByService(ctx, serviceID, pagination)
 }
 
+func (m *mockTestDefinitionRepository) ApplySync(ctx context.Context, sync *database.TestDefinitionSync) error {
+	for _, def := range sync.Create {
+		if err := m.Create(ctx, def); err != nil {
+			return err
+		}
+	}
+	for _, def := range sync.Update {
+		if err := m.Update(ctx, def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Tests
 
 func TestAgentRepositoryAdapter_Create(t *testing.T) {
@@ -691,6 +761,24 @@ func TestRunRepositoryAdapter_List_Filters(t *testing.T) {
 			t.Errorf("List() returned %d runs, want 1", len(runs))
 		}
 	})
+
+	t.Run("filter by agent", func(t *testing.T) {
+		agentID := uuid.New()
+		started := time.Now().Add(-time.Hour)
+		mock.runs[id1].AgentID = &agentID
+		mock.runs[id1].StartedAt = &started
+		defer func() { mock.runs[id1].AgentID, mock.runs[id1].StartedAt = nil, nil }()
+
+		start, end := started.Add(-time.Minute), time.Now()
+		filter := server.RunFilter{AgentID: &agentID, StartTime: &start, EndTime: &end}
+		runs, _, err := adapter.List(context.Background(), filter, database.Pagination{Limit: 10})
+		if err != nil {
+			t.Errorf("List() error = %v", err)
+		}
+		if len(runs) != 1 || runs[0].ID != id1 {
+			t.Errorf("List() returned %d runs, want run %s", len(runs), id1)
+		}
+	})
 }
 
 func TestServiceRepositoryAdapter_CRUD(t *testing.T) {
@@ -900,7 +988,7 @@ func TestNoopScheduler(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("AssignWork returns nil", func(t *testing.T) {
-		work, err := scheduler.AssignWork(ctx, uuid.New(), nil)
+		work, err := scheduler.AssignWork(ctx, uuid.New(), nil, nil, "")
 		if err != nil {
 			t.Errorf("AssignWork() error = %v", err)
 		}
@@ -954,7 +1042,7 @@ func TestNoopArtifactStorage(t *testing.T) {
 	storage := &NoopArtifactStorage{}
 	ctx := context.Background()
 
-	url, expiresAt, err := storage.GenerateDownloadURL(ctx, "/path/to/artifact", 3600)
+	url, expiresAt, err := storage.GenerateDownloadURL(ctx, "/path/to/artifact", 3600, server.DownloadURLOptions{})
 	if err != nil {
 		t.Errorf("GenerateDownloadURL() error = %v", err)
 	}
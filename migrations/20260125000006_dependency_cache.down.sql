@@ -0,0 +1,5 @@
+-- Rollback dependency cache configuration
+
+ALTER TABLE test_definitions
+    DROP COLUMN IF EXISTS cache_paths,
+    DROP COLUMN IF EXISTS cache_key;
@@ -0,0 +1,12 @@
+-- This migration adds dependency cache configuration to test definitions
+
+-- ============================================================================
+-- TEST_DEFINITIONS ADDITIONS
+-- Keyed dependency caches restored and saved by agents around execution
+-- ============================================================================
+ALTER TABLE test_definitions
+    ADD COLUMN cache_key VARCHAR(512),
+    ADD COLUMN cache_paths TEXT[];
+
+COMMENT ON COLUMN test_definitions.cache_key IS 'Dependency cache key template (e.g. go-{{ checksum "go.sum" }})';
+COMMENT ON COLUMN test_definitions.cache_paths IS 'Workspace-relative paths persisted under the cache key';
@@ -0,0 +1,4 @@
+-- Rollback per-service SSH deploy keys
+
+DROP TRIGGER IF EXISTS update_service_deploy_keys_updated_at ON service_deploy_keys;
+DROP TABLE IF EXISTS service_deploy_keys;
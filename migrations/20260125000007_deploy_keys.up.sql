@@ -0,0 +1,25 @@
+-- This migration adds per-service SSH deploy keys for private repository access
+
+-- ============================================================================
+-- SERVICE_DEPLOY_KEYS TABLE
+-- Encrypted SSH private keys used by agents to clone service repositories
+-- ============================================================================
+CREATE TABLE service_deploy_keys (
+    service_id UUID PRIMARY KEY REFERENCES services(id) ON DELETE CASCADE,
+    encrypted_private_key BYTEA NOT NULL,
+    public_key TEXT NOT NULL,
+    fingerprint VARCHAR(255) NOT NULL,
+    known_hosts TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER update_service_deploy_keys_updated_at
+    BEFORE UPDATE ON service_deploy_keys
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE service_deploy_keys IS 'SSH deploy keys for cloning private service repositories';
+COMMENT ON COLUMN service_deploy_keys.encrypted_private_key IS 'AES-256-GCM encrypted private key (nonce-prefixed)';
+COMMENT ON COLUMN service_deploy_keys.fingerprint IS 'SHA256 fingerprint of the public key';
+COMMENT ON COLUMN service_deploy_keys.known_hosts IS 'Optional known_hosts entries pinned for the git host';
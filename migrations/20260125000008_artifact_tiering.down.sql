@@ -0,0 +1,8 @@
+-- Rollback artifact storage class tiering
+
+DROP INDEX IF EXISTS idx_artifacts_storage_class_created_at;
+ALTER TABLE artifacts
+    DROP COLUMN IF EXISTS restored_until,
+    DROP COLUMN IF EXISTS restore_requested_at,
+    DROP COLUMN IF EXISTS archived_at,
+    DROP COLUMN IF EXISTS storage_class;
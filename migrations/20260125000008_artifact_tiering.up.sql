@@ -0,0 +1,19 @@
+-- This migration adds storage class tiering for old artifacts
+
+-- ============================================================================
+-- ARTIFACTS STORAGE CLASS
+-- Tracks which storage class each artifact lives in and any pending restore
+-- ============================================================================
+ALTER TABLE artifacts
+    ADD COLUMN storage_class VARCHAR(50) NOT NULL DEFAULT 'STANDARD',
+    ADD COLUMN archived_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN restore_requested_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN restored_until TIMESTAMP WITH TIME ZONE;
+
+-- Index for finding artifacts due for archival
+CREATE INDEX idx_artifacts_storage_class_created_at ON artifacts(storage_class, created_at);
+
+COMMENT ON COLUMN artifacts.storage_class IS 'S3 storage class of the object (STANDARD, STANDARD_IA, GLACIER, ...)';
+COMMENT ON COLUMN artifacts.archived_at IS 'When the object was transitioned out of the STANDARD storage class';
+COMMENT ON COLUMN artifacts.restore_requested_at IS 'When a restore of the archived object was last requested';
+COMMENT ON COLUMN artifacts.restored_until IS 'When the temporary restored copy of an archived object expires';
@@ -0,0 +1,6 @@
+-- Rollback commit status reporting
+
+DROP TRIGGER IF EXISTS update_service_git_credentials_updated_at ON service_git_credentials;
+DROP TABLE IF EXISTS service_git_credentials;
+ALTER TABLE test_runs
+    DROP COLUMN IF EXISTS pull_request_number;
@@ -0,0 +1,33 @@
+-- This migration adds commit status reporting for pull request runs
+
+-- ============================================================================
+-- TEST_RUNS PULL REQUEST
+-- Pull/merge request that triggered the run, if any
+-- ============================================================================
+ALTER TABLE test_runs
+    ADD COLUMN pull_request_number BIGINT;
+
+COMMENT ON COLUMN test_runs.pull_request_number IS 'Pull or merge request number for runs triggered by a PR/MR webhook';
+
+-- ============================================================================
+-- SERVICE_GIT_CREDENTIALS TABLE
+-- Encrypted provider API tokens used to report commit statuses per service
+-- ============================================================================
+CREATE TABLE service_git_credentials (
+    service_id UUID PRIMARY KEY REFERENCES services(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    base_url TEXT,
+    encrypted_token BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER update_service_git_credentials_updated_at
+    BEFORE UPDATE ON service_git_credentials
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE service_git_credentials IS 'Git provider API credentials for reporting commit statuses';
+COMMENT ON COLUMN service_git_credentials.provider IS 'Provider type: github, gitlab, bitbucket';
+COMMENT ON COLUMN service_git_credentials.base_url IS 'API base URL for self-hosted providers';
+COMMENT ON COLUMN service_git_credentials.encrypted_token IS 'AES-256-GCM encrypted API token (nonce-prefixed)';
@@ -0,0 +1,7 @@
+-- Rollback service environment sets
+
+ALTER TABLE test_definitions
+    DROP COLUMN IF EXISTS secrets,
+    DROP COLUMN IF EXISTS environment;
+DROP TRIGGER IF EXISTS update_service_environments_updated_at ON service_environments;
+DROP TABLE IF EXISTS service_environments;
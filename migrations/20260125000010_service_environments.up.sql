@@ -0,0 +1,33 @@
+-- This migration adds service-level environment and secret sets inherited by test definitions
+
+-- ============================================================================
+-- SERVICE_ENVIRONMENTS TABLE
+-- Environment variables and secret references shared by all tests of a service
+-- ============================================================================
+CREATE TABLE service_environments (
+    service_id UUID PRIMARY KEY REFERENCES services(id) ON DELETE CASCADE,
+    environment JSONB NOT NULL DEFAULT '{}',
+    secrets JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER update_service_environments_updated_at
+    BEFORE UPDATE ON service_environments
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE service_environments IS 'Default environment and secret references inherited by every test definition of a service';
+COMMENT ON COLUMN service_environments.environment IS 'Environment variables as a JSON object of name to value';
+COMMENT ON COLUMN service_environments.secrets IS 'Secret references resolved by agents: [{name, provider, path, key, version}]';
+
+-- ============================================================================
+-- TEST_DEFINITIONS OVERRIDES
+-- Per-definition environment and secrets that override the service set
+-- ============================================================================
+ALTER TABLE test_definitions
+    ADD COLUMN environment JSONB,
+    ADD COLUMN secrets JSONB;
+
+COMMENT ON COLUMN test_definitions.environment IS 'Environment variables overriding the service environment';
+COMMENT ON COLUMN test_definitions.secrets IS 'Secret references overriding service secrets with the same name';
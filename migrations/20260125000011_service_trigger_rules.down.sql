@@ -0,0 +1,4 @@
+-- Rollback service trigger rules
+
+DROP TRIGGER IF EXISTS update_service_trigger_rules_updated_at ON service_trigger_rules;
+DROP TABLE IF EXISTS service_trigger_rules;
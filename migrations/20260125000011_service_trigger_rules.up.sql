@@ -0,0 +1,30 @@
+-- This migration adds per-service trigger rules for webhook-triggered runs
+
+-- ============================================================================
+-- SERVICE_TRIGGER_RULES TABLE
+-- Branch, path, tag, and skip marker filters evaluated before scheduling runs
+-- ============================================================================
+CREATE TABLE service_trigger_rules (
+    service_id UUID PRIMARY KEY REFERENCES services(id) ON DELETE CASCADE,
+    branches TEXT[] NOT NULL DEFAULT '{}',
+    ignore_branches TEXT[] NOT NULL DEFAULT '{}',
+    paths TEXT[] NOT NULL DEFAULT '{}',
+    ignore_paths TEXT[] NOT NULL DEFAULT '{}',
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    skip_markers TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER update_service_trigger_rules_updated_at
+    BEFORE UPDATE ON service_trigger_rules
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE service_trigger_rules IS 'Filters deciding which webhook events trigger runs for a service';
+COMMENT ON COLUMN service_trigger_rules.branches IS 'Branch glob patterns that trigger runs; empty means all branches';
+COMMENT ON COLUMN service_trigger_rules.ignore_branches IS 'Branch glob patterns that never trigger runs';
+COMMENT ON COLUMN service_trigger_rules.paths IS 'Changed-path glob patterns, at least one of which must match; empty means any path';
+COMMENT ON COLUMN service_trigger_rules.ignore_paths IS 'Changed-path glob patterns ignored when deciding whether to run';
+COMMENT ON COLUMN service_trigger_rules.tags IS 'Tag glob patterns whose pushes trigger runs; empty means tags never trigger';
+COMMENT ON COLUMN service_trigger_rules.skip_markers IS 'Commit message or title markers, such as [skip ci], that suppress a run';
@@ -0,0 +1,7 @@
+-- Rollback per-run ingestion counters
+
+ALTER TABLE test_runs
+    DROP COLUMN IF EXISTS artifacts_dropped,
+    DROP COLUMN IF EXISTS artifacts_ingested,
+    DROP COLUMN IF EXISTS results_dropped,
+    DROP COLUMN IF EXISTS results_ingested;
@@ -0,0 +1,16 @@
+-- This migration adds per-run ingestion counters used to cap result and artifact counts
+
+-- ============================================================================
+-- TEST_RUNS INGESTION COUNTERS
+-- Results and artifacts stored for the run, and how many were dropped at the cap
+-- ============================================================================
+ALTER TABLE test_runs
+    ADD COLUMN results_ingested BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN results_dropped BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN artifacts_ingested INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN artifacts_dropped INTEGER NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN test_runs.results_ingested IS 'Test results accepted for the run while a per-run result cap is enforced';
+COMMENT ON COLUMN test_runs.results_dropped IS 'Test results discarded after the run reached the per-run result cap';
+COMMENT ON COLUMN test_runs.artifacts_ingested IS 'Artifacts accepted for the run while a per-run artifact cap is enforced';
+COMMENT ON COLUMN test_runs.artifacts_dropped IS 'Artifacts discarded after the run reached the per-run artifact cap';
@@ -0,0 +1,4 @@
+-- Rollback service root paths
+
+ALTER TABLE services
+    DROP COLUMN IF EXISTS root_path;
@@ -0,0 +1,10 @@
+-- This migration adds root paths for services that share a monorepo
+
+-- ============================================================================
+-- SERVICES ROOT PATH
+-- Directory of the repository a service lives in
+-- ============================================================================
+ALTER TABLE services
+    ADD COLUMN root_path TEXT;
+
+COMMENT ON COLUMN services.root_path IS 'Repository directory the service lives in; webhook events only trigger runs when it changed. NULL means the whole repository';
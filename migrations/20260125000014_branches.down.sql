@@ -0,0 +1,5 @@
+-- Rollback branches
+
+DROP INDEX IF EXISTS idx_test_runs_service_ref_created;
+DROP TRIGGER IF EXISTS update_branches_updated_at ON branches;
+DROP TABLE IF EXISTS branches;
@@ -0,0 +1,31 @@
+-- This migration adds branches as first-class entities of a service
+
+-- ============================================================================
+-- BRANCHES TABLE
+-- Branches of a service repository, maintained from webhooks and runs
+-- ============================================================================
+CREATE TABLE branches (
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    last_sha VARCHAR(64),
+    last_run_id UUID REFERENCES test_runs(id) ON DELETE SET NULL,
+    protected BOOLEAN NOT NULL DEFAULT FALSE,
+    last_pushed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (service_id, name)
+);
+
+CREATE TRIGGER update_branches_updated_at
+    BEFORE UPDATE ON branches
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Per-branch run history and health
+CREATE INDEX idx_test_runs_service_ref_created ON test_runs(service_id, git_ref, created_at DESC);
+
+COMMENT ON TABLE branches IS 'Branches of a service repository, created by push webhooks and removed when the branch is deleted';
+COMMENT ON COLUMN branches.last_sha IS 'Head commit of the branch as of the last push';
+COMMENT ON COLUMN branches.last_run_id IS 'Most recently created run of the branch';
+COMMENT ON COLUMN branches.protected IS 'Whether the branch is marked as protected, e.g. main or release branches';
+COMMENT ON COLUMN branches.last_pushed_at IS 'When the branch was last pushed to';
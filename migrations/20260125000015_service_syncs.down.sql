@@ -0,0 +1,7 @@
+-- Rollback service syncs
+
+DROP INDEX IF EXISTS idx_service_syncs_service_started;
+DROP TABLE IF EXISTS service_syncs;
+
+ALTER TABLE services
+    DROP COLUMN IF EXISTS sync_interval_seconds;
@@ -0,0 +1,35 @@
+-- This migration adds periodic re-sync of test definitions and sync history
+
+-- ============================================================================
+-- SERVICES SYNC INTERVAL
+-- Per-service override of how often definitions are re-synced
+-- ============================================================================
+ALTER TABLE services
+    ADD COLUMN sync_interval_seconds INTEGER CHECK (sync_interval_seconds >= 0);
+
+COMMENT ON COLUMN services.sync_interval_seconds IS 'How often test definitions are re-synced from the repository; 0 disables periodic sync. NULL uses the server default';
+
+-- ============================================================================
+-- SERVICE SYNCS TABLE
+-- History of test definition syncs from service repositories
+-- ============================================================================
+CREATE TABLE service_syncs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    branch VARCHAR(255) NOT NULL DEFAULT '',
+    trigger VARCHAR(20) NOT NULL CHECK (trigger IN ('manual', 'scheduled')),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('success', 'partial', 'failed')),
+    tests_added INTEGER NOT NULL DEFAULT 0,
+    tests_updated INTEGER NOT NULL DEFAULT 0,
+    tests_removed INTEGER NOT NULL DEFAULT 0,
+    errors TEXT[] NOT NULL DEFAULT '{}',
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_service_syncs_service_started ON service_syncs(service_id, started_at DESC);
+
+COMMENT ON TABLE service_syncs IS 'History of test definition syncs from service repositories';
+COMMENT ON COLUMN service_syncs.trigger IS 'What started the sync: manual (API) or scheduled (periodic re-sync)';
+COMMENT ON COLUMN service_syncs.status IS 'success, partial when some definitions were rejected, or failed';
+COMMENT ON COLUMN service_syncs.errors IS 'Validation and sync errors reported by the sync';
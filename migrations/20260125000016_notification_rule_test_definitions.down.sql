@@ -0,0 +1,6 @@
+-- Rollback notification rule test definitions
+
+DROP INDEX IF EXISTS idx_notification_rules_test_definition_id;
+
+ALTER TABLE notification_rules
+    DROP COLUMN IF EXISTS test_definition_id;
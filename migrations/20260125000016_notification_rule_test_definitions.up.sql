@@ -0,0 +1,13 @@
+-- This migration lets notification rules target a single test definition
+
+-- ============================================================================
+-- NOTIFICATION RULES TEST DEFINITION
+-- Rules of a test definition take precedence over service and global rules
+-- ============================================================================
+ALTER TABLE notification_rules
+    ADD COLUMN test_definition_id UUID REFERENCES test_definitions(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_notification_rules_test_definition_id ON notification_rules(test_definition_id)
+    WHERE test_definition_id IS NOT NULL;
+
+COMMENT ON COLUMN notification_rules.test_definition_id IS 'Test definition to notify for; overrides service and global rules when set. NULL means the whole service';
@@ -0,0 +1,13 @@
+-- Rollback retry policies
+
+DROP INDEX IF EXISTS idx_test_runs_retry_of_run_id;
+
+ALTER TABLE test_runs
+    DROP COLUMN IF EXISTS not_before,
+    DROP COLUMN IF EXISTS retry_of_run_id,
+    DROP COLUMN IF EXISTS attempt;
+
+DROP TRIGGER IF EXISTS update_retry_policies_updated_at ON retry_policies;
+DROP INDEX IF EXISTS idx_retry_policies_test_definition;
+DROP INDEX IF EXISTS idx_retry_policies_service;
+DROP TABLE IF EXISTS retry_policies;
@@ -0,0 +1,50 @@
+-- This migration adds automatic retry policies for runs that fail with
+-- infrastructure errors
+
+-- ============================================================================
+-- RETRY_POLICIES TABLE
+-- Per-service and per-test-definition policies for rescheduling runs
+-- ============================================================================
+CREATE TABLE retry_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    test_definition_id UUID REFERENCES test_definitions(id) ON DELETE CASCADE,
+    max_attempts INTEGER NOT NULL CHECK (max_attempts >= 1),
+    backoff_seconds INTEGER NOT NULL DEFAULT 30 CHECK (backoff_seconds >= 0),
+    max_backoff_seconds INTEGER NOT NULL DEFAULT 3600 CHECK (max_backoff_seconds >= backoff_seconds),
+    retry_on VARCHAR(20) NOT NULL DEFAULT 'error' CHECK (retry_on IN ('error', 'failure')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_retry_policies_service ON retry_policies(service_id)
+    WHERE test_definition_id IS NULL;
+CREATE UNIQUE INDEX idx_retry_policies_test_definition ON retry_policies(test_definition_id)
+    WHERE test_definition_id IS NOT NULL;
+
+CREATE TRIGGER update_retry_policies_updated_at
+    BEFORE UPDATE ON retry_policies
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE retry_policies IS 'Policies for automatically rescheduling runs that errored or failed';
+COMMENT ON COLUMN retry_policies.test_definition_id IS 'Test definition the policy applies to; NULL for the service policy';
+COMMENT ON COLUMN retry_policies.max_attempts IS 'Total attempts of a run, including the first';
+COMMENT ON COLUMN retry_policies.backoff_seconds IS 'Delay before the first retry; doubles with every further attempt';
+COMMENT ON COLUMN retry_policies.max_backoff_seconds IS 'Upper bound of the retry delay';
+COMMENT ON COLUMN retry_policies.retry_on IS 'error retries infrastructure errors only; failure also retries failed and timed out runs';
+
+-- ============================================================================
+-- TEST_RUNS RETRY ATTEMPTS
+-- ============================================================================
+ALTER TABLE test_runs
+    ADD COLUMN attempt INTEGER NOT NULL DEFAULT 1 CHECK (attempt >= 1),
+    ADD COLUMN retry_of_run_id UUID REFERENCES test_runs(id) ON DELETE SET NULL,
+    ADD COLUMN not_before TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_test_runs_retry_of_run_id ON test_runs(retry_of_run_id)
+    WHERE retry_of_run_id IS NOT NULL;
+
+COMMENT ON COLUMN test_runs.attempt IS 'Attempt number of the run; retries of a run increment it';
+COMMENT ON COLUMN test_runs.retry_of_run_id IS 'Run this run automatically retries';
+COMMENT ON COLUMN test_runs.not_before IS 'Earliest time a pending run may be scheduled, for retry backoff';
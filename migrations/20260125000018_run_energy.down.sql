@@ -0,0 +1,5 @@
+-- Rollback run energy samples
+
+DROP INDEX IF EXISTS idx_run_energy_samples_sampled_at;
+DROP INDEX IF EXISTS idx_run_energy_samples_run_id;
+DROP TABLE IF EXISTS run_energy_samples;
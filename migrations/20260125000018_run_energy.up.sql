@@ -0,0 +1,26 @@
+-- This migration adds energy samples of runs, estimated from the resource
+-- usage agents report with their heartbeats
+
+-- ============================================================================
+-- RUN_ENERGY_SAMPLES TABLE
+-- Estimated energy used by a run between two heartbeats of its agent
+-- ============================================================================
+CREATE TABLE run_energy_samples (
+    id BIGSERIAL PRIMARY KEY,
+    run_id UUID NOT NULL REFERENCES test_runs(id) ON DELETE CASCADE,
+    agent_id UUID REFERENCES agents(id) ON DELETE SET NULL,
+    zone VARCHAR(255) NOT NULL DEFAULT '',
+    cpu_percent DOUBLE PRECISION NOT NULL,
+    cpu_cores INTEGER NOT NULL,
+    memory_bytes BIGINT NOT NULL,
+    energy_wh DOUBLE PRECISION NOT NULL CHECK (energy_wh >= 0),
+    sampled_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_run_energy_samples_run_id ON run_energy_samples(run_id);
+CREATE INDEX idx_run_energy_samples_sampled_at ON run_energy_samples(sampled_at);
+
+COMMENT ON TABLE run_energy_samples IS 'Estimated energy used by runs, sampled from agent heartbeats';
+COMMENT ON COLUMN run_energy_samples.zone IS 'Network zone of the agent, for its carbon intensity factor';
+COMMENT ON COLUMN run_energy_samples.cpu_percent IS 'CPU utilization of the agent when sampled';
+COMMENT ON COLUMN run_energy_samples.energy_wh IS 'Estimated watt-hours since the previous heartbeat, split between the runs active on the agent';
@@ -0,0 +1,9 @@
+-- Rollback agent labels and label selectors
+
+ALTER TABLE test_runs
+    DROP COLUMN IF EXISTS label_selector;
+ALTER TABLE test_definitions
+    DROP COLUMN IF EXISTS label_selector;
+DROP INDEX IF EXISTS idx_agents_labels;
+ALTER TABLE agents
+    DROP COLUMN IF EXISTS labels;
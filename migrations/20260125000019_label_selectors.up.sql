@@ -0,0 +1,26 @@
+-- This migration adds agent labels and the label selectors test definitions
+-- and runs use to constrain which agents may run them
+
+-- ============================================================================
+-- AGENT LABELS
+-- Labels agents report on registration, including built-in os and arch
+-- ============================================================================
+ALTER TABLE agents
+    ADD COLUMN labels JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_agents_labels ON agents USING GIN (labels);
+
+COMMENT ON COLUMN agents.labels IS 'Agent labels as a JSON object of key to value, matched against label selectors';
+
+-- ============================================================================
+-- LABEL SELECTORS
+-- Labels an agent must carry to run a test definition or run
+-- ============================================================================
+ALTER TABLE test_definitions
+    ADD COLUMN label_selector JSONB;
+
+ALTER TABLE test_runs
+    ADD COLUMN label_selector JSONB;
+
+COMMENT ON COLUMN test_definitions.label_selector IS 'Labels an agent must carry to run this test, as a JSON object of key to value';
+COMMENT ON COLUMN test_runs.label_selector IS 'Labels an agent must carry to run any shard of this run, in addition to test definition selectors';
@@ -0,0 +1,4 @@
+-- Rollback test definition container images
+
+ALTER TABLE test_definitions
+    DROP COLUMN IF EXISTS container_image;
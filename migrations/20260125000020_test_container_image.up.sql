@@ -0,0 +1,11 @@
+-- This migration stores the container image of test definitions so it can be
+-- sent to agents and verified by pre-flight checks before a run is queued
+
+-- ============================================================================
+-- CONTAINER IMAGE
+-- Image container tests run in, from the repository test configuration
+-- ============================================================================
+ALTER TABLE test_definitions
+    ADD COLUMN container_image VARCHAR(500);
+
+COMMENT ON COLUMN test_definitions.container_image IS 'Container image for container execution, e.g. mcr.microsoft.com/playwright:v1.40.0';
@@ -0,0 +1,9 @@
+-- Rollback agent pools
+
+ALTER TABLE services
+    DROP COLUMN IF EXISTS agent_pool;
+DROP INDEX IF EXISTS idx_agents_pool;
+ALTER TABLE agents
+    DROP COLUMN IF EXISTS pool;
+DROP TRIGGER IF EXISTS update_agent_pools_updated_at ON agent_pools;
+DROP TABLE IF EXISTS agent_pools;
@@ -0,0 +1,39 @@
+-- This migration adds agent pools: named groups of agents with concurrency
+-- quotas that services can be pinned to
+
+-- ============================================================================
+-- AGENT POOLS
+-- Agents join a pool by name when they register
+-- ============================================================================
+CREATE TABLE agent_pools (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT,
+    max_concurrency INT NOT NULL DEFAULT 0,
+    max_concurrency_per_service INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER update_agent_pools_updated_at
+    BEFORE UPDATE ON agent_pools
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE agent_pools IS 'Named groups of agents with concurrency quotas';
+COMMENT ON COLUMN agent_pools.max_concurrency IS 'Maximum shards running on the pool''s agents at once; 0 means unlimited';
+COMMENT ON COLUMN agent_pools.max_concurrency_per_service IS 'Maximum shards of one service running on the pool''s agents at once; 0 means unlimited';
+
+-- ============================================================================
+-- POOL MEMBERSHIP
+-- ============================================================================
+ALTER TABLE agents
+    ADD COLUMN pool VARCHAR(255);
+
+CREATE INDEX idx_agents_pool ON agents(pool);
+
+ALTER TABLE services
+    ADD COLUMN agent_pool VARCHAR(255);
+
+COMMENT ON COLUMN agents.pool IS 'Name of the agent pool the agent joined on registration';
+COMMENT ON COLUMN services.agent_pool IS 'Name of the agent pool whose agents run the service; NULL allows any agent';
@@ -0,0 +1,6 @@
+-- Rollback test architectures
+
+ALTER TABLE run_shards
+    DROP COLUMN IF EXISTS arch;
+ALTER TABLE test_definitions
+    DROP COLUMN IF EXISTS architectures;
@@ -0,0 +1,20 @@
+-- This migration lets test definitions request execution on several CPU
+-- architectures; runs fan out one set of shards per requested architecture
+
+-- ============================================================================
+-- TEST ARCHITECTURES
+-- Architectures a test runs on, e.g. {amd64,arm64}; NULL runs it once on any
+-- ============================================================================
+ALTER TABLE test_definitions
+    ADD COLUMN architectures TEXT[];
+
+COMMENT ON COLUMN test_definitions.architectures IS 'CPU architectures the test runs on, once each; NULL runs it once on any architecture';
+
+-- ============================================================================
+-- SHARD ARCHITECTURE
+-- Architecture an agent must have to run a shard
+-- ============================================================================
+ALTER TABLE run_shards
+    ADD COLUMN arch VARCHAR(50);
+
+COMMENT ON COLUMN run_shards.arch IS 'CPU architecture the shard runs on; NULL runs on any architecture';
@@ -0,0 +1,19 @@
+-- Rollback agent assignment history
+
+DROP INDEX IF EXISTS idx_run_shards_agent_id;
+DROP INDEX IF EXISTS idx_test_runs_agent_started;
+
+UPDATE run_shards SET agent_id = NULL
+WHERE agent_id IS NOT NULL AND agent_id NOT IN (SELECT id FROM agents);
+UPDATE test_runs SET agent_id = NULL
+WHERE agent_id IS NOT NULL AND agent_id NOT IN (SELECT id FROM agents);
+
+ALTER TABLE run_shards
+    DROP COLUMN IF EXISTS agent_network_zones,
+    DROP COLUMN IF EXISTS agent_name,
+    ADD CONSTRAINT run_shards_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE SET NULL;
+
+ALTER TABLE test_runs
+    DROP COLUMN IF EXISTS agent_network_zones,
+    DROP COLUMN IF EXISTS agent_name,
+    ADD CONSTRAINT test_runs_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE SET NULL;
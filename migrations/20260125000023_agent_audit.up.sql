@@ -0,0 +1,37 @@
+-- This migration keeps the agent assignment history of runs and shards after
+-- agents are deleted, for audits of what ran on an agent
+
+-- ============================================================================
+-- AGENT ASSIGNMENT HISTORY
+-- Agent IDs are kept when agents are deleted; name and network zones are
+-- copied from the agent when a run or shard starts
+-- ============================================================================
+ALTER TABLE test_runs
+    DROP CONSTRAINT IF EXISTS test_runs_agent_id_fkey,
+    ADD COLUMN agent_name VARCHAR(255),
+    ADD COLUMN agent_network_zones TEXT[];
+
+ALTER TABLE run_shards
+    DROP CONSTRAINT IF EXISTS run_shards_agent_id_fkey,
+    ADD COLUMN agent_name VARCHAR(255),
+    ADD COLUMN agent_network_zones TEXT[];
+
+UPDATE test_runs r
+SET agent_name = a.name, agent_network_zones = a.network_zones
+FROM agents a
+WHERE r.agent_id = a.id;
+
+UPDATE run_shards s
+SET agent_name = a.name, agent_network_zones = a.network_zones
+FROM agents a
+WHERE s.agent_id = a.id;
+
+CREATE INDEX idx_test_runs_agent_started ON test_runs(agent_id, started_at DESC);
+CREATE INDEX idx_run_shards_agent_id ON run_shards(agent_id);
+
+COMMENT ON COLUMN test_runs.agent_id IS 'Agent that executed the run; kept after the agent is deleted';
+COMMENT ON COLUMN test_runs.agent_name IS 'Name of the executing agent when the run started';
+COMMENT ON COLUMN test_runs.agent_network_zones IS 'Network zones of the executing agent when the run started';
+COMMENT ON COLUMN run_shards.agent_id IS 'Agent that executed the shard; kept after the agent is deleted';
+COMMENT ON COLUMN run_shards.agent_name IS 'Name of the executing agent when the shard started';
+COMMENT ON COLUMN run_shards.agent_network_zones IS 'Network zones of the executing agent when the shard started';
@@ -0,0 +1,27 @@
+-- This migration adds run annotations: structured warnings agents attach to
+-- runs about their environment
+
+-- ============================================================================
+-- RUN_ANNOTATIONS TABLE
+-- Clock skew, disk pressure, slow clones and other infrastructure conditions
+-- ============================================================================
+CREATE TABLE run_annotations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id UUID NOT NULL REFERENCES test_runs(id) ON DELETE CASCADE,
+    shard_id UUID REFERENCES run_shards(id) ON DELETE CASCADE,
+    agent_id UUID,
+    kind VARCHAR(100) NOT NULL,
+    severity VARCHAR(20) NOT NULL DEFAULT 'warning',
+    message TEXT NOT NULL,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+
+    CONSTRAINT valid_annotation_severity CHECK (severity IN ('info', 'warning'))
+);
+
+CREATE INDEX idx_run_annotations_run_id ON run_annotations(run_id, created_at);
+
+COMMENT ON TABLE run_annotations IS 'Warnings agents attach to runs about their environment';
+COMMENT ON COLUMN run_annotations.agent_id IS 'Agent that reported the annotation; kept after the agent is deleted';
+COMMENT ON COLUMN run_annotations.kind IS 'Kind of condition, e.g. clock_skew, disk_pressure or slow_clone';
+COMMENT ON COLUMN run_annotations.metadata IS 'Measurements behind the annotation';
@@ -0,0 +1,4 @@
+-- Rollback service archiving
+
+ALTER TABLE services
+    DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,10 @@
+-- This migration adds archiving for decommissioned services
+
+-- ============================================================================
+-- SERVICES ARCHIVED AT
+-- Archived services keep their history but no longer trigger runs
+-- ============================================================================
+ALTER TABLE services
+    ADD COLUMN archived_at TIMESTAMP WITH TIME ZONE;
+
+COMMENT ON COLUMN services.archived_at IS 'When the service was archived; archived services are not triggered or synced and their test definitions are read-only. NULL means active';
@@ -0,0 +1,8 @@
+-- Rollback artifact budgets
+
+DROP INDEX IF EXISTS idx_artifacts_run_test;
+ALTER TABLE artifacts
+    DROP COLUMN IF EXISTS test_definition_id;
+ALTER TABLE test_definitions
+    DROP COLUMN IF EXISTS artifact_max_files,
+    DROP COLUMN IF EXISTS artifact_max_bytes;
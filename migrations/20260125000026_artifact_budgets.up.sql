@@ -0,0 +1,25 @@
+-- This migration lets test definitions cap the artifacts a run keeps for
+-- them, and records which test definition an artifact belongs to
+
+-- ============================================================================
+-- TEST ARTIFACT BUDGETS
+-- Caps on the artifacts kept per run of a test; NULL is unlimited
+-- ============================================================================
+ALTER TABLE test_definitions
+    ADD COLUMN artifact_max_bytes BIGINT CHECK (artifact_max_bytes > 0),
+    ADD COLUMN artifact_max_files INTEGER CHECK (artifact_max_files > 0);
+
+COMMENT ON COLUMN test_definitions.artifact_max_bytes IS 'Total size in bytes of the artifacts kept per run of the test; NULL is unlimited';
+COMMENT ON COLUMN test_definitions.artifact_max_files IS 'Number of artifacts kept per run of the test; NULL is unlimited';
+
+-- ============================================================================
+-- ARTIFACT TEST DEFINITION
+-- Test whose artifact patterns collected an artifact, charged to its budget
+-- ============================================================================
+ALTER TABLE artifacts
+    ADD COLUMN test_definition_id UUID REFERENCES test_definitions(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN artifacts.test_definition_id IS 'Test definition whose artifact patterns collected the artifact; NULL if unknown';
+
+CREATE INDEX idx_artifacts_run_test ON artifacts(run_id, test_definition_id)
+    WHERE test_definition_id IS NOT NULL;
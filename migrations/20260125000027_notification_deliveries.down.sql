@@ -0,0 +1,7 @@
+-- Rollback notification deliveries
+
+DROP INDEX IF EXISTS idx_notification_deliveries_run;
+DROP INDEX IF EXISTS idx_notification_deliveries_service_sent;
+DROP INDEX IF EXISTS idx_notification_deliveries_channel_sent;
+DROP INDEX IF EXISTS idx_notification_deliveries_sent;
+DROP TABLE IF EXISTS notification_deliveries;
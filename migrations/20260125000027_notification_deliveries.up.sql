@@ -0,0 +1,33 @@
+-- This migration adds a persisted log of notification deliveries
+
+-- ============================================================================
+-- NOTIFICATION DELIVERIES TABLE
+-- One row per notification sent to a channel, successful or not
+-- ============================================================================
+CREATE TABLE notification_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_type VARCHAR(50) NOT NULL,
+    service_id UUID REFERENCES services(id) ON DELETE CASCADE,
+    run_id UUID,
+    channel_id UUID REFERENCES notification_channels(id) ON DELETE SET NULL,
+    channel_type VARCHAR(50) NOT NULL,
+    rule_ids UUID[] NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL CHECK (status IN ('sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 1 CHECK (attempts >= 0),
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    response_excerpt TEXT NOT NULL DEFAULT '',
+    sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_notification_deliveries_sent ON notification_deliveries(sent_at DESC);
+CREATE INDEX idx_notification_deliveries_channel_sent ON notification_deliveries(channel_id, sent_at DESC);
+CREATE INDEX idx_notification_deliveries_service_sent ON notification_deliveries(service_id, sent_at DESC);
+CREATE INDEX idx_notification_deliveries_run ON notification_deliveries(run_id) WHERE run_id IS NOT NULL;
+
+COMMENT ON TABLE notification_deliveries IS 'Log of notifications sent to channels, one row per channel and event';
+COMMENT ON COLUMN notification_deliveries.event_type IS 'Notification type of the event, such as run_failed or agent_offline';
+COMMENT ON COLUMN notification_deliveries.channel_id IS 'Channel the notification was sent to; NULL once the channel is deleted';
+COMMENT ON COLUMN notification_deliveries.rule_ids IS 'Rules that routed the event to the channel';
+COMMENT ON COLUMN notification_deliveries.attempts IS 'Requests made to the channel, including retries';
+COMMENT ON COLUMN notification_deliveries.response_excerpt IS 'Start of the last response body returned by the channel';
@@ -0,0 +1,6 @@
+-- Rollback schedule time zones
+
+COMMENT ON COLUMN scheduled_runs.next_run_at IS 'Computed by scheduler; used for efficient polling';
+
+ALTER TABLE scheduled_runs
+    DROP COLUMN IF EXISTS timezone;
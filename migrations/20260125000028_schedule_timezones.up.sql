@@ -0,0 +1,11 @@
+-- This migration evaluates schedule cron expressions in an explicit time zone
+
+-- ============================================================================
+-- SCHEDULED_RUNS TIMEZONE
+-- IANA time zone of the cron expression; existing schedules keep UTC
+-- ============================================================================
+ALTER TABLE scheduled_runs
+    ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+COMMENT ON COLUMN scheduled_runs.timezone IS 'IANA time zone the cron expression is evaluated in, e.g. Europe/Stockholm. Wall-clock times skipped by a DST change fire at the end of the gap; repeated times fire once';
+COMMENT ON COLUMN scheduled_runs.next_run_at IS 'Computed by the scheduler in the schedule''s time zone; stored in UTC and used for efficient polling';
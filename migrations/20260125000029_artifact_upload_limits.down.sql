@@ -0,0 +1,4 @@
+-- Rollback pool artifact upload limits
+
+ALTER TABLE agent_pools
+    DROP COLUMN IF EXISTS max_artifact_bytes;
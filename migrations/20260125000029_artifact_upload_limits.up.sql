@@ -0,0 +1,10 @@
+-- This migration adds a per-pool limit on the size of artifacts agents upload
+
+-- ============================================================================
+-- AGENT_POOLS MAX ARTIFACT BYTES
+-- Combined with the agent's and the control plane's limits at registration
+-- ============================================================================
+ALTER TABLE agent_pools
+    ADD COLUMN max_artifact_bytes BIGINT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN agent_pools.max_artifact_bytes IS 'Largest artifact the pool''s agents may upload, in bytes; 0 means no pool limit. Agents get the smallest of this, their own and the control plane''s limit';
@@ -0,0 +1,7 @@
+-- Rollback test ownership
+
+ALTER TABLE notification_rules
+    DROP COLUMN IF EXISTS owner;
+
+ALTER TABLE test_definitions
+    DROP COLUMN IF EXISTS owner;
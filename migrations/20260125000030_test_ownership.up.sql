@@ -0,0 +1,20 @@
+-- This migration adds test ownership so that failure notifications can be
+-- routed to the teams owning the failed tests
+
+-- ============================================================================
+-- TEST_DEFINITIONS OWNER
+-- Set from the owner field of a test in the service manifest
+-- ============================================================================
+ALTER TABLE test_definitions
+    ADD COLUMN owner VARCHAR(255);
+
+COMMENT ON COLUMN test_definitions.owner IS 'Team or person owning the test; NULL means the service''s owner is responsible';
+
+-- ============================================================================
+-- NOTIFICATION_RULES OWNER
+-- Owner rules notify for the tests of one owner
+-- ============================================================================
+ALTER TABLE notification_rules
+    ADD COLUMN owner VARCHAR(255);
+
+COMMENT ON COLUMN notification_rules.owner IS 'Owner whose tests the rule notifies for; when the owners of all failed tests have rules, service and global rules stay quiet';
@@ -0,0 +1,5 @@
+-- Rollback notification rule templates
+
+ALTER TABLE notification_rules
+    DROP COLUMN IF EXISTS message_template,
+    DROP COLUMN IF EXISTS title_template;
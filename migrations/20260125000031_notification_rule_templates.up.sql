@@ -0,0 +1,12 @@
+-- This migration adds custom message templates to notification rules
+
+-- ============================================================================
+-- NOTIFICATION_RULES TEMPLATES
+-- Go text/template sources rendered with the event's template variables
+-- ============================================================================
+ALTER TABLE notification_rules
+    ADD COLUMN title_template TEXT,
+    ADD COLUMN message_template TEXT;
+
+COMMENT ON COLUMN notification_rules.title_template IS 'Go template of the notification title; NULL uses the built-in title of the event type';
+COMMENT ON COLUMN notification_rules.message_template IS 'Go template of the notification message; NULL uses the built-in message of the event type';
@@ -0,0 +1,8 @@
+-- Rollback notification redelivery
+
+DROP INDEX IF EXISTS idx_notification_deliveries_redelivery_of;
+
+ALTER TABLE notification_deliveries
+    DROP COLUMN IF EXISTS redelivery_of,
+    DROP COLUMN IF EXISTS payload_hash,
+    DROP COLUMN IF EXISTS payload;
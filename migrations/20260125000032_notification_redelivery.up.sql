@@ -0,0 +1,17 @@
+-- This migration stores the notification sent with each delivery so failed
+-- deliveries can be redelivered
+
+-- ============================================================================
+-- NOTIFICATION_DELIVERIES PAYLOADS
+-- The notification as sent to the channel, and the delivery it redelivers
+-- ============================================================================
+ALTER TABLE notification_deliveries
+    ADD COLUMN payload JSONB,
+    ADD COLUMN payload_hash VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN redelivery_of UUID REFERENCES notification_deliveries(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_notification_deliveries_redelivery_of ON notification_deliveries(redelivery_of) WHERE redelivery_of IS NOT NULL;
+
+COMMENT ON COLUMN notification_deliveries.payload IS 'Notification sent to the channel; NULL for deliveries recorded before payloads were stored';
+COMMENT ON COLUMN notification_deliveries.payload_hash IS 'Hex SHA-256 of payload; equal hashes mean the same notification';
+COMMENT ON COLUMN notification_deliveries.redelivery_of IS 'Failed delivery this delivery manually redelivers';
@@ -0,0 +1,4 @@
+-- Rollback notification dead letters
+
+DROP TRIGGER IF EXISTS update_notification_dead_letters_updated_at ON notification_dead_letters;
+DROP TABLE IF EXISTS notification_dead_letters;
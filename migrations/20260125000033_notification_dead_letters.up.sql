@@ -0,0 +1,31 @@
+-- This migration adds a dead-letter queue of notifications that could not be
+-- delivered
+
+-- ============================================================================
+-- NOTIFICATION DEAD LETTERS TABLE
+-- One row per undelivered notification, pointing at its latest failed delivery
+-- ============================================================================
+CREATE TABLE notification_dead_letters (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delivery_id UUID NOT NULL UNIQUE REFERENCES notification_deliveries(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES notification_channels(id) ON DELETE CASCADE,
+    error TEXT NOT NULL DEFAULT '',
+    transient BOOLEAN NOT NULL DEFAULT TRUE,
+    attempts INTEGER NOT NULL DEFAULT 0 CHECK (attempts >= 0),
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_notification_dead_letters_next_attempt ON notification_dead_letters(next_attempt_at) WHERE next_attempt_at IS NOT NULL;
+
+CREATE TRIGGER update_notification_dead_letters_updated_at
+    BEFORE UPDATE ON notification_dead_letters
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE notification_dead_letters IS 'Notifications that failed after all retries, waiting to be redelivered';
+COMMENT ON COLUMN notification_dead_letters.delivery_id IS 'Latest failed delivery of the notification; its payload is redelivered';
+COMMENT ON COLUMN notification_dead_letters.transient IS 'Whether the last failure may succeed when retried, such as a timeout or server error';
+COMMENT ON COLUMN notification_dead_letters.attempts IS 'Redeliveries attempted since the notification was dead-lettered';
+COMMENT ON COLUMN notification_dead_letters.next_attempt_at IS 'When the notification is redelivered automatically; NULL once it is no longer retried';
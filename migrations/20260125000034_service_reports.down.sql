@@ -0,0 +1,4 @@
+-- Rollback service reports
+
+DROP MATERIALIZED VIEW IF EXISTS service_recoveries;
+DROP MATERIALIZED VIEW IF EXISTS service_run_daily_stats;
@@ -0,0 +1,85 @@
+-- This migration adds materialized views caching the run statistics behind
+-- service success-rate reports
+
+-- ============================================================================
+-- SERVICE RUN DAILY STATS VIEW
+-- Completed runs per service, branch and UTC day. Durations are kept so that
+-- percentiles can be computed over any window of days.
+-- ============================================================================
+CREATE MATERIALIZED VIEW service_run_daily_stats AS
+SELECT
+    service_id,
+    COALESCE(git_ref, '') AS git_ref,
+    (finished_at AT TIME ZONE 'UTC')::date AS day,
+    COUNT(*) AS total_runs,
+    COUNT(*) FILTER (WHERE status = 'passed') AS passed_runs,
+    COUNT(*) FILTER (WHERE status <> 'passed') AS failed_runs,
+    COUNT(duration_ms) AS timed_runs,
+    COALESCE(SUM(duration_ms), 0) AS duration_sum_ms,
+    COALESCE(ARRAY_AGG(duration_ms) FILTER (WHERE duration_ms IS NOT NULL), '{}') AS durations_ms
+FROM test_runs
+WHERE status IN ('passed', 'failed', 'error', 'timeout')
+  AND finished_at IS NOT NULL
+GROUP BY service_id, COALESCE(git_ref, ''), (finished_at AT TIME ZONE 'UTC')::date;
+
+-- Required to refresh the view concurrently
+CREATE UNIQUE INDEX idx_service_run_daily_stats_key ON service_run_daily_stats(service_id, git_ref, day);
+CREATE INDEX idx_service_run_daily_stats_day ON service_run_daily_stats(day);
+
+COMMENT ON MATERIALIZED VIEW service_run_daily_stats IS 'Completed runs per service, branch and day for success-rate reports';
+COMMENT ON COLUMN service_run_daily_stats.failed_runs IS 'Runs that failed, errored or timed out; cancelled runs are not counted';
+COMMENT ON COLUMN service_run_daily_stats.timed_runs IS 'Runs with a recorded duration';
+COMMENT ON COLUMN service_run_daily_stats.durations_ms IS 'Durations of the timed runs in milliseconds';
+
+-- ============================================================================
+-- SERVICE RECOVERIES VIEW
+-- One row per failure streak of a service branch that ended with a passed
+-- run, from the first failed run to the run that passed
+-- ============================================================================
+CREATE MATERIALIZED VIEW service_recoveries AS
+WITH completed AS (
+    SELECT
+        id,
+        service_id,
+        COALESCE(git_ref, '') AS git_ref,
+        finished_at,
+        status = 'passed' AS passed
+    FROM test_runs
+    WHERE status IN ('passed', 'failed', 'error', 'timeout')
+      AND finished_at IS NOT NULL
+),
+changes AS (
+    SELECT
+        completed.*,
+        LAG(passed) OVER (PARTITION BY service_id, git_ref ORDER BY finished_at, id) AS previous_passed
+    FROM completed
+),
+transitions AS (
+    SELECT
+        id,
+        service_id,
+        git_ref,
+        finished_at,
+        passed,
+        LAG(id) OVER (PARTITION BY service_id, git_ref ORDER BY finished_at, id) AS previous_id,
+        LAG(finished_at) OVER (PARTITION BY service_id, git_ref ORDER BY finished_at, id) AS previous_finished_at
+    FROM changes
+    WHERE previous_passed IS DISTINCT FROM passed
+)
+SELECT
+    service_id,
+    git_ref,
+    previous_id AS failed_run_id,
+    id AS recovered_run_id,
+    previous_finished_at AS failed_at,
+    finished_at AS recovered_at
+FROM transitions
+WHERE passed AND previous_id IS NOT NULL;
+
+-- Required to refresh the view concurrently
+CREATE UNIQUE INDEX idx_service_recoveries_key ON service_recoveries(recovered_run_id);
+CREATE INDEX idx_service_recoveries_service_recovered ON service_recoveries(service_id, recovered_at);
+
+COMMENT ON MATERIALIZED VIEW service_recoveries IS 'Failure streaks of service branches ended by a passed run, for MTTR reports';
+COMMENT ON COLUMN service_recoveries.failed_run_id IS 'First failed run of the streak';
+COMMENT ON COLUMN service_recoveries.recovered_run_id IS 'Passed run that ended the streak';
@@ -0,0 +1,3 @@
+-- Rollback run duration regressions
+
+DROP TABLE IF EXISTS run_duration_regressions;
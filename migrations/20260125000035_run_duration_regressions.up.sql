@@ -0,0 +1,26 @@
+-- This migration adds duration regressions: runs and tests that took
+-- significantly longer than their recent passed runs on the same branch
+
+-- ============================================================================
+-- RUN_DURATION_REGRESSIONS TABLE
+-- One row per regressed run or test, with the baseline it was compared to
+-- ============================================================================
+CREATE TABLE run_duration_regressions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id UUID NOT NULL REFERENCES test_runs(id) ON DELETE CASCADE,
+    test_name VARCHAR(512),
+    duration_ms BIGINT NOT NULL,
+    baseline_mean_ms BIGINT NOT NULL,
+    baseline_stddev_ms BIGINT NOT NULL,
+    sigma DOUBLE PRECISION NOT NULL,
+    baseline_runs INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- A run or test regresses at most once per run
+CREATE UNIQUE INDEX idx_run_duration_regressions_run_test ON run_duration_regressions(run_id, COALESCE(test_name, ''));
+
+COMMENT ON TABLE run_duration_regressions IS 'Runs and tests that took significantly longer than their recent passed runs on the same branch';
+COMMENT ON COLUMN run_duration_regressions.test_name IS 'Regressed test; NULL when the run as a whole regressed';
+COMMENT ON COLUMN run_duration_regressions.sigma IS 'Standard deviations above the baseline mean; Infinity when the baseline durations are identical';
+COMMENT ON COLUMN run_duration_regressions.baseline_runs IS 'Number of previous passed runs the baseline was computed from';
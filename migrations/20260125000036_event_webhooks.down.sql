@@ -0,0 +1,6 @@
+-- Rollback event webhooks
+
+DROP TABLE IF EXISTS event_webhook_deliveries;
+DROP TRIGGER IF EXISTS record_test_runs_events ON test_runs;
+DROP FUNCTION IF EXISTS record_run_event();
+DROP TABLE IF EXISTS run_events;
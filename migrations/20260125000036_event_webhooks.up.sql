@@ -0,0 +1,78 @@
+-- This migration adds event webhooks: run lifecycle events recorded as runs
+-- change status, and their deliveries to the configured endpoints
+
+-- ============================================================================
+-- RUN_EVENTS TABLE
+-- Outbox of run lifecycle events, written by a trigger on test_runs so that
+-- every path that creates, starts or finishes a run records its event
+-- ============================================================================
+CREATE TABLE run_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    event_type VARCHAR(50) NOT NULL, -- run.created, run.started, run.finished
+    run_id UUID NOT NULL,
+    service_id UUID NOT NULL,
+    run JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    dispatched_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_run_events_undispatched ON run_events(id) WHERE dispatched_at IS NULL;
+CREATE INDEX idx_run_events_created_at ON run_events(created_at);
+
+COMMENT ON TABLE run_events IS 'Run lifecycle events waiting to be, or already, fanned out to event webhook endpoints';
+COMMENT ON COLUMN run_events.run_id IS 'Run the event is about; not a foreign key so events of deleted runs are still delivered';
+COMMENT ON COLUMN run_events.run IS 'Snapshot of the test_runs row at the time of the event';
+COMMENT ON COLUMN run_events.dispatched_at IS 'When deliveries were created for the endpoints subscribed to the event; NULL until then';
+
+CREATE OR REPLACE FUNCTION record_run_event()
+RETURNS TRIGGER AS $$
+DECLARE
+    kind VARCHAR(50);
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        kind := 'run.created';
+    ELSIF NEW.status = 'running' AND OLD.status IS DISTINCT FROM 'running' THEN
+        kind := 'run.started';
+    ELSIF NEW.status IN ('passed', 'failed', 'error', 'timeout', 'cancelled')
+        AND OLD.status NOT IN ('passed', 'failed', 'error', 'timeout', 'cancelled') THEN
+        kind := 'run.finished';
+    ELSE
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO run_events (event_type, run_id, service_id, run)
+    VALUES (kind, NEW.id, NEW.service_id, to_jsonb(NEW));
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER record_test_runs_events
+    AFTER INSERT OR UPDATE OF status ON test_runs
+    FOR EACH ROW
+    EXECUTE FUNCTION record_run_event();
+
+-- ============================================================================
+-- EVENT_WEBHOOK_DELIVERIES TABLE
+-- One row per event and subscribed endpoint, tracking retries
+-- ============================================================================
+CREATE TABLE event_webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id BIGINT NOT NULL REFERENCES run_events(id) ON DELETE CASCADE,
+    endpoint VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending', -- pending, delivered, failed
+    attempts INTEGER NOT NULL DEFAULT 0 CHECK (attempts >= 0),
+    next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    response_status INTEGER,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (event_id, endpoint)
+);
+
+CREATE INDEX idx_event_webhook_deliveries_due ON event_webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+COMMENT ON TABLE event_webhook_deliveries IS 'Deliveries of run lifecycle events to event webhook endpoints';
+COMMENT ON COLUMN event_webhook_deliveries.endpoint IS 'Name of the configured endpoint';
+COMMENT ON COLUMN event_webhook_deliveries.next_attempt_at IS 'When a pending delivery is attempted next';
+COMMENT ON COLUMN event_webhook_deliveries.response_status IS 'HTTP status of the last attempt; NULL when no response was received';
@@ -0,0 +1,38 @@
+-- This migration adds the audit log: one row per mutating API call
+
+-- ============================================================================
+-- AUDIT_LOGS TABLE
+-- Who changed what, when and from where, with the state of the changed
+-- resource before and after the call
+-- ============================================================================
+CREATE TABLE audit_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor VARCHAR(255) NOT NULL,
+    actor_email VARCHAR(255) NOT NULL DEFAULT '',
+    action VARCHAR(255) NOT NULL,
+    protocol VARCHAR(10) NOT NULL CHECK (protocol IN ('grpc', 'http')),
+    resource_type VARCHAR(50) NOT NULL DEFAULT '',
+    resource_id VARCHAR(255) NOT NULL DEFAULT '',
+    request JSONB,
+    before JSONB,
+    after JSONB,
+    status VARCHAR(50) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    source_ip VARCHAR(64) NOT NULL DEFAULT '',
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at DESC);
+CREATE INDEX idx_audit_logs_actor ON audit_logs(actor, created_at DESC);
+CREATE INDEX idx_audit_logs_resource ON audit_logs(resource_type, resource_id, created_at DESC);
+
+COMMENT ON TABLE audit_logs IS 'Mutating API calls, kept for the configured audit retention';
+COMMENT ON COLUMN audit_logs.actor IS 'Subject of the caller''s token, or anonymous for unauthenticated calls such as git webhooks';
+COMMENT ON COLUMN audit_logs.action IS 'Full gRPC method, or HTTP method and path';
+COMMENT ON COLUMN audit_logs.request IS 'Request message with secrets redacted; NULL for HTTP calls';
+COMMENT ON COLUMN audit_logs.before IS 'State of the resource before the call with secrets redacted; NULL if it did not exist or is not tracked';
+COMMENT ON COLUMN audit_logs.after IS 'Response of the call with secrets redacted; NULL if the call failed';
+COMMENT ON COLUMN audit_logs.status IS 'gRPC status code name, or HTTP status code';
+COMMENT ON COLUMN audit_logs.source_ip IS 'Address of the client connected to the control plane';
@@ -0,0 +1,8 @@
+-- Rollback organizations and projects
+
+ALTER TABLE notification_channels DROP COLUMN IF EXISTS project_id;
+ALTER TABLE agents DROP COLUMN IF EXISTS project_id;
+ALTER TABLE services DROP COLUMN IF EXISTS project_id;
+
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS organizations;
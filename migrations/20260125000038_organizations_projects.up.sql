@@ -0,0 +1,72 @@
+-- This migration adds organizations and projects. Services, agents and
+-- notification channels belong to a project; existing ones are moved to the
+-- default project of the default organization
+
+-- ============================================================================
+-- ORGANIZATIONS TABLE
+-- ============================================================================
+CREATE TABLE organizations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL UNIQUE,
+    display_name VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER update_organizations_updated_at
+    BEFORE UPDATE ON organizations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE organizations IS 'Tenants of the control plane; an organization groups projects';
+
+-- ============================================================================
+-- PROJECTS TABLE
+-- ============================================================================
+CREATE TABLE projects (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE RESTRICT,
+    name VARCHAR(255) NOT NULL,
+    display_name VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    UNIQUE (organization_id, name)
+);
+
+CREATE TRIGGER update_projects_updated_at
+    BEFORE UPDATE ON projects
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE projects IS 'Projects own services, agents and notification channels; callers only see the projects they are members of';
+
+-- The default organization and project keep the resources created before
+-- multi-tenancy, and those created without a project
+INSERT INTO organizations (id, name, display_name)
+VALUES ('00000000-0000-0000-0000-000000000001', 'default', 'Default');
+
+INSERT INTO projects (id, organization_id, name, display_name)
+VALUES ('00000000-0000-0000-0000-000000000001', '00000000-0000-0000-0000-000000000001', 'default', 'Default');
+
+-- ============================================================================
+-- PROJECT OWNERSHIP
+-- ============================================================================
+ALTER TABLE services
+    ADD COLUMN project_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001'
+    REFERENCES projects(id) ON DELETE RESTRICT;
+
+ALTER TABLE agents
+    ADD COLUMN project_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001'
+    REFERENCES projects(id) ON DELETE RESTRICT;
+
+ALTER TABLE notification_channels
+    ADD COLUMN project_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001'
+    REFERENCES projects(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_services_project_id ON services(project_id);
+CREATE INDEX idx_agents_project_id ON agents(project_id);
+CREATE INDEX idx_notification_channels_project_id ON notification_channels(project_id);
+
+COMMENT ON COLUMN services.project_id IS 'Project the service belongs to; its runs, tests and schedules belong to the same project';
+COMMENT ON COLUMN agents.project_id IS 'Project the agent belongs to; it only runs the tests of that project''s services';
+COMMENT ON COLUMN notification_channels.project_id IS 'Project the channel belongs to';
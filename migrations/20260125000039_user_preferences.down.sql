@@ -0,0 +1,4 @@
+-- Rollback user preferences
+
+DROP TABLE IF EXISTS user_saved_views;
+DROP TABLE IF EXISTS user_pinned_services;
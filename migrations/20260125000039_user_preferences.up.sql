@@ -0,0 +1,52 @@
+-- This migration adds dashboard preferences: the services a user pinned and
+-- the views they saved, keyed by the subject of their token
+
+-- ============================================================================
+-- USER_PINNED_SERVICES TABLE
+-- ============================================================================
+CREATE TABLE user_pinned_services (
+    user_id VARCHAR(255) NOT NULL,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (user_id, service_id)
+);
+
+CREATE INDEX idx_user_pinned_services_service_id ON user_pinned_services(service_id);
+
+COMMENT ON TABLE user_pinned_services IS 'Services a user pinned to their dashboard';
+COMMENT ON COLUMN user_pinned_services.user_id IS 'Subject of the user''s token';
+
+-- ============================================================================
+-- USER_SAVED_VIEWS TABLE
+-- Named filters and display settings of a dashboard page, optionally limited
+-- to the dashboard of one service
+-- ============================================================================
+CREATE TABLE user_saved_views (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    page VARCHAR(50) NOT NULL,
+    service_id UUID REFERENCES services(id) ON DELETE CASCADE,
+    filters JSONB NOT NULL DEFAULT '{}',
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    UNIQUE NULLS NOT DISTINCT (user_id, page, service_id, name)
+);
+
+-- A page has at most one default view per user and service
+CREATE UNIQUE INDEX idx_user_saved_views_default
+    ON user_saved_views(user_id, page, service_id) NULLS NOT DISTINCT
+    WHERE is_default;
+
+CREATE TRIGGER update_user_saved_views_updated_at
+    BEFORE UPDATE ON user_saved_views
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE user_saved_views IS 'Dashboard views a user saved';
+COMMENT ON COLUMN user_saved_views.user_id IS 'Subject of the user''s token';
+COMMENT ON COLUMN user_saved_views.page IS 'Dashboard page the view applies to, e.g. runs or services';
+COMMENT ON COLUMN user_saved_views.service_id IS 'Service whose dashboard the view applies to; NULL for the whole page';
+COMMENT ON COLUMN user_saved_views.filters IS 'JSON object of filters and display settings restored by the frontend';
+COMMENT ON COLUMN user_saved_views.is_default IS 'Whether the view opens by default on its page';
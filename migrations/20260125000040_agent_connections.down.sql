@@ -0,0 +1,3 @@
+-- Rollback agent connections
+
+DROP TABLE IF EXISTS agent_connections;
@@ -0,0 +1,18 @@
+-- This migration adds the registry of the control plane replica each agent
+-- holds its work stream with, so that any replica can reach any agent
+
+-- ============================================================================
+-- AGENT_CONNECTIONS TABLE
+-- ============================================================================
+CREATE TABLE agent_connections (
+    agent_id UUID PRIMARY KEY REFERENCES agents(id) ON DELETE CASCADE,
+    replica_id VARCHAR(255) NOT NULL,
+    replica_address VARCHAR(255) NOT NULL,
+    connected_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_agent_connections_replica_id ON agent_connections(replica_id);
+
+COMMENT ON TABLE agent_connections IS 'Control plane replica each connected agent holds its work stream with';
+COMMENT ON COLUMN agent_connections.replica_id IS 'ID of the control plane replica';
+COMMENT ON COLUMN agent_connections.replica_address IS 'gRPC address other replicas reach the replica at';
@@ -0,0 +1,51 @@
+-- Rollback: Merge the monthly partitions of test_results back into one table.
+-- Archived months are not restored.
+
+DROP TABLE IF EXISTS test_result_archives;
+
+ALTER TABLE test_results RENAME TO test_results_partitioned;
+ALTER TABLE test_results_partitioned RENAME CONSTRAINT test_results_pkey TO test_results_partitioned_pkey;
+DROP INDEX idx_test_results_run_id;
+DROP INDEX idx_test_results_status;
+DROP INDEX idx_test_results_test_name;
+DROP INDEX idx_test_results_run_status;
+DROP INDEX idx_test_results_shard_id;
+
+CREATE TABLE test_results (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id UUID NOT NULL REFERENCES test_runs(id) ON DELETE CASCADE,
+    test_definition_id UUID REFERENCES test_definitions(id) ON DELETE SET NULL,
+    test_name VARCHAR(512) NOT NULL,
+    suite_name VARCHAR(255),
+    status VARCHAR(50) NOT NULL,
+    duration_ms BIGINT,
+    error_message TEXT,
+    stack_trace TEXT,
+    stdout TEXT,
+    stderr TEXT,
+    retry_count INTEGER DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    shard_id UUID REFERENCES run_shards(id) ON DELETE SET NULL
+);
+
+INSERT INTO test_results (
+    id, run_id, test_definition_id, test_name, suite_name, status, duration_ms,
+    error_message, stack_trace, stdout, stderr, retry_count, created_at, shard_id
+)
+SELECT
+    id, run_id, test_definition_id, test_name, suite_name, status, duration_ms,
+    error_message, stack_trace, stdout, stderr, retry_count, created_at, shard_id
+FROM test_results_partitioned;
+
+DROP TABLE test_results_partitioned;
+DROP FUNCTION IF EXISTS create_test_results_partition(DATE);
+
+CREATE INDEX idx_test_results_run_id ON test_results(run_id);
+CREATE INDEX idx_test_results_status ON test_results(status);
+CREATE INDEX idx_test_results_test_name ON test_results(test_name);
+CREATE INDEX idx_test_results_run_status ON test_results(run_id, status);
+CREATE INDEX idx_test_results_shard_id ON test_results(shard_id);
+
+COMMENT ON TABLE test_results IS 'Individual test case results from parsed output';
+COMMENT ON COLUMN test_results.status IS 'Test outcome: pass, fail, skip, error';
+COMMENT ON COLUMN test_results.retry_count IS 'Retry attempt number (0 = first attempt)';
@@ -0,0 +1,120 @@
+-- This migration partitions test_results by month of created_at, so that old
+-- results can be archived to object storage and dropped a month at a time,
+-- and records the archived months
+
+-- ============================================================================
+-- PARTITIONED TEST_RESULTS TABLE
+-- The primary key of a partitioned table must include the partition key
+-- ============================================================================
+ALTER TABLE test_results RENAME TO test_results_unpartitioned;
+ALTER TABLE test_results_unpartitioned RENAME CONSTRAINT test_results_pkey TO test_results_unpartitioned_pkey;
+DROP INDEX idx_test_results_run_id;
+DROP INDEX idx_test_results_status;
+DROP INDEX idx_test_results_test_name;
+DROP INDEX idx_test_results_run_status;
+DROP INDEX idx_test_results_shard_id;
+
+CREATE TABLE test_results (
+    id UUID DEFAULT gen_random_uuid() NOT NULL,
+    run_id UUID NOT NULL REFERENCES test_runs(id) ON DELETE CASCADE,
+    test_definition_id UUID REFERENCES test_definitions(id) ON DELETE SET NULL,
+    test_name VARCHAR(512) NOT NULL,
+    suite_name VARCHAR(255),
+    status VARCHAR(50) NOT NULL,
+    duration_ms BIGINT,
+    error_message TEXT,
+    stack_trace TEXT,
+    stdout TEXT,
+    stderr TEXT,
+    retry_count INTEGER DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    shard_id UUID REFERENCES run_shards(id) ON DELETE SET NULL,
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+CREATE INDEX idx_test_results_run_id ON test_results(run_id);
+CREATE INDEX idx_test_results_status ON test_results(status);
+CREATE INDEX idx_test_results_test_name ON test_results(test_name);
+CREATE INDEX idx_test_results_run_status ON test_results(run_id, status);
+CREATE INDEX idx_test_results_shard_id ON test_results(shard_id);
+
+-- Catches results of months whose partition does not exist yet
+CREATE TABLE test_results_default PARTITION OF test_results DEFAULT;
+
+COMMENT ON TABLE test_results IS 'Individual test case results from parsed output, partitioned by month';
+COMMENT ON COLUMN test_results.status IS 'Test outcome: pass, fail, skip, error';
+COMMENT ON COLUMN test_results.retry_count IS 'Retry attempt number (0 = first attempt)';
+
+-- ============================================================================
+-- PARTITION MAINTENANCE
+-- Creates the partition of the month (UTC) containing a date, named
+-- test_results_YYYY_MM, moving its rows out of the default partition
+-- ============================================================================
+CREATE OR REPLACE FUNCTION create_test_results_partition(month DATE)
+RETURNS TEXT AS $$
+DECLARE
+    start_at TIMESTAMP WITH TIME ZONE := date_trunc('month', month::timestamp) AT TIME ZONE 'UTC';
+    end_at TIMESTAMP WITH TIME ZONE := (date_trunc('month', month::timestamp) + INTERVAL '1 month') AT TIME ZONE 'UTC';
+    partition_name TEXT := 'test_results_' || to_char(month, 'YYYY_MM');
+BEGIN
+    IF to_regclass(partition_name) IS NOT NULL THEN
+        RETURN partition_name;
+    END IF;
+
+    EXECUTE format('CREATE TABLE %I (LIKE test_results INCLUDING DEFAULTS)', partition_name);
+    EXECUTE format(
+        'INSERT INTO %I SELECT * FROM test_results_default WHERE created_at >= $1 AND created_at < $2',
+        partition_name
+    ) USING start_at, end_at;
+    DELETE FROM test_results_default WHERE created_at >= start_at AND created_at < end_at;
+    EXECUTE format(
+        'ALTER TABLE test_results ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)',
+        partition_name, start_at, end_at
+    );
+    RETURN partition_name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Partitions of the months with results, and of this month and the next
+-- two, before the results are copied
+DO $$
+DECLARE
+    month DATE;
+BEGIN
+    FOR month IN
+        SELECT DISTINCT date_trunc('month', created_at AT TIME ZONE 'UTC')::date
+        FROM test_results_unpartitioned
+        UNION
+        SELECT (date_trunc('month', NOW() AT TIME ZONE 'UTC') + n * INTERVAL '1 month')::date
+        FROM generate_series(0, 2) AS n
+    LOOP
+        PERFORM create_test_results_partition(month);
+    END LOOP;
+END;
+$$;
+
+INSERT INTO test_results (
+    id, run_id, test_definition_id, test_name, suite_name, status, duration_ms,
+    error_message, stack_trace, stdout, stderr, retry_count, created_at, shard_id
+)
+SELECT
+    id, run_id, test_definition_id, test_name, suite_name, status, duration_ms,
+    error_message, stack_trace, stdout, stderr, retry_count, created_at, shard_id
+FROM test_results_unpartitioned;
+
+DROP TABLE test_results_unpartitioned;
+
+-- ============================================================================
+-- TEST_RESULT_ARCHIVES TABLE
+-- Months of results dropped past their retention
+-- ============================================================================
+CREATE TABLE test_result_archives (
+    month DATE PRIMARY KEY,
+    object_path VARCHAR(1024),
+    row_count BIGINT NOT NULL,
+    archived_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+COMMENT ON TABLE test_result_archives IS 'Months of test results dropped past their retention';
+COMMENT ON COLUMN test_result_archives.month IS 'First day of the month (UTC) the results were created in';
+COMMENT ON COLUMN test_result_archives.object_path IS 'Object storage path of the exported results; NULL if they were dropped without export';
@@ -0,0 +1,8 @@
+-- Rollback run search indexes
+
+DROP INDEX IF EXISTS idx_test_results_stack_trace_trgm;
+DROP INDEX IF EXISTS idx_test_results_error_trgm;
+DROP INDEX IF EXISTS idx_test_results_error_fts;
+DROP INDEX IF EXISTS idx_test_runs_git_ref_created;
+DROP INDEX IF EXISTS idx_test_runs_error_trgm;
+DROP INDEX IF EXISTS idx_test_runs_error_fts;
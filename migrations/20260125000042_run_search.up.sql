@@ -0,0 +1,23 @@
+-- This migration indexes the error messages of runs and the error messages
+-- and stack traces of test results, so that runs can be searched by the
+-- errors they failed with
+
+-- Trigram indexes serve substring searches, full-text indexes word searches
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- ============================================================================
+-- TEST_RUNS
+-- ============================================================================
+CREATE INDEX idx_test_runs_error_fts ON test_runs
+    USING GIN (to_tsvector('simple', COALESCE(error_message, '')));
+CREATE INDEX idx_test_runs_error_trgm ON test_runs USING GIN (error_message gin_trgm_ops);
+CREATE INDEX idx_test_runs_git_ref_created ON test_runs(git_ref, created_at DESC);
+
+-- ============================================================================
+-- TEST_RESULTS
+-- Indexes of the partitioned table are created on every partition
+-- ============================================================================
+CREATE INDEX idx_test_results_error_fts ON test_results
+    USING GIN (to_tsvector('simple', COALESCE(error_message, '') || ' ' || COALESCE(stack_trace, '')));
+CREATE INDEX idx_test_results_error_trgm ON test_results USING GIN (error_message gin_trgm_ops);
+CREATE INDEX idx_test_results_stack_trace_trgm ON test_results USING GIN (stack_trace gin_trgm_ops);
@@ -0,0 +1,3 @@
+-- Rollback run trace context
+
+ALTER TABLE test_runs DROP COLUMN IF EXISTS trace_parent;
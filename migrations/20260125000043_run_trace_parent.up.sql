@@ -0,0 +1,9 @@
+-- This migration stores the trace context runs are created in, so that the
+-- scheduling and execution of a run continue the trace that created it
+
+-- ============================================================================
+-- TEST_RUNS
+-- ============================================================================
+ALTER TABLE test_runs ADD COLUMN trace_parent TEXT;
+
+COMMENT ON COLUMN test_runs.trace_parent IS 'W3C traceparent of the span the run was created in';
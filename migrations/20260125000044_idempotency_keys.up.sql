@@ -0,0 +1,24 @@
+-- This migration adds idempotency keys, so that retried run creation
+-- requests and redelivered webhooks answer with the run they created first
+-- instead of creating another
+
+-- ============================================================================
+-- IDEMPOTENCY_KEYS TABLE
+-- ============================================================================
+CREATE TABLE idempotency_keys (
+    scope VARCHAR(255) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    response JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+COMMENT ON TABLE idempotency_keys IS 'Keys of requests that must not be applied twice, with their responses';
+COMMENT ON COLUMN idempotency_keys.scope IS 'Operation and caller the key belongs to';
+COMMENT ON COLUMN idempotency_keys.request_hash IS 'SHA-256 of the request, to reject keys reused with another request';
+COMMENT ON COLUMN idempotency_keys.response IS 'Snapshot of the response, NULL while the request is in progress';
+COMMENT ON COLUMN idempotency_keys.expires_at IS 'When the key may be claimed again; in-progress keys expire after a short lock timeout';
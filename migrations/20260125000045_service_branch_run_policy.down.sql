@@ -0,0 +1,4 @@
+-- Rollback service branch run policies
+
+ALTER TABLE services
+    DROP COLUMN IF EXISTS branch_run_policy;
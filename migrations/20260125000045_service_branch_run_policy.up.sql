@@ -0,0 +1,13 @@
+-- This migration adds branch run policies, which decide what happens to the
+-- pending and running runs of a branch when a new run of it is created
+
+-- ============================================================================
+-- SERVICES BRANCH RUN POLICY
+-- allow keeps every run, supersede cancels the older runs of the branch and
+-- coalesce moves a pending run of the branch to the new commit
+-- ============================================================================
+ALTER TABLE services
+    ADD COLUMN branch_run_policy VARCHAR(20) NOT NULL DEFAULT 'allow'
+        CHECK (branch_run_policy IN ('allow', 'supersede', 'coalesce'));
+
+COMMENT ON COLUMN services.branch_run_policy IS 'What a new run of a branch does to the active runs of the branch: allow, supersede or coalesce';
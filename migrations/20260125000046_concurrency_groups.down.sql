@@ -0,0 +1,10 @@
+-- Rollback concurrency groups
+
+DROP TABLE IF EXISTS concurrency_groups;
+
+DROP INDEX IF EXISTS idx_test_runs_concurrency_group;
+
+ALTER TABLE test_runs
+    DROP COLUMN IF EXISTS concurrency_group;
+
+DROP TABLE IF EXISTS service_concurrency;
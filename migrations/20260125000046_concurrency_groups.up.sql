@@ -0,0 +1,48 @@
+-- This migration adds concurrency groups, so that runs sharing a group key
+-- execute one at a time, or cancel the runs of the group in progress
+
+-- ============================================================================
+-- SERVICE_CONCURRENCY TABLE
+-- The concurrency group a service's configuration file declares
+-- ============================================================================
+CREATE TABLE service_concurrency (
+    service_id UUID PRIMARY KEY REFERENCES services(id) ON DELETE CASCADE,
+    group_key TEXT NOT NULL,
+    cancel_in_progress BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER update_service_concurrency_updated_at
+    BEFORE UPDATE ON service_concurrency
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE service_concurrency IS 'Concurrency group of the runs of a service, declared by its configuration file';
+COMMENT ON COLUMN service_concurrency.group_key IS 'Group key template; ${SERVICE_NAME}, ${GIT_REF} and ${GIT_BRANCH} are replaced per run';
+COMMENT ON COLUMN service_concurrency.cancel_in_progress IS 'Whether a new run cancels the pending and running runs of its group instead of waiting for them';
+
+-- ============================================================================
+-- TEST_RUNS CONCURRENCY GROUP
+-- ============================================================================
+ALTER TABLE test_runs
+    ADD COLUMN concurrency_group TEXT;
+
+CREATE INDEX idx_test_runs_concurrency_group ON test_runs(concurrency_group)
+    WHERE concurrency_group IS NOT NULL AND status IN ('pending', 'running');
+
+COMMENT ON COLUMN test_runs.concurrency_group IS 'Group whose runs execute one at a time; NULL for runs without a group';
+
+-- ============================================================================
+-- CONCURRENCY_GROUPS TABLE
+-- The run each group is held by
+-- ============================================================================
+CREATE TABLE concurrency_groups (
+    group_key TEXT PRIMARY KEY,
+    run_id UUID NOT NULL REFERENCES test_runs(id) ON DELETE CASCADE,
+    acquired_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_concurrency_groups_run_id ON concurrency_groups(run_id);
+
+COMMENT ON TABLE concurrency_groups IS 'Run holding each concurrency group; the group is free once that run is no longer pending or running';
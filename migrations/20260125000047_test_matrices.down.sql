@@ -0,0 +1,6 @@
+-- Rollback test matrices
+
+ALTER TABLE run_shards
+    DROP COLUMN IF EXISTS matrix;
+ALTER TABLE test_definitions
+    DROP COLUMN IF EXISTS matrix;
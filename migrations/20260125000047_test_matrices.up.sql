@@ -0,0 +1,21 @@
+-- This migration lets test definitions fan out over a matrix of environment
+-- variables and agent labels; runs create one set of shards per combination
+
+-- ============================================================================
+-- TEST MATRICES
+-- Axes a test fans out over, e.g. {"env": {"GO_VERSION": ["1.22", "1.23"]},
+-- "labels": {"os": ["linux", "windows"]}}; NULL runs it once
+-- ============================================================================
+ALTER TABLE test_definitions
+    ADD COLUMN matrix JSONB;
+
+COMMENT ON COLUMN test_definitions.matrix IS 'Environment variable and label axes the test runs once per combination of; NULL runs it once';
+
+-- ============================================================================
+-- SHARD MATRIX CELL
+-- Combination of matrix values a shard runs its tests with
+-- ============================================================================
+ALTER TABLE run_shards
+    ADD COLUMN matrix JSONB;
+
+COMMENT ON COLUMN run_shards.matrix IS 'Environment variables and agent labels of the matrix combination the shard runs; NULL for tests without a matrix';
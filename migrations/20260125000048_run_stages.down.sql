@@ -0,0 +1,4 @@
+-- Rollback run stages
+
+ALTER TABLE run_shards
+    DROP COLUMN IF EXISTS stage;
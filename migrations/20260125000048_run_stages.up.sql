@@ -0,0 +1,12 @@
+-- This migration orders the shards of a run into pipeline stages derived
+-- from the depends_on of its tests; a stage starts once the stages before
+-- it passed
+
+-- ============================================================================
+-- SHARD STAGE
+-- Position of a shard's tests in the dependency order of the run's tests
+-- ============================================================================
+ALTER TABLE run_shards
+    ADD COLUMN stage INT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN run_shards.stage IS 'Pipeline stage of the shard''s tests; a stage starts once every earlier stage passed';
@@ -0,0 +1,9 @@
+-- Rollback run approvals
+
+ALTER TABLE test_runs
+    DROP COLUMN IF EXISTS approved_at,
+    DROP COLUMN IF EXISTS approved_by,
+    DROP COLUMN IF EXISTS approval_reason;
+
+ALTER TABLE test_definitions
+    DROP COLUMN IF EXISTS requires_approval;
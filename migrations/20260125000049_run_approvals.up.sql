@@ -0,0 +1,25 @@
+-- This migration adds manual approval gates: runs of protected branches or
+-- of tests that require approval wait for an authorized user to approve
+-- them before they are scheduled
+
+-- ============================================================================
+-- TEST APPROVAL
+-- Whether runs of a test wait for approval
+-- ============================================================================
+ALTER TABLE test_definitions
+    ADD COLUMN requires_approval BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN test_definitions.requires_approval IS 'Runs including the test wait in waiting_approval until approved';
+
+-- ============================================================================
+-- RUN APPROVAL
+-- Why a run waits for approval, and who approved it
+-- ============================================================================
+ALTER TABLE test_runs
+    ADD COLUMN approval_reason TEXT,
+    ADD COLUMN approved_by TEXT,
+    ADD COLUMN approved_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN test_runs.approval_reason IS 'Why the run required approval; NULL for runs that did not';
+COMMENT ON COLUMN test_runs.approved_by IS 'User who approved the run';
+COMMENT ON COLUMN test_runs.approved_at IS 'When the run was approved';
@@ -0,0 +1,5 @@
+-- Rollback service quotas
+
+DROP INDEX IF EXISTS idx_test_runs_service_created;
+DROP TRIGGER IF EXISTS update_service_quotas_updated_at ON service_quotas;
+DROP TABLE IF EXISTS service_quotas;
@@ -0,0 +1,30 @@
+-- This migration adds per-service run quotas: a cap on the runs created per
+-- day and on the execution minutes used per month, enforced when runs are
+-- created
+
+-- ============================================================================
+-- SERVICE_QUOTAS TABLE
+-- Run and compute limits of a service; zero means unlimited
+-- ============================================================================
+CREATE TABLE service_quotas (
+    service_id UUID PRIMARY KEY REFERENCES services(id) ON DELETE CASCADE,
+    max_runs_per_day INT NOT NULL DEFAULT 0 CHECK (max_runs_per_day >= 0),
+    max_minutes_per_month INT NOT NULL DEFAULT 0 CHECK (max_minutes_per_month >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER update_service_quotas_updated_at
+    BEFORE UPDATE ON service_quotas
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE service_quotas IS 'Run and execution time limits of a service, checked when runs are created';
+COMMENT ON COLUMN service_quotas.max_runs_per_day IS 'Runs the service may create per UTC day; 0 means unlimited';
+COMMENT ON COLUMN service_quotas.max_minutes_per_month IS 'Shard execution minutes the service may use per UTC month; 0 means unlimited';
+
+-- ============================================================================
+-- QUOTA USAGE
+-- Counts the runs of a service since the start of the day
+-- ============================================================================
+CREATE INDEX idx_test_runs_service_created ON test_runs(service_id, created_at DESC);
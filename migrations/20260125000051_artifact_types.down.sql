@@ -0,0 +1,5 @@
+-- Rollback artifact types
+
+ALTER TABLE artifacts
+    DROP COLUMN IF EXISTS checksum,
+    DROP COLUMN IF EXISTS artifact_type;
@@ -0,0 +1,24 @@
+-- This migration types artifacts so they can be browsed by kind, and records
+-- the content hash agents report for them
+
+-- ============================================================================
+-- ARTIFACT TYPE AND CHECKSUM
+-- What an artifact holds, and the SHA-256 of its content
+-- ============================================================================
+ALTER TABLE artifacts
+    ADD COLUMN artifact_type VARCHAR(20) NOT NULL DEFAULT 'binary'
+        CHECK (artifact_type IN ('log', 'junit', 'coverage', 'screenshot', 'video', 'binary')),
+    ADD COLUMN checksum VARCHAR(128);
+
+-- Type existing artifacts by content type and name, like new ones are
+UPDATE artifacts SET artifact_type = CASE
+    WHEN lower(content_type) LIKE 'image/%' OR lower(name) ~ '\.(png|jpe?g|gif|webp|bmp)$' THEN 'screenshot'
+    WHEN lower(content_type) LIKE 'video/%' OR lower(name) ~ '\.(mp4|webm|mov|avi|mkv)$' THEN 'video'
+    WHEN lower(name) ~ '(coverage|lcov|jacoco|cobertura)' OR lower(name) ~ '(^|/)cover\.out$' THEN 'coverage'
+    WHEN lower(name) ~ '(junit|test)[^/]*\.xml$' THEN 'junit'
+    WHEN lower(content_type) LIKE 'text/plain%' OR lower(name) ~ '\.(log|txt)$' THEN 'log'
+    ELSE 'binary'
+END;
+
+COMMENT ON COLUMN artifacts.artifact_type IS 'Kind of artifact: log, junit, coverage, screenshot, video or binary';
+COMMENT ON COLUMN artifacts.checksum IS 'SHA-256 of the artifact content, hex encoded, as reported by the agent';
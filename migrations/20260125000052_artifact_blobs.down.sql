@@ -0,0 +1,8 @@
+-- Rollback artifact blobs. Artifacts keep the blob paths they point to.
+
+DROP TRIGGER IF EXISTS artifacts_count_blob_refs ON artifacts;
+DROP FUNCTION IF EXISTS count_artifact_blob_refs();
+
+ALTER TABLE artifacts DROP COLUMN IF EXISTS blob_digest;
+
+DROP TABLE IF EXISTS artifact_blobs;
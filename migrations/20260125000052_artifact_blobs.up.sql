@@ -0,0 +1,54 @@
+-- This migration stores identical artifacts once: artifact content is kept in
+-- blobs keyed by its SHA-256 digest, and artifacts reference the blob they
+-- hold, counting references so cleanup only removes blobs no artifact uses
+
+-- ============================================================================
+-- ARTIFACT_BLOBS TABLE
+-- Content-addressed artifact objects, shared by the artifacts that hold them
+-- ============================================================================
+CREATE TABLE artifact_blobs (
+    digest VARCHAR(64) PRIMARY KEY,
+    path TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    content_type VARCHAR(255),
+    ref_count INTEGER NOT NULL DEFAULT 0 CHECK (ref_count >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    last_referenced_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- Unreferenced blobs are swept by when they were last referenced
+CREATE INDEX idx_artifact_blobs_unreferenced ON artifact_blobs(last_referenced_at) WHERE ref_count = 0;
+
+ALTER TABLE artifacts ADD COLUMN blob_digest VARCHAR(64) REFERENCES artifact_blobs(digest);
+
+CREATE INDEX idx_artifacts_blob_digest ON artifacts(blob_digest) WHERE blob_digest IS NOT NULL;
+
+COMMENT ON TABLE artifact_blobs IS 'Artifact content stored once per SHA-256 digest';
+COMMENT ON COLUMN artifact_blobs.digest IS 'SHA-256 of the content, hex encoded';
+COMMENT ON COLUMN artifact_blobs.path IS 'Object storage path of the blob';
+COMMENT ON COLUMN artifact_blobs.ref_count IS 'Number of artifacts holding the blob, maintained by trigger';
+COMMENT ON COLUMN artifact_blobs.last_referenced_at IS 'When the blob was last uploaded or referenced; unreferenced blobs are kept for a grace period after it';
+COMMENT ON COLUMN artifacts.blob_digest IS 'Digest of the blob holding the content; NULL for artifacts stored per run';
+
+-- ============================================================================
+-- REFERENCE COUNTING
+-- Kept by trigger, so artifacts deleted with their run release their blobs
+-- ============================================================================
+CREATE OR REPLACE FUNCTION count_artifact_blob_refs()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.blob_digest IS NOT NULL THEN
+        UPDATE artifact_blobs SET ref_count = ref_count - 1 WHERE digest = OLD.blob_digest;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.blob_digest IS NOT NULL THEN
+        UPDATE artifact_blobs
+        SET ref_count = ref_count + 1, last_referenced_at = NOW()
+        WHERE digest = NEW.blob_digest;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER artifacts_count_blob_refs
+    AFTER INSERT OR DELETE OR UPDATE OF blob_digest ON artifacts
+    FOR EACH ROW EXECUTE FUNCTION count_artifact_blob_refs();
@@ -0,0 +1,7 @@
+-- Rollback media capture
+
+DROP TRIGGER IF EXISTS artifacts_flag_run_media ON artifacts;
+DROP FUNCTION IF EXISTS flag_run_media_artifacts();
+
+ALTER TABLE test_runs DROP COLUMN IF EXISTS has_media_artifacts;
+ALTER TABLE test_definitions DROP COLUMN IF EXISTS capture_patterns;
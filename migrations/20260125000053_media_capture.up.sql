@@ -0,0 +1,39 @@
+-- This migration adds screenshot and video capture for browser tests: tests
+-- declare the paths their media is written to, and runs record whether they
+-- have media artifacts so the UI can show a gallery
+
+-- ============================================================================
+-- TEST CAPTURE PATTERNS
+-- Files or directories with screenshots, videos and reports to capture
+-- ============================================================================
+ALTER TABLE test_definitions
+    ADD COLUMN capture_patterns TEXT[];
+
+COMMENT ON COLUMN test_definitions.capture_patterns IS 'Files or directories of screenshots, videos and reports agents capture and upload';
+
+-- ============================================================================
+-- RUN MEDIA FLAG
+-- Set by trigger when a screenshot or video artifact is recorded
+-- ============================================================================
+ALTER TABLE test_runs
+    ADD COLUMN has_media_artifacts BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE test_runs SET has_media_artifacts = TRUE
+WHERE id IN (SELECT run_id FROM artifacts WHERE artifact_type IN ('screenshot', 'video'));
+
+COMMENT ON COLUMN test_runs.has_media_artifacts IS 'Whether the run has screenshot or video artifacts';
+
+CREATE OR REPLACE FUNCTION flag_run_media_artifacts()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE test_runs SET has_media_artifacts = TRUE
+    WHERE id = NEW.run_id AND NOT has_media_artifacts;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER artifacts_flag_run_media
+    AFTER INSERT ON artifacts
+    FOR EACH ROW
+    WHEN (NEW.artifact_type IN ('screenshot', 'video'))
+    EXECUTE FUNCTION flag_run_media_artifacts();
@@ -0,0 +1,3 @@
+-- Rollback result sequences
+
+DROP TABLE IF EXISTS run_result_sequences;
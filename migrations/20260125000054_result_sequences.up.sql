@@ -0,0 +1,14 @@
+-- This migration records the result stream sequences of the results agents
+-- sent, so results an agent replays after a reconnect are stored once
+
+-- ============================================================================
+-- RUN RESULT SEQUENCES
+-- One row per stored result of an agent that replays results
+-- ============================================================================
+CREATE TABLE run_result_sequences (
+    run_id UUID NOT NULL REFERENCES test_runs(id) ON DELETE CASCADE,
+    sequence BIGINT NOT NULL,
+    PRIMARY KEY (run_id, sequence)
+);
+
+COMMENT ON TABLE run_result_sequences IS 'Result stream sequences of the stored results of a run, claimed with the results so replays are stored once';
@@ -0,0 +1,3 @@
+-- Rollback scheduling pauses
+
+DROP TABLE IF EXISTS scheduling_pauses;
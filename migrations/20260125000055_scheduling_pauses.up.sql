@@ -0,0 +1,22 @@
+-- This migration records the pauses operators put on scheduling, so that
+-- runs queue without being assigned to agents until scheduling resumes
+
+-- ============================================================================
+-- SCHEDULING PAUSES
+-- One row for a global pause and one per paused service
+-- ============================================================================
+CREATE TABLE scheduling_pauses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID REFERENCES services(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL DEFAULT '',
+    paused_by TEXT NOT NULL DEFAULT '',
+    paused_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_scheduling_pauses_service ON scheduling_pauses(service_id)
+    WHERE service_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_scheduling_pauses_global ON scheduling_pauses((service_id IS NULL))
+    WHERE service_id IS NULL;
+
+COMMENT ON TABLE scheduling_pauses IS 'Pauses of scheduling, globally when service_id is NULL or for one service';
+COMMENT ON COLUMN scheduling_pauses.paused_by IS 'Subject of the operator who paused scheduling';
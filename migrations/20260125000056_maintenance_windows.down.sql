@@ -0,0 +1,3 @@
+-- Rollback maintenance windows
+
+DROP TABLE IF EXISTS maintenance_windows;
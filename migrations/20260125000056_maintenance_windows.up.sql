@@ -0,0 +1,46 @@
+-- This migration adds maintenance windows: recurring or one-off periods in
+-- which runs of a service or agent pool are deferred and notifications are
+-- suppressed or tagged
+
+-- ============================================================================
+-- MAINTENANCE WINDOWS
+-- A window without a service or pool applies to every service
+-- ============================================================================
+CREATE TABLE maintenance_windows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    service_id UUID REFERENCES services(id) ON DELETE CASCADE,
+    agent_pool VARCHAR(255),
+    cron_expression VARCHAR(100),
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    duration_seconds INT NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP WITH TIME ZONE,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    notifications VARCHAR(20) NOT NULL DEFAULT 'suppress',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+
+    CONSTRAINT maintenance_windows_scope CHECK (service_id IS NULL OR agent_pool IS NULL),
+    CONSTRAINT maintenance_windows_recurrence CHECK (
+        (cron_expression IS NOT NULL AND duration_seconds > 0) OR
+        (cron_expression IS NULL AND starts_at IS NOT NULL AND ends_at IS NOT NULL)
+    ),
+    CONSTRAINT maintenance_windows_notifications CHECK (notifications IN ('suppress', 'tag'))
+);
+
+CREATE INDEX idx_maintenance_windows_service ON maintenance_windows(service_id)
+    WHERE service_id IS NOT NULL;
+
+CREATE TRIGGER update_maintenance_windows_updated_at
+    BEFORE UPDATE ON maintenance_windows
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE maintenance_windows IS 'Periods in which runs are deferred and notifications are suppressed or tagged';
+COMMENT ON COLUMN maintenance_windows.agent_pool IS 'Name of the agent pool whose agents get no work during the window';
+COMMENT ON COLUMN maintenance_windows.cron_expression IS 'Start times of a recurring window; NULL for a one-off window from starts_at to ends_at';
+COMMENT ON COLUMN maintenance_windows.duration_seconds IS 'How long each occurrence of a recurring window lasts';
+COMMENT ON COLUMN maintenance_windows.notifications IS 'suppress drops notifications during the window; tag sends them marked as sent during maintenance';
@@ -0,0 +1,3 @@
+-- Rollback run metadata
+
+ALTER TABLE test_runs DROP COLUMN IF EXISTS metadata;
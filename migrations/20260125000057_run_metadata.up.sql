@@ -0,0 +1,11 @@
+-- This migration adds the annotations and external links API clients attach
+-- to runs, such as the CI build, ticket or deployment a run belongs to
+
+-- ============================================================================
+-- RUN METADATA
+-- Annotations and links, each an object of names to values
+-- ============================================================================
+ALTER TABLE test_runs
+    ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN test_runs.metadata IS 'Annotations and external links attached by API clients, as {"annotations": {...}, "links": {...}}';
@@ -0,0 +1,4 @@
+-- Rollback run parameters
+
+ALTER TABLE test_runs DROP COLUMN IF EXISTS parameters;
+ALTER TABLE test_definitions DROP COLUMN IF EXISTS parameters;
@@ -0,0 +1,21 @@
+-- This migration adds parameters: test definitions declare the parameters
+-- runs can set, such as TARGET_ENV=staging, and runs record the values they
+-- were created with
+
+-- ============================================================================
+-- TEST PARAMETERS
+-- Schema of the parameters of a test, as an array of declarations
+-- ============================================================================
+ALTER TABLE test_definitions
+    ADD COLUMN parameters JSONB;
+
+COMMENT ON COLUMN test_definitions.parameters IS 'Parameters runs of the test can set, as [{"name", "type", "required", "default", "choices"}]';
+
+-- ============================================================================
+-- RUN PARAMETERS
+-- Values of parameters set when the run was created
+-- ============================================================================
+ALTER TABLE test_runs
+    ADD COLUMN parameters JSONB NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN test_runs.parameters IS 'Parameter values the run was created with, passed to its tests as environment variables';
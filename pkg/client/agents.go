@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+// AgentFilter selects the agents ListAgents returns.
+type AgentFilter struct {
+	// Statuses filters by agent status; empty returns all.
+	Statuses []AgentStatus
+	// Pool filters by agent pool.
+	Pool string
+	// NetworkZone filters by network zone.
+	NetworkZone string
+	// Labels filters by labels; all must match.
+	Labels map[string]string
+	ListOptions
+}
+
+// ListAgents lists the registered agents.
+func (c *Client) ListAgents(ctx context.Context, filter AgentFilter) (*Page[Agent], error) {
+	statuses := make([]conductorv1.AgentStatus, len(filter.Statuses))
+	for i, s := range filter.Statuses {
+		statuses[i] = agentStatusValues[s]
+	}
+
+	resp, err := c.agents.ListAgents(ctx, &conductorv1.ListAgentsRequest{
+		Statuses:    statuses,
+		Pool:        filter.Pool,
+		NetworkZone: filter.NetworkZone,
+		Labels:      filter.Labels,
+		Pagination:  filter.toProto(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newPage(resp.GetAgents(), resp.GetPagination(), agentFromProto), nil
+}
+
+// GetAgent returns an agent by ID.
+func (c *Client) GetAgent(ctx context.Context, agentID string) (*Agent, error) {
+	resp, err := c.agents.GetAgent(ctx, &conductorv1.GetAgentRequest{AgentId: agentID})
+	if err != nil {
+		return nil, err
+	}
+	agent := agentFromProto(resp.GetAgent())
+	return &agent, nil
+}
+
+// DrainAgent stops assigning new work to an agent. Runs in progress finish
+// unless cancelActive is set.
+func (c *Client) DrainAgent(ctx context.Context, agentID, reason string, cancelActive bool) (*Agent, error) {
+	resp, err := c.agents.DrainAgent(ctx, &conductorv1.DrainAgentRequest{
+		AgentId:      agentID,
+		Reason:       reason,
+		CancelActive: cancelActive,
+	})
+	if err != nil {
+		return nil, err
+	}
+	agent := agentFromProto(resp.GetAgent())
+	return &agent, nil
+}
+
+// UndrainAgent lets a drained agent receive work again.
+func (c *Client) UndrainAgent(ctx context.Context, agentID string) error {
+	_, err := c.agents.UndrainAgent(ctx, &conductorv1.UndrainAgentRequest{AgentId: agentID})
+	return err
+}
@@ -0,0 +1,227 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+// fastRetry retries quickly so tests do not wait on backoff.
+var fastRetry = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     time.Millisecond,
+	Codes:          []codes.Code{codes.Unavailable},
+}
+
+// fakeRunService answers run calls from a scripted sequence of runs and log
+// streams.
+type fakeRunService struct {
+	conductorv1.RunServiceClient
+
+	runs     []*conductorv1.Run
+	gets     int
+	created  *conductorv1.CreateRunRequest
+	streams  [][]*conductorv1.RunLogEntry
+	failures []error
+	requests []*conductorv1.StreamRunLogsRequest
+}
+
+func (f *fakeRunService) CreateRun(ctx context.Context, in *conductorv1.CreateRunRequest, opts ...grpc.CallOption) (*conductorv1.CreateRunResponse, error) {
+	f.created = in
+	return &conductorv1.CreateRunResponse{Run: &conductorv1.Run{Id: "run-1", Status: conductorv1.RunStatus_RUN_STATUS_PENDING}}, nil
+}
+
+func (f *fakeRunService) GetRun(ctx context.Context, in *conductorv1.GetRunRequest, opts ...grpc.CallOption) (*conductorv1.GetRunResponse, error) {
+	run := f.runs[min(f.gets, len(f.runs)-1)]
+	f.gets++
+	return &conductorv1.GetRunResponse{Run: run}, nil
+}
+
+func (f *fakeRunService) StreamRunLogs(ctx context.Context, in *conductorv1.StreamRunLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[conductorv1.RunLogEntry], error) {
+	i := len(f.requests)
+	f.requests = append(f.requests, &conductorv1.StreamRunLogsRequest{FromSequence: in.FromSequence})
+	return &fakeLogStream{entries: f.streams[i], err: f.failures[i]}, nil
+}
+
+// fakeLogStream returns entries and then err, or io.EOF if err is nil.
+type fakeLogStream struct {
+	grpc.ClientStream
+	entries []*conductorv1.RunLogEntry
+	err     error
+}
+
+func (s *fakeLogStream) Recv() (*conductorv1.RunLogEntry, error) {
+	if len(s.entries) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	entry := s.entries[0]
+	s.entries = s.entries[1:]
+	return entry, nil
+}
+
+func TestCreateRun(t *testing.T) {
+	runs := &fakeRunService{}
+	c := &Client{runs: runs, retry: fastRetry}
+
+	run, err := c.CreateRun(context.Background(), CreateRunRequest{
+		ServiceID: "svc-1",
+		Branch:    "main",
+		Timeout:   90 * time.Second,
+		CIJobID:   "1234",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "run-1", run.ID)
+	assert.Equal(t, RunStatusPending, run.Status)
+
+	assert.Equal(t, "main", runs.created.GitRef.Branch)
+	assert.Equal(t, int64(90), runs.created.Timeout.Seconds)
+	assert.Equal(t, conductorv1.TriggerType_TRIGGER_TYPE_CI, runs.created.Trigger.Type)
+	assert.NotEmpty(t, runs.created.IdempotencyKey, "retries of the call share a generated key")
+
+	_, err = c.CreateRun(context.Background(), CreateRunRequest{ServiceID: "svc-1", IdempotencyKey: "deploy-42"})
+	require.NoError(t, err)
+	assert.Equal(t, "deploy-42", runs.created.IdempotencyKey)
+}
+
+func TestWaitForRun(t *testing.T) {
+	finished := time.Date(2026, 1, 25, 12, 0, 0, 0, time.UTC)
+	runs := &fakeRunService{runs: []*conductorv1.Run{
+		{Id: "run-1", Status: conductorv1.RunStatus_RUN_STATUS_PENDING},
+		{Id: "run-1", Status: conductorv1.RunStatus_RUN_STATUS_RUNNING},
+		{
+			Id:         "run-1",
+			Status:     conductorv1.RunStatus_RUN_STATUS_FAILED,
+			GitRef:     &conductorv1.GitRef{Branch: "main", CommitSha: "abc123"},
+			Summary:    &conductorv1.RunSummary{Total: 10, Passed: 9, Failed: 1, Duration: &conductorv1.Duration{Seconds: 42}},
+			FinishedAt: timestamppb.New(finished),
+		},
+	}}
+	c := &Client{runs: runs, retry: fastRetry}
+
+	run, err := c.WaitForRun(context.Background(), "run-1", time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 3, runs.gets)
+	assert.Equal(t, RunStatusFailed, run.Status)
+	assert.Equal(t, "abc123", run.CommitSHA)
+	assert.Equal(t, RunSummary{Total: 10, Passed: 9, Failed: 1, Duration: 42 * time.Second}, run.Summary)
+	require.NotNil(t, run.FinishedAt)
+	assert.Equal(t, finished, *run.FinishedAt)
+
+	runs = &fakeRunService{runs: []*conductorv1.Run{{Status: conductorv1.RunStatus_RUN_STATUS_RUNNING}}}
+	c = &Client{runs: runs, retry: fastRetry}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = c.WaitForRun(ctx, "run-1", time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestStreamRunLogsResumes(t *testing.T) {
+	entry := func(seq int64) *conductorv1.RunLogEntry {
+		return &conductorv1.RunLogEntry{Sequence: seq, Message: "line", Stream: conductorv1.LogStream_LOG_STREAM_STDOUT}
+	}
+	runs := &fakeRunService{
+		streams:  [][]*conductorv1.RunLogEntry{{entry(1), entry(2)}, {}, {entry(3)}},
+		failures: []error{status.Error(codes.Unavailable, "reset"), status.Error(codes.Unavailable, "reset"), nil},
+	}
+	c := &Client{runs: runs, retry: fastRetry}
+
+	var seen []int64
+	err := c.StreamRunLogs(context.Background(), "run-1", LogOptions{FromSequence: 1}, func(e LogEntry) error {
+		assert.Equal(t, LogStreamStdout, e.Stream)
+		seen = append(seen, e.Sequence)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, seen)
+	require.Len(t, runs.requests, 3)
+	assert.Equal(t, int64(3), runs.requests[1].FromSequence, "resumes after the last line")
+	assert.Equal(t, int64(3), runs.requests[2].FromSequence)
+}
+
+func TestStreamRunLogsStops(t *testing.T) {
+	stop := errors.New("stop")
+	runs := &fakeRunService{
+		streams:  [][]*conductorv1.RunLogEntry{{{Sequence: 1}, {Sequence: 2}}},
+		failures: []error{nil},
+	}
+	c := &Client{runs: runs, retry: fastRetry}
+
+	err := c.StreamRunLogs(context.Background(), "run-1", LogOptions{}, func(e LogEntry) error {
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Len(t, runs.requests, 1, "callback errors are not retried")
+
+	runs = &fakeRunService{
+		streams:  [][]*conductorv1.RunLogEntry{{}},
+		failures: []error{status.Error(codes.NotFound, "run not found")},
+	}
+	c = &Client{runs: runs, retry: fastRetry}
+	err = c.StreamRunLogs(context.Background(), "run-1", LogOptions{}, func(e LogEntry) error { return nil })
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRetryInterceptor(t *testing.T) {
+	call := func(policy RetryPolicy, errs ...error) (int, error) {
+		calls := 0
+		invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			err := errs[min(calls, len(errs)-1)]
+			calls++
+			return err
+		}
+		err := policy.unaryInterceptor()(context.Background(), "/conductor.v1.RunService/GetRun", nil, nil, nil, invoker)
+		return calls, err
+	}
+
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	calls, err := call(fastRetry, unavailable, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	calls, err = call(fastRetry, unavailable)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 3, calls, "gives up after MaxAttempts")
+
+	calls, _ = call(fastRetry, status.Error(codes.InvalidArgument, "bad request"))
+	assert.Equal(t, 1, calls, "only listed codes are retried")
+
+	calls, _ = call(RetryPolicy{MaxAttempts: 1, Codes: fastRetry.Codes}, unavailable)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	assert.InDelta(t, float64(100*time.Millisecond), float64(policy.backoff(1)), float64(20*time.Millisecond))
+	assert.InDelta(t, float64(400*time.Millisecond), float64(policy.backoff(3)), float64(80*time.Millisecond))
+	assert.InDelta(t, float64(time.Second), float64(policy.backoff(10)), float64(200*time.Millisecond))
+}
+
+func TestTokenCredentials(t *testing.T) {
+	md, err := tokenCredentials{token: "secret", secure: true}.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer secret", md["authorization"])
+
+	_, err = New("")
+	assert.Error(t, err)
+
+	c, err := New("localhost:9090", WithInsecure(), WithToken("secret"))
+	require.NoError(t, err)
+	assert.NoError(t, c.Close())
+}
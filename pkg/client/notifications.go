@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+// ChannelFilter selects the channels ListChannels returns.
+type ChannelFilter struct {
+	// Type filters by channel type; empty returns all.
+	Type ChannelType
+	// Enabled filters by whether channels are enabled; nil returns both.
+	Enabled *bool
+	ListOptions
+}
+
+// ListChannels lists the notification channels.
+func (c *Client) ListChannels(ctx context.Context, filter ChannelFilter) (*Page[Channel], error) {
+	resp, err := c.notifications.ListChannels(ctx, &conductorv1.ListChannelsRequest{
+		Type:       channelTypeValues[filter.Type],
+		Enabled:    filter.Enabled,
+		Pagination: filter.toProto(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newPage(resp.GetChannels(), resp.GetPagination(), channelFromProto), nil
+}
+
+// GetChannel returns a notification channel by ID.
+func (c *Client) GetChannel(ctx context.Context, channelID string) (*Channel, error) {
+	resp, err := c.notifications.GetChannel(ctx, &conductorv1.GetChannelRequest{ChannelId: channelID})
+	if err != nil {
+		return nil, err
+	}
+	channel := channelFromProto(resp.GetChannel())
+	return &channel, nil
+}
+
+// TestChannel sends a test message through a channel. It returns an error
+// describing the failure when the channel could not deliver it.
+func (c *Client) TestChannel(ctx context.Context, channelID, message string) error {
+	resp, err := c.notifications.TestChannel(ctx, &conductorv1.TestChannelRequest{
+		ChannelId: channelID,
+		Message:   message,
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.GetSuccess() {
+		return errors.New("test notification failed: " + resp.GetErrorMessage())
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+// ResultFilter selects the results ListTestResults returns.
+type ResultFilter struct {
+	// Statuses filters by test status; empty returns all.
+	Statuses []TestStatus
+	// Suite filters by test suite.
+	Suite string
+	// NamePattern filters by test name.
+	NamePattern string
+	ListOptions
+}
+
+// ListTestResults lists the test results of a run.
+func (c *Client) ListTestResults(ctx context.Context, runID string, filter ResultFilter) (*Page[TestResult], error) {
+	statuses := make([]conductorv1.TestStatus, len(filter.Statuses))
+	for i, s := range filter.Statuses {
+		statuses[i] = testStatusValues[s]
+	}
+
+	resp, err := c.results.ListTestResults(ctx, &conductorv1.ListTestResultsRequest{
+		RunId:       runID,
+		Statuses:    statuses,
+		SuiteName:   filter.Suite,
+		NamePattern: filter.NamePattern,
+		Pagination:  filter.toProto(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newPage(resp.GetResults(), resp.GetPagination(), testResultFromProto), nil
+}
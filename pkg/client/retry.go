@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy controls how calls that fail with a transient error are
+// retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts per call, including the
+	// first; values below 1 mean 1.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry. It doubles with
+	// every retry, up to MaxBackoff, and is jittered.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts.
+	MaxBackoff time.Duration
+	// Codes are the gRPC status codes that are retried.
+	Codes []codes.Code
+}
+
+// DefaultRetryPolicy retries calls that fail because the control plane is
+// unreachable or rate limits the caller, up to 4 attempts over about 3
+// seconds.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Codes:          []codes.Code{codes.Unavailable, codes.ResourceExhausted},
+	}
+}
+
+// retryable reports whether err is worth another attempt.
+func (p RetryPolicy) retryable(err error) bool {
+	return slices.Contains(p.Codes, status.Code(err))
+}
+
+// backoff returns the wait before retry n (starting at 1), with up to 20%
+// jitter either way so clients that failed together do not retry together.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	wait := p.InitialBackoff
+	for i := 1; i < n && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	if wait <= 0 {
+		return 0
+	}
+	jitter := time.Duration(rand.Int64N(int64(wait)*2/5+1)) - wait/5
+	return wait + jitter
+}
+
+// sleep waits for the backoff of retry n, or until ctx is done.
+func (p RetryPolicy) sleep(ctx context.Context, n int) error {
+	timer := time.NewTimer(p.backoff(n))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// unaryInterceptor retries unary calls according to the policy.
+func (p RetryPolicy) unaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		for attempt := 1; attempt < p.MaxAttempts && err != nil && p.retryable(err); attempt++ {
+			if sleepErr := p.sleep(ctx, attempt); sleepErr != nil {
+				return err
+			}
+			err = invoker(ctx, method, req, reply, cc, opts...)
+		}
+		return err
+	}
+}
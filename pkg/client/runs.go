@@ -0,0 +1,268 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+// DefaultWaitInterval is how often WaitForRun polls when no interval is
+// given.
+const DefaultWaitInterval = 5 * time.Second
+
+// CreateRunRequest describes a run to queue.
+type CreateRunRequest struct {
+	ServiceID string
+	// Branch to test; empty uses the service's default branch.
+	Branch string
+	// CommitSHA pins the commit; empty tests the head of the branch.
+	CommitSHA string
+	// TestIDs selects tests by ID; empty runs all tests.
+	TestIDs []string
+	// Tags selects tests by tag.
+	Tags []string
+	// Environment adds environment variables to the run.
+	Environment map[string]string
+	Labels      map[string]string
+	Priority    int
+	// Timeout bounds the run; 0 uses the service default.
+	Timeout time.Duration
+	// TriggeredBy records who started the run.
+	TriggeredBy string
+	// CIJobID and CIPipelineURL mark the run as started by a CI job.
+	CIJobID       string
+	CIPipelineURL string
+	// IdempotencyKey identifies the request across retries: the control
+	// plane answers a request with a key it has seen with the run it
+	// created. Empty generates a key per call, so that the retries of the
+	// client itself never create a second run.
+	IdempotencyKey string
+}
+
+// RunFilter selects the runs ListRuns returns.
+type RunFilter struct {
+	ServiceID string
+	// Statuses filters by run status; empty returns all.
+	Statuses  []RunStatus
+	Branch    string
+	CommitSHA string
+	// Labels filters by labels; all must match.
+	Labels map[string]string
+	ListOptions
+}
+
+// LogOptions selects the output StreamRunLogs returns.
+type LogOptions struct {
+	// Stream filters by output stream; empty returns both.
+	Stream LogStream
+	// TestID filters by test.
+	TestID string
+	// FromSequence starts the stream at this sequence number.
+	FromSequence int64
+}
+
+// CreateRun queues a run.
+func (c *Client) CreateRun(ctx context.Context, req CreateRunRequest) (*Run, error) {
+	trigger := &conductorv1.RunTrigger{
+		Type: conductorv1.TriggerType_TRIGGER_TYPE_MANUAL,
+		User: req.TriggeredBy,
+	}
+	if req.CIJobID != "" || req.CIPipelineURL != "" {
+		trigger.Type = conductorv1.TriggerType_TRIGGER_TYPE_CI
+		trigger.CiJobId = req.CIJobID
+		trigger.CiPipelineUrl = req.CIPipelineURL
+	}
+
+	key := req.IdempotencyKey
+	if key == "" {
+		key = uuid.NewString()
+	}
+
+	resp, err := c.runs.CreateRun(ctx, &conductorv1.CreateRunRequest{
+		ServiceId: req.ServiceID,
+		GitRef: &conductorv1.GitRef{
+			Branch:    req.Branch,
+			CommitSha: req.CommitSHA,
+		},
+		TestIds:        req.TestIDs,
+		Tags:           req.Tags,
+		Environment:    req.Environment,
+		Labels:         req.Labels,
+		Priority:       int32(req.Priority),
+		Timeout:        durationToProto(req.Timeout),
+		Trigger:        trigger,
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	run := runFromProto(resp.GetRun())
+	return &run, nil
+}
+
+// GetRun returns a run by ID.
+func (c *Client) GetRun(ctx context.Context, runID string) (*Run, error) {
+	resp, err := c.runs.GetRun(ctx, &conductorv1.GetRunRequest{RunId: runID})
+	if err != nil {
+		return nil, err
+	}
+	run := runFromProto(resp.GetRun())
+	return &run, nil
+}
+
+// ListRuns lists runs, newest first.
+func (c *Client) ListRuns(ctx context.Context, filter RunFilter) (*Page[Run], error) {
+	statuses := make([]conductorv1.RunStatus, len(filter.Statuses))
+	for i, s := range filter.Statuses {
+		statuses[i] = runStatusValues[s]
+	}
+
+	resp, err := c.runs.ListRuns(ctx, &conductorv1.ListRunsRequest{
+		ServiceId:  filter.ServiceID,
+		Statuses:   statuses,
+		Branch:     filter.Branch,
+		CommitSha:  filter.CommitSHA,
+		Labels:     filter.Labels,
+		Pagination: filter.toProto(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newPage(resp.GetRuns(), resp.GetPagination(), runFromProto), nil
+}
+
+// CancelRun cancels a pending or running run.
+func (c *Client) CancelRun(ctx context.Context, runID, reason string) (*Run, error) {
+	resp, err := c.runs.CancelRun(ctx, &conductorv1.CancelRunRequest{RunId: runID, Reason: reason})
+	if err != nil {
+		return nil, err
+	}
+	run := runFromProto(resp.GetRun())
+	return &run, nil
+}
+
+// ApproveRun releases a run waiting for approval to the scheduler. The
+// comment is recorded with the approval in the audit log.
+func (c *Client) ApproveRun(ctx context.Context, runID, comment string) (*Run, error) {
+	resp, err := c.runs.ApproveRun(ctx, &conductorv1.ApproveRunRequest{RunId: runID, Comment: comment})
+	if err != nil {
+		return nil, err
+	}
+	run := runFromProto(resp.GetRun())
+	return &run, nil
+}
+
+// RetryRun queues a new run with the parameters of a finished one. With
+// failedOnly, only the tests that failed are run again.
+func (c *Client) RetryRun(ctx context.Context, runID string, failedOnly bool) (*Run, error) {
+	resp, err := c.runs.RetryRun(ctx, &conductorv1.RetryRunRequest{RunId: runID, FailedOnly: failedOnly})
+	if err != nil {
+		return nil, err
+	}
+	run := runFromProto(resp.GetRun())
+	return &run, nil
+}
+
+// WaitForRun polls a run every interval (DefaultWaitInterval if 0) until it
+// finishes or ctx is done, and returns the finished run.
+func (c *Client) WaitForRun(ctx context.Context, runID string, interval time.Duration) (*Run, error) {
+	if interval <= 0 {
+		interval = DefaultWaitInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		run, err := c.GetRun(ctx, runID)
+		if err != nil {
+			return nil, err
+		}
+		if run.Status.IsTerminal() {
+			return run, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// StreamRunLogs calls fn with each line of a run's output until the run
+// finishes, fn returns an error, or ctx is done. When the stream breaks with
+// a transient error it is reopened after the last line received, following
+// the client's retry policy.
+func (c *Client) StreamRunLogs(ctx context.Context, runID string, opts LogOptions, fn func(LogEntry) error) error {
+	req := &conductorv1.StreamRunLogsRequest{
+		RunId:        runID,
+		Stream:       logStreamValues[opts.Stream],
+		TestId:       opts.TestID,
+		FromSequence: opts.FromSequence,
+	}
+
+	for failures := 0; ; {
+		err := c.streamRunLogs(ctx, req, func(entry *conductorv1.RunLogEntry) error {
+			failures = 0
+			req.FromSequence = entry.GetSequence() + 1
+			return fn(logEntryFromProto(entry))
+		})
+		if err == nil {
+			return nil
+		}
+
+		var stop stopError
+		if errors.As(err, &stop) {
+			return stop.err
+		}
+
+		failures++
+		if failures >= c.retry.MaxAttempts || !c.retry.retryable(err) {
+			return err
+		}
+		if sleepErr := c.retry.sleep(ctx, failures); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// stopError wraps an error returned by a StreamRunLogs callback, which ends
+// the stream without retrying.
+type stopError struct {
+	err error
+}
+
+func (e stopError) Error() string {
+	return e.err.Error()
+}
+
+// streamRunLogs opens a log stream and passes entries to fn until the stream
+// ends. It returns nil when the server closed the stream.
+func (c *Client) streamRunLogs(ctx context.Context, req *conductorv1.StreamRunLogsRequest, fn func(*conductorv1.RunLogEntry) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.runs.StreamRunLogs(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	for {
+		entry, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return stopError{err: err}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+// ServiceFilter selects the services ListServices returns.
+type ServiceFilter struct {
+	// Owner filters by owning team.
+	Owner string
+	// NetworkZone filters by network zone.
+	NetworkZone string
+	// Labels filters by labels; all must match.
+	Labels map[string]string
+	// Query searches service names.
+	Query string
+	ListOptions
+}
+
+// CreateServiceRequest describes a service to register.
+type CreateServiceRequest struct {
+	Name          string
+	GitURL        string
+	DefaultBranch string
+	Owner         string
+	NetworkZones  []string
+	Labels        map[string]string
+	// ConfigPath is the path of the test manifest in the repository.
+	ConfigPath string
+	// DefaultTimeout bounds runs of the service; 0 uses the server default.
+	DefaultTimeout time.Duration
+	AgentPool      string
+	// ProjectID is the project the service belongs to; empty uses the
+	// caller's first project.
+	ProjectID string
+}
+
+// ListServices lists the registered services.
+func (c *Client) ListServices(ctx context.Context, filter ServiceFilter) (*Page[Service], error) {
+	resp, err := c.services.ListServices(ctx, &conductorv1.ListServicesRequest{
+		Owner:       filter.Owner,
+		NetworkZone: filter.NetworkZone,
+		Labels:      filter.Labels,
+		Query:       filter.Query,
+		Pagination:  filter.toProto(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newPage(resp.GetServices(), resp.GetPagination(), serviceFromProto), nil
+}
+
+// GetService returns a service by ID.
+func (c *Client) GetService(ctx context.Context, serviceID string) (*Service, error) {
+	resp, err := c.services.GetService(ctx, &conductorv1.GetServiceRequest{ServiceId: serviceID})
+	if err != nil {
+		return nil, err
+	}
+	service := serviceFromProto(resp.GetService())
+	return &service, nil
+}
+
+// CreateService registers a service.
+func (c *Client) CreateService(ctx context.Context, req CreateServiceRequest) (*Service, error) {
+	resp, err := c.services.CreateService(ctx, &conductorv1.CreateServiceRequest{
+		Name:           req.Name,
+		GitUrl:         req.GitURL,
+		DefaultBranch:  req.DefaultBranch,
+		Owner:          req.Owner,
+		NetworkZones:   req.NetworkZones,
+		Labels:         req.Labels,
+		ConfigPath:     req.ConfigPath,
+		DefaultTimeout: durationToProto(req.DefaultTimeout),
+		AgentPool:      req.AgentPool,
+		ProjectId:      req.ProjectID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	service := serviceFromProto(resp.GetService())
+	return &service, nil
+}
+
+// DeleteService deletes a service. Its run history is kept unless
+// deleteHistory is set.
+func (c *Client) DeleteService(ctx context.Context, serviceID string, deleteHistory bool) error {
+	_, err := c.services.DeleteService(ctx, &conductorv1.DeleteServiceRequest{
+		ServiceId:     serviceID,
+		DeleteHistory: deleteHistory,
+	})
+	return err
+}
+
+// SyncService re-reads the test manifest of a service from its repository.
+// An empty branch syncs the default branch.
+func (c *Client) SyncService(ctx context.Context, serviceID, branch string) error {
+	_, err := c.services.SyncService(ctx, &conductorv1.SyncServiceRequest{
+		ServiceId: serviceID,
+		Branch:    branch,
+	})
+	return err
+}
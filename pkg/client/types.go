@@ -0,0 +1,433 @@
+package client
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	conductorv1 "github.com/conductor/conductor/api/gen/conductor/v1"
+)
+
+// RunStatus is the status of a test run.
+type RunStatus string
+
+// Run statuses.
+const (
+	RunStatusPending   RunStatus = "pending"
+	RunStatusRunning   RunStatus = "running"
+	RunStatusPassed    RunStatus = "passed"
+	RunStatusFailed    RunStatus = "failed"
+	RunStatusError     RunStatus = "error"
+	RunStatusTimeout   RunStatus = "timeout"
+	RunStatusCancelled RunStatus = "cancelled"
+
+	RunStatusWaitingApproval RunStatus = "waiting_approval"
+)
+
+var runStatuses = map[conductorv1.RunStatus]RunStatus{
+	conductorv1.RunStatus_RUN_STATUS_PENDING:   RunStatusPending,
+	conductorv1.RunStatus_RUN_STATUS_RUNNING:   RunStatusRunning,
+	conductorv1.RunStatus_RUN_STATUS_PASSED:    RunStatusPassed,
+	conductorv1.RunStatus_RUN_STATUS_FAILED:    RunStatusFailed,
+	conductorv1.RunStatus_RUN_STATUS_ERROR:     RunStatusError,
+	conductorv1.RunStatus_RUN_STATUS_TIMEOUT:   RunStatusTimeout,
+	conductorv1.RunStatus_RUN_STATUS_CANCELLED: RunStatusCancelled,
+
+	conductorv1.RunStatus_RUN_STATUS_WAITING_APPROVAL: RunStatusWaitingApproval,
+}
+
+// IsTerminal reports whether a run with this status has finished.
+func (s RunStatus) IsTerminal() bool {
+	switch s {
+	case RunStatusPassed, RunStatusFailed, RunStatusError, RunStatusTimeout, RunStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// TestStatus is the outcome of a single test.
+type TestStatus string
+
+// Test statuses.
+const (
+	TestStatusPass  TestStatus = "pass"
+	TestStatusFail  TestStatus = "fail"
+	TestStatusSkip  TestStatus = "skip"
+	TestStatusError TestStatus = "error"
+)
+
+var testStatuses = map[conductorv1.TestStatus]TestStatus{
+	conductorv1.TestStatus_TEST_STATUS_PASS:  TestStatusPass,
+	conductorv1.TestStatus_TEST_STATUS_FAIL:  TestStatusFail,
+	conductorv1.TestStatus_TEST_STATUS_SKIP:  TestStatusSkip,
+	conductorv1.TestStatus_TEST_STATUS_ERROR: TestStatusError,
+}
+
+// AgentStatus is the status of an agent.
+type AgentStatus string
+
+// Agent statuses.
+const (
+	AgentStatusIdle     AgentStatus = "idle"
+	AgentStatusBusy     AgentStatus = "busy"
+	AgentStatusDraining AgentStatus = "draining"
+	AgentStatusOffline  AgentStatus = "offline"
+)
+
+var agentStatuses = map[conductorv1.AgentStatus]AgentStatus{
+	conductorv1.AgentStatus_AGENT_STATUS_IDLE:     AgentStatusIdle,
+	conductorv1.AgentStatus_AGENT_STATUS_BUSY:     AgentStatusBusy,
+	conductorv1.AgentStatus_AGENT_STATUS_DRAINING: AgentStatusDraining,
+	conductorv1.AgentStatus_AGENT_STATUS_OFFLINE:  AgentStatusOffline,
+}
+
+// ChannelType is the kind of a notification channel.
+type ChannelType string
+
+// Notification channel types.
+const (
+	ChannelTypeSlack      ChannelType = "slack"
+	ChannelTypeEmail      ChannelType = "email"
+	ChannelTypeWebhook    ChannelType = "webhook"
+	ChannelTypePagerDuty  ChannelType = "pagerduty"
+	ChannelTypeTeams      ChannelType = "teams"
+	ChannelTypeDiscord    ChannelType = "discord"
+	ChannelTypeMattermost ChannelType = "mattermost"
+)
+
+var channelTypes = map[conductorv1.ChannelType]ChannelType{
+	conductorv1.ChannelType_CHANNEL_TYPE_SLACK:      ChannelTypeSlack,
+	conductorv1.ChannelType_CHANNEL_TYPE_EMAIL:      ChannelTypeEmail,
+	conductorv1.ChannelType_CHANNEL_TYPE_WEBHOOK:    ChannelTypeWebhook,
+	conductorv1.ChannelType_CHANNEL_TYPE_PAGERDUTY:  ChannelTypePagerDuty,
+	conductorv1.ChannelType_CHANNEL_TYPE_TEAMS:      ChannelTypeTeams,
+	conductorv1.ChannelType_CHANNEL_TYPE_DISCORD:    ChannelTypeDiscord,
+	conductorv1.ChannelType_CHANNEL_TYPE_MATTERMOST: ChannelTypeMattermost,
+}
+
+// LogStream is the output stream a log line was written to.
+type LogStream string
+
+// Log streams.
+const (
+	LogStreamStdout LogStream = "stdout"
+	LogStreamStderr LogStream = "stderr"
+)
+
+var logStreams = map[conductorv1.LogStream]LogStream{
+	conductorv1.LogStream_LOG_STREAM_STDOUT: LogStreamStdout,
+	conductorv1.LogStream_LOG_STREAM_STDERR: LogStreamStderr,
+}
+
+// reverse inverts an enum mapping, for converting filters to their API form.
+func reverse[K, V comparable](m map[K]V) map[V]K {
+	r := make(map[V]K, len(m))
+	for k, v := range m {
+		r[v] = k
+	}
+	return r
+}
+
+var (
+	runStatusValues   = reverse(runStatuses)
+	testStatusValues  = reverse(testStatuses)
+	agentStatusValues = reverse(agentStatuses)
+	channelTypeValues = reverse(channelTypes)
+	logStreamValues   = reverse(logStreams)
+)
+
+// ListOptions selects a page of a list call.
+type ListOptions struct {
+	// PageSize is the maximum number of items to return; 0 uses the server
+	// default.
+	PageSize int
+	// PageToken is the NextPageToken of the previous page; empty for the
+	// first page.
+	PageToken string
+}
+
+func (o ListOptions) toProto() *conductorv1.Pagination {
+	return &conductorv1.Pagination{PageSize: int32(o.PageSize), PageToken: o.PageToken}
+}
+
+// Page is a page of a list call.
+type Page[T any] struct {
+	// Items are the items of the page.
+	Items []T
+	// NextPageToken fetches the following page; empty on the last page.
+	NextPageToken string
+	// TotalCount is the number of items across all pages, if the server
+	// computed it.
+	TotalCount int64
+}
+
+func newPage[P, T any](items []P, pagination *conductorv1.PaginationResponse, convert func(P) T) *Page[T] {
+	page := &Page[T]{
+		Items:         make([]T, len(items)),
+		NextPageToken: pagination.GetNextPageToken(),
+		TotalCount:    pagination.GetTotalCount(),
+	}
+	for i, item := range items {
+		page.Items[i] = convert(item)
+	}
+	return page
+}
+
+// Service is a repository registered with Conductor.
+type Service struct {
+	ID            string
+	Name          string
+	GitURL        string
+	DefaultBranch string
+	Owner         string
+	NetworkZones  []string
+	Labels        map[string]string
+	AgentPool     string
+	ProjectID     string
+	TestCount     int
+	Active        bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	LastSyncedAt  *time.Time
+	ArchivedAt    *time.Time
+}
+
+func serviceFromProto(s *conductorv1.Service) Service {
+	return Service{
+		ID:            s.GetId(),
+		Name:          s.GetName(),
+		GitURL:        s.GetGitUrl(),
+		DefaultBranch: s.GetDefaultBranch(),
+		Owner:         s.GetOwner(),
+		NetworkZones:  s.GetNetworkZones(),
+		Labels:        s.GetLabels(),
+		AgentPool:     s.GetAgentPool(),
+		ProjectID:     s.GetProjectId(),
+		TestCount:     int(s.GetTestCount()),
+		Active:        s.GetActive(),
+		CreatedAt:     timeFromProto(s.GetCreatedAt()),
+		UpdatedAt:     timeFromProto(s.GetUpdatedAt()),
+		LastSyncedAt:  optionalTimeFromProto(s.GetLastSyncedAt()),
+		ArchivedAt:    optionalTimeFromProto(s.GetArchivedAt()),
+	}
+}
+
+// Run is a test run of a service.
+type Run struct {
+	ID                string
+	ServiceID         string
+	ServiceName       string
+	Status            RunStatus
+	Branch            string
+	CommitSHA         string
+	PullRequestNumber int64
+	Tag               string
+	AgentID           string
+	AgentName         string
+	Priority          int
+	Labels            map[string]string
+	Summary           RunSummary
+	ErrorMessage      string
+	RetryOfRunID      string
+	Attempt           int
+	ApprovalReason    string
+	ApprovedBy        string
+	ApprovedAt        *time.Time
+	CreatedAt         time.Time
+	StartedAt         *time.Time
+	FinishedAt        *time.Time
+}
+
+// RunSummary counts the tests of a run.
+type RunSummary struct {
+	Total    int
+	Passed   int
+	Failed   int
+	Skipped  int
+	Errored  int
+	Duration time.Duration
+}
+
+func runFromProto(r *conductorv1.Run) Run {
+	summary := r.GetSummary()
+	return Run{
+		ID:                r.GetId(),
+		ServiceID:         r.GetServiceId(),
+		ServiceName:       r.GetServiceName(),
+		Status:            runStatuses[r.GetStatus()],
+		Branch:            r.GetGitRef().GetBranch(),
+		CommitSHA:         r.GetGitRef().GetCommitSha(),
+		PullRequestNumber: r.GetGitRef().GetPullRequestNumber(),
+		Tag:               r.GetGitRef().GetTag(),
+		AgentID:           r.GetAgentId(),
+		AgentName:         r.GetAgentName(),
+		Priority:          int(r.GetPriority()),
+		Labels:            r.GetLabels(),
+		Summary: RunSummary{
+			Total:    int(summary.GetTotal()),
+			Passed:   int(summary.GetPassed()),
+			Failed:   int(summary.GetFailed()),
+			Skipped:  int(summary.GetSkipped()),
+			Errored:  int(summary.GetErrored()),
+			Duration: durationFromProto(summary.GetDuration()),
+		},
+		ErrorMessage:   r.GetErrorMessage(),
+		RetryOfRunID:   r.GetRetryOfRunId(),
+		Attempt:        int(r.GetAttempt()),
+		ApprovalReason: r.GetApprovalReason(),
+		ApprovedBy:     r.GetApprovedBy(),
+		ApprovedAt:     optionalTimeFromProto(r.GetApprovedAt()),
+		CreatedAt:      timeFromProto(r.GetCreatedAt()),
+		StartedAt:      optionalTimeFromProto(r.GetStartedAt()),
+		FinishedAt:     optionalTimeFromProto(r.GetFinishedAt()),
+	}
+}
+
+// TestResult is the result of a single test in a run.
+type TestResult struct {
+	ID           string
+	RunID        string
+	Name         string
+	Suite        string
+	Status       TestStatus
+	Duration     time.Duration
+	ErrorMessage string
+	StackTrace   string
+	Stdout       string
+	Stderr       string
+	RetryAttempt int
+	Metadata     map[string]string
+	StartedAt    *time.Time
+	FinishedAt   *time.Time
+}
+
+func testResultFromProto(r *conductorv1.TestResult) TestResult {
+	return TestResult{
+		ID:           r.GetId(),
+		RunID:        r.GetRunId(),
+		Name:         r.GetTestName(),
+		Suite:        r.GetSuiteName(),
+		Status:       testStatuses[r.GetStatus()],
+		Duration:     time.Duration(r.GetDurationMs()) * time.Millisecond,
+		ErrorMessage: r.GetErrorMessage(),
+		StackTrace:   r.GetStackTrace(),
+		Stdout:       r.GetStdout(),
+		Stderr:       r.GetStderr(),
+		RetryAttempt: int(r.GetRetryAttempt()),
+		Metadata:     r.GetMetadata(),
+		StartedAt:    optionalTimeFromProto(r.GetStartedAt()),
+		FinishedAt:   optionalTimeFromProto(r.GetFinishedAt()),
+	}
+}
+
+// Agent is a machine that executes test runs.
+type Agent struct {
+	ID             string
+	Name           string
+	Status         AgentStatus
+	Version        string
+	Pool           string
+	ProjectID      string
+	NetworkZones   []string
+	Labels         map[string]string
+	OS             string
+	Arch           string
+	Hostname       string
+	MaxParallel    int
+	ActiveRunCount int
+	RegisteredAt   time.Time
+	LastHeartbeat  *time.Time
+}
+
+func agentFromProto(a *conductorv1.Agent) Agent {
+	return Agent{
+		ID:             a.GetId(),
+		Name:           a.GetName(),
+		Status:         agentStatuses[a.GetStatus()],
+		Version:        a.GetVersion(),
+		Pool:           a.GetPool(),
+		ProjectID:      a.GetProjectId(),
+		NetworkZones:   a.GetNetworkZones(),
+		Labels:         a.GetLabels(),
+		OS:             a.GetOs(),
+		Arch:           a.GetArch(),
+		Hostname:       a.GetHostname(),
+		MaxParallel:    int(a.GetMaxParallel()),
+		ActiveRunCount: int(a.GetActiveRunCount()),
+		RegisteredAt:   timeFromProto(a.GetRegisteredAt()),
+		LastHeartbeat:  optionalTimeFromProto(a.GetLastHeartbeat()),
+	}
+}
+
+// Channel is a notification channel.
+type Channel struct {
+	ID                string
+	Name              string
+	Type              ChannelType
+	Enabled           bool
+	NotificationCount int64
+	CreatedAt         time.Time
+	LastUsedAt        *time.Time
+}
+
+func channelFromProto(c *conductorv1.NotificationChannel) Channel {
+	return Channel{
+		ID:                c.GetId(),
+		Name:              c.GetName(),
+		Type:              channelTypes[c.GetType()],
+		Enabled:           c.GetEnabled(),
+		NotificationCount: c.GetNotificationCount(),
+		CreatedAt:         timeFromProto(c.GetCreatedAt()),
+		LastUsedAt:        optionalTimeFromProto(c.GetLastUsedAt()),
+	}
+}
+
+// LogEntry is a line of run output.
+type LogEntry struct {
+	// Sequence orders the lines of a run; streams resume after it.
+	Sequence  int64
+	Timestamp time.Time
+	Stream    LogStream
+	Message   string
+	// TestID is the test that wrote the line, if known.
+	TestID string
+}
+
+func logEntryFromProto(e *conductorv1.RunLogEntry) LogEntry {
+	return LogEntry{
+		Sequence:  e.GetSequence(),
+		Timestamp: timeFromProto(e.GetTimestamp()),
+		Stream:    logStreams[e.GetStream()],
+		Message:   e.GetMessage(),
+		TestID:    e.GetTestId(),
+	}
+}
+
+func timeFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func optionalTimeFromProto(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func durationFromProto(d *conductorv1.Duration) time.Duration {
+	return time.Duration(d.GetSeconds())*time.Second + time.Duration(d.GetNanos())
+}
+
+func durationToProto(d time.Duration) *conductorv1.Duration {
+	if d == 0 {
+		return nil
+	}
+	return &conductorv1.Duration{
+		Seconds: int64(d / time.Second),
+		Nanos:   int32(d % time.Second),
+	}
+}
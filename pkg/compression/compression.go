@@ -0,0 +1,108 @@
+// Package compression registers the gRPC message compressors agents and the
+// control plane negotiate on the work stream. Importing it registers gzip
+// and zstd; the control plane answers each stream in the encoding the agent
+// sends with.
+package compression
+
+import (
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// Supported compression settings.
+const (
+	// None sends messages uncompressed.
+	None = "none"
+	// Gzip compresses messages with gzip.
+	Gzip = gzip.Name
+	// Zstd compresses messages with zstd, which is faster than gzip at a
+	// similar ratio.
+	Zstd = "zstd"
+)
+
+// maxDecoderMemory bounds the memory a zstd stream may ask the decoder to
+// allocate; messages are capped well below it.
+const maxDecoderMemory = 64 << 20
+
+func init() {
+	encoding.RegisterCompressor(&zstdCompressor{})
+}
+
+// Valid reports whether name is a supported compression setting.
+func Valid(name string) bool {
+	switch name {
+	case None, Gzip, Zstd:
+		return true
+	}
+	return false
+}
+
+// zstdCompressor implements encoding.Compressor with pooled zstd encoders
+// and decoders, like the gzip compressor of grpc.
+type zstdCompressor struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+func (c *zstdCompressor) Name() string {
+	return Zstd
+}
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	if enc, ok := c.encoders.Get().(*zstd.Encoder); ok {
+		enc.Reset(w)
+		return &zstdWriter{Encoder: enc, pool: &c.encoders}, nil
+	}
+	enc, err := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &zstdWriter{Encoder: enc, pool: &c.encoders}, nil
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	if dec, ok := c.decoders.Get().(*zstd.Decoder); ok {
+		if err := dec.Reset(r); err != nil {
+			c.decoders.Put(dec)
+			return nil, err
+		}
+		return &zstdReader{Decoder: dec, pool: &c.decoders}, nil
+	}
+	dec, err := zstd.NewReader(r,
+		zstd.WithDecoderConcurrency(1),
+		zstd.WithDecoderMaxMemory(maxDecoderMemory),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &zstdReader{Decoder: dec, pool: &c.decoders}, nil
+}
+
+// zstdWriter returns its encoder to the pool once the message is written.
+type zstdWriter struct {
+	*zstd.Encoder
+	pool *sync.Pool
+}
+
+func (w *zstdWriter) Close() error {
+	defer w.pool.Put(w.Encoder)
+	return w.Encoder.Close()
+}
+
+// zstdReader returns its decoder to the pool once the message is read.
+type zstdReader struct {
+	*zstd.Decoder
+	pool *sync.Pool
+}
+
+func (r *zstdReader) Read(p []byte) (int, error) {
+	n, err := r.Decoder.Read(p)
+	if err == io.EOF {
+		r.pool.Put(r.Decoder)
+	}
+	return n, err
+}
@@ -0,0 +1,61 @@
+package compression
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/encoding"
+)
+
+func TestCompressors(t *testing.T) {
+	message := []byte(strings.Repeat("PASS TestCheckout/applies_discount (0.01s)\n", 2000))
+
+	for _, name := range []string{Gzip, Zstd} {
+		t.Run(name, func(t *testing.T) {
+			compressor := encoding.GetCompressor(name)
+			if compressor == nil {
+				t.Fatalf("%s compressor is not registered", name)
+			}
+
+			// Round trip twice to reuse the pooled encoder and decoder
+			for i := 0; i < 2; i++ {
+				var compressed bytes.Buffer
+				w, err := compressor.Compress(&compressed)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := w.Write(message); err != nil {
+					t.Fatal(err)
+				}
+				if err := w.Close(); err != nil {
+					t.Fatal(err)
+				}
+				if compressed.Len() >= len(message)/10 {
+					t.Errorf("compressed %d bytes to %d", len(message), compressed.Len())
+				}
+
+				r, err := compressor.Decompress(&compressed)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, message) {
+					t.Fatalf("round trip changed the message")
+				}
+			}
+		})
+	}
+}
+
+func TestValid(t *testing.T) {
+	for name, want := range map[string]bool{None: true, Gzip: true, Zstd: true, "": false, "brotli": false} {
+		if got := Valid(name); got != want {
+			t.Errorf("Valid(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
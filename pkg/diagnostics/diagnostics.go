@@ -0,0 +1,187 @@
+// Package diagnostics serves runtime diagnostics of a process for debugging
+// it in production: pprof profiles, goroutine dumps, memory and garbage
+// collector statistics, and the state of its components, such as open
+// streams and the sizes of internal queues.
+package diagnostics
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	rpprof "runtime/pprof"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WriteTimeout is the least write timeout of servers serving diagnostics, so
+// that CPU profiles and execution traces of up to a minute complete.
+const WriteTimeout = 65 * time.Second
+
+// recentPauses is the number of most recent GC pauses reported.
+const recentPauses = 10
+
+// Source returns the state of a component, which is reported as JSON.
+type Source func() any
+
+// Diagnostics serves the diagnostics of the process to clients presenting a
+// bearer token. See Mount for the endpoints.
+type Diagnostics struct {
+	token   string
+	started time.Time
+
+	mu      sync.RWMutex
+	sources map[string]Source
+}
+
+// New creates diagnostics served to clients presenting token. No client is
+// served with an empty token.
+func New(token string) *Diagnostics {
+	return &Diagnostics{
+		token:   token,
+		started: time.Now(),
+		sources: make(map[string]Source),
+	}
+}
+
+// Register adds the state of a component to the report, under name. It may
+// be called while diagnostics are served.
+func (d *Diagnostics) Register(name string, source Source) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sources[name] = source
+}
+
+// Mount serves the diagnostics on mux:
+//
+//	/debug/pprof/       pprof profiles, as net/http/pprof serves them
+//	/debug/goroutines   stack traces of all goroutines, as text
+//	/debug/diagnostics  report of the runtime and components, as JSON
+func (d *Diagnostics) Mount(mux *http.ServeMux) {
+	mux.Handle("/debug/pprof/", d.authorize(http.HandlerFunc(pprof.Index)))
+	mux.Handle("/debug/pprof/cmdline", d.authorize(http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("/debug/pprof/profile", d.authorize(http.HandlerFunc(pprof.Profile)))
+	mux.Handle("/debug/pprof/symbol", d.authorize(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("/debug/pprof/trace", d.authorize(http.HandlerFunc(pprof.Trace)))
+	mux.Handle("/debug/goroutines", d.authorize(http.HandlerFunc(d.serveGoroutines)))
+	mux.Handle("/debug/diagnostics", d.authorize(http.HandlerFunc(d.serveReport)))
+}
+
+// authorize serves requests presenting the token as a bearer token.
+func (d *Diagnostics) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || d.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(d.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="diagnostics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (d *Diagnostics) serveGoroutines(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_ = rpprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+func (d *Diagnostics) serveReport(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(d.Report())
+}
+
+// Report is a snapshot of the runtime and the components of a process.
+type Report struct {
+	Time       time.Time      `json:"time"`
+	Uptime     string         `json:"uptime"`
+	GoVersion  string         `json:"go_version"`
+	NumCPU     int            `json:"num_cpu"`
+	GOMAXPROCS int            `json:"gomaxprocs"`
+	Goroutines int            `json:"goroutines"`
+	Memory     MemoryStats    `json:"memory"`
+	GC         GCStats        `json:"gc"`
+	Components map[string]any `json:"components,omitempty"`
+}
+
+// MemoryStats are statistics of the memory of the Go runtime.
+type MemoryStats struct {
+	HeapAllocBytes   uint64 `json:"heap_alloc_bytes"`
+	HeapInuseBytes   uint64 `json:"heap_inuse_bytes"`
+	HeapIdleBytes    uint64 `json:"heap_idle_bytes"`
+	HeapObjects      uint64 `json:"heap_objects"`
+	StackInuseBytes  uint64 `json:"stack_inuse_bytes"`
+	SysBytes         uint64 `json:"sys_bytes"`
+	TotalAllocBytes  uint64 `json:"total_alloc_bytes"`
+	NextGCHeapBytes  uint64 `json:"next_gc_heap_bytes"`
+	MemoryLimitBytes int64  `json:"memory_limit_bytes"`
+}
+
+// GCStats are statistics of the garbage collector.
+type GCStats struct {
+	NumGC       int64     `json:"num_gc"`
+	LastGC      time.Time `json:"last_gc"`
+	PauseTotal  string    `json:"pause_total"`
+	CPUFraction float64   `json:"cpu_fraction"`
+	// RecentPauses are the most recent pauses, most recent first.
+	RecentPauses []string `json:"recent_pauses"`
+}
+
+// Report returns a snapshot of the runtime and of the registered components.
+func (d *Diagnostics) Report() Report {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+
+	report := Report{
+		Time:       time.Now().UTC(),
+		Uptime:     time.Since(d.started).Round(time.Second).String(),
+		GoVersion:  runtime.Version(),
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Goroutines: runtime.NumGoroutine(),
+		Memory: MemoryStats{
+			HeapAllocBytes:   mem.HeapAlloc,
+			HeapInuseBytes:   mem.HeapInuse,
+			HeapIdleBytes:    mem.HeapIdle,
+			HeapObjects:      mem.HeapObjects,
+			StackInuseBytes:  mem.StackInuse,
+			SysBytes:         mem.Sys,
+			TotalAllocBytes:  mem.TotalAlloc,
+			NextGCHeapBytes:  mem.NextGC,
+			MemoryLimitBytes: debug.SetMemoryLimit(-1),
+		},
+		GC: GCStats{
+			NumGC:        gc.NumGC,
+			LastGC:       gc.LastGC.UTC(),
+			PauseTotal:   gc.PauseTotal.String(),
+			CPUFraction:  mem.GCCPUFraction,
+			RecentPauses: make([]string, 0, recentPauses),
+		},
+	}
+	for i, pause := range gc.Pause {
+		if i == recentPauses {
+			break
+		}
+		report.GC.RecentPauses = append(report.GC.RecentPauses, pause.String())
+	}
+
+	// Sources are called without the lock, as they may take locks of their own
+	d.mu.RLock()
+	sources := maps.Clone(d.sources)
+	d.mu.RUnlock()
+
+	if len(sources) > 0 {
+		report.Components = make(map[string]any, len(sources))
+		for name, source := range sources {
+			report.Components[name] = source()
+		}
+	}
+	return report
+}
@@ -0,0 +1,84 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, d *Diagnostics, path, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	d.Mount(mux)
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDiagnostics_Authorization(t *testing.T) {
+	paths := []string{
+		"/debug/pprof/",
+		"/debug/pprof/cmdline",
+		"/debug/pprof/symbol",
+		"/debug/goroutines",
+		"/debug/diagnostics",
+	}
+
+	tests := []struct {
+		name       string
+		configured string
+		presented  string
+		wantStatus int
+	}{
+		{"valid token", "secret", "secret", http.StatusOK},
+		{"wrong token", "secret", "other", http.StatusUnauthorized},
+		{"missing token", "secret", "", http.StatusUnauthorized},
+		{"no token configured", "", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := New(tt.configured)
+			for _, path := range paths {
+				rec := serve(t, d, path, tt.presented)
+				assert.Equal(t, tt.wantStatus, rec.Code, path)
+				if tt.wantStatus == http.StatusUnauthorized {
+					assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"), path)
+				}
+			}
+		})
+	}
+}
+
+func TestDiagnostics_Report(t *testing.T) {
+	d := New("secret")
+	d.Register("queues", func() any { return map[string]int{"work": 3} })
+
+	rec := serve(t, d, "/debug/diagnostics", "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var report map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.NotEmpty(t, report["go_version"])
+	assert.Greater(t, report["goroutines"], 0.0)
+	assert.Contains(t, report["memory"], "heap_alloc_bytes")
+	assert.Contains(t, report["gc"], "num_gc")
+	assert.Equal(t, map[string]any{"queues": map[string]any{"work": 3.0}}, report["components"])
+}
+
+func TestDiagnostics_Goroutines(t *testing.T) {
+	rec := serve(t, New("secret"), "/debug/goroutines", "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Body.String(), "goroutine "), rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "TestDiagnostics_Goroutines")
+}
@@ -0,0 +1,358 @@
+// Package errcode defines the machine-readable error codes returned by the
+// Conductor API.
+//
+// Every error returned by the control plane carries a google.rpc.ErrorInfo
+// detail whose reason is one of the codes below. Clients should branch on the
+// code rather than matching the human-readable message, which may change.
+package errcode
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Domain is the ErrorInfo domain used for all Conductor error codes.
+const Domain = "conductor.dev"
+
+// HTTPHeader is the response header that carries the error code on
+// HTTP API responses.
+const HTTPHeader = "X-Conductor-Error-Code"
+
+// Code is a stable, machine-readable error identifier.
+type Code string
+
+// Generic codes, used when no more specific code applies.
+const (
+	// Unknown is returned by FromError when an error carries no code.
+	Unknown Code = "CONDUCTOR_UNKNOWN"
+	// InvalidArgument indicates a malformed or missing request field.
+	InvalidArgument Code = "CONDUCTOR_INVALID_ARGUMENT"
+	// Unauthenticated indicates missing or invalid credentials.
+	Unauthenticated Code = "CONDUCTOR_UNAUTHENTICATED"
+	// PermissionDenied indicates the caller may not perform the operation.
+	PermissionDenied Code = "CONDUCTOR_PERMISSION_DENIED"
+	// NotFound indicates a resource that does not exist.
+	NotFound Code = "CONDUCTOR_NOT_FOUND"
+	// AlreadyExists indicates a conflicting resource already exists.
+	AlreadyExists Code = "CONDUCTOR_ALREADY_EXISTS"
+	// FailedPrecondition indicates the resource is not in a valid state.
+	FailedPrecondition Code = "CONDUCTOR_FAILED_PRECONDITION"
+	// QuotaExceeded indicates a quota or rate limit was exceeded.
+	QuotaExceeded Code = "CONDUCTOR_QUOTA_EXCEEDED"
+	// NotConfigured indicates the feature is disabled on this server.
+	NotConfigured Code = "CONDUCTOR_NOT_CONFIGURED"
+	// Unimplemented indicates the operation is not supported yet.
+	Unimplemented Code = "CONDUCTOR_UNIMPLEMENTED"
+	// Unavailable indicates a dependency is temporarily unavailable.
+	Unavailable Code = "CONDUCTOR_UNAVAILABLE"
+	// Internal indicates an unexpected server-side failure.
+	Internal Code = "CONDUCTOR_INTERNAL"
+)
+
+// Resource-specific codes.
+const (
+	// ServiceNotFound indicates the service does not exist.
+	ServiceNotFound Code = "CONDUCTOR_SERVICE_NOT_FOUND"
+	// ServiceAlreadyExists indicates a service with the same name exists.
+	ServiceAlreadyExists Code = "CONDUCTOR_SERVICE_ALREADY_EXISTS"
+	// ServiceArchived indicates the operation is not allowed on an archived service.
+	ServiceArchived Code = "CONDUCTOR_SERVICE_ARCHIVED"
+	// TestNotFound indicates the test definition does not exist.
+	TestNotFound Code = "CONDUCTOR_TEST_NOT_FOUND"
+	// TestAlreadyExists indicates a test definition with the same name exists.
+	TestAlreadyExists Code = "CONDUCTOR_TEST_ALREADY_EXISTS"
+	// RunNotFound indicates the test run does not exist.
+	RunNotFound Code = "CONDUCTOR_RUN_NOT_FOUND"
+	// RunTerminal indicates the run has already finished.
+	RunTerminal Code = "CONDUCTOR_RUN_TERMINAL"
+	// RunThrottled indicates runs for the branch were triggered too often.
+	RunThrottled Code = "CONDUCTOR_RUN_THROTTLED"
+	// AgentNotFound indicates the agent does not exist.
+	AgentNotFound Code = "CONDUCTOR_AGENT_NOT_FOUND"
+	// AgentNotRegistered indicates the agent stream has not registered yet.
+	AgentNotRegistered Code = "CONDUCTOR_AGENT_NOT_REGISTERED"
+	// AgentOnline indicates the operation requires the agent to be offline.
+	AgentOnline Code = "CONDUCTOR_AGENT_ONLINE"
+	// AgentOffline indicates the operation requires the agent to be online.
+	AgentOffline Code = "CONDUCTOR_AGENT_OFFLINE"
+	// AgentNotDraining indicates the agent is not draining.
+	AgentNotDraining Code = "CONDUCTOR_AGENT_NOT_DRAINING"
+	// ArtifactNotFound indicates the artifact does not exist.
+	ArtifactNotFound Code = "CONDUCTOR_ARTIFACT_NOT_FOUND"
+	// ArtifactBudgetExceeded indicates an artifact exceeds its test's artifact budget.
+	ArtifactBudgetExceeded Code = "CONDUCTOR_ARTIFACT_BUDGET_EXCEEDED"
+	// ArtifactTooLarge indicates an artifact exceeds the agent's artifact size limit.
+	ArtifactTooLarge Code = "CONDUCTOR_ARTIFACT_TOO_LARGE"
+	// ChannelNotFound indicates the notification channel does not exist.
+	ChannelNotFound Code = "CONDUCTOR_CHANNEL_NOT_FOUND"
+	// RuleNotFound indicates the notification rule does not exist.
+	RuleNotFound Code = "CONDUCTOR_RULE_NOT_FOUND"
+	// DeliveryNotFound indicates the notification delivery does not exist.
+	DeliveryNotFound Code = "CONDUCTOR_DELIVERY_NOT_FOUND"
+	// DeployKeyNotFound indicates the service has no deploy key.
+	DeployKeyNotFound Code = "CONDUCTOR_DEPLOY_KEY_NOT_FOUND"
+	// GitCredentialNotFound indicates the service has no git credential.
+	GitCredentialNotFound Code = "CONDUCTOR_GIT_CREDENTIAL_NOT_FOUND"
+	// EnvironmentNotFound indicates the service has no environment set.
+	EnvironmentNotFound Code = "CONDUCTOR_ENVIRONMENT_NOT_FOUND"
+	// TriggerRulesNotFound indicates the service has no trigger rules.
+	TriggerRulesNotFound Code = "CONDUCTOR_TRIGGER_RULES_NOT_FOUND"
+	// BranchNotFound indicates the service has no such branch.
+	BranchNotFound Code = "CONDUCTOR_BRANCH_NOT_FOUND"
+	// RetryPolicyNotFound indicates the service or test definition has no retry policy.
+	RetryPolicyNotFound Code = "CONDUCTOR_RETRY_POLICY_NOT_FOUND"
+	// NoMatchingAgent indicates no registered agent satisfies the network
+	// zones and label selector of a run or test definition.
+	NoMatchingAgent Code = "CONDUCTOR_NO_MATCHING_AGENT"
+	// PreflightFailed indicates the commit, container images or secrets of
+	// a run failed pre-flight checks.
+	PreflightFailed Code = "CONDUCTOR_PREFLIGHT_FAILED"
+	// AgentPoolNotFound indicates the agent pool does not exist.
+	AgentPoolNotFound Code = "CONDUCTOR_AGENT_POOL_NOT_FOUND"
+	// AgentPoolAlreadyExists indicates an agent pool with the same name already exists.
+	AgentPoolAlreadyExists Code = "CONDUCTOR_AGENT_POOL_ALREADY_EXISTS"
+	// ScheduleNotFound indicates the service has no such schedule.
+	ScheduleNotFound Code = "CONDUCTOR_SCHEDULE_NOT_FOUND"
+	// OrganizationNotFound indicates the organization does not exist.
+	OrganizationNotFound Code = "CONDUCTOR_ORGANIZATION_NOT_FOUND"
+	// OrganizationAlreadyExists indicates an organization with the same name already exists.
+	OrganizationAlreadyExists Code = "CONDUCTOR_ORGANIZATION_ALREADY_EXISTS"
+	// ProjectAlreadyExists indicates a project with the same name already exists in the organization.
+	ProjectAlreadyExists Code = "CONDUCTOR_PROJECT_ALREADY_EXISTS"
+	// ViewNotFound indicates the caller has no such saved view.
+	ViewNotFound Code = "CONDUCTOR_VIEW_NOT_FOUND"
+	// ViewAlreadyExists indicates the caller saved a view with the same name for the page.
+	ViewAlreadyExists Code = "CONDUCTOR_VIEW_ALREADY_EXISTS"
+	// IdempotencyKeyReused indicates the idempotency key was sent before with a different request.
+	IdempotencyKeyReused Code = "CONDUCTOR_IDEMPOTENCY_KEY_REUSED"
+	// IdempotencyKeyInProgress indicates a request with the same idempotency key has not completed yet.
+	IdempotencyKeyInProgress Code = "CONDUCTOR_IDEMPOTENCY_KEY_IN_PROGRESS"
+)
+
+// Entry describes a catalog entry.
+type Entry struct {
+	Code        Code
+	GRPCCode    codes.Code
+	Description string
+}
+
+var catalog = map[Code]Entry{
+	Unknown:            {Unknown, codes.Unknown, "The error did not carry a Conductor error code."},
+	InvalidArgument:    {InvalidArgument, codes.InvalidArgument, "A request field is missing or malformed."},
+	Unauthenticated:    {Unauthenticated, codes.Unauthenticated, "Credentials are missing or invalid."},
+	PermissionDenied:   {PermissionDenied, codes.PermissionDenied, "The caller is not allowed to perform the operation."},
+	NotFound:           {NotFound, codes.NotFound, "The requested resource does not exist."},
+	AlreadyExists:      {AlreadyExists, codes.AlreadyExists, "A conflicting resource already exists."},
+	FailedPrecondition: {FailedPrecondition, codes.FailedPrecondition, "The resource is not in a state that allows the operation."},
+	QuotaExceeded:      {QuotaExceeded, codes.ResourceExhausted, "A quota or rate limit was exceeded."},
+	NotConfigured:      {NotConfigured, codes.FailedPrecondition, "The feature is not configured on this server."},
+	Unimplemented:      {Unimplemented, codes.Unimplemented, "The operation is not implemented."},
+	Unavailable:        {Unavailable, codes.Unavailable, "A required dependency is temporarily unavailable."},
+	Internal:           {Internal, codes.Internal, "An unexpected server error occurred."},
+
+	ServiceNotFound:           {ServiceNotFound, codes.NotFound, "The service does not exist."},
+	ServiceAlreadyExists:      {ServiceAlreadyExists, codes.AlreadyExists, "A service with the same name already exists."},
+	ServiceArchived:           {ServiceArchived, codes.FailedPrecondition, "The service is archived; unarchive it first."},
+	TestNotFound:              {TestNotFound, codes.NotFound, "The test definition does not exist."},
+	TestAlreadyExists:         {TestAlreadyExists, codes.AlreadyExists, "A test definition with the same name already exists in the service."},
+	RunNotFound:               {RunNotFound, codes.NotFound, "The test run does not exist."},
+	RunTerminal:               {RunTerminal, codes.FailedPrecondition, "The run has already reached a terminal state."},
+	RunThrottled:              {RunThrottled, codes.ResourceExhausted, "Runs for the service and branch were triggered too often; retry later."},
+	AgentNotFound:             {AgentNotFound, codes.NotFound, "The agent does not exist."},
+	AgentNotRegistered:        {AgentNotRegistered, codes.FailedPrecondition, "The agent must register before sending other messages."},
+	AgentOnline:               {AgentOnline, codes.FailedPrecondition, "The agent is online; use force to override."},
+	AgentOffline:              {AgentOffline, codes.FailedPrecondition, "The agent is offline."},
+	AgentNotDraining:          {AgentNotDraining, codes.FailedPrecondition, "The agent is not draining."},
+	ArtifactNotFound:          {ArtifactNotFound, codes.NotFound, "The artifact does not exist."},
+	ArtifactBudgetExceeded:    {ArtifactBudgetExceeded, codes.ResourceExhausted, "The artifact exceeds the test's artifact budget."},
+	ArtifactTooLarge:          {ArtifactTooLarge, codes.ResourceExhausted, "The artifact exceeds the agent's artifact size limit."},
+	ChannelNotFound:           {ChannelNotFound, codes.NotFound, "The notification channel does not exist."},
+	RuleNotFound:              {RuleNotFound, codes.NotFound, "The notification rule does not exist."},
+	DeliveryNotFound:          {DeliveryNotFound, codes.NotFound, "The notification delivery does not exist."},
+	DeployKeyNotFound:         {DeployKeyNotFound, codes.NotFound, "The service has no deploy key."},
+	GitCredentialNotFound:     {GitCredentialNotFound, codes.NotFound, "The service has no git credential."},
+	EnvironmentNotFound:       {EnvironmentNotFound, codes.NotFound, "The service has no environment set."},
+	TriggerRulesNotFound:      {TriggerRulesNotFound, codes.NotFound, "The service has no trigger rules."},
+	BranchNotFound:            {BranchNotFound, codes.NotFound, "The service has no such branch."},
+	RetryPolicyNotFound:       {RetryPolicyNotFound, codes.NotFound, "The service or test definition has no retry policy."},
+	NoMatchingAgent:           {NoMatchingAgent, codes.FailedPrecondition, "No registered agent satisfies the network zones and label selector."},
+	PreflightFailed:           {PreflightFailed, codes.FailedPrecondition, "The run's commit, container images or secrets failed pre-flight checks."},
+	AgentPoolNotFound:         {AgentPoolNotFound, codes.NotFound, "The agent pool does not exist."},
+	AgentPoolAlreadyExists:    {AgentPoolAlreadyExists, codes.AlreadyExists, "An agent pool with the same name already exists."},
+	ScheduleNotFound:          {ScheduleNotFound, codes.NotFound, "The service has no such schedule."},
+	OrganizationNotFound:      {OrganizationNotFound, codes.NotFound, "The organization does not exist."},
+	OrganizationAlreadyExists: {OrganizationAlreadyExists, codes.AlreadyExists, "An organization with the same name already exists."},
+	ProjectAlreadyExists:      {ProjectAlreadyExists, codes.AlreadyExists, "A project with the same name already exists in the organization."},
+	ViewNotFound:              {ViewNotFound, codes.NotFound, "The caller has no such saved view."},
+	ViewAlreadyExists:         {ViewAlreadyExists, codes.AlreadyExists, "A saved view with the same name already exists for the page."},
+	IdempotencyKeyReused:      {IdempotencyKeyReused, codes.InvalidArgument, "The idempotency key was sent before with a different request."},
+	IdempotencyKeyInProgress:  {IdempotencyKeyInProgress, codes.Aborted, "A request with the same idempotency key is in progress; retry later."},
+}
+
+// genericByGRPC maps gRPC codes to generic Conductor codes for errors that
+// were created without a catalog code.
+var genericByGRPC = map[codes.Code]Code{
+	codes.InvalidArgument:    InvalidArgument,
+	codes.Unauthenticated:    Unauthenticated,
+	codes.PermissionDenied:   PermissionDenied,
+	codes.NotFound:           NotFound,
+	codes.AlreadyExists:      AlreadyExists,
+	codes.FailedPrecondition: FailedPrecondition,
+	codes.ResourceExhausted:  QuotaExceeded,
+	codes.Unimplemented:      Unimplemented,
+	codes.Unavailable:        Unavailable,
+	codes.Internal:           Internal,
+}
+
+// GRPCCode returns the gRPC status code associated with the code.
+func (c Code) GRPCCode() codes.Code {
+	if e, ok := catalog[c]; ok {
+		return e.GRPCCode
+	}
+	return codes.Unknown
+}
+
+// String returns the code as a string.
+func (c Code) String() string {
+	return string(c)
+}
+
+// Catalog returns all known codes sorted by name.
+func Catalog() []Entry {
+	entries := make([]Entry, 0, len(catalog))
+	for _, e := range catalog {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Code < entries[j].Code
+	})
+	return entries
+}
+
+// Lookup returns the catalog entry for a code.
+func Lookup(c Code) (Entry, bool) {
+	e, ok := catalog[c]
+	return e, ok
+}
+
+// New returns a gRPC status error for the code with a formatted message.
+// The code is attached as an ErrorInfo detail.
+func New(c Code, format string, args ...any) error {
+	return NewWithMetadata(c, nil, format, args...)
+}
+
+// NewWithMetadata is like New but also attaches key/value metadata to the
+// ErrorInfo detail, e.g. the ID of the missing resource.
+func NewWithMetadata(c Code, metadata map[string]string, format string, args ...any) error {
+	st := status.New(c.GRPCCode(), fmt.Sprintf(format, args...))
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   string(c),
+		Domain:   Domain,
+		Metadata: metadata,
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// FieldViolation describes a request field that is missing or malformed.
+type FieldViolation struct {
+	// Field is the path of the field, such as "service_id" or
+	// "git_ref.branch".
+	Field string `json:"field"`
+	// Description says what is wrong with the field.
+	Description string `json:"description"`
+}
+
+// NewInvalidFields returns an InvalidArgument error for the fields of a
+// request that are missing or malformed. The fields are attached as a
+// google.rpc.BadRequest detail besides the ErrorInfo detail, so clients can
+// point at each of them.
+func NewInvalidFields(violations ...FieldViolation) error {
+	messages := make([]string, len(violations))
+	badRequest := &errdetails.BadRequest{}
+	for i, v := range violations {
+		messages[i] = v.Field + ": " + v.Description
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+
+	st := status.New(InvalidArgument.GRPCCode(), "invalid request: "+strings.Join(messages, "; "))
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: string(InvalidArgument),
+		Domain: Domain,
+	}, badRequest)
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// FieldViolations returns the field violations attached to err, if any.
+func FieldViolations(err error) []FieldViolation {
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+	var violations []FieldViolation
+	for _, d := range st.Details() {
+		if badRequest, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range badRequest.GetFieldViolations() {
+				violations = append(violations, FieldViolation{Field: v.GetField(), Description: v.GetDescription()})
+			}
+		}
+	}
+	return violations
+}
+
+// FromError extracts the Conductor error code from an error. Status errors
+// without an ErrorInfo detail are mapped to a generic code based on their
+// gRPC code. Nil errors return an empty code.
+func FromError(err error) Code {
+	if err == nil {
+		return ""
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return Unknown
+	}
+
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.GetDomain() == Domain {
+			return Code(info.GetReason())
+		}
+	}
+
+	if c, ok := genericByGRPC[st.Code()]; ok {
+		return c
+	}
+	return Unknown
+}
+
+// Is reports whether err carries the given code.
+func Is(err error, c Code) bool {
+	return FromError(err) == c
+}
+
+// Metadata returns the ErrorInfo metadata attached to err, if any.
+func Metadata(err error) map[string]string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.GetDomain() == Domain {
+			return info.GetMetadata()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNew(t *testing.T) {
+	err := New(RunNotFound, "run not found: %s", "abc")
+
+	st, ok := status.FromError(err)
+	if !ok {
+		t.Fatal("expected a gRPC status error")
+	}
+	if st.Code() != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", st.Code())
+	}
+	if st.Message() != "run not found: abc" {
+		t.Errorf("unexpected message %q", st.Message())
+	}
+	if got := FromError(err); got != RunNotFound {
+		t.Errorf("expected %s, got %s", RunNotFound, got)
+	}
+	if !Is(err, RunNotFound) {
+		t.Error("expected Is to match")
+	}
+}
+
+func TestNewWithMetadata(t *testing.T) {
+	err := NewWithMetadata(AgentNotFound, map[string]string{"agent_id": "a1"}, "agent not found")
+
+	md := Metadata(err)
+	if md["agent_id"] != "a1" {
+		t.Errorf("expected agent_id metadata, got %v", md)
+	}
+	if Metadata(errors.New("plain")) != nil {
+		t.Error("expected no metadata for plain error")
+	}
+}
+
+func TestNewInvalidFields(t *testing.T) {
+	err := NewInvalidFields(
+		FieldViolation{Field: "service_id", Description: "must be a UUID"},
+		FieldViolation{Field: "name", Description: "is required"},
+	)
+
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", status.Code(err))
+	}
+	if got := FromError(err); got != InvalidArgument {
+		t.Errorf("expected %s, got %s", InvalidArgument, got)
+	}
+	if msg := status.Convert(err).Message(); msg != "invalid request: service_id: must be a UUID; name: is required" {
+		t.Errorf("unexpected message %q", msg)
+	}
+
+	violations := FieldViolations(err)
+	if len(violations) != 2 || violations[0].Field != "service_id" || violations[1].Description != "is required" {
+		t.Errorf("unexpected field violations %v", violations)
+	}
+	if FieldViolations(New(InvalidArgument, "bad")) != nil {
+		t.Error("expected no field violations for an error without them")
+	}
+}
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"nil", nil, ""},
+		{"plain error", errors.New("boom"), Unknown},
+		{"status without detail", status.Error(codes.PermissionDenied, "nope"), PermissionDenied},
+		{"unmapped status", status.Error(codes.DataLoss, "lost"), Unknown},
+		{"wrapped", fmt.Errorf("call failed: %w", New(QuotaExceeded, "slow down")), QuotaExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromError(tt.err); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	entries := Catalog()
+	if len(entries) == 0 {
+		t.Fatal("catalog is empty")
+	}
+
+	for i, e := range entries {
+		if !strings.HasPrefix(string(e.Code), "CONDUCTOR_") {
+			t.Errorf("code %s is missing the CONDUCTOR_ prefix", e.Code)
+		}
+		if e.Description == "" {
+			t.Errorf("code %s has no description", e.Code)
+		}
+		if e.Code != Unknown && e.GRPCCode == codes.Unknown {
+			t.Errorf("code %s has no gRPC mapping", e.Code)
+		}
+		if i > 0 && entries[i-1].Code >= e.Code {
+			t.Errorf("catalog not sorted at %s", e.Code)
+		}
+	}
+
+	if _, ok := Lookup("CONDUCTOR_DOES_NOT_EXIST"); ok {
+		t.Error("expected lookup of unknown code to fail")
+	}
+}
@@ -0,0 +1,67 @@
+package errcode
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/status"
+)
+
+// ProblemContentType is the media type of the error responses of the HTTP
+// API, which are RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypePrefix prefixes the code of an error in the type of its problem
+// details, such as https://conductor.dev/errors/CONDUCTOR_RUN_NOT_FOUND.
+const ProblemTypePrefix = "https://" + Domain + "/errors/"
+
+// Problem is the body of an error response of the HTTP API: RFC 7807 problem
+// details, extended with the error code and the fields at fault.
+type Problem struct {
+	// Type identifies the error code as a URI.
+	Type string `json:"type"`
+	// Title describes the error code; it is the same for every error with
+	// the code.
+	Title string `json:"title"`
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+	// Detail describes this occurrence of the error.
+	Detail string `json:"detail,omitempty"`
+	// Instance is the path of the request that failed.
+	Instance string `json:"instance,omitempty"`
+
+	// Code is the machine-readable error code.
+	Code Code `json:"code"`
+	// GRPCStatus is the name of the gRPC status code of the error, such as
+	// NotFound.
+	GRPCStatus string `json:"grpcStatus"`
+	// RequestID is the X-Request-ID of the request that failed.
+	RequestID string `json:"requestId,omitempty"`
+	// Metadata is the metadata of the error, such as the ID of the missing
+	// resource.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// FieldViolations are the request fields at fault.
+	FieldViolations []FieldViolation `json:"fieldViolations,omitempty"`
+}
+
+// NewProblem returns the problem details of err, answered with an HTTP
+// status code.
+func NewProblem(err error, httpStatus int) Problem {
+	st := status.Convert(err)
+	code := FromError(err)
+
+	title := http.StatusText(httpStatus)
+	if entry, ok := catalog[code]; ok && code != Unknown {
+		title = entry.Description
+	}
+
+	return Problem{
+		Type:            ProblemTypePrefix + string(code),
+		Title:           title,
+		Status:          httpStatus,
+		Detail:          st.Message(),
+		Code:            code,
+		GRPCStatus:      st.Code().String(),
+		Metadata:        Metadata(err),
+		FieldViolations: FieldViolations(err),
+	}
+}
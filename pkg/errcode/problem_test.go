@@ -0,0 +1,54 @@
+package errcode
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestNewProblem(t *testing.T) {
+	t.Run("catalog code", func(t *testing.T) {
+		err := NewWithMetadata(RunNotFound, map[string]string{"run_id": "abc"}, "run not found: abc")
+		p := NewProblem(err, http.StatusNotFound)
+
+		want := Problem{
+			Type:       "https://conductor.dev/errors/CONDUCTOR_RUN_NOT_FOUND",
+			Title:      "The test run does not exist.",
+			Status:     http.StatusNotFound,
+			Detail:     "run not found: abc",
+			Code:       RunNotFound,
+			GRPCStatus: "NotFound",
+			Metadata:   map[string]string{"run_id": "abc"},
+		}
+		if !reflect.DeepEqual(p, want) {
+			t.Errorf("NewProblem() = %+v, want %+v", p, want)
+		}
+	})
+
+	t.Run("field violations", func(t *testing.T) {
+		err := NewInvalidFields(FieldViolation{Field: "service_id", Description: "must be a UUID"})
+		p := NewProblem(err, http.StatusBadRequest)
+
+		if p.Code != InvalidArgument {
+			t.Errorf("expected %s, got %s", InvalidArgument, p.Code)
+		}
+		if len(p.FieldViolations) != 1 || p.FieldViolations[0].Field != "service_id" {
+			t.Errorf("unexpected field violations %v", p.FieldViolations)
+		}
+	})
+
+	t.Run("error without a code", func(t *testing.T) {
+		p := NewProblem(errors.New("boom"), http.StatusInternalServerError)
+
+		if p.Code != Unknown || p.GRPCStatus != "Unknown" {
+			t.Errorf("expected an unknown error, got %s (%s)", p.Code, p.GRPCStatus)
+		}
+		if p.Title != "Internal Server Error" {
+			t.Errorf("expected the HTTP status text as title, got %q", p.Title)
+		}
+		if p.Detail != "boom" {
+			t.Errorf("unexpected detail %q", p.Detail)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Core is the log output of a process, from which its loggers are made:
+// zerolog loggers, slog loggers and Loggers. They share the output, its
+// format and the levels, and add the request, correlation, user and run IDs
+// of contexts to events, see ContextWithRequestID and ContextWithRunID.
+// zerolog events get them with Ctx, and slog records from the context they
+// are logged with.
+type Core struct {
+	levels *Levels
+	out    zerolog.LevelWriter
+	fields []any
+}
+
+// NewCore creates a core writing to w, filtered by levels. See Output for
+// writing in a format.
+func NewCore(w io.Writer, levels *Levels) *Core {
+	return &Core{levels: levels, out: levels.Writer(w)}
+}
+
+// Output returns w writing in a format, json or console.
+func Output(w io.Writer, format string) io.Writer {
+	if format == "console" {
+		return zerolog.ConsoleWriter{Out: w, TimeFormat: time.RFC3339}
+	}
+	return w
+}
+
+// With returns a core whose loggers add a field to every event, such as the
+// name of the service.
+func (c *Core) With(key string, value any) *Core {
+	core := *c
+	core.fields = append(append([]any(nil), c.fields...), key, value)
+	return &core
+}
+
+// Levels returns the levels the output is filtered by.
+func (c *Core) Levels() *Levels {
+	return c.levels
+}
+
+// Zerolog returns a zerolog logger. Its events add the IDs of the context
+// they are given with Ctx.
+func (c *Core) Zerolog() zerolog.Logger {
+	return zerolog.New(c.out).With().Timestamp().Fields(c.fields).Logger().Hook(contextHook{})
+}
+
+// Slog returns a slog logger.
+func (c *Core) Slog() *slog.Logger {
+	return slog.New(c.levels.Handler(NewSlogHandler(c.Zerolog())))
+}
+
+// Logger returns a Logger.
+func (c *Core) Logger() Logger {
+	return &logger{zl: c.Zerolog()}
+}
+
+// contextHook adds the IDs of the context of an event to it.
+type contextHook struct{}
+
+func (contextHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	if fields := contextFields(e.GetCtx()); len(fields) > 0 {
+		e.Fields(fields)
+	}
+}
@@ -0,0 +1,114 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeLines decodes the JSON events written to buf.
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var events []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var event map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &event), line)
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestCore_SharedOutput(t *testing.T) {
+	var buf bytes.Buffer
+	core := NewCore(&buf, newTestLevels(t, "info,scheduler=debug")).With("service", "control-plane")
+
+	zl := core.Zerolog()
+	zl.Info().Str("component", "api").Msg("from zerolog")
+	core.Slog().With("component", "api").Info("from slog", "count", 3)
+	core.Logger().Warn().Msg("from logger")
+	core.Slog().Debug("filtered")
+	core.Slog().With("component", "scheduler").Debug("scheduler debug")
+
+	events := decodeLines(t, &buf)
+	require.Len(t, events, 4)
+	for _, event := range events {
+		assert.Equal(t, "control-plane", event["service"])
+		assert.NotEmpty(t, event["time"])
+	}
+	assert.Equal(t, "from zerolog", events[0]["message"])
+	assert.Equal(t, map[string]any{"service": "control-plane", "level": "info", "component": "api", "count": 3.0, "time": events[1]["time"], "message": "from slog"}, events[1])
+	assert.Equal(t, "warn", events[2]["level"])
+	assert.Equal(t, "scheduler debug", events[3]["message"])
+	assert.Equal(t, "debug", events[3]["level"])
+}
+
+func TestCore_ContextIDs(t *testing.T) {
+	var buf bytes.Buffer
+	core := NewCore(&buf, newTestLevels(t, "info"))
+
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	ctx = ContextWithRunID(ctx, "run-1")
+
+	zl := core.Zerolog()
+	zl.Info().Ctx(ctx).Msg("zerolog")
+	zl.Info().Msg("without context")
+	core.Slog().InfoContext(ctx, "slog")
+	core.Logger().WithContext(ctx).Info().Msg("logger")
+
+	events := decodeLines(t, &buf)
+	require.Len(t, events, 4)
+	for _, i := range []int{0, 2, 3} {
+		assert.Equal(t, "req-1", events[i]["request_id"], events[i]["message"])
+		assert.Equal(t, "run-1", events[i]["run_id"], events[i]["message"])
+	}
+	assert.NotContains(t, events[1], "request_id")
+}
+
+func TestSlogHandler_Attrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewCore(&buf, newTestLevels(t, "debug")).Slog()
+
+	logger.With("a", 1).WithGroup("g").With("b", 2).WithGroup("h").Info("grouped", "c", 3)
+	logger.WithGroup("empty").Info("no attrs")
+	logger.Error("failed",
+		"error", errors.New("boom"),
+		"duration", 1500*time.Millisecond,
+		slog.Group("inline", "d", 4),
+		slog.Group("", "e", 5),
+		slog.Attr{},
+	)
+
+	events := decodeLines(t, &buf)
+	require.Len(t, events, 3)
+	assert.Equal(t, 1.0, events[0]["a"])
+	assert.Equal(t, map[string]any{"b": 2.0, "h": map[string]any{"c": 3.0}}, events[0]["g"])
+	assert.NotContains(t, events[1], "empty")
+	assert.Equal(t, "error", events[2]["level"])
+	assert.Equal(t, "boom", events[2]["error"])
+	assert.Equal(t, 1500.0, events[2]["duration"])
+	assert.Equal(t, map[string]any{"d": 4.0}, events[2]["inline"])
+	assert.Equal(t, 5.0, events[2]["e"])
+	assert.NotContains(t, events[2], "")
+}
+
+func TestOutput(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Same(t, &buf, Output(&buf, "json"))
+
+	core := NewCore(Output(&buf, "console"), newTestLevels(t, "info"))
+	core.Slog().Info("console message", "key", "value")
+	assert.Contains(t, buf.String(), "console message")
+	assert.Contains(t, buf.String(), "key=")
+	assert.False(t, json.Valid(buf.Bytes()))
+}
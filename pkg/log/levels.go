@@ -0,0 +1,352 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/rs/zerolog"
+)
+
+// Levels holds the log level of a process and those of its components that
+// differ from it, such as scheduler=debug. Levels can be changed while the
+// process runs. A component is named by the "component" field of its logger.
+// Levels are given by specifications, see ParseLevels.
+//
+// Zerolog output filtered by Levels relies on the global zerolog level, which
+// Levels keeps at the lowest level in use.
+type Levels struct {
+	mu         sync.RWMutex
+	level      zerolog.Level
+	components map[string]zerolog.Level
+}
+
+// NewLevels creates levels from a specification, see ParseLevels.
+func NewLevels(spec string) (*Levels, error) {
+	l := &Levels{}
+	if err := l.Set(spec); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// ParseLevels parses a specification of levels: a level, optionally followed
+// by levels of components, such as "info,scheduler=debug,websocket=debug".
+// An empty level means info.
+func ParseLevels(spec string) (zerolog.Level, map[string]zerolog.Level, error) {
+	parts := strings.Split(spec, ",")
+	level, err := parseLevelName(parts[0])
+	if err != nil {
+		return 0, nil, err
+	}
+
+	components := make(map[string]zerolog.Level)
+	for _, part := range parts[1:] {
+		component, name, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || component == "" {
+			return 0, nil, fmt.Errorf("invalid component log level %q: must be component=level", part)
+		}
+		if components[component], err = parseLevelName(name); err != nil {
+			return 0, nil, err
+		}
+	}
+	return level, components, nil
+}
+
+func parseLevelName(name string) (zerolog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return zerolog.DebugLevel, nil
+	case "info", "":
+		return zerolog.InfoLevel, nil
+	case "warn":
+		return zerolog.WarnLevel, nil
+	case "error":
+		return zerolog.ErrorLevel, nil
+	default:
+		return 0, fmt.Errorf("invalid log level %q: must be one of debug, info, warn, error", name)
+	}
+}
+
+// Set replaces the levels with those of a specification, see ParseLevels.
+func (l *Levels) Set(spec string) error {
+	level, components, err := ParseLevels(spec)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+	l.components = components
+	l.apply()
+	return nil
+}
+
+// SetComponent sets the level of a component.
+func (l *Levels) SetComponent(component string, level zerolog.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.components[component] = level
+	l.apply()
+}
+
+// apply keeps the global zerolog level at the lowest level in use, so that
+// events of components with a lower level than the process are created.
+// Callers must hold the lock.
+func (l *Levels) apply() {
+	lowest := l.level
+	for _, level := range l.components {
+		lowest = min(lowest, level)
+	}
+	zerolog.SetGlobalLevel(lowest)
+}
+
+// String returns the specification of the levels, with components sorted.
+func (l *Levels) String() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	parts := []string{l.level.String()}
+	for _, component := range slices.Sorted(maps.Keys(l.components)) {
+		parts = append(parts, component+"="+l.components[component].String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// Enabled reports whether a component logs at level. The level of a
+// component also applies to the components named after it: that of
+// websocket to websocket_hub and websocket_conn, unless they have their own.
+// Other components log at the level of the process.
+func (l *Levels) Enabled(component string, level zerolog.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for name := component; name != ""; {
+		if threshold, ok := l.components[name]; ok {
+			return level >= threshold
+		}
+		i := strings.LastIndexByte(name, '_')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return level >= l.level
+}
+
+// hasComponents reports whether any component has its own level.
+func (l *Levels) hasComponents() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.components) > 0
+}
+
+// Writer filters the zerolog output written to w by level.
+func (l *Levels) Writer(w io.Writer) zerolog.LevelWriter {
+	return &levelWriter{levels: l, next: w}
+}
+
+// levelWriter filters zerolog events by the level of their component.
+type levelWriter struct {
+	levels *Levels
+	next   io.Writer
+}
+
+func (w *levelWriter) Write(p []byte) (int, error) {
+	return w.next.Write(p)
+}
+
+func (w *levelWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < zerolog.PanicLevel && !w.levels.Enabled(w.component(p), level) {
+		return len(p), nil
+	}
+	if lw, ok := w.next.(zerolog.LevelWriter); ok {
+		return lw.WriteLevel(level, p)
+	}
+	return w.next.Write(p)
+}
+
+// component returns the component of an event. The event is only decoded
+// while a component has its own level. A component added to a logger of
+// another component is the last of the event, which decoding keeps.
+func (w *levelWriter) component(p []byte) string {
+	if !w.levels.hasComponents() {
+		return ""
+	}
+	var event struct {
+		Component string `json:"component"`
+	}
+	_ = json.Unmarshal(p, &event)
+	return event.Component
+}
+
+// Handler filters the slog records handled by h by level. h must handle
+// records of every level.
+func (l *Levels) Handler(h slog.Handler) slog.Handler {
+	return &levelHandler{levels: l, next: h}
+}
+
+// levelHandler filters slog records by the level of their component.
+type levelHandler struct {
+	levels    *Levels
+	component string
+	grouped   bool
+	next      slog.Handler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.levels.Enabled(h.component, zerologLevel(level)) && h.next.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	if !h.grouped {
+		for _, attr := range attrs {
+			if attr.Key == "component" {
+				c.component = attr.Value.String()
+			}
+		}
+	}
+	c.next = h.next.WithAttrs(attrs)
+	return &c
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.grouped = true
+	c.next = h.next.WithGroup(name)
+	return &c
+}
+
+// zerologLevel converts a slog level to the zerolog level it falls in.
+func zerologLevel(level slog.Level) zerolog.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return zerolog.TraceLevel
+	case level < slog.LevelInfo:
+		return zerolog.DebugLevel
+	case level < slog.LevelWarn:
+		return zerolog.InfoLevel
+	case level < slog.LevelError:
+		return zerolog.WarnLevel
+	default:
+		return zerolog.ErrorLevel
+	}
+}
+
+// levelsRequest is the body of a request to change levels. Either a whole
+// specification is set, or the levels of components are changed.
+type levelsRequest struct {
+	Levels     string            `json:"levels"`
+	Components map[string]string `json:"components"`
+}
+
+// levelsResponse is the body of a response with the current levels.
+type levelsResponse struct {
+	Levels string `json:"levels"`
+}
+
+// HTTPHandler serves the levels: GET returns them, and PUT changes them
+// with a JSON body of either {"levels": "info,scheduler=debug"} or
+// {"components": {"websocket": "debug"}}.
+func (l *Levels) HTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var req levelsRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := l.update(req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(levelsResponse{Levels: l.String()})
+	})
+}
+
+// update applies a request to change levels. Nothing changes if it is
+// invalid.
+func (l *Levels) update(req levelsRequest) error {
+	if req.Levels != "" {
+		return l.Set(req.Levels)
+	}
+	if len(req.Components) == 0 {
+		return fmt.Errorf("levels or components is required")
+	}
+
+	levels := make(map[string]zerolog.Level, len(req.Components))
+	for component, name := range req.Components {
+		if component == "" {
+			return fmt.Errorf("component is required")
+		}
+		level, err := parseLevelName(name)
+		if err != nil {
+			return err
+		}
+		levels[component] = level
+	}
+	for component, level := range levels {
+		l.SetComponent(component, level)
+	}
+	return nil
+}
+
+// Reload sets the levels from the file at path if it is set, and otherwise
+// from spec, such as the levels the process started with.
+func (l *Levels) Reload(spec, path string) error {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read log levels: %w", err)
+		}
+		spec = strings.TrimSpace(string(data))
+	}
+	return l.Set(spec)
+}
+
+// ReloadOnSIGHUP reloads the levels, see Reload, whenever the process
+// receives SIGHUP, until ctx is done. spec returns the specification to
+// reload without a file, which may change while the process runs.
+func (l *Levels) ReloadOnSIGHUP(ctx context.Context, spec func() string, path string, logger zerolog.Logger) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+			if err := l.Reload(spec(), path); err != nil {
+				logger.Error().Err(err).Msg("failed to reload log levels")
+				continue
+			}
+			logger.Info().Str("levels", l.String()).Msg("log levels reloaded")
+		}
+	}
+}
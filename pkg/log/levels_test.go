@@ -0,0 +1,136 @@
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLevels(t *testing.T, spec string) *Levels {
+	t.Helper()
+	global := zerolog.GlobalLevel()
+	t.Cleanup(func() { zerolog.SetGlobalLevel(global) })
+
+	levels, err := NewLevels(spec)
+	require.NoError(t, err)
+	return levels
+}
+
+func TestParseLevels(t *testing.T) {
+	level, components, err := ParseLevels("warn, scheduler=debug,websocket=ERROR")
+	require.NoError(t, err)
+	assert.Equal(t, zerolog.WarnLevel, level)
+	assert.Equal(t, map[string]zerolog.Level{"scheduler": zerolog.DebugLevel, "websocket": zerolog.ErrorLevel}, components)
+
+	level, components, err = ParseLevels("")
+	require.NoError(t, err)
+	assert.Equal(t, zerolog.InfoLevel, level)
+	assert.Empty(t, components)
+
+	for _, spec := range []string{"verbose", "info,scheduler", "info,=debug", "info,scheduler=loud"} {
+		_, _, err := ParseLevels(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestLevels_Enabled(t *testing.T) {
+	levels := newTestLevels(t, "info,websocket=debug,websocket_conn=warn")
+
+	assert.False(t, levels.Enabled("", zerolog.DebugLevel))
+	assert.True(t, levels.Enabled("", zerolog.InfoLevel))
+	assert.True(t, levels.Enabled("websocket_hub", zerolog.DebugLevel), "components inherit the level of the component they are named after")
+	assert.False(t, levels.Enabled("websocket_conn", zerolog.InfoLevel))
+	assert.False(t, levels.Enabled("scheduler", zerolog.DebugLevel))
+	assert.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel(), "the global level is the lowest in use")
+	assert.Equal(t, "info,websocket=debug,websocket_conn=warn", levels.String())
+
+	require.NoError(t, levels.Set("error"))
+	assert.False(t, levels.Enabled("websocket_hub", zerolog.DebugLevel))
+	assert.Equal(t, zerolog.ErrorLevel, zerolog.GlobalLevel())
+}
+
+func TestLevels_Writer(t *testing.T) {
+	levels := newTestLevels(t, "info")
+	var buf bytes.Buffer
+	root := zerolog.New(levels.Writer(&buf))
+	scheduler := root.With().Str("component", "scheduler").Logger()
+
+	scheduler.Debug().Msg("hidden")
+	levels.SetComponent("scheduler", zerolog.DebugLevel)
+	scheduler.Debug().Msg("shown")
+	root.Debug().Msg("hidden")
+	root.Info().Msg("info")
+
+	out := buf.String()
+	assert.NotContains(t, out, "hidden")
+	assert.Contains(t, out, "shown")
+	assert.Contains(t, out, `"message":"info"`)
+}
+
+func TestLevels_Handler(t *testing.T) {
+	levels := newTestLevels(t, "info,scheduler=debug")
+	var buf bytes.Buffer
+	root := slog.New(levels.Handler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	root.With("component", "scheduler").Debug("shown")
+	root.With("component", "cache").Debug("hidden")
+	root.WithGroup("request").With("component", "scheduler").Debug("hidden")
+
+	out := buf.String()
+	assert.Contains(t, out, "shown")
+	assert.NotContains(t, out, "hidden")
+}
+
+func TestLevels_HTTPHandler(t *testing.T) {
+	levels := newTestLevels(t, "info")
+	handler := levels.HTTPHandler()
+
+	put := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/log-level", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := put(`{"components": {"scheduler": "debug"}}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"levels": "info,scheduler=debug"}`, rec.Body.String())
+
+	rec = put(`{"levels": "warn"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "warn", levels.String())
+
+	assert.Equal(t, http.StatusBadRequest, put(`{"components": {"scheduler": "loud", "cache": "debug"}}`).Code)
+	assert.Equal(t, "warn", levels.String(), "invalid requests change nothing")
+	assert.Equal(t, http.StatusBadRequest, put(`{}`).Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/log-level", nil))
+	assert.JSONEq(t, `{"levels": "warn"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/log-level", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestLevels_Reload(t *testing.T) {
+	levels := newTestLevels(t, "info,scheduler=debug")
+
+	require.NoError(t, levels.Reload("warn", ""))
+	assert.Equal(t, "warn", levels.String())
+
+	path := filepath.Join(t.TempDir(), "levels")
+	require.NoError(t, os.WriteFile(path, []byte("debug,cache=error\n"), 0o600))
+	require.NoError(t, levels.Reload("warn", path))
+	assert.Equal(t, "debug,cache=error", levels.String())
+
+	assert.Error(t, levels.Reload("warn", filepath.Join(t.TempDir(), "missing")))
+}
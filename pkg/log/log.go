@@ -149,24 +149,8 @@ func (l *logger) WithError(err error) Logger {
 }
 
 func (l *logger) WithContext(ctx context.Context) Logger {
-	newLogger := l.zl
-
-	// Extract request ID from context if present
-	if requestID := RequestIDFromContext(ctx); requestID != "" {
-		newLogger = newLogger.With().Str("request_id", requestID).Logger()
-	}
-
-	// Extract correlation ID from context if present
-	if correlationID := CorrelationIDFromContext(ctx); correlationID != "" {
-		newLogger = newLogger.With().Str("correlation_id", correlationID).Logger()
-	}
-
-	// Extract user ID from context if present
-	if userID := UserIDFromContext(ctx); userID != "" {
-		newLogger = newLogger.With().Str("user_id", userID).Logger()
-	}
-
-	return &logger{zl: newLogger}
+	// Add the request, correlation, user and run IDs of the context
+	return &logger{zl: l.zl.With().Fields(contextFields(ctx)).Logger()}
 }
 
 func (l *logger) Underlying() *zerolog.Logger {
@@ -236,9 +220,22 @@ const (
 	requestIDKey     contextKey = "request_id"
 	correlationIDKey contextKey = "correlation_id"
 	userIDKey        contextKey = "user_id"
+	runIDKey         contextKey = "run_id"
 	loggerKey        contextKey = "logger"
 )
 
+// contextFields returns the IDs of the context as log fields, in pairs of
+// key and value.
+func contextFields(ctx context.Context) []any {
+	var fields []any
+	for _, key := range []contextKey{requestIDKey, correlationIDKey, userIDKey, runIDKey} {
+		if id, ok := ctx.Value(key).(string); ok && id != "" {
+			fields = append(fields, string(key), id)
+		}
+	}
+	return fields
+}
+
 // ContextWithRequestID adds a request ID to the context.
 func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
 	return context.WithValue(ctx, requestIDKey, requestID)
@@ -278,6 +275,19 @@ func UserIDFromContext(ctx context.Context) string {
 	return ""
 }
 
+// ContextWithRunID adds the ID of the run a context works on to the context.
+func ContextWithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey, runID)
+}
+
+// RunIDFromContext extracts the run ID from the context.
+func RunIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(runIDKey).(string); ok {
+		return id
+	}
+	return ""
+}
+
 // ContextWithLogger adds a logger to the context.
 func ContextWithLogger(ctx context.Context, log Logger) context.Context {
 	return context.WithValue(ctx, loggerKey, log)
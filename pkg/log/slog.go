@@ -0,0 +1,123 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/rs/zerolog"
+)
+
+// NewSlogHandler returns a slog handler that logs records with a zerolog
+// logger, in its output and format. Groups are logged as objects, and the
+// IDs of the context of records are added to them.
+func NewSlogHandler(logger zerolog.Logger) slog.Handler {
+	return &slogHandler{logger: logger}
+}
+
+// slogHandler logs slog records with zerolog. Attributes outside of groups
+// are added to the logger; those of open groups are kept until records are
+// logged, which completes the groups.
+type slogHandler struct {
+	logger zerolog.Logger
+	groups []slogGroup
+}
+
+// slogGroup is an open group and the attributes added to it.
+type slogGroup struct {
+	name  string
+	attrs []slog.Attr
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	l := zerologLevel(level)
+	return l >= h.logger.GetLevel() && l >= zerolog.GlobalLevel()
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	e := h.logger.WithLevel(zerologLevel(r.Level))
+	if e == nil {
+		return nil
+	}
+
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	fields := appendSlogFields(nil, attrs)
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		group := append(appendSlogFields(nil, h.groups[i].attrs), fields...)
+		fields = nil
+		if len(group) > 0 {
+			fields = []any{h.groups[i].name, fieldMap(group)}
+		}
+	}
+	if ctx != nil {
+		fields = append(fields, contextFields(ctx)...)
+	}
+
+	e.Fields(fields).Msg(r.Message)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	if len(h.groups) == 0 {
+		c.logger = h.logger.With().Fields(appendSlogFields(nil, attrs)).Logger()
+		return &c
+	}
+
+	c.groups = slices.Clone(h.groups)
+	last := &c.groups[len(c.groups)-1]
+	last.attrs = append(slices.Clip(last.attrs), attrs...)
+	return &c
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.groups = append(slices.Clip(h.groups), slogGroup{name: name})
+	return &c
+}
+
+// appendSlogFields appends attributes to fields, in pairs of key and value.
+// Empty attributes are skipped, and those of groups without a key inlined.
+func appendSlogFields(fields []any, attrs []slog.Attr) []any {
+	for _, attr := range attrs {
+		value := attr.Value.Resolve()
+		switch {
+		case attr.Equal(slog.Attr{}):
+		case value.Kind() == slog.KindGroup && attr.Key == "":
+			fields = appendSlogFields(fields, value.Group())
+		case value.Kind() == slog.KindGroup:
+			if group := appendSlogFields(nil, value.Group()); len(group) > 0 {
+				fields = append(fields, attr.Key, fieldMap(group))
+			}
+		default:
+			fields = append(fields, attr.Key, slogValue(value))
+		}
+	}
+	return fields
+}
+
+// slogValue returns a resolved value that is not a group as a field value.
+func slogValue(value slog.Value) any {
+	switch v := value.Any().(type) {
+	case error:
+		return v.Error()
+	default:
+		return v
+	}
+}
+
+// fieldMap returns fields, in pairs of key and value, as an object.
+func fieldMap(fields []any) map[string]any {
+	m := make(map[string]any, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		m[fields[i].(string)] = fields[i+1]
+	}
+	return m
+}
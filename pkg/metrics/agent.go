@@ -39,7 +39,7 @@ type AgentMetrics struct {
 }
 
 // newAgentMetrics creates and registers all agent metrics.
-func newAgentMetrics(registry *prometheus.Registry) *AgentMetrics {
+func newAgentMetrics(registry prometheus.Registerer) *AgentMetrics {
 	m := &AgentMetrics{
 		// Work execution metrics
 		WorkDuration: prometheus.NewHistogramVec(
@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// alertRule is a Prometheus alerting rule on the metrics of Conductor.
+type alertRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+// rule returns an alerting rule of a severity: critical, warning or info.
+func rule(alert, expr, forDuration, severity, summary, description string) alertRule {
+	return alertRule{
+		Alert:       alert,
+		Expr:        expr,
+		For:         forDuration,
+		Labels:      map[string]string{"severity": severity},
+		Annotations: map[string]string{"summary": summary, "description": description},
+	}
+}
+
+// alertRules are the alerting rules of AlertRules. Their expressions are
+// checked against the metric families the components export.
+var alertRules = []alertRule{
+	rule("ConductorNoLeader",
+		`max(conductor_control_plane_leader) < 1`, "5m", "critical",
+		"No control plane replica is the leader",
+		"Background jobs such as scheduling retries, cleanup and notification redelivery are not running."),
+	rule("ConductorHTTPErrors",
+		`sum(rate(conductor_http_requests_total{status=~"5.."}[5m])) / sum(rate(conductor_http_requests_total[5m])) > 0.05`, "10m", "warning",
+		"More than 5% of HTTP API requests fail",
+		"{{ $value | humanizePercentage }} of HTTP API requests returned a server error."),
+	rule("ConductorGRPCErrors",
+		`sum(rate(conductor_grpc_requests_total{status="error"}[5m])) / sum(rate(conductor_grpc_requests_total[5m])) > 0.05`, "10m", "warning",
+		"More than 5% of gRPC requests fail",
+		"{{ $value | humanizePercentage }} of gRPC requests returned an error."),
+	rule("ConductorWebhookDeliveriesRejected",
+		`sum by (provider) (increase(conductor_webhook_deliveries_rejected_total[15m])) > 10`, "", "warning",
+		"Webhook deliveries of {{ $labels.provider }} are rejected",
+		"{{ $value }} deliveries were rejected in 15 minutes; check the webhook secrets and the rate limits."),
+	rule("ConductorIngestionDropped",
+		`sum by (kind) (increase(conductor_ingestion_dropped_total[1h])) > 0`, "", "info",
+		"Runs dropped {{ $labels.kind }} over their ingestion caps",
+		"{{ $value }} {{ $labels.kind }} were dropped in the last hour."),
+	rule("ConductorNotificationDeadLetters",
+		`sum(conductor_notifications_dead_letters{state="exhausted"}) > 0`, "15m", "warning",
+		"Notifications failed all deliveries",
+		"{{ $value }} notifications are left for manual redelivery."),
+	rule("ConductorAgentPoolOffline",
+		`conductor_agent_pool_agents > 0 and conductor_agent_pool_online_agents == 0`, "5m", "critical",
+		"No agent of pool {{ $labels.pool }} is online",
+		"Work pinned to the pool is not scheduled."),
+	rule("ConductorAgentPoolSaturated",
+		`conductor_agent_pool_running_shards / (conductor_agent_pool_max_concurrency > 0) >= 1`, "30m", "info",
+		"Agent pool {{ $labels.pool }} is at its concurrency quota",
+		"Shards wait for the pool; consider raising its quota or adding agents."),
+	rule("ConductorAgentDiskFull",
+		`conductor_agent_disk_usage_percent > 90`, "10m", "warning",
+		"Agent {{ $labels.instance }} is running out of disk space",
+		"Disk usage is {{ $value | humanize }}%; clones and artifacts may fail."),
+	rule("ConductorAgentWorkErrors",
+		`sum(rate(conductor_agent_work_total{status=~"error|timeout"}[30m])) / sum(rate(conductor_agent_work_total[30m])) > 0.2`, "15m", "warning",
+		"More than 20% of runs fail to execute",
+		"{{ $value | humanizePercentage }} of runs ended with an error or timed out, rather than with test results."),
+}
+
+var (
+	// metricPattern matches the metric names of expressions, with the label
+	// matchers that follow them, if any.
+	metricPattern = regexp.MustCompile(`\b(conductor_[a-z0-9_]+)(\{[^}]*\})?`)
+	// matcherPattern matches the labels of label matchers.
+	matcherPattern = regexp.MustCompile(`([a-zA-Z_][a-zA-Z0-9_]*)\s*(?:=~|!~|!=|=)`)
+	// groupingPattern matches the labels of by clauses.
+	groupingPattern = regexp.MustCompile(`\bby\s*\(([^)]*)\)`)
+)
+
+// targetLabels are the labels Prometheus adds to the series it scrapes.
+var targetLabels = []string{"instance", "job"}
+
+// AlertRules returns Prometheus alerting rules for the metrics of Conductor,
+// as a rule file in YAML. It fails if a rule uses a metric family or label
+// that families do not have.
+func AlertRules(families []Family) ([]byte, error) {
+	byName := make(map[string]Family, len(families))
+	for _, family := range families {
+		byName[family.Name] = family
+	}
+	for _, r := range alertRules {
+		if err := checkRule(r, byName); err != nil {
+			return nil, fmt.Errorf("alert %s: %w", r.Alert, err)
+		}
+	}
+
+	type ruleGroup struct {
+		Name  string      `yaml:"name"`
+		Rules []alertRule `yaml:"rules"`
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string][]ruleGroup{"groups": {{Name: "conductor", Rules: alertRules}}}); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// checkRule checks that the expression of a rule only uses metric families
+// and labels that exist.
+func checkRule(r alertRule, families map[string]Family) error {
+	var labels []string
+	for _, m := range metricPattern.FindAllStringSubmatch(r.Expr, -1) {
+		family, ok := lookupFamily(m[1], families)
+		if !ok {
+			return fmt.Errorf("unknown metric %s", m[1])
+		}
+		for _, matcher := range matcherPattern.FindAllStringSubmatch(m[2], -1) {
+			if !hasLabel(family, matcher[1]) {
+				return fmt.Errorf("metric %s has no label %s", family.Name, matcher[1])
+			}
+		}
+		labels = append(labels, family.Labels...)
+	}
+
+	for _, group := range groupingPattern.FindAllStringSubmatch(r.Expr, -1) {
+		for _, label := range strings.Split(group[1], ",") {
+			label = strings.TrimSpace(label)
+			if label != "" && label != "le" && !slices.Contains(labels, label) && !slices.Contains(targetLabels, label) {
+				return fmt.Errorf("no metric has label %s", label)
+			}
+		}
+	}
+	return nil
+}
+
+// lookupFamily returns the family of a metric name, which for histograms may
+// be that of one of their series.
+func lookupFamily(name string, families map[string]Family) (Family, bool) {
+	if family, ok := families[name]; ok {
+		return family, true
+	}
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if family, ok := families[strings.TrimSuffix(name, suffix)]; ok && family.Type == "histogram" && strings.HasSuffix(name, suffix) {
+			return family, true
+		}
+	}
+	return Family{}, false
+}
+
+// hasLabel reports whether the series of a family can have a label.
+func hasLabel(family Family, label string) bool {
+	return slices.Contains(family.Labels, label) || slices.Contains(targetLabels, label) ||
+		(label == "le" && family.Type == "histogram")
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Components of Conductor that export metrics.
+const (
+	ComponentControlPlane = "control-plane"
+	ComponentAgent        = "agent"
+)
+
+// maxLabels bounds the number of labels of the metric vectors of Families.
+const maxLabels = 8
+
+// Family describes a metric family exported by a component.
+type Family struct {
+	Name string
+	Help string
+	// Type is counter, gauge or histogram.
+	Type      string
+	Labels    []string
+	Component string
+}
+
+// Families returns the metric families the control plane and agents export,
+// other than the Go and process metrics, sorted by component and name. They
+// are read from registries of the metrics, so that what is generated from
+// them, such as dashboards and alerting rules, matches the code.
+func Families() ([]Family, error) {
+	var families []Family
+	for _, component := range []struct {
+		name     string
+		register func(prometheus.Registerer)
+	}{
+		{ComponentControlPlane, func(r prometheus.Registerer) { newControlPlaneMetrics(r) }},
+		{ComponentAgent, func(r prometheus.Registerer) { newAgentMetrics(r) }},
+	} {
+		registry := &recordingRegistry{Registry: prometheus.NewRegistry()}
+		component.register(registry)
+
+		// Vectors are only gathered once they have a child
+		for _, c := range registry.collectors {
+			if err := addChild(c); err != nil {
+				return nil, err
+			}
+		}
+
+		gathered, err := registry.Gather()
+		if err != nil {
+			return nil, fmt.Errorf("failed to gather %s metrics: %w", component.name, err)
+		}
+		for _, mf := range gathered {
+			family := Family{
+				Name:      mf.GetName(),
+				Help:      mf.GetHelp(),
+				Type:      strings.ToLower(mf.GetType().String()),
+				Component: component.name,
+			}
+			if len(mf.GetMetric()) > 0 {
+				for _, label := range mf.GetMetric()[0].GetLabel() {
+					family.Labels = append(family.Labels, label.GetName())
+				}
+			}
+			families = append(families, family)
+		}
+	}
+
+	sort.SliceStable(families, func(i, j int) bool {
+		if families[i].Component != families[j].Component {
+			return families[i].Component == ComponentControlPlane
+		}
+		return families[i].Name < families[j].Name
+	})
+	return families, nil
+}
+
+// recordingRegistry is a registry that records the collectors registered
+// with MustRegister.
+type recordingRegistry struct {
+	*prometheus.Registry
+	collectors []prometheus.Collector
+}
+
+func (r *recordingRegistry) MustRegister(cs ...prometheus.Collector) {
+	r.Registry.MustRegister(cs...)
+	r.collectors = append(r.collectors, cs...)
+}
+
+// addChild adds a child with empty label values to a metric vector. The
+// number of labels is found by trying each until the vector accepts it.
+func addChild(c prometheus.Collector) error {
+	var child func(lvs ...string) error
+	switch v := c.(type) {
+	case *prometheus.CounterVec:
+		child = func(lvs ...string) error { _, err := v.GetMetricWithLabelValues(lvs...); return err }
+	case *prometheus.GaugeVec:
+		child = func(lvs ...string) error { _, err := v.GetMetricWithLabelValues(lvs...); return err }
+	case *prometheus.HistogramVec:
+		child = func(lvs ...string) error { _, err := v.GetMetricWithLabelValues(lvs...); return err }
+	case *prometheus.SummaryVec:
+		child = func(lvs ...string) error { _, err := v.GetMetricWithLabelValues(lvs...); return err }
+	default:
+		return nil
+	}
+
+	for n := 1; n <= maxLabels; n++ {
+		if child(make([]string, n)...) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("metric vector has more than %d labels", maxLabels)
+}
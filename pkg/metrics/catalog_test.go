@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestFamilies(t *testing.T) {
+	families, err := Families()
+	if err != nil {
+		t.Fatalf("Families() error = %v", err)
+	}
+
+	byName := make(map[string]Family)
+	for _, family := range families {
+		if !strings.HasPrefix(family.Name, "conductor_") {
+			t.Errorf("unexpected family %s", family.Name)
+		}
+		byName[family.Name] = family
+	}
+
+	tests := []struct {
+		name      string
+		typ       string
+		labels    []string
+		component string
+	}{
+		{"conductor_http_requests_total", "counter", []string{"method", "path", "status"}, ComponentControlPlane},
+		{"conductor_control_plane_leader", "gauge", nil, ComponentControlPlane},
+		{"conductor_grpc_request_duration_seconds", "histogram", []string{"method", "status"}, ComponentControlPlane},
+		{"conductor_agent_work_total", "counter", []string{"execution_type", "status"}, ComponentAgent},
+		{"conductor_agent_heartbeat_latency_seconds", "histogram", nil, ComponentAgent},
+	}
+	for _, tt := range tests {
+		family, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("family %s not found", tt.name)
+			continue
+		}
+		if family.Type != tt.typ || !slices.Equal(family.Labels, tt.labels) || family.Component != tt.component {
+			t.Errorf("family %s = %+v, want type %s, labels %v, component %s", tt.name, family, tt.typ, tt.labels, tt.component)
+		}
+		if family.Help == "" {
+			t.Errorf("family %s has no help", tt.name)
+		}
+	}
+
+	if families[0].Component != ComponentControlPlane || families[len(families)-1].Component != ComponentAgent {
+		t.Error("families should be sorted by component")
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	families, err := Families()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Dashboard(families)
+	if err != nil {
+		t.Fatalf("Dashboard() error = %v", err)
+	}
+
+	var dashboard grafanaDashboard
+	if err := json.Unmarshal(data, &dashboard); err != nil {
+		t.Fatalf("invalid dashboard JSON: %v", err)
+	}
+
+	var rows []string
+	exprs := make(map[string][]string)
+	ids := make(map[int]bool)
+	for _, panel := range dashboard.Panels {
+		if ids[panel.ID] {
+			t.Errorf("duplicate panel ID %d", panel.ID)
+		}
+		ids[panel.ID] = true
+		if panel.Type == "row" {
+			rows = append(rows, panel.Title)
+			continue
+		}
+		for _, target := range panel.Targets {
+			exprs[panel.Title] = append(exprs[panel.Title], target.Expr)
+		}
+	}
+
+	if !slices.Equal(rows, []string{"Control plane", "Agents"}) {
+		t.Errorf("rows = %v", rows)
+	}
+	if len(exprs) != len(families) {
+		t.Errorf("got %d panels, want one for each of %d families", len(exprs), len(families))
+	}
+
+	want := map[string][]string{
+		"http_requests_total":      {"sum by (method, path, status) (rate(conductor_http_requests_total[$__rate_interval]))"},
+		"agent_disk_usage_percent": {"conductor_agent_disk_usage_percent"},
+		"agent_pool_agents":        {"sum by (pool) (conductor_agent_pool_agents)"},
+		"agent_work_duration_seconds": {
+			"histogram_quantile(0.5, sum by (le) (rate(conductor_agent_work_duration_seconds_bucket[$__rate_interval])))",
+			"histogram_quantile(0.95, sum by (le) (rate(conductor_agent_work_duration_seconds_bucket[$__rate_interval])))",
+		},
+	}
+	for title, want := range want {
+		if !slices.Equal(exprs[title], want) {
+			t.Errorf("panel %s queries = %v, want %v", title, exprs[title], want)
+		}
+	}
+}
+
+func TestAlertRules(t *testing.T) {
+	families, err := Families()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := AlertRules(families)
+	if err != nil {
+		t.Fatalf("AlertRules() error = %v", err)
+	}
+
+	var file struct {
+		Groups []struct {
+			Name  string      `yaml:"name"`
+			Rules []alertRule `yaml:"rules"`
+		} `yaml:"groups"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		t.Fatalf("invalid rule file: %v", err)
+	}
+	if len(file.Groups) != 1 || len(file.Groups[0].Rules) != len(alertRules) {
+		t.Fatalf("rule file = %+v", file)
+	}
+	for _, r := range file.Groups[0].Rules {
+		if r.Labels["severity"] == "" || r.Annotations["summary"] == "" {
+			t.Errorf("alert %s has no severity or summary", r.Alert)
+		}
+	}
+}
+
+func TestCheckRule(t *testing.T) {
+	families := map[string]Family{
+		"conductor_grpc_requests_total":           {Name: "conductor_grpc_requests_total", Type: "counter", Labels: []string{"method", "status"}},
+		"conductor_grpc_request_duration_seconds": {Name: "conductor_grpc_request_duration_seconds", Type: "histogram", Labels: []string{"method", "status"}},
+	}
+
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{`sum by (method) (rate(conductor_grpc_requests_total{status="error"}[5m])) > 0`, ""},
+		{`histogram_quantile(0.95, sum by (le, instance) (rate(conductor_grpc_request_duration_seconds_bucket[5m]))) > 1`, ""},
+		{`rate(conductor_grpc_requests_totals[5m]) > 0`, "unknown metric conductor_grpc_requests_totals"},
+		{`rate(conductor_grpc_requests_total_bucket[5m]) > 0`, "unknown metric conductor_grpc_requests_total_bucket"},
+		{`rate(conductor_grpc_requests_total{code!="OK"}[5m]) > 0`, "metric conductor_grpc_requests_total has no label code"},
+		{`sum by (service) (rate(conductor_grpc_requests_total[5m])) > 0`, "no metric has label service"},
+	}
+	for _, tt := range tests {
+		err := checkRule(alertRule{Alert: "Test", Expr: tt.expr}, families)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("checkRule(%s) error = %v", tt.expr, err)
+		case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+			t.Errorf("checkRule(%s) error = %v, want %s", tt.expr, err, tt.wantErr)
+		}
+	}
+}
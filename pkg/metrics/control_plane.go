@@ -6,9 +6,18 @@ import (
 
 // ControlPlaneMetrics holds all metrics for the control plane.
 type ControlPlaneMetrics struct {
+	// Leader election metrics
+	Leader prometheus.Gauge
+
 	// Agent metrics
 	AgentsTotal *prometheus.GaugeVec
 
+	// Agent pool metrics
+	AgentPoolAgents         *prometheus.GaugeVec
+	AgentPoolOnlineAgents   *prometheus.GaugeVec
+	AgentPoolRunningShards  *prometheus.GaugeVec
+	AgentPoolMaxConcurrency *prometheus.GaugeVec
+
 	// Run metrics
 	RunsTotal     *prometheus.CounterVec
 	RunsActive    prometheus.Gauge
@@ -19,6 +28,7 @@ type ControlPlaneMetrics struct {
 	// API metrics
 	APIRequestDuration *prometheus.HistogramVec
 	APIRequestsTotal   *prometheus.CounterVec
+	APIRateLimited     *prometheus.CounterVec
 
 	// WebSocket metrics
 	WebSocketConnections   prometheus.Gauge
@@ -37,13 +47,33 @@ type ControlPlaneMetrics struct {
 	DBConnectionsIdle   prometheus.Gauge
 
 	// Scheduler metrics
-	SchedulerDecisions *prometheus.CounterVec
-	SchedulerLatency   prometheus.Histogram
+	SchedulerDecisions   *prometheus.CounterVec
+	SchedulerLatency     prometheus.Histogram
+	RunTriggersThrottled *prometheus.CounterVec
+
+	// Webhook metrics
+	WebhookDeliveriesRejected *prometheus.CounterVec
+
+	// Ingestion metrics
+	IngestionDropped *prometheus.CounterVec
+
+	// Notification metrics
+	NotificationDeadLetters *prometheus.GaugeVec
 }
 
 // newControlPlaneMetrics creates and registers all control plane metrics.
-func newControlPlaneMetrics(registry *prometheus.Registry) *ControlPlaneMetrics {
+func newControlPlaneMetrics(registry prometheus.Registerer) *ControlPlaneMetrics {
 	m := &ControlPlaneMetrics{
+		// Leader election metrics
+		Leader: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "conductor",
+				Subsystem: "control_plane",
+				Name:      "leader",
+				Help:      "Whether this replica is the leader running the background jobs (1) or not (0).",
+			},
+		),
+
 		// Agent metrics
 		AgentsTotal: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -55,6 +85,47 @@ func newControlPlaneMetrics(registry *prometheus.Registry) *ControlPlaneMetrics
 			[]string{"status"},
 		),
 
+		// Agent pool metrics
+		AgentPoolAgents: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "conductor",
+				Subsystem: "agent_pool",
+				Name:      "agents",
+				Help:      "Number of agents that joined each agent pool.",
+			},
+			[]string{"pool"},
+		),
+
+		AgentPoolOnlineAgents: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "conductor",
+				Subsystem: "agent_pool",
+				Name:      "online_agents",
+				Help:      "Number of agents of each agent pool that are not offline.",
+			},
+			[]string{"pool"},
+		),
+
+		AgentPoolRunningShards: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "conductor",
+				Subsystem: "agent_pool",
+				Name:      "running_shards",
+				Help:      "Number of shards running on the agents of each agent pool.",
+			},
+			[]string{"pool"},
+		),
+
+		AgentPoolMaxConcurrency: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "conductor",
+				Subsystem: "agent_pool",
+				Name:      "max_concurrency",
+				Help:      "Concurrency quota of each agent pool; 0 means unlimited.",
+			},
+			[]string{"pool"},
+		),
+
 		// Run metrics
 		RunsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
@@ -129,6 +200,16 @@ func newControlPlaneMetrics(registry *prometheus.Registry) *ControlPlaneMetrics
 			[]string{"method", "path", "status"},
 		),
 
+		APIRateLimited: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "conductor",
+				Subsystem: "api",
+				Name:      "requests_rate_limited_total",
+				Help:      "Total number of API requests rejected by the rate limiter, by API.",
+			},
+			[]string{"api"},
+		),
+
 		// WebSocket metrics
 		WebSocketConnections: prometheus.NewGauge(
 			prometheus.GaugeOpts{
@@ -252,11 +333,59 @@ func newControlPlaneMetrics(registry *prometheus.Registry) *ControlPlaneMetrics
 				Buckets:   []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1},
 			},
 		),
+
+		RunTriggersThrottled: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "conductor",
+				Subsystem: "scheduler",
+				Name:      "triggers_throttled_total",
+				Help:      "Total number of run triggers held back by the trigger throttle, by outcome.",
+			},
+			[]string{"outcome"},
+		),
+
+		// Webhook metrics
+		WebhookDeliveriesRejected: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "conductor",
+				Subsystem: "webhook",
+				Name:      "deliveries_rejected_total",
+				Help:      "Total number of rejected webhook deliveries by provider and reason.",
+			},
+			[]string{"provider", "reason"},
+		),
+
+		// Ingestion metrics
+		IngestionDropped: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "conductor",
+				Subsystem: "ingestion",
+				Name:      "dropped_total",
+				Help:      "Total number of results and artifacts dropped after a run reached its ingestion cap, by kind.",
+			},
+			[]string{"kind"},
+		),
+
+		// Notification metrics
+		NotificationDeadLetters: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "conductor",
+				Subsystem: "notifications",
+				Name:      "dead_letters",
+				Help:      "Number of undelivered notifications in the dead-letter queue, by state (pending, exhausted).",
+			},
+			[]string{"state"},
+		),
 	}
 
 	// Register all metrics
 	registry.MustRegister(
+		m.Leader,
 		m.AgentsTotal,
+		m.AgentPoolAgents,
+		m.AgentPoolOnlineAgents,
+		m.AgentPoolRunningShards,
+		m.AgentPoolMaxConcurrency,
 		m.RunsTotal,
 		m.RunsActive,
 		m.RunDuration,
@@ -264,6 +393,7 @@ func newControlPlaneMetrics(registry *prometheus.Registry) *ControlPlaneMetrics
 		m.QueueWaitTime,
 		m.APIRequestDuration,
 		m.APIRequestsTotal,
+		m.APIRateLimited,
 		m.WebSocketConnections,
 		m.WebSocketMessagesTotal,
 		m.GRPCRequestDuration,
@@ -276,6 +406,10 @@ func newControlPlaneMetrics(registry *prometheus.Registry) *ControlPlaneMetrics
 		m.DBConnectionsIdle,
 		m.SchedulerDecisions,
 		m.SchedulerLatency,
+		m.RunTriggersThrottled,
+		m.WebhookDeliveriesRejected,
+		m.IngestionDropped,
+		m.NotificationDeadLetters,
 	)
 
 	return m
@@ -351,3 +485,59 @@ func (m *ControlPlaneMetrics) RecordSchedulerDecision(decision string, durationS
 	m.SchedulerDecisions.WithLabelValues(decision).Inc()
 	m.SchedulerLatency.Observe(durationSeconds)
 }
+
+// RecordTriggerThrottled records a run trigger that was coalesced or rejected
+// by the trigger throttle.
+func (m *ControlPlaneMetrics) RecordTriggerThrottled(outcome string) {
+	m.RunTriggersThrottled.WithLabelValues(outcome).Inc()
+}
+
+// RecordRateLimited records a request rejected by the rate limiter of an
+// API: http, grpc or webhook.
+func (m *ControlPlaneMetrics) RecordRateLimited(api string) {
+	m.APIRateLimited.WithLabelValues(api).Inc()
+}
+
+// RecordWebhookRejected records a rejected webhook delivery.
+func (m *ControlPlaneMetrics) RecordWebhookRejected(provider, reason string) {
+	m.WebhookDeliveriesRejected.WithLabelValues(provider, reason).Inc()
+}
+
+// RecordIngestionDropped records results or artifacts dropped after a run
+// reached its ingestion cap.
+func (m *ControlPlaneMetrics) RecordIngestionDropped(kind string, count int) {
+	m.IngestionDropped.WithLabelValues(kind).Add(float64(count))
+}
+
+// SetNotificationDeadLetters sets the depth of the notification dead-letter
+// queue: dead letters pending automatic redelivery and dead letters left for
+// manual redelivery.
+func (m *ControlPlaneMetrics) SetNotificationDeadLetters(pending, exhausted float64) {
+	m.NotificationDeadLetters.WithLabelValues("pending").Set(pending)
+	m.NotificationDeadLetters.WithLabelValues("exhausted").Set(exhausted)
+}
+
+// SetLeader records whether this replica is the leader.
+func (m *ControlPlaneMetrics) SetLeader(leader bool) {
+	if leader {
+		m.Leader.Set(1)
+	} else {
+		m.Leader.Set(0)
+	}
+}
+
+// SetAgentPool sets the usage and quota of an agent pool.
+func (m *ControlPlaneMetrics) SetAgentPool(pool string, agents, onlineAgents, runningShards, maxConcurrency float64) {
+	m.AgentPoolAgents.WithLabelValues(pool).Set(agents)
+	m.AgentPoolOnlineAgents.WithLabelValues(pool).Set(onlineAgents)
+	m.AgentPoolRunningShards.WithLabelValues(pool).Set(runningShards)
+	m.AgentPoolMaxConcurrency.WithLabelValues(pool).Set(maxConcurrency)
+}
+
+// DeleteAgentPool removes the metrics of a deleted agent pool.
+func (m *ControlPlaneMetrics) DeleteAgentPool(pool string) {
+	m.AgentPoolAgents.DeleteLabelValues(pool)
+	m.AgentPoolOnlineAgents.DeleteLabelValues(pool)
+	m.AgentPoolRunningShards.DeleteLabelValues(pool)
+	m.AgentPoolMaxConcurrency.DeleteLabelValues(pool)
+}
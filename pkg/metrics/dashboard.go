@@ -0,0 +1,214 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Dashboard layout, in Grafana grid units of a width of 24.
+const (
+	panelWidth  = 12
+	panelHeight = 8
+)
+
+// componentTitles are the titles of the dashboard rows of components.
+var componentTitles = map[string]string{
+	ComponentControlPlane: "Control plane",
+	ComponentAgent:        "Agents",
+}
+
+// grafanaDashboard is the JSON model of a Grafana dashboard.
+type grafanaDashboard struct {
+	UID           string            `json:"uid"`
+	Title         string            `json:"title"`
+	Tags          []string          `json:"tags"`
+	Timezone      string            `json:"timezone"`
+	Refresh       string            `json:"refresh"`
+	SchemaVersion int               `json:"schemaVersion"`
+	Time          grafanaTimeRange  `json:"time"`
+	Templating    grafanaTemplating `json:"templating"`
+	Panels        []grafanaPanel    `json:"panels"`
+}
+
+type grafanaTimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type grafanaTemplating struct {
+	List []grafanaVariable `json:"list"`
+}
+
+type grafanaVariable struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	Query string `json:"query"`
+}
+
+type grafanaPanel struct {
+	ID          int                `json:"id"`
+	Type        string             `json:"type"`
+	Title       string             `json:"title"`
+	Description string             `json:"description,omitempty"`
+	GridPos     grafanaGridPos     `json:"gridPos"`
+	Datasource  *grafanaDatasource `json:"datasource,omitempty"`
+	FieldConfig *grafanaFieldConf  `json:"fieldConfig,omitempty"`
+	Targets     []grafanaTarget    `json:"targets,omitempty"`
+}
+
+type grafanaGridPos struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type grafanaDatasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type grafanaFieldConf struct {
+	Defaults grafanaFieldDefaults `json:"defaults"`
+}
+
+type grafanaFieldDefaults struct {
+	Unit string `json:"unit,omitempty"`
+}
+
+type grafanaTarget struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat,omitempty"`
+}
+
+// Dashboard returns a Grafana dashboard, in JSON, with a panel for each
+// metric family, in a row for each component. Counters are graphed as
+// rates, histograms as their median and 95th percentile, and gauges as they
+// are, summed by their labels.
+func Dashboard(families []Family) ([]byte, error) {
+	dashboard := grafanaDashboard{
+		UID:           "conductor",
+		Title:         "Conductor",
+		Tags:          []string{"conductor"},
+		Timezone:      "browser",
+		Refresh:       "30s",
+		SchemaVersion: 39,
+		Time:          grafanaTimeRange{From: "now-6h", To: "now"},
+		Templating: grafanaTemplating{List: []grafanaVariable{
+			{Name: "datasource", Label: "Data source", Type: "datasource", Query: "prometheus"},
+		}},
+	}
+	datasource := &grafanaDatasource{Type: "prometheus", UID: "${datasource}"}
+
+	id, x, y, component := 0, 0, 0, ""
+	for _, family := range families {
+		if family.Component != component {
+			if x > 0 {
+				x, y = 0, y+panelHeight
+			}
+			component = family.Component
+			id++
+			dashboard.Panels = append(dashboard.Panels, grafanaPanel{
+				ID:      id,
+				Type:    "row",
+				Title:   componentTitles[component],
+				GridPos: grafanaGridPos{Y: y, W: 2 * panelWidth, H: 1},
+			})
+			y++
+		}
+
+		id++
+		dashboard.Panels = append(dashboard.Panels, grafanaPanel{
+			ID:          id,
+			Type:        "timeseries",
+			Title:       strings.TrimPrefix(family.Name, "conductor_"),
+			Description: family.Help,
+			GridPos:     grafanaGridPos{X: x, Y: y, W: panelWidth, H: panelHeight},
+			Datasource:  datasource,
+			FieldConfig: &grafanaFieldConf{Defaults: grafanaFieldDefaults{Unit: panelUnit(family)}},
+			Targets:     panelTargets(family),
+		})
+		if x += panelWidth; x == 2*panelWidth {
+			x, y = 0, y+panelHeight
+		}
+	}
+
+	return json.MarshalIndent(dashboard, "", "  ")
+}
+
+// histogramQuantiles are the quantiles graphed of histograms, by legend.
+var histogramQuantiles = []struct {
+	legend   string
+	quantile string
+}{
+	{"p50", "0.5"},
+	{"p95", "0.95"},
+}
+
+// panelTargets returns the queries of the panel of a metric family.
+func panelTargets(family Family) []grafanaTarget {
+	switch {
+	case family.Type == "counter":
+		return []grafanaTarget{{
+			RefID:        "A",
+			Expr:         fmt.Sprintf("sum%s (rate(%s[$__rate_interval]))", byClause(family.Labels), family.Name),
+			LegendFormat: legendFormat(family.Labels),
+		}}
+	case family.Type == "histogram":
+		targets := make([]grafanaTarget, len(histogramQuantiles))
+		for i, q := range histogramQuantiles {
+			targets[i] = grafanaTarget{
+				RefID:        string(rune('A' + i)),
+				Expr:         fmt.Sprintf("histogram_quantile(%s, sum by (le) (rate(%s_bucket[$__rate_interval])))", q.quantile, family.Name),
+				LegendFormat: q.legend,
+			}
+		}
+		return targets
+	case len(family.Labels) == 0:
+		// Gauges of agents, such as their disk usage, are graphed per instance
+		return []grafanaTarget{{RefID: "A", Expr: family.Name, LegendFormat: "{{instance}}"}}
+	default:
+		return []grafanaTarget{{
+			RefID:        "A",
+			Expr:         fmt.Sprintf("sum%s (%s)", byClause(family.Labels), family.Name),
+			LegendFormat: legendFormat(family.Labels),
+		}}
+	}
+}
+
+// byClause returns the by clause of an aggregation by labels, if any.
+func byClause(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	return " by (" + strings.Join(labels, ", ") + ")"
+}
+
+// legendFormat returns the legend of the series of labels, such as
+// {{method}} {{status}}.
+func legendFormat(labels []string) string {
+	parts := make([]string, len(labels))
+	for i, label := range labels {
+		parts[i] = "{{" + label + "}}"
+	}
+	return strings.Join(parts, " ")
+}
+
+// panelUnit returns the Grafana unit of the values of a metric family.
+func panelUnit(family Family) string {
+	switch {
+	case family.Type == "counter":
+		return "ops"
+	case strings.HasSuffix(family.Name, "_seconds"):
+		return "s"
+	case strings.HasSuffix(family.Name, "_bytes"):
+		return "bytes"
+	case strings.HasSuffix(family.Name, "_percent"):
+		return "percent"
+	default:
+		return "short"
+	}
+}
@@ -132,6 +132,29 @@ func TestControlPlaneMetricsRecording(t *testing.T) {
 	// Test RecordSchedulerDecision
 	m.ControlPlane.RecordSchedulerDecision("assigned", 0.001)
 
+	// Test RecordTriggerThrottled
+	m.ControlPlane.RecordTriggerThrottled("coalesced")
+
+	// Test RecordWebhookRejected
+	m.ControlPlane.RecordWebhookRejected("github", "replayed")
+
+	// Test RecordRateLimited
+	m.ControlPlane.RecordRateLimited("http")
+
+	// Test RecordIngestionDropped
+	m.ControlPlane.RecordIngestionDropped("result", 3)
+
+	// Test SetNotificationDeadLetters
+	m.ControlPlane.SetNotificationDeadLetters(2, 1)
+
+	// Test SetLeader
+	m.ControlPlane.SetLeader(true)
+
+	// Test SetAgentPool and DeleteAgentPool
+	m.ControlPlane.SetAgentPool("gpu", 4, 3, 2, 8)
+	m.ControlPlane.SetAgentPool("retired", 1, 0, 0, 0)
+	m.ControlPlane.DeleteAgentPool("retired")
+
 	// Verify metrics are exposed
 	handler := m.Handler()
 	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
@@ -150,6 +173,16 @@ func TestControlPlaneMetricsRecording(t *testing.T) {
 		"conductor_control_plane_agents_total",
 		"conductor_control_plane_runs_active",
 		"conductor_control_plane_queue_depth",
+		"conductor_scheduler_triggers_throttled_total",
+		"conductor_webhook_deliveries_rejected_total",
+		"conductor_ingestion_dropped_total",
+		`conductor_api_requests_rate_limited_total{api="http"} 1`,
+		`conductor_notifications_dead_letters{state="pending"} 2`,
+		`conductor_notifications_dead_letters{state="exhausted"} 1`,
+		"conductor_control_plane_leader 1",
+		`conductor_agent_pool_agents{pool="gpu"} 4`,
+		`conductor_agent_pool_running_shards{pool="gpu"} 2`,
+		`conductor_agent_pool_max_concurrency{pool="gpu"} 8`,
 	}
 
 	for _, metric := range expectedMetrics {
@@ -157,6 +190,9 @@ func TestControlPlaneMetricsRecording(t *testing.T) {
 			t.Errorf("expected metric %s in response", metric)
 		}
 	}
+	if strings.Contains(body, `pool="retired"`) {
+		t.Error("expected deleted agent pool metrics to be removed")
+	}
 }
 
 func TestAgentMetricsRecording(t *testing.T) {
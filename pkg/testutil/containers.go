@@ -456,10 +456,11 @@ func (tc *TestConfig) ToControlPlaneConfig() *config.Config {
 			JWTExpiration: 24 * time.Hour,
 		},
 		Agent: config.AgentConfig{
-			HeartbeatTimeout:       90 * time.Second,
-			DefaultTestTimeout:     30 * time.Minute,
-			MaxTestTimeout:         4 * time.Hour,
-			ResultStreamBufferSize: 100,
+			HeartbeatTimeout:          90 * time.Second,
+			DefaultTestTimeout:        30 * time.Minute,
+			MaxTestTimeout:            4 * time.Hour,
+			ResultStreamBufferSize:    100,
+			ResultStreamFlushInterval: time.Second,
 		},
 		Git: config.GitConfig{
 			Provider: "github",
@@ -393,6 +393,7 @@ func (f *Fixtures) CreateScheduledRun(ctx context.Context, serviceID uuid.UUID,
 		ServiceID:      serviceID,
 		Name:           name,
 		CronExpression: "0 0 * * *", // Daily at midnight
+		Timezone:       "UTC",
 		GitRef:         "main",
 		TestFilter:     []string{},
 		Enabled:        true,
@@ -183,6 +183,32 @@ func SpanID(ctx context.Context) string {
 	return ""
 }
 
+// TraceParent returns the span context of ctx as a W3C traceparent header,
+// or an empty string if ctx has no valid span context. It carries a trace
+// across boundaries that have no request metadata, such as stored runs and
+// work assignments; see ContextWithTraceParent.
+func TraceParent(ctx context.Context) string {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ""
+	}
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get(traceParentHeader)
+}
+
+// ContextWithTraceParent returns ctx with the remote span context of a W3C
+// traceparent header, so spans started from it continue that trace. ctx is
+// returned unchanged if traceParent is empty or invalid.
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	return propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{traceParentHeader: traceParent})
+}
+
+// traceParentHeader is the W3C Trace Context header of the span context.
+const traceParentHeader = "traceparent"
+
 // WithSpanKind returns a span start option that sets the span kind.
 func WithSpanKind(kind trace.SpanKind) trace.SpanStartOption {
 	return trace.WithSpanKind(kind)
@@ -199,6 +225,10 @@ var (
 	AttrAgentID = attribute.Key("conductor.agent.id")
 	// AttrRunID is the run ID attribute.
 	AttrRunID = attribute.Key("conductor.run.id")
+	// AttrRunStatus is the run status attribute.
+	AttrRunStatus = attribute.Key("conductor.run.status")
+	// AttrShardID is the shard ID attribute.
+	AttrShardID = attribute.Key("conductor.shard.id")
 	// AttrServiceID is the service ID attribute.
 	AttrServiceID = attribute.Key("conductor.service.id")
 	// AttrTestID is the test ID attribute.
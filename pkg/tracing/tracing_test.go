@@ -431,3 +431,43 @@ func TestMetadataCarrier(t *testing.T) {
 		t.Errorf("Keys() returned %d keys, want 3", len(keys))
 	}
 }
+
+func TestTraceParent(t *testing.T) {
+	exporter, cleanup := setupTestTracer(t)
+	defer cleanup()
+
+	if got := TraceParent(context.Background()); got != "" {
+		t.Errorf("TraceParent() without span = %q, want empty string", got)
+	}
+
+	ctx, span := StartSpan(context.Background(), "webhook")
+	traceParent := TraceParent(ctx)
+	span.End()
+	if traceParent == "" {
+		t.Fatal("TraceParent() = empty string, want traceparent")
+	}
+
+	// Continuing the trace elsewhere, such as on an agent
+	child, childSpan := StartSpan(ContextWithTraceParent(context.Background(), traceParent), "agent")
+	childSpan.End()
+	if TraceID(child) != span.SpanContext().TraceID().String() {
+		t.Errorf("continued trace ID = %q, want %q", TraceID(child), span.SpanContext().TraceID())
+	}
+
+	if err := otel.GetTracerProvider().(*sdktrace.TracerProvider).ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[1].Parent.SpanID() != span.SpanContext().SpanID() || !spans[1].Parent.IsRemote() {
+		t.Errorf("continued span parent = %v, want remote %v", spans[1].Parent.SpanID(), span.SpanContext().SpanID())
+	}
+
+	for _, invalid := range []string{"", "not-a-traceparent"} {
+		if ctx := ContextWithTraceParent(context.Background(), invalid); trace.SpanContextFromContext(ctx).IsValid() {
+			t.Errorf("ContextWithTraceParent(%q) has a valid span context", invalid)
+		}
+	}
+}
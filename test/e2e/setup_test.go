@@ -535,8 +535,8 @@ func (r *e2eAgentRepository) GetAvailable(ctx context.Context, zones []string, l
 }
 
 // MarkOfflineAgents implements database.AgentRepository
-func (r *e2eAgentRepository) MarkOfflineAgents(ctx context.Context) (int64, error) {
-	return 0, nil
+func (r *e2eAgentRepository) MarkOfflineAgents(ctx context.Context, timeout time.Duration) ([]database.Agent, error) {
+	return nil, nil
 }
 
 // CountByStatus implements database.AgentRepository
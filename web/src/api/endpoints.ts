@@ -8,9 +8,16 @@ import type {
   TestRun,
   Agent,
   Artifact,
+  ArtifactBrowse,
+  ArtifactType,
   DashboardStats,
   RunHistoryPoint,
   RunShard,
+  RunArchResult,
+  RunMatrixResult,
+  RunStageResult,
+  RunAnnotation,
+  TestParameter,
 } from "@/types/models";
 import type {
   PaginatedResponse,
@@ -38,8 +45,12 @@ export interface Service {
   defaultContainerImage?: string;
   defaultTimeout: number;
   configPath: string;
+  rootPath?: string;
+  syncIntervalSeconds?: number;
+  branchRunPolicy?: "allow" | "supersede" | "coalesce";
   labels: Record<string, string>;
   active: boolean;
+  archivedAt?: string;
   createdAt: string;
   updatedAt: string;
   lastSyncedAt?: string;
@@ -64,6 +75,43 @@ export interface RecentRunSummary {
   total: number;
 }
 
+export interface Branch {
+  serviceId: string;
+  name: string;
+  lastSha?: string;
+  protected: boolean;
+  isDefault: boolean;
+  lastRunId?: string;
+  lastRunStatus?: TestRun["status"];
+  lastRunAt?: string;
+  lastPushedAt?: string;
+  recentRuns: number;
+  recentPassed: number;
+  passRate: number;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface SyncRecord {
+  id: string;
+  branch: string;
+  trigger: "manual" | "scheduled";
+  status: "success" | "partial" | "failed";
+  testsAdded: number;
+  testsUpdated: number;
+  testsRemoved: number;
+  errors: string[];
+  startedAt: string;
+  finishedAt: string;
+}
+
+export interface SyncStatus {
+  syncIntervalSeconds: number;
+  lastSync?: SyncRecord;
+  nextSyncAt?: string;
+  history: SyncRecord[];
+}
+
 export interface TestDefinition {
   id: string;
   serviceId: string;
@@ -76,10 +124,18 @@ export interface TestDefinition {
   tags: string[];
   environment: Record<string, string>;
   artifactPaths: string[];
+  capturePaths?: string[];
+  parameters?: TestParameter[];
   enabled: boolean;
   retryCount: number;
   requiredRuntimes: string[];
   requiredNetworkZones: string[];
+  labelSelector?: Record<string, string>;
+  architectures?: string[];
+  artifactBudget?: {
+    maxBytes?: number;
+    maxFiles?: number;
+  };
   createdAt: string;
   updatedAt: string;
   estimatedDuration?: number;
@@ -124,9 +180,15 @@ export interface RunDetails extends TestRun {
     ciPipelineUrl?: string;
   };
   labels: Record<string, string>;
+  labelSelector?: Record<string, string>;
   retryOfRunId?: string;
   retryCount: number;
   shards?: RunShard[];
+  archResults?: RunArchResult[];
+  matrixResults?: RunMatrixResult[];
+  stageResults?: RunStageResult[];
+  annotations?: RunAnnotation[];
+  parameters?: Record<string, string>;
 }
 
 export interface RunWithResults extends RunDetails {
@@ -152,6 +214,85 @@ export interface TestResult {
   stderr?: string;
 }
 
+export interface OutputDiff {
+  name: string;
+  diff?: string;
+  identical: boolean;
+  truncated: boolean;
+}
+
+export interface TestOutputDiff {
+  resultId: string;
+  baselineRunId: string;
+  baselineResultId: string;
+  diffs: OutputDiff[];
+  skipped?: string[];
+}
+
+export interface DiffTestOutputParams {
+  testName: string;
+  suiteName?: string;
+  includeArtifacts?: boolean;
+  maxBytes?: number;
+}
+
+export interface StackFrame {
+  language: "go" | "java" | "python" | "javascript";
+  function?: string;
+  file: string;
+  line: number;
+  column?: number;
+  path?: string;
+  url?: string;
+}
+
+export interface TestStackTrace {
+  resultId: string;
+  commitSha?: string;
+  frames: StackFrame[];
+}
+
+export interface TestStackTraceParams {
+  testName: string;
+  suiteName?: string;
+}
+
+export interface ZoneEnergy {
+  zone: string;
+  energyWh: number;
+  carbonIntensity: number;
+  carbonGrams: number;
+}
+
+export interface RunEnergy {
+  runId: string;
+  energyWh: number;
+  carbonGrams: number;
+  zones: ZoneEnergy[];
+}
+
+export interface MonthlyEnergy {
+  serviceId: string;
+  serviceName: string;
+  month: string;
+  runCount: number;
+  energyWh: number;
+  carbonGrams: number;
+  zones: ZoneEnergy[];
+}
+
+export interface EnergyStats {
+  months: MonthlyEnergy[];
+  totalEnergyWh: number;
+  totalCarbonGrams: number;
+}
+
+export interface EnergyStatsParams {
+  serviceId?: string;
+  "timeRange.start"?: string;
+  "timeRange.end"?: string;
+}
+
 export interface LogEntry {
   sequence: number;
   timestamp: string;
@@ -185,6 +326,9 @@ export interface CreateRunRequest {
   priority?: number;
   executionType?: "subprocess" | "container";
   timeout?: number;
+  labelSelector?: Record<string, string>;
+  idempotencyKey?: string;
+  parameters?: Record<string, string>;
 }
 
 export interface CancelRunRequest {
@@ -237,6 +381,45 @@ export interface AgentStats {
   };
 }
 
+export interface AgentCurrentRun {
+  runId: string;
+  serviceName: string;
+  startedAt: string;
+  progressPercent: number;
+}
+
+export interface AgentDetail {
+  agent: Agent;
+  connection: {
+    connected: boolean;
+    connectedAt?: string;
+    lastHeartbeat?: string;
+    reportedStatus?: string;
+  };
+  utilization?: {
+    cpuPercent: number;
+    memoryBytes: number;
+    memoryTotalBytes: number;
+    diskBytes: number;
+    diskTotalBytes: number;
+  };
+  currentRuns?: AgentCurrentRun[];
+  queuedWork?: Array<{
+    runId: string;
+    shardId?: string;
+    shardIndex: number;
+    shardCount: number;
+    assignedAt: string;
+  }>;
+  recentRuns?: TestRun[];
+}
+
+export interface AgentLogs {
+  lines?: Array<{ level: string; line: string }>;
+  truncated: boolean;
+  collectedAt: string;
+}
+
 // =============================================================================
 // API Endpoints
 // =============================================================================
@@ -246,6 +429,7 @@ export const endpoints = {
   dashboard: {
     stats: "/api/v1/dashboard/stats",
     runHistory: "/api/v1/dashboard/run-history",
+    energy: "/api/v1/stats/energy",
   },
 
   // Runs
@@ -254,12 +438,17 @@ export const endpoints = {
     get: (id: string) => `/api/v1/runs/${id}`,
     create: "/api/v1/runs",
     cancel: (id: string) => `/api/v1/runs/${id}/cancel`,
+    approve: (id: string) => `/api/v1/runs/${id}/approve`,
     retry: (id: string) => `/api/v1/runs/${id}/retry`,
     logs: (id: string) => `/api/v1/runs/${id}/logs`,
     logsStream: (id: string) => `/api/v1/runs/${id}/logs/stream`,
     results: (id: string) => `/api/v1/runs/${id}/results`,
     testResults: (id: string) => `/api/v1/runs/${id}/results/tests`,
+    diffOutput: (id: string) => `/api/v1/runs/${id}/results/diff`,
+    stackTrace: (id: string) => `/api/v1/runs/${id}/results/stack-trace`,
     artifacts: (id: string) => `/api/v1/runs/${id}/artifacts`,
+    artifactTree: (id: string) => `/api/v1/runs/${id}/artifacts/tree`,
+    energy: (id: string) => `/api/v1/runs/${id}/energy`,
   },
 
   // Services
@@ -269,19 +458,27 @@ export const endpoints = {
     create: "/api/v1/services",
     update: (id: string) => `/api/v1/services/${id}`,
     delete: (id: string) => `/api/v1/services/${id}`,
+    archive: (id: string) => `/api/v1/services/${id}/archive`,
+    unarchive: (id: string) => `/api/v1/services/${id}/unarchive`,
     sync: (id: string) => `/api/v1/services/${id}/sync`,
     tests: (id: string) => `/api/v1/services/${id}/tests`,
     stats: (id: string) => `/api/v1/services/${id}/stats`,
+    branches: (id: string) => `/api/v1/services/${id}/branches`,
+    branch: (id: string, name: string) =>
+      `/api/v1/services/${id}/branches/${name.split("/").map(encodeURIComponent).join("/")}`,
   },
 
   // Agents
   agents: {
     list: "/api/v1/agents",
     get: (id: string) => `/api/v1/agents/${id}`,
+    detail: (id: string) => `/api/v1/agents/${id}/detail`,
+    logs: (id: string) => `/api/v1/agents/${id}/logs`,
     drain: (id: string) => `/api/v1/agents/${id}/drain`,
     undrain: (id: string) => `/api/v1/agents/${id}/undrain`,
     delete: (id: string) => `/api/v1/agents/${id}`,
     stats: (id: string) => `/api/v1/agents/${id}/stats`,
+    runs: (id: string) => `/api/v1/agents/${id}/runs`,
   },
 
   // Artifacts
@@ -305,6 +502,8 @@ export const dashboardApi = {
   getStats: () => get<DashboardStats>(endpoints.dashboard.stats),
   getRunHistory: (days: number = 14) =>
     get<RunHistoryPoint[]>(endpoints.dashboard.runHistory, { days }),
+  getEnergyStats: (params?: EnergyStatsParams) =>
+    get<EnergyStats>(endpoints.dashboard.energy, params as Record<string, unknown>),
 };
 
 // Runs
@@ -326,6 +525,8 @@ export const runsApi = {
     post<{ run: RunDetails }>(endpoints.runs.create, data),
   cancel: (id: string, reason?: string, shardId?: string) =>
     post<{ run: RunDetails }>(endpoints.runs.cancel(id), { reason, shardId }),
+  approve: (id: string, comment?: string) =>
+    post<{ run: RunDetails }>(endpoints.runs.approve(id), { comment }),
   retry: (id: string, data?: RetryRunRequest) =>
     post<{ run: RunDetails; originalRunId: string }>(
       endpoints.runs.retry(id),
@@ -339,8 +540,15 @@ export const runsApi = {
     ),
   getTestResults: (id: string, params?: TestResultFilterParams) =>
     get<PaginatedResponse<TestResult>>(endpoints.runs.testResults(id), params as Record<string, unknown>),
+  diffTestOutput: (id: string, params: DiffTestOutputParams) =>
+    get<TestOutputDiff>(endpoints.runs.diffOutput(id), params as unknown as Record<string, unknown>),
+  getTestStackTrace: (id: string, params: TestStackTraceParams) =>
+    get<TestStackTrace>(endpoints.runs.stackTrace(id), params as unknown as Record<string, unknown>),
   getArtifacts: (id: string) =>
     get<PaginatedResponse<Artifact>>(endpoints.runs.artifacts(id)),
+  browseArtifacts: (id: string, params?: { testId?: string; type?: ArtifactType }) =>
+    get<ArtifactBrowse>(endpoints.runs.artifactTree(id), params),
+  getEnergy: (id: string) => get<RunEnergy>(endpoints.runs.energy(id)),
 };
 
 // Services
@@ -361,6 +569,10 @@ export const servicesApi = {
     del<{ success: boolean }>(
       `${endpoints.services.delete(id)}?deleteHistory=${deleteHistory}`
     ),
+  archive: (id: string) =>
+    post<{ service: Service }>(endpoints.services.archive(id), {}),
+  unarchive: (id: string) =>
+    post<{ service: Service }>(endpoints.services.unarchive(id), {}),
   sync: (id: string, branch?: string, deleteMissing = false) =>
     post<{
       testsAdded: number;
@@ -368,10 +580,18 @@ export const servicesApi = {
       testsRemoved: number;
       errors: string[];
     }>(endpoints.services.sync(id), { branch, deleteMissing }),
+  getSyncStatus: (id: string) =>
+    get<SyncStatus>(endpoints.services.sync(id)),
   getTests: (id: string) =>
     get<PaginatedResponse<TestDefinition>>(endpoints.services.tests(id)),
   getStats: (id: string, days = 30) =>
     get<ServiceStats>(endpoints.services.stats(id), { days }),
+  listBranches: (id: string) =>
+    get<{ branches: Branch[] }>(endpoints.services.branches(id)),
+  getBranch: (id: string, name: string) =>
+    get<{ branch: Branch; runs: TestRun[] }>(endpoints.services.branch(id, name)),
+  updateBranch: (id: string, name: string, data: { protected?: boolean }) =>
+    patch<{ branch: Branch }>(endpoints.services.branch(id, name), data),
 };
 
 // Agents
@@ -381,13 +601,12 @@ export const agentsApi = {
   get: (id: string, includeCurrentRuns = false) =>
     get<{
       agent: Agent;
-      currentRuns?: Array<{
-        runId: string;
-        serviceName: string;
-        startedAt: string;
-        progressPercent: number;
-      }>;
+      currentRuns?: AgentCurrentRun[];
     }>(endpoints.agents.get(id), { includeCurrentRuns }),
+  getDetail: (id: string, recentRunsLimit?: number) =>
+    get<AgentDetail>(endpoints.agents.detail(id), { recentRunsLimit }),
+  getLogs: (id: string, params?: { limit?: number; minLevel?: string }) =>
+    get<AgentLogs>(endpoints.agents.logs(id), params),
   drain: (id: string, data?: DrainAgentRequest) =>
     post<{ agent: Agent; cancelledRuns: number }>(
       endpoints.agents.drain(id),
@@ -401,14 +620,16 @@ export const agentsApi = {
     ),
   getStats: (id: string, startDate?: string, endDate?: string) =>
     get<AgentStats>(endpoints.agents.stats(id), { startDate, endDate }),
+  listRuns: (id: string, startDate?: string, endDate?: string) =>
+    get<PaginatedResponse<TestRun>>(endpoints.agents.runs(id), { startDate, endDate }),
 };
 
 // Artifacts
 export const artifactsApi = {
   get: (id: string) => get<{ artifact: Artifact }>(endpoints.artifacts.get(id)),
-  getDownloadUrl: (id: string, expirationSeconds = 300) =>
-    get<{ downloadUrl: string; expiresAt: string }>(
+  getDownloadUrl: (id: string, expirationSeconds = 300, inline = false) =>
+    get<{ downloadUrl: string; expiresAt: string; contentType: string }>(
       endpoints.artifacts.download(id),
-      { expirationSeconds }
+      { expirationSeconds, inline }
     ),
 };
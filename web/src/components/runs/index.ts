@@ -3,6 +3,7 @@
  */
 
 export { LogViewer, type LogLine, type LogViewerProps } from "./log-viewer";
+export { MediaGallery, type MediaGalleryProps } from "./media-gallery";
 export {
   TestResultsTable,
   type TestResultRow,
@@ -0,0 +1,88 @@
+/**
+ * Gallery of the screenshots and videos of a run
+ * Media is loaded through short-lived inline URLs
+ */
+
+import { useQuery } from "@tanstack/react-query";
+import { Download } from "lucide-react";
+import { Skeleton } from "@/components/ui/skeleton";
+import { artifactsApi } from "@/api/endpoints";
+import type { Artifact } from "@/types/models";
+
+// =============================================================================
+// Types
+// =============================================================================
+
+export interface MediaGalleryProps {
+  artifacts: Artifact[];
+}
+
+// =============================================================================
+// Media Tile
+// =============================================================================
+
+function MediaTile({ artifact }: { artifact: Artifact }) {
+  const { data, isLoading } = useQuery({
+    queryKey: ["artifacts", artifact.id, "inline"],
+    queryFn: () => artifactsApi.getDownloadUrl(artifact.id, 300, true),
+    // Sign a new URL before the current one expires
+    refetchInterval: 240_000,
+  });
+
+  return (
+    <figure className="overflow-hidden rounded-lg border">
+      {isLoading || !data ? (
+        <Skeleton className="aspect-video w-full" />
+      ) : artifact.type === "ARTIFACT_TYPE_VIDEO" ? (
+        <video
+          src={data.downloadUrl}
+          controls
+          preload="metadata"
+          className="aspect-video w-full bg-black"
+        />
+      ) : (
+        <a href={data.downloadUrl} target="_blank" rel="noreferrer">
+          <img
+            src={data.downloadUrl}
+            alt={artifact.name}
+            loading="lazy"
+            className="aspect-video w-full bg-muted object-contain"
+          />
+        </a>
+      )}
+      <figcaption className="flex items-center justify-between gap-2 p-2 text-xs">
+        <span className="truncate" title={artifact.name}>
+          {artifact.name}
+        </span>
+        {artifact.downloadUrl && (
+          <a href={artifact.downloadUrl} download aria-label={`Download ${artifact.name}`}>
+            <Download className="h-4 w-4 text-muted-foreground" />
+          </a>
+        )}
+      </figcaption>
+    </figure>
+  );
+}
+
+// =============================================================================
+// Media Gallery
+// =============================================================================
+
+export function MediaGallery({ artifacts }: MediaGalleryProps) {
+  const media = artifacts.filter(
+    (artifact) =>
+      artifact.type === "ARTIFACT_TYPE_SCREENSHOT" ||
+      artifact.type === "ARTIFACT_TYPE_VIDEO"
+  );
+  if (media.length === 0) {
+    return null;
+  }
+
+  return (
+    <div className="grid gap-3 sm:grid-cols-2 lg:grid-cols-3">
+      {media.map((artifact) => (
+        <MediaTile key={artifact.id} artifact={artifact} />
+      ))}
+    </div>
+  );
+}
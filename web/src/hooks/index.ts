@@ -18,6 +18,7 @@ export {
   useRunArtifacts,
   useCreateRun,
   useCancelRun,
+  useApproveRun,
   useRetryRun,
   runKeys,
   type RunDetails,
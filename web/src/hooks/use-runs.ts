@@ -189,6 +189,22 @@ export function useCancelRun() {
   });
 }
 
+/**
+ * Approve a run waiting for approval
+ */
+export function useApproveRun() {
+  const queryClient = useQueryClient();
+
+  return useMutation({
+    mutationFn: ({ runId, comment }: { runId: string; comment?: string }) =>
+      runsApi.approve(runId, comment),
+    onSuccess: (data) => {
+      queryClient.setQueryData(runKeys.detail(data.run.id), data.run);
+      void queryClient.invalidateQueries({ queryKey: runKeys.lists() });
+    },
+  });
+}
+
 /**
  * Retry a failed run
  */
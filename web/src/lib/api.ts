@@ -4,7 +4,7 @@ import axios, {
   type AxiosResponse,
   type InternalAxiosRequestConfig,
 } from "axios";
-import type { ApiError } from "@/types/api";
+import type { ApiError, ProblemDetails } from "@/types/api";
 import { TokenStorage, isAccessTokenExpired, refreshAccessToken, getAuthConfig } from "@/lib/auth";
 import type { AuthConfig } from "@/types/auth";
 
@@ -101,7 +101,7 @@ function createApiClient(): AxiosInstance {
   // Response interceptor for error handling
   client.interceptors.response.use(
     (response: AxiosResponse) => response,
-    async (error: AxiosError<ApiError>) => {
+    async (error: AxiosError<ProblemDetails>) => {
       const originalRequest = error.config as InternalAxiosRequestConfig & {
         _retry?: boolean;
       };
@@ -147,11 +147,13 @@ function createApiClient(): AxiosInstance {
 
         // Enhance error with API error details
         const apiError = Object.assign(
-          new Error(data?.message || error.message),
+          new Error(data?.detail || data?.title || error.message),
           {
             code: data?.code || `HTTP_${status}`,
             status,
-            details: data?.details,
+            requestId: data?.requestId,
+            fieldViolations: data?.fieldViolations,
+            details: data?.metadata,
           }
         ) as ApiError & Error;
 
@@ -17,6 +17,7 @@ import {
   Download,
   CheckCircle,
   AlertCircle,
+  ShieldCheck,
 } from "lucide-react";
 import { Button } from "@/components/ui/button";
 import {
@@ -30,11 +31,13 @@ import { Badge } from "@/components/ui/badge";
 import { Tabs, TabsContent, TabsList, TabsTrigger } from "@/components/ui/tabs";
 import { Skeleton } from "@/components/ui/skeleton";
 import { LogViewer } from "@/components/runs/log-viewer";
+import { MediaGallery } from "@/components/runs/media-gallery";
 import { TestResultsTable, type TestResultRow } from "@/components/runs/test-results-table";
 import {
   useRun,
   useRunResults,
   useCancelRun,
+  useApproveRun,
   useRetryRun,
   useRunArtifacts,
   useRunLogs,
@@ -70,6 +73,13 @@ function getStatusBadge(status: TestRunStatus) {
           Running
         </Badge>
       );
+    case "waiting_approval":
+      return (
+        <Badge variant="warning" className="gap-1">
+          <ShieldCheck className="h-3 w-3" />
+          Waiting for Approval
+        </Badge>
+      );
     case "pending":
     case "queued":
       return (
@@ -259,6 +269,7 @@ export function RunDetailsPage() {
 
   // Mutations
   const cancelRun = useCancelRun();
+  const approveRun = useApproveRun();
   const retryRun = useRetryRun();
 
   // Determine current status (prefer live if available)
@@ -266,7 +277,8 @@ export function RunDetailsPage() {
   const isInProgress =
     currentStatus === "running" ||
     currentStatus === "pending" ||
-    currentStatus === "queued";
+    currentStatus === "queued" ||
+    currentStatus === "waiting_approval";
 
   // Build timeline events
   const timelineEvents: TimelineEvent[] = runData
@@ -354,6 +366,7 @@ export function RunDetailsPage() {
 
   const run = runData;
   const shards = run.shards || [];
+  const annotations = run.annotations || [];
   const shardTotal = run.shardCount ?? (shards.length || 0);
   const shardTotalLabel = shardTotal > 0 ? shardTotal : "—";
 
@@ -394,6 +407,16 @@ export function RunDetailsPage() {
         </div>
 
         <div className="flex gap-2">
+          {currentStatus === "waiting_approval" && (
+            <Button
+              onClick={() => approveRun.mutate({ runId })}
+              disabled={approveRun.isPending}
+              title={run.approvalReason}
+            >
+              <ShieldCheck className="mr-2 h-4 w-4" />
+              Approve Run
+            </Button>
+          )}
           {isInProgress && (
             <Button
               variant="destructive"
@@ -495,6 +518,14 @@ export function RunDetailsPage() {
                 {run.durationMs ? formatDuration(run.durationMs) : "—"}
               </span>
             </div>
+            {Object.entries(run.parameters || {}).map(([name, value]) => (
+              <div key={name} className="flex items-center justify-between">
+                <span className="text-sm text-muted-foreground">{name}</span>
+                <code className="rounded bg-muted px-1.5 py-0.5 text-xs">
+                  {value}
+                </code>
+              </div>
+            ))}
           </CardContent>
         </Card>
 
@@ -583,6 +614,53 @@ export function RunDetailsPage() {
         </Card>
       )}
 
+      {/* Agent annotations */}
+      {annotations.length > 0 && (
+        <Card className="border-warning/50 bg-warning/5">
+          <CardHeader className="pb-2">
+            <CardTitle className="flex items-center gap-2 text-base">
+              <AlertCircle className="h-4 w-4" />
+              Environment warnings
+            </CardTitle>
+            <CardDescription>
+              Conditions agents reported about their environment during this run
+            </CardDescription>
+          </CardHeader>
+          <CardContent>
+            <ul className="space-y-2 text-sm">
+              {annotations.map((annotation) => (
+                <li key={annotation.id} className="flex flex-wrap items-center gap-2">
+                  <Badge variant={annotation.severity === "warning" ? "warning" : "secondary"}>
+                    {annotation.kind}
+                  </Badge>
+                  <span>{annotation.message}</span>
+                  <span className="text-muted-foreground">
+                    {formatRelativeTime(annotation.timestamp)}
+                  </span>
+                </li>
+              ))}
+            </ul>
+          </CardContent>
+        </Card>
+      )}
+
+      {/* Truncation notice */}
+      {((run.resultsDropped ?? 0) > 0 || (run.artifactsDropped ?? 0) > 0) && (
+        <Card className="border-destructive/50 bg-destructive/5">
+          <CardHeader className="pb-2">
+            <CardTitle className="flex items-center gap-2 text-base text-destructive">
+              <AlertCircle className="h-4 w-4" />
+              Results truncated
+            </CardTitle>
+          </CardHeader>
+          <CardContent className="text-sm text-destructive">
+            This run reached its ingestion cap.{" "}
+            {(run.resultsDropped ?? 0) > 0 && `${run.resultsDropped} test results were dropped. `}
+            {(run.artifactsDropped ?? 0) > 0 && `${run.artifactsDropped} artifacts were dropped.`}
+          </CardContent>
+        </Card>
+      )}
+
       {/* Tabs */}
       <Tabs value={activeTab} onValueChange={setActiveTab}>
         <TabsList>
@@ -623,7 +701,20 @@ export function RunDetailsPage() {
           />
         </TabsContent>
 
-        <TabsContent value="artifacts" className="mt-4">
+        <TabsContent value="artifacts" className="mt-4 space-y-4">
+          {run.hasMediaArtifacts && (
+            <Card>
+              <CardHeader>
+                <CardTitle className="text-base">Screenshots &amp; Videos</CardTitle>
+                <CardDescription>
+                  Media captured by browser tests
+                </CardDescription>
+              </CardHeader>
+              <CardContent>
+                <MediaGallery artifacts={artifactsData?.items || []} />
+              </CardContent>
+            </Card>
+          )}
           <Card>
             <CardHeader>
               <CardTitle className="text-base">Artifacts</CardTitle>
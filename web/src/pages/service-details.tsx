@@ -306,24 +306,40 @@ interface TriggerRunDialogProps {
   serviceId: string;
   serviceName: string;
   defaultBranch: string;
+  tests?: TestDefinition[];
   onTriggered?: () => void;
 }
 
+const inputClassName =
+  "flex h-10 w-full rounded-md border border-input bg-background px-3 py-2 text-sm ring-offset-background focus-visible:outline-none focus-visible:ring-2 focus-visible:ring-ring";
+
 function TriggerRunDialog({
   serviceId,
   serviceName,
   defaultBranch,
+  tests = [],
   onTriggered,
 }: TriggerRunDialogProps) {
   const [open, setOpen] = useState(false);
   const [branch, setBranch] = useState(defaultBranch);
+  const [parameterValues, setParameterValues] = useState<Record<string, string>>({});
   const createRun = useCreateRun();
 
+  // Parameters declared by the service's tests, once per name
+  const parameters = tests
+    .flatMap((test) => test.parameters || [])
+    .filter((param, i, all) => all.findIndex((p) => p.name === param.name) === i);
+
   const handleTrigger = async () => {
+    // Unset parameters take their defaults
+    const values = Object.fromEntries(
+      Object.entries(parameterValues).filter(([, value]) => value !== "")
+    );
     try {
       await createRun.mutateAsync({
         serviceId,
         gitRef: { branch },
+        parameters: Object.keys(values).length > 0 ? values : undefined,
       });
       setOpen(false);
       onTriggered?.();
@@ -354,10 +370,61 @@ function TriggerRunDialog({
               type="text"
               value={branch}
               onChange={(e) => setBranch(e.target.value)}
-              className="flex h-10 w-full rounded-md border border-input bg-background px-3 py-2 text-sm ring-offset-background focus-visible:outline-none focus-visible:ring-2 focus-visible:ring-ring"
+              className={inputClassName}
               placeholder={defaultBranch}
             />
           </div>
+          {parameters.map((param) => {
+            const value = parameterValues[param.name] ?? "";
+            const setValue = (next: string) =>
+              setParameterValues((values) => ({ ...values, [param.name]: next }));
+            const choices =
+              param.choices && param.choices.length > 0
+                ? param.choices
+                : param.type === "TEST_PARAMETER_TYPE_BOOLEAN"
+                  ? ["true", "false"]
+                  : undefined;
+            return (
+              <div key={param.name} className="space-y-2">
+                <label className="text-sm font-medium">
+                  <code>{param.name}</code>
+                  {param.required && !param.defaultValue && (
+                    <span className="text-destructive"> *</span>
+                  )}
+                </label>
+                {choices ? (
+                  <select
+                    value={value}
+                    onChange={(e) => setValue(e.target.value)}
+                    className={inputClassName}
+                  >
+                    <option value="">
+                      {param.defaultValue ? `Default (${param.defaultValue})` : "Not set"}
+                    </option>
+                    {choices.map((choice) => (
+                      <option key={choice} value={choice}>
+                        {choice}
+                      </option>
+                    ))}
+                  </select>
+                ) : (
+                  <input
+                    type={param.type === "TEST_PARAMETER_TYPE_INTEGER" ? "number" : "text"}
+                    value={value}
+                    onChange={(e) => setValue(e.target.value)}
+                    className={inputClassName}
+                    placeholder={param.defaultValue}
+                  />
+                )}
+                {param.description && (
+                  <p className="text-xs text-muted-foreground">{param.description}</p>
+                )}
+              </div>
+            );
+          })}
+          {createRun.error && (
+            <p className="text-sm text-destructive">{createRun.error.message}</p>
+          )}
         </div>
         <DialogFooter>
           <Button variant="outline" onClick={() => setOpen(false)}>
@@ -522,6 +589,7 @@ export function ServiceDetailsPage() {
             serviceId={service.id}
             serviceName={service.name}
             defaultBranch={service.defaultBranch}
+            tests={tests}
           />
         </div>
       </div>
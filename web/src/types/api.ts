@@ -5,9 +5,35 @@ export interface ApiError {
   message: string;
   code: string;
   status: number;
+  requestId?: string;
+  fieldViolations?: FieldViolation[];
   details?: Record<string, unknown>;
 }
 
+/**
+ * A request field that is missing or malformed
+ */
+export interface FieldViolation {
+  field: string;
+  description: string;
+}
+
+/**
+ * Error response body of the API (RFC 7807 problem details)
+ */
+export interface ProblemDetails {
+  type: string;
+  title: string;
+  status: number;
+  detail?: string;
+  instance?: string;
+  code: string;
+  grpcStatus: string;
+  requestId?: string;
+  metadata?: Record<string, string>;
+  fieldViolations?: FieldViolation[];
+}
+
 /**
  * Paginated response wrapper
  */
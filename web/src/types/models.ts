@@ -2,6 +2,7 @@
  * Test run status
  */
 export type TestRunStatus =
+  | "waiting_approval"
   | "pending"
   | "queued"
   | "running"
@@ -34,6 +35,11 @@ export interface TestRun {
   executionMode: ExecutionMode;
   agentId?: string;
   agentName?: string;
+  agentNetworkZones?: string[];
+  concurrencyGroup?: string;
+  approvalReason?: string;
+  approvedBy?: string;
+  approvedAt?: string;
   startedAt?: string;
   completedAt?: string;
   durationMs?: number;
@@ -45,6 +51,12 @@ export interface TestRun {
   shardsCompleted?: number;
   shardsFailed?: number;
   maxParallelTests?: number;
+  resultsDropped?: number;
+  artifactsDropped?: number;
+  hasMediaArtifacts?: boolean;
+  attempt?: number;
+  retryOfRunId?: string;
+  notBefore?: string;
   errorMessage?: string;
   createdAt: string;
   updatedAt: string;
@@ -57,6 +69,8 @@ export interface RunShard {
   shardCount: number;
   status: TestRunStatus;
   agentId?: string;
+  agentName?: string;
+  agentNetworkZones?: string[];
   totalTests: number;
   passedTests: number;
   failedTests: number;
@@ -64,6 +78,95 @@ export interface RunShard {
   errorMessage?: string;
   startedAt?: string;
   finishedAt?: string;
+  arch?: string;
+  matrixEnv?: Record<string, string>;
+  matrixLabels?: Record<string, string>;
+  stage?: number;
+}
+
+/**
+ * Warning an agent attached to a run about its environment
+ */
+export interface RunAnnotation {
+  id: string;
+  kind: string;
+  severity: "info" | "warning";
+  message: string;
+  metadata?: Record<string, string>;
+  timestamp: string;
+  shardId?: string;
+  agentId?: string;
+}
+
+/**
+ * Run results on one CPU architecture
+ */
+export interface RunArchResult {
+  arch: string;
+  status: TestRunStatus;
+  summary: {
+    total: number;
+    passed: number;
+    failed: number;
+    skipped: number;
+  };
+  shardCount: number;
+  shardsCompleted: number;
+  shardsFailed: number;
+  errorMessage?: string;
+}
+
+/**
+ * Run results of one pipeline stage
+ */
+export interface RunStageResult {
+  stage: number;
+  status: TestRunStatus;
+  summary: {
+    total: number;
+    passed: number;
+    failed: number;
+    skipped: number;
+  };
+  shardCount: number;
+  shardsCompleted: number;
+  shardsFailed: number;
+  errorMessage?: string;
+}
+
+/**
+ * Run results of one combination of a test matrix
+ */
+export interface RunMatrixResult {
+  env?: Record<string, string>;
+  labels?: Record<string, string>;
+  status: TestRunStatus;
+  summary: {
+    total: number;
+    passed: number;
+    failed: number;
+    skipped: number;
+  };
+  shardCount: number;
+  shardsCompleted: number;
+  shardsFailed: number;
+  errorMessage?: string;
+}
+
+/**
+ * Parameter runs of a test can set, passed to the test as an environment
+ * variable
+ */
+export interface TestParameter {
+  name: string;
+  description?: string;
+  type?:
+    | "TEST_PARAMETER_TYPE_STRING"
+    | "TEST_PARAMETER_TYPE_INTEGER"
+    | "TEST_PARAMETER_TYPE_BOOLEAN";
+  required?: boolean;
+  defaultValue?: string;
+  choices?: string[];
 }
 
 /**
@@ -124,19 +227,53 @@ export interface Repository {
   updatedAt: string;
 }
 
+/**
+ * Kind of content an artifact holds
+ */
+export type ArtifactType =
+  | "ARTIFACT_TYPE_LOG"
+  | "ARTIFACT_TYPE_JUNIT"
+  | "ARTIFACT_TYPE_COVERAGE"
+  | "ARTIFACT_TYPE_SCREENSHOT"
+  | "ARTIFACT_TYPE_VIDEO"
+  | "ARTIFACT_TYPE_BINARY";
+
 /**
  * Artifact model
  */
 export interface Artifact {
   id: string;
   runId: string;
+  testId?: string;
   name: string;
+  type: ArtifactType;
   contentType: string;
   sizeBytes: number;
+  checksum?: string;
   downloadUrl: string;
   createdAt: string;
 }
 
+/**
+ * Directory or artifact in a run's artifact tree
+ */
+export interface ArtifactTreeNode {
+  name: string;
+  path: string;
+  count: number;
+  sizeBytes: number;
+  children?: ArtifactTreeNode[];
+  artifact?: Artifact;
+}
+
+/**
+ * Run artifacts grouped by directory and type
+ */
+export interface ArtifactBrowse {
+  root: ArtifactTreeNode;
+  types: { type: ArtifactType; count: number; sizeBytes: number }[];
+}
+
 /**
  * Dashboard statistics
  */